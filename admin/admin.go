@@ -47,6 +47,7 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/config"
 	kithttputil "github.com/rudderlabs/rudder-go-kit/httputil"
 	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/internal/audit"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 )
 
@@ -97,11 +98,6 @@ func (*Admin) ServerConfig(_ struct{}, reply *string) (err error) {
 	return err
 }
 
-type LogLevel struct {
-	Module string
-	Level  string
-}
-
 func (*Admin) SetLogLevel(l LogLevel, reply *string) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -109,13 +105,29 @@ func (*Admin) SetLogLevel(l LogLevel, reply *string) (err error) {
 			err = fmt.Errorf("internal Rudder server error: %v", r)
 		}
 	}()
-	err = logger.SetLogLevel(l.Module, l.Level)
+	err = setLogLevel(l)
+	recordRPC("Admin.SetLogLevel", l, err)
 	if err == nil {
 		*reply = fmt.Sprintf("Module %s log level set to %s", l.Module, l.Level)
 	}
 	return err
 }
 
+// recordRPC records the mutation made through the rpc method to the audit log
+func recordRPC(method string, args any, err error) {
+	parameters, _ := json.Marshal(args)
+	outcome := "ok"
+	if err != nil {
+		outcome = err.Error()
+	}
+	audit.Default.Record(context.Background(), audit.Entry{
+		Actor:      audit.AdminCLIActor,
+		Action:     method,
+		Parameters: parameters,
+		Outcome:    outcome,
+	})
+}
+
 // GetLoggingConfig returns the logging configuration
 func (*Admin) GetLoggingConfig(_ struct{}, reply *string) (err error) {
 	defer func() {
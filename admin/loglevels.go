@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+)
+
+// levelNames are the names of the levels of the loggers, by level
+var levelNames = []string{"EVENT", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+// LogLevel is the log level of a module, the root logger if the module is empty
+type LogLevel struct {
+	Module string `json:"module"`
+	Level  string `json:"level"`
+}
+
+// setLogLevel sets the log level of the module, which also applies to its children modules unless they have their own
+func setLogLevel(l LogLevel) error {
+	level := strings.ToUpper(l.Level)
+	if err := logger.SetLogLevel(l.Module, level); err != nil {
+		return err
+	}
+	pkgLogger.Infon("Log level changed",
+		logger.NewStringField("module", l.Module),
+		logger.NewStringField("level", level),
+	)
+	return nil
+}
+
+// logLevels returns the log levels of the modules by module, which only include the modules that have logged since
+// the levels last changed
+func logLevels() map[string]string {
+	levels := make(map[string]string)
+	for module, level := range logger.GetLoggingConfig() {
+		if level >= 0 && level < len(levelNames) {
+			levels[module] = levelNames[level]
+		}
+	}
+	return levels
+}
+
+// LogLevelsHttpHandler returns the http handler for changing the log levels of the modules at runtime, e.g. for
+// turning on the debug logs of the warehouse without redeploying:
+//
+//	GET  /     returns the log levels of the modules by module
+//	PUT  /     sets the log level of the module in the body, e.g. {"module": "warehouse", "level": "DEBUG"},
+//	           or of the root logger if the module is empty. Modules inherit the log level of their parent
+func LogLevelsHttpHandler() http.Handler {
+	srvMux := chi.NewRouter()
+	srvMux.Get("/", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(logLevels())
+	})
+	srvMux.Put("/", func(w http.ResponseWriter, r *http.Request) {
+		var l LogLevel
+		if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+			http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := setLogLevel(l); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return srvMux
+}
@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+)
+
+func TestLogLevelsHttpHandler(t *testing.T) {
+	// log levels are global, so they are restored for the other tests of the package
+	t.Cleanup(logger.Reset)
+	Init()
+	srv := httptest.NewServer(LogLevelsHttpHandler())
+	defer srv.Close()
+
+	put := func(body string) int {
+		req, err := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader(body))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.Equal(t, http.StatusBadRequest, put(`{"module": "warehouse", "level": "VERBOSE"}`))
+	require.Equal(t, http.StatusBadRequest, put(`invalid`))
+	require.Equal(t, http.StatusNoContent, put(`{"module": "warehouse", "level": "debug"}`))
+
+	log := logger.NewLogger().Child("warehouse").Child("router")
+	require.True(t, log.IsDebugLevel(), "children modules should inherit the log level")
+	require.False(t, logger.NewLogger().Child("router").IsDebugLevel())
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	var levels map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&levels))
+	require.Equal(t, "DEBUG", levels["warehouse.router"])
+	require.Equal(t, "INFO", levels["router"])
+}
@@ -142,8 +142,15 @@ func New(options *Options) App {
 
 // LivenessHandler is the http handler for the Kubernetes liveness probe
 func LivenessHandler(jobsDB jobsdb.JobsDB) http.HandlerFunc {
+	return DegradedLivenessHandler(jobsDB, func() bool { return false })
+}
+
+// DegradedLivenessHandler is the http handler for the Kubernetes liveness probe, which additionally reports
+// whether the server is running in a degraded mode, i.e. it is temporarily not accepting events.
+// A degraded server is still considered alive.
+func DegradedLivenessHandler(jobsDB jobsdb.JobsDB, degraded func() bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, _ *http.Request) {
-		healthy, responsePayload := getHealthVal(jobsDB)
+		healthy, responsePayload := getHealthVal(jobsDB, degraded())
 		if !healthy {
 			http.Error(w, "Cannot connect to db", http.StatusServiceUnavailable)
 			return
@@ -152,13 +159,17 @@ func LivenessHandler(jobsDB jobsdb.JobsDB) http.HandlerFunc {
 	}
 }
 
-func getHealthVal(jobsDB jobsdb.JobsDB) (bool, string) {
+func getHealthVal(jobsDB jobsdb.JobsDB, degraded bool) (bool, string) {
 	dbService := "UP"
 	healthy := true
 	if jobsDB.Ping() != nil {
 		dbService = "DOWN"
 		healthy = false
 	}
+	acceptingEvents, degradedStr := "TRUE", "FALSE"
+	if degraded {
+		acceptingEvents, degradedStr = "FALSE", "TRUE"
+	}
 	enabledRouter := "TRUE"
 	if !config.GetBool("enableRouter", true) {
 		enabledRouter = "FALSE"
@@ -170,9 +181,9 @@ func getHealthVal(jobsDB jobsdb.JobsDB) (bool, string) {
 
 	appTypeStr := strings.ToUpper(config.GetString("APP_TYPE", EMBEDDED))
 	return healthy, fmt.Sprintf(
-		`{"appType":"%s","server":"UP","db":"%s","acceptingEvents":"TRUE","routingEvents":"%s","mode":"NORMAL",`+
+		`{"appType":"%s","server":"UP","db":"%s","acceptingEvents":"%s","degraded":"%s","routingEvents":"%s","mode":"NORMAL",`+
 			`"backendConfigMode":"%s","lastSync":"%s","lastRegulationSync":"%s"}`,
-		appTypeStr, dbService, enabledRouter,
+		appTypeStr, dbService, acceptingEvents, degradedStr, enabledRouter,
 		backendConfigMode, backendconfig.LastSync, backendconfig.LastRegulationSync,
 	)
 }
@@ -14,18 +14,26 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 
+	"github.com/rudderlabs/rudder-server/admin"
 	"github.com/rudderlabs/rudder-server/app"
 	"github.com/rudderlabs/rudder-server/app/cluster"
 	"github.com/rudderlabs/rudder-server/archiver"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/gateway"
 	gwThrottler "github.com/rudderlabs/rudder-server/gateway/throttler"
+	"github.com/rudderlabs/rudder-server/internal/audit"
 	drain_config "github.com/rudderlabs/rudder-server/internal/drain-config"
+	"github.com/rudderlabs/rudder-server/internal/featureflags"
+	job_trace "github.com/rudderlabs/rudder-server/internal/job-trace"
+	"github.com/rudderlabs/rudder-server/internal/liveevents"
 	"github.com/rudderlabs/rudder-server/internal/pulsar"
+	"github.com/rudderlabs/rudder-server/internal/shutdown"
 	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/middleware"
 	"github.com/rudderlabs/rudder-server/processor"
 	"github.com/rudderlabs/rudder-server/router"
 	"github.com/rudderlabs/rudder-server/router/batchrouter"
+	"github.com/rudderlabs/rudder-server/router/capture"
 	routerManager "github.com/rudderlabs/rudder-server/router/manager"
 	rtThrottler "github.com/rudderlabs/rudder-server/router/throttler"
 	schema_forwarder "github.com/rudderlabs/rudder-server/schema-forwarder"
@@ -33,6 +41,7 @@ import (
 	sourcedebugger "github.com/rudderlabs/rudder-server/services/debugger/source"
 	transformationdebugger "github.com/rudderlabs/rudder-server/services/debugger/transformation"
 	"github.com/rudderlabs/rudder-server/services/fileuploader"
+	"github.com/rudderlabs/rudder-server/services/streammanager/plugin"
 	"github.com/rudderlabs/rudder-server/services/transformer"
 	"github.com/rudderlabs/rudder-server/services/transientsource"
 	"github.com/rudderlabs/rudder-server/utils/crash"
@@ -40,6 +49,7 @@ import (
 	"github.com/rudderlabs/rudder-server/utils/payload"
 	"github.com/rudderlabs/rudder-server/utils/types"
 	"github.com/rudderlabs/rudder-server/utils/types/deployment"
+	warehouseclient "github.com/rudderlabs/rudder-server/warehouse/client"
 )
 
 // embeddedApp is the type for embedded type implementation
@@ -103,6 +113,17 @@ func (a *embeddedApp) StartRudderCore(ctx context.Context, options *app.Options)
 		syncer()
 		return nil
 	})
+	deliveryMetrics, err := setupDeliveryMetrics(config, a.log)
+	if err != nil {
+		return fmt.Errorf("setting up delivery metrics: %w", err)
+	}
+	if deliveryMetrics != nil {
+		defer deliveryMetrics.Stop()
+		g.Go(crash.Wrapper(func() (err error) {
+			return deliveryMetrics.CleanupRoutine(ctx)
+		}))
+		reporting = deliveryMetrics.Reporting(reporting)
+	}
 
 	a.log.Info("Clearing DB ", options.ClearDB)
 
@@ -242,6 +263,41 @@ func (a *embeddedApp) StartRudderCore(ctx context.Context, options *app.Options)
 		}
 	}()
 
+	transformationDLQ, err := setupTransformationDLQ(config, a.log)
+	if err != nil {
+		return fmt.Errorf("setting up transformation dlq: %w", err)
+	}
+	procOpts := []processor.Opts{processor.WithAdaptiveLimit(adaptiveLimit)}
+	if transformationDLQ != nil {
+		defer transformationDLQ.Stop()
+		g.Go(crash.Wrapper(func() (err error) {
+			return transformationDLQ.CleanupRoutine(ctx)
+		}))
+		procOpts = append(procOpts, processor.WithTransformationDLQ(transformationDLQ))
+	}
+	checkpoints, err := setupProcessorCheckpoints(config, a.log)
+	if err != nil {
+		return fmt.Errorf("setting up processor checkpoints: %w", err)
+	}
+	if checkpoints != nil {
+		defer func() { _ = checkpoints.Close() }()
+		procOpts = append(procOpts, processor.WithCheckpoints(checkpoints))
+	}
+	meter, err := setupMetering(config, a.log)
+	if err != nil {
+		return fmt.Errorf("setting up metering: %w", err)
+	}
+	if meter != nil {
+		defer meter.Stop()
+		g.Go(crash.Wrapper(func() (err error) {
+			return meter.CompactionRoutine(ctx)
+		}))
+		g.Go(crash.Wrapper(func() (err error) {
+			return meter.CleanupRoutine(ctx)
+		}))
+		procOpts = append(procOpts, processor.WithUsageMeter(meter))
+	}
+
 	proc := processor.New(
 		ctx,
 		&options.ClearDB,
@@ -261,12 +317,13 @@ func (a *embeddedApp) StartRudderCore(ctx context.Context, options *app.Options)
 		transformationhandle,
 		enrichers,
 		trackedUsersReporter,
-		processor.WithAdaptiveLimit(adaptiveLimit),
+		procOpts...,
 	)
 	throttlerFactory, err := rtThrottler.NewFactory(config, stats.Default)
 	if err != nil {
 		return fmt.Errorf("failed to create rt throttler factory: %w", err)
 	}
+	responseCapture := capture.New(config)
 	rtFactory := &router.Factory{
 		Logger:                     logger.NewLogger().Child("router"),
 		Reporting:                  reporting,
@@ -279,6 +336,18 @@ func (a *embeddedApp) StartRudderCore(ctx context.Context, options *app.Options)
 		ThrottlerFactory:           throttlerFactory,
 		Debugger:                   destinationHandle,
 		AdaptiveLimit:              adaptiveLimit,
+		ResponseCapture:            responseCapture,
+	}
+	destinationDLQ, err := setupDestinationDLQ(config, a.log)
+	if err != nil {
+		return fmt.Errorf("setting up destination dlq: %w", err)
+	}
+	if destinationDLQ != nil {
+		defer destinationDLQ.Stop()
+		g.Go(crash.Wrapper(func() (err error) {
+			return destinationDLQ.CleanupRoutine(ctx)
+		}))
+		rtFactory.DestinationDLQ = destinationDLQ
 	}
 	brtFactory := &batchrouter.Factory{
 		Reporting:        reporting,
@@ -290,7 +359,22 @@ func (a *embeddedApp) StartRudderCore(ctx context.Context, options *app.Options)
 		Debugger:         destinationHandle,
 		AdaptiveLimit:    adaptiveLimit,
 	}
+	routerSharding, routerShardingDB, err := setupRouterSharding(config, a.log)
+	if err != nil {
+		return fmt.Errorf("setting up router sharding: %w", err)
+	}
+	if routerSharding != nil {
+		defer func() { _ = routerShardingDB.Close() }()
+		rtFactory.Sharding = routerSharding
+		brtFactory.Sharding = routerSharding
+	}
 	rt := routerManager.New(rtFactory, brtFactory, backendconfig.DefaultBackendConfig, logger.NewLogger())
+	if routerSharding != nil {
+		// started after the router manager registers how to recover the jobs of the shards it acquires
+		g.Go(crash.Wrapper(func() error {
+			return routerSharding.Run(ctx)
+		}))
+	}
 
 	dm := cluster.Dynamic{
 		Provider:        modeProvider,
@@ -327,15 +411,35 @@ func (a *embeddedApp) StartRudderCore(ctx context.Context, options *app.Options)
 	g.Go(crash.Wrapper(func() (err error) {
 		return drainConfigManager.CleanupRoutine(ctx)
 	}))
+	internalHttpHandlers := map[string]http.Handler{
+		"/audit-log":     audit.Default.HttpHandler(),
+		"/drain":         drainConfigManager.DrainConfigHttpHandler(),
+		"/feature-flags": featureflags.Default.HttpHandler(),
+		"/live-events":   liveevents.Default.HttpHandler(),
+		"/log-levels":    admin.LogLevelsHttpHandler(),
+		"/plugins":       plugin.Default.HttpHandler(),
+	}
+	if transformationDLQ != nil {
+		internalHttpHandlers["/transformation-dlq"] = transformationDLQ.HttpHandler(gatewayDB)
+	}
+	if destinationDLQ != nil {
+		internalHttpHandlers["/destination-dlq"] = destinationDLQ.HttpHandler(routerDB)
+	}
+	if deliveryMetrics != nil {
+		internalHttpHandlers["/delivery-metrics"] = deliveryMetrics.HttpHandler()
+	}
+	if meter != nil {
+		internalHttpHandlers["/metering"] = meter.HttpHandler()
+	}
+	internalHttpHandlers["/destination-responses"] = middleware.AdminAuth(config)(responseCapture.HttpHandler())
+	internalHttpHandlers["/job-trace"] = job_trace.New(gwDBForProcessor, errDBForRead, routerDB, batchRouterDB,
+		job_trace.WithStagingFileDeliveries(warehouseclient.NewWarehouse(misc.GetWarehouseURL())),
+	).HttpHandler()
 	streamMsgValidator := stream.NewMessageValidator()
 	gw := gateway.Handle{}
 	err = gw.Setup(ctx, config, logger.NewLogger().Child("gateway"), stats.Default, a.app, backendconfig.DefaultBackendConfig,
 		gatewayDB, errDBForWrite, rateLimiter, a.versionHandler, rsourcesService, transformerFeaturesService, sourceHandle,
-		streamMsgValidator, gateway.WithInternalHttpHandlers(
-			map[string]http.Handler{
-				"/drain": drainConfigManager.DrainConfigHttpHandler(),
-			},
-		))
+		streamMsgValidator, gateway.WithInternalHttpHandlers(internalHttpHandlers))
 	if err != nil {
 		return fmt.Errorf("could not setup gateway: %w", err)
 	}
@@ -366,11 +470,15 @@ func (a *embeddedApp) StartRudderCore(ctx context.Context, options *app.Options)
 		defer func() { _ = replay.Stop() }()
 	}
 
+	// When shutting down, the processor and routers keep going for the drain timeout once the gateway stopped
+	// accepting events, for delivering the events accepted so far
+	drainCtx, cancelDrain := shutdown.Drain(ctx)
+	defer cancelDrain()
 	g.Go(func() error {
 		// This should happen only after setupDatabaseTables() is called and journal table migrations are done
 		// because if this start before that then there might be a case when ReadDB will try to read the owner table
 		// which gets created after either Write or ReadWrite DB is created.
-		return dm.Run(ctx)
+		return dm.Run(drainCtx)
 	})
 
 	g.Go(func() error {
@@ -14,12 +14,16 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 
+	"github.com/rudderlabs/rudder-server/admin"
 	"github.com/rudderlabs/rudder-server/app"
 	"github.com/rudderlabs/rudder-server/app/cluster"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/gateway"
 	gwThrottler "github.com/rudderlabs/rudder-server/gateway/throttler"
+	"github.com/rudderlabs/rudder-server/internal/audit"
 	drain_config "github.com/rudderlabs/rudder-server/internal/drain-config"
+	"github.com/rudderlabs/rudder-server/internal/featureflags"
+	"github.com/rudderlabs/rudder-server/internal/liveevents"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	sourcedebugger "github.com/rudderlabs/rudder-server/services/debugger/source"
 	"github.com/rudderlabs/rudder-server/services/transformer"
@@ -34,11 +38,20 @@ type gatewayApp struct {
 	log            logger.Logger
 	config         struct {
 		gatewayDSLimit config.ValueLoader[int]
+		jobsDBBackend  string
 	}
 }
 
 func (a *gatewayApp) Setup() error {
 	a.config.gatewayDSLimit = config.GetReloadableIntVar(0, 1, "Gateway.jobsDB.dsLimit", "JobsDB.dsLimit")
+	a.config.jobsDBBackend = config.GetString("Gateway.jobsDB.backend", jobsdb.PostgresBackend)
+	switch a.config.jobsDBBackend {
+	case jobsdb.PostgresBackend, jobsdb.BadgerBackend:
+		// the jobs buffered by the badger backend are forwarded to postgres, for the processor to read them,
+		// thus postgres is required by either backend
+	default:
+		return fmt.Errorf("unsupported jobsdb backend: %q", a.config.jobsDBBackend)
+	}
 	if err := rudderCoreDBValidator(); err != nil {
 		return err
 	}
@@ -67,12 +80,10 @@ func (a *gatewayApp) StartRudderCore(ctx context.Context, options *app.Options)
 	}
 	defer sourceHandle.Stop()
 
-	gatewayDB := jobsdb.NewForWrite(
-		"gw",
-		jobsdb.WithClearDB(options.ClearDB),
-		jobsdb.WithDSLimit(a.config.gatewayDSLimit),
-		jobsdb.WithSkipMaintenanceErr(config.GetBool("Gateway.jobsDB.skipMaintenanceError", true)),
-	)
+	gatewayDB, errDB, err := a.setupJobsDBs(options)
+	if err != nil {
+		return err
+	}
 	defer gatewayDB.Close()
 
 	if err := gatewayDB.Start(); err != nil {
@@ -80,11 +91,6 @@ func (a *gatewayApp) StartRudderCore(ctx context.Context, options *app.Options)
 	}
 	defer gatewayDB.Stop()
 
-	errDB := jobsdb.NewForWrite(
-		"proc_error",
-		jobsdb.WithClearDB(options.ClearDB),
-		jobsdb.WithSkipMaintenanceErr(config.GetBool("Gateway.jobsDB.skipMaintenanceError", true)),
-	)
 	defer errDB.Close()
 
 	if err := errDB.Start(); err != nil {
@@ -107,6 +113,10 @@ func (a *gatewayApp) StartRudderCore(ctx context.Context, options *app.Options)
 		return dm.Run(ctx)
 	})
 
+	if a.config.jobsDBBackend == jobsdb.BadgerBackend {
+		a.forwardBadgerJobsDBs(ctx, g, options, gatewayDB, errDB)
+	}
+
 	var gw gateway.Handle
 	rateLimiter, err := gwThrottler.New(stats.Default)
 	if err != nil {
@@ -121,24 +131,34 @@ func (a *gatewayApp) StartRudderCore(ctx context.Context, options *app.Options)
 		TransformerURL:           config.GetString("DEST_TRANSFORM_URL", "http://localhost:9090"),
 		FeaturesRetryMaxAttempts: 10,
 	})
+	drainConfigHttpHandler := drain_config.ErrorResponder("unable to start drain config http handler")
 	drainConfigManager, err := drain_config.NewDrainConfigManager(config, a.log.Child("drain-config"))
 	if err != nil {
 		a.log.Errorw("drain config manager setup failed while starting gateway", "error", err)
 	}
-
-	drainConfigHttpHandler := drain_config.ErrorResponder("unable to start drain config http handler")
 	if drainConfigManager != nil {
 		defer drainConfigManager.Stop()
 		drainConfigHttpHandler = drainConfigManager.DrainConfigHttpHandler()
 	}
+	internalHttpHandlers := map[string]http.Handler{
+		"/audit-log":     audit.Default.HttpHandler(),
+		"/drain":         drainConfigHttpHandler,
+		"/feature-flags": featureflags.Default.HttpHandler(),
+		"/live-events":   liveevents.Default.HttpHandler(),
+		"/log-levels":    admin.LogLevelsHttpHandler(),
+	}
+	transformationDLQ, err := setupTransformationDLQ(config, a.log)
+	if err != nil {
+		return fmt.Errorf("setting up transformation dlq: %w", err)
+	}
+	if transformationDLQ != nil {
+		defer transformationDLQ.Stop()
+		internalHttpHandlers["/transformation-dlq"] = transformationDLQ.HttpHandler(gatewayDB)
+	}
 	streamMsgValidator := stream.NewMessageValidator()
 	err = gw.Setup(ctx, config, logger.NewLogger().Child("gateway"), stats.Default, a.app, backendconfig.DefaultBackendConfig,
 		gatewayDB, errDB, rateLimiter, a.versionHandler, rsourcesService, transformerFeaturesService, sourceHandle,
-		streamMsgValidator, gateway.WithInternalHttpHandlers(
-			map[string]http.Handler{
-				"/drain": drainConfigHttpHandler,
-			},
-		))
+		streamMsgValidator, gateway.WithInternalHttpHandlers(internalHttpHandlers))
 	if err != nil {
 		return fmt.Errorf("failed to setup gateway: %w", err)
 	}
@@ -153,3 +173,58 @@ func (a *gatewayApp) StartRudderCore(ctx context.Context, options *app.Options)
 	})
 	return g.Wait()
 }
+
+// setupJobsDBs returns the gateway and error jobsdbs of the configured backend. The badger backend buffers the jobs in
+// JobsDB.badger.path, so that the gateway keeps accepting events while postgres is slow or unavailable, and forwards
+// them to postgres, see forwardBadgerJobsDBs. It doesn't remove the need for postgres, which the processor reads from.
+func (a *gatewayApp) setupJobsDBs(options *app.Options) (gatewayDB, errDB jobsdb.LifecycleJobsDB, err error) {
+	if a.config.jobsDBBackend == jobsdb.BadgerBackend {
+		a.log.Infon("Using badger jobsdb backend, buffering jobs before forwarding them to postgres")
+		if gatewayDB, err = jobsdb.NewBadger("gw"); err != nil {
+			return nil, nil, fmt.Errorf("could not open gatewayDB: %w", err)
+		}
+		if errDB, err = jobsdb.NewBadger("proc_error"); err != nil {
+			gatewayDB.Close()
+			return nil, nil, fmt.Errorf("could not open errDB: %w", err)
+		}
+		return gatewayDB, errDB, nil
+	}
+	gatewayDB = jobsdb.NewForWrite(
+		"gw",
+		jobsdb.WithClearDB(options.ClearDB),
+		jobsdb.WithDSLimit(a.config.gatewayDSLimit),
+		jobsdb.WithSkipMaintenanceErr(config.GetBool("Gateway.jobsDB.skipMaintenanceError", true)),
+	)
+	errDB = jobsdb.NewForWrite(
+		"proc_error",
+		jobsdb.WithClearDB(options.ClearDB),
+		jobsdb.WithSkipMaintenanceErr(config.GetBool("Gateway.jobsDB.skipMaintenanceError", true)),
+	)
+	return gatewayDB, errDB, nil
+}
+
+// forwardBadgerJobsDBs forwards the jobs buffered in the badger gateway and error jobsdbs to their postgres
+// counterparts, which the processor reads from, until the context is done.
+func (a *gatewayApp) forwardBadgerJobsDBs(ctx context.Context, g *errgroup.Group, options *app.Options, gatewayDB, errDB jobsdb.JobsDB) {
+	pgGatewayDB := jobsdb.NewForWrite(
+		"gw",
+		jobsdb.WithClearDB(options.ClearDB),
+		jobsdb.WithDSLimit(a.config.gatewayDSLimit),
+		jobsdb.WithSkipMaintenanceErr(config.GetBool("Gateway.jobsDB.skipMaintenanceError", true)),
+	)
+	pgErrDB := jobsdb.NewForWrite(
+		"proc_error",
+		jobsdb.WithClearDB(options.ClearDB),
+		jobsdb.WithSkipMaintenanceErr(config.GetBool("Gateway.jobsDB.skipMaintenanceError", true)),
+	)
+	for from, to := range map[jobsdb.JobsDB]*jobsdb.Handle{gatewayDB: pgGatewayDB, errDB: pgErrDB} {
+		g.Go(func() error {
+			defer to.Close()
+			if err := to.Start(); err != nil {
+				return fmt.Errorf("could not start postgres %s jobsdb: %w", to.Identifier(), err)
+			}
+			defer to.Stop()
+			return jobsdb.NewBadgerForwarder(from, to, config.Default, a.log).Run(ctx)
+		})
+	}
+}
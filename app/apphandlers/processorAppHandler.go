@@ -15,22 +15,31 @@ import (
 	kithttputil "github.com/rudderlabs/rudder-go-kit/httputil"
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-server/admin"
 	"github.com/rudderlabs/rudder-server/app"
 	"github.com/rudderlabs/rudder-server/app/cluster"
 	"github.com/rudderlabs/rudder-server/archiver"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/internal/audit"
 	drain_config "github.com/rudderlabs/rudder-server/internal/drain-config"
+	"github.com/rudderlabs/rudder-server/internal/featureflags"
+	job_trace "github.com/rudderlabs/rudder-server/internal/job-trace"
+	"github.com/rudderlabs/rudder-server/internal/liveevents"
 	"github.com/rudderlabs/rudder-server/internal/pulsar"
+	"github.com/rudderlabs/rudder-server/internal/shutdown"
 	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/middleware"
 	proc "github.com/rudderlabs/rudder-server/processor"
 	"github.com/rudderlabs/rudder-server/router"
 	"github.com/rudderlabs/rudder-server/router/batchrouter"
+	"github.com/rudderlabs/rudder-server/router/capture"
 	routerManager "github.com/rudderlabs/rudder-server/router/manager"
 	"github.com/rudderlabs/rudder-server/router/throttler"
 	schema_forwarder "github.com/rudderlabs/rudder-server/schema-forwarder"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
 	transformationdebugger "github.com/rudderlabs/rudder-server/services/debugger/transformation"
 	"github.com/rudderlabs/rudder-server/services/fileuploader"
+	"github.com/rudderlabs/rudder-server/services/streammanager/plugin"
 	"github.com/rudderlabs/rudder-server/services/transformer"
 	"github.com/rudderlabs/rudder-server/services/transientsource"
 	"github.com/rudderlabs/rudder-server/utils/crash"
@@ -38,6 +47,7 @@ import (
 	"github.com/rudderlabs/rudder-server/utils/payload"
 	"github.com/rudderlabs/rudder-server/utils/types"
 	"github.com/rudderlabs/rudder-server/utils/types/deployment"
+	warehouseclient "github.com/rudderlabs/rudder-server/warehouse/client"
 )
 
 // processorApp is the type for Processor type implementation
@@ -115,6 +125,17 @@ func (a *processorApp) StartRudderCore(ctx context.Context, options *app.Options
 		syncer()
 		return nil
 	}))
+	deliveryMetrics, err := setupDeliveryMetrics(config, a.log)
+	if err != nil {
+		return fmt.Errorf("setting up delivery metrics: %w", err)
+	}
+	if deliveryMetrics != nil {
+		defer deliveryMetrics.Stop()
+		g.Go(crash.Wrapper(func() (err error) {
+			return deliveryMetrics.CleanupRoutine(ctx)
+		}))
+		reporting = deliveryMetrics.Reporting(reporting)
+	}
 
 	a.log.Info("Clearing DB ", options.ClearDB)
 
@@ -243,6 +264,41 @@ func (a *processorApp) StartRudderCore(ctx context.Context, options *app.Options
 		return drainConfigManager.CleanupRoutine(ctx)
 	}))
 
+	transformationDLQ, err := setupTransformationDLQ(config, a.log)
+	if err != nil {
+		return fmt.Errorf("setting up transformation dlq: %w", err)
+	}
+	procOpts := []proc.Opts{proc.WithAdaptiveLimit(adaptiveLimit)}
+	if transformationDLQ != nil {
+		defer transformationDLQ.Stop()
+		g.Go(crash.Wrapper(func() (err error) {
+			return transformationDLQ.CleanupRoutine(ctx)
+		}))
+		procOpts = append(procOpts, proc.WithTransformationDLQ(transformationDLQ))
+	}
+	checkpoints, err := setupProcessorCheckpoints(config, a.log)
+	if err != nil {
+		return fmt.Errorf("setting up processor checkpoints: %w", err)
+	}
+	if checkpoints != nil {
+		defer func() { _ = checkpoints.Close() }()
+		procOpts = append(procOpts, proc.WithCheckpoints(checkpoints))
+	}
+	meter, err := setupMetering(config, a.log)
+	if err != nil {
+		return fmt.Errorf("setting up metering: %w", err)
+	}
+	if meter != nil {
+		defer meter.Stop()
+		g.Go(crash.Wrapper(func() (err error) {
+			return meter.CompactionRoutine(ctx)
+		}))
+		g.Go(crash.Wrapper(func() (err error) {
+			return meter.CleanupRoutine(ctx)
+		}))
+		procOpts = append(procOpts, proc.WithUsageMeter(meter))
+	}
+
 	p := proc.New(
 		ctx,
 		&options.ClearDB,
@@ -262,12 +318,13 @@ func (a *processorApp) StartRudderCore(ctx context.Context, options *app.Options
 		transformationhandle,
 		enrichers,
 		trackedUsersReporter,
-		proc.WithAdaptiveLimit(adaptiveLimit),
+		procOpts...,
 	)
 	throttlerFactory, err := throttler.NewFactory(config, stats.Default)
 	if err != nil {
 		return fmt.Errorf("failed to create throttler factory: %w", err)
 	}
+	responseCapture := capture.New(config)
 	rtFactory := &router.Factory{
 		Logger:                     logger.NewLogger().Child("router"),
 		Reporting:                  reporting,
@@ -280,6 +337,37 @@ func (a *processorApp) StartRudderCore(ctx context.Context, options *app.Options
 		ThrottlerFactory:           throttlerFactory,
 		Debugger:                   destinationHandle,
 		AdaptiveLimit:              adaptiveLimit,
+		ResponseCapture:            responseCapture,
+	}
+	jobTracer := job_trace.New(gwDBForProcessor, errDBForRead, routerDB, batchRouterDB,
+		job_trace.WithStagingFileDeliveries(warehouseclient.NewWarehouse(misc.GetWarehouseURL())),
+	)
+	internalHttpHandlers := map[string]http.Handler{
+		"/audit-log":             audit.Default.HttpHandler(),
+		"/destination-responses": middleware.AdminAuth(config)(responseCapture.HttpHandler()),
+		"/feature-flags":         featureflags.Default.HttpHandler(),
+		"/job-trace":             jobTracer.HttpHandler(),
+		"/live-events":           liveevents.Default.HttpHandler(),
+		"/log-levels":            admin.LogLevelsHttpHandler(),
+		"/plugins":               plugin.Default.HttpHandler(),
+	}
+	destinationDLQ, err := setupDestinationDLQ(config, a.log)
+	if err != nil {
+		return fmt.Errorf("setting up destination dlq: %w", err)
+	}
+	if destinationDLQ != nil {
+		defer destinationDLQ.Stop()
+		g.Go(crash.Wrapper(func() (err error) {
+			return destinationDLQ.CleanupRoutine(ctx)
+		}))
+		rtFactory.DestinationDLQ = destinationDLQ
+		internalHttpHandlers["/destination-dlq"] = destinationDLQ.HttpHandler(routerDB)
+	}
+	if deliveryMetrics != nil {
+		internalHttpHandlers["/delivery-metrics"] = deliveryMetrics.HttpHandler()
+	}
+	if meter != nil {
+		internalHttpHandlers["/metering"] = meter.HttpHandler()
 	}
 	brtFactory := &batchrouter.Factory{
 		Reporting:        reporting,
@@ -291,7 +379,22 @@ func (a *processorApp) StartRudderCore(ctx context.Context, options *app.Options
 		Debugger:         destinationHandle,
 		AdaptiveLimit:    adaptiveLimit,
 	}
+	routerSharding, routerShardingDB, err := setupRouterSharding(config, a.log)
+	if err != nil {
+		return fmt.Errorf("setting up router sharding: %w", err)
+	}
+	if routerSharding != nil {
+		defer func() { _ = routerShardingDB.Close() }()
+		rtFactory.Sharding = routerSharding
+		brtFactory.Sharding = routerSharding
+	}
 	rt := routerManager.New(rtFactory, brtFactory, backendconfig.DefaultBackendConfig, logger.NewLogger())
+	if routerSharding != nil {
+		// started after the router manager registers how to recover the jobs of the shards it acquires
+		g.Go(crash.Wrapper(func() error {
+			return routerSharding.Run(ctx)
+		}))
+	}
 
 	dm := cluster.Dynamic{
 		Provider:         modeProvider,
@@ -315,14 +418,18 @@ func (a *processorApp) StartRudderCore(ctx context.Context, options *app.Options
 	}
 
 	g.Go(func() error {
-		return a.startHealthWebHandler(ctx, gwDBForProcessor)
+		return a.startHealthWebHandler(ctx, gwDBForProcessor, internalHttpHandlers)
 	})
 
+	// When shutting down, the processor and routers keep going for the drain timeout, for delivering the events
+	// accepted so far
+	drainCtx, cancelDrain := shutdown.Drain(ctx)
+	defer cancelDrain()
 	g.Go(func() error {
 		// This should happen only after setupDatabaseTables() is called and journal table migrations are done
 		// because if this start before that then there might be a case when ReadDB will try to read the owner table
 		// which gets created after either Write or ReadWrite DB is created.
-		return dm.Run(ctx)
+		return dm.Run(drainCtx)
 	})
 
 	g.Go(func() error {
@@ -339,12 +446,15 @@ func (a *processorApp) StartRudderCore(ctx context.Context, options *app.Options
 	return g.Wait()
 }
 
-func (a *processorApp) startHealthWebHandler(ctx context.Context, db *jobsdb.Handle) error {
+func (a *processorApp) startHealthWebHandler(ctx context.Context, db *jobsdb.Handle, internalHttpHandlers map[string]http.Handler) error {
 	// Port where Processor health handler is running
 	a.log.Infof("Starting in %d", a.config.http.webPort)
 	srvMux := chi.NewMux()
 	srvMux.HandleFunc("/health", app.LivenessHandler(db))
 	srvMux.HandleFunc("/", app.LivenessHandler(db))
+	for path, handler := range internalHttpHandlers {
+		srvMux.Mount("/internal"+path, audit.Default.Middleware(handler))
+	}
 	srv := &http.Server{
 		Addr:              ":" + strconv.Itoa(a.config.http.webPort),
 		Handler:           crash.Handler(srvMux),
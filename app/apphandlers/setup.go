@@ -2,6 +2,7 @@ package apphandlers
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"sync"
@@ -14,7 +15,13 @@ import (
 	"github.com/rudderlabs/rudder-server/app"
 	"github.com/rudderlabs/rudder-server/app/cluster"
 	"github.com/rudderlabs/rudder-server/app/cluster/state"
+	"github.com/rudderlabs/rudder-server/internal/checkpoint"
+	delivery_metrics "github.com/rudderlabs/rudder-server/internal/delivery-metrics"
+	destination_dlq "github.com/rudderlabs/rudder-server/internal/destination-dlq"
 	"github.com/rudderlabs/rudder-server/internal/enricher"
+	"github.com/rudderlabs/rudder-server/internal/metering"
+	"github.com/rudderlabs/rudder-server/internal/sharding"
+	transformation_dlq "github.com/rudderlabs/rudder-server/internal/transformation-dlq"
 	"github.com/rudderlabs/rudder-server/services/rsources"
 	"github.com/rudderlabs/rudder-server/services/validators"
 	"github.com/rudderlabs/rudder-server/utils/misc"
@@ -150,5 +157,110 @@ func setupPipelineEnrichers(conf *config.Config, log logger.Logger, stats stats.
 		enrichers = append(enrichers, geoEnricher)
 	}
 
+	if conf.GetBool("LookupEnrichment.enabled", false) {
+		log.Infof("Setting up the lookup pipeline enricher")
+
+		lookupEnricher, err := enricher.NewLookupEnricher(conf, log, stats)
+		if err != nil {
+			return nil, fmt.Errorf("starting lookup enrichment process for pipeline: %w", err)
+		}
+		enrichers = append(enrichers, lookupEnricher)
+	}
+
+	if conf.GetBool("Sessionization.enabled", false) {
+		log.Infof("Setting up the sessionization pipeline enricher")
+
+		sessionEnricher, err := enricher.NewSessionEnricher(conf, log, stats)
+		if err != nil {
+			return nil, fmt.Errorf("starting sessionization process for pipeline: %w", err)
+		}
+		enrichers = append(enrichers, sessionEnricher)
+	}
+
 	return enrichers, nil
 }
+
+// setupTransformationDLQ returns the dead-letter queue for events failing in user transformations,
+// or nil if TransformationDLQ.enabled is not set
+func setupTransformationDLQ(conf *config.Config, log logger.Logger) (*transformation_dlq.DLQ, error) {
+	if !conf.GetBool("TransformationDLQ.enabled", false) {
+		return nil, nil
+	}
+	log.Infof("Setting up the transformation dead-letter queue")
+	dlq, err := transformation_dlq.New(conf, log.Child("transformation-dlq"))
+	if err != nil {
+		return nil, fmt.Errorf("starting transformation dead-letter queue: %w", err)
+	}
+	return dlq, nil
+}
+
+// setupDestinationDLQ returns the dead-letter queue for jobs aborted by the router,
+// or nil if DestinationDLQ.enabled is not set
+func setupDestinationDLQ(conf *config.Config, log logger.Logger) (*destination_dlq.DLQ, error) {
+	if !conf.GetBool("DestinationDLQ.enabled", false) {
+		return nil, nil
+	}
+	log.Infof("Setting up the destination dead-letter queue")
+	dlq, err := destination_dlq.New(conf, log.Child("destination-dlq"))
+	if err != nil {
+		return nil, fmt.Errorf("starting destination dead-letter queue: %w", err)
+	}
+	return dlq, nil
+}
+
+// setupDeliveryMetrics returns the reporter aggregating the delivery metrics of the router and batch router,
+// or nil if DeliveryMetrics.enabled is not set. Its database needs to be the one of the jobsdbs.
+func setupDeliveryMetrics(conf *config.Config, log logger.Logger) (*delivery_metrics.Reporter, error) {
+	if !conf.GetBool("DeliveryMetrics.enabled", false) {
+		return nil, nil
+	}
+	log.Infof("Setting up the delivery metrics")
+	reporter, err := delivery_metrics.New(conf, log.Child("delivery-metrics"))
+	if err != nil {
+		return nil, fmt.Errorf("starting delivery metrics: %w", err)
+	}
+	return reporter, nil
+}
+
+// setupMetering returns the meter of the events ingested by the sources and their monthly tracked users,
+// or nil if Metering.enabled is not set. Its database needs to be the one of the gateway jobsdb.
+func setupMetering(conf *config.Config, log logger.Logger) (*metering.Meter, error) {
+	if !conf.GetBool("Metering.enabled", false) {
+		return nil, nil
+	}
+	log.Infof("Setting up the metering")
+	meter, err := metering.New(conf, log.Child("metering"))
+	if err != nil {
+		return nil, fmt.Errorf("starting metering: %w", err)
+	}
+	return meter, nil
+}
+
+// setupRouterSharding returns the manager of the destinations served by the routers and batch routers of this server,
+// along with the database holding its locks, or nil if Router.sharding.enabled is not set. Its database needs to be
+// the one of the jobsdbs, shared by all the servers.
+func setupRouterSharding(conf *config.Config, log logger.Logger) (*sharding.Manager, *sql.DB, error) {
+	if !conf.GetBool("Router.sharding.enabled", false) {
+		return nil, nil, nil
+	}
+	log.Infof("Setting up the router sharding")
+	db, err := sql.Open("postgres", misc.GetConnectionString(conf, "router_sharding"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("db open: %w", err)
+	}
+	return sharding.New("Router", conf, log, stats.Default, db), db, nil
+}
+
+// setupProcessorCheckpoints returns the store of the completed steps of the processor storing the outputs of gateway jobs,
+// or nil if Processor.checkpoints.enabled is not set. Its database needs to be the one of the jobsdbs.
+func setupProcessorCheckpoints(conf *config.Config, log logger.Logger) (*checkpoint.Store, error) {
+	if !conf.GetBool("Processor.checkpoints.enabled", false) {
+		return nil, nil
+	}
+	log.Infof("Setting up the processor checkpoints")
+	store, err := checkpoint.New(conf, log.Child("checkpoints"), "processor_store")
+	if err != nil {
+		return nil, fmt.Errorf("starting processor checkpoints: %w", err)
+	}
+	return store, nil
+}
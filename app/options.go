@@ -13,6 +13,7 @@ type Options struct {
 	Cpuprofile      string
 	Memprofile      string
 	VersionFlag     bool
+	ValidateConfig  bool
 	EnterpriseToken string
 }
 
@@ -26,6 +27,7 @@ func LoadOptions(args []string) *Options {
 	cpuprofile := flagSet.String("cpuprofile", "", "write cpu profile to `file`")
 	memprofile := flagSet.String("memprofile", "", "write memory profile to `file`")
 	versionFlag := flagSet.Bool("v", false, "Print the current version and exit")
+	validateConfig := flagSet.Bool("validate-config", false, "Validate the configuration and exit")
 
 	serverMode := os.Getenv("RSERVER_MODE")
 	if serverMode == "normal" {
@@ -38,11 +40,12 @@ func LoadOptions(args []string) *Options {
 	_ = flagSet.Parse(args[1:])
 
 	return &Options{
-		NormalMode:   *normalMode,
-		DegradedMode: *degradedMode,
-		ClearDB:      *clearDB,
-		Cpuprofile:   *cpuprofile,
-		Memprofile:   *memprofile,
-		VersionFlag:  *versionFlag,
+		NormalMode:     *normalMode,
+		DegradedMode:   *degradedMode,
+		ClearDB:        *clearDB,
+		Cpuprofile:     *cpuprofile,
+		Memprofile:     *memprofile,
+		VersionFlag:    *versionFlag,
+		ValidateConfig: *validateConfig,
 	}
 }
@@ -9,6 +9,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"sync"
@@ -24,6 +26,7 @@ import (
 	"github.com/rudderlabs/rudder-server/rruntime"
 	"github.com/rudderlabs/rudder-server/services/controlplane/identity"
 	"github.com/rudderlabs/rudder-server/services/diagnostics"
+	"github.com/rudderlabs/rudder-server/utils/misc"
 	"github.com/rudderlabs/rudder-server/utils/pubsub"
 	"github.com/rudderlabs/rudder-server/utils/sysUtils"
 	"github.com/rudderlabs/rudder-server/utils/types"
@@ -39,6 +42,8 @@ var (
 	configFromFile              bool
 	configEnvReplacementEnabled bool
 	dbCacheEnabled              bool
+	fileCacheEnabled            bool
+	fileCachePath               string
 
 	LastSync           string
 	LastRegulationSync string
@@ -58,8 +63,8 @@ func disableCache() {
 
 type noCache struct{}
 
-func (*noCache) Get(context.Context) ([]byte, error) {
-	return nil, fmt.Errorf(`noCache: cache disabled`)
+func (*noCache) Get(context.Context) ([]byte, time.Time, error) {
+	return nil, time.Time{}, fmt.Errorf(`noCache: cache disabled`)
 }
 
 type workspaceConfig interface {
@@ -89,6 +94,7 @@ type backendConfigImpl struct {
 	curSourceJSON     map[string]ConfigT
 	curSourceJSONLock sync.RWMutex
 	usingCache        bool
+	cachedAt          time.Time // when the config served from the cache was cached
 	cache             cache.Cache
 }
 
@@ -101,6 +107,8 @@ func loadConfig() {
 	configEnvReplacementEnabled = config.GetBoolVar(true, "BackendConfig.envReplacementEnabled")
 	incrementalConfigUpdates = config.GetBoolVar(false, "BackendConfig.incrementalConfigUpdates")
 	dbCacheEnabled = config.GetBoolVar(true, "BackendConfig.dbCacheEnabled")
+	fileCacheEnabled = config.GetBoolVar(false, "BackendConfig.fileCache.enabled")
+	fileCachePath = config.GetStringVar("", "BackendConfig.fileCache.path")
 }
 
 func Init() {
@@ -167,10 +175,14 @@ func (bc *backendConfigImpl) configUpdate(ctx context.Context) {
 	)
 	defer func() {
 		cacheConfigGauge := stats.Default.NewStat("config_from_cache", stats.GaugeType)
+		// how old the config served from the cache is, while the config backend is unreachable
+		cacheStalenessGauge := stats.Default.NewStat("config_from_cache_staleness_seconds", stats.GaugeType)
 		if bc.usingCache {
 			cacheConfigGauge.Gauge(1)
+			cacheStalenessGauge.Gauge(time.Since(bc.cachedAt).Seconds())
 		} else {
 			cacheConfigGauge.Gauge(0)
+			cacheStalenessGauge.Gauge(0)
 		}
 	}()
 
@@ -187,7 +199,7 @@ func (bc *backendConfigImpl) configUpdate(ctx context.Context) {
 		bc.initializedLock.RUnlock()
 
 		// try to get config from cache
-		sourceJSONBytes, cacheErr := bc.cache.Get(ctx)
+		sourceJSONBytes, cachedAt, cacheErr := bc.cache.Get(ctx)
 		if cacheErr != nil {
 			pkgLogger.Warnf("Error fetching config from cache: %v", cacheErr)
 			return
@@ -197,7 +209,11 @@ func (bc *backendConfigImpl) configUpdate(ctx context.Context) {
 			pkgLogger.Warnf("Error unmarshalling cached config: %v", cacheErr)
 			return
 		}
+		pkgLogger.Warnn("Config backend is unreachable, using cached config",
+			logger.NewTimeField("cachedAt", cachedAt),
+		)
 		bc.usingCache = true
+		bc.cachedAt = cachedAt
 	} else {
 		bc.usingCache = false
 	}
@@ -320,37 +336,59 @@ func Setup(configEnvHandler types.ConfigEnvI) (err error) {
 }
 
 func (bc *backendConfigImpl) StartWithIDs(ctx context.Context, _ string) {
-	var err error
 	ctx, cancel := context.WithCancel(ctx)
 	bc.ctx = ctx
 	bc.cancel = cancel
 	bc.blockChan = make(chan struct{})
 	bc.cache = cacheOverride
 
-	if !dbCacheEnabled {
-		bc.cache = &noCache{}
-	}
 	if bc.cache == nil {
 		identifier := bc.Identity()
 		u, _ := identifier.BasicAuth()
 		secret := sha256.Sum256([]byte(u))
 		cacheKey := identifier.ID()
-		bc.cache, err = cache.Start(
-			ctx,
-			secret,
-			cacheKey,
-			func() pubsub.DataChannel { return bc.Subscribe(ctx, TopicBackendConfig) },
-		)
-		if err != nil {
-			// the only reason why we should resume by using no cache,
-			// would be if no database configuration has been set
-			if config.IsSet("DB.host") {
-				panic(fmt.Errorf("error starting backend config cache: %w", err))
+		var caches []cache.Cache
+		if dbCacheEnabled {
+			dbCache, err := cache.Start(
+				ctx,
+				secret,
+				cacheKey,
+				func() pubsub.DataChannel { return bc.Subscribe(ctx, TopicBackendConfig) },
+			)
+			if err != nil {
+				// the only reason why we should resume by using no cache,
+				// would be if no database configuration has been set
+				if config.IsSet("DB.host") {
+					panic(fmt.Errorf("error starting backend config cache: %w", err))
+				} else {
+					pkgLogger.Warnf("Failed to start backend config cache, no cache will be used: %w", err)
+				}
+			} else {
+				caches = append(caches, dbCache)
+			}
+		}
+		if fileCacheEnabled {
+			// the file cache allows booting while both the config backend and the database are unreachable
+			fileCache, err := cache.StartFile(
+				secret,
+				cacheKey,
+				bc.fileCachePath(),
+				func() pubsub.DataChannel { return bc.Subscribe(ctx, TopicBackendConfig) },
+			)
+			if err != nil {
+				pkgLogger.Warnf("Failed to start backend config file cache, it will not be used: %v", err)
 			} else {
-				pkgLogger.Warnf("Failed to start backend config cache, no cache will be used: %w", err)
-				bc.cache = &noCache{}
+				caches = append(caches, fileCache)
 			}
 		}
+		switch len(caches) {
+		case 0:
+			bc.cache = &noCache{}
+		case 1:
+			bc.cache = caches[0]
+		default:
+			bc.cache = cache.Freshest(caches...)
+		}
 	}
 
 	rruntime.Go(func() {
@@ -359,6 +397,18 @@ func (bc *backendConfigImpl) StartWithIDs(ctx context.Context, _ string) {
 	})
 }
 
+// fileCachePath returns the path of the config cache file, which defaults to a file in the tmp directory
+func (bc *backendConfigImpl) fileCachePath() string {
+	if fileCachePath != "" {
+		return fileCachePath
+	}
+	tmpDir, err := misc.CreateTMPDIR()
+	if err != nil {
+		tmpDir = os.TempDir()
+	}
+	return filepath.Join(tmpDir, "rudder-backend-config-cache")
+}
+
 func (bc *backendConfigImpl) Stop() {
 	if bc.cancel != nil {
 		bc.cancel()
@@ -251,7 +251,7 @@ func TestConfigUpdate(t *testing.T) {
 			workspaceConfig: wc,
 			cache:           cacheStore,
 		}
-		cacheStore.EXPECT().Get(ctx).Return([]byte{}, cacheError).Times(1)
+		cacheStore.EXPECT().Get(ctx).Return([]byte{}, time.Time{}, cacheError).Times(1)
 		bc.configUpdate(ctx)
 		require.False(t, bc.initialized)
 	})
@@ -464,7 +464,7 @@ func TestCache(t *testing.T) {
 		}
 		bc.StartWithIDs(ctx, workspaces)
 
-		cacheVal, err := bc.cache.Get(ctx)
+		cacheVal, _, err := bc.cache.Get(ctx)
 		require.Equal(t, fmt.Errorf(`noCache: cache disabled`), err)
 		require.Nil(t, cacheVal)
 	})
@@ -501,7 +501,7 @@ func TestCache(t *testing.T) {
 		unmarshalledConfig := make(map[string]ConfigT)
 		err = json.Unmarshal(sampleBackendConfigBytes, &unmarshalledConfig)
 		require.NoError(t, err)
-		cacheStore.EXPECT().Get(gomock.Eq(ctx)).Return(sampleBackendConfigBytes, nil).Times(1)
+		cacheStore.EXPECT().Get(gomock.Eq(ctx)).Return(sampleBackendConfigBytes, time.Now(), nil).Times(1)
 		var pubSub pubsub.PublishSubscriber
 		bc := &backendConfigImpl{
 			eb:              &pubSub,
@@ -530,7 +530,7 @@ func TestCache(t *testing.T) {
 
 		wc := NewMockworkspaceConfig(ctrl)
 		wc.EXPECT().Get(gomock.Eq(ctx)).Return(map[string]ConfigT{}, errors.New("control plane down")).Times(1)
-		cacheStore.EXPECT().Get(gomock.Eq(ctx)).Return([]byte{}, sql.ErrNoRows).Times(1)
+		cacheStore.EXPECT().Get(gomock.Eq(ctx)).Return([]byte{}, time.Time{}, sql.ErrNoRows).Times(1)
 		var pubSub pubsub.PublishSubscriber
 		bc := &backendConfigImpl{
 			eb:              &pubSub,
@@ -7,7 +7,9 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 
@@ -24,7 +26,8 @@ var (
 )
 
 type Cache interface {
-	Get(ctx context.Context) ([]byte, error)
+	// Get returns the cached config and the time it was cached at
+	Get(ctx context.Context) ([]byte, time.Time, error)
 }
 
 type cacheStore struct {
@@ -92,7 +95,7 @@ func (db *cacheStore) set(ctx context.Context, config interface{}) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 	// encrypt
-	encrypted, err := db.encryptAES(configBytes)
+	encrypted, err := encryptAES(db.secret, configBytes)
 	if err != nil {
 		return err
 	}
@@ -111,27 +114,61 @@ func (db *cacheStore) set(ctx context.Context, config interface{}) error {
 }
 
 // Fetch the cached config when needed
-func (db *cacheStore) Get(ctx context.Context) ([]byte, error) {
+func (db *cacheStore) Get(ctx context.Context) ([]byte, time.Time, error) {
 	// read from database
 	var (
-		config []byte
-		err    error
+		config    []byte
+		updatedAt time.Time
+		err       error
 	)
 	err = db.QueryRowContext(
 		ctx,
-		`SELECT config FROM config_cache WHERE key = $1`,
+		`SELECT config, updated_at AT TIME ZONE current_setting('TimeZone') FROM config_cache WHERE key = $1`,
 		db.key,
-	).Scan(&config)
+	).Scan(&config, &updatedAt)
 	switch err {
 	case nil:
 	case sql.ErrNoRows:
 		// maybe fetch the config where workspaces = ''?
-		return nil, err
+		return nil, time.Time{}, err
 	default:
-		return nil, err
+		return nil, time.Time{}, err
 	}
 	// decrypt and return
-	return db.decryptAES(config)
+	decrypted, err := decryptAES(db.secret, config)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return decrypted, updatedAt, nil
+}
+
+// Freshest returns a Cache serving the most recently cached config among the given caches
+func Freshest(caches ...Cache) Cache {
+	return freshest(caches)
+}
+
+type freshest []Cache
+
+func (f freshest) Get(ctx context.Context) ([]byte, time.Time, error) {
+	var (
+		config    []byte
+		updatedAt time.Time
+		errs      []error
+	)
+	for _, c := range f {
+		cachedConfig, cachedAt, err := c.Get(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if config == nil || cachedAt.After(updatedAt) {
+			config, updatedAt = cachedConfig, cachedAt
+		}
+	}
+	if config == nil {
+		return nil, time.Time{}, errors.Join(errs...)
+	}
+	return config, updatedAt, nil
 }
 
 // setupDBConn sets up the database connection, creates the config table if it doesn't exist
@@ -167,8 +204,8 @@ func migrate(db *sql.DB) error {
 	return m.Migrate("config_cache")
 }
 
-func (db *cacheStore) encryptAES(data []byte) ([]byte, error) {
-	gcm, err := newGCM(db.secret)
+func encryptAES(secret [32]byte, data []byte) ([]byte, error) {
+	gcm, err := newGCM(secret)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create encrypt gcm: %w", err)
 	}
@@ -179,12 +216,15 @@ func (db *cacheStore) encryptAES(data []byte) ([]byte, error) {
 	return gcm.Seal(nonce, nonce, data, nil), nil
 }
 
-func (db *cacheStore) decryptAES(data []byte) ([]byte, error) {
-	gcm, err := newGCM(db.secret)
+func decryptAES(secret [32]byte, data []byte) ([]byte, error) {
+	gcm, err := newGCM(secret)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create decrypt gcm: %w", err)
 	}
 	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("failed to decrypt: data too short")
+	}
 	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
 	out, err := gcm.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/rudderlabs/rudder-server/utils/pubsub"
+)
+
+// fileEntry is the encrypted content of the cache file
+type fileEntry struct {
+	Key       string              `json:"key"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+	Config    jsoniter.RawMessage `json:"config"`
+}
+
+type fileStore struct {
+	path   string
+	secret [32]byte
+	key    string
+
+	mu sync.Mutex // serializes writes to the cache file
+}
+
+// StartFile returns a new Cache instance storing the config in a file, which is available even if the database is not,
+// and starts a goroutine to cache the config
+//
+// path is the path of the file, which is replaced atomically every time the config is updated
+//
+// secret, key and channelProvider are the same as for Start
+func StartFile(secret [32]byte, key, path string, channelProvider func() pubsub.DataChannel) (Cache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("creating config cache directory: %w", err)
+	}
+	fileStore := &fileStore{
+		path:   path,
+		secret: secret,
+		key:    key,
+	}
+
+	ch := channelProvider()
+	go func() {
+		for config := range ch {
+			if err := fileStore.set(config.Data); err != nil {
+				pkgLogger.Errorf("failed writing config to file: %v", err)
+			}
+		}
+	}()
+	return fileStore, nil
+}
+
+// Encrypt and store the config to the file
+func (fs *fileStore) set(config interface{}) error {
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	entryBytes, err := json.Marshal(fileEntry{Key: fs.key, UpdatedAt: time.Now(), Config: configBytes})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+	encrypted, err := encryptAES(fs.secret, entryBytes)
+	if err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	// the file is replaced atomically, so that a crash while writing it doesn't leave a corrupted config behind
+	tmp, err := os.CreateTemp(filepath.Dir(fs.path), filepath.Base(fs.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temporary config cache file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(encrypted); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing temporary config cache file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("syncing temporary config cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing temporary config cache file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), fs.path); err != nil {
+		return fmt.Errorf("replacing config cache file: %w", err)
+	}
+	return nil
+}
+
+// Fetch the cached config from the file
+func (fs *fileStore) Get(context.Context) ([]byte, time.Time, error) {
+	encrypted, err := os.ReadFile(fs.path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("reading config cache file: %w", err)
+	}
+	entryBytes, err := decryptAES(fs.secret, encrypted)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var entry fileEntry
+	if err := json.Unmarshal(entryBytes, &entry); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to unmarshal cache entry: %w", err)
+	}
+	if entry.Key != fs.key {
+		// the config of other workspaces is never served
+		return nil, time.Time{}, fmt.Errorf("config cache file is for a different key: %w", os.ErrNotExist)
+	}
+	return entry.Config, entry.UpdatedAt, nil
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-server/utils/pubsub"
+)
+
+func TestFileCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	secret := sha256.Sum256([]byte("secret"))
+	path := filepath.Join(t.TempDir(), "config", "cache")
+
+	var ps pubsub.PublishSubscriber
+	fileCache, err := StartFile(secret, "key", path, func() pubsub.DataChannel { return ps.Subscribe(ctx, "config") })
+	require.NoError(t, err)
+
+	_, _, err = fileCache.Get(ctx)
+	require.ErrorIs(t, err, os.ErrNotExist, "nothing should be cached before the first config")
+
+	before := time.Now()
+	ps.Publish("config", map[string]string{"workspace": "config"})
+	require.Eventually(t, func() bool {
+		_, _, err := fileCache.Get(ctx)
+		return err == nil
+	}, time.Second, time.Millisecond)
+	cached, cachedAt, err := fileCache.Get(ctx)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"workspace":"config"}`, string(cached))
+	require.False(t, cachedAt.Before(before))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(content), "workspace", "config should be encrypted")
+
+	t.Run("other key", func(t *testing.T) {
+		otherCache, err := StartFile(secret, "other-key", path, func() pubsub.DataChannel { return ps.Subscribe(ctx, "other") })
+		require.NoError(t, err)
+		_, _, err = otherCache.Get(ctx)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("freshest", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		olderCache := NewMockCache(ctrl)
+		olderCache.EXPECT().Get(ctx).Return([]byte(`{"workspace":"older"}`), cachedAt.Add(-time.Minute), nil).AnyTimes()
+		failingCache := NewMockCache(ctrl)
+		failingCache.EXPECT().Get(ctx).Return(nil, time.Time{}, errors.New("unavailable")).AnyTimes()
+
+		config, _, err := Freshest(olderCache, failingCache, fileCache).Get(ctx)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"workspace":"config"}`, string(config))
+
+		config, _, err = Freshest(failingCache, olderCache).Get(ctx)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"workspace":"older"}`, string(config))
+
+		_, _, err = Freshest(failingCache).Get(ctx)
+		require.ErrorContains(t, err, "unavailable")
+	})
+}
@@ -67,6 +67,7 @@ type SourceT struct {
 	WorkspaceID                string
 	Destinations               []DestinationT
 	WriteKey                   string
+	WriteKeys                  []WriteKeyT
 	DgSourceTrackingPlanConfig DgSourceTrackingPlanConfigT
 	Transient                  bool
 	GeoEnrichment              struct {
@@ -84,6 +85,49 @@ func (s *SourceT) IsReplaySource() bool {
 	return s.OriginalID != ""
 }
 
+// WriteKeyT is an additional write key of a source, having its own create/revoke lifecycle so that credentials can be rotated without a hard cutover.
+type WriteKeyT struct {
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"createdAt"`
+	RevokedAt time.Time `json:"revokedAt"`
+}
+
+// IsActive returns true if the write key has not been revoked at the provided time.
+// A revocation time in the future allows for a grace period during key rotation.
+func (wk WriteKeyT) IsActive(now time.Time) bool {
+	return wk.Key != "" && (wk.RevokedAt.IsZero() || now.Before(wk.RevokedAt))
+}
+
+// ActiveWriteKeys returns the source's primary write key along with all of its additional write keys which are active at the provided time.
+func (s *SourceT) ActiveWriteKeys(now time.Time) []string {
+	var keys []string
+	if s.WriteKey != "" {
+		keys = append(keys, s.WriteKey)
+	}
+	for _, wk := range s.WriteKeys {
+		if wk.IsActive(now) && wk.Key != s.WriteKey {
+			keys = append(keys, wk.Key)
+		}
+	}
+	return keys
+}
+
+// IsWriteKeyActive returns true if the provided write key is the source's primary write key or one of its active additional write keys.
+func (s *SourceT) IsWriteKeyActive(writeKey string, now time.Time) bool {
+	if writeKey == "" {
+		return false
+	}
+	if writeKey == s.WriteKey {
+		return true
+	}
+	for _, wk := range s.WriteKeys {
+		if wk.Key == writeKey {
+			return wk.IsActive(now)
+		}
+	}
+	return false
+}
+
 type ConfigT struct {
 	EnableMetrics   bool                         `json:"enableMetrics"`
 	WorkspaceID     string                       `json:"workspaceId"`
@@ -127,8 +171,11 @@ func (c *ConfigT) DestinationsMap() map[string]*DestinationT {
 }
 
 type Settings struct {
-	DataRetention     DataRetention `json:"dataRetention"`
-	EventAuditEnabled bool          `json:"eventAuditEnabled"`
+	DataRetention     DataRetention   `json:"dataRetention"`
+	EventAuditEnabled bool            `json:"eventAuditEnabled"`
+	FeatureFlags      map[string]bool `json:"featureFlags"`
+	// WarehouseDefaults are the settings inherited by the warehouse destinations of the workspace not setting them
+	WarehouseDefaults map[string]interface{} `json:"warehouseDefaults"`
 }
 
 type DataRetention struct {
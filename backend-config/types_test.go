@@ -0,0 +1,38 @@
+package backendconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceWriteKeys(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := SourceT{
+		WriteKey: "primary",
+		WriteKeys: []WriteKeyT{
+			{Key: "primary", CreatedAt: now.Add(-time.Hour)},
+			{Key: "active", CreatedAt: now.Add(-time.Hour)},
+			{Key: "grace", CreatedAt: now.Add(-time.Hour), RevokedAt: now.Add(time.Hour)},
+			{Key: "revoked", CreatedAt: now.Add(-2 * time.Hour), RevokedAt: now.Add(-time.Hour)},
+			{Key: ""},
+		},
+	}
+
+	t.Run("ActiveWriteKeys", func(t *testing.T) {
+		require.Equal(t, []string{"primary", "active", "grace"}, s.ActiveWriteKeys(now))
+		require.Equal(t, []string{"primary", "active"}, s.ActiveWriteKeys(now.Add(2*time.Hour)))
+		require.Empty(t, (&SourceT{}).ActiveWriteKeys(now))
+	})
+
+	t.Run("IsWriteKeyActive", func(t *testing.T) {
+		require.True(t, s.IsWriteKeyActive("primary", now))
+		require.True(t, s.IsWriteKeyActive("active", now))
+		require.True(t, s.IsWriteKeyActive("grace", now))
+		require.False(t, s.IsWriteKeyActive("grace", now.Add(2*time.Hour)))
+		require.False(t, s.IsWriteKeyActive("revoked", now))
+		require.False(t, s.IsWriteKeyActive("unknown", now))
+		require.False(t, s.IsWriteKeyActive("", now))
+	})
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/google/uuid"
+	"github.com/urfave/cli/v2"
+
+	kitconfig "github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	kitlogger "github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/jobsdb/archive"
+	"github.com/rudderlabs/rudder-server/utils/filemanagerutil"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+// archiveReader reads the archived jobs of a jobsdb from object storage
+type archiveReader struct {
+	logger      kitlogger.Logger
+	fileManager filemanager.FileManager
+	prefix      string
+	filter      archive.Filter
+}
+
+func newArchiveReader(ctx context.Context, c *cli.Context, conf *kitconfig.Config, logger kitlogger.Logger) (*archiveReader, error) {
+	storageProvider := conf.GetString("JOBS_BACKUP_STORAGE_PROVIDER", "S3")
+	fileManager, err := filemanager.New(&filemanager.Settings{
+		Provider: storageProvider,
+		Config:   filemanagerutil.GetProviderConfigForBackupsFromEnv(ctx, conf),
+		Logger:   logger,
+		Conf:     conf,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating file manager: %w", err)
+	}
+	r := &archiveReader{
+		logger:      logger,
+		fileManager: fileManager,
+		prefix: path.Join(
+			conf.GetString("JOBS_BACKUP_PREFIX", ""),
+			conf.GetString("JobsDB.archival.prefix", "rudder-jobs-archive"),
+			c.String("tablePrefix"),
+			c.String("workspaceId"),
+		) + "/",
+		filter: archive.Filter{
+			WorkspaceID: c.String("workspaceId"),
+			JobState:    c.String("jobState"),
+		},
+	}
+	if startTime := c.Timestamp("startTime"); startTime != nil {
+		r.filter.From = *startTime
+	}
+	if endTime := c.Timestamp("endTime"); endTime != nil {
+		r.filter.To = *endTime
+	}
+	return r, nil
+}
+
+// forEach calls fn for every archived job matching the filter, downloading only the files which may contain such jobs
+func (r *archiveReader) forEach(ctx context.Context, fn func(*archive.Job) error) error {
+	tmpDir, err := misc.CreateTMPDIR()
+	if err != nil {
+		return fmt.Errorf("creating tmp dir: %w", err)
+	}
+	dir, err := os.MkdirTemp(tmpDir, "rudder-jobs-archive-*")
+	if err != nil {
+		return fmt.Errorf("creating download dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	iterator := filemanager.IterateFilesWithPrefix(ctx, r.prefix, "", 100, r.fileManager)
+	for iterator.Next() {
+		key := iterator.Get().Key
+		if !strings.HasSuffix(key, archive.FileExtension) || !r.filter.MatchFile(key) {
+			continue
+		}
+		r.logger.Infon("Reading archive file", kitlogger.NewStringField("key", key))
+		if err := r.readFile(ctx, dir, key, fn); err != nil {
+			return err
+		}
+	}
+	if err := iterator.Err(); err != nil {
+		return fmt.Errorf("listing archive files under %q: %w", r.prefix, err)
+	}
+	return nil
+}
+
+func (r *archiveReader) readFile(ctx context.Context, dir, key string, fn func(*archive.Job) error) error {
+	filePath := filepath.Join(dir, path.Base(key))
+	f, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("creating file for %q: %w", key, err)
+	}
+	defer func() { _ = os.Remove(filePath) }()
+	err = r.fileManager.Download(ctx, f, key)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("downloading %q: %w", key, err)
+	}
+	return archive.ReadFile(filePath, r.filter, fn)
+}
+
+func query(c *cli.Context) error {
+	ctx, cancel := signal.NotifyContext(c.Context, syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	conf := kitconfig.New()
+	logger := kitlogger.NewFactory(conf).NewLogger().Child("jobs-archive")
+
+	r, err := newArchiveReader(ctx, c, conf, logger)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	return r.forEach(ctx, func(job *archive.Job) error {
+		return encoder.Encode(job)
+	})
+}
+
+func restore(c *cli.Context) error {
+	ctx, cancel := signal.NotifyContext(c.Context, syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	conf := kitconfig.New()
+	logger := kitlogger.NewFactory(conf).NewLogger().Child("jobs-archive")
+
+	r, err := newArchiveReader(ctx, c, conf, logger)
+	if err != nil {
+		return err
+	}
+	targetTablePrefix := c.String("targetTablePrefix")
+	if targetTablePrefix == "" {
+		targetTablePrefix = c.String("tablePrefix")
+	}
+	batchSize := c.Int("batchSize")
+	if batchSize <= 0 {
+		return fmt.Errorf("batchSize should be positive")
+	}
+
+	db := jobsdb.NewForWrite(targetTablePrefix, jobsdb.WithConfig(conf))
+	if err := db.Start(); err != nil {
+		return fmt.Errorf("starting jobsdb: %w", err)
+	}
+	defer db.Close()
+	defer db.Stop()
+
+	var restored int
+	batch := make([]*jobsdb.JobT, 0, batchSize)
+	store := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := db.Store(ctx, batch); err != nil {
+			return fmt.Errorf("storing jobs: %w", err)
+		}
+		restored += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+	err = r.forEach(ctx, func(job *archive.Job) error {
+		restoredJob, err := toJobsDBJob(job)
+		if err != nil {
+			return err
+		}
+		if batch = append(batch, restoredJob); len(batch) >= batchSize {
+			return store()
+		}
+		return nil
+	})
+	if err == nil {
+		err = store()
+	}
+	logger.Infon("Restored archived jobs",
+		kitlogger.NewStringField("tablePrefix", targetTablePrefix),
+		kitlogger.NewIntField("jobs", int64(restored)),
+	)
+	if err != nil {
+		logger.Errorn("Restoring archived jobs", obskit.Error(err))
+	}
+	return err
+}
+
+// toJobsDBJob converts an archived job to an unprocessed jobsdb job, keeping its uuid, payload and parameters
+func toJobsDBJob(job *archive.Job) (*jobsdb.JobT, error) {
+	jobUUID, err := uuid.Parse(job.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("parsing uuid of archived job %d: %w", job.JobID, err)
+	}
+	return &jobsdb.JobT{
+		UUID:         jobUUID,
+		UserID:       job.UserID,
+		CustomVal:    job.CustomVal,
+		EventCount:   int(job.EventCount),
+		EventPayload: json.RawMessage(job.EventPayload),
+		Parameters:   json.RawMessage(job.Parameters),
+		WorkspaceId:  job.WorkspaceID,
+	}, nil
+}
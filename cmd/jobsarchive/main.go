@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+var filterFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:     "tablePrefix",
+		Usage:    "jobsdb table prefix of the archived jobs, e.g. gw, rt or batch_rt",
+		Required: true,
+	},
+	&cli.StringFlag{
+		Name:  "workspaceId",
+		Usage: "only jobs of this workspace",
+	},
+	&cli.StringFlag{
+		Name:  "jobState",
+		Usage: "only jobs whose last status is in this state, e.g. succeeded or aborted",
+	},
+	&cli.TimestampFlag{
+		Name:   "startTime",
+		Layout: time.RFC3339Nano,
+		Usage:  "only jobs created at or after this time in RFC3339 format",
+	},
+	&cli.TimestampFlag{
+		Name:   "endTime",
+		Layout: time.RFC3339Nano,
+		Usage:  "only jobs created before this time in RFC3339 format",
+	},
+}
+
+var app = &cli.App{
+	Name:  "jobs-archive",
+	Usage: "Query and restore jobs archived by jobsdb",
+	Commands: []*cli.Command{
+		{
+			Name:  "query",
+			Usage: "Print the archived jobs matching the filters as newline delimited json",
+			Flags: filterFlags,
+			Action: func(c *cli.Context) error {
+				return query(c)
+			},
+		},
+		{
+			Name:  "restore",
+			Usage: "Store the archived jobs matching the filters back into jobsdb as unprocessed jobs",
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{
+					Name:  "targetTablePrefix",
+					Usage: "jobsdb table prefix to restore the jobs into, defaults to the table prefix of the archived jobs",
+				},
+				&cli.IntFlag{
+					Name:  "batchSize",
+					Usage: "no. of jobs to store in a single transaction",
+					Value: 1000,
+				},
+			}, filterFlags...),
+			Action: func(c *cli.Context) error {
+				return restore(c)
+			},
+		},
+	},
+}
+
+func init() {
+	sort.Sort(cli.FlagsByName(app.Flags))
+	sort.Sort(cli.CommandsByName(app.Commands))
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
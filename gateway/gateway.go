@@ -19,6 +19,13 @@ const (
 	extractEvent              = "extract"
 	rETLEvent                 = "record"
 	customVal                 = "GW"
+
+	// granularResponseHeader is the request header with which clients can opt in for per-message responses in batch requests
+	granularResponseHeader = "X-Rudder-Granular-Response"
+
+	// correlationIDParam is the job parameter holding the uuid of the gateway job of an event, which is propagated to the
+	// jobs of every destination the event fans out to. It is returned as the jobUUID of granular responses.
+	correlationIDParam = "correlation_id"
 )
 
 var (
@@ -560,8 +560,8 @@ var _ = Describe("Gateway", func() {
 			var paramsMap, expectedParamsMap map[string]interface{}
 			_ = json.Unmarshal(job.Parameters, &paramsMap)
 			expectedStr := []byte(fmt.Sprintf(
-				`{"source_id": "%v", "source_job_run_id": "", "source_task_run_id": "","source_category": "webhook", "traceparent": ""}`,
-				SourceIDEnabled,
+				`{"source_id": "%v", "source_job_run_id": "", "source_task_run_id": "","source_category": "webhook", "traceparent": "", "correlation_id": "%v"}`,
+				SourceIDEnabled, job.UUID.String(),
 			))
 			_ = json.Unmarshal(expectedStr, &expectedParamsMap)
 			equals := reflect.DeepEqual(paramsMap, expectedParamsMap)
@@ -1440,6 +1440,27 @@ var _ = Describe("Gateway", func() {
 			Expect(jobData.jobs).To(BeNil())
 		})
 
+		It("reports per-message results if a granular response is requested", func() {
+			gr := &granularResponse{}
+			req := &webRequestT{
+				reqType:          "batch",
+				authContext:      rCtxEnabled,
+				done:             make(chan<- string),
+				userIDHeader:     userIDHeader,
+				requestPayload:   []byte(`{"batch": [{"type": "track", "userId": "u1", "messageId": "m1"}, "invalid", {"type": "track", "messageId": "m3"}, {"type": "identify", "anonymousId": "a1", "messageId": "m4"}]}`),
+				granularResponse: gr,
+			}
+			jobData, err := gateway.getJobDataFromRequest(req)
+			Expect(err).To(BeNil())
+			Expect(jobData.jobs).To(HaveLen(2))
+			Expect(gr.Messages).To(Equal([]messageResponse{
+				{Index: 0, MessageID: "m1", Status: messageStatusAccepted, JobUUID: jobData.jobs[0].UUID.String()},
+				{Index: 1, Status: messageStatusRejected, Error: response.NotRudderEvent},
+				{Index: 2, MessageID: "m3", Status: messageStatusRejected, Error: response.NonIdentifiableRequest},
+				{Index: 3, MessageID: "m4", Status: messageStatusAccepted, JobUUID: jobData.jobs[1].UUID.String()},
+			}))
+		})
+
 		It("accepts events with non-string type anonymousId and/or userId", func() {
 			// map type usreId
 			payloadMap := map[string]interface{}{
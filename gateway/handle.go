@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
@@ -38,6 +39,7 @@ import (
 	"github.com/rudderlabs/rudder-server/gateway/response"
 	"github.com/rudderlabs/rudder-server/gateway/throttler"
 	"github.com/rudderlabs/rudder-server/gateway/webhook"
+	"github.com/rudderlabs/rudder-server/internal/liveevents"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	sourcedebugger "github.com/rudderlabs/rudder-server/services/debugger/source"
 	"github.com/rudderlabs/rudder-server/services/rsources"
@@ -96,6 +98,11 @@ type Handle struct {
 	backendConfigInitialised bool
 	inFlightRequests         *sync.WaitGroup
 
+	rawArchive *rawArchiveT // only set if raw request archival is enabled
+	backfill   *backfillT   // only set if backfill is enabled
+
+	saturated atomic.Bool // true while the gateway's jobsdb exceeds the configured backpressure thresholds
+
 	trackCounterMu    sync.Mutex // protects trackSuccessCount and trackFailureCount
 	trackSuccessCount int
 	trackFailureCount int
@@ -120,6 +127,19 @@ type Handle struct {
 		IdleTimeout                          time.Duration
 		allowReqsWithoutUserIDAndAnonymousID config.ValueLoader[bool]
 		gwAllowPartialWriteWithErrors        config.ValueLoader[bool]
+		otlp                                 struct {
+			eventNameAttribute   config.ValueLoader[string]
+			userIDAttribute      config.ValueLoader[string]
+			anonymousIDAttribute config.ValueLoader[string]
+			messageIDAttribute   config.ValueLoader[string]
+		}
+		backpressure struct {
+			maxPendingJobs config.ValueLoader[int64]
+			maxSizeInMB    config.ValueLoader[int64]
+			checkInterval  config.ValueLoader[time.Duration]
+			checkTimeout   config.ValueLoader[time.Duration]
+			retryAfter     config.ValueLoader[time.Duration]
+		}
 	}
 
 	// additional internal http handlers
@@ -221,6 +241,7 @@ func (gw *Handle) userWebRequestWorkerProcess(userWebRequestWorker *userWebReque
 						sourceDebugger{
 							data:     job.EventPayload,
 							writeKey: arctx.WriteKey,
+							sourceID: arctx.SourceID,
 						},
 					)
 				}
@@ -253,6 +274,7 @@ func (gw *Handle) userWebRequestWorkerProcess(userWebRequestWorker *userWebReque
 		// Sending events to config backend
 		for _, eventBatch := range eventBatchesToRecord {
 			gw.sourcehandle.RecordEvent(eventBatch.writeKey, eventBatch.data)
+			liveevents.Default.Record(liveevents.GatewayIn, eventBatch.sourceID, "", eventBatch.data)
 		}
 
 		userWebRequestWorker.batchTimeStat.Since(batchStart)
@@ -290,7 +312,15 @@ func (gw *Handle) getJobDataFromRequest(req *webRequestT) (jobData *jobFromReq,
 
 		// tracing
 		traceParent = req.traceParent
+
+		// per-message results, if requested by the client
+		gr = req.granularResponse
+
+		receivedAt = req.receivedAt
 	)
+	if receivedAt.IsZero() {
+		receivedAt = gw.now()
+	}
 
 	fillMessageID := func(event map[string]interface{}) {
 		messageID, _ := event["messageId"].(string)
@@ -322,8 +352,10 @@ func (gw *Handle) getJobDataFromRequest(req *webRequestT) (jobData *jobFromReq,
 	jobData.numEvents = len(eventsBatch)
 
 	type jobObject struct {
-		userID string
-		events []map[string]interface{}
+		userID    string
+		events    []map[string]interface{}
+		index     int
+		messageID string
 	}
 
 	var (
@@ -340,6 +372,10 @@ func (gw *Handle) getJobDataFromRequest(req *webRequestT) (jobData *jobFromReq,
 	for idx, v := range eventsBatch {
 		toSet, ok := v.Value().(map[string]interface{})
 		if !ok {
+			if gr != nil {
+				gr.rejected(idx, "", response.NotRudderEvent)
+				continue
+			}
 			err = errors.New(response.NotRudderEvent)
 			return
 		}
@@ -352,6 +388,11 @@ func (gw *Handle) getJobDataFromRequest(req *webRequestT) (jobData *jobFromReq,
 		).(string)
 
 		if gw.isNonIdentifiable(anonIDFromReq, userIDFromReq, eventTypeFromReq) {
+			if gr != nil {
+				messageID, _ := toSet["messageId"].(string)
+				gr.rejected(idx, messageID, response.NonIdentifiableRequest)
+				continue
+			}
 			err = errors.New(response.NonIdentifiableRequest)
 			return
 		}
@@ -397,6 +438,10 @@ func (gw *Handle) getJobDataFromRequest(req *webRequestT) (jobData *jobFromReq,
 
 		if isUserSuppressed(workspaceId, userIDFromReq, sourceID) {
 			suppressed = true
+			if gr != nil {
+				messageID, _ := toSet["messageId"].(string)
+				gr.suppressed(idx, messageID)
+			}
 			continue
 		}
 
@@ -409,7 +454,7 @@ func (gw *Handle) getJobDataFromRequest(req *webRequestT) (jobData *jobFromReq,
 		}
 		toSet["rudderId"] = rudderId
 		if _, ok := toSet["receivedAt"]; !ok {
-			toSet["receivedAt"] = gw.now().Format(misc.RFC3339Milli)
+			toSet["receivedAt"] = receivedAt.Format(misc.RFC3339Milli)
 		}
 		if _, ok := toSet["request_ip"]; ok {
 			var tcOk bool
@@ -428,12 +473,15 @@ func (gw *Handle) getJobDataFromRequest(req *webRequestT) (jobData *jobFromReq,
 
 		userID := buildUserID(userIDHeader, anonIDFromReq, userIDFromReq)
 		out = append(out, jobObject{
-			userID: userID,
-			events: []map[string]interface{}{toSet},
+			userID:    userID,
+			events:    []map[string]interface{}{toSet},
+			index:     idx,
+			messageID: toSet["messageId"].(string),
 		})
 	}
 
-	if gw.conf.enableRateLimit.Load() && sourcesJobRunID == "" && sourcesTaskRunID == "" {
+	// backfills are throttled separately, so that they don't use up the rate limit of live traffic
+	if gw.conf.enableRateLimit.Load() && sourcesJobRunID == "" && sourcesTaskRunID == "" && req.backfillID == "" {
 		// In case of "batch" requests, if rate-limiter returns true for LimitReached, just drop the event batch and continue.
 		ok, errCheck := gw.rateLimiter.CheckLimitReached(context.TODO(), workspaceId, int64(len(eventsBatch)))
 		if errCheck != nil {
@@ -465,6 +513,10 @@ func (gw *Handle) getJobDataFromRequest(req *webRequestT) (jobData *jobFromReq,
 	if len(destinationID) != 0 {
 		params["destination_id"] = destinationID
 	}
+	if req.backfillID != "" {
+		params["backfill_id"] = req.backfillID
+		params["destination_ids"] = req.destinationIDs
+	}
 	marshalledParams, err = json.Marshal(params)
 	if err != nil {
 		gw.logger.Errorf(
@@ -489,7 +541,7 @@ func (gw *Handle) getJobDataFromRequest(req *webRequestT) (jobData *jobFromReq,
 				ReceivedAt string                   `json:"receivedAt"`
 			}
 			receivedAt, ok := userEvent.events[0]["receivedAt"].(string)
-			if !ok || !arctx.ReplaySource {
+			if !ok || !(arctx.ReplaySource || req.backfillID != "") {
 				receivedAt = time.Now().Format(misc.RFC3339Milli)
 			}
 			singularEventBatch := SingularEventBatch{
@@ -505,15 +557,27 @@ func (gw *Handle) getJobDataFromRequest(req *webRequestT) (jobData *jobFromReq,
 			eventCount = len(userEvent.events)
 		}
 
+		jobUUID := uuid.New()
+		// the id of the job correlates the event with every job derived from it, see correlationIDParam
+		jobParams, setErr := sjson.SetBytes(marshalledParams, correlationIDParam, jobUUID.String())
+		if setErr != nil {
+			jobParams = marshalledParams
+		}
 		jobs = append(jobs, &jobsdb.JobT{
-			UUID:         uuid.New(),
+			UUID:         jobUUID,
 			UserID:       userEvent.userID,
-			Parameters:   marshalledParams,
+			Parameters:   jobParams,
 			CustomVal:    customVal,
 			EventPayload: payload,
 			EventCount:   eventCount,
 			WorkspaceId:  workspaceId,
 		})
+		if gr != nil {
+			gr.accepted(userEvent.index, userEvent.messageID, jobUUID)
+		}
+	}
+	if gr != nil {
+		gr.sort()
 	}
 	err = nil
 	jobData.jobs = jobs
@@ -640,6 +704,9 @@ func (gw *Handle) addToWebRequestQ(_ *http.ResponseWriter, req *http.Request, do
 		ipAddr:         ipAddr,
 		userIDHeader:   userIDHeader,
 	}
+	if gr, ok := req.Context().Value(gwtypes.CtxParamGranularResponse).(*granularResponse); ok {
+		webReq.granularResponse = gr
+	}
 	userWebRequestWorker.webRequestQ <- &webReq
 }
 
@@ -690,6 +757,7 @@ func (gw *Handle) internalBatchHandlerFunc() http.HandlerFunc {
 					continue
 				}
 				gw.sourcehandle.RecordEvent(jws.stat.WriteKey, jws.job.EventPayload)
+				liveevents.Default.Record(liveevents.GatewayIn, jws.stat.SourceID, "", jws.job.EventPayload)
 			}
 		} else {
 			stat.RequestEventsSucceeded(0)
@@ -745,6 +813,7 @@ func (gw *Handle) extractJobsFromInternalBatchPayload(reqType string, body []byt
 		TraceParent     string `json:"traceparent"`
 		DestinationID   string `json:"destination_id,omitempty"`
 		SourceCategory  string `json:"source_category"`
+		CorrelationID   string `json:"correlation_id"`
 	}
 
 	type singularEventBatch struct {
@@ -816,7 +885,9 @@ func (gw *Handle) extractJobsFromInternalBatchPayload(reqType string, body []byt
 			"workspaceId": msg.Properties.WorkspaceID,
 		}).Since(msg.Properties.ReceivedAt)
 
+		jobUUID := uuid.New()
 		jobsDBParams := params{
+			CorrelationID:   jobUUID.String(),
 			MessageID:       msg.Properties.MessageID,
 			SourceID:        msg.Properties.SourceID,
 			SourceJobRunID:  msg.Properties.SourceJobRunID,
@@ -867,7 +938,6 @@ func (gw *Handle) extractJobsFromInternalBatchPayload(reqType string, body []byt
 			stat.Report(gw.stats)
 			return nil, fmt.Errorf("marshalling event batch: %w", err)
 		}
-		jobUUID := uuid.New()
 		res = append(res, jobWithStat{
 			stat: stat,
 			job: &jobsdb.JobT{
@@ -0,0 +1,240 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/gateway/internal/backfill"
+	"github.com/rudderlabs/rudder-server/gateway/response"
+)
+
+const (
+	backfillStatusRunning   = "running"
+	backfillStatusSucceeded = "succeeded"
+	backfillStatusFailed    = "failed"
+)
+
+// backfillJobT tracks the progress of a backfill of historical events
+type backfillJobT struct {
+	ID       string           `json:"id"`
+	Request  backfill.Request `json:"request"`
+	Status   string           `json:"status"`
+	Result   backfill.Result  `json:"result"`
+	Rejected int              `json:"rejected"`
+	Error    string           `json:"error,omitempty"`
+
+	fileManager filemanager.FileManager // set for backfills reading from the object storage of a warehouse destination
+}
+
+// backfillT holds the state of the historical backfill feature, which is only initialised if enabled
+type backfillT struct {
+	importer      *backfill.Importer
+	stagingFolder string // folder of the staging files of warehouse destinations
+
+	ctx  context.Context // background context, backfills are stopped when it gets cancelled
+	wait sync.WaitGroup  // running backfills
+
+	jobsMu sync.RWMutex
+	jobs   map[string]*backfillJobT
+}
+
+// backfillHandler starts importing historical events from the object storage of the workspace of a write key, routing
+// them only to the selected destinations of its source. Events can also be replayed from the staging files of a
+// warehouse destination of the source, e.g. to bring the history of the source to a newly added destination.
+// The backfill runs in the background and its progress can be retrieved through [backfillStatusHandler].
+func (gw *Handle) backfillHandler(w http.ResponseWriter, r *http.Request) {
+	if gw.backfill == nil {
+		http.Error(w, "backfill is not enabled", http.StatusNotFound)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, response.RequestBodyReadFailed, http.StatusBadRequest)
+		return
+	}
+	var req backfill.Request
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, response.InvalidJSON, http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	gw.configSubscriberLock.RLock()
+	source, ok := gw.writeKeysSourceMap[req.WriteKey]
+	gw.configSubscriberLock.RUnlock()
+	if !ok {
+		http.Error(w, response.InvalidWriteKey, http.StatusBadRequest)
+		return
+	}
+	for _, destinationID := range req.DestinationIDs {
+		if !slices.ContainsFunc(source.Destinations, func(d backendconfig.DestinationT) bool { return d.ID == destinationID }) {
+			http.Error(w, fmt.Sprintf("destination %q is not connected to the source", destinationID), http.StatusBadRequest)
+			return
+		}
+	}
+	req.WorkspaceID = source.WorkspaceID
+
+	job := &backfillJobT{ID: uuid.NewString(), Request: req, Status: backfillStatusRunning}
+	if req.FromDestinationID != "" {
+		destination, ok := lo.Find(source.Destinations, func(d backendconfig.DestinationT) bool { return d.ID == req.FromDestinationID })
+		if !ok {
+			http.Error(w, fmt.Sprintf("destination %q is not connected to the source", req.FromDestinationID), http.StatusBadRequest)
+			return
+		}
+		if job.fileManager, err = backfill.StagingFileManager(destination); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if strings.Trim(req.Prefix, "/") == "" {
+			job.Request.Prefix = path.Join(gw.backfill.stagingFolder, source.ID)
+		}
+	}
+	gw.backfill.jobsMu.Lock()
+	gw.backfill.jobs[job.ID] = job
+	gw.backfill.jobsMu.Unlock()
+
+	gw.backfill.wait.Add(1)
+	go func() {
+		defer gw.backfill.wait.Done()
+		gw.runBackfill(gw.backfill.ctx, job, source.ID)
+	}()
+	gw.writeBackfill(w, http.StatusAccepted, job)
+}
+
+// backfillStatusHandler returns the progress of a backfill started through [backfillHandler]
+func (gw *Handle) backfillStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if gw.backfill == nil {
+		http.Error(w, "backfill is not enabled", http.StatusNotFound)
+		return
+	}
+	gw.backfill.jobsMu.RLock()
+	job, ok := gw.backfill.jobs[chi.URLParam(r, "id")]
+	var snapshot backfillJobT
+	if ok {
+		snapshot = *job
+	}
+	gw.backfill.jobsMu.RUnlock()
+	if !ok {
+		http.Error(w, "backfill not found", http.StatusNotFound)
+		return
+	}
+	gw.writeBackfill(w, http.StatusOK, &snapshot)
+}
+
+func (gw *Handle) writeBackfill(w http.ResponseWriter, status int, job *backfillJobT) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		http.Error(w, response.ErrorInMarshal, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func (gw *Handle) runBackfill(ctx context.Context, job *backfillJobT, sourceID string) {
+	log := gw.logger.Withn(
+		logger.NewStringField("backfillId", job.ID),
+		obskit.WorkspaceID(job.Request.WorkspaceID),
+		obskit.SourceID(sourceID),
+	)
+	log.Infon("Starting backfill", logger.NewStringField("prefix", job.Request.Prefix))
+	start := time.Now()
+	sink := func(ctx context.Context, events []json.RawMessage) error {
+		return gw.backfillEvents(ctx, job, sourceID, events)
+	}
+	var (
+		result backfill.Result
+		err    error
+	)
+	if job.fileManager != nil {
+		result, err = gw.backfill.importer.ImportFrom(ctx, job.fileManager, job.ID, job.Request, sink)
+	} else {
+		result, err = gw.backfill.importer.Import(ctx, job.ID, job.Request, sink)
+	}
+
+	gw.backfill.jobsMu.Lock()
+	job.Result = result
+	job.Status = backfillStatusSucceeded
+	if err != nil {
+		job.Status = backfillStatusFailed
+		job.Error = err.Error()
+	}
+	gw.backfill.jobsMu.Unlock()
+
+	gw.stats.NewTaggedStat("gateway.backfill_events", stats.CountType, stats.Tags{"workspaceId": job.Request.WorkspaceID, "sourceId": sourceID, "status": job.Status}).Count(result.Events)
+	if err != nil {
+		log.Errorn("Backfill failed", logger.NewIntField("events", int64(result.Events)), obskit.Error(err))
+		return
+	}
+	log.Infon("Backfill completed",
+		logger.NewIntField("files", int64(result.Files)),
+		logger.NewIntField("events", int64(result.Events)),
+		logger.NewIntField("skipped", int64(result.Skipped)),
+		logger.NewDurationField("duration", time.Since(start)),
+	)
+}
+
+// backfillEvents ingests a batch of historical events through the regular request pipeline, as a batch request which
+// bypasses the rate limit of live traffic and keeps the receivedAt of the events. Batches which are rejected by the
+// gateway (e.g. due to a disabled source) are counted and skipped.
+func (gw *Handle) backfillEvents(ctx context.Context, job *backfillJobT, sourceID string, events []json.RawMessage) error {
+	arctx := gw.authRequestContextForSourceID(sourceID)
+	if arctx == nil {
+		return fmt.Errorf("source %q not found", sourceID)
+	}
+	payload, err := json.Marshal(map[string][]json.RawMessage{"batch": events})
+	if err != nil {
+		return fmt.Errorf("marshalling batch: %w", err)
+	}
+	done := make(chan string, 1)
+	webReq := webRequestT{
+		done:           done,
+		reqType:        "batch",
+		requestPayload: payload,
+		authContext:    arctx,
+		backfillID:     job.ID,
+		destinationIDs: job.Request.DestinationIDs,
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case gw.findUserWebRequestWorker(uuid.New().String()).webRequestQ <- &webReq:
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case errorMessage := <-done:
+		if errorMessage != "" {
+			gw.backfill.jobsMu.Lock()
+			job.Rejected += len(events)
+			gw.backfill.jobsMu.Unlock()
+			gw.logger.Warnn("Backfilled events were rejected",
+				obskit.SourceID(sourceID),
+				logger.NewStringField("backfillId", job.ID),
+				logger.NewStringField("reason", errorMessage),
+			)
+		}
+		return nil
+	}
+}
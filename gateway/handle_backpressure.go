@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	kithttputil "github.com/rudderlabs/rudder-go-kit/httputil"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/gateway/response"
+)
+
+// monitorBackpressure periodically checks whether the gateway's jobsdb has exceeded the configured pending jobs or disk usage thresholds
+func (gw *Handle) monitorBackpressure(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(gw.conf.backpressure.checkInterval.Load()):
+			gw.checkBackpressure(ctx)
+		}
+	}
+}
+
+// checkBackpressure updates the saturation state of the gateway according to the current jobsdb statistics.
+// If statistics cannot be retrieved, the previous state is retained.
+func (gw *Handle) checkBackpressure(ctx context.Context) {
+	maxPendingJobs := gw.conf.backpressure.maxPendingJobs.Load()
+	maxSize := gw.conf.backpressure.maxSizeInMB.Load() * 1024 * 1024
+	if maxPendingJobs <= 0 && maxSize <= 0 {
+		gw.saturated.Store(false)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, gw.conf.backpressure.checkTimeout.Load())
+	defer cancel()
+	datasetStats, err := gw.jobsDB.GetDatasetStats(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			gw.logger.Warnn("Getting gateway jobsdb dataset stats for backpressure", obskit.Error(err))
+		}
+		return
+	}
+	gw.stats.NewStat("gateway.jobsdb_jobs", stats.GaugeType).Gauge(datasetStats.Jobs)
+	gw.stats.NewStat("gateway.jobsdb_pending_jobs", stats.GaugeType).Gauge(datasetStats.Pending)
+	gw.stats.NewStat("gateway.jobsdb_size_bytes", stats.GaugeType).Gauge(datasetStats.SizeBytes)
+
+	saturated := (maxPendingJobs > 0 && datasetStats.Pending >= maxPendingJobs) || (maxSize > 0 && datasetStats.SizeBytes >= maxSize)
+	if previous := gw.saturated.Swap(saturated); previous != saturated {
+		gw.logger.Warnn("Gateway jobsdb saturation changed",
+			logger.NewBoolField("saturated", saturated),
+			logger.NewIntField("pendingJobs", datasetStats.Pending),
+			logger.NewIntField("sizeBytes", datasetStats.SizeBytes),
+			logger.NewIntField("datasets", int64(datasetStats.Datasets)),
+		)
+	}
+	var saturatedValue int
+	if saturated {
+		saturatedValue = 1
+	}
+	gw.stats.NewStat("gateway.jobsdb_saturated", stats.GaugeType).Gauge(saturatedValue)
+}
+
+// Degraded returns true if the gateway is refusing new events since its jobsdb is saturated
+func (gw *Handle) Degraded() bool {
+	return gw.saturated.Load()
+}
+
+// backpressure middleware rejects requests with a 503 status code and a Retry-After header while the gateway's jobsdb is saturated,
+// so that clients back off instead of the gateway accepting writes until the node falls over
+func (gw *Handle) backpressure(delegate http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !gw.saturated.Load() {
+			delegate.ServeHTTP(w, r)
+			return
+		}
+		gw.stats.NewTaggedStat("gateway.backpressure_rejected_requests", stats.CountType, stats.Tags{"path": r.URL.Path}).Increment()
+		status := response.GetErrorStatusCode(response.GatewaySaturated)
+		responseBody := response.GetStatus(response.GatewaySaturated)
+		gw.logger.Debugw("response",
+			"ip", kithttputil.GetRequestIP(r),
+			"path", r.URL.Path,
+			"status", status,
+			"body", responseBody)
+		w.Header().Set("Retry-After", strconv.Itoa(int(gw.conf.backpressure.retryAfter.Load().Seconds())))
+		http.Error(w, responseBody, status)
+	})
+}
@@ -0,0 +1,99 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	mocksJobsDB "github.com/rudderlabs/rudder-server/mocks/jobsdb"
+)
+
+func TestBackpressure(t *testing.T) {
+	delegate := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	})
+
+	newGateway := func(t *testing.T, maxPendingJobs, maxSizeInMB int64) (*Handle, *mocksJobsDB.MockJobsDB) {
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+		mockJobsDB := mocksJobsDB.NewMockJobsDB(gomock.NewController(t))
+		gw := &Handle{
+			logger: logger.NOP,
+			stats:  statsStore,
+			jobsDB: mockJobsDB,
+		}
+		gw.conf.backpressure.maxPendingJobs = config.SingleValueLoader(maxPendingJobs)
+		gw.conf.backpressure.maxSizeInMB = config.SingleValueLoader(maxSizeInMB)
+		gw.conf.backpressure.checkTimeout = config.SingleValueLoader(time.Second)
+		gw.conf.backpressure.retryAfter = config.SingleValueLoader(30 * time.Second)
+		return gw, mockJobsDB
+	}
+
+	serve := func(gw *Handle) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		gw.backpressure(delegate).ServeHTTP(w, httptest.NewRequest("POST", "/v1/batch", nil))
+		return w
+	}
+
+	t.Run("disabled thresholds never query jobsdb", func(t *testing.T) {
+		gw, _ := newGateway(t, 0, 0)
+		gw.checkBackpressure(context.Background())
+		require.False(t, gw.Degraded())
+		w := serve(gw)
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("pending jobs threshold exceeded", func(t *testing.T) {
+		gw, mockJobsDB := newGateway(t, 100, 0)
+		mockJobsDB.EXPECT().GetDatasetStats(gomock.Any()).Return(jobsdb.DatasetStats{Datasets: 2, Jobs: 150, Pending: 100}, nil).Times(1)
+		gw.checkBackpressure(context.Background())
+		require.True(t, gw.Degraded())
+
+		w := serve(gw)
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		require.Equal(t, "30", w.Header().Get("Retry-After"))
+		require.Contains(t, w.Body.String(), "retry later")
+	})
+
+	t.Run("processed jobs don't count towards the pending jobs threshold", func(t *testing.T) {
+		gw, mockJobsDB := newGateway(t, 100, 0)
+		mockJobsDB.EXPECT().GetDatasetStats(gomock.Any()).Return(jobsdb.DatasetStats{Datasets: 2, Jobs: 1000, Pending: 10}, nil).Times(1)
+		gw.checkBackpressure(context.Background())
+		require.False(t, gw.Degraded())
+		require.Equal(t, http.StatusOK, serve(gw).Code)
+	})
+
+	t.Run("size threshold exceeded and recovered", func(t *testing.T) {
+		gw, mockJobsDB := newGateway(t, 0, 1)
+		mockJobsDB.EXPECT().GetDatasetStats(gomock.Any()).Return(jobsdb.DatasetStats{Datasets: 1, SizeBytes: 2 * 1024 * 1024}, nil).Times(1)
+		gw.checkBackpressure(context.Background())
+		require.True(t, gw.Degraded())
+
+		mockJobsDB.EXPECT().GetDatasetStats(gomock.Any()).Return(jobsdb.DatasetStats{Datasets: 1, SizeBytes: 1024}, nil).Times(1)
+		gw.checkBackpressure(context.Background())
+		require.False(t, gw.Degraded())
+		require.Equal(t, http.StatusOK, serve(gw).Code)
+	})
+
+	t.Run("stats error retains previous state", func(t *testing.T) {
+		gw, mockJobsDB := newGateway(t, 10, 0)
+		mockJobsDB.EXPECT().GetDatasetStats(gomock.Any()).Return(jobsdb.DatasetStats{Jobs: 20, Pending: 20}, nil).Times(1)
+		gw.checkBackpressure(context.Background())
+		require.True(t, gw.Degraded())
+
+		mockJobsDB.EXPECT().GetDatasetStats(gomock.Any()).Return(jobsdb.DatasetStats{}, errors.New("db error")).Times(1)
+		gw.checkBackpressure(context.Background())
+		require.True(t, gw.Degraded())
+	})
+}
@@ -94,6 +94,11 @@ func (gw *Handle) webRequestHandler(rh RequestHandler, w http.ResponseWriter, r
 			"sourceId":    arctx.SourceID,
 		}),
 	)
+	var gr *granularResponse
+	if reqType == "batch" && r.Header.Get(granularResponseHeader) == "true" {
+		gr = &granularResponse{}
+		ctx = context.WithValue(ctx, gwtypes.CtxParamGranularResponse, gr)
+	}
 	r = r.WithContext(ctx)
 
 	gw.logger.LogRequest(r)
@@ -119,7 +124,20 @@ func (gw *Handle) webRequestHandler(rh RequestHandler, w http.ResponseWriter, r
 		return
 	}
 	errorMessage = rh.ProcessRequest(&w, r, reqType, payload, arctx)
+	if errorMessage == "" {
+		gw.archiveRawRequest(r, reqType, payload, arctx)
+	}
 	gw.TrackRequestMetrics(errorMessage)
+	if gr != nil && (errorMessage == "" || errorMessage == response.EmptyBatchPayload && len(gr.Messages) > 0) {
+		// all messages were either accepted or rejected individually
+		status := http.StatusOK
+		if errorMessage != "" {
+			status = http.StatusBadRequest
+			errorMessage = ""
+		}
+		gw.writeGranularResponse(w, r, status, gr)
+		return
+	}
 	if errorMessage != "" {
 		return
 	}
@@ -133,6 +151,24 @@ func (gw *Handle) webRequestHandler(rh RequestHandler, w http.ResponseWriter, r
 	_, _ = w.Write([]byte(responseBody))
 }
 
+// writeGranularResponse writes the per-message results of a batch request as a json response
+func (gw *Handle) writeGranularResponse(w http.ResponseWriter, r *http.Request, status int, gr *granularResponse) {
+	responseBody, err := jsonfast.Marshal(gr)
+	if err != nil {
+		gw.logger.Errorw("marshalling granular response", "error", err)
+		http.Error(w, response.GetStatus(response.ErrorInMarshal), http.StatusInternalServerError)
+		return
+	}
+	gw.logger.Debugw("response",
+		"ip", kithttputil.GetRequestIP(r),
+		"path", r.URL.Path,
+		"status", status,
+		"body", string(responseBody))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(responseBody)
+}
+
 // callType middleware sets the call type in the request context
 func (gw *Handle) callType(callType string, delegate http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -3,6 +3,7 @@ package gateway
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/samber/lo"
 
@@ -214,11 +215,15 @@ func (gw *Handle) authRequestContextForSourceID(sourceID string) *gwtypes.AuthRe
 	return nil
 }
 
-// authRequestContextForWriteKey gets request context for a given writeKey. If the writeKey is invalid, returns nil.
+// authRequestContextForWriteKey gets request context for a given writeKey. If the writeKey is invalid or has been revoked, returns nil.
+// Any active write key of a source resolves to the same source, so that keys can be rotated without a hard cutover.
 func (gw *Handle) authRequestContextForWriteKey(writeKey string) *gwtypes.AuthRequestContext {
 	gw.configSubscriberLock.RLock()
 	defer gw.configSubscriberLock.RUnlock()
 	if s, ok := gw.writeKeysSourceMap[writeKey]; ok {
+		if len(s.WriteKeys) > 0 && !s.IsWriteKeyActive(writeKey, time.Now()) {
+			return nil
+		}
 		return sourceToRequestContext(s)
 	}
 	return nil
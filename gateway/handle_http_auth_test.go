@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/rudderlabs/rudder-go-kit/config"
 
@@ -126,6 +127,37 @@ func TestAuth(t *testing.T) {
 			require.Equal(t, "Invalid Write Key\n", string(body))
 		})
 
+		t.Run("rotated writeKeys", func(t *testing.T) {
+			source := backendconfig.SourceT{
+				Enabled:  true,
+				ID:       "456",
+				WriteKey: "primary",
+				WriteKeys: []backendconfig.WriteKeyT{
+					{Key: "secondary", CreatedAt: time.Now().Add(-time.Hour)},
+					{Key: "revoked", CreatedAt: time.Now().Add(-time.Hour), RevokedAt: time.Now().Add(-time.Minute)},
+				},
+			}
+			gw := newGateway(map[string]backendconfig.SourceT{
+				"primary":   source,
+				"secondary": source,
+				"revoked":   source,
+			}, nil)
+			for _, writeKey := range []string{"primary", "secondary"} {
+				var arctx *gwtypes.AuthRequestContext
+				w := httptest.NewRecorder()
+				gw.writeKeyAuth(func(w http.ResponseWriter, r *http.Request) {
+					arctx = r.Context().Value(gwtypes.CtxParamAuthRequestContext).(*gwtypes.AuthRequestContext)
+				}).ServeHTTP(w, newWriteKeyRequest(writeKey))
+				require.Equal(t, http.StatusOK, w.Code, "authentication should succeed for %q", writeKey)
+				require.Equal(t, "456", arctx.SourceID)
+				require.Equal(t, "primary", arctx.WriteKey, "request context should always carry the primary writeKey")
+			}
+
+			w := httptest.NewRecorder()
+			gw.writeKeyAuth(delegate).ServeHTTP(w, newWriteKeyRequest("revoked"))
+			require.Equal(t, http.StatusUnauthorized, w.Code, "authentication should not succeed for a revoked writeKey")
+		})
+
 		t.Run("disabled source", func(t *testing.T) {
 			writeKey := "123"
 			gw := newGateway(map[string]backendconfig.SourceT{
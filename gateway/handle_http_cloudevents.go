@@ -0,0 +1,50 @@
+package gateway
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/rudderlabs/rudder-server/gateway/internal/cloudevents"
+	gwstats "github.com/rudderlabs/rudder-server/gateway/internal/stats"
+	gwtypes "github.com/rudderlabs/rudder-server/gateway/internal/types"
+	"github.com/rudderlabs/rudder-server/gateway/response"
+)
+
+// cloudEventsHandler can handle CloudEvents sent using any of the content modes of the HTTP protocol binding.
+// Events are converted to a Rudder batch payload and processed as a regular batch request.
+func (gw *Handle) cloudEventsHandler() http.HandlerFunc {
+	return gw.callType("batch", gw.writeKeyAuth(gw.cloudEventsInterceptor(gw.webHandler())))
+}
+
+// cloudEventsInterceptor replaces the CloudEvents contained in the request's body with their Rudder batch payload equivalent before passing it to the next handler
+func (gw *Handle) cloudEventsInterceptor(delegate http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		arctx := r.Context().Value(gwtypes.CtxParamAuthRequestContext).(*gwtypes.AuthRequestContext)
+		payload, err := gw.getPayload(arctx, r, "cloudevents")
+		if err != nil {
+			gw.handleHttpError(w, r, err.Error())
+			return
+		}
+		payload, err = cloudevents.ToBatch(r.Header, payload)
+		if err != nil {
+			gw.logger.Debugw("invalid cloudevents request", "sourceID", arctx.SourceID, "error", err)
+			stat := gwstats.SourceStat{
+				Source:      arctx.SourceTag(),
+				WriteKey:    arctx.WriteKey,
+				ReqType:     "cloudevents",
+				SourceID:    arctx.SourceID,
+				WorkspaceID: arctx.WorkspaceID,
+				SourceType:  arctx.SourceCategory,
+			}
+			stat.RequestFailed("invalidCloudEvent")
+			stat.Report(gw.stats)
+			gw.handleHttpError(w, r, response.InvalidCloudEvent)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(payload))
+		r.ContentLength = int64(len(payload))
+		r.Header.Set("Content-Type", "application/json")
+		delegate(w, r)
+	}
+}
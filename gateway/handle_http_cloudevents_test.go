@@ -0,0 +1,75 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	gwtypes "github.com/rudderlabs/rudder-server/gateway/internal/types"
+)
+
+func TestCloudEventsInterceptor(t *testing.T) {
+	var delegatedBody string
+	delegate := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		delegatedBody = string(body)
+		_, _ = w.Write([]byte("OK"))
+	})
+
+	newGateway := func() *Handle {
+		return &Handle{
+			logger:           logger.NOP,
+			stats:            stats.NOP,
+			bodyReadTimeStat: stats.NOP.NewStat("gateway.http_body_read_time", stats.TimerType),
+		}
+	}
+	newRequest := func(body string, headers map[string]string) *http.Request {
+		r := httptest.NewRequest("POST", "/v1/cloudevents", strings.NewReader(body))
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		return r.WithContext(context.WithValue(r.Context(), gwtypes.CtxParamAuthRequestContext, &gwtypes.AuthRequestContext{SourceID: "source-1", WriteKey: "writeKey-1"}))
+	}
+
+	t.Run("binary cloudevent", func(t *testing.T) {
+		delegatedBody = ""
+		w := httptest.NewRecorder()
+		newGateway().cloudEventsInterceptor(delegate).ServeHTTP(w, newRequest(`{"amount":10}`, map[string]string{
+			"Content-Type":   "application/json",
+			"ce-specversion": "1.0",
+			"ce-id":          "event-1",
+			"ce-source":      "/orders",
+			"ce-type":        "order.created",
+			"ce-subject":     "user-1",
+		}))
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"batch":[{
+			"type":"track",
+			"event":"order.created",
+			"messageId":"event-1",
+			"userId":"user-1",
+			"properties":{"amount":10},
+			"context":{"cloudEvent":{"specversion":"1.0","id":"event-1","source":"/orders","type":"order.created","subject":"user-1","datacontenttype":"application/json"}}
+		}]}`, delegatedBody)
+	})
+
+	t.Run("invalid cloudevent", func(t *testing.T) {
+		delegatedBody = ""
+		w := httptest.NewRecorder()
+		newGateway().cloudEventsInterceptor(delegate).ServeHTTP(w, newRequest(`{"specversion":"1.0"}`, map[string]string{
+			"Content-Type": "application/cloudevents+json",
+		}))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Equal(t, "Invalid CloudEvent\n", w.Body.String())
+		require.Empty(t, delegatedBody, "delegate should not be called")
+	})
+}
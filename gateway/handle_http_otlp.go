@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"net/http"
+
+	kithttputil "github.com/rudderlabs/rudder-go-kit/httputil"
+
+	"github.com/rudderlabs/rudder-server/gateway/internal/otlp"
+	gwstats "github.com/rudderlabs/rudder-server/gateway/internal/stats"
+	gwtypes "github.com/rudderlabs/rudder-server/gateway/internal/types"
+	"github.com/rudderlabs/rudder-server/gateway/response"
+)
+
+// otlpLogsHandler can handle OTLP/HTTP logs export requests, ingesting every log record as a track event
+func (gw *Handle) otlpLogsHandler() http.HandlerFunc {
+	return gw.callType("batch", gw.writeKeyAuth(gw.otlpHandler(otlp.LogsToBatch, otlp.LogsResponse)))
+}
+
+// otlpTracesHandler can handle OTLP/HTTP traces export requests, ingesting every span as a track event
+func (gw *Handle) otlpTracesHandler() http.HandlerFunc {
+	return gw.callType("batch", gw.writeKeyAuth(gw.otlpHandler(otlp.TracesToBatch, otlp.TracesResponse)))
+}
+
+// otlpHandler converts an OTLP export request to a Rudder batch payload, processes it as a regular batch request
+// and replies with an OTLP export response, using the same encoding as the request. Gzip encoded requests are
+// uncompressed by the gateway middleware beforehand.
+func (gw *Handle) otlpHandler(
+	toBatch func(encoding string, body []byte, m otlp.Mapping) (otlp.Result, error),
+	toResponse func(encoding string, res otlp.Result) ([]byte, error),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		arctx := r.Context().Value(gwtypes.CtxParamAuthRequestContext).(*gwtypes.AuthRequestContext)
+		reqType := r.Context().Value(gwtypes.CtxParamCallType).(string)
+		failed := func(reason, errorMessage string) {
+			stat := gwstats.SourceStat{
+				Source:      arctx.SourceTag(),
+				WriteKey:    arctx.WriteKey,
+				ReqType:     "otlp",
+				SourceID:    arctx.SourceID,
+				WorkspaceID: arctx.WorkspaceID,
+				SourceType:  arctx.SourceCategory,
+			}
+			stat.RequestFailed(reason)
+			stat.Report(gw.stats)
+			gw.handleHttpError(w, r, errorMessage)
+		}
+
+		encoding, err := otlp.Encoding(r.Header.Get("Content-Type"))
+		if err != nil {
+			failed("unsupportedContentType", response.InvalidOTLPRequest)
+			return
+		}
+		body, err := gw.getPayload(arctx, r, "otlp")
+		if err != nil {
+			gw.handleHttpError(w, r, err.Error())
+			return
+		}
+		res, err := toBatch(encoding, body, otlp.Mapping{
+			EventNameAttribute:   gw.conf.otlp.eventNameAttribute.Load(),
+			UserIDAttribute:      gw.conf.otlp.userIDAttribute.Load(),
+			AnonymousIDAttribute: gw.conf.otlp.anonymousIDAttribute.Load(),
+			MessageIDAttribute:   gw.conf.otlp.messageIDAttribute.Load(),
+		})
+		if err != nil {
+			gw.logger.Debugw("invalid otlp request", "sourceID", arctx.SourceID, "error", err)
+			failed("invalidOTLPRequest", response.InvalidOTLPRequest)
+			return
+		}
+		if res.Payload != nil {
+			gw.archiveRawRequest(r, reqType, res.Payload, arctx)
+			errorMessage := gw.rrh.ProcessRequest(&w, r, reqType, res.Payload, arctx)
+			gw.TrackRequestMetrics(errorMessage)
+			if errorMessage != "" {
+				gw.handleHttpError(w, r, errorMessage)
+				return
+			}
+		}
+		responseBody, err := toResponse(encoding, res)
+		if err != nil {
+			gw.handleHttpError(w, r, response.ErrorInMarshal)
+			return
+		}
+		gw.logger.Debugw("response",
+			"ip", kithttputil.GetRequestIP(r),
+			"path", r.URL.Path,
+			"status", http.StatusOK,
+			"rejected", res.Rejected)
+		w.Header().Set("Content-Type", encoding)
+		_, _ = w.Write(responseBody)
+	}
+}
@@ -0,0 +1,104 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	"github.com/rudderlabs/rudder-server/gateway/internal/otlp"
+	gwtypes "github.com/rudderlabs/rudder-server/gateway/internal/types"
+	"github.com/rudderlabs/rudder-server/gateway/response"
+	"github.com/rudderlabs/rudder-server/middleware"
+)
+
+type recordingRequestHandler struct {
+	payloads     []string
+	errorMessage string
+}
+
+func (rh *recordingRequestHandler) ProcessRequest(_ *http.ResponseWriter, _ *http.Request, _ string, payload []byte, _ *gwtypes.AuthRequestContext) string {
+	rh.payloads = append(rh.payloads, string(payload))
+	return rh.errorMessage
+}
+
+func TestOTLPHandler(t *testing.T) {
+	newGateway := func(rh RequestHandler) *Handle {
+		gw := &Handle{
+			logger:           logger.NOP,
+			stats:            stats.NOP,
+			bodyReadTimeStat: stats.NOP.NewStat("gateway.http_body_read_time", stats.TimerType),
+			rrh:              rh,
+		}
+		gw.conf.otlp.eventNameAttribute = config.SingleValueLoader("event.name")
+		gw.conf.otlp.userIDAttribute = config.SingleValueLoader("enduser.id")
+		gw.conf.otlp.anonymousIDAttribute = config.SingleValueLoader("rudder.anonymous_id")
+		gw.conf.otlp.messageIDAttribute = config.SingleValueLoader("rudder.message_id")
+		return gw
+	}
+	newRequest := func(contentType, body string) *http.Request {
+		r := httptest.NewRequest("POST", "/v1/otlp/v1/logs", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		ctx := context.WithValue(r.Context(), gwtypes.CtxParamAuthRequestContext, &gwtypes.AuthRequestContext{SourceID: "source-1", WriteKey: "writeKey-1"})
+		ctx = context.WithValue(ctx, gwtypes.CtxParamCallType, "batch")
+		return r.WithContext(ctx)
+	}
+	logs := `{"resourceLogs":[{"scopeLogs":[{"logRecords":[
+		{"attributes":[{"key":"event.name","value":{"stringValue":"Signed Up"}},{"key":"enduser.id","value":{"stringValue":"user-1"}}]},
+		{"body":{"intValue":"1"}}
+	]}]}]}`
+
+	t.Run("json logs with partial success", func(t *testing.T) {
+		rh := &recordingRequestHandler{}
+		w := httptest.NewRecorder()
+		newGateway(rh).otlpHandler(otlp.LogsToBatch, otlp.LogsResponse).ServeHTTP(w, newRequest("application/json", logs))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		require.Contains(t, w.Body.String(), `"rejectedLogRecords":"1"`)
+		require.Len(t, rh.payloads, 1)
+		require.JSONEq(t, `{"batch":[{"type":"track","event":"Signed Up","userId":"user-1","properties":{},"context":{"otel":{}}}]}`, rh.payloads[0])
+	})
+
+	t.Run("gzip encoded json logs", func(t *testing.T) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write([]byte(logs))
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+
+		rh := &recordingRequestHandler{}
+		w := httptest.NewRecorder()
+		r := newRequest("application/json", buf.String())
+		r.Header.Set("Content-Encoding", "gzip")
+		middleware.UncompressMiddleware(newGateway(rh).otlpHandler(otlp.LogsToBatch, otlp.LogsResponse)).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Len(t, rh.payloads, 1, "the body should only be uncompressed once, by the middleware")
+		require.JSONEq(t, `{"batch":[{"type":"track","event":"Signed Up","userId":"user-1","properties":{},"context":{"otel":{}}}]}`, rh.payloads[0])
+	})
+
+	t.Run("processing failure", func(t *testing.T) {
+		rh := &recordingRequestHandler{errorMessage: response.TooManyRequests}
+		w := httptest.NewRecorder()
+		newGateway(rh).otlpHandler(otlp.LogsToBatch, otlp.LogsResponse).ServeHTTP(w, newRequest("application/json", logs))
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		rh := &recordingRequestHandler{}
+		w := httptest.NewRecorder()
+		newGateway(rh).otlpHandler(otlp.LogsToBatch, otlp.LogsResponse).ServeHTTP(w, newRequest("text/plain", logs))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Empty(t, rh.payloads)
+	})
+}
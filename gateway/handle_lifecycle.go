@@ -26,12 +26,16 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/stats"
 	"github.com/rudderlabs/rudder-server/app"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/gateway/internal/backfill"
+	"github.com/rudderlabs/rudder-server/gateway/internal/rawarchive"
 	"github.com/rudderlabs/rudder-server/gateway/throttler"
 	"github.com/rudderlabs/rudder-server/gateway/webhook"
+	"github.com/rudderlabs/rudder-server/internal/audit"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	"github.com/rudderlabs/rudder-server/middleware"
 	sourcedebugger "github.com/rudderlabs/rudder-server/services/debugger/source"
 	"github.com/rudderlabs/rudder-server/services/diagnostics"
+	"github.com/rudderlabs/rudder-server/services/fileuploader"
 	"github.com/rudderlabs/rudder-server/services/rsources"
 	rsources_http "github.com/rudderlabs/rudder-server/services/rsources/http"
 	"github.com/rudderlabs/rudder-server/services/transformer"
@@ -102,6 +106,17 @@ func (gw *Handle) Setup(
 	gw.conf.maxHeaderBytes = config.GetIntVar(524288, 1, "MaxHeaderBytes")
 	// if set to '0', it means disabled.
 	gw.conf.maxConcurrentRequests = config.GetIntVar(50000, 1, "Gateway.maxConcurrentRequests")
+	// Attributes of OTLP log records and spans from which event fields are populated
+	gw.conf.otlp.eventNameAttribute = config.GetReloadableStringVar("event.name", "Gateway.otlp.eventNameAttribute")
+	gw.conf.otlp.userIDAttribute = config.GetReloadableStringVar("enduser.id", "Gateway.otlp.userIdAttribute")
+	gw.conf.otlp.anonymousIDAttribute = config.GetReloadableStringVar("rudder.anonymous_id", "Gateway.otlp.anonymousIdAttribute")
+	gw.conf.otlp.messageIDAttribute = config.GetReloadableStringVar("rudder.message_id", "Gateway.otlp.messageIdAttribute")
+	// Reject requests with 503 when the gateway jobsdb exceeds the configured number of pending jobs or disk size ('0' means no limit)
+	gw.conf.backpressure.maxPendingJobs = config.GetReloadableInt64Var(0, 1, "Gateway.backpressure.maxPendingJobs")
+	gw.conf.backpressure.maxSizeInMB = config.GetReloadableInt64Var(0, 1, "Gateway.backpressure.maxSizeInMB")
+	gw.conf.backpressure.checkInterval = config.GetReloadableDurationVar(10, time.Second, "Gateway.backpressure.checkInterval")
+	gw.conf.backpressure.checkTimeout = config.GetReloadableDurationVar(5, time.Second, "Gateway.backpressure.checkTimeout")
+	gw.conf.backpressure.retryAfter = config.GetReloadableDurationVar(30, time.Second, "Gateway.backpressure.retryAfter")
 
 	// Registering stats
 	gw.batchSizeStat = gw.stats.NewStat("gateway.batch_size", stats.HistogramType)
@@ -170,6 +185,40 @@ func (gw *Handle) Setup(
 		gw.collectMetrics(ctx)
 		return nil
 	}))
+	g.Go(crash.Wrapper(func() error {
+		gw.monitorBackpressure(ctx)
+		return nil
+	}))
+	var storageProvider fileuploader.Provider
+	if config.GetBoolVar(false, "Gateway.rawArchive.enabled") || config.GetBoolVar(false, "Gateway.backfill.enabled") {
+		storageProvider = fileuploader.NewProvider(ctx, gw.backendConfig)
+	}
+	// Archive raw request bodies to object storage, so that they can be replayed later on
+	if config.GetBoolVar(false, "Gateway.rawArchive.enabled") {
+		gw.rawArchive = &rawArchiveT{
+			archiver: rawarchive.NewArchiver(config, storageProvider, gw.logger, gw.stats),
+			replayer: rawarchive.NewReplayer(config, storageProvider, gw.logger),
+			ctx:      ctx,
+			replays:  make(map[string]*rawReplayT),
+		}
+		g.Go(crash.Wrapper(func() error {
+			gw.rawArchive.archiver.Run(ctx)
+			return nil
+		}))
+	}
+	// Import historical events from object storage
+	if config.GetBoolVar(false, "Gateway.backfill.enabled") {
+		importer, err := backfill.NewImporter(config, storageProvider, gw.logger)
+		if err != nil {
+			return fmt.Errorf("creating backfill importer: %w", err)
+		}
+		gw.backfill = &backfillT{
+			importer:      importer,
+			stagingFolder: config.GetString("WAREHOUSE_STAGING_BUCKET_FOLDER_NAME", "rudder-warehouse-staging-logs"),
+			ctx:           ctx,
+			jobs:          make(map[string]*backfillJobT),
+		}
+	}
 	return nil
 }
 
@@ -221,9 +270,12 @@ func (gw *Handle) backendConfigSubscriber(ctx context.Context) {
 			sourceIDSourceMap  = map[string]backendconfig.SourceT{}
 		)
 		configData := data.Data.(map[string]backendconfig.ConfigT)
+		now := time.Now()
 		for _, wsConfig := range configData {
 			for _, source := range wsConfig.Sources {
-				writeKeysSourceMap[source.WriteKey] = source
+				for _, writeKey := range source.ActiveWriteKeys(now) {
+					writeKeysSourceMap[writeKey] = source
+				}
 				sourceIDSourceMap[source.ID] = source
 				if source.Enabled && source.SourceDefinition.Category == "webhook" {
 					gw.webhook.Register(source.SourceDefinition.Name)
@@ -402,20 +454,28 @@ func (gw *Handle) StartWebHandler(ctx context.Context) error {
 		middleware.LimitConcurrentRequests(gw.conf.maxConcurrentRequests),
 		middleware.UncompressMiddleware,
 	)
+	adminAuth := middleware.AdminAuth(gw.config)
 	srvMux.Route("/internal", func(r chi.Router) {
-		r.Post("/v1/extract", gw.webExtractHandler())
-		r.Post("/v1/retl", gw.webRetlHandler())
+		r.Group(func(r chi.Router) {
+			r.Use(gw.backpressure)
+			r.Post("/v1/extract", gw.webExtractHandler())
+			r.Post("/v1/retl", gw.webRetlHandler())
+			r.Post("/v1/audiencelist", gw.webAudienceListHandler())
+			r.Post("/v1/replay", gw.webReplayHandler())
+			r.Post("/v1/batch", gw.internalBatchHandler())
+			r.With(adminAuth).Post("/v1/raw-replay", gw.rawReplayHandler)
+			r.With(adminAuth).Post("/v1/backfill", gw.backfillHandler)
+		})
+		r.With(adminAuth).Get("/v1/raw-replay/{id}", gw.rawReplayStatusHandler)
+		r.With(adminAuth).Get("/v1/backfill/{id}", gw.backfillStatusHandler)
 		r.Get("/v1/warehouse/fetch-tables", gw.whProxy.ServeHTTP)
-		r.Post("/v1/audiencelist", gw.webAudienceListHandler())
-		r.Post("/v1/replay", gw.webReplayHandler())
-		r.Post("/v1/batch", gw.internalBatchHandler())
 
 		// TODO: delete this handler once we are ready to remove support for the v1 api
 		r.Mount("/v1/job-status", withContentType("application/json; charset=utf-8", rsourcesHandlerV1.ServeHTTP))
 
 		r.Mount("/v2/job-status", withContentType("application/json; charset=utf-8", rsourcesHandlerV2.ServeHTTP))
 		for path, handler := range gw.internalHttpHandlers {
-			r.Mount(path, withContentType("application/json; charset=utf-8", handler.ServeHTTP))
+			r.Mount(path, withContentType("application/json; charset=utf-8", audit.Default.Middleware(handler).ServeHTTP))
 		}
 	})
 
@@ -423,20 +483,26 @@ func (gw *Handle) StartWebHandler(ctx context.Context) error {
 	srvMux.Mount("/v1/job-status", withContentType("application/json; charset=utf-8", rsourcesHandlerV1.ServeHTTP))
 
 	srvMux.Route("/v1", func(r chi.Router) {
-		r.Post("/alias", gw.webAliasHandler())
-		r.Post("/audiencelist", gw.webAudienceListHandler())
-		r.Post("/batch", gw.webBatchHandler())
-		r.Post("/group", gw.webGroupHandler())
-		r.Post("/identify", gw.webIdentifyHandler())
-		r.Post("/merge", gw.webMergeHandler())
-		r.Post("/page", gw.webPageHandler())
-		r.Post("/screen", gw.webScreenHandler())
-		r.Post("/track", gw.webTrackHandler())
-
-		r.Post("/import", gw.webImportHandler())
-		r.Post("/webhook", gw.webhookHandler())
-
-		r.Get("/webhook", gw.webhookHandler())
+		r.Group(func(r chi.Router) {
+			r.Use(gw.backpressure)
+			r.Post("/alias", gw.webAliasHandler())
+			r.Post("/audiencelist", gw.webAudienceListHandler())
+			r.Post("/batch", gw.webBatchHandler())
+			r.Post("/cloudevents", gw.cloudEventsHandler())
+			r.Post("/otlp/v1/logs", gw.otlpLogsHandler())
+			r.Post("/otlp/v1/traces", gw.otlpTracesHandler())
+			r.Post("/group", gw.webGroupHandler())
+			r.Post("/identify", gw.webIdentifyHandler())
+			r.Post("/merge", gw.webMergeHandler())
+			r.Post("/page", gw.webPageHandler())
+			r.Post("/screen", gw.webScreenHandler())
+			r.Post("/track", gw.webTrackHandler())
+
+			r.Post("/import", gw.webImportHandler())
+			r.Post("/webhook", gw.webhookHandler())
+
+			r.Get("/webhook", gw.webhookHandler())
+		})
 
 		r.Route("/warehouse", func(r chi.Router) {
 			r.Post("/pending-events", gw.whProxy.ServeHTTP)
@@ -449,8 +515,8 @@ func (gw *Handle) StartWebHandler(ctx context.Context) error {
 		})
 	})
 
-	srvMux.Get("/health", withContentType("application/json; charset=utf-8", app.LivenessHandler(gw.jobsDB)))
-	srvMux.Get("/", withContentType("application/json; charset=utf-8", app.LivenessHandler(gw.jobsDB)))
+	srvMux.Get("/health", withContentType("application/json; charset=utf-8", app.DegradedLivenessHandler(gw.jobsDB, gw.Degraded)))
+	srvMux.Get("/", withContentType("application/json; charset=utf-8", app.DegradedLivenessHandler(gw.jobsDB, gw.Degraded)))
 	srvMux.Get("/docs",
 		func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -459,10 +525,11 @@ func (gw *Handle) StartWebHandler(ctx context.Context) error {
 	)
 
 	srvMux.Route("/pixel/v1", func(r chi.Router) {
+		r.Use(gw.backpressure)
 		r.Get("/track", gw.pixelTrackHandler())
 		r.Get("/page", gw.pixelPageHandler())
 	})
-	srvMux.Post("/beacon/v1/batch", gw.beaconBatchHandler())
+	srvMux.With(gw.backpressure).Post("/beacon/v1/batch", gw.beaconBatchHandler())
 	srvMux.Get("/version", withContentType("application/json; charset=utf-8", gw.versionHandler))
 	srvMux.Get("/robots.txt", gw.robotsHandler)
 
@@ -498,6 +565,12 @@ func (gw *Handle) Shutdown() error {
 	}
 
 	gw.inFlightRequests.Wait()
+	if gw.rawArchive != nil {
+		gw.rawArchive.wait.Wait()
+	}
+	if gw.backfill != nil {
+		gw.backfill.wait.Wait()
+	}
 
 	// UserWebRequestWorkers
 	for _, worker := range gw.userWebRequestWorkers {
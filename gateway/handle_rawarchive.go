@@ -0,0 +1,216 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+
+	kithttputil "github.com/rudderlabs/rudder-go-kit/httputil"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/gateway/internal/rawarchive"
+	gwtypes "github.com/rudderlabs/rudder-server/gateway/internal/types"
+	"github.com/rudderlabs/rudder-server/gateway/response"
+)
+
+const (
+	rawReplayStatusRunning   = "running"
+	rawReplayStatusSucceeded = "succeeded"
+	rawReplayStatusFailed    = "failed"
+)
+
+// rawReplayT tracks the progress of a replay of archived raw requests
+type rawReplayT struct {
+	ID      string                   `json:"id"`
+	Request rawarchive.ReplayRequest `json:"request"`
+	Status  string                   `json:"status"`
+	Result  rawarchive.ReplayResult  `json:"result"`
+	Error   string                   `json:"error,omitempty"`
+}
+
+// rawArchiveT holds the state of the raw request archival and replay feature, which is only initialised if enabled
+type rawArchiveT struct {
+	archiver *rawarchive.Archiver
+	replayer *rawarchive.Replayer
+
+	ctx  context.Context // background context, replays are stopped when it gets cancelled
+	wait sync.WaitGroup  // running replays
+
+	replaysMu sync.RWMutex
+	replays   map[string]*rawReplayT
+}
+
+// archiveRawRequest enqueues the raw body of a request accepted by the gateway for archival, if raw request archival is
+// enabled. Replayed requests are never archived again.
+func (gw *Handle) archiveRawRequest(r *http.Request, reqType string, payload []byte, arctx *gwtypes.AuthRequestContext) {
+	if gw.rawArchive == nil || reqType == "replay" || arctx.ReplaySource || arctx.SourceID == "" || !gjson.ValidBytes(payload) {
+		return
+	}
+	gw.rawArchive.archiver.Archive(rawarchive.Record{
+		ReceivedAt:  gw.now(),
+		WorkspaceID: arctx.WorkspaceID,
+		SourceID:    arctx.SourceID,
+		RequestType: reqType,
+		IP:          kithttputil.GetRequestIP(r),
+		AnonymousID: r.Header.Get("AnonymousId"),
+		Body:        payload,
+	})
+}
+
+// rawReplayHandler starts replaying the archived raw requests of a source for the requested time range.
+// The replay runs in the background and its progress can be retrieved through [rawReplayStatusHandler].
+func (gw *Handle) rawReplayHandler(w http.ResponseWriter, r *http.Request) {
+	if gw.rawArchive == nil {
+		http.Error(w, "raw request archival is not enabled", http.StatusNotFound)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, response.RequestBodyReadFailed, http.StatusBadRequest)
+		return
+	}
+	var req rawarchive.ReplayRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, response.InvalidJSON, http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	gw.configSubscriberLock.RLock()
+	source, ok := gw.sourceIDSourceMap[req.SourceID]
+	gw.configSubscriberLock.RUnlock()
+	if !ok {
+		http.Error(w, response.InvalidSourceID, http.StatusBadRequest)
+		return
+	}
+	req.WorkspaceID = source.WorkspaceID
+
+	replay := &rawReplayT{ID: uuid.NewString(), Request: req, Status: rawReplayStatusRunning}
+	gw.rawArchive.replaysMu.Lock()
+	gw.rawArchive.replays[replay.ID] = replay
+	gw.rawArchive.replaysMu.Unlock()
+
+	gw.rawArchive.wait.Add(1)
+	go func() {
+		defer gw.rawArchive.wait.Done()
+		gw.runRawReplay(gw.rawArchive.ctx, replay)
+	}()
+	gw.writeRawReplay(w, http.StatusAccepted, replay)
+}
+
+// rawReplayStatusHandler returns the progress of a replay started through [rawReplayHandler]
+func (gw *Handle) rawReplayStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if gw.rawArchive == nil {
+		http.Error(w, "raw request archival is not enabled", http.StatusNotFound)
+		return
+	}
+	gw.rawArchive.replaysMu.RLock()
+	replay, ok := gw.rawArchive.replays[chi.URLParam(r, "id")]
+	var snapshot rawReplayT
+	if ok {
+		snapshot = *replay
+	}
+	gw.rawArchive.replaysMu.RUnlock()
+	if !ok {
+		http.Error(w, "replay not found", http.StatusNotFound)
+		return
+	}
+	gw.writeRawReplay(w, http.StatusOK, &snapshot)
+}
+
+func (gw *Handle) writeRawReplay(w http.ResponseWriter, status int, replay *rawReplayT) {
+	body, err := json.Marshal(replay)
+	if err != nil {
+		http.Error(w, response.ErrorInMarshal, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func (gw *Handle) runRawReplay(ctx context.Context, replay *rawReplayT) {
+	log := gw.logger.Withn(
+		logger.NewStringField("replayId", replay.ID),
+		obskit.WorkspaceID(replay.Request.WorkspaceID),
+	)
+	log.Infon("Starting replay of archived raw requests",
+		logger.NewTimeField("start", replay.Request.Start),
+		logger.NewTimeField("end", replay.Request.End),
+	)
+	start := time.Now()
+	result, err := gw.rawArchive.replayer.Replay(ctx, replay.Request, gw.replayRawRequest)
+
+	gw.rawArchive.replaysMu.Lock()
+	replay.Result = result
+	replay.Status = rawReplayStatusSucceeded
+	if err != nil {
+		replay.Status = rawReplayStatusFailed
+		replay.Error = err.Error()
+	}
+	gw.rawArchive.replaysMu.Unlock()
+
+	gw.stats.NewTaggedStat("gateway.raw_replay_requests", stats.CountType, stats.Tags{"workspaceId": replay.Request.WorkspaceID, "status": replay.Status}).Count(result.Requests)
+	if err != nil {
+		log.Errorn("Replay of archived raw requests failed", logger.NewIntField("requests", int64(result.Requests)), obskit.Error(err))
+		return
+	}
+	log.Infon("Replay of archived raw requests completed",
+		logger.NewIntField("files", int64(result.Files)),
+		logger.NewIntField("requests", int64(result.Requests)),
+		logger.NewDurationField("duration", time.Since(start)),
+	)
+}
+
+// replayRawRequest re-ingests an archived raw request through the regular request pipeline, so that it gets stored in the gateway jobsdb
+// and processed again by the processor. Events without a receivedAt keep the time the original request was received.
+// Requests which are rejected by the gateway (e.g. due to a disabled source) are skipped.
+func (gw *Handle) replayRawRequest(ctx context.Context, record rawarchive.Record) error {
+	arctx := gw.authRequestContextForSourceID(record.SourceID)
+	if arctx == nil {
+		return fmt.Errorf("source %q not found", record.SourceID)
+	}
+	workerKey := record.AnonymousID
+	if workerKey == "" {
+		workerKey = uuid.New().String()
+	}
+	done := make(chan string, 1)
+	webReq := webRequestT{
+		done:           done,
+		reqType:        record.RequestType,
+		requestPayload: record.Body,
+		authContext:    arctx,
+		ipAddr:         record.IP,
+		userIDHeader:   record.AnonymousID,
+		receivedAt:     record.ReceivedAt,
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case gw.findUserWebRequestWorker(workerKey).webRequestQ <- &webReq:
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case errorMessage := <-done:
+		if errorMessage != "" {
+			gw.logger.Warnn("Archived raw request was rejected during replay",
+				obskit.SourceID(record.SourceID),
+				logger.NewStringField("reason", errorMessage),
+			)
+		}
+		return nil
+	}
+}
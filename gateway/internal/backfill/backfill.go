@@ -0,0 +1,430 @@
+// Package backfill imports historical events from files in the object storage of a workspace, so that they are ingested
+// with their original timestamps and routed only to the selected destinations of their source.
+//
+// Files are read from the following object key prefix of the storage configured for the workspace:
+//
+//	<storage prefix>/<request prefix>
+//
+// Every file under the prefix is imported, decompressing the ones with a .gz extension. Depending on the format of the
+// request, files contain either one json encoded event per line (ndjson), or one event per row with a header naming the
+// field of every column, using dots for nested fields, e.g. properties.revenue (csv). Values of csv columns which are
+// valid json literals, e.g. numbers or booleans, are imported as such, empty values are left out.
+//
+// Files can also be read from the object storage of a warehouse destination of the source, so that the history which
+// was already synced to the warehouse can be replayed to destinations which were added later on (warehouse-staging).
+// Events are then regenerated from the rows of the staging files, see [stagingEvent].
+//
+// Events without a type or without an originalTimestamp or timestamp are skipped, since they cannot be backfilled, as
+// well as events which happened outside the time range of the request, if any.
+// The events which are imported are tagged with the id of the backfill under context.backfill.id.
+package backfill
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/throttling"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/services/fileuploader"
+)
+
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+	// FormatWarehouseStaging is the format of the staging files of warehouse destinations
+	FormatWarehouseStaging = "warehouse-staging"
+)
+
+// Request selects the files to import for the source of a write key, and the destinations their events are routed to
+type Request struct {
+	WorkspaceID    string   `json:"workspaceId"`
+	WriteKey       string   `json:"writeKey"`
+	Prefix         string   `json:"prefix"`
+	Format         string   `json:"format"`
+	DestinationIDs []string `json:"destinationIds"`
+	// FromDestinationID is the warehouse destination whose object storage the files are read from, instead of the
+	// object storage of the workspace. The prefix is optional then, defaulting to the staging folder of the source.
+	FromDestinationID string `json:"fromDestinationId,omitempty"`
+	// From and To restrict the events to the ones which happened in [From, To)
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+}
+
+// Validate returns an error if the backfill request is incomplete
+func (r Request) Validate() error {
+	if r.WriteKey == "" {
+		return errors.New("writeKey is required")
+	}
+	if strings.Trim(r.Prefix, "/") == "" && r.Format != FormatWarehouseStaging {
+		return errors.New("prefix is required")
+	}
+	if !slices.Contains([]string{FormatNDJSON, FormatCSV, FormatWarehouseStaging}, r.Format) {
+		return fmt.Errorf("format must be one of %s, %s, %s", FormatNDJSON, FormatCSV, FormatWarehouseStaging)
+	}
+	if len(r.DestinationIDs) == 0 {
+		return errors.New("destinationIds are required")
+	}
+	if (r.Format == FormatWarehouseStaging) != (r.FromDestinationID != "") {
+		return fmt.Errorf("fromDestinationId is required for, and only supported by, format %s", FormatWarehouseStaging)
+	}
+	if r.FromDestinationID != "" && slices.Contains(r.DestinationIDs, r.FromDestinationID) {
+		return errors.New("fromDestinationId cannot be one of destinationIds")
+	}
+	if r.From != nil && r.To != nil && !r.From.Before(*r.To) {
+		return errors.New("from must be before to")
+	}
+	return nil
+}
+
+// Result summarises a backfill
+type Result struct {
+	Files   int `json:"files"`
+	Events  int `json:"events"`
+	Skipped int `json:"skipped"`
+}
+
+// Sink receives the events of a backfill in batches. Returning an error aborts the backfill.
+type Sink func(ctx context.Context, events []json.RawMessage) error
+
+type limiter interface {
+	AllowAfter(ctx context.Context, cost, rate, window int64, key string) (bool, time.Duration, func(context.Context) error, error)
+}
+
+// Importer reads historical events from object storage, throttling them separately from live traffic
+type Importer struct {
+	storage fileuploader.Provider
+	limiter limiter
+	log     logger.Logger
+
+	conf struct {
+		listMaxItems    int64
+		batchSize       config.ValueLoader[int]
+		eventsPerSecond config.ValueLoader[int64]
+		maxLineSize     int
+	}
+}
+
+// NewImporter creates a new importer for the files in the object storage of the workspaces
+func NewImporter(conf *config.Config, storage fileuploader.Provider, log logger.Logger) (*Importer, error) {
+	l, err := throttling.New(throttling.WithInMemoryGCRA(0))
+	if err != nil {
+		return nil, fmt.Errorf("creating limiter: %w", err)
+	}
+	i := &Importer{
+		storage: storage,
+		limiter: l,
+		log:     log.Child("backfill"),
+	}
+	i.conf.listMaxItems = conf.GetInt64Var(1000, 1, "Gateway.backfill.listMaxItems")
+	i.conf.batchSize = conf.GetReloadableIntVar(100, 1, "Gateway.backfill.batchSize")
+	// shared by all the backfills of a workspace, independently of the rate limit of live traffic
+	i.conf.eventsPerSecond = conf.GetReloadableInt64Var(1000, 1, "Gateway.backfill.eventsPerSecond")
+	i.conf.maxLineSize = conf.GetIntVar(4, 1024*1024, "Gateway.backfill.maxLineSize")
+	return i, nil
+}
+
+// Import sends the events of every file matching the backfill request to the sink, tagged with the backfill id
+func (i *Importer) Import(ctx context.Context, id string, req Request, sink Sink) (Result, error) {
+	if err := req.Validate(); err != nil {
+		return Result{}, err
+	}
+	fm, err := i.storage.GetFileManager(ctx, req.WorkspaceID)
+	if err != nil {
+		return Result{}, fmt.Errorf("no file manager found: %w", err)
+	}
+	return i.ImportFrom(ctx, fm, id, req, sink)
+}
+
+// ImportFrom is like [Importer.Import], reading the files from the object storage of the given file manager, e.g. the
+// one returned by [StagingFileManager] for the warehouse destination of the request
+func (i *Importer) ImportFrom(ctx context.Context, fm filemanager.FileManager, id string, req Request, sink Sink) (Result, error) {
+	if err := req.Validate(); err != nil {
+		return Result{}, err
+	}
+	b := &batcher{
+		importer:    i,
+		sink:        sink,
+		workspaceID: req.WorkspaceID,
+		backfillID:  id,
+		from:        req.From,
+		to:          req.To,
+	}
+	prefix := path.Join(fm.Prefix(), strings.Trim(req.Prefix, "/"))
+	iter := filemanager.IterateFilesWithPrefix(ctx, prefix, "", i.conf.listMaxItems, fm)
+	for iter.Next() {
+		key := iter.Get().Key
+		if strings.HasSuffix(key, "/") {
+			continue
+		}
+		if err := i.importFile(ctx, fm, key, req.Format, b); err != nil {
+			return b.result, fmt.Errorf("importing %q: %w", key, err)
+		}
+		b.result.Files++
+	}
+	if err := iter.Err(); err != nil {
+		return b.result, fmt.Errorf("listing files with prefix %q: %w", prefix, err)
+	}
+	if err := b.flush(ctx); err != nil {
+		return b.result, err
+	}
+	return b.result, nil
+}
+
+func (i *Importer) importFile(ctx context.Context, fm filemanager.FileManager, key, format string, b *batcher) error {
+	file, err := os.CreateTemp("", "rudder-backfill-*")
+	if err != nil {
+		return fmt.Errorf("creating tmp file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+	if err := fm.Download(ctx, file, key); err != nil {
+		return fmt.Errorf("downloading file: %w", err)
+	}
+	// some file managers replace the file instead of writing to it, so it needs to be reopened
+	rawFile, err := os.Open(file.Name())
+	if err != nil {
+		return fmt.Errorf("opening downloaded file: %w", err)
+	}
+	defer func() { _ = rawFile.Close() }()
+
+	var reader io.Reader = rawFile
+	if strings.HasSuffix(key, ".gz") {
+		gzipReader, err := gzip.NewReader(rawFile)
+		if err != nil {
+			return fmt.Errorf("creating gzip reader: %w", err)
+		}
+		defer func() { _ = gzipReader.Close() }()
+		reader = gzipReader
+	}
+
+	switch format {
+	case FormatCSV:
+		return i.importCSV(ctx, reader, b)
+	case FormatWarehouseStaging:
+		return i.importStaging(ctx, reader, b)
+	default:
+		return i.importNDJSON(ctx, reader, b)
+	}
+}
+
+func (i *Importer) importNDJSON(ctx context.Context, reader io.Reader, b *batcher) error {
+	return i.scanLines(reader, func(line []byte) error {
+		return b.add(ctx, slices.Clone(line))
+	})
+}
+
+func (i *Importer) importStaging(ctx context.Context, reader io.Reader, b *batcher) error {
+	return i.scanLines(reader, func(line []byte) error {
+		event, err := stagingEvent(line)
+		if errors.Is(err, errSkippedRow) {
+			return nil
+		}
+		if err != nil {
+			i.log.Warnn("Skipping invalid staging row", obskit.Error(err))
+			b.result.Skipped++
+			return nil
+		}
+		return b.add(ctx, event)
+	})
+}
+
+// scanLines calls fn for every non-blank line of the reader
+func (i *Importer) scanLines(reader io.Reader, fn func(line []byte) error) error {
+	sc := bufio.NewScanner(reader)
+	sc.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), i.conf.maxLineSize)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("reading file: %w", err)
+	}
+	return nil
+}
+
+func (i *Importer) importCSV(ctx context.Context, reader io.Reader, b *batcher) error {
+	r := csv.NewReader(reader)
+	r.ReuseRecord = true
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading header: %w", err)
+	}
+	fields := slices.Clone(header)
+
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading row: %w", err)
+		}
+		event, err := csvEvent(fields, row)
+		if err != nil {
+			i.log.Warnn("Skipping invalid csv row", obskit.Error(err))
+			b.result.Skipped++
+			continue
+		}
+		if err := b.add(ctx, event); err != nil {
+			return err
+		}
+	}
+}
+
+// csvEvent returns the json encoded event of a csv row
+func csvEvent(fields, row []string) ([]byte, error) {
+	event := []byte(`{}`)
+	for idx, value := range row {
+		if value == "" || idx >= len(fields) || fields[idx] == "" {
+			continue
+		}
+		var err error
+		if gjson.Valid(value) && !strings.HasPrefix(value, `"`) {
+			event, err = sjson.SetRawBytes(event, fields[idx], []byte(value))
+		} else {
+			event, err = sjson.SetBytes(event, fields[idx], value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("setting %q: %w", fields[idx], err)
+		}
+	}
+	return event, nil
+}
+
+// prepareEvent tags the event with the backfill id and makes sure that it keeps its original timestamps. Events
+// without a receivedAt are considered received when they happened.
+func prepareEvent(event []byte, backfillID string) ([]byte, error) {
+	if !gjson.ValidBytes(event) || !gjson.ParseBytes(event).IsObject() {
+		return nil, errors.New("event is not a json object")
+	}
+	if gjson.GetBytes(event, "type").String() == "" {
+		return nil, errors.New("event has no type")
+	}
+	timestamp := gjson.GetBytes(event, "timestamp").String()
+	if timestamp == "" {
+		timestamp = gjson.GetBytes(event, "originalTimestamp").String()
+	}
+	if timestamp == "" {
+		return nil, errors.New("event has neither a timestamp nor an originalTimestamp")
+	}
+
+	var err error
+	if !gjson.GetBytes(event, "timestamp").Exists() {
+		if event, err = sjson.SetBytes(event, "timestamp", timestamp); err != nil {
+			return nil, err
+		}
+	}
+	if !gjson.GetBytes(event, "receivedAt").Exists() {
+		if event, err = sjson.SetBytes(event, "receivedAt", timestamp); err != nil {
+			return nil, err
+		}
+	}
+	return sjson.SetBytes(event, "context.backfill.id", backfillID)
+}
+
+// batcher sends the events of a backfill to the sink in throttled batches
+type batcher struct {
+	importer    *Importer
+	sink        Sink
+	workspaceID string
+	backfillID  string
+	from, to    *time.Time
+
+	events []json.RawMessage
+	result Result
+}
+
+func (b *batcher) add(ctx context.Context, event []byte) error {
+	event, err := prepareEvent(event, b.backfillID)
+	if err != nil {
+		b.importer.log.Debugn("Skipping event", obskit.Error(err))
+		b.result.Skipped++
+		return nil
+	}
+	if !b.inRange(event) {
+		b.result.Skipped++
+		return nil
+	}
+	b.events = append(b.events, event)
+	if int64(len(b.events)) >= min(int64(b.importer.conf.batchSize.Load()), b.importer.conf.eventsPerSecond.Load()) {
+		return b.flush(ctx)
+	}
+	return nil
+}
+
+// inRange returns true if the event happened within the time range of the backfill
+func (b *batcher) inRange(event []byte) bool {
+	if b.from == nil && b.to == nil {
+		return true
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, gjson.GetBytes(event, "timestamp").String())
+	if err != nil {
+		return false
+	}
+	return (b.from == nil || !timestamp.Before(*b.from)) && (b.to == nil || timestamp.Before(*b.to))
+}
+
+func (b *batcher) flush(ctx context.Context) error {
+	if len(b.events) == 0 {
+		return nil
+	}
+	if err := b.throttle(ctx, int64(len(b.events))); err != nil {
+		return err
+	}
+	if err := b.sink(ctx, b.events); err != nil {
+		return err
+	}
+	b.result.Events += len(b.events)
+	b.events = nil
+	return nil
+}
+
+// throttle waits until the events can be sent within the rate of the backfills of the workspace
+func (b *batcher) throttle(ctx context.Context, cost int64) error {
+	for {
+		rate := max(b.importer.conf.eventsPerSecond.Load(), cost)
+		allowed, retryAfter, _, err := b.importer.limiter.AllowAfter(ctx, cost, rate, 1, "backfill:"+b.workspaceID)
+		if err != nil {
+			return fmt.Errorf("throttling: %w", err)
+		}
+		if allowed {
+			return nil
+		}
+		if retryAfter <= 0 {
+			retryAfter = 100 * time.Millisecond
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryAfter):
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package backfill_test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/minio"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/gateway/internal/backfill"
+	"github.com/rudderlabs/rudder-server/services/fileuploader"
+)
+
+func TestImport(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	minioResource, err := minio.Setup(pool, t)
+	require.NoError(t, err)
+
+	storage := fileuploader.NewStaticProvider(map[string]fileuploader.StorageSettings{
+		"workspace-1": {
+			Bucket: backendconfig.StorageBucket{
+				Type: "MINIO",
+				Config: map[string]interface{}{
+					"bucketName":      minioResource.BucketName,
+					"prefix":          "some-prefix",
+					"endPoint":        minioResource.Endpoint,
+					"accessKeyID":     minioResource.AccessKeyID,
+					"secretAccessKey": minioResource.AccessKeySecret,
+				},
+			},
+		},
+	})
+	fm, err := storage.GetFileManager(context.Background(), "workspace-1")
+	require.NoError(t, err)
+
+	upload := func(t *testing.T, name, content string, prefixes ...string) {
+		t.Helper()
+		file, err := os.Create(filepath.Join(t.TempDir(), name))
+		require.NoError(t, err)
+		if filepath.Ext(name) == ".gz" {
+			gw := gzip.NewWriter(file)
+			_, err = gw.Write([]byte(content))
+			require.NoError(t, err)
+			require.NoError(t, gw.Close())
+		} else {
+			_, err = file.WriteString(content)
+			require.NoError(t, err)
+		}
+		_, err = file.Seek(0, 0)
+		require.NoError(t, err)
+		_, err = fm.Upload(context.Background(), file, prefixes...)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+	upload(t, "events-1.json.gz", `{"type":"track","event":"Order Completed","messageId":"1","anonymousId":"a","originalTimestamp":"2020-01-01T10:00:00.000Z"}
+{"type":"track","event":"Order Completed","messageId":"2","anonymousId":"a","timestamp":"2020-01-02T10:00:00.000Z","receivedAt":"2020-01-02T10:00:05.000Z"}
+
+{"type":"track","event":"No Timestamp","messageId":"3","anonymousId":"a"}
+{"event":"No Type","messageId":"4","anonymousId":"a","originalTimestamp":"2020-01-01T10:00:00.000Z"}
+`, "history", "ndjson")
+	upload(t, "events.csv", `type,messageId,userId,properties.revenue,properties.coupon,originalTimestamp
+identify,5,u,,,2020-01-03T10:00:00.000Z
+track,6,u,10.5,00123,2020-01-04T10:00:00.000Z
+`, "history", "csv")
+
+	upload(t, "staging.json.gz", `{"metadata":{"table":"tracks","columns":{}},"data":{"id":"7","event":"order_completed","event_text":"Order Completed","timestamp":"2020-01-05T10:00:00.000Z"}}
+{"metadata":{"table":"order_completed","columns":{}},"data":{"id":"7","anonymous_id":"a","event":"order_completed","event_text":"Order Completed","context_ip":"1.2.3.4","context_destination_id":"warehouse-1","revenue":10.5,"timestamp":"2020-01-05T10:00:00.000Z","received_at":"2020-01-05T10:00:01.000Z","uuid_ts":"2020-01-05T11:00:00.000Z"}}
+{"metadata":{"table":"IDENTIFIES","columns":{}},"data":{"ID":"8","USER_ID":"u","EMAIL":"u@example.com","TIMESTAMP":"2020-01-06T10:00:00.000Z"}}
+{"metadata":{"table":"pages","columns":{}},"data":{"id":"9","anonymous_id":"a","name":"Home","timestamp":"2019-12-31T10:00:00.000Z"}}
+{"metadata":{"table":"users","columns":{}},"data":{"id":"u","email":"u@example.com"}}
+`, "rudder-warehouse-staging-logs", "source-1", "2020-01-06")
+
+	c := config.New()
+	c.Set("Gateway.backfill.batchSize", 1)
+	importer, err := backfill.NewImporter(c, storage, logger.NOP)
+	require.NoError(t, err)
+
+	importEvents := func(t *testing.T, req backfill.Request) ([]json.RawMessage, backfill.Result) {
+		t.Helper()
+		var events []json.RawMessage
+		res, err := importer.Import(context.Background(), "backfill-1", req, func(_ context.Context, batch []json.RawMessage) error {
+			require.Len(t, batch, 1)
+			events = append(events, batch...)
+			return nil
+		})
+		require.NoError(t, err)
+		return events, res
+	}
+
+	t.Run("ndjson", func(t *testing.T) {
+		events, res := importEvents(t, backfill.Request{
+			WorkspaceID:    "workspace-1",
+			WriteKey:       "wk-1",
+			Prefix:         "history/ndjson",
+			Format:         backfill.FormatNDJSON,
+			DestinationIDs: []string{"destination-1"},
+		})
+		require.Equal(t, backfill.Result{Files: 1, Events: 2, Skipped: 2}, res)
+		require.Len(t, events, 2)
+
+		require.Equal(t, "2020-01-01T10:00:00.000Z", gjson.GetBytes(events[0], "timestamp").String())
+		require.Equal(t, "2020-01-01T10:00:00.000Z", gjson.GetBytes(events[0], "receivedAt").String())
+		require.Equal(t, "backfill-1", gjson.GetBytes(events[0], "context.backfill.id").String())
+		require.Equal(t, "2020-01-02T10:00:05.000Z", gjson.GetBytes(events[1], "receivedAt").String(), "receivedAt of the events is kept")
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		events, res := importEvents(t, backfill.Request{
+			WorkspaceID:    "workspace-1",
+			WriteKey:       "wk-1",
+			Prefix:         "history/csv",
+			Format:         backfill.FormatCSV,
+			DestinationIDs: []string{"destination-1"},
+		})
+		require.Equal(t, backfill.Result{Files: 1, Events: 2}, res)
+		require.Len(t, events, 2)
+
+		require.Equal(t, "identify", gjson.GetBytes(events[0], "type").String())
+		require.False(t, gjson.GetBytes(events[0], "properties").Exists(), "empty values are left out")
+		require.Equal(t, 10.5, gjson.GetBytes(events[1], "properties.revenue").Value())
+		require.Equal(t, "00123", gjson.GetBytes(events[1], "properties.coupon").Value())
+		require.Equal(t, "2020-01-04T10:00:00.000Z", gjson.GetBytes(events[1], "timestamp").String())
+	})
+
+	t.Run("warehouse staging", func(t *testing.T) {
+		from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		var events []json.RawMessage
+		res, err := importer.ImportFrom(context.Background(), fm, "backfill-1", backfill.Request{
+			WorkspaceID:       "workspace-1",
+			WriteKey:          "wk-1",
+			Prefix:            "rudder-warehouse-staging-logs/source-1",
+			Format:            backfill.FormatWarehouseStaging,
+			DestinationIDs:    []string{"destination-1"},
+			FromDestinationID: "warehouse-1",
+			From:              &from,
+		}, func(_ context.Context, batch []json.RawMessage) error {
+			events = append(events, batch...)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, backfill.Result{Files: 1, Events: 2, Skipped: 1}, res, "the page happened before the time range")
+		require.Len(t, events, 2)
+
+		require.JSONEq(t, `{
+			"type":"track",
+			"messageId":"7",
+			"anonymousId":"a",
+			"event":"Order Completed",
+			"context":{"ip":"1.2.3.4","backfill":{"id":"backfill-1"}},
+			"properties":{"revenue":10.5},
+			"timestamp":"2020-01-05T10:00:00.000Z",
+			"receivedAt":"2020-01-05T10:00:01.000Z"
+		}`, string(events[0]))
+		require.JSONEq(t, `{
+			"type":"identify",
+			"messageId":"8",
+			"userId":"u",
+			"traits":{"email":"u@example.com"},
+			"context":{"backfill":{"id":"backfill-1"}},
+			"timestamp":"2020-01-06T10:00:00.000Z",
+			"receivedAt":"2020-01-06T10:00:00.000Z"
+		}`, string(events[1]))
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, err := importer.Import(context.Background(), "backfill-1", backfill.Request{WorkspaceID: "workspace-1", WriteKey: "wk-1", Prefix: "history", Format: "parquet", DestinationIDs: []string{"destination-1"}}, nil)
+		require.Error(t, err)
+
+		_, err = importer.Import(context.Background(), "backfill-1", backfill.Request{WorkspaceID: "workspace-1", WriteKey: "wk-1", Format: backfill.FormatWarehouseStaging, DestinationIDs: []string{"destination-1"}}, nil)
+		require.Error(t, err, "fromDestinationId is required for warehouse staging files")
+	})
+}
@@ -0,0 +1,134 @@
+package backfill
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+// errSkippedRow is returned for staging rows which are not events, or whose events are already part of other tables
+var errSkippedRow = errors.New("row is not an event")
+
+var (
+	// stagingEventTypes are the types of the events of the tables of the warehouse schema, besides the tables of track
+	// events, which are named after their events
+	stagingEventTypes = map[string]string{
+		"identifies": "identify",
+		"pages":      "page",
+		"screens":    "screen",
+		"groups":     "group",
+		"aliases":    "alias",
+	}
+	// stagingSkippedTables hold rows which are not events, or duplicates of the rows of the tables of track events
+	stagingSkippedTables = []string{
+		"tracks",
+		"users",
+		warehouseutils.DiscardsTable,
+		warehouseutils.IdentityMergeRulesTable,
+		warehouseutils.IdentityMappingsTable,
+	}
+	// stagingFields are the columns of the warehouse schema which hold top level fields of the events
+	stagingFields = map[string]string{
+		"id":                 "messageId",
+		"anonymous_id":       "anonymousId",
+		"user_id":            "userId",
+		"channel":            "channel",
+		"sent_at":            "sentAt",
+		"timestamp":          "timestamp",
+		"original_timestamp": "originalTimestamp",
+		"received_at":        "receivedAt",
+		"event_text":         "event",
+		"group_id":           "groupId",
+		"previous_id":        "previousId",
+	}
+	// stagingIgnoredColumns are added by the warehouse and are not part of the events
+	stagingIgnoredColumns = []string{"event", "uuid_ts", "loaded_at"}
+)
+
+// StagingFileManager returns a file manager for the object storage of a warehouse destination, where the staging
+// files of its sources are kept
+func StagingFileManager(destination backendconfig.DestinationT) (filemanager.FileManager, error) {
+	destType := destination.DestinationDefinition.Name
+	if _, ok := warehouseutils.WarehouseDestinationMap[destType]; !ok {
+		return nil, fmt.Errorf("destination type %q is not a warehouse", destType)
+	}
+	useRudderStorage := misc.IsConfiguredToUseRudderObjectStorage(destination.Config)
+	provider := warehouseutils.ObjectStorageType(destType, destination.Config, useRudderStorage)
+	return filemanager.New(&filemanager.Settings{
+		Provider: provider,
+		Config: misc.GetObjectStorageConfig(misc.ObjectStorageOptsT{
+			Provider:         provider,
+			Config:           destination.Config,
+			UseRudderStorage: useRudderStorage,
+			WorkspaceID:      destination.WorkspaceID,
+		}),
+	})
+}
+
+// stagingEvent regenerates the json encoded event of a row of a warehouse staging file. Since the rows hold the
+// flattened events, nested fields cannot be restored: columns prefixed with context_ are set as fields of the context
+// and the remaining columns as properties (or traits for identify and group events) with their column names.
+func stagingEvent(line []byte) ([]byte, error) {
+	if !gjson.ValidBytes(line) {
+		return nil, errors.New("row is not valid json")
+	}
+	table := strings.ToLower(gjson.GetBytes(line, "metadata.table").String())
+	data := gjson.GetBytes(line, "data")
+	if table == "" || !data.IsObject() {
+		return nil, errors.New("row has no table or data")
+	}
+	if slices.Contains(stagingSkippedTables, table) {
+		return nil, errSkippedRow
+	}
+	eventType, ok := stagingEventTypes[table]
+	if !ok {
+		if !data.Get("event_text").Exists() && !data.Get("EVENT_TEXT").Exists() {
+			return nil, errSkippedRow
+		}
+		eventType = "track"
+	}
+	extraFields := "properties"
+	if eventType == "identify" || eventType == "group" {
+		extraFields = "traits"
+	}
+
+	event := []byte(`{}`)
+	var err error
+	if event, err = sjson.SetBytes(event, "type", eventType); err != nil {
+		return nil, err
+	}
+	data.ForEach(func(key, value gjson.Result) bool {
+		column := strings.ToLower(key.String())
+		var field string
+		switch {
+		case slices.Contains(stagingIgnoredColumns, column),
+			strings.HasPrefix(column, "context_destination_"),
+			strings.HasPrefix(column, "context_source_"):
+			return true
+		case column == "name" && (eventType == "page" || eventType == "screen"):
+			field = "name"
+		case stagingFields[column] != "":
+			field = stagingFields[column]
+		case strings.HasPrefix(column, "context_"):
+			field = "context." + strings.TrimPrefix(column, "context_")
+		default:
+			field = extraFields + "." + column
+		}
+		event, err = sjson.SetRawBytes(event, field, []byte(value.Raw))
+		return err == nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setting field: %w", err)
+	}
+	return event, nil
+}
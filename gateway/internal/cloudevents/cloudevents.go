@@ -0,0 +1,191 @@
+// Package cloudevents converts CloudEvents (https://cloudevents.io) received over the HTTP protocol binding into Rudder events.
+//
+// All three content modes of the HTTP binding are supported:
+//
+//   - binary: event attributes are sent as ce-* headers and the request body carries the event data
+//   - structured: the request body is a single json encoded event (Content-Type: application/cloudevents+json)
+//   - batched: the request body is a json array of events (Content-Type: application/cloudevents-batch+json)
+//
+// Every CloudEvent is mapped to a track event:
+//
+//   - type becomes the event name
+//   - id becomes the messageId
+//   - time becomes the originalTimestamp
+//   - the userid & anonymousid extension attributes become the userId & anonymousId respectively, while subject is used as the userId if no userid extension is present
+//   - data becomes the event properties, if it is a json object, otherwise it is kept in properties.data
+//   - all other attributes are kept in context.cloudEvent
+package cloudevents
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+const (
+	// ContentTypeStructured is the media type of the structured content mode
+	ContentTypeStructured = "application/cloudevents+json"
+	// ContentTypeBatch is the media type of the batched content mode
+	ContentTypeBatch = "application/cloudevents-batch+json"
+
+	headerPrefix = "Ce-"
+
+	specVersion = "1.0"
+)
+
+var (
+	// ErrEmptyBatch is returned when a batched request contains no events
+	ErrEmptyBatch = errors.New("no cloudevents in batch")
+	// ErrUnsupportedSpecVersion is returned for events of a specversion other than 1.0
+	ErrUnsupportedSpecVersion = errors.New("unsupported cloudevents specversion")
+)
+
+// ToBatch converts a CloudEvents HTTP request to the payload of a Rudder batch request
+func ToBatch(header http.Header, body []byte) ([]byte, error) {
+	var events []map[string]any
+	switch mediaType(header) {
+	case ContentTypeStructured:
+		var event map[string]any
+		if err := json.Unmarshal(body, &event); err != nil {
+			return nil, fmt.Errorf("invalid structured cloudevent: %w", err)
+		}
+		events = append(events, event)
+	case ContentTypeBatch:
+		if err := json.Unmarshal(body, &events); err != nil {
+			return nil, fmt.Errorf("invalid cloudevents batch: %w", err)
+		}
+		if len(events) == 0 {
+			return nil, ErrEmptyBatch
+		}
+	default:
+		event, err := fromBinary(header, body)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	batch := make([]map[string]any, 0, len(events))
+	for i, event := range events {
+		rudderEvent, err := toRudderEvent(event)
+		if err != nil {
+			return nil, fmt.Errorf("cloudevent at index %d: %w", i, err)
+		}
+		batch = append(batch, rudderEvent)
+	}
+	return json.Marshal(map[string]any{"batch": batch})
+}
+
+func mediaType(header http.Header) string {
+	mt, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(mt)
+}
+
+// fromBinary builds a structured event out of a binary content mode request
+func fromBinary(header http.Header, body []byte) (map[string]any, error) {
+	event := make(map[string]any)
+	for key, values := range header {
+		if len(values) == 0 || !strings.HasPrefix(http.CanonicalHeaderKey(key), headerPrefix) {
+			continue
+		}
+		event[strings.ToLower(key[len(headerPrefix):])] = values[0]
+	}
+	if len(event) == 0 {
+		return nil, errors.New("missing ce-* headers for binary cloudevent")
+	}
+	if contentType := header.Get("Content-Type"); contentType != "" {
+		event["datacontenttype"] = contentType
+	}
+	if len(body) > 0 {
+		if isJSON(header.Get("Content-Type")) {
+			var data any
+			if err := json.Unmarshal(body, &data); err != nil {
+				return nil, fmt.Errorf("invalid json data: %w", err)
+			}
+			event["data"] = data
+		} else {
+			event["data_base64"] = base64.StdEncoding.EncodeToString(body)
+		}
+	}
+	return event, nil
+}
+
+// isJSON returns true if data of the given content type is json, which is also the default when no content type is specified
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || mt == "text/json" || strings.HasSuffix(mt, "+json")
+}
+
+func toRudderEvent(event map[string]any) (map[string]any, error) {
+	attribute := func(name string) string {
+		v, _ := event[name].(string)
+		return v
+	}
+	if v := attribute("specversion"); v != specVersion {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedSpecVersion, v)
+	}
+	for _, required := range []string{"id", "source", "type"} {
+		if attribute(required) == "" {
+			return nil, fmt.Errorf("missing required attribute %q", required)
+		}
+	}
+
+	rudderEvent := map[string]any{
+		"type":      "track",
+		"event":     attribute("type"),
+		"messageId": attribute("id"),
+	}
+	if t := attribute("time"); t != "" {
+		rudderEvent["originalTimestamp"] = t
+	}
+	userID := attribute("userid")
+	if userID == "" {
+		userID = attribute("subject")
+	}
+	if userID != "" {
+		rudderEvent["userId"] = userID
+	}
+	if anonymousID := attribute("anonymousid"); anonymousID != "" {
+		rudderEvent["anonymousId"] = anonymousID
+	}
+
+	properties := make(map[string]any)
+	switch data := event["data"].(type) {
+	case nil:
+		if dataBase64 := attribute("data_base64"); dataBase64 != "" {
+			properties["data_base64"] = dataBase64
+		}
+	case map[string]any:
+		properties = data
+	default:
+		properties["data"] = data
+	}
+	rudderEvent["properties"] = properties
+
+	cloudEvent := make(map[string]any, len(event))
+	for name, value := range event {
+		switch name {
+		case "data", "data_base64", "userid", "anonymousid":
+		default:
+			cloudEvent[name] = value
+		}
+	}
+	rudderEvent["context"] = map[string]any{"cloudEvent": cloudEvent}
+	return rudderEvent, nil
+}
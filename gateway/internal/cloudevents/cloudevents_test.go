@@ -0,0 +1,121 @@
+package cloudevents_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/gateway/internal/cloudevents"
+)
+
+func TestToBatch(t *testing.T) {
+	t.Run("binary mode with json data", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "application/json")
+		header.Set("ce-specversion", "1.0")
+		header.Set("ce-id", "event-1")
+		header.Set("ce-source", "/orders")
+		header.Set("ce-type", "order.created")
+		header.Set("ce-time", "2024-01-01T10:00:00Z")
+		header.Set("ce-subject", "user-1")
+		header.Set("ce-anonymousid", "anon-1")
+		header.Set("ce-tenant", "acme")
+
+		batch, err := cloudevents.ToBatch(header, []byte(`{"amount":10}`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"batch":[{
+			"type":"track",
+			"event":"order.created",
+			"messageId":"event-1",
+			"originalTimestamp":"2024-01-01T10:00:00Z",
+			"userId":"user-1",
+			"anonymousId":"anon-1",
+			"properties":{"amount":10},
+			"context":{"cloudEvent":{
+				"specversion":"1.0",
+				"id":"event-1",
+				"source":"/orders",
+				"type":"order.created",
+				"time":"2024-01-01T10:00:00Z",
+				"subject":"user-1",
+				"tenant":"acme",
+				"datacontenttype":"application/json"
+			}}
+		}]}`, string(batch))
+	})
+
+	t.Run("binary mode with non json data", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "text/plain")
+		header.Set("ce-specversion", "1.0")
+		header.Set("ce-id", "event-1")
+		header.Set("ce-source", "/orders")
+		header.Set("ce-type", "order.created")
+		header.Set("ce-userid", "user-1")
+
+		batch, err := cloudevents.ToBatch(header, []byte(`hello`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"batch":[{
+			"type":"track",
+			"event":"order.created",
+			"messageId":"event-1",
+			"userId":"user-1",
+			"properties":{"data_base64":"aGVsbG8="},
+			"context":{"cloudEvent":{"specversion":"1.0","id":"event-1","source":"/orders","type":"order.created","datacontenttype":"text/plain"}}
+		}]}`, string(batch))
+	})
+
+	t.Run("binary mode without ce headers", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "application/json")
+		_, err := cloudevents.ToBatch(header, []byte(`{}`))
+		require.Error(t, err)
+	})
+
+	t.Run("structured mode", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", "application/cloudevents+json; charset=utf-8")
+		batch, err := cloudevents.ToBatch(header, []byte(`{"specversion":"1.0","id":"event-1","source":"/orders","type":"order.created","data":"some-string","userid":"user-1"}`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"batch":[{
+			"type":"track",
+			"event":"order.created",
+			"messageId":"event-1",
+			"userId":"user-1",
+			"properties":{"data":"some-string"},
+			"context":{"cloudEvent":{"specversion":"1.0","id":"event-1","source":"/orders","type":"order.created"}}
+		}]}`, string(batch))
+	})
+
+	t.Run("batched mode", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", cloudevents.ContentTypeBatch)
+		batch, err := cloudevents.ToBatch(header, []byte(`[
+			{"specversion":"1.0","id":"event-1","source":"/orders","type":"order.created","subject":"user-1"},
+			{"specversion":"1.0","id":"event-2","source":"/orders","type":"order.cancelled","subject":"user-2"}
+		]`))
+		require.NoError(t, err)
+		require.JSONEq(t, `{"batch":[
+			{"type":"track","event":"order.created","messageId":"event-1","userId":"user-1","properties":{},"context":{"cloudEvent":{"specversion":"1.0","id":"event-1","source":"/orders","type":"order.created","subject":"user-1"}}},
+			{"type":"track","event":"order.cancelled","messageId":"event-2","userId":"user-2","properties":{},"context":{"cloudEvent":{"specversion":"1.0","id":"event-2","source":"/orders","type":"order.cancelled","subject":"user-2"}}}
+		]}`, string(batch))
+
+		_, err = cloudevents.ToBatch(header, []byte(`[]`))
+		require.ErrorIs(t, err, cloudevents.ErrEmptyBatch)
+	})
+
+	t.Run("invalid events", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("Content-Type", cloudevents.ContentTypeStructured)
+
+		_, err := cloudevents.ToBatch(header, []byte(`{"specversion":"0.3","id":"event-1","source":"/orders","type":"order.created"}`))
+		require.ErrorIs(t, err, cloudevents.ErrUnsupportedSpecVersion)
+
+		_, err = cloudevents.ToBatch(header, []byte(`{"specversion":"1.0","source":"/orders","type":"order.created"}`))
+		require.ErrorContains(t, err, `missing required attribute "id"`)
+
+		_, err = cloudevents.ToBatch(header, []byte(`not json`))
+		require.Error(t, err)
+	})
+}
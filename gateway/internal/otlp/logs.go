@@ -0,0 +1,51 @@
+package otlp
+
+import (
+	"fmt"
+
+	collectorlogsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+// LogsToBatch converts an OTLP logs export request to a Rudder batch payload
+func LogsToBatch(encoding string, body []byte, m Mapping) (Result, error) {
+	var req collectorlogsv1.ExportLogsServiceRequest
+	if err := unmarshal(encoding, body, &req); err != nil {
+		return Result{}, fmt.Errorf("invalid logs export request: %w", err)
+	}
+	var records []*record
+	for _, rl := range req.GetResourceLogs() {
+		for _, sl := range rl.GetScopeLogs() {
+			for _, lr := range sl.GetLogRecords() {
+				r := &record{
+					attributes: lr.GetAttributes(),
+					scope:      sl.GetScope(),
+					resource:   rl.GetResource(),
+					timestamp:  lr.GetTimeUnixNano(),
+					traceID:    lr.GetTraceId(),
+					spanID:     lr.GetSpanId(),
+					properties: make(map[string]any),
+					otel:       make(map[string]any),
+				}
+				if r.timestamp == 0 {
+					r.timestamp = lr.GetObservedTimeUnixNano()
+				}
+				if body := lr.GetBody(); body != nil {
+					if s, ok := body.GetValue().(*commonv1.AnyValue_StringValue); ok {
+						r.fallbackEventName = s.StringValue
+					} else {
+						r.properties["body"] = anyValue(body)
+					}
+				}
+				if severity := lr.GetSeverityText(); severity != "" {
+					r.otel["severityText"] = severity
+				}
+				if severity := lr.GetSeverityNumber(); severity != 0 {
+					r.otel["severityNumber"] = int32(severity)
+				}
+				records = append(records, r)
+			}
+		}
+	}
+	return toBatch(records, m)
+}
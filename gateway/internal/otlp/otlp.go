@@ -0,0 +1,312 @@
+// Package otlp converts OpenTelemetry log records and spans received over OTLP/HTTP into Rudder track events.
+//
+// Both the binary protobuf (application/x-protobuf) and the json (application/json) encodings of OTLP/HTTP are supported.
+// Every log record or span becomes a track event:
+//
+//   - the event name is taken from the [Mapping.EventNameAttribute] attribute, falling back to the body of log records (if it is a string) and the name of spans
+//   - userId, anonymousId & messageId are taken from the corresponding [Mapping] attributes. Spans without a messageId attribute use their trace & span id instead.
+//   - the log record's (or span's start) time becomes the originalTimestamp
+//   - all other attributes of the log record or span become the event properties
+//   - resource attributes, instrumentation scope and trace context are kept in context.otel
+//
+// Mapped attributes are looked up in the log record or span first, then in its instrumentation scope and finally in its resource.
+// Records which cannot be mapped to an event (i.e. without an event name) are rejected, which is reported back to the client as a partial success.
+package otlp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	collectorlogsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+const (
+	// ContentTypeProtobuf is the media type of the binary protobuf encoding
+	ContentTypeProtobuf = "application/x-protobuf"
+	// ContentTypeJSON is the media type of the json encoding
+	ContentTypeJSON = "application/json"
+)
+
+// ErrUnsupportedContentType is returned for requests which are neither protobuf nor json encoded
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+// Mapping defines the attributes from which the Rudder event envelope fields are populated
+type Mapping struct {
+	EventNameAttribute   string
+	UserIDAttribute      string
+	AnonymousIDAttribute string
+	MessageIDAttribute   string
+}
+
+// Result is the outcome of converting an OTLP export request
+type Result struct {
+	// Payload is the Rudder batch payload containing all converted events, nil if no event could be converted
+	Payload []byte
+	// Rejected is the number of log records or spans that could not be converted
+	Rejected int64
+	// RejectionReason describes why records were rejected, if any
+	RejectionReason string
+}
+
+// Encoding returns the encoding of an OTLP request or response based on the content type of the request
+func Encoding(contentType string) (string, error) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+	switch mt {
+	case ContentTypeProtobuf, ContentTypeJSON:
+		return mt, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedContentType, mt)
+	}
+}
+
+// unmarshal decodes an OTLP request according to its encoding.
+// The json encoding of OTLP represents trace & span ids as hex strings instead of base64, so they are converted before decoding.
+func unmarshal(encoding string, body []byte, m proto.Message) error {
+	if encoding == ContentTypeProtobuf {
+		return proto.Unmarshal(body, m)
+	}
+	var raw any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber() // preserve the precision of unix nano timestamps
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+	if err := hexIDsToBase64(raw); err != nil {
+		return err
+	}
+	body, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, m)
+}
+
+func hexIDsToBase64(v any) error {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			switch key {
+			case "traceId", "spanId", "parentSpanId":
+				if s, ok := value.(string); ok {
+					id, err := hex.DecodeString(s)
+					if err != nil {
+						return fmt.Errorf("invalid %s %q: %w", key, s, err)
+					}
+					v[key] = base64.StdEncoding.EncodeToString(id)
+				}
+			default:
+				if err := hexIDsToBase64(value); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		for _, value := range v {
+			if err := hexIDsToBase64(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// marshal encodes an OTLP response according to the encoding of the request
+func marshal(encoding string, m proto.Message) ([]byte, error) {
+	if encoding == ContentTypeProtobuf {
+		return proto.Marshal(m)
+	}
+	return protojson.Marshal(m)
+}
+
+// LogsResponse returns the encoded response of an OTLP logs export request
+func LogsResponse(encoding string, res Result) ([]byte, error) {
+	resp := &collectorlogsv1.ExportLogsServiceResponse{}
+	if res.Rejected > 0 {
+		resp.PartialSuccess = &collectorlogsv1.ExportLogsPartialSuccess{RejectedLogRecords: res.Rejected, ErrorMessage: res.RejectionReason}
+	}
+	return marshal(encoding, resp)
+}
+
+// TracesResponse returns the encoded response of an OTLP traces export request
+func TracesResponse(encoding string, res Result) ([]byte, error) {
+	resp := &collectortracev1.ExportTraceServiceResponse{}
+	if res.Rejected > 0 {
+		resp.PartialSuccess = &collectortracev1.ExportTracePartialSuccess{RejectedSpans: res.Rejected, ErrorMessage: res.RejectionReason}
+	}
+	return marshal(encoding, resp)
+}
+
+// record holds the information of a log record or span needed for building a track event
+type record struct {
+	attributes []*commonv1.KeyValue
+	scope      *commonv1.InstrumentationScope
+	resource   *resourcev1.Resource
+	timestamp  uint64
+	traceID    []byte
+	spanID     []byte
+	// fallbackEventName is used if the event name attribute is missing
+	fallbackEventName string
+	// fallbackMessageID is used if the message id attribute is missing
+	fallbackMessageID string
+	// extra properties & otel context fields
+	properties map[string]any
+	otel       map[string]any
+}
+
+// lookup returns the value of an attribute, searching the record, scope and resource attributes in that order
+func (r *record) lookup(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	for _, attributes := range [][]*commonv1.KeyValue{r.attributes, r.scope.GetAttributes(), r.resource.GetAttributes()} {
+		for _, kv := range attributes {
+			if kv.GetKey() == key {
+				return fmt.Sprint(anyValue(kv.GetValue())), true
+			}
+		}
+	}
+	return "", false
+}
+
+// toEvent builds a track event out of the record, returning false if no event name could be determined
+func (r *record) toEvent(m Mapping) (map[string]any, bool) {
+	eventName, ok := r.lookup(m.EventNameAttribute)
+	if !ok || eventName == "" {
+		eventName = r.fallbackEventName
+	}
+	if eventName == "" {
+		return nil, false
+	}
+	event := map[string]any{
+		"type":  "track",
+		"event": eventName,
+	}
+	if userID, ok := r.lookup(m.UserIDAttribute); ok {
+		event["userId"] = userID
+	}
+	if anonymousID, ok := r.lookup(m.AnonymousIDAttribute); ok {
+		event["anonymousId"] = anonymousID
+	}
+	if messageID, ok := r.lookup(m.MessageIDAttribute); ok {
+		event["messageId"] = messageID
+	} else if r.fallbackMessageID != "" {
+		event["messageId"] = r.fallbackMessageID
+	}
+	if r.timestamp > 0 {
+		event["originalTimestamp"] = time.Unix(0, int64(r.timestamp)).UTC().Format(misc.RFC3339Milli)
+	}
+
+	mapped := map[string]struct{}{m.EventNameAttribute: {}, m.UserIDAttribute: {}, m.AnonymousIDAttribute: {}, m.MessageIDAttribute: {}}
+	properties := r.properties
+	if properties == nil {
+		properties = make(map[string]any)
+	}
+	for _, kv := range r.attributes {
+		if _, ok := mapped[kv.GetKey()]; ok {
+			continue
+		}
+		properties[kv.GetKey()] = anyValue(kv.GetValue())
+	}
+	event["properties"] = properties
+
+	otel := r.otel
+	if otel == nil {
+		otel = make(map[string]any)
+	}
+	if len(r.traceID) > 0 {
+		otel["traceId"] = hex.EncodeToString(r.traceID)
+	}
+	if len(r.spanID) > 0 {
+		otel["spanId"] = hex.EncodeToString(r.spanID)
+	}
+	if attributes := r.resource.GetAttributes(); len(attributes) > 0 {
+		otel["resource"] = keyValues(attributes)
+	}
+	if r.scope != nil {
+		scope := map[string]any{"name": r.scope.GetName()}
+		if version := r.scope.GetVersion(); version != "" {
+			scope["version"] = version
+		}
+		if attributes := r.scope.GetAttributes(); len(attributes) > 0 {
+			scope["attributes"] = keyValues(attributes)
+		}
+		otel["scope"] = scope
+	}
+	event["context"] = map[string]any{"otel": otel}
+	return event, true
+}
+
+// toBatch converts records to a Rudder batch payload
+func toBatch(records []*record, m Mapping) (Result, error) {
+	var res Result
+	batch := make([]map[string]any, 0, len(records))
+	for _, r := range records {
+		event, ok := r.toEvent(m)
+		if !ok {
+			res.Rejected++
+			continue
+		}
+		batch = append(batch, event)
+	}
+	if res.Rejected > 0 {
+		res.RejectionReason = fmt.Sprintf("%d records without an event name, either set the %q attribute or a name", res.Rejected, m.EventNameAttribute)
+	}
+	if len(batch) == 0 {
+		return res, nil
+	}
+	var err error
+	res.Payload, err = json.Marshal(map[string]any{"batch": batch})
+	return res, err
+}
+
+func keyValues(kvs []*commonv1.KeyValue) map[string]any {
+	m := make(map[string]any, len(kvs))
+	for _, kv := range kvs {
+		m[kv.GetKey()] = anyValue(kv.GetValue())
+	}
+	return m
+}
+
+func anyValue(v *commonv1.AnyValue) any {
+	switch v := v.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		return v.StringValue
+	case *commonv1.AnyValue_BoolValue:
+		return v.BoolValue
+	case *commonv1.AnyValue_IntValue:
+		return v.IntValue
+	case *commonv1.AnyValue_DoubleValue:
+		return v.DoubleValue
+	case *commonv1.AnyValue_BytesValue:
+		return base64.StdEncoding.EncodeToString(v.BytesValue)
+	case *commonv1.AnyValue_ArrayValue:
+		values := make([]any, 0, len(v.ArrayValue.GetValues()))
+		for _, value := range v.ArrayValue.GetValues() {
+			values = append(values, anyValue(value))
+		}
+		return values
+	case *commonv1.AnyValue_KvlistValue:
+		return keyValues(v.KvlistValue.GetValues())
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,182 @@
+package otlp_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	collectorlogsv1 "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+
+	"github.com/rudderlabs/rudder-server/gateway/internal/otlp"
+)
+
+var mapping = otlp.Mapping{
+	EventNameAttribute:   "event.name",
+	UserIDAttribute:      "enduser.id",
+	AnonymousIDAttribute: "rudder.anonymous_id",
+	MessageIDAttribute:   "rudder.message_id",
+}
+
+func stringKV(key, value string) *commonv1.KeyValue {
+	return &commonv1.KeyValue{Key: key, Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: value}}}
+}
+
+func intKV(key string, value int64) *commonv1.KeyValue {
+	return &commonv1.KeyValue{Key: key, Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: value}}}
+}
+
+func TestEncoding(t *testing.T) {
+	encoding, err := otlp.Encoding("application/x-protobuf")
+	require.NoError(t, err)
+	require.Equal(t, otlp.ContentTypeProtobuf, encoding)
+
+	encoding, err = otlp.Encoding("application/json; charset=utf-8")
+	require.NoError(t, err)
+	require.Equal(t, otlp.ContentTypeJSON, encoding)
+
+	_, err = otlp.Encoding("text/plain")
+	require.ErrorIs(t, err, otlp.ErrUnsupportedContentType)
+}
+
+func TestLogsToBatch(t *testing.T) {
+	t.Run("protobuf", func(t *testing.T) {
+		req := &collectorlogsv1.ExportLogsServiceRequest{
+			ResourceLogs: []*logsv1.ResourceLogs{{
+				Resource: &resourcev1.Resource{Attributes: []*commonv1.KeyValue{stringKV("service.name", "checkout"), stringKV("enduser.id", "resource-user")}},
+				ScopeLogs: []*logsv1.ScopeLogs{{
+					Scope: &commonv1.InstrumentationScope{Name: "analytics", Version: "1.0.0"},
+					LogRecords: []*logsv1.LogRecord{
+						{
+							TimeUnixNano: 1704103200000000000,
+							SeverityText: "INFO",
+							Attributes:   []*commonv1.KeyValue{stringKV("event.name", "Order Completed"), stringKV("enduser.id", "user-1"), stringKV("rudder.message_id", "message-1"), intKV("revenue", 10)},
+						},
+						{
+							TimeUnixNano: 1704103200000000000,
+							Body:         &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: "Cart Viewed"}},
+							TraceId:      []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+						},
+						{
+							Body: &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: 1}},
+						},
+					},
+				}},
+			}},
+		}
+		body, err := proto.Marshal(req)
+		require.NoError(t, err)
+
+		res, err := otlp.LogsToBatch(otlp.ContentTypeProtobuf, body, mapping)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, res.Rejected, "log record without event name should be rejected")
+		require.NotEmpty(t, res.RejectionReason)
+		require.JSONEq(t, `{"batch":[
+			{
+				"type":"track",
+				"event":"Order Completed",
+				"userId":"user-1",
+				"messageId":"message-1",
+				"originalTimestamp":"2024-01-01T10:00:00.000Z",
+				"properties":{"revenue":10},
+				"context":{"otel":{"severityText":"INFO","resource":{"service.name":"checkout","enduser.id":"resource-user"},"scope":{"name":"analytics","version":"1.0.0"}}}
+			},
+			{
+				"type":"track",
+				"event":"Cart Viewed",
+				"userId":"resource-user",
+				"originalTimestamp":"2024-01-01T10:00:00.000Z",
+				"properties":{},
+				"context":{"otel":{"traceId":"0102030405060708090a0b0c0d0e0f10","resource":{"service.name":"checkout","enduser.id":"resource-user"},"scope":{"name":"analytics","version":"1.0.0"}}}
+			}
+		]}`, string(res.Payload))
+
+		resp, err := otlp.LogsResponse(otlp.ContentTypeProtobuf, res)
+		require.NoError(t, err)
+		var decoded collectorlogsv1.ExportLogsServiceResponse
+		require.NoError(t, proto.Unmarshal(resp, &decoded))
+		require.EqualValues(t, 1, decoded.GetPartialSuccess().GetRejectedLogRecords())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		body := `{"resourceLogs":[{"scopeLogs":[{"logRecords":[{
+			"timeUnixNano":"1704103200000000000",
+			"traceId":"0102030405060708090a0b0c0d0e0f10",
+			"spanId":"0102030405060708",
+			"attributes":[{"key":"event.name","value":{"stringValue":"Signed Up"}},{"key":"rudder.anonymous_id","value":{"stringValue":"anon-1"}}]
+		}]}]}]}`
+		res, err := otlp.LogsToBatch(otlp.ContentTypeJSON, []byte(body), mapping)
+		require.NoError(t, err)
+		require.Zero(t, res.Rejected)
+		require.JSONEq(t, `{"batch":[{
+			"type":"track",
+			"event":"Signed Up",
+			"anonymousId":"anon-1",
+			"originalTimestamp":"2024-01-01T10:00:00.000Z",
+			"properties":{},
+			"context":{"otel":{"traceId":"0102030405060708090a0b0c0d0e0f10","spanId":"0102030405060708"}}
+		}]}`, string(res.Payload))
+
+		resp, err := otlp.LogsResponse(otlp.ContentTypeJSON, res)
+		require.NoError(t, err)
+		require.JSONEq(t, `{}`, string(resp))
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, err := otlp.LogsToBatch(otlp.ContentTypeJSON, []byte(`{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"traceId":"not-hex"}]}]}]}`), mapping)
+		require.Error(t, err)
+		_, err = otlp.LogsToBatch(otlp.ContentTypeProtobuf, []byte(`not protobuf`), mapping)
+		require.Error(t, err)
+	})
+}
+
+func TestTracesToBatch(t *testing.T) {
+	req := &collectortracev1.ExportTraceServiceRequest{
+		ResourceSpans: []*tracev1.ResourceSpans{{
+			ScopeSpans: []*tracev1.ScopeSpans{{
+				Spans: []*tracev1.Span{{
+					TraceId:           []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+					SpanId:            []byte{1, 2, 3, 4, 5, 6, 7, 8},
+					Name:              "Checkout Started",
+					Kind:              tracev1.Span_SPAN_KIND_CLIENT,
+					StartTimeUnixNano: 1704103200000000000,
+					EndTimeUnixNano:   1704103200250000000,
+					Attributes:        []*commonv1.KeyValue{stringKV("enduser.id", "user-1"), stringKV("cart.id", "cart-1")},
+					Status:            &tracev1.Status{Code: tracev1.Status_STATUS_CODE_ERROR, Message: "payment failed"},
+				}},
+			}},
+		}},
+	}
+	body, err := proto.Marshal(req)
+	require.NoError(t, err)
+
+	res, err := otlp.TracesToBatch(otlp.ContentTypeProtobuf, body, mapping)
+	require.NoError(t, err)
+	require.Zero(t, res.Rejected)
+	require.JSONEq(t, `{"batch":[{
+		"type":"track",
+		"event":"Checkout Started",
+		"userId":"user-1",
+		"messageId":"0102030405060708090a0b0c0d0e0f10-0102030405060708",
+		"originalTimestamp":"2024-01-01T10:00:00.000Z",
+		"properties":{"cart.id":"cart-1","durationMs":250},
+		"context":{"otel":{
+			"traceId":"0102030405060708090a0b0c0d0e0f10",
+			"spanId":"0102030405060708",
+			"spanName":"Checkout Started",
+			"spanKind":"SPAN_KIND_CLIENT",
+			"status":{"code":"STATUS_CODE_ERROR","message":"payment failed"}
+		}}
+	}]}`, string(res.Payload))
+
+	resp, err := otlp.TracesResponse(otlp.ContentTypeProtobuf, res)
+	require.NoError(t, err)
+	var decoded collectortracev1.ExportTraceServiceResponse
+	require.NoError(t, proto.Unmarshal(resp, &decoded))
+	require.Nil(t, decoded.GetPartialSuccess())
+}
@@ -0,0 +1,49 @@
+package otlp
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// TracesToBatch converts an OTLP traces export request to a Rudder batch payload
+func TracesToBatch(encoding string, body []byte, m Mapping) (Result, error) {
+	var req collectortracev1.ExportTraceServiceRequest
+	if err := unmarshal(encoding, body, &req); err != nil {
+		return Result{}, fmt.Errorf("invalid traces export request: %w", err)
+	}
+	var records []*record
+	for _, rs := range req.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			for _, span := range ss.GetSpans() {
+				r := &record{
+					attributes:        span.GetAttributes(),
+					scope:             ss.GetScope(),
+					resource:          rs.GetResource(),
+					timestamp:         span.GetStartTimeUnixNano(),
+					traceID:           span.GetTraceId(),
+					spanID:            span.GetSpanId(),
+					fallbackEventName: span.GetName(),
+					properties:        make(map[string]any),
+					otel:              map[string]any{"spanName": span.GetName(), "spanKind": span.GetKind().String()},
+				}
+				if len(span.GetTraceId()) > 0 && len(span.GetSpanId()) > 0 {
+					r.fallbackMessageID = hex.EncodeToString(span.GetTraceId()) + "-" + hex.EncodeToString(span.GetSpanId())
+				}
+				if len(span.GetParentSpanId()) > 0 {
+					r.otel["parentSpanId"] = hex.EncodeToString(span.GetParentSpanId())
+				}
+				if start, end := span.GetStartTimeUnixNano(), span.GetEndTimeUnixNano(); end > start {
+					r.properties["durationMs"] = float64(end-start) / 1e6
+				}
+				if status := span.GetStatus(); status.GetCode() != tracev1.Status_STATUS_CODE_UNSET {
+					r.otel["status"] = map[string]any{"code": status.GetCode().String(), "message": status.GetMessage()}
+				}
+				records = append(records, r)
+			}
+		}
+	}
+	return toBatch(records, m)
+}
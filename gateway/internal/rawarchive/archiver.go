@@ -0,0 +1,214 @@
+package rawarchive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	"github.com/rudderlabs/rudder-go-kit/bytesize"
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/services/fileuploader"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+// Archiver buffers raw requests in memory and periodically uploads them to the object storage of their workspace
+type Archiver struct {
+	storage fileuploader.Provider
+	log     logger.Logger
+	stats   stats.Stats
+	records chan Record
+
+	conf struct {
+		prefix        string
+		instanceID    string
+		maxFileSize   config.ValueLoader[int64]
+		flushInterval config.ValueLoader[time.Duration]
+		uploadTimeout config.ValueLoader[time.Duration]
+	}
+}
+
+type partitionKey struct {
+	workspaceID string
+	sourceID    string
+	hour        time.Time
+}
+
+type partition struct {
+	records []Record
+	size    int64
+	since   time.Time
+}
+
+// NewArchiver creates a new archiver for raw gateway requests
+func NewArchiver(conf *config.Config, storage fileuploader.Provider, log logger.Logger, stat stats.Stats) *Archiver {
+	a := &Archiver{
+		storage: storage,
+		log:     log.Child("rawarchive"),
+		stats:   stat,
+		records: make(chan Record, conf.GetIntVar(10000, 1, "Gateway.rawArchive.bufferSize")),
+	}
+	a.conf.prefix = conf.GetStringVar("rudder-raw-requests", "Gateway.rawArchive.prefix")
+	a.conf.instanceID = conf.GetString("INSTANCE_ID", "1")
+	a.conf.maxFileSize = conf.GetReloadableInt64Var(32, bytesize.MB, "Gateway.rawArchive.maxFileSize")
+	a.conf.flushInterval = conf.GetReloadableDurationVar(1, time.Minute, "Gateway.rawArchive.flushInterval")
+	a.conf.uploadTimeout = conf.GetReloadableDurationVar(2, time.Minute, "Gateway.rawArchive.uploadTimeout")
+	return a
+}
+
+// Archive enqueues a raw request for archival without blocking.
+// If the archiver cannot keep up, the request is dropped and false is returned.
+func (a *Archiver) Archive(record Record) bool {
+	select {
+	case a.records <- record:
+		return true
+	default:
+		a.stats.NewTaggedStat("gateway.raw_archive_dropped_requests", stats.CountType, stats.Tags{"workspaceId": record.WorkspaceID, "sourceId": record.SourceID}).Increment()
+		return false
+	}
+}
+
+// Run consumes archived requests and uploads them until the context is cancelled, at which point any buffered requests are uploaded before returning
+func (a *Archiver) Run(ctx context.Context) {
+	partitions := make(map[partitionKey]*partition)
+	ticker := time.NewTicker(a.conf.flushInterval.Load())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// drain anything already enqueued and upload using a fresh context, since ctx is already cancelled
+		drain:
+			for {
+				select {
+				case record := <-a.records:
+					a.add(partitions, record)
+				default:
+					break drain
+				}
+			}
+			for key, p := range partitions {
+				a.flush(context.Background(), key, p)
+			}
+			return
+		case record := <-a.records:
+			key, p := a.add(partitions, record)
+			if p.size >= a.conf.maxFileSize.Load() {
+				a.flush(ctx, key, p)
+				delete(partitions, key)
+			}
+		case <-ticker.C:
+			for key, p := range partitions {
+				if time.Since(p.since) >= a.conf.flushInterval.Load() || time.Since(key.hour) > time.Hour {
+					a.flush(ctx, key, p)
+					delete(partitions, key)
+				}
+			}
+		}
+	}
+}
+
+func (a *Archiver) add(partitions map[partitionKey]*partition, record Record) (partitionKey, *partition) {
+	key := partitionKey{
+		workspaceID: record.WorkspaceID,
+		sourceID:    record.SourceID,
+		hour:        record.ReceivedAt.UTC().Truncate(time.Hour),
+	}
+	p, ok := partitions[key]
+	if !ok {
+		p = &partition{since: time.Now()}
+		partitions[key] = p
+	}
+	p.records = append(p.records, record)
+	p.size += int64(len(record.Body))
+	return key, p
+}
+
+// flush uploads the requests of a partition, logging any failure since there is no client left to report it to
+func (a *Archiver) flush(ctx context.Context, key partitionKey, p *partition) {
+	if len(p.records) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, a.conf.uploadTimeout.Load())
+	defer cancel()
+	start := time.Now()
+	tags := stats.Tags{"workspaceId": key.workspaceID}
+	location, err := a.upload(ctx, key, p.records)
+	if err != nil {
+		a.log.Errorn("Uploading raw requests archive",
+			obskit.WorkspaceID(key.workspaceID),
+			logger.NewIntField("requests", int64(len(p.records))),
+			obskit.Error(err),
+		)
+		a.stats.NewTaggedStat("gateway.raw_archive_failed_requests", stats.CountType, tags).Count(len(p.records))
+		return
+	}
+	a.log.Debugn("Uploaded raw requests archive",
+		obskit.WorkspaceID(key.workspaceID),
+		logger.NewStringField("location", location),
+		logger.NewIntField("requests", int64(len(p.records))),
+	)
+	a.stats.NewTaggedStat("gateway.raw_archive_upload_time", stats.TimerType, tags).Since(start)
+	a.stats.NewTaggedStat("gateway.raw_archive_requests", stats.CountType, tags).Count(len(p.records))
+	a.stats.NewTaggedStat("gateway.raw_archive_bytes", stats.CountType, tags).Count(int(p.size))
+}
+
+func (a *Archiver) upload(ctx context.Context, key partitionKey, records []Record) (string, error) {
+	first, last := records[0].ReceivedAt, records[0].ReceivedAt
+	for _, record := range records[1:] {
+		first = lo.Ternary(record.ReceivedAt.Before(first), record.ReceivedAt, first)
+		last = lo.Ternary(record.ReceivedAt.After(last), record.ReceivedAt, last)
+	}
+	tmpDir, err := misc.CreateTMPDIR()
+	if err != nil {
+		return "", fmt.Errorf("creating tmp dir: %w", err)
+	}
+	filePath := path.Join(tmpDir, "rudder-raw-requests", key.sourceID, fileName(first, last, uuid.NewString()))
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return "", fmt.Errorf("creating gz file %q: mkdir error: %w", filePath, err)
+	}
+	gzWriter, err := misc.CreateGZ(filePath)
+	if err != nil {
+		return "", fmt.Errorf("create gz writer: %w", err)
+	}
+	defer func() { _ = os.Remove(filePath) }()
+	for i := range records {
+		line, err := json.Marshal(records[i])
+		if err != nil {
+			_ = gzWriter.Close()
+			return "", fmt.Errorf("marshal raw request: %w", err)
+		}
+		if _, err := gzWriter.Write(append(line, '\n')); err != nil {
+			_ = gzWriter.Close()
+			return "", fmt.Errorf("write to file: %w", err)
+		}
+	}
+	if err := gzWriter.Close(); err != nil {
+		return "", fmt.Errorf("close writer: %w", err)
+	}
+
+	fm, err := a.storage.GetFileManager(ctx, key.workspaceID)
+	if err != nil {
+		return "", fmt.Errorf("no file manager found: %w", err)
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("open file %s: %w", filePath, err)
+	}
+	defer func() { _ = file.Close() }()
+	prefixes := append(partitionPrefixes(a.conf.prefix, key.sourceID, first), a.conf.instanceID)
+	uploaded, err := fm.Upload(ctx, file, prefixes...)
+	if err != nil {
+		return "", fmt.Errorf("upload file to object storage: %w", err)
+	}
+	return uploaded.Location, nil
+}
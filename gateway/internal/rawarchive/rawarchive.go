@@ -0,0 +1,62 @@
+// Package rawarchive archives raw gateway request bodies to object storage and replays them back for a selected time range.
+//
+// Archived files are partitioned by source and time, using the following object key layout:
+//
+//	<prefix>/<sourceID>/<yyyy-mm-dd>/<hh>/<instanceID>/<firstReceivedAt>_<lastReceivedAt>_<uuid>.json.gz
+//
+// where firstReceivedAt and lastReceivedAt are unix timestamps (seconds) of the first and last request contained in the file.
+// Every line of an archived file is a json encoded [Record].
+package rawarchive
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dateFormat = "2006-01-02"
+	hourFormat = "15"
+)
+
+// Record is a raw request received by the gateway, along with the information needed for replaying it
+type Record struct {
+	ReceivedAt  time.Time       `json:"receivedAt"`
+	WorkspaceID string          `json:"workspaceId"`
+	SourceID    string          `json:"sourceId"`
+	RequestType string          `json:"requestType"`
+	IP          string          `json:"ip"`
+	AnonymousID string          `json:"anonymousId,omitempty"`
+	Body        json.RawMessage `json:"body"`
+}
+
+// partitionPrefixes returns the object key prefixes of the partition a request received at [receivedAt] for [sourceID] belongs to
+func partitionPrefixes(prefix, sourceID string, receivedAt time.Time) []string {
+	receivedAt = receivedAt.UTC()
+	return []string{prefix, sourceID, receivedAt.Format(dateFormat), receivedAt.Format(hourFormat)}
+}
+
+// fileName returns the name of an archive file containing requests received between [first] and [last]
+func fileName(first, last time.Time, id string) string {
+	return fmt.Sprintf("%d_%d_%s.json.gz", first.Unix(), last.Unix(), id)
+}
+
+// parseFileName returns the time range of the requests contained in an archive file, as encoded in its object key
+func parseFileName(key string) (first, last time.Time, err error) {
+	tokens := strings.SplitN(path.Base(key), "_", 3)
+	if len(tokens) != 3 {
+		return first, last, fmt.Errorf("invalid archive file name %q", key)
+	}
+	firstUnix, err := strconv.ParseInt(tokens[0], 10, 64)
+	if err != nil {
+		return first, last, fmt.Errorf("parsing first received at of %q: %w", key, err)
+	}
+	lastUnix, err := strconv.ParseInt(tokens[1], 10, 64)
+	if err != nil {
+		return first, last, fmt.Errorf("parsing last received at of %q: %w", key, err)
+	}
+	return time.Unix(firstUnix, 0).UTC(), time.Unix(lastUnix, 0).UTC(), nil
+}
@@ -0,0 +1,148 @@
+package rawarchive_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/minio"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/gateway/internal/rawarchive"
+	"github.com/rudderlabs/rudder-server/services/fileuploader"
+)
+
+func TestArchiveAndReplay(t *testing.T) {
+	t.Setenv("RUDDER_TMPDIR", t.TempDir())
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	minioResource, err := minio.Setup(pool, t)
+	require.NoError(t, err)
+
+	storage := fileuploader.NewStaticProvider(map[string]fileuploader.StorageSettings{
+		"workspace-1": {
+			Bucket: backendconfig.StorageBucket{
+				Type: "MINIO",
+				Config: map[string]interface{}{
+					"bucketName":      minioResource.BucketName,
+					"prefix":          "some-prefix",
+					"endPoint":        minioResource.Endpoint,
+					"accessKeyID":     minioResource.AccessKeyID,
+					"secretAccessKey": minioResource.AccessKeySecret,
+				},
+			},
+		},
+	})
+	c := config.New()
+	archiver := rawarchive.NewArchiver(c, storage, logger.NOP, stats.NOP)
+	replayer := rawarchive.NewReplayer(c, storage, logger.NOP)
+
+	hour := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	newRecord := func(sourceID string, receivedAt time.Time, messageID string) rawarchive.Record {
+		return rawarchive.Record{
+			ReceivedAt:  receivedAt,
+			WorkspaceID: "workspace-1",
+			SourceID:    sourceID,
+			RequestType: "track",
+			IP:          "1.2.3.4",
+			AnonymousID: "anon-1",
+			Body:        json.RawMessage(`{"messageId":"` + messageID + `","anonymousId":"anon-1"}`),
+		}
+	}
+	records := []rawarchive.Record{
+		newRecord("source-1", hour.Add(10*time.Minute), "1"),
+		newRecord("source-1", hour.Add(50*time.Minute), "2"),
+		newRecord("source-1", hour.Add(70*time.Minute), "3"),
+		newRecord("source-1", hour.Add(130*time.Minute), "4"),
+		newRecord("source-2", hour.Add(20*time.Minute), "5"),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		archiver.Run(ctx)
+	}()
+	for _, record := range records {
+		require.True(t, archiver.Archive(record))
+	}
+	cancel() // uploads all buffered requests
+	wg.Wait()
+
+	replay := func(t *testing.T, req rawarchive.ReplayRequest) ([]string, rawarchive.ReplayResult) {
+		t.Helper()
+		var messageIDs []string
+		res, err := replayer.Replay(context.Background(), req, func(_ context.Context, record rawarchive.Record) error {
+			var body struct {
+				MessageID string `json:"messageId"`
+			}
+			require.NoError(t, json.Unmarshal(record.Body, &body))
+			require.Equal(t, req.SourceID, record.SourceID)
+			require.Equal(t, "anon-1", record.AnonymousID)
+			messageIDs = append(messageIDs, body.MessageID)
+			return nil
+		})
+		require.NoError(t, err)
+		return messageIDs, res
+	}
+
+	t.Run("replay range spanning multiple partitions", func(t *testing.T) {
+		messageIDs, res := replay(t, rawarchive.ReplayRequest{
+			WorkspaceID: "workspace-1",
+			SourceID:    "source-1",
+			Start:       hour.Add(30 * time.Minute),
+			End:         hour.Add(2 * time.Hour),
+		})
+		require.Equal(t, []string{"2", "3"}, messageIDs)
+		require.Equal(t, rawarchive.ReplayResult{Files: 2, Requests: 2}, res)
+	})
+
+	t.Run("replay is limited to the requested source", func(t *testing.T) {
+		messageIDs, _ := replay(t, rawarchive.ReplayRequest{
+			WorkspaceID: "workspace-1",
+			SourceID:    "source-2",
+			Start:       hour,
+			End:         hour.Add(3 * time.Hour),
+		})
+		require.Equal(t, []string{"5"}, messageIDs)
+	})
+
+	t.Run("nothing archived in range", func(t *testing.T) {
+		messageIDs, res := replay(t, rawarchive.ReplayRequest{
+			WorkspaceID: "workspace-1",
+			SourceID:    "source-1",
+			Start:       hour.Add(-2 * time.Hour),
+			End:         hour,
+		})
+		require.Empty(t, messageIDs)
+		require.Zero(t, res.Files)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, err := replayer.Replay(context.Background(), rawarchive.ReplayRequest{WorkspaceID: "workspace-1", SourceID: "source-1", Start: hour, End: hour}, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestArchiveDropsWhenFull(t *testing.T) {
+	c := config.New()
+	c.Set("Gateway.rawArchive.bufferSize", 1)
+	statsStore, err := memstats.New()
+	require.NoError(t, err)
+	archiver := rawarchive.NewArchiver(c, fileuploader.NewStaticProvider(nil), logger.NOP, statsStore)
+
+	record := rawarchive.Record{WorkspaceID: "workspace-1", SourceID: "source-1", Body: json.RawMessage(`{}`)}
+	require.True(t, archiver.Archive(record))
+	require.False(t, archiver.Archive(record), "requests are dropped while the buffer is full")
+	require.EqualValues(t, 1, statsStore.Get("gateway.raw_archive_dropped_requests", stats.Tags{"workspaceId": "workspace-1", "sourceId": "source-1"}).LastValue())
+}
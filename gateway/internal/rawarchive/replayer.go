@@ -0,0 +1,159 @@
+package rawarchive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/services/fileuploader"
+)
+
+// ReplayRequest selects the archived requests of a source that were received within [Start, End)
+type ReplayRequest struct {
+	WorkspaceID string    `json:"workspaceId"`
+	SourceID    string    `json:"sourceId"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+}
+
+// Validate returns an error if the replay request is incomplete or its time range is empty
+func (r ReplayRequest) Validate() error {
+	if r.SourceID == "" {
+		return errors.New("sourceId is required")
+	}
+	if r.Start.IsZero() || r.End.IsZero() {
+		return errors.New("start and end are required")
+	}
+	if !r.Start.Before(r.End) {
+		return errors.New("start must be before end")
+	}
+	return nil
+}
+
+// ReplayResult summarises a completed replay
+type ReplayResult struct {
+	Files    int `json:"files"`
+	Requests int `json:"requests"`
+}
+
+// Sink receives the archived requests during a replay. Returning an error aborts the replay.
+type Sink func(ctx context.Context, record Record) error
+
+// Replayer reads archived raw requests back from object storage
+type Replayer struct {
+	storage fileuploader.Provider
+	log     logger.Logger
+
+	conf struct {
+		prefix       string
+		listMaxItems int64
+	}
+}
+
+// NewReplayer creates a new replayer for raw gateway requests archived by an [Archiver] using the same configuration
+func NewReplayer(conf *config.Config, storage fileuploader.Provider, log logger.Logger) *Replayer {
+	r := &Replayer{
+		storage: storage,
+		log:     log.Child("rawarchive"),
+	}
+	r.conf.prefix = conf.GetStringVar("rudder-raw-requests", "Gateway.rawArchive.prefix")
+	r.conf.listMaxItems = conf.GetInt64Var(1000, 1, "Gateway.rawArchive.listMaxItems")
+	return r
+}
+
+// Replay sends every archived request matching the replay request to the sink, in the order of the hourly partitions they belong to
+func (r *Replayer) Replay(ctx context.Context, req ReplayRequest, sink Sink) (ReplayResult, error) {
+	var res ReplayResult
+	if err := req.Validate(); err != nil {
+		return res, err
+	}
+	fm, err := r.storage.GetFileManager(ctx, req.WorkspaceID)
+	if err != nil {
+		return res, fmt.Errorf("no file manager found: %w", err)
+	}
+	start, end := req.Start.UTC(), req.End.UTC()
+	for hour := start.Truncate(time.Hour); hour.Before(end); hour = hour.Add(time.Hour) {
+		prefix := path.Join(fm.Prefix(), path.Join(partitionPrefixes(r.conf.prefix, req.SourceID, hour)...)) + "/"
+		iter := filemanager.IterateFilesWithPrefix(ctx, prefix, "", r.conf.listMaxItems, fm)
+		for iter.Next() {
+			key := iter.Get().Key
+			first, last, err := parseFileName(key)
+			if err != nil {
+				r.log.Warnn("Skipping unexpected file in raw requests archive", logger.NewStringField("key", key), obskit.Error(err))
+				continue
+			}
+			if last.Before(start.Truncate(time.Second)) || !first.Before(end) {
+				continue
+			}
+			n, err := r.replayFile(ctx, fm, key, start, end, sink)
+			if err != nil {
+				return res, fmt.Errorf("replaying %q: %w", key, err)
+			}
+			res.Files++
+			res.Requests += n
+		}
+		if err := iter.Err(); err != nil {
+			return res, fmt.Errorf("listing files with prefix %q: %w", prefix, err)
+		}
+	}
+	return res, nil
+}
+
+// replayFile sends the requests of an archive file which were received within [start, end) to the sink, returning their number
+func (r *Replayer) replayFile(ctx context.Context, fm filemanager.FileManager, key string, start, end time.Time, sink Sink) (int, error) {
+	file, err := os.CreateTemp("", "rudder-raw-requests-*.json.gz")
+	if err != nil {
+		return 0, fmt.Errorf("creating tmp file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+	if err := fm.Download(ctx, file, key); err != nil {
+		return 0, fmt.Errorf("downloading file: %w", err)
+	}
+	// some file managers replace the file instead of writing to it, so it needs to be reopened
+	rawFile, err := os.Open(file.Name())
+	if err != nil {
+		return 0, fmt.Errorf("opening downloaded file: %w", err)
+	}
+	defer func() { _ = rawFile.Close() }()
+	reader, err := gzip.NewReader(rawFile)
+	if err != nil {
+		return 0, fmt.Errorf("creating gzip reader: %w", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	var n int
+	sc := bufio.NewScanner(reader)
+	// raw requests can be up to Gateway.maxReqSizeInKB, so allow for considerably larger lines than the default 64KB
+	sc.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 100*1024*1024)
+	for sc.Scan() {
+		var record Record
+		if err := json.Unmarshal(sc.Bytes(), &record); err != nil {
+			return n, fmt.Errorf("unmarshalling raw request: %w", err)
+		}
+		if record.ReceivedAt.Before(start) || !record.ReceivedAt.Before(end) {
+			continue
+		}
+		if err := sink(ctx, record); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := sc.Err(); err != nil {
+		return n, fmt.Errorf("reading file: %w", err)
+	}
+	return n, nil
+}
@@ -12,6 +12,8 @@ const (
 	CtxParamCallType ContextKey = "rudder.gateway.callType"
 	// CtxParamAuthRequestContext is the key for the auth request context in the request context.
 	CtxParamAuthRequestContext ContextKey = "rudder.gateway.authRequestContext"
+	// CtxParamGranularResponse is the key for the per-message response collector in the request context.
+	CtxParamGranularResponse ContextKey = "rudder.gateway.granularResponse"
 )
 
 // AuthRequestContext contains the authenticated source information for a request.
@@ -30,6 +30,12 @@ const (
 	EmptyBatchPayload = "Empty batch payload"
 	// InvalidWebhookSource - Source does not accept webhook events
 	InvalidWebhookSource = "Source does not accept webhook events"
+	// InvalidWebhookSignature - Webhook signature verification failed
+	InvalidWebhookSignature = "Invalid webhook signature"
+	// MissingWebhookSigningSecret - Source requires webhook signatures, but no signing secret is configured for it
+	MissingWebhookSigningSecret = "Webhook signing secret is not configured"
+	// WebhookTransformationFailed - Failed to transform webhook payload to rudder events
+	WebhookTransformationFailed = "Failed to transform webhook payload"
 	// SourceTransformerResponseErrorReadFailed - Failed to read error from source transformer response
 	SourceTransformerResponseErrorReadFailed = "Failed to read error from source transformer response"
 	// SourceDisabled - write key is present, but the source for it is disabled.
@@ -60,6 +66,12 @@ const (
 	GatewayTimeout = "Gateway timeout"
 	// ServiceUnavailable - Service unavailable
 	ServiceUnavailable = "Service unavailable"
+	// InvalidCloudEvent - Request does not contain valid CloudEvents
+	InvalidCloudEvent = "Invalid CloudEvent"
+	// InvalidOTLPRequest - Request is not a valid OTLP/HTTP export request
+	InvalidOTLPRequest = "Invalid OTLP request"
+	// GatewaySaturated - Gateway is not accepting events temporarily due to backpressure
+	GatewaySaturated = "Gateway is temporarily not accepting events, please retry later"
 	// NoSourceIdInHeader - Failed to read source id from header
 	NoSourceIdInHeader = "Failed to read source id from header"
 	// InvalidSourceID - Invalid source id
@@ -102,6 +114,9 @@ var statusMap = map[string]status{
 
 	// webhook specific status
 	InvalidWebhookSource:                           {message: InvalidWebhookSource, code: http.StatusNotFound},
+	InvalidWebhookSignature:                        {message: InvalidWebhookSignature, code: http.StatusUnauthorized},
+	MissingWebhookSigningSecret:                    {message: MissingWebhookSigningSecret, code: http.StatusUnauthorized},
+	WebhookTransformationFailed:                    {message: WebhookTransformationFailed, code: http.StatusBadRequest},
 	SourceTransformerFailed:                        {message: SourceTransformerFailed, code: http.StatusBadRequest},
 	SourceTransformerResponseErrorReadFailed:       {message: SourceTransformerResponseErrorReadFailed, code: http.StatusInternalServerError},
 	SourceTransformerFailedToReadOutput:            {message: SourceTransformerFailedToReadOutput, code: http.StatusInternalServerError},
@@ -116,6 +131,9 @@ var statusMap = map[string]status{
 	ContextDeadlineExceeded:                        {message: GatewayTimeout, code: http.StatusGatewayTimeout},
 	GatewayTimeout:                                 {message: GatewayTimeout, code: http.StatusGatewayTimeout},
 	ServiceUnavailable:                             {message: ServiceUnavailable, code: http.StatusServiceUnavailable},
+	InvalidCloudEvent:                              {message: InvalidCloudEvent, code: http.StatusBadRequest},
+	InvalidOTLPRequest:                             {message: InvalidOTLPRequest, code: http.StatusBadRequest},
+	GatewaySaturated:                               {message: GatewaySaturated, code: http.StatusServiceUnavailable},
 }
 
 // status holds the gateway response status message and code
@@ -2,11 +2,14 @@ package gateway
 
 import (
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/rudderlabs/rudder-go-kit/stats"
 	gwtypes "github.com/rudderlabs/rudder-server/gateway/internal/types"
+	"github.com/rudderlabs/rudder-server/gateway/response"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 )
 
@@ -27,6 +30,53 @@ type webRequestT struct {
 	ipAddr         string
 	userIDHeader   string
 	errors         []string
+	// granularResponse collects per-message results, only set if the client requested a granular response
+	granularResponse *granularResponse
+	// receivedAt overrides the time the request was received at, only set for replayed requests
+	receivedAt time.Time
+	// backfillID and destinationIDs are only set for backfilled events, which are only routed to these destinations
+	backfillID     string
+	destinationIDs []string
+}
+
+const (
+	messageStatusAccepted   = "accepted"
+	messageStatusRejected   = "rejected"
+	messageStatusSuppressed = "suppressed"
+)
+
+// messageResponse is the outcome of a single message of a batch request
+type messageResponse struct {
+	Index     int    `json:"index"`
+	MessageID string `json:"messageId,omitempty"`
+	Status    string `json:"status"`
+	JobUUID   string `json:"jobUUID,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// granularResponse is the response body returned for batch requests when the client has requested per-message responses,
+// allowing clients to retry only the messages which failed instead of whole batches
+type granularResponse struct {
+	Messages []messageResponse `json:"messages"`
+}
+
+func (gr *granularResponse) accepted(index int, messageID string, jobUUID uuid.UUID) {
+	gr.Messages = append(gr.Messages, messageResponse{Index: index, MessageID: messageID, Status: messageStatusAccepted, JobUUID: jobUUID.String()})
+}
+
+func (gr *granularResponse) rejected(index int, messageID, reason string) {
+	gr.Messages = append(gr.Messages, messageResponse{Index: index, MessageID: messageID, Status: messageStatusRejected, Error: response.GetStatus(reason)})
+}
+
+func (gr *granularResponse) suppressed(index int, messageID string) {
+	gr.Messages = append(gr.Messages, messageResponse{Index: index, MessageID: messageID, Status: messageStatusSuppressed})
+}
+
+// sort sorts the message responses by their index in the batch
+func (gr *granularResponse) sort() {
+	slices.SortFunc(gr.Messages, func(a, b messageResponse) int {
+		return a.Index - b.Index
+	})
 }
 
 type batchWebRequestT struct {
@@ -45,6 +95,7 @@ type batchUserWorkerBatchRequestT struct {
 type sourceDebugger struct {
 	data     []byte
 	writeKey string
+	sourceID string
 }
 
 // userWebRequestWorkerT is a basic worker unit that works on incoming webRequests.
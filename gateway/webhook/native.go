@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	kithttputil "github.com/rudderlabs/rudder-go-kit/httputil"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	gwtypes "github.com/rudderlabs/rudder-server/gateway/internal/types"
+	"github.com/rudderlabs/rudder-server/gateway/response"
+	"github.com/rudderlabs/rudder-server/gateway/webhook/provider"
+)
+
+const (
+	// signingSecretConfigKey is the source config key holding the secret used for verifying webhook signatures
+	signingSecretConfigKey = "webhookSigningSecret"
+	// requireSignatureConfigKey is the source config key which, if true, rejects requests of the source when no secret
+	// is configured for verifying their signatures, instead of accepting them unverified
+	requireSignatureConfigKey = "webhookRequireSignature"
+)
+
+// nativeProvider returns the native provider for the given source definition, if native handling is enabled for it
+func (webhook *HandleT) nativeProvider(sourceDefName string) (provider.Provider, bool) {
+	if _, ok := webhook.config.nativeProviderSrcMap[strings.ToLower(sourceDefName)]; !ok {
+		return nil, false
+	}
+	return provider.Get(sourceDefName)
+}
+
+// nativeRequestHandler handles a webhook request using a native provider: the request's signature is verified
+// and its payload is transformed to rudder events in-process, which are then enqueued in the gateway as a batch request.
+func (webhook *HandleT) nativeRequestHandler(p provider.Provider, w http.ResponseWriter, r *http.Request, reqType string, arctx *gwtypes.AuthRequestContext) {
+	defer atomic.AddUint64(&webhook.ackCount, 1)
+	ss := webhook.gwHandle.NewSourceStat(arctx, reqType)
+	fail := func(reason, errorMessage string, code int) {
+		webhook.gwHandle.TrackRequestMetrics(errorMessage)
+		webhook.stats.NewTaggedStat("webhook_native_errors", stats.CountType, stats.Tags{
+			"sourceType":  p.Name(),
+			"sourceID":    arctx.SourceID,
+			"workspaceId": arctx.WorkspaceID,
+			"reason":      reason,
+		}).Increment()
+		ss.RequestFailed(reason)
+		ss.Report(webhook.stats)
+		webhook.failRequest(w, r, errorMessage, code)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, int64(webhook.config.maxReqSize.Load())+1))
+	_ = r.Body.Close()
+	if err != nil {
+		fail("requestBodyReadFailed", response.GetStatus(response.RequestBodyReadFailed), response.GetErrorStatusCode(response.RequestBodyReadFailed))
+		return
+	}
+	if len(body) > webhook.config.maxReqSize.Load() {
+		fail("requestBodyTooLarge", response.GetStatus(response.RequestBodyTooLarge), response.GetErrorStatusCode(response.RequestBodyTooLarge))
+		return
+	}
+
+	secret, _ := arctx.Source.Config[signingSecretConfigKey].(string)
+	if requireSignature, _ := arctx.Source.Config[requireSignatureConfigKey].(bool); requireSignature && secret == "" {
+		webhook.logger.Infof("IP: %s -- %s -- webhook signing secret is not configured for source %s, which requires signatures", kithttputil.GetRequestIP(r), r.URL.Path, arctx.SourceID)
+		fail("missingSigningSecret", response.GetStatus(response.MissingWebhookSigningSecret), response.GetErrorStatusCode(response.MissingWebhookSigningSecret))
+		return
+	}
+	if secret != "" {
+		if err := p.Verify(r.Header, body, secret); err != nil {
+			webhook.logger.Infof("IP: %s -- %s -- webhook signature verification failed for source %s: %v", kithttputil.GetRequestIP(r), r.URL.Path, arctx.SourceID, err)
+			fail("invalidSignature", response.GetStatus(response.InvalidWebhookSignature), response.GetErrorStatusCode(response.InvalidWebhookSignature))
+			return
+		}
+	}
+
+	events, err := p.Transform(r.Header, body)
+	if err == nil && len(events) == 0 {
+		err = errors.New("no events")
+	}
+	if err != nil {
+		webhook.logger.Infof("IP: %s -- %s -- webhook %s native transformation failed: %v", kithttputil.GetRequestIP(r), r.URL.Path, p.Name(), err)
+		fail("transformationFailed", response.GetStatus(response.WebhookTransformationFailed), response.GetErrorStatusCode(response.WebhookTransformationFailed))
+		return
+	}
+	payload, err := json.Marshal(map[string]any{"batch": events})
+	if err != nil {
+		fail("couldNotMarshal", response.GetStatus(response.ErrorInMarshal), response.GetErrorStatusCode(response.ErrorInMarshal))
+		return
+	}
+
+	if errorMessage := webhook.gwHandle.ProcessWebRequest(&w, r, "batch", payload, arctx); errorMessage != "" {
+		fail("enqueueInGatewayFailed", response.GetStatus(errorMessage), response.GetErrorStatusCode(errorMessage))
+		return
+	}
+	webhook.gwHandle.TrackRequestMetrics("")
+	_, _ = w.Write([]byte(response.GetStatus(response.Ok)))
+	ss.RequestSucceeded()
+	ss.Report(webhook.stats)
+}
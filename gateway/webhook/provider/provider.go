@@ -0,0 +1,71 @@
+// Package provider contains native webhook source providers.
+//
+// A provider verifies the authenticity of incoming webhook requests and maps their payloads to rudder events,
+// without requiring a roundtrip to the source transformer. New providers can be added by implementing the [Provider]
+// interface and registering it using [Register], without touching the gateway core.
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrInvalidSignature is returned when the signature of a webhook request cannot be verified
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrMissingSignature is returned when a webhook request doesn't contain the expected signature
+	ErrMissingSignature = errors.New("missing webhook signature")
+)
+
+// Provider is a native webhook source provider
+type Provider interface {
+	// Name returns the source definition name that this provider handles, e.g. "Stripe"
+	Name() string
+	// Verify verifies the authenticity of the webhook request using the secret configured for the source
+	Verify(header http.Header, body []byte, secret string) error
+	// Transform maps the webhook payload to one or more rudder events
+	Transform(header http.Header, body []byte) ([]map[string]any, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Provider{}
+)
+
+func init() {
+	Register(&stripe{})
+	Register(&shopify{})
+	Register(&sendgrid{})
+}
+
+// Register registers a provider, replacing any provider previously registered with the same name
+func Register(p Provider) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(p.Name())] = p
+}
+
+// Get returns the provider registered for the given source definition name (case insensitive)
+func Get(name string) (Provider, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	p, ok := registry[strings.ToLower(name)]
+	return p, ok
+}
+
+// newEvent returns a rudder event of the given type, tagged with the provider's integration name
+func newEvent(eventType, integration string) map[string]any {
+	return map[string]any{
+		"type": eventType,
+		"context": map[string]any{
+			"library": map[string]any{
+				"name": "unknown",
+			},
+			"integration": map[string]any{
+				"name": integration,
+			},
+		},
+	}
+}
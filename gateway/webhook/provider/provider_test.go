@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	for _, name := range []string{"Stripe", "shopify", "SENDGRID"} {
+		_, ok := Get(name)
+		require.True(t, ok, "provider %q should be registered", name)
+	}
+	_, ok := Get("unknown")
+	require.False(t, ok)
+}
+
+func TestStripe(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := &stripe{now: func() time.Time { return now }}
+	body := []byte(`{"id":"evt_1","type":"charge.succeeded","created":1704067200,"data":{"object":{"id":"ch_1","customer":"cus_1","amount":100}}}`)
+
+	sign := func(ts time.Time, secret string) http.Header {
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "." + string(body)))
+		h := http.Header{}
+		h.Set("Stripe-Signature", "t="+timestamp+",v1="+hex.EncodeToString(mac.Sum(nil)))
+		return h
+	}
+
+	t.Run("verify", func(t *testing.T) {
+		require.NoError(t, p.Verify(sign(now, "secret"), body, "secret"))
+		require.ErrorIs(t, p.Verify(sign(now, "other"), body, "secret"), ErrInvalidSignature)
+		require.ErrorIs(t, p.Verify(sign(now.Add(-time.Hour), "secret"), body, "secret"), ErrInvalidSignature)
+		require.ErrorIs(t, p.Verify(http.Header{}, body, "secret"), ErrMissingSignature)
+	})
+
+	t.Run("transform", func(t *testing.T) {
+		events, err := p.Transform(nil, body)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, "track", events[0]["type"])
+		require.Equal(t, "charge.succeeded", events[0]["event"])
+		require.Equal(t, "evt_1", events[0]["messageId"])
+		require.Equal(t, "cus_1", events[0]["anonymousId"])
+		require.Equal(t, "2024-01-01T00:00:00Z", events[0]["originalTimestamp"])
+
+		_, err = p.Transform(nil, []byte(`{"id":"evt_1"}`))
+		require.Error(t, err)
+	})
+}
+
+func TestShopify(t *testing.T) {
+	p := &shopify{}
+	body := []byte(`{"id":1001,"total_price":"10.00","customer":{"id":42}}`)
+
+	t.Run("verify", func(t *testing.T) {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(body)
+		h := http.Header{}
+		h.Set("X-Shopify-Hmac-Sha256", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		require.NoError(t, p.Verify(h, body, "secret"))
+		require.ErrorIs(t, p.Verify(h, body, "other"), ErrInvalidSignature)
+		require.ErrorIs(t, p.Verify(http.Header{}, body, "secret"), ErrMissingSignature)
+	})
+
+	t.Run("transform track", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("X-Shopify-Topic", "orders/create")
+		h.Set("X-Shopify-Webhook-Id", "wh-1")
+		events, err := p.Transform(h, body)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, "track", events[0]["type"])
+		require.Equal(t, "orders/create", events[0]["event"])
+		require.Equal(t, "42", events[0]["userId"])
+		require.Equal(t, "wh-1", events[0]["messageId"])
+	})
+
+	t.Run("transform identify", func(t *testing.T) {
+		h := http.Header{}
+		h.Set("X-Shopify-Topic", "customers/update")
+		events, err := p.Transform(h, []byte(`{"id":42,"email":"user@example.com"}`))
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, "identify", events[0]["type"])
+		require.Equal(t, "42", events[0]["userId"])
+	})
+
+	t.Run("transform without topic", func(t *testing.T) {
+		_, err := p.Transform(http.Header{}, body)
+		require.Error(t, err)
+	})
+}
+
+func TestSendGrid(t *testing.T) {
+	p := &sendgrid{}
+	body := []byte(`[{"email":"user@example.com","event":"delivered","sg_event_id":"sg-1","timestamp":1704067200},{"event":"open","sg_event_id":"sg-2"}]`)
+
+	t.Run("verify", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		require.NoError(t, err)
+		secret := base64.StdEncoding.EncodeToString(der)
+
+		timestamp := "1704067200"
+		hash := sha256.Sum256(append([]byte(timestamp), body...))
+		sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+		require.NoError(t, err)
+
+		h := http.Header{}
+		h.Set("X-Twilio-Email-Event-Webhook-Signature", base64.StdEncoding.EncodeToString(sig))
+		h.Set("X-Twilio-Email-Event-Webhook-Timestamp", timestamp)
+		require.NoError(t, p.Verify(h, body, secret))
+		require.ErrorIs(t, p.Verify(h, append(body, ' '), secret), ErrInvalidSignature)
+		require.ErrorIs(t, p.Verify(http.Header{}, body, secret), ErrMissingSignature)
+		require.Error(t, p.Verify(h, body, "invalid"))
+	})
+
+	t.Run("transform", func(t *testing.T) {
+		events, err := p.Transform(nil, body)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.Equal(t, "delivered", events[0]["event"])
+		require.Equal(t, "user@example.com", events[0]["anonymousId"])
+		require.Equal(t, "sg-1", events[0]["messageId"])
+		require.Equal(t, "2024-01-01T00:00:00Z", events[0]["originalTimestamp"])
+		require.Equal(t, "open", events[1]["event"])
+		require.Equal(t, "sg-2", events[1]["anonymousId"])
+	})
+}
@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// sendgrid verifies requests using sendgrid's signed event webhook headers and maps every sendgrid event to a track event
+type sendgrid struct{}
+
+func (*sendgrid) Name() string { return "SendGrid" }
+
+// Verify verifies the X-Twilio-Email-Event-Webhook-Signature header, which is a base64 encoded ECDSA signature of
+// the SHA256 hash of "<timestamp><body>". The secret is the base64 encoded verification public key provided by sendgrid.
+func (*sendgrid) Verify(header http.Header, body []byte, secret string) error {
+	signature := header.Get("X-Twilio-Email-Event-Webhook-Signature")
+	timestamp := header.Get("X-Twilio-Email-Event-Webhook-Timestamp")
+	if signature == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+	der, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return fmt.Errorf("decoding sendgrid public key: %w", err)
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return fmt.Errorf("parsing sendgrid public key: %w", err)
+	}
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("sendgrid public key is not an ECDSA key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("decoding sendgrid signature: %w", ErrInvalidSignature)
+	}
+	hash := sha256.Sum256(append([]byte(timestamp), body...))
+	if !ecdsa.VerifyASN1(ecdsaPub, hash[:], sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Transform maps every event of the sendgrid events array to a track event named after the sendgrid event
+func (s *sendgrid) Transform(_ http.Header, body []byte) ([]map[string]any, error) {
+	var events []map[string]any
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("unmarshalling sendgrid events: %w", err)
+	}
+	res := make([]map[string]any, 0, len(events))
+	for _, event := range events {
+		name, _ := event["event"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("sendgrid event name is missing")
+		}
+		e := newEvent("track", s.Name())
+		e["event"] = name
+		e["properties"] = event
+		if email, ok := event["email"].(string); ok && email != "" {
+			e["anonymousId"] = email
+			e["context"].(map[string]any)["traits"] = map[string]any{"email": email}
+		}
+		if id, ok := event["sg_event_id"].(string); ok {
+			e["messageId"] = id
+			if e["anonymousId"] == nil {
+				e["anonymousId"] = id
+			}
+		}
+		if ts, ok := event["timestamp"].(float64); ok {
+			e["originalTimestamp"] = time.Unix(int64(ts), 0).UTC().Format(time.RFC3339)
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// shopify verifies requests using the X-Shopify-Hmac-Sha256 header and maps shopify webhook topics to rudder events
+type shopify struct{}
+
+func (*shopify) Name() string { return "Shopify" }
+
+// Verify verifies the X-Shopify-Hmac-Sha256 header, which is the base64 encoded HMAC-SHA256 of the body
+func (*shopify) Verify(header http.Header, body []byte, secret string) error {
+	signature := header.Get("X-Shopify-Hmac-Sha256")
+	if signature == "" {
+		return ErrMissingSignature
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("decoding shopify signature: %w", ErrInvalidSignature)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Transform maps customer topics to identify events and every other topic to a track event named after the topic
+func (s *shopify) Transform(header http.Header, body []byte) ([]map[string]any, error) {
+	topic := header.Get("X-Shopify-Topic")
+	if topic == "" {
+		return nil, fmt.Errorf("shopify topic header is missing")
+	}
+	var resource map[string]any
+	if err := json.Unmarshal(body, &resource); err != nil {
+		return nil, fmt.Errorf("unmarshalling shopify payload: %w", err)
+	}
+
+	var e map[string]any
+	if strings.HasPrefix(topic, "customers/") {
+		e = newEvent("identify", s.Name())
+		e["userId"] = stringID(resource["id"])
+		e["traits"] = resource
+	} else {
+		e = newEvent("track", s.Name())
+		e["event"] = topic
+		e["properties"] = resource
+		if customer, ok := resource["customer"].(map[string]any); ok {
+			e["userId"] = stringID(customer["id"])
+		}
+	}
+	if webhookID := header.Get("X-Shopify-Webhook-Id"); webhookID != "" {
+		e["messageId"] = webhookID
+	}
+	if e["userId"] == nil || e["userId"] == "" {
+		e["anonymousId"] = stringID(resource["id"])
+	}
+	return []map[string]any{e}, nil
+}
+
+// stringID converts shopify numeric identifiers to strings
+func stringID(id any) string {
+	switch v := id.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stripeSignatureTolerance is the maximum age of a stripe signature's timestamp
+const stripeSignatureTolerance = 5 * time.Minute
+
+// stripe verifies requests using the Stripe-Signature header and maps stripe events to track events
+type stripe struct {
+	now func() time.Time
+}
+
+func (*stripe) Name() string { return "Stripe" }
+
+// Verify verifies the Stripe-Signature header, which has the form t=<timestamp>,v1=<signature>[,v1=<signature>...],
+// where signature is the hex encoded HMAC-SHA256 of "<timestamp>.<body>"
+func (s *stripe) Verify(header http.Header, body []byte, secret string) error {
+	sigHeader := header.Get("Stripe-Signature")
+	if sigHeader == "" {
+		return ErrMissingSignature
+	}
+	var (
+		timestamp  string
+		signatures []string
+	)
+	for _, part := range strings.Split(sigHeader, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			timestamp = v
+		case "v1":
+			signatures = append(signatures, v)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrMissingSignature
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("parsing stripe signature timestamp: %w", ErrInvalidSignature)
+	}
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	if now().Sub(time.Unix(ts, 0)) > stripeSignatureTolerance {
+		return fmt.Errorf("stripe signature timestamp too old: %w", ErrInvalidSignature)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+	for _, signature := range signatures {
+		sig, err := hex.DecodeString(signature)
+		if err != nil {
+			continue
+		}
+		if hmac.Equal(sig, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// Transform maps a stripe event to a track event named after the stripe event type
+func (s *stripe) Transform(_ http.Header, body []byte) ([]map[string]any, error) {
+	var event struct {
+		ID      string `json:"id"`
+		Type    string `json:"type"`
+		Created int64  `json:"created"`
+		Data    struct {
+			Object map[string]any `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("unmarshalling stripe event: %w", err)
+	}
+	if event.Type == "" {
+		return nil, fmt.Errorf("stripe event type is missing")
+	}
+
+	e := newEvent("track", s.Name())
+	e["event"] = event.Type
+	e["messageId"] = event.ID
+	e["properties"] = event.Data.Object
+	if event.Created > 0 {
+		e["originalTimestamp"] = time.Unix(event.Created, 0).UTC().Format(time.RFC3339)
+	}
+	// use the stripe customer as the anonymous identifier, falling back to the event id
+	anonymousID := event.ID
+	if customer, ok := event.Data.Object["customer"].(string); ok && customer != "" {
+		anonymousID = customer
+	}
+	e["anonymousId"] = anonymousID
+	return []map[string]any{e}, nil
+}
@@ -67,6 +67,14 @@ func Setup(gwHandle Gateway, transformerFeaturesService transformer.FeaturesServ
 		},
 	)
 
+	// Sources handled by native providers, bypassing the source transformer
+	webhook.config.nativeProviderSrcMap = lo.SliceToMap(
+		config.GetStringSliceVar([]string{}, "Gateway.webhook.nativeProviderSources"),
+		func(item string) (string, struct{}) {
+			return strings.ToLower(item), struct{}{}
+		},
+	)
+
 	// lowercasing the strings in sourceListForParsingParams
 	for i, s := range webhook.config.sourceListForParsingParams {
 		webhook.config.sourceListForParsingParams[i] = strings.ToLower(s)
@@ -71,6 +71,7 @@ type HandleT struct {
 		maxWebhookBatchSize        config.ValueLoader[int]
 		sourceListForParsingParams []string
 		forwardGetRequestForSrcMap map[string]struct{}
+		nativeProviderSrcMap       map[string]struct{}
 	}
 }
 
@@ -124,6 +125,10 @@ func (webhook *HandleT) RequestHandler(w http.ResponseWriter, r *http.Request) {
 	if webhook.IsGetAndNotAllow(r.Method, sourceDefName) {
 		return
 	}
+	if p, ok := webhook.nativeProvider(sourceDefName); ok {
+		webhook.nativeRequestHandler(p, w, r, reqType, arctx)
+		return
+	}
 	contentType := r.Header.Get("Content-Type")
 	if strings.Contains(strings.ToLower(contentType), "application/x-www-form-urlencoded") {
 		if err := r.ParseForm(); err != nil {
@@ -3,6 +3,9 @@ package webhook
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,6 +17,7 @@ import (
 	"testing"
 	"testing/iotest"
 
+	"github.com/tidwall/gjson"
 	"go.uber.org/mock/gomock"
 
 	"github.com/rudderlabs/rudder-go-kit/bytesize"
@@ -624,3 +628,72 @@ func TestAllowGetReqForWebhookSrc(t *testing.T) {
 		})
 	}
 }
+
+func TestNativeProviderWebhook(t *testing.T) {
+	initWebhook()
+	ctrl := gomock.NewController(t)
+	mockGW := mockWebhook.NewMockGateway(ctrl)
+	mockTransformerFeaturesService := mock_features.NewMockFeaturesService(ctrl)
+
+	webhookHandler := Setup(mockGW, mockTransformerFeaturesService, stats.NOP)
+	webhookHandler.config.nativeProviderSrcMap = map[string]struct{}{"shopify": {}}
+	t.Cleanup(func() {
+		_ = webhookHandler.Shutdown()
+	})
+
+	body := `{"id":1001,"customer":{"id":42}}`
+	newRequest := func(secret string) *http.Request {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		req := httptest.NewRequest(http.MethodPost, "/v1/webhook", bytes.NewBufferString(body))
+		req.Header.Set("X-Shopify-Topic", "orders/create")
+		req.Header.Set("X-Shopify-Hmac-Sha256", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		return req
+	}
+	arctx := &gwtypes.AuthRequestContext{
+		SourceDefName: "Shopify",
+		WriteKey:      sampleWriteKey,
+	}
+	arctx.Source.Config = map[string]interface{}{signingSecretConfigKey: "secret"}
+
+	t.Run("valid signature", func(t *testing.T) {
+		mockGW.EXPECT().NewSourceStat(gomock.Any(), gomock.Any()).Return(&gwStats.SourceStat{}).Times(1)
+		mockGW.EXPECT().TrackRequestMetrics("").Times(1)
+		mockGW.EXPECT().ProcessWebRequest(gomock.Any(), gomock.Any(), "batch", gomock.Any(), arctx).DoAndReturn(
+			func(_ *http.ResponseWriter, _ *http.Request, _ string, payload []byte, _ *gwtypes.AuthRequestContext) string {
+				require.Equal(t, "orders/create", gjson.GetBytes(payload, "batch.0.event").String())
+				require.Equal(t, "42", gjson.GetBytes(payload, "batch.0.userId").String())
+				return ""
+			}).Times(1)
+
+		req := newRequest("secret")
+		w := httptest.NewRecorder()
+		webhookHandler.RequestHandler(w, req.WithContext(context.WithValue(context.WithValue(req.Context(), gwtypes.CtxParamCallType, "webhook"), gwtypes.CtxParamAuthRequestContext, arctx)))
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		mockGW.EXPECT().NewSourceStat(gomock.Any(), gomock.Any()).Return(&gwStats.SourceStat{}).Times(1)
+		mockGW.EXPECT().TrackRequestMetrics(response.InvalidWebhookSignature).Times(1)
+
+		req := newRequest("other")
+		w := httptest.NewRecorder()
+		webhookHandler.RequestHandler(w, req.WithContext(context.WithValue(context.WithValue(req.Context(), gwtypes.CtxParamCallType, "webhook"), gwtypes.CtxParamAuthRequestContext, arctx)))
+		require.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+	})
+
+	t.Run("missing secret for a source requiring signatures", func(t *testing.T) {
+		mockGW.EXPECT().NewSourceStat(gomock.Any(), gomock.Any()).Return(&gwStats.SourceStat{}).Times(1)
+		mockGW.EXPECT().TrackRequestMetrics(response.MissingWebhookSigningSecret).Times(1)
+
+		unsigned := &gwtypes.AuthRequestContext{
+			SourceDefName: "Shopify",
+			WriteKey:      sampleWriteKey,
+		}
+		unsigned.Source.Config = map[string]interface{}{requireSignatureConfigKey: true}
+		req := newRequest("secret")
+		w := httptest.NewRecorder()
+		webhookHandler.RequestHandler(w, req.WithContext(context.WithValue(context.WithValue(req.Context(), gwtypes.CtxParamCallType, "webhook"), gwtypes.CtxParamAuthRequestContext, unsigned)))
+		require.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+	})
+}
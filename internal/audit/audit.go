@@ -0,0 +1,235 @@
+// Package audit records the mutations made through the admin interfaces of the server, e.g. retrying warehouse uploads,
+// requeuing dead-lettered jobs or draining destinations, along with who made them and with which parameters, in an
+// audit log which can be queried as evidence of the operations performed on the server.
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	migrator "github.com/rudderlabs/rudder-server/services/sql-migrator"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+const (
+	defaultCleanupFrequency      = 1
+	defaultCleanupFrequencyUnits = time.Hour
+
+	defaultRetention      = 365 * 24
+	defaultRetentionUnits = time.Hour
+
+	defaultListLimit = 100
+	maxListLimit     = 1000
+
+	recordTimeout = 10 * time.Second
+)
+
+const (
+	// AdminCLIActor is the actor of the mutations made through the admin rpc interface, which is only reachable by
+	// rudder-cli through the unix socket of the server
+	AdminCLIActor = "rudder-cli"
+	// ControlPlaneActor is the actor of the mutations made by the control plane through the grpc interface
+	ControlPlaneActor = "control-plane"
+)
+
+// Default is the audit log of the server, nil if disabled. Nothing is recorded with a nil audit log.
+var Default *Log
+
+// Entry is a mutation made through an admin interface
+type Entry struct {
+	ID         int64           `json:"id"`
+	Actor      string          `json:"actor"`      // who made the mutation
+	Action     string          `json:"action"`     // e.g. the method and path of the http request
+	Parameters json.RawMessage `json:"parameters"` // parameters of the mutation, e.g. the body of the http request
+	Outcome    string          `json:"outcome"`    // e.g. the status code of the http response or the error
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+// Filter narrows down the entries returned by [Log.List]
+type Filter struct {
+	Actor  string
+	Action string // prefix of the action
+	From   time.Time
+	To     time.Time
+	// AfterID returns entries with an id greater than AfterID, for paginating through the entries
+	AfterID int64
+	Limit   int
+}
+
+// Log is the audit log of the mutations made through the admin interfaces
+type Log struct {
+	log  logger.Logger
+	conf *config.Config
+	db   *sql.DB
+
+	done *atomic.Bool
+	wg   sync.WaitGroup
+}
+
+// New returns an audit log, after migrating its database table
+func New(conf *config.Config, log logger.Logger) (*Log, error) {
+	db, err := setupDBConn(conf)
+	if err != nil {
+		return nil, fmt.Errorf("db setup: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("db migrations: %w", err)
+	}
+	return &Log{
+		log:  log,
+		conf: conf,
+		db:   db,
+
+		done: &atomic.Bool{},
+	}, nil
+}
+
+// Record adds the entry to the audit log. The mutation has been made already by then, so failing to record it is
+// only logged, along with the entry.
+func (l *Log) Record(ctx context.Context, e Entry) {
+	if l == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancel()
+	parameters := string(e.Parameters)
+	if len(e.Parameters) == 0 {
+		parameters = "{}"
+	}
+	if _, err := l.db.ExecContext(ctx,
+		`INSERT INTO audit_log (actor, action, parameters, outcome) VALUES ($1, $2, $3, $4)`,
+		e.Actor, e.Action, parameters, e.Outcome,
+	); err != nil {
+		l.log.Errorn("Recording audit log entry",
+			logger.NewStringField("actor", e.Actor),
+			logger.NewStringField("action", e.Action),
+			logger.NewStringField("parameters", parameters),
+			logger.NewStringField("outcome", e.Outcome),
+			logger.NewErrorField(err),
+		)
+	}
+}
+
+// List returns the entries matching the filter, ordered by id
+func (l *Log) List(ctx context.Context, f Filter) ([]Entry, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if f.Actor != "" {
+		addCondition("actor = $%d", f.Actor)
+	}
+	if f.Action != "" {
+		addCondition("starts_with(action, $%d)", f.Action)
+	}
+	if !f.From.IsZero() {
+		addCondition("created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		addCondition("created_at < $%d", f.To)
+	}
+	addCondition("id > $%d", f.AfterID)
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	args = append(args, min(limit, maxListLimit))
+	query := fmt.Sprintf(`SELECT id, actor, action, parameters, outcome, created_at FROM audit_log WHERE %s ORDER BY id ASC LIMIT $%d`,
+		strings.Join(conditions, " AND "), len(args))
+	rows, err := l.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query entries: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var entries []Entry
+	for rows.Next() {
+		var (
+			e          Entry
+			parameters []byte
+		)
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &parameters, &e.Outcome, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan entry: %w", err)
+		}
+		e.Parameters = parameters
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate entries: %w", err)
+	}
+	return entries, nil
+}
+
+// CleanupRoutine periodically deletes entries older than the configured retention
+func (l *Log) CleanupRoutine(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.wg.Add(1)
+	defer l.wg.Done()
+	for {
+		if l.done.Load() {
+			return nil
+		}
+		if _, err := l.db.ExecContext(
+			ctx,
+			"DELETE FROM audit_log WHERE created_at < $1",
+			time.Now().Add(-l.conf.GetDuration("AuditLog.retention", defaultRetention, defaultRetentionUnits)),
+		); err != nil && ctx.Err() == nil {
+			l.log.Errorn("audit log cleanup", logger.NewErrorField(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(l.conf.GetDuration("AuditLog.cleanupFrequency", defaultCleanupFrequency, defaultCleanupFrequencyUnits)):
+		}
+	}
+}
+
+func (l *Log) Stop() {
+	if l == nil {
+		return
+	}
+	l.done.Store(true)
+	l.wg.Wait()
+	_ = l.db.Close()
+}
+
+func migrate(db *sql.DB) error {
+	m := &migrator.Migrator{
+		Handle:                     db,
+		MigrationsTable:            "audit_log_migrations",
+		ShouldForceSetLowerVersion: config.GetBool("SQLMigrator.forceSetLowerVersion", true),
+	}
+
+	return m.Migrate("audit_log")
+}
+
+// setupDBConn sets up the database connection
+func setupDBConn(conf *config.Config) (*sql.DB, error) {
+	psqlInfo := misc.GetConnectionString(conf, "audit-log")
+	if conf.IsSet("SharedDB.dsn") {
+		psqlInfo = conf.GetString("SharedDB.dsn", "")
+	}
+	db, err := sql.Open("postgres", psqlInfo)
+	if err != nil {
+		return nil, fmt.Errorf("db open: %w", err)
+	}
+	db.SetMaxIdleConns(conf.GetInt("AuditLog.maxIdleConns", 1))
+	db.SetMaxOpenConns(conf.GetInt("AuditLog.maxOpenConns", 2))
+	return db, nil
+}
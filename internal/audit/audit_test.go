@@ -0,0 +1,108 @@
+package audit_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/bytesize"
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
+	"github.com/rudderlabs/rudder-server/internal/audit"
+)
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	auditLog, err := audit.New(testSetup(t), logger.NOP)
+	require.NoError(t, err, "should create the audit log")
+	t.Cleanup(auditLog.Stop)
+
+	auditLog.Record(ctx, audit.Entry{Actor: audit.ControlPlaneActor, Action: "/proto.Warehouse/RetryWHUploads", Parameters: []byte(`{"sourceId":"source-1"}`), Outcome: "OK"})
+	auditLog.Record(ctx, audit.Entry{Actor: audit.AdminCLIActor, Action: "Admin.SetLogLevel"})
+
+	entries, err := auditLog.List(ctx, audit.Filter{Action: "/proto.Warehouse/"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, audit.ControlPlaneActor, entries[0].Actor)
+	require.JSONEq(t, `{"sourceId":"source-1"}`, string(entries[0].Parameters))
+	require.Equal(t, "OK", entries[0].Outcome)
+
+	entries, err = auditLog.List(ctx, audit.Filter{Actor: audit.AdminCLIActor})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.JSONEq(t, `{}`, string(entries[0].Parameters))
+
+	entries, err = auditLog.List(ctx, audit.Filter{To: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	t.Run("middleware", func(t *testing.T) {
+		var body string
+		handler := auditLog.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		req := httptest.NewRequest(http.MethodPut, "/internal/drain?dryRun=true", strings.NewReader(`{"destinationId":"destination-1"}`))
+		req.Header.Set(audit.ActorHeader, "jane@example.com")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		require.Equal(t, `{"destinationId":"destination-1"}`, body, "the request body should still be readable")
+
+		req = httptest.NewRequest(http.MethodGet, "/internal/drain", http.NoBody)
+		req.Header.Set(audit.ActorHeader, "jane@example.com")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		entries, err := auditLog.List(ctx, audit.Filter{Actor: "jane@example.com"})
+		require.NoError(t, err)
+		require.Len(t, entries, 1, "only mutations should be recorded")
+		require.Equal(t, "PUT /internal/drain", entries[0].Action)
+		require.Equal(t, "201", entries[0].Outcome)
+		require.JSONEq(t, `{"query":{"dryRun":["true"]},"body":{"destinationId":"destination-1"}}`, string(entries[0].Parameters))
+	})
+
+	t.Run("http", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		auditLog.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?actor=rudder-cli", http.NoBody))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.EqualValues(t, 1, gjson.Get(resp.Body.String(), "#").Int())
+		require.Equal(t, "Admin.SetLogLevel", gjson.Get(resp.Body.String(), "0.action").String())
+
+		resp = httptest.NewRecorder()
+		auditLog.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?from=yesterday", http.NoBody))
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		var disabled *audit.Log
+		disabled.Record(ctx, audit.Entry{Actor: "actor"})
+		resp := httptest.NewRecorder()
+		disabled.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func testSetup(t *testing.T) *config.Config {
+	conf := config.New()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err, "Failed to create docker pool")
+	postgresResource, err := postgres.Setup(pool, t, postgres.WithShmSize(256*bytesize.MB))
+	require.NoError(t, err, "failed to setup postgres resource")
+	conf.Set("DB.name", postgresResource.Database)
+	conf.Set("DB.host", postgresResource.Host)
+	conf.Set("DB.port", postgresResource.Port)
+	conf.Set("DB.user", postgresResource.User)
+	conf.Set("DB.password", postgresResource.Password)
+
+	return conf
+}
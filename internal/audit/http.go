@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ActorHeader is the header of the http requests identifying who makes them, for the audit log.
+// Requests without it are attributed to the user of their basic auth, or to their remote address.
+const ActorHeader = "X-Rudder-Actor"
+
+// maxRecordedBodySize is the size up to which the body of a request is recorded with its parameters
+const maxRecordedBodySize = 64 * 1024
+
+// Middleware records the mutations, i.e. the requests other than GET, HEAD and OPTIONS, served by next
+func (l *Log) Middleware(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRecordedBodySize+1))
+		if err != nil {
+			http.Error(w, "reading request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		l.Record(r.Context(), Entry{
+			Actor:      Actor(r),
+			Action:     r.Method + " " + r.URL.Path,
+			Parameters: requestParameters(r, body),
+			Outcome:    strconv.Itoa(sw.status),
+		})
+	})
+}
+
+// Actor returns who makes the request
+func Actor(r *http.Request) string {
+	if actor := r.Header.Get(ActorHeader); actor != "" {
+		return actor
+	}
+	if user, _, ok := r.BasicAuth(); ok && user != "" {
+		return user
+	}
+	return r.RemoteAddr
+}
+
+// requestParameters returns the query and the body of the request, the latter truncated to maxRecordedBodySize
+func requestParameters(r *http.Request, body []byte) json.RawMessage {
+	parameters := struct {
+		Query map[string][]string `json:"query,omitempty"`
+		Body  json.RawMessage     `json:"body,omitempty"`
+	}{Query: r.URL.Query()}
+	switch {
+	case len(body) > maxRecordedBodySize:
+		parameters.Body, _ = json.Marshal(fmt.Sprintf("%s... (truncated)", body[:maxRecordedBodySize]))
+	case len(body) > 0 && json.Valid(body):
+		parameters.Body = body
+	case len(body) > 0:
+		parameters.Body, _ = json.Marshal(string(body))
+	}
+	data, err := json.Marshal(parameters)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = status, true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// HttpHandler returns the http handler for querying the audit log:
+//
+//	GET  /     lists the entries of the actor and the actions starting with the action query parameters, recorded
+//	           within the from and to query parameters (RFC3339), paginated through the afterId and limit query parameters
+func (l *Log) HttpHandler() http.Handler {
+	srvMux := chi.NewRouter()
+	srvMux.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		if l == nil {
+			http.Error(w, "audit log is disabled, enable it with AuditLog.enabled", http.StatusNotFound)
+			return
+		}
+		filter, err := parseFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		entries, err := l.List(r.Context(), filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if entries == nil {
+			entries = []Entry{}
+		}
+		body, err := json.Marshal(entries)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+	return srvMux
+}
+
+func parseFilter(r *http.Request) (Filter, error) {
+	q := r.URL.Query()
+	f := Filter{
+		Actor:  q.Get("actor"),
+		Action: q.Get("action"),
+	}
+	var err error
+	if v := q.Get("from"); v != "" {
+		if f.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return Filter{}, fmt.Errorf("invalid from: %q", v)
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if f.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return Filter{}, fmt.Errorf("invalid to: %q", v)
+		}
+	}
+	if v := q.Get("afterId"); v != "" {
+		if f.AfterID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return Filter{}, fmt.Errorf("invalid afterId: %q", v)
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil {
+			return Filter{}, fmt.Errorf("invalid limit: %q", v)
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,158 @@
+// Package checkpoint records the completed steps of multi-step operations on batches of jobs, which are not atomic
+// as a whole, e.g. the processor storing the outputs of gateway jobs in the router, batch router and proc error jobsdbs
+// before marking the gateway jobs as processed.
+//
+// Each step is recorded in the same transaction as its writes, as a single record for all the jobs of the batch, and the
+// records of an operation are cleared in the transaction completing it. Thus, the records found when starting up belong to operations interrupted by a crash,
+// which resume with the steps not completed yet, instead of repeating all of them and duplicating their writes.
+package checkpoint
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/lib/pq"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+
+	migrator "github.com/rudderlabs/rudder-server/services/sql-migrator"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	. "github.com/rudderlabs/rudder-server/utils/tx" //nolint:staticcheck
+)
+
+// Store records the completed steps of an operation. Its database needs to be the one of the transactions
+// the steps are recorded in.
+type Store struct {
+	operation string
+	log       logger.Logger
+	db        *sql.DB
+
+	mu sync.RWMutex
+	// completed holds, by step, the jobs of interrupted operations for which the step is completed
+	completed map[string]map[int64]struct{}
+}
+
+// New returns a store of the completed steps of an operation, after migrating its database table and
+// loading the steps completed by the interrupted operations
+func New(conf *config.Config, log logger.Logger, operation string) (*Store, error) {
+	db, err := sql.Open("postgres", misc.GetConnectionString(conf, "checkpoints"))
+	if err != nil {
+		return nil, fmt.Errorf("db open: %w", err)
+	}
+	db.SetMaxIdleConns(conf.GetInt("Checkpoints.maxIdleConns", 1))
+	db.SetMaxOpenConns(conf.GetInt("Checkpoints.maxOpenConns", 2))
+	if err := migrate(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("db migrations: %w", err)
+	}
+	s := &Store{
+		operation: operation,
+		log:       log,
+		db:        db,
+		completed: make(map[string]map[int64]struct{}),
+	}
+	if err := s.load(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Completed returns whether the step was completed for the job by an operation interrupted before starting up
+func (s *Store) Completed(step string, jobID int64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.completed[step][jobID]
+	return ok
+}
+
+// Record records the step as completed for the jobs of a batch, in the transaction of the step
+func (s *Store) Record(ctx context.Context, tx *Tx, step string, jobIDs []int64) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO checkpoint_batches (operation, step, job_ids) VALUES ($1, $2, $3)`,
+		s.operation, step, pq.Array(jobIDs),
+	); err != nil {
+		return fmt.Errorf("recording checkpoints of step %q: %w", step, err)
+	}
+	return nil
+}
+
+// Clear clears the records of the operation for the jobs of a batch, in the transaction completing it. Records of
+// interrupted operations are only cleared once all of their jobs are completed, in case they were batched differently.
+func (s *Store) Clear(ctx context.Context, tx *Tx, jobIDs []int64) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM checkpoint_batches WHERE operation = $1 AND job_ids <@ $2::BIGINT[]`,
+		s.operation, pq.Array(jobIDs),
+	); err != nil {
+		return fmt.Errorf("clearing checkpoints: %w", err)
+	}
+	tx.AddSuccessListener(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for step, jobs := range s.completed {
+			for _, jobID := range jobIDs {
+				delete(jobs, jobID)
+			}
+			if len(jobs) == 0 {
+				delete(s.completed, step)
+			}
+		}
+	})
+	return nil
+}
+
+// Close closes the database connection of the store
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) load() error {
+	rows, err := s.db.Query(`SELECT UNNEST(job_ids), step FROM checkpoint_batches WHERE operation = $1`, s.operation)
+	if err != nil {
+		return fmt.Errorf("loading checkpoints: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var count int
+	for rows.Next() {
+		var (
+			jobID int64
+			step  string
+		)
+		if err := rows.Scan(&jobID, &step); err != nil {
+			return fmt.Errorf("scanning checkpoint: %w", err)
+		}
+		if _, ok := s.completed[step]; !ok {
+			s.completed[step] = make(map[int64]struct{})
+		}
+		s.completed[step][jobID] = struct{}{}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating checkpoints: %w", err)
+	}
+	if count > 0 {
+		s.log.Infon("Resuming interrupted operations",
+			logger.NewStringField("operation", s.operation),
+			logger.NewIntField("checkpoints", int64(count)),
+		)
+	}
+	return nil
+}
+
+func migrate(db *sql.DB) error {
+	m := &migrator.Migrator{
+		Handle:                     db,
+		MigrationsTable:            "checkpoints_migrations",
+		ShouldForceSetLowerVersion: config.GetBool("SQLMigrator.forceSetLowerVersion", true),
+	}
+	return m.Migrate("checkpoints")
+}
@@ -0,0 +1,79 @@
+package checkpoint_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
+
+	"github.com/rudderlabs/rudder-server/internal/checkpoint"
+	. "github.com/rudderlabs/rudder-server/utils/tx" //nolint:staticcheck
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	postgresResource, err := postgres.Setup(pool, t)
+	require.NoError(t, err)
+	conf := config.New()
+	conf.Set("DB.name", postgresResource.Database)
+	conf.Set("DB.host", postgresResource.Host)
+	conf.Set("DB.port", postgresResource.Port)
+	conf.Set("DB.user", postgresResource.User)
+	conf.Set("DB.password", postgresResource.Password)
+	db := postgresResource.DB
+
+	inTx := func(t *testing.T, f func(tx *Tx) error) error {
+		sqlTx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		tx := &Tx{Tx: sqlTx}
+		if err := f(tx); err != nil {
+			require.NoError(t, sqlTx.Rollback())
+			return err
+		}
+		return tx.Commit()
+	}
+
+	store, err := checkpoint.New(conf, logger.NOP, "op")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	require.NoError(t, inTx(t, func(tx *Tx) error { return store.Record(ctx, tx, "step-1", []int64{1, 2}) }))
+	require.NoError(t, inTx(t, func(tx *Tx) error { return store.Record(ctx, tx, "step-1", []int64{2, 3}) }), "recording overlapping batches")
+	require.NoError(t, inTx(t, func(tx *Tx) error { return store.Record(ctx, tx, "step-2", []int64{1}) }))
+	rollback := errors.New("rollback")
+	require.ErrorIs(t, inTx(t, func(tx *Tx) error {
+		require.NoError(t, store.Record(ctx, tx, "step-2", []int64{2}))
+		return rollback
+	}), rollback)
+	require.False(t, store.Completed("step-1", 1), "steps recorded after starting up belong to running operations")
+
+	other, err := checkpoint.New(conf, logger.NOP, "other-op")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = other.Close() })
+	require.False(t, other.Completed("step-1", 1), "steps of other operations should not be loaded")
+
+	restarted, err := checkpoint.New(conf, logger.NOP, "op")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = restarted.Close() })
+	require.True(t, restarted.Completed("step-1", 1))
+	require.True(t, restarted.Completed("step-1", 3))
+	require.True(t, restarted.Completed("step-2", 1))
+	require.False(t, restarted.Completed("step-2", 2), "steps of rolled back transactions should not be recorded")
+
+	require.NoError(t, inTx(t, func(tx *Tx) error { return restarted.Clear(ctx, tx, []int64{1, 2}) }))
+	require.False(t, restarted.Completed("step-1", 1))
+	require.False(t, restarted.Completed("step-2", 1))
+	require.True(t, restarted.Completed("step-1", 3))
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM checkpoint_batches WHERE operation = 'op'`).Scan(&count))
+	require.Equal(t, 1, count)
+}
@@ -0,0 +1,61 @@
+package delivery_metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// HttpHandler returns the http handler for querying the delivery metrics:
+//
+//	GET  /     lists the hourly buckets of the sourceId and destinationId query parameters, within the from and to
+//	           query parameters (RFC3339), the last day by default
+func (r *Reporter) HttpHandler() http.Handler {
+	srvMux := chi.NewRouter()
+	srvMux.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		filter, err := parseFilter(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		buckets, err := r.List(req.Context(), filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if buckets == nil {
+			buckets = []Bucket{}
+		}
+		body, err := json.Marshal(buckets)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+	return srvMux
+}
+
+func parseFilter(r *http.Request) (Filter, error) {
+	q := r.URL.Query()
+	f := Filter{
+		SourceID:      q.Get("sourceId"),
+		DestinationID: q.Get("destinationId"),
+	}
+	var err error
+	if v := q.Get("from"); v != "" {
+		if f.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return Filter{}, fmt.Errorf("invalid from: %q", v)
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if f.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return Filter{}, fmt.Errorf("invalid to: %q", v)
+		}
+	}
+	return f, nil
+}
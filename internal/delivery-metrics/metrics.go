@@ -0,0 +1,287 @@
+// Package delivery_metrics aggregates the outcome of the deliveries of the router and batch router, i.e. the number of
+// events succeeded, failed and aborted along with their latencies since their receipt, per source and destination into
+// hourly buckets, so that delivery analytics can be queried from the server instead of being derived from its stats.
+package delivery_metrics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	migrator "github.com/rudderlabs/rudder-server/services/sql-migrator"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/utils/tx"
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+const (
+	defaultCleanupFrequency      = 1
+	defaultCleanupFrequencyUnits = time.Hour
+
+	defaultRetention      = 90 * 24
+	defaultRetentionUnits = time.Hour
+
+	// defaultListWindow is how far back buckets are listed when no start is given
+	defaultListWindow = 24 * time.Hour
+	maxListLimit      = 10000
+
+	bucketSize = time.Hour
+)
+
+// Bucket is the outcome of the deliveries from a source to a destination within an hour
+type Bucket struct {
+	Bucket        time.Time `json:"bucket"` // start of the hour
+	SourceID      string    `json:"sourceId"`
+	DestinationID string    `json:"destinationId"`
+	Succeeded     int64     `json:"succeeded"`
+	Failed        int64     `json:"failed"` // events whose first delivery attempt failed
+	Aborted       int64     `json:"aborted"`
+	// AvgLatencyMs and MaxLatencyMs are the latencies of the succeeded and aborted events since their receipt
+	AvgLatencyMs float64 `json:"avgLatencyMs"`
+	MaxLatencyMs int64   `json:"maxLatencyMs"`
+}
+
+// Filter narrows down the buckets returned by [Reporter.List]
+type Filter struct {
+	SourceID      string
+	DestinationID string
+	From          time.Time // defaults to a day ago
+	To            time.Time
+}
+
+// Reporter aggregates the delivery metrics of the router and batch router
+type Reporter struct {
+	log  logger.Logger
+	conf *config.Config
+	db   *sql.DB
+
+	done *atomic.Bool
+	wg   sync.WaitGroup
+}
+
+// New returns a delivery metrics reporter, after migrating its database table. Its database needs to be the one of
+// the jobsdbs, since the metrics are aggregated in the transactions updating the statuses of the jobs.
+func New(conf *config.Config, log logger.Logger) (*Reporter, error) {
+	db, err := setupDBConn(conf)
+	if err != nil {
+		return nil, fmt.Errorf("db setup: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("db migrations: %w", err)
+	}
+	return &Reporter{
+		log:  log,
+		conf: conf,
+		db:   db,
+
+		done: &atomic.Bool{},
+	}, nil
+}
+
+// Reporting returns the reporting, which also reports the metrics to r
+func (r *Reporter) Reporting(reporting types.Reporting) types.Reporting {
+	if r == nil {
+		return reporting
+	}
+	return &reportingWithDeliveryMetrics{Reporting: reporting, deliveryMetrics: r}
+}
+
+type reportingWithDeliveryMetrics struct {
+	types.Reporting
+	deliveryMetrics *Reporter
+}
+
+func (r *reportingWithDeliveryMetrics) Report(ctx context.Context, metrics []*types.PUReportedMetric, txn *tx.Tx) error {
+	if err := r.Reporting.Report(ctx, metrics, txn); err != nil {
+		return err
+	}
+	return r.deliveryMetrics.Report(ctx, metrics, txn)
+}
+
+type connection struct {
+	sourceID      string
+	destinationID string
+}
+
+type outcome struct {
+	succeeded, failed, aborted int64
+	latency                    types.Latency
+}
+
+// Report adds the metrics reported by the router and batch router to the bucket of the current hour, within the
+// transaction updating the statuses of their jobs
+func (r *Reporter) Report(ctx context.Context, metrics []*types.PUReportedMetric, txn *tx.Tx) error {
+	if r == nil {
+		return nil
+	}
+	outcomes := make(map[connection]*outcome)
+	for _, m := range metrics {
+		if m.PUDetails.PU != types.ROUTER && m.PUDetails.PU != types.BATCH_ROUTER {
+			continue
+		}
+		c := connection{sourceID: m.ConnectionDetails.SourceID, destinationID: m.ConnectionDetails.DestinationID}
+		o, ok := outcomes[c]
+		if !ok {
+			o = &outcome{}
+		}
+		switch m.StatusDetail.Status {
+		case jobsdb.Succeeded.State:
+			o.succeeded += m.StatusDetail.Count
+		case jobsdb.Failed.State:
+			o.failed += m.StatusDetail.Count
+		case jobsdb.Aborted.State:
+			o.aborted += m.StatusDetail.Count
+		default:
+			continue
+		}
+		o.latency.Sum += m.StatusDetail.Latency.Sum
+		o.latency.Max = max(o.latency.Max, m.StatusDetail.Latency.Max)
+		o.latency.Count += m.StatusDetail.Latency.Count
+		outcomes[c] = o
+	}
+	if len(outcomes) == 0 {
+		return nil
+	}
+
+	// upserting in the same order in every transaction, so that concurrent transactions don't deadlock
+	connections := make([]connection, 0, len(outcomes))
+	for c := range outcomes {
+		connections = append(connections, c)
+	}
+	slices.SortFunc(connections, func(a, b connection) int {
+		if n := strings.Compare(a.sourceID, b.sourceID); n != 0 {
+			return n
+		}
+		return strings.Compare(a.destinationID, b.destinationID)
+	})
+	bucket := time.Now().UTC().Truncate(bucketSize)
+	for _, c := range connections {
+		o := outcomes[c]
+		if _, err := txn.ExecContext(ctx, `INSERT INTO delivery_metrics
+			(bucket, source_id, destination_id, succeeded, failed, aborted, latency_sum_ms, latency_max_ms, latency_count)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			ON CONFLICT (bucket, source_id, destination_id) DO UPDATE SET
+				succeeded = delivery_metrics.succeeded + EXCLUDED.succeeded,
+				failed = delivery_metrics.failed + EXCLUDED.failed,
+				aborted = delivery_metrics.aborted + EXCLUDED.aborted,
+				latency_sum_ms = delivery_metrics.latency_sum_ms + EXCLUDED.latency_sum_ms,
+				latency_max_ms = GREATEST(delivery_metrics.latency_max_ms, EXCLUDED.latency_max_ms),
+				latency_count = delivery_metrics.latency_count + EXCLUDED.latency_count`,
+			bucket, c.sourceID, c.destinationID, o.succeeded, o.failed, o.aborted,
+			o.latency.Sum.Milliseconds(), o.latency.Max.Milliseconds(), o.latency.Count,
+		); err != nil {
+			return fmt.Errorf("upserting delivery metrics of source %q and destination %q: %w", c.sourceID, c.destinationID, err)
+		}
+	}
+	return nil
+}
+
+// List returns the buckets matching the filter, ordered by bucket
+func (r *Reporter) List(ctx context.Context, f Filter) ([]Bucket, error) {
+	from := f.From
+	if from.IsZero() {
+		from = time.Now().Add(-defaultListWindow)
+	}
+	conditions := []string{"bucket >= $1"}
+	args := []interface{}{from.UTC().Truncate(bucketSize)}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if f.SourceID != "" {
+		addCondition("source_id = $%d", f.SourceID)
+	}
+	if f.DestinationID != "" {
+		addCondition("destination_id = $%d", f.DestinationID)
+	}
+	if !f.To.IsZero() {
+		addCondition("bucket < $%d", f.To)
+	}
+	query := fmt.Sprintf(`SELECT bucket, source_id, destination_id, succeeded, failed, aborted, latency_sum_ms, latency_max_ms, latency_count
+		FROM delivery_metrics WHERE %s ORDER BY bucket ASC, source_id ASC, destination_id ASC LIMIT %d`,
+		strings.Join(conditions, " AND "), maxListLimit)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query buckets: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var buckets []Bucket
+	for rows.Next() {
+		var (
+			b                        Bucket
+			latencySum, latencyCount int64
+		)
+		if err := rows.Scan(&b.Bucket, &b.SourceID, &b.DestinationID, &b.Succeeded, &b.Failed, &b.Aborted, &latencySum, &b.MaxLatencyMs, &latencyCount); err != nil {
+			return nil, fmt.Errorf("scan bucket: %w", err)
+		}
+		if latencyCount > 0 {
+			b.AvgLatencyMs = float64(latencySum) / float64(latencyCount)
+		}
+		b.Bucket = b.Bucket.UTC()
+		buckets = append(buckets, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate buckets: %w", err)
+	}
+	return buckets, nil
+}
+
+// CleanupRoutine periodically deletes buckets older than the configured retention
+func (r *Reporter) CleanupRoutine(ctx context.Context) error {
+	r.wg.Add(1)
+	defer r.wg.Done()
+	for {
+		if r.done.Load() {
+			return nil
+		}
+		if _, err := r.db.ExecContext(
+			ctx,
+			"DELETE FROM delivery_metrics WHERE bucket < $1",
+			time.Now().Add(-r.conf.GetDuration("DeliveryMetrics.retention", defaultRetention, defaultRetentionUnits)),
+		); err != nil && ctx.Err() == nil {
+			r.log.Errorn("delivery metrics cleanup", logger.NewErrorField(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(r.conf.GetDuration("DeliveryMetrics.cleanupFrequency", defaultCleanupFrequency, defaultCleanupFrequencyUnits)):
+		}
+	}
+}
+
+func (r *Reporter) Stop() {
+	r.done.Store(true)
+	r.wg.Wait()
+	_ = r.db.Close()
+}
+
+func migrate(db *sql.DB) error {
+	m := &migrator.Migrator{
+		Handle:                     db,
+		MigrationsTable:            "delivery_metrics_migrations",
+		ShouldForceSetLowerVersion: config.GetBool("SQLMigrator.forceSetLowerVersion", true),
+	}
+
+	return m.Migrate("delivery_metrics")
+}
+
+// setupDBConn sets up the connection to the database of the jobsdbs
+func setupDBConn(conf *config.Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", misc.GetConnectionString(conf, "delivery-metrics"))
+	if err != nil {
+		return nil, fmt.Errorf("db open: %w", err)
+	}
+	db.SetMaxIdleConns(conf.GetInt("DeliveryMetrics.maxIdleConns", 1))
+	db.SetMaxOpenConns(conf.GetInt("DeliveryMetrics.maxOpenConns", 2))
+	return db, nil
+}
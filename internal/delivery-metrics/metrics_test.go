@@ -0,0 +1,115 @@
+package delivery_metrics_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/bytesize"
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
+	delivery_metrics "github.com/rudderlabs/rudder-server/internal/delivery-metrics"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/utils/tx"
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+func TestDeliveryMetrics(t *testing.T) {
+	ctx := context.Background()
+	conf, db := testSetup(t)
+	reporter, err := delivery_metrics.New(conf, logger.NOP)
+	require.NoError(t, err, "should create the delivery metrics reporter")
+	t.Cleanup(reporter.Stop)
+
+	metric := func(pu, sourceID, destinationID, status string, count int64, latency types.Latency) *types.PUReportedMetric {
+		return &types.PUReportedMetric{
+			ConnectionDetails: types.ConnectionDetails{SourceID: sourceID, DestinationID: destinationID},
+			PUDetails:         types.PUDetails{PU: pu, TerminalPU: true},
+			StatusDetail:      &types.StatusDetail{Status: status, Count: count, Latency: latency},
+		}
+	}
+	report := func(metrics ...*types.PUReportedMetric) {
+		sqlTx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		txn := &tx.Tx{Tx: sqlTx}
+		require.NoError(t, reporter.Report(ctx, metrics, txn))
+		require.NoError(t, txn.Commit())
+	}
+
+	report(
+		metric(types.ROUTER, "source-1", "destination-1", jobsdb.Succeeded.State, 3, types.Latency{Sum: 600 * time.Millisecond, Max: 300 * time.Millisecond, Count: 3}),
+		metric(types.ROUTER, "source-1", "destination-1", jobsdb.Failed.State, 2, types.Latency{}),
+		metric(types.ROUTER, "source-1", "destination-1", jobsdb.Filtered.State, 5, types.Latency{}),
+		metric(types.DEST_TRANSFORMER, "source-1", "destination-1", jobsdb.Succeeded.State, 10, types.Latency{}),
+		metric(types.BATCH_ROUTER, "source-1", "destination-2", jobsdb.Succeeded.State, 1, types.Latency{Sum: time.Second, Max: time.Second, Count: 1}),
+	)
+	report(
+		metric(types.ROUTER, "source-1", "destination-1", jobsdb.Aborted.State, 1, types.Latency{Sum: time.Second, Max: time.Second, Count: 1}),
+	)
+
+	buckets, err := reporter.List(ctx, delivery_metrics.Filter{DestinationID: "destination-1"})
+	require.NoError(t, err)
+	require.Len(t, buckets, 1, "reports of the same hour should be aggregated")
+	require.Equal(t, time.Now().UTC().Truncate(time.Hour), buckets[0].Bucket)
+	require.Equal(t, "source-1", buckets[0].SourceID)
+	require.EqualValues(t, 3, buckets[0].Succeeded)
+	require.EqualValues(t, 2, buckets[0].Failed)
+	require.EqualValues(t, 1, buckets[0].Aborted)
+	require.InDelta(t, 400, buckets[0].AvgLatencyMs, 0.001)
+	require.EqualValues(t, 1000, buckets[0].MaxLatencyMs)
+
+	buckets, err = reporter.List(ctx, delivery_metrics.Filter{From: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	require.Empty(t, buckets)
+
+	t.Run("rolled back", func(t *testing.T) {
+		sqlTx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		require.NoError(t, reporter.Report(ctx, []*types.PUReportedMetric{
+			metric(types.BATCH_ROUTER, "source-1", "destination-2", jobsdb.Succeeded.State, 1, types.Latency{}),
+		}, &tx.Tx{Tx: sqlTx}))
+		require.NoError(t, sqlTx.Rollback())
+
+		buckets, err := reporter.List(ctx, delivery_metrics.Filter{DestinationID: "destination-2"})
+		require.NoError(t, err)
+		require.Len(t, buckets, 1)
+		require.EqualValues(t, 1, buckets[0].Succeeded, "metrics of rolled back transactions should not be aggregated")
+	})
+
+	t.Run("http", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		reporter.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?sourceId=source-1", http.NoBody))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.EqualValues(t, 2, gjson.Get(resp.Body.String(), "#").Int())
+		require.Equal(t, "destination-1", gjson.Get(resp.Body.String(), "0.destinationId").String())
+		require.EqualValues(t, 3, gjson.Get(resp.Body.String(), "0.succeeded").Int())
+
+		resp = httptest.NewRecorder()
+		reporter.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?to=tomorrow", http.NoBody))
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func testSetup(t *testing.T) (*config.Config, *sql.DB) {
+	conf := config.New()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err, "Failed to create docker pool")
+	postgresResource, err := postgres.Setup(pool, t, postgres.WithShmSize(256*bytesize.MB))
+	require.NoError(t, err, "failed to setup postgres resource")
+	conf.Set("DB.name", postgresResource.Database)
+	conf.Set("DB.host", postgresResource.Host)
+	conf.Set("DB.port", postgresResource.Port)
+	conf.Set("DB.user", postgresResource.User)
+	conf.Set("DB.password", postgresResource.Password)
+
+	return conf, postgresResource.DB
+}
@@ -0,0 +1,147 @@
+// Package destination_dlq keeps the jobs which the router aborted while delivering them to destinations in a
+// dead-letter queue, along with the number of attempts and the final response of the destination, so that they can be
+// inspected, exported and requeued once the destination's configuration is fixed, instead of being dropped.
+package destination_dlq
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/internal/dlq"
+)
+
+// Entry is a router job which got aborted while being delivered to its destination
+type Entry struct {
+	ID              int64           `json:"id"`
+	WorkspaceID     string          `json:"workspaceId"`
+	SourceID        string          `json:"sourceId"`
+	DestinationID   string          `json:"destinationId"`
+	DestinationType string          `json:"destinationType"`
+	JobID           int64           `json:"jobId"`
+	UserID          string          `json:"userId"`
+	Attempts        int             `json:"attempts"`
+	StatusCode      int             `json:"statusCode"`
+	Response        json.RawMessage `json:"response"`   // final error response of the job
+	Payload         json.RawMessage `json:"payload"`    // payload of the router job
+	Parameters      json.RawMessage `json:"parameters"` // parameters of the router job
+	CreatedAt       time.Time       `json:"createdAt"`
+	RequeuedAt      *time.Time      `json:"requeuedAt,omitempty"`
+}
+
+// Filter narrows down the entries returned by [DLQ.List]
+type Filter struct {
+	WorkspaceID     string
+	SourceID        string
+	DestinationID   string
+	DestinationType string
+	// IncludeRequeued includes entries which have already been requeued
+	IncludeRequeued bool
+	// AfterID returns entries with an id greater than AfterID, for paginating through the entries
+	AfterID int64
+	Limit   int
+}
+
+// DLQ is the dead-letter queue of jobs aborted by the router. Its http handler, i.e. [dlq.Store.HttpHandler], serves:
+//
+//	GET  /                 lists the entries matching the workspaceId, sourceId, destinationId and destinationType query parameters,
+//	                       paginated through the afterId and limit query parameters. Requeued entries are included if includeRequeued=true
+//	GET  /export           streams all the entries matching the same query parameters as GET / as newline delimited json
+//	GET  /{id}             returns a single entry
+//	POST /{id}/requeue     requeues a single entry
+//	POST /requeue          requeues the entries matching the same query parameters as GET /
+//
+// Requeuing an entry stores its job in the router again, to be delivered using the latest configuration of its destination.
+type DLQ struct {
+	*dlq.Store[Entry]
+}
+
+// New returns a dead-letter queue, after migrating its database table
+func New(conf *config.Config, log logger.Logger) (*DLQ, error) {
+	store, err := dlq.New(conf, log, kind)
+	if err != nil {
+		return nil, err
+	}
+	return &DLQ{Store: store}, nil
+}
+
+// List returns the entries matching the filter, ordered by id
+func (d *DLQ) List(ctx context.Context, f Filter) ([]Entry, error) {
+	return d.Store.List(ctx, dlq.Filter{
+		Equals: map[string]string{
+			"workspace_id":     f.WorkspaceID,
+			"source_id":        f.SourceID,
+			"destination_id":   f.DestinationID,
+			"destination_type": f.DestinationType,
+		},
+		IncludeDone: f.IncludeRequeued,
+		AfterID:     f.AfterID,
+		Limit:       f.Limit,
+	})
+}
+
+// MarkRequeued marks the entries as requeued, excluding them from future listings by default
+func (d *DLQ) MarkRequeued(ctx context.Context, ids []int64) error {
+	return d.MarkDone(ctx, ids)
+}
+
+var kind = dlq.Kind[Entry]{
+	Name:         "destination_dlq",
+	ConfigPrefix: "DestinationDLQ",
+
+	Columns: []string{
+		"workspace_id", "source_id", "destination_id", "destination_type", "job_id",
+		"user_id", "attempts", "status_code", "response", "payload", "parameters",
+	},
+	Values: func(e Entry) []any {
+		return []any{
+			e.WorkspaceID, e.SourceID, e.DestinationID, e.DestinationType, e.JobID,
+			e.UserID, e.Attempts, e.StatusCode, jsonOrEmpty(e.Response), jsonOrEmpty(e.Payload), jsonOrEmpty(e.Parameters),
+		}
+	},
+	Select: `id, workspace_id, source_id, destination_id, destination_type, job_id, user_id,
+	attempts, status_code, response, payload, parameters, created_at, requeued_at`,
+	Scan: scan,
+	ID:   func(e Entry) int64 { return e.ID },
+
+	Filters: map[string]string{
+		"workspaceId":     "workspace_id",
+		"sourceId":        "source_id",
+		"destinationId":   "destination_id",
+		"destinationType": "destination_type",
+	},
+	Action: "requeue",
+	Done:   "requeued",
+	Job:    routerJob,
+}
+
+func scan(row interface{ Scan(...any) error }) (Entry, error) {
+	var (
+		e                             Entry
+		response, payload, parameters []byte
+		requeuedAt                    sql.NullTime
+	)
+	if err := row.Scan(
+		&e.ID, &e.WorkspaceID, &e.SourceID, &e.DestinationID, &e.DestinationType, &e.JobID, &e.UserID,
+		&e.Attempts, &e.StatusCode, &response, &payload, &parameters, &e.CreatedAt, &requeuedAt,
+	); err != nil {
+		return Entry{}, fmt.Errorf("scan entry: %w", err)
+	}
+	e.Response, e.Payload, e.Parameters = response, payload, parameters
+	if requeuedAt.Valid {
+		e.RequeuedAt = &requeuedAt.Time
+	}
+	return e, nil
+}
+
+// jsonOrEmpty returns the json as a string, or an empty json object if there is none
+func jsonOrEmpty(v json.RawMessage) string {
+	if len(v) == 0 {
+		return "{}"
+	}
+	return string(v)
+}
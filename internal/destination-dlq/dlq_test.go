@@ -0,0 +1,140 @@
+package destination_dlq_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/bytesize"
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
+	destination_dlq "github.com/rudderlabs/rudder-server/internal/destination-dlq"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	mocksJobsDB "github.com/rudderlabs/rudder-server/mocks/jobsdb"
+)
+
+func TestDestinationDLQ(t *testing.T) {
+	ctx := context.Background()
+	dlq, err := destination_dlq.New(testSetup(t), logger.NOP)
+	require.NoError(t, err, "should create the dead-letter queue")
+	t.Cleanup(dlq.Stop)
+
+	entry := func(jobID int64, destinationID string) destination_dlq.Entry {
+		return destination_dlq.Entry{
+			WorkspaceID:     "workspace-1",
+			SourceID:        "source-1",
+			DestinationID:   destinationID,
+			DestinationType: "WEBHOOK",
+			JobID:           jobID,
+			UserID:          "rudder-id",
+			Attempts:        3,
+			StatusCode:      401,
+			Response:        json.RawMessage(`{"response":"invalid api key"}`),
+			Payload:         json.RawMessage(`{"body":{"JSON":{"event":"test"}}}`),
+			Parameters:      json.RawMessage(`{"source_id":"source-1","destination_id":"` + destinationID + `","stage":"router","reason":{"response":"invalid api key"}}`),
+		}
+	}
+	require.NoError(t, dlq.Add(ctx, []destination_dlq.Entry{
+		entry(1, "destination-1"),
+		entry(2, "destination-2"),
+		entry(3, "destination-1"),
+	}))
+
+	entries, err := dlq.List(ctx, destination_dlq.Filter{DestinationID: "destination-1"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.EqualValues(t, 1, entries[0].JobID)
+	require.Equal(t, 3, entries[0].Attempts)
+	require.Equal(t, 401, entries[0].StatusCode)
+	require.JSONEq(t, `{"response":"invalid api key"}`, string(entries[0].Response))
+	require.JSONEq(t, `{"body":{"JSON":{"event":"test"}}}`, string(entries[0].Payload))
+
+	page, err := dlq.List(ctx, destination_dlq.Filter{DestinationID: "destination-1", AfterID: entries[0].ID, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.Equal(t, entries[1].ID, page[0].ID)
+
+	t.Run("inspect", func(t *testing.T) {
+		handler := dlq.HttpHandler(nil)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?destinationId=destination-1", http.NoBody))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.EqualValues(t, 2, gjson.Get(resp.Body.String(), "#").Int())
+
+		resp = httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/0", http.NoBody))
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		dlq.HttpHandler(nil).ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/export?destinationType=WEBHOOK", http.NoBody))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, "application/x-ndjson", resp.Header().Get("Content-Type"))
+
+		var jobIDs []int64
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			jobIDs = append(jobIDs, gjson.GetBytes(scanner.Bytes(), "jobId").Int())
+		}
+		require.NoError(t, scanner.Err())
+		require.Equal(t, []int64{1, 2, 3}, jobIDs)
+	})
+
+	t.Run("requeue", func(t *testing.T) {
+		routerDB := mocksJobsDB.NewMockJobsDB(gomock.NewController(t))
+		routerDB.EXPECT().Store(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, jobs []*jobsdb.JobT) error {
+			require.Len(t, jobs, 2)
+			for _, job := range jobs {
+				require.Equal(t, "workspace-1", job.WorkspaceId)
+				require.Equal(t, "rudder-id", job.UserID)
+				require.Equal(t, "WEBHOOK", job.CustomVal)
+				require.True(t, gjson.GetBytes(job.Parameters, "dlq_requeue").Bool())
+				require.Equal(t, "destination-1", gjson.GetBytes(job.Parameters, "destination_id").String())
+				require.False(t, gjson.GetBytes(job.Parameters, "reason").Exists(), "the abort reason should be removed")
+				require.False(t, gjson.GetBytes(job.Parameters, "stage").Exists(), "the abort stage should be removed")
+				require.JSONEq(t, `{"body":{"JSON":{"event":"test"}}}`, string(job.EventPayload))
+			}
+			return nil
+		}).Times(1)
+
+		resp := httptest.NewRecorder()
+		dlq.HttpHandler(routerDB).ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/requeue?destinationId=destination-1", http.NoBody))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.EqualValues(t, 2, gjson.Get(resp.Body.String(), "requeued.#").Int())
+
+		remaining, err := dlq.List(ctx, destination_dlq.Filter{DestinationID: "destination-1"})
+		require.NoError(t, err)
+		require.Empty(t, remaining, "requeued entries should not be listed by default")
+
+		requeued, err := dlq.List(ctx, destination_dlq.Filter{DestinationID: "destination-1", IncludeRequeued: true})
+		require.NoError(t, err)
+		require.Len(t, requeued, 2)
+		require.NotNil(t, requeued[0].RequeuedAt)
+	})
+}
+
+func testSetup(t *testing.T) *config.Config {
+	conf := config.New()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err, "Failed to create docker pool")
+	postgresResource, err := postgres.Setup(pool, t, postgres.WithShmSize(256*bytesize.MB))
+	require.NoError(t, err, "failed to setup postgres resource")
+	conf.Set("DB.name", postgresResource.Database)
+	conf.Set("DB.host", postgresResource.Host)
+	conf.Set("DB.port", postgresResource.Port)
+	conf.Set("DB.user", postgresResource.User)
+	conf.Set("DB.password", postgresResource.Password)
+
+	return conf
+}
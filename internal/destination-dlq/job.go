@@ -0,0 +1,37 @@
+package destination_dlq
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/tidwall/sjson"
+
+	"github.com/rudderlabs/rudder-server/jobsdb"
+)
+
+// routerJob builds a router job out of the entry, without the abort reason the router added to its parameters
+func routerJob(e Entry) (*jobsdb.JobT, error) {
+	if e.DestinationType == "" {
+		return nil, errors.New("no destination type")
+	}
+	params := []byte(e.Parameters)
+	var err error
+	for _, key := range []string{"stage", "reason"} {
+		if params, err = sjson.DeleteBytes(params, key); err != nil {
+			return nil, fmt.Errorf("removing %q from parameters: %w", key, err)
+		}
+	}
+	if params, err = sjson.SetBytes(params, "dlq_requeue", true); err != nil {
+		return nil, fmt.Errorf("marking parameters as requeued: %w", err)
+	}
+	return &jobsdb.JobT{
+		UUID:         uuid.New(),
+		UserID:       e.UserID,
+		Parameters:   params,
+		CustomVal:    e.DestinationType,
+		EventPayload: e.Payload,
+		EventCount:   1,
+		WorkspaceId:  e.WorkspaceID,
+	}, nil
+}
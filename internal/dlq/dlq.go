@@ -0,0 +1,256 @@
+// Package dlq keeps failed jobs or events in dead-letter queues, i.e. postgres tables holding them along with the reason
+// they failed, so that they can be inspected and handled again, e.g. replayed or requeued, instead of being dropped.
+//
+// The mechanics shared by the dead-letter queues, i.e. storing, listing, marking and cleaning up their entries and
+// serving them over http, are implemented by [Store], which is parameterised by the [Kind] of its entries.
+package dlq
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	migrator "github.com/rudderlabs/rudder-server/services/sql-migrator"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+const (
+	defaultCleanupFrequency      = 1
+	defaultCleanupFrequencyUnits = time.Hour
+
+	defaultRetention      = 7 * 24
+	defaultRetentionUnits = time.Hour
+
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+// Kind describes the entries of a dead-letter queue, and how they are handled again
+type Kind[E any] struct {
+	// Name is the name of the table of the entries, and of its migrations
+	Name string
+	// ConfigPrefix prefixes the configuration of the dead-letter queue, e.g. its retention
+	ConfigPrefix string
+
+	// Columns are the columns the entries are inserted into, with the values returned by Values
+	Columns []string
+	Values  func(e E) []any
+	// Select are the columns the entries are read from by Scan, starting with their id
+	Select string
+	Scan   func(row interface{ Scan(...any) error }) (E, error)
+	ID     func(e E) int64
+
+	// Filters maps the query parameters the entries can be listed by to their columns
+	Filters map[string]string
+	// Action is handling the entries again, e.g. replay, by storing the jobs returned by Job. Handled entries are
+	// marked as Done, e.g. replayed, in the <Done>_at column, and excluded from listings by default.
+	Action string
+	Done   string
+	Job    func(e E) (*jobsdb.JobT, error)
+}
+
+// Filter narrows down the entries returned by [Store.List]
+type Filter struct {
+	// Equals holds the values of the columns the entries should match, empty values matching any entry
+	Equals map[string]string
+	// IncludeDone includes entries which have already been handled again
+	IncludeDone bool
+	// AfterID returns entries with an id greater than AfterID, for paginating through the entries
+	AfterID int64
+	Limit   int
+}
+
+// Store is a dead-letter queue of entries of a kind
+type Store[E any] struct {
+	kind Kind[E]
+	log  logger.Logger
+	conf *config.Config
+	db   *sql.DB
+
+	done *atomic.Bool
+	wg   sync.WaitGroup
+}
+
+// New returns a dead-letter queue of entries of the kind, after migrating its database table
+func New[E any](conf *config.Config, log logger.Logger, kind Kind[E]) (*Store[E], error) {
+	db, err := setupDBConn(conf, kind)
+	if err != nil {
+		return nil, fmt.Errorf("db setup: %w", err)
+	}
+	if err := migrate(db, kind); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("db migrations: %w", err)
+	}
+	return &Store[E]{
+		kind: kind,
+		log:  log,
+		conf: conf,
+		db:   db,
+
+		done: &atomic.Bool{},
+	}, nil
+}
+
+// Add adds the entries to the dead-letter queue
+func (s *Store[E]) Add(ctx context.Context, entries []E) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	txn, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = txn.Rollback() }()
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn(s.kind.Name, s.kind.Columns...))
+	if err != nil {
+		return fmt.Errorf("prepare copy statement: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, e := range entries {
+		if _, err := stmt.ExecContext(ctx, s.kind.Values(e)...); err != nil {
+			return fmt.Errorf("copy entry: %w", err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("flush copy: %w", err)
+	}
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("close copy statement: %w", err)
+	}
+	return txn.Commit()
+}
+
+// List returns the entries matching the filter, ordered by id
+func (s *Store[E]) List(ctx context.Context, f Filter) ([]E, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	columns := make([]string, 0, len(f.Equals))
+	for column, value := range f.Equals {
+		if value != "" {
+			columns = append(columns, column)
+		}
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		args = append(args, f.Equals[column])
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	if !f.IncludeDone {
+		conditions = append(conditions, s.doneColumn()+" IS NULL")
+	}
+	args = append(args, f.AfterID)
+	conditions = append(conditions, fmt.Sprintf("id > $%d", len(args)))
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	args = append(args, min(limit, maxListLimit))
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s ORDER BY id ASC LIMIT $%d`, s.kind.Select, s.kind.Name, strings.Join(conditions, " AND "), len(args))
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query entries: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var entries []E
+	for rows.Next() {
+		e, err := s.kind.Scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate entries: %w", err)
+	}
+	return entries, nil
+}
+
+// Get returns the entry with the given id, or [sql.ErrNoRows] if it doesn't exist
+func (s *Store[E]) Get(ctx context.Context, id int64) (E, error) {
+	row := s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1`, s.kind.Select, s.kind.Name), id)
+	return s.kind.Scan(row)
+}
+
+// MarkDone marks the entries as handled again, excluding them from future listings by default
+func (s *Store[E]) MarkDone(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET %s = NOW() WHERE id = ANY($1)`, s.kind.Name, s.doneColumn()), pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("mark entries as %s: %w", s.kind.Done, err)
+	}
+	return nil
+}
+
+// CleanupRoutine periodically deletes entries older than the configured retention
+func (s *Store[E]) CleanupRoutine(ctx context.Context) error {
+	s.wg.Add(1)
+	defer s.wg.Done()
+	for {
+		if s.done.Load() {
+			return nil
+		}
+		if _, err := s.db.ExecContext(
+			ctx,
+			fmt.Sprintf("DELETE FROM %s WHERE created_at < $1", s.kind.Name),
+			time.Now().Add(-s.conf.GetDuration(s.kind.ConfigPrefix+".retention", defaultRetention, defaultRetentionUnits)),
+		); err != nil && ctx.Err() == nil {
+			s.log.Errorn("dlq cleanup", logger.NewStringField("dlq", s.kind.Name), logger.NewErrorField(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.conf.GetDuration(s.kind.ConfigPrefix+".cleanupFrequency", defaultCleanupFrequency, defaultCleanupFrequencyUnits)):
+		}
+	}
+}
+
+func (s *Store[E]) Stop() {
+	s.done.Store(true)
+	s.wg.Wait()
+	_ = s.db.Close()
+}
+
+func (s *Store[E]) doneColumn() string {
+	return s.kind.Done + "_at"
+}
+
+func migrate[E any](db *sql.DB, kind Kind[E]) error {
+	m := &migrator.Migrator{
+		Handle:                     db,
+		MigrationsTable:            kind.Name + "_migrations",
+		ShouldForceSetLowerVersion: config.GetBool("SQLMigrator.forceSetLowerVersion", true),
+	}
+
+	return m.Migrate(kind.Name)
+}
+
+// setupDBConn sets up the database connection
+func setupDBConn[E any](conf *config.Config, kind Kind[E]) (*sql.DB, error) {
+	psqlInfo := misc.GetConnectionString(conf, strings.ReplaceAll(kind.Name, "_", "-"))
+	if conf.IsSet("SharedDB.dsn") {
+		psqlInfo = conf.GetString("SharedDB.dsn", "")
+	}
+	db, err := sql.Open("postgres", psqlInfo)
+	if err != nil {
+		return nil, fmt.Errorf("db open: %w", err)
+	}
+	db.SetMaxIdleConns(conf.GetInt(kind.ConfigPrefix+".maxIdleConns", 1))
+	db.SetMaxOpenConns(conf.GetInt(kind.ConfigPrefix+".maxOpenConns", 4))
+	return db, nil
+}
@@ -0,0 +1,197 @@
+package dlq
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+)
+
+// HttpHandler returns the http handler for inspecting, exporting and handling again the entries of the dead-letter queue:
+//
+//	GET  /                 lists the entries matching the query parameters of the kind's filters, paginated through the
+//	                       afterId and limit query parameters. Entries already handled again are included if include<Done>=true,
+//	                       e.g. includeReplayed=true
+//	GET  /export           streams all the entries matching the same query parameters as GET / as newline delimited json
+//	GET  /{id}             returns a single entry
+//	POST /{id}/<action>    handles a single entry again, e.g. POST /{id}/replay
+//	POST /<action>         handles the entries matching the same query parameters as GET / again
+//
+// Handling entries again stores their jobs in the given jobsdb, responding with the ids of the entries under <done>,
+// e.g. {"replayed": [1, 2]}.
+func (s *Store[E]) HttpHandler(jobsDB jobsdb.JobsDB) http.Handler {
+	srvMux := chi.NewRouter()
+	srvMux.Get("/", s.listHandler)
+	srvMux.Get("/export", s.exportHandler)
+	srvMux.Get("/{id}", s.getHandler)
+	srvMux.Post("/{id}/"+s.kind.Action, func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid id", http.StatusBadRequest)
+			return
+		}
+		entry, err := s.Get(r.Context(), id)
+		if err != nil {
+			writeGetError(w, err)
+			return
+		}
+		s.handleAgain(w, r, jobsDB, []E{entry})
+	})
+	srvMux.Post("/"+s.kind.Action, func(w http.ResponseWriter, r *http.Request) {
+		filter, err := s.parseFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		entries, err := s.List(r.Context(), filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.handleAgain(w, r, jobsDB, entries)
+	})
+	return srvMux
+}
+
+func (s *Store[E]) listHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := s.parseFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	entries, err := s.List(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []E{}
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *Store[E]) exportHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := s.parseFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Limit = maxListLimit
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	var written bool
+	for {
+		entries, err := s.List(r.Context(), filter)
+		if err != nil {
+			if !written {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			} else {
+				s.log.Errorn("exporting dlq entries", logger.NewStringField("dlq", s.kind.Name), logger.NewErrorField(err))
+			}
+			return
+		}
+		for _, e := range entries {
+			if err := encoder.Encode(e); err != nil {
+				return
+			}
+			written = true
+		}
+		if len(entries) < filter.Limit {
+			return
+		}
+		filter.AfterID = s.kind.ID(entries[len(entries)-1])
+	}
+}
+
+func (s *Store[E]) getHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	entry, err := s.Get(r.Context(), id)
+	if err != nil {
+		writeGetError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// handleAgain stores the jobs of the entries and marks them as done
+func (s *Store[E]) handleAgain(w http.ResponseWriter, r *http.Request, jobsDB jobsdb.JobsDB, entries []E) {
+	if len(entries) == 0 {
+		writeJSON(w, http.StatusOK, map[string][]int64{s.kind.Done: {}})
+		return
+	}
+	jobs := make([]*jobsdb.JobT, 0, len(entries))
+	ids := make([]int64, 0, len(entries))
+	for _, e := range entries {
+		job, err := s.kind.Job(e)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("entry %d: %v", s.kind.ID(e), err), http.StatusInternalServerError)
+			return
+		}
+		jobs = append(jobs, job)
+		ids = append(ids, s.kind.ID(e))
+	}
+	if err := jobsDB.Store(r.Context(), jobs); err != nil {
+		http.Error(w, fmt.Sprintf("storing jobs: %v", err), http.StatusInternalServerError)
+		return
+	}
+	// the jobs have been stored already, so entries should be marked as done even if the request is cancelled
+	if err := s.MarkDone(context.WithoutCancel(r.Context()), ids); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]int64{s.kind.Done: ids})
+}
+
+func (s *Store[E]) parseFilter(r *http.Request) (Filter, error) {
+	q := r.URL.Query()
+	f := Filter{
+		Equals:      make(map[string]string, len(s.kind.Filters)),
+		IncludeDone: q.Get("include"+strings.ToUpper(s.kind.Done[:1])+s.kind.Done[1:]) == "true",
+	}
+	for param, column := range s.kind.Filters {
+		f.Equals[column] = q.Get(param)
+	}
+	var err error
+	if v := q.Get("afterId"); v != "" {
+		if f.AfterID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return Filter{}, fmt.Errorf("invalid afterId: %q", v)
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil {
+			return Filter{}, fmt.Errorf("invalid limit: %q", v)
+		}
+	}
+	return f, nil
+}
+
+func writeGetError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "entry not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
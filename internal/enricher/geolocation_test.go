@@ -80,6 +80,7 @@ func TestGeolocationEnrichment_Success(t *testing.T) {
 			}
 			// Empty enrichment happens if the requestIP is empty / invalid
 			err := enricher.Enrich(
+				context.Background(),
 				NewSourceBuilder("source-id").
 					WithGeoEnrichment(true).
 					Build(), ip)
@@ -101,6 +102,7 @@ func TestGeolocationEnrichment_Success(t *testing.T) {
 			}
 
 			err := enricher.Enrich(
+				context.Background(),
 				NewSourceBuilder("source-id").
 					WithGeoEnrichment(true).
 					Build(), input)
@@ -127,6 +129,7 @@ func TestGeolocationEnrichment_Success(t *testing.T) {
 			}
 
 			err := enricher.Enrich(
+				context.Background(),
 				NewSourceBuilder("source-id").
 					WithGeoEnrichment(true).
 					Build(), input)
@@ -170,6 +173,7 @@ func TestGeolocationEnrichment_Success(t *testing.T) {
 			}
 
 			err := enricher.Enrich(
+				context.Background(),
 				NewSourceBuilder("source-id").
 					WithGeoEnrichment(false). // flag for enrichment is `false`
 					Build(),
@@ -205,6 +209,7 @@ func TestGeolocationEnrichment_Success(t *testing.T) {
 			}
 
 			err := enricher.Enrich(
+				context.Background(),
 				NewSourceBuilder("source-id").
 					WithGeoEnrichment(true).
 					Build(), input)
@@ -257,6 +262,7 @@ func TestGeolocationEnrichment_Success(t *testing.T) {
 			}
 
 			err := enricher.Enrich(
+				context.Background(),
 				NewSourceBuilder("source-id").
 					WithGeoEnrichment(true).
 					Build(), input)
@@ -318,6 +324,7 @@ func TestGeolocationEnrichment_Success(t *testing.T) {
 			}
 
 			err := enricher.Enrich(
+				context.Background(),
 				NewSourceBuilder("source-id").
 					WithGeoEnrichment(true).
 					Build(), input)
@@ -0,0 +1,387 @@
+package enricher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	lru "github.com/hashicorp/golang-lru/v2"
+	jsoniter "github.com/json-iterator/go"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+const (
+	LookupProviderRedis = "redis"
+	LookupProviderHTTP  = "http"
+
+	// LookupFailurePolicySkip lets events through without enrichment when the lookup fails
+	LookupFailurePolicySkip = "skip"
+	// LookupFailurePolicyAnnotate lets events through without enrichment, annotating them with the lookup error under context.lookupEnrichmentError
+	LookupFailurePolicyAnnotate = "annotate"
+
+	ERR_LOOKUP_FAILED = "lookup_failed"
+	ERR_NOT_FOUND     = "not_found"
+	ERR_EMPTY_KEY     = "empty_key"
+)
+
+// errLookupNotFound is returned by lookup fetchers when there are no attributes for the key
+var errLookupNotFound = errors.New("not found")
+
+// lookupFetcher fetches the attributes of a key from an external store
+type lookupFetcher interface {
+	Lookup(ctx context.Context, key string) (map[string]interface{}, error)
+	Close() error
+}
+
+type lookupCacheEntry struct {
+	attributes map[string]interface{}
+	err        error
+	expiresAt  time.Time
+}
+
+type lookupEnricher struct {
+	fetcher lookupFetcher
+	cache   *lru.Cache[string, lookupCacheEntry]
+	logger  logger.Logger
+	stats   stats.Stats
+	now     func() time.Time
+
+	config struct {
+		keyPath       []string
+		targetPath    []string
+		overwrite     bool
+		sourceIDs     map[string]struct{}
+		failurePolicy string
+		timeout       time.Duration
+		concurrency   int
+		cacheTTL      time.Duration
+		negativeTTL   time.Duration
+	}
+}
+
+// NewLookupEnricher returns an enricher which looks up a key of every event (e.g. userId) in Redis or an HTTP service,
+// merging the returned attributes into the event. Lookups are cached, bounded by a timeout and, when they fail, events
+// are let through without enrichment according to the configured failure policy.
+func NewLookupEnricher(conf *config.Config, log logger.Logger, statClient stats.Stats) (PipelineEnricher, error) {
+	log.Infof("Setting up new event lookup enricher")
+
+	e := &lookupEnricher{
+		logger: log.Child("lookup"),
+		stats:  statClient,
+		now:    time.Now,
+	}
+	e.config.keyPath = strings.Split(conf.GetString("LookupEnrichment.key", "userId"), ".")
+	e.config.targetPath = strings.Split(conf.GetString("LookupEnrichment.target", "context.traits"), ".")
+	e.config.overwrite = conf.GetBool("LookupEnrichment.overwrite", false)
+	e.config.failurePolicy = conf.GetString("LookupEnrichment.failurePolicy", LookupFailurePolicySkip)
+	e.config.timeout = conf.GetDuration("LookupEnrichment.timeout", 1, time.Second)
+	e.config.concurrency = conf.GetInt("LookupEnrichment.concurrency", 10)
+	e.config.cacheTTL = conf.GetDuration("LookupEnrichment.cache.ttl", 5, time.Minute)
+	e.config.negativeTTL = conf.GetDuration("LookupEnrichment.cache.negativeTTL", 1, time.Minute)
+	if sourceIDs := conf.GetStringSlice("LookupEnrichment.sourceIds", nil); len(sourceIDs) > 0 {
+		e.config.sourceIDs = make(map[string]struct{}, len(sourceIDs))
+		for _, sourceID := range sourceIDs {
+			e.config.sourceIDs[sourceID] = struct{}{}
+		}
+	}
+	switch e.config.failurePolicy {
+	case LookupFailurePolicySkip, LookupFailurePolicyAnnotate:
+	default:
+		return nil, fmt.Errorf("unsupported lookup enrichment failure policy: %q", e.config.failurePolicy)
+	}
+
+	cache, err := lru.New[string, lookupCacheEntry](conf.GetInt("LookupEnrichment.cache.size", 10000))
+	if err != nil {
+		return nil, fmt.Errorf("creating lookup cache: %w", err)
+	}
+	e.cache = cache
+
+	switch provider := conf.GetString("LookupEnrichment.provider", LookupProviderHTTP); provider {
+	case LookupProviderRedis:
+		e.fetcher = &redisLookup{
+			client: redis.NewClient(&redis.Options{
+				Addr:     conf.GetString("LookupEnrichment.redis.addr", "localhost:6379"),
+				Username: conf.GetString("LookupEnrichment.redis.username", ""),
+				Password: conf.GetString("LookupEnrichment.redis.password", ""),
+				DB:       conf.GetInt("LookupEnrichment.redis.db", 0),
+			}),
+			keyPrefix: conf.GetString("LookupEnrichment.redis.keyPrefix", ""),
+			hash:      conf.GetString("LookupEnrichment.redis.dataType", "string") == "hash",
+		}
+	case LookupProviderHTTP:
+		urlTemplate := conf.GetString("LookupEnrichment.http.url", "")
+		if !strings.Contains(urlTemplate, "{key}") {
+			return nil, fmt.Errorf("lookup enrichment http url must contain a {key} placeholder: %q", urlTemplate)
+		}
+		e.fetcher = &httpLookup{
+			client:      &http.Client{Timeout: e.config.timeout},
+			urlTemplate: urlTemplate,
+			authToken:   conf.GetString("LookupEnrichment.http.authToken", ""),
+		}
+	default:
+		return nil, fmt.Errorf("unsupported lookup enrichment provider: %q", provider)
+	}
+	return e, nil
+}
+
+// Enrich looks up the key of every event in the batch, merging the returned attributes into the event.
+// Keys are looked up once per batch and cached across batches.
+func (e *lookupEnricher) Enrich(ctx context.Context, source *backendconfig.SourceT, request *types.GatewayBatchRequest) error {
+	if e.config.sourceIDs != nil {
+		if _, ok := e.config.sourceIDs[source.ID]; !ok {
+			return nil
+		}
+	}
+	defer e.stats.NewTaggedStat(
+		"proc_lookup_enricher_request_latency",
+		stats.TimerType,
+		stats.Tags{
+			"sourceId":    source.ID,
+			"sourceType":  source.SourceDefinition.Type,
+			"workspaceId": source.WorkspaceID,
+		},
+	).RecordDuration()()
+
+	keys := make([]string, len(request.Batch))
+	results := make(map[string]lookupCacheEntry)
+	var missing []string
+	for i, event := range request.Batch {
+		key := keyString(misc.MapLookup(event, e.config.keyPath...))
+		keys[i] = key
+		if key == "" {
+			continue
+		}
+		if _, ok := results[key]; ok {
+			continue
+		}
+		if entry, ok := e.cache.Get(key); ok && entry.expiresAt.After(e.now()) {
+			results[key] = entry
+			continue
+		}
+		results[key] = lookupCacheEntry{}
+		missing = append(missing, key)
+	}
+
+	var mu sync.Mutex
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(e.config.concurrency)
+	for _, key := range missing {
+		g.Go(func() error {
+			ctx, cancel := context.WithTimeout(ctx, e.config.timeout)
+			defer cancel()
+			attributes, err := e.fetcher.Lookup(ctx, key)
+			entry := lookupCacheEntry{attributes: attributes, err: err}
+			switch {
+			case err == nil:
+				entry.expiresAt = e.now().Add(e.config.cacheTTL)
+				e.cache.Add(key, entry)
+			case errors.Is(err, errLookupNotFound):
+				entry.expiresAt = e.now().Add(e.config.negativeTTL)
+				e.cache.Add(key, entry)
+			}
+			mu.Lock()
+			results[key] = entry
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var enrichErrs []error
+	for i, event := range request.Batch {
+		errType := ""
+		entry := results[keys[i]]
+		switch {
+		case keys[i] == "":
+			errType = ERR_EMPTY_KEY
+		case errors.Is(entry.err, errLookupNotFound):
+			errType = ERR_NOT_FOUND
+		case entry.err != nil:
+			errType = ERR_LOOKUP_FAILED
+			enrichErrs = append(enrichErrs, fmt.Errorf("looking up key %q: %w", keys[i], entry.err))
+			if e.config.failurePolicy == LookupFailurePolicyAnnotate {
+				if context, ok := event["context"].(map[string]interface{}); ok {
+					context["lookupEnrichmentError"] = entry.err.Error()
+				} else if _, exists := event["context"]; !exists {
+					event["context"] = map[string]interface{}{"lookupEnrichmentError": entry.err.Error()}
+				}
+			}
+		default:
+			e.merge(event, entry.attributes)
+		}
+		e.stats.NewTaggedStat(
+			"proc_lookup_enricher_request",
+			stats.CountType,
+			stats.Tags{
+				"sourceId":    source.ID,
+				"workspaceId": source.WorkspaceID,
+				"sourceType":  source.SourceDefinition.Type,
+				"error":       errType,
+			}).Increment()
+	}
+	return errors.Join(enrichErrs...)
+}
+
+// merge merges the attributes into the target section of the event, creating it if missing.
+// Existing values are preserved, unless overwrite is enabled.
+func (e *lookupEnricher) merge(event types.SingularEventT, attributes map[string]interface{}) {
+	target := map[string]interface{}(event)
+	for _, k := range e.config.targetPath {
+		next, ok := target[k]
+		if !ok {
+			next = map[string]interface{}{}
+			target[k] = next
+		}
+		nextMap, ok := next.(map[string]interface{})
+		if !ok {
+			return
+		}
+		target = nextMap
+	}
+	for k, v := range attributes {
+		if _, exists := target[k]; exists && !e.config.overwrite {
+			continue
+		}
+		// attributes are shared with the cache and other events of the batch, so nested values get copied
+		target[k] = deepCopy(v)
+	}
+}
+
+// deepCopy returns a copy of the value, copying the nested maps and slices of attributes decoded from JSON
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, nested := range v {
+			c[k] = deepCopy(nested)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, nested := range v {
+			c[i] = deepCopy(nested)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+func (e *lookupEnricher) Close() error {
+	e.logger.Info("closing the lookup enricher")
+
+	if err := e.fetcher.Close(); err != nil {
+		return fmt.Errorf("closing the lookup enricher: %w", err)
+	}
+	return nil
+}
+
+func keyString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case float64, int, int64, bool:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// redisLookup looks up keys in redis, either as strings holding a JSON object or as hashes
+type redisLookup struct {
+	client    *redis.Client
+	keyPrefix string
+	hash      bool
+}
+
+func (r *redisLookup) Lookup(ctx context.Context, key string) (map[string]interface{}, error) {
+	if r.hash {
+		values, err := r.client.HGetAll(ctx, r.keyPrefix+key).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(values) == 0 {
+			return nil, errLookupNotFound
+		}
+		attributes := make(map[string]interface{}, len(values))
+		for k, v := range values {
+			attributes[k] = v
+		}
+		return attributes, nil
+	}
+	value, err := r.client.Get(ctx, r.keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errLookupNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var attributes map[string]interface{}
+	if err := jsoniter.Unmarshal(value, &attributes); err != nil {
+		return nil, fmt.Errorf("unmarshalling attributes: %w", err)
+	}
+	return attributes, nil
+}
+
+func (r *redisLookup) Close() error {
+	return r.client.Close()
+}
+
+// httpLookup looks up keys by issuing a GET request to a url, replacing its {key} placeholder.
+// The service is expected to respond with a JSON object, or 404 if there are no attributes for the key.
+type httpLookup struct {
+	client      *http.Client
+	urlTemplate string
+	authToken   string
+}
+
+func (h *httpLookup) Lookup(ctx context.Context, key string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.ReplaceAll(h.urlTemplate, "{key}", url.PathEscape(key)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if h.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+h.authToken)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, errLookupNotFound
+	default:
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	var attributes map[string]interface{}
+	if err := jsoniter.Unmarshal(body, &attributes); err != nil {
+		return nil, fmt.Errorf("unmarshalling attributes: %w", err)
+	}
+	return attributes, nil
+}
+
+func (h *httpLookup) Close() error {
+	h.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,154 @@
+package enricher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+func TestLookupEnrichment(t *testing.T) {
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch strings.TrimPrefix(r.URL.Path, "/users/") {
+		case "user-1":
+			_, _ = w.Write([]byte(`{"plan":"enterprise","name":"Looked up"}`))
+		case "user-2":
+			_, _ = w.Write([]byte(`{"plan":"free"}`))
+		case "nested":
+			_, _ = w.Write([]byte(`{"company":{"name":"Acme"},"tags":["a"]}`))
+		case "slow":
+			time.Sleep(500 * time.Millisecond)
+			_, _ = w.Write([]byte(`{"plan":"free"}`))
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	source := &backendconfig.SourceT{ID: "source-1", WorkspaceID: "workspace-1"}
+	newEnricher := func(t *testing.T, overrides map[string]interface{}) (PipelineEnricher, *memstats.Store) {
+		c := config.New()
+		c.Set("LookupEnrichment.http.url", srv.URL+"/users/{key}")
+		c.Set("LookupEnrichment.http.authToken", "token")
+		c.Set("LookupEnrichment.timeout", "100ms")
+		for k, v := range overrides {
+			c.Set(k, v)
+		}
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+		e, err := NewLookupEnricher(c, logger.NOP, statsStore)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = e.Close() })
+		return e, statsStore
+	}
+
+	t.Run("enriches events, skipping failed lookups", func(t *testing.T) {
+		e, statsStore := newEnricher(t, nil)
+		request := &types.GatewayBatchRequest{Batch: []types.SingularEventT{
+			{"userId": "user-1", "context": map[string]interface{}{"traits": map[string]interface{}{"name": "Original"}}},
+			{"userId": "user-2"},
+			{"userId": "unknown"},
+			{"userId": "broken"},
+			{"userId": "slow"},
+			{"anonymousId": "anon"},
+		}}
+		err := e.Enrich(context.Background(), source, request)
+		require.Error(t, err, "failed lookups should be reported")
+
+		require.Equal(t, map[string]interface{}{"traits": map[string]interface{}{"name": "Original", "plan": "enterprise"}}, request.Batch[0]["context"])
+		require.Equal(t, map[string]interface{}{"traits": map[string]interface{}{"plan": "free"}}, request.Batch[1]["context"])
+		require.NotContains(t, request.Batch[2], "context")
+		require.NotContains(t, request.Batch[3], "context")
+		require.NotContains(t, request.Batch[4], "context")
+		require.NotContains(t, request.Batch[5], "context")
+
+		tags := func(errType string) stats.Tags {
+			return stats.Tags{"sourceId": "source-1", "workspaceId": "workspace-1", "sourceType": "", "error": errType}
+		}
+		require.EqualValues(t, 2, statsStore.Get("proc_lookup_enricher_request", tags("")).LastValue())
+		require.EqualValues(t, 1, statsStore.Get("proc_lookup_enricher_request", tags(ERR_NOT_FOUND)).LastValue())
+		require.EqualValues(t, 2, statsStore.Get("proc_lookup_enricher_request", tags(ERR_LOOKUP_FAILED)).LastValue())
+		require.EqualValues(t, 1, statsStore.Get("proc_lookup_enricher_request", tags(ERR_EMPTY_KEY)).LastValue())
+	})
+
+	t.Run("caches lookups", func(t *testing.T) {
+		e, _ := newEnricher(t, nil)
+		before := requests.Load()
+		for i := 0; i < 3; i++ {
+			request := &types.GatewayBatchRequest{Batch: []types.SingularEventT{{"userId": "user-1"}, {"userId": "user-1"}, {"userId": "unknown"}}}
+			require.NoError(t, e.Enrich(context.Background(), source, request))
+			require.Equal(t, map[string]interface{}{"traits": map[string]interface{}{"plan": "enterprise", "name": "Looked up"}}, request.Batch[1]["context"])
+		}
+		require.EqualValues(t, 2, requests.Load()-before, "found and not found keys should be looked up once")
+	})
+
+	t.Run("events don't share the looked up attributes", func(t *testing.T) {
+		e, _ := newEnricher(t, nil)
+		request := &types.GatewayBatchRequest{Batch: []types.SingularEventT{{"userId": "nested"}, {"userId": "nested"}}}
+		require.NoError(t, e.Enrich(context.Background(), source, request))
+		request.Batch[0]["context"].(map[string]interface{})["traits"].(map[string]interface{})["company"].(map[string]interface{})["name"] = "Changed"
+		request.Batch[0]["context"].(map[string]interface{})["traits"].(map[string]interface{})["tags"].([]interface{})[0] = "changed"
+
+		expected := map[string]interface{}{"traits": map[string]interface{}{"company": map[string]interface{}{"name": "Acme"}, "tags": []interface{}{"a"}}}
+		require.Equal(t, expected, request.Batch[1]["context"], "changing an event should not affect other events")
+		request = &types.GatewayBatchRequest{Batch: []types.SingularEventT{{"userId": "nested"}}}
+		require.NoError(t, e.Enrich(context.Background(), source, request))
+		require.Equal(t, expected, request.Batch[0]["context"], "changing an event should not affect the cache")
+	})
+
+	t.Run("overwrite, custom key and target, annotate policy", func(t *testing.T) {
+		e, _ := newEnricher(t, map[string]interface{}{
+			"LookupEnrichment.key":           "context.traits.accountId",
+			"LookupEnrichment.target":        "properties.account",
+			"LookupEnrichment.overwrite":     true,
+			"LookupEnrichment.failurePolicy": LookupFailurePolicyAnnotate,
+		})
+		request := &types.GatewayBatchRequest{Batch: []types.SingularEventT{
+			{"context": map[string]interface{}{"traits": map[string]interface{}{"accountId": "user-1"}}, "properties": map[string]interface{}{"account": map[string]interface{}{"plan": "old"}}},
+			{"context": map[string]interface{}{"traits": map[string]interface{}{"accountId": "broken"}}},
+		}}
+		require.Error(t, e.Enrich(context.Background(), source, request))
+		require.Equal(t, map[string]interface{}{"account": map[string]interface{}{"plan": "enterprise", "name": "Looked up"}}, request.Batch[0]["properties"])
+		require.Contains(t, request.Batch[1]["context"], "lookupEnrichmentError")
+	})
+
+	t.Run("only configured sources are enriched", func(t *testing.T) {
+		e, _ := newEnricher(t, map[string]interface{}{"LookupEnrichment.sourceIds": []string{"other-source"}})
+		request := &types.GatewayBatchRequest{Batch: []types.SingularEventT{{"userId": "user-1"}}}
+		require.NoError(t, e.Enrich(context.Background(), source, request))
+		require.NotContains(t, request.Batch[0], "context")
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		for _, overrides := range []map[string]interface{}{
+			{"LookupEnrichment.provider": "memcached"},
+			{"LookupEnrichment.failurePolicy": "drop"},
+			{"LookupEnrichment.http.url": srv.URL + "/users"},
+		} {
+			c := config.New()
+			c.Set("LookupEnrichment.http.url", srv.URL+"/users/{key}")
+			for k, v := range overrides {
+				c.Set(k, v)
+			}
+			_, err := NewLookupEnricher(c, logger.NOP, stats.NOP)
+			require.Error(t, err)
+		}
+	})
+}
@@ -0,0 +1,329 @@
+package enricher
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+const (
+	SessionStoreMemory = "memory"
+	SessionStoreRedis  = "redis"
+)
+
+// sessionState is the state of the current session of a user
+type sessionState struct {
+	sessionID   int64 // session start time in unix milliseconds, following the convention of the SDKs
+	lastEventAt int64 // time of the latest event of the session in unix milliseconds
+}
+
+// sessionAssignment is the session assigned to an event
+type sessionAssignment struct {
+	sessionID int64
+	start     bool // the event starts the session
+}
+
+// sessionStore keeps the current session of users, expiring them after the inactivity window
+type sessionStore interface {
+	// Assign assigns sessions to the event times of every user, given in chronological order, continuing the current
+	// session of the user if within the inactivity window. Sessions of a user are read and updated atomically, so that
+	// concurrent batches of the same user, e.g. from multiple processors, don't start overlapping sessions.
+	Assign(ctx context.Context, eventTimes map[string][]int64, window time.Duration) (map[string][]sessionAssignment, error)
+	Close() error
+}
+
+// assignSessions assigns sessions to the event times, starting from the current session of the user if any
+func assignSessions(state sessionState, ok bool, eventTimes []int64, window int64) ([]sessionAssignment, sessionState) {
+	assignments := make([]sessionAssignment, len(eventTimes))
+	for i, at := range eventTimes {
+		sessionStart := !ok || at-state.lastEventAt > window
+		if sessionStart {
+			state = sessionState{sessionID: at, lastEventAt: at}
+			ok = true
+		} else if at > state.lastEventAt {
+			state.lastEventAt = at
+		}
+		assignments[i] = sessionAssignment{sessionID: state.sessionID, start: sessionStart}
+	}
+	return assignments, state
+}
+
+type sessionEnricher struct {
+	store  sessionStore
+	logger logger.Logger
+	stats  stats.Stats
+
+	config struct {
+		inactivityWindow config.ValueLoader[time.Duration]
+		overwrite        bool
+		sourceIDs        map[string]struct{}
+		timeout          time.Duration
+	}
+}
+
+// NewSessionEnricher returns an enricher which assigns server-side sessions to events, based on an inactivity window per user.
+// Every event gets context.sessionId, the start time of its session in unix milliseconds, while the first event of every
+// session gets context.sessionStart set to true. Sessions are kept in memory or in redis, expiring after the inactivity window.
+func NewSessionEnricher(conf *config.Config, log logger.Logger, statClient stats.Stats) (PipelineEnricher, error) {
+	log.Infof("Setting up new sessionization enricher")
+
+	e := &sessionEnricher{
+		logger: log.Child("session"),
+		stats:  statClient,
+	}
+	e.config.inactivityWindow = conf.GetReloadableDurationVar(30, time.Minute, "Sessionization.inactivityWindow")
+	e.config.overwrite = conf.GetBool("Sessionization.overwrite", false)
+	e.config.timeout = conf.GetDuration("Sessionization.timeout", 1, time.Second)
+	if sourceIDs := conf.GetStringSlice("Sessionization.sourceIds", nil); len(sourceIDs) > 0 {
+		e.config.sourceIDs = make(map[string]struct{}, len(sourceIDs))
+		for _, sourceID := range sourceIDs {
+			e.config.sourceIDs[sourceID] = struct{}{}
+		}
+	}
+
+	switch store := conf.GetString("Sessionization.store", SessionStoreMemory); store {
+	case SessionStoreMemory:
+		cache, err := lru.New[string, memorySessionEntry](conf.GetInt("Sessionization.memory.size", 100000))
+		if err != nil {
+			return nil, fmt.Errorf("creating session cache: %w", err)
+		}
+		e.store = &memorySessionStore{cache: cache, now: time.Now}
+	case SessionStoreRedis:
+		e.store = &redisSessionStore{
+			client: redis.NewClient(&redis.Options{
+				Addr:     conf.GetString("Sessionization.redis.addr", "localhost:6379"),
+				Username: conf.GetString("Sessionization.redis.username", ""),
+				Password: conf.GetString("Sessionization.redis.password", ""),
+				DB:       conf.GetInt("Sessionization.redis.db", 0),
+			}),
+			keyPrefix: conf.GetString("Sessionization.redis.keyPrefix", "rudder:session:"),
+		}
+	default:
+		return nil, fmt.Errorf("unsupported sessionization store: %q", store)
+	}
+	return e, nil
+}
+
+// Enrich assigns a session to every event of the batch having a user, in the order of the event timestamps.
+// Events which already carry a context.sessionId, e.g. from a client-side session, are left as they are unless overwrite is enabled.
+func (e *sessionEnricher) Enrich(ctx context.Context, source *backendconfig.SourceT, request *types.GatewayBatchRequest) error {
+	if e.config.sourceIDs != nil {
+		if _, ok := e.config.sourceIDs[source.ID]; !ok {
+			return nil
+		}
+	}
+
+	type sessionEvent struct {
+		event types.SingularEventT
+		key   string
+		at    int64
+	}
+	events := make([]sessionEvent, 0, len(request.Batch))
+	for _, event := range request.Batch {
+		if !e.config.overwrite && misc.MapLookup(event, "context", "sessionId") != nil {
+			continue
+		}
+		user := keyString(event["anonymousId"])
+		if user == "" {
+			user = keyString(event["userId"])
+		}
+		if user == "" {
+			continue
+		}
+		key := source.WorkspaceID + ":" + user
+		events = append(events, sessionEvent{event: event, key: key, at: eventTime(event, request.ReceivedAt).UnixMilli()})
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	// events of a user are assigned to sessions in chronological order, regardless of their order in the batch
+	sort.SliceStable(events, func(i, j int) bool { return events[i].at < events[j].at })
+	eventTimes := make(map[string][]int64)
+	for _, se := range events {
+		eventTimes[se.key] = append(eventTimes[se.key], se.at)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.config.timeout)
+	defer cancel()
+	statTags := stats.Tags{"sourceId": source.ID, "workspaceId": source.WorkspaceID, "sourceType": source.SourceDefinition.Category}
+	assignments, err := e.store.Assign(ctx, eventTimes, e.config.inactivityWindow.Load())
+	if err != nil {
+		e.stats.NewTaggedStat("proc_sessionization_store_errors", stats.CountType, statTags).Increment()
+		return fmt.Errorf("assigning sessions: %w", err)
+	}
+
+	var started int
+	for _, se := range events {
+		assignment := assignments[se.key][0]
+		assignments[se.key] = assignments[se.key][1:]
+		if assignment.start {
+			started++
+		}
+
+		eventContext, ok := se.event["context"].(map[string]interface{})
+		if !ok {
+			eventContext = make(map[string]interface{})
+			se.event["context"] = eventContext
+		}
+		eventContext["sessionId"] = assignment.sessionID
+		if assignment.start {
+			eventContext["sessionStart"] = true
+		} else {
+			delete(eventContext, "sessionStart")
+		}
+	}
+	e.stats.NewTaggedStat("proc_sessionization_events", stats.CountType, statTags).Count(len(events))
+	e.stats.NewTaggedStat("proc_sessionization_sessions_started", stats.CountType, statTags).Count(started)
+	return nil
+}
+
+func (e *sessionEnricher) Close() error {
+	e.logger.Info("closing the sessionization enricher")
+
+	if err := e.store.Close(); err != nil {
+		return fmt.Errorf("closing the sessionization enricher: %w", err)
+	}
+	return nil
+}
+
+// eventTime returns the time the event happened at, falling back to the time it was received at
+func eventTime(event types.SingularEventT, receivedAt time.Time) time.Time {
+	for _, field := range []string{"originalTimestamp", "timestamp"} {
+		if v, ok := event[field].(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t
+			}
+		}
+	}
+	if receivedAt.IsZero() {
+		return time.Now()
+	}
+	return receivedAt
+}
+
+type memorySessionEntry struct {
+	state     sessionState
+	expiresAt time.Time
+}
+
+// memorySessionStore keeps sessions in a bounded in-memory cache, which is lost on restarts
+type memorySessionStore struct {
+	mu    sync.Mutex
+	cache *lru.Cache[string, memorySessionEntry]
+	now   func() time.Time
+}
+
+func (m *memorySessionStore) Assign(_ context.Context, eventTimes map[string][]int64, window time.Duration) (map[string][]sessionAssignment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	assignments := make(map[string][]sessionAssignment, len(eventTimes))
+	for key, times := range eventTimes {
+		entry, ok := m.cache.Get(key)
+		ok = ok && entry.expiresAt.After(now)
+		var state sessionState
+		assignments[key], state = assignSessions(entry.state, ok, times, window.Milliseconds())
+		m.cache.Add(key, memorySessionEntry{state: state, expiresAt: now.Add(window)})
+	}
+	return assignments, nil
+}
+
+func (m *memorySessionStore) Close() error {
+	return nil
+}
+
+// redisSessionStore keeps sessions in redis as "<sessionId>:<lastEventAt>" strings, expiring after the inactivity window
+type redisSessionStore struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+// assignSessionsScript is the redis counterpart of assignSessions, reading and updating the session of a user atomically:
+//
+//	KEYS[1]      the key of the user
+//	ARGV[1]      the inactivity window in milliseconds, which is also the ttl of the session
+//	ARGV[2...]   the event times of the user in chronological order
+//
+// returning the session id and whether the event starts the session (1) or not (0) for every event time
+var assignSessionsScript = redis.NewScript(`
+local sessionID, lastEventAt
+local value = redis.call('GET', KEYS[1])
+if value then
+	local sep = string.find(value, ':', 1, true)
+	if sep then
+		sessionID = tonumber(string.sub(value, 1, sep - 1))
+		lastEventAt = tonumber(string.sub(value, sep + 1))
+	end
+end
+local window = tonumber(ARGV[1])
+local assignments = {}
+for i = 2, #ARGV do
+	local at = tonumber(ARGV[i])
+	if not sessionID or not lastEventAt or at - lastEventAt > window then
+		sessionID, lastEventAt = at, at
+		table.insert(assignments, sessionID)
+		table.insert(assignments, 1)
+	else
+		if at > lastEventAt then
+			lastEventAt = at
+		end
+		table.insert(assignments, sessionID)
+		table.insert(assignments, 0)
+	end
+end
+redis.call('SET', KEYS[1], string.format('%d:%d', sessionID, lastEventAt), 'PX', window)
+return assignments
+`)
+
+func (r *redisSessionStore) Assign(ctx context.Context, eventTimes map[string][]int64, window time.Duration) (map[string][]sessionAssignment, error) {
+	// make sure the script is cached, so that it can be evaluated by its sha in the pipeline
+	if err := assignSessionsScript.Load(ctx, r.client).Err(); err != nil {
+		return nil, fmt.Errorf("loading script: %w", err)
+	}
+	cmds := make(map[string]*redis.Cmd, len(eventTimes))
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, times := range eventTimes {
+			args := make([]interface{}, 0, len(times)+1)
+			args = append(args, window.Milliseconds())
+			for _, at := range times {
+				args = append(args, at)
+			}
+			cmds[key] = assignSessionsScript.EvalSha(ctx, pipe, []string{r.keyPrefix + key}, args...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	assignments := make(map[string][]sessionAssignment, len(eventTimes))
+	for key, cmd := range cmds {
+		values, err := cmd.Int64Slice()
+		if err != nil {
+			return nil, fmt.Errorf("reading sessions of %q: %w", key, err)
+		}
+		if len(values) != 2*len(eventTimes[key]) {
+			return nil, fmt.Errorf("unexpected number of sessions for %q: %d", key, len(values)/2)
+		}
+		assignments[key] = make([]sessionAssignment, len(values)/2)
+		for i := range assignments[key] {
+			assignments[key][i] = sessionAssignment{sessionID: values[2*i], start: values[2*i+1] == 1}
+		}
+	}
+	return assignments, nil
+}
+
+func (r *redisSessionStore) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,132 @@
+package enricher
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+func TestSessionEnrichment(t *testing.T) {
+	source := &backendconfig.SourceT{ID: "source-1", WorkspaceID: "workspace-1"}
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) string { return base.Add(d).Format(time.RFC3339Nano) }
+	sessionOf := func(event types.SingularEventT) (interface{}, interface{}) {
+		eventContext, _ := event["context"].(map[string]interface{})
+		return eventContext["sessionId"], eventContext["sessionStart"]
+	}
+	newEnricher := func(t *testing.T, overrides map[string]interface{}) PipelineEnricher {
+		c := config.New()
+		for k, v := range overrides {
+			c.Set(k, v)
+		}
+		e, err := NewSessionEnricher(c, logger.NOP, stats.NOP)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = e.Close() })
+		return e
+	}
+
+	t.Run("assigns sessions based on the inactivity window", func(t *testing.T) {
+		e := newEnricher(t, nil)
+		request := &types.GatewayBatchRequest{Batch: []types.SingularEventT{
+			{"anonymousId": "anon-1", "originalTimestamp": at(10 * time.Minute)},
+			{"anonymousId": "anon-1", "originalTimestamp": at(0)},
+			{"anonymousId": "anon-1", "originalTimestamp": at(50 * time.Minute)},
+			{"userId": "user-2", "timestamp": at(5 * time.Minute), "context": map[string]interface{}{"ip": "1.1.1.1"}},
+			{"event": "no user"},
+		}}
+		require.NoError(t, e.Enrich(context.Background(), source, request))
+
+		sessionID, sessionStart := sessionOf(request.Batch[1])
+		require.Equal(t, base.UnixMilli(), sessionID)
+		require.Equal(t, true, sessionStart)
+		sessionID, sessionStart = sessionOf(request.Batch[0])
+		require.Equal(t, base.UnixMilli(), sessionID, "events within the window belong to the same session, regardless of their order")
+		require.Nil(t, sessionStart)
+		sessionID, sessionStart = sessionOf(request.Batch[2])
+		require.Equal(t, base.Add(50*time.Minute).UnixMilli(), sessionID, "a new session starts after the inactivity window")
+		require.Equal(t, true, sessionStart)
+		sessionID, _ = sessionOf(request.Batch[3])
+		require.Equal(t, base.Add(5*time.Minute).UnixMilli(), sessionID)
+		require.Equal(t, "1.1.1.1", request.Batch[3]["context"].(map[string]interface{})["ip"])
+		require.NotContains(t, request.Batch[4], "context")
+
+		request = &types.GatewayBatchRequest{Batch: []types.SingularEventT{
+			{"anonymousId": "anon-1", "originalTimestamp": at(60 * time.Minute)},
+		}}
+		require.NoError(t, e.Enrich(context.Background(), source, request))
+		sessionID, sessionStart = sessionOf(request.Batch[0])
+		require.Equal(t, base.Add(50*time.Minute).UnixMilli(), sessionID, "sessions are kept across batches")
+		require.Nil(t, sessionStart)
+	})
+
+	t.Run("concurrent batches of a user share the session", func(t *testing.T) {
+		e := newEnricher(t, nil)
+		requests := make([]*types.GatewayBatchRequest, 20)
+		errs := make([]error, len(requests))
+		var wg sync.WaitGroup
+		for i := range requests {
+			requests[i] = &types.GatewayBatchRequest{Batch: []types.SingularEventT{
+				{"anonymousId": "anon-1", "originalTimestamp": at(time.Duration(i) * time.Second)},
+			}}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = e.Enrich(context.Background(), source, requests[i])
+			}()
+		}
+		wg.Wait()
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+
+		sessionIDs := make(map[interface{}]struct{})
+		var starts int
+		for _, request := range requests {
+			sessionID, sessionStart := sessionOf(request.Batch[0])
+			sessionIDs[sessionID] = struct{}{}
+			if sessionStart == true {
+				starts++
+			}
+		}
+		require.Len(t, sessionIDs, 1, "all events should belong to the same session")
+		require.Equal(t, 1, starts, "only one event should start the session")
+	})
+
+	t.Run("client-side sessions", func(t *testing.T) {
+		event := func() types.SingularEventT {
+			return types.SingularEventT{"anonymousId": "anon-1", "originalTimestamp": at(0), "context": map[string]interface{}{"sessionId": 123}}
+		}
+		request := &types.GatewayBatchRequest{Batch: []types.SingularEventT{event()}}
+		require.NoError(t, newEnricher(t, nil).Enrich(context.Background(), source, request))
+		sessionID, _ := sessionOf(request.Batch[0])
+		require.Equal(t, 123, sessionID)
+
+		request = &types.GatewayBatchRequest{Batch: []types.SingularEventT{event()}}
+		require.NoError(t, newEnricher(t, map[string]interface{}{"Sessionization.overwrite": true}).Enrich(context.Background(), source, request))
+		sessionID, _ = sessionOf(request.Batch[0])
+		require.Equal(t, base.UnixMilli(), sessionID)
+	})
+
+	t.Run("only configured sources are sessionized", func(t *testing.T) {
+		e := newEnricher(t, map[string]interface{}{"Sessionization.sourceIds": []string{"other-source"}})
+		request := &types.GatewayBatchRequest{Batch: []types.SingularEventT{{"userId": "user-1"}}}
+		require.NoError(t, e.Enrich(context.Background(), source, request))
+		require.NotContains(t, request.Batch[0], "context")
+	})
+
+	t.Run("invalid store", func(t *testing.T) {
+		c := config.New()
+		c.Set("Sessionization.store", "memcached")
+		_, err := NewSessionEnricher(c, logger.NOP, stats.NOP)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,198 @@
+// Package featureflags gates risky changes behind flags, which are rolled out gradually to a percentage of the
+// workspaces or destinations, or enabled for specific ones, through config or the control plane.
+//
+// The config of a flag is read from:
+//
+//	FeatureFlags.<name>.enabled       kill switch of the flag, nothing is enabled unless it is set
+//	FeatureFlags.<name>.percentage    percentage of the destinations, or of the workspaces if there is no destination, enabled
+//	FeatureFlags.<name>.workspaces    workspaces enabled regardless of the percentage
+//	FeatureFlags.<name>.destinations  destinations enabled regardless of the percentage
+//
+// The featureFlags of the workspace settings sent by the control plane take precedence over the config.
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+)
+
+// Flag is a feature flag
+type Flag struct {
+	Name        string
+	Description string
+}
+
+// ClickhouseS3Engine enables loading ClickHouse tables from S3 compatible object storages through the S3 table engine,
+// instead of downloading the load files
+var ClickhouseS3Engine = Flag{
+	Name:        "clickhouseS3Engine",
+	Description: "Load ClickHouse tables through the S3 table engine",
+}
+
+// flags are the feature flags reported in diagnostics, new flags need to be added here
+var flags = []Flag{
+	ClickhouseS3Engine,
+}
+
+// Default is the feature flags service of the server, nil until it is set up. All flags are disabled with a nil service.
+var Default *Service
+
+// Status is the state of a feature flag
+type Status struct {
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	Enabled      bool            `json:"enabled"`
+	Percentage   int             `json:"percentage"`
+	Workspaces   []string        `json:"workspaces"`
+	Destinations []string        `json:"destinations"`
+	Overrides    map[string]bool `json:"overrides"` // by workspace, set by the control plane
+}
+
+type flagConfig struct {
+	enabled      config.ValueLoader[bool]
+	percentage   config.ValueLoader[int]
+	workspaces   config.ValueLoader[[]string]
+	destinations config.ValueLoader[[]string]
+}
+
+// Service evaluates the feature flags
+type Service struct {
+	log    logger.Logger
+	config map[string]flagConfig
+
+	overridesMu sync.RWMutex
+	overrides   map[string]map[string]bool // by workspace, by flag
+}
+
+// New returns a feature flags service reading the config of the flags from conf
+func New(conf *config.Config, log logger.Logger) *Service {
+	s := &Service{
+		log:       log,
+		config:    make(map[string]flagConfig, len(flags)),
+		overrides: make(map[string]map[string]bool),
+	}
+	for _, flag := range flags {
+		s.config[flag.Name] = flagConfig{
+			enabled:      conf.GetReloadableBoolVar(false, "FeatureFlags."+flag.Name+".enabled"),
+			percentage:   conf.GetReloadableIntVar(0, 1, "FeatureFlags."+flag.Name+".percentage"),
+			workspaces:   conf.GetReloadableStringSliceVar(nil, "FeatureFlags."+flag.Name+".workspaces"),
+			destinations: conf.GetReloadableStringSliceVar(nil, "FeatureFlags."+flag.Name+".destinations"),
+		}
+	}
+	return s
+}
+
+// Enabled returns whether the flag is enabled for the workspace and the destination, which can be empty if the flag
+// is not about destinations
+func (s *Service) Enabled(flag Flag, workspaceID, destinationID string) bool {
+	if s == nil {
+		return false
+	}
+	s.overridesMu.RLock()
+	enabled, overridden := s.overrides[workspaceID][flag.Name]
+	s.overridesMu.RUnlock()
+	if overridden {
+		return enabled
+	}
+
+	c, ok := s.config[flag.Name]
+	if !ok || !c.enabled.Load() {
+		return false
+	}
+	if slices.Contains(c.workspaces.Load(), workspaceID) {
+		return true
+	}
+	if destinationID != "" && slices.Contains(c.destinations.Load(), destinationID) {
+		return true
+	}
+	rolloutKey := workspaceID
+	if destinationID != "" {
+		rolloutKey = destinationID
+	}
+	return bucket(flag.Name, rolloutKey) < c.percentage.Load()
+}
+
+// bucket assigns the key to one of 100 buckets, differently for each flag so that the same workspaces are not always
+// the first ones to get new features
+func bucket(flagName, key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(flagName + ":" + key))
+	return int(h.Sum32() % 100)
+}
+
+// Run keeps the overrides of the flags up to date with the workspace settings sent by the control plane,
+// until the context is cancelled
+func (s *Service) Run(ctx context.Context, bc backendconfig.BackendConfig) {
+	for data := range bc.Subscribe(ctx, backendconfig.TopicBackendConfig) {
+		workspaces := data.Data.(map[string]backendconfig.ConfigT)
+		overrides := make(map[string]map[string]bool, len(workspaces))
+		for workspaceID, wConfig := range workspaces {
+			if len(wConfig.Settings.FeatureFlags) > 0 {
+				overrides[workspaceID] = wConfig.Settings.FeatureFlags
+			}
+		}
+		s.overridesMu.Lock()
+		s.overrides = overrides
+		s.overridesMu.Unlock()
+		s.log.Debugn("Feature flag overrides updated", logger.NewIntField("workspaces", int64(len(overrides))))
+	}
+}
+
+// Status returns the state of all the flags
+func (s *Service) Status() []Status {
+	if s == nil {
+		return nil
+	}
+	s.overridesMu.RLock()
+	defer s.overridesMu.RUnlock()
+	statuses := make([]Status, 0, len(flags))
+	for _, flag := range flags {
+		c := s.config[flag.Name]
+		status := Status{
+			Name:         flag.Name,
+			Description:  flag.Description,
+			Enabled:      c.enabled.Load(),
+			Percentage:   c.percentage.Load(),
+			Workspaces:   c.workspaces.Load(),
+			Destinations: c.destinations.Load(),
+			Overrides:    make(map[string]bool),
+		}
+		for workspaceID, overrides := range s.overrides {
+			if enabled, ok := overrides[flag.Name]; ok {
+				status.Overrides[workspaceID] = enabled
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// HttpHandler returns the http handler reporting the state of all the flags
+func (s *Service) HttpHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.Status())
+	})
+}
+
+// Admin exposes the state of the flags over the admin interface
+type Admin struct {
+	Service *Service
+}
+
+// Status reports the state of all the flags
+func (a *Admin) Status(_ struct{}, reply *[]Status) error {
+	*reply = a.Service.Status()
+	return nil
+}
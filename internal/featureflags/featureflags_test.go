@@ -0,0 +1,132 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	mocksBackendConfig "github.com/rudderlabs/rudder-server/mocks/backend-config"
+	"github.com/rudderlabs/rudder-server/utils/pubsub"
+)
+
+func TestEnabled(t *testing.T) {
+	flag := ClickhouseS3Engine
+
+	t.Run("nil service", func(t *testing.T) {
+		var s *Service
+		require.False(t, s.Enabled(flag, "workspace", "destination"))
+		require.Nil(t, s.Status())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		c := config.New()
+		c.Set("FeatureFlags.clickhouseS3Engine.percentage", 100)
+		c.Set("FeatureFlags.clickhouseS3Engine.workspaces", []string{"workspace"})
+		s := New(c, logger.NOP)
+		require.False(t, s.Enabled(flag, "workspace", "destination"), "nothing should be enabled without the kill switch")
+	})
+
+	t.Run("workspaces and destinations", func(t *testing.T) {
+		c := config.New()
+		c.Set("FeatureFlags.clickhouseS3Engine.enabled", true)
+		c.Set("FeatureFlags.clickhouseS3Engine.workspaces", []string{"workspace-1"})
+		c.Set("FeatureFlags.clickhouseS3Engine.destinations", []string{"destination-2"})
+		s := New(c, logger.NOP)
+		require.True(t, s.Enabled(flag, "workspace-1", "destination-1"))
+		require.True(t, s.Enabled(flag, "workspace-2", "destination-2"))
+		require.False(t, s.Enabled(flag, "workspace-2", "destination-1"))
+		require.False(t, s.Enabled(Flag{Name: "unknown"}, "workspace-1", ""))
+
+		c.Set("FeatureFlags.clickhouseS3Engine.enabled", false)
+		require.False(t, s.Enabled(flag, "workspace-1", "destination-1"), "changes should be applied without restarting")
+	})
+
+	t.Run("percentage", func(t *testing.T) {
+		c := config.New()
+		c.Set("FeatureFlags.clickhouseS3Engine.enabled", true)
+		s := New(c, logger.NOP)
+
+		enabledDestinations := func() map[string]struct{} {
+			enabled := make(map[string]struct{})
+			for i := 0; i < 1000; i++ {
+				destinationID := fmt.Sprintf("destination-%d", i)
+				if s.Enabled(flag, "workspace", destinationID) {
+					enabled[destinationID] = struct{}{}
+				}
+			}
+			return enabled
+		}
+		require.Empty(t, enabledDestinations())
+
+		c.Set("FeatureFlags.clickhouseS3Engine.percentage", 20)
+		enabled := enabledDestinations()
+		require.InDelta(t, 200, len(enabled), 50)
+		require.Equal(t, enabled, enabledDestinations(), "rollout should be deterministic")
+
+		c.Set("FeatureFlags.clickhouseS3Engine.percentage", 50)
+		moreEnabled := enabledDestinations()
+		require.InDelta(t, 500, len(moreEnabled), 50)
+		for destinationID := range enabled {
+			require.Contains(t, moreEnabled, destinationID, "increasing the percentage should only add destinations")
+		}
+
+		c.Set("FeatureFlags.clickhouseS3Engine.percentage", 100)
+		require.Len(t, enabledDestinations(), 1000)
+	})
+}
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := config.New()
+	c.Set("FeatureFlags.clickhouseS3Engine.enabled", true)
+	c.Set("FeatureFlags.clickhouseS3Engine.workspaces", []string{"workspace-1"})
+	s := New(c, logger.NOP)
+
+	ctrl := gomock.NewController(t)
+	bc := mocksBackendConfig.NewMockBackendConfig(ctrl)
+	ch := make(chan pubsub.DataEvent, 1)
+	bc.EXPECT().Subscribe(gomock.Any(), backendconfig.TopicBackendConfig).Return(pubsub.DataChannel(ch))
+	ch <- pubsub.DataEvent{Topic: string(backendconfig.TopicBackendConfig), Data: map[string]backendconfig.ConfigT{
+		"workspace-1": {Settings: backendconfig.Settings{FeatureFlags: map[string]bool{"clickhouseS3Engine": false}}},
+		"workspace-2": {Settings: backendconfig.Settings{FeatureFlags: map[string]bool{"clickhouseS3Engine": true}}},
+		"workspace-3": {},
+	}}
+	close(ch)
+	s.Run(ctx, bc)
+
+	require.False(t, s.Enabled(ClickhouseS3Engine, "workspace-1", ""), "control plane should take precedence over config")
+	require.True(t, s.Enabled(ClickhouseS3Engine, "workspace-2", ""))
+	require.False(t, s.Enabled(ClickhouseS3Engine, "workspace-3", ""))
+
+	t.Run("status", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		s.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var statuses []Status
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &statuses))
+		require.Equal(t, []Status{{
+			Name:        ClickhouseS3Engine.Name,
+			Description: ClickhouseS3Engine.Description,
+			Enabled:     true,
+			Workspaces:  []string{"workspace-1"},
+			Overrides:   map[string]bool{"workspace-1": false, "workspace-2": true},
+		}}, statuses)
+
+		var reply []Status
+		require.NoError(t, (&Admin{Service: s}).Status(struct{}{}, &reply))
+		require.Equal(t, statuses, reply)
+	})
+}
@@ -0,0 +1,215 @@
+package job_trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/warehouse/client"
+)
+
+const (
+	FanOutDelivered  = "delivered"
+	FanOutFailed     = "failed"
+	FanOutInProgress = "in_progress"
+
+	stageProcessor   = "processor"
+	stageRouter      = "router"
+	stageBatchRouter = "batchRouter"
+
+	// statuses of the warehouse uploads, see the model of the warehouse
+	uploadExportedData = "exported_data"
+	uploadAborted      = "aborted"
+)
+
+// StagingFileDeliveries returns the status of the warehouse staging files events got uploaded to,
+// see [client.Warehouse.StagingFileDelivery]
+type StagingFileDeliveries interface {
+	StagingFileDelivery(ctx context.Context, sourceID, destinationID, location string) (client.StagingFileDelivery, error)
+}
+
+// Opt configures optional dependencies of the tracer
+type Opt func(*Tracer)
+
+// WithStagingFileDeliveries follows the events of warehouse destinations up to the uploads loading their staging files
+func WithStagingFileDeliveries(deliveries StagingFileDeliveries) Opt {
+	return func(t *Tracer) {
+		t.stagingFileDeliveries = deliveries
+	}
+}
+
+// FanOutParams selects the ingested events whose fan-out is reported, either by their correlation id,
+// i.e. the id of their gateway job, or by their message id
+type FanOutParams struct {
+	CorrelationID string
+	MessageID     string
+}
+
+// FanOut is the delivery status of ingested events across all of their destinations
+type FanOut struct {
+	Events []*EventFanOut `json:"events"`
+}
+
+// EventFanOut is the delivery status of an ingested event across all of its destinations
+type EventFanOut struct {
+	CorrelationID string    `json:"correlationId"`
+	MessageID     string    `json:"messageId"`
+	SourceID      string    `json:"sourceId"`
+	ReceivedAt    time.Time `json:"receivedAt"`
+	// Status is delivered once the event got processed and all the jobs derived from it succeeded, failed as soon as
+	// any of them failed for good, in_progress otherwise
+	Status       string                `json:"status"`
+	Destinations []DestinationDelivery `json:"destinations"`
+
+	processed bool // whether the processor is done with the gateway job of the event
+}
+
+// DestinationDelivery is the status of a job an event got transformed into for one of its destinations
+type DestinationDelivery struct {
+	DestinationID   string `json:"destinationId"`
+	DestinationType string `json:"destinationType"`
+	Stage           string `json:"stage"`
+	JobID           int64  `json:"jobId"`
+	// State is the state of the last status of the job, empty if the job was not picked up yet
+	State    string `json:"state"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+	// StagingFile and Warehouse are only set for the jobs of warehouse destinations which got uploaded to a staging file
+	StagingFile string                      `json:"stagingFile,omitempty"`
+	Warehouse   *client.StagingFileDelivery `json:"warehouse,omitempty"`
+}
+
+// FanOut reports the delivery status of the jobs derived from the ingested events matching the params, for every
+// destination the events were sent to. Jobs are matched by the correlation id they inherit from the gateway job of
+// their event.
+func (t *Tracer) FanOut(ctx context.Context, params FanOutParams) (*FanOut, error) {
+	if params.CorrelationID == "" && params.MessageID == "" {
+		return nil, errors.New("either correlationId or messageId is required")
+	}
+	lookupParams := jobsdb.LookupParams{Limit: maxLimit}
+	if params.CorrelationID != "" {
+		lookupParams.CorrelationIDs = []string{params.CorrelationID}
+	} else {
+		lookupParams.MessageIDs = []string{params.MessageID}
+	}
+	gatewayJobs, err := t.gatewayDB.Lookup(ctx, lookupParams)
+	if err != nil {
+		return nil, fmt.Errorf("looking up gateway jobs: %w", err)
+	}
+
+	fanOut := FanOut{Events: []*EventFanOut{}}
+	events := make(map[string]*EventFanOut)
+	for _, job := range gatewayJobs {
+		correlationID := gjson.GetBytes(job.Parameters, "correlation_id").String()
+		if correlationID == "" {
+			continue // ingested before correlation ids got introduced
+		}
+		event := &EventFanOut{
+			CorrelationID: correlationID,
+			MessageID:     gjson.GetBytes(job.EventPayload, "batch.0.messageId").String(),
+			SourceID:      gjson.GetBytes(job.Parameters, "source_id").String(),
+			ReceivedAt:    job.CreatedAt,
+			Destinations:  []DestinationDelivery{},
+			processed:     job.LastJobStatus.JobState == jobsdb.Succeeded.State,
+		}
+		events[correlationID] = event
+		fanOut.Events = append(fanOut.Events, event)
+	}
+	if len(events) == 0 {
+		return &fanOut, nil
+	}
+
+	lookupParams = jobsdb.LookupParams{Limit: maxLimit}
+	for correlationID := range events {
+		lookupParams.CorrelationIDs = append(lookupParams.CorrelationIDs, correlationID)
+	}
+	var procErrorJobs, routerJobs, batchRouterJobs []*jobsdb.TracedJob
+	g, gctx := errgroup.WithContext(ctx)
+	lookup := func(stage string, db JobsLookup, jobs *[]*jobsdb.TracedJob) {
+		g.Go(func() (err error) {
+			if *jobs, err = db.Lookup(gctx, lookupParams); err != nil {
+				return fmt.Errorf("looking up %s jobs: %w", stage, err)
+			}
+			return nil
+		})
+	}
+	lookup("processor error", t.procErrorDB, &procErrorJobs)
+	lookup("router", t.routerDB, &routerJobs)
+	lookup("batch router", t.batchRouterDB, &batchRouterJobs)
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	add := func(stage string, jobs []*jobsdb.TracedJob) {
+		for _, job := range jobs {
+			event, ok := events[gjson.GetBytes(job.Parameters, "correlation_id").String()]
+			if !ok {
+				continue
+			}
+			event.Destinations = append(event.Destinations, t.destinationDelivery(ctx, stage, job))
+		}
+	}
+	add(stageProcessor, procErrorJobs)
+	add(stageRouter, routerJobs)
+	add(stageBatchRouter, batchRouterJobs)
+	for _, event := range fanOut.Events {
+		event.Status = fanOutStatus(event.processed, event.Destinations)
+	}
+	return &fanOut, nil
+}
+
+func (t *Tracer) destinationDelivery(ctx context.Context, stage string, job *jobsdb.TracedJob) DestinationDelivery {
+	delivery := DestinationDelivery{
+		DestinationID:   gjson.GetBytes(job.Parameters, "destination_id").String(),
+		DestinationType: job.CustomVal,
+		Stage:           stage,
+		JobID:           job.JobID,
+	}
+	if stage == stageProcessor {
+		// processor errors are the events which failed to be transformed for the destination
+		delivery.State = jobsdb.Aborted.State
+		delivery.Error = gjson.GetBytes(job.Parameters, "error").String()
+		return delivery
+	}
+	if len(job.Statuses) == 0 {
+		return delivery
+	}
+	status := job.Statuses[len(job.Statuses)-1]
+	delivery.State = status.JobState
+	delivery.Attempts = status.AttemptNum
+	if status.JobState != jobsdb.Succeeded.State {
+		delivery.Error = string(status.ErrorResponse)
+	}
+	delivery.StagingFile = gjson.GetBytes(status.ErrorResponse, "stagingFile").String()
+	if delivery.StagingFile != "" && t.stagingFileDeliveries != nil {
+		sourceID := gjson.GetBytes(job.Parameters, "source_id").String()
+		if warehouse, err := t.stagingFileDeliveries.StagingFileDelivery(ctx, sourceID, delivery.DestinationID, delivery.StagingFile); err == nil {
+			delivery.Warehouse = &warehouse
+		}
+	}
+	return delivery
+}
+
+// fanOutStatus returns the overall status of the delivery of an event to its destinations
+func fanOutStatus(processed bool, destinations []DestinationDelivery) string {
+	status := FanOutDelivered
+	if !processed {
+		status = FanOutInProgress
+	}
+	for _, d := range destinations {
+		switch {
+		case d.State == jobsdb.Aborted.State,
+			d.Warehouse != nil && d.Warehouse.UploadStatus == uploadAborted:
+			return FanOutFailed
+		case d.State == jobsdb.Succeeded.State && d.Warehouse != nil && d.Warehouse.UploadStatus != uploadExportedData,
+			d.State != jobsdb.Succeeded.State && d.State != jobsdb.Filtered.State:
+			status = FanOutInProgress
+		}
+	}
+	return status
+}
@@ -0,0 +1,93 @@
+package job_trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// HttpHandler returns the http handler for tracing events through the pipeline, for support investigations:
+//
+//	GET  /     returns the jobs of the events matching either the messageId or the userId query parameter at each stage,
+//	           along with all of their statuses and the warehouse staging files they got uploaded to.
+//	           Jobs can be limited to the ones created within the from and to query parameters (RFC3339)
+//	           and their number per stage through the limit query parameter
+//	GET  /fan-out  returns the delivery status of the event matching either the correlationId or the messageId query
+//	           parameter across all of its destinations, following warehouse destinations up to their uploads
+func (t *Tracer) HttpHandler() http.Handler {
+	srvMux := chi.NewRouter()
+	srvMux.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		params, err := parseParams(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		trace, err := t.Trace(r.Context(), params)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body, err := json.Marshal(trace)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+	srvMux.Get("/fan-out", func(w http.ResponseWriter, r *http.Request) {
+		params := FanOutParams{
+			CorrelationID: r.URL.Query().Get("correlationId"),
+			MessageID:     r.URL.Query().Get("messageId"),
+		}
+		if params.CorrelationID == "" && params.MessageID == "" {
+			http.Error(w, "either correlationId or messageId is required", http.StatusBadRequest)
+			return
+		}
+		fanOut, err := t.FanOut(r.Context(), params)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body, err := json.Marshal(fanOut)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+	return srvMux
+}
+
+func parseParams(r *http.Request) (Params, error) {
+	q := r.URL.Query()
+	p := Params{
+		MessageID: q.Get("messageId"),
+		UserID:    q.Get("userId"),
+	}
+	if p.MessageID == "" && p.UserID == "" {
+		return Params{}, fmt.Errorf("either messageId or userId is required")
+	}
+	var err error
+	if v := q.Get("from"); v != "" {
+		if p.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return Params{}, fmt.Errorf("invalid from: %q", v)
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if p.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return Params{}, fmt.Errorf("invalid to: %q", v)
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if p.Limit, err = strconv.Atoi(v); err != nil {
+			return Params{}, fmt.Errorf("invalid limit: %q", v)
+		}
+	}
+	return p, nil
+}
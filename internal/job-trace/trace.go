@@ -0,0 +1,157 @@
+package job_trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rudderlabs/rudder-server/jobsdb"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// JobsLookup looks jobs up in a jobsdb, see [jobsdb.Handle.Lookup]
+type JobsLookup interface {
+	Lookup(ctx context.Context, params jobsdb.LookupParams) ([]*jobsdb.TracedJob, error)
+}
+
+// Tracer traces events through the jobsdbs of the pipeline: from the gateway, through the processor,
+// up to the router, the batch router and the warehouse staging files events got uploaded to
+type Tracer struct {
+	gatewayDB     JobsLookup
+	procErrorDB   JobsLookup
+	routerDB      JobsLookup
+	batchRouterDB JobsLookup
+
+	stagingFileDeliveries StagingFileDeliveries // optional
+}
+
+// New returns a tracer looking jobs up in the given jobsdbs
+func New(gatewayDB, procErrorDB, routerDB, batchRouterDB JobsLookup, opts ...Opt) *Tracer {
+	t := &Tracer{
+		gatewayDB:     gatewayDB,
+		procErrorDB:   procErrorDB,
+		routerDB:      routerDB,
+		batchRouterDB: batchRouterDB,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Params selects the events to trace, either by their message id or by their user id
+type Params struct {
+	MessageID string
+	UserID    string
+	// From and To limit the creation time of the jobs, if not zero
+	From, To time.Time
+	// Limit is the maximum number of jobs returned per stage
+	Limit int
+}
+
+// Trace holds the jobs of the traced events at each stage of the pipeline
+type Trace struct {
+	Gateway         []*jobsdb.TracedJob `json:"gateway"`
+	ProcessorErrors []*jobsdb.TracedJob `json:"processorErrors"`
+	Router          []*jobsdb.TracedJob `json:"router"`
+	BatchRouter     []*jobsdb.TracedJob `json:"batchRouter"`
+	// StagingFiles are the locations of the warehouse staging files the batch router uploaded the events to
+	StagingFiles []string `json:"stagingFiles"`
+}
+
+// Trace looks up the gateway jobs of the events first, for finding the message ids of the events of the user,
+// and then the jobs the processor created out of them.
+// Processor outputs are matched by message id, since their user ids are not the ones of the events.
+func (t *Tracer) Trace(ctx context.Context, params Params) (*Trace, error) {
+	if params.MessageID == "" && params.UserID == "" {
+		return nil, errors.New("either messageId or userId is required")
+	}
+	if params.Limit <= 0 {
+		params.Limit = defaultLimit
+	}
+	params.Limit = min(params.Limit, maxLimit)
+
+	lookupParams := jobsdb.LookupParams{
+		UserID: params.UserID,
+		From:   params.From,
+		To:     params.To,
+		Limit:  params.Limit,
+	}
+	if params.MessageID != "" {
+		lookupParams.MessageIDs = []string{params.MessageID}
+	}
+	gatewayJobs, err := t.gatewayDB.Lookup(ctx, lookupParams)
+	if err != nil {
+		return nil, fmt.Errorf("looking up gateway jobs: %w", err)
+	}
+	lookupParams.MessageIDs = messageIDs(gatewayJobs, params)
+
+	trace := Trace{Gateway: gatewayJobs}
+	g, ctx := errgroup.WithContext(ctx)
+	lookup := func(stage string, db JobsLookup, jobs *[]*jobsdb.TracedJob) {
+		g.Go(func() (err error) {
+			if *jobs, err = db.Lookup(ctx, lookupParams); err != nil {
+				return fmt.Errorf("looking up %s jobs: %w", stage, err)
+			}
+			return nil
+		})
+	}
+	lookup("processor error", t.procErrorDB, &trace.ProcessorErrors)
+	if len(lookupParams.MessageIDs) > 0 {
+		lookup("router", t.routerDB, &trace.Router)
+		lookup("batch router", t.batchRouterDB, &trace.BatchRouter)
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	trace.StagingFiles = stagingFiles(trace.BatchRouter)
+	return &trace, nil
+}
+
+// messageIDs returns the message ids of the traced events, out of the gateway batches containing them
+func messageIDs(gatewayJobs []*jobsdb.TracedJob, params Params) []string {
+	var ids []string
+	seen := make(map[string]struct{})
+	add := func(id string) {
+		if _, ok := seen[id]; ok || id == "" {
+			return
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	add(params.MessageID)
+	for _, job := range gatewayJobs {
+		gjson.GetBytes(job.EventPayload, "batch").ForEach(func(_, event gjson.Result) bool {
+			if params.UserID != "" && event.Get("userId").String() == params.UserID {
+				add(event.Get("messageId").String())
+			}
+			return true
+		})
+	}
+	return ids
+}
+
+// stagingFiles returns the locations of the staging files recorded in the statuses of batch router jobs
+func stagingFiles(batchRouterJobs []*jobsdb.TracedJob) []string {
+	locations := []string{}
+	seen := make(map[string]struct{})
+	for _, job := range batchRouterJobs {
+		for _, status := range job.Statuses {
+			location := gjson.GetBytes(status.ErrorResponse, "stagingFile").String()
+			if _, ok := seen[location]; ok || location == "" {
+				continue
+			}
+			seen[location] = struct{}{}
+			locations = append(locations, location)
+		}
+	}
+	return locations
+}
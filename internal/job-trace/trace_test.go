@@ -0,0 +1,184 @@
+package job_trace_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	job_trace "github.com/rudderlabs/rudder-server/internal/job-trace"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/warehouse/client"
+)
+
+type lookupFunc func(params jobsdb.LookupParams) []*jobsdb.TracedJob
+
+func (f lookupFunc) Lookup(_ context.Context, params jobsdb.LookupParams) ([]*jobsdb.TracedJob, error) {
+	return f(params), nil
+}
+
+func TestTracer(t *testing.T) {
+	tracedJob := func(jobID int64, payload string, errorResponses ...string) *jobsdb.TracedJob {
+		job := &jobsdb.TracedJob{JobT: jobsdb.JobT{JobID: jobID, EventPayload: json.RawMessage(payload)}}
+		for _, errorResponse := range errorResponses {
+			job.Statuses = append(job.Statuses, jobsdb.JobStatusT{JobID: jobID, ErrorResponse: json.RawMessage(errorResponse)})
+		}
+		return job
+	}
+	var routerParams jobsdb.LookupParams
+	tracer := job_trace.New(
+		lookupFunc(func(params jobsdb.LookupParams) []*jobsdb.TracedJob {
+			return []*jobsdb.TracedJob{tracedJob(1, `{"batch":[{"messageId":"message-1","userId":"user-1"},{"messageId":"message-2","userId":"user-2"},{"messageId":"message-3","userId":"user-1"}]}`)}
+		}),
+		lookupFunc(func(params jobsdb.LookupParams) []*jobsdb.TracedJob {
+			return nil
+		}),
+		lookupFunc(func(params jobsdb.LookupParams) []*jobsdb.TracedJob {
+			routerParams = params
+			return []*jobsdb.TracedJob{tracedJob(2, `{}`)}
+		}),
+		lookupFunc(func(params jobsdb.LookupParams) []*jobsdb.TracedJob {
+			return []*jobsdb.TracedJob{
+				tracedJob(3, `{}`, `{"reason":"unavailable"}`, `{"success":"OK","stagingFile":"staging/file-1.json.gz"}`),
+				tracedJob(4, `{}`, `{"success":"OK","stagingFile":"staging/file-1.json.gz"}`),
+			}
+		}),
+	)
+
+	t.Run("by user id", func(t *testing.T) {
+		trace, err := tracer.Trace(context.Background(), job_trace.Params{UserID: "user-1"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"message-1", "message-3"}, routerParams.MessageIDs, "outputs should be looked up by the message ids of the events of the user")
+		require.Equal(t, 100, routerParams.Limit)
+		require.Len(t, trace.Gateway, 1)
+		require.Len(t, trace.Router, 1)
+		require.Len(t, trace.BatchRouter, 2)
+		require.Equal(t, []string{"staging/file-1.json.gz"}, trace.StagingFiles)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		tracer.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?messageId=message-2&limit=5000&from=2024-06-01T00:00:00Z", http.NoBody))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, []string{"message-2"}, routerParams.MessageIDs)
+		require.Equal(t, 1000, routerParams.Limit)
+		require.Equal(t, "2024-06-01T00:00:00Z", routerParams.From.Format("2006-01-02T15:04:05Z07:00"))
+		require.EqualValues(t, 1, gjson.Get(resp.Body.String(), "gateway.#").Int())
+		require.EqualValues(t, 0, gjson.Get(resp.Body.String(), "processorErrors.#").Int())
+		require.Equal(t, "staging/file-1.json.gz", gjson.Get(resp.Body.String(), "stagingFiles.0").String())
+
+		resp = httptest.NewRecorder()
+		tracer.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+
+		resp = httptest.NewRecorder()
+		tracer.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?userId=user-1&to=yesterday", http.NoBody))
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+type stagingFileDeliveriesFunc func(sourceID, destinationID, location string) client.StagingFileDelivery
+
+func (f stagingFileDeliveriesFunc) StagingFileDelivery(_ context.Context, sourceID, destinationID, location string) (client.StagingFileDelivery, error) {
+	return f(sourceID, destinationID, location), nil
+}
+
+func TestFanOut(t *testing.T) {
+	job := func(jobID int64, customVal, parameters string, states ...string) *jobsdb.TracedJob {
+		job := &jobsdb.TracedJob{JobT: jobsdb.JobT{JobID: jobID, CustomVal: customVal, Parameters: json.RawMessage(parameters), EventPayload: json.RawMessage(`{"batch":[{"messageId":"message-1"}]}`)}}
+		for i, state := range states {
+			status := jobsdb.JobStatusT{JobID: jobID, JobState: state, AttemptNum: i + 1, ErrorResponse: json.RawMessage(`{}`)}
+			if customVal == "POSTGRES" && state == jobsdb.Succeeded.State {
+				status.ErrorResponse = json.RawMessage(`{"success":"OK","stagingFile":"staging/file-1.json.gz"}`)
+			}
+			job.Statuses = append(job.Statuses, status)
+			job.LastJobStatus = status
+		}
+		return job
+	}
+	var (
+		gatewayParams  jobsdb.LookupParams
+		routerParams   jobsdb.LookupParams
+		uploadStatus   = "exporting_data"
+		routerStates   = []string{jobsdb.Failed.State, jobsdb.Succeeded.State}
+		procErrorsJobs []*jobsdb.TracedJob
+	)
+	tracer := job_trace.New(
+		lookupFunc(func(params jobsdb.LookupParams) []*jobsdb.TracedJob {
+			gatewayParams = params
+			return []*jobsdb.TracedJob{
+				job(1, "GW", `{"source_id":"source-1","correlation_id":"correlation-1"}`, jobsdb.Succeeded.State),
+				job(2, "GW", `{"source_id":"source-1"}`, jobsdb.Succeeded.State),
+			}
+		}),
+		lookupFunc(func(params jobsdb.LookupParams) []*jobsdb.TracedJob {
+			return procErrorsJobs
+		}),
+		lookupFunc(func(params jobsdb.LookupParams) []*jobsdb.TracedJob {
+			routerParams = params
+			return []*jobsdb.TracedJob{job(3, "WEBHOOK", `{"destination_id":"destination-1","correlation_id":"correlation-1"}`, routerStates...)}
+		}),
+		lookupFunc(func(params jobsdb.LookupParams) []*jobsdb.TracedJob {
+			return []*jobsdb.TracedJob{job(4, "POSTGRES", `{"source_id":"source-1","destination_id":"destination-2","correlation_id":"correlation-1"}`, jobsdb.Succeeded.State)}
+		}),
+		job_trace.WithStagingFileDeliveries(stagingFileDeliveriesFunc(func(sourceID, destinationID, location string) client.StagingFileDelivery {
+			require.Equal(t, "source-1", sourceID)
+			require.Equal(t, "destination-2", destinationID)
+			require.Equal(t, "staging/file-1.json.gz", location)
+			return client.StagingFileDelivery{StagingFileID: 1, Status: "succeeded", UploadID: 2, UploadStatus: uploadStatus}
+		})),
+	)
+
+	t.Run("in progress", func(t *testing.T) {
+		fanOut, err := tracer.FanOut(context.Background(), job_trace.FanOutParams{MessageID: "message-1"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"message-1"}, gatewayParams.MessageIDs)
+		require.Equal(t, []string{"correlation-1"}, routerParams.CorrelationIDs, "jobs should be looked up by the correlation ids of the gateway jobs")
+		require.Len(t, fanOut.Events, 1, "gateway jobs without a correlation id should be left out")
+
+		event := fanOut.Events[0]
+		require.Equal(t, "correlation-1", event.CorrelationID)
+		require.Equal(t, "message-1", event.MessageID)
+		require.Equal(t, job_trace.FanOutInProgress, event.Status, "the upload of the staging file is not done yet")
+		require.Equal(t, []job_trace.DestinationDelivery{
+			{DestinationID: "destination-1", DestinationType: "WEBHOOK", Stage: "router", JobID: 3, State: jobsdb.Succeeded.State, Attempts: 2},
+			{
+				DestinationID: "destination-2", DestinationType: "POSTGRES", Stage: "batchRouter", JobID: 4, State: jobsdb.Succeeded.State, Attempts: 1,
+				StagingFile: "staging/file-1.json.gz",
+				Warehouse:   &client.StagingFileDelivery{StagingFileID: 1, Status: "succeeded", UploadID: 2, UploadStatus: "exporting_data"},
+			},
+		}, event.Destinations)
+	})
+
+	t.Run("delivered", func(t *testing.T) {
+		uploadStatus = "exported_data"
+		fanOut, err := tracer.FanOut(context.Background(), job_trace.FanOutParams{CorrelationID: "correlation-1"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"correlation-1"}, gatewayParams.CorrelationIDs)
+		require.Equal(t, job_trace.FanOutDelivered, fanOut.Events[0].Status)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		procErrorsJobs = []*jobsdb.TracedJob{job(5, "WEBHOOK", `{"destination_id":"destination-3","correlation_id":"correlation-1","error":"invalid event"}`)}
+		fanOut, err := tracer.FanOut(context.Background(), job_trace.FanOutParams{CorrelationID: "correlation-1"})
+		require.NoError(t, err)
+		require.Equal(t, job_trace.FanOutFailed, fanOut.Events[0].Status)
+		require.Equal(t, job_trace.DestinationDelivery{DestinationID: "destination-3", DestinationType: "WEBHOOK", Stage: "processor", JobID: 5, State: jobsdb.Aborted.State, Error: "invalid event"}, fanOut.Events[0].Destinations[0])
+	})
+
+	t.Run("http", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		tracer.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/fan-out?correlationId=correlation-1", http.NoBody))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, "correlation-1", gjson.Get(resp.Body.String(), "events.0.correlationId").String())
+		require.EqualValues(t, 3, gjson.Get(resp.Body.String(), "events.0.destinations.#").Int())
+
+		resp = httptest.NewRecorder()
+		tracer.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/fan-out", http.NoBody))
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
@@ -0,0 +1,277 @@
+// Package liveevents streams samples of the events flowing through the pipeline to debugging sessions over
+// server-sent events, as they reach each stage:
+//
+//	gateway_in        events received by the gateway
+//	transformed       events transformed for a destination by the processor
+//	delivered         events sent to a destination by the router, along with the response of the destination
+//	warehouse_staged  staging files uploaded by the batch router for a warehouse destination
+//
+// Events are only recorded while a session is listening, and are dropped instead of slowing the pipeline down
+// whenever a session can't keep up.
+package liveevents
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+)
+
+// Stage is a stage of the pipeline events are recorded at
+type Stage string
+
+const (
+	GatewayIn       Stage = "gateway_in"
+	Transformed     Stage = "transformed"
+	Delivered       Stage = "delivered"
+	WarehouseStaged Stage = "warehouse_staged"
+)
+
+var stages = []Stage{GatewayIn, Transformed, Delivered, WarehouseStaged}
+
+// Default is the live events stream of the server, nil until it is set up. Nothing is recorded with a nil stream.
+var Default *Stream
+
+var (
+	errDisabled        = errors.New("live events are disabled, enable them with LiveEvents.enabled")
+	errTooManySessions = errors.New("too many live events sessions")
+)
+
+// Event is an event recorded at a stage of the pipeline
+type Event struct {
+	Stage         Stage           `json:"stage"`
+	SourceID      string          `json:"sourceId,omitempty"`
+	DestinationID string          `json:"destinationId,omitempty"`
+	Time          time.Time       `json:"time"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// Filter selects the events streamed to a session
+type Filter struct {
+	Stages        []Stage // all stages if empty
+	SourceID      string  // all sources if empty
+	DestinationID string  // all destinations if empty
+	SampleRate    float64 // fraction of the matching events streamed
+}
+
+func (f Filter) matches(stage Stage, sourceID, destinationID string) bool {
+	if len(f.Stages) > 0 && !slices.Contains(f.Stages, stage) {
+		return false
+	}
+	if f.SourceID != "" && f.SourceID != sourceID {
+		return false
+	}
+	if f.DestinationID != "" && f.DestinationID != destinationID {
+		return false
+	}
+	return f.SampleRate >= 1 || rand.Float64() < f.SampleRate // skipcq: GSC-G404
+}
+
+type session struct {
+	filter  Filter
+	events  chan Event
+	dropped atomic.Int64
+}
+
+// Stream dispatches the events recorded at each stage to the sessions listening to them
+type Stream struct {
+	log logger.Logger
+
+	enabled            config.ValueLoader[bool]
+	maxSessions        config.ValueLoader[int]
+	sampleRate         config.ValueLoader[float64]
+	maxSessionDuration config.ValueLoader[time.Duration]
+	heartbeatInterval  config.ValueLoader[time.Duration]
+	bufferSize         int
+
+	listening  atomic.Int32 // number of sessions, so that recording is cheap when nobody listens
+	sessionsMu sync.RWMutex
+	sessions   map[*session]struct{}
+}
+
+// New returns a live events stream reading its config from conf
+func New(conf *config.Config, log logger.Logger) *Stream {
+	return &Stream{
+		log:                log,
+		enabled:            conf.GetReloadableBoolVar(false, "LiveEvents.enabled"),
+		maxSessions:        conf.GetReloadableIntVar(10, 1, "LiveEvents.maxSessions"),
+		sampleRate:         conf.GetReloadableFloat64Var(0.1, "LiveEvents.sampleRate"),
+		maxSessionDuration: conf.GetReloadableDurationVar(30, time.Minute, "LiveEvents.maxSessionDuration"),
+		heartbeatInterval:  conf.GetReloadableDurationVar(15, time.Second, "LiveEvents.heartbeatInterval"),
+		bufferSize:         conf.GetIntVar(100, 1, "LiveEvents.bufferSize"),
+		sessions:           make(map[*session]struct{}),
+	}
+}
+
+// Listening returns whether a session is listening to events, so that callers can skip preparing the payload of
+// events when nobody is
+func (s *Stream) Listening() bool {
+	return s != nil && s.listening.Load() > 0
+}
+
+// Record sends the event to the sessions listening to it, without blocking
+func (s *Stream) Record(stage Stage, sourceID, destinationID string, payload []byte) {
+	if !s.Listening() {
+		return
+	}
+	var event *Event
+	s.sessionsMu.RLock()
+	defer s.sessionsMu.RUnlock()
+	for session := range s.sessions {
+		if !session.filter.matches(stage, sourceID, destinationID) {
+			continue
+		}
+		if event == nil {
+			if !json.Valid(payload) {
+				payload, _ = json.Marshal(string(payload))
+			}
+			event = &Event{Stage: stage, SourceID: sourceID, DestinationID: destinationID, Time: time.Now(), Payload: payload}
+		}
+		select {
+		case session.events <- *event:
+		default:
+			session.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribe starts a session receiving the events matching the filter, until the returned function is called
+func (s *Stream) Subscribe(filter Filter) (<-chan Event, func() (dropped int64), error) {
+	if s == nil || !s.enabled.Load() {
+		return nil, nil, errDisabled
+	}
+	session := &session{filter: filter, events: make(chan Event, s.bufferSize)}
+	s.sessionsMu.Lock()
+	if len(s.sessions) >= s.maxSessions.Load() {
+		s.sessionsMu.Unlock()
+		return nil, nil, errTooManySessions
+	}
+	s.sessions[session] = struct{}{}
+	s.listening.Add(1)
+	s.sessionsMu.Unlock()
+
+	var once sync.Once
+	return session.events, func() int64 {
+		once.Do(func() {
+			s.sessionsMu.Lock()
+			delete(s.sessions, session)
+			s.listening.Add(-1)
+			s.sessionsMu.Unlock()
+		})
+		return session.dropped.Load()
+	}, nil
+}
+
+// HttpHandler returns the http handler streaming events as server-sent events, named after their stage:
+//
+//	GET  /     streams the events of the stages (comma separated) in the stage query parameter, or all of them,
+//	           of the source and the destination in the sourceId and destinationId query parameters, if set.
+//	           Only the fraction of the events in the sample query parameter (defaults to LiveEvents.sampleRate)
+//	           is streamed, and the session ends after LiveEvents.maxSessionDuration
+func (s *Stream) HttpHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if s == nil {
+			http.Error(w, errDisabled.Error(), http.StatusNotFound)
+			return
+		}
+		filter, err := s.parseFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		events, unsubscribe, err := s.Subscribe(filter)
+		switch {
+		case errors.Is(err, errDisabled):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer func() {
+			dropped := unsubscribe()
+			s.log.Infon("Live events session ended",
+				logger.NewStringField("sourceId", filter.SourceID),
+				logger.NewStringField("destinationId", filter.DestinationID),
+				logger.NewIntField("dropped", dropped),
+			)
+		}()
+
+		rc := http.NewResponseController(w)
+		_ = rc.SetWriteDeadline(time.Time{}) // the write timeout of the server would end the session
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		sessionEnd := time.NewTimer(s.maxSessionDuration.Load())
+		defer sessionEnd.Stop()
+		heartbeat := time.NewTicker(s.heartbeatInterval.Load())
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-sessionEnd.C:
+				return
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			case event := <-events:
+				data, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Stage, data); err != nil {
+					return
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	})
+}
+
+func (s *Stream) parseFilter(r *http.Request) (Filter, error) {
+	q := r.URL.Query()
+	filter := Filter{
+		SourceID:      q.Get("sourceId"),
+		DestinationID: q.Get("destinationId"),
+		SampleRate:    s.sampleRate.Load(),
+	}
+	if v := q.Get("stage"); v != "" {
+		for _, stage := range strings.Split(v, ",") {
+			if !slices.Contains(stages, Stage(stage)) {
+				return Filter{}, fmt.Errorf("invalid stage: %q", stage)
+			}
+			filter.Stages = append(filter.Stages, Stage(stage))
+		}
+	}
+	if v := q.Get("sample"); v != "" {
+		sampleRate, err := strconv.ParseFloat(v, 64)
+		if err != nil || sampleRate <= 0 || sampleRate > 1 {
+			return Filter{}, fmt.Errorf("invalid sample: %q, needs to be within (0, 1]", v)
+		}
+		filter.SampleRate = sampleRate
+	}
+	return filter, nil
+}
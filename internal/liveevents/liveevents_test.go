@@ -0,0 +1,148 @@
+package liveevents
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+)
+
+func TestStream(t *testing.T) {
+	newStream := func() *Stream {
+		c := config.New()
+		c.Set("LiveEvents.enabled", true)
+		c.Set("LiveEvents.maxSessions", 2)
+		c.Set("LiveEvents.bufferSize", 2)
+		return New(c, logger.NOP)
+	}
+
+	t.Run("nil stream", func(t *testing.T) {
+		var s *Stream
+		require.False(t, s.Listening())
+		s.Record(GatewayIn, "source", "", []byte(`{}`))
+		_, _, err := s.Subscribe(Filter{SampleRate: 1})
+		require.ErrorIs(t, err, errDisabled)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		_, _, err := New(config.New(), logger.NOP).Subscribe(Filter{SampleRate: 1})
+		require.ErrorIs(t, err, errDisabled)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		s := newStream()
+		require.False(t, s.Listening())
+		events, unsubscribe, err := s.Subscribe(Filter{Stages: []Stage{Delivered}, DestinationID: "destination", SampleRate: 1})
+		require.NoError(t, err)
+		require.True(t, s.Listening())
+
+		s.Record(GatewayIn, "source", "", []byte(`{}`))
+		s.Record(Delivered, "source", "other", []byte(`{}`))
+		s.Record(Delivered, "source", "destination", []byte(`not json`))
+		s.Record(Delivered, "source", "destination", []byte(`{"a":1}`))
+		s.Record(Delivered, "source", "destination", []byte(`{"a":2}`))
+
+		event := <-events
+		require.Equal(t, Delivered, event.Stage)
+		require.Equal(t, "source", event.SourceID)
+		require.Equal(t, "destination", event.DestinationID)
+		require.JSONEq(t, `"not json"`, string(event.Payload))
+		require.JSONEq(t, `{"a":1}`, string((<-events).Payload))
+		require.Empty(t, events)
+		require.EqualValues(t, 1, unsubscribe(), "events should be dropped when the buffer is full")
+		require.False(t, s.Listening())
+		require.EqualValues(t, 1, unsubscribe())
+	})
+
+	t.Run("sampling", func(t *testing.T) {
+		s := newStream()
+		events, unsubscribe, err := s.Subscribe(Filter{SampleRate: 0.000001})
+		require.NoError(t, err)
+		defer unsubscribe()
+		for range 100 {
+			s.Record(Transformed, "source", "destination", []byte(`{}`))
+		}
+		require.Empty(t, events)
+	})
+
+	t.Run("too many sessions", func(t *testing.T) {
+		s := newStream()
+		_, unsubscribe1, err := s.Subscribe(Filter{SampleRate: 1})
+		require.NoError(t, err)
+		_, unsubscribe2, err := s.Subscribe(Filter{SampleRate: 1})
+		require.NoError(t, err)
+		defer unsubscribe2()
+		_, _, err = s.Subscribe(Filter{SampleRate: 1})
+		require.ErrorIs(t, err, errTooManySessions)
+		unsubscribe1()
+		_, unsubscribe3, err := s.Subscribe(Filter{SampleRate: 1})
+		require.NoError(t, err)
+		unsubscribe3()
+	})
+}
+
+func TestHttpHandler(t *testing.T) {
+	c := config.New()
+	c.Set("LiveEvents.enabled", true)
+	s := New(c, logger.NOP)
+	srv := httptest.NewServer(s.HttpHandler())
+	defer srv.Close()
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"stage=unknown", "sample=0", "sample=2", "sample=a"} {
+			resp, err := http.Get(srv.URL + "?" + query)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		srv := httptest.NewServer(New(config.New(), logger.NOP).HttpHandler())
+		defer srv.Close()
+		resp, err := http.Get(srv.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?stage=gateway_in,transformed&sourceId=source&sample=1", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		require.Eventually(t, s.Listening, time.Second, time.Millisecond)
+		s.Record(GatewayIn, "other", "", []byte(`{}`))
+		s.Record(Delivered, "source", "destination", []byte(`{}`))
+		s.Record(Transformed, "source", "destination", []byte(`{"event":"track"}`))
+
+		scanner := bufio.NewScanner(resp.Body)
+		require.True(t, scanner.Scan())
+		require.Equal(t, "event: transformed", scanner.Text())
+		require.True(t, scanner.Scan())
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		require.True(t, ok)
+		var event Event
+		require.NoError(t, json.Unmarshal([]byte(data), &event))
+		require.Equal(t, "destination", event.DestinationID)
+		require.JSONEq(t, `{"event":"track"}`, string(event.Payload))
+
+		cancel()
+		require.Eventually(t, func() bool { return !s.Listening() }, time.Second, time.Millisecond)
+	})
+}
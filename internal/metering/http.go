@@ -0,0 +1,66 @@
+package metering
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// HttpHandler returns the http handler for querying the usage:
+//
+//	GET  /     lists the usage of the workspaceId and sourceId query parameters, within the from and to months
+//	           query parameters (e.g. 2024-10), the current month by default. The usage is listed per workspace,
+//	           counting the users across their sources, with the groupBy=workspace query parameter
+func (m *Meter) HttpHandler() http.Handler {
+	srvMux := chi.NewRouter()
+	srvMux.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		usage, err := m.Usage(r.Context(), filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body, err := json.Marshal(usage)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+	return srvMux
+}
+
+func parseFilter(r *http.Request) (Filter, error) {
+	q := r.URL.Query()
+	f := Filter{
+		WorkspaceID: q.Get("workspaceId"),
+		SourceID:    q.Get("sourceId"),
+	}
+	var err error
+	if v := q.Get("from"); v != "" {
+		if f.From, err = time.Parse("2006-01", v); err != nil {
+			return Filter{}, fmt.Errorf("invalid from: %q", v)
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if f.To, err = time.Parse("2006-01", v); err != nil {
+			return Filter{}, fmt.Errorf("invalid to: %q", v)
+		}
+	}
+	switch groupBy := q.Get("groupBy"); groupBy {
+	case "", "source":
+	case "workspace":
+		f.ByWorkspace = true
+	default:
+		return Filter{}, fmt.Errorf("invalid groupBy: %q", groupBy)
+	}
+	return f, nil
+}
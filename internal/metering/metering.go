@@ -0,0 +1,467 @@
+// Package metering meters the usage of the sources, i.e. the events they ingested and their monthly tracked users,
+// per workspace, source and month, so that self-hosted deployments can plan their capacity and charge back their usage.
+//
+// The usage of the gateway jobs is recorded by the processor in the transaction marking them as processed, so that
+// every event is metered exactly once. Users are counted with hyperloglogs, which are merged per month by a compaction
+// routine, so that the transactions of the processor don't contend on the monthly usage.
+package metering
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/segmentio/go-hll"
+	"github.com/spaolacci/murmur3"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	migrator "github.com/rudderlabs/rudder-server/services/sql-migrator"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/utils/tx"
+)
+
+const (
+	defaultCompactionFrequency      = 1
+	defaultCompactionFrequencyUnits = time.Minute
+
+	defaultCleanupFrequency      = 24
+	defaultCleanupFrequencyUnits = time.Hour
+
+	defaultRetention      = 2 * 365 * 24
+	defaultRetentionUnits = time.Hour
+
+	// murmurSeed seeds the hashes of the users, changing it would count the users of the current month twice
+	murmurSeed = 123
+)
+
+// Report is the usage of a source within a batch of gateway jobs
+type Report struct {
+	Month       time.Time
+	WorkspaceID string
+	SourceID    string
+	Events      int64
+	Users       *hll.Hll
+}
+
+// Usage is the usage of a source, or of a workspace if SourceID is empty, within a month
+type Usage struct {
+	Month       string `json:"month"` // e.g. 2024-10
+	WorkspaceID string `json:"workspaceId"`
+	SourceID    string `json:"sourceId,omitempty"`
+	Events      int64  `json:"events"`
+	// MTU is the estimated number of distinct users, identified by their userId or else their anonymousId
+	MTU uint64 `json:"mtu"`
+}
+
+// Filter narrows down the usage returned by [Meter.Usage]
+type Filter struct {
+	WorkspaceID string
+	SourceID    string
+	From        time.Time // first month, defaults to the current month
+	To          time.Time // last month
+	// ByWorkspace returns the usage of the workspaces, counting the users across their sources
+	ByWorkspace bool
+}
+
+// Meter meters the usage of the sources
+type Meter struct {
+	log         logger.Logger
+	conf        *config.Config
+	db          *sql.DB
+	hllSettings hll.Settings
+
+	done *atomic.Bool
+	wg   sync.WaitGroup
+}
+
+// New returns a meter, after validating its settings and migrating its database tables. Its database needs to be the
+// one of the gateway jobsdb, since the usage is recorded in the transactions marking the gateway jobs as processed.
+func New(conf *config.Config, log logger.Logger) (*Meter, error) {
+	hllSettings := hll.Settings{
+		Log2m:             conf.GetInt("Metering.precision", 14),
+		Regwidth:          conf.GetInt("Metering.registerWidth", 5),
+		ExplicitThreshold: hll.AutoExplicitThreshold,
+		SparseEnabled:     true,
+	}
+	if _, err := hll.NewHll(hllSettings); err != nil {
+		return nil, fmt.Errorf("invalid hll settings: %w", err)
+	}
+	db, err := setupDBConn(conf)
+	if err != nil {
+		return nil, fmt.Errorf("db setup: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("db migrations: %w", err)
+	}
+	return &Meter{
+		log:         log,
+		conf:        conf,
+		db:          db,
+		hllSettings: hllSettings,
+
+		done: &atomic.Bool{},
+	}, nil
+}
+
+type usageKey struct {
+	month       time.Time
+	workspaceID string
+	sourceID    string
+}
+
+// GenerateReports returns the usage of the sources within the gateway jobs, in the month the jobs were received
+func (m *Meter) GenerateReports(jobs []*jobsdb.JobT) []*Report {
+	reports := make(map[usageKey]*Report)
+	for _, job := range jobs {
+		sourceID := gjson.GetBytes(job.Parameters, "source_id").String()
+		if job.WorkspaceId == "" || sourceID == "" {
+			continue
+		}
+		key := usageKey{
+			month:       monthOf(job.CreatedAt),
+			workspaceID: job.WorkspaceId,
+			sourceID:    sourceID,
+		}
+		r, ok := reports[key]
+		if !ok {
+			users, _ := hll.NewHll(m.hllSettings) // the settings are validated by New
+			r = &Report{Month: key.month, WorkspaceID: key.workspaceID, SourceID: key.sourceID, Users: &users}
+			reports[key] = r
+		}
+		gjson.GetBytes(job.EventPayload, "batch").ForEach(func(_, event gjson.Result) bool {
+			r.Events++
+			user := event.Get("userId").String()
+			if user == "" {
+				user = event.Get("anonymousId").String()
+			}
+			if user != "" {
+				r.Users.AddRaw(murmur3.Sum64WithSeed([]byte(user), murmurSeed))
+			}
+			return true
+		})
+	}
+	result := make([]*Report, 0, len(reports))
+	for _, r := range reports {
+		result = append(result, r)
+	}
+	return result
+}
+
+// monthOf returns the first day of the month of t, in UTC
+func monthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Record records the reports within the transaction marking their gateway jobs as processed
+func (m *Meter) Record(ctx context.Context, reports []*Report, txn *tx.Tx) error {
+	if len(reports) == 0 {
+		return nil
+	}
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn("metering_reports", "month", "workspace_id", "source_id", "events", "users_hll"))
+	if err != nil {
+		return fmt.Errorf("preparing statement: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, r := range reports {
+		if _, err := stmt.ExecContext(ctx, r.Month, r.WorkspaceID, r.SourceID, r.Events, r.Users.ToBytes()); err != nil {
+			return fmt.Errorf("executing statement: %w", err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("executing final statement: %w", err)
+	}
+	return nil
+}
+
+// usage is the usage of a key, while being aggregated
+type usage struct {
+	events int64
+	users  *hll.Hll
+}
+
+func (u *usage) add(events int64, usersHll []byte) error {
+	u.events += events
+	if len(usersHll) == 0 {
+		return nil
+	}
+	users, err := hll.FromBytes(usersHll)
+	if err != nil {
+		return fmt.Errorf("decoding users hll: %w", err)
+	}
+	if u.users == nil {
+		u.users = &users
+		return nil
+	}
+	u.users.Union(users)
+	return nil
+}
+
+func (u *usage) usersHll() []byte {
+	if u.users == nil {
+		return []byte{}
+	}
+	return u.users.ToBytes()
+}
+
+// CompactionRoutine periodically merges the recorded reports into the monthly usage
+func (m *Meter) CompactionRoutine(ctx context.Context) error {
+	m.wg.Add(1)
+	defer m.wg.Done()
+	for {
+		if m.done.Load() {
+			return nil
+		}
+		for {
+			compacted, err := m.compact(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					m.log.Errorn("metering compaction", logger.NewErrorField(err))
+				}
+				break
+			}
+			if compacted < m.conf.GetInt("Metering.compactionBatchSize", 1000) {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(m.conf.GetDuration("Metering.compactionFrequency", defaultCompactionFrequency, defaultCompactionFrequencyUnits)):
+		}
+	}
+}
+
+// compact merges a batch of reports into the monthly usage and returns the number of reports merged. Reports are
+// locked while being merged, so that servers sharing the database can compact concurrently.
+func (m *Meter) compact(ctx context.Context) (int, error) {
+	txn, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = txn.Rollback() }()
+
+	rows, err := txn.QueryContext(ctx, `DELETE FROM metering_reports WHERE id IN (
+			SELECT id FROM metering_reports ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED
+		) RETURNING month, workspace_id, source_id, events, users_hll`,
+		m.conf.GetInt("Metering.compactionBatchSize", 1000),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("deleting reports: %w", err)
+	}
+	compacted := 0
+	usages := make(map[usageKey]*usage)
+	for rows.Next() {
+		var (
+			key      usageKey
+			events   int64
+			usersHll []byte
+		)
+		if err := rows.Scan(&key.month, &key.workspaceID, &key.sourceID, &events, &usersHll); err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("scan report: %w", err)
+		}
+		key.month = key.month.UTC()
+		if usages[key] == nil {
+			usages[key] = &usage{}
+		}
+		if err := usages[key].add(events, usersHll); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		compacted++
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate reports: %w", err)
+	}
+
+	// merging in the same order in every transaction, so that concurrent compactions don't deadlock
+	keys := make([]usageKey, 0, len(usages))
+	for key := range usages {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b usageKey) int {
+		if n := a.month.Compare(b.month); n != 0 {
+			return n
+		}
+		if n := strings.Compare(a.workspaceID, b.workspaceID); n != 0 {
+			return n
+		}
+		return strings.Compare(a.sourceID, b.sourceID)
+	})
+	for _, key := range keys {
+		// inserting the usage first, so that it exists to be locked
+		if _, err := txn.ExecContext(ctx,
+			`INSERT INTO metering_usage (month, workspace_id, source_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+			key.month, key.workspaceID, key.sourceID,
+		); err != nil {
+			return 0, fmt.Errorf("inserting usage: %w", err)
+		}
+		var (
+			events   int64
+			usersHll []byte
+		)
+		if err := txn.QueryRowContext(ctx,
+			`SELECT events, users_hll FROM metering_usage WHERE month = $1 AND workspace_id = $2 AND source_id = $3 FOR UPDATE`,
+			key.month, key.workspaceID, key.sourceID,
+		).Scan(&events, &usersHll); err != nil {
+			return 0, fmt.Errorf("locking usage: %w", err)
+		}
+		u := usages[key]
+		if err := u.add(events, usersHll); err != nil {
+			return 0, err
+		}
+		if _, err := txn.ExecContext(ctx,
+			`UPDATE metering_usage SET events = $4, users_hll = $5, updated_at = NOW() WHERE month = $1 AND workspace_id = $2 AND source_id = $3`,
+			key.month, key.workspaceID, key.sourceID, u.events, u.usersHll(),
+		); err != nil {
+			return 0, fmt.Errorf("updating usage: %w", err)
+		}
+	}
+	if err := txn.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	return compacted, nil
+}
+
+// Usage returns the usage matching the filter, ordered by month, including the reports which are not compacted yet
+func (m *Meter) Usage(ctx context.Context, f Filter) ([]Usage, error) {
+	from := f.From
+	if from.IsZero() {
+		from = time.Now()
+	}
+	conditions := []string{"month >= $1"}
+	args := []interface{}{monthOf(from)}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if !f.To.IsZero() {
+		addCondition("month <= $%d", monthOf(f.To))
+	}
+	if f.WorkspaceID != "" {
+		addCondition("workspace_id = $%d", f.WorkspaceID)
+	}
+	if f.SourceID != "" {
+		addCondition("source_id = $%d", f.SourceID)
+	}
+	where := strings.Join(conditions, " AND ")
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf(`SELECT month, workspace_id, source_id, events, users_hll FROM metering_usage WHERE %[1]s
+		UNION ALL SELECT month, workspace_id, source_id, events, users_hll FROM metering_reports WHERE %[1]s`, where), args...)
+	if err != nil {
+		return nil, fmt.Errorf("query usage: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	usages := make(map[usageKey]*usage)
+	for rows.Next() {
+		var (
+			key      usageKey
+			events   int64
+			usersHll []byte
+		)
+		if err := rows.Scan(&key.month, &key.workspaceID, &key.sourceID, &events, &usersHll); err != nil {
+			return nil, fmt.Errorf("scan usage: %w", err)
+		}
+		key.month = key.month.UTC()
+		if f.ByWorkspace {
+			key.sourceID = ""
+		}
+		if usages[key] == nil {
+			usages[key] = &usage{}
+		}
+		if err := usages[key].add(events, usersHll); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate usage: %w", err)
+	}
+
+	result := make([]Usage, 0, len(usages))
+	for key, u := range usages {
+		var mtu uint64
+		if u.users != nil {
+			mtu = u.users.Cardinality()
+		}
+		result = append(result, Usage{
+			Month:       key.month.Format("2006-01"),
+			WorkspaceID: key.workspaceID,
+			SourceID:    key.sourceID,
+			Events:      u.events,
+			MTU:         mtu,
+		})
+	}
+	slices.SortFunc(result, func(a, b Usage) int {
+		if n := strings.Compare(a.Month, b.Month); n != 0 {
+			return n
+		}
+		if n := strings.Compare(a.WorkspaceID, b.WorkspaceID); n != 0 {
+			return n
+		}
+		return strings.Compare(a.SourceID, b.SourceID)
+	})
+	return result, nil
+}
+
+// CleanupRoutine periodically deletes the usage of the months older than the configured retention
+func (m *Meter) CleanupRoutine(ctx context.Context) error {
+	m.wg.Add(1)
+	defer m.wg.Done()
+	for {
+		if m.done.Load() {
+			return nil
+		}
+		if _, err := m.db.ExecContext(
+			ctx,
+			"DELETE FROM metering_usage WHERE month < $1",
+			time.Now().Add(-m.conf.GetDuration("Metering.retention", defaultRetention, defaultRetentionUnits)),
+		); err != nil && ctx.Err() == nil {
+			m.log.Errorn("metering cleanup", logger.NewErrorField(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(m.conf.GetDuration("Metering.cleanupFrequency", defaultCleanupFrequency, defaultCleanupFrequencyUnits)):
+		}
+	}
+}
+
+func (m *Meter) Stop() {
+	m.done.Store(true)
+	m.wg.Wait()
+	_ = m.db.Close()
+}
+
+func migrate(db *sql.DB) error {
+	m := &migrator.Migrator{
+		Handle:                     db,
+		MigrationsTable:            "metering_migrations",
+		ShouldForceSetLowerVersion: config.GetBool("SQLMigrator.forceSetLowerVersion", true),
+	}
+
+	return m.Migrate("metering")
+}
+
+// setupDBConn sets up the connection to the database of the gateway jobsdb
+func setupDBConn(conf *config.Config) (*sql.DB, error) {
+	db, err := sql.Open("postgres", misc.GetConnectionString(conf, "metering"))
+	if err != nil {
+		return nil, fmt.Errorf("db open: %w", err)
+	}
+	db.SetMaxIdleConns(conf.GetInt("Metering.maxIdleConns", 1))
+	db.SetMaxOpenConns(conf.GetInt("Metering.maxOpenConns", 2))
+	return db, nil
+}
@@ -0,0 +1,137 @@
+package metering_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/bytesize"
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
+	"github.com/rudderlabs/rudder-server/internal/metering"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/utils/tx"
+)
+
+func TestMetering(t *testing.T) {
+	ctx := context.Background()
+	conf, db := testSetup(t)
+	conf.Set("Metering.compactionFrequency", "100ms")
+	meter, err := metering.New(conf, logger.NOP)
+	require.NoError(t, err, "should create the meter")
+	t.Cleanup(meter.Stop)
+
+	now := time.Now()
+	lastMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	job := func(workspaceID, sourceID string, createdAt time.Time, users ...string) *jobsdb.JobT {
+		var batch []map[string]string
+		for _, user := range users {
+			batch = append(batch, map[string]string{"anonymousId": user})
+		}
+		payload, err := json.Marshal(map[string]interface{}{"batch": batch})
+		require.NoError(t, err)
+		return &jobsdb.JobT{
+			WorkspaceId:  workspaceID,
+			CreatedAt:    createdAt,
+			Parameters:   []byte(fmt.Sprintf(`{"source_id":%q}`, sourceID)),
+			EventPayload: payload,
+		}
+	}
+	record := func(jobs ...*jobsdb.JobT) {
+		sqlTx, err := db.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		txn := &tx.Tx{Tx: sqlTx}
+		require.NoError(t, meter.Record(ctx, meter.GenerateReports(jobs), txn))
+		require.NoError(t, txn.Commit())
+	}
+
+	record(
+		job("workspace-1", "source-1", now, "user-1", "user-2", "user-1"),
+		job("workspace-1", "source-2", now, "user-2", "user-3"),
+		job("workspace-1", "source-1", lastMonth, "user-1"),
+		job("workspace-2", "source-3", now, "user-4"),
+	)
+	record(
+		job("workspace-1", "source-1", now, "user-3"),
+	)
+
+	verify := func(t *testing.T) {
+		usage, err := meter.Usage(ctx, metering.Filter{WorkspaceID: "workspace-1"})
+		require.NoError(t, err)
+		require.Equal(t, []metering.Usage{
+			{Month: now.UTC().Format("2006-01"), WorkspaceID: "workspace-1", SourceID: "source-1", Events: 4, MTU: 3},
+			{Month: now.UTC().Format("2006-01"), WorkspaceID: "workspace-1", SourceID: "source-2", Events: 2, MTU: 2},
+		}, usage)
+
+		usage, err = meter.Usage(ctx, metering.Filter{From: lastMonth, ByWorkspace: true})
+		require.NoError(t, err)
+		require.Equal(t, []metering.Usage{
+			{Month: lastMonth.Format("2006-01"), WorkspaceID: "workspace-1", Events: 1, MTU: 1},
+			{Month: now.UTC().Format("2006-01"), WorkspaceID: "workspace-1", Events: 6, MTU: 3},
+			{Month: now.UTC().Format("2006-01"), WorkspaceID: "workspace-2", Events: 1, MTU: 1},
+		}, usage, "users should be counted once across the sources of a workspace")
+	}
+	t.Run("before compaction", verify)
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = meter.CompactionRoutine(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		var pending int
+		require.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM metering_reports`).Scan(&pending))
+		return pending == 0
+	}, 10*time.Second, 100*time.Millisecond, "reports should be compacted")
+	cancel()
+	<-done
+	ctx = context.Background()
+	t.Run("after compaction", verify)
+
+	t.Run("http", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		meter.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?workspaceId=workspace-1&groupBy=workspace&from="+lastMonth.Format("2006-01")+"&to="+lastMonth.Format("2006-01"), http.NoBody))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.EqualValues(t, 1, gjson.Get(resp.Body.String(), "#").Int())
+		require.EqualValues(t, 1, gjson.Get(resp.Body.String(), "0.events").Int())
+		require.EqualValues(t, 1, gjson.Get(resp.Body.String(), "0.mtu").Int())
+
+		resp = httptest.NewRecorder()
+		meter.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?groupBy=destination", http.NoBody))
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestInvalidSettings(t *testing.T) {
+	conf := config.New()
+	conf.Set("Metering.precision", 40)
+	_, err := metering.New(conf, logger.NOP)
+	require.ErrorContains(t, err, "invalid hll settings")
+}
+
+func testSetup(t *testing.T) (*config.Config, *sql.DB) {
+	conf := config.New()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err, "Failed to create docker pool")
+	postgresResource, err := postgres.Setup(pool, t, postgres.WithShmSize(256*bytesize.MB))
+	require.NoError(t, err, "failed to setup postgres resource")
+	conf.Set("DB.name", postgresResource.Database)
+	conf.Set("DB.host", postgresResource.Host)
+	conf.Set("DB.port", postgresResource.Port)
+	conf.Set("DB.user", postgresResource.User)
+	conf.Set("DB.password", postgresResource.Password)
+
+	return conf, postgresResource.DB
+}
@@ -0,0 +1,218 @@
+package preflight
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/utils/filemanagerutil"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/utils/types/deployment"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+// objectStorageDestinations are the destinations which are batch uploaded to a bucket of the customer
+var objectStorageDestinations = []string{"S3", "GCS", "AZURE_BLOB", "MINIO", "DIGITAL_OCEAN_SPACES"}
+
+// Environment checks the environment variables and the files the server needs to start
+func Environment(conf *config.Config) Check {
+	return Check{
+		Name: "environment",
+		Run: func(context.Context) error {
+			var errs []error
+			deploymentType := deployment.Type(conf.GetString("DEPLOYMENT_TYPE", string(deployment.DedicatedType)))
+			configFromFile := conf.GetBool("BackendConfig.configFromFile", false)
+			switch {
+			case !deploymentType.Valid():
+				errs = append(errs, fmt.Errorf("DEPLOYMENT_TYPE %q is invalid, use either %s or %s", deploymentType, deployment.DedicatedType, deployment.MultiTenantType))
+			case deploymentType == deployment.MultiTenantType:
+				if !conf.IsSet("WORKSPACE_NAMESPACE") && !conf.IsSet("HOSTED_SERVICE_SECRET") {
+					errs = append(errs, errors.New("either WORKSPACE_NAMESPACE or HOSTED_SERVICE_SECRET needs to be set in a multitenant deployment"))
+				}
+			case !configFromFile && workspaceToken(conf) == "":
+				errs = append(errs, errors.New("WORKSPACE_TOKEN needs to be set, unless the workspace config is read from a file with BackendConfig.configFromFile"))
+			}
+			if configFromFile {
+				path := conf.GetString("BackendConfig.configJSONPath", "/etc/rudderstack/workspaceConfig.json")
+				if _, err := os.Stat(path); err != nil {
+					errs = append(errs, fmt.Errorf("reading the workspace config file set with BackendConfig.configJSONPath: %w", err))
+				}
+			}
+
+			warehouseModes := []string{config.EmbeddedMode, config.MasterMode, config.MasterSlaveMode, config.SlaveMode, config.OffMode, config.EmbeddedMasterMode}
+			if mode := conf.GetString("Warehouse.mode", config.EmbeddedMode); !slices.Contains(warehouseModes, mode) {
+				errs = append(errs, fmt.Errorf("Warehouse.mode %q is invalid, use one of %s", mode, strings.Join(warehouseModes, ", ")))
+			}
+
+			if tmpDir := conf.GetString("RUDDER_TMPDIR", ""); tmpDir != "" {
+				f, err := os.CreateTemp(tmpDir, "preflight")
+				if err != nil {
+					errs = append(errs, fmt.Errorf("RUDDER_TMPDIR %q needs to be writable: %w", tmpDir, err))
+				} else {
+					_ = f.Close()
+					_ = os.Remove(f.Name())
+				}
+			}
+			return errors.Join(errs...)
+		},
+	}
+}
+
+// workspaceToken returns the workspace token the same way as config.GetWorkspaceToken, but from conf
+func workspaceToken(conf *config.Config) string {
+	if token := conf.GetString("WORKSPACE_TOKEN", ""); token != "" && token != "<your_token_here>" {
+		return token
+	}
+	return conf.GetString("CONFIG_BACKEND_TOKEN", "")
+}
+
+// Database checks that the postgres database of the connection string is reachable with its credentials
+func Database(name, connectionString string) Check {
+	return Check{
+		Name: name + " database",
+		Run: func(ctx context.Context) error {
+			db, err := sql.Open("postgres", connectionString)
+			if err != nil {
+				return fmt.Errorf("opening connection: %w", err)
+			}
+			defer func() { _ = db.Close() }()
+			var version string
+			if err := db.QueryRowContext(ctx, "SHOW server_version").Scan(&version); err != nil {
+				return fmt.Errorf("connecting to database, check the host, port, credentials and database name: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// ObjectStorage checks that the object storage where jobs are backed up, set with JOBS_BACKUP_STORAGE_PROVIDER and
+// JOBS_BACKUP_BUCKET, is writable, by uploading a small file and deleting it
+func ObjectStorage(conf *config.Config, log logger.Logger, fileManagerFactory filemanager.Factory) Check {
+	return Check{
+		Name: "object storage",
+		Run: func(ctx context.Context) error {
+			provider := conf.GetString("JOBS_BACKUP_STORAGE_PROVIDER", "S3")
+			fm, err := fileManagerFactory(&filemanager.Settings{
+				Provider: provider,
+				Config:   filemanagerutil.GetProviderConfigForBackupsFromEnv(ctx, conf),
+				Logger:   log,
+				Conf:     conf,
+			})
+			if err != nil {
+				return fmt.Errorf("creating %s file manager, check JOBS_BACKUP_STORAGE_PROVIDER: %w", provider, err)
+			}
+
+			f, err := os.CreateTemp("", "preflight")
+			if err != nil {
+				return fmt.Errorf("creating file to upload: %w", err)
+			}
+			defer func() { _ = os.Remove(f.Name()) }()
+			defer func() { _ = f.Close() }()
+			if _, err := f.WriteString("preflight"); err != nil {
+				return fmt.Errorf("writing file to upload: %w", err)
+			}
+			if _, err := f.Seek(0, 0); err != nil {
+				return fmt.Errorf("rewinding file to upload: %w", err)
+			}
+
+			uploaded, err := fm.Upload(ctx, f, "rudder-preflight", misc.DefaultString("rudder-server").OnError(os.Hostname()))
+			if err != nil {
+				return fmt.Errorf("uploading to %s bucket %q, check JOBS_BACKUP_BUCKET and the credentials: %w",
+					provider, conf.GetString("JOBS_BACKUP_BUCKET", "rudder-saas"), err)
+			}
+			if err := fm.Delete(ctx, []string{uploaded.ObjectName}); err != nil {
+				return fmt.Errorf("deleting %s from %s bucket %q, check the permissions: %w",
+					uploaded.ObjectName, provider, conf.GetString("JOBS_BACKUP_BUCKET", "rudder-saas"), err)
+			}
+			return nil
+		},
+	}
+}
+
+// Destinations checks the shape of the config of all the destinations of the workspaces served by the server
+func Destinations(bc backendconfig.BackendConfig) Check {
+	return Check{
+		Name: "destinations",
+		Run: func(ctx context.Context) error {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			var workspaces map[string]backendconfig.ConfigT
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("waiting for the workspace config: %w", ctx.Err())
+			case data, ok := <-bc.Subscribe(ctx, backendconfig.TopicBackendConfig):
+				if !ok {
+					return errors.New("workspace config subscription closed before receiving the config")
+				}
+				workspaces = data.Data.(map[string]backendconfig.ConfigT)
+			}
+
+			var errs []error
+			validated := make(map[string]struct{})
+			for workspaceID, wConfig := range workspaces {
+				for _, source := range wConfig.Sources {
+					for _, destination := range source.Destinations {
+						if _, ok := validated[destination.ID]; ok {
+							continue
+						}
+						validated[destination.ID] = struct{}{}
+						if err := validateDestination(destination); err != nil {
+							errs = append(errs, fmt.Errorf("destination %q (%s) of workspace %q: %w", destination.ID, destination.Name, workspaceID, err))
+						}
+					}
+				}
+			}
+			return errors.Join(errs...)
+		},
+	}
+}
+
+// validateDestination checks the config of an enabled destination has what the server needs to deliver events to it
+func validateDestination(destination backendconfig.DestinationT) error {
+	if !destination.Enabled {
+		return nil
+	}
+	destType := destination.DestinationDefinition.Name
+	if destination.ID == "" {
+		return errors.New("missing id")
+	}
+	if destType == "" {
+		return errors.New("missing destination definition")
+	}
+	if destination.Config == nil {
+		return errors.New("missing config")
+	}
+
+	var storageType string
+	switch {
+	case slices.Contains(objectStorageDestinations, destType):
+		storageType = destType
+	case slices.Contains(warehouseutils.WarehouseDestinations, destType):
+		if misc.IsConfiguredToUseRudderObjectStorage(destination.Config) {
+			return nil
+		}
+		storageType = warehouseutils.ObjectStorageType(destType, destination.Config, false)
+		if storageType == "" {
+			return errors.New("missing bucketProvider, the object storage for staging the load files")
+		}
+	default:
+		return nil
+	}
+	bucketKey := "bucketName"
+	if storageType == warehouseutils.AzureBlob {
+		bucketKey = "containerName"
+	}
+	if bucket, _ := destination.Config[bucketKey].(string); bucket == "" {
+		return fmt.Errorf("missing %s of the %s object storage", bucketKey, storageType)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Package preflight checks that the server can run with its configuration, so that misconfigurations are reported
+// with actionable errors before the server enters its normal run loop, instead of surfacing later as runtime failures.
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+)
+
+// Check is a named check of the configuration
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of a check, Err is nil if the check passed
+type Result struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// Run runs the checks one after the other, each one within the timeout, and returns their results.
+// All the checks are run even if some of them fail, so that all the problems are reported at once.
+func Run(ctx context.Context, timeout time.Duration, checks ...Check) []Result {
+	results := make([]Result, 0, len(checks))
+	for _, check := range checks {
+		start := time.Now()
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := check.Run(checkCtx)
+		if err != nil && errors.Is(checkCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s: %w", timeout, err)
+		}
+		cancel()
+		results = append(results, Result{Name: check.Name, Err: err, Duration: time.Since(start)})
+	}
+	return results
+}
+
+// Report logs the results and returns an error joining the errors of the failed checks, if any
+func Report(log logger.Logger, results []Result) error {
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			log.Errorn("Preflight check failed",
+				logger.NewStringField("check", result.Name),
+				logger.NewDurationField("duration", result.Duration),
+				obskit.Error(result.Err),
+			)
+			errs = append(errs, fmt.Errorf("%s: %w", result.Name, result.Err))
+			continue
+		}
+		log.Infon("Preflight check passed",
+			logger.NewStringField("check", result.Name),
+			logger.NewDurationField("duration", result.Duration),
+		)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,157 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/minio"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	mocksBackendConfig "github.com/rudderlabs/rudder-server/mocks/backend-config"
+	"github.com/rudderlabs/rudder-server/utils/pubsub"
+)
+
+func TestRun(t *testing.T) {
+	results := Run(context.Background(), 10*time.Millisecond,
+		Check{Name: "failing", Run: func(context.Context) error { return errors.New("failed") }},
+		Check{Name: "slow", Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+		Check{Name: "passing", Run: func(context.Context) error { return nil }},
+	)
+	require.Len(t, results, 3, "all checks should run even if some fail")
+	require.EqualError(t, results[0].Err, "failed")
+	require.ErrorIs(t, results[1].Err, context.DeadlineExceeded)
+	require.ErrorContains(t, results[1].Err, "timed out after 10ms")
+	require.NoError(t, results[2].Err)
+
+	err := Report(logger.NOP, results)
+	require.ErrorContains(t, err, "failing: failed")
+	require.ErrorContains(t, err, "slow: timed out")
+	require.NotContains(t, err.Error(), "passing")
+	require.NoError(t, Report(logger.NOP, results[2:]))
+}
+
+func TestEnvironment(t *testing.T) {
+	c := config.New()
+	c.Set("WORKSPACE_TOKEN", "token")
+	require.NoError(t, Environment(c).Run(context.Background()))
+
+	c = config.New()
+	c.Set("DEPLOYMENT_TYPE", "SHARED")
+	c.Set("Warehouse.mode", "standalone")
+	c.Set("RUDDER_TMPDIR", filepath.Join(t.TempDir(), "missing"))
+	err := Environment(c).Run(context.Background())
+	require.ErrorContains(t, err, `DEPLOYMENT_TYPE "SHARED" is invalid`)
+	require.ErrorContains(t, err, `Warehouse.mode "standalone" is invalid`)
+	require.ErrorContains(t, err, "RUDDER_TMPDIR")
+
+	c = config.New()
+	require.ErrorContains(t, Environment(c).Run(context.Background()), "WORKSPACE_TOKEN needs to be set")
+	c.Set("BackendConfig.configFromFile", true)
+	c.Set("BackendConfig.configJSONPath", filepath.Join(t.TempDir(), "workspaceConfig.json"))
+	require.ErrorContains(t, Environment(c).Run(context.Background()), "BackendConfig.configJSONPath")
+
+	c = config.New()
+	c.Set("DEPLOYMENT_TYPE", "MULTITENANT")
+	require.ErrorContains(t, Environment(c).Run(context.Background()), "WORKSPACE_NAMESPACE or HOSTED_SERVICE_SECRET")
+	c.Set("WORKSPACE_NAMESPACE", "namespace")
+	require.NoError(t, Environment(c).Run(context.Background()))
+}
+
+func TestDatabaseAndObjectStorage(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	pgResource, err := postgres.Setup(pool, t)
+	require.NoError(t, err)
+	minioResource, err := minio.Setup(pool, t)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("database", func(t *testing.T) {
+		require.NoError(t, Database("jobs", pgResource.DBDsn).Run(ctx))
+		err := Database("jobs", "postgres://rudder:wrong@"+pgResource.Host+":"+pgResource.Port+"/"+pgResource.Database+"?sslmode=disable").Run(ctx)
+		require.ErrorContains(t, err, "check the host, port, credentials and database name")
+	})
+
+	t.Run("object storage", func(t *testing.T) {
+		c := config.New()
+		c.Set("JOBS_BACKUP_STORAGE_PROVIDER", "MINIO")
+		c.Set("JOBS_BACKUP_BUCKET", minioResource.BucketName)
+		c.Set("MINIO_ENDPOINT", minioResource.Endpoint)
+		c.Set("MINIO_ACCESS_KEY_ID", minioResource.AccessKeyID)
+		c.Set("MINIO_SECRET_ACCESS_KEY", minioResource.AccessKeySecret)
+		require.NoError(t, ObjectStorage(c, logger.NOP, filemanager.New).Run(ctx))
+
+		files, err := minioResource.Contents(ctx, "")
+		require.NoError(t, err)
+		require.Empty(t, files, "uploaded file should be deleted")
+
+		c.Set("JOBS_BACKUP_BUCKET", "missing-bucket")
+		require.ErrorContains(t, ObjectStorage(c, logger.NOP, filemanager.New).Run(ctx), `bucket "missing-bucket"`)
+	})
+}
+
+func TestDestinations(t *testing.T) {
+	destination := func(id, destType string, enabled bool, config map[string]interface{}) backendconfig.DestinationT {
+		return backendconfig.DestinationT{
+			ID:                    id,
+			Name:                  id,
+			Enabled:               enabled,
+			Config:                config,
+			DestinationDefinition: backendconfig.DestinationDefinitionT{Name: destType},
+		}
+	}
+	workspaces := map[string]backendconfig.ConfigT{
+		"workspace": {Sources: []backendconfig.SourceT{{
+			Destinations: []backendconfig.DestinationT{
+				destination("webhook", "WEBHOOK", true, map[string]interface{}{}),
+				destination("s3", "S3", true, map[string]interface{}{"bucketName": "bucket"}),
+				destination("s3-without-bucket", "S3", true, map[string]interface{}{}),
+				destination("disabled", "S3", false, map[string]interface{}{}),
+				destination("azure-without-container", "AZURE_BLOB", true, map[string]interface{}{"bucketName": "bucket"}),
+				destination("postgres", "POSTGRES", true, map[string]interface{}{"bucketProvider": "MINIO", "bucketName": "bucket"}),
+				destination("postgres-without-provider", "POSTGRES", true, map[string]interface{}{}),
+				destination("postgres-rudder-storage", "POSTGRES", true, map[string]interface{}{"useRudderStorage": true}),
+				destination("without-definition", "", true, map[string]interface{}{}),
+			},
+		}}},
+	}
+
+	ctrl := gomock.NewController(t)
+	bc := mocksBackendConfig.NewMockBackendConfig(ctrl)
+	bc.EXPECT().Subscribe(gomock.Any(), backendconfig.TopicBackendConfig).DoAndReturn(func(ctx context.Context, topic backendconfig.Topic) pubsub.DataChannel {
+		ch := make(chan pubsub.DataEvent, 1)
+		ch <- pubsub.DataEvent{Data: workspaces, Topic: string(topic)}
+		close(ch)
+		return ch
+	})
+
+	err := Destinations(bc).Run(context.Background())
+	require.Error(t, err)
+	require.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 4)
+	require.ErrorContains(t, err, `destination "s3-without-bucket" (s3-without-bucket) of workspace "workspace": missing bucketName of the S3 object storage`)
+	require.ErrorContains(t, err, `destination "azure-without-container" (azure-without-container) of workspace "workspace": missing containerName of the AZURE_BLOB object storage`)
+	require.ErrorContains(t, err, `destination "postgres-without-provider" (postgres-without-provider) of workspace "workspace": missing bucketProvider`)
+	require.ErrorContains(t, err, `destination "without-definition" (without-definition) of workspace "workspace": missing destination definition`)
+
+	t.Run("no workspace config", func(t *testing.T) {
+		bc := mocksBackendConfig.NewMockBackendConfig(ctrl)
+		bc.EXPECT().Subscribe(gomock.Any(), backendconfig.TopicBackendConfig).Return(make(pubsub.DataChannel))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.ErrorContains(t, Destinations(bc).Run(ctx), "waiting for the workspace config")
+	})
+}
@@ -0,0 +1,349 @@
+// Package sharding spreads the destinations of a component, e.g. the warehouse master or the router, across the
+// replicas sharing its database, so that each destination is served by a single replica at a time and large
+// installations can spread the destinations across nodes.
+//
+// Destinations are hashed into a fixed number of shards, and every replica owns a fair share of the shards by holding
+// a postgres advisory lock for each one of them. The locks are held by a dedicated database session, so the shards of
+// a replica which goes away are released together with its session and taken over by the other replicas. Replicas
+// register themselves by holding a shared advisory lock, which lets each one of them compute its fair share.
+//
+// Shards are released gracefully when replicas are added: a shard stops being owned right away, but its lock is only
+// released once the work in progress for its destinations is done.
+//
+// The owner of the first shard is the leader, which runs the tasks needing a single instance across the replicas.
+package sharding
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+)
+
+// membershipLockID is the second key of the shared advisory lock held by every replica
+const membershipLockID = 2147483647
+
+// Manager manages the shards owned by this replica. A nil Manager owns everything, as if there was a single replica.
+type Manager struct {
+	db     *sql.DB
+	log    logger.Logger
+	shards int
+	// lockClassID is the first key of the advisory locks of the component, shared by all its locks
+	lockClassID int32
+
+	pollInterval time.Duration
+
+	conn *sql.Conn // session holding the advisory locks, nil until connected
+
+	mu        sync.RWMutex
+	owned     map[int]struct{}
+	releasing map[int]struct{} // owned shards to be released, once none of their uploads is in progress
+	inFlight  map[int]int      // work in progress by shard
+	onAcquire []func(ctx context.Context, shard int) error
+
+	stats struct {
+		ownedShards stats.Gauge
+		replicas    stats.Gauge
+	}
+}
+
+// New returns a new Manager for the shards of the component, configured with <component>.sharding.*, taking a
+// connection of db for holding the locks. Replicas of the same component need to share db.
+func New(component string, conf *config.Config, log logger.Logger, statsFactory stats.Stats, db *sql.DB) *Manager {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(component))
+	m := &Manager{
+		db:           db,
+		log:          log.Child("sharding"),
+		shards:       conf.GetInt(component+".sharding.shards", 16),
+		lockClassID:  int32(h.Sum32() & 0x7fffffff),
+		pollInterval: conf.GetDuration(component+".sharding.pollInterval", 10, time.Second),
+		owned:        make(map[int]struct{}),
+		releasing:    make(map[int]struct{}),
+		inFlight:     make(map[int]int),
+	}
+	if m.shards < 1 {
+		m.shards = 1
+	}
+	tags := stats.Tags{"component": strings.ToLower(component)}
+	m.stats.ownedShards = statsFactory.NewTaggedStat("sharding_owned_shards", stats.GaugeType, tags)
+	m.stats.replicas = statsFactory.NewTaggedStat("sharding_replicas", stats.GaugeType, tags)
+	return m
+}
+
+// OnAcquire registers f to be called every time a shard is acquired, before the shard is owned, e.g. for recovering
+// the work left in progress by its previous owner. The shard isn't acquired if f fails.
+// It needs to be called before Run.
+func (m *Manager) OnAcquire(f func(ctx context.Context, shard int) error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onAcquire = append(m.onAcquire, f)
+}
+
+// Shard returns the shard of the destination
+func (m *Manager) Shard(destinationID string) int {
+	if m == nil {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(destinationID))
+	return int(h.Sum32() % uint32(m.shards))
+}
+
+// Owns returns whether new work for the destination should be started by this replica
+func (m *Manager) Owns(destinationID string) bool {
+	if m == nil {
+		return true
+	}
+	shard := m.Shard(destinationID)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, owned := m.owned[shard]
+	_, releasing := m.releasing[shard]
+	return owned && !releasing
+}
+
+// OwnedShards returns the shards owned by this replica, including the ones being released
+func (m *Manager) OwnedShards() []int {
+	if m == nil {
+		return []int{0}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	shards := make([]int, 0, len(m.owned))
+	for shard := range m.owned {
+		shards = append(shards, shard)
+	}
+	sort.Ints(shards)
+	return shards
+}
+
+// Leader returns whether this replica is the leader
+func (m *Manager) Leader() bool {
+	if m == nil {
+		return true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, owned := m.owned[0]
+	return owned
+}
+
+// Begin marks work for the destination as in progress, so that its shard is not released until End is called
+func (m *Manager) Begin(destinationID string) {
+	if m == nil {
+		return
+	}
+	shard := m.Shard(destinationID)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight[shard]++
+}
+
+// End marks work for the destination marked with Begin as done
+func (m *Manager) End(destinationID string) {
+	if m == nil {
+		return
+	}
+	shard := m.Shard(destinationID)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight[shard]--
+}
+
+// Run keeps acquiring and releasing shards, so that this replica owns its fair share, until the context is cancelled
+func (m *Manager) Run(ctx context.Context) error {
+	defer m.disconnect()
+	for {
+		if err := m.rebalance(ctx); err != nil && ctx.Err() == nil {
+			m.log.Warnn("Rebalancing shards", logger.NewErrorField(err))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(m.pollInterval):
+		}
+	}
+}
+
+// RunAsLeader runs f once this replica is the leader, with a context which is cancelled once it is not anymore.
+// If f is stopped by losing the leadership, it is run again once this replica becomes the leader again,
+// otherwise its error is returned.
+func (m *Manager) RunAsLeader(ctx context.Context, f func(ctx context.Context) error) error {
+	if m == nil {
+		return f(ctx)
+	}
+	for {
+		for !m.Leader() {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(m.pollInterval):
+			}
+		}
+		leaderCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			for m.Leader() {
+				select {
+				case <-leaderCtx.Done():
+					return
+				case <-time.After(m.pollInterval):
+				}
+			}
+			m.log.Infon("Not the leader anymore")
+			cancel()
+		}()
+		err := f(leaderCtx)
+		lost := leaderCtx.Err() != nil
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !lost {
+			return err
+		}
+	}
+}
+
+func (m *Manager) rebalance(ctx context.Context) error {
+	if m.conn == nil {
+		if err := m.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if err := m.conn.PingContext(ctx); err != nil {
+		// the locks are gone together with the session
+		m.log.Warnn("Lost the connection holding the shards", logger.NewIntField("shards", int64(len(m.OwnedShards()))))
+		m.disconnect()
+		return fmt.Errorf("pinging: %w", err)
+	}
+
+	var replicas int
+	if err := m.conn.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM pg_locks
+		WHERE locktype = 'advisory'
+		AND database = (SELECT oid FROM pg_database WHERE datname = current_database())
+		AND classid = $1 AND objid = $2 AND objsubid = 2 AND granted`,
+		m.lockClassID, membershipLockID,
+	).Scan(&replicas); err != nil {
+		return fmt.Errorf("counting replicas: %w", err)
+	}
+	replicas = max(replicas, 1)
+	fairShare := (m.shards + replicas - 1) / replicas
+	m.stats.replicas.Gauge(replicas)
+
+	m.mu.Lock()
+	owned := make([]int, 0, len(m.owned))
+	for shard := range m.owned {
+		if _, releasing := m.releasing[shard]; !releasing {
+			owned = append(owned, shard)
+		}
+	}
+	// the shards with the highest numbers are released first, the first one makes the leader
+	sort.Sort(sort.Reverse(sort.IntSlice(owned)))
+	for i := 0; len(owned)-i > fairShare; i++ {
+		m.releasing[owned[i]] = struct{}{}
+	}
+	var release []int
+	for shard := range m.releasing {
+		if m.inFlight[shard] <= 0 {
+			release = append(release, shard)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, shard := range release {
+		if _, err := m.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1, $2)`, m.lockClassID, shard); err != nil {
+			return fmt.Errorf("releasing shard %d: %w", shard, err)
+		}
+		m.mu.Lock()
+		delete(m.owned, shard)
+		delete(m.releasing, shard)
+		m.mu.Unlock()
+		m.log.Infon("Released shard", logger.NewIntField("shard", int64(shard)))
+	}
+
+	for shard := 0; shard < m.shards && len(m.OwnedShards()) < fairShare; shard++ {
+		m.mu.RLock()
+		_, owned := m.owned[shard]
+		m.mu.RUnlock()
+		if owned {
+			continue
+		}
+		var acquired bool
+		if err := m.conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, $2)`, m.lockClassID, shard).Scan(&acquired); err != nil {
+			return fmt.Errorf("acquiring shard %d: %w", shard, err)
+		}
+		if !acquired {
+			continue
+		}
+		if err := m.acquired(ctx, shard); err != nil {
+			if _, unlockErr := m.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1, $2)`, m.lockClassID, shard); unlockErr != nil {
+				return fmt.Errorf("releasing shard %d: %w", shard, unlockErr)
+			}
+			return fmt.Errorf("acquiring shard %d: %w", shard, err)
+		}
+		m.mu.Lock()
+		m.owned[shard] = struct{}{}
+		m.mu.Unlock()
+		m.log.Infon("Acquired shard", logger.NewIntField("shard", int64(shard)))
+	}
+	m.stats.ownedShards.Gauge(len(m.OwnedShards()))
+	return nil
+}
+
+// acquired calls the functions registered with OnAcquire for the shard
+func (m *Manager) acquired(ctx context.Context, shard int) error {
+	m.mu.RLock()
+	onAcquire := m.onAcquire
+	m.mu.RUnlock()
+	for _, f := range onAcquire {
+		if err := f(ctx, shard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Manager) connect(ctx context.Context) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("connecting: %w", err)
+	}
+	var registered bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock_shared($1, $2)`, m.lockClassID, membershipLockID).Scan(&registered); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("registering replica: %w", err)
+	}
+	m.conn = conn
+	return nil
+}
+
+// disconnect closes the session, releasing all the locks it holds
+func (m *Manager) disconnect() {
+	if m.conn == nil {
+		return
+	}
+	// the session is not returned to the pool with the locks it holds
+	_ = m.conn.Raw(func(any) error { return driver.ErrBadConn })
+	_ = m.conn.Close()
+	m.conn = nil
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.owned = make(map[int]struct{})
+	m.releasing = make(map[int]struct{})
+	m.stats.ownedShards.Gauge(0)
+}
@@ -0,0 +1,114 @@
+package sharding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
+)
+
+func TestManager(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	pgResource, err := postgres.Setup(pool, t)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	c := config.New()
+	c.Set("Test.sharding.shards", 4)
+
+	newManager := func() *Manager {
+		m := New("Test", c, logger.NOP, stats.NOP, pgResource.DB)
+		t.Cleanup(m.disconnect)
+		return m
+	}
+	// destinationOf returns a destination belonging to the shard
+	destinationOf := func(m *Manager, shard int) string {
+		for i := 0; ; i++ {
+			if destinationID := fmt.Sprintf("destination-%d", i); m.Shard(destinationID) == shard {
+				return destinationID
+			}
+		}
+	}
+
+	m1, m2 := newManager(), newManager()
+
+	require.NoError(t, m1.rebalance(ctx))
+	require.Equal(t, []int{0, 1, 2, 3}, m1.OwnedShards(), "a single replica should own all the shards")
+	require.True(t, m1.Leader())
+
+	require.NoError(t, m2.rebalance(ctx))
+	require.Empty(t, m2.OwnedShards(), "shards should be owned by a single replica")
+	require.False(t, m2.Leader())
+
+	lastDestination := destinationOf(m1, 3)
+	m1.Begin(lastDestination)
+	require.NoError(t, m1.rebalance(ctx))
+	require.Equal(t, []int{0, 1, 3}, m1.OwnedShards(), "shards with uploads in progress should be released once they are done")
+	require.False(t, m1.Owns(lastDestination))
+	require.True(t, m1.Owns(destinationOf(m1, 0)))
+
+	require.NoError(t, m2.rebalance(ctx))
+	require.Equal(t, []int{2}, m2.OwnedShards())
+
+	m1.End(lastDestination)
+	require.NoError(t, m1.rebalance(ctx))
+	require.Equal(t, []int{0, 1}, m1.OwnedShards())
+	require.NoError(t, m2.rebalance(ctx))
+	require.Equal(t, []int{2, 3}, m2.OwnedShards())
+	require.True(t, m2.Owns(lastDestination))
+
+	t.Run("other component", func(t *testing.T) {
+		m := New("Other", c, logger.NOP, stats.NOP, pgResource.DB)
+		defer m.disconnect()
+		require.NoError(t, m.rebalance(ctx))
+		require.Len(t, m.OwnedShards(), 16, "components should be sharded independently")
+	})
+
+	t.Run("on acquire", func(t *testing.T) {
+		m := newManager()
+		var acquired []int
+		m.OnAcquire(func(_ context.Context, shard int) error {
+			if shard == 1 {
+				return errors.New("failed")
+			}
+			acquired = append(acquired, shard)
+			return nil
+		})
+		m1.disconnect()
+		require.Error(t, m.rebalance(ctx))
+		require.Equal(t, []int{0}, acquired)
+		require.Equal(t, []int{0}, m.OwnedShards(), "shards should not be acquired if recovering them fails")
+		m.disconnect()
+	})
+
+	t.Run("failover", func(t *testing.T) {
+		require.Empty(t, m1.OwnedShards())
+		require.False(t, m1.Leader())
+
+		require.NoError(t, m2.rebalance(ctx))
+		require.Equal(t, []int{0, 1, 2, 3}, m2.OwnedShards())
+		require.True(t, m2.Leader())
+	})
+}
+
+func TestManager_Nil(t *testing.T) {
+	var m *Manager
+	require.True(t, m.Owns("destination"))
+	require.True(t, m.Leader())
+	m.Begin("destination")
+	m.End("destination")
+
+	err := m.RunAsLeader(context.Background(), func(context.Context) error {
+		return errors.New("failed")
+	})
+	require.EqualError(t, err, "failed")
+}
@@ -0,0 +1,54 @@
+// Package shutdown lets components finish the work they have in flight when the server is asked to shut down,
+// within a drain deadline, instead of abandoning it halfway. Shutting down goes:
+//  1. the gateway stops accepting events, as soon as the server is asked to shut down
+//  2. the processor and routers keep delivering the events accepted so far, until the drain deadline
+//  3. warehouse uploads finish their current stage, which is checkpointed, until the drain deadline
+//
+// The drain timeout is carried by the context of the server, so that it reaches the components through their
+// existing context parameters:
+//
+//	ctx = shutdown.WithDrainTimeout(ctx, timeout)
+//	...
+//	drainCtx, cancel := shutdown.Drain(ctx) // keeps going for timeout after ctx is cancelled
+//	defer cancel()
+package shutdown
+
+import (
+	"context"
+	"time"
+)
+
+type drainTimeoutKey struct{}
+
+// WithDrainTimeout returns a copy of ctx carrying the drain timeout, which is how long the work in flight is given
+// to finish once ctx is cancelled
+func WithDrainTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, drainTimeoutKey{}, timeout)
+}
+
+// DrainTimeout returns the drain timeout carried by ctx, zero if there is none
+func DrainTimeout(ctx context.Context) time.Duration {
+	timeout, _ := ctx.Value(drainTimeoutKey{}).(time.Duration)
+	return timeout
+}
+
+// Drain returns a context for finishing the work in flight when ctx is cancelled, which is only cancelled once the
+// drain timeout has passed since ctx was cancelled, or when the returned cancel function is called.
+// Without a drain timeout it is cancelled together with ctx.
+//
+// Work should not be started with the drain context once ctx is cancelled.
+func Drain(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := DrainTimeout(ctx)
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	drainCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		timer := time.AfterFunc(timeout, cancel)
+		context.AfterFunc(drainCtx, func() { timer.Stop() })
+	})
+	return drainCtx, func() {
+		stop()
+		cancel()
+	}
+}
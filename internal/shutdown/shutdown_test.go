@@ -0,0 +1,44 @@
+package shutdown_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/internal/shutdown"
+)
+
+func TestDrain(t *testing.T) {
+	t.Run("without drain timeout", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		drainCtx, drainCancel := shutdown.Drain(ctx)
+		defer drainCancel()
+		require.Zero(t, shutdown.DrainTimeout(ctx))
+
+		cancel()
+		require.Eventually(t, func() bool { return drainCtx.Err() != nil }, time.Second, time.Millisecond)
+	})
+
+	t.Run("with drain timeout", func(t *testing.T) {
+		parent, cancel := context.WithCancel(context.Background())
+		ctx := shutdown.WithDrainTimeout(parent, 100*time.Millisecond)
+		require.Equal(t, 100*time.Millisecond, shutdown.DrainTimeout(ctx))
+		drainCtx, drainCancel := shutdown.Drain(ctx)
+		defer drainCancel()
+
+		cancel()
+		cancelled := time.Now()
+		require.NoError(t, drainCtx.Err(), "drain context should outlive the cancelled context")
+		<-drainCtx.Done()
+		require.GreaterOrEqual(t, time.Since(cancelled), 100*time.Millisecond)
+	})
+
+	t.Run("cancelled before the drain timeout", func(t *testing.T) {
+		ctx := shutdown.WithDrainTimeout(context.Background(), time.Hour)
+		drainCtx, drainCancel := shutdown.Drain(ctx)
+		drainCancel()
+		require.Error(t, drainCtx.Err())
+	})
+}
@@ -0,0 +1,140 @@
+// Package transformation_dlq keeps the events which failed in user transformations in a dead-letter queue, along with
+// the error and the transformation version which produced it, so that they can be inspected and re-run once the
+// transformation is fixed, instead of being dropped.
+package transformation_dlq
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/internal/dlq"
+)
+
+// Entry is a batch of events which failed in a user transformation
+type Entry struct {
+	ID                      int64           `json:"id"`
+	WorkspaceID             string          `json:"workspaceId"`
+	SourceID                string          `json:"sourceId"`
+	DestinationID           string          `json:"destinationId"`
+	TransformationID        string          `json:"transformationId"`
+	TransformationVersionID string          `json:"transformationVersionId"`
+	UserID                  string          `json:"userId"`
+	SourceJobRunID          string          `json:"sourceJobRunId,omitempty"`
+	SourceTaskRunID         string          `json:"sourceTaskRunId,omitempty"`
+	StatusCode              int             `json:"statusCode"`
+	Error                   string          `json:"error"`
+	Payload                 json.RawMessage `json:"payload"` // array of the failed events
+	CreatedAt               time.Time       `json:"createdAt"`
+	ReplayedAt              *time.Time      `json:"replayedAt,omitempty"`
+}
+
+// Filter narrows down the entries returned by [DLQ.List]
+type Filter struct {
+	WorkspaceID      string
+	SourceID         string
+	DestinationID    string
+	TransformationID string
+	// IncludeReplayed includes entries which have already been replayed
+	IncludeReplayed bool
+	// AfterID returns entries with an id greater than AfterID, for paginating through the entries
+	AfterID int64
+	Limit   int
+}
+
+// DLQ is the dead-letter queue of user transformation failures. Its http handler, i.e. [dlq.Store.HttpHandler], serves:
+//
+//	GET  /                 lists the entries matching the workspaceId, sourceId, destinationId and transformationId query parameters,
+//	                       paginated through the afterId and limit query parameters. Replayed entries are included if includeReplayed=true
+//	GET  /export           streams all the entries matching the same query parameters as GET / as newline delimited json
+//	GET  /{id}             returns a single entry
+//	POST /{id}/replay      re-runs the events of a single entry
+//	POST /replay           re-runs the events of the entries matching the same query parameters as GET /
+//
+// Re-running an entry stores its events in the gateway, to be processed again only for the destination they failed for,
+// i.e. using the latest version of the transformation connected to it.
+type DLQ struct {
+	*dlq.Store[Entry]
+}
+
+// New returns a dead-letter queue, after migrating its database table
+func New(conf *config.Config, log logger.Logger) (*DLQ, error) {
+	store, err := dlq.New(conf, log, kind)
+	if err != nil {
+		return nil, err
+	}
+	return &DLQ{Store: store}, nil
+}
+
+// List returns the entries matching the filter, ordered by id
+func (d *DLQ) List(ctx context.Context, f Filter) ([]Entry, error) {
+	return d.Store.List(ctx, dlq.Filter{
+		Equals: map[string]string{
+			"workspace_id":      f.WorkspaceID,
+			"source_id":         f.SourceID,
+			"destination_id":    f.DestinationID,
+			"transformation_id": f.TransformationID,
+		},
+		IncludeDone: f.IncludeReplayed,
+		AfterID:     f.AfterID,
+		Limit:       f.Limit,
+	})
+}
+
+// MarkReplayed marks the entries as replayed, excluding them from future listings by default
+func (d *DLQ) MarkReplayed(ctx context.Context, ids []int64) error {
+	return d.MarkDone(ctx, ids)
+}
+
+var kind = dlq.Kind[Entry]{
+	Name:         "transformation_dlq",
+	ConfigPrefix: "TransformationDLQ",
+
+	Columns: []string{
+		"workspace_id", "source_id", "destination_id", "transformation_id", "transformation_version_id",
+		"user_id", "source_job_run_id", "source_task_run_id", "status_code", "error", "payload",
+	},
+	Values: func(e Entry) []any {
+		return []any{
+			e.WorkspaceID, e.SourceID, e.DestinationID, e.TransformationID, e.TransformationVersionID,
+			e.UserID, e.SourceJobRunID, e.SourceTaskRunID, e.StatusCode, e.Error, string(e.Payload),
+		}
+	},
+	Select: `id, workspace_id, source_id, destination_id, transformation_id, transformation_version_id, user_id,
+	source_job_run_id, source_task_run_id, status_code, error, payload, created_at, replayed_at`,
+	Scan: scan,
+	ID:   func(e Entry) int64 { return e.ID },
+
+	Filters: map[string]string{
+		"workspaceId":      "workspace_id",
+		"sourceId":         "source_id",
+		"destinationId":    "destination_id",
+		"transformationId": "transformation_id",
+	},
+	Action: "replay",
+	Done:   "replayed",
+	Job:    gatewayJob,
+}
+
+func scan(row interface{ Scan(...any) error }) (Entry, error) {
+	var (
+		e          Entry
+		payload    []byte
+		replayedAt sql.NullTime
+	)
+	if err := row.Scan(
+		&e.ID, &e.WorkspaceID, &e.SourceID, &e.DestinationID, &e.TransformationID, &e.TransformationVersionID, &e.UserID,
+		&e.SourceJobRunID, &e.SourceTaskRunID, &e.StatusCode, &e.Error, &payload, &e.CreatedAt, &replayedAt,
+	); err != nil {
+		return Entry{}, fmt.Errorf("scan entry: %w", err)
+	}
+	e.Payload = payload
+	if replayedAt.Valid {
+		e.ReplayedAt = &replayedAt.Time
+	}
+	return e, nil
+}
@@ -0,0 +1,118 @@
+package transformation_dlq_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/bytesize"
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
+	transformation_dlq "github.com/rudderlabs/rudder-server/internal/transformation-dlq"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	mocksJobsDB "github.com/rudderlabs/rudder-server/mocks/jobsdb"
+)
+
+func TestTransformationDLQ(t *testing.T) {
+	ctx := context.Background()
+	dlq, err := transformation_dlq.New(testSetup(t), logger.NOP)
+	require.NoError(t, err, "should create the dead-letter queue")
+	t.Cleanup(dlq.Stop)
+
+	entry := func(transformationID, destinationID string) transformation_dlq.Entry {
+		return transformation_dlq.Entry{
+			WorkspaceID:             "workspace-1",
+			SourceID:                "source-1",
+			DestinationID:           destinationID,
+			TransformationID:        transformationID,
+			TransformationVersionID: transformationID + "-v1",
+			UserID:                  "rudder-id",
+			StatusCode:              400,
+			Error:                   "TypeError: Cannot read properties of undefined",
+			Payload:                 json.RawMessage(`[{"messageId":"message-1","receivedAt":"2024-01-01T00:00:00.000Z"}]`),
+		}
+	}
+	require.NoError(t, dlq.Add(ctx, []transformation_dlq.Entry{
+		entry("transformation-1", "destination-1"),
+		entry("transformation-1", "destination-2"),
+		entry("transformation-2", "destination-1"),
+	}))
+
+	entries, err := dlq.List(ctx, transformation_dlq.Filter{TransformationID: "transformation-1"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "destination-1", entries[0].DestinationID)
+	require.Equal(t, "transformation-1-v1", entries[0].TransformationVersionID)
+	require.JSONEq(t, `[{"messageId":"message-1","receivedAt":"2024-01-01T00:00:00.000Z"}]`, string(entries[0].Payload))
+
+	page, err := dlq.List(ctx, transformation_dlq.Filter{TransformationID: "transformation-1", AfterID: entries[0].ID, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	require.Equal(t, entries[1].ID, page[0].ID)
+
+	t.Run("inspect", func(t *testing.T) {
+		handler := dlq.HttpHandler(nil)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?destinationId=destination-1", http.NoBody))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.EqualValues(t, 2, gjson.Get(resp.Body.String(), "#").Int())
+
+		resp = httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/0", http.NoBody))
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		gatewayDB := mocksJobsDB.NewMockJobsDB(gomock.NewController(t))
+		gatewayDB.EXPECT().Store(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, jobs []*jobsdb.JobT) error {
+			require.Len(t, jobs, 2)
+			for _, job := range jobs {
+				require.Equal(t, "workspace-1", job.WorkspaceId)
+				require.Equal(t, "rudder-id", job.UserID)
+				require.True(t, gjson.GetBytes(job.Parameters, "dlq_replay").Bool())
+				require.Equal(t, "source-1", gjson.GetBytes(job.Parameters, "source_id").String())
+				require.Equal(t, "message-1", gjson.GetBytes(job.EventPayload, "batch.0.messageId").String())
+				require.Equal(t, "2024-01-01T00:00:00.000Z", gjson.GetBytes(job.EventPayload, "receivedAt").String())
+			}
+			return nil
+		}).Times(1)
+
+		resp := httptest.NewRecorder()
+		dlq.HttpHandler(gatewayDB).ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/replay?transformationId=transformation-1", http.NoBody))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.EqualValues(t, 2, gjson.Get(resp.Body.String(), "replayed.#").Int())
+
+		remaining, err := dlq.List(ctx, transformation_dlq.Filter{TransformationID: "transformation-1"})
+		require.NoError(t, err)
+		require.Empty(t, remaining, "replayed entries should not be listed by default")
+
+		replayed, err := dlq.List(ctx, transformation_dlq.Filter{TransformationID: "transformation-1", IncludeReplayed: true})
+		require.NoError(t, err)
+		require.Len(t, replayed, 2)
+		require.NotNil(t, replayed[0].ReplayedAt)
+	})
+}
+
+func testSetup(t *testing.T) *config.Config {
+	conf := config.New()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err, "Failed to create docker pool")
+	postgresResource, err := postgres.Setup(pool, t, postgres.WithShmSize(256*bytesize.MB))
+	require.NoError(t, err, "failed to setup postgres resource")
+	conf.Set("DB.name", postgresResource.Database)
+	conf.Set("DB.host", postgresResource.Host)
+	conf.Set("DB.port", postgresResource.Port)
+	conf.Set("DB.user", postgresResource.User)
+	conf.Set("DB.password", postgresResource.Password)
+
+	return conf
+}
@@ -0,0 +1,56 @@
+package transformation_dlq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+// gatewayJob builds a gateway job out of the entry, which is only routed to the destination the events failed for
+func gatewayJob(e Entry) (*jobsdb.JobT, error) {
+	var events []map[string]interface{}
+	if err := json.Unmarshal(e.Payload, &events); err != nil {
+		return nil, fmt.Errorf("unmarshalling payload: %w", err)
+	}
+	if len(events) == 0 {
+		return nil, errors.New("no events in payload")
+	}
+	receivedAt, ok := events[0]["receivedAt"].(string)
+	if !ok {
+		receivedAt = time.Now().Format(misc.RFC3339Milli)
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"batch":      events,
+		"receivedAt": receivedAt,
+		"requestIP":  "",
+		"writeKey":   "",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshalling payload: %w", err)
+	}
+	params, err := json.Marshal(map[string]interface{}{
+		"source_id":          e.SourceID,
+		"destination_id":     e.DestinationID,
+		"source_job_run_id":  e.SourceJobRunID,
+		"source_task_run_id": e.SourceTaskRunID,
+		"dlq_replay":         true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshalling parameters: %w", err)
+	}
+	return &jobsdb.JobT{
+		UUID:         uuid.New(),
+		UserID:       e.UserID,
+		Parameters:   params,
+		CustomVal:    "GW",
+		EventPayload: payload,
+		EventCount:   len(events),
+		WorkspaceId:  e.WorkspaceID,
+	}, nil
+}
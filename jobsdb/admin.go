@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/samber/lo"
 
 	"github.com/rudderlabs/rudder-server/utils/crash"
@@ -123,6 +124,47 @@ func (jd *Handle) failExecuting() {
 	jd.assertError(err)
 }
 
+// FailExecutingOfDestinations fails the jobs of the destinations whose latest job state is executing
+func (jd *Handle) FailExecutingOfDestinations(ctx context.Context, destinationIDs []string) error {
+	if len(destinationIDs) == 0 {
+		return nil
+	}
+	tags := statTags{
+		CustomValFilters: []string{jd.tablePrefix},
+	}
+	command := func() error {
+		return jd.WithUpdateSafeTx(ctx, func(tx UpdateSafeTx) error {
+			defer jd.getTimerStat(
+				"jobsdb_fail_executing_of_destinations_time",
+				&statTags{CustomValFilters: []string{jd.tablePrefix}},
+			).RecordDuration()()
+
+			for _, ds := range jd.getDSList() {
+				ds := ds
+				if _, err := tx.SqlTx().ExecContext(ctx,
+					fmt.Sprintf(
+						`UPDATE %[1]q SET job_state='failed'
+							WHERE id = ANY(
+								SELECT s.id FROM "v_last_%[1]s" s JOIN %[2]q j ON j.job_id = s.job_id
+								WHERE s.job_state='executing' AND j.parameters->>'destination_id' = ANY($1)
+							)`,
+						ds.JobStatusTable,
+						ds.JobTable,
+					),
+					pq.Array(destinationIDs),
+				); err != nil {
+					return fmt.Errorf("failing executing jobs of %s: %w", ds.JobStatusTable, err)
+				}
+				tx.Tx().AddSuccessListener(func() {
+					jd.noResultsCache.InvalidateDataset(ds.Index)
+				})
+			}
+			return nil
+		})
+	}
+	return executeDbRequest(jd, newWriteDbRequest("fail_executing_of_destinations", &tags, command))
+}
+
 func (jd *Handle) failExecutingDSInTx(txHandler transactionHandler, ds dataSetT) error {
 	defer jd.getTimerStat(
 		"jobsdb_fail_executing_ds_time",
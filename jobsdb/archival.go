@@ -0,0 +1,274 @@
+package jobsdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/jobsdb/archive"
+	"github.com/rudderlabs/rudder-server/utils/filemanagerutil"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+// WithArchivalFileManager sets the file manager used for archiving jobs, instead of the one of the jobs backup storage
+func WithArchivalFileManager(fm filemanager.FileManager) OptsFunc {
+	return func(jd *Handle) {
+		jd.archival.fileManager = fm
+	}
+}
+
+// archivedStatuses holds, for every archived dataset, the id of its last job status when it got archived. Jobs whose
+// last status is newer than that haven't been archived, so they cannot be dropped until their dataset gets archived again.
+// It is nil while archival is disabled.
+type archivedStatuses map[string]int64
+
+// unarchived returns the sql condition matching the job statuses of the given table alias of the dataset which are newer
+// than its archival, i.e. all of them if the dataset hasn't been archived
+func (a archivedStatuses) unarchived(ds dataSetT, alias string) string {
+	if a == nil {
+		return "false"
+	}
+	return fmt.Sprintf("%s.id > %d", alias, a[ds.JobStatusTable])
+}
+
+// archiveDSList uploads the jobs of the datasets matching the condition on their last status "s" to object storage,
+// i.e. the ones which are about to be dropped, so that they are kept after that. Jobs are archived in parquet files,
+// one per workspace and dataset, under
+//
+//	<JobsDB.archival.prefix>/<tablePrefix>/<workspaceID>/<firstCreatedAt>_<lastCreatedAt>_<instanceID>_<jobTable><nameSuffix>.parquet
+//
+// Archival doesn't block the other operations of the jobsdb, thus jobs can keep getting updated while their datasets
+// get archived. Only the jobs whose last status is older than the returned archived statuses get archived, so callers
+// need to keep the rest.
+// Archiving the same dataset more than once, e.g. after a failed migration, overwrites the files of the previous attempt.
+func (jd *Handle) archiveDSList(ctx context.Context, dsList []dataSetT, condition, nameSuffix string) (archivedStatuses, error) {
+	if !jd.conf.archival.enabled.Load() {
+		return nil, nil
+	}
+	fm, err := jd.archivalFileManager(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating archival file manager: %w", err)
+	}
+	archived, err := jd.lastJobStatuses(ctx, dsList)
+	if err != nil {
+		return nil, err
+	}
+	tmpDir, err := misc.CreateTMPDIR()
+	if err != nil {
+		return nil, fmt.Errorf("creating tmp dir: %w", err)
+	}
+	dir, err := os.MkdirTemp(tmpDir, "rudder-jobs-archive-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating archival dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	for _, ds := range dsList {
+		if err := jd.archiveDS(ctx, fm, dir, ds, fmt.Sprintf("%s AND NOT %s", condition, archived.unarchived(ds, "s")), nameSuffix); err != nil {
+			return nil, fmt.Errorf("archiving %s: %w", ds.JobTable, err)
+		}
+	}
+	return archived, nil
+}
+
+// lastJobStatuses returns the ids of the last job statuses of the datasets. The migration lock is held while getting
+// them, so that no job statuses get updated meanwhile, thus all job statuses up to them are visible once it is released.
+func (jd *Handle) lastJobStatuses(ctx context.Context, dsList []dataSetT) (archivedStatuses, error) {
+	if !jd.dsMigrationLock.TryLockWithCtx(ctx) {
+		return nil, fmt.Errorf("could not acquire a migration lock: %w", ctx.Err())
+	}
+	defer jd.dsMigrationLock.Unlock()
+	statuses := make(archivedStatuses, len(dsList))
+	for _, ds := range dsList {
+		var id int64
+		if err := jd.dbHandle.QueryRowContext(ctx, fmt.Sprintf(`SELECT COALESCE(MAX(id), 0) FROM %q`, ds.JobStatusTable)).Scan(&id); err != nil {
+			return nil, fmt.Errorf("getting last job status of %s: %w", ds.JobStatusTable, err)
+		}
+		statuses[ds.JobStatusTable] = id
+	}
+	return statuses, nil
+}
+
+func (jd *Handle) archiveDS(ctx context.Context, fm filemanager.FileManager, dir string, ds dataSetT, condition, nameSuffix string) error {
+	defer jd.getTimerStat(
+		"archive_ds_time",
+		&statTags{CustomValFilters: []string{jd.tablePrefix}},
+	).RecordDuration()()
+
+	rows, err := jd.dbHandle.QueryContext(ctx, fmt.Sprintf(
+		`SELECT j.job_id, j.workspace_id, j.uuid, j.user_id, j.custom_val, j.parameters, j.event_payload, j.event_count, j.created_at,
+			s.job_state, s.attempt, s.exec_time, s.error_code, s.error_response
+		FROM %q j JOIN "v_last_%s" s ON j.job_id = s.job_id
+		WHERE %s
+		ORDER BY j.workspace_id, j.job_id`,
+		ds.JobTable, ds.JobStatusTable, condition,
+	))
+	if err != nil {
+		return fmt.Errorf("querying jobs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var file *archiveFile
+	defer func() {
+		if file != nil {
+			_ = file.close()
+		}
+	}()
+	for rows.Next() {
+		var (
+			job                 archive.Job
+			parameters, payload []byte
+			createdAt           time.Time
+			execTime            sql.NullTime
+			errorCode           sql.NullString
+			errorResponse       []byte
+			attempt             sql.NullInt64
+		)
+		if err := rows.Scan(
+			&job.JobID, &job.WorkspaceID, &job.UUID, &job.UserID, &job.CustomVal, &parameters, &payload, &job.EventCount, &createdAt,
+			&job.JobState, &attempt, &execTime, &errorCode, &errorResponse,
+		); err != nil {
+			return fmt.Errorf("scanning job: %w", err)
+		}
+		if payload, err = decompressPayload(payload); err != nil {
+			return fmt.Errorf("job %d: %w", job.JobID, err)
+		}
+		job.Parameters, job.EventPayload, job.ErrorResponse = string(parameters), string(payload), string(errorResponse)
+		job.ErrorCode, job.Attempt = errorCode.String, attempt.Int64
+		job.SetCreatedAt(createdAt)
+		job.SetExecTime(execTime.Time)
+
+		if file != nil && file.workspaceID != job.WorkspaceID {
+			if err := jd.uploadArchiveFile(ctx, fm, ds, file, nameSuffix); err != nil {
+				return err
+			}
+			file = nil
+		}
+		if file == nil {
+			if file, err = jd.newArchiveFile(dir, job.WorkspaceID); err != nil {
+				return err
+			}
+		}
+		if err := file.write(&job, createdAt); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating jobs: %w", err)
+	}
+	if file != nil {
+		return jd.uploadArchiveFile(ctx, fm, ds, file, nameSuffix)
+	}
+	return nil
+}
+
+// archivalFileManager returns the file manager used for archiving jobs, defaulting to one of the jobs backup storage
+func (jd *Handle) archivalFileManager(ctx context.Context) (filemanager.FileManager, error) {
+	jd.archival.mu.Lock()
+	defer jd.archival.mu.Unlock()
+	if jd.archival.fileManager != nil {
+		return jd.archival.fileManager, nil
+	}
+	provider := jd.config.GetString("JOBS_BACKUP_STORAGE_PROVIDER", "S3")
+	fm, err := filemanager.New(&filemanager.Settings{
+		Provider: provider,
+		Config:   filemanagerutil.GetProviderConfigForBackupsFromEnv(ctx, jd.config),
+		Logger:   jd.logger,
+		Conf:     jd.config,
+	})
+	if err != nil {
+		return nil, err
+	}
+	jd.archival.fileManager = fm
+	return fm, nil
+}
+
+func (jd *Handle) newArchiveFile(dir, workspaceID string) (*archiveFile, error) {
+	f, err := os.CreateTemp(dir, "*"+archive.FileExtension)
+	if err != nil {
+		return nil, fmt.Errorf("creating archive file: %w", err)
+	}
+	w, err := archive.NewWriter(f, jd.conf.archival.parquetParallelWriters.Load(), jd.conf.archival.parquetRowGroupSize.Load())
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &archiveFile{workspaceID: workspaceID, file: f, writer: w}, nil
+}
+
+// uploadArchiveFile completes the archive file and uploads it, after renaming it after the creation time range of its jobs
+func (jd *Handle) uploadArchiveFile(ctx context.Context, fm filemanager.FileManager, ds dataSetT, file *archiveFile, nameSuffix string) error {
+	if err := file.close(); err != nil {
+		return err
+	}
+	filePath := filepath.Join(filepath.Dir(file.file.Name()), archive.FileName(file.first, file.last, jd.conf.archival.instanceID, ds.JobTable+nameSuffix))
+	if err := os.Rename(file.file.Name(), filePath); err != nil {
+		return fmt.Errorf("renaming archive file: %w", err)
+	}
+	defer func() { _ = os.Remove(filePath) }()
+	f, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("opening archive file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	uploaded, err := fm.Upload(ctx, f, jd.conf.archival.prefix, jd.tablePrefix, file.workspaceID)
+	if err != nil {
+		return fmt.Errorf("uploading archive file: %w", err)
+	}
+	jd.logger.Debugn("Archived jobs",
+		logger.NewStringField("dataset", ds.JobTable),
+		obskit.WorkspaceID(file.workspaceID),
+		logger.NewIntField("jobs", int64(file.jobs)),
+		logger.NewStringField("location", uploaded.Location),
+	)
+	stats.Default.NewTaggedStat("jobsdb_archived_jobs", stats.CountType, stats.Tags{
+		"customVal":   jd.tablePrefix,
+		"workspaceId": file.workspaceID,
+	}).Count(file.jobs)
+	return nil
+}
+
+// archiveFile is a local archive file of the jobs of a workspace
+type archiveFile struct {
+	workspaceID string
+	file        *os.File
+	writer      *archive.Writer
+	jobs        int
+	first, last time.Time
+	closed      bool
+}
+
+func (f *archiveFile) write(job *archive.Job, createdAt time.Time) error {
+	if err := f.writer.Write(job); err != nil {
+		return err
+	}
+	if f.jobs == 0 || createdAt.Before(f.first) {
+		f.first = createdAt
+	}
+	if f.jobs == 0 || createdAt.After(f.last) {
+		f.last = createdAt
+	}
+	f.jobs++
+	return nil
+}
+
+func (f *archiveFile) close() error {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	err := f.writer.Close()
+	if closeErr := f.file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("closing archive file: %w", closeErr)
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package jobsdb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	"github.com/rudderlabs/rudder-go-kit/filemanager/mock_filemanager"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/rand"
+	"github.com/rudderlabs/rudder-server/jobsdb/archive"
+)
+
+func TestArchival(t *testing.T) {
+	config.Reset()
+	c := config.New()
+	c.Set("JobsDB.maxDSSize", 1)
+	c.Set("JobsDB.archival.enabled", true)
+
+	_ = startPostgres(t)
+
+	triggerAddNewDS := make(chan time.Time)
+	triggerMigrateDS := make(chan time.Time)
+	fm := mock_filemanager.NewMockFileManager(gomock.NewController(t))
+	jobDB := Handle{
+		TriggerAddNewDS: func() <-chan time.Time {
+			return triggerAddNewDS
+		},
+		TriggerMigrateDS: func() <-chan time.Time {
+			return triggerMigrateDS
+		},
+		config: c,
+	}
+	WithArchivalFileManager(fm)(&jobDB)
+	tablePrefix := strings.ToLower(rand.String(5))
+	require.NoError(t, jobDB.Setup(ReadWrite, true, tablePrefix))
+	defer jobDB.TearDown()
+	c.Set("JobsDB."+tablePrefix+"."+"maxDSRetention", "1ms")
+
+	customVal := rand.String(5)
+	jobs := genJobs(defaultWorkspaceID, customVal, 10, 1)
+	require.NoError(t, jobDB.Store(context.Background(), jobs))
+	require.NoError(t, jobDB.UpdateJobStatus(context.Background(), genJobStatuses(jobs[:9], Succeeded.State), []string{customVal}, nil))
+	require.NoError(t, jobDB.UpdateJobStatus(context.Background(), genJobStatuses(jobs[9:], Failed.State), []string{customVal}, nil))
+
+	triggerAddNewDS <- time.Now() // trigger addNewDSLoop to run
+	triggerAddNewDS <- time.Now() // Second time, waits for the first loop to finish
+	require.EqualValues(t, 2, jobDB.GetMaxDSIndex())
+
+	t.Run("archival failure postpones migration", func(t *testing.T) {
+		fm.EXPECT().Upload(gomock.Any(), gomock.Any(), gomock.Any()).Return(filemanager.UploadedFile{}, errors.New("unavailable")).MinTimes(1)
+		triggerMigrateDS <- time.Now() // trigger migrateDSLoop to run
+		triggerMigrateDS <- time.Now() // waits for last loop to finish
+		require.Equal(t, "1", jobDB.getDSList()[0].Index, "the dataset should not be dropped")
+	})
+
+	t.Run("terminal jobs are archived before migration", func(t *testing.T) {
+		var archived []archive.Job
+		fm.EXPECT().Upload(gomock.Any(), gomock.Any(), "rudder-jobs-archive", tablePrefix, defaultWorkspaceID).DoAndReturn(
+			func(_ context.Context, file *os.File, _ ...string) (filemanager.UploadedFile, error) {
+				first, last, ok := archive.ParseFileName(file.Name())
+				require.True(t, ok)
+				require.False(t, last.Before(first))
+				require.True(t, strings.HasSuffix(file.Name(), "_1_"+tablePrefix+"_jobs_1.parquet"))
+				require.NoError(t, archive.ReadFile(file.Name(), archive.Filter{}, func(job *archive.Job) error {
+					archived = append(archived, *job)
+					return nil
+				}))
+				// archival shouldn't block job status updates
+				require.NoError(t, jobDB.UpdateJobStatus(context.Background(), genJobStatuses(jobs[9:], Succeeded.State), []string{customVal}, nil))
+				return filemanager.UploadedFile{Location: file.Name()}, nil
+			},
+		).Times(1)
+		triggerMigrateDS <- time.Now() // trigger migrateDSLoop to run
+		triggerMigrateDS <- time.Now() // waits for last loop to finish
+		require.NotEqual(t, "1", jobDB.getDSList()[0].Index, "the dataset should be dropped")
+
+		require.Len(t, archived, 9, "only jobs in terminal states should be archived")
+		for i, job := range archived {
+			require.EqualValues(t, i+1, job.JobID)
+			require.Equal(t, jobs[i].UUID.String(), job.UUID)
+			require.Equal(t, defaultWorkspaceID, job.WorkspaceID)
+			require.Equal(t, customVal, job.CustomVal)
+			require.Equal(t, Succeeded.State, job.JobState)
+			require.EqualValues(t, 1, job.Attempt)
+			require.Equal(t, "999", job.ErrorCode)
+			require.JSONEq(t, string(jobs[i].EventPayload), job.EventPayload)
+		}
+
+		var migrated int64
+		require.NoError(t, jobDB.dbHandle.QueryRow(`SELECT job_id FROM "v_last_`+jobDB.getDSList()[0].JobStatusTable+`" WHERE job_state = 'succeeded'`).Scan(&migrated))
+		require.EqualValues(t, 10, migrated, "jobs in non terminal states while getting archived should be migrated")
+	})
+}
@@ -0,0 +1,187 @@
+// Package archive encodes jobs in terminal states to parquet files, so that they can be kept in object storage after
+// their datasets get dropped from jobsdb, and decodes them back for querying and restoring them.
+package archive
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// FileExtension is the extension of archive files
+const FileExtension = ".parquet"
+
+// Job is an archived job along with its last status
+type Job struct {
+	JobID         int64  `json:"jobId" parquet:"name=job_id, type=INT64, encoding=DELTA_BINARY_PACKED"`
+	WorkspaceID   string `json:"workspaceId" parquet:"name=workspace_id, type=BYTE_ARRAY, convertedtype=UTF8, encoding=RLE_DICTIONARY"`
+	UUID          string `json:"uuid" parquet:"name=uuid, type=BYTE_ARRAY, convertedtype=UTF8"`
+	UserID        string `json:"userId" parquet:"name=user_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CustomVal     string `json:"customVal" parquet:"name=custom_val, type=BYTE_ARRAY, convertedtype=UTF8, encoding=RLE_DICTIONARY"`
+	Parameters    string `json:"parameters" parquet:"name=parameters, type=BYTE_ARRAY, convertedtype=UTF8"`
+	EventPayload  string `json:"eventPayload" parquet:"name=event_payload, type=BYTE_ARRAY, convertedtype=UTF8"`
+	EventCount    int64  `json:"eventCount" parquet:"name=event_count, type=INT64"`
+	CreatedAt     int64  `json:"createdAt" parquet:"name=created_at, type=INT64, encoding=DELTA_BINARY_PACKED"` // In Microseconds
+	JobState      string `json:"jobState" parquet:"name=job_state, type=BYTE_ARRAY, convertedtype=UTF8, encoding=RLE_DICTIONARY"`
+	Attempt       int64  `json:"attempt" parquet:"name=attempt, type=INT64"`
+	ExecTime      int64  `json:"execTime" parquet:"name=exec_time, type=INT64, encoding=DELTA_BINARY_PACKED"` // In Microseconds
+	ErrorCode     string `json:"errorCode" parquet:"name=error_code, type=BYTE_ARRAY, convertedtype=UTF8, encoding=RLE_DICTIONARY"`
+	ErrorResponse string `json:"errorResponse" parquet:"name=error_response, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+func (j *Job) SetCreatedAt(t time.Time) {
+	j.CreatedAt = t.UTC().UnixMicro()
+}
+
+func (j *Job) CreatedAtTime() time.Time {
+	return time.UnixMicro(j.CreatedAt).UTC()
+}
+
+func (j *Job) SetExecTime(t time.Time) {
+	j.ExecTime = t.UTC().UnixMicro()
+}
+
+func (j *Job) ExecTimeTime() time.Time {
+	return time.UnixMicro(j.ExecTime).UTC()
+}
+
+// Writer encodes jobs to a parquet file
+type Writer struct {
+	pw *writer.ParquetWriter
+}
+
+// NewWriter returns a writer encoding jobs to w, which needs to be closed for the file to be complete
+func NewWriter(w io.Writer, parallelism, rowGroupSize int64) (*Writer, error) {
+	pw, err := writer.NewParquetWriterFromWriter(w, new(Job), parallelism)
+	if err != nil {
+		return nil, fmt.Errorf("creating parquet writer: %v", err)
+	}
+	pw.RowGroupSize = rowGroupSize
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	return &Writer{pw: pw}, nil
+}
+
+func (w *Writer) Write(job *Job) error {
+	if err := w.pw.Write(job); err != nil {
+		return fmt.Errorf("writing to parquet writer: %v", err)
+	}
+	return nil
+}
+
+// Close flushes the buffered jobs and writes the footer of the file, without closing the underlying writer
+func (w *Writer) Close() error {
+	if err := w.pw.WriteStop(); err != nil {
+		return fmt.Errorf("stopping parquet writer: %v", err)
+	}
+	return nil
+}
+
+// Filter selects archived jobs, with empty fields matching all jobs
+type Filter struct {
+	WorkspaceID string
+	JobState    string
+	// From and To are the bounds of the creation time of the jobs, inclusive and exclusive respectively
+	From, To time.Time
+}
+
+func (f Filter) Match(job *Job) bool {
+	if f.WorkspaceID != "" && job.WorkspaceID != f.WorkspaceID {
+		return false
+	}
+	if f.JobState != "" && job.JobState != f.JobState {
+		return false
+	}
+	createdAt := job.CreatedAtTime()
+	if !f.From.IsZero() && createdAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !createdAt.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// MatchFile reports whether the archive file with the given name may contain jobs matching the filter, judging by the
+// creation time range in its name. Files whose names are not in the expected format are always considered a match.
+func (f Filter) MatchFile(name string) bool {
+	first, last, ok := ParseFileName(name)
+	if !ok {
+		return true
+	}
+	if !f.From.IsZero() && !last.Add(time.Second).After(f.From) { // the name only has second precision
+		return false
+	}
+	if !f.To.IsZero() && !first.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// FileName returns the name of an archive file containing jobs created between first and last, e.g.
+//
+//	1717171717_1717175317_1_gw_jobs_1.parquet
+func FileName(first, last time.Time, instanceID, table string) string {
+	return fmt.Sprintf("%d_%d_%s_%s%s", first.Unix(), last.Unix(), instanceID, table, FileExtension)
+}
+
+// ParseFileName returns the creation time range of the jobs of an archive file, as encoded by [FileName]
+func ParseFileName(name string) (first, last time.Time, ok bool) {
+	name = name[strings.LastIndex(name, "/")+1:]
+	if !strings.HasSuffix(name, FileExtension) {
+		return time.Time{}, time.Time{}, false
+	}
+	parts := strings.SplitN(name, "_", 3)
+	if len(parts) != 3 {
+		return time.Time{}, time.Time{}, false
+	}
+	firstUnix, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	lastUnix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	return time.Unix(firstUnix, 0).UTC(), time.Unix(lastUnix, 0).UTC(), true
+}
+
+// ReadFile decodes the jobs of a local archive file, calling fn for every job matching the filter, in the order they
+// got archived
+func ReadFile(path string, filter Filter, fn func(*Job) error) error {
+	f, err := local.NewLocalFileReader(path)
+	if err != nil {
+		return fmt.Errorf("opening archive file %q: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	pr, err := reader.NewParquetReader(f, new(Job), 4)
+	if err != nil {
+		return fmt.Errorf("creating parquet reader for %q: %w", path, err)
+	}
+	defer pr.ReadStop()
+
+	const batchSize = 1000
+	for remaining := int(pr.GetNumRows()); remaining > 0; {
+		jobs := make([]Job, min(batchSize, remaining))
+		if err := pr.Read(&jobs); err != nil {
+			return fmt.Errorf("reading archive file %q: %w", path, err)
+		}
+		remaining -= len(jobs)
+		for i := range jobs {
+			if !filter.Match(&jobs[i]) {
+				continue
+			}
+			if err := fn(&jobs[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package archive_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/bytesize"
+	"github.com/rudderlabs/rudder-server/jobsdb/archive"
+)
+
+func TestArchive(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	job := func(jobID int64, workspaceID, jobState string, createdAt time.Time) archive.Job {
+		j := archive.Job{
+			JobID:         jobID,
+			WorkspaceID:   workspaceID,
+			UUID:          "b96f3d8a-7c26-4329-9671-4e3202f42f15",
+			UserID:        "user",
+			CustomVal:     "GW",
+			Parameters:    `{"source_id":"source"}`,
+			EventPayload:  `{"event":"test"}`,
+			EventCount:    1,
+			JobState:      jobState,
+			Attempt:       1,
+			ErrorCode:     "200",
+			ErrorResponse: `{}`,
+		}
+		j.SetCreatedAt(createdAt)
+		j.SetExecTime(createdAt.Add(time.Second))
+		return j
+	}
+	jobs := []archive.Job{
+		job(1, "workspace-1", "succeeded", now),
+		job(2, "workspace-1", "aborted", now.Add(time.Minute)),
+		job(3, "workspace-2", "succeeded", now.Add(2*time.Minute)),
+	}
+
+	filePath := filepath.Join(t.TempDir(), archive.FileName(now, now.Add(2*time.Minute), "1", "gw_jobs_1"))
+	f, err := os.Create(filePath)
+	require.NoError(t, err)
+	w, err := archive.NewWriter(f, 4, 128*bytesize.MB)
+	require.NoError(t, err)
+	for i := range jobs {
+		require.NoError(t, w.Write(&jobs[i]))
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	read := func(t *testing.T, filter archive.Filter) []archive.Job {
+		var res []archive.Job
+		require.NoError(t, archive.ReadFile(filePath, filter, func(job *archive.Job) error {
+			res = append(res, *job)
+			return nil
+		}))
+		return res
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		res := read(t, archive.Filter{})
+		require.Equal(t, jobs, res)
+		require.Equal(t, now, res[0].CreatedAtTime())
+		require.Equal(t, now.Add(time.Second), res[0].ExecTimeTime())
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		require.Len(t, read(t, archive.Filter{WorkspaceID: "workspace-1"}), 2)
+		require.Len(t, read(t, archive.Filter{JobState: "aborted"}), 1)
+		require.Len(t, read(t, archive.Filter{From: now.Add(time.Minute)}), 2)
+		require.Len(t, read(t, archive.Filter{To: now.Add(time.Minute)}), 1)
+		require.Len(t, read(t, archive.Filter{WorkspaceID: "workspace-1", JobState: "succeeded", From: now.Add(time.Minute)}), 0)
+	})
+
+	t.Run("file names", func(t *testing.T) {
+		first, last, ok := archive.ParseFileName("prefix/gw/workspace-1/" + filepath.Base(filePath))
+		require.True(t, ok)
+		require.Equal(t, now, first)
+		require.Equal(t, now.Add(2*time.Minute), last)
+
+		_, _, ok = archive.ParseFileName("prefix/gw/workspace-1/gw_jobs_1.json.gz")
+		require.False(t, ok)
+
+		require.True(t, archive.Filter{}.MatchFile(filePath))
+		require.True(t, archive.Filter{From: now.Add(2*time.Minute + 500*time.Millisecond)}.MatchFile(filePath), "names only have second precision")
+		require.False(t, archive.Filter{From: now.Add(3 * time.Minute)}.MatchFile(filePath))
+		require.True(t, archive.Filter{To: now.Add(time.Millisecond)}.MatchFile(filePath))
+		require.False(t, archive.Filter{To: now}.MatchFile(filePath))
+		require.True(t, archive.Filter{From: now.Add(3 * time.Minute)}.MatchFile("gw_jobs_1.json.gz"), "unknown names should always match")
+	})
+}
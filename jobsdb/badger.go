@@ -0,0 +1,913 @@
+package jobsdb
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/badger/v4/options"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/rruntime"
+	"github.com/rudderlabs/rudder-server/services/rmetrics"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	. "github.com/rudderlabs/rudder-server/utils/tx" //nolint:staticcheck
+)
+
+// Backends of a jobsdb, configured through e.g. Gateway.jobsDB.backend
+const (
+	PostgresBackend = "postgres"
+	BadgerBackend   = "badger"
+)
+
+// LifecycleJobsDB is a JobsDB which needs to be started before being used and stopped and closed afterwards,
+// regardless of its backend
+type LifecycleJobsDB interface {
+	JobsDB
+	Start() error
+	Stop()
+	Close()
+}
+
+var (
+	_ LifecycleJobsDB = &Handle{}
+	_ LifecycleJobsDB = &BadgerHandle{}
+)
+
+// errBadgerTxUnsupported is returned by [BadgerHandle.WithTx], since badger transactions cannot be shared with postgres
+var errBadgerTxUnsupported = errors.New("sql transactions are not supported by the badger jobsdb backend")
+
+// key prefixes of the badger jobsdb, followed by the big endian job (or journal operation) id, so that keys are sorted by id
+var (
+	badgerJobPrefix     = []byte("j/") // the job
+	badgerStatusPrefix  = []byte("s/") // the latest status of the job, if any
+	badgerPendingPrefix = []byte("p/") // present as long as the job is not in a terminal state
+	badgerJournalPrefix = []byte("o/") // a journal operation
+
+	badgerJobSequenceKey     = []byte("seq/jobs")
+	badgerJournalSequenceKey = []byte("seq/journal")
+)
+
+// BadgerHandle is a jobsdb backed by an embedded badger database, buffering the jobs of the gateway locally. Its jobs are
+// forwarded to the postgres jobsdbs the processor reads from through a [BadgerForwarder].
+//
+// It is a write buffer in front of postgres rather than a replacement for it: the processor and the rest of the services
+// of the gateway, e.g. job status tracking, keep requiring postgres, which only needs to be reachable eventually for
+// forwarding the jobs, instead of on every request.
+//
+// Jobs are kept in a single dataset: jobs reaching a terminal state expire after JobsDB.badger.retention,
+// while pending jobs are indexed separately so that querying them doesn't require scanning the jobs already done.
+// There are no sql transactions, so store-safe and update-safe transactions buffer their jobs and statuses
+// until their function returns successfully and write them atomically, as long as they fit in a single badger transaction.
+type BadgerHandle struct {
+	tablePrefix string
+	path        string
+	conf        *config.Config
+	stats       stats.Stats
+	logger      logger.Logger
+
+	db          *badger.DB
+	jobSeq      *badger.Sequence
+	journalSeq  *badger.Sequence
+	updateMu    sync.Mutex // serializes status updates, since they read the previous status of the jobs
+	retention   config.ValueLoader[time.Duration]
+	gcFrequency config.ValueLoader[time.Duration]
+
+	lifecycle struct {
+		mu      sync.Mutex
+		started bool
+		cancel  context.CancelFunc
+		wg      sync.WaitGroup
+	}
+}
+
+// BadgerOpt is a functional option for [NewBadger]
+type BadgerOpt func(b *BadgerHandle)
+
+// WithBadgerPath overrides the directory of the badger database, which defaults to JobsDB.badger.path/<tablePrefix>
+func WithBadgerPath(path string) BadgerOpt {
+	return func(b *BadgerHandle) {
+		b.path = path
+	}
+}
+
+// WithBadgerConfig overrides the config used by the badger jobsdb, which defaults to config.Default
+func WithBadgerConfig(conf *config.Config) BadgerOpt {
+	return func(b *BadgerHandle) {
+		b.conf = conf
+	}
+}
+
+// WithBadgerStats overrides the stats used by the badger jobsdb, which defaults to stats.Default
+func WithBadgerStats(s stats.Stats) BadgerOpt {
+	return func(b *BadgerHandle) {
+		b.stats = s
+	}
+}
+
+// NewBadger opens the badger jobsdb with the given table prefix.
+// A persistent volume should be configured through JobsDB.badger.path, otherwise jobs are kept in a temporary directory.
+func NewBadger(tablePrefix string, opts ...BadgerOpt) (*BadgerHandle, error) {
+	b := &BadgerHandle{
+		tablePrefix: tablePrefix,
+		conf:        config.Default,
+		stats:       stats.Default,
+		logger:      logger.NewLogger().Child("jobsdb").Child(tablePrefix),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.path == "" {
+		basePath := b.conf.GetString("JobsDB.badger.path", "")
+		if basePath == "" {
+			tmpDirPath, err := misc.CreateTMPDIR()
+			if err != nil {
+				return nil, fmt.Errorf("creating tmp dir: %w", err)
+			}
+			basePath = filepath.Join(tmpDirPath, "jobsdb")
+			b.logger.Warnn("JobsDB.badger.path is not configured, jobs will not survive the loss of the tmp directory",
+				logger.NewStringField("path", basePath),
+			)
+		}
+		b.path = filepath.Join(basePath, tablePrefix)
+	}
+	b.retention = b.conf.GetReloadableDurationVar(24, time.Hour, "JobsDB."+tablePrefix+".badger.retention", "JobsDB.badger.retention")
+	b.gcFrequency = b.conf.GetReloadableDurationVar(5, time.Minute, "JobsDB.badger.gcFrequency")
+
+	badgerOpts := badger.
+		DefaultOptions(b.path).
+		WithLogger(badgerLogger{b.logger}).
+		WithCompression(options.None).
+		WithIndexCacheSize(16 << 20). // 16mb
+		WithNumGoroutines(1).
+		WithNumMemtables(b.conf.GetInt("JobsDB.badger.numMemtable", 5)).
+		WithValueThreshold(b.conf.GetInt64("JobsDB.badger.valueThreshold", 1024)).
+		WithBlockCacheSize(0).
+		WithNumVersionsToKeep(1).
+		WithNumLevelZeroTables(b.conf.GetInt("JobsDB.badger.numLevelZeroTables", 5)).
+		WithNumLevelZeroTablesStall(b.conf.GetInt("JobsDB.badger.numLevelZeroTablesStall", 15)).
+		WithSyncWrites(b.conf.GetBool("JobsDB.badger.syncWrites", true)).
+		WithDetectConflicts(false)
+	var err error
+	if b.db, err = badger.Open(badgerOpts); err != nil {
+		return nil, fmt.Errorf("opening badger db at %q: %w", b.path, err)
+	}
+	if b.jobSeq, err = b.db.GetSequence(badgerJobSequenceKey, 1000); err != nil {
+		_ = b.db.Close()
+		return nil, fmt.Errorf("getting job id sequence: %w", err)
+	}
+	if b.journalSeq, err = b.db.GetSequence(badgerJournalSequenceKey, 1); err != nil {
+		_ = b.jobSeq.Release()
+		_ = b.db.Close()
+		return nil, fmt.Errorf("getting journal id sequence: %w", err)
+	}
+	return b, nil
+}
+
+// Start starts the background garbage collection of the badger database
+func (b *BadgerHandle) Start() error {
+	b.lifecycle.mu.Lock()
+	defer b.lifecycle.mu.Unlock()
+	if b.lifecycle.started {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b.lifecycle.cancel = cancel
+	b.lifecycle.wg.Add(1)
+	rruntime.Go(func() {
+		defer b.lifecycle.wg.Done()
+		b.gcLoop(ctx)
+	})
+	b.lifecycle.started = true
+	return nil
+}
+
+// Stop stops the background garbage collection of the badger database
+func (b *BadgerHandle) Stop() {
+	b.lifecycle.mu.Lock()
+	defer b.lifecycle.mu.Unlock()
+	if b.lifecycle.started {
+		b.lifecycle.cancel()
+		b.lifecycle.wg.Wait()
+		b.lifecycle.started = false
+	}
+}
+
+// Close closes the badger database. Stop should be called before Close.
+func (b *BadgerHandle) Close() {
+	_ = b.jobSeq.Release()
+	_ = b.journalSeq.Release()
+	_ = b.db.Close()
+}
+
+func (b *BadgerHandle) gcLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(b.gcFrequency.Load()):
+		}
+		// each call removes at most one value log file, so keep calling it for as long as it succeeds
+		for ctx.Err() == nil {
+			if err := b.db.RunValueLogGC(0.5); err != nil {
+				break
+			}
+		}
+		lsmSize, vlogSize, totSize, err := misc.GetBadgerDBUsage(b.path)
+		if err != nil {
+			b.logger.Errorn("Getting badger db usage", obskit.Error(err))
+			continue
+		}
+		tags := stats.Tags{"name": "jobsdb", "tablePrefix": b.tablePrefix}
+		b.stats.NewTaggedStat("badger_db_size", stats.GaugeType, lo.Assign(tags, stats.Tags{"type": "lsm"})).Gauge(lsmSize)
+		b.stats.NewTaggedStat("badger_db_size", stats.GaugeType, lo.Assign(tags, stats.Tags{"type": "vlog"})).Gauge(vlogSize)
+		b.stats.NewTaggedStat("badger_db_size", stats.GaugeType, lo.Assign(tags, stats.Tags{"type": "total"})).Gauge(totSize)
+	}
+}
+
+// Identifier returns the jobsdb's table prefix
+func (b *BadgerHandle) Identifier() string {
+	return b.tablePrefix
+}
+
+/* Commands */
+
+// WithTx always fails, since the badger backend has no sql transactions
+func (b *BadgerHandle) WithTx(func(tx *Tx) error) error {
+	return errBadgerTxUnsupported
+}
+
+// badgerStoreSafeTx buffers the jobs stored in it until the function using it returns successfully
+type badgerStoreSafeTx struct {
+	storeSafeTx
+	handle *BadgerHandle
+	jobs   []*JobT
+}
+
+func (b *BadgerHandle) WithStoreSafeTx(ctx context.Context, f func(tx StoreSafeTx) error) error {
+	tx := &badgerStoreSafeTx{storeSafeTx: storeSafeTx{tx: &Tx{}, identity: b.tablePrefix}, handle: b}
+	if err := f(tx); err != nil {
+		return err
+	}
+	return b.Store(ctx, tx.jobs)
+}
+
+// WithStoreSafeTxFromTx ignores the provided transaction, since the badger backend has no sql transactions
+func (b *BadgerHandle) WithStoreSafeTxFromTx(ctx context.Context, _ *Tx, f func(tx StoreSafeTx) error) error {
+	return b.WithStoreSafeTx(ctx, f)
+}
+
+func (b *BadgerHandle) Store(ctx context.Context, jobList []*JobT) error {
+	if len(jobList) == 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	entries := make([]*badger.Entry, 0, 2*len(jobList))
+	now := time.Now()
+	for _, job := range jobList {
+		id, err := b.jobSeq.Next()
+		if err != nil {
+			return fmt.Errorf("getting next job id: %w", err)
+		}
+		stored := *job
+		stored.JobID = int64(id) + 1 // job ids start from 1, like postgres sequences
+		stored.CreatedAt = now
+		stored.ExpireAt = now
+		stored.EventCount = max(stored.EventCount, 1)
+		stored.PayloadSize = int64(len(stored.EventPayload))
+		stored.LastJobStatus = JobStatusT{}
+		value, err := json.Marshal(&stored)
+		if err != nil {
+			if err = stored.sanitizeJSON(); err != nil {
+				return fmt.Errorf("sanitizeJSON: %w", err)
+			}
+			if value, err = json.Marshal(&stored); err != nil {
+				return fmt.Errorf("marshalling job: %w", err)
+			}
+		}
+		entries = append(entries,
+			badger.NewEntry(badgerKey(badgerJobPrefix, stored.JobID), value),
+			badger.NewEntry(badgerKey(badgerPendingPrefix, stored.JobID), nil),
+		)
+	}
+	return b.write(entries, nil)
+}
+
+func (b *BadgerHandle) StoreInTx(ctx context.Context, tx StoreSafeTx, jobList []*JobT) error {
+	if btx, ok := tx.(*badgerStoreSafeTx); ok && btx.handle == b {
+		btx.jobs = append(btx.jobs, jobList...)
+		return nil
+	}
+	return b.Store(ctx, jobList)
+}
+
+func (b *BadgerHandle) StoreEachBatchRetry(ctx context.Context, jobBatches [][]*JobT) map[uuid.UUID]string {
+	if err := b.Store(ctx, lo.Flatten(jobBatches)); err == nil {
+		return nil
+	}
+	// retry storing each batch separately
+	var failed map[uuid.UUID]string
+	for _, batch := range jobBatches {
+		if err := b.Store(ctx, batch); err != nil {
+			if failed == nil {
+				failed = make(map[uuid.UUID]string)
+			}
+			failed[batch[0].UUID] = err.Error()
+		}
+	}
+	return failed
+}
+
+func (b *BadgerHandle) StoreEachBatchRetryInTx(ctx context.Context, tx StoreSafeTx, jobBatches [][]*JobT) (map[uuid.UUID]string, error) {
+	if btx, ok := tx.(*badgerStoreSafeTx); ok && btx.handle == b {
+		btx.jobs = append(btx.jobs, lo.Flatten(jobBatches)...)
+		return nil, nil
+	}
+	return b.StoreEachBatchRetry(ctx, jobBatches), nil
+}
+
+// badgerUpdateSafeTx buffers the statuses updated in it until the function using it returns successfully
+type badgerUpdateSafeTx struct {
+	updateSafeTx
+	handle   *BadgerHandle
+	statuses []*JobStatusT
+}
+
+func (b *BadgerHandle) WithUpdateSafeTx(ctx context.Context, f func(tx UpdateSafeTx) error) error {
+	tx := &badgerUpdateSafeTx{updateSafeTx: updateSafeTx{tx: &Tx{}, identity: b.tablePrefix}, handle: b}
+	if err := f(tx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return b.updateJobStatus(tx.statuses)
+}
+
+// UpdateJobStatus updates the provided job statuses. Filters are only used by postgres for invalidating its cache, so they are ignored.
+func (b *BadgerHandle) UpdateJobStatus(ctx context.Context, statusList []*JobStatusT, _ []string, _ []ParameterFilterT) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return b.updateJobStatus(statusList)
+}
+
+func (b *BadgerHandle) UpdateJobStatusInTx(ctx context.Context, tx UpdateSafeTx, statusList []*JobStatusT, customValFilters []string, parameterFilters []ParameterFilterT) error {
+	if btx, ok := tx.(*badgerUpdateSafeTx); ok && btx.handle == b {
+		btx.statuses = append(btx.statuses, statusList...)
+		return nil
+	}
+	return b.UpdateJobStatus(ctx, statusList, customValFilters, parameterFilters)
+}
+
+// badgerJobStatus is the latest status of a job, along with the status it replaced if the job is executing,
+// so that [BadgerHandle.DeleteExecuting] can restore it
+type badgerJobStatus struct {
+	Status   JobStatusT  `json:"status"`
+	Previous *JobStatusT `json:"previous,omitempty"`
+}
+
+func (b *BadgerHandle) updateJobStatus(statusList []*JobStatusT) error {
+	if len(statusList) == 0 {
+		return nil
+	}
+	b.updateMu.Lock()
+	defer b.updateMu.Unlock()
+
+	var (
+		entries []*badger.Entry
+		deletes [][]byte
+		latest  = make(map[int64]*badgerJobStatus, len(statusList)) // for jobs with more than one status in the list
+	)
+	err := b.db.View(func(txn *badger.Txn) error {
+		for _, status := range statusList {
+			previous, ok := latest[status.JobID]
+			if !ok {
+				var err error
+				if previous, err = getBadgerJobStatus(txn, status.JobID); err != nil {
+					return err
+				}
+			}
+			record := &badgerJobStatus{Status: *status}
+			if status.JobState == Executing.State && previous != nil && previous.Status.JobState != Executing.State {
+				record.Previous = &previous.Status
+			}
+			latest[status.JobID] = record
+			value, err := json.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("marshalling status of job %d: %w", status.JobID, err)
+			}
+			statusKey := badgerKey(badgerStatusPrefix, status.JobID)
+			if !slices.Contains(validTerminalStates, status.JobState) {
+				entries = append(entries, badger.NewEntry(statusKey, value))
+				continue
+			}
+			// jobs done are kept for the retention period
+			jobKey := badgerKey(badgerJobPrefix, status.JobID)
+			item, err := txn.Get(jobKey)
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				continue // already expired
+			} else if err != nil {
+				return fmt.Errorf("getting job %d: %w", status.JobID, err)
+			}
+			job, err := item.ValueCopy(nil)
+			if err != nil {
+				return fmt.Errorf("reading job %d: %w", status.JobID, err)
+			}
+			retention := b.retention.Load()
+			entries = append(entries,
+				badger.NewEntry(jobKey, job).WithTTL(retention),
+				badger.NewEntry(statusKey, value).WithTTL(retention),
+			)
+			deletes = append(deletes, badgerKey(badgerPendingPrefix, status.JobID))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return b.write(entries, deletes)
+}
+
+// write sets the entries and deletes the keys in a single transaction, or in more if they don't fit in one
+func (b *BadgerHandle) write(entries []*badger.Entry, deletes [][]byte) error {
+	txn := b.db.NewTransaction(true)
+	defer func() { txn.Discard() }()
+	apply := func(op func(txn *badger.Txn) error) error {
+		err := op(txn)
+		if !errors.Is(err, badger.ErrTxnTooBig) {
+			return err
+		}
+		if err := txn.Commit(); err != nil {
+			return err
+		}
+		txn = b.db.NewTransaction(true)
+		return op(txn)
+	}
+	for _, e := range entries {
+		if err := apply(func(txn *badger.Txn) error { return txn.SetEntry(e) }); err != nil {
+			return err
+		}
+	}
+	for _, key := range deletes {
+		if err := apply(func(txn *badger.Txn) error { return txn.Delete(key) }); err != nil {
+			return err
+		}
+	}
+	return txn.Commit()
+}
+
+/* Queries */
+
+func (b *BadgerHandle) GetJobs(ctx context.Context, states []string, params GetQueryParams) (JobsResult, error) {
+	params.stateFilters = states
+	res, err := b.getJobs(ctx, params)
+	if err != nil {
+		return JobsResult{}, err
+	}
+	return res.JobsResult, nil
+}
+
+func (b *BadgerHandle) GetUnprocessed(ctx context.Context, params GetQueryParams) (JobsResult, error) {
+	return b.GetJobs(ctx, []string{Unprocessed.State}, params)
+}
+
+func (b *BadgerHandle) GetImporting(ctx context.Context, params GetQueryParams) (JobsResult, error) {
+	return b.GetJobs(ctx, []string{Importing.State}, params)
+}
+
+func (b *BadgerHandle) GetAborted(ctx context.Context, params GetQueryParams) (JobsResult, error) {
+	return b.GetJobs(ctx, []string{Aborted.State}, params)
+}
+
+func (b *BadgerHandle) GetWaiting(ctx context.Context, params GetQueryParams) (JobsResult, error) {
+	return b.GetJobs(ctx, []string{Waiting.State}, params)
+}
+
+func (b *BadgerHandle) GetSucceeded(ctx context.Context, params GetQueryParams) (JobsResult, error) {
+	return b.GetJobs(ctx, []string{Succeeded.State}, params)
+}
+
+func (b *BadgerHandle) GetFailed(ctx context.Context, params GetQueryParams) (JobsResult, error) {
+	return b.GetJobs(ctx, []string{Failed.State}, params)
+}
+
+func (b *BadgerHandle) GetToProcess(ctx context.Context, params GetQueryParams, more MoreToken) (*MoreJobsResult, error) {
+	mtoken := &moreToken{}
+	if more != nil {
+		var ok bool
+		if mtoken, ok = more.(*moreToken); !ok {
+			return nil, fmt.Errorf("invalid token: %+v", more)
+		}
+	}
+	params.stateFilters = []string{Failed.State, Waiting.State, Unprocessed.State}
+	params.afterJobID = mtoken.afterJobID
+	return b.getJobs(ctx, params)
+}
+
+// getJobs returns the jobs matching the params in job id order, applying the limits the same way postgres does:
+// a job is returned as long as it doesn't exceed the limits or it is the first one.
+func (b *BadgerHandle) getJobs(ctx context.Context, params GetQueryParams) (*MoreJobsResult, error) {
+	res := &MoreJobsResult{More: &moreToken{afterJobID: params.afterJobID}}
+	if params.JobsLimit <= 0 || params.PayloadSizeLimit < 0 {
+		return res, nil
+	}
+	states := lo.SliceToMap(params.stateFilters, func(state string) (string, struct{}) { return state, struct{}{} })
+	// pending jobs are indexed separately, so that jobs done are only scanned if they are requested
+	prefix := badgerPendingPrefix
+	if lo.SomeBy(params.stateFilters, func(state string) bool { return slices.Contains(validTerminalStates, state) }) {
+		prefix = badgerJobPrefix
+	}
+	err := b.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+		defer it.Close()
+		start := prefix
+		if params.afterJobID != nil {
+			start = badgerKey(prefix, *params.afterJobID+1)
+		}
+		for it.Seek(start); it.ValidForPrefix(prefix); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			job, err := getBadgerJob(txn, badgerKeyID(prefix, it.Item().Key()))
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				continue // expired
+			} else if err != nil {
+				return err
+			}
+			state := job.LastJobStatus.JobState
+			if state == "" {
+				state = Unprocessed.State
+			}
+			if _, ok := states[state]; !ok || !badgerJobMatches(job, params) {
+				continue
+			}
+			eventCount, payloadSize := res.EventsCount+job.EventCount, res.PayloadSize+job.PayloadSize
+			if len(res.Jobs) > 0 &&
+				(params.EventsLimit > 0 && eventCount > params.EventsLimit ||
+					params.PayloadSizeLimit > 0 && payloadSize > params.PayloadSizeLimit) {
+				res.LimitsReached = true
+				return nil
+			}
+			res.Jobs = append(res.Jobs, job)
+			res.EventsCount, res.PayloadSize = eventCount, payloadSize
+			if len(res.Jobs) == params.JobsLimit ||
+				params.EventsLimit > 0 && res.EventsCount >= params.EventsLimit ||
+				params.PayloadSizeLimit > 0 && res.PayloadSize >= params.PayloadSizeLimit {
+				res.LimitsReached = true
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Jobs) > 0 {
+		afterJobID := res.Jobs[len(res.Jobs)-1].JobID
+		res.More = &moreToken{afterJobID: &afterJobID}
+	}
+	return res, nil
+}
+
+// badgerJobMatches returns whether the job matches the workspace, custom value and parameter filters of the params
+func badgerJobMatches(job *JobT, params GetQueryParams) bool {
+	if params.WorkspaceID != "" && job.WorkspaceId != params.WorkspaceID {
+		return false
+	}
+	if !params.IgnoreCustomValFiltersInQuery && len(params.CustomValFilters) > 0 && !slices.Contains(params.CustomValFilters, job.CustomVal) {
+		return false
+	}
+	if len(params.ParameterFilters) > 0 && !lo.SomeBy(params.ParameterFilters, func(f ParameterFilterT) bool {
+		return gjson.GetBytes(job.Parameters, f.Name).String() == f.Value
+	}) {
+		return false
+	}
+	return true
+}
+
+func (b *BadgerHandle) GetPileUpCounts(ctx context.Context) error {
+	type key struct{ workspace, customVal string }
+	counts := make(map[key]int)
+	err := b.forEachJob(ctx, badgerPendingPrefix, func(job *JobT) {
+		counts[key{workspace: job.WorkspaceId, customVal: job.CustomVal}]++
+	})
+	if err != nil {
+		return err
+	}
+	for k, count := range counts {
+		rmetrics.IncreasePendingEvents(b.tablePrefix, k.workspace, k.customVal, float64(count))
+	}
+	return nil
+}
+
+func (b *BadgerHandle) GetActiveWorkspaces(ctx context.Context, customVal string) ([]string, error) {
+	workspaces := make(map[string]struct{})
+	err := b.forEachJob(ctx, badgerJobPrefix, func(job *JobT) {
+		if customVal == "" || job.CustomVal == customVal {
+			workspaces[job.WorkspaceId] = struct{}{}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	workspaceIDs := lo.Keys(workspaces)
+	slices.Sort(workspaceIDs)
+	return workspaceIDs, nil
+}
+
+func (b *BadgerHandle) GetDistinctParameterValues(ctx context.Context, parameterName string) ([]string, error) {
+	values := make(map[string]struct{})
+	err := b.forEachJob(ctx, badgerJobPrefix, func(job *JobT) {
+		if value := gjson.GetBytes(job.Parameters, parameterName); value.Exists() {
+			values[value.String()] = struct{}{}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	distinct := lo.Keys(values)
+	slices.Sort(distinct)
+	return distinct, nil
+}
+
+// GetDatasetStats returns the number of jobs, including the ones done which haven't expired yet, the number of pending jobs
+// and the size of the badger database
+func (b *BadgerHandle) GetDatasetStats(ctx context.Context) (DatasetStats, error) {
+	var jobs, pending int64
+	count := func(txn *badger.Txn, prefix []byte, n *int64) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+		defer it.Close()
+		for it.Rewind(); it.ValidForPrefix(prefix); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			*n++
+		}
+		return nil
+	}
+	err := b.db.View(func(txn *badger.Txn) error {
+		if err := count(txn, badgerJobPrefix, &jobs); err != nil {
+			return err
+		}
+		return count(txn, badgerPendingPrefix, &pending)
+	})
+	if err != nil {
+		return DatasetStats{}, err
+	}
+	lsmSize, vlogSize := b.db.Size()
+	return DatasetStats{Datasets: 1, Jobs: jobs, Pending: pending, SizeBytes: lsmSize + vlogSize}, nil
+}
+
+// forEachJob calls f for each job whose key has the prefix, i.e. either all jobs or the pending ones
+func (b *BadgerHandle) forEachJob(ctx context.Context, prefix []byte, f func(job *JobT)) error {
+	return b.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: prefix})
+		defer it.Close()
+		for it.Rewind(); it.ValidForPrefix(prefix); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			job, err := getBadgerJob(txn, badgerKeyID(prefix, it.Item().Key()))
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			f(job)
+		}
+		return nil
+	})
+}
+
+/* Admin */
+
+func (b *BadgerHandle) Ping() error {
+	if b.db.IsClosed() {
+		return errors.New("badger db is closed")
+	}
+	return nil
+}
+
+// DeleteExecuting reverts the jobs left executing to their previous status
+func (b *BadgerHandle) DeleteExecuting() {
+	err := b.updateExecuting(nil, func(record *badgerJobStatus) *badgerJobStatus {
+		if record.Previous == nil {
+			return nil
+		}
+		return &badgerJobStatus{Status: *record.Previous}
+	})
+	if err != nil {
+		b.logger.Errorn("Updating executing jobs", obskit.Error(err))
+	}
+}
+
+// FailExecuting marks the jobs left executing as failed
+func (b *BadgerHandle) FailExecuting() {
+	if err := b.updateExecuting(nil, failExecuting); err != nil {
+		b.logger.Errorn("Updating executing jobs", obskit.Error(err))
+	}
+}
+
+// FailExecutingOfDestinations marks the jobs of the destinations left executing as failed
+func (b *BadgerHandle) FailExecutingOfDestinations(_ context.Context, destinationIDs []string) error {
+	return b.updateExecuting(func(job *JobT) bool {
+		return slices.Contains(destinationIDs, gjson.GetBytes(job.Parameters, "destination_id").String())
+	}, failExecuting)
+}
+
+func failExecuting(record *badgerJobStatus) *badgerJobStatus {
+	record.Status.JobState = Failed.State
+	record.Previous = nil
+	return record
+}
+
+// updateExecuting replaces the status of executing jobs matching filter, or all of them if filter is nil, with the
+// one returned by update, or deletes it if update returns nil
+func (b *BadgerHandle) updateExecuting(filter func(job *JobT) bool, update func(record *badgerJobStatus) *badgerJobStatus) error {
+	b.updateMu.Lock()
+	defer b.updateMu.Unlock()
+	var (
+		entries []*badger.Entry
+		deletes [][]byte
+	)
+	err := b.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: badgerPendingPrefix})
+		defer it.Close()
+		for it.Rewind(); it.ValidForPrefix(badgerPendingPrefix); it.Next() {
+			jobID := badgerKeyID(badgerPendingPrefix, it.Item().Key())
+			record, err := getBadgerJobStatus(txn, jobID)
+			if err != nil {
+				return err
+			}
+			if record == nil || record.Status.JobState != Executing.State {
+				continue
+			}
+			if filter != nil {
+				job, err := getBadgerJob(txn, jobID)
+				if err != nil {
+					return err
+				}
+				if !filter(job) {
+					continue
+				}
+			}
+			statusKey := badgerKey(badgerStatusPrefix, jobID)
+			if record = update(record); record == nil {
+				deletes = append(deletes, statusKey)
+				continue
+			}
+			value, err := json.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("marshalling status of job %d: %w", jobID, err)
+			}
+			entries = append(entries, badger.NewEntry(statusKey, value))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return b.write(entries, deletes)
+}
+
+/* Journal */
+
+func (b *BadgerHandle) GetJournalEntries(opType string) (entries []JournalEntryT) {
+	err := b.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: badgerJournalPrefix, PrefetchValues: true, PrefetchSize: 100})
+		defer it.Close()
+		for it.Rewind(); it.ValidForPrefix(badgerJournalPrefix); it.Next() {
+			var entry JournalEntryT
+			if err := it.Item().Value(func(value []byte) error { return json.Unmarshal(value, &entry) }); err != nil {
+				return err
+			}
+			if !entry.OpDone && entry.OpType == opType {
+				entries = append(entries, entry)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		b.logger.Errorn("Getting journal entries", obskit.Error(err))
+	}
+	return entries
+}
+
+func (b *BadgerHandle) JournalDeleteEntry(opID int64) {
+	if err := b.write(nil, [][]byte{badgerKey(badgerJournalPrefix, opID)}); err != nil {
+		b.logger.Errorn("Deleting journal entry", logger.NewIntField("opID", opID), obskit.Error(err))
+	}
+}
+
+func (b *BadgerHandle) JournalMarkStart(opType string, opPayload json.RawMessage) (int64, error) {
+	id, err := b.journalSeq.Next()
+	if err != nil {
+		return 0, fmt.Errorf("getting next journal id: %w", err)
+	}
+	entry := JournalEntryT{OpID: int64(id) + 1, OpType: opType, OpPayload: opPayload}
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return 0, fmt.Errorf("marshalling journal entry: %w", err)
+	}
+	if err := b.write([]*badger.Entry{badger.NewEntry(badgerKey(badgerJournalPrefix, entry.OpID), value)}, nil); err != nil {
+		return 0, err
+	}
+	return entry.OpID, nil
+}
+
+func (b *BadgerHandle) JournalMarkDone(opID int64) error {
+	key := badgerKey(badgerJournalPrefix, opID)
+	return b.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return fmt.Errorf("getting journal entry %d: %w", opID, err)
+		}
+		var entry JournalEntryT
+		if err := item.Value(func(value []byte) error { return json.Unmarshal(value, &entry) }); err != nil {
+			return err
+		}
+		entry.OpDone = true
+		value, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return txn.Set(key, value)
+	})
+}
+
+// IsMasterBackupEnabled is always false, since jobs done are not backed up by the badger backend
+func (b *BadgerHandle) IsMasterBackupEnabled() bool {
+	return false
+}
+
+// getBadgerJob returns the job along with its latest status, or [badger.ErrKeyNotFound] if it doesn't exist
+func getBadgerJob(txn *badger.Txn, jobID int64) (*JobT, error) {
+	item, err := txn.Get(badgerKey(badgerJobPrefix, jobID))
+	if err != nil {
+		return nil, err
+	}
+	var job JobT
+	if err := item.Value(func(value []byte) error { return json.Unmarshal(value, &job) }); err != nil {
+		return nil, fmt.Errorf("unmarshalling job %d: %w", jobID, err)
+	}
+	record, err := getBadgerJobStatus(txn, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		job.LastJobStatus = record.Status
+	}
+	job.LastJobStatus.JobParameters = job.Parameters
+	return &job, nil
+}
+
+// getBadgerJobStatus returns the latest status of the job, or nil if the job has no status yet
+func getBadgerJobStatus(txn *badger.Txn, jobID int64) (*badgerJobStatus, error) {
+	item, err := txn.Get(badgerKey(badgerStatusPrefix, jobID))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("getting status of job %d: %w", jobID, err)
+	}
+	var record badgerJobStatus
+	if err := item.Value(func(value []byte) error { return json.Unmarshal(value, &record) }); err != nil {
+		return nil, fmt.Errorf("unmarshalling status of job %d: %w", jobID, err)
+	}
+	return &record, nil
+}
+
+func badgerKey(prefix []byte, id int64) []byte {
+	key := make([]byte, len(prefix)+8)
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], uint64(id))
+	return key
+}
+
+func badgerKeyID(prefix, key []byte) int64 {
+	return int64(binary.BigEndian.Uint64(key[len(prefix):]))
+}
+
+type badgerLogger struct {
+	logger.Logger
+}
+
+func (l badgerLogger) Warningf(fmt string, args ...interface{}) {
+	l.Warnf(fmt, args...)
+}
@@ -0,0 +1,98 @@
+package jobsdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+// BadgerForwarder forwards the jobs buffered in a badger jobsdb to the jobsdb their consumers read from, e.g. the jobs
+// accepted by the gateway to the gw postgres jobsdb the processor reads from. Jobs are marked as succeeded in the
+// badger jobsdb once stored in the other one, thus they are forwarded at least once.
+type BadgerForwarder struct {
+	from   JobsDB
+	to     JobsDB
+	logger logger.Logger
+
+	pickupSize config.ValueLoader[int]
+	loopSleep  config.ValueLoader[time.Duration]
+}
+
+func NewBadgerForwarder(from, to JobsDB, conf *config.Config, log logger.Logger) *BadgerForwarder {
+	return &BadgerForwarder{
+		from:       from,
+		to:         to,
+		logger:     log.Child("forwarder").Withn(logger.NewStringField("from", from.Identifier()), logger.NewStringField("to", to.Identifier())),
+		pickupSize: conf.GetReloadableIntVar(1000, 1, "JobsDB.badger.forwarder.pickupSize"),
+		loopSleep:  conf.GetReloadableDurationVar(1, time.Second, "JobsDB.badger.forwarder.loopSleep"),
+	}
+}
+
+// Run forwards the jobs until the context is done, retrying on errors
+func (f *BadgerForwarder) Run(ctx context.Context) error {
+	for {
+		forwarded, err := f.forward(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			f.logger.Warnn("Forwarding jobs", obskit.Error(err))
+		}
+		if err != nil || forwarded == 0 {
+			if err := misc.SleepCtx(ctx, f.loopSleep.Load()); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// forward forwards a batch of unprocessed jobs, returning their number
+func (f *BadgerForwarder) forward(ctx context.Context) (int, error) {
+	unprocessed, err := f.from.GetUnprocessed(ctx, GetQueryParams{JobsLimit: f.pickupSize.Load()})
+	if err != nil {
+		return 0, fmt.Errorf("getting unprocessed jobs: %w", err)
+	}
+	if len(unprocessed.Jobs) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	jobs := make([]*JobT, len(unprocessed.Jobs))
+	statuses := make([]*JobStatusT, len(unprocessed.Jobs))
+	for i, job := range unprocessed.Jobs {
+		jobs[i] = &JobT{
+			UUID:         job.UUID,
+			UserID:       job.UserID,
+			CustomVal:    job.CustomVal,
+			EventCount:   job.EventCount,
+			EventPayload: job.EventPayload,
+			Parameters:   job.Parameters,
+			WorkspaceId:  job.WorkspaceId,
+		}
+		statuses[i] = &JobStatusT{
+			JobID:         job.JobID,
+			JobState:      Succeeded.State,
+			AttemptNum:    job.LastJobStatus.AttemptNum + 1,
+			ExecTime:      now,
+			RetryTime:     now,
+			ErrorCode:     "200",
+			ErrorResponse: []byte(`{}`),
+			Parameters:    []byte(`{}`),
+			JobParameters: job.Parameters,
+			WorkspaceId:   job.WorkspaceId,
+		}
+	}
+	if err := f.to.Store(ctx, jobs); err != nil {
+		return 0, fmt.Errorf("storing jobs: %w", err)
+	}
+	if err := f.from.UpdateJobStatus(ctx, statuses, nil, nil); err != nil {
+		return 0, fmt.Errorf("marking forwarded jobs as succeeded: %w", err)
+	}
+	return len(jobs), nil
+}
@@ -0,0 +1,236 @@
+package jobsdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	. "github.com/rudderlabs/rudder-server/utils/tx" //nolint:staticcheck
+)
+
+func TestBadgerJobsDB(t *testing.T) {
+	ctx := context.Background()
+	setup := func(t *testing.T) *BadgerHandle {
+		jd, err := NewBadger("gw", WithBadgerPath(t.TempDir()), WithBadgerConfig(config.New()), WithBadgerStats(stats.NOP))
+		require.NoError(t, err)
+		require.NoError(t, jd.Start())
+		t.Cleanup(func() {
+			jd.Stop()
+			jd.Close()
+		})
+		return jd
+	}
+	newJob := func(workspaceID, customVal, sourceID string) *JobT {
+		return &JobT{
+			UUID:         uuid.New(),
+			UserID:       "user",
+			CustomVal:    customVal,
+			EventCount:   1,
+			EventPayload: []byte(`{"event":"test"}`),
+			Parameters:   []byte(`{"source_id":"` + sourceID + `"}`),
+			WorkspaceId:  workspaceID,
+		}
+	}
+	status := func(job *JobT, state string) *JobStatusT {
+		return &JobStatusT{
+			JobID:         job.JobID,
+			JobState:      state,
+			AttemptNum:    1,
+			ExecTime:      time.Now(),
+			RetryTime:     time.Now(),
+			ErrorCode:     "200",
+			ErrorResponse: []byte(`{}`),
+			Parameters:    []byte(`{}`),
+			WorkspaceId:   job.WorkspaceId,
+		}
+	}
+
+	t.Run("store and query", func(t *testing.T) {
+		jd := setup(t)
+		require.NoError(t, jd.Store(ctx, []*JobT{
+			newJob("workspace-1", "GW", "source-1"),
+			newJob("workspace-1", "GW", "source-2"),
+			newJob("workspace-2", "GW", "source-1"),
+		}))
+
+		res, err := jd.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 10})
+		require.NoError(t, err)
+		require.Len(t, res.Jobs, 3)
+		require.EqualValues(t, []int64{1, 2, 3}, []int64{res.Jobs[0].JobID, res.Jobs[1].JobID, res.Jobs[2].JobID})
+		require.JSONEq(t, `{"event":"test"}`, string(res.Jobs[0].EventPayload))
+		require.EqualValues(t, 48, res.PayloadSize)
+		require.False(t, res.LimitsReached)
+
+		res, err = jd.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 10, WorkspaceID: "workspace-2"})
+		require.NoError(t, err)
+		require.Len(t, res.Jobs, 1)
+
+		res, err = jd.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 10, ParameterFilters: []ParameterFilterT{{Name: "source_id", Value: "source-2"}}})
+		require.NoError(t, err)
+		require.Len(t, res.Jobs, 1)
+		require.EqualValues(t, 2, res.Jobs[0].JobID)
+
+		res, err = jd.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 2})
+		require.NoError(t, err)
+		require.Len(t, res.Jobs, 2)
+		require.True(t, res.LimitsReached)
+
+		res, err = jd.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 10, EventsLimit: 1})
+		require.NoError(t, err)
+		require.Len(t, res.Jobs, 1, "the first job should be returned even if it reaches the events limit")
+		require.True(t, res.LimitsReached)
+
+		workspaces, err := jd.GetActiveWorkspaces(ctx, "GW")
+		require.NoError(t, err)
+		require.Equal(t, []string{"workspace-1", "workspace-2"}, workspaces)
+
+		sources, err := jd.GetDistinctParameterValues(ctx, "source_id")
+		require.NoError(t, err)
+		require.Equal(t, []string{"source-1", "source-2"}, sources)
+
+		datasetStats, err := jd.GetDatasetStats(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, datasetStats.Jobs)
+		require.EqualValues(t, 3, datasetStats.Pending)
+	})
+
+	t.Run("status updates", func(t *testing.T) {
+		jd := setup(t)
+		require.NoError(t, jd.Store(ctx, []*JobT{newJob("workspace", "GW", "source"), newJob("workspace", "GW", "source")}))
+		res, err := jd.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 10})
+		require.NoError(t, err)
+		require.Len(t, res.Jobs, 2)
+		first, second := res.Jobs[0], res.Jobs[1]
+
+		require.NoError(t, jd.UpdateJobStatus(ctx, []*JobStatusT{status(first, Failed.State), status(second, Succeeded.State)}, nil, nil))
+		toProcess, err := jd.GetToProcess(ctx, GetQueryParams{JobsLimit: 10}, nil)
+		require.NoError(t, err)
+		require.Len(t, toProcess.Jobs, 1)
+		require.Equal(t, first.JobID, toProcess.Jobs[0].JobID)
+		require.Equal(t, Failed.State, toProcess.Jobs[0].LastJobStatus.JobState)
+
+		more, err := jd.GetToProcess(ctx, GetQueryParams{JobsLimit: 10}, toProcess.More)
+		require.NoError(t, err)
+		require.Empty(t, more.Jobs, "jobs before the more token should not be returned again")
+
+		succeeded, err := jd.GetSucceeded(ctx, GetQueryParams{JobsLimit: 10})
+		require.NoError(t, err)
+		require.Len(t, succeeded.Jobs, 1)
+		require.Equal(t, second.JobID, succeeded.Jobs[0].JobID)
+
+		require.NoError(t, jd.UpdateJobStatus(ctx, []*JobStatusT{status(first, Executing.State)}, nil, nil))
+		jd.DeleteExecuting()
+		failed, err := jd.GetFailed(ctx, GetQueryParams{JobsLimit: 10})
+		require.NoError(t, err)
+		require.Len(t, failed.Jobs, 1, "deleting executing jobs should revert them to their previous status")
+
+		require.NoError(t, jd.UpdateJobStatus(ctx, []*JobStatusT{status(first, Executing.State)}, nil, nil))
+		jd.FailExecuting()
+		failed, err = jd.GetFailed(ctx, GetQueryParams{JobsLimit: 10})
+		require.NoError(t, err)
+		require.Len(t, failed.Jobs, 1, "failing executing jobs should mark them as failed")
+
+		require.NoError(t, jd.UpdateJobStatus(ctx, []*JobStatusT{status(first, Executing.State)}, nil, nil))
+		require.NoError(t, jd.FailExecutingOfDestinations(ctx, []string{"other"}))
+		failed, err = jd.GetFailed(ctx, GetQueryParams{JobsLimit: 10})
+		require.NoError(t, err)
+		require.Empty(t, failed.Jobs, "jobs of other destinations should be left executing")
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		jd := setup(t)
+		require.ErrorIs(t, jd.WithTx(func(*Tx) error { return nil }), errBadgerTxUnsupported)
+
+		err := jd.WithStoreSafeTx(ctx, func(tx StoreSafeTx) error {
+			require.NoError(t, jd.StoreInTx(ctx, tx, []*JobT{newJob("workspace", "GW", "source")}))
+			return errors.New("rollback")
+		})
+		require.Error(t, err)
+		res, err := jd.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 10})
+		require.NoError(t, err)
+		require.Empty(t, res.Jobs, "jobs should not be stored if the transaction fails")
+
+		require.NoError(t, jd.WithStoreSafeTx(ctx, func(tx StoreSafeTx) error {
+			require.Nil(t, tx.SqlTx())
+			return jd.StoreInTx(ctx, tx, []*JobT{newJob("workspace", "GW", "source")})
+		}))
+		res, err = jd.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 10})
+		require.NoError(t, err)
+		require.Len(t, res.Jobs, 1)
+
+		require.NoError(t, jd.WithUpdateSafeTx(ctx, func(tx UpdateSafeTx) error {
+			return jd.UpdateJobStatusInTx(ctx, tx, []*JobStatusT{status(res.Jobs[0], Aborted.State)}, nil, nil)
+		}))
+		aborted, err := jd.GetAborted(ctx, GetQueryParams{JobsLimit: 10})
+		require.NoError(t, err)
+		require.Len(t, aborted.Jobs, 1)
+	})
+
+	t.Run("journal", func(t *testing.T) {
+		jd := setup(t)
+		opID, err := jd.JournalMarkStart("op", json.RawMessage(`{"key":"value"}`))
+		require.NoError(t, err)
+		entries := jd.GetJournalEntries("op")
+		require.Len(t, entries, 1)
+		require.Equal(t, opID, entries[0].OpID)
+		require.JSONEq(t, `{"key":"value"}`, string(entries[0].OpPayload))
+
+		require.NoError(t, jd.JournalMarkDone(opID))
+		require.Empty(t, jd.GetJournalEntries("op"))
+		jd.JournalDeleteEntry(opID)
+	})
+
+	t.Run("forwarder", func(t *testing.T) {
+		from, to := setup(t), setup(t)
+		jobs := []*JobT{newJob("workspace-1", "GW", "source-1"), newJob("workspace-2", "GW", "source-2")}
+		require.NoError(t, from.Store(ctx, jobs))
+
+		c := config.New()
+		c.Set("JobsDB.badger.forwarder.loopSleep", "10ms")
+		forwarderCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() { done <- NewBadgerForwarder(from, to, c, logger.NOP).Run(forwarderCtx) }()
+
+		require.Eventually(t, func() bool {
+			res, err := from.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 10})
+			return err == nil && len(res.Jobs) == 0
+		}, 5*time.Second, 10*time.Millisecond, "forwarded jobs should be marked as succeeded")
+		cancel()
+		require.NoError(t, <-done)
+
+		forwarded, err := to.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 10})
+		require.NoError(t, err)
+		require.Len(t, forwarded.Jobs, len(jobs))
+		for i, job := range forwarded.Jobs {
+			require.Equal(t, jobs[i].UUID, job.UUID)
+			require.Equal(t, jobs[i].WorkspaceId, job.WorkspaceId)
+			require.JSONEq(t, string(jobs[i].Parameters), string(job.Parameters))
+			require.JSONEq(t, string(jobs[i].EventPayload), string(job.EventPayload))
+		}
+	})
+
+	t.Run("reopen", func(t *testing.T) {
+		path := t.TempDir()
+		jd, err := NewBadger("gw", WithBadgerPath(path), WithBadgerConfig(config.New()), WithBadgerStats(stats.NOP))
+		require.NoError(t, err)
+		require.NoError(t, jd.Store(ctx, []*JobT{newJob("workspace", "GW", "source")}))
+		jd.Close()
+
+		jd, err = NewBadger("gw", WithBadgerPath(path), WithBadgerConfig(config.New()), WithBadgerStats(stats.NOP))
+		require.NoError(t, err)
+		defer jd.Close()
+		require.NoError(t, jd.Store(ctx, []*JobT{newJob("workspace", "GW", "source")}))
+		res, err := jd.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 10})
+		require.NoError(t, err)
+		require.Len(t, res.Jobs, 2, "jobs should survive restarts")
+		require.Less(t, res.Jobs[0].JobID, res.Jobs[1].JobID)
+	})
+}
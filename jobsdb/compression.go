@@ -0,0 +1,108 @@
+package jobsdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+
+	. "github.com/rudderlabs/rudder-server/utils/tx" //nolint:staticcheck
+)
+
+const (
+	jsonbPayloadColumnType = "jsonb"
+	byteaPayloadColumnType = "bytea"
+)
+
+// zstdMagic is the magic number every zstd frame starts with, which is never the start of a json document.
+// It is what tells compressed payloads apart from plain json ones in datasets storing payloads as bytes.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// payloadDecoder is shared by all jobsdb handles, since decompression doesn't depend on the compression level
+var payloadDecoder = sync.OnceValues(func() (*zstd.Decoder, error) {
+	return zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+})
+
+// newPayloadColumnType returns the type of the payload column of new datasets: bytes if payload compression is
+// enabled, since compressed payloads cannot be stored as jsonb, otherwise jsonb
+func (jd *Handle) newPayloadColumnType() string {
+	if jd.conf.payloadCompression.enabled.Load() {
+		return byteaPayloadColumnType
+	}
+	return jsonbPayloadColumnType
+}
+
+// rowQuerier is either a database handle or a transaction
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// payloadColumnType returns the type of the payload column of the dataset, which doesn't change during its lifetime.
+// Datasets created before payload compression got enabled keep storing payloads as jsonb, until they get migrated.
+func (jd *Handle) payloadColumnType(ctx context.Context, tx rowQuerier, ds dataSetT) (string, error) {
+	if columnType, ok := jd.payloadColumnTypes.Load(ds.JobTable); ok {
+		return columnType.(string), nil
+	}
+	var columnType string
+	if err := tx.QueryRowContext(ctx,
+		`SELECT data_type FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 AND column_name = 'event_payload'`,
+		ds.JobTable,
+	).Scan(&columnType); err != nil {
+		return "", fmt.Errorf("getting payload column type of %s: %w", ds.JobTable, err)
+	}
+	jd.payloadColumnTypes.Store(ds.JobTable, columnType)
+	return columnType, nil
+}
+
+// migrationPayloadColumnType returns the type of the payload column of the dataset the given datasets get migrated to,
+// storing payloads as bytes if any of them does, since compressed payloads cannot be converted back to jsonb
+func (jd *Handle) migrationPayloadColumnType(ctx context.Context, tx *Tx, dsList []dataSetT) (string, error) {
+	columnType := jd.newPayloadColumnType()
+	for _, ds := range dsList {
+		dsColumnType, err := jd.payloadColumnType(ctx, tx, ds)
+		if err != nil {
+			return "", err
+		}
+		if dsColumnType == byteaPayloadColumnType {
+			columnType = byteaPayloadColumnType
+		}
+	}
+	return columnType, nil
+}
+
+// payloadSizeColumn returns the expression of the size of the payloads of a dataset with the given payload column type.
+// Datasets storing payloads as bytes keep their uncompressed size in a separate column, so that payload size limits
+// apply to the actual payloads rather than to their compressed bytes.
+func payloadSizeColumn(payloadColumnType, alias string) string {
+	if payloadColumnType == byteaPayloadColumnType {
+		return alias + ".event_payload_size"
+	}
+	return "pg_column_size(" + alias + ".event_payload)"
+}
+
+// compressPayload compresses the payload if it is large enough for compression to pay off
+func (jd *Handle) compressPayload(payload []byte) []byte {
+	if jd.payloadEncoder == nil || len(payload) < jd.conf.payloadCompression.minSize.Load() {
+		return payload
+	}
+	return jd.payloadEncoder.EncodeAll(payload, make([]byte, 0, len(payload)/2))
+}
+
+// decompressPayload decompresses the payload if it is compressed, returning it as is otherwise
+func decompressPayload(payload []byte) ([]byte, error) {
+	if !bytes.HasPrefix(payload, zstdMagic) {
+		return payload, nil
+	}
+	decoder, err := payloadDecoder()
+	if err != nil {
+		return nil, fmt.Errorf("creating payload decoder: %w", err)
+	}
+	decompressed, err := decoder.DecodeAll(payload, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing payload: %w", err)
+	}
+	return decompressed, nil
+}
@@ -0,0 +1,105 @@
+package jobsdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/rand"
+)
+
+func TestPayloadCompression(t *testing.T) {
+	config.Reset()
+	c := config.New()
+	c.Set("JobsDB.maxDSSize", 1)
+	c.Set("JobsDB.payloadCompression.minSize", 0)
+
+	_ = startPostgres(t)
+
+	triggerAddNewDS := make(chan time.Time)
+	triggerMigrateDS := make(chan time.Time)
+	jobDB := Handle{
+		TriggerAddNewDS: func() <-chan time.Time {
+			return triggerAddNewDS
+		},
+		TriggerMigrateDS: func() <-chan time.Time {
+			return triggerMigrateDS
+		},
+		config: c,
+	}
+	tablePrefix := strings.ToLower(rand.String(5))
+	require.NoError(t, jobDB.Setup(ReadWrite, true, tablePrefix))
+	defer jobDB.TearDown()
+	c.Set("JobsDB."+tablePrefix+"."+"maxDSRetention", "1ms")
+
+	payloadColumnType := func(t *testing.T, ds dataSetT) string {
+		var columnType string
+		require.NoError(t, jobDB.dbHandle.QueryRow(
+			`SELECT data_type FROM information_schema.columns WHERE table_name = $1 AND column_name = 'event_payload'`, ds.JobTable,
+		).Scan(&columnType))
+		return columnType
+	}
+	storedPayload := func(t *testing.T, ds dataSetT, jobID int64) []byte {
+		var payload []byte
+		require.NoError(t, jobDB.dbHandle.QueryRow(fmt.Sprintf(`SELECT event_payload FROM %q WHERE job_id = $1`, ds.JobTable), jobID).Scan(&payload))
+		return payload
+	}
+
+	customVal := rand.String(5)
+	jobs := genJobs(defaultWorkspaceID, customVal, 20, 1)
+	require.NoError(t, jobDB.Store(context.Background(), jobs[:10]))
+	require.Equal(t, jsonbPayloadColumnType, payloadColumnType(t, jobDB.getDSList()[0]), "payloads should be stored as jsonb while compression is disabled")
+
+	c.Set("JobsDB.payloadCompression.enabled", true)
+	triggerAddNewDS <- time.Now() // trigger addNewDSLoop to run
+	triggerAddNewDS <- time.Now() // Second time, waits for the first loop to finish
+	require.EqualValues(t, 2, jobDB.GetMaxDSIndex())
+	require.NoError(t, jobDB.Store(context.Background(), jobs[10:]))
+
+	dsList := jobDB.getDSList()
+	require.Equal(t, byteaPayloadColumnType, payloadColumnType(t, dsList[1]), "new datasets should store payloads as bytes")
+	require.True(t, bytes.HasPrefix(storedPayload(t, dsList[1], 11), zstdMagic), "payloads should be stored compressed")
+
+	res, err := jobDB.GetUnprocessed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100})
+	require.NoError(t, err)
+	require.Len(t, res.Jobs, 20)
+	for i, job := range res.Jobs {
+		require.JSONEq(t, string(jobs[i].EventPayload), string(job.EventPayload), "payloads should be decompressed when read")
+	}
+	for _, job := range res.Jobs[10:] {
+		require.EqualValues(t, len(job.EventPayload), job.PayloadSize, "payload sizes should be the uncompressed ones")
+	}
+	limited, err := jobDB.GetUnprocessed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100, afterJobID: &res.Jobs[9].JobID, PayloadSizeLimit: res.Jobs[10].PayloadSize + res.Jobs[11].PayloadSize})
+	require.NoError(t, err)
+	require.Len(t, limited.Jobs, 2, "payload size limits should apply to the uncompressed payloads")
+
+	t.Run("jsonb payloads migrated to a dataset storing payloads as bytes", func(t *testing.T) {
+		require.NoError(t, jobDB.UpdateJobStatus(context.Background(), genJobStatuses(res.Jobs[:9], Succeeded.State), []string{customVal}, nil))
+		triggerMigrateDS <- time.Now() // trigger migrateDSLoop to run
+		triggerMigrateDS <- time.Now() // waits for last loop to finish
+
+		dsList := jobDB.getDSList()
+		require.Equal(t, "1_1", dsList[0].Index)
+		require.Equal(t, byteaPayloadColumnType, payloadColumnType(t, dsList[0]))
+		require.JSONEq(t, string(jobs[9].EventPayload), string(storedPayload(t, dsList[0], 10)), "migrated payloads should be stored uncompressed")
+
+		res, err := jobDB.GetUnprocessed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100})
+		require.NoError(t, err)
+		require.Len(t, res.Jobs, 11)
+		for _, job := range res.Jobs {
+			require.JSONEq(t, string(jobs[job.JobID-1].EventPayload), string(job.EventPayload))
+		}
+	})
+
+	t.Run("small payloads", func(t *testing.T) {
+		c.Set("JobsDB.payloadCompression.minSize", 1_000_000)
+		require.NoError(t, jobDB.Store(context.Background(), genJobs(defaultWorkspaceID, customVal, 1, 1)))
+		require.False(t, bytes.HasPrefix(storedPayload(t, jobDB.getDSList()[len(jobDB.getDSList())-1], 21), zstdMagic), "payloads smaller than the minimum size should not be compressed")
+	})
+}
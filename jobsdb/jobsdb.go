@@ -52,11 +52,13 @@ import (
 	. "github.com/rudderlabs/rudder-server/utils/tx" //nolint:staticcheck
 
 	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 	"github.com/rudderlabs/rudder-server/services/rmetrics"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 
 	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
 	"github.com/lib/pq"
 )
 
@@ -105,6 +107,11 @@ type GetQueryParams struct {
 	// A value less than or equal to zero will disable this limit (no limit),
 	// only values greater than zero are considered as valid limits.
 	PayloadSizeLimit int64
+	// Limit the number of jobs of every workspace, so that jobs are picked up fairly across workspaces,
+	// i.e. the backlog of a workspace doesn't take up the whole JobsLimit at the expense of the rest.
+	// A value less than or equal to zero will disable this limit, as does filtering by WorkspaceID.
+	WorkspaceJobsLimit int
+	workspaceJobs      map[string]int // jobs of every workspace picked up from the previous datasets
 }
 
 // StoreSafeTx sealed interface
@@ -300,11 +307,17 @@ type JobsDB interface {
 	// GetDistinctParameterValues returns the list of distinct parameter values inside the jobs tables
 	GetDistinctParameterValues(ctx context.Context, parameterName string) (values []string, err error)
 
+	// GetDatasetStats returns statistics about the number of jobs, pending or not, and disk usage of the jobsdb's datasets
+	GetDatasetStats(ctx context.Context) (DatasetStats, error)
+
 	/* Admin */
 
 	Ping() error
 	DeleteExecuting()
 	FailExecuting()
+	// FailExecutingOfDestinations fails the jobs of the destinations whose latest job state is executing,
+	// e.g. the ones left behind by another server sharing the jobsdb which was serving the destinations
+	FailExecutingOfDestinations(ctx context.Context, destinationIDs []string) error
 
 	/* Journal */
 
@@ -481,12 +494,21 @@ type Handle struct {
 	TriggerRefreshDS func() <-chan time.Time
 
 	TriggerJobCleanUp func() <-chan time.Time
+	TriggerRetention  func() <-chan time.Time
 
 	lifecycle struct {
 		mu      sync.Mutex
 		started bool
 	}
 
+	archival struct {
+		mu          sync.Mutex
+		fileManager filemanager.FileManager
+	}
+
+	payloadEncoder     *zstd.Encoder
+	payloadColumnTypes sync.Map // job table -> payload column type
+
 	config *config.Config
 	conf   struct {
 		maxTableSize                   config.ValueLoader[int64]
@@ -522,6 +544,25 @@ type Handle struct {
 		backup struct {
 			masterBackupEnabled config.ValueLoader[bool]
 		}
+		archival struct {
+			enabled                config.ValueLoader[bool]
+			prefix                 string
+			instanceID             string
+			parquetParallelWriters config.ValueLoader[int64]
+			parquetRowGroupSize    config.ValueLoader[int64]
+		}
+		payloadCompression struct {
+			enabled config.ValueLoader[bool]
+			minSize config.ValueLoader[int]
+		}
+		retention struct {
+			periods          map[string]config.ValueLoader[time.Duration] // terminal job state -> retention period
+			janitorFrequency config.ValueLoader[time.Duration]
+		}
+		reindex struct {
+			lockTimeout   config.ValueLoader[time.Duration]
+			retryInterval config.ValueLoader[time.Duration]
+		}
 	}
 }
 
@@ -913,6 +954,44 @@ func (jd *Handle) loadConfig() {
 		true, "JobsDB.backup.enabled",
 	)
 
+	// archival: jobs in terminal states are archived to object storage before their datasets get dropped
+	jd.conf.archival.enabled = jd.config.GetReloadableBoolVar(
+		false, "JobsDB."+jd.tablePrefix+".archival.enabled", "JobsDB.archival.enabled",
+	)
+	jd.conf.archival.prefix = jd.config.GetStringVar("rudder-jobs-archive", "JobsDB.archival.prefix")
+	jd.conf.archival.instanceID = jd.config.GetString("INSTANCE_ID", "1")
+	jd.conf.archival.parquetParallelWriters = jd.config.GetReloadableInt64Var(4, 1, "JobsDB.archival.parquetParallelWriters")
+	jd.conf.archival.parquetRowGroupSize = jd.config.GetReloadableInt64Var(128*bytesize.MB, 1, "JobsDB.archival.parquetRowGroupSize")
+
+	// retention: jobs in terminal states are kept for the configured period after reaching them, instead of getting
+	// dropped along with their datasets, and are dropped by a janitor once the period has elapsed
+	jd.conf.retention.periods = make(map[string]config.ValueLoader[time.Duration], len(validTerminalStates))
+	for _, state := range validTerminalStates {
+		retentionKeys := []string{"JobsDB." + jd.tablePrefix + "." + "retention." + state, "JobsDB." + "retention." + state}
+		jd.conf.retention.periods[state] = jd.config.GetReloadableDurationVar(0, time.Hour, retentionKeys...)
+	}
+	jd.conf.retention.janitorFrequency = jd.config.GetReloadableDurationVar(1, time.Hour, "JobsDB.retention.janitorFrequency")
+
+	// reindex: partition indexes of datasets created before they included the job id are rebuilt in the background
+	jd.conf.reindex.lockTimeout = jd.config.GetReloadableDurationVar(5, time.Second, "JobsDB.reindex.lockTimeout")
+	jd.conf.reindex.retryInterval = jd.config.GetReloadableDurationVar(1, time.Minute, "JobsDB.reindex.retryInterval")
+
+	// payloadCompression: payloads are compressed with zstd in datasets created while it is enabled
+	payloadCompressionEnabledKeys := []string{"JobsDB." + jd.tablePrefix + "." + "payloadCompression.enabled", "JobsDB." + "payloadCompression.enabled"}
+	jd.conf.payloadCompression.enabled = jd.config.GetReloadableBoolVar(false, payloadCompressionEnabledKeys...)
+	payloadCompressionMinSizeKeys := []string{"JobsDB." + jd.tablePrefix + "." + "payloadCompression.minSize", "JobsDB." + "payloadCompression.minSize"}
+	jd.conf.payloadCompression.minSize = jd.config.GetReloadableIntVar(512, 1, payloadCompressionMinSizeKeys...)
+	payloadCompressionLevelKeys := []string{"JobsDB." + jd.tablePrefix + "." + "payloadCompression.level", "JobsDB." + "payloadCompression.level"}
+	payloadCompressionLevelName := jd.config.GetStringVar("default", payloadCompressionLevelKeys...)
+	ok, payloadCompressionLevel := zstd.EncoderLevelFromString(payloadCompressionLevelName)
+	if !ok {
+		jd.logger.Warnn("Unknown payload compression level, using the default one", logger.NewStringField("level", payloadCompressionLevelName))
+		payloadCompressionLevel = zstd.SpeedDefault
+	}
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(payloadCompressionLevel))
+	jd.assertError(err)
+	jd.payloadEncoder = encoder
+
 	// maxDSSize: Maximum size of a DS. The process which adds new DS runs in the background
 	// (every few seconds) so a DS may go beyond this size
 	// passing `maxDSSize` by reference, so it can be hot reloaded
@@ -942,6 +1021,12 @@ func (jd *Handle) loadConfig() {
 		}
 	}
 
+	if jd.TriggerRetention == nil {
+		jd.TriggerRetention = func() <-chan time.Time {
+			return time.After(jd.conf.retention.janitorFrequency.Load())
+		}
+	}
+
 	if jd.conf.jobMaxAge == nil {
 		jd.conf.jobMaxAge = func() time.Duration {
 			return jd.config.GetDuration("JobsDB.jobMaxAge", 720, time.Hour)
@@ -1055,6 +1140,8 @@ func (jd *Handle) readerSetup(ctx context.Context, l lock.LockToken) {
 
 	jd.startMigrateDSLoop(ctx)
 	jd.startCleanupLoop(ctx)
+	jd.startRetentionLoop(ctx)
+	jd.startReindexLoop(ctx)
 }
 
 func (jd *Handle) writerSetup(ctx context.Context, l lock.LockToken) {
@@ -1082,6 +1169,8 @@ func (jd *Handle) readerWriterSetup(ctx context.Context, l lock.LockToken) {
 
 	jd.startMigrateDSLoop(ctx)
 	jd.startCleanupLoop(ctx)
+	jd.startRetentionLoop(ctx)
+	jd.startReindexLoop(ctx)
 }
 
 // Stop stops the background goroutines and waits until they finish.
@@ -1394,7 +1483,7 @@ func (jd *Handle) createDSInTx(tx *Tx, newDS dataSetT) error {
 	}
 
 	// Create the jobs and job_status tables
-	if err = jd.createDSTablesInTx(ctx, tx, newDS); err != nil {
+	if err = jd.createDSTablesInTx(ctx, tx, newDS, jd.newPayloadColumnType()); err != nil {
 		return fmt.Errorf("creating DS tables %w", err)
 	}
 	if err = jd.createDSIndicesInTx(ctx, tx, newDS); err != nil {
@@ -1408,7 +1497,12 @@ func (jd *Handle) createDSInTx(tx *Tx, newDS dataSetT) error {
 	return nil
 }
 
-func (jd *Handle) createDSTablesInTx(ctx context.Context, tx *Tx, newDS dataSetT) error {
+// createDSTablesInTx creates the tables of the dataset, storing payloads in a column of the given type
+func (jd *Handle) createDSTablesInTx(ctx context.Context, tx *Tx, newDS dataSetT, payloadColumnType string) error {
+	var payloadSizeColumnDef string
+	if payloadColumnType == byteaPayloadColumnType { // the uncompressed size of the payloads
+		payloadSizeColumnDef = "event_payload_size INTEGER NOT NULL,"
+	}
 	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE %q (
 		job_id BIGSERIAL PRIMARY KEY,
 		workspace_id TEXT NOT NULL DEFAULT '',
@@ -1416,12 +1510,14 @@ func (jd *Handle) createDSTablesInTx(ctx context.Context, tx *Tx, newDS dataSetT
 		user_id TEXT NOT NULL,
 		parameters JSONB NOT NULL,
 		custom_val VARCHAR(64) NOT NULL,
-		event_payload JSONB NOT NULL,
+		event_payload %s NOT NULL,
+		%s
 		event_count INTEGER NOT NULL DEFAULT 1,
 		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-		expire_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW());`, newDS.JobTable)); err != nil {
+		expire_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW());`, newDS.JobTable, strings.ToUpper(payloadColumnType), payloadSizeColumnDef)); err != nil {
 		return fmt.Errorf("creating %s: %w", newDS.JobTable, err)
 	}
+	tx.AddSuccessListener(func() { jd.payloadColumnTypes.Store(newDS.JobTable, payloadColumnType) })
 	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE %q (
 		id BIGSERIAL,
 		job_id BIGINT,
@@ -1439,17 +1535,14 @@ func (jd *Handle) createDSTablesInTx(ctx context.Context, tx *Tx, newDS dataSetT
 }
 
 func (jd *Handle) createDSIndicesInTx(ctx context.Context, tx *Tx, newDS dataSetT) error {
-	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`CREATE INDEX "idx_%[1]s_ws" ON %[1]q (workspace_id)`, newDS.JobTable)); err != nil {
-		return fmt.Errorf("creating workspace index: %w", err)
+	for _, index := range partitionIndexes() {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`CREATE INDEX %q ON %q %s`, index.name(newDS), newDS.JobTable, index.definition)); err != nil {
+			return fmt.Errorf("creating %s index: %w", index.suffix, err)
+		}
 	}
 	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`CREATE INDEX "idx_%[1]s_cv" ON %[1]q (custom_val)`, newDS.JobTable)); err != nil {
 		return fmt.Errorf("creating custom_val index: %w", err)
 	}
-	for _, param := range cacheParameterFilters {
-		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`CREATE INDEX "idx_%[1]s_%[2]s" ON %[1]q USING BTREE ((parameters->>'%[2]s'))`, newDS.JobTable, param)); err != nil {
-			return fmt.Errorf("creating %s index: %w", param, err)
-		}
-	}
 	if _, err := tx.ExecContext(
 		ctx,
 		fmt.Sprintf(
@@ -1596,6 +1689,7 @@ func (jd *Handle) dropDSForRecovery(ds dataSetT) {
 
 func (jd *Handle) postDropDs(ds dataSetT) {
 	jd.noResultsCache.InvalidateDataset(ds.Index)
+	jd.payloadColumnTypes.Delete(ds.JobTable)
 
 	// Tracking time interval between drop ds operations. Hence calling end before start
 	if jd.isStatDropDSPeriodInitialized {
@@ -1919,6 +2013,58 @@ func (jd *Handle) GetActiveWorkspaces(ctx context.Context, customVal string) ([]
 	return workspaceIds, nil
 }
 
+// DatasetStats are statistics about the datasets of a jobsdb
+type DatasetStats struct {
+	Datasets  int   // number of datasets
+	Jobs      int64 // estimated total number of jobs across all datasets, processed or not
+	Pending   int64 // number of jobs which haven't reached a terminal state yet, i.e. the backlog of the jobsdb
+	SizeBytes int64 // total disk size of all datasets' tables, including indexes and toast
+}
+
+// GetDatasetStats returns statistics about the number of jobs and disk usage of the jobsdb's datasets.
+// Total job counts are estimated based on postgres' table statistics, so they include the processed jobs too, until their
+// datasets get migrated or dropped. Pending jobs are counted exactly instead, from the latest status of the jobs.
+func (jd *Handle) GetDatasetStats(ctx context.Context) (DatasetStats, error) {
+	if !jd.dsMigrationLock.RTryLockWithCtx(ctx) {
+		return DatasetStats{}, fmt.Errorf("could not acquire a migration read lock: %w", ctx.Err())
+	}
+	defer jd.dsMigrationLock.RUnlock()
+	if !jd.dsListLock.RTryLockWithCtx(ctx) {
+		return DatasetStats{}, fmt.Errorf("could not acquire a dslist read lock: %w", ctx.Err())
+	}
+	dsList := jd.getDSList()
+	jd.dsListLock.RUnlock()
+
+	jobTables := make([]string, 0, len(dsList))
+	tables := make([]string, 0, 2*len(dsList))
+	for _, ds := range dsList {
+		jobTables = append(jobTables, ds.JobTable)
+		tables = append(tables, ds.JobTable, ds.JobStatusTable)
+	}
+	res := DatasetStats{Datasets: len(dsList)}
+	if err := jd.dbHandle.QueryRowContext(ctx, `SELECT
+		COALESCE(SUM(CASE WHEN relname = ANY($1) THEN GREATEST(reltuples, 0) ELSE 0 END), 0)::BIGINT,
+		COALESCE(SUM(pg_total_relation_size(oid)), 0)::BIGINT
+		FROM pg_class
+		WHERE relname = ANY($2) AND relkind = 'r' AND relnamespace = current_schema()::regnamespace`,
+		pq.Array(jobTables), pq.Array(tables),
+	).Scan(&res.Jobs, &res.SizeBytes); err != nil {
+		return DatasetStats{}, fmt.Errorf("querying dataset stats: %w", err)
+	}
+	for _, ds := range dsList {
+		var pending int64
+		if err := jd.dbHandle.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %[1]q j
+			WHERE NOT EXISTS (SELECT 1 FROM %[2]q s WHERE s.job_id = j.job_id AND s.job_state = ANY($1))`,
+			ds.JobTable, ds.JobStatusTable),
+			pq.Array(validTerminalStates),
+		).Scan(&pending); err != nil {
+			return DatasetStats{}, fmt.Errorf("counting pending jobs of %s: %w", ds.JobTable, err)
+		}
+		res.Pending += pending
+	}
+	return res, nil
+}
+
 func (jd *Handle) GetDistinctParameterValues(ctx context.Context, parameterName string) ([]string, error) {
 	if !jd.dsMigrationLock.RTryLockWithCtx(ctx) {
 		return nil, fmt.Errorf("could not acquire a migration read lock: %w", ctx.Err())
@@ -1964,11 +2110,19 @@ func (jd *Handle) GetDistinctParameterValues(ctx context.Context, parameterName
 }
 
 func (jd *Handle) doStoreJobsInTx(ctx context.Context, tx *Tx, ds dataSetT, jobList []*JobT) error {
+	payloadColumnType, err := jd.payloadColumnType(ctx, tx, ds)
+	if err != nil {
+		return err
+	}
 	store := func() error {
 		var stmt *sql.Stmt
 		var err error
 
-		stmt, err = tx.PrepareContext(ctx, pq.CopyIn(ds.JobTable, "uuid", "user_id", "custom_val", "parameters", "event_payload", "event_count", "workspace_id"))
+		columns := []string{"uuid", "user_id", "custom_val", "parameters", "event_payload", "event_count", "workspace_id"}
+		if payloadColumnType == byteaPayloadColumnType {
+			columns = append(columns, "event_payload_size")
+		}
+		stmt, err = tx.PrepareContext(ctx, pq.CopyIn(ds.JobTable, columns...))
 		if err != nil {
 			return err
 		}
@@ -1980,7 +2134,12 @@ func (jd *Handle) doStoreJobsInTx(ctx context.Context, tx *Tx, ds dataSetT, jobL
 				eventCount = job.EventCount
 			}
 
-			if _, err = stmt.ExecContext(ctx, job.UUID, job.UserID, job.CustomVal, string(job.Parameters), string(job.EventPayload), eventCount, job.WorkspaceId); err != nil {
+			values := []any{job.UUID, job.UserID, job.CustomVal, string(job.Parameters), string(job.EventPayload), eventCount, job.WorkspaceId}
+			if payloadColumnType == byteaPayloadColumnType {
+				values[4] = jd.compressPayload(job.EventPayload)
+				values = append(values, len(job.EventPayload))
+			}
+			if _, err = stmt.ExecContext(ctx, values...); err != nil {
 				return err
 			}
 		}
@@ -2000,7 +2159,7 @@ func (jd *Handle) doStoreJobsInTx(ctx context.Context, tx *Tx, ds dataSetT, jobL
 	if _, err := tx.ExecContext(ctx, savepointSql); err != nil {
 		return err
 	}
-	err := store()
+	err = store()
 
 	var e *pq.Error
 	if err != nil && errors.As(err, &e) {
@@ -2062,6 +2221,8 @@ func (jd *Handle) getJobsDS(ctx context.Context, ds dataSetT, lastDS bool, param
 
 	containsUnprocessed := lo.Contains(stateFilters, Unprocessed.State)
 	skipCacheResult := params.afterJobID != nil
+	// jobs of a state may be missing from the results of a workspace which reached its limit, though it has some
+	workspaceLimited := params.WorkspaceJobsLimit > 0 && workspaceID == ""
 	cacheTx := map[string]*cache.NoResultTx[ParameterFilterT]{}
 	if !skipCacheResult {
 		for _, state := range stateFilters {
@@ -2101,6 +2262,31 @@ func (jd *Handle) getJobsDS(ctx context.Context, ds dataSetT, lastDS bool, param
 		filterConditions = append(filterConditions, fmt.Sprintf("jobs.workspace_id = '%s'", workspaceID))
 	}
 
+	joinType := "LEFT"
+	joinTable := "v_last_" + ds.JobStatusTable
+
+	if !containsUnprocessed { // If we are not querying for unprocessed jobs, we can use an inner join
+		joinType = "INNER"
+	} else if slices.Equal(stateFilters, []string{Unprocessed.State}) {
+		// If we are querying only for unprocessed jobs, we should join with the status table instead of the view (performance reasons)
+		joinTable = ds.JobStatusTable
+	}
+
+	if workspaceLimited {
+		// only the first jobs of every workspace matching the rest of the conditions, accounting for the jobs picked up
+		// from the previous datasets so that the jobs of a workspace are picked up in order
+		workspaceJobs, err := json.Marshal(params.workspaceJobs)
+		if err != nil {
+			return JobsResult{}, false, fmt.Errorf("marshalling workspace jobs: %w", err)
+		}
+		filterConditions = append(filterConditions, fmt.Sprintf(`jobs.job_id IN (SELECT ranked.job_id FROM (
+				SELECT jobs.job_id, jobs.workspace_id, ROW_NUMBER() OVER (PARTITION BY jobs.workspace_id ORDER BY jobs.job_id) AS workspace_rank
+				FROM %[1]q AS jobs %[2]s JOIN %[3]q job_latest_state ON jobs.job_id=job_latest_state.job_id
+				WHERE %[4]s) ranked
+			WHERE ranked.workspace_rank + COALESCE((%[5]s::jsonb->>ranked.workspace_id)::int, 0) <= %[6]d)`,
+			ds.JobTable, joinType, joinTable, strings.Join(filterConditions, " AND "), pq.QuoteLiteral(string(workspaceJobs)), params.WorkspaceJobsLimit))
+	}
+
 	var filterQuery string
 	if len(filterConditions) > 0 {
 		filterQuery = "WHERE " + strings.Join(filterConditions, " AND ")
@@ -2111,23 +2297,18 @@ func (jd *Handle) getJobsDS(ctx context.Context, ds dataSetT, lastDS bool, param
 		limitQuery = fmt.Sprintf(" LIMIT %d ", params.JobsLimit)
 	}
 
-	joinType := "LEFT"
-	joinTable := "v_last_" + ds.JobStatusTable
-
-	if !containsUnprocessed { // If we are not querying for unprocessed jobs, we can use an inner join
-		joinType = "INNER"
-	} else if slices.Equal(stateFilters, []string{Unprocessed.State}) {
-		// If we are querying only for unprocessed jobs, we should join with the status table instead of the view (performance reasons)
-		joinTable = ds.JobStatusTable
+	payloadColumnType, err := jd.payloadColumnType(ctx, jd.dbHandle, ds)
+	if err != nil {
+		return JobsResult{}, false, err
 	}
 
 	var rows *sql.Rows
 	sqlStatement := fmt.Sprintf(`SELECT
 									jobs.job_id, jobs.uuid, jobs.user_id, jobs.parameters, jobs.custom_val, jobs.event_payload, jobs.event_count,
 									jobs.created_at, jobs.expire_at, jobs.workspace_id,
-									pg_column_size(jobs.event_payload) as payload_size,
+									%[6]s as payload_size,
 									sum(jobs.event_count) over (order by jobs.job_id asc) as running_event_counts,
-									sum(pg_column_size(jobs.event_payload)) over (order by jobs.job_id) as running_payload_size,
+									sum(%[6]s) over (order by jobs.job_id) as running_payload_size,
 									job_latest_state.job_state, job_latest_state.attempt,
 									job_latest_state.exec_time, job_latest_state.retry_time,
 									job_latest_state.error_code, job_latest_state.error_response, job_latest_state.parameters
@@ -2136,7 +2317,7 @@ func (jd *Handle) getJobsDS(ctx context.Context, ds dataSetT, lastDS bool, param
 									%[2]s JOIN %[3]q job_latest_state ON jobs.job_id=job_latest_state.job_id
 								    %[4]s
 									ORDER BY jobs.job_id %[5]s`,
-		ds.JobTable, joinType, joinTable, filterQuery, limitQuery)
+		ds.JobTable, joinType, joinTable, filterQuery, limitQuery, payloadSizeColumn(payloadColumnType, "jobs"))
 
 	var args []interface{}
 
@@ -2196,6 +2377,9 @@ func (jd *Handle) getJobsDS(ctx context.Context, ds dataSetT, lastDS bool, param
 		if err != nil {
 			return JobsResult{}, false, err
 		}
+		if job.EventPayload, err = decompressPayload(job.EventPayload); err != nil {
+			return JobsResult{}, false, fmt.Errorf("job %d: %w", job.JobID, err)
+		}
 		if jsState.Valid {
 			resultsetStates[jsState.String] = struct{}{}
 			job.LastJobStatus.JobState = jsState.String
@@ -2241,7 +2425,7 @@ func (jd *Handle) getJobsDS(ctx context.Context, ds dataSetT, lastDS bool, param
 			// we are committing the cache Tx only if
 			// (a) no jobs are returned by the query or
 			// (b) the state is not present in the resultset and limits have not been reached
-			if _, ok := resultsetStates[state]; len(jobList) == 0 || (!ok && !limitsReached) {
+			if _, ok := resultsetStates[state]; len(jobList) == 0 || (!ok && !limitsReached && !workspaceLimited) {
 				cacheTx.Commit()
 			}
 		}
@@ -3141,6 +3325,14 @@ func (jd *Handle) getJobs(ctx context.Context, params GetQueryParams, more MoreT
 		if limitByPayloadSize {
 			params.PayloadSizeLimit -= jobs.PayloadSize
 		}
+		if params.WorkspaceJobsLimit > 0 {
+			if params.workspaceJobs == nil {
+				params.workspaceJobs = make(map[string]int)
+			}
+			for _, job := range jobs.Jobs {
+				params.workspaceJobs[job.WorkspaceId]++
+			}
+		}
 	}
 
 	statTags := tags.getStatsTags(jd.tablePrefix)
@@ -3236,6 +3428,8 @@ func (jd *Handle) GetLastJob(ctx context.Context) *JobT {
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		jd.assertError(err)
 	}
+	job.EventPayload, err = decompressPayload(job.EventPayload)
+	jd.assertError(err)
 	return &job
 }
 
@@ -19,6 +19,7 @@ import (
 	"github.com/ory/dockertest/v3"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/rudderlabs/rudder-go-kit/config"
@@ -1177,6 +1178,59 @@ func TestFailExecuting(t *testing.T) {
 	require.Equal(t, 2, len(failed.Jobs))
 }
 
+func TestFailExecutingOfDestinations(t *testing.T) {
+	_ = startPostgres(t)
+	customVal := "CUSTOMVAL"
+	generateJobs := func(numOfJob int, destinationID string) []*JobT {
+		js := make([]*JobT, numOfJob)
+		for i := 0; i < numOfJob; i++ {
+			js[i] = &JobT{
+				Parameters:   []byte(fmt.Sprintf(`{"batch_id":1,"source_id":"sourceID","destination_id":%q}`, destinationID)),
+				EventPayload: []byte(`{"testKey":"testValue"}`),
+				UserID:       "a-292e-4e79-9880-f8009e0ae4a3",
+				UUID:         uuid.New(),
+				CustomVal:    customVal,
+				EventCount:   1,
+			}
+		}
+		return js
+	}
+
+	prefix := strings.ToLower(rsRand.String(5))
+	jobsDB := NewForReadWrite(prefix)
+	require.NoError(t, jobsDB.Start())
+	defer jobsDB.TearDown()
+	require.NoError(t, jobsDB.Store(context.Background(), append(generateJobs(2, "destination-1"), generateJobs(3, "destination-2")...)))
+	unprocessed, err := jobsDB.GetUnprocessed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100})
+	require.NoError(t, err)
+	require.Equal(t, 5, len(unprocessed.Jobs))
+
+	var statuses []*JobStatusT
+	for _, job := range unprocessed.Jobs {
+		statuses = append(statuses, &JobStatusT{
+			JobID:         job.JobID,
+			JobState:      Executing.State,
+			AttemptNum:    1,
+			ExecTime:      time.Now(),
+			RetryTime:     time.Now(),
+			ErrorCode:     "",
+			ErrorResponse: []byte(`{}`),
+			Parameters:    []byte(`{}`),
+			WorkspaceId:   defaultWorkspaceID,
+		})
+	}
+	require.NoError(t, jobsDB.UpdateJobStatus(context.Background(), statuses, []string{customVal}, []ParameterFilterT{}))
+
+	require.NoError(t, jobsDB.FailExecutingOfDestinations(context.Background(), []string{"destination-2"}))
+
+	failed, err := jobsDB.GetFailed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(failed.Jobs))
+	for _, job := range failed.Jobs {
+		require.Equal(t, "destination-2", gjson.GetBytes(job.Parameters, "destination_id").String())
+	}
+}
+
 func TestMaxAgeCleanup(t *testing.T) {
 	_ = startPostgres(t)
 	customVal := "CUSTOMVAL"
@@ -1408,6 +1462,155 @@ func TestGetActiveWorkspaces(t *testing.T) {
 	require.ElementsMatch(t, []string{"ws-1", "ws-2", "ws-3"}, activeWorkspaces)
 }
 
+func TestGetDatasetStats(t *testing.T) {
+	_ = startPostgres(t)
+	jobsDB := &Handle{config: config.New()}
+	err := jobsDB.Setup(ReadWrite, true, strings.ToLower(rsRand.String(5)))
+	require.NoError(t, err)
+	defer jobsDB.TearDown()
+
+	jobs := make([]*JobT, 10)
+	for i := range jobs {
+		jobs[i] = &JobT{
+			WorkspaceId:  "workspace",
+			Parameters:   []byte(`{"source_id":"sourceID"}`),
+			EventPayload: []byte(`{"testKey":"testValue"}`),
+			UserID:       "user",
+			UUID:         uuid.New(),
+			CustomVal:    "MOCKDS",
+			EventCount:   1,
+		}
+	}
+	require.NoError(t, jobsDB.Store(context.Background(), jobs))
+	_, err = jobsDB.dbHandle.Exec(fmt.Sprintf(`ANALYZE %q`, jobsDB.getDSList()[0].JobTable))
+	require.NoError(t, err)
+
+	datasetStats, err := jobsDB.GetDatasetStats(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, datasetStats.Datasets)
+	require.EqualValues(t, 10, datasetStats.Jobs)
+	require.EqualValues(t, 10, datasetStats.Pending)
+	require.Positive(t, datasetStats.SizeBytes)
+
+	res, err := jobsDB.GetUnprocessed(context.Background(), GetQueryParams{JobsLimit: 10})
+	require.NoError(t, err)
+	statuses := make([]*JobStatusT, 0, len(res.Jobs))
+	for i, job := range res.Jobs {
+		state := Succeeded.State
+		if i%2 == 0 {
+			state = Failed.State
+		}
+		statuses = append(statuses, &JobStatusT{
+			JobID:         job.JobID,
+			JobState:      state,
+			AttemptNum:    1,
+			ExecTime:      time.Now(),
+			RetryTime:     time.Now(),
+			ErrorResponse: []byte(`{}`),
+			Parameters:    []byte(`{}`),
+			WorkspaceId:   "workspace",
+		})
+	}
+	require.NoError(t, jobsDB.UpdateJobStatus(context.Background(), statuses, []string{"MOCKDS"}, []ParameterFilterT{}))
+
+	datasetStats, err = jobsDB.GetDatasetStats(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 10, datasetStats.Jobs, "processed jobs are still counted as jobs")
+	require.EqualValues(t, 5, datasetStats.Pending, "only failed jobs are still pending")
+}
+
+func TestWorkspaceJobsLimit(t *testing.T) {
+	_ = startPostgres(t)
+	triggerAddNewDS := make(chan time.Time)
+	jobsDB := &Handle{
+		TriggerAddNewDS: func() <-chan time.Time {
+			return triggerAddNewDS
+		},
+	}
+	prefix := strings.ToLower(rsRand.String(5))
+	require.NoError(t, jobsDB.Setup(ReadWrite, true, prefix))
+	defer jobsDB.TearDown()
+
+	store := func(workspaceJobs ...string) {
+		t.Helper()
+		jobs := make([]*JobT, len(workspaceJobs))
+		for i, workspaceJob := range workspaceJobs {
+			jobs[i] = &JobT{
+				WorkspaceId:  strings.Split(workspaceJob, "-")[0],
+				Parameters:   []byte(`{}`),
+				EventPayload: []byte(`{}`),
+				UserID:       workspaceJob,
+				UUID:         uuid.New(),
+				CustomVal:    "MOCKDS",
+				EventCount:   1,
+			}
+		}
+		require.NoError(t, jobsDB.Store(context.Background(), jobs))
+	}
+	store("a-1", "a-2", "a-3", "b-1")
+	require.NoError(t, jobsDB.WithTx(func(tx *Tx) error {
+		return jobsDB.createDSInTx(tx, newDataSet(prefix, "2"))
+	}))
+	jobsDB.dsListLock.WithLock(func(l lock.LockToken) {
+		_, err := jobsDB.doRefreshDSList(l)
+		require.NoError(t, err)
+	})
+	store("a-4", "b-2", "b-3", "c-1")
+
+	pickup := func(params GetQueryParams) []string {
+		t.Helper()
+		res, err := jobsDB.GetUnprocessed(context.Background(), params)
+		require.NoError(t, err)
+		return lo.Map(res.Jobs, func(job *JobT, _ int) string { return job.UserID })
+	}
+	require.Equal(t, []string{"a-1", "a-2", "b-1", "b-2", "c-1"}, pickup(GetQueryParams{JobsLimit: 100, WorkspaceJobsLimit: 2}),
+		"the jobs of a workspace should be limited across datasets, in order")
+	require.Equal(t, []string{"a-1", "a-2", "b-1"}, pickup(GetQueryParams{JobsLimit: 3, WorkspaceJobsLimit: 2}))
+	require.Equal(t, []string{"a-1", "a-2", "a-3", "a-4"}, pickup(GetQueryParams{JobsLimit: 100, WorkspaceJobsLimit: 2, WorkspaceID: "a"}),
+		"filtering by workspace should disable the limit")
+	require.Len(t, pickup(GetQueryParams{JobsLimit: 100}), 8)
+}
+
+func TestPartitionKeyIndexes(t *testing.T) {
+	_ = startPostgres(t)
+	jobsDB := &Handle{config: config.New()}
+	err := jobsDB.Setup(ReadWrite, true, strings.ToLower(rsRand.String(5)))
+	require.NoError(t, err)
+	defer jobsDB.TearDown()
+
+	ds := jobsDB.getDSList()[0]
+	requireIndexes := func(t *testing.T) {
+		t.Helper()
+		rows, err := jobsDB.dbHandle.Query(`SELECT indexname, indexdef FROM pg_indexes WHERE tablename = $1`, ds.JobTable)
+		require.NoError(t, err)
+		defer func() { _ = rows.Close() }()
+		indexes := map[string]string{}
+		for rows.Next() {
+			var name, def string
+			require.NoError(t, rows.Scan(&name, &def))
+			indexes[name] = def
+		}
+		require.NoError(t, rows.Err())
+
+		for _, suffix := range []string{"ws", "source_id", "destination_id"} {
+			def, ok := indexes["idx_"+ds.JobTable+"_"+suffix]
+			require.True(t, ok, "index for %s should exist", suffix)
+			require.True(t, strings.HasSuffix(def, ", job_id)"), "index for %s should be ordered by job id within the partition: %s", suffix, def)
+		}
+		require.Len(t, indexes, 5, "no rebuilt indexes should be left behind")
+	}
+	requireIndexes(t)
+
+	t.Run("indexes of existing datasets are rebuilt", func(t *testing.T) {
+		_, err := jobsDB.dbHandle.Exec(fmt.Sprintf(`DROP INDEX %[1]q; CREATE INDEX %[1]q ON %[2]q (workspace_id)`, "idx_"+ds.JobTable+"_ws", ds.JobTable))
+		require.NoError(t, err)
+		_, err = jobsDB.dbHandle.Exec(fmt.Sprintf(`DROP INDEX %[1]q; CREATE INDEX %[1]q ON %[2]q USING BTREE ((parameters->>'source_id'))`, "idx_"+ds.JobTable+"_source_id", ds.JobTable))
+		require.NoError(t, err)
+		require.NoError(t, jobsDB.reindexPartitionIndexes(context.Background()))
+		requireIndexes(t)
+	})
+}
+
 func TestGetDistinctParameterValues(t *testing.T) {
 	_ = startPostgres(t)
 	c := config.New()
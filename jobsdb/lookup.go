@@ -0,0 +1,219 @@
+package jobsdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/samber/lo"
+	"github.com/tidwall/gjson"
+
+	. "github.com/rudderlabs/rudder-server/utils/tx" //nolint:staticcheck
+)
+
+// LookupParams filters the jobs returned by [Handle.Lookup]. Jobs need to match either any of the message ids, the user id
+// or any of the correlation ids.
+type LookupParams struct {
+	// MessageIDs matches jobs by their message_id parameter, or by the messageId of the events in their payload,
+	// be it a gateway batch or an array of events
+	MessageIDs []string
+	// UserID matches jobs by the userId of the events in their payload, be it a gateway batch or an array of events
+	UserID string
+	// CorrelationIDs matches jobs by their correlation_id parameter, i.e. the gateway job of the event they derive from
+	CorrelationIDs []string
+	// From and To limit the creation time of the jobs, if not zero
+	From, To time.Time
+	// Limit is the maximum number of jobs returned
+	Limit int
+}
+
+// TracedJob is a job returned by [Handle.Lookup], along with all of its statuses in the order they got recorded
+type TracedJob struct {
+	JobT
+	Statuses []JobStatusT `json:"Statuses"`
+}
+
+// Lookup returns the jobs matching the params, in the order they got stored, for tracing specific events through the system.
+// It scans all datasets, thus it is meant for investigations rather than for regular processing.
+// Payloads of datasets storing them as bytes cannot be queried, so jobs of such datasets get matched after their payloads
+// get decompressed, scanning all jobs created within the given time range.
+func (jd *Handle) Lookup(ctx context.Context, params LookupParams) ([]*TracedJob, error) {
+	if len(params.MessageIDs) == 0 && params.UserID == "" && len(params.CorrelationIDs) == 0 {
+		return nil, fmt.Errorf("either message ids, a user id or correlation ids are required")
+	}
+	if params.Limit <= 0 {
+		return nil, nil
+	}
+	if !jd.dsMigrationLock.RTryLockWithCtx(ctx) {
+		return nil, fmt.Errorf("could not acquire a migration read lock: %w", ctx.Err())
+	}
+	defer jd.dsMigrationLock.RUnlock()
+	if !jd.dsListLock.RTryLockWithCtx(ctx) {
+		return nil, fmt.Errorf("could not acquire a dslist read lock: %w", ctx.Err())
+	}
+	dsList := jd.getDSList()
+	jd.dsListLock.RUnlock()
+
+	var res []*TracedJob
+	err := jd.WithTx(func(tx *Tx) error {
+		for _, ds := range dsList {
+			jobs, err := jd.lookupDSInTx(ctx, tx, ds, params, params.Limit-len(res))
+			if err != nil {
+				return err
+			}
+			res = append(res, jobs...)
+			if len(res) >= params.Limit {
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (jd *Handle) lookupDSInTx(ctx context.Context, tx *Tx, ds dataSetT, params LookupParams, limit int) ([]*TracedJob, error) {
+	payloadColumnType, err := jd.payloadColumnType(ctx, tx, ds)
+	if err != nil {
+		return nil, err
+	}
+
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	// eventsContain matches payloads of gateway batches or arrays of events containing an event with the given field values
+	eventsContain := func(field string, values ...string) string {
+		elements := make([]string, 0, len(values))
+		for _, value := range values {
+			element, _ := json.Marshal([]map[string]string{{field: value}})
+			elements = append(elements, string(element))
+		}
+		elementsArg := arg(pq.Array(elements))
+		return fmt.Sprintf(`j.event_payload->'batch' @> ANY(%[1]s::jsonb[]) OR j.event_payload @> ANY(%[1]s::jsonb[])`, elementsArg)
+	}
+	// payloads stored as bytes can only be matched after getting decompressed
+	matchPayloads := payloadColumnType == byteaPayloadColumnType && (len(params.MessageIDs) > 0 || params.UserID != "")
+	conditions := []string{"TRUE"}
+	if !matchPayloads {
+		var matches []string
+		if len(params.MessageIDs) > 0 {
+			matches = append(matches, fmt.Sprintf(`j.parameters->>'message_id' = ANY(%s)`, arg(pq.Array(params.MessageIDs))))
+			matches = append(matches, eventsContain("messageId", params.MessageIDs...))
+		}
+		if params.UserID != "" {
+			matches = append(matches, eventsContain("userId", params.UserID))
+		}
+		if len(params.CorrelationIDs) > 0 {
+			matches = append(matches, fmt.Sprintf(`j.parameters->>'correlation_id' = ANY(%s)`, arg(pq.Array(params.CorrelationIDs))))
+		}
+		conditions = []string{"(" + strings.Join(matches, " OR ") + ")"}
+	}
+	if !params.From.IsZero() {
+		conditions = append(conditions, "j.created_at >= "+arg(params.From))
+	}
+	if !params.To.IsZero() {
+		conditions = append(conditions, "j.created_at < "+arg(params.To))
+	}
+
+	var limitQuery string
+	if !matchPayloads {
+		limitQuery = "LIMIT " + arg(limit)
+	}
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(
+		`SELECT j.job_id, j.uuid, j.user_id, j.parameters, j.custom_val, j.event_payload, j.event_count, j.created_at, j.expire_at, j.workspace_id
+		FROM %[1]q j WHERE %[2]s ORDER BY j.job_id %[3]s`,
+		ds.JobTable, strings.Join(conditions, " AND "), limitQuery,
+	), args...)
+	if err != nil {
+		return nil, fmt.Errorf("looking up jobs in %s: %w", ds.JobTable, err)
+	}
+	defer func() { _ = rows.Close() }()
+	var jobs []*TracedJob
+	jobsByID := make(map[int64]*TracedJob)
+	for rows.Next() {
+		var job TracedJob
+		if err := rows.Scan(&job.JobID, &job.UUID, &job.UserID, &job.Parameters, &job.CustomVal, &job.EventPayload,
+			&job.EventCount, &job.CreatedAt, &job.ExpireAt, &job.WorkspaceId); err != nil {
+			return nil, fmt.Errorf("scanning job of %s: %w", ds.JobTable, err)
+		}
+		if job.EventPayload, err = decompressPayload(job.EventPayload); err != nil {
+			return nil, fmt.Errorf("job %d of %s: %w", job.JobID, ds.JobTable, err)
+		}
+		if matchPayloads && !lookupMatches(&job.JobT, params) {
+			continue
+		}
+		job.PayloadSize = int64(len(job.EventPayload))
+		jobs = append(jobs, &job)
+		jobsByID[job.JobID] = &job
+		if len(jobs) >= limit {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("looking up jobs in %s: %w", ds.JobTable, err)
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	_ = rows.Close()
+
+	jobIDs := make([]int64, 0, len(jobs))
+	for _, job := range jobs {
+		jobIDs = append(jobIDs, job.JobID)
+	}
+	statusRows, err := tx.QueryContext(ctx, fmt.Sprintf(
+		`SELECT job_id, job_state, attempt, exec_time, retry_time, COALESCE(error_code, ''), COALESCE(error_response, '{}'), COALESCE(parameters, '{}')
+		FROM %q WHERE job_id = ANY($1) ORDER BY job_id, id`, ds.JobStatusTable,
+	), pq.Array(jobIDs))
+	if err != nil {
+		return nil, fmt.Errorf("getting statuses of jobs in %s: %w", ds.JobStatusTable, err)
+	}
+	defer func() { _ = statusRows.Close() }()
+	for statusRows.Next() {
+		var status JobStatusT
+		if err := statusRows.Scan(&status.JobID, &status.JobState, &status.AttemptNum, &status.ExecTime, &status.RetryTime,
+			&status.ErrorCode, &status.ErrorResponse, &status.Parameters); err != nil {
+			return nil, fmt.Errorf("scanning job status of %s: %w", ds.JobStatusTable, err)
+		}
+		job := jobsByID[status.JobID]
+		status.WorkspaceId = job.WorkspaceId
+		job.Statuses = append(job.Statuses, status)
+		job.LastJobStatus = status
+	}
+	if err := statusRows.Err(); err != nil {
+		return nil, fmt.Errorf("getting statuses of jobs in %s: %w", ds.JobStatusTable, err)
+	}
+	return jobs, nil
+}
+
+// lookupMatches reports whether the job matches the params, for jobs whose payloads cannot be queried
+func lookupMatches(job *JobT, params LookupParams) bool {
+	if lo.Contains(params.MessageIDs, gjson.GetBytes(job.Parameters, "message_id").String()) ||
+		lo.Contains(params.CorrelationIDs, gjson.GetBytes(job.Parameters, "correlation_id").String()) {
+		return true
+	}
+	var matches bool
+	for _, path := range []string{"batch", "@this"} { // a gateway batch or an array of events
+		events := gjson.GetBytes(job.EventPayload, path)
+		if !events.IsArray() {
+			continue
+		}
+		events.ForEach(func(_, event gjson.Result) bool {
+			matches = lo.Contains(params.MessageIDs, event.Get("messageId").String()) ||
+				(params.UserID != "" && event.Get("userId").String() == params.UserID)
+			return !matches
+		})
+		if matches {
+			return true
+		}
+	}
+	return false
+}
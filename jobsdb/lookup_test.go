@@ -0,0 +1,123 @@
+package jobsdb
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/rand"
+)
+
+func TestLookup(t *testing.T) {
+	config.Reset()
+	c := config.New()
+	c.Set("JobsDB.maxDSSize", 1)
+	c.Set("JobsDB.payloadCompression.minSize", 0)
+
+	_ = startPostgres(t)
+
+	triggerAddNewDS := make(chan time.Time)
+	triggerMigrateDS := make(chan time.Time)
+	jobDB := Handle{
+		TriggerAddNewDS: func() <-chan time.Time {
+			return triggerAddNewDS
+		},
+		TriggerMigrateDS: func() <-chan time.Time {
+			return triggerMigrateDS
+		},
+		config: c,
+	}
+	tablePrefix := strings.ToLower(rand.String(5))
+	require.NoError(t, jobDB.Setup(ReadWrite, true, tablePrefix))
+	defer jobDB.TearDown()
+
+	job := func(parameters, payload string) *JobT {
+		return &JobT{
+			UUID:         uuid.New(),
+			UserID:       "rudder-id",
+			CustomVal:    "WEBHOOK",
+			EventCount:   1,
+			Parameters:   []byte(parameters),
+			EventPayload: []byte(payload),
+			WorkspaceId:  defaultWorkspaceID,
+		}
+	}
+	start := time.Now()
+	require.NoError(t, jobDB.Store(context.Background(), []*JobT{
+		job(`{"source_id":"source"}`, `{"batch":[{"messageId":"message-1","userId":"user-1"},{"messageId":"message-2","userId":"user-2"}]}`),
+		job(`{"source_id":"source"}`, `[{"messageId":"message-3","userId":"user-1"}]`),
+		job(`{"source_id":"source","message_id":"message-2","correlation_id":"correlation-1"}`, `{"body":{"JSON":{"userId":"user-2"}}}`),
+	}))
+	require.NoError(t, jobDB.UpdateJobStatus(context.Background(), []*JobStatusT{
+		{JobID: 3, JobState: Failed.State, AttemptNum: 1, ExecTime: time.Now(), RetryTime: time.Now(), ErrorCode: "500", ErrorResponse: []byte(`{"reason":"unavailable"}`), Parameters: []byte(`{}`), WorkspaceId: defaultWorkspaceID},
+		{JobID: 3, JobState: Succeeded.State, AttemptNum: 2, ExecTime: time.Now(), RetryTime: time.Now(), ErrorCode: "200", ErrorResponse: []byte(`{}`), Parameters: []byte(`{}`), WorkspaceId: defaultWorkspaceID},
+	}, []string{"WEBHOOK"}, nil))
+
+	c.Set("JobsDB.payloadCompression.enabled", true)
+	triggerAddNewDS <- time.Now() // trigger addNewDSLoop to run
+	triggerAddNewDS <- time.Now() // Second time, waits for the first loop to finish
+	require.EqualValues(t, 2, jobDB.GetMaxDSIndex())
+	require.NoError(t, jobDB.Store(context.Background(), []*JobT{
+		job(`{"source_id":"source"}`, `{"batch":[{"messageId":"message-4","userId":"user-1"}]}`),
+		job(`{"source_id":"source","message_id":"message-1","correlation_id":"correlation-2"}`, `{"body":{"JSON":{"userId":"user-1"}}}`),
+	}))
+
+	lookup := func(t *testing.T, params LookupParams) []int64 {
+		if params.Limit == 0 {
+			params.Limit = 100
+		}
+		jobs, err := jobDB.Lookup(context.Background(), params)
+		require.NoError(t, err)
+		jobIDs := []int64{}
+		for _, job := range jobs {
+			jobIDs = append(jobIDs, job.JobID)
+		}
+		return jobIDs
+	}
+
+	t.Run("by message id", func(t *testing.T) {
+		require.Equal(t, []int64{1, 5}, lookup(t, LookupParams{MessageIDs: []string{"message-1"}}))
+		require.Equal(t, []int64{1, 2, 3}, lookup(t, LookupParams{MessageIDs: []string{"message-2", "message-3"}}))
+		require.Equal(t, []int64{4}, lookup(t, LookupParams{MessageIDs: []string{"message-4"}}), "payloads stored as bytes should be matched after being decompressed")
+	})
+
+	t.Run("by user id", func(t *testing.T) {
+		require.Equal(t, []int64{1, 2, 4}, lookup(t, LookupParams{UserID: "user-1"}))
+		require.Equal(t, []int64{1}, lookup(t, LookupParams{UserID: "user-2"}), "only events in payloads should be matched")
+	})
+
+	t.Run("by correlation id", func(t *testing.T) {
+		require.Equal(t, []int64{3, 5}, lookup(t, LookupParams{CorrelationIDs: []string{"correlation-1", "correlation-2"}}))
+		require.Equal(t, []int64{}, lookup(t, LookupParams{CorrelationIDs: []string{"correlation-3"}}))
+	})
+
+	t.Run("time range and limit", func(t *testing.T) {
+		require.Equal(t, []int64{1, 5}, lookup(t, LookupParams{MessageIDs: []string{"message-1"}, From: start.Add(-time.Minute)}))
+		require.Equal(t, []int64{}, lookup(t, LookupParams{MessageIDs: []string{"message-1"}, From: time.Now().Add(time.Minute)}))
+		require.Equal(t, []int64{}, lookup(t, LookupParams{MessageIDs: []string{"message-1"}, To: start.Add(-time.Minute)}))
+		require.Equal(t, []int64{1}, lookup(t, LookupParams{MessageIDs: []string{"message-1"}, Limit: 1}))
+		require.Equal(t, []int64{1, 2}, lookup(t, LookupParams{UserID: "user-1", Limit: 2}))
+		require.Equal(t, []int64{}, lookup(t, LookupParams{MessageIDs: []string{"message-4"}, To: start.Add(-time.Minute)}))
+	})
+
+	t.Run("statuses and payloads", func(t *testing.T) {
+		jobs, err := jobDB.Lookup(context.Background(), LookupParams{MessageIDs: []string{"message-1", "message-2"}, Limit: 100})
+		require.NoError(t, err)
+		require.Len(t, jobs, 3)
+		require.Empty(t, jobs[0].Statuses)
+		require.Len(t, jobs[1].Statuses, 2)
+		require.Equal(t, Failed.State, jobs[1].Statuses[0].JobState)
+		require.JSONEq(t, `{"reason":"unavailable"}`, string(jobs[1].Statuses[0].ErrorResponse))
+		require.Equal(t, Succeeded.State, jobs[1].Statuses[1].JobState)
+		require.Equal(t, Succeeded.State, jobs[1].LastJobStatus.JobState)
+		require.JSONEq(t, `{"body":{"JSON":{"userId":"user-1"}}}`, string(jobs[2].EventPayload), "compressed payloads should be decompressed")
+	})
+
+	_, err := jobDB.Lookup(context.Background(), LookupParams{Limit: 100})
+	require.Error(t, err, "either message ids, a user id or correlation ids should be required")
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/samber/lo"
 
 	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
 	"github.com/rudderlabs/rudder-server/jobsdb/internal/dsindex"
 	"github.com/rudderlabs/rudder-server/jobsdb/internal/lock"
 	"github.com/rudderlabs/rudder-server/utils/crash"
@@ -76,6 +77,22 @@ func (jd *Handle) doMigrateDS(ctx context.Context) error {
 	if len(migrateFrom) == 0 {
 		return nil
 	}
+	// jobs which are still retained get migrated along with pending ones, using the same policy for all datasets
+	retention := jd.retentionPolicy(time.Now())
+
+	// archive the jobs that won't be migrated before taking the migration lock, so that a failed archival postpones
+	// the migration instead of dropping jobs which haven't been archived
+	dropped := fmt.Sprintf(`s.job_state = ANY('{%s}') AND NOT %s`, strings.Join(validTerminalStates, ","), retention.retained("s"))
+	archived, err := jd.archiveDSList(ctx, migrateFrom, dropped, "")
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		jd.logger.Errorn("Archiving datasets, postponing their migration", obskit.Error(err))
+		stats.Default.NewTaggedStat("jobsdb_archival_failures", stats.CountType, stats.Tags{"customVal": jd.tablePrefix}).Increment()
+		return nil
+	}
+
 	var l lock.LockToken
 	var lockChan chan<- lock.LockToken
 
@@ -96,6 +113,12 @@ func (jd *Handle) doMigrateDS(ctx context.Context) error {
 			if len(migrateFrom) == 0 {
 				return nil
 			}
+			// jobs which reached a terminal state after their datasets got archived are migrated along with pending ones
+			unarchivedJobsCount, err := jd.countUnarchivedJobsInTx(ctx, tx, migrateFrom, dropped, archived)
+			if err != nil {
+				return fmt.Errorf("counting unarchived jobs: %w", err)
+			}
+			pendingJobsCount += unarchivedJobsCount
 
 			if pendingJobsCount > 0 { // migrate incomplete jobs
 				var destination dataSetT
@@ -124,7 +147,11 @@ func (jd *Handle) doMigrateDS(ctx context.Context) error {
 					return fmt.Errorf("failed to mark journal start: %w", err)
 				}
 
-				if err = jd.createDSTablesInTx(ctx, tx, destination); err != nil {
+				payloadColumnType, err := jd.migrationPayloadColumnType(ctx, tx, migrateFrom)
+				if err != nil {
+					return fmt.Errorf("getting payload column type: %w", err)
+				}
+				if err = jd.createDSTablesInTx(ctx, tx, destination, payloadColumnType); err != nil {
 					return fmt.Errorf("failed to create dataset tables: %w", err)
 				}
 
@@ -132,7 +159,7 @@ func (jd *Handle) doMigrateDS(ctx context.Context) error {
 				var noJobsMigrated int
 				for _, source := range migrateFrom {
 					jd.logger.Infof("[[ migrateDSLoop ]]: Migrate: %v to: %v", source, destination)
-					noJobsMigrated, err = jd.migrateJobsInTx(ctx, tx, source, destination)
+					noJobsMigrated, err = jd.migrateJobsInTx(ctx, tx, source, destination, retention, archived)
 					if err != nil {
 						return fmt.Errorf("failed to migrate jobs: %w", err)
 					}
@@ -425,24 +452,59 @@ func (jd *Handle) getMigrationList(dsList []dataSetT) (migrateFrom []dataSetT, p
 	return
 }
 
-func (jd *Handle) migrateJobsInTx(ctx context.Context, tx *Tx, srcDS, destDS dataSetT) (int, error) {
+// countUnarchivedJobsInTx counts the jobs of the datasets which are about to be dropped, i.e. match the condition on
+// their last status "s", but got there after their datasets got archived
+func (jd *Handle) countUnarchivedJobsInTx(ctx context.Context, tx *Tx, dsList []dataSetT, condition string, archived archivedStatuses) (int, error) {
+	if archived == nil {
+		return 0, nil
+	}
+	var total int
+	for _, ds := range dsList {
+		var count int
+		if err := tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM "v_last_%s" s WHERE %s AND %s`,
+			ds.JobStatusTable, condition, archived.unarchived(ds, "s")),
+		).Scan(&count); err != nil {
+			return 0, fmt.Errorf("counting unarchived jobs of %s: %w", ds.JobTable, err)
+		}
+		total += count
+	}
+	return total, nil
+}
+
+func (jd *Handle) migrateJobsInTx(ctx context.Context, tx *Tx, srcDS, destDS dataSetT, retention retentionPolicy, archived archivedStatuses) (int, error) {
 	defer jd.getTimerStat(
 		"migration_jobs",
 		&statTags{CustomValFilters: []string{jd.tablePrefix}},
 	).RecordDuration()()
 
+	srcPayloadColumnType, err := jd.payloadColumnType(ctx, tx, srcDS)
+	if err != nil {
+		return 0, err
+	}
+	destPayloadColumnType, err := jd.payloadColumnType(ctx, tx, destDS)
+	if err != nil {
+		return 0, err
+	}
+	payloadColumns, payload := "event_payload", "j.event_payload"
+	if destPayloadColumnType == byteaPayloadColumnType {
+		payloadColumns, payload = "event_payload, event_payload_size", "j.event_payload, j.event_payload_size"
+		if srcPayloadColumnType != destPayloadColumnType { // jsonb payloads migrated to a dataset storing payloads as bytes
+			payload = "convert_to(j.event_payload::text, 'UTF8'), octet_length(j.event_payload::text)"
+		}
+	}
+
 	compactDSQuery := fmt.Sprintf(
 		`with last_status as (select * from "v_last_%[1]s"),
 		inserted_jobs as
 		(
-			insert into %[3]q (job_id,   workspace_id,   uuid,   user_id,   custom_val,   parameters,   event_payload,   event_count,   created_at,   expire_at)
-			           (select j.job_id, j.workspace_id, j.uuid, j.user_id, j.custom_val, j.parameters, j.event_payload, j.event_count, j.created_at, j.expire_at from %[2]q j left join last_status js on js.job_id = j.job_id
-				where js.job_id is null or js.job_state = ANY('{%[5]s}') order by j.job_id) returning job_id
+			insert into %[3]q (job_id,   workspace_id,   uuid,   user_id,   custom_val,   parameters,   %[9]s,   event_count,   created_at,   expire_at)
+			           (select j.job_id, j.workspace_id, j.uuid, j.user_id, j.custom_val, j.parameters, %[6]s, j.event_count, j.created_at, j.expire_at from %[2]q j left join last_status js on js.job_id = j.job_id
+				where js.job_id is null or js.job_state = ANY('{%[5]s}') or %[7]s order by j.job_id) returning job_id
 		),
 		insertedStatuses as
 		(
 			insert into %[4]q (job_id, job_state, attempt, exec_time, retry_time, error_code, error_response, parameters)
-			           (select job_id, job_state, attempt, exec_time, retry_time, error_code, error_response, parameters from last_status where job_state = ANY('{%[5]s}'))
+			           (select job_id, job_state, attempt, exec_time, retry_time, error_code, error_response, parameters from last_status ls where job_state = ANY('{%[5]s}') or %[8]s)
 		)
 		select count(*) from inserted_jobs;`,
 		srcDS.JobStatusTable,
@@ -450,6 +512,10 @@ func (jd *Handle) migrateJobsInTx(ctx context.Context, tx *Tx, srcDS, destDS dat
 		destDS.JobTable,
 		destDS.JobStatusTable,
 		strings.Join(validNonTerminalStates, ","),
+		payload,
+		retention.retained("js")+" or "+archived.unarchived(srcDS, "js"),
+		retention.retained("ls")+" or "+archived.unarchived(srcDS, "ls"),
+		payloadColumns,
 	)
 
 	var numJobsMigrated int64
@@ -531,11 +597,12 @@ func (jd *Handle) checkIfMigrateDS(ds dataSetT) (
 		return false, false, 0, fmt.Errorf("error getting count of jobs in %s: %w", ds.JobTable, err)
 	}
 
-	// Jobs which have either succeeded or expired
+	// Jobs which have either succeeded or expired, and are no longer retained
+	retention := jd.retentionPolicy(time.Now())
 	sqlStatement = fmt.Sprintf(`SELECT COUNT(DISTINCT(job_id))
-                                      from %q
-                                      WHERE job_state IN ('%s')`,
-		ds.JobStatusTable, strings.Join(validTerminalStates, "', '"))
+                                      from %q s
+                                      WHERE job_state IN ('%s') AND NOT %s`,
+		ds.JobStatusTable, strings.Join(validTerminalStates, "', '"), retention.retained("s"))
 	if err = jd.dbHandle.QueryRow(sqlStatement).Scan(&delCount); err != nil {
 		return false, false, 0, fmt.Errorf("error getting count of jobs in %s: %w", ds.JobStatusTable, err)
 	}
@@ -558,9 +625,9 @@ func (jd *Handle) checkIfMigrateDS(ds dataSetT) (
 		var terminalJobsExist bool
 		sqlStatement = fmt.Sprintf(`SELECT EXISTS (
 									SELECT id
-										FROM %q
-										WHERE job_state = ANY($1) and exec_time < $2)`,
-			ds.JobStatusTable)
+										FROM %q s
+										WHERE job_state = ANY($1) and exec_time < $2 and NOT %s)`,
+			ds.JobStatusTable, retention.retained("s"))
 		if err = jd.dbHandle.QueryRow(sqlStatement, pq.Array(validTerminalStates), time.Now().Add(-1*jd.conf.maxDSRetentionPeriod.Load())).Scan(&terminalJobsExist); err != nil {
 			return false, false, 0, fmt.Errorf("checking terminalJobsExist %s: %w", ds.JobStatusTable, err)
 		}
@@ -0,0 +1,116 @@
+package jobsdb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/utils/crash"
+)
+
+// partitionIndex is an index of a key jobs are partitioned by during pickup (workspace, source or destination,
+// depending on the isolation mode). It includes the job id, so that the jobs of a partition are read in order without
+// going through the jobs of other partitions, i.e. a partition with a large backlog doesn't slow down pickup queries for the rest.
+type partitionIndex struct {
+	suffix     string
+	definition string
+}
+
+// name returns the name of the index in the dataset
+func (index partitionIndex) name(ds dataSetT) string {
+	return "idx_" + ds.JobTable + "_" + index.suffix
+}
+
+func partitionIndexes() []partitionIndex {
+	indexes := []partitionIndex{{suffix: "ws", definition: "(workspace_id, job_id)"}}
+	for _, param := range cacheParameterFilters {
+		indexes = append(indexes, partitionIndex{suffix: param, definition: fmt.Sprintf("USING BTREE ((parameters->>'%s'), job_id)", param)})
+	}
+	return indexes
+}
+
+// startReindexLoop rebuilds the partition indexes of datasets created before they included the job id, until all of
+// them get rebuilt. Indexes are rebuilt concurrently, one at a time, so that neither reads nor writes get blocked meanwhile.
+func (jd *Handle) startReindexLoop(ctx context.Context) {
+	jd.backgroundGroup.Go(crash.Wrapper(func() error {
+		for {
+			err := jd.reindexPartitionIndexes(ctx)
+			if err == nil || ctx.Err() != nil {
+				return nil
+			}
+			jd.logger.Warnn("Rebuilding partition indexes, will retry", obskit.Error(err))
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(jd.conf.reindex.retryInterval.Load()):
+			}
+		}
+	}))
+}
+
+func (jd *Handle) reindexPartitionIndexes(ctx context.Context) error {
+	if !jd.dsListLock.RTryLockWithCtx(ctx) {
+		return fmt.Errorf("could not acquire a dslist read lock: %w", ctx.Err())
+	}
+	dsList := jd.getDSList()
+	jd.dsListLock.RUnlock()
+
+	// session level settings need a dedicated connection
+	conn, err := jd.dbHandle.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("getting connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	// rebuilding an index never queues up traffic behind it while waiting for a lock
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(`SET lock_timeout = %d`, jd.conf.reindex.lockTimeout.Load().Milliseconds())); err != nil {
+		return fmt.Errorf("setting lock timeout: %w", err)
+	}
+	for _, ds := range dsList {
+		for _, index := range partitionIndexes() {
+			if err := jd.reindex(ctx, conn, ds, index); err != nil {
+				if !jd.dsExists(ctx, ds) { // dropped by a migration meanwhile
+					break
+				}
+				return fmt.Errorf("rebuilding %s: %w", index.name(ds), err)
+			}
+		}
+	}
+	return nil
+}
+
+// reindex rebuilds the partition index of the dataset, unless it includes the job id already. The index is replaced by
+// one built concurrently, thus a failed attempt leaves behind at most an invalid index, which gets dropped by the next one.
+func (jd *Handle) reindex(ctx context.Context, conn *sql.Conn, ds dataSetT, index partitionIndex) error {
+	name := index.name(ds)
+	var definition string
+	err := conn.QueryRowContext(ctx, `SELECT indexdef FROM pg_indexes WHERE schemaname = current_schema() AND indexname = $1`, name).Scan(&definition)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("getting index definition: %w", err)
+	}
+	if strings.HasSuffix(definition, ", job_id)") {
+		return nil
+	}
+	start := time.Now()
+	rebuilt := name + "_rebuilt"
+	for _, statement := range []string{
+		fmt.Sprintf(`DROP INDEX CONCURRENTLY IF EXISTS %q`, rebuilt),
+		fmt.Sprintf(`CREATE INDEX CONCURRENTLY %q ON %q %s`, rebuilt, ds.JobTable, index.definition),
+		fmt.Sprintf(`DROP INDEX CONCURRENTLY IF EXISTS %q`, name),
+		fmt.Sprintf(`ALTER INDEX %q RENAME TO %q`, rebuilt, name),
+	} {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("executing %q: %w", statement, err)
+		}
+	}
+	jd.logger.Infon("Rebuilt partition index",
+		logger.NewStringField("index", name),
+		logger.NewDurationField("duration", time.Since(start)),
+	)
+	return nil
+}
@@ -0,0 +1,176 @@
+package jobsdb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/utils/crash"
+	. "github.com/rudderlabs/rudder-server/utils/tx" //nolint:staticcheck
+)
+
+// retentionPolicy holds, for every terminal job state with a retention period, the time after which jobs which reached
+// the state are still retained. Retained jobs are migrated along with pending ones, instead of getting dropped along
+// with their datasets, until the retention janitor drops them.
+type retentionPolicy map[string]time.Time
+
+// retentionPolicy returns the retention policy of the jobsdb at the given time
+func (jd *Handle) retentionPolicy(now time.Time) retentionPolicy {
+	policy := make(retentionPolicy)
+	for state, retention := range jd.conf.retention.periods {
+		if retention := retention.Load(); retention > 0 {
+			policy[state] = now.Add(-retention)
+		}
+	}
+	return policy
+}
+
+// retained returns the sql condition matching the job statuses of the given table alias which are still retained
+func (p retentionPolicy) retained(alias string) string {
+	return p.condition(alias, ">")
+}
+
+// expired returns the sql condition matching the job statuses of the given table alias whose retention has elapsed
+func (p retentionPolicy) expired(alias string) string {
+	return p.condition(alias, "<=")
+}
+
+func (p retentionPolicy) condition(alias, op string) string {
+	if len(p) == 0 {
+		return "false"
+	}
+	states := lo.Keys(p)
+	slices.Sort(states)
+	conditions := make([]string, 0, len(states))
+	for _, state := range states {
+		conditions = append(conditions, fmt.Sprintf(`(%[1]s.job_state = '%[2]s' AND %[1]s.exec_time %[3]s '%[4]s')`,
+			alias, state, op, p[state].UTC().Format(time.RFC3339Nano)))
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// startRetentionLoop starts the retention janitor, which periodically drops the jobs whose retention has elapsed,
+// so that they don't outlive their retention while their datasets are kept around for pending jobs
+func (jd *Handle) startRetentionLoop(ctx context.Context) {
+	jd.backgroundGroup.Go(crash.Wrapper(func() error {
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-jd.TriggerRetention():
+				start := time.Now()
+				err := jd.dropExpiredJobs(ctx, start)
+				stats.Default.NewTaggedStat("jobsdb_retention_loop", stats.TimerType, stats.Tags{"customVal": jd.tablePrefix, "error": strconv.FormatBool(err != nil)}).Since(start)
+				if err != nil && ctx.Err() == nil {
+					jd.logger.Errorn("Dropping jobs past their retention", obskit.Error(err))
+				}
+			}
+		}
+	}))
+}
+
+// dropExpiredJobs drops the jobs of all datasets whose retention has elapsed, after archiving them if archival is enabled
+func (jd *Handle) dropExpiredJobs(ctx context.Context, now time.Time) error {
+	policy := jd.retentionPolicy(now)
+	if len(policy) == 0 {
+		return nil
+	}
+	if !jd.dsListLock.RTryLockWithCtx(ctx) {
+		return fmt.Errorf("could not acquire a dslist read lock: %w", ctx.Err())
+	}
+	dsList := jd.getDSList()
+	jd.dsListLock.RUnlock()
+
+	// files of the same run are named alike, while a new run after a failed one doesn't overwrite the files of jobs
+	// which got dropped in the meantime
+	archiveNameSuffix := "_retention_" + strconv.FormatInt(now.Unix(), 10)
+	for _, ds := range dsList {
+		// archival doesn't block migrations, which may drop the dataset meanwhile
+		archived, err := jd.archiveDSList(ctx, []dataSetT{ds}, policy.expired("s"), archiveNameSuffix)
+		if err != nil {
+			if !jd.dsExists(ctx, ds) {
+				continue
+			}
+			return fmt.Errorf("archiving expired jobs of %s: %w", ds.JobTable, err)
+		}
+		if err := jd.dropExpiredJobsDS(ctx, ds, policy, archived); err != nil {
+			return fmt.Errorf("dropping expired jobs of %s: %w", ds.JobTable, err)
+		}
+	}
+	return nil
+}
+
+// dsExists reports whether the dataset is still in the list of datasets
+func (jd *Handle) dsExists(ctx context.Context, ds dataSetT) bool {
+	if !jd.dsListLock.RTryLockWithCtx(ctx) {
+		return true
+	}
+	defer jd.dsListLock.RUnlock()
+	return slices.Contains(jd.getDSList(), ds)
+}
+
+// dropExpiredJobsDS drops the expired jobs of the dataset which got archived, unless the dataset got dropped by a migration
+func (jd *Handle) dropExpiredJobsDS(ctx context.Context, ds dataSetT, policy retentionPolicy, archived archivedStatuses) error {
+	// datasets cannot be dropped by migration while their jobs are getting dropped
+	if !jd.dsMigrationLock.RTryLockWithCtx(ctx) {
+		return fmt.Errorf("could not acquire a migration read lock: %w", ctx.Err())
+	}
+	defer jd.dsMigrationLock.RUnlock()
+	if !jd.dsExists(ctx, ds) {
+		return nil
+	}
+	return jd.WithTx(func(tx *Tx) error {
+		return jd.dropExpiredJobsDSInTx(ctx, tx, ds, policy, archived)
+	})
+}
+
+func (jd *Handle) dropExpiredJobsDSInTx(ctx context.Context, tx *Tx, ds dataSetT, policy retentionPolicy, archived archivedStatuses) error {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(
+		`WITH expired AS (SELECT s.job_id, s.job_state FROM "v_last_%[1]s" s WHERE %[3]s),
+		deleted_statuses AS (DELETE FROM %[1]q WHERE job_id IN (SELECT job_id FROM expired)),
+		deleted_jobs AS (DELETE FROM %[2]q WHERE job_id IN (SELECT job_id FROM expired))
+		SELECT job_state, COUNT(*) FROM expired GROUP BY job_state`,
+		ds.JobStatusTable, ds.JobTable, policy.expired("s")+" AND NOT "+archived.unarchived(ds, "s"),
+	))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	dropped := make(map[string]int)
+	for rows.Next() {
+		var (
+			state string
+			count int
+		)
+		if err := rows.Scan(&state, &count); err != nil {
+			return err
+		}
+		dropped[state] = count
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	tx.AddSuccessListener(func() {
+		for state, count := range dropped {
+			jd.logger.Debugn("Dropped jobs past their retention",
+				logger.NewStringField("dataset", ds.JobTable),
+				logger.NewStringField("state", state),
+				logger.NewIntField("jobs", int64(count)),
+			)
+			stats.Default.NewTaggedStat("jobsdb_retention_dropped_jobs", stats.CountType, stats.Tags{
+				"customVal": jd.tablePrefix,
+				"state":     state,
+			}).Count(count)
+		}
+	})
+	return nil
+}
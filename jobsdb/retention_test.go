@@ -0,0 +1,84 @@
+package jobsdb
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/rand"
+)
+
+func TestRetention(t *testing.T) {
+	config.Reset()
+	c := config.New()
+	c.Set("JobsDB.maxDSSize", 1)
+
+	_ = startPostgres(t)
+
+	triggerAddNewDS := make(chan time.Time)
+	triggerMigrateDS := make(chan time.Time)
+	triggerRetention := make(chan time.Time)
+	jobDB := Handle{
+		TriggerAddNewDS: func() <-chan time.Time {
+			return triggerAddNewDS
+		},
+		TriggerMigrateDS: func() <-chan time.Time {
+			return triggerMigrateDS
+		},
+		TriggerRetention: func() <-chan time.Time {
+			return triggerRetention
+		},
+		config: c,
+	}
+	tablePrefix := strings.ToLower(rand.String(5))
+	require.NoError(t, jobDB.Setup(ReadWrite, true, tablePrefix))
+	defer jobDB.TearDown()
+	c.Set("JobsDB."+tablePrefix+"."+"maxDSRetention", "1ms")
+	c.Set("JobsDB."+tablePrefix+"."+"retention.succeeded", "1h")
+
+	customVal := rand.String(5)
+	jobs := genJobs(defaultWorkspaceID, customVal, 10, 1)
+	require.NoError(t, jobDB.Store(context.Background(), jobs))
+	require.NoError(t, jobDB.UpdateJobStatus(context.Background(), genJobStatuses(jobs[:4], Succeeded.State), []string{customVal}, nil))
+	require.NoError(t, jobDB.UpdateJobStatus(context.Background(), genJobStatuses(jobs[4:7], Aborted.State), []string{customVal}, nil))
+	require.NoError(t, jobDB.UpdateJobStatus(context.Background(), genJobStatuses(jobs[7:], Failed.State), []string{customVal}, nil))
+
+	triggerAddNewDS <- time.Now() // trigger addNewDSLoop to run
+	triggerAddNewDS <- time.Now() // Second time, waits for the first loop to finish
+	require.EqualValues(t, 2, jobDB.GetMaxDSIndex())
+
+	count := func(t *testing.T, state string) int {
+		res, err := jobDB.GetJobs(context.Background(), []string{state}, GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100})
+		require.NoError(t, err)
+		return len(res.Jobs)
+	}
+
+	t.Run("retained jobs are migrated", func(t *testing.T) {
+		triggerMigrateDS <- time.Now() // trigger migrateDSLoop to run
+		triggerMigrateDS <- time.Now() // waits for last loop to finish
+		require.Equal(t, "1_1", jobDB.getDSList()[0].Index)
+		require.Equal(t, 4, count(t, Succeeded.State), "succeeded jobs should be retained")
+		require.Equal(t, 0, count(t, Aborted.State), "aborted jobs without retention should be dropped")
+		require.Equal(t, 3, count(t, Failed.State))
+
+		triggerMigrateDS <- time.Now()
+		triggerMigrateDS <- time.Now()
+		require.Equal(t, "1_1", jobDB.getDSList()[0].Index, "retained jobs should not cause the dataset to be migrated again")
+	})
+
+	t.Run("janitor drops jobs past their retention", func(t *testing.T) {
+		triggerRetention <- time.Now() // trigger the retention janitor to run
+		triggerRetention <- time.Now() // waits for last run to finish
+		require.Equal(t, 4, count(t, Succeeded.State), "jobs within their retention should not be dropped")
+
+		c.Set("JobsDB."+tablePrefix+"."+"retention.succeeded", "1ms")
+		triggerRetention <- time.Now()
+		triggerRetention <- time.Now()
+		require.Equal(t, 0, count(t, Succeeded.State))
+		require.Equal(t, 3, count(t, Failed.State), "pending jobs should not be dropped")
+	})
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+)
+
+// AdminAuth authenticates requests to administrative endpoints by the admin token, which is expected as the password
+// of their basic auth. All requests are rejected while no admin token is configured.
+func AdminAuth(conf *config.Config) func(http.Handler) http.Handler {
+	token := conf.GetReloadableStringVar("", "AdminAuth.token")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			expected := token.Load()
+			_, password, ok := r.BasicAuth()
+			if expected == "" || !ok || subtle.ConstantTimeCompare([]byte(password), []byte(expected)) != 1 {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
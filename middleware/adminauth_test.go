@@ -0,0 +1,37 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+
+	"github.com/rudderlabs/rudder-server/middleware"
+)
+
+func TestAdminAuth(t *testing.T) {
+	conf := config.New()
+	handler := middleware.AdminAuth(conf)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(password string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		if password != "" {
+			r.SetBasicAuth("", password)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusUnauthorized, serve(""), "requests should be rejected while no admin token is configured")
+	require.Equal(t, http.StatusUnauthorized, serve("token"), "requests should be rejected while no admin token is configured")
+
+	conf.Set("AdminAuth.token", "token")
+	require.Equal(t, http.StatusUnauthorized, serve(""))
+	require.Equal(t, http.StatusUnauthorized, serve("other"))
+	require.Equal(t, http.StatusOK, serve("token"))
+}
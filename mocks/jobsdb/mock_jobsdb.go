@@ -67,6 +67,20 @@ func (mr *MockJobsDBMockRecorder) FailExecuting() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailExecuting", reflect.TypeOf((*MockJobsDB)(nil).FailExecuting))
 }
 
+// FailExecutingOfDestinations mocks base method.
+func (m *MockJobsDB) FailExecutingOfDestinations(arg0 context.Context, arg1 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailExecutingOfDestinations", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailExecutingOfDestinations indicates an expected call of FailExecutingOfDestinations.
+func (mr *MockJobsDBMockRecorder) FailExecutingOfDestinations(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailExecutingOfDestinations", reflect.TypeOf((*MockJobsDB)(nil).FailExecutingOfDestinations), arg0, arg1)
+}
+
 // GetAborted mocks base method.
 func (m *MockJobsDB) GetAborted(arg0 context.Context, arg1 jobsdb.GetQueryParams) (jobsdb.JobsResult, error) {
 	m.ctrl.T.Helper()
@@ -97,6 +111,21 @@ func (mr *MockJobsDBMockRecorder) GetActiveWorkspaces(arg0, arg1 any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveWorkspaces", reflect.TypeOf((*MockJobsDB)(nil).GetActiveWorkspaces), arg0, arg1)
 }
 
+// GetDatasetStats mocks base method.
+func (m *MockJobsDB) GetDatasetStats(arg0 context.Context) (jobsdb.DatasetStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDatasetStats", arg0)
+	ret0, _ := ret[0].(jobsdb.DatasetStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDatasetStats indicates an expected call of GetDatasetStats.
+func (mr *MockJobsDBMockRecorder) GetDatasetStats(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDatasetStats", reflect.TypeOf((*MockJobsDB)(nil).GetDatasetStats), arg0)
+}
+
 // GetDistinctParameterValues mocks base method.
 func (m *MockJobsDB) GetDistinctParameterValues(arg0 context.Context, arg1 string) ([]string, error) {
 	m.ctrl.T.Helper()
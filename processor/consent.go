@@ -1,11 +1,20 @@
 package processor
 
 import (
+	"context"
 	"fmt"
+	"sync"
 
 	"github.com/samber/lo"
+	"github.com/tidwall/gjson"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
 
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/jobsdb"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 	"github.com/rudderlabs/rudder-server/utils/types"
 )
@@ -15,6 +24,8 @@ type ConsentManagementInfo struct {
 	AllowedConsentIDs  interface{} `json:"allowedConsentIds"` // Not used currently but added for future use
 	Provider           string      `json:"provider"`
 	ResolutionStrategy string      `json:"resolutionStrategy"`
+
+	denyAll bool // consent preferences are unknown, deny delivery to all destinations with consent categories
 }
 
 type GenericConsentManagementProviderData struct {
@@ -32,12 +43,25 @@ type GenericConsentManagementProviderConfig struct {
 	Consents           []GenericConsentsConfig `json:"consents"`
 }
 
+// consentPreferencesCache holds the consent preferences fetched from the consent store while processing a batch of events,
+// keyed by workspace and user, so that the store is looked up once per user of the batch. A nil cache doesn't cache anything.
+type consentPreferencesCache map[string]ConsentManagementInfo
+
+// Sources of the consent preferences of an event, used for tagging the consent audit metrics
+const (
+	consentPreferencesFromEvent = "event"
+	consentPreferencesFromStore = "store"
+)
+
 /*
 Filters and returns destinations based on the consents configured for the destination and the user consents present in the event.
+If the event doesn't carry any consent preferences and a consent store is configured, the preferences of the user are fetched from the store,
+unless already present in the cache of the batch.
 
-Supports legacy and generic consent management.
+Supports legacy and generic consent management, along with the consent categories mapped to destinations through Processor.Consent.destinationCategories.
+Every suppressed delivery is counted in the proc_consent_suppressed_events audit metric.
 */
-func (proc *Handle) getConsentFilteredDestinations(event types.SingularEventT, destinations []backendconfig.DestinationT) []backendconfig.DestinationT {
+func (proc *Handle) getConsentFilteredDestinations(ctx context.Context, event types.SingularEventT, destinations []backendconfig.DestinationT, cache consentPreferencesCache) []backendconfig.DestinationT {
 	// If the event does not have denied consent IDs, do not filter any destinations
 	consentManagementInfo, err := getConsentManagementInfo(event)
 	if err != nil {
@@ -45,49 +69,184 @@ func (proc *Handle) getConsentFilteredDestinations(event types.SingularEventT, d
 		proc.logger.Errorw("failed to get consent management info", "error", err.Error())
 	}
 
-	if len(consentManagementInfo.DeniedConsentIDs) == 0 {
+	preferencesSource := consentPreferencesFromEvent
+	if proc.consentStore != nil && len(destinations) > 0 && misc.MapLookup(event, "context", "consentManagement") == nil {
+		preferencesSource = consentPreferencesFromStore
+		consentManagementInfo = proc.getStoredConsentManagementInfo(ctx, event, destinations[0].WorkspaceID, cache)
+	}
+
+	if len(consentManagementInfo.DeniedConsentIDs) == 0 && !consentManagementInfo.denyAll {
 		return destinations
 	}
 
 	return lo.Filter(destinations, func(dest backendconfig.DestinationT, _ int) bool {
-		// Generic consent management
-		if cmpData := proc.getGCMData(dest.ID, consentManagementInfo.Provider); len(cmpData.Consents) > 0 {
+		if proc.isDestinationConsented(&dest, consentManagementInfo) {
+			return true
+		}
+		proc.statsFactory.NewTaggedStat("proc_consent_suppressed_events", stats.CountType, stats.Tags{
+			"workspaceId":       dest.WorkspaceID,
+			"destinationId":     dest.ID,
+			"destType":          dest.DestinationDefinition.Name,
+			"provider":          consentManagementInfo.Provider,
+			"preferencesSource": preferencesSource,
+		}).Increment()
+		return false
+	})
+}
 
-			finalResolutionStrategy := consentManagementInfo.ResolutionStrategy
+// isDestinationConsented returns true if the user consents allow delivering the event to the destination
+func (proc *Handle) isDestinationConsented(dest *backendconfig.DestinationT, consentManagementInfo ConsentManagementInfo) bool {
+	serverCategories := proc.getServerConsentCategories(dest.ID)
+	if consentManagementInfo.denyAll {
+		// the preferences of the user are unknown, so only destinations without any consent categories can be delivered to
+		return len(serverCategories) == 0 &&
+			len(proc.getOneTrustConsentData(dest.ID)) == 0 &&
+			len(proc.getKetchConsentData(dest.ID)) == 0 &&
+			!proc.hasGCMData(dest.ID)
+	}
 
-			// For custom provider, the resolution strategy is to be picked from the destination config
-			if consentManagementInfo.Provider == "custom" {
-				finalResolutionStrategy = cmpData.ResolutionStrategy
-			}
+	// Categories mapped to the destination server-side must all be consented to, regardless of the provider
+	if len(lo.Intersect(serverCategories, consentManagementInfo.DeniedConsentIDs)) > 0 {
+		return false
+	}
 
-			switch finalResolutionStrategy {
-			// The user must consent to at least one of the configured consents in the destination
-			case "or":
-				return !lo.Every(consentManagementInfo.DeniedConsentIDs, cmpData.Consents)
+	// Generic consent management
+	if cmpData := proc.getGCMData(dest.ID, consentManagementInfo.Provider); len(cmpData.Consents) > 0 {
 
-			// The user must consent to all of the configured consents in the destination
-			default: // "and"
-				return len(lo.Intersect(cmpData.Consents, consentManagementInfo.DeniedConsentIDs)) == 0
-			}
+		finalResolutionStrategy := consentManagementInfo.ResolutionStrategy
+
+		// For custom provider, the resolution strategy is to be picked from the destination config
+		if consentManagementInfo.Provider == "custom" {
+			finalResolutionStrategy = cmpData.ResolutionStrategy
 		}
 
-		// Legacy consent management
-		if consentManagementInfo.Provider == "" || consentManagementInfo.Provider == "oneTrust" {
-			// If the destination has oneTrustCookieCategories, returns false if any of the oneTrustCategories are present in deniedCategories
-			if oneTrustCategories := proc.getOneTrustConsentData(dest.ID); len(oneTrustCategories) > 0 {
-				return len(lo.Intersect(oneTrustCategories, consentManagementInfo.DeniedConsentIDs)) == 0
-			}
+		switch finalResolutionStrategy {
+		// The user must consent to at least one of the configured consents in the destination
+		case "or":
+			return !lo.Every(consentManagementInfo.DeniedConsentIDs, cmpData.Consents)
+
+		// The user must consent to all of the configured consents in the destination
+		default: // "and"
+			return len(lo.Intersect(cmpData.Consents, consentManagementInfo.DeniedConsentIDs)) == 0
 		}
+	}
 
-		if consentManagementInfo.Provider == "" || consentManagementInfo.Provider == "ketch" {
-			// If the destination has ketchConsentPurposes, returns false if all ketchPurposes are present in deniedCategories
-			if ketchPurposes := proc.getKetchConsentData(dest.ID); len(ketchPurposes) > 0 {
-				return !lo.Every(consentManagementInfo.DeniedConsentIDs, ketchPurposes)
-			}
+	// Legacy consent management
+	if consentManagementInfo.Provider == "" || consentManagementInfo.Provider == "oneTrust" {
+		// If the destination has oneTrustCookieCategories, returns false if any of the oneTrustCategories are present in deniedCategories
+		if oneTrustCategories := proc.getOneTrustConsentData(dest.ID); len(oneTrustCategories) > 0 {
+			return len(lo.Intersect(oneTrustCategories, consentManagementInfo.DeniedConsentIDs)) == 0
 		}
+	}
 
-		return true
-	})
+	if consentManagementInfo.Provider == "" || consentManagementInfo.Provider == "ketch" {
+		// If the destination has ketchConsentPurposes, returns false if all ketchPurposes are present in deniedCategories
+		if ketchPurposes := proc.getKetchConsentData(dest.ID); len(ketchPurposes) > 0 {
+			return !lo.Every(consentManagementInfo.DeniedConsentIDs, ketchPurposes)
+		}
+	}
+
+	return true
+}
+
+// getStoredConsentManagementInfo returns the consent preferences of the user of the event from the cache of the batch,
+// or from the consent store if not cached yet. Failed lookups get cached too, so that an unavailable store is called
+// once per user of the batch.
+func (proc *Handle) getStoredConsentManagementInfo(ctx context.Context, event types.SingularEventT, workspaceID string, cache consentPreferencesCache) ConsentManagementInfo {
+	userID, _ := event["userId"].(string)
+	if userID == "" {
+		userID, _ = event["anonymousId"].(string)
+	}
+	if userID == "" {
+		return ConsentManagementInfo{}
+	}
+	key := workspaceID + ":" + userID
+	if consentManagementInfo, ok := cache[key]; ok {
+		return consentManagementInfo
+	}
+	consentManagementInfo := proc.fetchConsentManagementInfo(ctx, workspaceID, userID)
+	if cache != nil {
+		cache[key] = consentManagementInfo
+	}
+	return consentManagementInfo
+}
+
+// prefetchConsentPreferences looks up the consent preferences of the distinct users of the jobs in the consent store
+// concurrently, using Processor.Consent.store.concurrency lookups at most, returning the cache of the batch. Only the users
+// of events which don't carry consent preferences themselves are looked up.
+func (proc *Handle) prefetchConsentPreferences(ctx context.Context, jobs []*jobsdb.JobT) consentPreferencesCache {
+	cache := make(consentPreferencesCache)
+	if proc.consentStore == nil {
+		return cache
+	}
+	type user struct{ workspaceID, userID string }
+	users := make(map[string]user)
+	for _, job := range jobs {
+		gjson.GetBytes(job.EventPayload, "batch").ForEach(func(_, event gjson.Result) bool {
+			if event.Get("context.consentManagement").Exists() {
+				return true
+			}
+			userID := event.Get("userId").String()
+			if userID == "" {
+				userID = event.Get("anonymousId").String()
+			}
+			if userID != "" {
+				users[job.WorkspaceId+":"+userID] = user{workspaceID: job.WorkspaceId, userID: userID}
+			}
+			return true
+		})
+	}
+
+	var mu sync.Mutex
+	g := errgroup.Group{}
+	g.SetLimit(max(1, proc.config.consentStoreConcurrency.Load()))
+	for key, u := range users {
+		g.Go(func() error {
+			consentManagementInfo := proc.fetchConsentManagementInfo(ctx, u.workspaceID, u.userID)
+			mu.Lock()
+			cache[key] = consentManagementInfo
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return cache
+}
+
+// fetchConsentManagementInfo fetches the consent preferences of the user from the consent store, waiting for
+// Processor.Consent.store.timeout at most. If the store cannot be reached, delivery is either allowed or denied to all
+// destinations with consent categories, depending on Processor.Consent.store.failClosed.
+func (proc *Handle) fetchConsentManagementInfo(ctx context.Context, workspaceID, userID string) ConsentManagementInfo {
+	ctx, cancel := context.WithTimeout(ctx, proc.config.consentStoreTimeout.Load())
+	defer cancel()
+	preferences, err := proc.consentStore.Get(ctx, workspaceID, userID)
+	if err != nil {
+		proc.logger.Warnn("failed to get consent preferences from the consent store",
+			obskit.WorkspaceID(workspaceID),
+			obskit.Error(err),
+		)
+		return ConsentManagementInfo{denyAll: proc.config.consentStoreFailClosed.Load()}
+	}
+	if preferences == nil {
+		return ConsentManagementInfo{}
+	}
+	return ConsentManagementInfo{
+		DeniedConsentIDs:   lo.Compact(preferences.DeniedConsentIDs),
+		Provider:           preferences.Provider,
+		ResolutionStrategy: preferences.ResolutionStrategy,
+	}
+}
+
+func (proc *Handle) getServerConsentCategories(destinationID string) []string {
+	proc.config.configSubscriberLock.RLock()
+	defer proc.config.configSubscriberLock.RUnlock()
+	return proc.config.serverConsentCategoriesMap[destinationID]
+}
+
+func (proc *Handle) hasGCMData(destinationID string) bool {
+	proc.config.configSubscriberLock.RLock()
+	defer proc.config.configSubscriberLock.RUnlock()
+	return len(proc.config.destGenericConsentManagementMap[destinationID]) > 0
 }
 
 func (proc *Handle) getOneTrustConsentData(destinationID string) []string {
@@ -224,3 +383,13 @@ func getConsentManagementInfo(event types.SingularEventT) (ConsentManagementInfo
 
 	return consentManagementInfo, nil
 }
+
+// getServerConsentCategories returns the consent categories mapped to the destination through
+// Processor.Consent.destinationCategories.<destinationId>, or Processor.Consent.destinationCategories.<destinationType> otherwise
+func getServerConsentCategories(conf *config.Config, dest *backendconfig.DestinationT) []string {
+	categories := conf.GetStringSlice("Processor.Consent.destinationCategories."+dest.ID, nil)
+	if len(categories) == 0 {
+		categories = conf.GetStringSlice("Processor.Consent.destinationCategories."+dest.DestinationDefinition.Name, nil)
+	}
+	return lo.Compact(categories)
+}
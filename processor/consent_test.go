@@ -1,12 +1,21 @@
 package processor
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/samber/lo"
 
+	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/processor/consentstore"
 
 	"github.com/stretchr/testify/require"
 
@@ -1115,6 +1124,7 @@ func TestFilterDestinations(t *testing.T) {
 			proc.config.ketchConsentCategoriesMap = make(map[string][]string)
 			proc.config.destGenericConsentManagementMap = make(map[string]map[string]GenericConsentManagementProviderData)
 			proc.logger = logger.NewLogger().Child("processor")
+			proc.statsFactory = stats.NOP
 
 			for _, dest := range tc.destinations {
 				proc.config.oneTrustConsentCategoriesMap[dest.ID] = getOneTrustConsentCategories(&dest)
@@ -1122,7 +1132,7 @@ func TestFilterDestinations(t *testing.T) {
 				proc.config.destGenericConsentManagementMap[dest.ID], _ = getGenericConsentManagementData(&dest)
 			}
 
-			filteredDestinations := proc.getConsentFilteredDestinations(tc.event, tc.destinations)
+			filteredDestinations := proc.getConsentFilteredDestinations(context.Background(), tc.event, tc.destinations, nil)
 
 			require.EqualValues(t, tc.expectedDestIDs, lo.Map(filteredDestinations, func(dest backendconfig.DestinationT, _ int) string {
 				return dest.ID
@@ -1131,6 +1141,187 @@ func TestFilterDestinations(t *testing.T) {
 	}
 }
 
+type mockConsentStore struct {
+	preferences map[string]*consentstore.Preferences
+	err         error
+
+	delay time.Duration // time each call takes
+
+	mu             sync.Mutex
+	calls          int
+	callsDeadlined int // calls with a context having a deadline
+	inFlight       int
+	maxInFlight    int // maximum number of concurrent calls
+}
+
+func (m *mockConsentStore) Get(ctx context.Context, workspaceID, userID string) (*consentstore.Preferences, error) {
+	m.mu.Lock()
+	m.calls++
+	if _, ok := ctx.Deadline(); ok {
+		m.callsDeadlined++
+	}
+	m.inFlight++
+	m.maxInFlight = max(m.maxInFlight, m.inFlight)
+	m.mu.Unlock()
+
+	time.Sleep(m.delay)
+
+	m.mu.Lock()
+	m.inFlight--
+	m.mu.Unlock()
+	return m.preferences[workspaceID+":"+userID], m.err
+}
+
+func TestConsentEnforcement(t *testing.T) {
+	destinations := []backendconfig.DestinationT{
+		{
+			ID:                    "dest-onetrust",
+			WorkspaceID:           "workspace-1",
+			DestinationDefinition: backendconfig.DestinationDefinitionT{Name: "GA4"},
+			Config: map[string]interface{}{
+				"oneTrustCookieCategories": []interface{}{
+					map[string]interface{}{"oneTrustCookieCategory": "analytics"},
+				},
+			},
+		},
+		{
+			ID:                    "dest-server-mapped",
+			WorkspaceID:           "workspace-1",
+			DestinationDefinition: backendconfig.DestinationDefinitionT{Name: "FB_PIXEL"},
+			Config:                map[string]interface{}{},
+		},
+		{
+			ID:                    "dest-no-consent",
+			WorkspaceID:           "workspace-1",
+			DestinationDefinition: backendconfig.DestinationDefinitionT{Name: "WEBHOOK"},
+			Config:                map[string]interface{}{},
+		},
+	}
+	newProc := func(t *testing.T, store consentstore.Store, failClosed bool) (*Handle, *memstats.Store) {
+		conf := config.New()
+		conf.Set("Processor.Consent.destinationCategories.FB_PIXEL", []string{"marketing"})
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+
+		proc := &Handle{}
+		proc.logger = logger.NOP
+		proc.statsFactory = statsStore
+		proc.consentStore = store
+		proc.config.consentStoreFailClosed = config.SingleValueLoader(failClosed)
+		proc.config.consentStoreTimeout = config.SingleValueLoader(time.Second)
+		proc.config.consentStoreConcurrency = config.SingleValueLoader(2)
+		proc.config.oneTrustConsentCategoriesMap = make(map[string][]string)
+		proc.config.ketchConsentCategoriesMap = make(map[string][]string)
+		proc.config.destGenericConsentManagementMap = make(map[string]map[string]GenericConsentManagementProviderData)
+		proc.config.serverConsentCategoriesMap = make(map[string][]string)
+		for i := range destinations {
+			dest := &destinations[i]
+			proc.config.oneTrustConsentCategoriesMap[dest.ID] = getOneTrustConsentCategories(dest)
+			proc.config.ketchConsentCategoriesMap[dest.ID] = getKetchConsentCategories(dest)
+			proc.config.destGenericConsentManagementMap[dest.ID], _ = getGenericConsentManagementData(dest)
+			proc.config.serverConsentCategoriesMap[dest.ID] = getServerConsentCategories(conf, dest)
+		}
+		return proc, statsStore
+	}
+	destIDs := func(dests []backendconfig.DestinationT) []string {
+		return lo.Map(dests, func(dest backendconfig.DestinationT, _ int) string { return dest.ID })
+	}
+	suppressed := func(statsStore *memstats.Store, destID, destType, source string) float64 {
+		return statsStore.Get("proc_consent_suppressed_events", stats.Tags{
+			"workspaceId":       "workspace-1",
+			"destinationId":     destID,
+			"destType":          destType,
+			"provider":          "",
+			"preferencesSource": source,
+		}).LastValue()
+	}
+
+	t.Run("server-side category mapping with preferences from the event", func(t *testing.T) {
+		proc, statsStore := newProc(t, nil, false)
+		event := types.SingularEventT{
+			"userId":  "user-1",
+			"context": map[string]interface{}{"consentManagement": map[string]interface{}{"deniedConsentIds": []interface{}{"marketing"}}},
+		}
+		require.Equal(t, []string{"dest-onetrust", "dest-no-consent"}, destIDs(proc.getConsentFilteredDestinations(context.Background(), event, destinations, nil)))
+		require.EqualValues(t, 1, suppressed(statsStore, "dest-server-mapped", "FB_PIXEL", consentPreferencesFromEvent))
+	})
+
+	t.Run("preferences from the consent store", func(t *testing.T) {
+		store := &mockConsentStore{preferences: map[string]*consentstore.Preferences{
+			"workspace-1:user-1": {DeniedConsentIDs: []string{"analytics", "marketing"}},
+		}}
+		proc, statsStore := newProc(t, store, false)
+
+		require.Equal(t, []string{"dest-no-consent"}, destIDs(proc.getConsentFilteredDestinations(context.Background(), types.SingularEventT{"userId": "user-1"}, destinations, nil)))
+		require.EqualValues(t, 1, suppressed(statsStore, "dest-onetrust", "GA4", consentPreferencesFromStore))
+		require.EqualValues(t, 1, suppressed(statsStore, "dest-server-mapped", "FB_PIXEL", consentPreferencesFromStore))
+
+		require.Equal(t, destIDs(destinations), destIDs(proc.getConsentFilteredDestinations(context.Background(), types.SingularEventT{"anonymousId": "anon-1"}, destinations, nil)), "users without preferences should not be suppressed")
+
+		event := types.SingularEventT{
+			"userId":  "user-1",
+			"context": map[string]interface{}{"consentManagement": map[string]interface{}{"deniedConsentIds": []interface{}{}}},
+		}
+		require.Equal(t, destIDs(destinations), destIDs(proc.getConsentFilteredDestinations(context.Background(), event, destinations, nil)), "preferences in the event take precedence over the store")
+	})
+
+	t.Run("consent store lookups are cached within a batch", func(t *testing.T) {
+		store := &mockConsentStore{preferences: map[string]*consentstore.Preferences{
+			"workspace-1:user-1": {DeniedConsentIDs: []string{"analytics", "marketing"}},
+		}}
+		proc, _ := newProc(t, store, false)
+
+		cache := make(consentPreferencesCache)
+		for range 3 {
+			require.Equal(t, []string{"dest-no-consent"}, destIDs(proc.getConsentFilteredDestinations(context.Background(), types.SingularEventT{"userId": "user-1"}, destinations, cache)))
+			require.Equal(t, destIDs(destinations), destIDs(proc.getConsentFilteredDestinations(context.Background(), types.SingularEventT{"userId": "user-2"}, destinations, cache)))
+		}
+		require.Equal(t, 2, store.calls, "the store should be looked up once per user of the batch")
+		require.Equal(t, 2, store.callsDeadlined, "lookups should be bounded by a timeout")
+
+		_ = proc.getConsentFilteredDestinations(context.Background(), types.SingularEventT{"userId": "user-1"}, destinations, make(consentPreferencesCache))
+		require.Equal(t, 3, store.calls, "the store should be looked up again for another batch")
+
+		failing := &mockConsentStore{err: errors.New("unavailable")}
+		proc, _ = newProc(t, failing, true)
+		cache = make(consentPreferencesCache)
+		for range 3 {
+			require.Equal(t, []string{"dest-no-consent"}, destIDs(proc.getConsentFilteredDestinations(context.Background(), types.SingularEventT{"userId": "user-1"}, destinations, cache)))
+		}
+		require.Equal(t, 1, failing.calls, "failed lookups should be cached within the batch too")
+	})
+
+	t.Run("consent store lookups are prefetched concurrently for the users of a batch", func(t *testing.T) {
+		store := &mockConsentStore{delay: 10 * time.Millisecond, preferences: map[string]*consentstore.Preferences{
+			"workspace-1:user-1": {DeniedConsentIDs: []string{"analytics", "marketing"}},
+		}}
+		proc, _ := newProc(t, store, false)
+
+		jobs := []*jobsdb.JobT{
+			{WorkspaceId: "workspace-1", EventPayload: []byte(`{"batch":[{"userId":"user-1"},{"userId":"user-2"},{"anonymousId":"anon-1"}]}`)},
+			{WorkspaceId: "workspace-1", EventPayload: []byte(`{"batch":[{"userId":"user-1"},{"userId":"user-3","context":{"consentManagement":{"deniedConsentIds":[]}}},{"userId":"user-4"}]}`)},
+		}
+		cache := proc.prefetchConsentPreferences(context.Background(), jobs)
+		require.Len(t, cache, 4, "users with consent preferences in their events should not be looked up")
+		require.Equal(t, 4, store.calls, "the store should be looked up once per user of the batch")
+		require.Equal(t, 2, store.maxInFlight, "lookups should run concurrently, up to the configured concurrency")
+
+		require.Equal(t, []string{"dest-no-consent"}, destIDs(proc.getConsentFilteredDestinations(context.Background(), types.SingularEventT{"userId": "user-1"}, destinations, cache)))
+		require.Equal(t, 4, store.calls, "prefetched preferences should be used")
+	})
+
+	t.Run("consent store failures", func(t *testing.T) {
+		store := &mockConsentStore{err: errors.New("unavailable")}
+		event := types.SingularEventT{"userId": "user-1"}
+
+		proc, _ := newProc(t, store, false)
+		require.Equal(t, destIDs(destinations), destIDs(proc.getConsentFilteredDestinations(context.Background(), event, destinations, nil)))
+
+		proc, _ = newProc(t, store, true)
+		require.Equal(t, []string{"dest-no-consent"}, destIDs(proc.getConsentFilteredDestinations(context.Background(), event, destinations, nil)))
+	})
+}
+
 func TestGetConsentManagementInfo(t *testing.T) {
 	type testCaseT struct {
 		description string
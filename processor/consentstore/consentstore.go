@@ -0,0 +1,142 @@
+// Package consentstore fetches the consent preferences of users from an external consent store, for events which
+// don't carry them in context.consentManagement.
+//
+// The store is expected to respond to GET requests on the configured url, where {workspaceId} and {userId} are
+// replaced accordingly, with the preferences of the user, or with 404 if the user has no preferences:
+//
+//	{"provider": "oneTrust", "resolutionStrategy": "and", "deniedConsentIds": ["C0002", "C0004"]}
+package consentstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+)
+
+// Preferences are the consent preferences of a user
+type Preferences struct {
+	Provider           string   `json:"provider"`
+	ResolutionStrategy string   `json:"resolutionStrategy"`
+	DeniedConsentIDs   []string `json:"deniedConsentIds"`
+}
+
+// Store returns the consent preferences of users
+type Store interface {
+	// Get returns the preferences of the user, or nil preferences if the user has none
+	Get(ctx context.Context, workspaceID, userID string) (*Preferences, error)
+}
+
+type cacheEntry struct {
+	preferences *Preferences
+	expiresAt   time.Time
+}
+
+type httpStore struct {
+	client      *http.Client
+	urlTemplate string
+	authToken   string
+	cache       *lru.Cache[string, cacheEntry]
+	cacheTTL    time.Duration
+	negativeTTL time.Duration
+	stats       stats.Stats
+	now         func() time.Time
+}
+
+// NewHTTPStore returns a store which fetches preferences over http from Processor.Consent.store.url.
+// Preferences are cached for Processor.Consent.store.cache.ttl, or Processor.Consent.store.cache.negativeTTL for users without preferences.
+func NewHTTPStore(conf *config.Config, statsFactory stats.Stats) (Store, error) {
+	urlTemplate := conf.GetString("Processor.Consent.store.url", "")
+	if !strings.Contains(urlTemplate, "{userId}") {
+		return nil, fmt.Errorf("consent store url %q should contain the {userId} placeholder", urlTemplate)
+	}
+	cache, err := lru.New[string, cacheEntry](conf.GetInt("Processor.Consent.store.cache.size", 10000))
+	if err != nil {
+		return nil, fmt.Errorf("creating consent store cache: %w", err)
+	}
+	return &httpStore{
+		client:      &http.Client{Timeout: conf.GetDuration("Processor.Consent.store.timeout", 1, time.Second)},
+		urlTemplate: urlTemplate,
+		authToken:   conf.GetString("Processor.Consent.store.authToken", ""),
+		cache:       cache,
+		cacheTTL:    conf.GetDuration("Processor.Consent.store.cache.ttl", 5, time.Minute),
+		negativeTTL: conf.GetDuration("Processor.Consent.store.cache.negativeTTL", 1, time.Minute),
+		stats:       statsFactory,
+		now:         time.Now,
+	}, nil
+}
+
+var errNotFound = errors.New("not found")
+
+func (s *httpStore) Get(ctx context.Context, workspaceID, userID string) (*Preferences, error) {
+	key := workspaceID + ":" + userID
+	if entry, ok := s.cache.Get(key); ok && entry.expiresAt.After(s.now()) {
+		return entry.preferences, nil
+	}
+
+	start := s.now()
+	preferences, err := s.fetch(ctx, workspaceID, userID)
+	errType := ""
+	switch {
+	case errors.Is(err, errNotFound):
+		errType = "not_found"
+		err = nil
+	case err != nil:
+		errType = "request_failed"
+	}
+	tags := stats.Tags{"workspaceId": workspaceID, "error": errType}
+	s.stats.NewTaggedStat("proc_consent_store_request", stats.CountType, tags).Increment()
+	s.stats.NewTaggedStat("proc_consent_store_request_latency", stats.TimerType, tags).Since(start)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := s.cacheTTL
+	if preferences == nil {
+		ttl = s.negativeTTL
+	}
+	s.cache.Add(key, cacheEntry{preferences: preferences, expiresAt: s.now().Add(ttl)})
+	return preferences, nil
+}
+
+func (s *httpStore) fetch(ctx context.Context, workspaceID, userID string) (*Preferences, error) {
+	u := strings.NewReplacer("{workspaceId}", url.PathEscape(workspaceID), "{userId}", url.PathEscape(userID)).Replace(s.urlTemplate)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if s.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.authToken)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, errNotFound
+	default:
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	var preferences Preferences
+	if err := jsoniter.Unmarshal(body, &preferences); err != nil {
+		return nil, fmt.Errorf("unmarshalling preferences: %w", err)
+	}
+	return &preferences, nil
+}
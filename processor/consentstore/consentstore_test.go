@@ -0,0 +1,63 @@
+package consentstore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+)
+
+func TestHTTPStore(t *testing.T) {
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/workspace-1/users/user-1":
+			_, _ = w.Write([]byte(`{"provider":"oneTrust","deniedConsentIds":["C0002"]}`))
+		case "/workspace-1/users/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	conf := config.New()
+	conf.Set("Processor.Consent.store.url", srv.URL+"/{workspaceId}/users/{userId}")
+	conf.Set("Processor.Consent.store.authToken", "token")
+	statsStore, err := memstats.New()
+	require.NoError(t, err)
+	store, err := NewHTTPStore(conf, statsStore)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		preferences, err := store.Get(ctx, "workspace-1", "user-1")
+		require.NoError(t, err)
+		require.Equal(t, &Preferences{Provider: "oneTrust", DeniedConsentIDs: []string{"C0002"}}, preferences)
+
+		preferences, err = store.Get(ctx, "workspace-1", "unknown")
+		require.NoError(t, err)
+		require.Nil(t, preferences)
+	}
+	require.EqualValues(t, 2, requests.Load(), "preferences should be cached")
+
+	_, err = store.Get(ctx, "workspace-1", "broken")
+	require.Error(t, err)
+
+	require.EqualValues(t, 1, statsStore.Get("proc_consent_store_request", stats.Tags{"workspaceId": "workspace-1", "error": ""}).LastValue())
+	require.EqualValues(t, 1, statsStore.Get("proc_consent_store_request", stats.Tags{"workspaceId": "workspace-1", "error": "not_found"}).LastValue())
+	require.EqualValues(t, 1, statsStore.Get("proc_consent_store_request", stats.Tags{"workspaceId": "workspace-1", "error": "request_failed"}).LastValue())
+
+	conf.Set("Processor.Consent.store.url", srv.URL)
+	_, err = NewHTTPStore(conf, stats.NOP)
+	require.Error(t, err, "url without the user placeholder")
+}
@@ -0,0 +1,309 @@
+package eventfilter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+const eventFilteringRulesKey = "eventFilteringRules"
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Rule actions
+const (
+	ActionAllow = "allow"
+	ActionDrop  = "drop"
+)
+
+// Condition operators
+const (
+	OperatorEquals      = "eq"
+	OperatorNotEquals   = "neq"
+	OperatorIn          = "in"
+	OperatorNotIn       = "nin"
+	OperatorExists      = "exists"
+	OperatorNotExists   = "notExists"
+	OperatorContains    = "contains"
+	OperatorStartsWith  = "startsWith"
+	OperatorEndsWith    = "endsWith"
+	OperatorGreaterThan = "gt"
+	OperatorGreaterOrEq = "gte"
+	OperatorLessThan    = "lt"
+	OperatorLessOrEq    = "lte"
+	OperatorRegex       = "regex"
+)
+
+/*
+RuleSet is a compiled set of declarative event filtering rules, configured per source-destination connection, e.g.
+
+	"eventFilteringRules": {
+		"defaultAction": "allow",
+		"rules": [
+			{
+				"name": "drop test events",
+				"action": "drop",
+				"conditions": [{"field": "properties.test", "operator": "eq", "value": true}]
+			},
+			{
+				"name": "orders only",
+				"action": "allow",
+				"conditions": [{"field": "type", "operator": "eq", "value": "track"}, {"field": "event", "operator": "in", "value": ["Order Completed", "Order Refunded"]}]
+			}
+		]
+	}
+
+Rules are evaluated in order and the action of the first rule whose conditions all match is applied.
+If no rule matches, the default action is applied, which is allow unless configured otherwise.
+Fields are dot-separated paths into the event, e.g. event, type, properties.price or context.traits.email.
+*/
+type RuleSet struct {
+	defaultAction string
+	rules         []rule
+}
+
+type rule struct {
+	name       string
+	action     string
+	conditions []condition
+}
+
+type condition struct {
+	path     []string
+	operator string
+	value    interface{}
+	values   []interface{}  // for in, nin
+	number   float64        // for gt, gte, lt, lte
+	regex    *regexp.Regexp // for regex
+}
+
+type rulesConfig struct {
+	DefaultAction string `json:"defaultAction"`
+	Rules         []struct {
+		Name       string `json:"name"`
+		Action     string `json:"action"`
+		Conditions []struct {
+			Field    string      `json:"field"`
+			Operator string      `json:"operator"`
+			Value    interface{} `json:"value"`
+		} `json:"conditions"`
+	} `json:"rules"`
+}
+
+// NewRuleSet compiles the event filtering rules found in the given connection config.
+// It returns a nil RuleSet if the connection has no rules configured, and an error if the rules are invalid.
+func NewRuleSet(connectionConfig map[string]interface{}) (*RuleSet, error) {
+	raw, ok := connectionConfig[eventFilteringRulesKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling %s: %w", eventFilteringRulesKey, err)
+	}
+	var conf rulesConfig
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return nil, fmt.Errorf("unmarshalling %s: %w", eventFilteringRulesKey, err)
+	}
+
+	rs := &RuleSet{defaultAction: ActionAllow}
+	if conf.DefaultAction != "" {
+		if !validAction(conf.DefaultAction) {
+			return nil, fmt.Errorf("invalid default action %q", conf.DefaultAction)
+		}
+		rs.defaultAction = conf.DefaultAction
+	}
+	for i, r := range conf.Rules {
+		if !validAction(r.Action) {
+			return nil, fmt.Errorf("rule %d: invalid action %q", i, r.Action)
+		}
+		if len(r.Conditions) == 0 {
+			return nil, fmt.Errorf("rule %d: no conditions", i)
+		}
+		compiled := rule{name: r.Name, action: r.Action}
+		if compiled.name == "" {
+			compiled.name = strconv.Itoa(i)
+		}
+		for j, c := range r.Conditions {
+			cond, err := newCondition(c.Field, c.Operator, c.Value)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d, condition %d: %w", i, j, err)
+			}
+			compiled.conditions = append(compiled.conditions, cond)
+		}
+		rs.rules = append(rs.rules, compiled)
+	}
+	return rs, nil
+}
+
+// Allow evaluates the rules against the event, returning whether the event is allowed
+// along with the name of the rule which decided it (empty if the default action was applied).
+// A nil RuleSet allows every event.
+func (rs *RuleSet) Allow(event types.SingularEventT) (bool, string) {
+	if rs == nil {
+		return true, ""
+	}
+	for _, r := range rs.rules {
+		if r.matches(event) {
+			return r.action == ActionAllow, r.name
+		}
+	}
+	return rs.defaultAction == ActionAllow, ""
+}
+
+func (r rule) matches(event types.SingularEventT) bool {
+	for _, c := range r.conditions {
+		if !c.matches(event) {
+			return false
+		}
+	}
+	return true
+}
+
+func validAction(action string) bool {
+	return action == ActionAllow || action == ActionDrop
+}
+
+func newCondition(field, operator string, value interface{}) (condition, error) {
+	if field == "" {
+		return condition{}, fmt.Errorf("empty field")
+	}
+	c := condition{path: strings.Split(field, "."), operator: operator, value: value}
+	switch operator {
+	case OperatorEquals, OperatorNotEquals, OperatorExists, OperatorNotExists:
+	case OperatorIn, OperatorNotIn:
+		values, ok := value.([]interface{})
+		if !ok {
+			return condition{}, fmt.Errorf("operator %q requires an array value", operator)
+		}
+		c.values = values
+	case OperatorContains, OperatorStartsWith, OperatorEndsWith:
+		if _, ok := value.(string); !ok {
+			return condition{}, fmt.Errorf("operator %q requires a string value", operator)
+		}
+	case OperatorGreaterThan, OperatorGreaterOrEq, OperatorLessThan, OperatorLessOrEq:
+		n, ok := toNumber(value)
+		if !ok {
+			return condition{}, fmt.Errorf("operator %q requires a numeric value", operator)
+		}
+		c.number = n
+	case OperatorRegex:
+		expr, ok := value.(string)
+		if !ok {
+			return condition{}, fmt.Errorf("operator %q requires a string value", operator)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return condition{}, fmt.Errorf("invalid regex %q: %w", expr, err)
+		}
+		c.regex = re
+	default:
+		return condition{}, fmt.Errorf("unsupported operator %q", operator)
+	}
+	return c, nil
+}
+
+func (c condition) matches(event types.SingularEventT) bool {
+	actual := misc.MapLookup(event, c.path...)
+	switch c.operator {
+	case OperatorExists:
+		return actual != nil
+	case OperatorNotExists:
+		return actual == nil
+	case OperatorEquals:
+		return equal(actual, c.value)
+	case OperatorNotEquals:
+		return !equal(actual, c.value)
+	case OperatorIn:
+		for _, v := range c.values {
+			if equal(actual, v) {
+				return true
+			}
+		}
+		return false
+	case OperatorNotIn:
+		for _, v := range c.values {
+			if equal(actual, v) {
+				return false
+			}
+		}
+		return true
+	case OperatorContains, OperatorStartsWith, OperatorEndsWith:
+		s, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		expected := c.value.(string)
+		switch c.operator {
+		case OperatorContains:
+			return strings.Contains(s, expected)
+		case OperatorStartsWith:
+			return strings.HasPrefix(s, expected)
+		default:
+			return strings.HasSuffix(s, expected)
+		}
+	case OperatorGreaterThan, OperatorGreaterOrEq, OperatorLessThan, OperatorLessOrEq:
+		n, ok := toNumber(actual)
+		if !ok {
+			return false
+		}
+		switch c.operator {
+		case OperatorGreaterThan:
+			return n > c.number
+		case OperatorGreaterOrEq:
+			return n >= c.number
+		case OperatorLessThan:
+			return n < c.number
+		default:
+			return n <= c.number
+		}
+	case OperatorRegex:
+		s, ok := actual.(string)
+		return ok && c.regex.MatchString(s)
+	}
+	return false
+}
+
+// equal compares scalar values, treating numbers of different types as equal if their values are.
+// Objects and arrays never match.
+func equal(actual, expected interface{}) bool {
+	if a, ok := toNumber(actual); ok {
+		if e, ok := toNumber(expected); ok {
+			return a == e
+		}
+		return false
+	}
+	switch a := actual.(type) {
+	case string:
+		e, ok := expected.(string)
+		return ok && a == e
+	case bool:
+		e, ok := expected.(bool)
+		return ok && a == e
+	case nil:
+		return expected == nil
+	}
+	return false
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	}
+	return 0, false
+}
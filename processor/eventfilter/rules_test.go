@@ -0,0 +1,119 @@
+package eventfilter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+func TestRuleSet(t *testing.T) {
+	parse := func(t *testing.T, rules string) *RuleSet {
+		var conf map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(`{"eventFilteringRules":`+rules+`}`), &conf))
+		rs, err := NewRuleSet(conf)
+		require.NoError(t, err)
+		return rs
+	}
+	event := func(t *testing.T, e string) types.SingularEventT {
+		var event types.SingularEventT
+		require.NoError(t, json.Unmarshal([]byte(e), &event))
+		return event
+	}
+
+	t.Run("no rules configured", func(t *testing.T) {
+		rs, err := NewRuleSet(map[string]interface{}{"other": true})
+		require.NoError(t, err)
+		require.Nil(t, rs)
+		allowed, rule := rs.Allow(event(t, `{"type":"track"}`))
+		require.True(t, allowed)
+		require.Empty(t, rule)
+	})
+
+	t.Run("first matching rule wins", func(t *testing.T) {
+		rs := parse(t, `{
+			"defaultAction": "drop",
+			"rules": [
+				{"name": "drop test events", "action": "drop", "conditions": [{"field": "properties.test", "operator": "eq", "value": true}]},
+				{"name": "orders", "action": "allow", "conditions": [
+					{"field": "type", "operator": "eq", "value": "track"},
+					{"field": "event", "operator": "in", "value": ["Order Completed", "Order Refunded"]}
+				]},
+				{"name": "identifies", "action": "allow", "conditions": [{"field": "type", "operator": "eq", "value": "identify"}]}
+			]
+		}`)
+
+		testCases := []struct {
+			name    string
+			event   string
+			allowed bool
+			rule    string
+		}{
+			{"order", `{"type":"track","event":"Order Completed"}`, true, "orders"},
+			{"test order", `{"type":"track","event":"Order Completed","properties":{"test":true}}`, false, "drop test events"},
+			{"identify", `{"type":"identify"}`, true, "identifies"},
+			{"other track", `{"type":"track","event":"Product Viewed"}`, false, ""},
+			{"page", `{"type":"page","event":"Order Completed"}`, false, ""},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				allowed, rule := rs.Allow(event(t, tc.event))
+				require.Equal(t, tc.allowed, allowed)
+				require.Equal(t, tc.rule, rule)
+			})
+		}
+	})
+
+	t.Run("operators", func(t *testing.T) {
+		e := event(t, `{"type":"track","event":"Order Completed","userId":"user-123","properties":{"price":10.5,"quantity":2,"email":"a@example.com","tags":["x"]}}`)
+		testCases := []struct {
+			condition string
+			matches   bool
+		}{
+			{`{"field": "properties.quantity", "operator": "eq", "value": 2}`, true},
+			{`{"field": "properties.quantity", "operator": "eq", "value": "2"}`, false},
+			{`{"field": "properties.tags", "operator": "eq", "value": "x"}`, false},
+			{`{"field": "event", "operator": "neq", "value": "Order Completed"}`, false},
+			{`{"field": "event", "operator": "nin", "value": ["Product Viewed"]}`, true},
+			{`{"field": "properties.email", "operator": "exists"}`, true},
+			{`{"field": "properties.missing", "operator": "exists"}`, false},
+			{`{"field": "properties.missing", "operator": "notExists"}`, true},
+			{`{"field": "event", "operator": "contains", "value": "Order"}`, true},
+			{`{"field": "userId", "operator": "startsWith", "value": "user-"}`, true},
+			{`{"field": "properties.email", "operator": "endsWith", "value": "@rudderstack.com"}`, false},
+			{`{"field": "properties.price", "operator": "gt", "value": 10}`, true},
+			{`{"field": "properties.price", "operator": "gte", "value": 10.5}`, true},
+			{`{"field": "properties.price", "operator": "lt", "value": 10}`, false},
+			{`{"field": "properties.price", "operator": "lte", "value": 10.5}`, true},
+			{`{"field": "event", "operator": "gt", "value": 10}`, false},
+			{`{"field": "userId", "operator": "regex", "value": "^user-[0-9]+$"}`, true},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.condition, func(t *testing.T) {
+				rs := parse(t, `{"rules": [{"action": "drop", "conditions": [`+tc.condition+`]}]}`)
+				allowed, _ := rs.Allow(e)
+				require.Equal(t, tc.matches, !allowed)
+			})
+		}
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		for _, rules := range []string{
+			`{"defaultAction": "route"}`,
+			`{"rules": [{"action": "route", "conditions": [{"field": "event", "operator": "eq", "value": "a"}]}]}`,
+			`{"rules": [{"action": "drop", "conditions": []}]}`,
+			`{"rules": [{"action": "drop", "conditions": [{"field": "", "operator": "eq", "value": "a"}]}]}`,
+			`{"rules": [{"action": "drop", "conditions": [{"field": "event", "operator": "like", "value": "a"}]}]}`,
+			`{"rules": [{"action": "drop", "conditions": [{"field": "event", "operator": "in", "value": "a"}]}]}`,
+			`{"rules": [{"action": "drop", "conditions": [{"field": "event", "operator": "gt", "value": "a"}]}]}`,
+			`{"rules": [{"action": "drop", "conditions": [{"field": "event", "operator": "regex", "value": "("}]}]}`,
+			`"rules"`,
+		} {
+			var conf map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(`{"eventFilteringRules":`+rules+`}`), &conf))
+			_, err := NewRuleSet(conf)
+			require.Error(t, err, rules)
+		}
+	})
+}
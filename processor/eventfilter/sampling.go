@@ -0,0 +1,160 @@
+package eventfilter
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+const (
+	eventSamplingKey = "eventSampling"
+
+	// samplingBuckets is the number of buckets users are assigned to, allowing percentages with two decimals
+	samplingBuckets = 10000
+)
+
+/*
+Sampler delivers a deterministic sample of the events of a source-destination connection, e.g.
+
+	"eventSampling": {
+		"defaultPercentage": 100,
+		"rules": [
+			{
+				"name": "pages",
+				"percentage": 10,
+				"conditions": [{"field": "type", "operator": "eq", "value": "page"}]
+			}
+		]
+	}
+
+Rules are evaluated in order and the percentage of the first rule whose conditions all match is applied.
+If no rule matches, the default percentage is applied, which is 100 unless configured otherwise.
+Conditions use the same fields and operators as the event filtering rules, see [RuleSet].
+
+Events are sampled by user rather than by event: the userId of the event (or its anonymousId if it has none) is
+assigned to a bucket, and the event is kept if the bucket falls within the percentage. All the events of a user are
+thus either kept or dropped together, keeping user journeys intact, and users sampled at a lower percentage are
+always part of the samples at higher percentages.
+*/
+type Sampler struct {
+	defaultPercentage float64
+	rules             []samplingRule
+}
+
+type samplingRule struct {
+	name       string
+	percentage float64
+	conditions []condition
+}
+
+type samplingConfig struct {
+	DefaultPercentage *float64 `json:"defaultPercentage"`
+	Rules             []struct {
+		Name       string  `json:"name"`
+		Percentage float64 `json:"percentage"`
+		Conditions []struct {
+			Field    string      `json:"field"`
+			Operator string      `json:"operator"`
+			Value    interface{} `json:"value"`
+		} `json:"conditions"`
+	} `json:"rules"`
+}
+
+// NewSampler compiles the event sampling rules found in the given connection config.
+// It returns a nil Sampler if the connection has no sampling configured, and an error if the rules are invalid.
+func NewSampler(connectionConfig map[string]interface{}) (*Sampler, error) {
+	raw, ok := connectionConfig[eventSamplingKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling %s: %w", eventSamplingKey, err)
+	}
+	var conf samplingConfig
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return nil, fmt.Errorf("unmarshalling %s: %w", eventSamplingKey, err)
+	}
+
+	s := &Sampler{defaultPercentage: 100}
+	if conf.DefaultPercentage != nil {
+		if !validPercentage(*conf.DefaultPercentage) {
+			return nil, fmt.Errorf("invalid default percentage %v", *conf.DefaultPercentage)
+		}
+		s.defaultPercentage = *conf.DefaultPercentage
+	}
+	for i, r := range conf.Rules {
+		if !validPercentage(r.Percentage) {
+			return nil, fmt.Errorf("rule %d: invalid percentage %v", i, r.Percentage)
+		}
+		if len(r.Conditions) == 0 {
+			return nil, fmt.Errorf("rule %d: no conditions", i)
+		}
+		compiled := samplingRule{name: r.Name, percentage: r.Percentage}
+		if compiled.name == "" {
+			compiled.name = strconv.Itoa(i)
+		}
+		for j, c := range r.Conditions {
+			cond, err := newCondition(c.Field, c.Operator, c.Value)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d, condition %d: %w", i, j, err)
+			}
+			compiled.conditions = append(compiled.conditions, cond)
+		}
+		s.rules = append(s.rules, compiled)
+	}
+	return s, nil
+}
+
+// Sample returns whether the event is part of the sample, along with the name of the rule whose percentage was
+// applied (empty if the default percentage was applied). A nil Sampler keeps every event.
+func (s *Sampler) Sample(event types.SingularEventT) (bool, string) {
+	if s == nil {
+		return true, ""
+	}
+	percentage, name := s.defaultPercentage, ""
+	for _, r := range s.rules {
+		if r.matches(event) {
+			percentage, name = r.percentage, r.name
+			break
+		}
+	}
+	switch percentage {
+	case 100:
+		return true, name
+	case 0:
+		return false, name
+	}
+	return float64(samplingBucket(event)) < percentage*samplingBuckets/100, name
+}
+
+func (r samplingRule) matches(event types.SingularEventT) bool {
+	for _, c := range r.conditions {
+		if !c.matches(event) {
+			return false
+		}
+	}
+	return true
+}
+
+// samplingBucket assigns the user of the event to one of the sampling buckets. Events without any user
+// identifier are assigned by their message id.
+func samplingBucket(event types.SingularEventT) uint32 {
+	var key string
+	for _, field := range []string{"userId", "anonymousId", "messageId"} {
+		if v, ok := event[field]; ok && v != nil {
+			if key = fmt.Sprint(v); key != "" {
+				break
+			}
+		}
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum32() % samplingBuckets
+}
+
+func validPercentage(percentage float64) bool {
+	return percentage >= 0 && percentage <= 100
+}
@@ -0,0 +1,98 @@
+package eventfilter
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+func TestSampler(t *testing.T) {
+	parse := func(t *testing.T, sampling string) *Sampler {
+		var conf map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(`{"eventSampling":`+sampling+`}`), &conf))
+		s, err := NewSampler(conf)
+		require.NoError(t, err)
+		return s
+	}
+
+	t.Run("no sampling configured", func(t *testing.T) {
+		s, err := NewSampler(map[string]interface{}{"other": true})
+		require.NoError(t, err)
+		require.Nil(t, s)
+		sampled, rule := s.Sample(types.SingularEventT{"type": "page"})
+		require.True(t, sampled)
+		require.Empty(t, rule)
+	})
+
+	t.Run("percentage of users", func(t *testing.T) {
+		s := parse(t, `{
+			"rules": [
+				{"name": "pages", "percentage": 10, "conditions": [{"field": "type", "operator": "eq", "value": "page"}]},
+				{"name": "tests", "percentage": 0, "conditions": [{"field": "properties.test", "operator": "eq", "value": true}]}
+			]
+		}`)
+
+		var pages, tracks int
+		for i := 0; i < 10000; i++ {
+			userID := "user-" + strconv.Itoa(i)
+			sampled, rule := s.Sample(types.SingularEventT{"type": "page", "userId": userID})
+			require.Equal(t, "pages", rule)
+			if sampled {
+				pages++
+				// the other pages of the same user are part of the sample too
+				sampled, _ = s.Sample(types.SingularEventT{"type": "page", "userId": userID, "messageId": "other"})
+				require.True(t, sampled)
+			}
+			sampled, rule = s.Sample(types.SingularEventT{"type": "track", "userId": userID})
+			require.Empty(t, rule)
+			if sampled {
+				tracks++
+			}
+		}
+		require.InDelta(t, 1000, pages, 150)
+		require.Equal(t, 10000, tracks, "events not matching any rule are sampled with the default percentage")
+
+		sampled, rule := s.Sample(types.SingularEventT{"type": "track", "userId": "user-1", "properties": map[string]interface{}{"test": true}})
+		require.False(t, sampled)
+		require.Equal(t, "tests", rule)
+	})
+
+	t.Run("anonymous users", func(t *testing.T) {
+		s := parse(t, `{"defaultPercentage": 50}`)
+		for i := 0; i < 100; i++ {
+			anonymousID := "anonymous-" + strconv.Itoa(i)
+			expected, _ := s.Sample(types.SingularEventT{"anonymousId": anonymousID, "messageId": "1"})
+			sampled, _ := s.Sample(types.SingularEventT{"anonymousId": anonymousID, "messageId": "2"})
+			require.Equal(t, expected, sampled)
+		}
+	})
+
+	t.Run("lower percentages are subsets of higher ones", func(t *testing.T) {
+		low, high := parse(t, `{"defaultPercentage": 5}`), parse(t, `{"defaultPercentage": 20.5}`)
+		for i := 0; i < 1000; i++ {
+			event := types.SingularEventT{"userId": "user-" + strconv.Itoa(i)}
+			if sampled, _ := low.Sample(event); sampled {
+				sampled, _ = high.Sample(event)
+				require.True(t, sampled)
+			}
+		}
+	})
+
+	t.Run("invalid sampling", func(t *testing.T) {
+		for _, sampling := range []string{
+			`{"defaultPercentage": 101}`,
+			`{"rules": [{"percentage": -1, "conditions": [{"field": "type", "operator": "eq", "value": "page"}]}]}`,
+			`{"rules": [{"percentage": 10}]}`,
+			`{"rules": [{"percentage": 10, "conditions": [{"field": "type", "operator": "like", "value": "page"}]}]}`,
+			`"10%"`,
+		} {
+			var conf map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(`{"eventSampling":`+sampling+`}`), &conf))
+			_, err := NewSampler(conf)
+			require.Error(t, err, sampling)
+		}
+	})
+}
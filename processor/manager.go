@@ -13,6 +13,7 @@ import (
 	"github.com/rudderlabs/rudder-server/internal/enricher"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	"github.com/rudderlabs/rudder-server/processor/transformer"
+	"github.com/rudderlabs/rudder-server/processor/transformer/embedded"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
 	transformationdebugger "github.com/rudderlabs/rudder-server/services/debugger/transformation"
 	"github.com/rudderlabs/rudder-server/services/fileuploader"
@@ -119,15 +120,21 @@ func New(
 	trackedUsersReporter trackedusers.UsersReporter,
 	opts ...Opts,
 ) *LifecycleManager {
-	proc := &LifecycleManager{
-		Handle: NewHandle(
+	trans := transformer.NewTransformer(
+		config.Default,
+		logger.NewLogger().Child("processor"),
+		stats.Default,
+	)
+	if config.GetBool("Processor.embeddedUserTransformations.enabled", false) {
+		trans = embedded.NewTransformer(
 			config.Default,
-			transformer.NewTransformer(
-				config.Default,
-				logger.NewLogger().Child("processor"),
-				stats.Default,
-			),
-		),
+			logger.NewLogger().Child("processor"),
+			stats.Default,
+			trans,
+		)
+	}
+	proc := &LifecycleManager{
+		Handle:                     NewHandle(config.Default, trans),
 		mainCtx:                    ctx,
 		gatewayDB:                  gwDb,
 		routerDB:                   rtDb,
@@ -162,6 +169,27 @@ func WithAdaptiveLimit(adaptiveLimitFunction func(int64) int64) Opts {
 	}
 }
 
+// WithTransformationDLQ enables persisting events which failed in user transformations to the given dead-letter queue
+func WithTransformationDLQ(dlq transformationDLQ) Opts {
+	return func(l *LifecycleManager) {
+		l.Handle.transformationDLQ = dlq
+	}
+}
+
+// WithCheckpoints enables skipping, after a crash, the outputs of gateway jobs which were already stored
+func WithCheckpoints(checkpoints checkpointStore) Opts {
+	return func(l *LifecycleManager) {
+		l.Handle.checkpoints = checkpoints
+	}
+}
+
+// WithUsageMeter enables metering the events ingested by the sources and their monthly tracked users
+func WithUsageMeter(meter usageMeter) Opts {
+	return func(l *LifecycleManager) {
+		l.Handle.usageMeter = meter
+	}
+}
+
 func WithStats(stats stats.Stats) Opts {
 	return func(l *LifecycleManager) {
 		l.Handle.statsFactory = stats
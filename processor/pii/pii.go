@@ -0,0 +1,247 @@
+// Package pii masks, hashes, tokenizes or removes personally identifiable information from events, according to rules
+// configured per destination, e.g. hashing emails sent to ad destinations while passing them in plaintext to a warehouse.
+//
+// Rules are configured in the destination config under the piiRules key:
+//
+//	"piiRules": [
+//		{"field": "context.traits.email", "type": "email", "action": "hash"},
+//		{"field": "context.ip", "type": "ip", "action": "mask"},
+//		{"field": "context.traits.phone", "type": "phone", "action": "tokenize"},
+//		{"field": "properties.ssn", "action": "remove"}
+//	]
+package pii
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+const rulesKey = "piiRules"
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Actions
+const (
+	ActionHash     = "hash"     // sha256 hex digest of the normalized value
+	ActionMask     = "mask"     // partially redacted value, keeping it recognizable
+	ActionTokenize = "tokenize" // deterministic, keyed token (HMAC-SHA256) of the normalized value
+	ActionRemove   = "remove"   // field removed from the event
+)
+
+// Field types, deciding how values are normalized and masked
+const (
+	TypeEmail   = "email"
+	TypeIP      = "ip"
+	TypePhone   = "phone"
+	TypeGeneric = "generic"
+)
+
+// ErrMissingTokenizationKey is returned when a tokenize rule is configured without a tokenization key
+var ErrMissingTokenizationKey = errors.New("tokenization key is not configured")
+
+// Rules is a compiled set of pii rules of a destination
+type Rules struct {
+	rules           []rule
+	tokenizationKey []byte
+}
+
+type rule struct {
+	field  string
+	path   []string
+	action string
+	typ    string
+}
+
+// Applied describes a rule which was applied on an event
+type Applied struct {
+	Field  string
+	Action string
+}
+
+// NewRules compiles the pii rules found in the given destination config.
+// It returns nil Rules if the destination has no rules configured, and an error if the rules are invalid.
+func NewRules(destinationConfig map[string]interface{}, tokenizationKey string) (*Rules, error) {
+	raw, ok := destinationConfig[rulesKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling %s: %w", rulesKey, err)
+	}
+	var conf []struct {
+		Field  string `json:"field"`
+		Type   string `json:"type"`
+		Action string `json:"action"`
+	}
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return nil, fmt.Errorf("unmarshalling %s: %w", rulesKey, err)
+	}
+	if len(conf) == 0 {
+		return nil, nil
+	}
+	r := &Rules{tokenizationKey: []byte(tokenizationKey)}
+	for i, c := range conf {
+		if c.Field == "" {
+			return nil, fmt.Errorf("rule %d: empty field", i)
+		}
+		switch c.Action {
+		case ActionHash, ActionMask, ActionRemove:
+		case ActionTokenize:
+			if tokenizationKey == "" {
+				return nil, fmt.Errorf("rule %d: %w", i, ErrMissingTokenizationKey)
+			}
+		default:
+			return nil, fmt.Errorf("rule %d: unsupported action %q", i, c.Action)
+		}
+		switch c.Type {
+		case "":
+			c.Type = TypeGeneric
+		case TypeEmail, TypeIP, TypePhone, TypeGeneric:
+		default:
+			return nil, fmt.Errorf("rule %d: unsupported type %q", i, c.Type)
+		}
+		r.rules = append(r.rules, rule{field: c.Field, path: strings.Split(c.Field, "."), action: c.Action, typ: c.Type})
+	}
+	return r, nil
+}
+
+// Apply applies the rules on the event, returning the resulting event along with the rules which were applied.
+// The provided event is never modified, since it can be shared between destinations: maps along the paths of modified fields are copied instead.
+// Nil Rules return the event as is.
+func (r *Rules) Apply(event types.SingularEventT) (types.SingularEventT, []Applied) {
+	if r == nil {
+		return event, nil
+	}
+	var applied []Applied
+	for _, rl := range r.rules {
+		value := misc.MapLookup(event, rl.path...)
+		if value == nil {
+			continue
+		}
+		if rl.action == ActionRemove {
+			event = with(event, rl.path, nil, true)
+			applied = append(applied, Applied{Field: rl.field, Action: rl.action})
+			continue
+		}
+		s, ok := scalar(value)
+		if !ok || s == "" {
+			continue
+		}
+		var result string
+		switch rl.action {
+		case ActionHash:
+			sum := sha256.Sum256([]byte(normalize(rl.typ, s)))
+			result = hex.EncodeToString(sum[:])
+		case ActionTokenize:
+			mac := hmac.New(sha256.New, r.tokenizationKey)
+			mac.Write([]byte(normalize(rl.typ, s)))
+			result = "tok_" + hex.EncodeToString(mac.Sum(nil))
+		case ActionMask:
+			result = mask(rl.typ, s)
+		}
+		event = with(event, rl.path, result, false)
+		applied = append(applied, Applied{Field: rl.field, Action: rl.action})
+	}
+	return event, applied
+}
+
+// with returns a copy of m having the value at path replaced (or removed), copying every map along the path
+func with(m map[string]interface{}, path []string, value interface{}, remove bool) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	if len(path) == 1 {
+		if remove {
+			delete(c, path[0])
+		} else {
+			c[path[0]] = value
+		}
+		return c
+	}
+	if nested, ok := c[path[0]].(map[string]interface{}); ok {
+		c[path[0]] = with(nested, path[1:], value, remove)
+	}
+	return c
+}
+
+func scalar(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	}
+	return "", false
+}
+
+func normalize(typ, s string) string {
+	s = strings.TrimSpace(s)
+	switch typ {
+	case TypeEmail:
+		return strings.ToLower(s)
+	case TypePhone:
+		return digits(s)
+	}
+	return s
+}
+
+func digits(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+func mask(typ, s string) string {
+	switch typ {
+	case TypeEmail:
+		at := strings.LastIndex(s, "@")
+		if at > 0 {
+			return s[:1] + "***" + s[at:]
+		}
+	case TypeIP:
+		if ip := net.ParseIP(strings.TrimSpace(s)); ip != nil {
+			if v4 := ip.To4(); v4 != nil {
+				return v4.Mask(net.CIDRMask(24, 32)).String()
+			}
+			return ip.Mask(net.CIDRMask(48, 128)).String()
+		}
+	case TypePhone:
+		// keep the last 4 digits, masking every other digit
+		remaining := len(digits(s)) - 4
+		b := []byte(s)
+		for i := range b {
+			if b[i] >= '0' && b[i] <= '9' && remaining > 0 {
+				b[i] = '*'
+				remaining--
+			}
+		}
+		return string(b)
+	}
+	// keep the last 4 characters
+	runes := []rune(s)
+	if len(runes) <= 4 {
+		return strings.Repeat("*", len(runes))
+	}
+	return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+}
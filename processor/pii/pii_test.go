@@ -0,0 +1,109 @@
+package pii
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+func TestRules(t *testing.T) {
+	parse := func(t *testing.T, rules string) *Rules {
+		var conf map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(`{"piiRules":`+rules+`}`), &conf))
+		r, err := NewRules(conf, "secret")
+		require.NoError(t, err)
+		return r
+	}
+	sha := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+
+	t.Run("no rules configured", func(t *testing.T) {
+		r, err := NewRules(map[string]interface{}{"apiKey": "key"}, "")
+		require.NoError(t, err)
+		require.Nil(t, r)
+		event := types.SingularEventT{"userId": "user"}
+		out, applied := r.Apply(event)
+		require.Equal(t, event, out)
+		require.Empty(t, applied)
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		r := parse(t, `[
+			{"field": "context.traits.email", "type": "email", "action": "hash"},
+			{"field": "context.ip", "type": "ip", "action": "mask"},
+			{"field": "context.traits.phone", "type": "phone", "action": "tokenize"},
+			{"field": "properties.ssn", "action": "remove"},
+			{"field": "properties.card", "action": "mask"},
+			{"field": "properties.missing", "action": "hash"}
+		]`)
+		event := types.SingularEventT{
+			"type": "identify",
+			"context": map[string]interface{}{
+				"ip":     "192.168.1.42",
+				"traits": map[string]interface{}{"email": " John.Doe@Example.com ", "phone": "+1 (555) 123-4567", "name": "John"},
+			},
+			"properties": map[string]interface{}{"ssn": "123-45-6789", "card": "4111111111111111"},
+		}
+		out, applied := r.Apply(event)
+
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte("15551234567"))
+		require.Equal(t, types.SingularEventT{
+			"type": "identify",
+			"context": map[string]interface{}{
+				"ip":     "192.168.1.0",
+				"traits": map[string]interface{}{"email": sha("john.doe@example.com"), "phone": "tok_" + hex.EncodeToString(mac.Sum(nil)), "name": "John"},
+			},
+			"properties": map[string]interface{}{"card": "************1111"},
+		}, out)
+		require.Equal(t, []Applied{
+			{Field: "context.traits.email", Action: ActionHash},
+			{Field: "context.ip", Action: ActionMask},
+			{Field: "context.traits.phone", Action: ActionTokenize},
+			{Field: "properties.ssn", Action: ActionRemove},
+			{Field: "properties.card", Action: ActionMask},
+		}, applied)
+
+		require.Equal(t, " John.Doe@Example.com ", event["context"].(map[string]interface{})["traits"].(map[string]interface{})["email"], "the original event should not be modified")
+		require.Equal(t, "123-45-6789", event["properties"].(map[string]interface{})["ssn"], "the original event should not be modified")
+	})
+
+	t.Run("mask", func(t *testing.T) {
+		testCases := []struct {
+			typ, value, expected string
+		}{
+			{TypeEmail, "john.doe@example.com", "j***@example.com"},
+			{TypeEmail, "not-an-email", "********mail"},
+			{TypeIP, "2001:db8:85a3:8d3:1319:8a2e:370:7348", "2001:db8:85a3::"},
+			{TypeIP, "10.0.0.1", "10.0.0.0"},
+			{TypePhone, "+1 (555) 123-4567", "+* (***) ***-4567"},
+			{TypeGeneric, "abc", "***"},
+		}
+		for _, tc := range testCases {
+			require.Equal(t, tc.expected, mask(tc.typ, tc.value), tc.value)
+		}
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		for _, rules := range []string{
+			`[{"field": "", "action": "hash"}]`,
+			`[{"field": "email", "action": "encrypt"}]`,
+			`[{"field": "email", "type": "ssn", "action": "hash"}]`,
+			`{"field": "email"}`,
+		} {
+			var conf map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(`{"piiRules":`+rules+`}`), &conf))
+			_, err := NewRules(conf, "secret")
+			require.Error(t, err, rules)
+		}
+		_, err := NewRules(map[string]interface{}{"piiRules": []interface{}{map[string]interface{}{"field": "email", "action": "tokenize"}}}, "")
+		require.ErrorIs(t, err, ErrMissingTokenizationKey)
+	})
+}
@@ -29,14 +29,21 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/stats"
 	"github.com/rudderlabs/rudder-go-kit/stats/metric"
 	kitsync "github.com/rudderlabs/rudder-go-kit/sync"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
 
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/internal/enricher"
+	"github.com/rudderlabs/rudder-server/internal/liveevents"
+	"github.com/rudderlabs/rudder-server/internal/metering"
+	transformation_dlq "github.com/rudderlabs/rudder-server/internal/transformation-dlq"
 	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/processor/consentstore"
 	"github.com/rudderlabs/rudder-server/processor/delayed"
 	"github.com/rudderlabs/rudder-server/processor/eventfilter"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
 	"github.com/rudderlabs/rudder-server/processor/isolation"
+	"github.com/rudderlabs/rudder-server/processor/pii"
+	"github.com/rudderlabs/rudder-server/processor/propertymapping"
 	"github.com/rudderlabs/rudder-server/processor/stash"
 	"github.com/rudderlabs/rudder-server/processor/transformer"
 	"github.com/rudderlabs/rudder-server/router/batchrouter"
@@ -55,6 +62,7 @@ import (
 	. "github.com/rudderlabs/rudder-server/utils/tx" //nolint:staticcheck
 	"github.com/rudderlabs/rudder-server/utils/types"
 	"github.com/rudderlabs/rudder-server/utils/workerpool"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
 )
 
 const (
@@ -82,6 +90,32 @@ type trackedUsersReporter interface {
 	GenerateReportsFromJobs(jobs []*jobsdb.JobT, sourceIdFilter map[string]bool) []*trackedusers.UsersReport
 }
 
+// transformationDLQ persists the events which failed in user transformations, so that they can be re-run later on
+type transformationDLQ interface {
+	Add(ctx context.Context, entries []transformation_dlq.Entry) error
+}
+
+// usageMeter meters the events ingested by the sources and their monthly tracked users
+type usageMeter interface {
+	GenerateReports(jobs []*jobsdb.JobT) []*metering.Report
+	Record(ctx context.Context, reports []*metering.Report, tx *Tx) error
+}
+
+// checkpointStore records which steps of storing the outputs of gateway jobs are completed, so that the jobs of a store
+// interrupted by a crash are not stored again in the jobsdbs they already were stored in
+type checkpointStore interface {
+	Completed(step string, jobID int64) bool
+	Record(ctx context.Context, tx *Tx, step string, jobIDs []int64) error
+	Clear(ctx context.Context, tx *Tx, jobIDs []int64) error
+}
+
+// the steps of storing the outputs of gateway jobs
+const (
+	storeStepBatchRouter = "batch_rt"
+	storeStepRouter      = "rt"
+	storeStepProcError   = "proc_error"
+)
+
 // Handle is a handle to the processor module
 type Handle struct {
 	conf          *config.Config
@@ -133,6 +167,7 @@ type Handle struct {
 		maxLoopSleep                    config.ValueLoader[time.Duration]
 		storeTimeout                    config.ValueLoader[time.Duration]
 		maxEventsToProcess              config.ValueLoader[int]
+		workspaceJobsLimit              config.ValueLoader[int]
 		transformBatchSize              config.ValueLoader[int]
 		userTransformBatchSize          config.ValueLoader[int]
 		sourceIdDestinationMap          map[string][]backendconfig.DestinationT
@@ -140,8 +175,18 @@ type Handle struct {
 		workspaceLibrariesMap           map[string]backendconfig.LibrariesT
 		oneTrustConsentCategoriesMap    map[string][]string
 		connectionConfigMap             map[connection]backendconfig.Connection
+		eventFilteringRulesMap          map[connection]*eventfilter.RuleSet
+		eventSamplersMap                map[connection]*eventfilter.Sampler
+		destPIIRulesMap                 map[string]*pii.Rules
+		destPIIRulesErrMap              map[string]error
+		propertyMappingsMap             map[connection]*propertymapping.Mappings
+		piiTokenizationKey              string
 		ketchConsentCategoriesMap       map[string][]string
 		destGenericConsentManagementMap map[string]map[string]GenericConsentManagementProviderData
+		serverConsentCategoriesMap      map[string][]string
+		consentStoreFailClosed          config.ValueLoader[bool]
+		consentStoreTimeout             config.ValueLoader[time.Duration]
+		consentStoreConcurrency         config.ValueLoader[int]
 		batchDestinations               []string
 		configSubscriberLock            sync.RWMutex
 		enableDedup                     bool
@@ -170,6 +215,10 @@ type Handle struct {
 
 	sourceObservers      []sourceObserver
 	trackedUsersReporter trackedUsersReporter
+	transformationDLQ    transformationDLQ  // nil if disabled
+	consentStore         consentstore.Store // nil if disabled
+	checkpoints          checkpointStore    // nil if disabled
+	usageMeter           usageMeter         // nil if disabled
 }
 type processorStats struct {
 	statGatewayDBR                func(partition string) stats.Measurement
@@ -238,6 +287,7 @@ type ParametersT struct {
 	RecordID                interface{} `json:"record_id"`
 	WorkspaceId             string      `json:"workspaceId"`
 	TraceParent             string      `json:"traceparent"`
+	CorrelationID           string      `json:"correlation_id,omitempty"`
 }
 
 type MetricMetadata struct {
@@ -622,6 +672,13 @@ func (proc *Handle) Setup(
 			return err
 		}
 	}
+	if proc.conf.GetBool("Processor.Consent.store.enabled", false) {
+		var err error
+		proc.consentStore, err = consentstore.NewHTTPStore(proc.conf, proc.statsFactory)
+		if err != nil {
+			return fmt.Errorf("setting up consent store: %w", err)
+		}
+	}
 	proc.sourceObservers = []sourceObserver{delayed.NewEventStats(proc.statsFactory, proc.conf)}
 	ctx, cancel := context.WithCancel(context.Background())
 	g, ctx := errgroup.WithContext(ctx)
@@ -794,10 +851,12 @@ func (proc *Handle) loadConfig() {
 	// Enable dedup of incoming events by default
 	proc.config.enableDedup = config.GetBoolVar(false, "Dedup.enableDedup")
 	proc.config.eventSchemaV2Enabled = config.GetBoolVar(false, "EventSchemas2.enabled")
-	proc.config.batchDestinations = misc.BatchDestinations()
+	proc.config.batchDestinations = append(misc.BatchDestinations(), warehouseutils.PluginWarehouses()...)
 	proc.config.transformTimesPQLength = config.GetIntVar(5, 1, "Processor.transformTimesPQLength")
 	// GWCustomVal is used as a key in the jobsDB customval column
 	proc.config.GWCustomVal = config.GetStringVar("GW", "Gateway.CustomVal")
+	// Key used for deterministic tokenization of pii fields
+	proc.config.piiTokenizationKey = config.GetStringVar("", "Processor.PII.tokenizationKey")
 
 	proc.loadReloadableConfig(defaultPayloadLimit, defaultMaxEventsToProcess)
 }
@@ -812,9 +871,17 @@ func (proc *Handle) loadReloadableConfig(defaultPayloadLimit int64, defaultMaxEv
 	proc.config.userTransformBatchSize = config.GetReloadableIntVar(200, 1, "Processor.userTransformBatchSize")
 	proc.config.enableEventCount = config.GetReloadableBoolVar(true, "Processor.enableEventCount")
 	proc.config.maxEventsToProcess = config.GetReloadableIntVar(defaultMaxEventsToProcess, 1, "Processor.maxLoopProcessEvents")
+	// limits the jobs of every workspace picked up in a loop, so that the backlog of a workspace doesn't hold back the rest
+	proc.config.workspaceJobsLimit = config.GetReloadableIntVar(0, 1, "Processor.workspaceJobsLimit")
 	proc.config.archivalEnabled = config.GetReloadableBoolVar(true, "archival.Enabled")
 	// Capture event name as a tag in event level stats
 	proc.config.captureEventNameStats = config.GetReloadableBoolVar(false, "Processor.Stats.captureEventName")
+	// Deny delivery to destinations with consent categories when the consent preferences of a user cannot be fetched from the consent store
+	proc.config.consentStoreFailClosed = config.GetReloadableBoolVar(false, "Processor.Consent.store.failClosed")
+	// Maximum time to wait for the consent preferences of a user from the consent store
+	proc.config.consentStoreTimeout = config.GetReloadableDurationVar(1, time.Second, "Processor.Consent.store.timeout")
+	// Maximum number of concurrent lookups of the consent preferences of the users of a batch
+	proc.config.consentStoreConcurrency = config.GetReloadableIntVar(10, 1, "Processor.Consent.store.concurrency")
 }
 
 type connection struct {
@@ -830,6 +897,7 @@ func (proc *Handle) backendConfigSubscriber(ctx context.Context) {
 			oneTrustConsentCategoriesMap    = make(map[string][]string)
 			ketchConsentCategoriesMap       = make(map[string][]string)
 			destGenericConsentManagementMap = make(map[string]map[string]GenericConsentManagementProviderData)
+			serverConsentCategoriesMap      = make(map[string][]string)
 			workspaceLibrariesMap           = make(map[string]backendconfig.LibrariesT, len(config))
 			sourceIdDestinationMap          = make(map[string][]backendconfig.DestinationT)
 			sourceIdSourceMap               = make(map[string]backendconfig.SourceT)
@@ -837,10 +905,30 @@ func (proc *Handle) backendConfigSubscriber(ctx context.Context) {
 			credentialsMap                  = make(map[string][]transformer.Credential)
 			nonEventStreamSources           = make(map[string]bool)
 			connectionConfigMap             = make(map[connection]backendconfig.Connection)
+			eventFilteringRulesMap          = make(map[connection]*eventfilter.RuleSet)
+			eventSamplersMap                = make(map[connection]*eventfilter.Sampler)
+			destPIIRulesMap                 = make(map[string]*pii.Rules)
+			destPIIRulesErrMap              = make(map[string]error)
+			propertyMappingsMap             = make(map[connection]*propertymapping.Mappings)
 		)
 		for workspaceID, wConfig := range config {
 			for _, conn := range wConfig.Connections {
 				connectionConfigMap[connection{sourceID: conn.SourceID, destinationID: conn.DestinationID}] = conn
+				if rules, err := eventfilter.NewRuleSet(conn.Config); err != nil {
+					proc.logger.Errorf("Invalid event filtering rules for connection %s -> %s, rules will not be applied: %v", conn.SourceID, conn.DestinationID, err)
+				} else if rules != nil {
+					eventFilteringRulesMap[connection{sourceID: conn.SourceID, destinationID: conn.DestinationID}] = rules
+				}
+				if sampler, err := eventfilter.NewSampler(conn.Config); err != nil {
+					proc.logger.Errorf("Invalid event sampling for connection %s -> %s, sampling will not be applied: %v", conn.SourceID, conn.DestinationID, err)
+				} else if sampler != nil {
+					eventSamplersMap[connection{sourceID: conn.SourceID, destinationID: conn.DestinationID}] = sampler
+				}
+				if mappings, err := propertymapping.New(conn.Config); err != nil {
+					proc.logger.Errorf("Invalid property mappings for connection %s -> %s, mappings will not be applied: %v", conn.SourceID, conn.DestinationID, err)
+				} else if mappings != nil {
+					propertyMappingsMap[connection{sourceID: conn.SourceID, destinationID: conn.DestinationID}] = mappings
+				}
 			}
 			for i := range wConfig.Sources {
 				source := &wConfig.Sources[i]
@@ -857,6 +945,15 @@ func (proc *Handle) backendConfigSubscriber(ctx context.Context) {
 						if err != nil {
 							proc.logger.Error(err)
 						}
+						if categories := getServerConsentCategories(proc.conf, destination); len(categories) > 0 {
+							serverConsentCategoriesMap[destination.ID] = categories
+						}
+						if rules, err := pii.NewRules(destination.Config, proc.config.piiTokenizationKey); err != nil {
+							proc.logger.Errorf("Invalid pii rules for destination %s, its events will be failed: %v", destination.ID, err)
+							destPIIRulesErrMap[destination.ID] = err
+						} else if rules != nil {
+							destPIIRulesMap[destination.ID] = rules
+						}
 					}
 				}
 				if source.SourceDefinition.Category != "" && !strings.EqualFold(source.SourceDefinition.Category, sourceCategoryWebhook) {
@@ -876,9 +973,15 @@ func (proc *Handle) backendConfigSubscriber(ctx context.Context) {
 		}
 		proc.config.configSubscriberLock.Lock()
 		proc.config.connectionConfigMap = connectionConfigMap
+		proc.config.eventFilteringRulesMap = eventFilteringRulesMap
+		proc.config.eventSamplersMap = eventSamplersMap
+		proc.config.destPIIRulesMap = destPIIRulesMap
+		proc.config.destPIIRulesErrMap = destPIIRulesErrMap
+		proc.config.propertyMappingsMap = propertyMappingsMap
 		proc.config.oneTrustConsentCategoriesMap = oneTrustConsentCategoriesMap
 		proc.config.ketchConsentCategoriesMap = ketchConsentCategoriesMap
 		proc.config.destGenericConsentManagementMap = destGenericConsentManagementMap
+		proc.config.serverConsentCategoriesMap = serverConsentCategoriesMap
 		proc.config.workspaceLibrariesMap = workspaceLibrariesMap
 		proc.config.sourceIdDestinationMap = sourceIdDestinationMap
 		proc.config.sourceIdSourceMap = sourceIdSourceMap
@@ -905,6 +1008,75 @@ func (proc *Handle) getConnectionConfig(conn connection) backendconfig.Connectio
 	return proc.config.connectionConfigMap[conn]
 }
 
+// getEventFilteringRules returns the event filtering rules of the connection, nil if it has none
+func (proc *Handle) getEventFilteringRules(conn connection) *eventfilter.RuleSet {
+	proc.config.configSubscriberLock.RLock()
+	defer proc.config.configSubscriberLock.RUnlock()
+	return proc.config.eventFilteringRulesMap[conn]
+}
+
+// getEventSampler returns the event sampler of the connection, nil if it has none
+func (proc *Handle) getEventSampler(conn connection) *eventfilter.Sampler {
+	proc.config.configSubscriberLock.RLock()
+	defer proc.config.configSubscriberLock.RUnlock()
+	return proc.config.eventSamplersMap[conn]
+}
+
+// getPropertyMappings returns the property mappings of the connection, nil if it has none
+func (proc *Handle) getPropertyMappings(sourceID, destinationID string) *propertymapping.Mappings {
+	proc.config.configSubscriberLock.RLock()
+	defer proc.config.configSubscriberLock.RUnlock()
+	return proc.config.propertyMappingsMap[connection{sourceID: sourceID, destinationID: destinationID}]
+}
+
+// getPIIRules returns the pii rules of the destination, nil if it has none, or the error of its invalid rules
+func (proc *Handle) getPIIRules(destID string) (*pii.Rules, error) {
+	proc.config.configSubscriberLock.RLock()
+	defer proc.config.configSubscriberLock.RUnlock()
+	if err, ok := proc.config.destPIIRulesErrMap[destID]; ok {
+		return nil, err
+	}
+	return proc.config.destPIIRulesMap[destID], nil
+}
+
+// maskPII applies the pii rules of the destination to the events. If the rules of the destination are invalid, all the
+// events are returned as failed instead, so that they never get delivered with their pii fields unmasked.
+func (proc *Handle) maskPII(events []transformer.TransformerEvent, destination *backendconfig.DestinationT, sourceID, workspaceID string) ([]transformer.TransformerEvent, []transformer.TransformerResponse) {
+	rules, err := proc.getPIIRules(destination.ID)
+	if err != nil {
+		return nil, lo.Map(events, func(event transformer.TransformerEvent, _ int) transformer.TransformerResponse {
+			return transformer.TransformerResponse{
+				Output:     event.Message,
+				StatusCode: 400,
+				Metadata:   event.Metadata,
+				Error:      fmt.Sprintf("invalid pii rules: %v", err),
+			}
+		})
+	}
+	if rules == nil {
+		return events, nil
+	}
+	appliedCount := make(map[pii.Applied]int)
+	for i := range events {
+		var applied []pii.Applied
+		events[i].Message, applied = rules.Apply(events[i].Message)
+		for _, a := range applied {
+			appliedCount[a]++
+		}
+	}
+	for a, count := range appliedCount {
+		proc.statsFactory.NewTaggedStat("proc_pii_masked_fields", stats.CountType, stats.Tags{
+			"workspaceId":   workspaceID,
+			"sourceId":      sourceID,
+			"destinationId": destination.ID,
+			"destType":      destination.DestinationDefinition.Name,
+			"field":         a.Field,
+			"action":        a.Action,
+		}).Count(count)
+	}
+	return events, nil
+}
+
 func (proc *Handle) getSourceBySourceID(sourceId string) (*backendconfig.SourceT, error) {
 	var err error
 	proc.config.configSubscriberLock.RLock()
@@ -1005,6 +1177,7 @@ func (proc *Handle) makeCommonMetadataFromSingularEvent(singularEvent types.Sing
 	commonMetadata.SourceDefinitionType = source.SourceDefinition.Type
 
 	commonMetadata.TraceParent = eventParams.TraceParent
+	commonMetadata.CorrelationID = eventParams.CorrelationID
 
 	return &commonMetadata
 }
@@ -1037,6 +1210,7 @@ func enhanceWithMetadata(commonMetadata *transformer.Metadata, event *transforme
 	metadata.DestinationType = destination.DestinationDefinition.Name
 	metadata.SourceDefinitionType = commonMetadata.SourceDefinitionType
 	metadata.TraceParent = commonMetadata.TraceParent
+	metadata.CorrelationID = commonMetadata.CorrelationID
 	event.Metadata = metadata
 }
 
@@ -1166,6 +1340,7 @@ func (proc *Handle) getTransformerEvents(
 		eventMetadata.DestinationDefinitionID = userTransformedEvent.Metadata.DestinationDefinitionID
 		eventMetadata.SourceCategory = userTransformedEvent.Metadata.SourceCategory
 		eventMetadata.TraceParent = userTransformedEvent.Metadata.TraceParent
+		eventMetadata.CorrelationID = userTransformedEvent.Metadata.CorrelationID
 		updatedEvent := transformer.TransformerEvent{
 			Message:     userTransformedEvent.Output,
 			Metadata:    *eventMetadata,
@@ -1355,6 +1530,68 @@ func (proc *Handle) getNonSuccessfulMetrics(
 	return m
 }
 
+// addToTransformationDLQ persists the events which failed in the user transformation to the transformation dead-letter queue, if enabled.
+// Failing to do so doesn't fail the pipeline, since failed events are still stored in the proc error DB.
+func (proc *Handle) addToTransformationDLQ(
+	ctx context.Context,
+	failedEvents []transformer.TransformerResponse,
+	commonMetaData *transformer.Metadata,
+	eventsByMessageID map[string]types.SingularEventWithReceivedAt,
+) {
+	if proc.transformationDLQ == nil {
+		return
+	}
+	var entries []transformation_dlq.Entry
+	for i := range failedEvents {
+		failedEvent := &failedEvents[i]
+		if failedEvent.StatusCode == types.FilterEventCode {
+			continue
+		}
+		messages := lo.Map(
+			failedEvent.Metadata.GetMessagesIDs(),
+			func(msgID string, _ int) types.SingularEventT {
+				return eventsByMessageID[msgID].SingularEvent
+			},
+		)
+		payload, err := jsonfast.Marshal(messages)
+		if err != nil {
+			proc.logger.Errorf(`[Processor: addToTransformationDLQ] Failed to marshal list of failed events: %v`, err)
+			continue
+		}
+		entries = append(entries, transformation_dlq.Entry{
+			WorkspaceID:             failedEvent.Metadata.WorkspaceID,
+			SourceID:                commonMetaData.SourceID,
+			DestinationID:           commonMetaData.DestinationID,
+			TransformationID:        failedEvent.Metadata.TransformationID,
+			TransformationVersionID: failedEvent.Metadata.TransformationVersionID,
+			UserID:                  failedEvent.Metadata.RudderID,
+			SourceJobRunID:          failedEvent.Metadata.SourceJobRunID,
+			SourceTaskRunID:         failedEvent.Metadata.SourceTaskRunID,
+			StatusCode:              failedEvent.StatusCode,
+			Error:                   failedEvent.Error,
+			Payload:                 payload,
+		})
+	}
+	if len(entries) == 0 {
+		return
+	}
+	tags := stats.Tags{
+		"workspaceId":   commonMetaData.WorkspaceID,
+		"sourceId":      commonMetaData.SourceID,
+		"destinationId": commonMetaData.DestinationID,
+	}
+	if err := proc.transformationDLQ.Add(ctx, entries); err != nil {
+		proc.logger.Errorn("Failed to add events to the transformation dead-letter queue",
+			obskit.SourceID(commonMetaData.SourceID),
+			obskit.DestinationID(commonMetaData.DestinationID),
+			obskit.Error(err),
+		)
+		proc.statsFactory.NewTaggedStat("proc_transformation_dlq_errors", stats.CountType, tags).Count(len(entries))
+		return
+	}
+	proc.statsFactory.NewTaggedStat("proc_transformation_dlq_entries", stats.CountType, tags).Count(len(entries))
+}
+
 func procFilteredCountStat(destType, pu, statusCode string) {
 	stats.Default.NewTaggedStat(
 		"proc_filtered_counts",
@@ -1448,6 +1685,9 @@ func (proc *Handle) getTransformationMetrics(
 			"record_id":          failedEvent.Metadata.RecordID,
 			"source_task_run_id": failedEvent.Metadata.SourceTaskRunID,
 		}
+		if failedEvent.Metadata.CorrelationID != "" {
+			params["correlation_id"] = failedEvent.Metadata.CorrelationID
+		}
 		if eventContext, castOk := failedEvent.Output["context"].(map[string]interface{}); castOk {
 			params["violationErrors"] = eventContext["violationErrors"]
 		}
@@ -1591,7 +1831,7 @@ func (proc *Handle) eventAuditEnabled(workspaceID string) bool {
 	return proc.config.eventAuditEnabled[workspaceID]
 }
 
-func (proc *Handle) processJobsForDest(partition string, subJobs subJob) *transformationMessage {
+func (proc *Handle) processJobsForDest(ctx context.Context, partition string, subJobs subJob) *transformationMessage {
 	if proc.limiter.preprocess != nil {
 		defer proc.limiter.preprocess.BeginWithPriority(partition, proc.getLimiterPriority(partition))()
 	}
@@ -1608,6 +1848,7 @@ func (proc *Handle) processJobsForDest(partition string, subJobs subJob) *transf
 	var procErrorJobs []*jobsdb.JobT
 	eventSchemaJobs := make([]*jobsdb.JobT, 0)
 	archivalJobs := make([]*jobsdb.JobT, 0)
+	consentPreferences := proc.prefetchConsentPreferences(ctx, jobList)
 
 	// Each block we receive from a client has a bunch of
 	// requests. We parse the block and take out individual
@@ -1634,8 +1875,8 @@ func (proc *Handle) processJobsForDest(partition string, subJobs subJob) *transf
 
 	outCountMap := make(map[string]int64) // destinations enabled
 	destFilterStatusDetailMap := make(map[string]map[string]*types.StatusDetail)
-	// map of jobID to destinationID: for messages that needs to be delivered to a specific destinations only
-	jobIDToSpecificDestMapOnly := make(map[int64]string)
+	// map of jobID to destinationIDs: for messages that needs to be delivered to specific destinations only
+	jobIDToSpecificDestMapOnly := make(map[int64][]string)
 
 	spans := make([]stats.TraceSpan, 0, len(jobList))
 	defer func() {
@@ -1653,9 +1894,10 @@ func (proc *Handle) processJobsForDest(partition string, subJobs subJob) *transf
 
 		sourceID := eventParams.SourceId
 		traceParent := eventParams.TraceParent
-		destinationID := eventParams.DestinationID
-		if destinationID != "" {
-			jobIDToSpecificDestMapOnly[batchEvent.JobID] = destinationID
+		if eventParams.DestinationID != "" {
+			jobIDToSpecificDestMapOnly[batchEvent.JobID] = []string{eventParams.DestinationID}
+		} else if len(eventParams.DestinationIDs) > 0 {
+			jobIDToSpecificDestMapOnly[batchEvent.JobID] = eventParams.DestinationIDs
 		}
 
 		var span stats.TraceSpan
@@ -1711,7 +1953,7 @@ func (proc *Handle) processJobsForDest(partition string, subJobs subJob) *transf
 		}
 
 		for _, enricher := range proc.enrichers {
-			if err := enricher.Enrich(source, &gatewayBatchEvent); err != nil {
+			if err := enricher.Enrich(ctx, source, &gatewayBatchEvent); err != nil {
 				proc.logger.Errorf("unable to enrich the gateway batch event: %v", err.Error())
 			}
 		}
@@ -1728,7 +1970,8 @@ func (proc *Handle) processJobsForDest(partition string, subJobs subJob) *transf
 				return payloadBytes
 			})
 
-			if proc.config.enableDedup {
+			// events re-run from the transformation dead-letter queue have already been seen, so they are not deduplicated
+			if proc.config.enableDedup && !eventParams.DLQReplay {
 				p := payloadFunc()
 				messageSize := int64(len(p))
 				dedupKey := fmt.Sprintf("%v%v", messageId, eventParams.SourceJobRunId)
@@ -1831,9 +2074,9 @@ func (proc *Handle) processJobsForDest(partition string, subJobs subJob) *transf
 
 			// Getting all the destinations which are enabled for this event.
 			// Event will be dropped if no valid destination is present
-			// if empty destinationID is passed in this fn all the destinations for the source are validated
-			// else only passed destinationID will be validated
-			if !proc.isDestinationAvailable(singularEvent, sourceID, destinationID) {
+			// if no destinationIDs are passed in this fn all the destinations for the source are validated
+			// else only passed destinationIDs will be validated
+			if !proc.isDestinationAvailable(ctx, singularEvent, sourceID, consentPreferences, jobIDToSpecificDestMapOnly[batchEvent.JobID]...) {
 				continue
 			}
 
@@ -2009,21 +2252,45 @@ func (proc *Handle) processJobsForDest(partition string, subJobs subJob) *transf
 			for i := range enabledDestTypes {
 				destType := &enabledDestTypes[i]
 				enabledDestinationsList := proc.getConsentFilteredDestinations(
+					ctx,
 					singularEvent,
 					lo.Filter(proc.getEnabledDestinations(sourceId, *destType), func(item backendconfig.DestinationT, index int) bool {
-						destId := jobIDToSpecificDestMapOnly[event.Metadata.JobID]
-						if destId != "" {
-							return destId == item.ID
+						destIds := jobIDToSpecificDestMapOnly[event.Metadata.JobID]
+						if len(destIds) > 0 {
+							return slices.Contains(destIds, item.ID)
 						}
-						return destId == ""
+						return true
 					}),
+					consentPreferences,
 				)
 
 				// Adding a singular event multiple times if there are multiple destinations of same type
 				for idx := range enabledDestinationsList {
 					destination := &enabledDestinationsList[idx]
+					conn := connection{sourceID: sourceId, destinationID: destination.ID}
+					// Declarative event filtering rules of the connection decide whether the event is routed to the destination,
+					// before any transformation takes place
+					if allowed, rule := proc.getEventFilteringRules(conn).Allow(singularEvent); !allowed {
+						proc.statsFactory.NewTaggedStat("proc_event_filtering_rules_dropped_events", stats.CountType, stats.Tags{
+							"sourceId":      sourceId,
+							"destinationId": destination.ID,
+							"destType":      destination.DestinationDefinition.Name,
+							"rule":          rule,
+						}).Increment()
+						continue
+					}
+					// Sampling of the connection drops the events of the users which are not part of its sample
+					if sampled, rule := proc.getEventSampler(conn).Sample(singularEvent); !sampled {
+						proc.statsFactory.NewTaggedStat("proc_event_sampling_dropped_events", stats.CountType, stats.Tags{
+							"sourceId":      sourceId,
+							"destinationId": destination.ID,
+							"destType":      destination.DestinationDefinition.Name,
+							"rule":          rule,
+						}).Increment()
+						continue
+					}
 					shallowEventCopy := transformer.TransformerEvent{}
-					shallowEventCopy.Connection = proc.getConnectionConfig(connection{sourceID: sourceId, destinationID: destination.ID})
+					shallowEventCopy.Connection = proc.getConnectionConfig(conn)
 					shallowEventCopy.Message = singularEvent
 					shallowEventCopy.Destination = *destination
 					shallowEventCopy.Libraries = workspaceLibraries
@@ -2067,6 +2334,10 @@ func (proc *Handle) processJobsForDest(partition string, subJobs subJob) *transf
 	trackedUsersReportGenStart := time.Now()
 	trackedUsersReports := proc.trackedUsersReporter.GenerateReportsFromJobs(jobList, proc.getNonEventStreamSources())
 	proc.stats.trackedUsersReportGeneration(partition).SendTiming(time.Since(trackedUsersReportGenStart))
+	var usageReports []*metering.Report
+	if proc.usageMeter != nil {
+		usageReports = proc.usageMeter.GenerateReports(jobList)
+	}
 
 	processTime := time.Since(start)
 	proc.stats.processJobsTime(partition).SendTiming(processTime)
@@ -2090,6 +2361,7 @@ func (proc *Handle) processJobsForDest(partition string, subJobs subJob) *transf
 		subJobs.hasMore,
 		subJobs.rsourcesStats,
 		trackedUsersReports,
+		usageReports,
 	}
 }
 
@@ -2111,6 +2383,7 @@ type transformationMessage struct {
 	hasMore             bool
 	rsourcesStats       rsources.StatsCollector
 	trackedUsersReports []*trackedusers.UsersReport
+	usageReports        []*metering.Report
 }
 
 func (proc *Handle) transformations(partition string, in *transformationMessage) *storeMessage {
@@ -2207,6 +2480,7 @@ func (proc *Handle) transformations(partition string, in *transformationMessage)
 
 	return &storeMessage{
 		in.trackedUsersReports,
+		in.usageReports,
 		in.statusList,
 		destJobs,
 		batchDestJobs,
@@ -2229,6 +2503,7 @@ func (proc *Handle) transformations(partition string, in *transformationMessage)
 
 type storeMessage struct {
 	trackedUsersReports []*trackedusers.UsersReport
+	usageReports        []*metering.Report
 	statusList          []*jobsdb.JobStatusT
 	destJobs            []*jobsdb.JobT
 	batchDestJobs       []*jobsdb.JobT
@@ -2272,6 +2547,7 @@ func (sm *storeMessage) merge(subJob *storeMessage) {
 	sm.totalEvents += subJob.totalEvents
 
 	sm.trackedUsersReports = append(sm.trackedUsersReports, subJob.trackedUsersReports...)
+	sm.usageReports = append(sm.usageReports, subJob.usageReports...)
 }
 
 func (proc *Handle) sendRetryStoreStats(attempt int) {
@@ -2307,6 +2583,18 @@ func (proc *Handle) Store(partition string, in *storeMessage) {
 	}
 
 	statusList, destJobs, batchDestJobs := in.statusList, in.destJobs, in.batchDestJobs
+	for _, jobs := range in.procErrorJobsByDestID {
+		in.procErrorJobs = append(in.procErrorJobs, jobs...)
+	}
+	gatewayJobIDs := lo.Map(statusList, func(status *jobsdb.JobStatusT, _ int) int64 { return status.JobID })
+	if proc.checkpoints != nil {
+		// the outputs already stored by a previous attempt interrupted before updating the gateway jobs are skipped
+		batchDestJobs = proc.skipCheckpointed(storeStepBatchRouter, batchDestJobs)
+		destJobs = proc.skipCheckpointed(storeStepRouter, destJobs)
+		if proc.checkpointed(storeStepProcError, gatewayJobIDs) {
+			in.procErrorJobs = nil
+		}
+	}
 	beforeStoreStatus := time.Now()
 	// XX: Need to do this in a transaction
 	if len(batchDestJobs) > 0 {
@@ -2323,6 +2611,10 @@ func (proc *Handle) Store(partition string, in *storeMessage) {
 							return fmt.Errorf("storing batch router jobs: %w", err)
 						}
 
+						if err := proc.recordCheckpoint(ctx, tx.Tx(), storeStepBatchRouter, gatewayJobIDs); err != nil {
+							return err
+						}
+
 						// rsources stats
 						err = proc.updateRudderSourcesStats(ctx, tx, batchDestJobs)
 						if err != nil {
@@ -2378,6 +2670,10 @@ func (proc *Handle) Store(partition string, in *storeMessage) {
 								return fmt.Errorf("storing router jobs: %w", err)
 							}
 
+							if err := proc.recordCheckpoint(ctx, tx.Tx(), storeStepRouter, gatewayJobIDs); err != nil {
+								return err
+							}
+
 							// rsources stats
 							err = proc.updateRudderSourcesStats(ctx, tx, destJobs)
 							if err != nil {
@@ -2399,12 +2695,17 @@ func (proc *Handle) Store(partition string, in *storeMessage) {
 		}()
 	}
 
-	for _, jobs := range in.procErrorJobsByDestID {
-		in.procErrorJobs = append(in.procErrorJobs, jobs...)
-	}
 	if len(in.procErrorJobs) > 0 {
 		err := misc.RetryWithNotify(context.Background(), proc.jobsDBCommandTimeout.Load(), proc.jobdDBMaxRetries.Load(), func(ctx context.Context) error {
-			return proc.writeErrorDB.Store(ctx, in.procErrorJobs)
+			if proc.checkpoints == nil {
+				return proc.writeErrorDB.Store(ctx, in.procErrorJobs)
+			}
+			return proc.writeErrorDB.WithStoreSafeTx(ctx, func(tx jobsdb.StoreSafeTx) error {
+				if err := proc.writeErrorDB.StoreInTx(ctx, tx, in.procErrorJobs); err != nil {
+					return fmt.Errorf("storing proc error jobs: %w", err)
+				}
+				return proc.recordCheckpoint(ctx, tx.Tx(), storeStepProcError, gatewayJobIDs)
+			})
 		}, proc.sendRetryStoreStats)
 		if err != nil {
 			proc.logger.Errorf("Store into proc error table failed with error: %v", err)
@@ -2442,11 +2743,23 @@ func (proc *Handle) Store(partition string, in *storeMessage) {
 				return fmt.Errorf("storing tracked users: %w", err)
 			}
 
+			if proc.usageMeter != nil {
+				if err := proc.usageMeter.Record(ctx, in.usageReports, tx.Tx()); err != nil {
+					return fmt.Errorf("recording usage: %w", err)
+				}
+			}
+
 			err = in.rsourcesStats.Publish(ctx, tx.SqlTx())
 			if err != nil {
 				return fmt.Errorf("publishing rsources stats: %w", err)
 			}
 
+			if proc.checkpoints != nil {
+				if err := proc.checkpoints.Clear(ctx, tx.Tx(), gatewayJobIDs); err != nil {
+					return err
+				}
+			}
+
 			return nil
 		})
 	}, proc.sendRetryUpdateStats)
@@ -2477,6 +2790,29 @@ func (proc *Handle) Store(partition string, in *storeMessage) {
 	proc.stats.statProcErrDBW(partition).Count(len(in.procErrorJobs))
 }
 
+// skipCheckpointed returns the jobs whose gateway job has not completed the given store step yet
+func (proc *Handle) skipCheckpointed(step string, jobs []*jobsdb.JobT) []*jobsdb.JobT {
+	return lo.Filter(jobs, func(job *jobsdb.JobT, _ int) bool {
+		return !proc.checkpoints.Completed(step, gjson.GetBytes(job.Parameters, "gateway_job_id").Int())
+	})
+}
+
+// checkpointed returns whether the gateway jobs all completed the given store step. Proc error jobs don't reference
+// their gateway job, so they are only skipped if the whole batch already stored them.
+func (proc *Handle) checkpointed(step string, gatewayJobIDs []int64) bool {
+	return len(gatewayJobIDs) > 0 && lo.EveryBy(gatewayJobIDs, func(jobID int64) bool {
+		return proc.checkpoints.Completed(step, jobID)
+	})
+}
+
+// recordCheckpoint records the store step as completed for the gateway jobs, if checkpoints are enabled
+func (proc *Handle) recordCheckpoint(ctx context.Context, tx *Tx, step string, gatewayJobIDs []int64) error {
+	if proc.checkpoints == nil {
+		return nil
+	}
+	return proc.checkpoints.Record(ctx, tx, step, gatewayJobIDs)
+}
+
 // getJobCountsByWorkspaceDestType returns the number of jobs per workspace and destination type
 //
 // map[workspaceID]map[destType]count
@@ -2619,6 +2955,7 @@ func (proc *Handle) transformSrcDest(
 				procErrorJobsByDestID[destID] = make([]*jobsdb.JobT, 0)
 			}
 			procErrorJobsByDestID[destID] = append(procErrorJobsByDestID[destID], nonSuccessMetrics.failedJobs...)
+			proc.addToTransformationDLQ(ctx, response.FailedEvents, commonMetaData, eventsByMessageID)
 			userTransformationStat.numOutputSuccessEvents.Count(len(eventsToTransform))
 			userTransformationStat.numOutputFailedEvents.Count(len(nonSuccessMetrics.failedJobs))
 			userTransformationStat.numOutputFilteredEvents.Count(len(nonSuccessMetrics.filteredJobs))
@@ -2754,6 +3091,62 @@ func (proc *Handle) transformSrcDest(
 		}
 	}
 
+	// Property mapping - START
+	if mappings := proc.getPropertyMappings(sourceID, destID); mappings != nil {
+		var mappedEvents int
+		for i := range eventsToTransform {
+			var applied int
+			eventsToTransform[i].Message, applied = mappings.Apply(eventsToTransform[i].Message)
+			if applied > 0 {
+				mappedEvents++
+			}
+		}
+		proc.statsFactory.NewTaggedStat("proc_property_mapping_events", stats.CountType, stats.Tags{
+			"workspaceId":   workspaceID,
+			"sourceId":      sourceID,
+			"destinationId": destID,
+			"destType":      destination.DestinationDefinition.Name,
+		}).Count(mappedEvents)
+	}
+	// Property mapping - END
+
+	// PII masking - START
+	// Applied right before the destination transformation, so that user transformations still see the original values
+	var piiFailedEvents []transformer.TransformerResponse
+	eventsToTransform, piiFailedEvents = proc.maskPII(eventsToTransform, destination, sourceID, workspaceID)
+	if len(piiFailedEvents) > 0 {
+		response = transformer.Response{FailedEvents: piiFailedEvents}
+		nonSuccessMetrics := proc.getNonSuccessfulMetrics(response, commonMetaData, eventsByMessageID, types.EVENT_FILTER, types.DEST_TRANSFORMER)
+		droppedJobs = append(droppedJobs, append(nonSuccessMetrics.failedJobs, nonSuccessMetrics.filteredJobs...)...)
+		procErrorJobsByDestID[destID] = append(procErrorJobsByDestID[destID], nonSuccessMetrics.failedJobs...)
+		if proc.isReportingEnabled() {
+			diffMetrics := getDiffMetrics(
+				types.EVENT_FILTER,
+				types.DEST_TRANSFORMER,
+				inCountMetadataMap,
+				inCountMap,
+				map[string]int64{},
+				nonSuccessMetrics.failedCountMap,
+				nonSuccessMetrics.filteredCountMap,
+				proc.statsFactory,
+			)
+			reportMetrics = append(reportMetrics, nonSuccessMetrics.failedMetrics...)
+			reportMetrics = append(reportMetrics, diffMetrics...)
+		}
+	}
+	// PII masking - END
+
+	if len(eventsToTransform) == 0 {
+		return transformSrcDestOutput{
+			destJobs:        destJobs,
+			batchDestJobs:   batchDestJobs,
+			errorsPerDestID: procErrorJobsByDestID,
+			reportMetrics:   reportMetrics,
+			routerDestIDs:   routerDestIDs,
+			droppedJobs:     droppedJobs,
+		}
+	}
+
 	// Destination transformation - START
 	// Send to transformer only if is
 	// a. transformAt is processor
@@ -2888,6 +3281,7 @@ func (proc *Handle) transformSrcDest(
 				RecordID:                recordId,
 				WorkspaceId:             workspaceId,
 				TraceParent:             metadata.TraceParent,
+				CorrelationID:           metadata.CorrelationID,
 			}
 			marshalledParams, err := jsonfast.Marshal(params)
 			if err != nil {
@@ -2905,6 +3299,7 @@ func (proc *Handle) transformSrcDest(
 				EventPayload: destEventJSON,
 				WorkspaceId:  workspaceId,
 			}
+			liveevents.Default.Record(liveevents.Transformed, sourceID, destID, destEventJSON)
 			if slices.Contains(proc.config.batchDestinations, newJob.CustomVal) {
 				batchDestJobs = append(batchDestJobs, &newJob)
 			} else {
@@ -3054,10 +3449,11 @@ func (proc *Handle) getJobs(partition string) jobsdb.JobsResult {
 		eventCount = 0
 	}
 	queryParams := jobsdb.GetQueryParams{
-		CustomValFilters: []string{proc.config.GWCustomVal},
-		JobsLimit:        proc.config.maxEventsToProcess.Load(),
-		EventsLimit:      eventCount,
-		PayloadSizeLimit: proc.adaptiveLimit(proc.payloadLimit.Load()),
+		CustomValFilters:   []string{proc.config.GWCustomVal},
+		JobsLimit:          proc.config.maxEventsToProcess.Load(),
+		EventsLimit:        eventCount,
+		PayloadSizeLimit:   proc.adaptiveLimit(proc.payloadLimit.Load()),
+		WorkspaceJobsLimit: proc.config.workspaceJobsLimit.Load(),
 	}
 	proc.isolationStrategy.AugmentQueryParams(partition, &queryParams)
 
@@ -3132,7 +3528,7 @@ func (proc *Handle) markExecuting(partition string, jobs []*jobsdb.JobT) error {
 
 // handlePendingGatewayJobs is checking for any pending gateway jobs (failed and unprocessed), and routes them appropriately
 // Returns true if any job is handled, otherwise returns false.
-func (proc *Handle) handlePendingGatewayJobs(partition string) bool {
+func (proc *Handle) handlePendingGatewayJobs(ctx context.Context, partition string) bool {
 	s := time.Now()
 
 	unprocessedList := proc.getJobs(partition)
@@ -3146,7 +3542,7 @@ func (proc *Handle) handlePendingGatewayJobs(partition string) bool {
 
 	proc.Store(partition,
 		proc.transformations(partition,
-			proc.processJobsForDest(partition, subJob{
+			proc.processJobsForDest(ctx, partition, subJob{
 				subJobs:       unprocessedList.Jobs,
 				hasMore:       false,
 				rsourcesStats: rsourcesStats,
@@ -3229,7 +3625,8 @@ func (*Handle) getLimiterPriority(partition string) kitsync.LimiterPriorityValue
 // check if event has eligible destinations to send to
 //
 // event will be dropped if no destination is found
-func (proc *Handle) isDestinationAvailable(event types.SingularEventT, sourceId, destinationID string) bool {
+func (proc *Handle) isDestinationAvailable(ctx context.Context, event types.SingularEventT, sourceId string, consentPreferences consentPreferencesCache, destinationIDs ...string) bool {
+	destinationIDs = lo.Compact(destinationIDs)
 	enabledDestTypes := integrations.FilterClientIntegrations(
 		event,
 		proc.getBackendEnabledDestinationTypes(sourceId),
@@ -3240,6 +3637,7 @@ func (proc *Handle) isDestinationAvailable(event types.SingularEventT, sourceId,
 	}
 
 	if enabledDestinationsList := lo.Filter(proc.getConsentFilteredDestinations(
+		ctx,
 		event,
 		lo.Flatten(
 			lo.Map(
@@ -3249,8 +3647,9 @@ func (proc *Handle) isDestinationAvailable(event types.SingularEventT, sourceId,
 				},
 			),
 		),
+		consentPreferences,
 	), func(dest backendconfig.DestinationT, index int) bool {
-		return len(destinationID) == 0 || dest.ID == destinationID
+		return len(destinationIDs) == 0 || slices.Contains(destinationIDs, dest.ID)
 	}); len(enabledDestinationsList) == 0 {
 		proc.logger.Debug("No destination to route this event to")
 		return false
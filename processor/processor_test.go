@@ -39,6 +39,7 @@ import (
 	mock_features "github.com/rudderlabs/rudder-server/mocks/services/transformer"
 	mockReportingTypes "github.com/rudderlabs/rudder-server/mocks/utils/types"
 	"github.com/rudderlabs/rudder-server/processor/isolation"
+	"github.com/rudderlabs/rudder-server/processor/pii"
 	"github.com/rudderlabs/rudder-server/processor/transformer"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
 	transformationdebugger "github.com/rudderlabs/rudder-server/services/debugger/transformation"
@@ -965,6 +966,7 @@ var _ = Describe("Tracking Plan Validation", Ordered, func() {
 			GinkgoT().Log("Processor setup and init done")
 
 			_ = processor.processJobsForDest(
+				context.Background(),
 				"",
 				subJob{
 					subJobs: []*jobsdb.JobT{
@@ -1040,6 +1042,7 @@ var _ = Describe("Tracking Plan Validation", Ordered, func() {
 			GinkgoT().Log("Processor setup and init done")
 
 			_ = processor.processJobsForDest(
+				context.Background(),
 				"",
 				subJob{
 					subJobs: []*jobsdb.JobT{
@@ -1293,6 +1296,7 @@ var _ = Describe("Processor with event schemas v2", Ordered, func() {
 			Expect(processor.config.asyncInit.WaitContext(ctx)).To(BeNil())
 			GinkgoT().Log("Processor setup and init done")
 			_ = processor.processJobsForDest(
+				context.Background(),
 				"",
 				subJob{
 					subJobs: unprocessedJobsList,
@@ -1478,6 +1482,7 @@ var _ = Describe("Processor with ArchivalV2 enabled", Ordered, func() {
 			Expect(processor.config.asyncInit.WaitContext(ctx)).To(BeNil())
 			GinkgoT().Log("Processor setup and init done")
 			_ = processor.processJobsForDest(
+				context.Background(),
 				"",
 				subJob{
 					subJobs: unprocessedJobsList,
@@ -1629,6 +1634,7 @@ var _ = Describe("Processor with ArchivalV2 enabled", Ordered, func() {
 			Expect(processor.config.asyncInit.WaitContext(ctx)).To(BeNil())
 			GinkgoT().Log("Processor setup and init done")
 			_ = processor.processJobsForDest(
+				context.Background(),
 				"",
 				subJob{
 					subJobs: unprocessedJobsList,
@@ -2083,7 +2089,7 @@ var _ = Describe("Processor", Ordered, func() {
 					PayloadSizeLimit: processor.payloadLimit.Load(),
 				}).Return(jobsdb.JobsResult{Jobs: emptyJobsList}, nil).Times(1)
 
-			didWork := processor.handlePendingGatewayJobs("")
+			didWork := processor.handlePendingGatewayJobs(context.Background(), "")
 			Expect(didWork).To(Equal(false))
 		})
 
@@ -3341,47 +3347,55 @@ var _ = Describe("Processor", Ordered, func() {
 			defer cancel()
 			Expect(processor.config.asyncInit.WaitContext(ctx)).To(BeNil())
 
-			Expect(processor.isDestinationAvailable(eventWithDeniedConsents, SourceIDOneTrustConsent, "")).To(BeTrue())
+			Expect(processor.isDestinationAvailable(context.Background(), eventWithDeniedConsents, SourceIDOneTrustConsent, nil, "")).To(BeTrue())
 			Expect(
 				len(processor.getConsentFilteredDestinations(
+					context.Background(),
 					eventWithDeniedConsents,
 					processor.getEnabledDestinations(
 						SourceIDOneTrustConsent,
 						"destination-definition-name-enabled",
 					),
+					nil,
 				)),
 			).To(Equal(3)) // all except D1 and D3
 
-			Expect(processor.isDestinationAvailable(eventWithoutDeniedConsents, SourceIDOneTrustConsent, "")).To(BeTrue())
+			Expect(processor.isDestinationAvailable(context.Background(), eventWithoutDeniedConsents, SourceIDOneTrustConsent, nil, "")).To(BeTrue())
 			Expect(
 				len(processor.getConsentFilteredDestinations(
+					context.Background(),
 					eventWithoutDeniedConsents,
 					processor.getEnabledDestinations(
 						SourceIDOneTrustConsent,
 						"destination-definition-name-enabled",
 					),
+					nil,
 				)),
 			).To(Equal(5)) // all
 
-			Expect(processor.isDestinationAvailable(eventWithoutConsentManagementData, SourceIDOneTrustConsent, "")).To(BeTrue())
+			Expect(processor.isDestinationAvailable(context.Background(), eventWithoutConsentManagementData, SourceIDOneTrustConsent, nil, "")).To(BeTrue())
 			Expect(
 				len(processor.getConsentFilteredDestinations(
+					context.Background(),
 					eventWithoutConsentManagementData,
 					processor.getEnabledDestinations(
 						SourceIDOneTrustConsent,
 						"destination-definition-name-enabled",
 					),
+					nil,
 				)),
 			).To(Equal(5)) // all
 
-			Expect(processor.isDestinationAvailable(eventWithoutConsentManagementData, SourceIDOneTrustConsent, "dest-id-1")).To(BeTrue())
+			Expect(processor.isDestinationAvailable(context.Background(), eventWithoutConsentManagementData, SourceIDOneTrustConsent, nil, "dest-id-1")).To(BeTrue())
 			Expect(
 				len(processor.getConsentFilteredDestinations(
+					context.Background(),
 					eventWithoutConsentManagementData,
 					processor.getEnabledDestinations(
 						SourceIDOneTrustConsent,
 						"destination-definition-name-enabled",
 					),
+					nil,
 				)),
 			).To(Equal(5)) // all
 		})
@@ -3426,14 +3440,16 @@ var _ = Describe("Processor", Ordered, func() {
 			Expect(processor.config.asyncInit.WaitContext(ctx)).To(BeNil())
 
 			filteredDestinations := processor.getConsentFilteredDestinations(
+				context.Background(),
 				event,
 				processor.getEnabledDestinations(
 					SourceIDKetchConsent,
 					"destination-definition-name-enabled",
 				),
+				nil,
 			)
 			Expect(len(filteredDestinations)).To(Equal(4)) // all except dest-id-5 since both purpose1 and purpose2 are denied
-			Expect(processor.isDestinationAvailable(event, SourceIDKetchConsent, "")).To(BeTrue())
+			Expect(processor.isDestinationAvailable(context.Background(), event, SourceIDKetchConsent, nil, "")).To(BeTrue())
 		})
 
 		It("should filter based on generic consent management preferences", func() {
@@ -3590,69 +3606,82 @@ var _ = Describe("Processor", Ordered, func() {
 			defer cancel()
 			Expect(processor.config.asyncInit.WaitContext(ctx)).To(BeNil())
 
-			Expect(processor.isDestinationAvailable(eventWithoutConsentManagementData, SourceIDGCM, "")).To(BeTrue())
+			Expect(processor.isDestinationAvailable(context.Background(), eventWithoutConsentManagementData, SourceIDGCM, nil, "")).To(BeTrue())
 			Expect(
 				len(processor.getConsentFilteredDestinations(
+					context.Background(),
 					eventWithoutConsentManagementData,
 					processor.getEnabledDestinations(
 						SourceIDGCM,
 						"destination-definition-name-enabled",
 					),
+					nil,
 				)),
 			).To(Equal(9)) // all
 
-			Expect(processor.isDestinationAvailable(eventWithoutDeniedConsentsGCM, SourceIDGCM, "")).To(BeTrue())
+			Expect(processor.isDestinationAvailable(context.Background(), eventWithoutDeniedConsentsGCM, SourceIDGCM, nil, "")).To(BeTrue())
 			Expect(
 				len(processor.getConsentFilteredDestinations(
+					context.Background(),
 					eventWithoutDeniedConsentsGCM,
 					processor.getEnabledDestinations(
 						SourceIDGCM,
 						"destination-definition-name-enabled",
 					),
+					nil,
 				)),
 			).To(Equal(9)) // all
 
-			Expect(processor.isDestinationAvailable(eventWithCustomConsentsGCM, SourceIDGCM, "")).To(BeTrue())
+			Expect(processor.isDestinationAvailable(context.Background(), eventWithCustomConsentsGCM, SourceIDGCM, nil, "")).To(BeTrue())
 			Expect(
 				len(processor.getConsentFilteredDestinations(
+					context.Background(),
 					eventWithCustomConsentsGCM,
 					processor.getEnabledDestinations(
 						SourceIDGCM,
 						"destination-definition-name-enabled",
 					),
+					nil,
 				)),
 			).To(Equal(8)) // all except D13
 
-			Expect(processor.isDestinationAvailable(eventWithDeniedConsentsGCM, SourceIDGCM, "")).To(BeTrue())
+			Expect(processor.isDestinationAvailable(context.Background(), eventWithDeniedConsentsGCM, SourceIDGCM, nil, "")).To(BeTrue())
 			Expect(
 				len(processor.getConsentFilteredDestinations(
+					context.Background(),
 					eventWithDeniedConsentsGCM,
 					processor.getEnabledDestinations(
 						SourceIDGCM,
 						"destination-definition-name-enabled",
 					),
+					nil,
 				)),
 			).To(Equal(7)) // all except D6 and D7
 
-			Expect(processor.isDestinationAvailable(eventWithDeniedConsentsGCMKetch, SourceIDGCM, "")).To(BeTrue())
+			Expect(processor.isDestinationAvailable(context.Background(), eventWithDeniedConsentsGCMKetch, SourceIDGCM, nil, "")).To(BeTrue())
 			Expect(
 				len(processor.getConsentFilteredDestinations(
+					context.Background(),
 					eventWithDeniedConsentsGCMKetch,
 					processor.getEnabledDestinations(
 						SourceIDGCM,
 						"destination-definition-name-enabled",
 					),
+					nil,
 				)),
 			).To(Equal(8)) // all except D7
 
 			// some unknown destination ID is passed destination will be unavailable
-			Expect(processor.isDestinationAvailable(eventWithDeniedConsentsGCMKetch, SourceIDGCM, "unknown-destination")).To(BeFalse())
+			Expect(processor.isDestinationAvailable(context.Background(), eventWithDeniedConsentsGCMKetch, SourceIDGCM, nil, "unknown-destination")).To(BeFalse())
 
 			// known destination ID is passed and destination is enabled
-			Expect(processor.isDestinationAvailable(eventWithDeniedConsentsGCMKetch, SourceIDTransient, DestinationIDEnabledA)).To(BeTrue())
+			Expect(processor.isDestinationAvailable(context.Background(), eventWithDeniedConsentsGCMKetch, SourceIDTransient, nil, DestinationIDEnabledA)).To(BeTrue())
 
 			// know destination ID is passed and destination is not enabled
-			Expect(processor.isDestinationAvailable(eventWithDeniedConsentsGCMKetch, SourceIDTransient, DestinationIDDisabled)).To(BeFalse())
+			Expect(processor.isDestinationAvailable(context.Background(), eventWithDeniedConsentsGCMKetch, SourceIDTransient, nil, DestinationIDDisabled)).To(BeFalse())
+
+			// several destination IDs are passed and one of them is enabled
+			Expect(processor.isDestinationAvailable(context.Background(), eventWithDeniedConsentsGCMKetch, SourceIDTransient, nil, DestinationIDDisabled, DestinationIDEnabledA)).To(BeTrue())
 		})
 	})
 
@@ -5247,7 +5276,7 @@ func Setup(processor *Handle, c *testContext, enableDedup, enableReporting bool)
 }
 
 func handlePendingGatewayJobs(processor *Handle) {
-	didWork := processor.handlePendingGatewayJobs("")
+	didWork := processor.handlePendingGatewayJobs(context.Background(), "")
 	Expect(didWork).To(Equal(true))
 }
 
@@ -5671,6 +5700,7 @@ func TestStoreMessageMerge(t *testing.T) {
 
 	sm3 := &storeMessage{
 		[]*trackedusers.UsersReport{{WorkspaceID: sampleWorkspaceID}, {WorkspaceID: sampleWorkspaceID}},
+		nil,
 		[]*jobsdb.JobStatusT{{JobID: 3}},
 		[]*jobsdb.JobT{{JobID: 3}},
 		[]*jobsdb.JobT{{JobID: 3}},
@@ -5751,3 +5781,55 @@ func TestStoreMessageMerge(t *testing.T) {
 		start:          time.UnixMicro(99999999),
 	})
 }
+
+func TestMaskPII(t *testing.T) {
+	destination := &backendconfig.DestinationT{
+		ID:                    "dest-1",
+		DestinationDefinition: backendconfig.DestinationDefinitionT{Name: "WEBHOOK"},
+		Config: map[string]interface{}{
+			"piiRules": []interface{}{
+				map[string]interface{}{"field": "context.traits.email", "action": "remove"},
+				map[string]interface{}{"field": "context.traits.phone", "type": "phone", "action": "tokenize"},
+			},
+		},
+	}
+	newEvents := func() []transformer.TransformerEvent {
+		return []transformer.TransformerEvent{{
+			Message: types.SingularEventT{
+				"context": map[string]interface{}{"traits": map[string]interface{}{"email": "user@example.com", "phone": "5551234567"}},
+			},
+			Metadata:    transformer.Metadata{JobID: 1, DestinationID: destination.ID},
+			Destination: *destination,
+		}}
+	}
+	newProc := func(t *testing.T, tokenizationKey string) *Handle {
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+		proc := &Handle{statsFactory: statsStore}
+		proc.config.destPIIRulesMap = make(map[string]*pii.Rules)
+		proc.config.destPIIRulesErrMap = make(map[string]error)
+		if rules, err := pii.NewRules(destination.Config, tokenizationKey); err != nil {
+			proc.config.destPIIRulesErrMap[destination.ID] = err
+		} else {
+			proc.config.destPIIRulesMap[destination.ID] = rules
+		}
+		return proc
+	}
+
+	t.Run("valid rules", func(t *testing.T) {
+		events, failed := newProc(t, "secret").maskPII(newEvents(), destination, "source-1", "workspace-1")
+		require.Empty(t, failed)
+		require.Len(t, events, 1)
+		require.Nil(t, misc.MapLookup(events[0].Message, "context", "traits", "email"))
+		require.NotEqual(t, "5551234567", misc.MapLookup(events[0].Message, "context", "traits", "phone"))
+	})
+
+	t.Run("invalid rules fail the events", func(t *testing.T) {
+		events, failed := newProc(t, "").maskPII(newEvents(), destination, "source-1", "workspace-1")
+		require.Empty(t, events, "events should never be delivered unmasked")
+		require.Len(t, failed, 1)
+		require.Equal(t, 400, failed[0].StatusCode)
+		require.Contains(t, failed[0].Error, pii.ErrMissingTokenizationKey.Error())
+		require.Equal(t, int64(1), failed[0].Metadata.JobID)
+	})
+}
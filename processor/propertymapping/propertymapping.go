@@ -0,0 +1,183 @@
+// Package propertymapping renames, copies or deletes event fields according to operations configured per
+// source-destination connection, e.g. renaming properties.revenue to properties.value for a single destination.
+//
+// Operations are configured in the connection config under the propertyMappings key and are applied in order:
+//
+//	"propertyMappings": [
+//		{"op": "rename", "from": "properties.revenue", "to": "properties.value"},
+//		{"op": "copy", "from": "context.traits.email", "to": "properties.email"},
+//		{"op": "delete", "field": "properties.internal_id"}
+//	]
+package propertymapping
+
+import (
+	"fmt"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+const mappingsKey = "propertyMappings"
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// Operations
+const (
+	OpRename = "rename"
+	OpCopy   = "copy"
+	OpDelete = "delete"
+)
+
+// Mappings is a compiled list of property mapping operations of a connection
+type Mappings struct {
+	operations []operation
+}
+
+type operation struct {
+	op   string
+	from []string // source path for rename and copy, field to delete for delete
+	to   []string // destination path for rename and copy
+}
+
+// New compiles the property mapping operations found in the given connection config.
+// It returns nil Mappings if the connection has no operations configured, and an error if the operations are invalid.
+func New(connectionConfig map[string]interface{}) (*Mappings, error) {
+	raw, ok := connectionConfig[mappingsKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling %s: %w", mappingsKey, err)
+	}
+	var conf []struct {
+		Op    string `json:"op"`
+		From  string `json:"from"`
+		To    string `json:"to"`
+		Field string `json:"field"`
+	}
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return nil, fmt.Errorf("unmarshalling %s: %w", mappingsKey, err)
+	}
+	if len(conf) == 0 {
+		return nil, nil
+	}
+	m := &Mappings{}
+	for i, c := range conf {
+		switch c.Op {
+		case OpRename, OpCopy:
+			if c.From == "" || c.To == "" {
+				return nil, fmt.Errorf("operation %d: %s requires both from and to", i, c.Op)
+			}
+			if c.From == c.To {
+				return nil, fmt.Errorf("operation %d: from and to are the same", i)
+			}
+			m.operations = append(m.operations, operation{op: c.Op, from: strings.Split(c.From, "."), to: strings.Split(c.To, ".")})
+		case OpDelete:
+			field := c.Field
+			if field == "" {
+				field = c.From
+			}
+			if field == "" {
+				return nil, fmt.Errorf("operation %d: delete requires a field", i)
+			}
+			m.operations = append(m.operations, operation{op: c.Op, from: strings.Split(field, ".")})
+		default:
+			return nil, fmt.Errorf("operation %d: unsupported op %q", i, c.Op)
+		}
+	}
+	return m, nil
+}
+
+// Apply applies the operations on the event, returning the resulting event along with the number of operations which changed it.
+// The provided event is never modified, since it can be shared between destinations: maps along the modified paths are copied instead.
+// Nil Mappings return the event as is.
+func (m *Mappings) Apply(event types.SingularEventT) (types.SingularEventT, int) {
+	if m == nil {
+		return event, 0
+	}
+	var applied int
+	for _, o := range m.operations {
+		value := misc.MapLookup(event, o.from...)
+		if value == nil {
+			continue
+		}
+		switch o.op {
+		case OpRename:
+			updated, ok := set(event, o.to, value)
+			if !ok {
+				continue
+			}
+			event, _ = remove(updated, o.from)
+		case OpCopy:
+			updated, ok := set(event, o.to, value)
+			if !ok {
+				continue
+			}
+			event = updated
+		case OpDelete:
+			event, _ = remove(event, o.from)
+		}
+		applied++
+	}
+	return event, applied
+}
+
+// set returns a copy of m with value set at path, copying every map along the path and creating missing ones.
+// It returns false if a non-map value is found along the path.
+func set(m map[string]interface{}, path []string, value interface{}) (map[string]interface{}, bool) {
+	c := clone(m)
+	if len(path) == 1 {
+		c[path[0]] = value
+		return c, true
+	}
+	var nested map[string]interface{}
+	switch v := c[path[0]].(type) {
+	case map[string]interface{}:
+		nested = v
+	case nil:
+		nested = map[string]interface{}{}
+	default:
+		return m, false
+	}
+	updated, ok := set(nested, path[1:], value)
+	if !ok {
+		return m, false
+	}
+	c[path[0]] = updated
+	return c, true
+}
+
+// remove returns a copy of m without the value at path, copying every map along the path
+func remove(m map[string]interface{}, path []string) (map[string]interface{}, bool) {
+	if len(path) == 1 {
+		if _, ok := m[path[0]]; !ok {
+			return m, false
+		}
+		c := clone(m)
+		delete(c, path[0])
+		return c, true
+	}
+	nested, ok := m[path[0]].(map[string]interface{})
+	if !ok {
+		return m, false
+	}
+	updated, ok := remove(nested, path[1:])
+	if !ok {
+		return m, false
+	}
+	c := clone(m)
+	c[path[0]] = updated
+	return c, true
+}
+
+func clone(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
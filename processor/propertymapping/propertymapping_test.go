@@ -0,0 +1,72 @@
+package propertymapping
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+func TestMappings(t *testing.T) {
+	parse := func(t *testing.T, mappings string) *Mappings {
+		var conf map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(`{"propertyMappings":`+mappings+`}`), &conf))
+		m, err := New(conf)
+		require.NoError(t, err)
+		return m
+	}
+
+	t.Run("no mappings configured", func(t *testing.T) {
+		m, err := New(map[string]interface{}{})
+		require.NoError(t, err)
+		require.Nil(t, m)
+		event := types.SingularEventT{"event": "Order Completed"}
+		out, applied := m.Apply(event)
+		require.Equal(t, event, out)
+		require.Zero(t, applied)
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		m := parse(t, `[
+			{"op": "rename", "from": "properties.revenue", "to": "properties.value"},
+			{"op": "copy", "from": "context.traits.email", "to": "properties.user.email"},
+			{"op": "delete", "field": "properties.internal_id"},
+			{"op": "rename", "from": "properties.missing", "to": "properties.other"},
+			{"op": "copy", "from": "event", "to": "properties.value.nested"}
+		]`)
+		event := types.SingularEventT{
+			"event":      "Order Completed",
+			"context":    map[string]interface{}{"traits": map[string]interface{}{"email": "john@example.com"}},
+			"properties": map[string]interface{}{"revenue": 10.5, "internal_id": "abc", "currency": "USD"},
+		}
+		out, applied := m.Apply(event)
+		require.Equal(t, 3, applied)
+		require.Equal(t, types.SingularEventT{
+			"event":   "Order Completed",
+			"context": map[string]interface{}{"traits": map[string]interface{}{"email": "john@example.com"}},
+			"properties": map[string]interface{}{
+				"value":    10.5,
+				"currency": "USD",
+				"user":     map[string]interface{}{"email": "john@example.com"},
+			},
+		}, out)
+		require.Equal(t, map[string]interface{}{"revenue": 10.5, "internal_id": "abc", "currency": "USD"}, event["properties"], "the original event should not be modified")
+	})
+
+	t.Run("invalid mappings", func(t *testing.T) {
+		for _, mappings := range []string{
+			`[{"op": "rename", "from": "properties.revenue"}]`,
+			`[{"op": "copy", "to": "properties.value"}]`,
+			`[{"op": "copy", "from": "properties.value", "to": "properties.value"}]`,
+			`[{"op": "delete"}]`,
+			`[{"op": "move", "from": "a", "to": "b"}]`,
+			`{"op": "delete"}`,
+		} {
+			var conf map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(`{"propertyMappings":`+mappings+`}`), &conf))
+			_, err := New(conf)
+			require.Error(t, err, mappings)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+)
+
+// transformationCode is the payload returned by the control plane for a transformation version
+type transformationCode struct {
+	ID          string `json:"id"`
+	VersionID   string `json:"versionId"`
+	Name        string `json:"name"`
+	Code        string `json:"code"`
+	CodeVersion string `json:"codeVersion"`
+	Language    string `json:"language"`
+}
+
+// program is the outcome of preparing a transformation version for in-process execution.
+// Since transformation versions are immutable, a program is cached for as long as it is not evicted.
+// When [fallbackReason] is not empty the version can not be executed in-process and needs to be sent to the external transformer.
+type program struct {
+	transformationID string
+	versionID        string
+	compiled         *goja.Program
+	fallbackReason   string
+	expiresAt        time.Time // only set for programs that failed to be fetched, so that we retry fetching them later on
+}
+
+func (p *program) eligible() bool {
+	return p.fallbackReason == ""
+}
+
+var (
+	// code containing any of these can not be executed in-process, since the embedded runtime doesn't provide them
+	importRegex       = regexp.MustCompile(`(?m)^\s*import\s`)
+	requireRegex      = regexp.MustCompile(`\brequire\s*\(`)
+	fetchRegex        = regexp.MustCompile(`\b(fetch|fetchV2|geolocation)\s*\(`)
+	transformBatchRgx = regexp.MustCompile(`\btransformBatch\b`)
+	transformEventRgx = regexp.MustCompile(`\btransformEvent\b`)
+
+	// export keywords are not supported by the embedded runtime's script mode, we strip them before compiling
+	exportRegex = regexp.MustCompile(`(?m)^(\s*)export\s+(default\s+)?`)
+)
+
+// fetchCode fetches the code of a transformation version from the control plane
+func (t *handle) fetchCode(ctx context.Context, versionID string) (*transformationCode, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.config.fetchTimeout)
+	defer cancel()
+	u := fmt.Sprintf("%s/transformation/getByVersionId?versionId=%s", strings.TrimSuffix(t.config.codeURL, "/"), url.QueryEscape(versionID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	if t.config.workspaceToken != "" {
+		req.SetBasicAuth(t.config.workspaceToken, "")
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching transformation code: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading transformation code: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching transformation code: unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+	var code transformationCode
+	if err := json.Unmarshal(body, &code); err != nil {
+		return nil, fmt.Errorf("unmarshalling transformation code: %w", err)
+	}
+	return &code, nil
+}
+
+// getProgram returns the program for the given transformation version, fetching and compiling it if it is not already cached
+func (t *handle) getProgram(ctx context.Context, transformationID, versionID string) *program {
+	if p, ok := t.programs.Get(versionID); ok && (p.expiresAt.IsZero() || p.expiresAt.After(t.now())) {
+		return p
+	}
+	p := &program{transformationID: transformationID, versionID: versionID}
+	code, err := t.fetchCode(ctx, versionID)
+	if err != nil {
+		t.log.Warnn("Failed to fetch transformation code, falling back to the external transformer",
+			logger.NewStringField("transformationId", transformationID),
+			logger.NewStringField("versionId", versionID),
+			obskit.Error(err),
+		)
+		p.fallbackReason = "fetch_failed"
+		p.expiresAt = t.now().Add(t.config.fetchRetryInterval)
+		t.programs.Add(versionID, p)
+		return p
+	}
+	p.compiled, p.fallbackReason = compile(code)
+	if !p.eligible() {
+		t.log.Infon("Transformation not eligible for in-process execution",
+			logger.NewStringField("transformationId", transformationID),
+			logger.NewStringField("versionId", versionID),
+			logger.NewStringField("reason", p.fallbackReason),
+		)
+	}
+	t.programs.Add(versionID, p)
+	return p
+}
+
+// compile compiles the transformation's code, returning a non-empty fallback reason if the code can not be executed in-process
+func compile(code *transformationCode) (*goja.Program, string) {
+	if !strings.EqualFold(code.Language, "javascript") {
+		return nil, "unsupported_language"
+	}
+	if code.CodeVersion != "1" {
+		return nil, "unsupported_code_version"
+	}
+	switch {
+	case importRegex.MatchString(code.Code), requireRegex.MatchString(code.Code):
+		return nil, "imports"
+	case fetchRegex.MatchString(code.Code):
+		return nil, "network_access"
+	case transformBatchRgx.MatchString(code.Code):
+		return nil, "transform_batch"
+	case !transformEventRgx.MatchString(code.Code):
+		return nil, "missing_transform_event"
+	}
+	compiled, err := goja.Compile(code.VersionID, exportRegex.ReplaceAllString(code.Code, "$1"), false)
+	if err != nil {
+		return nil, "compilation_error"
+	}
+	return compiled, ""
+}
@@ -0,0 +1,187 @@
+// Package embedded provides a [transformer.Transformer] which executes eligible user transformations in-process,
+// using an embedded javascript runtime, instead of sending them to the external transformer service.
+//
+// Only javascript transformations (codeVersion 1) defining a transformEvent function are eligible, as long as they
+// don't use libraries, imports or network access, and aren't chained with other transformations of the destination.
+// Every other transformation, along with destination transformations and tracking plan validations, is delegated to the
+// external transformer.
+package embedded
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	"github.com/rudderlabs/rudder-server/processor/transformer"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// NewTransformer returns a transformer which executes eligible user transformations in-process and delegates everything else to [remote]
+func NewTransformer(conf *config.Config, log logger.Logger, stat stats.Stats, remote transformer.Transformer) transformer.Transformer {
+	t := &handle{
+		remote: remote,
+		conf:   conf,
+		log:    log.Child("embedded"),
+		stat:   stat,
+		now:    time.Now,
+	}
+	t.config.codeURL = conf.GetString("Processor.embeddedUserTransformations.codeURL", conf.GetString("CONFIG_BACKEND_URL", "https://api.rudderstack.com"))
+	t.config.workspaceToken = config.GetWorkspaceToken()
+	t.config.fetchTimeout = conf.GetDuration("Processor.embeddedUserTransformations.fetchTimeout", 10, time.Second)
+	t.config.fetchRetryInterval = conf.GetDuration("Processor.embeddedUserTransformations.fetchRetryInterval", 1, time.Minute)
+	t.client = &http.Client{Timeout: t.config.fetchTimeout}
+
+	programs, err := lru.New[string, *program](conf.GetInt("Processor.embeddedUserTransformations.cacheSize", 1000))
+	if err != nil {
+		panic(err)
+	}
+	t.programs = programs
+	return t
+}
+
+type handle struct {
+	remote transformer.Transformer
+	conf   *config.Config
+	log    logger.Logger
+	stat   stats.Stats
+	now    func() time.Time
+	client *http.Client
+
+	programs *lru.Cache[string, *program] // transformation programs keyed by version id
+
+	limitsMu sync.Mutex
+	limits   map[string]*limits // execution limits keyed by transformation id
+
+	config struct {
+		codeURL            string
+		workspaceToken     string
+		fetchTimeout       time.Duration
+		fetchRetryInterval time.Duration
+	}
+}
+
+// limits are the execution limits of a transformation, which can be configured per transformation id, e.g.
+// Processor.embeddedUserTransformations.<transformationId>.timeout, falling back to the global settings
+type limits struct {
+	timeout           config.ValueLoader[time.Duration] // maximum time an event may spend in the transformation
+	maxCallStackSize  config.ValueLoader[int]           // maximum depth of the javascript call stack
+	maxOutputSizeInKB config.ValueLoader[int64]         // maximum size of the output produced for a single event
+	maxHeapGrowthInMB config.ValueLoader[int64]         // maximum growth of the heap while a single event is being transformed
+}
+
+func (t *handle) limitsFor(transformationID string) *limits {
+	t.limitsMu.Lock()
+	defer t.limitsMu.Unlock()
+	if t.limits == nil {
+		t.limits = make(map[string]*limits)
+	}
+	if l, ok := t.limits[transformationID]; ok {
+		return l
+	}
+	key := func(name string) []string {
+		return []string{
+			"Processor.embeddedUserTransformations." + transformationID + "." + name,
+			"Processor.embeddedUserTransformations." + name,
+		}
+	}
+	l := &limits{
+		timeout:           t.conf.GetReloadableDurationVar(5, time.Second, key("timeout")...),
+		maxCallStackSize:  t.conf.GetReloadableIntVar(1000, 1, key("maxCallStackSize")...),
+		maxOutputSizeInKB: t.conf.GetReloadableInt64Var(1024, 1, key("maxOutputSizeInKB")...),
+		maxHeapGrowthInMB: t.conf.GetReloadableInt64Var(256, 1, key("maxHeapGrowthInMB")...),
+	}
+	t.limits[transformationID] = l
+	return l
+}
+
+// Transform delegates destination transformations to the external transformer
+func (t *handle) Transform(ctx context.Context, clientEvents []transformer.TransformerEvent, batchSize int) transformer.Response {
+	return t.remote.Transform(ctx, clientEvents, batchSize)
+}
+
+// Validate delegates tracking plan validations to the external transformer
+func (t *handle) Validate(ctx context.Context, clientEvents []transformer.TransformerEvent, batchSize int) transformer.Response {
+	return t.remote.Validate(ctx, clientEvents, batchSize)
+}
+
+// UserTransform executes eligible user transformations in-process, sending the rest of the events to the external transformer
+func (t *handle) UserTransform(ctx context.Context, clientEvents []transformer.TransformerEvent, batchSize int) transformer.Response {
+	var (
+		remoteEvents []transformer.TransformerEvent
+		embedded     = make(map[*program][]transformer.TransformerEvent)
+		order        []*program
+	)
+	for _, event := range clientEvents {
+		if len(event.Destination.Transformations) == 0 {
+			remoteEvents = append(remoteEvents, event)
+			continue
+		}
+		transformation := event.Destination.Transformations[0]
+		var (
+			p      *program
+			reason string
+		)
+		if len(event.Destination.Transformations) > 1 {
+			reason = "chain" // the external transformer runs the whole chain
+		} else if p = t.getProgram(ctx, transformation.ID, transformation.VersionID); !p.eligible() {
+			reason = p.fallbackReason
+		} else if len(event.Libraries) > 0 {
+			reason = "libraries"
+		}
+		if reason != "" {
+			t.stat.NewTaggedStat("processor.embedded_user_transformer.fallback_events", stats.CountType, stats.Tags{
+				"transformationId": transformation.ID,
+				"reason":           reason,
+			}).Increment()
+			remoteEvents = append(remoteEvents, event)
+			continue
+		}
+		if _, ok := embedded[p]; !ok {
+			order = append(order, p)
+		}
+		embedded[p] = append(embedded[p], event)
+	}
+
+	var response transformer.Response
+	for _, p := range order {
+		r := t.run(ctx, p, embedded[p])
+		response.Events = append(response.Events, r.Events...)
+		response.FailedEvents = append(response.FailedEvents, r.FailedEvents...)
+	}
+	if len(remoteEvents) > 0 {
+		r := t.remote.UserTransform(ctx, remoteEvents, batchSize)
+		response.Events = append(response.Events, r.Events...)
+		response.FailedEvents = append(response.FailedEvents, r.FailedEvents...)
+	}
+	return response
+}
+
+// run executes the program against the events and reports the outcome
+func (t *handle) run(ctx context.Context, p *program, events []transformer.TransformerEvent) transformer.Response {
+	start := t.now()
+	response := newRuntime(p, t.limitsFor(p.transformationID)).transform(ctx, events)
+	tags := stats.Tags{"transformationId": p.transformationID}
+	t.stat.NewTaggedStat("processor.embedded_user_transformer.time", stats.TimerType, tags).Since(start)
+	t.stat.NewTaggedStat("processor.embedded_user_transformer.input_events", stats.CountType, tags).Count(len(events))
+	t.stat.NewTaggedStat("processor.embedded_user_transformer.output_events", stats.CountType, tags).Count(len(response.Events))
+	t.stat.NewTaggedStat("processor.embedded_user_transformer.failed_events", stats.CountType, tags).Count(len(response.FailedEvents))
+	lo.ForEach(response.FailedEvents, func(r transformer.TransformerResponse, _ int) {
+		t.log.Debugn("Embedded user transformation failed",
+			logger.NewStringField("transformationId", p.transformationID),
+			logger.NewStringField("versionId", p.versionID),
+			logger.NewStringField("messageId", r.Metadata.MessageID),
+			logger.NewStringField("error", r.Error),
+		)
+	})
+	return response
+}
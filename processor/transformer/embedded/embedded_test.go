@@ -0,0 +1,186 @@
+package embedded
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	mocksTransformer "github.com/rudderlabs/rudder-server/mocks/processor/transformer"
+	"github.com/rudderlabs/rudder-server/processor/transformer"
+)
+
+func TestUserTransform(t *testing.T) {
+	codes := map[string]transformationCode{
+		"enrich": {Code: `
+			export function transformEvent(event, metadata) {
+				event.properties.enriched = true;
+				event.properties.sourceId = metadata(event).sourceId;
+				event.properties.secret = getCredential("key");
+				log("enriched");
+				return event;
+			}`},
+		"drop":   {Code: `function transformEvent(event) { return null; }`},
+		"split":  {Code: `function transformEvent(event) { return [{...event, n: 1}, null, {...event, n: 2}]; }`},
+		"throw":  {Code: `function transformEvent(event) { throw new Error("boom"); }`},
+		"async":  {Code: `async function transformEvent(event) { event.async = await Promise.resolve(true); return event; }`},
+		"loop":   {Code: `function transformEvent(event) { while (true) {} }`},
+		"huge":   {Code: `function transformEvent(event) { return { data: "x".repeat(2048) }; }`},
+		"hog":    {Code: `function transformEvent(event) { const a = []; while (true) { a.push("x".repeat(1024) + a.length); } }`},
+		"string": {Code: `function transformEvent(event) { return "event"; }`},
+		"fetch":  {Code: `async function transformEvent(event) { return await fetch("https://example.com"); }`},
+		"batch":  {Code: `export function transformBatch(events) { return events; }`},
+		"python": {Code: `def transformEvent(event, metadata): return event`, Language: "pythonfaas"},
+	}
+	var fetches atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		versionID := r.URL.Query().Get("versionId")
+		code, ok := codes[versionID]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		code.VersionID = versionID
+		code.CodeVersion = "1"
+		if code.Language == "" {
+			code.Language = "javascript"
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(code))
+	}))
+	t.Cleanup(srv.Close)
+
+	newTransformer := func(t *testing.T, remote transformer.Transformer) (transformer.Transformer, *memstats.Store) {
+		c := config.New()
+		c.Set("Processor.embeddedUserTransformations.codeURL", srv.URL)
+		c.Set("Processor.embeddedUserTransformations.timeout", "100ms")
+		c.Set("Processor.embeddedUserTransformations.huge.maxOutputSizeInKB", 1)
+		c.Set("Processor.embeddedUserTransformations.hog.timeout", "10s")
+		c.Set("Processor.embeddedUserTransformations.hog.maxHeapGrowthInMB", 16)
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+		return NewTransformer(c, logger.NOP, statsStore, remote), statsStore
+	}
+	event := func(versionID, messageID string) transformer.TransformerEvent {
+		return transformer.TransformerEvent{
+			Message:     map[string]interface{}{"type": "track", "event": "Product Viewed", "properties": map[string]interface{}{"price": 10}},
+			Metadata:    transformer.Metadata{SourceID: "source-1", MessageID: messageID},
+			Destination: backendconfig.DestinationT{Transformations: []backendconfig.TransformationT{{ID: versionID, VersionID: versionID}}},
+			Credentials: []transformer.Credential{{Key: "key", Value: "value"}},
+		}
+	}
+
+	t.Run("eligible transformations are executed in-process", func(t *testing.T) {
+		trans, statsStore := newTransformer(t, nil) // any call to the remote transformer would panic
+
+		response := trans.UserTransform(context.Background(), []transformer.TransformerEvent{
+			event("enrich", "1"),
+			event("drop", "2"),
+			event("split", "3"),
+			event("async", "4"),
+		}, 200)
+		require.Empty(t, response.FailedEvents)
+		require.Len(t, response.Events, 4)
+
+		require.Equal(t, "1", response.Events[0].Metadata.MessageID)
+		require.Equal(t, http.StatusOK, response.Events[0].StatusCode)
+		require.Equal(t, map[string]interface{}{
+			"price":    float64(10),
+			"enriched": true,
+			"sourceId": "source-1",
+			"secret":   "value",
+		}, response.Events[0].Output["properties"])
+
+		require.Equal(t, "3", response.Events[1].Metadata.MessageID)
+		require.EqualValues(t, 1, response.Events[1].Output["n"])
+		require.Equal(t, "3", response.Events[2].Metadata.MessageID)
+		require.EqualValues(t, 2, response.Events[2].Output["n"])
+
+		require.Equal(t, "4", response.Events[3].Metadata.MessageID)
+		require.Equal(t, true, response.Events[3].Output["async"])
+
+		require.EqualValues(t, 1, statsStore.Get("processor.embedded_user_transformer.input_events", stats.Tags{"transformationId": "drop"}).LastValue())
+		require.EqualValues(t, 0, statsStore.Get("processor.embedded_user_transformer.output_events", stats.Tags{"transformationId": "drop"}).LastValue())
+		require.EqualValues(t, 2, statsStore.Get("processor.embedded_user_transformer.output_events", stats.Tags{"transformationId": "split"}).LastValue())
+	})
+
+	t.Run("failing events", func(t *testing.T) {
+		trans, _ := newTransformer(t, nil)
+
+		start := time.Now()
+		response := trans.UserTransform(context.Background(), []transformer.TransformerEvent{
+			event("throw", "1"),
+			event("loop", "2"),
+			event("huge", "3"),
+			event("string", "4"),
+			event("hog", "5"),
+		}, 200)
+		require.Less(t, time.Since(start), 5*time.Second, "the infinite loops should have been interrupted")
+		require.Empty(t, response.Events)
+		require.Len(t, response.FailedEvents, 5)
+		for _, r := range response.FailedEvents {
+			require.Equal(t, http.StatusBadRequest, r.StatusCode)
+		}
+		require.Contains(t, response.FailedEvents[0].Error, "boom")
+		require.Contains(t, response.FailedEvents[1].Error, "timed out")
+		require.Contains(t, response.FailedEvents[2].Error, "exceeds the limit")
+		require.Contains(t, response.FailedEvents[3].Error, "must be an object")
+		require.Contains(t, response.FailedEvents[4].Error, "exceeded the memory limit")
+	})
+
+	t.Run("ineligible transformations fall back to the external transformer", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		remote := mocksTransformer.NewMockTransformer(ctrl)
+		trans, statsStore := newTransformer(t, remote)
+
+		withLibraries := event("enrich", "6")
+		withLibraries.Libraries = []backendconfig.LibraryT{{VersionID: "lib"}}
+		chained := event("enrich", "7")
+		chained.Destination.Transformations = append(chained.Destination.Transformations, backendconfig.TransformationT{ID: "drop", VersionID: "drop"})
+		remoteEvents := []transformer.TransformerEvent{
+			event("fetch", "1"),
+			event("batch", "2"),
+			event("python", "3"),
+			event("unknown", "4"),
+			withLibraries,
+			chained,
+		}
+		remote.EXPECT().UserTransform(gomock.Any(), remoteEvents, 200).Return(transformer.Response{
+			Events: []transformer.TransformerResponse{{Metadata: transformer.Metadata{MessageID: "remote"}, StatusCode: http.StatusOK}},
+		}).Times(1)
+
+		response := trans.UserTransform(context.Background(), append([]transformer.TransformerEvent{event("enrich", "5")}, remoteEvents...), 200)
+		require.Empty(t, response.FailedEvents)
+		require.Len(t, response.Events, 2)
+		require.Equal(t, "5", response.Events[0].Metadata.MessageID)
+		require.Equal(t, "remote", response.Events[1].Metadata.MessageID)
+
+		require.EqualValues(t, 1, statsStore.Get("processor.embedded_user_transformer.fallback_events", stats.Tags{"transformationId": "fetch", "reason": "network_access"}).LastValue())
+		require.EqualValues(t, 1, statsStore.Get("processor.embedded_user_transformer.fallback_events", stats.Tags{"transformationId": "batch", "reason": "transform_batch"}).LastValue())
+		require.EqualValues(t, 1, statsStore.Get("processor.embedded_user_transformer.fallback_events", stats.Tags{"transformationId": "python", "reason": "unsupported_language"}).LastValue())
+		require.EqualValues(t, 1, statsStore.Get("processor.embedded_user_transformer.fallback_events", stats.Tags{"transformationId": "unknown", "reason": "fetch_failed"}).LastValue())
+		require.EqualValues(t, 1, statsStore.Get("processor.embedded_user_transformer.fallback_events", stats.Tags{"transformationId": "enrich", "reason": "libraries"}).LastValue())
+		require.EqualValues(t, 1, statsStore.Get("processor.embedded_user_transformer.fallback_events", stats.Tags{"transformationId": "enrich", "reason": "chain"}).LastValue())
+	})
+
+	t.Run("transformation versions are fetched once", func(t *testing.T) {
+		trans, _ := newTransformer(t, nil)
+		before := fetches.Load()
+		for i := 0; i < 3; i++ {
+			response := trans.UserTransform(context.Background(), []transformer.TransformerEvent{event("enrich", "1")}, 200)
+			require.Len(t, response.Events, 1)
+		}
+		require.EqualValues(t, 1, fetches.Load()-before)
+	})
+}
@@ -0,0 +1,249 @@
+package embedded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/metrics"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/rudderlabs/rudder-server/processor/transformer"
+)
+
+var (
+	errTimeout     = errors.New("transformation timed out")
+	errMemoryLimit = errors.New("transformation exceeded the memory limit")
+)
+
+// heapCheckInterval is how often the heap is sampled while a transformation is running
+const heapCheckInterval = 10 * time.Millisecond
+
+// runtime executes a program against a batch of events.
+//
+// A fresh javascript runtime is created for every batch, so that no state can leak between batches, while the compiled program is shared.
+// The embedded runtime doesn't account for the allocations of a single execution, thus memory usage is bounded by
+// sampling the heap of the process while a transformation is running, interrupting it once the heap grew by more than
+// the configured limit, along with the execution timeout, the maximum call stack size and the maximum size of the output
+// produced for each event. Since the heap is shared, allocations of concurrent executions count towards the growth too.
+type runtime struct {
+	program *program
+	limits  *limits
+}
+
+func newRuntime(p *program, l *limits) *runtime {
+	return &runtime{program: p, limits: l}
+}
+
+// transform runs the transformEvent function of the program for every event:
+//   - a null or undefined result drops the event
+//   - an object result produces a single output event
+//   - an array result produces an output event for every non-null element
+//   - an exception, a timeout or an invalid result fails the event
+//
+// Async transformEvent functions are supported, as long as the returned promise settles without waiting on external resources.
+func (r *runtime) transform(ctx context.Context, events []transformer.TransformerEvent) transformer.Response {
+	var response transformer.Response
+	failAll := func(err error) transformer.Response {
+		for _, event := range events {
+			response.FailedEvents = append(response.FailedEvents, failedResponse(event, err))
+		}
+		return response
+	}
+
+	vm := goja.New()
+	vm.SetMaxCallStackSize(r.limits.maxCallStackSize.Load())
+	if err := vm.Set("log", func(goja.FunctionCall) goja.Value { return goja.Undefined() }); err != nil {
+		return failAll(err)
+	}
+	if _, err := r.call(ctx, vm, func() (goja.Value, error) { return vm.RunProgram(r.program.compiled) }); err != nil {
+		return failAll(err)
+	}
+	transformEvent, ok := goja.AssertFunction(vm.Get("transformEvent"))
+	if !ok {
+		return failAll(errors.New("transformEvent is not a function"))
+	}
+	jsonObject := vm.Get("JSON").ToObject(vm)
+	parse, _ := goja.AssertFunction(jsonObject.Get("parse"))
+	stringify, _ := goja.AssertFunction(jsonObject.Get("stringify"))
+
+	for _, event := range events {
+		outputs, err := r.transformEvent(ctx, vm, transformEvent, parse, stringify, event)
+		if err != nil {
+			response.FailedEvents = append(response.FailedEvents, failedResponse(event, err))
+			continue
+		}
+		for _, output := range outputs {
+			response.Events = append(response.Events, transformer.TransformerResponse{
+				Output:     output,
+				Metadata:   event.Metadata,
+				StatusCode: http.StatusOK,
+			})
+		}
+	}
+	return response
+}
+
+func (r *runtime) transformEvent(ctx context.Context, vm *goja.Runtime, transformEvent, parse, stringify goja.Callable, event transformer.TransformerEvent) ([]map[string]interface{}, error) {
+	// events and metadata are passed through JSON, so that the transformation works on plain javascript objects
+	rawMessage, err := json.Marshal(event.Message)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling event: %w", err)
+	}
+	rawMetadata, err := json.Marshal(event.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling metadata: %w", err)
+	}
+	message, err := parse(goja.Undefined(), vm.ToValue(string(rawMessage)))
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := parse(goja.Undefined(), vm.ToValue(string(rawMetadata)))
+	if err != nil {
+		return nil, err
+	}
+	credentials := make(map[string]string, len(event.Credentials))
+	for _, c := range event.Credentials {
+		credentials[c.Key] = c.Value
+	}
+	if err := vm.Set("getCredential", func(key string) goja.Value {
+		if v, ok := credentials[key]; ok {
+			return vm.ToValue(v)
+		}
+		return goja.Undefined()
+	}); err != nil {
+		return nil, err
+	}
+
+	result, err := r.call(ctx, vm, func() (goja.Value, error) {
+		return transformEvent(goja.Undefined(), message, vm.ToValue(func(goja.FunctionCall) goja.Value { return metadata }))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if promise, ok := result.Export().(*goja.Promise); ok {
+		switch promise.State() {
+		case goja.PromiseStateFulfilled:
+			result = promise.Result()
+		case goja.PromiseStateRejected:
+			return nil, errors.New(promise.Result().String())
+		default:
+			return nil, errors.New("transformEvent returned a promise which never settled")
+		}
+	}
+	if goja.IsUndefined(result) || goja.IsNull(result) {
+		return nil, nil
+	}
+
+	rawResult, err := stringify(goja.Undefined(), result)
+	if err != nil {
+		return nil, err
+	}
+	output := rawResult.String()
+	if maxSize := r.limits.maxOutputSizeInKB.Load() * 1024; int64(len(output)) > maxSize {
+		return nil, fmt.Errorf("transformation output size %d bytes exceeds the limit of %d bytes", len(output), maxSize)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		return nil, fmt.Errorf("unmarshalling transformation output: %w", err)
+	}
+	switch v := decoded.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{v}, nil
+	case []interface{}:
+		outputs := make([]map[string]interface{}, 0, len(v))
+		for _, item := range v {
+			if item == nil {
+				continue
+			}
+			o, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("returned value must be an object or an array of objects")
+			}
+			outputs = append(outputs, o)
+		}
+		return outputs, nil
+	default:
+		return nil, errors.New("returned value must be an object or an array of objects")
+	}
+}
+
+// call invokes fn, interrupting the runtime if it takes longer than the configured timeout or if the context gets cancelled
+func (r *runtime) call(ctx context.Context, vm *goja.Runtime, fn func() (goja.Value, error)) (goja.Value, error) {
+	timeout := r.limits.timeout.Load()
+	maxHeapGrowth := uint64(r.limits.maxHeapGrowthInMB.Load()) * 1024 * 1024
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		if err := watchHeap(watchCtx, maxHeapGrowth); err != nil {
+			vm.Interrupt(err)
+		}
+	}()
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		vm.Interrupt(errTimeout)
+		close(interrupted)
+	})
+	v, err := fn()
+	stopWatching()
+	<-watched
+	if !stop() {
+		<-interrupted
+	}
+	// an interrupt may have been raised after fn returned, make sure it doesn't affect the next call
+	vm.ClearInterrupt()
+	var interruptedErr *goja.InterruptedError
+	if errors.As(err, &interruptedErr) {
+		if interruptedErr.Value() == errMemoryLimit {
+			return nil, fmt.Errorf("%w of %dMB", errMemoryLimit, maxHeapGrowth/1024/1024)
+		}
+		return nil, fmt.Errorf("%w after %s", errTimeout, timeout)
+	}
+	var stackOverflowErr *goja.StackOverflowError
+	if errors.As(err, &stackOverflowErr) {
+		return nil, errors.New("maximum call stack size exceeded")
+	}
+	var exception *goja.Exception
+	if errors.As(err, &exception) {
+		return nil, errors.New(exception.Value().String())
+	}
+	return v, err
+}
+
+// watchHeap samples the heap until the context is done, returning [errMemoryLimit] if it grew by more than maxGrowth bytes
+func watchHeap(ctx context.Context, maxGrowth uint64) error {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	heap := func() uint64 {
+		metrics.Read(sample)
+		if sample[0].Value.Kind() != metrics.KindUint64 {
+			return 0
+		}
+		return sample[0].Value.Uint64()
+	}
+	start := heap()
+	ticker := time.NewTicker(heapCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if current := heap(); current > start && current-start > maxGrowth {
+				return errMemoryLimit
+			}
+		}
+	}
+}
+
+func failedResponse(event transformer.TransformerEvent, err error) transformer.TransformerResponse {
+	return transformer.TransformerResponse{
+		Metadata:   event.Metadata,
+		StatusCode: http.StatusBadRequest,
+		Error:      err.Error(),
+	}
+}
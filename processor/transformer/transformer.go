@@ -68,6 +68,7 @@ type Metadata struct {
 	MessageID           string                            `json:"messageId"`
 	OAuthAccessToken    string                            `json:"oauthAccessToken"`
 	TraceParent         string                            `json:"traceparent"`
+	CorrelationID       string                            `json:"correlationId,omitempty"`
 	// set by user_transformer to indicate transformed event is part of group indicated by messageIDs
 	MessageIDs              []string `json:"messageIds"`
 	RudderID                string   `json:"rudderId"`
@@ -72,7 +72,7 @@ func (w *worker) start() {
 		defer close(w.channel.transform)
 		defer w.logger.Debugf("preprocessing routine stopped for worker: %s", w.partition)
 		for jobs := range w.channel.preprocess {
-			w.channel.transform <- w.handle.processJobsForDest(w.partition, jobs)
+			w.channel.transform <- w.handle.processJobsForDest(w.lifecycle.ctx, w.partition, jobs)
 		}
 	})
 
@@ -124,7 +124,7 @@ func (w *worker) start() {
 // Work picks the next set of jobs from the jobsdb and returns [true] if jobs were picked, [false] otherwise
 func (w *worker) Work() (worked bool) {
 	if !w.handle.config().enablePipelining {
-		return w.handle.handlePendingGatewayJobs(w.partition)
+		return w.handle.handlePendingGatewayJobs(w.lifecycle.ctx, w.partition)
 	}
 
 	start := time.Now()
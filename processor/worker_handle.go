@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"time"
 
 	"github.com/rudderlabs/rudder-go-kit/config"
@@ -16,14 +17,14 @@ type workerHandle interface {
 	logger() logger.Logger
 	config() workerHandleConfig
 	rsourcesService() rsources.JobService
-	handlePendingGatewayJobs(key string) bool
+	handlePendingGatewayJobs(ctx context.Context, key string) bool
 	stats() *processorStats
 	tracer() stats.Tracer
 
 	getJobs(partition string) jobsdb.JobsResult
 	markExecuting(partition string, jobs []*jobsdb.JobT) error
 	jobSplitter(jobs []*jobsdb.JobT, rsourcesStats rsources.StatsCollector) []subJob
-	processJobsForDest(partition string, subJobs subJob) *transformationMessage
+	processJobsForDest(ctx context.Context, partition string, subJobs subJob) *transformationMessage
 	transformations(partition string, in *transformationMessage) *storeMessage
 	Store(partition string, in *storeMessage)
 }
@@ -212,7 +212,7 @@ func (*mockWorkerHandle) rsourcesService() rsources.JobService {
 	return nil
 }
 
-func (m *mockWorkerHandle) handlePendingGatewayJobs(partition string) bool {
+func (m *mockWorkerHandle) handlePendingGatewayJobs(ctx context.Context, partition string) bool {
 	jobs := m.getJobs(partition)
 	if len(jobs.Jobs) > 0 {
 		_ = m.markExecuting(partition, jobs.Jobs)
@@ -220,7 +220,7 @@ func (m *mockWorkerHandle) handlePendingGatewayJobs(partition string) bool {
 	rsourcesStats := rsources.NewStatsCollector(m.rsourcesService(), rsources.IgnoreDestinationID())
 	for _, subJob := range m.jobSplitter(jobs.Jobs, rsourcesStats) {
 		m.Store(partition, m.transformations(partition,
-			m.processJobsForDest(partition, subJob),
+			m.processJobsForDest(ctx, partition, subJob),
 		))
 	}
 	return len(jobs.Jobs) > 0
@@ -305,7 +305,7 @@ func (m *mockWorkerHandle) jobSplitter(jobs []*jobsdb.JobT, rsourcesStats rsourc
 	}
 }
 
-func (m *mockWorkerHandle) processJobsForDest(partition string, subJobs subJob) *transformationMessage {
+func (m *mockWorkerHandle) processJobsForDest(_ context.Context, partition string, subJobs subJob) *transformationMessage {
 	if m.limiters.process != nil {
 		defer m.limiters.process.Begin(partition)()
 	}
@@ -46,6 +46,7 @@ import (
 	"github.com/rudderlabs/rudder-server/utils/pubsub"
 	testutils "github.com/rudderlabs/rudder-server/utils/tests"
 	"github.com/rudderlabs/rudder-server/warehouse/client"
+	"github.com/rudderlabs/rudder-server/warehouse/stagingfile"
 )
 
 const (
@@ -456,6 +457,7 @@ func TestPostToWarehouse(t *testing.T) {
 
 		responseCode int
 		responseBody string
+		footer       *stagingfile.Footer
 
 		expectedPayload string
 		expectedError   error
@@ -466,7 +468,20 @@ func TestPostToWarehouse(t *testing.T) {
 			responseBody: "OK",
 			responseCode: http.StatusOK,
 
-			expectedPayload: `{"WorkspaceID":"test-workspace","Schema":{"tracks":{"id":"string"}},"BatchDestination":{"Source":{"ID":""},"Destination":{"ID":""}},"Location":"","FirstEventAt":"","LastEventAt":"","TotalEvents":1,"TotalBytes":200,"UseRudderStorage":false,"DestinationRevisionID":"","SourceTaskRunID":"","SourceJobID":"","SourceJobRunID":"","TimeWindow":"0001-01-01T00:00:00Z"}`,
+			expectedPayload: `{"WorkspaceID":"test-workspace","Schema":{"tracks":{"id":"string"}},"BatchDestination":{"Source":{"ID":""},"Destination":{"ID":""}},"Location":"","FirstEventAt":"","LastEventAt":"","TotalEvents":1,"TotalBytes":200,"EventsPerTable":{"tracks":1},"UseRudderStorage":false,"DestinationRevisionID":"","SourceTaskRunID":"","SourceJobID":"","SourceJobRunID":"","TimeWindow":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name: "should post the schema of the footer of v2 staging files",
+
+			responseBody: "OK",
+			responseCode: http.StatusOK,
+			footer: &stagingfile.Footer{
+				Schema:       map[string]map[string]string{"tracks": {"id": "string", "event": "text"}},
+				RowsPerTable: map[string]int{"tracks": 1},
+				TotalRows:    1,
+			},
+
+			expectedPayload: `{"WorkspaceID":"test-workspace","Schema":{"tracks":{"id":"string","event":"text"}},"BatchDestination":{"Source":{"ID":""},"Destination":{"ID":""}},"Location":"","FirstEventAt":"","LastEventAt":"","TotalEvents":1,"TotalBytes":200,"EventsPerTable":{"tracks":1},"UseRudderStorage":false,"DestinationRevisionID":"","SourceTaskRunID":"","SourceJobID":"","SourceJobRunID":"","TimeWindow":"0001-01-01T00:00:00Z"}`,
 		},
 		{
 			name: "should fail to post to warehouse",
@@ -519,6 +534,7 @@ func TestPostToWarehouse(t *testing.T) {
 			err := job.pingWarehouse(&batchJobs, UploadResult{
 				TotalEvents: 1,
 				TotalBytes:  200,
+				Footer:      input.footer,
 			})
 			if input.expectedError != nil {
 				require.Equal(t, fmt.Sprintf(input.expectedError.Error(), ts.URL), err.Error())
@@ -0,0 +1,172 @@
+// Package datalake lays out the events delivered by the batch router to object storage destinations as a lightweight
+// data lake: events can be written as gzipped newline delimited json or parquet files, to paths rendered from a
+// template partitioning them by their event time, along with a manifest listing the files of every delivered batch.
+package datalake
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tidwall/gjson"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// File formats
+const (
+	FormatJSON    = "json"
+	FormatParquet = "parquet"
+)
+
+// Destination config keys
+const (
+	fileFormatKey     = "fileFormat"
+	pathTemplateKey   = "pathTemplate"
+	writeManifestKey  = "writeManifest"
+	manifestFolderKey = "manifestFolder"
+
+	defaultManifestFolder = "_manifests"
+)
+
+// placeholderRegex matches the placeholders of path templates, e.g. {sourceId} or {yyyy}
+var placeholderRegex = regexp.MustCompile(`\{([a-zA-Z]+)}`)
+
+// placeholders of path templates, resolved per connection or per event
+var placeholders = map[string]func(ctx pathContext) string{
+	"workspaceId":   func(ctx pathContext) string { return ctx.workspaceID },
+	"sourceId":      func(ctx pathContext) string { return ctx.sourceID },
+	"destinationId": func(ctx pathContext) string { return ctx.destinationID },
+	"eventType":     func(ctx pathContext) string { return ctx.eventType },
+	"yyyy":          func(ctx pathContext) string { return ctx.eventTime.Format("2006") },
+	"MM":            func(ctx pathContext) string { return ctx.eventTime.Format("01") },
+	"dd":            func(ctx pathContext) string { return ctx.eventTime.Format("02") },
+	"HH":            func(ctx pathContext) string { return ctx.eventTime.Format("15") },
+}
+
+type pathContext struct {
+	workspaceID   string
+	sourceID      string
+	destinationID string
+	eventType     string
+	eventTime     time.Time
+}
+
+/*
+Settings are the data lake settings of an object storage destination, found in its config, e.g.
+
+	"fileFormat": "parquet",
+	"pathTemplate": "events/{sourceId}/year={yyyy}/month={MM}/day={dd}/hour={HH}",
+	"writeManifest": true
+
+The path template supports the placeholders {workspaceId}, {sourceId}, {destinationId} and {eventType}, along with
+{yyyy}, {MM}, {dd} and {HH} for the event time of the events in UTC, which is their timestamp, or their receivedAt
+if they have none. A batch of events is split in one file per path.
+
+If enabled, a manifest listing the files of the batch is written once all of them got uploaded, to a folder per source
+in the manifest folder ("_manifests" by default), so that readers can tell complete batches apart from the leftovers
+of failed uploads.
+*/
+type Settings struct {
+	FileFormat     string
+	PathTemplate   string
+	WriteManifest  bool
+	ManifestFolder string
+}
+
+// NewSettings returns the data lake settings of the given destination config, returning an error if they are invalid
+func NewSettings(destinationConfig map[string]interface{}) (Settings, error) {
+	s := Settings{FileFormat: FormatJSON, ManifestFolder: defaultManifestFolder}
+	if format, _ := destinationConfig[fileFormatKey].(string); format != "" {
+		if format != FormatJSON && format != FormatParquet {
+			return Settings{}, fmt.Errorf("unsupported file format %q", format)
+		}
+		s.FileFormat = format
+	}
+	if template, _ := destinationConfig[pathTemplateKey].(string); strings.Trim(template, "/ ") != "" {
+		for _, match := range placeholderRegex.FindAllStringSubmatch(template, -1) {
+			if _, ok := placeholders[match[1]]; !ok {
+				return Settings{}, fmt.Errorf("unsupported placeholder %q in path template", match[0])
+			}
+		}
+		s.PathTemplate = strings.Trim(strings.TrimSpace(template), "/")
+	}
+	s.WriteManifest, _ = destinationConfig[writeManifestKey].(bool)
+	if folder, _ := destinationConfig[manifestFolderKey].(string); strings.Trim(folder, "/ ") != "" {
+		s.ManifestFolder = strings.Trim(strings.TrimSpace(folder), "/")
+	}
+	return s, nil
+}
+
+// Enabled returns whether the destination uses any of the data lake settings, instead of the regular layout of
+// gzipped json files in date folders
+func (s Settings) Enabled() bool {
+	return s.FileFormat != FormatJSON || s.PathTemplate != "" || s.WriteManifest
+}
+
+// FileExtension returns the extension of the files written in the format of the settings
+func (s Settings) FileExtension() string {
+	if s.FileFormat == FormatParquet {
+		return ".parquet"
+	}
+	return ".json.gz"
+}
+
+// Path renders the path template for the given event, delivered through the given connection
+func (s Settings) Path(workspaceID, sourceID, destinationID string, event []byte) string {
+	ctx := pathContext{
+		workspaceID:   workspaceID,
+		sourceID:      sourceID,
+		destinationID: destinationID,
+		eventType:     gjson.GetBytes(event, "type").String(),
+		eventTime:     EventTime(event),
+	}
+	if ctx.eventType == "" {
+		ctx.eventType = "unknown"
+	}
+	return placeholderRegex.ReplaceAllStringFunc(s.PathTemplate, func(placeholder string) string {
+		return placeholders[placeholder[1:len(placeholder)-1]](ctx)
+	})
+}
+
+// EventTime returns the time the event happened at in UTC, i.e. its timestamp, falling back to its receivedAt
+func EventTime(event []byte) time.Time {
+	for _, field := range []string{"timestamp", "originalTimestamp", "receivedAt"} {
+		if t, err := time.Parse(time.RFC3339Nano, gjson.GetBytes(event, field).String()); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Now().UTC()
+}
+
+// Manifest lists the files a batch of events got delivered to
+type Manifest struct {
+	BatchID       string         `json:"batchId"`
+	SourceID      string         `json:"sourceId"`
+	DestinationID string         `json:"destinationId"`
+	FileFormat    string         `json:"fileFormat"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	Files         []ManifestFile `json:"files"`
+}
+
+// ManifestFile is a file of a batch listed in its [Manifest]
+type ManifestFile struct {
+	Key          string    `json:"key"`
+	Location     string    `json:"location"`
+	Events       int       `json:"events"`
+	Bytes        int64     `json:"bytes"`
+	FirstEventAt time.Time `json:"firstEventAt"`
+	LastEventAt  time.Time `json:"lastEventAt"`
+}
+
+// ManifestName returns the name of the manifest of a batch
+func ManifestName(createdAt time.Time, batchID string) string {
+	return fmt.Sprintf("%d.%s.json", createdAt.Unix(), batchID)
+}
+
+// Marshal encodes the manifest as json
+func (m *Manifest) Marshal() ([]byte, error) {
+	return json.Marshal(m)
+}
@@ -0,0 +1,111 @@
+package datalake_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+
+	"github.com/rudderlabs/rudder-server/router/batchrouter/datalake"
+)
+
+func TestSettings(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		s, err := datalake.NewSettings(map[string]interface{}{"bucketName": "bucket"})
+		require.NoError(t, err)
+		require.False(t, s.Enabled(), "destinations without data lake settings keep the regular layout")
+		require.Equal(t, ".json.gz", s.FileExtension())
+	})
+
+	t.Run("path template", func(t *testing.T) {
+		s, err := datalake.NewSettings(map[string]interface{}{
+			"fileFormat":    "parquet",
+			"pathTemplate":  "/events/{sourceId}/{eventType}/year={yyyy}/month={MM}/day={dd}/hour={HH}/",
+			"writeManifest": true,
+		})
+		require.NoError(t, err)
+		require.True(t, s.Enabled())
+		require.Equal(t, ".parquet", s.FileExtension())
+		require.Equal(t, "_manifests", s.ManifestFolder)
+
+		require.Equal(t, "events/source-1/track/year=2024/month=03/day=01/hour=22",
+			s.Path("workspace-1", "source-1", "destination-1", []byte(`{"type":"track","timestamp":"2024-03-02T01:30:00.000+03:00","receivedAt":"2024-03-05T10:00:00.000Z"}`)),
+			"events are partitioned by their timestamp in UTC")
+		require.Equal(t, "events/source-1/unknown/year=2024/month=03/day=05/hour=10",
+			s.Path("workspace-1", "source-1", "destination-1", []byte(`{"receivedAt":"2024-03-05T10:00:00.000Z"}`)),
+			"receivedAt is used for events without a timestamp")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := datalake.NewSettings(map[string]interface{}{"fileFormat": "avro"})
+		require.Error(t, err)
+		_, err = datalake.NewSettings(map[string]interface{}{"pathTemplate": "events/{userId}"})
+		require.Error(t, err)
+	})
+}
+
+func TestWriteFile(t *testing.T) {
+	events := [][]byte{
+		[]byte(`{"type":"track","event":"Order Completed","messageId":"1","userId":"u","timestamp":"2024-03-01T10:00:00.000Z","receivedAt":"2024-03-01T10:00:01.000Z"}`),
+		[]byte(`{"type":"identify","messageId":"2","anonymousId":"a","receivedAt":"2024-03-01T11:00:00.000Z"}`),
+	}
+
+	t.Run("json", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.json.gz")
+		size, err := datalake.Settings{FileFormat: datalake.FormatJSON}.WriteFile(path, events)
+		require.NoError(t, err)
+		require.Positive(t, size)
+
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer func() { _ = f.Close() }()
+		gz, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		var lines []string
+		for sc := bufio.NewScanner(gz); sc.Scan(); {
+			lines = append(lines, sc.Text())
+		}
+		require.Equal(t, []string{string(events[0]), string(events[1])}, lines)
+	})
+
+	t.Run("parquet", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.parquet")
+		size, err := datalake.Settings{FileFormat: datalake.FormatParquet}.WriteFile(path, events)
+		require.NoError(t, err)
+		require.Positive(t, size)
+
+		f, err := local.NewLocalFileReader(path)
+		require.NoError(t, err)
+		defer func() { _ = f.Close() }()
+		pr, err := reader.NewParquetReader(f, new(datalake.ParquetEvent), 1)
+		require.NoError(t, err)
+		defer pr.ReadStop()
+		rows := make([]datalake.ParquetEvent, pr.GetNumRows())
+		require.NoError(t, pr.Read(&rows))
+		require.Equal(t, []datalake.ParquetEvent{
+			{
+				MessageID:  "1",
+				Type:       "track",
+				Event:      "Order Completed",
+				UserID:     "u",
+				Timestamp:  time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC).UnixMicro(),
+				ReceivedAt: time.Date(2024, 3, 1, 10, 0, 1, 0, time.UTC).UnixMicro(),
+				Payload:    string(events[0]),
+			},
+			{
+				MessageID:   "2",
+				Type:        "identify",
+				AnonymousID: "a",
+				Timestamp:   time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC).UnixMicro(),
+				ReceivedAt:  time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC).UnixMicro(),
+				Payload:     string(events[1]),
+			},
+		}, rows)
+	})
+}
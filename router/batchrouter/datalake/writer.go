@@ -0,0 +1,97 @@
+package datalake
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+// ParquetEvent is the schema of the rows of parquet files, holding the common fields of the events as columns along
+// with the whole event as json
+type ParquetEvent struct {
+	MessageID   string `parquet:"name=message_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Type        string `parquet:"name=type, type=BYTE_ARRAY, convertedtype=UTF8, encoding=RLE_DICTIONARY"`
+	Event       string `parquet:"name=event, type=BYTE_ARRAY, convertedtype=UTF8, encoding=RLE_DICTIONARY"`
+	UserID      string `parquet:"name=user_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AnonymousID string `parquet:"name=anonymous_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Timestamp   int64  `parquet:"name=timestamp, type=INT64, convertedtype=TIMESTAMP_MICROS"`
+	ReceivedAt  int64  `parquet:"name=received_at, type=INT64, convertedtype=TIMESTAMP_MICROS"`
+	Payload     string `parquet:"name=payload, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+func newParquetEvent(event []byte) *ParquetEvent {
+	e := &ParquetEvent{
+		MessageID:   gjson.GetBytes(event, "messageId").String(),
+		Type:        gjson.GetBytes(event, "type").String(),
+		Event:       gjson.GetBytes(event, "event").String(),
+		UserID:      gjson.GetBytes(event, "userId").String(),
+		AnonymousID: gjson.GetBytes(event, "anonymousId").String(),
+		Timestamp:   EventTime(event).UnixMicro(),
+		Payload:     string(event),
+	}
+	if receivedAt, err := time.Parse(time.RFC3339Nano, gjson.GetBytes(event, "receivedAt").String()); err == nil {
+		e.ReceivedAt = receivedAt.UTC().UnixMicro()
+	}
+	return e
+}
+
+// WriteFile writes the events to a local file in the format of the settings, returning the size of the file
+func (s Settings) WriteFile(path string, events [][]byte) (int64, error) {
+	if s.FileFormat == FormatParquet {
+		if err := writeParquet(path, events); err != nil {
+			return 0, err
+		}
+	} else if err := writeJSON(path, events); err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("stat of %q: %w", path, err)
+	}
+	return info.Size(), nil
+}
+
+func writeJSON(path string, events [][]byte) error {
+	gzWriter, err := misc.CreateGZ(path)
+	if err != nil {
+		return fmt.Errorf("creating %q: %w", path, err)
+	}
+	for _, event := range events {
+		if err := gzWriter.WriteGZ(string(event) + "\n"); err != nil {
+			_ = gzWriter.CloseGZ()
+			return fmt.Errorf("writing to %q: %w", path, err)
+		}
+	}
+	if err := gzWriter.CloseGZ(); err != nil {
+		return fmt.Errorf("closing %q: %w", path, err)
+	}
+	return nil
+}
+
+func writeParquet(path string, events [][]byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating %q: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+	pw, err := writer.NewParquetWriterFromWriter(f, new(ParquetEvent), 1)
+	if err != nil {
+		return fmt.Errorf("creating parquet writer: %w", err)
+	}
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	for _, event := range events {
+		if err := pw.Write(newParquetEvent(event)); err != nil {
+			return fmt.Errorf("writing to parquet writer: %w", err)
+		}
+	}
+	if err := pw.WriteStop(); err != nil {
+		return fmt.Errorf("stopping parquet writer: %w", err)
+	}
+	return f.Close()
+}
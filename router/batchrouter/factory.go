@@ -3,6 +3,7 @@ package batchrouter
 import (
 	"github.com/rudderlabs/rudder-go-kit/config"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/internal/sharding"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
 	"github.com/rudderlabs/rudder-server/services/rsources"
@@ -19,11 +20,13 @@ type Factory struct {
 	RsourcesService  rsources.JobService
 	Debugger         destinationdebugger.DestinationDebugger
 	AdaptiveLimit    func(int64) int64
+	Sharding         *sharding.Manager // nil if destinations aren't sharded across servers
 }
 
 func (f *Factory) New(destType string) *Handle {
 	r := &Handle{
 		adaptiveLimit: f.AdaptiveLimit,
+		sharding:      f.Sharding,
 	}
 
 	r.Setup(
@@ -5,6 +5,7 @@ import (
 	stdjson "encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -29,12 +30,16 @@ import (
 	kitsync "github.com/rudderlabs/rudder-go-kit/sync"
 	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/internal/sharding"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	asynccommon "github.com/rudderlabs/rudder-server/router/batchrouter/asyncdestinationmanager/common"
+	"github.com/rudderlabs/rudder-server/router/batchrouter/datalake"
 	"github.com/rudderlabs/rudder-server/router/batchrouter/isolation"
 	"github.com/rudderlabs/rudder-server/router/rterror"
 	routerutils "github.com/rudderlabs/rudder-server/router/utils"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
+	"github.com/rudderlabs/rudder-server/services/dedup/badger"
+	dedupTypes "github.com/rudderlabs/rudder-server/services/dedup/types"
 	"github.com/rudderlabs/rudder-server/services/diagnostics"
 	"github.com/rudderlabs/rudder-server/services/rmetrics"
 	"github.com/rudderlabs/rudder-server/services/rsources"
@@ -43,6 +48,7 @@ import (
 	"github.com/rudderlabs/rudder-server/utils/types"
 	"github.com/rudderlabs/rudder-server/utils/workerpool"
 	"github.com/rudderlabs/rudder-server/warehouse/client"
+	"github.com/rudderlabs/rudder-server/warehouse/stagingfile"
 	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
 )
 
@@ -67,6 +73,7 @@ type Handle struct {
 	Diagnostics        diagnostics.DiagnosticsI
 	adaptiveLimit      func(int64) int64
 	isolationStrategy  isolation.Strategy
+	sharding           *sharding.Manager // nil if destinations aren't sharded across servers
 	now                func() time.Time
 
 	// configuration
@@ -93,6 +100,8 @@ type Handle struct {
 	transformerURL               string
 	datePrefixOverride           config.ValueLoader[string]
 	customDatePrefix             config.ValueLoader[string]
+	warehouseStreamingEnabled    config.ValueLoader[bool]
+	warehouseStagingFileVersion  config.ValueLoader[int]
 
 	drainer routerutils.Drainer
 
@@ -123,6 +132,9 @@ type Handle struct {
 	lastExecTimesMu sync.RWMutex
 	lastExecTimes   map[string]time.Time
 
+	stagingFilePoliciesMu sync.Mutex
+	stagingFilePolicies   map[string]*stagingFilePolicy // destinationID -> staging file policy
+
 	failingDestinationsMu sync.RWMutex
 	failingDestinations   map[string]bool
 
@@ -136,6 +148,7 @@ type Handle struct {
 
 	diagnosisTicker          *time.Ticker
 	uploadedRawDataJobsCache map[string]map[string]bool
+	warehouseDedup           *badger.Dedup // persistent dedup of events added to warehouse staging files, nil if disabled
 	asyncDestinationStruct   map[string]*asynccommon.AsyncDestinationStruct
 
 	asyncPollTimeStat       stats.Measurement
@@ -236,7 +249,7 @@ func (brt *Handle) getWorkerJobs(partition string) (workerJobs []*DestinationJob
 				processJobs = true
 			} else { // honour upload frequency
 				lastExecTime := brt.lastExecTimes[destID]
-				if lastExecTime.IsZero() || time.Since(lastExecTime) >= brt.uploadFreq.Load() {
+				if lastExecTime.IsZero() || time.Since(lastExecTime) >= brt.uploadFrequency(destID) {
 					processJobs = true
 					brt.lastExecTimes[destID] = time.Now()
 				}
@@ -259,6 +272,15 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 	if brt.disableEgress {
 		return UploadResult{Error: rterror.DisabledEgress}
 	}
+	if !isWarehouse {
+		settings, err := datalake.NewSettings(batchJobs.Connection.Destination.Config)
+		if err != nil {
+			return UploadResult{Error: fmt.Errorf("invalid data lake settings: %w", err)}
+		}
+		if settings.Enabled() {
+			return brt.uploadToDataLake(provider, batchJobs, settings)
+		}
+	}
 
 	var localTmpDirName string
 	if isWarehouse {
@@ -294,7 +316,13 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 		panic(err)
 	}
 
-	var dedupedIDMergeRuleJobs int
+	// v2 staging files end with a footer summarizing their events, see stagingfile.Footer
+	var footer *stagingfile.Footer
+	if isWarehouse && brt.warehouseStagingFileVersion != nil && brt.warehouseStagingFileVersion.Load() >= stagingfile.V2 {
+		footer = &stagingfile.Footer{}
+	}
+	var dedupedIDMergeRuleJobs, dedupedStagingJobs int
+	var dedupKeys []string
 	eventsFound := false
 	connIdentifier := connectionIdentifier(*batchJobs.Connection)
 	brt.configSubscriberMu.RLock()
@@ -322,6 +350,23 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 		}
 
 		eventID := gjson.GetBytes(job.EventPayload, "messageId").String()
+		// do not add to staging file if the event has already been added to a staging file of this destination,
+		// e.g. before a restart which happened after uploading the staging file but before updating the job statuses
+		if isWarehouse && brt.warehouseDedup != nil && eventID != "" {
+			dedupKey := batchJobs.Connection.Destination.ID + ":" + eventID
+			firstTime, _, err := brt.warehouseDedup.Get(dedupTypes.KeyValue{Key: dedupKey, Value: int64(len(job.EventPayload)), WorkspaceID: job.WorkspaceId})
+			if err != nil {
+				brt.logger.Errorn("BRT: Failed to check warehouse staging dedup store, event will not be deduplicated",
+					obskit.DestinationID(batchJobs.Connection.Destination.ID),
+					obskit.Error(err),
+				)
+			} else if !firstTime {
+				dedupedStagingJobs++
+				continue
+			} else {
+				dedupKeys = append(dedupKeys, dedupKey)
+			}
+		}
 		var ok bool
 		interruptedEventsMap, isDestInterrupted := brt.uploadedRawDataJobsCache[batchJobs.Connection.Destination.ID]
 		if isDestInterrupted {
@@ -330,19 +375,41 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 				line := string(job.EventPayload) + "\n"
 				totalBytes += len(line)
 				_ = gzWriter.WriteGZ(line)
+				if footer != nil {
+					footer.Add(job.EventPayload)
+				}
 			}
 		} else {
 			eventsFound = true
 			line := string(job.EventPayload) + "\n"
 			totalBytes += len(line)
 			_ = gzWriter.WriteGZ(line)
+			if footer != nil {
+				footer.Add(job.EventPayload)
+			}
 		}
 	}
+	if footer != nil && eventsFound {
+		line, err := footer.Line()
+		if err != nil {
+			panic(err)
+		}
+		_ = gzWriter.WriteGZ(line)
+	}
 	_ = gzWriter.CloseGZ()
+	if dedupedStagingJobs > 0 {
+		stats.Default.NewTaggedStat("batch_router_warehouse_staging_deduped_events", stats.CountType, stats.Tags{
+			"module":        module,
+			"destType":      brt.destType,
+			"destinationId": batchJobs.Connection.Destination.ID,
+			"workspaceId":   batchJobs.Connection.Destination.WorkspaceID,
+		}).Count(dedupedStagingJobs)
+	}
 	if !eventsFound {
 		brt.logger.Infof("BRT: No events in this batch for upload to %s. Events are either de-deuplicated or skipped", provider)
 		return UploadResult{
 			LocalFilePaths: []string{gzipFilePath},
+			DedupKeys:      dedupKeys,
 		}
 	}
 	// assumes events from warehouse have receivedAt in metadata
@@ -365,6 +432,10 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 
 		firstEventAt = firstEventAtWithTimeZone.UTC().Format(time.RFC3339)
 		lastEventAt = lastEventAtWithTimeZone.UTC().Format(time.RFC3339)
+		if footer != nil && !footer.MinEventAt.IsZero() {
+			firstEventAt = footer.MinEventAt.Format(time.RFC3339)
+			lastEventAt = footer.MaxEventAt.Format(time.RFC3339)
+		}
 	} else {
 		firstEventAt = gjson.GetBytes(batchJobs.Jobs[0].EventPayload, "receivedAt").String()
 		lastEventAt = gjson.GetBytes(batchJobs.Jobs[len(batchJobs.Jobs)-1].EventPayload, "receivedAt").String()
@@ -385,6 +456,7 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 		return UploadResult{
 			Error:          err,
 			LocalFilePaths: []string{gzipFilePath},
+			DedupKeys:      dedupKeys,
 		}
 	}
 
@@ -401,40 +473,7 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 		folderName = config.GetString("DESTINATION_BUCKET_FOLDER_NAME", "rudder-logs")
 	}
 
-	var datePrefixLayout string
-	if brt.datePrefixOverride.Load() != "" {
-		datePrefixLayout = brt.datePrefixOverride.Load()
-	} else {
-		dateFormat, _ := brt.dateFormatProvider.GetFormat(brt.logger, uploader, batchJobs.Connection, folderName)
-		datePrefixLayout = dateFormat
-	}
-
-	workspaceID := batchJobs.Connection.Destination.WorkspaceID
-	customTimezone := brt.conf.GetString("BatchRouter.customTimezone."+workspaceID, "")
-
-	now := brt.now()
-	if customTimezone != "" {
-		loc, err := time.LoadLocation(customTimezone)
-		if err != nil {
-			brt.logger.Errorn(
-				"Error loading custom timezone",
-				obskit.Error(err),
-				obskit.WorkspaceID(workspaceID),
-				logger.NewStringField("customTimezone", customTimezone),
-			)
-		}
-		now = now.In(loc)
-	}
-
-	brt.logger.Debugf("BRT: Date prefix layout is %s", datePrefixLayout)
-	switch datePrefixLayout {
-	case "MM-DD-YYYY": // used to be earlier default
-		datePrefixLayout = now.Format("01-02-2006")
-	default:
-		datePrefixLayout = now.Format("2006-01-02")
-	}
-
-	keyPrefixes := []string{folderName, batchJobs.Connection.Source.ID, brt.customDatePrefix.Load() + datePrefixLayout}
+	keyPrefixes := []string{folderName, batchJobs.Connection.Source.ID, brt.datePrefix(uploader, batchJobs.Connection, folderName)}
 
 	_, fileName := filepath.Split(gzipFilePath)
 	var (
@@ -471,6 +510,7 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 			Error:          err,
 			JournalOpID:    opID,
 			LocalFilePaths: []string{gzipFilePath},
+			DedupKeys:      dedupKeys,
 		}
 	}
 
@@ -482,39 +522,107 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 		JournalOpID:      opID,
 		FirstEventAt:     firstEventAt,
 		LastEventAt:      lastEventAt,
-		TotalEvents:      len(batchJobs.Jobs) - dedupedIDMergeRuleJobs,
+		TotalEvents:      len(batchJobs.Jobs) - dedupedIDMergeRuleJobs - dedupedStagingJobs,
 		TotalBytes:       totalBytes,
 		UseRudderStorage: useRudderStorage,
+		DedupKeys:        dedupKeys,
+		Footer:           footer,
+	}
+}
+
+// datePrefix returns the date folder the files of a connection are uploaded to, in the date format of the bucket
+func (brt *Handle) datePrefix(uploader filemanager.FileManager, connection *Connection, folderName string) string {
+	var datePrefixLayout string
+	if brt.datePrefixOverride.Load() != "" {
+		datePrefixLayout = brt.datePrefixOverride.Load()
+	} else {
+		dateFormat, _ := brt.dateFormatProvider.GetFormat(brt.logger, uploader, connection, folderName)
+		datePrefixLayout = dateFormat
+	}
+
+	workspaceID := connection.Destination.WorkspaceID
+	customTimezone := brt.conf.GetString("BatchRouter.customTimezone."+workspaceID, "")
+
+	now := brt.now()
+	if customTimezone != "" {
+		loc, err := time.LoadLocation(customTimezone)
+		if err != nil {
+			brt.logger.Errorn(
+				"Error loading custom timezone",
+				obskit.Error(err),
+				obskit.WorkspaceID(workspaceID),
+				logger.NewStringField("customTimezone", customTimezone),
+			)
+		}
+		now = now.In(loc)
+	}
+
+	brt.logger.Debugf("BRT: Date prefix layout is %s", datePrefixLayout)
+	switch datePrefixLayout {
+	case "MM-DD-YYYY": // used to be earlier default
+		datePrefixLayout = now.Format("01-02-2006")
+	default:
+		datePrefixLayout = now.Format("2006-01-02")
+	}
+
+	return brt.customDatePrefix.Load() + datePrefixLayout
+}
+
+// commitWarehouseDedupKeys persists the dedup keys of a staging file once it has been accepted by the warehouse service,
+// so that its events are not added to another staging file, even after a restart. Keys of failed uploads are discarded for the events to be retried.
+func (brt *Handle) commitWarehouseDedupKeys(output UploadResult) {
+	if brt.warehouseDedup == nil || len(output.DedupKeys) == 0 {
+		return
+	}
+	if output.Error != nil {
+		brt.warehouseDedup.Discard(output.DedupKeys)
+		return
+	}
+	if err := brt.warehouseDedup.Commit(output.DedupKeys); err != nil {
+		brt.logger.Errorn("BRT: Failed to commit warehouse staging dedup keys", obskit.Error(err))
 	}
 }
 
 // pingWarehouse notifies the warehouse about a new data upload (staging files)
 func (brt *Handle) pingWarehouse(batchJobs *BatchedJobs, output UploadResult) (err error) {
 	schemaMap := make(map[string]map[string]interface{})
-	for _, job := range batchJobs.Jobs {
-		var payload map[string]interface{}
-		err := json.Unmarshal(job.EventPayload, &payload)
-		if err != nil {
-			panic(err)
-		}
-		var ok bool
-		tableName, ok := payload["metadata"].(map[string]interface{})["table"].(string)
-		if !ok {
-			brt.logger.Errorf(`BRT: tableName not found in event metadata: %v`, payload["metadata"])
-			return nil
-		}
-		if _, ok = schemaMap[tableName]; !ok {
-			schemaMap[tableName] = make(map[string]interface{})
-		}
-		columns := payload["metadata"].(map[string]interface{})["columns"].(map[string]interface{})
-		for columnName, columnType := range columns {
-			if _, ok := schemaMap[tableName][columnName]; !ok {
-				schemaMap[tableName][columnName] = columnType
-			} else if columnType == "text" && schemaMap[tableName][columnName] == "string" {
-				// this condition is required for altering string to text. if schemaMap[tableName][columnName] has string and in the next job if it has text type then we change schemaMap[tableName][columnName] to text
+	eventsPerTable := make(map[string]int)
+	if output.Footer != nil {
+		// the footer of v2 staging files already holds the schema and the row counts of their events
+		for tableName, columns := range output.Footer.Schema {
+			schemaMap[tableName] = make(map[string]interface{}, len(columns))
+			for columnName, columnType := range columns {
 				schemaMap[tableName][columnName] = columnType
 			}
 		}
+		maps.Copy(eventsPerTable, output.Footer.RowsPerTable)
+	} else {
+		for _, job := range batchJobs.Jobs {
+			var payload map[string]interface{}
+			err := json.Unmarshal(job.EventPayload, &payload)
+			if err != nil {
+				panic(err)
+			}
+			var ok bool
+			tableName, ok := payload["metadata"].(map[string]interface{})["table"].(string)
+			if !ok {
+				brt.logger.Errorf(`BRT: tableName not found in event metadata: %v`, payload["metadata"])
+				return nil
+			}
+			if _, ok = schemaMap[tableName]; !ok {
+				schemaMap[tableName] = make(map[string]interface{})
+			}
+			eventsPerTable[tableName]++
+			columns := payload["metadata"].(map[string]interface{})["columns"].(map[string]interface{})
+			for columnName, columnType := range columns {
+				if _, ok := schemaMap[tableName][columnName]; !ok {
+					schemaMap[tableName][columnName] = columnType
+				} else if columnType == "text" && schemaMap[tableName][columnName] == "string" {
+					// this condition is required for altering string to text. if schemaMap[tableName][columnName] has string and in the next job if it has text type then we change schemaMap[tableName][columnName] to text
+					schemaMap[tableName][columnName] = columnType
+				}
+			}
+		}
 	}
 	var sampleParameters routerutils.JobParameters
 	err = json.Unmarshal(batchJobs.Jobs[0].Parameters, &sampleParameters)
@@ -532,6 +640,7 @@ func (brt *Handle) pingWarehouse(batchJobs *BatchedJobs, output UploadResult) (e
 		LastEventAt:           output.LastEventAt,
 		TotalEvents:           output.TotalEvents,
 		TotalBytes:            output.TotalBytes,
+		EventsPerTable:        eventsPerTable,
 		UseRudderStorage:      output.UseRudderStorage,
 		SourceTaskRunID:       sampleParameters.SourceTaskRunID,
 		SourceJobID:           sampleParameters.SourceJobID,
@@ -589,6 +698,12 @@ func (brt *Handle) updateJobStatus(batchJobs *BatchedJobs, isWarehouse bool, err
 		brt.logger.Debugf("BRT: Uploaded to object storage : %v at %v", batchJobs.Connection.Source.ID, time.Now().Format("01-02-2006"))
 		batchJobState = jobsdb.Succeeded.State
 		errorResp = []byte(`{"success":"OK"}`)
+		if batchJobs.StagingFile != "" {
+			errorResp, _ = sjson.SetBytes(errorResp, "stagingFile", batchJobs.StagingFile)
+		}
+		if batchJobs.Streamed {
+			errorResp, _ = sjson.SetBytes(errorResp, "streamed", true)
+		}
 		batchReqMetric.batchRequestSuccess = 1
 	}
 	brt.trackRequestMetrics(batchReqMetric)
@@ -710,6 +825,7 @@ func (brt *Handle) updateJobStatus(batchJobs *BatchedJobs, isWarehouse bool, err
 					batchRouterWorkspaceJobStatusCount[workspaceID] += 1
 				}
 				sd.Count++
+				sd.Latency.Observe(parameters.ParseReceivedAtTime())
 				if failedMessage != nil {
 					sd.FailedMessages = append(sd.FailedMessages, failedMessage)
 				}
@@ -582,10 +582,12 @@ func (brt *Handle) getReportMetrics(statusList []*jobsdb.JobStatusT, parametersM
 		case jobsdb.Succeeded.State:
 			routerWorkspaceJobStatusCount[workspaceID]++
 			sd.Count++
+			sd.Latency.Observe(parameters.ParseReceivedAtTime())
 		case jobsdb.Aborted.State:
 			sd.FailedMessages = append(sd.FailedMessages, &utilTypes.FailedMessage{MessageID: parameters.MessageID, ReceivedAt: parameters.ParseReceivedAtTime()})
 			routerWorkspaceJobStatusCount[workspaceID]++
 			sd.Count++
+			sd.Latency.Observe(parameters.ParseReceivedAtTime())
 		}
 	}
 
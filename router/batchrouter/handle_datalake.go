@@ -0,0 +1,180 @@
+package batchrouter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/router/batchrouter/datalake"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+// uploadToDataLake uploads a batch of jobs of an object storage destination according to its data lake settings: the
+// events are split in one file per path rendered from the path template of the destination, written in its file format,
+// and a manifest listing the files of the batch is uploaded once all of them got uploaded.
+func (brt *Handle) uploadToDataLake(provider string, batchJobs *BatchedJobs, settings datalake.Settings) UploadResult {
+	source, destination := batchJobs.Connection.Source, batchJobs.Connection.Destination
+	tmpDirPath, err := misc.CreateTMPDIR()
+	if err != nil {
+		panic(err)
+	}
+	localDir := filepath.Join(tmpDirPath, misc.RudderRawDataDestinationLogs)
+	if err := os.MkdirAll(localDir, os.ModePerm); err != nil {
+		panic(err)
+	}
+
+	uploader, err := brt.fileManagerFactory(&filemanager.Settings{
+		Provider: provider,
+		Config: misc.GetObjectStorageConfig(misc.ObjectStorageOptsT{
+			Provider:    provider,
+			Config:      destination.Config,
+			WorkspaceID: destination.WorkspaceID,
+		}),
+	})
+	if err != nil {
+		return UploadResult{Error: err}
+	}
+
+	var defaultPath string
+	if settings.PathTemplate == "" {
+		folderName := config.GetString("DESTINATION_BUCKET_FOLDER_NAME", "rudder-logs")
+		defaultPath = strings.Join([]string{folderName, source.ID, brt.datePrefix(uploader, batchJobs.Connection, folderName)}, "/")
+	}
+	var paths []string
+	eventsByPath := make(map[string][][]byte)
+	interruptedEventsMap := brt.uploadedRawDataJobsCache[destination.ID]
+	for _, job := range batchJobs.Jobs {
+		// do not upload events which were already uploaded before a crash
+		if _, ok := interruptedEventsMap[gjson.GetBytes(job.EventPayload, "messageId").String()]; ok {
+			continue
+		}
+		path := defaultPath
+		if settings.PathTemplate != "" {
+			path = settings.Path(destination.WorkspaceID, source.ID, destination.ID, job.EventPayload)
+		}
+		if _, ok := eventsByPath[path]; !ok {
+			paths = append(paths, path)
+		}
+		eventsByPath[path] = append(eventsByPath[path], job.EventPayload)
+	}
+	if len(paths) == 0 {
+		brt.logger.Infof("BRT: No events in this batch for upload to %s. Events are either de-deuplicated or skipped", provider)
+		return UploadResult{}
+	}
+
+	now := brt.now()
+	manifest := datalake.Manifest{
+		BatchID:       uuid.NewString(),
+		SourceID:      source.ID,
+		DestinationID: destination.ID,
+		FileFormat:    settings.FileFormat,
+		CreatedAt:     now.UTC(),
+	}
+	result := UploadResult{
+		Config:       destination.Config,
+		FirstEventAt: gjson.GetBytes(batchJobs.Jobs[0].EventPayload, "receivedAt").String(),
+		LastEventAt:  gjson.GetBytes(batchJobs.Jobs[len(batchJobs.Jobs)-1].EventPayload, "receivedAt").String(),
+	}
+	for _, path := range paths {
+		events := eventsByPath[path]
+		fileName := fmt.Sprintf("%v.%v.%v%s", now.Unix(), source.ID, uuid.New(), settings.FileExtension())
+		localFilePath := filepath.Join(localDir, fileName)
+		result.LocalFilePaths = append(result.LocalFilePaths, localFilePath)
+		size, err := settings.WriteFile(localFilePath, events)
+		if err != nil {
+			result.Error = fmt.Errorf("writing data lake file: %w", err)
+			return result
+		}
+		keyPrefixes := strings.Split(path, "/")
+		// only json files can be recovered from, see crashRecover
+		if settings.FileFormat == datalake.FormatJSON {
+			opPayload, _ := json.Marshal(&ObjectStorageDefinition{
+				Config:          destination.Config,
+				Key:             strings.Join(append(keyPrefixes, fileName), "/"),
+				Provider:        provider,
+				DestinationID:   destination.ID,
+				DestinationType: destination.DestinationDefinition.Name,
+			})
+			opID, err := brt.jobsDB.JournalMarkStart(jobsdb.RawDataDestUploadOperation, opPayload)
+			if err != nil {
+				panic(fmt.Errorf("BRT: Error marking start of upload operation in journal: %v", err))
+			}
+			result.JournalOpIDs = append(result.JournalOpIDs, opID)
+		}
+
+		uploadOutput, err := brt.uploadLocalFile(uploader, destination.ID, localFilePath, keyPrefixes)
+		if err != nil {
+			brt.logger.Errorn("BRT: Error uploading data lake file", obskit.DestinationID(destination.ID), obskit.Error(err))
+			result.Error = err
+			return result
+		}
+		file := datalake.ManifestFile{Key: uploadOutput.ObjectName, Location: uploadOutput.Location, Events: len(events), Bytes: size}
+		for i, event := range events {
+			eventTime := datalake.EventTime(event)
+			if i == 0 || eventTime.Before(file.FirstEventAt) {
+				file.FirstEventAt = eventTime
+			}
+			if eventTime.After(file.LastEventAt) {
+				file.LastEventAt = eventTime
+			}
+		}
+		manifest.Files = append(manifest.Files, file)
+		result.Key, result.FileLocation = file.Key, file.Location
+		result.TotalEvents += file.Events
+		result.TotalBytes += int(size)
+	}
+
+	if settings.WriteManifest {
+		data, err := manifest.Marshal()
+		if err != nil {
+			result.Error = fmt.Errorf("marshalling manifest: %w", err)
+			return result
+		}
+		localFilePath := filepath.Join(localDir, datalake.ManifestName(now, manifest.BatchID))
+		result.LocalFilePaths = append(result.LocalFilePaths, localFilePath)
+		if err := os.WriteFile(localFilePath, data, 0o600); err != nil {
+			result.Error = fmt.Errorf("writing manifest: %w", err)
+			return result
+		}
+		keyPrefixes := append(strings.Split(settings.ManifestFolder, "/"), source.ID)
+		uploadOutput, err := brt.uploadLocalFile(uploader, destination.ID, localFilePath, keyPrefixes)
+		if err != nil {
+			brt.logger.Errorn("BRT: Error uploading data lake manifest", obskit.DestinationID(destination.ID), obskit.Error(err))
+			result.Error = err
+			return result
+		}
+		result.Key, result.FileLocation = uploadOutput.ObjectName, uploadOutput.Location
+	}
+	return result
+}
+
+// uploadLocalFile uploads a local file under the given key prefixes
+func (brt *Handle) uploadLocalFile(uploader filemanager.FileManager, destinationID, localFilePath string, keyPrefixes []string) (filemanager.UploadedFile, error) {
+	f, err := os.Open(localFilePath)
+	if err != nil {
+		return filemanager.UploadedFile{}, fmt.Errorf("opening %q: %w", localFilePath, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	startTime := time.Now()
+	uploadOutput, err := uploader.Upload(context.TODO(), f, keyPrefixes...)
+	stats.Default.NewTaggedStat("brt_upload_time", stats.TimerType, map[string]string{
+		"success":     strconv.FormatBool(err == nil),
+		"destType":    brt.destType,
+		"destination": destinationID,
+	}).Since(startTime)
+	return uploadOutput, err
+}
@@ -32,13 +32,16 @@ import (
 	"github.com/rudderlabs/rudder-server/router/batchrouter/isolation"
 	routerutils "github.com/rudderlabs/rudder-server/router/utils"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
+	"github.com/rudderlabs/rudder-server/services/dedup/badger"
 	"github.com/rudderlabs/rudder-server/services/diagnostics"
 	"github.com/rudderlabs/rudder-server/services/rsources"
 	"github.com/rudderlabs/rudder-server/services/transientsource"
 	"github.com/rudderlabs/rudder-server/utils/crash"
+	"github.com/rudderlabs/rudder-server/utils/filemanagerutil"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 	"github.com/rudderlabs/rudder-server/utils/types"
 	"github.com/rudderlabs/rudder-server/warehouse/client"
+	"github.com/rudderlabs/rudder-server/warehouse/stagingfile"
 	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
 )
 
@@ -64,7 +67,7 @@ func (brt *Handle) Setup(
 	brt.jobsDB = jobsDB
 	brt.errorDB = errorDB
 	brt.reporting = reporting
-	brt.fileManagerFactory = filemanager.New
+	brt.fileManagerFactory = filemanagerutil.FailoverFactory(filemanager.New)
 	brt.transientSources = transientSources
 	brt.rsourcesService = rsourcesService
 	if brt.warehouseClient == nil {
@@ -82,12 +85,18 @@ func (brt *Handle) Setup(
 		defaultIsolationMode = isolation.ModeWorkspace
 	}
 	isolationMode := config.GetString("BatchRouter.isolationMode", string(defaultIsolationMode))
+	if brt.sharding != nil && isolation.Mode(isolationMode) != isolation.ModeDestination {
+		brt.logger.Warnn("Sharding destinations requires destination isolation, enabling it",
+			logger.NewStringField("isolationMode", isolationMode),
+		)
+		isolationMode = string(isolation.ModeDestination)
+	}
 	var err error
 	if brt.isolationStrategy, err = isolation.GetStrategy(isolation.Mode(isolationMode), destType, func(destinationID string) bool {
 		brt.configSubscriberMu.RLock()
 		defer brt.configSubscriberMu.RUnlock()
 		_, ok := brt.destinationsMap[destinationID]
-		return ok
+		return ok && brt.sharding.Owns(destinationID)
 	}); err != nil {
 		panic(fmt.Errorf("resolving isolation strategy for mode %q: %w", isolationMode, err))
 	}
@@ -120,11 +129,18 @@ func (brt *Handle) Setup(
 	brt.encounteredMergeRuleMap = map[string]map[string]bool{}
 	brt.uploadIntervalMap = map[string]time.Duration{}
 	brt.lastExecTimes = map[string]time.Time{}
+	brt.stagingFilePolicies = map[string]*stagingFilePolicy{}
 	brt.failingDestinations = map[string]bool{}
 	brt.dateFormatProvider = &storageDateFormatProvider{dateFormatsCache: make(map[string]string)}
 	diagnosisTickerTime := config.GetDurationVar(600, time.Second, "Diagnostics.batchRouterTimePeriod", "Diagnostics.batchRouterTimePeriodInS")
 	brt.diagnosisTicker = time.NewTicker(diagnosisTickerTime)
 	brt.uploadedRawDataJobsCache = make(map[string]map[string]bool)
+	if IsWarehouseDestination(destType) && conf.GetBool("BatchRouter.warehouseDedup.enabled", false) {
+		brt.warehouseDedup = badger.NewBadgerDB(conf, stats.Default, warehouseDedupPath(conf, destType),
+			badger.WithWindow(conf.GetReloadableDurationVar(24, time.Hour, "BatchRouter.warehouseDedup.window")),
+			badger.WithStatName("warehouse_staging_dedup_"+strings.ToLower(destType)),
+		)
+	}
 
 	var limiterGroup sync.WaitGroup
 	limiterStatsPeriod := config.GetDuration("BatchRouter.Limiter.statsPeriod", 15, time.Second)
@@ -216,6 +232,8 @@ func (brt *Handle) setupReloadableVars() {
 	brt.warehouseServiceMaxRetryTime = config.GetReloadableDurationVar(3, time.Hour, "BatchRouter.warehouseServiceMaxRetryTime", "BatchRouter.warehouseServiceMaxRetryTimeinHr")
 	brt.datePrefixOverride = config.GetReloadableStringVar("", "BatchRouter.datePrefixOverride")
 	brt.customDatePrefix = config.GetReloadableStringVar("", "BatchRouter.customDatePrefix")
+	brt.warehouseStreamingEnabled = config.GetReloadableBoolVar(true, "BatchRouter."+brt.destType+".warehouseStreaming.enabled", "BatchRouter.warehouseStreaming.enabled")
+	brt.warehouseStagingFileVersion = config.GetReloadableIntVar(stagingfile.V1, 1, "BatchRouter."+brt.destType+".warehouseStagingFileVersion", "BatchRouter.warehouseStagingFileVersion")
 }
 
 func (brt *Handle) startAsyncDestinationManager() {
@@ -258,6 +276,23 @@ func (brt *Handle) Start() {
 func (brt *Handle) Shutdown() {
 	brt.backgroundCancel()
 	_ = brt.backgroundWait()
+	if brt.warehouseDedup != nil {
+		brt.warehouseDedup.Close()
+	}
+}
+
+// warehouseDedupPath returns the path of the badger DB used for deduplicating events added to warehouse staging files.
+// BatchRouter.warehouseDedup.path should point to a persistent volume for deduplication to survive restarts.
+func warehouseDedupPath(conf *config.Config, destType string) string {
+	basePath := conf.GetString("BatchRouter.warehouseDedup.path", "")
+	if basePath == "" {
+		tmpDirPath, err := misc.CreateTMPDIR()
+		if err != nil {
+			panic(err)
+		}
+		basePath = filepath.Join(tmpDirPath, "warehouse_staging_dedup")
+	}
+	return filepath.Join(basePath, destType)
 }
 
 func (brt *Handle) initAsyncDestinationStruct(destination *backendconfig.DestinationT) {
@@ -10,6 +10,7 @@ import (
 	"github.com/tidwall/sjson"
 
 	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-server/internal/liveevents"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
 	"github.com/rudderlabs/rudder-server/services/diagnostics"
@@ -120,6 +121,7 @@ func (brt *Handle) recordAsyncDestinationDeliveryStatus(sourceID, destinationID
 		ErrorResponse: errorResp,
 	}
 	brt.debugger.RecordEventDeliveryStatus(destinationID, &deliveryStatus)
+	recordLiveEvent(liveevents.Delivered, &deliveryStatus)
 }
 
 func (brt *Handle) recordDeliveryStatus(batchDestination Connection, output UploadResult, isWarehouse bool) {
@@ -165,6 +167,22 @@ func (brt *Handle) recordDeliveryStatus(batchDestination Connection, output Uplo
 		ErrorResponse: errorResp,
 	}
 	brt.debugger.RecordEventDeliveryStatus(batchDestination.Destination.ID, &deliveryStatus)
+	if isWarehouse {
+		recordLiveEvent(liveevents.WarehouseStaged, &deliveryStatus)
+	} else {
+		recordLiveEvent(liveevents.Delivered, &deliveryStatus)
+	}
+}
+
+// recordLiveEvent records the delivery status, or the staging file status of warehouse destinations,
+// to the live events stream
+func recordLiveEvent(stage liveevents.Stage, deliveryStatus *destinationdebugger.DeliveryStatusT) {
+	if !liveevents.Default.Listening() {
+		return
+	}
+	if data, err := json.Marshal(deliveryStatus); err == nil {
+		liveevents.Default.Record(stage, deliveryStatus.SourceID, deliveryStatus.DestinationID, data)
+	}
 }
 
 func (brt *Handle) trackRequestMetrics(batchReqDiagnostics batchRequestMetric) {
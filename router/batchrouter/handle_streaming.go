@@ -0,0 +1,110 @@
+package batchrouter
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"slices"
+	"sort"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/warehouse/client"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+// streamingTablesSetting is the setting of the warehouse destinations listing the tables whose events get streamed
+// directly into the warehouse, bypassing the staging files, if the warehouse supports it
+const streamingTablesSetting = "streamingTables"
+
+// streamingTables returns the tables of the destination whose events get streamed into the warehouse
+func (brt *Handle) streamingTables(batchJobs *BatchedJobs) []string {
+	if !slices.Contains(warehouseutils.StreamingWarehouses, brt.destType) {
+		return nil
+	}
+	tables, _ := batchJobs.Connection.Destination.Config[streamingTablesSetting].([]interface{})
+	var streamingTables []string
+	for _, table := range tables {
+		if tableName, ok := table.(string); ok && tableName != "" {
+			streamingTables = append(streamingTables, tableName)
+		}
+	}
+	return streamingTables
+}
+
+// streamToWarehouse streams the jobs of the streaming tables of the destination into the warehouse, marking them as
+// succeeded, and returns the rest of the jobs, including the ones the warehouse didn't stream, to be uploaded to the
+// staging files.
+//
+// Streaming can be disabled through BatchRouter.<destType>.warehouseStreaming.enabled, in which case all the jobs go to
+// the staging files.
+func (brt *Handle) streamToWarehouse(ctx context.Context, batchJobs BatchedJobs) BatchedJobs {
+	streamingTables := brt.streamingTables(&batchJobs)
+	if len(streamingTables) == 0 || !brt.warehouseStreamingEnabled.Load() {
+		return batchJobs
+	}
+
+	var streamingJobs, remainingJobs []*jobsdb.JobT
+	for _, job := range batchJobs.Jobs {
+		if slices.Contains(streamingTables, gjson.GetBytes(job.EventPayload, "metadata.table").String()) {
+			streamingJobs = append(streamingJobs, job)
+		} else {
+			remainingJobs = append(remainingJobs, job)
+		}
+	}
+	if len(streamingJobs) == 0 {
+		return batchJobs
+	}
+
+	events := make([]stdjson.RawMessage, 0, len(streamingJobs))
+	for _, job := range streamingJobs {
+		events = append(events, job.EventPayload)
+	}
+	res, err := brt.warehouseClient.Stream(ctx, client.StreamRequest{
+		SourceID:      batchJobs.Connection.Source.ID,
+		DestinationID: batchJobs.Connection.Destination.ID,
+		Events:        events,
+	})
+	if err != nil {
+		brt.logger.Warnn("BRT: Failed to stream events to warehouse, uploading them to staging files",
+			obskit.SourceID(batchJobs.Connection.Source.ID),
+			obskit.DestinationID(batchJobs.Connection.Destination.ID),
+			obskit.DestinationType(brt.destType),
+			obskit.WorkspaceID(batchJobs.Connection.Source.WorkspaceID),
+			obskit.Error(err),
+		)
+		return batchJobs
+	}
+
+	var streamedJobs []*jobsdb.JobT
+	for i, job := range streamingJobs {
+		if res.Streamed[i] {
+			streamedJobs = append(streamedJobs, job)
+		} else {
+			remainingJobs = append(remainingJobs, job)
+		}
+	}
+	if len(streamedJobs) > 0 {
+		brt.updateJobStatus(&BatchedJobs{
+			Jobs:       streamedJobs,
+			Connection: batchJobs.Connection,
+			Streamed:   true,
+		}, true, nil, false)
+		stats.Default.NewTaggedStat("batch_router_warehouse_streamed_events", stats.CountType, stats.Tags{
+			"module":        module,
+			"destType":      brt.destType,
+			"workspaceId":   batchJobs.Connection.Source.WorkspaceID,
+			"sourceId":      batchJobs.Connection.Source.ID,
+			"destinationId": batchJobs.Connection.Destination.ID,
+		}).Count(len(streamedJobs))
+	}
+
+	sort.Slice(remainingJobs, func(i, j int) bool {
+		return remainingJobs[i].JobID < remainingJobs[j].JobID
+	})
+	batchJobs.Jobs = remainingJobs
+	return batchJobs
+}
@@ -0,0 +1,93 @@
+package batchrouter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/warehouse/client"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+func TestStreamToWarehouse(t *testing.T) {
+	job := func(id int64, table string) *jobsdb.JobT {
+		return &jobsdb.JobT{JobID: id, EventPayload: []byte(`{"metadata":{"table":"` + table + `","columns":{"id":"int"}},"data":{"id":1}}`)}
+	}
+	batchJobs := func(streamingTables ...interface{}) BatchedJobs {
+		return BatchedJobs{
+			Jobs: []*jobsdb.JobT{job(1, "tracks"), job(2, "identifies"), job(3, "tracks"), job(4, "users")},
+			Connection: &Connection{
+				Source: backendconfig.SourceT{ID: "source-1"},
+				Destination: backendconfig.DestinationT{
+					ID:     "destination-1",
+					Config: map[string]interface{}{streamingTablesSetting: streamingTables},
+				},
+			},
+		}
+	}
+	jobIDs := func(batchJobs BatchedJobs) []int64 {
+		var ids []int64
+		for _, job := range batchJobs.Jobs {
+			ids = append(ids, job.JobID)
+		}
+		return ids
+	}
+	newHandle := func(t *testing.T, destType string, handler http.HandlerFunc) *Handle {
+		ts := httptest.NewServer(handler)
+		t.Cleanup(ts.Close)
+		return &Handle{
+			destType:                  destType,
+			logger:                    logger.NOP,
+			warehouseClient:           client.NewWarehouse(ts.URL),
+			warehouseStreamingEnabled: config.SingleValueLoader(true),
+		}
+	}
+	unexpectedRequest := func(t *testing.T) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}
+
+	t.Run("no streaming tables", func(t *testing.T) {
+		brt := newHandle(t, warehouseutils.BQ, unexpectedRequest(t))
+		require.Equal(t, []int64{1, 2, 3, 4}, jobIDs(brt.streamToWarehouse(context.Background(), batchJobs())))
+	})
+
+	t.Run("warehouse not supporting streaming", func(t *testing.T) {
+		brt := newHandle(t, warehouseutils.SNOWFLAKE, unexpectedRequest(t))
+		require.Equal(t, []int64{1, 2, 3, 4}, jobIDs(brt.streamToWarehouse(context.Background(), batchJobs("tracks"))))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		brt := newHandle(t, warehouseutils.BQ, unexpectedRequest(t))
+		brt.warehouseStreamingEnabled = config.SingleValueLoader(false)
+		require.Equal(t, []int64{1, 2, 3, 4}, jobIDs(brt.streamToWarehouse(context.Background(), batchJobs("tracks"))))
+	})
+
+	t.Run("streaming failed", func(t *testing.T) {
+		brt := newHandle(t, warehouseutils.BQ, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		})
+		require.Equal(t, []int64{1, 2, 3, 4}, jobIDs(brt.streamToWarehouse(context.Background(), batchJobs("tracks"))))
+	})
+
+	t.Run("events not streamed", func(t *testing.T) {
+		brt := newHandle(t, warehouseutils.CLICKHOUSE, func(w http.ResponseWriter, r *http.Request) {
+			var req client.StreamRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			require.Equal(t, "source-1", req.SourceID)
+			require.Equal(t, "destination-1", req.DestinationID)
+			require.Len(t, req.Events, 3)
+			_, _ = w.Write([]byte(`{"streamed":[false,false,false]}`))
+		})
+		require.Equal(t, []int64{1, 2, 3, 4}, jobIDs(brt.streamToWarehouse(context.Background(), batchJobs("tracks", "users"))), "jobs are left to the staging files, in order")
+	})
+}
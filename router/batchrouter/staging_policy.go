@@ -0,0 +1,86 @@
+package batchrouter
+
+import (
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+)
+
+// stagingFilePolicy controls how often the staging files of a warehouse destination get generated and how big they get,
+// so that destinations needing a low latency can get smaller and more frequent staging files:
+//   - maxAge is how long the jobs of the destination wait before getting uploaded to a staging file, BatchRouter.uploadFreq if not set
+//   - maxEvents and maxBytes rotate the staging file once it holds as many events or bytes of event payloads, unlimited if not set
+//
+// Settings are read from BatchRouter.<destinationID>.stagingFile.<setting>, falling back to
+// BatchRouter.<destType>.stagingFile.<setting> and BatchRouter.stagingFile.<setting>.
+type stagingFilePolicy struct {
+	maxAge    config.ValueLoader[time.Duration]
+	maxEvents config.ValueLoader[int]
+	maxBytes  config.ValueLoader[int64]
+}
+
+// stagingFilePolicy returns the staging file policy of the destination
+func (brt *Handle) stagingFilePolicy(destinationID string) *stagingFilePolicy {
+	brt.stagingFilePoliciesMu.Lock()
+	defer brt.stagingFilePoliciesMu.Unlock()
+	if policy, ok := brt.stagingFilePolicies[destinationID]; ok {
+		return policy
+	}
+	keys := func(setting string) []string {
+		return []string{
+			"BatchRouter." + destinationID + ".stagingFile." + setting,
+			"BatchRouter." + brt.destType + ".stagingFile." + setting,
+			"BatchRouter.stagingFile." + setting,
+		}
+	}
+	policy := &stagingFilePolicy{
+		maxAge:    brt.conf.GetReloadableDurationVar(0, time.Second, keys("maxAge")...),
+		maxEvents: brt.conf.GetReloadableIntVar(0, 1, keys("maxEvents")...),
+		maxBytes:  brt.conf.GetReloadableInt64Var(0, 1, keys("maxBytes")...),
+	}
+	if brt.stagingFilePolicies == nil {
+		brt.stagingFilePolicies = make(map[string]*stagingFilePolicy)
+	}
+	brt.stagingFilePolicies[destinationID] = policy
+	return policy
+}
+
+// uploadFrequency returns how often the jobs of the destination get uploaded, which for warehouse destinations is the
+// max age of their staging files
+func (brt *Handle) uploadFrequency(destinationID string) time.Duration {
+	if IsWarehouseDestination(brt.destType) {
+		if maxAge := brt.stagingFilePolicy(destinationID).maxAge.Load(); maxAge > 0 {
+			return maxAge
+		}
+	}
+	return brt.uploadFreq.Load()
+}
+
+// split splits the jobs of a staging file into as many staging files as needed for none of them to exceed the max
+// events and bytes of the policy
+func (p *stagingFilePolicy) split(batchJobs *BatchedJobs) []*BatchedJobs {
+	maxEvents, maxBytes := p.maxEvents.Load(), p.maxBytes.Load()
+	if maxEvents <= 0 && maxBytes <= 0 {
+		return []*BatchedJobs{batchJobs}
+	}
+
+	var batches []*BatchedJobs
+	var current *BatchedJobs
+	var currentBytes int64
+	for _, job := range batchJobs.Jobs {
+		size := int64(len(job.EventPayload))
+		if current == nil ||
+			maxEvents > 0 && len(current.Jobs) >= maxEvents ||
+			maxBytes > 0 && currentBytes+size > maxBytes {
+			current = &BatchedJobs{
+				Connection: batchJobs.Connection,
+				TimeWindow: batchJobs.TimeWindow,
+			}
+			batches = append(batches, current)
+			currentBytes = 0
+		}
+		current.Jobs = append(current.Jobs, job)
+		currentBytes += size
+	}
+	return batches
+}
@@ -0,0 +1,82 @@
+package batchrouter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+
+	"github.com/rudderlabs/rudder-server/jobsdb"
+)
+
+func TestStagingFilePolicy(t *testing.T) {
+	newHandle := func(c *config.Config, destType string) *Handle {
+		return &Handle{
+			destType:   destType,
+			conf:       c,
+			uploadFreq: config.SingleValueLoader(30 * time.Second),
+		}
+	}
+
+	t.Run("upload frequency", func(t *testing.T) {
+		c := config.New()
+		c.Set("BatchRouter.destination-1.stagingFile.maxAge", "5s")
+		c.Set("BatchRouter.SNOWFLAKE.stagingFile.maxAge", "10s")
+
+		brt := newHandle(c, "SNOWFLAKE")
+		require.Equal(t, 5*time.Second, brt.uploadFrequency("destination-1"))
+		require.Equal(t, 10*time.Second, brt.uploadFrequency("destination-2"), "falls back to the destination type")
+
+		require.Equal(t, 30*time.Second, newHandle(c, "POSTGRES").uploadFrequency("destination-2"), "falls back to the upload frequency")
+		require.Equal(t, 30*time.Second, newHandle(c, "S3").uploadFrequency("destination-1"), "not applicable to non warehouse destinations")
+	})
+
+	t.Run("split", func(t *testing.T) {
+		job := func(id int64, payload string) *jobsdb.JobT {
+			return &jobsdb.JobT{JobID: id, EventPayload: []byte(payload)}
+		}
+		timeWindow := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+		batchJobs := &BatchedJobs{
+			Jobs:       []*jobsdb.JobT{job(1, `{"a":1}`), job(2, `{"a":22}`), job(3, `{"a":333}`), job(4, `{"a":4}`)},
+			Connection: &Connection{},
+			TimeWindow: timeWindow,
+		}
+		jobIDs := func(batches []*BatchedJobs) [][]int64 {
+			var ids [][]int64
+			for _, batch := range batches {
+				require.Equal(t, timeWindow, batch.TimeWindow)
+				require.Same(t, batchJobs.Connection, batch.Connection)
+				var batchIDs []int64
+				for _, job := range batch.Jobs {
+					batchIDs = append(batchIDs, job.JobID)
+				}
+				ids = append(ids, batchIDs)
+			}
+			return ids
+		}
+
+		testCases := []struct {
+			name      string
+			maxEvents int
+			maxBytes  int64
+			expected  [][]int64
+		}{
+			{name: "no limits", expected: [][]int64{{1, 2, 3, 4}}},
+			{name: "max events", maxEvents: 3, expected: [][]int64{{1, 2, 3}, {4}}},
+			{name: "max bytes", maxBytes: 17, expected: [][]int64{{1, 2}, {3, 4}}},
+			{name: "events bigger than max bytes", maxBytes: 1, expected: [][]int64{{1}, {2}, {3}, {4}}},
+			{name: "max events and bytes", maxEvents: 1, maxBytes: 100, expected: [][]int64{{1}, {2}, {3}, {4}}},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				policy := &stagingFilePolicy{
+					maxEvents: config.SingleValueLoader(tc.maxEvents),
+					maxBytes:  config.SingleValueLoader(tc.maxBytes),
+				}
+				require.Equal(t, tc.expected, jobIDs(policy.split(batchJobs)))
+			})
+		}
+	})
+}
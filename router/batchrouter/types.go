@@ -6,6 +6,7 @@ import (
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	router_utils "github.com/rudderlabs/rudder-server/router/utils"
+	"github.com/rudderlabs/rudder-server/warehouse/stagingfile"
 )
 
 type Connection struct {
@@ -37,12 +38,15 @@ type UploadResult struct {
 	FileLocation     string
 	LocalFilePaths   []string
 	JournalOpID      int64
+	JournalOpIDs     []int64 // journal entries of the files of data lake uploads, which may upload several files
 	Error            error
 	FirstEventAt     string
 	LastEventAt      string
 	TotalEvents      int
 	TotalBytes       int
 	UseRudderStorage bool
+	DedupKeys        []string            // warehouse staging dedup keys of the events in the staging file, to be committed once the staging file is accepted
+	Footer           *stagingfile.Footer // footer of v2 warehouse staging files, nil for v1 ones
 }
 
 type ErrorResponse struct {
@@ -58,4 +62,8 @@ type BatchedJobs struct {
 	Connection *Connection
 	TimeWindow time.Time
 	JobState   string // ENUM waiting, executing, succeeded, waiting_retry, filtered, failed, aborted, migrating, migrated, wont_migrate
+	// StagingFile is the location of the warehouse staging file the jobs got uploaded to, recorded in their statuses for tracing them
+	StagingFile string
+	// Streamed is whether the jobs got streamed directly into the warehouse instead of being uploaded to a staging file
+	Streamed bool
 }
@@ -45,6 +45,9 @@ func getNamespace(config interface{}, source backendconfig.SourceT, destType str
 		// TODO: Handle if configMap["database"] is nil
 		return configMap["database"].(string)
 	}
+	if namespace, ok := warehouseutils.NamespaceFromTemplate(destType, configMap, source); ok {
+		return namespace
+	}
 	if configMap["namespace"] != nil {
 		namespace = configMap["namespace"].(string)
 		if len(strings.TrimSpace(namespace)) > 0 {
@@ -45,6 +45,12 @@ type worker struct {
 // false otherwise.
 func (w *worker) Work() bool {
 	brt := w.brt
+	// with sharding, partitions are destinations and their shard isn't released while their jobs are being processed
+	brt.sharding.Begin(w.partition)
+	defer brt.sharding.End(w.partition)
+	if !brt.sharding.Owns(w.partition) {
+		return false
+	}
 	workerJobs := brt.getWorkerJobs(w.partition)
 	if len(workerJobs) == 0 {
 		return false
@@ -219,6 +225,9 @@ func (w *worker) processJobAsync(jobsWg *sync.WaitGroup, destinationJobs *Destin
 					if output.JournalOpID > 0 {
 						brt.jobsDB.JournalDeleteEntry(output.JournalOpID)
 					}
+					for _, opID := range output.JournalOpIDs {
+						brt.jobsDB.JournalDeleteEntry(opID)
+					}
 					if output.Error == nil {
 						brt.recordUploadStats(*batchedJobs.Connection, output)
 					}
@@ -229,7 +238,16 @@ func (w *worker) processJobAsync(jobsWg *sync.WaitGroup, destinationJobs *Destin
 					objectStorageType := warehouseutils.ObjectStorageType(brt.destType, batchedJobs.Connection.Destination.Config, useRudderStorage)
 					destUploadStat := stats.Default.NewStat(fmt.Sprintf(`batch_router.%s_%s_dest_upload_time`, brt.destType, objectStorageType), stats.TimerType)
 					destUploadStart := time.Now()
-					splitBatchJobs := brt.splitBatchJobsOnTimeWindow(batchedJobs)
+					batchedJobs := brt.streamToWarehouse(brt.backgroundCtx, batchedJobs)
+					if len(batchedJobs.Jobs) == 0 {
+						destUploadStat.Since(destUploadStart)
+						break
+					}
+					stagingFilePolicy := brt.stagingFilePolicy(destWithSources.Destination.ID)
+					var splitBatchJobs []*BatchedJobs
+					for _, timeWindowBatchJob := range brt.splitBatchJobsOnTimeWindow(batchedJobs) {
+						splitBatchJobs = append(splitBatchJobs, stagingFilePolicy.split(timeWindowBatchJob)...)
+					}
 					for _, batchJob := range splitBatchJobs {
 						output := brt.upload(objectStorageType, batchJob, true)
 						notifyWarehouseErr := false
@@ -237,10 +255,13 @@ func (w *worker) processJobAsync(jobsWg *sync.WaitGroup, destinationJobs *Destin
 							output.Error = brt.pingWarehouse(batchJob, output)
 							if output.Error != nil {
 								notifyWarehouseErr = true
+							} else {
+								batchJob.StagingFile = output.Key
 							}
 							warehouseutils.DestStat(stats.CountType, "generate_staging_files", batchJob.Connection.Destination.ID).Count(1)
 							warehouseutils.DestStat(stats.CountType, "staging_file_batch_size", batchJob.Connection.Destination.ID).Count(len(batchJob.Jobs))
 						}
+						brt.commitWarehouseDedupKeys(output)
 						brt.recordDeliveryStatus(*batchJob.Connection, output, true)
 						brt.updateJobStatus(batchJob, true, output.Error, notifyWarehouseErr)
 						misc.RemoveFilePaths(output.LocalFilePaths...)
@@ -264,10 +285,12 @@ func (w *worker) SleepDurations() (min, max time.Duration) {
 	w.brt.lastExecTimesMu.Lock()
 	defer w.brt.lastExecTimesMu.Unlock()
 	if lastExecTime, ok := w.brt.lastExecTimes[w.partition]; ok {
-		if nextAllowedTime := lastExecTime.Add(w.brt.uploadFreq.Load()); nextAllowedTime.After(time.Now()) {
+		// partitions with an execution time are destinations, see [Handle.getWorkerJobs]
+		uploadFreq := w.brt.uploadFrequency(w.partition)
+		if nextAllowedTime := lastExecTime.Add(uploadFreq); nextAllowedTime.After(time.Now()) {
 			sleepTime := time.Until(nextAllowedTime)
 			// sleep at least until the next upload frequency window opens
-			return sleepTime, w.brt.uploadFreq.Load()
+			return sleepTime, uploadFreq
 		}
 	}
 	return w.brt.minIdleSleep.Load(), w.brt.uploadFreq.Load() / 2
@@ -0,0 +1,218 @@
+// Package capture keeps the most recent requests the router sent to each destination, along with the destination's
+// responses, so that mapping problems can be debugged through the live events api without enabling debug logging.
+// Capturing is disabled by default and applies only to the destinations it is enabled for.
+package capture
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rudderlabs/rudder-go-kit/bytesize"
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are the (lower case) fragments of header and query parameter names whose values are redacted
+var sensitiveKeys = []string{"auth", "token", "secret", "password", "key", "cookie", "signature", "credential"}
+
+// Request is a request sent by the router to a destination
+type Request struct {
+	Method      string                 `json:"method"`
+	URL         string                 `json:"url"`
+	Headers     map[string]interface{} `json:"headers,omitempty"`
+	QueryParams map[string]interface{} `json:"queryParams,omitempty"`
+	Body        string                 `json:"body,omitempty"`
+	// Payload is the body of the request if it isn't serialized yet, which is serialized into Body only when the
+	// exchange is read, so that capturing doesn't serialize every request sent to the destination
+	Payload interface{} `json:"-"`
+}
+
+// Exchange is a request sent to a destination along with the destination's response
+type Exchange struct {
+	DestinationID string    `json:"destinationId"`
+	JobIDs        []int64   `json:"jobIds"`
+	Proxied       bool      `json:"proxied"` // whether the request was sent through the transformer's proxy
+	Request       Request   `json:"request"`
+	StatusCode    int       `json:"statusCode"`
+	Response      string    `json:"response"`
+	LatencyMs     int64     `json:"latencyMs"`
+	CapturedAt    time.Time `json:"capturedAt"`
+}
+
+// Capture keeps the most recent exchanges of every destination in a ring buffer
+type Capture struct {
+	enabled        config.ValueLoader[bool]
+	destinationIDs config.ValueLoader[[]string]
+	size           config.ValueLoader[int]
+	maxBodySize    config.ValueLoader[int64]
+	retention      config.ValueLoader[time.Duration]
+	now            func() time.Time
+
+	mu      sync.Mutex
+	buffers map[string]*ring
+}
+
+type ring struct {
+	exchanges []Exchange
+	next      int // index of the next exchange to be overwritten, once the buffer is full
+}
+
+// New returns a new capture
+func New(conf *config.Config) *Capture {
+	return &Capture{
+		enabled:        conf.GetReloadableBoolVar(false, "Router.responseCapture.enabled"),
+		destinationIDs: conf.GetReloadableStringSliceVar([]string{}, "Router.responseCapture.destinationIds"),
+		size:           conf.GetReloadableIntVar(10, 1, "Router.responseCapture.size"),
+		maxBodySize:    conf.GetReloadableInt64Var(10, bytesize.KB, "Router.responseCapture.maxBodySize"),
+		retention:      conf.GetReloadableDurationVar(1, time.Hour, "Router.responseCapture.retention"),
+		now:            time.Now,
+		buffers:        make(map[string]*ring),
+	}
+}
+
+// Enabled returns true if exchanges with the destination are being captured
+func (c *Capture) Enabled(destinationID string) bool {
+	return c != nil && c.enabled.Load() && slices.Contains(c.destinationIDs.Load(), destinationID)
+}
+
+// Record records an exchange with a destination, after redacting sensitive headers and query parameters and truncating
+// the request's and response's bodies. Exchanges are only kept in memory and older ones are overwritten.
+func (c *Capture) Record(e Exchange) {
+	if !c.Enabled(e.DestinationID) {
+		return
+	}
+	maxBodySize := int(c.maxBodySize.Load())
+	e.Request.URL = redactURL(e.Request.URL)
+	e.Request.Headers = redact(e.Request.Headers)
+	e.Request.QueryParams = redact(e.Request.QueryParams)
+	e.Request.Body = misc.TruncateStr(e.Request.Body, maxBodySize)
+	e.Response = misc.TruncateStr(e.Response, maxBodySize)
+	if e.CapturedAt.IsZero() {
+		e.CapturedAt = c.now()
+	}
+
+	size := c.size.Load()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.buffers[e.DestinationID]
+	if !ok || cap(r.exchanges) != size { // (re)create the buffer if its size changed
+		r = &ring{exchanges: make([]Exchange, 0, size)}
+		c.buffers[e.DestinationID] = r
+	}
+	if len(r.exchanges) < size {
+		r.exchanges = append(r.exchanges, e)
+		return
+	}
+	r.exchanges[r.next] = e
+	r.next = (r.next + 1) % size
+}
+
+// Recent returns the exchanges of the destination captured within the retention period, most recent first
+func (c *Capture) Recent(destinationID string) []Exchange {
+	if c == nil {
+		return nil
+	}
+	exchanges := c.recent(destinationID)
+	maxBodySize := int(c.maxBodySize.Load())
+	for i := range exchanges {
+		if request := &exchanges[i].Request; request.Payload != nil {
+			body, _ := json.Marshal(request.Payload)
+			request.Body, request.Payload = misc.TruncateStr(string(body), maxBodySize), nil
+		}
+	}
+	return exchanges
+}
+
+func (c *Capture) recent(destinationID string) []Exchange {
+	after := c.now().Add(-c.retention.Load())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.buffers[destinationID]
+	if !ok {
+		return nil
+	}
+	exchanges := make([]Exchange, 0, len(r.exchanges))
+	for i := len(r.exchanges) - 1; i >= 0; i-- {
+		e := r.exchanges[(r.next+i)%len(r.exchanges)]
+		if e.CapturedAt.After(after) {
+			exchanges = append(exchanges, e)
+		}
+	}
+	if len(exchanges) == 0 { // all exchanges have expired
+		delete(c.buffers, destinationID)
+	}
+	return exchanges
+}
+
+// HttpHandler returns the http handler of the live events api for the captured exchanges:
+//
+//	GET /{destinationId}    returns the recent exchanges of the destination, most recent first
+func (c *Capture) HttpHandler() http.Handler {
+	srvMux := chi.NewRouter()
+	srvMux.Get("/{destinationId}", func(w http.ResponseWriter, r *http.Request) {
+		exchanges := c.Recent(chi.URLParam(r, "destinationId"))
+		if exchanges == nil {
+			exchanges = []Exchange{}
+		}
+		body, err := json.Marshal(exchanges)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+	return srvMux
+}
+
+// redact returns a copy of the values with the ones of sensitive keys redacted
+func redact(values map[string]interface{}) map[string]interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	res := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		if sensitive(k) {
+			v = redacted
+		}
+		res[k] = v
+	}
+	return res
+}
+
+// redactURL redacts the password and the values of sensitive query parameters of the url
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	for k := range query {
+		if sensitive(k) {
+			query.Set(k, redacted)
+		}
+	}
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	return u.Redacted()
+}
+
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
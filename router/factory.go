@@ -6,7 +6,9 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/internal/sharding"
 	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/router/capture"
 	"github.com/rudderlabs/rudder-server/router/throttler"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
 	"github.com/rudderlabs/rudder-server/services/rsources"
@@ -28,12 +30,18 @@ type Factory struct {
 	ThrottlerFactory           throttler.Factory
 	Debugger                   destinationdebugger.DestinationDebugger
 	AdaptiveLimit              func(int64) int64
+	DestinationDLQ             destinationDLQ // nil if disabled
+	ResponseCapture            *capture.Capture
+	Sharding                   *sharding.Manager // nil if destinations aren't sharded across servers
 }
 
 func (f *Factory) New(destination *backendconfig.DestinationT) *Handle {
 	r := &Handle{
-		Reporting:     f.Reporting,
-		adaptiveLimit: f.AdaptiveLimit,
+		Reporting:       f.Reporting,
+		adaptiveLimit:   f.AdaptiveLimit,
+		destinationDLQ:  f.DestinationDLQ,
+		responseCapture: f.ResponseCapture,
+		sharding:        f.Sharding,
 	}
 	r.Setup(
 		destination.DestinationDefinition,
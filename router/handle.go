@@ -23,9 +23,14 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 	kitsync "github.com/rudderlabs/rudder-go-kit/sync"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	destination_dlq "github.com/rudderlabs/rudder-server/internal/destination-dlq"
+	"github.com/rudderlabs/rudder-server/internal/sharding"
 	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/router/capture"
 	customDestinationManager "github.com/rudderlabs/rudder-server/router/customdestinationmanager"
+	"github.com/rudderlabs/rudder-server/router/internal/circuitbreaker"
 	"github.com/rudderlabs/rudder-server/router/internal/eventorder"
 	"github.com/rudderlabs/rudder-server/router/internal/jobiterator"
 	"github.com/rudderlabs/rudder-server/router/internal/partition"
@@ -47,12 +52,19 @@ import (
 
 const module = "router"
 
+// destinationDLQ persists the jobs aborted while being delivered, so that they can be requeued later on
+type destinationDLQ interface {
+	Add(ctx context.Context, entries []destination_dlq.Entry) error
+}
+
 // Handle is the handle to this module.
 type Handle struct {
 	// external dependencies
 	jobsDB                     jobsdb.JobsDB
 	errorDB                    jobsdb.JobsDB
 	throttlerFactory           rtThrottler.Factory
+	concurrencyLimiter         *rtThrottler.ConcurrencyLimiter
+	circuitBreaker             *circuitbreaker.Breaker
 	backendConfig              backendconfig.BackendConfig
 	Reporting                  reporter
 	transientSources           transientsource.Service
@@ -60,15 +72,19 @@ type Handle struct {
 	transformerFeaturesService transformerFeaturesService.FeaturesService
 	debugger                   destinationdebugger.DestinationDebugger
 	adaptiveLimit              func(int64) int64
+	destinationDLQ             destinationDLQ // nil if disabled
+	responseCapture            *capture.Capture
+	sharding                   *sharding.Manager // nil if destinations aren't sharded across servers
 
 	// configuration
 	reloadableConfig                   *reloadableConfig
 	destType                           string
 	guaranteeUserEventOrder            bool
+	strictEventOrdering                config.ValueLoader[bool]
 	netClientTimeout                   time.Duration
 	transformerTimeout                 time.Duration
 	enableBatching                     bool
-	noOfWorkers                        int
+	noOfWorkers                        config.ValueLoader[int]
 	eventOrderKeyThreshold             config.ValueLoader[int]
 	eventOrderDisabledStateDuration    config.ValueLoader[time.Duration]
 	eventOrderHalfEnabledStateDuration config.ValueLoader[time.Duration]
@@ -84,6 +100,7 @@ type Handle struct {
 	logger                         logger.Logger
 	tracer                         stats.Tracer
 	destinationResponseHandler     ResponseHandler
+	retryPolicy                    *retryPolicy
 	telemetry                      *Diagnostic
 	netHandle                      NetHandle
 	customDestinationManager       customDestinationManager.DestinationManager
@@ -92,6 +109,7 @@ type Handle struct {
 	destinationsMapMu              sync.RWMutex
 	destinationsMap                map[string]*routerutils.DestinationWithSources // destinationID -> destination
 	connectionsMap                 map[types.SourceDest]types.ConnectionWithID
+	destinationNetHandles          map[string]destinationNetHandle // destinationID -> network handle for destinations with a custom tls configuration
 	isBackendConfigInitialized     bool
 	backendConfigInitialized       chan bool
 	responseQ                      chan workerJobStatus
@@ -114,6 +132,7 @@ type Handle struct {
 
 	eventOrderingDisabledForWorkspace   func(workspaceID string) bool
 	eventOrderingDisabledForDestination func(destinationID string) bool
+	strictEventOrderingForDestination   func(destinationID string) bool
 
 	limiter struct {
 		pickup    kitsync.Limiter
@@ -154,14 +173,20 @@ func (rt *Handle) pickup(ctx context.Context, partition string, workers []*worke
 	limiterEnd := limiter.BeginWithPriority(partition, LimiterPriorityValueFrom(limiterStats.Score(partition), 100))
 	defer limiterEnd()
 
+	// with sharding, partitions are destinations and their shard isn't released while jobs are being picked up
+	rt.sharding.Begin(partition)
+	defer rt.sharding.End(partition)
+	if !rt.sharding.Owns(partition) {
+		return 0, false
+	}
+
 	defer func() {
 		limiterStats.Update(partition, time.Since(start), pickupCount+discardedCount, discardedCount)
 	}()
 
 	//#JobOrder (See comment marked #JobOrder
-	if rt.guaranteeUserEventOrder {
-		rt.barrier.Sync()
-	}
+	// syncing regardless of guaranteeUserEventOrder, since destinations requiring strict ordering use the barrier too
+	rt.barrier.Sync()
 
 	var firstJob *jobsdb.JobT
 	var lastJob *jobsdb.JobT
@@ -218,6 +243,7 @@ func (rt *Handle) pickup(ctx context.Context, partition string, workers []*worke
 		rt.logger.Debugf("[DRAIN DEBUG] counts  %v final jobs length being processed %v", rt.destType, len(reservedJobs))
 		assignedTime := time.Now()
 		for _, reservedJob := range reservedJobs {
+			rt.sharding.Begin(partition) // ended once the status of the job is committed
 			reservedJob.slot.Use(workerJob{job: reservedJob.job, assignedAt: assignedTime, drainReason: reservedJob.drainReason})
 		}
 		pickupCount += len(reservedJobs)
@@ -309,6 +335,22 @@ func (rt *Handle) pickup(ctx context.Context, partition string, workers []*worke
 	return
 }
 
+// addToDestinationDLQ persists the aborted jobs to the destination dead-letter queue, if enabled.
+// Failing to do so doesn't fail the router, since aborted jobs are still stored in the proc error DB.
+func (rt *Handle) addToDestinationDLQ(entries []destination_dlq.Entry) {
+	if rt.destinationDLQ == nil || len(entries) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), rt.reloadableConfig.jobsDBCommandTimeout.Load())
+	defer cancel()
+	if err := rt.destinationDLQ.Add(ctx, entries); err != nil {
+		rt.logger.Errorn("Failed to add jobs to the destination dead-letter queue", obskit.Error(err))
+		stats.Default.NewTaggedStat("router_destination_dlq_errors", stats.CountType, stats.Tags{"destType": rt.destType}).Count(len(entries))
+		return
+	}
+	stats.Default.NewTaggedStat("router_destination_dlq_entries", stats.CountType, stats.Tags{"destType": rt.destType}).Count(len(entries))
+}
+
 func (rt *Handle) stopIteration(err error) bool {
 	// if the context is cancelled, we can stop iteration
 	if errors.Is(err, types.ErrContextCancelled) {
@@ -332,6 +374,7 @@ func (rt *Handle) commitStatusList(workerJobStatuses *[]workerJobStatus) {
 	var completedJobsList []*jobsdb.JobT
 	var statusList []*jobsdb.JobStatusT
 	var routerAbortedJobs []*jobsdb.JobT
+	var dlqEntries []destination_dlq.Entry
 	jobIDConnectionDetailsMap := make(map[int64]jobsdb.ConnectionDetails)
 	for _, workerJobStatus := range *workerJobStatuses {
 		var parameters routerutils.JobParameters
@@ -377,13 +420,30 @@ func (rt *Handle) commitStatusList(workerJobStatuses *[]workerJobStatus) {
 		case jobsdb.Succeeded.State, jobsdb.Filtered.State:
 			routerWorkspaceJobStatusCount[workspaceID]++
 			sd.Count++
+			sd.Latency.Observe(parameters.ParseReceivedAtTime())
 			completedJobsList = append(completedJobsList, workerJobStatus.job)
 		case jobsdb.Aborted.State:
 			routerWorkspaceJobStatusCount[workspaceID]++
 			sd.Count++
 			sd.FailedMessages = append(sd.FailedMessages, &utilTypes.FailedMessage{MessageID: parameters.MessageID, ReceivedAt: parameters.ParseReceivedAtTime()})
+			sd.Latency.Observe(parameters.ParseReceivedAtTime())
 			routerAbortedJobs = append(routerAbortedJobs, workerJobStatus.job)
 			completedJobsList = append(completedJobsList, workerJobStatus.job)
+			if rt.destinationDLQ != nil {
+				dlqEntries = append(dlqEntries, destination_dlq.Entry{
+					WorkspaceID:     workspaceID,
+					SourceID:        parameters.SourceID,
+					DestinationID:   parameters.DestinationID,
+					DestinationType: rt.destType,
+					JobID:           workerJobStatus.job.JobID,
+					UserID:          workerJobStatus.job.UserID,
+					Attempts:        workerJobStatus.status.AttemptNum,
+					StatusCode:      errorCode,
+					Response:        workerJobStatus.status.ErrorResponse,
+					Payload:         workerJobStatus.job.EventPayload,
+					Parameters:      workerJobStatus.job.Parameters,
+				})
+			}
 		}
 
 		// REPORTING - ROUTER - END
@@ -445,6 +505,7 @@ func (rt *Handle) commitStatusList(workerJobStatuses *[]workerJobStatus) {
 				panic(fmt.Errorf("storing jobs into ErrorDB: %w", err))
 			}
 		}
+		rt.addToDestinationDLQ(dlqEntries)
 		// Update the status
 		err := misc.RetryWithNotify(context.Background(), rt.reloadableConfig.jobsDBCommandTimeout.Load(), rt.reloadableConfig.jobdDBMaxRetries.Load(), func(ctx context.Context) error {
 			return rt.jobsDB.WithUpdateSafeTx(ctx, func(tx jobsdb.UpdateSafeTx) error {
@@ -477,33 +538,42 @@ func (rt *Handle) commitStatusList(workerJobStatuses *[]workerJobStatus) {
 			)
 		}
 	}
+	// the shards of the destinations can be released once the statuses of their jobs are committed
+	for _, workerJobStatus := range *workerJobStatuses {
+		rt.sharding.End(jobIDConnectionDetailsMap[workerJobStatus.job.JobID].DestinationID)
+	}
 
-	if rt.guaranteeUserEventOrder {
-		//#JobOrder (see other #JobOrder comment)
-		for _, resp := range *workerJobStatuses {
-			status := resp.status.JobState
-			userID := resp.userID
-			worker := resp.worker
-			if status != jobsdb.Failed.State {
-				orderKey := eventorder.BarrierKey{
-					UserID:        userID,
-					DestinationID: gjson.GetBytes(resp.job.Parameters, "destination_id").String(),
-					WorkspaceID:   resp.job.WorkspaceId,
-				}
-				rt.logger.Debugw(
-					"EventOrder",
-					"worker#", worker.id,
-					"jobID", resp.status.JobID,
-					"key", orderKey.String(),
-					"jobState", status,
-				)
-				if err := worker.barrier.StateChanged(orderKey, resp.status.JobID, status); err != nil {
-					panic(err)
-				}
+	//#JobOrder (see other #JobOrder comment)
+	for _, resp := range *workerJobStatuses {
+		status := resp.status.JobState
+		userID := resp.userID
+		worker := resp.worker
+		destinationID := gjson.GetBytes(resp.job.Parameters, "destination_id").String()
+		if status != jobsdb.Failed.State && rt.guaranteeUserEventOrderFor(destinationID) {
+			orderKey := eventorder.BarrierKey{
+				UserID:        userID,
+				DestinationID: destinationID,
+				WorkspaceID:   resp.job.WorkspaceId,
+			}
+			rt.logger.Debugw(
+				"EventOrder",
+				"worker#", worker.id,
+				"jobID", resp.status.JobID,
+				"key", orderKey.String(),
+				"jobState", status,
+			)
+			if err := worker.barrier.StateChanged(orderKey, resp.status.JobID, status); err != nil {
+				panic(err)
 			}
 		}
-		// End #JobOrder
 	}
+	// End #JobOrder
+}
+
+// guaranteeUserEventOrderFor returns whether the order of the events of each user is guaranteed for the destination,
+// i.e. if it is guaranteed for all the destinations of the router or the destination requires strict event ordering
+func (rt *Handle) guaranteeUserEventOrderFor(destinationID string) bool {
+	return rt.guaranteeUserEventOrder || (rt.strictEventOrderingForDestination != nil && rt.strictEventOrderingForDestination(destinationID))
 }
 
 func (rt *Handle) getJobsFn(parentContext context.Context) func(context.Context, jobsdb.GetQueryParams, jobsdb.MoreToken) (*jobsdb.MoreJobsResult, error) {
@@ -554,8 +624,9 @@ func (rt *Handle) findWorkerSlot(ctx context.Context, workers []*worker, job *jo
 		WorkspaceID:   job.WorkspaceId,
 	}
 
-	eventOrderingDisabled := !rt.guaranteeUserEventOrder
-	if (rt.guaranteeUserEventOrder && rt.barrier.Disabled(orderKey)) ||
+	guaranteeUserEventOrder := rt.guaranteeUserEventOrderFor(parameters.DestinationID)
+	eventOrderingDisabled := !guaranteeUserEventOrder
+	if (guaranteeUserEventOrder && rt.barrier.Disabled(orderKey)) ||
 		(rt.eventOrderingDisabledForWorkspace(job.WorkspaceId) ||
 			rt.eventOrderingDisabledForDestination(parameters.DestinationID)) {
 		eventOrderingDisabled = true
@@ -582,6 +653,10 @@ func (rt *Handle) findWorkerSlot(ctx context.Context, workers []*worker, job *jo
 			slot.Release()
 			return nil, types.ErrJobBackoff
 		}
+		if rt.circuitOpen(job, parameters) {
+			slot.Release()
+			return nil, types.ErrDestinationCircuitOpen
+		}
 		if rt.shouldThrottle(ctx, job, parameters) {
 			slot.Release()
 			return nil, types.ErrDestinationThrottled
@@ -620,6 +695,12 @@ func (rt *Handle) findWorkerSlot(ctx context.Context, workers []*worker, job *jo
 		return nil, types.ErrBarrierExists
 	}
 	rt.logger.Debugf("EventOrder: job %d of orderKey %s is allowed to be processed", job.JobID, orderKey)
+	if !abortedJob && rt.circuitOpen(job, parameters) {
+		blockedOrderKeys[orderKey] = struct{}{}
+		worker.barrier.Leave(orderKey, job.JobID)
+		slot.Release()
+		return nil, types.ErrDestinationCircuitOpen
+	}
 	if !abortedJob && rt.shouldThrottle(ctx, job, parameters) {
 		blockedOrderKeys[orderKey] = struct{}{}
 		worker.barrier.Leave(orderKey, job.JobID)
@@ -676,7 +757,31 @@ func (*Handle) shouldBackoff(job *jobsdb.JobT) bool {
 	return job.LastJobStatus.JobState == jobsdb.Failed.State && job.LastJobStatus.AttemptNum > 0 && time.Until(job.LastJobStatus.RetryTime) > 0
 }
 
+// circuitOpen returns true if the circuit of the job's destination doesn't allow any more deliveries for now
+func (rt *Handle) circuitOpen(job *jobsdb.JobT, parameters routerutils.JobParameters) bool {
+	if rt.circuitBreaker == nil || rt.circuitBreaker.Allow(parameters.DestinationID) {
+		return false
+	}
+	rt.logger.Debugf(
+		"[%v Router] :: Skipping processing of job:%d of user:%s as the circuit of destination:%s is open",
+		rt.destType, job.JobID, job.UserID, parameters.DestinationID,
+	)
+	return true
+}
+
 func (rt *Handle) shouldThrottle(ctx context.Context, job *jobsdb.JobT, parameters routerutils.JobParameters) (limited bool) {
+	if rt.concurrencyLimiter != nil && rt.concurrencyLimiter.Saturated(parameters.DestinationID) {
+		// the destination has reached its concurrency limit, let the jobs wait in the queue instead of in workers
+		stats.Default.NewTaggedStat("router_destination_concurrency_throttled", stats.CountType, stats.Tags{
+			"destType":      rt.destType,
+			"destinationId": parameters.DestinationID,
+		}).Increment()
+		rt.logger.Debugf(
+			"[%v Router] :: Skipping processing of job:%d of user:%s as destination concurrency is saturated",
+			rt.destType, job.JobID, job.UserID,
+		)
+		return true
+	}
 	if rt.throttlerFactory == nil {
 		// throttlerFactory could be nil when throttling is disabled or misconfigured.
 		// in case of misconfiguration, logging errors are emitted.
@@ -21,6 +21,7 @@ import (
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	customDestinationManager "github.com/rudderlabs/rudder-server/router/customdestinationmanager"
+	"github.com/rudderlabs/rudder-server/router/internal/circuitbreaker"
 	"github.com/rudderlabs/rudder-server/router/internal/eventorder"
 	"github.com/rudderlabs/rudder-server/router/internal/partition"
 	"github.com/rudderlabs/rudder-server/router/isolation"
@@ -55,6 +56,8 @@ func (rt *Handle) Setup(
 	rt.backendConfig = backendConfig
 	rt.debugger = debugger
 	rt.throttlerFactory = throttlerFactory
+	rt.concurrencyLimiter = throttler.NewConcurrencyLimiter(config, stats.Default, destinationDefinition.Name)
+	rt.circuitBreaker = circuitbreaker.New(config, stats.Default, destinationDefinition.Name)
 
 	destType := destinationDefinition.Name
 	rt.logger = log.Child(destType)
@@ -95,11 +98,17 @@ func (rt *Handle) Setup(
 	rt.telemetry.diagnosisTicker = time.NewTicker(rt.diagnosisTickerTime)
 
 	rt.destinationResponseHandler = NewResponseHandler(rt.logger, destinationDefinition.ResponseRules)
+	rt.retryPolicy = newRetryPolicy(rt.logger, destinationDefinition.ResponseRules)
 	if value, ok := destinationDefinition.Config["saveDestinationResponse"].(bool); ok {
 		rt.saveDestinationResponse = value
 	}
 	rt.guaranteeUserEventOrder = getRouterConfigBool("guaranteeUserEventOrder", rt.destType, true)
-	rt.noOfWorkers = getRouterConfigInt("noOfWorkers", destType, 64)
+	rt.strictEventOrdering = config.GetReloadableBoolVar(false, "Router."+destType+".strictEventOrdering", "Router.strictEventOrdering")
+	if rt.strictEventOrdering.Load() && !rt.guaranteeUserEventOrder {
+		rt.logger.Warnn("Strict event ordering requires guaranteeUserEventOrder, enabling it")
+		rt.guaranteeUserEventOrder = true
+	}
+	rt.noOfWorkers = config.GetReloadableIntVar(64, 1, "Router."+destType+".noOfWorkers", "Router.noOfWorkers")
 	rt.workerInputBufferSize = getRouterConfigInt("noOfJobsPerChannel", destType, 1000)
 
 	rt.enableBatching = config.GetBoolVar(false, "Router."+rt.destType+".enableBatching")
@@ -131,12 +140,18 @@ func (rt *Handle) Setup(
 	rt.backendConfigInitialized = make(chan bool)
 
 	isolationMode := isolationMode(destType, config)
+	if rt.sharding != nil && isolationMode != isolation.ModeDestination {
+		rt.logger.Warnn("Sharding destinations requires destination isolation, enabling it",
+			logger.NewStringField("isolationMode", string(isolationMode)),
+		)
+		isolationMode = isolation.ModeDestination
+	}
 	var err error
 	if rt.isolationStrategy, err = isolation.GetStrategy(isolationMode, rt.destType, func(destinationID string) bool {
 		rt.destinationsMapMu.RLock()
 		defer rt.destinationsMapMu.RUnlock()
 		_, ok := rt.destinationsMap[destinationID]
-		return ok
+		return ok && rt.sharding.Owns(destinationID)
 	}); err != nil {
 		panic(fmt.Errorf("resolving isolation strategy for mode %q: %w", isolationMode, err))
 	}
@@ -147,6 +162,9 @@ func (rt *Handle) Setup(
 	rt.eventOrderingDisabledForDestination = func(destinationID string) bool {
 		return slices.Contains(config.GetStringSlice("Router.orderingDisabledDestinationIDs", nil), destinationID)
 	}
+	rt.strictEventOrderingForDestination = func(destinationID string) bool {
+		return rt.strictEventOrdering.Load() || slices.Contains(config.GetStringSlice("Router.strictOrderingDestinationIDs", nil), destinationID)
+	}
 	rt.barrier = eventorder.NewBarrier(eventorder.WithMetadata(map[string]string{
 		"destType":         rt.destType,
 		"batching":         strconv.FormatBool(rt.enableBatching),
@@ -160,6 +178,9 @@ func (rt *Handle) Setup(
 		eventorder.WithOrderingDisabledCheckForBarrierKey(func(key eventorder.BarrierKey) bool {
 			return rt.eventOrderingDisabledForWorkspace(key.WorkspaceID) || rt.eventOrderingDisabledForDestination(key.DestinationID)
 		}),
+		eventorder.WithStrictOrderingCheckForBarrierKey(func(key eventorder.BarrierKey) bool {
+			return rt.strictEventOrderingForDestination(key.DestinationID)
+		}),
 	)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -432,6 +453,7 @@ func (rt *Handle) backendConfigSubscriber() {
 						destinationsMap[destination.ID].Sources = append(destinationsMap[destination.ID].Sources, *source)
 
 						rt.destinationResponseHandler = NewResponseHandler(rt.logger, destination.DestinationDefinition.ResponseRules)
+						rt.retryPolicy = newRetryPolicy(rt.logger, destination.DestinationDefinition.ResponseRules)
 						if value, ok := destination.DestinationDefinition.Config["saveDestinationResponse"].(bool); ok {
 							rt.saveDestinationResponse = value
 						}
@@ -460,9 +482,11 @@ func (rt *Handle) backendConfigSubscriber() {
 				}
 			}
 		}
+		destinationNetHandles := rt.destinationNetHandlesFor(destinationsMap)
 		rt.destinationsMapMu.Lock()
 		rt.connectionsMap = connectionsMap
 		rt.destinationsMap = destinationsMap
+		rt.destinationNetHandles = destinationNetHandles
 		rt.destinationsMapMu.Unlock()
 		if !rt.isBackendConfigInitialized {
 			rt.isBackendConfigInitialized = true
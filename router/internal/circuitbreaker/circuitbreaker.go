@@ -0,0 +1,203 @@
+// Package circuitbreaker keeps a circuit per destination, opening it when the destination keeps failing with server
+// errors or timeouts, so that the router stops spending its workers on a destination endpoint which is down.
+//
+// While a circuit is closed every delivery is allowed. After a number of consecutive failed deliveries the circuit
+// opens, and no deliveries are allowed for the open timeout. Then the circuit becomes half-open, allowing a few probe
+// deliveries: the circuit closes as soon as one of them succeeds and opens again if one of them fails.
+package circuitbreaker
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+)
+
+// State is the state of the circuit of a destination
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// Breaker keeps the circuits of the destinations of a destination type, configured through
+// Router.<destType>.circuitBreaker.* or Router.circuitBreaker.* and disabled by default.
+type Breaker struct {
+	destType string
+	stats    stats.Stats
+	now      func() time.Time
+
+	config struct {
+		enabled             config.ValueLoader[bool]
+		consecutiveFailures config.ValueLoader[int]
+		openTimeout         config.ValueLoader[time.Duration]
+		halfOpenProbes      config.ValueLoader[int]
+	}
+
+	circuitsMu sync.Mutex
+	circuits   map[string]*circuit // destinationID -> circuit
+}
+
+type circuit struct {
+	mu       sync.Mutex
+	state    State
+	failures int       // consecutive failures while closed
+	openedAt time.Time // when the circuit last opened
+	probes   int       // probes allowed while half-open
+	probedAt time.Time // when the last probe was allowed
+
+	stateStat       stats.Measurement
+	transitionStats map[State]stats.Measurement
+	rejectedStat    stats.Measurement
+}
+
+// New returns a circuit breaker for the destinations of the destination type
+func New(conf *config.Config, statsFactory stats.Stats, destType string) *Breaker {
+	b := &Breaker{
+		destType: destType,
+		stats:    statsFactory,
+		now:      time.Now,
+		circuits: make(map[string]*circuit),
+	}
+	b.config.enabled = conf.GetReloadableBoolVar(false, "Router."+destType+".circuitBreaker.enabled", "Router.circuitBreaker.enabled")
+	b.config.consecutiveFailures = conf.GetReloadableIntVar(50, 1, "Router."+destType+".circuitBreaker.consecutiveFailures", "Router.circuitBreaker.consecutiveFailures")
+	b.config.openTimeout = conf.GetReloadableDurationVar(30, time.Second, "Router."+destType+".circuitBreaker.openTimeout", "Router.circuitBreaker.openTimeout")
+	b.config.halfOpenProbes = conf.GetReloadableIntVar(1, 1, "Router."+destType+".circuitBreaker.halfOpenProbes", "Router.circuitBreaker.halfOpenProbes")
+	return b
+}
+
+// Allow returns true if a job of the destination can be picked up for delivery. While the circuit is half-open, only
+// up to halfOpenProbes jobs are allowed until one of their deliveries is reported, or until the open timeout passes again.
+func (b *Breaker) Allow(destID string) bool {
+	if !b.config.enabled.Load() {
+		return true
+	}
+	c := b.get(destID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := b.now()
+	openTimeout := b.config.openTimeout.Load()
+	if c.state == StateOpen && now.Sub(c.openedAt) >= openTimeout {
+		c.transition(StateHalfOpen)
+		c.probes = 0
+	}
+	switch c.state {
+	case StateOpen:
+		c.rejectedStat.Increment()
+		return false
+	case StateHalfOpen:
+		if c.probes >= b.config.halfOpenProbes.Load() {
+			if now.Sub(c.probedAt) < openTimeout {
+				c.rejectedStat.Increment()
+				return false
+			}
+			// probes which never got reported, e.g. because their jobs got filtered, shouldn't keep the circuit half-open forever
+			c.probes = 0
+		}
+		c.probes++
+		c.probedAt = now
+	}
+	return true
+}
+
+// Open returns true if the circuit of the destination is open, in which case deliveries should fail fast without
+// reaching the destination
+func (b *Breaker) Open(destID string) bool {
+	if !b.config.enabled.Load() {
+		return false
+	}
+	c := b.get(destID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state == StateOpen && b.now().Sub(c.openedAt) < b.config.openTimeout.Load()
+}
+
+// Report records the outcome of a delivery to the destination. Server errors and timeouts count as failures, while
+// any other response, including client errors and throttling, shows that the destination endpoint is reachable.
+func (b *Breaker) Report(destID string, statusCode int) {
+	if !b.config.enabled.Load() {
+		return
+	}
+	c := b.get(destID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if statusCode < http.StatusInternalServerError {
+		c.failures = 0
+		if c.state != StateClosed {
+			c.transition(StateClosed)
+		}
+		return
+	}
+	switch c.state {
+	case StateClosed:
+		c.failures++
+		if c.failures >= b.config.consecutiveFailures.Load() {
+			c.open(b.now())
+		}
+	case StateHalfOpen:
+		c.open(b.now())
+	}
+}
+
+// State returns the current state of the circuit of the destination
+func (b *Breaker) State(destID string) State {
+	c := b.get(destID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+func (b *Breaker) get(destID string) *circuit {
+	b.circuitsMu.Lock()
+	defer b.circuitsMu.Unlock()
+	if c, ok := b.circuits[destID]; ok {
+		return c
+	}
+	tags := stats.Tags{"destType": b.destType, "destinationId": destID}
+	c := &circuit{
+		stateStat:       b.stats.NewTaggedStat("router_destination_circuit_state", stats.GaugeType, tags),
+		rejectedStat:    b.stats.NewTaggedStat("router_destination_circuit_rejected_jobs", stats.CountType, tags),
+		transitionStats: make(map[State]stats.Measurement),
+	}
+	for _, state := range []State{StateClosed, StateHalfOpen, StateOpen} {
+		c.transitionStats[state] = b.stats.NewTaggedStat("router_destination_circuit_transitions", stats.CountType, stats.Tags{
+			"destType":      b.destType,
+			"destinationId": destID,
+			"state":         state.String(),
+		})
+	}
+	b.circuits[destID] = c
+	return c
+}
+
+// open opens the circuit. It should be called with the lock held.
+func (c *circuit) open(now time.Time) {
+	c.failures = 0
+	c.openedAt = now
+	c.transition(StateOpen)
+}
+
+// transition moves the circuit to the state, reporting it. It should be called with the lock held.
+func (c *circuit) transition(state State) {
+	c.state = state
+	c.stateStat.Gauge(int(state))
+	c.transitionStats[state].Increment()
+}
@@ -0,0 +1,81 @@
+package circuitbreaker
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+)
+
+func TestBreaker(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		b := New(config.New(), stats.NOP, "destType")
+		for i := 0; i < 100; i++ {
+			b.Report("destID", http.StatusInternalServerError)
+		}
+		require.True(t, b.Allow("destID"))
+		require.False(t, b.Open("destID"))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		conf := config.New()
+		conf.Set("Router.destType.circuitBreaker.enabled", true)
+		conf.Set("Router.circuitBreaker.consecutiveFailures", 3)
+		conf.Set("Router.circuitBreaker.openTimeout", 10*time.Second)
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+		b := New(conf, statsStore, "destType")
+		now := time.Now()
+		b.now = func() time.Time { return now }
+		tags := stats.Tags{"destType": "destType", "destinationId": "destID"}
+
+		b.Report("destID", http.StatusInternalServerError)
+		b.Report("destID", http.StatusGatewayTimeout)
+		b.Report("destID", http.StatusBadRequest)
+		b.Report("destID", http.StatusInternalServerError)
+		b.Report("destID", http.StatusInternalServerError)
+		require.Equal(t, StateClosed, b.State("destID"), "client errors should reset the consecutive failures")
+		require.True(t, b.Allow("destID"))
+
+		b.Report("destID", http.StatusInternalServerError)
+		require.Equal(t, StateOpen, b.State("destID"))
+		require.True(t, b.Open("destID"))
+		require.False(t, b.Allow("destID"))
+		require.True(t, b.Allow("otherDestID"), "circuits should be per destination")
+		require.EqualValues(t, StateOpen, statsStore.Get("router_destination_circuit_state", tags).LastValue())
+		require.EqualValues(t, 1, statsStore.Get("router_destination_circuit_rejected_jobs", tags).LastValue())
+
+		// half-open: a single probe is allowed, failing re-opens the circuit
+		now = now.Add(10 * time.Second)
+		require.False(t, b.Open("destID"))
+		require.True(t, b.Allow("destID"))
+		require.Equal(t, StateHalfOpen, b.State("destID"))
+		require.False(t, b.Allow("destID"))
+		b.Report("destID", http.StatusServiceUnavailable)
+		require.Equal(t, StateOpen, b.State("destID"))
+
+		// half-open: a probe which is never reported gets replaced after the open timeout
+		now = now.Add(10 * time.Second)
+		require.True(t, b.Allow("destID"))
+		require.False(t, b.Allow("destID"))
+		now = now.Add(10 * time.Second)
+		require.True(t, b.Allow("destID"))
+
+		// half-open: a successful probe closes the circuit
+		b.Report("destID", http.StatusOK)
+		require.Equal(t, StateClosed, b.State("destID"))
+		require.True(t, b.Allow("destID"))
+		require.True(t, b.Allow("destID"))
+		require.EqualValues(t, StateClosed, statsStore.Get("router_destination_circuit_state", tags).LastValue())
+		require.EqualValues(t, 2, statsStore.Get("router_destination_circuit_transitions", stats.Tags{
+			"destType":      "destType",
+			"destinationId": "destID",
+			"state":         "open",
+		}).LastValue())
+	})
+}
@@ -64,6 +64,14 @@ func WithOrderingDisabledCheckForBarrierKey(orderingDisabledForKey func(key Barr
 	}
 }
 
+// WithStrictOrderingCheckForBarrierKey sets the check for keys requiring strict ordering. The barrier is never disabled for such keys
+// after reaching the event order key threshold, instead it stops accepting jobs until the number of concurrent jobs drops below the threshold.
+func WithStrictOrderingCheckForBarrierKey(strictOrderingForKey func(key BarrierKey) bool) OptFn {
+	return func(b *Barrier) {
+		b.strictOrderingForKey = strictOrderingForKey
+	}
+}
+
 // NewBarrier creates a new properly initialized Barrier
 func NewBarrier(fns ...OptFn) *Barrier {
 	b := &Barrier{
@@ -107,6 +115,7 @@ type Barrier struct {
 	debugInfo func(key BarrierKey) string
 
 	orderingDisabledForKey func(key BarrierKey) bool
+	strictOrderingForKey   func(key BarrierKey) bool
 }
 
 type BarrierKey struct {
@@ -142,8 +151,11 @@ func (b *Barrier) Enter(key BarrierKey, jobID int64) (accepted bool, previousFai
 		return true, nil
 	}
 
-	// if key threshold is reached, disable the barrier and accept the job
+	// if key threshold is reached, disable the barrier and accept the job, unless the key requires strict ordering
 	if barrier.concurrencyLimitReached(jobID, b.eventOrderKeyThreshold.Load()) {
+		if b.strictOrdering(key) {
+			return false, nil
+		}
 		b.barriers[key] = &barrierInfo{
 			state:              stateDisabled,
 			stateTime:          time.Now(),
@@ -285,12 +297,13 @@ func (b *Barrier) Sync() int {
 	return flushed
 }
 
-// Disabled returns [true] if the barrier is disabled for this key, [false] otherwise
+// Disabled returns [true] if the barrier is disabled for this key, [false] otherwise.
+// A barrier is never reported as disabled for keys requiring strict ordering.
 func (b *Barrier) Disabled(key BarrierKey) bool {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 	barrier, ok := b.barriers[key]
-	return ok && barrier.state == stateDisabled
+	return ok && barrier.state == stateDisabled && !b.strictOrdering(key)
 }
 
 // Size returns the number of active barriers
@@ -319,7 +332,7 @@ func (b *Barrier) String() string {
 
 // updateState applies the state transitions for the barrier if necessary.
 //
-// 1. Disabled: transitions to half-enabled after disabledStateDuration
+// 1. Disabled: transitions to half-enabled after disabledStateDuration, or right away if the key requires strict ordering
 // 2. Half-enabled: transitions to enabled after halfEnabledStateDuration
 func (b *Barrier) updateState(barrier *barrierInfo, key BarrierKey) {
 	if b.orderingDisabledForKey != nil && b.orderingDisabledForKey(key) {
@@ -328,7 +341,7 @@ func (b *Barrier) updateState(barrier *barrierInfo, key BarrierKey) {
 	}
 	switch barrier.state {
 	case stateDisabled:
-		if time.Since(barrier.stateTime) > b.disabledStateDuration.Load() {
+		if time.Since(barrier.stateTime) > b.disabledStateDuration.Load() || b.strictOrdering(key) {
 			barrier.state = stateHalfEnabled
 			barrier.stateTime = time.Now()
 		}
@@ -340,6 +353,14 @@ func (b *Barrier) updateState(barrier *barrierInfo, key BarrierKey) {
 	}
 }
 
+// strictOrdering returns [true] if the key requires strict ordering and ordering isn't explicitly disabled for it
+func (b *Barrier) strictOrdering(key BarrierKey) bool {
+	if b.orderingDisabledForKey != nil && b.orderingDisabledForKey(key) {
+		return false
+	}
+	return b.strictOrderingForKey != nil && b.strictOrderingForKey(key)
+}
+
 type barrierState int
 
 const (
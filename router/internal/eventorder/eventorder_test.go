@@ -358,6 +358,56 @@ func TestOrderingDisable(t *testing.T) {
 	require.Equal(t, 2, barrier.Size(), "barrier should have size of 2")
 }
 
+func TestStrictOrdering(t *testing.T) {
+	orderKey := BarrierKey{UserID: "user1"}
+	var strict bool
+	barrier := NewBarrier(
+		WithEventOrderKeyThreshold(config.SingleValueLoader(2)),
+		WithDisabledStateDuration(config.SingleValueLoader(time.Hour)),
+		WithStrictOrderingCheckForBarrierKey(func(BarrierKey) bool {
+			return strict
+		}),
+	)
+
+	// reach the threshold while ordering isn't strict
+	for jobID := int64(1); jobID <= 3; jobID++ {
+		enter, _ := barrier.Enter(orderKey, jobID)
+		require.True(t, enter, "job %d for %s should be accepted", jobID, orderKey)
+	}
+	require.True(t, barrier.Disabled(orderKey), "barrier should be disabled for %s after reaching the threshold", orderKey)
+
+	strict = true
+	require.False(t, barrier.Disabled(orderKey), "barrier should never be reported as disabled for %s with strict ordering", orderKey)
+	enter, previous := barrier.Enter(orderKey, 1)
+	require.True(t, enter, "job 1 for %s should be accepted", orderKey)
+	require.Nil(t, previous)
+	enter, previous = barrier.Enter(orderKey, 2)
+	require.True(t, enter, "job 2 for %s should be accepted", orderKey)
+	require.Nil(t, previous)
+
+	enter, previous = barrier.Enter(orderKey, 3)
+	require.False(t, enter, "job 3 for %s should not be accepted since the threshold has been reached and ordering is strict", orderKey)
+	require.Nil(t, previous)
+	require.False(t, barrier.Disabled(orderKey), "barrier should not be disabled for %s with strict ordering", orderKey)
+
+	require.NoError(t, barrier.StateChanged(orderKey, 1, jobsdb.Failed.State))
+	barrier.Sync()
+	require.True(t, firstBool(barrier.Wait(orderKey, 2)), "job 2 for %s should wait for the failed job 1", orderKey)
+
+	barrier.Leave(orderKey, 2)
+	enter, previous = barrier.Enter(orderKey, 1)
+	require.True(t, enter, "failed job 1 for %s should be accepted again", orderKey)
+	require.EqualValues(t, 1, *previous)
+	enter, _ = barrier.Enter(orderKey, 3)
+	require.False(t, enter, "job 3 for %s should not be accepted while job 1 is failed", orderKey)
+
+	require.NoError(t, barrier.StateChanged(orderKey, 1, jobsdb.Succeeded.State))
+	barrier.Sync()
+	enter, previous = barrier.Enter(orderKey, 2)
+	require.True(t, enter, "job 2 for %s should be accepted after job 1 succeeded", orderKey)
+	require.Nil(t, previous)
+}
+
 func firstBool(v bool, _ ...interface{}) bool {
 	return v
 }
@@ -55,6 +55,7 @@ func TestIsolationStrategy(t *testing.T) {
 		t.Run("stop iteration", func(t *testing.T) {
 			require.False(t, strategy.StopIteration(types.ErrBarrierExists))
 			require.True(t, strategy.StopIteration(types.ErrDestinationThrottled))
+			require.True(t, strategy.StopIteration(types.ErrDestinationCircuitOpen))
 		})
 	})
 }
@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/samber/lo"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/internal/sharding"
+	"github.com/rudderlabs/rudder-server/jobsdb"
 	"github.com/rudderlabs/rudder-server/router"
 	"github.com/rudderlabs/rudder-server/router/batchrouter"
 	"github.com/rudderlabs/rudder-server/utils/misc"
@@ -47,6 +50,11 @@ func (r *LifecycleManager) Stop() {
 func New(rtFactory *router.Factory, brtFactory *batchrouter.Factory,
 	backendConfig backendconfig.BackendConfig, logger logger.Logger,
 ) *LifecycleManager {
+	if rtFactory.Sharding != nil {
+		rtFactory.Sharding.OnAcquire(func(ctx context.Context, shard int) error {
+			return failExecutingOfShard(ctx, rtFactory.Sharding, shard, rtFactory.RouterDB, brtFactory.RouterDB)
+		})
+	}
 	return &LifecycleManager{
 		logger:        logger,
 		rt:            rtFactory,
@@ -55,6 +63,23 @@ func New(rtFactory *router.Factory, brtFactory *batchrouter.Factory,
 	}
 }
 
+// failExecutingOfShard fails the jobs of the destinations of the shard left executing by its previous owner
+func failExecutingOfShard(ctx context.Context, m *sharding.Manager, shard int, dbs ...jobsdb.JobsDB) error {
+	for _, db := range dbs {
+		destinationIDs, err := db.GetDistinctParameterValues(ctx, "destination_id")
+		if err != nil {
+			return fmt.Errorf("getting destinations: %w", err)
+		}
+		destinationIDs = lo.Filter(destinationIDs, func(destinationID string, _ int) bool {
+			return m.Shard(destinationID) == shard
+		})
+		if err := db.FailExecutingOfDestinations(ctx, destinationIDs); err != nil {
+			return fmt.Errorf("failing executing jobs: %w", err)
+		}
+	}
+	return nil
+}
+
 func cleanUpAsyncDestinationsLogsDir() {
 	localTmpDirName := fmt.Sprintf(`/%s/`, misc.RudderAsyncDestinationLogs)
 
@@ -80,8 +105,11 @@ func (r *LifecycleManager) monitorDestRouters(
 	// rt / batch_rt tables and there would be a delay reading from the 'ch' channel
 	// However, this shouldn't be the problem since backend config pushes config
 	// to its subscribers in separate goroutines to prevent blocking.
-	routerFactory.RouterDB.FailExecuting()
-	batchrouterFactory.RouterDB.FailExecuting()
+	// With sharding, the jobs of other servers are left alone and the ones of each shard are failed when acquiring it.
+	if routerFactory.Sharding == nil {
+		routerFactory.RouterDB.FailExecuting()
+		batchrouterFactory.RouterDB.FailExecuting()
+	}
 
 	// Remove all contents of aysnc destinations logs directory
 	cleanUpAsyncDestinationsLogsDir()
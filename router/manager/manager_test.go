@@ -16,12 +16,15 @@ import (
 
 	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
 	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
 	"github.com/rudderlabs/rudder-server/admin"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/enterprise/reporting"
+	"github.com/rudderlabs/rudder-server/internal/sharding"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	mocksBackendConfig "github.com/rudderlabs/rudder-server/mocks/backend-config"
+	mocksJobsDB "github.com/rudderlabs/rudder-server/mocks/jobsdb"
 	"github.com/rudderlabs/rudder-server/router"
 	"github.com/rudderlabs/rudder-server/router/batchrouter"
 	"github.com/rudderlabs/rudder-server/router/throttler"
@@ -178,3 +181,24 @@ func (m *mockJobsDB) GetToProcess(ctx context.Context, params jobsdb.GetQueryPar
 	m.called.Store(true)
 	return m.JobsDB.GetToProcess(ctx, params, more)
 }
+
+func TestFailExecutingOfShard(t *testing.T) {
+	c := config.New()
+	c.Set("Router.sharding.shards", 2)
+	m := sharding.New("Router", c, logger.NOP, stats.NOP, nil)
+	destinationIDs := []string{"destination-1", "destination-2", "destination-3", "destination-4"}
+	var shard0 []string
+	for _, destinationID := range destinationIDs {
+		if m.Shard(destinationID) == 0 {
+			shard0 = append(shard0, destinationID)
+		}
+	}
+
+	ctrl := gomock.NewController(t)
+	rtDB, brtDB := mocksJobsDB.NewMockJobsDB(ctrl), mocksJobsDB.NewMockJobsDB(ctrl)
+	rtDB.EXPECT().GetDistinctParameterValues(gomock.Any(), "destination_id").Return(destinationIDs, nil)
+	rtDB.EXPECT().FailExecutingOfDestinations(gomock.Any(), shard0).Return(nil)
+	brtDB.EXPECT().GetDistinctParameterValues(gomock.Any(), "destination_id").Return(nil, nil)
+	brtDB.EXPECT().FailExecutingOfDestinations(gomock.Any(), gomock.Len(0)).Return(nil)
+	require.NoError(t, failExecutingOfShard(context.Background(), m, 0, rtDB, brtDB))
+}
@@ -228,6 +228,7 @@ func (network *netHandle) SendPost(ctx context.Context, structData integrations.
 			StatusCode:          resp.StatusCode,
 			ResponseBody:        respBody,
 			ResponseContentType: contentTypeHeader,
+			RetryAfter:          resp.Header.Get("Retry-After"),
 		}
 	}
 
@@ -273,3 +274,31 @@ func (network *netHandle) Setup(destID string, netClientTimeout time.Duration) {
 	network.logger.Info("netClientTimeout: ", netClientTimeout)
 	network.httpClient = &http.Client{Transport: &defaultTransportCopy, Timeout: netClientTimeout}
 }
+
+// withTransport returns a copy of the network handle whose http client uses the provided tls configuration and/or proxy,
+// for destinations using a private certificate authority, requiring a client certificate or only allowing traffic from specific IPs
+func (network *netHandle) withTransport(tlsConfig *tls.Config, proxyURL *url.URL) (*netHandle, error) {
+	client, ok := network.httpClient.(*http.Client)
+	if !ok {
+		return nil, fmt.Errorf("unsupported http client type %T", network.httpClient)
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("unsupported http transport type %T", client.Transport)
+	}
+	transport = transport.Clone()
+	if tlsConfig != nil {
+		if transport.TLSClientConfig != nil {
+			tlsConfig.NextProtos = transport.TLSClientConfig.NextProtos
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	if proxyURL != nil {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return &netHandle{
+		disableEgress: network.disableEgress,
+		httpClient:    &http.Client{Transport: transport, Timeout: client.Timeout},
+		logger:        network.logger,
+	}, nil
+}
@@ -22,12 +22,19 @@ func newPartitionWorker(ctx context.Context, rt *Handle, partition string) *part
 		partition: partition,
 		ctx:       ctx,
 	}
+	pw.startWorkers(rt.noOfWorkers.Load())
+	return pw
+}
+
+// startWorkers starts the given number of internal workers
+func (pw *partitionWorker) startWorkers(noOfWorkers int) {
+	rt := pw.rt
 	pw.g, _ = errgroup.WithContext(context.Background())
-	pw.workers = make([]*worker, rt.noOfWorkers)
-	for i := 0; i < rt.noOfWorkers; i++ {
+	pw.workers = make([]*worker, noOfWorkers)
+	for i := 0; i < noOfWorkers; i++ {
 		worker := &worker{
 			logger:                    pw.logger.Child("w-" + strconv.Itoa(i)),
-			partition:                 partition,
+			partition:                 pw.partition,
 			id:                        i,
 			input:                     make(chan workerJob, rt.workerInputBufferSize),
 			barrier:                   rt.barrier,
@@ -44,7 +51,6 @@ func newPartitionWorker(ctx context.Context, rt *Handle, partition string) *part
 			return nil
 		}))
 	}
-	return pw
 }
 
 type partitionWorker struct {
@@ -66,6 +72,15 @@ type partitionWorker struct {
 
 // Work picks up jobs for the partitioned worker and returns whether it worked or not
 func (pw *partitionWorker) Work() bool {
+	if noOfWorkers := pw.rt.noOfWorkers.Load(); noOfWorkers > 0 && noOfWorkers != len(pw.workers) {
+		// workers are drained before being replaced, so that the jobs already assigned to them are processed before any other job
+		pw.logger.Infon("Number of workers changed, resizing workers",
+			logger.NewIntField("previous", int64(len(pw.workers))),
+			logger.NewIntField("current", int64(noOfWorkers)),
+		)
+		pw.Stop()
+		pw.startWorkers(noOfWorkers)
+	}
 	start := time.Now()
 	pw.pickupCount, pw.limitsReached = pw.rt.pickup(pw.ctx, pw.partition, pw.workers)
 	stats.Default.NewTaggedStat("router_generator_loop", stats.TimerType, stats.Tags{"destType": pw.rt.destType, "partition": pw.partition}).Since(start)
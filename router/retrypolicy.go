@@ -0,0 +1,178 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+)
+
+const (
+	retryActionRetry    = "retry"
+	retryActionAbort    = "abort"
+	retryActionThrottle = "throttle"
+)
+
+// retryRule is a rule of the "retryPolicy" of a destination definition's response rules, e.g.
+//
+//	"retryPolicy": [
+//		{"statusCodes": [429], "action": "throttle", "honorRetryAfter": true, "maxBackoff": "10m"},
+//		{"statusCodes": [400], "action": "abort"},
+//		{"statusCodes": [502, 503], "action": "retry", "minBackoff": "5s", "maxBackoff": "5m", "jitter": 0.5},
+//		{"statusCodes": [200], "bodyContains": "RATE_LIMITED", "action": "throttle"}
+//	]
+//
+// A rule matches a delivery response if its status code is one of the rule's status codes (any status code if none are
+// configured) and its body contains the rule's bodyContains text (if configured). The first matching rule decides whether
+// the job gets retried, aborted or throttled and how long it needs to wait before its next attempt.
+type retryRule struct {
+	StatusCodes     []int   `json:"statusCodes"`
+	BodyContains    string  `json:"bodyContains"`
+	Action          string  `json:"action"`
+	HonorRetryAfter bool    `json:"honorRetryAfter"` // wait for as long as the Retry-After response header asks for, if present
+	MinBackoff      string  `json:"minBackoff"`      // backoff of the first retry, doubling on every attempt (defaults to Router.minRetryBackoff)
+	MaxBackoff      string  `json:"maxBackoff"`      // maximum backoff, also capping Retry-After (defaults to Router.maxRetryBackoff)
+	Jitter          float64 `json:"jitter"`          // fraction of the backoff by which it is randomly increased or decreased, between 0 and 1
+
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// retryPolicy decides how failed deliveries to a destination get retried, based on the rules of its destination definition
+type retryPolicy struct {
+	rules []retryRule
+}
+
+// newRetryPolicy returns the retry policy configured in the response rules of a destination definition, or nil if
+// there is none or it is invalid
+func newRetryPolicy(log logger.Logger, responseRules map[string]interface{}) *retryPolicy {
+	rawRules, ok := responseRules["retryPolicy"]
+	if !ok {
+		return nil
+	}
+	rules, err := parseRetryRules(rawRules)
+	if err != nil {
+		log.Errorn("Invalid retry policy in destination definition's response rules, ignoring it", logger.NewErrorField(err))
+		return nil
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return &retryPolicy{rules: rules}
+}
+
+func parseRetryRules(rawRules interface{}) ([]retryRule, error) {
+	b, err := json.Marshal(rawRules)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling retry policy: %w", err)
+	}
+	var rules []retryRule
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("unmarshalling retry policy: %w", err)
+	}
+	for i := range rules {
+		rule := &rules[i]
+		switch rule.Action {
+		case retryActionRetry, retryActionAbort, retryActionThrottle:
+		default:
+			return nil, fmt.Errorf("rule %d: unsupported action %q", i, rule.Action)
+		}
+		if rule.MinBackoff != "" {
+			if rule.minBackoff, err = time.ParseDuration(rule.MinBackoff); err != nil {
+				return nil, fmt.Errorf("rule %d: invalid minBackoff: %w", i, err)
+			}
+		}
+		if rule.MaxBackoff != "" {
+			if rule.maxBackoff, err = time.ParseDuration(rule.MaxBackoff); err != nil {
+				return nil, fmt.Errorf("rule %d: invalid maxBackoff: %w", i, err)
+			}
+		}
+		if rule.Jitter < 0 || rule.Jitter > 1 {
+			return nil, fmt.Errorf("rule %d: jitter should be between 0 and 1", i)
+		}
+	}
+	return rules, nil
+}
+
+// match returns the first rule matching the response, if any
+func (p *retryPolicy) match(statusCode int, body string) *retryRule {
+	if p == nil {
+		return nil
+	}
+	for i := range p.rules {
+		rule := &p.rules[i]
+		if len(rule.StatusCodes) > 0 && !slices.Contains(rule.StatusCodes, statusCode) {
+			continue
+		}
+		if rule.BodyContains != "" && !strings.Contains(body, rule.BodyContains) {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+// statusCode returns the status code the router should handle the response as, according to the rule's action
+func (r *retryRule) statusCode(statusCode int) int {
+	switch r.Action {
+	case retryActionAbort:
+		if isJobTerminated(statusCode) && !isSuccessStatus(statusCode) {
+			return statusCode
+		}
+		return http.StatusBadRequest // Rudder abort code
+	case retryActionThrottle:
+		return http.StatusTooManyRequests // Rudder throttle code
+	default:
+		if isJobTerminated(statusCode) {
+			return http.StatusInternalServerError // Rudder retry code
+		}
+		return statusCode
+	}
+}
+
+// backoff returns how long a job needs to wait before its next attempt, given the attempt that just failed, the
+// Retry-After header of the response and the router's default backoff limits
+func (r *retryRule) backoff(attempt int, retryAfter string, defaultMinBackoff, defaultMaxBackoff time.Duration) time.Duration {
+	minBackoff, maxBackoff := defaultMinBackoff, defaultMaxBackoff
+	if r.minBackoff > 0 {
+		minBackoff = r.minBackoff
+	}
+	if r.maxBackoff > 0 {
+		maxBackoff = r.maxBackoff
+	}
+	if r.HonorRetryAfter {
+		if d, ok := parseRetryAfter(retryAfter, time.Now()); ok {
+			return min(d, maxBackoff)
+		}
+	}
+	backoff := nextAttemptAfter(attempt, minBackoff, maxBackoff)
+	if r.Jitter > 0 {
+		backoff = time.Duration(float64(backoff) * (1 + r.Jitter*(2*rand.Float64()-1)))
+	}
+	return backoff
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a number of seconds or an http date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds < 0 || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+			return 0, false
+		}
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,100 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	t.Run("no or invalid policy", func(t *testing.T) {
+		require.Nil(t, newRetryPolicy(logger.NOP, nil))
+		require.Nil(t, newRetryPolicy(logger.NOP, map[string]interface{}{"responseType": "JSON"}))
+		require.Nil(t, newRetryPolicy(logger.NOP, map[string]interface{}{"retryPolicy": []interface{}{}}))
+		require.Nil(t, newRetryPolicy(logger.NOP, map[string]interface{}{"retryPolicy": "invalid"}))
+		require.Nil(t, newRetryPolicy(logger.NOP, map[string]interface{}{"retryPolicy": []interface{}{
+			map[string]interface{}{"statusCodes": []interface{}{500}, "action": "unknown"},
+		}}))
+		require.Nil(t, newRetryPolicy(logger.NOP, map[string]interface{}{"retryPolicy": []interface{}{
+			map[string]interface{}{"statusCodes": []interface{}{500}, "action": "retry", "minBackoff": "soon"},
+		}}))
+		require.Nil(t, newRetryPolicy(logger.NOP, map[string]interface{}{"retryPolicy": []interface{}{
+			map[string]interface{}{"statusCodes": []interface{}{500}, "action": "retry", "jitter": 2},
+		}}))
+
+		var p *retryPolicy
+		require.Nil(t, p.match(http.StatusInternalServerError, ""))
+	})
+
+	p := newRetryPolicy(logger.NOP, map[string]interface{}{"retryPolicy": []interface{}{
+		map[string]interface{}{"statusCodes": []interface{}{429}, "action": "throttle", "honorRetryAfter": true, "maxBackoff": "10m"},
+		map[string]interface{}{"statusCodes": []interface{}{400}, "bodyContains": "LOCKED", "action": "retry"},
+		map[string]interface{}{"statusCodes": []interface{}{400, 404}, "action": "abort"},
+		map[string]interface{}{"statusCodes": []interface{}{502}, "action": "retry", "minBackoff": "5s", "maxBackoff": "1m", "jitter": 0.5},
+		map[string]interface{}{"statusCodes": []interface{}{200}, "bodyContains": "RATE_LIMITED", "action": "throttle"},
+		map[string]interface{}{"statusCodes": []interface{}{200}, "bodyContains": "INVALID", "action": "abort"},
+	}})
+	require.NotNil(t, p)
+
+	t.Run("matching", func(t *testing.T) {
+		require.Nil(t, p.match(http.StatusOK, `{"status":"ok"}`))
+		require.Nil(t, p.match(http.StatusInternalServerError, ""))
+
+		rule := p.match(http.StatusBadRequest, `{"error":"LOCKED"}`)
+		require.NotNil(t, rule)
+		require.Equal(t, retryActionRetry, rule.Action, "rules should be evaluated in order")
+		require.Equal(t, http.StatusInternalServerError, rule.statusCode(http.StatusBadRequest))
+
+		rule = p.match(http.StatusBadRequest, `{"error":"bad request"}`)
+		require.NotNil(t, rule)
+		require.Equal(t, http.StatusBadRequest, rule.statusCode(http.StatusBadRequest))
+		require.Equal(t, http.StatusNotFound, p.match(http.StatusNotFound, "").statusCode(http.StatusNotFound), "terminal codes should be kept when aborting")
+
+		require.Equal(t, http.StatusTooManyRequests, p.match(http.StatusOK, `{"error":"RATE_LIMITED"}`).statusCode(http.StatusOK))
+		require.Equal(t, http.StatusBadRequest, p.match(http.StatusOK, `{"error":"INVALID"}`).statusCode(http.StatusOK))
+		require.Equal(t, http.StatusBadGateway, p.match(http.StatusBadGateway, "").statusCode(http.StatusBadGateway))
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		minBackoff, maxBackoff := 10*time.Second, 300*time.Second
+
+		throttle := p.match(http.StatusTooManyRequests, "")
+		require.Equal(t, 120*time.Second, throttle.backoff(1, "120", minBackoff, maxBackoff), "Retry-After should be honoured")
+		require.Equal(t, 10*time.Minute, throttle.backoff(1, "3600", minBackoff, maxBackoff), "Retry-After should be capped by the max backoff")
+		require.Equal(t, 20*time.Second, throttle.backoff(2, "", minBackoff, maxBackoff), "default backoff without Retry-After")
+		require.Equal(t, 20*time.Second, throttle.backoff(2, "invalid", minBackoff, maxBackoff))
+
+		retry := p.match(http.StatusBadGateway, "")
+		for i := 0; i < 100; i++ {
+			require.InDelta(t, 5*time.Second, retry.backoff(1, "", minBackoff, maxBackoff), float64(2500*time.Millisecond))
+			require.InDelta(t, 40*time.Second, retry.backoff(4, "", minBackoff, maxBackoff), float64(20*time.Second))
+			require.InDelta(t, time.Minute, retry.backoff(10, "", minBackoff, maxBackoff), float64(30*time.Second))
+		}
+	})
+
+	t.Run("parseRetryAfter", func(t *testing.T) {
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		for _, tc := range []struct {
+			value    string
+			expected time.Duration
+			ok       bool
+		}{
+			{value: "", ok: false},
+			{value: "soon", ok: false},
+			{value: "-1", ok: false},
+			{value: "0", expected: 0, ok: true},
+			{value: " 30 ", expected: 30 * time.Second, ok: true},
+			{value: "1.5", expected: 1500 * time.Millisecond, ok: true},
+			{value: now.Add(time.Minute).Format(http.TimeFormat), expected: time.Minute, ok: true},
+			{value: now.Add(-time.Minute).Format(http.TimeFormat), expected: 0, ok: true},
+		} {
+			d, ok := parseRetryAfter(tc.value, now)
+			require.Equal(t, tc.ok, ok, tc.value)
+			require.Equal(t, tc.expected, d, tc.value)
+		}
+	})
+}
@@ -254,7 +254,7 @@ func TestBackoff(t *testing.T) {
 		r := &Handle{
 			logger:                logger.NOP,
 			backgroundCtx:         context.Background(),
-			noOfWorkers:           1,
+			noOfWorkers:           config.SingleValueLoader(1),
 			workerInputBufferSize: 3,
 			barrier:               barrier,
 			reloadableConfig: &reloadableConfig{
@@ -494,6 +494,45 @@ func TestBackoff(t *testing.T) {
 			require.Nil(t, slot)
 			require.ErrorIs(t, err, types.ErrJobOrderBlocked)
 		})
+
+		t.Run("job blocked for a strict ordering destination while event ordering isn't guaranteed", func(t *testing.T) {
+			r.guaranteeUserEventOrder = false
+			r.strictEventOrderingForDestination = func(destinationID string) bool {
+				return slices.Contains(conf.GetStringSlice("Router.strictOrderingDestinationIDs", nil), destinationID)
+			}
+			defer func() { r.strictEventOrderingForDestination = nil }()
+			workers[0].inputReservations = 0
+			job := &jobsdb.JobT{
+				JobID:      1,
+				Parameters: []byte(`{"destination_id": "destination"}`),
+				LastJobStatus: jobsdb.JobStatusT{
+					JobState:   jobsdb.Failed.State,
+					AttemptNum: 1,
+					RetryTime:  time.Now().Add(-1 * time.Hour),
+				},
+				WorkspaceId: "someWorkspace",
+			}
+			conf.Set("Router.strictOrderingDestinationIDs", []string{"destination"})
+			slot, err := r.findWorkerSlot(
+				context.Background(),
+				workers,
+				job,
+				map[eventorder.BarrierKey]struct{}{{UserID: job.UserID, DestinationID: "destination", WorkspaceID: job.WorkspaceId}: {}},
+			)
+			require.Nil(t, slot)
+			require.ErrorIs(t, err, types.ErrJobOrderBlocked, "order should be guaranteed for the strict ordering destination")
+
+			conf.Set("Router.strictOrderingDestinationIDs", nil)
+			slot, err = r.findWorkerSlot(
+				context.Background(),
+				workers,
+				job,
+				map[eventorder.BarrierKey]struct{}{{UserID: job.UserID, DestinationID: "destination", WorkspaceID: job.WorkspaceId}: {}},
+			)
+			require.NoError(t, err)
+			require.NotNil(t, slot)
+			slot.slot.Release()
+		})
 	})
 }
 
@@ -524,6 +563,33 @@ var _ = Describe("router", func() {
 			c.mockBackendConfig.EXPECT().AccessToken().AnyTimes()
 			router.Setup(gaDestinationDefinition, logger.NOP, conf, c.mockBackendConfig, c.mockRouterJobsDB, c.mockProcErrorsDB, transientsource.NewEmptyService(), rsources.NewNoOpService(), transformerFeaturesService.NewNoOpService(), destinationdebugger.NewNoOpService(), throttler.NewNoOpThrottlerFactory())
 		})
+
+		It("should resize the workers of partition workers when the number of workers changes", func() {
+			router := &Handle{
+				Reporting: &reporting.NOOP{},
+			}
+			c.mockBackendConfig.EXPECT().AccessToken().AnyTimes()
+			router.Setup(gaDestinationDefinition, logger.NOP, conf, c.mockBackendConfig, c.mockRouterJobsDB, c.mockProcErrorsDB, transientsource.NewEmptyService(), rsources.NewNoOpService(), transformerFeaturesService.NewNoOpService(), destinationdebugger.NewNoOpService(), throttler.NewNoOpThrottlerFactory())
+			router.noOfWorkers = conf.GetReloadableIntVar(1, 1, "Router.noOfWorkers")
+			router.reloadableConfig.readSleep = config.SingleValueLoader(time.Millisecond)
+			c.mockRouterJobsDB.EXPECT().GetToProcess(gomock.Any(), gomock.Any(), nil).AnyTimes().Return(&jobsdb.MoreJobsResult{}, nil)
+
+			<-router.backendConfigInitialized
+			worker := newPartitionWorker(context.Background(), router, gaDestinationID)
+			defer worker.Stop()
+			Expect(worker.workers).To(HaveLen(1))
+
+			conf.Set("Router.noOfWorkers", 3)
+			Expect(worker.Work()).To(BeFalse())
+			Expect(worker.workers).To(HaveLen(3), "workers should be added")
+
+			conf.Set("Router.noOfWorkers", 2)
+			Expect(worker.Work()).To(BeFalse())
+			Expect(worker.workers).To(HaveLen(2), "workers should be removed")
+			for i, w := range worker.workers {
+				Expect(w.id).To(Equal(i))
+			}
+		})
 	})
 
 	Context("normal operation", func() {
@@ -1082,7 +1148,7 @@ var _ = Describe("router", func() {
 			c.mockBackendConfig.EXPECT().AccessToken().AnyTimes()
 			router.Setup(gaDestinationDefinition, logger.NOP, conf, c.mockBackendConfig, c.mockRouterJobsDB, c.mockProcErrorsDB, transientsource.NewEmptyService(), rsources.NewNoOpService(), transformerFeaturesService.NewNoOpService(), destinationdebugger.NewNoOpService(), throttler.NewNoOpThrottlerFactory())
 			router.transformer = mockTransformer
-			router.noOfWorkers = 1
+			router.noOfWorkers = config.SingleValueLoader(1)
 			router.reloadableConfig.noOfJobsToBatchInAWorker = config.SingleValueLoader(5)
 
 			gaPayload := `{"body": {"XML": {}, "FORM": {}, "JSON": {}}, "type": "REST", "files": {}, "method": "POST", "params": {"t": "event", "v": "1", "an": "RudderAndroidClient", "av": "1.0", "ds": "android-sdk", "ea": "Demo Track", "ec": "Demo Category", "el": "Demo Label", "ni": 0, "qt": 59268380964, "ul": "en-US", "cid": "anon_id", "tid": "UA-185645846-1", "uip": "[::1]", "aiid": "com.rudderlabs.android.sdk"}, "userId": "anon_id", "headers": {}, "version": "1", "endpoint": "https://www.google-analytics.com/collect"}`
@@ -1184,7 +1250,7 @@ var _ = Describe("router", func() {
 
 			router.enableBatching = true
 			router.reloadableConfig.noOfJobsToBatchInAWorker = config.SingleValueLoader(3)
-			router.noOfWorkers = 1
+			router.noOfWorkers = config.SingleValueLoader(1)
 
 			gaPayload := `{"body": {"XML": {}, "FORM": {}, "JSON": {}}, "type": "REST", "files": {}, "method": "POST", "params": {"t": "event", "v": "1", "an": "RudderAndroidClient", "av": "1.0", "ds": "android-sdk", "ea": "Demo Track", "ec": "Demo Category", "el": "Demo Label", "ni": 0, "qt": 59268380964, "ul": "en-US", "cid": "anon_id", "tid": "UA-185645846-1", "uip": "[::1]", "aiid": "com.rudderlabs.android.sdk"}, "userId": "anon_id", "headers": {}, "version": "1", "endpoint": "https://www.google-analytics.com/collect"}`
 			parameters := fmt.Sprintf(`{"source_id": "%s", "destination_id": "%s", "message_id": "2f548e6d-60f6-44af-a1f4-62b3272445c3", "received_at": "2021-06-28T10:04:48.527+05:30", "transform_at": "processor"}`, sourceIDEnabled, gaDestinationID) // skipcq: GO-R4002
@@ -1510,7 +1576,7 @@ var _ = Describe("router", func() {
 			c.mockBackendConfig.EXPECT().AccessToken().AnyTimes()
 			router.Setup(gaDestinationDefinition, logger.NOP, conf, c.mockBackendConfig, c.mockRouterJobsDB, c.mockProcErrorsDB, transientsource.NewEmptyService(), rsources.NewNoOpService(), transformerFeaturesService.NewNoOpService(), destinationdebugger.NewNoOpService(), throttler.NewNoOpThrottlerFactory())
 			router.transformer = mockTransformer
-			router.noOfWorkers = 1
+			router.noOfWorkers = config.SingleValueLoader(1)
 			router.reloadableConfig.noOfJobsToBatchInAWorker = config.SingleValueLoader(5)
 
 			gaPayload := `{"body": {"XML": {}, "FORM": {}, "JSON": {}}, "type": "REST", "files": {}, "method": "POST", "params": {"t": "event", "v": "1", "an": "RudderAndroidClient", "av": "1.0", "ds": "android-sdk", "ea": "Demo Track", "ec": "Demo Category", "el": "Demo Label", "ni": 0, "qt": 59268380964, "ul": "en-US", "cid": "anon_id", "tid": "UA-185645846-1", "uip": "[::1]", "aiid": "com.rudderlabs.android.sdk"}, "userId": "anon_id", "headers": {}, "version": "1", "endpoint": "https://www.google-analytics.com/collect"}`
@@ -1719,7 +1785,7 @@ var _ = Describe("router", func() {
 			c.mockBackendConfig.EXPECT().AccessToken().AnyTimes()
 			router.Setup(gaDestinationDefinition, logger.NOP, conf, c.mockBackendConfig, c.mockRouterJobsDB, c.mockProcErrorsDB, transientsource.NewEmptyService(), rsources.NewNoOpService(), transformerFeaturesService.NewNoOpService(), destinationdebugger.NewNoOpService(), throttler.NewNoOpThrottlerFactory())
 			router.transformer = mockTransformer
-			router.noOfWorkers = 1
+			router.noOfWorkers = config.SingleValueLoader(1)
 			router.reloadableConfig.noOfJobsToBatchInAWorker = config.SingleValueLoader(3)
 
 			gaPayload := `{"body": {"XML": {}, "FORM": {}, "JSON": {}}, "type": "REST", "files": {}, "method": "POST", "params": {"t": "event", "v": "1", "an": "RudderAndroidClient", "av": "1.0", "ds": "android-sdk", "ea": "Demo Track", "ec": "Demo Category", "el": "Demo Label", "ni": 0, "qt": 59268380964, "ul": "en-US", "cid": "anon_id", "tid": "UA-185645846-1", "uip": "[::1]", "aiid": "com.rudderlabs.android.sdk"}, "userId": "anon_id", "headers": {}, "version": "1", "endpoint": "https://www.google-analytics.com/collect"}`
@@ -1889,7 +1955,7 @@ var _ = Describe("router", func() {
 			router.transformer = mockTransformer
 
 			router.reloadableConfig.noOfJobsToBatchInAWorker = config.SingleValueLoader(3)
-			router.noOfWorkers = 1
+			router.noOfWorkers = config.SingleValueLoader(1)
 
 			gaPayload := `{"body": {"XML": {}, "FORM": {}, "JSON": {}}, "type": "REST", "files": {}, "method": "POST", "params": {"t": "event", "v": "1", "an": "RudderAndroidClient", "av": "1.0", "ds": "android-sdk", "ea": "Demo Track", "ec": "Demo Category", "el": "Demo Label", "ni": 0, "qt": 59268380964, "ul": "en-US", "cid": "anon_id", "tid": "UA-185645846-1", "uip": "[::1]", "aiid": "com.rudderlabs.android.sdk"}, "userId": "anon_id", "headers": {}, "version": "1", "endpoint": "https://www.google-analytics.com/collect"}`
 			parameters := fmt.Sprintf(`{"source_id": "%s", "destination_id": "%s", "message_id": "2f548e6d-60f6-44af-a1f4-62b3272445c3", "received_at": "2021-06-28T10:04:48.527+05:30", "transform_at": "router"}`, sourceIDEnabled, gaDestinationID) // skipcq: GO-R4002
@@ -2049,7 +2115,7 @@ var _ = Describe("router", func() {
 
 			router.reloadableConfig.noOfJobsToBatchInAWorker = config.SingleValueLoader(3)
 			router.reloadableConfig.transformerProxy = config.SingleValueLoader(true)
-			router.noOfWorkers = 1
+			router.noOfWorkers = config.SingleValueLoader(1)
 
 			gaPayload := `{"body": {"XML": {}, "FORM": {}, "JSON": {}}, "type": "REST", "files": {}, "method": "POST", "params": {"t": "event", "v": "1", "an": "RudderAndroidClient", "av": "1.0", "ds": "android-sdk", "ea": "Demo Track", "ec": "Demo Category", "el": "Demo Label", "ni": 0, "qt": 59268380964, "ul": "en-US", "cid": "anon_id", "tid": "UA-185645846-1", "uip": "[::1]", "aiid": "com.rudderlabs.android.sdk"}, "userId": "anon_id", "headers": {}, "version": "1", "endpoint": "https://www.google-analytics.com/collect"}`
 			parameters := fmt.Sprintf(`{"source_id": "%s", "destination_id": "%s", "message_id": "2f548e6d-60f6-44af-a1f4-62b3272445c3", "received_at": "2021-06-28T10:04:48.527+05:30", "transform_at": "router"}`, sourceIDEnabled, gaDestinationID) // skipcq: GO-R4002
@@ -2195,7 +2261,7 @@ var _ = Describe("router", func() {
 
 			router.reloadableConfig.noOfJobsToBatchInAWorker = config.SingleValueLoader(3)
 			router.reloadableConfig.transformerProxy = config.SingleValueLoader(true)
-			router.noOfWorkers = 1
+			router.noOfWorkers = config.SingleValueLoader(1)
 
 			gaPayload := `{"body": {"XML": {}, "FORM": {}, "JSON": {}}, "type": "REST", "files": {}, "method": "POST", "params": {"t": "event", "v": "1", "an": "RudderAndroidClient", "av": "1.0", "ds": "android-sdk", "ea": "Demo Track", "ec": "Demo Category", "el": "Demo Label", "ni": 0, "qt": 59268380964, "ul": "en-US", "cid": "anon_id", "tid": "UA-185645846-1", "uip": "[::1]", "aiid": "com.rudderlabs.android.sdk"}, "userId": "anon_id", "headers": {}, "version": "1", "endpoint": "https://www.google-analytics.com/collect"}`
 			parameters := fmt.Sprintf(`{"source_id": "%s", "destination_id": "%s", "message_id": "2f548e6d-60f6-44af-a1f4-62b3272445c3", "received_at": "2021-06-28T10:04:48.527+05:30", "transform_at": "router"}`, sourceIDEnabled, gaDestinationID) // skipcq: GO-R4002
@@ -0,0 +1,111 @@
+package throttler
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+)
+
+// ConcurrencyLimiter limits the number of concurrent deliveries to every destination of a destination type,
+// based on the Router.throttler.<destType>.<destID>.maxConcurrency or Router.throttler.<destType>.maxConcurrency
+// configuration. A limit of 0 disables limiting.
+type ConcurrencyLimiter struct {
+	config   *config.Config
+	stats    stats.Stats
+	destType string
+
+	destinationsMu sync.Mutex
+	destinations   map[string]*destinationConcurrency // destinationID -> concurrency state
+}
+
+type destinationConcurrency struct {
+	limit config.ValueLoader[int]
+
+	mu       sync.Mutex
+	inflight int
+
+	inflightStat   stats.Measurement
+	saturationStat stats.Measurement
+	rejectedStat   stats.Measurement
+}
+
+// NewConcurrencyLimiter returns a concurrency limiter for the destinations of the destination type
+func NewConcurrencyLimiter(config *config.Config, statsFactory stats.Stats, destType string) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		config:       config,
+		stats:        statsFactory,
+		destType:     destType,
+		destinations: make(map[string]*destinationConcurrency),
+	}
+}
+
+// TryAcquire allows a delivery to the destination if it hasn't reached its limit, returning the function releasing it
+// once the delivery is over. It never waits, so that workers shared with other destinations aren't blocked: if the limit
+// has been reached, ok is false and the job should be returned to the queue instead.
+func (l *ConcurrencyLimiter) TryAcquire(destID string) (release func(), ok bool) {
+	d := l.get(destID)
+	limit := d.limit.Load()
+	if limit <= 0 {
+		return func() {}, true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.inflight >= limit {
+		d.rejectedStat.Increment()
+		return nil, false
+	}
+	d.inflight++
+	d.updateStats()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			d.inflight--
+			d.updateStats()
+		})
+	}, true
+}
+
+// Saturated returns true if the destination has reached its concurrency limit, in which case no more jobs of the
+// destination should be assigned to workers for now.
+func (l *ConcurrencyLimiter) Saturated(destID string) bool {
+	d := l.get(destID)
+	limit := d.limit.Load()
+	if limit <= 0 {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inflight >= limit
+}
+
+func (l *ConcurrencyLimiter) get(destID string) *destinationConcurrency {
+	l.destinationsMu.Lock()
+	defer l.destinationsMu.Unlock()
+	if d, ok := l.destinations[destID]; ok {
+		return d
+	}
+	tags := stats.Tags{"destType": l.destType, "destinationId": destID}
+	d := &destinationConcurrency{
+		limit: l.config.GetReloadableIntVar(0, 1,
+			fmt.Sprintf(`Router.throttler.%s.%s.maxConcurrency`, l.destType, destID),
+			fmt.Sprintf(`Router.throttler.%s.maxConcurrency`, l.destType)),
+		inflightStat:   l.stats.NewTaggedStat("router_destination_inflight_deliveries", stats.GaugeType, tags),
+		saturationStat: l.stats.NewTaggedStat("router_destination_concurrency_saturation", stats.GaugeType, tags),
+		rejectedStat:   l.stats.NewTaggedStat("router_destination_concurrency_rejected_deliveries", stats.CountType, tags),
+	}
+	l.destinations[destID] = d
+	return d
+}
+
+// updateStats reports the number of inflight deliveries and their ratio to the limit. It should be called with the lock held.
+func (d *destinationConcurrency) updateStats() {
+	d.inflightStat.Gauge(d.inflight)
+	if limit := d.limit.Load(); limit > 0 {
+		d.saturationStat.Gauge(float64(d.inflight) / float64(limit))
+	}
+}
@@ -0,0 +1,58 @@
+package throttler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	t.Run("no limit", func(t *testing.T) {
+		l := NewConcurrencyLimiter(config.New(), stats.NOP, "destType")
+		for i := 0; i < 10; i++ {
+			_, ok := l.TryAcquire("destID")
+			require.True(t, ok)
+		}
+		require.False(t, l.Saturated("destID"))
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		conf := config.New()
+		conf.Set("Router.throttler.destType.maxConcurrency", 1)
+		conf.Set("Router.throttler.destType.destID-2.maxConcurrency", 2)
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+		l := NewConcurrencyLimiter(conf, statsStore, "destType")
+
+		release, ok := l.TryAcquire("destID-1")
+		require.True(t, ok)
+		tags := stats.Tags{"destType": "destType", "destinationId": "destID-1"}
+		require.EqualValues(t, 1, statsStore.Get("router_destination_inflight_deliveries", tags).LastValue())
+		require.EqualValues(t, 1, statsStore.Get("router_destination_concurrency_saturation", tags).LastValue())
+		require.True(t, l.Saturated("destID-1"))
+
+		_, ok = l.TryAcquire("destID-1")
+		require.False(t, ok, "delivery shouldn't be allowed while the inflight one hasn't finished")
+		require.EqualValues(t, 1, statsStore.Get("router_destination_concurrency_rejected_deliveries", tags).LastValue())
+
+		for i := 0; i < 2; i++ {
+			_, ok = l.TryAcquire("destID-2")
+			require.True(t, ok, "limits should be per destination")
+		}
+		require.True(t, l.Saturated("destID-2"))
+
+		release()
+		release() // releasing twice is a no-op
+		require.False(t, l.Saturated("destID-1"))
+		require.EqualValues(t, 0, statsStore.Get("router_destination_inflight_deliveries", tags).LastValue())
+
+		secondRelease, ok := l.TryAcquire("destID-1")
+		require.True(t, ok)
+		secondRelease()
+		require.EqualValues(t, 0, statsStore.Get("router_destination_inflight_deliveries", tags).LastValue())
+	})
+}
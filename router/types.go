@@ -52,6 +52,7 @@ type JobResponse struct {
 	respStatusCode         int
 	respBody               string
 	errorAt                string
+	retryBackoff           time.Duration // backoff before the next attempt decided by the destination's retry policy, if any
 	status                 *jobsdb.JobStatusT
 }
 
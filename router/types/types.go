@@ -152,6 +152,8 @@ var (
 	ErrJobBackoff = errors.New("backoff")
 	// ErrDestinationThrottled is returned when the destination is being throttled
 	ErrDestinationThrottled = errors.New("throttled")
+	// ErrDestinationCircuitOpen is returned when the circuit of the destination is open
+	ErrDestinationCircuitOpen = errors.New("circuit open")
 	// ErrBarrierExists is returned when a job ordering barrier exists for the job's ordering key
 	ErrBarrierExists = errors.New("barrier")
 )
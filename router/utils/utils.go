@@ -48,6 +48,7 @@ type SendPostResponse struct {
 	StatusCode          int
 	ResponseContentType string
 	ResponseBody        []byte
+	RetryAfter          string // value of the Retry-After response header, if any
 }
 
 func getRetentionTimeForDestination(destID string) time.Duration {
@@ -19,8 +19,10 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/stats"
 	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/internal/liveevents"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
+	"github.com/rudderlabs/rudder-server/router/capture"
 	"github.com/rudderlabs/rudder-server/router/internal/eventorder"
 	"github.com/rudderlabs/rudder-server/router/transformer"
 	"github.com/rudderlabs/rudder-server/router/types"
@@ -129,7 +131,7 @@ func (w *worker) workLoop() {
 				continue
 			}
 
-			if w.rt.guaranteeUserEventOrder {
+			if w.rt.guaranteeUserEventOrderFor(parameters.DestinationID) {
 				orderKey := eventorder.BarrierKey{
 					UserID:        userID,
 					DestinationID: parameters.DestinationID,
@@ -455,16 +457,33 @@ func (w *worker) processDestinationJobs() {
 	})
 
 	dontBatchDirectives := make(map[int64]bool)
+	// whether the circuits of the destinations are open is decided once per batch, so that jobs of the same user can't
+	// be sent after earlier ones have been put back to waiting
+	openCircuits := make(map[string]bool)
+	// destinations which have reached their concurrency limit during the batch, for the same reason
+	saturatedDestinations := make(map[string]struct{})
 
 	for _, destinationJob := range w.destinationJobs {
 		var respStatusCodes map[int64]int
 		var respBodys map[int64]string
+		var respRetryAfter string
 
 		var errorAt string
 		if destinationJob.StatusCode == 200 || destinationJob.StatusCode == 0 {
+			if w.circuitOpen(openCircuits, destinationJob.JobMetadataArray[0].DestinationID) {
+				// the destination keeps failing, so the jobs aren't sent, nor do they count as an attempt
+				w.putBackToWaiting(&destinationJob, "destination circuit is open after consecutive failures")
+				continue
+			}
 			if w.canSendJobToDestination(failedJobOrderKeys, &destinationJob) {
-				diagnosisStartTime := time.Now()
 				destinationID := destinationJob.JobMetadataArray[0].DestinationID
+				releaseConcurrency, ok := w.acquireConcurrency(saturatedDestinations, destinationID)
+				if !ok {
+					// the worker doesn't wait for a delivery slot, since it is shared with other destinations
+					w.putBackToWaiting(&destinationJob, "destination reached its concurrency limit")
+					continue
+				}
+				diagnosisStartTime := time.Now()
 				transformAt := destinationJob.JobMetadataArray[0].TransformAt
 
 				// START: request to destination endpoint
@@ -523,7 +542,12 @@ func (w *worker) processDestinationJobs() {
 								w.logger.Debugf(`responseTransform status :%v, %s`, w.rt.reloadableConfig.transformerProxy, w.rt.destType)
 								errorAt = routerutils.ERROR_AT_DEL
 								if transformerProxy {
+									rdlTime := time.Now()
 									resp := w.proxyRequest(ctx, destinationJob, val)
+									if w.rt.responseCapture.Enabled(destinationJob.Destination.ID) && len(resp.RespStatusCodes) > 0 {
+										jobID := lo.MaxBy(lo.Keys(resp.RespStatusCodes), func(a, b int64) bool { return resp.RespStatusCodes[a] > resp.RespStatusCodes[b] })
+										w.captureExchange(&destinationJob, val, true, resp.RespStatusCodes[jobID], resp.RespBodys[jobID], time.Since(rdlTime))
+									}
 									for k, v := range resp.DontBatchDirectives {
 										dontBatchDirectives[k] = v
 									}
@@ -542,10 +566,12 @@ func (w *worker) processDestinationJobs() {
 								} else {
 									sendCtx, cancel := context.WithTimeout(ctx, w.rt.netClientTimeout)
 									rdlTime := time.Now()
-									resp := w.rt.netHandle.SendPost(sendCtx, val)
+									resp := w.rt.netHandleFor(destinationID).SendPost(sendCtx, val)
 									cancel()
 									respStatusCode, respBodyTemp, respContentType = resp.StatusCode, string(resp.ResponseBody), resp.ResponseContentType
+									respRetryAfter = resp.RetryAfter
 									w.routerDeliveryLatencyStat.SendTiming(time.Since(rdlTime))
+									w.captureExchange(&destinationJob, val, false, respStatusCode, respBodyTemp, time.Since(rdlTime))
 
 									if isSuccessStatus(respStatusCode) {
 										respBodyArr = append(respBodyArr, respBodyTemp)
@@ -560,6 +586,7 @@ func (w *worker) processDestinationJobs() {
 						if transformerProxy {
 							// respStatusCodes are already populated. Prepare respBodys from respBodyArrs
 							if len(respBodyArrs) == 0 { // Never the case
+								releaseConcurrency()
 								continue
 							} else {
 								respBodys = consolidateRespBodys(respBodyArrs)
@@ -596,6 +623,7 @@ func (w *worker) processDestinationJobs() {
 					}
 				}
 				ch <- struct{}{}
+				releaseConcurrency()
 				timeTaken := time.Since(startedAt)
 
 				w.deliveryTimeStat.SendTiming(timeTaken)
@@ -604,6 +632,10 @@ func (w *worker) processDestinationJobs() {
 				// END: request to destination endpoint
 
 				w.updateReqMetrics(respStatusCodes, &diagnosisStartTime)
+				if w.rt.circuitBreaker != nil && len(respStatusCodes) > 0 {
+					// the destination endpoint is reachable if any of the jobs didn't fail with a server error
+					w.rt.circuitBreaker.Report(destinationID, lo.Min(lo.Values(respStatusCodes)))
+				}
 			} else {
 				respStatusCode := http.StatusInternalServerError
 				var respBody string
@@ -632,7 +664,7 @@ func (w *worker) processDestinationJobs() {
 		}
 
 		w.updateFailedJobOrderKeys(failedJobOrderKeys, &destinationJob, respStatusCodes)
-		routerJobResponses = append(routerJobResponses, w.prepareRouterJobResponses(destinationJob, respStatusCodes, respBodys, errorAt, transformerProxy)...)
+		routerJobResponses = append(routerJobResponses, w.prepareRouterJobResponses(destinationJob, respStatusCodes, respBodys, respRetryAfter, errorAt, transformerProxy)...)
 	}
 
 	sort.Slice(routerJobResponses, func(i, j int) bool {
@@ -701,7 +733,7 @@ func (w *worker) processDestinationJobs() {
 				status.ErrorResponse = misc.UpdateJSONWithNewKeyVal(status.ErrorResponse, "dontBatch", true)
 			}
 		}
-		w.postStatusOnResponseQ(respStatusCode, destinationJob.Message, respContentType, destinationJobMetadata, &status, routerJobResponse.errorAt)
+		w.postStatusOnResponseQ(respStatusCode, destinationJob.Message, respContentType, destinationJobMetadata, &status, routerJobResponse.errorAt, routerJobResponse.retryBackoff)
 
 		w.sendEventDeliveryStat(destinationJobMetadata, &status, &destinationJob.Destination)
 
@@ -733,6 +765,58 @@ func (w *worker) processDestinationJobs() {
 	w.destinationJobs = nil
 }
 
+// circuitOpen returns true if the circuit of the destination was open when it was first checked during the batch
+func (w *worker) circuitOpen(openCircuits map[string]bool, destinationID string) bool {
+	if w.rt.circuitBreaker == nil {
+		return false
+	}
+	open, ok := openCircuits[destinationID]
+	if !ok {
+		open = w.rt.circuitBreaker.Open(destinationID)
+		openCircuits[destinationID] = open
+	}
+	return open
+}
+
+// acquireConcurrency allows a delivery to the destination if it hasn't reached its concurrency limit, returning the function
+// releasing it. Once the limit is reached, no more deliveries to the destination are allowed for the rest of the batch.
+func (w *worker) acquireConcurrency(saturatedDestinations map[string]struct{}, destinationID string) (release func(), ok bool) {
+	if w.rt.concurrencyLimiter == nil {
+		return func() {}, true
+	}
+	if _, saturated := saturatedDestinations[destinationID]; saturated {
+		return nil, false
+	}
+	if release, ok = w.rt.concurrencyLimiter.TryAcquire(destinationID); !ok {
+		saturatedDestinations[destinationID] = struct{}{}
+	}
+	return release, ok
+}
+
+// putBackToWaiting puts in-flight jobs which can't be sent to their destination for now back to waiting, e.g. if its
+// circuit has opened, keeping their attempt numbers since the destination hasn't been contacted. They get picked up again
+// once the destination allows it.
+func (w *worker) putBackToWaiting(destinationJob *types.DestinationJobT, reason string) {
+	for _, jobMetadata := range destinationJob.JobMetadataArray {
+		resp := misc.UpdateJSONWithNewKeyVal(routerutils.EmptyPayload, "reason", reason)
+		if jobMetadata.FirstAttemptedAt != "" {
+			resp = misc.UpdateJSONWithNewKeyVal(resp, "firstAttemptedAt", jobMetadata.FirstAttemptedAt)
+		}
+		status := jobsdb.JobStatusT{
+			JobID:         jobMetadata.JobID,
+			AttemptNum:    jobMetadata.AttemptNum,
+			ExecTime:      time.Now(),
+			RetryTime:     time.Now(),
+			JobState:      jobsdb.Waiting.State,
+			ErrorResponse: resp,
+			Parameters:    routerutils.EmptyPayload,
+			JobParameters: jobMetadata.JobT.Parameters,
+			WorkspaceId:   jobMetadata.WorkspaceID,
+		}
+		w.rt.responseQ <- workerJobStatus{userID: jobMetadata.UserID, worker: w, job: jobMetadata.JobT, status: &status}
+	}
+}
+
 func consolidateRespBodys(respBodyArrs []map[int64]string) map[int64]string {
 	if len(respBodyArrs) == 0 {
 		return nil
@@ -840,19 +924,21 @@ func (w *worker) updateFailedJobOrderKeys(failedJobOrderKeys map[eventorder.Barr
 				DestinationID: metadata.DestinationID,
 				WorkspaceID:   metadata.WorkspaceID,
 			}
-			if w.rt.guaranteeUserEventOrder && !w.barrier.Disabled(orderKey) { // if barrier is disabled, we shouldn't need to track the failed job
+			if w.rt.guaranteeUserEventOrderFor(metadata.DestinationID) && !w.barrier.Disabled(orderKey) { // if barrier is disabled, we shouldn't need to track the failed job
 				failedJobOrderKeys[orderKey] = struct{}{}
 			}
 		}
 	}
 }
 
-func (w *worker) prepareRouterJobResponses(destinationJob types.DestinationJobT, respStatusCodes map[int64]int, respBodys map[int64]string, errorAt string, transformerProxy bool) []*JobResponse {
+func (w *worker) prepareRouterJobResponses(destinationJob types.DestinationJobT, respStatusCodes map[int64]int, respBodys map[int64]string, respRetryAfter, errorAt string, transformerProxy bool) []*JobResponse {
 	w.hydrateRespStatusCodes(destinationJob, respStatusCodes, respBodys)
 
 	var destinationResponseHandler ResponseHandler
+	var destinationRetryPolicy *retryPolicy
 	w.rt.destinationsMapMu.RLock()
 	destinationResponseHandler = w.rt.destinationResponseHandler
+	destinationRetryPolicy = w.rt.retryPolicy
 	w.rt.destinationsMapMu.RUnlock()
 
 	// Using response status code and body to get response code rudder router logic is based on.
@@ -863,6 +949,18 @@ func (w *worker) prepareRouterJobResponses(destinationJob types.DestinationJobT,
 		}
 	}
 
+	// Applying the retry policy of the destination definition on delivery responses, deciding whether jobs get retried,
+	// aborted or throttled and for how long they wait before their next attempt
+	retryRules := make(map[int64]*retryRule)
+	if destinationRetryPolicy != nil && (errorAt == routerutils.ERROR_AT_DEL || errorAt == routerutils.ERROR_AT_CUST) {
+		for k, respStatusCode := range respStatusCodes {
+			if rule := destinationRetryPolicy.match(respStatusCode, respBodys[k]); rule != nil {
+				respStatusCodes[k] = rule.statusCode(respStatusCode)
+				retryRules[k] = rule
+			}
+		}
+	}
+
 	// Failure - Save response body
 	// Success - Skip saving response body
 	// By default we get some config from dest def
@@ -885,6 +983,11 @@ func (w *worker) prepareRouterJobResponses(destinationJob types.DestinationJobT,
 		// assigning the destinationJobMetadata to a local variable (_destinationJobMetadata), so that
 		// elements in routerJobResponses have pointer to the right destinationJobMetadata.
 
+		var retryBackoff time.Duration
+		if rule, ok := retryRules[destinationJobMetadata.JobID]; ok && !isJobTerminated(respStatusCodes[destinationJobMetadata.JobID]) {
+			retryBackoff = rule.backoff(destinationJobMetadata.AttemptNum+1, respRetryAfter,
+				w.rt.reloadableConfig.minRetryBackoff.Load(), w.rt.reloadableConfig.maxRetryBackoff.Load())
+		}
 		routerJobResponses = append(routerJobResponses, &JobResponse{
 			jobID:                  destinationJobMetadata.JobID,
 			destinationJob:         &destinationJob,
@@ -892,6 +995,7 @@ func (w *worker) prepareRouterJobResponses(destinationJob types.DestinationJobT,
 			respStatusCode:         respStatusCodes[destinationJobMetadata.JobID],
 			respBody:               respBodys[destinationJobMetadata.JobID],
 			errorAt:                errorAt,
+			retryBackoff:           retryBackoff,
 		})
 	}
 
@@ -912,7 +1016,7 @@ func (w *worker) prepareResponsesForJobs(destinationJob *types.DestinationJobT,
 func (w *worker) canSendJobToDestination(failedJobOrderKeys map[eventorder.BarrierKey]struct{}, destinationJob *types.DestinationJobT) bool {
 	destinationID := destinationJob.JobMetadataArray[0].DestinationID
 	workspaceID := destinationJob.JobMetadataArray[0].WorkspaceID
-	if !w.rt.guaranteeUserEventOrder ||
+	if !w.rt.guaranteeUserEventOrderFor(destinationID) ||
 		w.rt.eventOrderingDisabledForWorkspace(workspaceID) ||
 		w.rt.eventOrderingDisabledForDestination(destinationID) {
 		// if guaranteeUserEventOrder is false, letting the next jobs pass
@@ -980,7 +1084,7 @@ func (w *worker) updateAbortedMetrics(destinationID, workspaceId, statusCode, er
 
 func (w *worker) postStatusOnResponseQ(respStatusCode int, payload json.RawMessage,
 	respContentType string, destinationJobMetadata *types.JobMetadataT, status *jobsdb.JobStatusT,
-	errorAt string,
+	errorAt string, retryBackoff time.Duration,
 ) {
 	// Enhancing status.ErrorResponse with firstAttemptedAt
 	firstAttemptedAtTime := time.Now()
@@ -1044,11 +1148,14 @@ func (w *worker) postStatusOnResponseQ(respStatusCode int, payload json.RawMessa
 	} else {
 		status.JobState = jobsdb.Failed.State
 		if !w.rt.retryLimitReached(status) { // don't delay retry time if retry limit is reached, so that the job can be aborted immediately on the next loop
-			status.RetryTime = status.ExecTime.Add(nextAttemptAfter(status.AttemptNum, w.rt.reloadableConfig.minRetryBackoff.Load(), w.rt.reloadableConfig.maxRetryBackoff.Load()))
+			if retryBackoff <= 0 {
+				retryBackoff = nextAttemptAfter(status.AttemptNum, w.rt.reloadableConfig.minRetryBackoff.Load(), w.rt.reloadableConfig.maxRetryBackoff.Load())
+			}
+			status.RetryTime = status.ExecTime.Add(retryBackoff)
 		}
 	}
 
-	if w.rt.guaranteeUserEventOrder {
+	if w.rt.guaranteeUserEventOrderFor(destinationJobMetadata.DestinationID) {
 		if status.JobState == jobsdb.Failed.State {
 
 			orderKey := eventorder.BarrierKey{
@@ -1143,6 +1250,11 @@ func (w *worker) sendDestinationResponseToConfigBackend(payload json.RawMessage,
 			EventType:     gjson.GetBytes(destinationJobMetadata.JobT.Parameters, "event_type").String(),
 		}
 		w.rt.debugger.RecordEventDeliveryStatus(destinationJobMetadata.DestinationID, &deliveryStatus)
+		if liveevents.Default.Listening() {
+			if data, err := json.Marshal(deliveryStatus); err == nil {
+				liveevents.Default.Record(liveevents.Delivered, destinationJobMetadata.SourceID, destinationJobMetadata.DestinationID, data)
+			}
+		}
 	}
 }
 
@@ -1218,3 +1330,35 @@ func (w *worker) recordStatsForFailedTransforms(transformType string, transforme
 		}
 	}
 }
+
+// captureExchange records the request sent to the destination along with its response, if response capturing is enabled for it.
+// The body of the request is serialized only when the captured exchange is read.
+func (w *worker) captureExchange(destinationJob *types.DestinationJobT, val integrations.PostParametersT, proxied bool, statusCode int, response string, latency time.Duration) {
+	if !w.rt.responseCapture.Enabled(destinationJob.Destination.ID) {
+		return
+	}
+	var payload interface{}
+	for _, v := range val.Body {
+		if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+			payload = m
+			break
+		}
+	}
+	w.rt.responseCapture.Record(capture.Exchange{
+		DestinationID: destinationJob.Destination.ID,
+		JobIDs: lo.Map(destinationJob.JobMetadataArray, func(m types.JobMetadataT, _ int) int64 {
+			return m.JobID
+		}),
+		Proxied: proxied,
+		Request: capture.Request{
+			Method:      val.RequestMethod,
+			URL:         val.URL,
+			Headers:     val.Headers,
+			QueryParams: val.QueryParams,
+			Payload:     payload,
+		},
+		StatusCode: statusCode,
+		Response:   response,
+		LatencyMs:  latency.Milliseconds(),
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -11,7 +12,9 @@ import (
 
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/enterprise/reporting"
+	"github.com/rudderlabs/rudder-server/jobsdb"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
+	"github.com/rudderlabs/rudder-server/router/internal/circuitbreaker"
 	"github.com/rudderlabs/rudder-server/router/throttler"
 	"github.com/rudderlabs/rudder-server/router/transformer"
 	"github.com/rudderlabs/rudder-server/router/types"
@@ -152,6 +155,57 @@ func TestAnyNonTerminalCode(t *testing.T) {
 	}
 }
 
+func TestPutBackToWaiting(t *testing.T) {
+	conf := config.New()
+	conf.Set("Router.circuitBreaker.enabled", true)
+	conf.Set("Router.circuitBreaker.consecutiveFailures", 1)
+	w := &worker{rt: &Handle{
+		circuitBreaker: circuitbreaker.New(conf, stats.NOP, "GA"),
+		responseQ:      make(chan workerJobStatus, 2),
+	}}
+
+	openCircuits := make(map[string]bool)
+	require.False(t, w.circuitOpen(openCircuits, "dest-1"))
+	w.rt.circuitBreaker.Report("dest-1", http.StatusServiceUnavailable)
+	require.False(t, w.circuitOpen(openCircuits, "dest-1"), "the circuit is checked once per batch")
+	require.True(t, w.circuitOpen(make(map[string]bool), "dest-1"))
+
+	jobs := []*jobsdb.JobT{
+		{JobID: 1, UserID: "user-1", WorkspaceId: "workspace", Parameters: []byte(`{"destination_id":"dest-1"}`)},
+		{JobID: 2, UserID: "user-1", WorkspaceId: "workspace", Parameters: []byte(`{"destination_id":"dest-1"}`)},
+	}
+	w.putBackToWaiting(&types.DestinationJobT{JobMetadataArray: []types.JobMetadataT{
+		{JobID: 1, UserID: "user-1", WorkspaceID: "workspace", DestinationID: "dest-1", AttemptNum: 3, FirstAttemptedAt: "2024-01-01T00:00:00.000Z", JobT: jobs[0]},
+		{JobID: 2, UserID: "user-1", WorkspaceID: "workspace", DestinationID: "dest-1", JobT: jobs[1]},
+	}}, "destination circuit is open after consecutive failures")
+	for i, attemptNum := range []int{3, 0} {
+		status := <-w.rt.responseQ
+		require.Equal(t, jobs[i], status.job)
+		require.Equal(t, jobsdb.Waiting.State, status.status.JobState)
+		require.Equal(t, attemptNum, status.status.AttemptNum, "waiting for the circuit doesn't count as an attempt")
+	}
+}
+
+func TestAcquireConcurrency(t *testing.T) {
+	conf := config.New()
+	conf.Set("Router.throttler.GA.maxConcurrency", 1)
+	w := &worker{rt: &Handle{concurrencyLimiter: throttler.NewConcurrencyLimiter(conf, stats.NOP, "GA")}}
+
+	saturatedDestinations := make(map[string]struct{})
+	release, ok := w.acquireConcurrency(saturatedDestinations, "dest-1")
+	require.True(t, ok)
+	_, ok = w.acquireConcurrency(saturatedDestinations, "dest-1")
+	require.False(t, ok, "the worker shouldn't wait for the inflight delivery to finish")
+	_, ok = w.acquireConcurrency(saturatedDestinations, "dest-2")
+	require.True(t, ok, "limits should be per destination")
+
+	release()
+	_, ok = w.acquireConcurrency(saturatedDestinations, "dest-1")
+	require.False(t, ok, "the destination should remain saturated for the rest of the batch")
+	_, ok = w.acquireConcurrency(make(map[string]struct{}), "dest-1")
+	require.True(t, ok)
+}
+
 var _ = Describe("Proxy Request", func() {
 	initRouter()
 
@@ -26,6 +26,11 @@ import (
 	"github.com/rudderlabs/rudder-server/app/apphandlers"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/info"
+	"github.com/rudderlabs/rudder-server/internal/audit"
+	"github.com/rudderlabs/rudder-server/internal/featureflags"
+	"github.com/rudderlabs/rudder-server/internal/liveevents"
+	"github.com/rudderlabs/rudder-server/internal/preflight"
+	"github.com/rudderlabs/rudder-server/internal/shutdown"
 	"github.com/rudderlabs/rudder-server/router/customdestinationmanager"
 	"github.com/rudderlabs/rudder-server/rruntime"
 	"github.com/rudderlabs/rudder-server/services/alert"
@@ -33,6 +38,7 @@ import (
 	"github.com/rudderlabs/rudder-server/services/diagnostics"
 	"github.com/rudderlabs/rudder-server/services/oauth"
 	"github.com/rudderlabs/rudder-server/services/streammanager/kafka"
+	"github.com/rudderlabs/rudder-server/services/streammanager/plugin"
 	"github.com/rudderlabs/rudder-server/utils/crash"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 	"github.com/rudderlabs/rudder-server/utils/types/deployment"
@@ -61,6 +67,7 @@ type Runner struct {
 	logger                    logger.Logger
 	appHandler                apphandlers.AppHandler
 	gracefulShutdownTimeout   time.Duration
+	drainTimeout              time.Duration
 }
 
 // New creates and initializes a new Runner
@@ -72,6 +79,7 @@ func New(releaseInfo ReleaseInfo) *Runner {
 		warehouseMode:             config.GetString("Warehouse.mode", "embedded"),
 		enableSuppressUserFeature: config.GetBool("Gateway.enableSuppressUserFeature", true),
 		gracefulShutdownTimeout:   config.GetDuration("GracefulShutdownTimeout", 15, time.Second),
+		drainTimeout:              config.GetDuration("GracefulShutdownDrainTimeout", 0, time.Second),
 	}
 }
 
@@ -102,6 +110,8 @@ func (r *Runner) Run(ctx context.Context, args []string) int {
 		(!config.IsSet("WORKSPACE_NAMESPACE") || strings.Contains(config.GetString("WORKSPACE_NAMESPACE", ""), "free")) {
 		config.Set("statsExcludedTags", []string{"workspaceId", "sourceID", "destId"})
 	}
+	// the version of the server is part of the tag of the queries issued to the warehouses
+	config.Set("Warehouse.serverVersion", r.releaseInfo.Version)
 	statsOptions := []stats.Option{
 		stats.WithServiceName(r.appType),
 		stats.WithServiceVersion(r.releaseInfo.Version),
@@ -170,6 +180,32 @@ func (r *Runner) Run(ctx context.Context, args []string) int {
 		return 1
 	}
 	backendconfig.DefaultBackendConfig.StartWithIDs(ctx, "")
+	featureflags.Default = featureflags.New(config.Default, r.logger.Child("feature-flags"))
+	admin.RegisterAdminHandler("FeatureFlags", &featureflags.Admin{Service: featureflags.Default})
+	liveevents.Default = liveevents.New(config.Default, r.logger.Child("live-events"))
+	if plugin.Default, err = plugin.New(config.Default, r.logger.Child("plugin")); err != nil {
+		r.logger.Errorf("Unable to setup destination plugins: %v", err)
+		return 1
+	}
+
+	if options.ValidateConfig || config.GetBool("Preflight.enabled", false) {
+		if err := r.runPreflightChecks(ctx); err != nil {
+			r.logger.Errorf("Invalid configuration: %v", err)
+			return 1
+		}
+		if options.ValidateConfig {
+			r.logger.Info("Configuration is valid")
+			return 0
+		}
+	}
+
+	if config.GetBool("AuditLog.enabled", false) {
+		if audit.Default, err = audit.New(config.Default, r.logger.Child("audit")); err != nil {
+			r.logger.Errorf("Unable to setup the audit log: %v", err)
+			return 1
+		}
+		defer audit.Default.Stop()
+	}
 
 	// Prepare databases in sequential order, so that failure in one doesn't affect others (leaving dirty schema migration state)
 	if r.canStartServer() {
@@ -193,8 +229,23 @@ func (r *Runner) Run(ctx context.Context, args []string) int {
 			return 1
 		}
 	}
+	// work in flight when shutting down, i.e. the events accepted by the gateway and the stages of warehouse uploads in
+	// progress, is given the drain timeout to finish
+	ctx = shutdown.WithDrainTimeout(ctx, r.drainTimeout)
 	g, ctx := errgroup.WithContext(ctx)
 
+	g.Go(func() error {
+		featureflags.Default.Run(ctx, backendconfig.DefaultBackendConfig)
+		return nil
+	})
+	g.Go(func() error {
+		plugin.Default.Run(ctx)
+		return nil
+	})
+	g.Go(func() error {
+		return audit.Default.CleanupRoutine(ctx)
+	})
+
 	// Start admin server
 	if config.GetBool("AdminServer.enabled", true) {
 		g.Go(func() error {
@@ -262,6 +313,9 @@ func (r *Runner) Run(ctx context.Context, args []string) int {
 
 	<-ctx.Done()
 	ctxDoneTime := time.Now()
+	if r.drainTimeout > 0 {
+		r.logger.Infof("Draining work in flight for up to %s", r.drainTimeout)
+	}
 
 	select {
 	case <-shutdownDone:
@@ -274,7 +328,7 @@ func (r *Runner) Run(ctx context.Context, args []string) int {
 		// clearing zap Log buffer to std output
 		logger.Sync()
 		stats.Default.Stop()
-	case <-time.After(r.gracefulShutdownTimeout):
+	case <-time.After(r.drainTimeout + r.gracefulShutdownTimeout):
 		// Assume graceful shutdown failed, log remain goroutines and force kill
 		r.logger.Errorf(
 			"Graceful termination failed after %s, goroutine dump:\n",
@@ -331,6 +385,24 @@ func (r *Runner) printVersion() {
 	fmt.Printf("Version Info %s\n", versionFormatted)
 }
 
+// runPreflightChecks checks the environment, databases, object storage and destinations the server needs to run
+func (r *Runner) runPreflightChecks(ctx context.Context) error {
+	log := r.logger.Child("preflight")
+	checks := []preflight.Check{preflight.Environment(config.Default)}
+	if r.canStartServer() {
+		checks = append(checks, preflight.Database("jobs", misc.GetConnectionString(config.Default, "preflight")))
+		if config.GetBool("JobsDB.backup.enabled", true) {
+			checks = append(checks, preflight.ObjectStorage(config.Default, log, filemanager.New))
+		}
+	}
+	if r.canStartWarehouse() {
+		warehouseApp := warehouse.New(r.application, config.Default, r.logger, stats.Default, backendconfig.DefaultBackendConfig, filemanager.New)
+		checks = append(checks, preflight.Database("warehouse", warehouseApp.ConnectionString("preflight")))
+	}
+	checks = append(checks, preflight.Destinations(backendconfig.DefaultBackendConfig))
+	return preflight.Report(log, preflight.Run(ctx, config.GetDuration("Preflight.timeout", 30, time.Second), checks...))
+}
+
 func (r *Runner) canStartServer() bool {
 	r.logger.Info("warehousemode ", r.warehouseMode)
 	return r.warehouseMode == config.EmbeddedMode || r.warehouseMode == config.OffMode || r.warehouseMode == config.EmbeddedMasterMode
@@ -25,6 +25,7 @@ type BadgerDB struct {
 	badgerDB *badger.DB
 	window   config.ValueLoader[time.Duration]
 	path     string
+	statName string
 	opts     badger.Options
 	once     sync.Once
 	wg       sync.WaitGroup
@@ -42,7 +43,33 @@ func DefaultPath() string {
 	return fmt.Sprintf(`%v%v`, tmpDirPath, badgerPathName)
 }
 
-func NewBadgerDB(conf *config.Config, stats stats.Stats, path string) *Dedup {
+// Path returns the path configured for the deduplication service's badger DB through Dedup.Badger.path.
+// A persistent volume should be configured there so that deduplication survives restarts, otherwise [DefaultPath] is used.
+func Path(conf *config.Config) string {
+	if path := conf.GetString("Dedup.Badger.path", ""); path != "" {
+		return path
+	}
+	return DefaultPath()
+}
+
+// Opt is a functional option for [NewBadgerDB]
+type Opt func(*BadgerDB)
+
+// WithWindow overrides the retention window of the keys, which defaults to Dedup.dedupWindow
+func WithWindow(window config.ValueLoader[time.Duration]) Opt {
+	return func(d *BadgerDB) {
+		d.window = window
+	}
+}
+
+// WithStatName overrides the name tag used for the badger_db_size stat, which defaults to dedup
+func WithStatName(name string) Opt {
+	return func(d *BadgerDB) {
+		d.statName = name
+	}
+}
+
+func NewBadgerDB(conf *config.Config, stats stats.Stats, path string, opts ...Opt) *Dedup {
 	dedupWindow := conf.GetReloadableDurationVar(3600, time.Second, "Dedup.dedupWindow", "Dedup.dedupWindowInS")
 	log := logger.NewLogger().Child("Dedup")
 	badgerOpts := badger.
@@ -61,14 +88,18 @@ func NewBadgerDB(conf *config.Config, stats stats.Stats, path string) *Dedup {
 
 	bgCtx, cancel := context.WithCancel(context.Background())
 	db := &BadgerDB{
-		stats:  stats,
-		logger: loggerForBadger{log},
-		path:   path,
-		window: dedupWindow,
-		opts:   badgerOpts,
-		wg:     sync.WaitGroup{},
-		bgCtx:  bgCtx,
-		cancel: cancel,
+		stats:    stats,
+		logger:   loggerForBadger{log},
+		path:     path,
+		window:   dedupWindow,
+		statName: "dedup",
+		opts:     badgerOpts,
+		wg:       sync.WaitGroup{},
+		bgCtx:    bgCtx,
+		cancel:   cancel,
+	}
+	for _, opt := range opts {
+		opt(db)
 	}
 	return &Dedup{
 		badgerDB: db,
@@ -166,10 +197,9 @@ func (d *BadgerDB) gcLoop() {
 			d.logger.Errorf("Error while getting badgerDB usage: %v", err)
 			continue
 		}
-		statName := "dedup"
-		d.stats.NewTaggedStat("badger_db_size", stats.GaugeType, stats.Tags{"name": statName, "type": "lsm"}).Gauge(lsmSize)
-		d.stats.NewTaggedStat("badger_db_size", stats.GaugeType, stats.Tags{"name": statName, "type": "vlog"}).Gauge(vlogSize)
-		d.stats.NewTaggedStat("badger_db_size", stats.GaugeType, stats.Tags{"name": statName, "type": "total"}).Gauge(totSize)
+		d.stats.NewTaggedStat("badger_db_size", stats.GaugeType, stats.Tags{"name": d.statName, "type": "lsm"}).Gauge(lsmSize)
+		d.stats.NewTaggedStat("badger_db_size", stats.GaugeType, stats.Tags{"name": d.statName, "type": "vlog"}).Gauge(vlogSize)
+		d.stats.NewTaggedStat("badger_db_size", stats.GaugeType, stats.Tags{"name": d.statName, "type": "total"}).Gauge(totSize)
 	}
 }
 
@@ -236,6 +266,15 @@ func (d *Dedup) Commit(keys []string) error {
 	return nil
 }
 
+// Discard forgets a list of previously set keys without committing them, e.g. when their processing failed and they need to be retried
+func (d *Dedup) Discard(keys []string) {
+	d.cacheMu.Lock()
+	defer d.cacheMu.Unlock()
+	for _, key := range keys {
+		delete(d.cache, key)
+	}
+}
+
 func (d *Dedup) Close() {
 	d.badgerDB.Close()
 }
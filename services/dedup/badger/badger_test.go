@@ -54,3 +54,37 @@ func TestBadgerClose(t *testing.T) {
 	t.Log("close badger without any other operation")
 	badger.Close()
 }
+
+func TestBadgerDiscard(t *testing.T) {
+	badger := NewBadgerDB(config.New(), stats.NOP, t.TempDir())
+	defer badger.Close()
+
+	first, _, err := badger.Get(types.KeyValue{Key: "a", Value: 1})
+	require.NoError(t, err)
+	require.True(t, first)
+	badger.Discard([]string{"a"})
+
+	first, _, err = badger.Get(types.KeyValue{Key: "a", Value: 1})
+	require.NoError(t, err)
+	require.True(t, first, "discarded keys should not be deduped")
+	require.NoError(t, badger.Commit([]string{"a"}))
+}
+
+func TestBadgerSurvivesRestart(t *testing.T) {
+	path := t.TempDir()
+	conf := config.New()
+
+	badger := NewBadgerDB(conf, stats.NOP, path, WithStatName("test"))
+	first, _, err := badger.Get(types.KeyValue{Key: "a", Value: 10})
+	require.NoError(t, err)
+	require.True(t, first)
+	require.NoError(t, badger.Commit([]string{"a"}))
+	badger.Close()
+
+	badger = NewBadgerDB(conf, stats.NOP, path, WithStatName("test"))
+	defer badger.Close()
+	first, previous, err := badger.Get(types.KeyValue{Key: "a", Value: 10})
+	require.NoError(t, err)
+	require.False(t, first)
+	require.EqualValues(t, 10, previous)
+}
@@ -26,7 +26,7 @@ func New(conf *config.Config, stats stats.Stats) (Dedup, error) {
 	mode := Mode(conf.GetString("Dedup.Mode", string(Badger)))
 	switch mode {
 	case Badger:
-		return badger.NewBadgerDB(conf, stats, badger.DefaultPath()), nil
+		return badger.NewBadgerDB(conf, stats, badger.Path(conf)), nil
 	case Scylla:
 		scylla, err := scylla.New(conf, stats)
 		if err != nil {
@@ -42,7 +42,7 @@ func New(conf *config.Config, stats stats.Stats) (Dedup, error) {
 		// Read only from Badger
 		return mirrorBadger.NewMirrorBadger(conf, stats)
 	default:
-		return badger.NewBadgerDB(conf, stats, badger.DefaultPath()), nil
+		return badger.NewBadgerDB(conf, stats, badger.Path(conf)), nil
 	}
 }
 
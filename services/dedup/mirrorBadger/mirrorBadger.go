@@ -15,7 +15,7 @@ type MirrorBadger struct {
 }
 
 func NewMirrorBadger(conf *config.Config, stats stats.Stats) (*MirrorBadger, error) {
-	badger := badger.NewBadgerDB(conf, stats, badger.DefaultPath())
+	badger := badger.NewBadgerDB(conf, stats, badger.Path(conf))
 	scylla, err := scylla.New(conf, stats)
 	if err != nil {
 		return nil, err
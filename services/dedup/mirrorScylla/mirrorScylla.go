@@ -15,7 +15,7 @@ type MirrorScylla struct {
 }
 
 func NewMirrorScylla(conf *config.Config, stats stats.Stats) (*MirrorScylla, error) {
-	badger := badger.NewBadgerDB(conf, stats, badger.DefaultPath())
+	badger := badger.NewBadgerDB(conf, stats, badger.Path(conf))
 	scylla, err := scylla.New(conf, stats)
 	if err != nil {
 		return nil, err
@@ -72,6 +72,9 @@ type Job struct {
 }
 
 type PublishRequest struct {
+	// BatchID identifies the published batch, for tracking it again with Track, e.g. after a restart.
+	// A new one is generated if empty.
+	BatchID      string
 	Payloads     []json.RawMessage
 	UploadSchema json.RawMessage // ATM Hack to support merging schema with the payload at the postgres level
 	JobType      JobType
@@ -340,7 +343,10 @@ func (n *Notifier) Publish(
 ) (<-chan *PublishResponse, error) {
 	publishStartTime := n.now()
 
-	batchID := n.batchIDGenerator().String()
+	batchID := publishRequest.BatchID
+	if batchID == "" {
+		batchID = n.batchIDGenerator().String()
+	}
 
 	if err := n.repo.insert(ctx, publishRequest, n.workspaceIdentifier, batchID); err != nil {
 		return nil, fmt.Errorf("inserting jobs: %w", err)
@@ -358,6 +364,15 @@ func (n *Notifier) Publish(
 	return n.trackBatch(ctx, batchID), nil
 }
 
+// Track tracks a batch published earlier and returns a channel of type PublishResponse, same as Publish does.
+// The response of a batch which no longer exists, e.g. because its jobs were cleared, has no jobs.
+func (n *Notifier) Track(
+	ctx context.Context,
+	batchID string,
+) <-chan *PublishResponse {
+	return n.trackBatch(ctx, batchID)
+}
+
 // trackBatch tracks the batch and returns a channel of type PublishResponse
 func (n *Notifier) trackBatch(
 	ctx context.Context,
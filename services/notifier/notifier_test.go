@@ -374,6 +374,66 @@ func TestNotifier(t *testing.T) {
 		})
 		require.NoError(t, g.Wait())
 	})
+	t.Run("track batch", func(t *testing.T) {
+		t.Parallel()
+
+		pgResource := setup(t)
+		ctx := context.Background()
+
+		const batchID = "test_batch_id"
+
+		c := config.New()
+		c.Set("PgNotifier.trackBatchIntervalInS", "100ms")
+		c.Set("PgNotifier.maxPollSleep", "100ms")
+
+		groupCtx, groupCancel := context.WithCancel(ctx)
+		g, gCtx := errgroup.WithContext(groupCtx)
+
+		n := notifier.New(c, logger.NOP, stats.Default, workspaceIdentifier)
+		err := n.Setup(groupCtx, pgResource.DBDsn)
+		require.NoError(t, err)
+
+		// the publisher stops tracking the batch, e.g. because of a restart
+		publishCtx, publishCancel := context.WithCancel(gCtx)
+		_, err = n.Publish(publishCtx, &notifier.PublishRequest{
+			BatchID: batchID,
+			Payloads: []json.RawMessage{
+				json.RawMessage(`{"id":"1"}`),
+				json.RawMessage(`{"id":"2"}`),
+			},
+			JobType:      notifier.JobTypeUpload,
+			UploadSchema: json.RawMessage(`{"UploadSchema": "1"}`),
+			Priority:     50,
+		})
+		require.NoError(t, err)
+		publishCancel()
+
+		g.Go(func() error {
+			for job := range n.Subscribe(gCtx, workerID, 1) {
+				n.UpdateClaim(gCtx, job, &notifier.ClaimJobResponse{
+					Payload: json.RawMessage(`{"test": "payload"}`),
+				})
+			}
+			return nil
+		})
+		g.Go(func() error {
+			response := <-n.Track(gCtx, batchID)
+			require.NoError(t, response.Err)
+			require.Len(t, response.Jobs, 2)
+
+			response = <-n.Track(gCtx, batchID)
+			require.NoError(t, response.Err)
+			require.Empty(t, response.Jobs, "jobs of completed batches should be deleted")
+
+			groupCancel()
+			return nil
+		})
+		g.Go(func() error {
+			<-groupCtx.Done()
+			return n.Shutdown()
+		})
+		require.NoError(t, g.Wait())
+	})
 	t.Run("bigger batches and many subscribers", func(t *testing.T) {
 		t.Parallel()
 
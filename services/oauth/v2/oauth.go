@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/tidwall/gjson"
@@ -38,6 +39,19 @@ type OAuthHandler struct {
 	ExpirationTimeDiff        time.Duration
 	ConfigBEURL               string
 	cpConnectorTimeout        time.Duration
+	// RefreshFailureBackoff is the duration for which a failed fetch/refresh of an account's token is returned
+	// to subsequent requests without calling the control plane again, so that every job failing with a 401
+	// doesn't trigger its own refresh while the control plane (or the account) is failing
+	RefreshFailureBackoff time.Duration
+	failedRefreshes       sync.Map // accountID -> *failedRefresh
+}
+
+// failedRefresh is the outcome of a failed fetch/refresh of an account's token
+type failedRefresh struct {
+	statusCode int
+	response   *AuthResponse
+	err        error
+	until      time.Time
 }
 
 func WithCache(cache Cache) func(*OAuthHandler) {
@@ -58,6 +72,12 @@ func WithExpirationTimeDiff(expirationTimeDiff time.Duration) func(*OAuthHandler
 	}
 }
 
+func WithRefreshFailureBackoff(backoff time.Duration) func(*OAuthHandler) {
+	return func(h *OAuthHandler) {
+		h.RefreshFailureBackoff = backoff
+	}
+}
+
 func WithLogger(parentLogger logger.Logger) func(*OAuthHandler) {
 	return func(h *OAuthHandler) {
 		h.Logger = parentLogger
@@ -90,6 +110,7 @@ func NewOAuthHandler(provider TokenProvider, options ...func(*OAuthHandler)) *OA
 		RudderFlowType:            common.RudderFlowDelivery,
 		AuthStatusUpdateActiveMap: make(map[string]bool),
 		ConfigBEURL:               backendconfig.GetConfigBackendURL(),
+		RefreshFailureBackoff:     config.GetDurationVar(10, time.Second, "OAuth.refreshFailureBackoff"),
 	}
 	for _, opt := range options {
 		opt(h)
@@ -214,12 +235,30 @@ func (h *OAuthHandler) GetTokenInfo(refTokenParams *RefreshTokenParams, logTypeN
 			ExpiredSecret: refTokenParams.Secret,
 		}
 	}
+	if v, ok := h.failedRefreshes.Load(refTokenParams.AccountID); ok {
+		if failure := v.(*failedRefresh); time.Now().Before(failure.until) {
+			authStats.statName = GetOAuthActionStatName("request_suppressed")
+			authStats.errorMessage = failure.response.errType()
+			authStats.SendCountStat()
+			log.Debugn("[request] :: Returning the recent failure instead of calling the control plane")
+			return failure.statusCode, failure.response, failure.err
+		}
+		h.failedRefreshes.Delete(refTokenParams.AccountID)
+	}
 	statusCode, refSecret, refErr := h.fetchAccountInfoFromCp(refTokenParams, refTokenBody, authStats, logTypeName)
 	// handling of refresh token response
 	if statusCode == http.StatusOK {
 		// fetching/refreshing through control plane was successful
 		return statusCode, refSecret, nil
 	}
+	if h.RefreshFailureBackoff > 0 {
+		h.failedRefreshes.Store(refTokenParams.AccountID, &failedRefresh{
+			statusCode: statusCode,
+			response:   refSecret,
+			err:        refErr,
+			until:      time.Now().Add(h.RefreshFailureBackoff),
+		})
+	}
 	return statusCode, refSecret, refErr
 }
 
@@ -491,6 +491,63 @@ var _ = Describe("Oauth", func() {
 			Expect(err).To(MatchError(fmt.Errorf("invalid grant")))
 			Expect(response).To(Equal(expectedResponse))
 		})
+		It("refreshToken function calls following a failed refresh return the failure without calling the control plane until the backoff expires", func() {
+			refreshTokenParams := &v2.RefreshTokenParams{
+				AccountID:   "123",
+				WorkspaceID: "456",
+				DestDefName: "testDest",
+				Destination: Destination,
+				Secret:      []byte(`{"access_token":"storedAccessToken","refresh_token":"dummyRefreshToken","developer_token":"dummyDeveloperToken"}`),
+			}
+
+			ctrl := gomock.NewController(GinkgoT())
+			mockCpConnector := mock_oauthV2.NewMockConnector(ctrl)
+
+			mockCpConnector.EXPECT().CpApiCall(gomock.Any()).Return(http.StatusBadRequest, `{
+				"body":{
+				  "code":"ref_token_invalid_grant",
+				  "message":"invalid_grant error, refresh token has expired or revoked"
+				}
+			  }`).Times(2)
+
+			mockTokenProvider := mock_oauthV2.NewMockTokenProvider(ctrl)
+			mockTokenProvider.EXPECT().Identity().Return(nil).AnyTimes()
+
+			// Invoke code under test
+			oauthHandler := v2.NewOAuthHandler(mockTokenProvider,
+				v2.WithCache(v2.NewCache()),
+				v2.WithLocker(kitsync.NewPartitionRWLocker()),
+				v2.WithStats(stats.Default),
+				v2.WithLogger(logger.NewLogger().Child("MockOAuthHandler")),
+				v2.WithCpConnector(mockCpConnector),
+				v2.WithRefreshFailureBackoff(100*time.Millisecond),
+			)
+			storedAuthResponse := &v2.AuthResponse{
+				Account: v2.AccountSecret{
+					Secret: []byte(`{"access_token":"storedAccessToken","refresh_token":"dummyRefreshToken","developer_token":"dummyDeveloperToken"}`),
+				}, Err: "",
+				ErrorMessage: "",
+			}
+			oauthHandler.Cache.Store(refreshTokenParams.AccountID, storedAuthResponse)
+			expectedResponse := &v2.AuthResponse{
+				Account: v2.AccountSecret{
+					Secret: nil,
+				}, Err: "ref_token_invalid_grant",
+				ErrorMessage: "invalid_grant error, refresh token has expired or revoked",
+			}
+			for i := 0; i < 3; i++ {
+				statusCode, response, err := oauthHandler.RefreshToken(refreshTokenParams)
+				Expect(statusCode).To(Equal(http.StatusBadRequest))
+				Expect(err).To(MatchError(fmt.Errorf("invalid grant")))
+				Expect(response).To(Equal(expectedResponse))
+			}
+
+			time.Sleep(100 * time.Millisecond)
+			statusCode, response, err := oauthHandler.RefreshToken(refreshTokenParams)
+			Expect(statusCode).To(Equal(http.StatusBadRequest))
+			Expect(err).To(MatchError(fmt.Errorf("invalid grant")))
+			Expect(response).To(Equal(expectedResponse))
+		})
 		It("refreshToken function call when stored cache is same as provided secret and cpApiCall returns a failed response due to config backend service is not available", func() {
 			refreshTokenParams := &v2.RefreshTokenParams{
 				AccountID:   "123",
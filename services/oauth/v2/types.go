@@ -28,6 +28,14 @@ type AuthResponse struct {
 	Err          string
 	ErrorMessage string
 }
+
+func (r *AuthResponse) errType() string {
+	if r == nil {
+		return ""
+	}
+	return r.Err
+}
+
 type RefreshTokenParams struct {
 	AccountID   string
 	WorkspaceID string
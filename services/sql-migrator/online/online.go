@@ -0,0 +1,359 @@
+// Package online applies schema migrations of large tables online, in the background, while the service keeps
+// serving traffic. Migrations apply additive changes, e.g. adding nullable columns or creating indexes concurrently,
+// and backfill existing rows in small batches, reporting their progress along the way, instead of locking huge
+// tables for the whole duration of an ALTER at startup.
+package online
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusFailed    = "failed"
+	StatusCompleted = "completed"
+)
+
+// Migration is a schema migration applied online
+type Migration struct {
+	// ID identifies the migration. Migrations are applied once, in the order they are provided.
+	ID string
+
+	// Statements are the additive schema changes of the migration. They are executed outside of transactions,
+	// so that indexes can be created concurrently, and with a lock timeout, so that they never queue up traffic
+	// behind them while waiting for a lock. All of them are executed again until they succeed altogether, thus
+	// they need to be idempotent, e.g. dropping an index left invalid by a failed concurrent creation before creating it.
+	Statements []string
+
+	// Backfill optionally fills in existing rows, after the statements are applied
+	Backfill *Backfill
+}
+
+// Backfill updates the rows of a table in batches of consecutive keys, each one in its own short transaction
+type Backfill struct {
+	// Table is the table getting backfilled
+	Table string
+
+	// Key is an integer column of the table, usually its primary key, used for walking the table in batches.
+	// Only rows existing when the backfill starts are walked, rows written afterwards are expected to be complete already.
+	Key string
+
+	// Update is the statement updating the rows of a batch, the ones having their keys in the range of [$1, $2)
+	Update string
+}
+
+// Progress is the progress of a migration
+type Progress struct {
+	ID                string     `json:"id"`
+	Status            string     `json:"status"`
+	StatementsApplied bool       `json:"statementsApplied"`
+	BackfilledRows    int64      `json:"backfilledRows"`
+	BackfillProgress  float64    `json:"backfillProgress"` // ratio of the key range of the backfill walked so far
+	StartedAt         *time.Time `json:"startedAt,omitempty"`
+	UpdatedAt         *time.Time `json:"updatedAt,omitempty"`
+	CompletedAt       *time.Time `json:"completedAt,omitempty"`
+	Error             string     `json:"error,omitempty"`
+}
+
+// Migrator applies online migrations, keeping their state in a migrations table of its own
+type Migrator struct {
+	db              *sql.DB
+	migrationsTable string
+	migrations      []Migration
+	logger          logger.Logger
+	stats           stats.Stats
+
+	conf struct {
+		batchSize           config.ValueLoader[int64]
+		batchInterval       config.ValueLoader[time.Duration]
+		lockTimeout         config.ValueLoader[time.Duration]
+		retryInterval       config.ValueLoader[time.Duration]
+		progressLogInterval config.ValueLoader[time.Duration]
+	}
+}
+
+// New creates a new migrator for applying the given migrations, keeping their state in migrationsTable.
+// Each set of migrations requires a separate migrationsTable.
+func New(db *sql.DB, migrationsTable string, migrations []Migration, conf *config.Config, log logger.Logger, stat stats.Stats) *Migrator {
+	m := &Migrator{
+		db:              db,
+		migrationsTable: migrationsTable,
+		migrations:      migrations,
+		logger:          log.Child("online-migrator").Withn(logger.NewStringField("migrationsTable", migrationsTable)),
+		stats:           stat,
+	}
+	m.conf.batchSize = conf.GetReloadableInt64Var(1000, 1, "SQLMigrator.online.batchSize")
+	m.conf.batchInterval = conf.GetReloadableDurationVar(100, time.Millisecond, "SQLMigrator.online.batchInterval")
+	m.conf.lockTimeout = conf.GetReloadableDurationVar(5, time.Second, "SQLMigrator.online.lockTimeout")
+	m.conf.retryInterval = conf.GetReloadableDurationVar(1, time.Minute, "SQLMigrator.online.retryInterval")
+	m.conf.progressLogInterval = conf.GetReloadableDurationVar(1, time.Minute, "SQLMigrator.online.progressLogInterval")
+	return m
+}
+
+// Run applies the pending migrations, retrying until all of them are applied or the context is cancelled.
+// Only one migrator applies the migrations of a migrations table at a time, the rest of them wait for their turn.
+func (m *Migrator) Run(ctx context.Context) error {
+	for {
+		done, err := m.run(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			m.logger.Warnn("Applying online migrations, will retry", obskit.Error(err))
+		}
+		if done {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(m.conf.retryInterval.Load()):
+		}
+	}
+}
+
+// Progress returns the progress of all migrations
+func (m *Migrator) Progress(ctx context.Context) ([]Progress, error) {
+	if err := m.setup(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf(
+		`SELECT id, statements_applied, backfill_start, backfill_cursor, backfill_end, backfilled_rows, started_at, updated_at, completed_at, last_error FROM %q`,
+		m.migrationsTable,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("querying progress: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	progress := make(map[string]Progress)
+	for rows.Next() {
+		var (
+			p                                          Progress
+			backfillStart, backfillCursor, backfillEnd sql.NullInt64
+			startedAt, updatedAt                       time.Time
+			completedAt                                sql.NullTime
+		)
+		if err := rows.Scan(&p.ID, &p.StatementsApplied, &backfillStart, &backfillCursor, &backfillEnd, &p.BackfilledRows, &startedAt, &updatedAt, &completedAt, &p.Error); err != nil {
+			return nil, fmt.Errorf("scanning progress: %w", err)
+		}
+		p.StartedAt, p.UpdatedAt = &startedAt, &updatedAt
+		p.Status = StatusRunning
+		if p.Error != "" {
+			p.Status = StatusFailed
+		}
+		if completedAt.Valid {
+			p.Status = StatusCompleted
+			p.CompletedAt = &completedAt.Time
+			p.BackfillProgress = 1
+		} else if backfillCursor.Valid {
+			p.BackfillProgress = ratio(backfillStart.Int64, backfillCursor.Int64, backfillEnd.Int64)
+		}
+		progress[p.ID] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating progress: %w", err)
+	}
+
+	res := make([]Progress, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		p, ok := progress[migration.ID]
+		if !ok {
+			p = Progress{ID: migration.ID, Status: StatusPending}
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
+
+func (m *Migrator) setup(ctx context.Context) error {
+	if _, err := m.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q (
+		id TEXT PRIMARY KEY,
+		statements_applied BOOLEAN NOT NULL DEFAULT FALSE,
+		backfill_start BIGINT,
+		backfill_cursor BIGINT,
+		backfill_end BIGINT,
+		backfilled_rows BIGINT NOT NULL DEFAULT 0,
+		started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+		completed_at TIMESTAMP WITH TIME ZONE,
+		last_error TEXT NOT NULL DEFAULT '')`, m.migrationsTable)); err != nil {
+		return fmt.Errorf("creating migrations table %q: %w", m.migrationsTable, err)
+	}
+	return nil
+}
+
+// run applies the pending migrations, returning whether all of them are applied
+func (m *Migrator) run(ctx context.Context) (bool, error) {
+	if err := m.setup(ctx); err != nil {
+		return false, err
+	}
+	// session level locks and settings need a dedicated connection
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("getting connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, m.migrationsTable).Scan(&locked); err != nil {
+		return false, fmt.Errorf("acquiring advisory lock: %w", err)
+	}
+	if !locked {
+		m.logger.Infon("Online migrations are applied by another migrator, waiting for it")
+		return false, nil
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, m.migrationsTable)
+	}()
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(`SET lock_timeout = %d`, m.conf.lockTimeout.Load().Milliseconds())); err != nil {
+		return false, fmt.Errorf("setting lock timeout: %w", err)
+	}
+
+	for _, migration := range m.migrations {
+		if err := m.apply(ctx, conn, migration); err != nil {
+			if ctx.Err() == nil {
+				m.statsFor(migration, "sql_migrator_online_failures", stats.CountType).Increment()
+				if _, updateErr := m.db.ExecContext(ctx, fmt.Sprintf(`UPDATE %q SET last_error = $2, updated_at = NOW() WHERE id = $1`, m.migrationsTable), migration.ID, err.Error()); updateErr != nil {
+					err = errors.Join(err, updateErr)
+				}
+			}
+			return false, fmt.Errorf("applying online migration %q: %w", migration.ID, err)
+		}
+	}
+	return true, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %q (id) VALUES ($1) ON CONFLICT (id) DO NOTHING`, m.migrationsTable), migration.ID); err != nil {
+		return fmt.Errorf("inserting migration: %w", err)
+	}
+	var (
+		statementsApplied                          bool
+		backfillStart, backfillCursor, backfillEnd sql.NullInt64
+		completedAt                                sql.NullTime
+	)
+	if err := conn.QueryRowContext(ctx, fmt.Sprintf(`SELECT statements_applied, backfill_start, backfill_cursor, backfill_end, completed_at FROM %q WHERE id = $1`, m.migrationsTable), migration.ID).
+		Scan(&statementsApplied, &backfillStart, &backfillCursor, &backfillEnd, &completedAt); err != nil {
+		return fmt.Errorf("querying migration: %w", err)
+	}
+	if completedAt.Valid {
+		return nil
+	}
+	log := m.logger.Withn(logger.NewStringField("migration", migration.ID))
+	log.Infon("Applying online migration")
+	start := time.Now()
+
+	if !statementsApplied {
+		for _, statement := range migration.Statements {
+			if _, err := conn.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("executing statement %q: %w", statement, err)
+			}
+		}
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf(`UPDATE %q SET statements_applied = TRUE, updated_at = NOW() WHERE id = $1`, m.migrationsTable), migration.ID); err != nil {
+			return fmt.Errorf("marking statements as applied: %w", err)
+		}
+		log.Infon("Applied online migration statements", logger.NewIntField("statements", int64(len(migration.Statements))))
+	}
+
+	if b := migration.Backfill; b != nil {
+		if !backfillCursor.Valid {
+			// the range of keys is fixed when the backfill starts, rows written afterwards are complete already
+			if err := conn.QueryRowContext(ctx, fmt.Sprintf(`SELECT COALESCE(MIN(%[2]q), 0), COALESCE(MAX(%[2]q), 0) + 1 FROM %[1]q`, b.Table, b.Key)).
+				Scan(&backfillStart.Int64, &backfillEnd.Int64); err != nil {
+				return fmt.Errorf("querying backfill key range: %w", err)
+			}
+			backfillCursor.Int64 = backfillStart.Int64
+			if _, err := conn.ExecContext(ctx, fmt.Sprintf(`UPDATE %q SET backfill_start = $2, backfill_cursor = $2, backfill_end = $3, updated_at = NOW() WHERE id = $1`, m.migrationsTable),
+				migration.ID, backfillStart.Int64, backfillEnd.Int64); err != nil {
+				return fmt.Errorf("saving backfill key range: %w", err)
+			}
+		}
+		if err := m.backfill(ctx, conn, migration, backfillStart.Int64, backfillCursor.Int64, backfillEnd.Int64, log); err != nil {
+			return fmt.Errorf("backfilling %q: %w", b.Table, err)
+		}
+	}
+
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(`UPDATE %q SET completed_at = NOW(), updated_at = NOW(), last_error = '' WHERE id = $1`, m.migrationsTable), migration.ID); err != nil {
+		return fmt.Errorf("marking migration as completed: %w", err)
+	}
+	m.statsFor(migration, "sql_migrator_online_progress", stats.GaugeType).Gauge(1.0)
+	log.Infon("Applied online migration", logger.NewDurationField("duration", time.Since(start)))
+	return nil
+}
+
+// backfill walks the key range of the backfill in batches, saving its cursor along with every batch,
+// so that it resumes where it left off after a restart
+func (m *Migrator) backfill(ctx context.Context, conn *sql.Conn, migration Migration, start, cursor, end int64, log logger.Logger) error {
+	progressStat := m.statsFor(migration, "sql_migrator_online_progress", stats.GaugeType)
+	rowsStat := m.statsFor(migration, "sql_migrator_online_backfilled_rows", stats.CountType)
+	lastLog := time.Now()
+	for cursor < end {
+		next := min(cursor+m.conf.batchSize.Load(), end)
+		var rows int64
+		if err := func() error {
+			tx, err := conn.BeginTx(ctx, nil)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = tx.Rollback() }()
+			res, err := tx.ExecContext(ctx, migration.Backfill.Update, cursor, next)
+			if err != nil {
+				return fmt.Errorf("updating keys in [%d, %d): %w", cursor, next, err)
+			}
+			if rows, err = res.RowsAffected(); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %q SET backfill_cursor = $2, backfilled_rows = backfilled_rows + $3, updated_at = NOW() WHERE id = $1`, m.migrationsTable),
+				migration.ID, next, rows); err != nil {
+				return fmt.Errorf("saving backfill cursor: %w", err)
+			}
+			return tx.Commit()
+		}(); err != nil {
+			return err
+		}
+		cursor = next
+		rowsStat.Count(int(rows))
+		progressStat.Gauge(ratio(start, cursor, end))
+		if time.Since(lastLog) > m.conf.progressLogInterval.Load() {
+			lastLog = time.Now()
+			log.Infon("Backfilling online migration",
+				logger.NewIntField("cursor", cursor),
+				logger.NewIntField("end", end),
+				logger.NewFloatField("progress", ratio(start, cursor, end)),
+			)
+		}
+		if cursor < end {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(m.conf.batchInterval.Load()):
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) statsFor(migration Migration, name, statType string) stats.Measurement {
+	return m.stats.NewTaggedStat(name, statType, stats.Tags{
+		"migrationsTable": m.migrationsTable,
+		"migration":       migration.ID,
+	})
+}
+
+// ratio returns the ratio of the range [start, end) walked up to cursor
+func ratio(start, cursor, end int64) float64 {
+	if end <= start {
+		return 1
+	}
+	return float64(cursor-start) / float64(end-start)
+}
@@ -0,0 +1,137 @@
+package online_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
+
+	"github.com/rudderlabs/rudder-server/services/sql-migrator/online"
+)
+
+func TestMigrator(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	postgresContainer, err := postgres.Setup(pool, t)
+	require.NoError(t, err)
+	db := postgresContainer.DB
+	ctx := context.Background()
+
+	_, err = db.Exec(`CREATE TABLE events (id BIGSERIAL PRIMARY KEY, name TEXT NOT NULL)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO events (name) SELECT 'event-' || i FROM generate_series(1, 25) i`)
+	require.NoError(t, err)
+
+	c := config.New()
+	c.Set("SQLMigrator.online.batchSize", 10)
+	c.Set("SQLMigrator.online.batchInterval", "1ms")
+	c.Set("SQLMigrator.online.retryInterval", "1ms")
+
+	migrations := []online.Migration{
+		{
+			ID: "events_upper_name",
+			Statements: []string{
+				`ALTER TABLE events ADD COLUMN IF NOT EXISTS upper_name TEXT`,
+				`CREATE INDEX CONCURRENTLY IF NOT EXISTS events_upper_name_index ON events (upper_name)`,
+			},
+			Backfill: &online.Backfill{
+				Table:  "events",
+				Key:    "id",
+				Update: `UPDATE events SET upper_name = UPPER(name) WHERE id >= $1 AND id < $2`,
+			},
+		},
+	}
+
+	t.Run("pending", func(t *testing.T) {
+		m := online.New(db, "online_migrations", migrations, c, logger.NOP, stats.NOP)
+		progress, err := m.Progress(ctx)
+		require.NoError(t, err)
+		require.Len(t, progress, 1)
+		require.Equal(t, online.StatusPending, progress[0].Status)
+	})
+
+	t.Run("applies migrations", func(t *testing.T) {
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+		m := online.New(db, "online_migrations", migrations, c, logger.NOP, statsStore)
+		require.NoError(t, m.Run(ctx))
+
+		var missing int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM events WHERE upper_name IS DISTINCT FROM UPPER(name)`).Scan(&missing))
+		require.Zero(t, missing)
+		var indexValid bool
+		require.NoError(t, db.QueryRow(`SELECT indisvalid FROM pg_index WHERE indexrelid = 'events_upper_name_index'::regclass`).Scan(&indexValid))
+		require.True(t, indexValid)
+
+		progress, err := m.Progress(ctx)
+		require.NoError(t, err)
+		require.Len(t, progress, 1)
+		require.Equal(t, online.StatusCompleted, progress[0].Status)
+		require.True(t, progress[0].StatementsApplied)
+		require.EqualValues(t, 25, progress[0].BackfilledRows)
+		require.EqualValues(t, 1, progress[0].BackfillProgress)
+		require.NotNil(t, progress[0].CompletedAt)
+
+		tags := stats.Tags{"migrationsTable": "online_migrations", "migration": "events_upper_name"}
+		require.EqualValues(t, 25, statsStore.Get("sql_migrator_online_backfilled_rows", tags).LastValue())
+		require.EqualValues(t, 1, statsStore.Get("sql_migrator_online_progress", tags).LastValue())
+	})
+
+	t.Run("completed migrations are not applied again", func(t *testing.T) {
+		_, err := db.Exec(`UPDATE events SET upper_name = NULL WHERE id = 1`)
+		require.NoError(t, err)
+		m := online.New(db, "online_migrations", migrations, c, logger.NOP, stats.NOP)
+		require.NoError(t, m.Run(ctx))
+		var upperName *string
+		require.NoError(t, db.QueryRow(`SELECT upper_name FROM events WHERE id = 1`).Scan(&upperName))
+		require.Nil(t, upperName)
+	})
+
+	t.Run("resumes failed migrations", func(t *testing.T) {
+		failing := append(migrations, online.Migration{
+			ID: "events_lower_name",
+			Statements: []string{
+				`ALTER TABLE events ADD COLUMN IF NOT EXISTS lower_name TEXT`,
+			},
+			Backfill: &online.Backfill{
+				Table: "events",
+				Key:   "id",
+				// fails for the second batch
+				Update: `UPDATE events SET lower_name = CASE WHEN id < 11 THEN LOWER(name) ELSE (id/0)::text END WHERE id >= $1 AND id < $2`,
+			},
+		})
+		runCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		m := online.New(db, "online_migrations", failing, c, logger.NOP, stats.NOP)
+		require.NoError(t, m.Run(runCtx), "failures should be retried until the context is cancelled")
+
+		progress, err := m.Progress(ctx)
+		require.NoError(t, err)
+		require.Len(t, progress, 2)
+		require.Equal(t, online.StatusCompleted, progress[0].Status)
+		require.Equal(t, online.StatusFailed, progress[1].Status)
+		require.Contains(t, progress[1].Error, "division by zero")
+		require.EqualValues(t, 10, progress[1].BackfilledRows)
+		require.InDelta(t, 0.4, progress[1].BackfillProgress, 0.001)
+
+		failing[1].Backfill.Update = `UPDATE events SET lower_name = LOWER(name) WHERE id >= $1 AND id < $2 AND id >= 11`
+		m = online.New(db, "online_migrations", failing, c, logger.NOP, stats.NOP)
+		require.NoError(t, m.Run(ctx))
+		progress, err = m.Progress(ctx)
+		require.NoError(t, err)
+		require.Equal(t, online.StatusCompleted, progress[1].Status)
+		require.Empty(t, progress[1].Error)
+		require.EqualValues(t, 25, progress[1].BackfilledRows, "backfill should resume from its last batch")
+		var missing int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM events WHERE lower_name IS DISTINCT FROM LOWER(name)`).Scan(&missing))
+		require.Zero(t, missing)
+	})
+}
@@ -24,16 +24,27 @@ type Config struct {
 	Credentials     string              `json:"credentials"`
 	ProjectId       string              `json:"projectId"`
 	EventToTopicMap []map[string]string `json:"eventToTopicMap"`
-	TestConfig      TestConfig          `json:"testConfig"`
+	// EnableMessageOrdering publishes the messages of every user in order, using their userId as the ordering key.
+	// Subscriptions need to have message ordering enabled as well for messages to be delivered in order.
+	EnableMessageOrdering bool       `json:"enableMessageOrdering"`
+	TestConfig            TestConfig `json:"testConfig"`
 }
 
 type TestConfig struct {
 	Endpoint string `json:"endpoint"`
 }
 type PubsubClient struct {
-	pbs      *pubsub.Client
-	topicMap map[string]*pubsub.Topic
-	opts     common.Opts
+	pbs             *pubsub.Client
+	topicMap        map[string]*pubsub.Topic
+	opts            common.Opts
+	orderingEnabled bool
+}
+
+// deliveryReceipt is returned as the response of successfully published messages, ending up in the job status
+type deliveryReceipt struct {
+	MessageID   string `json:"messageId"`
+	TopicID     string `json:"topicId"`
+	OrderingKey string `json:"orderingKey,omitempty"`
 }
 
 var pkgLogger logger.Logger
@@ -84,9 +95,15 @@ func NewProducer(destination *backendconfig.DestinationT, o common.Opts) (*Googl
 	for _, s := range config.EventToTopicMap {
 		topic := client.Topic(s["to"])
 		topic.PublishSettings.DelayThreshold = 0
+		topic.EnableMessageOrdering = config.EnableMessageOrdering
 		topicMap[s["to"]] = topic
 	}
-	return &GooglePubSubProducer{client: &PubsubClient{client, topicMap, o}}, nil
+	return &GooglePubSubProducer{client: &PubsubClient{
+		pbs:             client,
+		topicMap:        topicMap,
+		opts:            o,
+		orderingEnabled: config.EnableMessageOrdering,
+	}}, nil
 }
 
 func (producer *GooglePubSubProducer) Produce(jsonData json.RawMessage, _ interface{}) (statusCode int, respStatus, responseMessage string) {
@@ -143,33 +160,26 @@ func (producer *GooglePubSubProducer) Produce(jsonData json.RawMessage, _ interf
 		return statusCode, respStatus, responseMessage
 	}
 
-	attributes := parsedJSON.Get("attributes").Map()
-	var result *pubsub.PublishResult
-
-	if len(attributes) != 0 {
-		attributesMap := make(map[string]string)
+	msg := &pubsub.Message{Data: value}
+	if attributes := parsedJSON.Get("attributes").Map(); len(attributes) != 0 {
+		msg.Attributes = make(map[string]string, len(attributes))
 		for k, v := range attributes {
-			attributesMap[k] = v.Str
+			msg.Attributes[k] = v.Str
 		}
-		result = topic.Publish(
-			ctx,
-			&pubsub.Message{
-				Data:       value,
-				Attributes: attributesMap,
-			},
-		)
-	} else {
-		result = topic.Publish(
-			ctx,
-			&pubsub.Message{
-				Data: value,
-			},
-		)
 	}
+	if pbs.orderingEnabled {
+		msg.OrderingKey = parsedJSON.Get("userId").String()
+	}
+	result := topic.Publish(ctx, msg)
 
 	serverID, err := result.Get(ctx)
 
 	if err != nil {
+		if msg.OrderingKey != "" {
+			// publishing for an ordering key is paused after a failure, until resumed.
+			// The router retries the failed job before any later job of the same user, so ordering is preserved
+			topic.ResumePublish(msg.OrderingKey)
+		}
 		if ctx.Err() != nil && errors.Is(err, context.DeadlineExceeded) {
 			statusCode = 504
 		} else {
@@ -178,9 +188,9 @@ func (producer *GooglePubSubProducer) Produce(jsonData json.RawMessage, _ interf
 		responseMessage = "[GooglePubSub] error :: Failed to publish:" + err.Error()
 		respStatus = "Failure"
 		return statusCode, respStatus, responseMessage
-	} else {
-		responseMessage = "Message publish with serverID" + serverID
 	}
+	receipt, _ := json.Marshal(deliveryReceipt{MessageID: serverID, TopicID: topicIdString, OrderingKey: msg.OrderingKey})
+	responseMessage = string(receipt)
 	respStatus = "Success"
 	return 200, respStatus, responseMessage
 }
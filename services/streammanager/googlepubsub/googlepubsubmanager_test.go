@@ -10,6 +10,7 @@ import (
 	"github.com/ory/dockertest/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
 	"google.golang.org/api/option"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -63,6 +64,36 @@ func Test_Timeout(t *testing.T) {
 	}
 }
 
+func TestOrderedPublish(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	pool.MaxWait = 2 * time.Minute
+
+	testConfig, err := SetupTestGooglePubSub(pool, t)
+	require.NoError(t, err)
+
+	config := map[string]interface{}{
+		"ProjectId": projectId,
+		"EventToTopicMap": []map[string]string{
+			{"to": topic},
+		},
+		"EnableMessageOrdering": true,
+		"TestConfig":            testConfig,
+	}
+	destination := backendconfig.DestinationT{Config: config}
+
+	producer, err := NewProducer(&destination, common.Opts{Timeout: 10 * time.Second})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = producer.Close() })
+
+	statusCode, respStatus, responseMessage := producer.Produce([]byte(`{"topicId": "my-topic", "userId": "user-1", "message": "{}"}`), nil)
+	require.Equal(t, 200, statusCode)
+	require.Equal(t, "Success", respStatus)
+	require.NotEmpty(t, gjson.Get(responseMessage, "messageId").String(), "the response should be a delivery receipt")
+	require.Equal(t, topic, gjson.Get(responseMessage, "topicId").String())
+	require.Equal(t, "user-1", gjson.Get(responseMessage, "orderingKey").String())
+}
+
 func TestUnsupportedCredentials(t *testing.T) {
 	config := map[string]interface{}{
 		"ProjectId": projectId,
@@ -54,6 +54,8 @@ type configuration struct {
 	SSHHost string
 	SSHPort string
 	SSHUser string
+
+	routingConfig
 }
 
 func (c *configuration) validate() error {
@@ -77,9 +79,91 @@ func (c *configuration) validate() error {
 			return fmt.Errorf("invalid ssh port: %w", err)
 		}
 	}
+	return c.routingConfig.validate()
+}
+
+const (
+	partitionKeyUserID      = "userId"
+	partitionKeyAnonymousID = "anonymousId"
+)
+
+// routingConfig is the configuration of the topics and keys of the messages, common to all the kafka destinations
+type routingConfig struct {
+	EventTypeToTopicMap []map[string]string
+	EventToTopicMap     []map[string]string
+	PartitionKey        string
+}
+
+func (c *routingConfig) validate() error {
+	switch c.PartitionKey {
+	case "", partitionKeyUserID, partitionKeyAnonymousID:
+	default:
+		return fmt.Errorf("invalid partition key %q, should be one of %q, %q", c.PartitionKey, partitionKeyUserID, partitionKeyAnonymousID)
+	}
 	return nil
 }
 
+// routing decides the topic and the key of the messages, based on the destination configuration.
+// Its zero value sends messages to the topic and with the key provided in the payload.
+type routing struct {
+	eventTopics     map[string]string // event name -> topic, for track events
+	eventTypeTopics map[string]string // event type -> topic
+	partitionKey    string
+}
+
+func newRouting(c *routingConfig) routing {
+	toMap := func(mappings []map[string]string) map[string]string {
+		if len(mappings) == 0 {
+			return nil
+		}
+		m := make(map[string]string, len(mappings))
+		for _, mapping := range mappings {
+			if mapping["from"] != "" && mapping["to"] != "" {
+				m[strings.ToLower(mapping["from"])] = mapping["to"]
+			}
+		}
+		return m
+	}
+	return routing{
+		eventTopics:     toMap(c.EventToTopicMap),
+		eventTypeTopics: toMap(c.EventTypeToTopicMap),
+		partitionKey:    c.PartitionKey,
+	}
+}
+
+// topic returns the topic mapped to the event name or, failing that, to the event type of the message.
+// Otherwise it falls back to the topic of the payload, or to the default topic of the destination.
+func (r routing) topic(message interface{}, payloadTopic, defaultTopic string) string {
+	if msg, ok := message.(map[string]interface{}); ok {
+		eventType, _ := msg["type"].(string)
+		if event, ok := msg["event"].(string); ok && strings.EqualFold(eventType, "track") {
+			if topic, ok := r.eventTopics[strings.ToLower(event)]; ok {
+				return topic
+			}
+		}
+		if topic, ok := r.eventTypeTopics[strings.ToLower(eventType)]; ok {
+			return topic
+		}
+	}
+	if payloadTopic != "" {
+		return payloadTopic
+	}
+	return defaultTopic
+}
+
+// key returns the partition key of the message, i.e. its userId or anonymousId depending on the configured
+// partition key, falling back to the userId of the payload
+func (r routing) key(message interface{}, payloadUserID string) string {
+	if r.partitionKey != "" {
+		if msg, ok := message.(map[string]interface{}); ok {
+			if key, ok := msg[r.partitionKey].(string); ok && key != "" {
+				return key
+			}
+		}
+	}
+	return payloadUserID
+}
+
 // azureEventHubConfig is the config that is required to send data to Azure Event Hub.
 // Make sure to select at least the Standard tier since the Basic tier does not support Kafka.
 type azureEventHubConfig struct {
@@ -89,6 +173,8 @@ type azureEventHubConfig struct {
 	BootstrapServer string
 	// EventHubsConnectionString starts with "Endpoint=sb://" and contains the SharedAccessKey
 	EventHubsConnectionString string
+
+	routingConfig
 }
 
 func (c *azureEventHubConfig) validate() error {
@@ -101,7 +187,7 @@ func (c *azureEventHubConfig) validate() error {
 	if c.EventHubsConnectionString == "" {
 		return fmt.Errorf("connection string cannot be empty")
 	}
-	return nil
+	return c.routingConfig.validate()
 }
 
 // confluentCloudConfig is the config that is required to send data to Confluent Cloud
@@ -110,6 +196,8 @@ type confluentCloudConfig struct {
 	BootstrapServer string
 	APIKey          string
 	APISecret       string
+
+	routingConfig
 }
 
 func (c *confluentCloudConfig) validate() error {
@@ -125,7 +213,7 @@ func (c *confluentCloudConfig) validate() error {
 	if c.APISecret == "" {
 		return fmt.Errorf("API secret cannot be empty")
 	}
-	return nil
+	return c.routingConfig.validate()
 }
 
 type publisher interface {
@@ -138,6 +226,8 @@ type producerManager interface {
 	getTimeout() time.Duration
 	getEmbedAvroSchemaID() bool
 	getCodecs() map[string]*goavro.Codec
+	getRouting() routing
+	getDeliveryReceipts() bool
 }
 
 type internalProducer interface {
@@ -150,6 +240,37 @@ type ProducerManager struct {
 	timeout           time.Duration
 	embedAvroSchemaID bool
 	codecs            map[string]*goavro.Codec
+	routing           routing
+	// deliveryReceipts responds with the receipts of the delivered messages, which end up in the job statuses
+	deliveryReceipts bool
+}
+
+// deliveryReceipt is the receipt of a message acknowledged by all the in-sync replicas of its partition
+type deliveryReceipt struct {
+	Topic        string    `json:"topic"`
+	PartitionKey string    `json:"partitionKey,omitempty"`
+	DeliveredAt  time.Time `json:"deliveredAt"`
+}
+
+// receiptsResponse returns the receipts of the delivered messages as a response
+func receiptsResponse(deliveredAt time.Time, msgs ...client.Message) string {
+	receipts := make([]deliveryReceipt, len(msgs))
+	for i := range msgs {
+		receipts[i] = deliveryReceipt{Topic: msgs[i].Topic, PartitionKey: string(msgs[i].Key), DeliveredAt: deliveredAt}
+	}
+	var (
+		response []byte
+		err      error
+	)
+	if len(receipts) == 1 {
+		response, err = json.Marshal(receipts[0])
+	} else {
+		response, err = json.Marshal(receipts)
+	}
+	if err != nil {
+		return "Message delivered, could not marshal the delivery receipt: " + err.Error()
+	}
+	return string(response)
 }
 
 func (p *ProducerManager) getTimeout() time.Duration {
@@ -161,6 +282,8 @@ func (p *ProducerManager) getTimeout() time.Duration {
 
 func (p *ProducerManager) getCodecs() map[string]*goavro.Codec { return p.codecs }
 func (p *ProducerManager) getEmbedAvroSchemaID() bool          { return p.embedAvroSchemaID }
+func (p *ProducerManager) getRouting() routing                 { return p.routing }
+func (p *ProducerManager) getDeliveryReceipts() bool           { return p.deliveryReceipts }
 
 type logger interface {
 	Error(args ...interface{})
@@ -390,10 +513,13 @@ func NewProducer(destination *backendconfig.DestinationT, o common.Opts) (*Produ
 		timeout:           o.Timeout,
 		embedAvroSchemaID: embedAvroSchemaID,
 		codecs:            codecs,
+		routing:           newRouting(&destConfig.routingConfig),
 	}, nil
 }
 
-// NewProducerForAzureEventHubs creates a producer for Azure event hub based on destination config
+// NewProducerForAzureEventHubs creates a producer for Azure event hub based on destination config.
+// Messages are keyed by their partition key (see routingConfig), so that the messages of a user land on the same
+// partition and are delivered in order, and their delivery receipts are returned as responses.
 func NewProducerForAzureEventHubs(destination *backendconfig.DestinationT, o common.Opts) (*ProducerManager, error) {
 	start := now()
 	defer func() { kafkaStats.creationTimeAzureEventHubs.SendTiming(since(start)) }()
@@ -438,7 +564,12 @@ func NewProducerForAzureEventHubs(destination *backendconfig.DestinationT, o com
 	if err != nil {
 		return nil, err
 	}
-	return &ProducerManager{p: p, timeout: o.Timeout}, nil
+	return &ProducerManager{
+		p:                p,
+		timeout:          o.Timeout,
+		routing:          newRouting(&destConfig.routingConfig),
+		deliveryReceipts: true,
+	}, nil
 }
 
 // NewProducerForConfluentCloud creates a producer for Confluent cloud based on destination config
@@ -487,7 +618,7 @@ func NewProducerForConfluentCloud(destination *backendconfig.DestinationT, o com
 	if err != nil {
 		return nil, err
 	}
-	return &ProducerManager{p: p, timeout: o.Timeout}, nil
+	return &ProducerManager{p: p, timeout: o.Timeout, routing: newRouting(&destConfig.routingConfig)}, nil
 }
 
 func prepareMessage(topic, key string, message []byte, timestamp time.Time) client.Message {
@@ -567,20 +698,19 @@ func prepareBatchOfMessages(batch []map[string]interface{}, timestamp time.Time,
 		pkgLogger.Error(err.Error())
 	}
 
+	r := p.getRouting()
 	for i, data := range batch {
-		topic, ok := data["topic"].(string)
-		if !ok || topic == "" {
-			topic = defaultTopic
-		}
-
+		payloadTopic, _ := data["topic"].(string)
 		message, ok := data["message"]
+		topic := r.topic(message, payloadTopic, defaultTopic)
 		if !ok {
 			kafkaStats.missingMessage.Increment()
 			addErrorSample("batch from topic %s is missing the message attribute", topic)
 			continue
 		}
 		userID, ok := data["userId"].(string)
-		if !ok && !allowReqsWithoutUserIDAndAnonymousID.Load() {
+		userID = r.key(message, userID)
+		if !ok && userID == "" && !allowReqsWithoutUserIDAndAnonymousID.Load() {
 			kafkaStats.missingUserID.Increment()
 			addErrorSample("batch from topic %s is missing the userId attribute", topic)
 			continue
@@ -701,6 +831,9 @@ func sendBatchedMessage(ctx context.Context, jsonData json.RawMessage, p produce
 	kafkaStats.batchSize.Observe(float64(len(batchOfMessages)))
 
 	returnMessage := "Kafka: Message delivered in batch"
+	if p.getDeliveryReceipts() {
+		returnMessage = receiptsResponse(time.Now(), batchOfMessages...)
+	}
 	return 200, returnMessage, returnMessage
 }
 
@@ -717,7 +850,8 @@ func sendMessage(ctx context.Context, jsonData json.RawMessage, p producerManage
 	}
 
 	timestamp := time.Now()
-	userID := parsedJSON.Get("userId").String()
+	r := p.getRouting()
+	userID := r.key(messageValue, parsedJSON.Get("userId").String())
 	codecs := p.getCodecs()
 	if len(codecs) > 0 {
 		schemaId := parsedJSON.Get("schemaId").String()
@@ -738,12 +872,7 @@ func sendMessage(ctx context.Context, jsonData json.RawMessage, p producerManage
 		}
 	}
 
-	topic := parsedJSON.Get("topic").String()
-
-	if topic == "" {
-		topic = defaultTopic
-	}
-
+	topic := r.topic(messageValue, parsedJSON.Get("topic").String(), defaultTopic)
 	message := prepareMessage(topic, userID, value, timestamp)
 
 	if err = publish(ctx, p, message); err != nil {
@@ -751,6 +880,9 @@ func sendMessage(ctx context.Context, jsonData json.RawMessage, p producerManage
 	}
 
 	returnMessage := fmt.Sprintf("Message delivered to topic: %s", topic)
+	if p.getDeliveryReceipts() {
+		returnMessage = receiptsResponse(time.Now(), message)
+	}
 	return 200, returnMessage, returnMessage
 }
 
@@ -23,6 +23,7 @@ import (
 	"github.com/ory/dockertest/v3"
 	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
 	"go.uber.org/mock/gomock"
 
 	"github.com/rudderlabs/rudder-go-kit/config"
@@ -104,6 +105,12 @@ func TestNewProducer(t *testing.T) {
 			},
 			"invalid configuration: invalid port"),
 		)
+		t.Run("invalid partition key", buildTest(
+			withRequired(map[string]interface{}{
+				"partitionKey": "messageId",
+			}),
+			`invalid configuration: invalid partition key "messageId"`),
+		)
 		t.Run("invalid schema", buildTest(
 			withRequired(map[string]interface{}{
 				"convertToAvro": true,
@@ -168,14 +175,22 @@ func TestNewProducer(t *testing.T) {
 		require.NoError(t, err)
 
 		destConfig := map[string]interface{}{
-			"topic":    "some-topic",
-			"hostname": kafkaHost,
-			"port":     kafkaPort,
+			"topic":               "some-topic",
+			"hostname":            kafkaHost,
+			"port":                kafkaPort,
+			"eventToTopicMap":     []map[string]string{{"from": "Order Completed", "to": "orders"}},
+			"eventTypeToTopicMap": []map[string]string{{"from": "identify", "to": "identities"}},
+			"partitionKey":        partitionKeyAnonymousID,
 		}
 		dest := backendconfig.DestinationT{Config: destConfig}
 		p, err := NewProducer(&dest, common.Opts{})
 		require.NotNilf(t, p, "expected producer to be created, got nil: %v", err)
 		require.NoError(t, err)
+		require.Equal(t, routing{
+			eventTopics:     map[string]string{"order completed": "orders"},
+			eventTypeTopics: map[string]string{"identify": "identities"},
+			partitionKey:    partitionKeyAnonymousID,
+		}, p.routing)
 
 		require.Eventually(t, func() bool {
 			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -468,6 +483,21 @@ func TestNewProducerForAzureEventHubs(t *testing.T) {
 			require.Nil(t, p)
 			require.ErrorContains(t, err, "invalid configuration: connection string cannot be empty")
 		})
+		t.Run("invalid partition key", func(t *testing.T) {
+			kafkaStats.creationTimeAzureEventHubs = getMockedTimer(t, gomock.NewController(t), false)
+
+			destConfig := map[string]interface{}{
+				"topic":                     "some-topic",
+				"bootstrapServer":           "some-server",
+				"eventHubsConnectionString": "some-connection-string",
+				"partitionKey":              "messageId",
+			}
+			dest := backendconfig.DestinationT{Config: destConfig}
+
+			p, err := NewProducerForAzureEventHubs(&dest, common.Opts{})
+			require.Nil(t, p)
+			require.ErrorContains(t, err, `invalid configuration: invalid partition key "messageId"`)
+		})
 	})
 
 	t.Run("ok", func(t *testing.T) {
@@ -485,12 +515,18 @@ func TestNewProducerForAzureEventHubs(t *testing.T) {
 			"topic":                     azureEventHubName,
 			"bootstrapServer":           "bad-host," + kafkaHost + "," + kafkaHost,
 			"eventHubsConnectionString": azureEventHubsConnString,
+			"eventTypeToTopicMap":       []map[string]string{{"from": "identify", "to": azureEventHubName}},
+			"partitionKey":              partitionKeyAnonymousID,
 		}
 		dest := backendconfig.DestinationT{Config: destConfig}
 
 		p, err := NewProducerForAzureEventHubs(&dest, common.Opts{})
 		require.NotNil(t, p)
 		require.NoError(t, err)
+		require.Equal(t, routing{
+			eventTypeTopics: map[string]string{"identify": azureEventHubName},
+			partitionKey:    partitionKeyAnonymousID,
+		}, p.routing)
 
 		require.Eventually(t, func() bool {
 			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -550,6 +586,22 @@ func TestProducerForConfluentCloud(t *testing.T) {
 			require.Nil(t, p)
 			require.ErrorContains(t, err, "invalid configuration: API secret cannot be empty")
 		})
+		t.Run("invalid partition key", func(t *testing.T) {
+			kafkaStats.creationTimeConfluentCloud = getMockedTimer(t, gomock.NewController(t), false)
+
+			destConfig := map[string]interface{}{
+				"topic":           "some-topic",
+				"bootstrapServer": "some-server",
+				"apiKey":          "secret-key",
+				"apiSecret":       "secret",
+				"partitionKey":    "messageId",
+			}
+			dest := backendconfig.DestinationT{Config: destConfig}
+
+			p, err := NewProducerForConfluentCloud(&dest, common.Opts{})
+			require.Nil(t, p)
+			require.ErrorContains(t, err, `invalid configuration: invalid partition key "messageId"`)
+		})
 	})
 
 	t.Run("ok", func(t *testing.T) {
@@ -568,12 +620,18 @@ func TestProducerForConfluentCloud(t *testing.T) {
 			"bootstrapServer": "bad-host," + kafkaHost + "," + kafkaHost,
 			"apiKey":          confluentCloudKey,
 			"apiSecret":       confluentCloudSecret,
+			"eventToTopicMap": []map[string]string{{"from": "Order Completed", "to": "TestProducerForConfluentCloud_OK"}},
+			"partitionKey":    partitionKeyUserID,
 		}
 		dest := backendconfig.DestinationT{Config: destConfig}
 
 		p, err := NewProducerForConfluentCloud(&dest, common.Opts{})
 		require.NoError(t, err)
 		require.NotNil(t, p)
+		require.Equal(t, routing{
+			eventTopics:  map[string]string{"order completed": "TestProducerForConfluentCloud_OK"},
+			partitionKey: partitionKeyUserID,
+		}, p.routing)
 
 		require.Eventually(t, func() bool {
 			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -651,6 +709,36 @@ func TestPrepareBatchOfMessages(t *testing.T) {
 		}, batch)
 	})
 
+	t.Run("with anonymous id as partition key", func(t *testing.T) {
+		mockPrepareBatchTime.EXPECT().SendTiming(sinceDuration).Times(1)
+
+		now := time.Now()
+		data := []map[string]interface{}{
+			{"message": map[string]interface{}{"type": "track", "anonymousId": "anon-1"}, "userId": "123", "topic": "some-topic"},
+			{"message": map[string]interface{}{"type": "identify", "anonymousId": "anon-2"}},
+		}
+		pm := &pmMockErr{routing: newRouting(&routingConfig{
+			EventTypeToTopicMap: []map[string]string{{"from": "identify", "to": "identities"}},
+			PartitionKey:        partitionKeyAnonymousID,
+		})}
+		batch, err := prepareBatchOfMessages(data, now, pm, "default-topic")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []client.Message{
+			{
+				Key:       []byte("anon-1"),
+				Value:     []byte(`{"anonymousId":"anon-1","type":"track"}`),
+				Topic:     "some-topic",
+				Timestamp: now,
+			},
+			{
+				Key:       []byte("anon-2"),
+				Value:     []byte(`{"anonymousId":"anon-2","type":"identify"}`),
+				Topic:     "identities",
+				Timestamp: now,
+			},
+		}, batch)
+	})
+
 	t.Run("with empty user id and allow empty", func(t *testing.T) {
 		mockSkippedDueToMessage.EXPECT().Increment().Times(1)
 		mockPrepareBatchTime.EXPECT().SendTiming(sinceDuration).Times(1)
@@ -900,6 +988,30 @@ func TestSendBatchedMessage(t *testing.T) {
 		require.InDelta(t, time.Now().Unix(), p.calls[0][0].Timestamp.Unix(), 1)
 	})
 
+	t.Run("delivery receipts", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		kafkaStats.publishTime = getMockedTimer(t, ctrl, false)
+		kafkaStats.prepareBatchTime = getMockedTimer(t, ctrl, false)
+		kafkaStats.batchSize = mock_stats.NewMockMeasurement(ctrl)
+		kafkaStats.batchSize.(*mock_stats.MockMeasurement).EXPECT().Observe(2.0).Times(1)
+
+		p := &pMockErr{error: nil}
+		pm := &ProducerManager{p: p, deliveryReceipts: true}
+		sc, res, _ := sendBatchedMessage(
+			context.Background(),
+			json.RawMessage(`[{"message":"ciao","userId":"123","topic":"some-topic"},{"message":"hello","userId":"456"}]`),
+			pm,
+			"default-topic",
+		)
+		require.Equal(t, 200, sc)
+		receipts := gjson.Parse(res).Array()
+		require.Len(t, receipts, 2)
+		require.Equal(t, "some-topic", receipts[0].Get("topic").String())
+		require.Equal(t, "123", receipts[0].Get("partitionKey").String())
+		require.Equal(t, "default-topic", receipts[1].Get("topic").String())
+		require.Equal(t, "456", receipts[1].Get("partitionKey").String())
+	})
+
 	t.Run("default topic test", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		kafkaStats.publishTime = getMockedTimer(t, ctrl, false)
@@ -1055,6 +1167,69 @@ func TestSendMessage(t *testing.T) {
 		require.InDelta(t, time.Now().Unix(), p.calls[0][0].Timestamp.Unix(), 1)
 	})
 
+	t.Run("delivery receipts", func(t *testing.T) {
+		kafkaStats.publishTime = getMockedTimer(t, gomock.NewController(t), false)
+
+		p := &pMockErr{error: nil}
+		pm := &ProducerManager{p: p, deliveryReceipts: true, routing: newRouting(&routingConfig{
+			PartitionKey: partitionKeyAnonymousID,
+		})}
+		sc, res, _ := sendMessage(
+			context.Background(),
+			json.RawMessage(`{"message":{"type":"track","userId":"123","anonymousId":"anon-1"},"userId":"123","topic":"some-topic"}`),
+			pm,
+			"default-topic",
+		)
+		require.Equal(t, 200, sc)
+		require.Equal(t, "some-topic", gjson.Get(res, "topic").String())
+		require.Equal(t, "anon-1", gjson.Get(res, "partitionKey").String())
+		require.InDelta(t, time.Now().Unix(), gjson.Get(res, "deliveredAt").Time().Unix(), 1)
+		require.Equal(t, []byte("anon-1"), p.calls[0][0].Key, "messages should be keyed by their partition key for being delivered in order")
+	})
+
+	t.Run("topic mapping and partition key", func(t *testing.T) {
+		kafkaStats.publishTime = getMockedTimer(t, gomock.NewController(t), false)
+
+		p := &pMockErr{error: nil}
+		pm := &ProducerManager{p: p, routing: newRouting(&routingConfig{
+			EventToTopicMap:     []map[string]string{{"from": "Order Completed", "to": "orders"}},
+			EventTypeToTopicMap: []map[string]string{{"from": "identify", "to": "identities"}},
+			PartitionKey:        partitionKeyAnonymousID,
+		})}
+		sc, res, _ := sendMessage(
+			context.Background(),
+			json.RawMessage(`{"message":{"type":"track","event":"order completed","userId":"123","anonymousId":"anon-1"},"userId":"123","topic":"some-topic"}`),
+			pm,
+			"default-topic",
+		)
+		require.Equal(t, 200, sc)
+		require.Equal(t, "Message delivered to topic: orders", res)
+
+		sc, _, _ = sendMessage(
+			context.Background(),
+			json.RawMessage(`{"message":{"type":"identify","userId":"123"},"userId":"123"}`),
+			pm,
+			"default-topic",
+		)
+		require.Equal(t, 200, sc)
+
+		sc, _, _ = sendMessage(
+			context.Background(),
+			json.RawMessage(`{"message":{"type":"page","anonymousId":"anon-2"}}`),
+			pm,
+			"default-topic",
+		)
+		require.Equal(t, 200, sc)
+
+		require.Len(t, p.calls, 3)
+		require.Equal(t, "orders", p.calls[0][0].Topic)
+		require.Equal(t, []byte("anon-1"), p.calls[0][0].Key)
+		require.Equal(t, "identities", p.calls[1][0].Topic)
+		require.Equal(t, []byte("123"), p.calls[1][0].Key, "should fall back to the userId of the payload")
+		require.Equal(t, "default-topic", p.calls[2][0].Topic)
+		require.Equal(t, []byte("anon-2"), p.calls[2][0].Key)
+	})
+
 	t.Run("publisher error", func(t *testing.T) {
 		kafkaStats.publishTime = getMockedTimer(t, gomock.NewController(t), false)
 
@@ -1440,7 +1615,9 @@ func requireEqualMessage(t testing.TB, topic, key, value string, actual client.M
 
 // Mocks
 type pmMockErr struct {
-	codecs map[string]*goavro.Codec
+	codecs           map[string]*goavro.Codec
+	routing          routing
+	deliveryReceipts bool
 }
 
 func (*pmMockErr) Close() error                                         { return nil }
@@ -1450,6 +1627,8 @@ func (*pmMockErr) getEmbedAvroSchemaID() bool                           { return
 func (pm *pmMockErr) getCodecs() map[string]*goavro.Codec {
 	return pm.codecs
 }
+func (pm *pmMockErr) getRouting() routing       { return pm.routing }
+func (pm *pmMockErr) getDeliveryReceipts() bool { return pm.deliveryReceipts }
 
 type pMockErr struct {
 	error error
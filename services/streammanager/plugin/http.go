@@ -0,0 +1,69 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type dryRunRequest struct {
+	Destination Destination     `json:"destination"`
+	Event       json.RawMessage `json:"event"`
+}
+
+type dryRunResponse struct {
+	Payload  json.RawMessage `json:"payload"`
+	Response DeliverResponse `json:"response"`
+}
+
+// HttpHandler returns an http handler reporting the state of the plugins and running dry runs of events through them:
+//
+//	GET  /                           returns the state of all the plugins
+//	POST /{destinationType}/dry-run  transforms an event for a destination and validates it without delivering it,
+//	                                 with a body of {"destination": {"id": "...", "config": {...}}, "event": {...}}
+func (m *Manager) HttpHandler() http.Handler {
+	srvMux := chi.NewRouter()
+	srvMux.Get("/", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, m.Status())
+	})
+	srvMux.Post("/{destinationType}/dry-run", func(w http.ResponseWriter, r *http.Request) {
+		p := m.Plugin(chi.URLParam(r, "destinationType"))
+		if p == nil {
+			http.Error(w, "plugin not found", http.StatusNotFound)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var req dryRunRequest
+		if err := json.Unmarshal(body, &req); err != nil || len(req.Event) == 0 {
+			http.Error(w, "invalid request, expected a destination and an event", http.StatusBadRequest)
+			return
+		}
+		payload, err := p.Transform(r.Context(), req.Destination, req.Event)
+		if err == nil {
+			var res DeliverResponse
+			if res, err = p.DryRun(r.Context(), req.Destination, payload); err == nil {
+				writeJSON(w, http.StatusOK, dryRunResponse{Payload: payload, Response: res})
+				return
+			}
+		}
+		status := http.StatusBadGateway
+		if errors.Is(err, ErrNotReady) {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, err.Error(), status)
+	})
+	return srvMux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
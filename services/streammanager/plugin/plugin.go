@@ -0,0 +1,381 @@
+/*
+Package plugin lets users implement proprietary destinations as sidecar services, without forking the router.
+
+A plugin serves a destination type and is registered through the Router.plugins.endpoints config, as a list of
+"<destination type>=<url>" entries, e.g. "MY_CRM=http://localhost:9092". Events of the destination type are then
+delivered through the plugin, which talks the following json over http contract (protocol version 1):
+
+	POST /v1/handshake  {"protocolVersion": 1, "destinationType": "MY_CRM"}
+	                    -> {"name": "my-crm", "version": "1.0.0", "protocolVersion": 1, "capabilities": {"transform": true, "dryRun": true}}
+	POST /v1/transform  {"destination": {"id": "...", "config": {...}}, "event": {...}}
+	                    -> {"payload": {...}}
+	POST /v1/deliver    {"destination": {"id": "...", "config": {...}}, "payload": {...}}
+	                    -> {"statusCode": 200, "message": "..."}
+	POST /v1/dry-run    same as deliver, validating the payload without delivering it
+	GET  /v1/health     -> 200 if the plugin is able to serve requests
+
+The handshake negotiates the protocol version and the optional capabilities of the plugin: events are only transformed
+through the plugin before being delivered if it supports transform, otherwise they are delivered as they reach the
+router. The status code returned by deliver follows the semantics of destination responses, i.e. 5xx and 429 are
+retried while other 4xx abort the event. Events are expected to reach the router untransformed, i.e. the destination
+definition should transform at "none".
+
+Plugins run as separate services rather than go plugins, which would need to be built with the exact same toolchain
+and dependencies as the server, and could bring it down when crashing.
+
+The lifecycle of plugins is managed by [Manager.Run]: plugins are handshaked once they are up, and health checked
+afterwards. Plugins failing their health checks are handshaked again once they recover, e.g. after a restart with a new
+version, and events are not delivered to them in the meantime.
+*/
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+
+	"github.com/rudderlabs/rudder-server/utils/httputil"
+)
+
+// ProtocolVersion is the version of the contract between the router and plugins
+const ProtocolVersion = 1
+
+const maxResponseSize = 1 << 20
+
+// ErrNotReady is returned for plugins which did not complete their handshake, or are failing their health checks
+var ErrNotReady = errors.New("plugin is not ready")
+
+// Default is the plugin manager of the server, nil until it is set up. No plugin is available with a nil manager.
+var Default *Manager
+
+// Capabilities are the optional methods a plugin supports
+type Capabilities struct {
+	Transform bool `json:"transform"`
+	DryRun    bool `json:"dryRun"`
+}
+
+type handshakeRequest struct {
+	ProtocolVersion int    `json:"protocolVersion"`
+	DestinationType string `json:"destinationType"`
+}
+
+// Info is returned by plugins on handshake
+type Info struct {
+	Name            string       `json:"name"`
+	Version         string       `json:"version"`
+	ProtocolVersion int          `json:"protocolVersion"`
+	Capabilities    Capabilities `json:"capabilities"`
+}
+
+// Destination is the destination events are transformed and delivered for
+type Destination struct {
+	ID     string                 `json:"id"`
+	Config map[string]interface{} `json:"config"`
+}
+
+type transformRequest struct {
+	Destination Destination     `json:"destination"`
+	Event       json.RawMessage `json:"event"`
+}
+
+type transformResponse struct {
+	Payload json.RawMessage `json:"payload"`
+}
+
+type deliverRequest struct {
+	Destination Destination     `json:"destination"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// DeliverResponse is the outcome of the delivery of a payload by a plugin
+type DeliverResponse struct {
+	StatusCode int    `json:"statusCode"`
+	Message    string `json:"message"`
+}
+
+// Status is the state of a plugin
+type Status struct {
+	DestinationType string    `json:"destinationType"`
+	URL             string    `json:"url"`
+	Ready           bool      `json:"ready"`
+	Info            *Info     `json:"info,omitempty"`
+	LastError       string    `json:"lastError,omitempty"`
+	LastCheckedAt   time.Time `json:"lastCheckedAt"`
+}
+
+// Plugin is a sidecar serving a destination type
+type Plugin struct {
+	destinationType string
+	url             string
+	client          *http.Client
+
+	mu            sync.RWMutex
+	info          *Info // nil until the handshake succeeds
+	ready         bool
+	lastError     error
+	lastCheckedAt time.Time
+}
+
+// Info returns the info the plugin returned on handshake, nil if it did not complete its handshake yet
+func (p *Plugin) Info() *Info {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.info
+}
+
+// Ready returns whether the plugin completed its handshake and passes its health checks
+func (p *Plugin) Ready() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.ready
+}
+
+// Status returns the state of the plugin
+func (p *Plugin) Status() Status {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	s := Status{DestinationType: p.destinationType, URL: p.url, Ready: p.ready, Info: p.info, LastCheckedAt: p.lastCheckedAt}
+	if p.lastError != nil {
+		s.LastError = p.lastError.Error()
+	}
+	return s
+}
+
+// Handshake negotiates the protocol version with the plugin and retrieves its capabilities
+func (p *Plugin) Handshake(ctx context.Context) (*Info, error) {
+	var info Info
+	if err := p.call(ctx, http.MethodPost, "/v1/handshake", handshakeRequest{ProtocolVersion: ProtocolVersion, DestinationType: p.destinationType}, &info); err != nil {
+		return nil, fmt.Errorf("handshake: %w", err)
+	}
+	if info.ProtocolVersion != ProtocolVersion {
+		return nil, fmt.Errorf("handshake: unsupported protocol version %d, expected %d", info.ProtocolVersion, ProtocolVersion)
+	}
+	return &info, nil
+}
+
+// Health checks whether the plugin is able to serve requests
+func (p *Plugin) Health(ctx context.Context) error {
+	return p.call(ctx, http.MethodGet, "/v1/health", nil, nil)
+}
+
+// Transform transforms an event into the payload to deliver, returning the event as it is if the plugin does not
+// support transform
+func (p *Plugin) Transform(ctx context.Context, destination Destination, event json.RawMessage) (json.RawMessage, error) {
+	info := p.Info()
+	if info == nil {
+		return nil, ErrNotReady
+	}
+	if !info.Capabilities.Transform {
+		return event, nil
+	}
+	var res transformResponse
+	if err := p.call(ctx, http.MethodPost, "/v1/transform", transformRequest{Destination: destination, Event: event}, &res); err != nil {
+		return nil, fmt.Errorf("transform: %w", err)
+	}
+	return res.Payload, nil
+}
+
+// Deliver delivers a payload to the destination
+func (p *Plugin) Deliver(ctx context.Context, destination Destination, payload json.RawMessage) (DeliverResponse, error) {
+	return p.deliver(ctx, "/v1/deliver", destination, payload)
+}
+
+// DryRun validates a payload for the destination without delivering it, failing if the plugin does not support dry runs
+func (p *Plugin) DryRun(ctx context.Context, destination Destination, payload json.RawMessage) (DeliverResponse, error) {
+	if info := p.Info(); info == nil || !info.Capabilities.DryRun {
+		return DeliverResponse{}, fmt.Errorf("plugin of %s does not support dry runs", p.destinationType)
+	}
+	return p.deliver(ctx, "/v1/dry-run", destination, payload)
+}
+
+func (p *Plugin) deliver(ctx context.Context, path string, destination Destination, payload json.RawMessage) (DeliverResponse, error) {
+	if !p.Ready() {
+		return DeliverResponse{}, ErrNotReady
+	}
+	var res DeliverResponse
+	if err := p.call(ctx, http.MethodPost, path, deliverRequest{Destination: destination, Payload: payload}, &res); err != nil {
+		return DeliverResponse{}, fmt.Errorf("%s: %w", strings.TrimPrefix(path, "/v1/"), err)
+	}
+	if res.StatusCode == 0 {
+		return DeliverResponse{}, fmt.Errorf("%s: no status code in response", strings.TrimPrefix(path, "/v1/"))
+	}
+	return res, nil
+}
+
+func (p *Plugin) call(ctx context.Context, method, path string, body, res interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshalling request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.url+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { httputil.CloseResponse(resp) }()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, respBody)
+	}
+	if res == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, res); err != nil {
+		return fmt.Errorf("unmarshalling response: %w", err)
+	}
+	return nil
+}
+
+// Manager manages the lifecycle of the plugins registered in the config
+type Manager struct {
+	log     logger.Logger
+	plugins map[string]*Plugin // by destination type
+
+	checkInterval config.ValueLoader[time.Duration]
+}
+
+// Endpoints returns the plugins registered in the config, as urls by destination type
+func Endpoints(conf *config.Config) (map[string]string, error) {
+	endpoints := make(map[string]string)
+	for _, entry := range conf.GetStringSlice("Router.plugins.endpoints", nil) {
+		destType, url, ok := strings.Cut(entry, "=")
+		destType, url = strings.ToUpper(strings.TrimSpace(destType)), strings.TrimSpace(url)
+		if !ok || destType == "" || url == "" {
+			return nil, fmt.Errorf("invalid plugin endpoint %q, expected <destination type>=<url>", entry)
+		}
+		if _, ok := endpoints[destType]; ok {
+			return nil, fmt.Errorf("duplicate plugin endpoint for %s", destType)
+		}
+		endpoints[destType] = strings.TrimSuffix(url, "/")
+	}
+	return endpoints, nil
+}
+
+// DestinationTypes returns the destination types served by the plugins registered in the config
+func DestinationTypes(conf *config.Config) []string {
+	endpoints, _ := Endpoints(conf)
+	destTypes := make([]string, 0, len(endpoints))
+	for destType := range endpoints {
+		destTypes = append(destTypes, destType)
+	}
+	slices.Sort(destTypes)
+	return destTypes
+}
+
+// New returns a manager of the plugins registered in the config, failing if their endpoints are invalid
+func New(conf *config.Config, log logger.Logger) (*Manager, error) {
+	endpoints, err := Endpoints(conf)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: conf.GetDurationVar(30, time.Second, "Router.plugins.timeout")}
+	m := &Manager{
+		log:           log,
+		plugins:       make(map[string]*Plugin, len(endpoints)),
+		checkInterval: conf.GetReloadableDurationVar(10, time.Second, "Router.plugins.checkInterval"),
+	}
+	for destType, url := range endpoints {
+		m.plugins[destType] = &Plugin{destinationType: destType, url: url, client: client}
+	}
+	return m, nil
+}
+
+// Plugin returns the plugin serving the destination type, nil if there is none
+func (m *Manager) Plugin(destType string) *Plugin {
+	if m == nil {
+		return nil
+	}
+	return m.plugins[destType]
+}
+
+// Status returns the state of all the plugins
+func (m *Manager) Status() []Status {
+	if m == nil {
+		return []Status{}
+	}
+	statuses := make([]Status, 0, len(m.plugins))
+	for _, p := range m.plugins {
+		statuses = append(statuses, p.Status())
+	}
+	slices.SortFunc(statuses, func(a, b Status) int { return strings.Compare(a.DestinationType, b.DestinationType) })
+	return statuses
+}
+
+// Run handshakes the plugins and keeps checking their health, until the context is cancelled
+func (m *Manager) Run(ctx context.Context) {
+	if m == nil || len(m.plugins) == 0 {
+		return
+	}
+	for {
+		m.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.checkInterval.Load()):
+		}
+	}
+}
+
+// check health checks the plugins, handshaking the healthy ones which are not ready yet
+func (m *Manager) check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, p := range m.plugins {
+		wg.Add(1)
+		go func(p *Plugin) {
+			defer wg.Done()
+			var info *Info
+			ready := p.Ready()
+			err := p.Health(ctx)
+			if err == nil && !ready {
+				if info, err = p.Handshake(ctx); err == nil {
+					m.log.Infon("Plugin is ready",
+						logger.NewStringField("destType", p.destinationType),
+						logger.NewStringField("name", info.Name),
+						logger.NewStringField("version", info.Version),
+					)
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.lastCheckedAt = time.Now()
+			p.lastError = err
+			if info != nil {
+				p.info = info
+			}
+			p.ready = err == nil
+			if err != nil {
+				m.log.Warnn("Plugin is not ready",
+					logger.NewStringField("destType", p.destinationType),
+					logger.NewBoolField("wasReady", ready),
+					logger.NewErrorField(err),
+				)
+			}
+		}(p)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,138 @@
+package plugin_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/services/streammanager/common"
+	"github.com/rudderlabs/rudder-server/services/streammanager/plugin"
+)
+
+func TestEndpoints(t *testing.T) {
+	c := config.New()
+	c.Set("Router.plugins.endpoints", []string{"my_crm=http://localhost:9092/", "OTHER = http://localhost:9093"})
+	endpoints, err := plugin.Endpoints(c)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"MY_CRM": "http://localhost:9092", "OTHER": "http://localhost:9093"}, endpoints)
+	require.Equal(t, []string{"MY_CRM", "OTHER"}, plugin.DestinationTypes(c))
+
+	c.Set("Router.plugins.endpoints", []string{"MY_CRM"})
+	_, err = plugin.New(c, logger.NOP)
+	require.Error(t, err)
+}
+
+func TestPlugin(t *testing.T) {
+	var (
+		healthy   atomic.Bool
+		handshake atomic.Int64
+		delivered = make(chan string, 10)
+	)
+	healthy.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/v1/handshake":
+			handshake.Add(1)
+			require.Equal(t, "MY_CRM", gjson.GetBytes(body, "destinationType").String())
+			_, _ = w.Write([]byte(`{"name":"my-crm","version":"1.0.0","protocolVersion":1,"capabilities":{"transform":true,"dryRun":true}}`))
+		case "/v1/health":
+			if !healthy.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/v1/transform":
+			_, _ = w.Write([]byte(`{"payload":{"contact":"` + gjson.GetBytes(body, "event.userId").String() + `","apiKey":"` + gjson.GetBytes(body, "destination.config.apiKey").String() + `"}}`))
+		case "/v1/deliver", "/v1/dry-run":
+			if gjson.GetBytes(body, "payload.contact").String() == "" {
+				_, _ = w.Write([]byte(`{"statusCode":400,"message":"contact is required"}`))
+				return
+			}
+			if r.URL.Path == "/v1/deliver" {
+				delivered <- gjson.GetBytes(body, "payload.contact").String()
+			}
+			_, _ = w.Write([]byte(`{"statusCode":200,"message":"ok"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := config.New()
+	c.Set("Router.plugins.endpoints", []string{"MY_CRM=" + srv.URL})
+	c.Set("Router.plugins.checkInterval", "10ms")
+	m, err := plugin.New(c, logger.NOP)
+	require.NoError(t, err)
+	require.Nil(t, m.Plugin("OTHER"))
+	p := m.Plugin("MY_CRM")
+	require.NotNil(t, p)
+
+	destination := &backendconfig.DestinationT{ID: "destination-1", Config: map[string]interface{}{"apiKey": "key"}}
+	_, err = plugin.NewProducer(p, destination, common.Opts{})
+	require.ErrorIs(t, err, plugin.ErrNotReady, "producers can't be created before the handshake")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+	require.Eventually(t, p.Ready, time.Second, 10*time.Millisecond)
+	require.Equal(t, "1.0.0", p.Info().Version)
+
+	t.Run("produce", func(t *testing.T) {
+		producer, err := plugin.NewProducer(p, destination, common.Opts{Timeout: time.Second})
+		require.NoError(t, err)
+		statusCode, respStatus, _ := producer.Produce(json.RawMessage(`{"userId":"user-1"}`), destination.Config)
+		require.Equal(t, http.StatusOK, statusCode)
+		require.Equal(t, "Success", respStatus)
+		require.Equal(t, "user-1", <-delivered, "events are transformed by the plugin before being delivered")
+
+		statusCode, respStatus, msg := producer.Produce(json.RawMessage(`{}`), destination.Config)
+		require.Equal(t, http.StatusBadRequest, statusCode)
+		require.Equal(t, "Failure", respStatus)
+		require.Contains(t, msg, "contact is required")
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		m.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/MY_CRM/dry-run", strings.NewReader(`{"destination":{"id":"destination-1","config":{"apiKey":"key"}},"event":{"userId":"user-2"}}`)))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.JSONEq(t, `{"payload":{"contact":"user-2","apiKey":"key"},"response":{"statusCode":200,"message":"ok"}}`, resp.Body.String())
+		require.Empty(t, delivered, "dry runs don't deliver events")
+
+		resp = httptest.NewRecorder()
+		m.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/OTHER/dry-run", strings.NewReader(`{}`)))
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("lifecycle", func(t *testing.T) {
+		producer, err := plugin.NewProducer(p, destination, common.Opts{})
+		require.NoError(t, err)
+
+		healthy.Store(false)
+		require.Eventually(t, func() bool { return !p.Ready() }, time.Second, 10*time.Millisecond)
+		statusCode, _, _ := producer.Produce(json.RawMessage(`{"userId":"user-1"}`), destination.Config)
+		require.Equal(t, http.StatusServiceUnavailable, statusCode, "events are retried while the plugin is not ready")
+
+		handshakes := handshake.Load()
+		healthy.Store(true)
+		require.Eventually(t, p.Ready, time.Second, 10*time.Millisecond)
+		require.Greater(t, handshake.Load(), handshakes, "plugins are handshaked again once they recover")
+
+		resp := httptest.NewRecorder()
+		m.HttpHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, "MY_CRM", gjson.Get(resp.Body.String(), "0.destinationType").String())
+		require.True(t, gjson.Get(resp.Body.String(), "0.ready").Bool())
+	})
+}
@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/services/streammanager/common"
+)
+
+// Producer delivers the events of a destination through the plugin serving its destination type
+type Producer struct {
+	plugin      *Plugin
+	destination Destination
+	opts        common.Opts
+}
+
+// NewProducer creates a producer for the destination, failing if the plugin did not complete its handshake yet
+func NewProducer(p *Plugin, destination *backendconfig.DestinationT, o common.Opts) (*Producer, error) {
+	if !p.Ready() {
+		return nil, ErrNotReady
+	}
+	return &Producer{
+		plugin:      p,
+		destination: Destination{ID: destination.ID, Config: destination.Config},
+		opts:        o,
+	}, nil
+}
+
+// Produce transforms the event through the plugin if it supports transform, and delivers it, returning the status
+// code returned by the plugin
+func (p *Producer) Produce(jsonData json.RawMessage, _ interface{}) (statusCode int, respStatus, responseMessage string) {
+	ctx := context.Background()
+	if p.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.opts.Timeout)
+		defer cancel()
+	}
+	payload, err := p.plugin.Transform(ctx, p.destination, jsonData)
+	if err != nil {
+		return errorStatusCode(err), "Failure", "[Plugin] error while transforming event :: " + err.Error()
+	}
+	res, err := p.plugin.Deliver(ctx, p.destination, payload)
+	if err != nil {
+		return errorStatusCode(err), "Failure", "[Plugin] error while delivering event :: " + err.Error()
+	}
+	if res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusMultipleChoices {
+		return res.StatusCode, "Success", res.Message
+	}
+	return res.StatusCode, "Failure", "[Plugin] error :: delivery failed :: " + res.Message
+}
+
+// Close has nothing to release, the lifecycle of plugins is managed by [Manager]
+func (*Producer) Close() error {
+	return nil
+}
+
+// errorStatusCode returns the status code of the failure of a call to a plugin, which is retried
+func errorStatusCode(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) || os.IsTimeout(err) {
+		return http.StatusGatewayTimeout
+	}
+	if errors.Is(err, ErrNotReady) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
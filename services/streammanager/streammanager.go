@@ -18,6 +18,8 @@ import (
 	"github.com/rudderlabs/rudder-server/services/streammanager/kinesis"
 	"github.com/rudderlabs/rudder-server/services/streammanager/lambda"
 	"github.com/rudderlabs/rudder-server/services/streammanager/personalize"
+	"github.com/rudderlabs/rudder-server/services/streammanager/plugin"
+	"github.com/rudderlabs/rudder-server/services/streammanager/templatedwebhook"
 	"github.com/rudderlabs/rudder-server/services/streammanager/wunderkind"
 )
 
@@ -53,7 +55,12 @@ func NewProducer(destination *backendconfig.DestinationT, opts common.Opts) (com
 		return googlecloudfunction.NewProducer(destination, opts)
 	case "WUNDERKIND":
 		return wunderkind.NewProducer(config.Default, logger.NewLogger().Child("streammanager"))
+	case templatedwebhook.DestinationName:
+		return templatedwebhook.NewProducer(destination, opts, logger.NewLogger().Child("streammanager"))
 	default:
+		if p := plugin.Default.Plugin(destination.DestinationDefinition.Name); p != nil {
+			return plugin.NewProducer(p, destination, opts)
+		}
 		return nil, fmt.Errorf("no provider configured for StreamManager") // 404, "No provider configured for StreamManager", ""
 	}
 }
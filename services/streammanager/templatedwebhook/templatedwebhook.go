@@ -0,0 +1,241 @@
+// Package templatedwebhook delivers events to http endpoints, where the url, method, headers and body of the requests
+// are configured with go templates over the event payload, e.g.
+//
+//	url:     https://internal.example.com/users/{{ .userId }}/events
+//	body:    {"name": {{ json .event }}, "email": {{ json (default "" .context.traits.email) }}}
+//
+// Besides the builtin template functions, json (marshals a value as json) and default (returns a fallback for empty
+// values) are available. Missing fields render as "<no value>", so optional ones should go through default.
+// When no body template is configured, the event is sent as it is.
+// Requests are signed with HMAC-SHA256 when a secret is configured, so that receivers can verify their origin.
+//
+// Events are expected to reach the router untransformed, i.e. the destination definition should transform at "none".
+package templatedwebhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/services/streammanager/common"
+	"github.com/rudderlabs/rudder-server/utils/httputil"
+)
+
+const (
+	DestinationName = "TEMPLATED_WEBHOOK"
+
+	defaultSignatureHeader = "X-Rudder-Signature"
+	defaultTimeout         = 10 * time.Second
+	maxResponseSize        = 10 * 1024
+)
+
+type header struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Config is the configuration of a templated webhook destination
+type Config struct {
+	URL     string   `json:"url"`
+	Method  string   `json:"method"`
+	Headers []header `json:"headers"`
+	Body    string   `json:"body"`
+	// HMACSecret, if set, signs the body of every request, sending "sha256=<hex encoded signature>" in the HMACHeader header
+	HMACSecret string `json:"hmacSecret"`
+	HMACHeader string `json:"hmacHeader"`
+}
+
+type Producer struct {
+	client *http.Client
+	logger logger.Logger
+
+	url     *template.Template
+	method  *template.Template
+	headers map[string]*template.Template
+	body    *template.Template
+
+	hmacSecret []byte
+	hmacHeader string
+}
+
+// NewProducer creates a producer based on destination config, failing if any of its templates is invalid
+func NewProducer(destination *backendconfig.DestinationT, o common.Opts, log logger.Logger) (*Producer, error) {
+	var conf Config
+	jsonConfig, err := json.Marshal(destination.Config)
+	if err != nil {
+		return nil, fmt.Errorf("[TemplatedWebhook] Error while marshalling destination config: %w", err)
+	}
+	if err := json.Unmarshal(jsonConfig, &conf); err != nil {
+		return nil, fmt.Errorf("[TemplatedWebhook] Error while unmarshalling destination config: %w", err)
+	}
+	if conf.URL == "" {
+		return nil, errors.New("[TemplatedWebhook] invalid configuration: url cannot be empty")
+	}
+
+	p := &Producer{
+		logger:     log.Child("templatedwebhook"),
+		headers:    make(map[string]*template.Template, len(conf.Headers)),
+		hmacSecret: []byte(conf.HMACSecret),
+		hmacHeader: conf.HMACHeader,
+	}
+	if p.hmacHeader == "" {
+		p.hmacHeader = defaultSignatureHeader
+	}
+	timeout := o.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	p.client = &http.Client{Timeout: timeout}
+
+	if p.url, err = parseTemplate("url", conf.URL); err != nil {
+		return nil, err
+	}
+	if conf.Method == "" {
+		conf.Method = http.MethodPost
+	}
+	if p.method, err = parseTemplate("method", conf.Method); err != nil {
+		return nil, err
+	}
+	for _, h := range conf.Headers {
+		if h.Key == "" {
+			continue
+		}
+		if p.headers[h.Key], err = parseTemplate("header "+h.Key, h.Value); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Body != "" {
+		if p.body, err = parseTemplate("body", conf.Body); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Produce renders the request out of the event and sends it, returning the status code of the response
+func (p *Producer) Produce(jsonData json.RawMessage, _ interface{}) (statusCode int, respStatus, responseMessage string) {
+	var event map[string]interface{}
+	if err := json.Unmarshal(jsonData, &event); err != nil {
+		return http.StatusBadRequest, "Failure", "[TemplatedWebhook] error while unmarshalling event :: " + err.Error()
+	}
+
+	req, err := p.newRequest(event, jsonData)
+	if err != nil {
+		// rendering depends only on the event, so retrying wouldn't help
+		return http.StatusBadRequest, "Failure", "[TemplatedWebhook] error while rendering request :: " + err.Error()
+	}
+
+	resp, err := p.client.Do(req)
+	var responseBody []byte
+	if err == nil {
+		defer func() { httputil.CloseResponse(resp) }()
+		responseBody, err = io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	}
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		if os.IsTimeout(err) {
+			statusCode = http.StatusGatewayTimeout
+		}
+		p.logger.Warnn("Sending request", logger.NewErrorField(err))
+		return statusCode, "Failure", "[TemplatedWebhook] error :: request failed :: " + err.Error()
+	}
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return resp.StatusCode, "Success", string(responseBody)
+	}
+	return resp.StatusCode, "Failure", "[TemplatedWebhook] error :: request failed :: " + string(responseBody)
+}
+
+func (p *Producer) newRequest(event map[string]interface{}, rawEvent []byte) (*http.Request, error) {
+	url, err := render(p.url, event)
+	if err != nil {
+		return nil, err
+	}
+	method, err := render(p.method, event)
+	if err != nil {
+		return nil, err
+	}
+	body := rawEvent
+	if p.body != nil {
+		renderedBody, err := render(p.body, event)
+		if err != nil {
+			return nil, err
+		}
+		body = []byte(renderedBody)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), strings.ToUpper(strings.TrimSpace(method)), strings.TrimSpace(url), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, t := range p.headers {
+		value, err := render(t, event)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(key, value)
+	}
+	if len(p.hmacSecret) > 0 {
+		req.Header.Set(p.hmacHeader, sign(p.hmacSecret, body))
+	}
+	return req, nil
+}
+
+func (p *Producer) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
+
+// sign returns the HMAC-SHA256 signature of the body, in the "sha256=<hex>" format
+func sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"default": func(fallback, v interface{}) interface{} {
+		if v == nil {
+			return fallback
+		}
+		if rv := reflect.ValueOf(v); rv.IsZero() {
+			return fallback
+		}
+		return v
+	},
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("[TemplatedWebhook] invalid %s template: %w", name, err)
+	}
+	return t, nil
+}
+
+func render(t *template.Template, event map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, event); err != nil {
+		return "", fmt.Errorf("rendering %s: %w", t.Name(), err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,122 @@
+package templatedwebhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/services/streammanager/common"
+)
+
+func TestNewProducer(t *testing.T) {
+	newProducer := func(config map[string]interface{}) error {
+		_, err := NewProducer(&backendconfig.DestinationT{Config: config}, common.Opts{}, logger.NOP)
+		return err
+	}
+	require.EqualError(t, newProducer(map[string]interface{}{}), "[TemplatedWebhook] invalid configuration: url cannot be empty")
+	require.ErrorContains(t, newProducer(map[string]interface{}{"url": "http://example.com/{{ .userId"}), "invalid url template")
+	require.ErrorContains(t, newProducer(map[string]interface{}{"url": "http://example.com", "body": "{{ unknown .event }}"}), "invalid body template")
+	require.NoError(t, newProducer(map[string]interface{}{"url": "http://example.com"}))
+}
+
+func TestProduce(t *testing.T) {
+	type request struct {
+		method  string
+		path    string
+		headers http.Header
+		body    string
+	}
+	var (
+		requests   []request
+		statusCode = http.StatusOK
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{method: r.Method, path: r.URL.Path, headers: r.Header, body: string(body)})
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte("response"))
+	}))
+	t.Cleanup(srv.Close)
+
+	newProducer := func(t *testing.T, config map[string]interface{}) *Producer {
+		config["url"] = srv.URL + config["url"].(string)
+		p, err := NewProducer(&backendconfig.DestinationT{Config: config}, common.Opts{Timeout: time.Second}, logger.NOP)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = p.Close() })
+		return p
+	}
+	event := []byte(`{"event":"Order Completed","userId":"user-1","properties":{"revenue":10.5}}`)
+
+	t.Run("templated request", func(t *testing.T) {
+		requests = nil
+		p := newProducer(t, map[string]interface{}{
+			"url":    "/users/{{ .userId }}/events",
+			"method": "{{ if .properties.revenue }}put{{ else }}post{{ end }}",
+			"headers": []map[string]string{
+				{"key": "X-Event", "value": "{{ .event }}"},
+			},
+			"body":       `{"name":{{ json .event }},"revenue":{{ .properties.revenue }},"anonymousId":{{ json (default "unknown" .anonymousId) }}}`,
+			"hmacSecret": "secret",
+		})
+		sc, status, resp := p.Produce(event, nil)
+		require.Equal(t, http.StatusOK, sc)
+		require.Equal(t, "Success", status)
+		require.Equal(t, "response", resp)
+
+		require.Len(t, requests, 1)
+		require.Equal(t, http.MethodPut, requests[0].method)
+		require.Equal(t, "/users/user-1/events", requests[0].path)
+		require.Equal(t, "Order Completed", requests[0].headers.Get("X-Event"))
+		require.Equal(t, "application/json", requests[0].headers.Get("Content-Type"))
+		require.JSONEq(t, `{"name":"Order Completed","revenue":10.5,"anonymousId":"unknown"}`, requests[0].body)
+
+		mac := hmac.New(sha256.New, []byte("secret"))
+		_, _ = mac.Write([]byte(requests[0].body))
+		require.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), requests[0].headers.Get("X-Rudder-Signature"))
+	})
+
+	t.Run("default request", func(t *testing.T) {
+		requests = nil
+		p := newProducer(t, map[string]interface{}{"url": "/events"})
+		sc, _, _ := p.Produce(event, nil)
+		require.Equal(t, http.StatusOK, sc)
+
+		require.Len(t, requests, 1)
+		require.Equal(t, http.MethodPost, requests[0].method)
+		require.Equal(t, string(event), requests[0].body, "the event should be sent as it is")
+		require.Empty(t, requests[0].headers.Get("X-Rudder-Signature"), "requests shouldn't be signed without a secret")
+	})
+
+	t.Run("invalid event", func(t *testing.T) {
+		p := newProducer(t, map[string]interface{}{"url": "/events"})
+		sc, status, _ := p.Produce([]byte(`not json`), nil)
+		require.Equal(t, http.StatusBadRequest, sc)
+		require.Equal(t, "Failure", status)
+	})
+
+	t.Run("rendering error", func(t *testing.T) {
+		p := newProducer(t, map[string]interface{}{"url": "/events", "body": "{{ .userId.name }}"})
+		sc, _, resp := p.Produce(event, nil)
+		require.Equal(t, http.StatusBadRequest, sc)
+		require.Contains(t, resp, "error while rendering request")
+	})
+
+	t.Run("endpoint error", func(t *testing.T) {
+		statusCode = http.StatusServiceUnavailable
+		t.Cleanup(func() { statusCode = http.StatusOK })
+		p := newProducer(t, map[string]interface{}{"url": "/events"})
+		sc, status, resp := p.Produce(event, nil)
+		require.Equal(t, http.StatusServiceUnavailable, sc)
+		require.Equal(t, "Failure", status)
+		require.Equal(t, "[TemplatedWebhook] error :: request failed :: response", resp)
+	})
+}
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS checkpoints (
+        operation TEXT NOT NULL,
+        job_id BIGINT NOT NULL,
+        step TEXT NOT NULL,
+        created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (operation, job_id, step)
+);
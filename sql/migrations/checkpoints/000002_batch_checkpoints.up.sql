@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS checkpoint_batches (
+        operation TEXT NOT NULL,
+        step TEXT NOT NULL,
+        job_ids BIGINT[] NOT NULL,
+        created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS checkpoint_batches_operation_idx ON checkpoint_batches (operation);
+
+INSERT INTO checkpoint_batches (operation, step, job_ids)
+SELECT operation, step, ARRAY_AGG(job_id ORDER BY job_id) FROM checkpoints GROUP BY operation, step;
+
+DROP TABLE IF EXISTS checkpoints;
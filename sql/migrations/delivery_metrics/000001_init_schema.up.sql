@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS delivery_metrics (
+        bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+        source_id TEXT NOT NULL,
+        destination_id TEXT NOT NULL,
+        succeeded BIGINT NOT NULL DEFAULT 0,
+        failed BIGINT NOT NULL DEFAULT 0,
+        aborted BIGINT NOT NULL DEFAULT 0,
+        latency_sum_ms BIGINT NOT NULL DEFAULT 0,
+        latency_max_ms BIGINT NOT NULL DEFAULT 0,
+        latency_count BIGINT NOT NULL DEFAULT 0,
+        PRIMARY KEY (bucket, source_id, destination_id)
+);
+
+CREATE INDEX IF NOT EXISTS delivery_metrics_destination_id_idx ON delivery_metrics (destination_id, bucket);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS destination_dlq (
+        id BIGSERIAL PRIMARY KEY,
+        workspace_id TEXT NOT NULL,
+        source_id TEXT NOT NULL,
+        destination_id TEXT NOT NULL,
+        destination_type TEXT NOT NULL,
+        job_id BIGINT NOT NULL,
+        user_id TEXT NOT NULL DEFAULT '',
+        attempts INT NOT NULL,
+        status_code INT NOT NULL,
+        response JSONB NOT NULL,
+        payload JSONB NOT NULL,
+        parameters JSONB NOT NULL,
+        created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+        requeued_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS destination_dlq_destination_id_idx ON destination_dlq (destination_id, id);
+CREATE INDEX IF NOT EXISTS destination_dlq_created_at_idx ON destination_dlq (created_at);
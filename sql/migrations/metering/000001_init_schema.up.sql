@@ -0,0 +1,22 @@
+-- usage of the batches of gateway jobs processed, pending their compaction into metering_usage
+CREATE TABLE IF NOT EXISTS metering_reports (
+        id BIGSERIAL PRIMARY KEY,
+        month DATE NOT NULL,
+        workspace_id TEXT NOT NULL,
+        source_id TEXT NOT NULL,
+        events BIGINT NOT NULL,
+        users_hll BYTEA NOT NULL,
+        reported_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS metering_usage (
+        month DATE NOT NULL,
+        workspace_id TEXT NOT NULL,
+        source_id TEXT NOT NULL,
+        events BIGINT NOT NULL DEFAULT 0,
+        users_hll BYTEA NOT NULL DEFAULT '',
+        updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (month, workspace_id, source_id)
+);
+
+CREATE INDEX IF NOT EXISTS metering_usage_workspace_id_idx ON metering_usage (workspace_id, month);
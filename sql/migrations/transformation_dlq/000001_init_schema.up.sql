@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS transformation_dlq (
+        id BIGSERIAL PRIMARY KEY,
+        workspace_id TEXT NOT NULL,
+        source_id TEXT NOT NULL,
+        destination_id TEXT NOT NULL,
+        transformation_id TEXT NOT NULL,
+        transformation_version_id TEXT NOT NULL,
+        user_id TEXT NOT NULL DEFAULT '',
+        source_job_run_id TEXT NOT NULL DEFAULT '',
+        source_task_run_id TEXT NOT NULL DEFAULT '',
+        status_code INT NOT NULL,
+        error TEXT NOT NULL,
+        payload JSONB NOT NULL,
+        created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+        replayed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS transformation_dlq_transformation_id_idx ON transformation_dlq (transformation_id, id);
+CREATE INDEX IF NOT EXISTS transformation_dlq_created_at_idx ON transformation_dlq (created_at);
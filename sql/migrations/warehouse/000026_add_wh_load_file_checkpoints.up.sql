@@ -0,0 +1,12 @@
+--
+-- wh_load_file_checkpoints
+--
+
+CREATE TABLE IF NOT EXISTS wh_load_file_checkpoints (
+    upload_id BIGINT NOT NULL,
+    batch_id VARCHAR(64) NOT NULL,
+    unique_load_gen_id VARCHAR(64) NOT NULL,
+    staging_file_ids BIGINT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (upload_id, batch_id)
+);
@@ -0,0 +1,11 @@
+--
+-- wh_reverse_etl_snapshots
+--
+
+CREATE TABLE IF NOT EXISTS wh_reverse_etl_snapshots (
+    source_id VARCHAR(64) NOT NULL,
+    primary_key TEXT NOT NULL,
+    row_hash VARCHAR(64) NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (source_id, primary_key)
+);
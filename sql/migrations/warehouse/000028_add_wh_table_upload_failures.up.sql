@@ -0,0 +1,38 @@
+--
+-- wh_table_upload_failures
+--
+
+-- summary of the tables which failed to be exported by the uploads, maintained on the status transitions of the
+-- table uploads, so that the tables failed by earlier uploads of a destination can be looked up without scanning
+-- the history of the table uploads
+CREATE TABLE IF NOT EXISTS wh_table_upload_failures (
+    wh_upload_id BIGINT NOT NULL,
+    table_name TEXT NOT NULL,
+    destination_id VARCHAR(64) NOT NULL,
+    namespace VARCHAR(64) NOT NULL,
+    error TEXT,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (wh_upload_id, table_name)
+);
+
+CREATE INDEX IF NOT EXISTS wh_table_upload_failures_destination_id_namespace_table_name_index ON wh_table_upload_failures (destination_id, namespace, table_name);
+
+INSERT INTO wh_table_upload_failures (wh_upload_id, table_name, destination_id, namespace, error, updated_at)
+SELECT
+    TU.wh_upload_id,
+    TU.table_name,
+    UT.destination_id,
+    UT.namespace,
+    TU.error,
+    TU.updated_at
+FROM
+    wh_table_uploads TU
+INNER JOIN
+    wh_uploads UT
+ON
+    UT.id = TU.wh_upload_id
+WHERE
+    TU.status = 'exporting_data_failed' AND
+    UT.status != 'exported_data' AND
+    UT.status != 'aborted'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,21 @@
+--
+-- wh_event_schemas
+--
+
+-- registry of the schemas observed for the tables of the events by the uploads, i.e. the types of the properties along
+-- with the number of uploads they were observed in. A new version is recorded whenever properties get added or change
+-- their types, keeping the history of the schemas.
+CREATE TABLE IF NOT EXISTS wh_event_schemas (
+    id BIGSERIAL PRIMARY KEY,
+    source_id VARCHAR(64) NOT NULL,
+    destination_id VARCHAR(64) NOT NULL,
+    namespace VARCHAR(64) NOT NULL,
+    table_name TEXT NOT NULL,
+    version INT NOT NULL,
+    properties JSONB NOT NULL,
+    observations BIGINT NOT NULL,
+    last_staging_file_id BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    UNIQUE (source_id, destination_id, namespace, table_name, version)
+);
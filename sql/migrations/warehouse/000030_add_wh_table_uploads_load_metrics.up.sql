@@ -0,0 +1,10 @@
+--
+-- wh_table_uploads
+--
+
+-- number of attempts at loading the table, along with the duration, rows and bytes of the last one
+ALTER TABLE wh_table_uploads
+    ADD COLUMN IF NOT EXISTS load_attempt INTEGER,
+    ADD COLUMN IF NOT EXISTS load_duration_ms BIGINT,
+    ADD COLUMN IF NOT EXISTS rows_loaded BIGINT,
+    ADD COLUMN IF NOT EXISTS bytes_loaded BIGINT;
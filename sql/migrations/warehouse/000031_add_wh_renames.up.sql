@@ -0,0 +1,23 @@
+--
+-- wh_renames
+--
+
+-- renames of the namespaces and tables of the warehouse destinations, executed by the routers once no upload of the
+-- connection is in progress. The table name is empty for the renames of namespaces.
+CREATE TABLE IF NOT EXISTS wh_renames (
+    id BIGSERIAL PRIMARY KEY,
+    workspace_id VARCHAR(64) NOT NULL,
+    source_id VARCHAR(64) NOT NULL,
+    destination_id VARCHAR(64) NOT NULL,
+    destination_type VARCHAR(64) NOT NULL,
+    namespace VARCHAR(64) NOT NULL,
+    table_name TEXT NOT NULL,
+    new_name TEXT NOT NULL,
+    status VARCHAR(64) NOT NULL,
+    error TEXT,
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS wh_renames_destination_type_status_index ON wh_renames (destination_type, status);
@@ -0,0 +1,166 @@
+package filemanagerutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+)
+
+// SecondaryStorageKey is the key of the provider config holding the settings of the secondary storage, overriding the
+// ones of the primary storage, e.g.
+//
+//	"secondaryStorage": {"bucketName": "staging-bucket-eu", "region": "eu-west-1"}
+const SecondaryStorageKey = "secondaryStorage"
+
+// FailoverFactory returns a factory of file managers which fail over to the secondary storage of the provider config,
+// if one is configured, whenever the primary storage is unavailable:
+//
+//   - uploads are retried against the secondary storage, whose bucket is part of the location of the uploaded files
+//   - downloads are retried against the secondary storage, since the file might have been uploaded there
+//   - locations are resolved by the file manager of the storage whose bucket they belong to
+//
+// Without a secondary storage, the file managers of the given factory are returned as they are.
+func FailoverFactory(factory filemanager.Factory) filemanager.Factory {
+	return func(settings *filemanager.Settings) (filemanager.FileManager, error) {
+		primary, err := factory(settings)
+		if err != nil {
+			return nil, err
+		}
+		secondaryConfig, ok := settings.Config[SecondaryStorageKey].(map[string]interface{})
+		if !ok || len(secondaryConfig) == 0 {
+			return primary, nil
+		}
+
+		config := make(map[string]interface{}, len(settings.Config)+len(secondaryConfig))
+		for k, v := range settings.Config {
+			if k != SecondaryStorageKey {
+				config[k] = v
+			}
+		}
+		for k, v := range secondaryConfig {
+			config[k] = v
+		}
+		secondarySettings := *settings
+		secondarySettings.Config = config
+		secondary, err := factory(&secondarySettings)
+		if err != nil {
+			return nil, fmt.Errorf("creating file manager for secondary storage: %w", err)
+		}
+
+		log := settings.Logger
+		if log == nil {
+			log = logger.NewLogger().Child("filemanager")
+		}
+		return &failoverFileManager{
+			FileManager:     primary,
+			secondary:       secondary,
+			primaryBucket:   bucket(settings.Config),
+			secondaryBucket: bucket(config),
+			log:             log.Withn(logger.NewStringField("provider", settings.Provider)),
+		}, nil
+	}
+}
+
+// failoverFileManager is a file manager of a primary storage, failing over to a secondary storage
+type failoverFileManager struct {
+	filemanager.FileManager // primary storage
+
+	secondary       filemanager.FileManager
+	primaryBucket   string
+	secondaryBucket string
+	log             logger.Logger
+}
+
+func (m *failoverFileManager) Upload(ctx context.Context, file *os.File, prefixes ...string) (filemanager.UploadedFile, error) {
+	uploaded, err := m.FileManager.Upload(ctx, file, prefixes...)
+	if err == nil || ctx.Err() != nil {
+		return uploaded, err
+	}
+	m.log.Warnn("Uploading to primary storage failed, failing over to secondary storage",
+		logger.NewStringField("bucket", m.secondaryBucket),
+		obskit.Error(err),
+	)
+	if _, seekErr := file.Seek(0, io.SeekStart); seekErr != nil {
+		return filemanager.UploadedFile{}, errors.Join(err, fmt.Errorf("rewinding file for secondary storage: %w", seekErr))
+	}
+	uploaded, secondaryErr := m.secondary.Upload(ctx, file, prefixes...)
+	if secondaryErr != nil {
+		return filemanager.UploadedFile{}, errors.Join(err, fmt.Errorf("uploading to secondary storage: %w", secondaryErr))
+	}
+	return uploaded, nil
+}
+
+func (m *failoverFileManager) Download(ctx context.Context, file *os.File, key string) error {
+	err := m.FileManager.Download(ctx, file, key)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	if truncateErr := truncate(file); truncateErr != nil {
+		return errors.Join(err, fmt.Errorf("truncating file for secondary storage: %w", truncateErr))
+	}
+	if secondaryErr := m.secondary.Download(ctx, file, key); secondaryErr != nil {
+		return errors.Join(err, fmt.Errorf("downloading from secondary storage: %w", secondaryErr))
+	}
+	return nil
+}
+
+// Delete deletes the files from both storages, succeeding if they got deleted from either of them, since a file is
+// only uploaded to one of them
+func (m *failoverFileManager) Delete(ctx context.Context, keys []string) error {
+	err := m.FileManager.Delete(ctx, keys)
+	secondaryErr := m.secondary.Delete(ctx, keys)
+	if err != nil && secondaryErr != nil {
+		return errors.Join(err, fmt.Errorf("deleting from secondary storage: %w", secondaryErr))
+	}
+	return nil
+}
+
+func (m *failoverFileManager) SetTimeout(timeout time.Duration) {
+	m.FileManager.SetTimeout(timeout)
+	m.secondary.SetTimeout(timeout)
+}
+
+func (m *failoverFileManager) GetObjectNameFromLocation(location string) (string, error) {
+	return m.fileManagerFor(location).GetObjectNameFromLocation(location)
+}
+
+func (m *failoverFileManager) GetDownloadKeyFromFileLocation(location string) string {
+	return m.fileManagerFor(location).GetDownloadKeyFromFileLocation(location)
+}
+
+// fileManagerFor returns the file manager of the storage the location belongs to, i.e. the storage whose bucket is
+// part of the location, preferring the longest bucket name if both are
+func (m *failoverFileManager) fileManagerFor(location string) filemanager.FileManager {
+	if m.secondaryBucket == "" || m.secondaryBucket == m.primaryBucket || !strings.Contains(location, m.secondaryBucket) {
+		return m.FileManager
+	}
+	if m.primaryBucket != "" && strings.Contains(location, m.primaryBucket) && len(m.primaryBucket) > len(m.secondaryBucket) {
+		return m.FileManager
+	}
+	return m.secondary
+}
+
+// bucket returns the bucket or container of the provider config
+func bucket(config map[string]interface{}) string {
+	if bucketName, _ := config["bucketName"].(string); bucketName != "" {
+		return bucketName
+	}
+	containerName, _ := config["containerName"].(string)
+	return containerName
+}
+
+func truncate(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := file.Seek(0, io.SeekStart)
+	return err
+}
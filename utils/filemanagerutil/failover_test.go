@@ -0,0 +1,106 @@
+package filemanagerutil_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	"github.com/rudderlabs/rudder-go-kit/filemanager/mock_filemanager"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/utils/filemanagerutil"
+)
+
+func TestFailoverFactory(t *testing.T) {
+	setup := func(t *testing.T, config map[string]interface{}) (filemanager.FileManager, *mock_filemanager.MockFileManager, *mock_filemanager.MockFileManager) {
+		ctrl := gomock.NewController(t)
+		primary, secondary := mock_filemanager.NewMockFileManager(ctrl), mock_filemanager.NewMockFileManager(ctrl)
+		fm, err := filemanagerutil.FailoverFactory(func(settings *filemanager.Settings) (filemanager.FileManager, error) {
+			if settings.Config["bucketName"] == "secondary-bucket" {
+				require.Equal(t, "eu-west-1", settings.Config["region"])
+				require.Equal(t, "accessKeyID", settings.Config["accessKeyID"], "secondary storage should inherit the primary's settings")
+				require.NotContains(t, settings.Config, filemanagerutil.SecondaryStorageKey)
+				return secondary, nil
+			}
+			return primary, nil
+		})(&filemanager.Settings{Provider: "S3", Config: config, Logger: logger.NOP})
+		require.NoError(t, err)
+		return fm, primary, secondary
+	}
+	config := map[string]interface{}{
+		"bucketName":  "primary-bucket",
+		"region":      "us-east-1",
+		"accessKeyID": "accessKeyID",
+		filemanagerutil.SecondaryStorageKey: map[string]interface{}{
+			"bucketName": "secondary-bucket",
+			"region":     "eu-west-1",
+		},
+	}
+	file, err := os.Create(filepath.Join(t.TempDir(), "file"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = file.Close() })
+
+	t.Run("no secondary storage", func(t *testing.T) {
+		fm, primary, _ := setup(t, map[string]interface{}{"bucketName": "primary-bucket"})
+		require.Equal(t, primary, fm)
+	})
+
+	t.Run("upload", func(t *testing.T) {
+		fm, primary, secondary := setup(t, config)
+		primary.EXPECT().Upload(gomock.Any(), file, "prefix").Return(filemanager.UploadedFile{Location: "https://primary-bucket.s3.amazonaws.com/prefix/file"}, nil)
+		uploaded, err := fm.Upload(context.Background(), file, "prefix")
+		require.NoError(t, err)
+		require.Equal(t, "https://primary-bucket.s3.amazonaws.com/prefix/file", uploaded.Location)
+
+		primary.EXPECT().Upload(gomock.Any(), file, "prefix").Return(filemanager.UploadedFile{}, errors.New("unavailable"))
+		secondary.EXPECT().Upload(gomock.Any(), file, "prefix").Return(filemanager.UploadedFile{Location: "https://secondary-bucket.s3.eu-west-1.amazonaws.com/prefix/file"}, nil)
+		uploaded, err = fm.Upload(context.Background(), file, "prefix")
+		require.NoError(t, err)
+		require.Equal(t, "https://secondary-bucket.s3.eu-west-1.amazonaws.com/prefix/file", uploaded.Location, "location should track the bucket serving the file")
+
+		primary.EXPECT().Upload(gomock.Any(), file, "prefix").Return(filemanager.UploadedFile{}, errors.New("unavailable"))
+		secondary.EXPECT().Upload(gomock.Any(), file, "prefix").Return(filemanager.UploadedFile{}, errors.New("also unavailable"))
+		_, err = fm.Upload(context.Background(), file, "prefix")
+		require.ErrorContains(t, err, "unavailable")
+		require.ErrorContains(t, err, "also unavailable")
+	})
+
+	t.Run("download", func(t *testing.T) {
+		fm, primary, secondary := setup(t, config)
+		primary.EXPECT().Download(gomock.Any(), file, "prefix/file").Return(filemanager.ErrKeyNotFound)
+		secondary.EXPECT().Download(gomock.Any(), file, "prefix/file").Return(nil)
+		require.NoError(t, fm.Download(context.Background(), file, "prefix/file"))
+
+		primary.EXPECT().Download(gomock.Any(), file, "prefix/file").Return(filemanager.ErrKeyNotFound)
+		secondary.EXPECT().Download(gomock.Any(), file, "prefix/file").Return(filemanager.ErrKeyNotFound)
+		require.ErrorIs(t, fm.Download(context.Background(), file, "prefix/file"), filemanager.ErrKeyNotFound)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		fm, primary, secondary := setup(t, config)
+		primary.EXPECT().Delete(gomock.Any(), []string{"key"}).Return(errors.New("not found"))
+		secondary.EXPECT().Delete(gomock.Any(), []string{"key"}).Return(nil)
+		require.NoError(t, fm.Delete(context.Background(), []string{"key"}))
+
+		primary.EXPECT().Delete(gomock.Any(), []string{"key"}).Return(errors.New("unavailable"))
+		secondary.EXPECT().Delete(gomock.Any(), []string{"key"}).Return(errors.New("unavailable"))
+		require.Error(t, fm.Delete(context.Background(), []string{"key"}))
+	})
+
+	t.Run("locations", func(t *testing.T) {
+		fm, primary, secondary := setup(t, config)
+		primary.EXPECT().GetDownloadKeyFromFileLocation("https://primary-bucket.s3.amazonaws.com/prefix/file").Return("prefix/file")
+		secondary.EXPECT().GetDownloadKeyFromFileLocation("https://s3.eu-west-1.amazonaws.com/secondary-bucket/prefix/file").Return("prefix/file")
+		secondary.EXPECT().GetObjectNameFromLocation("https://s3.eu-west-1.amazonaws.com/secondary-bucket/prefix/file").Return("prefix/file", nil)
+		require.Equal(t, "prefix/file", fm.GetDownloadKeyFromFileLocation("https://primary-bucket.s3.amazonaws.com/prefix/file"))
+		require.Equal(t, "prefix/file", fm.GetDownloadKeyFromFileLocation("https://s3.eu-west-1.amazonaws.com/secondary-bucket/prefix/file"))
+		objectName, err := fm.GetObjectNameFromLocation("https://s3.eu-west-1.amazonaws.com/secondary-bucket/prefix/file")
+		require.NoError(t, err)
+		require.Equal(t, "prefix/file", objectName)
+	})
+}
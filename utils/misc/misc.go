@@ -13,6 +13,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -685,9 +686,39 @@ func GetObjectStorageConfig(opts ObjectStorageOptsT) map[string]interface{} {
 		}
 		objectStorageConfigMap = clonedObjectStorageConfig
 	}
+	if opts.Provider == "MINIO" {
+		objectStorageConfigMap = s3CompatibleStorageConfig(objectStorageConfigMap)
+	}
 	return objectStorageConfigMap
 }
 
+// s3CompatibleStorageConfig returns the config of an S3 compatible storage for the MinIO file manager, which expects
+// the endpoint as host[:port]. Endpoints configured as URLs are split into their host and scheme, and the endpoint of
+// Cloudflare R2 buckets is derived from their account id, if not configured.
+func s3CompatibleStorageConfig(objectStorageConfigMap map[string]interface{}) map[string]interface{} {
+	endPoint, _ := objectStorageConfigMap["endPoint"].(string)
+	bucketProvider, _ := objectStorageConfigMap["bucketProvider"].(string)
+	accountID, _ := objectStorageConfigMap["accountId"].(string)
+
+	clonedObjectStorageConfig := make(map[string]interface{}, len(objectStorageConfigMap))
+	for k, v := range objectStorageConfigMap {
+		clonedObjectStorageConfig[k] = v
+	}
+	switch {
+	case endPoint == "" && bucketProvider == "CLOUDFLARE_R2" && accountID != "":
+		clonedObjectStorageConfig["endPoint"] = accountID + ".r2.cloudflarestorage.com"
+		clonedObjectStorageConfig["useSSL"] = true
+	case strings.HasPrefix(endPoint, "http://") || strings.HasPrefix(endPoint, "https://"):
+		if u, err := url.Parse(endPoint); err == nil && u.Host != "" {
+			clonedObjectStorageConfig["endPoint"] = u.Host
+			clonedObjectStorageConfig["useSSL"] = u.Scheme == "https"
+		}
+	default:
+		return objectStorageConfigMap
+	}
+	return clonedObjectStorageConfig
+}
+
 // GetParsedTimestamp returns the parsed timestamp
 func GetParsedTimestamp(input interface{}) (time.Time, bool) {
 	var parsedTimestamp time.Time
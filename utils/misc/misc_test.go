@@ -603,6 +603,38 @@ func TestGetObjectStorageConfig(t *testing.T) {
 		require.Equal(t, "someOtherAccessKeyID", config["accessKeyID"])
 		require.Equal(t, "someOtherAccessKey", config["accessKey"])
 	})
+
+	t.Run("MinIO with endpoint url", func(t *testing.T) {
+		destConfig := map[string]interface{}{
+			"endPoint": "https://storage.example.com:9000",
+			"useSSL":   false,
+		}
+		config := GetObjectStorageConfig(ObjectStorageOptsT{
+			Provider: "MINIO",
+			Config:   destConfig,
+		})
+		require.Equal(t, "storage.example.com:9000", config["endPoint"])
+		require.Equal(t, true, config["useSSL"])
+		require.Equal(t, "https://storage.example.com:9000", destConfig["endPoint"], "destination config should not be modified")
+
+		config = GetObjectStorageConfig(ObjectStorageOptsT{
+			Provider: "MINIO",
+			Config:   map[string]interface{}{"endPoint": "localhost:9000"},
+		})
+		require.Equal(t, "localhost:9000", config["endPoint"])
+	})
+
+	t.Run("Cloudflare R2", func(t *testing.T) {
+		config := GetObjectStorageConfig(ObjectStorageOptsT{
+			Provider: "MINIO",
+			Config: map[string]interface{}{
+				"bucketProvider": "CLOUDFLARE_R2",
+				"accountId":      "someAccountID",
+			},
+		})
+		require.Equal(t, "someAccountID.r2.cloudflarestorage.com", config["endPoint"])
+		require.Equal(t, true, config["useSSL"])
+	})
 }
 
 // FolderExists Check if folder exists at particular path
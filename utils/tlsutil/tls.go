@@ -0,0 +1,71 @@
+// Package tlsutil builds tls configurations for connecting to destinations which use a private certificate authority
+// and/or require clients to authenticate with a certificate (mutual tls)
+package tlsutil
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Config is the tls configuration of a destination
+type Config struct {
+	CACertificate string // PEM encoded bundle of certificate authorities for verifying the server's certificate
+	ClientCert    string // PEM encoded client certificate chain
+	ClientKey     string // PEM encoded private key of the client certificate
+}
+
+// FromDestinationConfig returns the tls configuration found in the caCertificate, clientCert and clientKey settings of a destination's config
+func FromDestinationConfig(config map[string]interface{}) Config {
+	value := func(key string) string {
+		v, _ := config[key].(string)
+		return strings.TrimSpace(v)
+	}
+	return Config{
+		CACertificate: value("caCertificate"),
+		ClientCert:    value("clientCert"),
+		ClientKey:     value("clientKey"),
+	}
+}
+
+// IsEmpty returns true if neither a certificate authority bundle nor a client certificate are configured
+func (c Config) IsEmpty() bool {
+	return c.CACertificate == "" && c.ClientCert == "" && c.ClientKey == ""
+}
+
+// Checksum returns a checksum of the configuration, for detecting changes without keeping the private key around
+func (c Config) Checksum() string {
+	h := sha256.New()
+	for _, v := range []string{c.CACertificate, c.ClientCert, c.ClientKey} {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// ClientConfig returns a client [tls.Config] which verifies the server's certificate against the configured certificate authorities
+// instead of the system's ones (if any are configured) and presents the configured client certificate (if any) to the server
+func (c Config) ClientConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.CACertificate != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(c.CACertificate)) {
+			return nil, errors.New("no valid certificates found in the certificate authority bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.ClientCert != "" || c.ClientKey != "" {
+		if c.ClientCert == "" || c.ClientKey == "" {
+			return nil, errors.New("both a client certificate and a client key are required")
+		}
+		certificate, err := tls.X509KeyPair([]byte(c.ClientCert), []byte(c.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
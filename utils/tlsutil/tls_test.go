@@ -0,0 +1,136 @@
+package tlsutil_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/utils/tlsutil"
+)
+
+func TestClientConfig(t *testing.T) {
+	ca, caKey, caPEM := newCertificate(t, nil, nil, true)
+	serverCert, serverKey, _ := newCertificate(t, ca, caKey, false)
+	_, clientKey, clientCertPEM := newCertificate(t, ca, caKey, false)
+	clientKeyPEM := keyPEM(t, clientKey)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	get := func(c tlsutil.Config) error {
+		tlsConfig, err := c.ClientConfig()
+		require.NoError(t, err)
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return nil
+	}
+
+	t.Run("from destination config", func(t *testing.T) {
+		c := tlsutil.FromDestinationConfig(map[string]interface{}{
+			"caCertificate": " " + caPEM + "\n",
+			"clientCert":    clientCertPEM,
+			"clientKey":     clientKeyPEM,
+			"other":         "value",
+		})
+		require.False(t, c.IsEmpty())
+		require.Equal(t, tlsutil.Config{
+			CACertificate: strings.TrimSpace(caPEM),
+			ClientCert:    strings.TrimSpace(clientCertPEM),
+			ClientKey:     strings.TrimSpace(clientKeyPEM),
+		}, c)
+		require.True(t, tlsutil.FromDestinationConfig(map[string]interface{}{"caCertificate": 1}).IsEmpty())
+	})
+
+	t.Run("checksum", func(t *testing.T) {
+		c := tlsutil.Config{CACertificate: caPEM, ClientCert: clientCertPEM, ClientKey: clientKeyPEM}
+		require.Equal(t, c.Checksum(), c.Checksum())
+		require.NotEqual(t, c.Checksum(), tlsutil.Config{CACertificate: caPEM, ClientCert: clientCertPEM}.Checksum())
+		require.NotEqual(t, tlsutil.Config{CACertificate: "ab"}.Checksum(), tlsutil.Config{ClientCert: "ab"}.Checksum())
+	})
+
+	t.Run("mutual tls", func(t *testing.T) {
+		require.NoError(t, get(tlsutil.Config{CACertificate: caPEM, ClientCert: clientCertPEM, ClientKey: clientKeyPEM}))
+	})
+
+	t.Run("without client certificate", func(t *testing.T) {
+		require.Error(t, get(tlsutil.Config{CACertificate: caPEM}), "the server should reject clients without a certificate")
+	})
+
+	t.Run("without certificate authority", func(t *testing.T) {
+		require.Error(t, get(tlsutil.Config{ClientCert: clientCertPEM, ClientKey: clientKeyPEM}), "the server's certificate should not be trusted")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := tlsutil.Config{CACertificate: "invalid"}.ClientConfig()
+		require.Error(t, err)
+		_, err = tlsutil.Config{CACertificate: caPEM, ClientCert: clientCertPEM}.ClientConfig()
+		require.Error(t, err)
+		_, err = tlsutil.Config{ClientCert: clientCertPEM, ClientKey: "invalid"}.ClientConfig()
+		require.Error(t, err)
+	})
+}
+
+// newCertificate returns a new certificate signed by the parent certificate, or a self-signed one if there is no parent
+func newCertificate(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, isCA bool) (*x509.Certificate, *ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		DNSNames:     []string{"localhost"},
+
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func keyPEM(t *testing.T, key *ecdsa.PrivateKey) string {
+	t.Helper()
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+}
@@ -58,6 +58,25 @@ type StatusDetail struct {
 	ErrorType      string           `json:"errorType"`
 	ViolationCount int64            `json:"violationCount"`
 	FailedMessages []*FailedMessage `json:"-"`
+	Latency        Latency          `json:"-"`
+}
+
+// Latency is the latency of the events of a status, from their receipt by the gateway until they reached the status
+type Latency struct {
+	Sum   time.Duration
+	Max   time.Duration
+	Count int64
+}
+
+// Observe adds the latency of an event received at receivedAt, ignoring events without a receipt time
+func (l *Latency) Observe(receivedAt time.Time) {
+	if receivedAt.IsZero() {
+		return
+	}
+	latency := time.Since(receivedAt)
+	l.Sum += latency
+	l.Max = max(l.Max, latency)
+	l.Count++
 }
 
 type FailedMessage struct {
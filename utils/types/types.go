@@ -37,6 +37,11 @@ type EventParams struct {
 	SourceTaskRunId string `json:"source_task_run_id"`
 	TraceParent     string `json:"traceparent"`
 	DestinationID   string `json:"destination_id"`
+	// CorrelationID correlates the event with every job derived from it, across all of its destinations
+	CorrelationID string `json:"correlation_id"`
+	// DestinationIDs restricts the events to several destinations, e.g. for backfills
+	DestinationIDs []string `json:"destination_ids,omitempty"`
+	DLQReplay      bool     `json:"dlq_replay"` // true for events re-run from the transformation dead-letter queue
 }
 
 // UserSuppression is interface to access Suppress user feature
@@ -2,6 +2,7 @@ package admin
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -9,6 +10,8 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-server/internal/audit"
+	"github.com/rudderlabs/rudder-server/services/sql-migrator/online"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
 	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
@@ -33,6 +36,7 @@ type ConfigurationTestOutput struct {
 type Admin struct {
 	connectionSources  connectionSourcesFetcher
 	createUploadAlways createUploadAlwaysSetter
+	onlineMigrations   onlineMigrationsProgress
 	logger             logger.Logger
 }
 
@@ -44,14 +48,20 @@ type createUploadAlwaysSetter interface {
 	Store(bool)
 }
 
+type onlineMigrationsProgress interface {
+	Progress(ctx context.Context) ([]online.Progress, error)
+}
+
 func New(
 	connectionSources connectionSourcesFetcher,
 	createUploadAlways createUploadAlwaysSetter,
+	onlineMigrations onlineMigrationsProgress,
 	logger logger.Logger,
 ) *Admin {
 	return &Admin{
 		connectionSources:  connectionSources,
 		createUploadAlways: createUploadAlways,
+		onlineMigrations:   onlineMigrations,
 		logger:             logger.Child("admin"),
 	}
 }
@@ -59,6 +69,8 @@ func New(
 // TriggerUpload sets uploads to start without delay
 func (a *Admin) TriggerUpload(off bool, reply *string) error {
 	a.createUploadAlways.Store(!off)
+	parameters, _ := json.Marshal(map[string]bool{"off": off})
+	audit.Default.Record(context.Background(), audit.Entry{Actor: audit.AdminCLIActor, Action: "Warehouse.TriggerUpload", Parameters: parameters})
 	if off {
 		*reply = "Turned off explicit warehouse upload triggers.\nWarehouse uploads will continue to be done as per schedule in control plane."
 	} else {
@@ -67,6 +79,16 @@ func (a *Admin) TriggerUpload(off bool, reply *string) error {
 	return nil
 }
 
+// OnlineMigrations returns the progress of the online migrations of the warehouse metadata tables
+func (a *Admin) OnlineMigrations(_ struct{}, reply *[]online.Progress) error {
+	progress, err := a.onlineMigrations.Progress(context.TODO())
+	if err != nil {
+		return err
+	}
+	*reply = progress
+	return nil
+}
+
 // Query the underlying warehouse
 func (a *Admin) Query(s QueryInput, reply *warehouseutils.QueryResult) error {
 	if strings.TrimSpace(s.DestID) == "" {
@@ -24,6 +24,8 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	protobuf "google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
@@ -35,6 +37,7 @@ import (
 
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/controlplane"
+	"github.com/rudderlabs/rudder-server/internal/audit"
 	proto "github.com/rudderlabs/rudder-server/proto/warehouse"
 	"github.com/rudderlabs/rudder-server/utils/filemanagerutil"
 	"github.com/rudderlabs/rudder-server/utils/misc"
@@ -143,7 +146,7 @@ func NewGRPCServer(
 		UseTLS:        g.config.cpRouterUseTLS,
 		Logger:        g.logger,
 		Options: []grpc.ServerOption{
-			grpc.UnaryInterceptor(statsInterceptor(statsFactory)),
+			grpc.ChainUnaryInterceptor(statsInterceptor(statsFactory), auditInterceptor()),
 		},
 		RegisterService: func(srv *grpc.Server) {
 			proto.RegisterWarehouseServer(srv, g)
@@ -216,6 +219,7 @@ func (g *GRPC) GetWHUploads(ctx context.Context, request *proto.WHUploadsRequest
 		DestinationType: request.DestinationType,
 		Status:          request.Status,
 		WorkspaceID:     request.WorkspaceId,
+		Tags:            request.Tags,
 	}
 
 	var uploadInfos []model.UploadInfo
@@ -258,6 +262,7 @@ func (g *GRPC) GetWHUploads(ctx context.Context, request *proto.WHUploadsRequest
 			Duration:         int32(item.Duration),
 			Tables:           []*proto.WHTable{},
 			IsArchivedUpload: item.IsArchivedUpload,
+			Tags:             item.Tags,
 		}
 		if !item.NextRetryTime.IsZero() {
 			ur.NextRetryTime = timestamppb.New(item.NextRetryTime)
@@ -328,6 +333,11 @@ func (g *GRPC) GetWHUpload(ctx context.Context, request *proto.WHUploadRequest)
 			Error:    item.Error,
 			Count:    int32(item.Count),
 			Duration: int32(item.Duration),
+
+			LoadAttempt:    int32(item.LoadAttempt),
+			LoadDurationMs: item.LoadDuration,
+			RowsLoaded:     item.RowsLoaded,
+			BytesLoaded:    item.BytesLoaded,
 		}
 		if !item.LastExecAt.IsZero() {
 			wht.LastExecAt = timestamppb.New(item.LastExecAt)
@@ -350,6 +360,8 @@ func (g *GRPC) GetWHUpload(ctx context.Context, request *proto.WHUploadRequest)
 		Duration:         int32(syncUploadInfo.Duration),
 		Tables:           tables,
 		IsArchivedUpload: syncUploadInfo.IsArchivedUpload,
+		Tags:             syncUploadInfo.Tags,
+		CleanupStatus:    syncUploadInfo.CleanupStatus,
 	}
 	if !syncUploadInfo.NextRetryTime.IsZero() {
 		ur.NextRetryTime = timestamppb.New(syncUploadInfo.NextRetryTime)
@@ -423,7 +435,7 @@ func (g *GRPC) TriggerWHUploads(ctx context.Context, request *proto.WHUploadsReq
 	}
 
 	for _, warehouse := range wh {
-		g.triggerStore.Store(warehouse.Identifier, struct{}{})
+		g.triggerStore.Store(warehouse.Identifier, request.Tags)
 	}
 
 	// TODO: Remove http status code and use grpc status code. Since it requires compatibility on the cp router side, leaving it as it is for now.
@@ -990,6 +1002,35 @@ func statsInterceptor(statsFactory stats.Stats) grpc.UnaryServerInterceptor {
 	}
 }
 
+// auditedMethods are the methods making mutations, recorded to the audit log
+var auditedMethods = map[string]struct{}{
+	proto.Warehouse_TriggerWHUpload_FullMethodName:    {},
+	proto.Warehouse_TriggerWHUploads_FullMethodName:   {},
+	proto.Warehouse_RetryWHUploads_FullMethodName:     {},
+	proto.Warehouse_RetryFailedBatches_FullMethodName: {},
+}
+
+// auditInterceptor records the calls of the audited methods, which are made by the control plane, to the audit log
+func auditInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if _, ok := auditedMethods[info.FullMethod]; !ok {
+			return handler(ctx, req)
+		}
+		res, err := handler(ctx, req)
+		var parameters []byte
+		if m, ok := req.(protobuf.Message); ok {
+			parameters, _ = protojson.Marshal(m)
+		}
+		audit.Default.Record(ctx, audit.Entry{
+			Actor:      audit.ControlPlaneActor,
+			Action:     info.FullMethod,
+			Parameters: parameters,
+			Outcome:    status.Code(err).String(),
+		})
+		return res, err
+	}
+}
+
 func (g *GRPC) GetFirstAbortedUploadInContinuousAbortsByDestination(
 	ctx context.Context,
 	request *proto.FirstAbortedUploadInContinuousAbortsByDestinationRequest,
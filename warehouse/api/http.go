@@ -8,14 +8,17 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/rudderlabs/rudder-server/utils/crash"
+	"github.com/rudderlabs/rudder-server/utils/timeutil"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/mode"
 
+	"github.com/rudderlabs/rudder-server/internal/audit"
 	"github.com/rudderlabs/rudder-server/services/notifier"
 	"github.com/rudderlabs/rudder-server/warehouse/bcm"
 
@@ -34,9 +37,12 @@ import (
 	sqlmw "github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/repo"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/streaming"
 	"github.com/rudderlabs/rudder-server/warehouse/multitenant"
+	"github.com/rudderlabs/rudder-server/warehouse/router"
 	"github.com/rudderlabs/rudder-server/warehouse/source"
 	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+	"github.com/rudderlabs/rudder-server/warehouse/validations"
 )
 
 const triggerUploadQPName = "triggerUpload"
@@ -61,9 +67,110 @@ type fetchTablesResponse struct {
 	ConnectionsTables []warehouseutils.FetchTableInfo `json:"connections_tables"`
 }
 
+type stagingFileDeliveryResponse struct {
+	StagingFileID int64  `json:"staging_file_id"`
+	Status        string `json:"status"`
+	UploadID      int64  `json:"upload_id,omitempty"`
+	UploadStatus  string `json:"upload_status,omitempty"`
+}
+
+type uploadEstimatesResponse struct {
+	Estimates []uploadEstimateResponse `json:"estimates"`
+}
+
+type uploadEstimateResponse struct {
+	SourceID            string           `json:"source_id"`
+	DestinationID       string           `json:"destination_id"`
+	DestinationType     string           `json:"destination_type"`
+	Namespace           string           `json:"namespace"`
+	PendingStagingFiles int              `json:"pending_staging_files"`
+	TotalEvents         int64            `json:"total_events"`
+	TotalBytes          int64            `json:"total_bytes"`
+	EventsPerTable      map[string]int64 `json:"events_per_table"`
+	FirstEventAt        *time.Time       `json:"first_event_at,omitempty"`
+	LastEventAt         *time.Time       `json:"last_event_at,omitempty"`
+}
+
+type syncStatusesResponse struct {
+	Syncs []syncStatusResponse `json:"syncs"`
+}
+
+type syncStatusResponse struct {
+	SourceID        string              `json:"source_id"`
+	DestinationID   string              `json:"destination_id"`
+	DestinationType string              `json:"destination_type"`
+	Namespace       string              `json:"namespace"`
+	NextScheduledAt time.Time           `json:"next_scheduled_at"`
+	LastSucceededAt *time.Time          `json:"last_succeeded_at,omitempty"`
+	PendingUploads  int64               `json:"pending_uploads"`
+	InProgress      *inProgressResponse `json:"in_progress,omitempty"`
+}
+
+type inProgressResponse struct {
+	UploadID       int64      `json:"upload_id"`
+	Status         string     `json:"status"`
+	Stage          string     `json:"stage,omitempty"`
+	StageStartedAt *time.Time `json:"stage_started_at,omitempty"`
+}
+
+type eventSchemasResponse struct {
+	EventSchemas []eventSchemaResponse `json:"event_schemas"`
+}
+
+type eventSchemaResponse struct {
+	SourceID      string                         `json:"source_id"`
+	DestinationID string                         `json:"destination_id"`
+	Namespace     string                         `json:"namespace"`
+	TableName     string                         `json:"table_name"`
+	Version       int                            `json:"version"`
+	Properties    map[string]model.EventProperty `json:"properties"`
+	Observations  int64                          `json:"observations"`
+	CreatedAt     time.Time                      `json:"created_at"`
+	UpdatedAt     time.Time                      `json:"updated_at"`
+}
+
+type streamRequest struct {
+	SourceID      string            `json:"source_id"`
+	DestinationID string            `json:"destination_id"`
+	Events        []json.RawMessage `json:"events"`
+}
+
+type streamResponse struct {
+	// Streamed is whether each of the events of the request got streamed, the ones which didn't being left to the uploads
+	Streamed []bool `json:"streamed"`
+}
+
+type renameRequest struct {
+	SourceID      string `json:"source_id"`
+	DestinationID string `json:"destination_id"`
+	// TableName is the table of the namespace of the connection to rename, the namespace getting renamed if empty
+	TableName string `json:"table_name"`
+	NewName   string `json:"new_name"`
+}
+
+type renameResponse struct {
+	ID            int64     `json:"id"`
+	SourceID      string    `json:"source_id"`
+	DestinationID string    `json:"destination_id"`
+	Namespace     string    `json:"namespace"`
+	TableName     string    `json:"table_name,omitempty"`
+	NewName       string    `json:"new_name"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+	Attempts      int       `json:"attempts"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type testConnectionRequest struct {
+	Destination backendconfig.DestinationT `json:"destination"`
+}
+
 type triggerUploadRequest struct {
 	SourceID      string `json:"source_id"`
 	DestinationID string `json:"destination_id"`
+	// Tags are attached to the uploads created for the trigger
+	Tags map[string]string `json:"tags"`
 }
 
 type Api struct {
@@ -79,7 +186,14 @@ type Api struct {
 	stagingRepo   *repo.StagingFiles
 	uploadRepo    *repo.Uploads
 	schemaRepo    *repo.WHSchema
+	eventSchemas  *repo.EventSchemas
+	streaming     *streaming.Service
+	renameRepo    *repo.Renames
 	triggerStore  *sync.Map
+	conf          *config.Config
+	now           func() time.Time
+	// verifyConnection tests the connection to the warehouse of a destination, see validations.VerifyConnection
+	verifyConnection func(context.Context, *backendconfig.DestinationT) *model.ConnectionTestResponse
 
 	config struct {
 		healthTimeout       time.Duration
@@ -104,20 +218,26 @@ func NewApi(
 	triggerStore *sync.Map,
 ) *Api {
 	a := &Api{
-		mode:          mode,
-		logger:        log.Child("api"),
-		db:            db,
-		notifier:      notifier,
-		bcConfig:      bcConfig,
-		statsFactory:  statsFactory,
-		tenantManager: tenantManager,
-		bcManager:     bcManager,
-		sourceManager: sourceManager,
-		triggerStore:  triggerStore,
-		stagingRepo:   repo.NewStagingFiles(db),
-		uploadRepo:    repo.NewUploads(db),
-		schemaRepo:    repo.NewWHSchemas(db),
+		mode:             mode,
+		logger:           log.Child("api"),
+		db:               db,
+		notifier:         notifier,
+		bcConfig:         bcConfig,
+		statsFactory:     statsFactory,
+		tenantManager:    tenantManager,
+		bcManager:        bcManager,
+		sourceManager:    sourceManager,
+		triggerStore:     triggerStore,
+		conf:             conf,
+		now:              timeutil.Now,
+		verifyConnection: validations.VerifyConnection,
+		stagingRepo:      repo.NewStagingFiles(db),
+		uploadRepo:       repo.NewUploads(db),
+		schemaRepo:       repo.NewWHSchemas(db),
+		eventSchemas:     repo.NewEventSchemas(db),
+		renameRepo:       repo.NewRenames(db),
 	}
+	a.streaming = streaming.New(conf, log, statsFactory, bcManager, a.schemaRepo)
 	a.config.healthTimeout = conf.GetDuration("Warehouse.healthTimeout", 10, time.Second)
 	a.config.readerHeaderTimeout = conf.GetDuration("Warehouse.readerHeaderTimeout", 3, time.Second)
 	a.config.runningMode = conf.GetString("Warehouse.runningMode", "")
@@ -150,6 +270,7 @@ func (a *Api) Start(ctx context.Context) error {
 		Handler:           crash.Handler(srvMux),
 		ReadHeaderTimeout: a.config.readerHeaderTimeout,
 	}
+	defer a.streaming.Cleanup(context.Background())
 	return kithttputil.ListenAndServe(ctx, srv)
 }
 
@@ -159,16 +280,22 @@ func (a *Api) addMasterEndpoints(ctx context.Context, r chi.Router) {
 	a.bcConfig.WaitForConfig(ctx)
 
 	r.Handle("/v1/process", (&api.WarehouseAPI{
+		Conf:        a.conf,
 		Logger:      a.logger,
 		Stats:       a.statsFactory,
 		Repo:        a.stagingRepo,
 		Multitenant: a.tenantManager,
+		Warehouses:  a.bcManager,
 	}).Handler())
 
 	r.Route("/v1", func(r chi.Router) {
 		r.Route("/warehouse", func(r chi.Router) {
+			r.Use(audit.Default.Middleware)
 			r.Post("/pending-events", a.logMiddleware(a.pendingEventsHandler))
 			r.Post("/trigger-upload", a.logMiddleware(a.triggerUploadHandler))
+			r.Get("/upload-estimate", a.logMiddleware(a.uploadEstimateHandler))
+			r.Get("/sync-status", a.logMiddleware(a.syncStatusHandler))
+			r.Post("/test-connection", a.logMiddleware(a.testConnectionHandler))
 
 			r.Post("/jobs", a.logMiddleware(a.sourceManager.InsertJobHandler))       // TODO: add degraded mode
 			r.Get("/jobs/status", a.logMiddleware(a.sourceManager.StatusJobHandler)) // TODO: add degraded mode
@@ -180,6 +307,12 @@ func (a *Api) addMasterEndpoints(ctx context.Context, r chi.Router) {
 		r.Route("/v1", func(r chi.Router) {
 			r.Route("/warehouse", func(r chi.Router) {
 				r.Get("/fetch-tables", a.logMiddleware(a.fetchTablesHandler))
+				r.Get("/staging-files/delivery", a.logMiddleware(a.stagingFileDeliveryHandler))
+				r.Post("/stream", a.logMiddleware(a.streamHandler))
+				r.Get("/event-schemas", a.logMiddleware(a.eventSchemasHandler))
+				r.Get("/event-schemas/versions", a.logMiddleware(a.eventSchemaVersionsHandler))
+				r.Post("/renames", a.logMiddleware(a.renameHandler))
+				r.Get("/renames", a.logMiddleware(a.renameStatusHandler))
 			})
 		})
 	})
@@ -397,12 +530,211 @@ func (a *Api) triggerUploadHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, warehouse := range wh {
-		a.triggerStore.Store(warehouse.Identifier, struct{}{})
+		a.triggerStore.Store(warehouse.Identifier, payload.Tags)
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
+// uploadEstimateHandler returns an estimate of the next upload of the destinations connected to a source, or of a
+// destination, from their pending staging files, so that operators can decide whether to trigger an upload now or wait
+func (a *Api) uploadEstimateHandler(w http.ResponseWriter, r *http.Request) {
+	sourceID := r.URL.Query().Get("source_id")
+	destinationID := r.URL.Query().Get("destination_id")
+	if sourceID == "" && destinationID == "" {
+		http.Error(w, "either source_id or destination_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var wh []model.Warehouse
+	if destinationID == "" {
+		wh = a.bcManager.WarehousesBySourceID(sourceID)
+	} else {
+		for _, warehouse := range a.bcManager.WarehousesByDestID(destinationID) {
+			if sourceID == "" || warehouse.Source.ID == sourceID {
+				wh = append(wh, warehouse)
+			}
+		}
+	}
+	if len(wh) == 0 {
+		a.logger.Warnw("no warehouse found for upload estimate",
+			lf.SourceID, sourceID,
+			lf.DestinationID, destinationID,
+		)
+		http.Error(w, ierrors.ErrNoWarehouseFound.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res := uploadEstimatesResponse{Estimates: make([]uploadEstimateResponse, 0, len(wh))}
+	for _, warehouse := range wh {
+		estimate, err := a.stagingRepo.Estimate(r.Context(), warehouse.Source.ID, warehouse.Destination.ID)
+		if err != nil {
+			if errors.Is(r.Context().Err(), context.Canceled) {
+				http.Error(w, ierrors.ErrRequestCancelled.Error(), http.StatusBadRequest)
+				return
+			}
+			a.logger.Errorw("estimating upload",
+				lf.SourceID, warehouse.Source.ID,
+				lf.DestinationID, warehouse.Destination.ID,
+				lf.Error, err.Error(),
+			)
+			http.Error(w, "can't estimate upload", http.StatusInternalServerError)
+			return
+		}
+
+		estimateRes := uploadEstimateResponse{
+			SourceID:            warehouse.Source.ID,
+			DestinationID:       warehouse.Destination.ID,
+			DestinationType:     warehouse.Type,
+			Namespace:           warehouse.Namespace,
+			PendingStagingFiles: estimate.PendingStagingFiles,
+			TotalEvents:         estimate.TotalEvents,
+			TotalBytes:          estimate.TotalBytes,
+			EventsPerTable:      estimate.EventsPerTable,
+		}
+		if !estimate.FirstEventAt.IsZero() {
+			estimateRes.FirstEventAt = &estimate.FirstEventAt
+		}
+		if !estimate.LastEventAt.IsZero() {
+			estimateRes.LastEventAt = &estimate.LastEventAt
+		}
+		res.Estimates = append(res.Estimates, estimateRes)
+	}
+
+	resBody, err := json.Marshal(res)
+	if err != nil {
+		a.logger.Errorw("marshalling response for upload estimate", lf.Error, err.Error())
+		http.Error(w, ierrors.ErrMarshallResponse.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(resBody)
+}
+
+// syncStatusHandler returns the state of the syncs of the connections of a source or destination: when the next upload
+// is scheduled, when the last one succeeded and the stage of the one in progress, for external schedulers to wait on
+// the syncs without access to the database
+func (a *Api) syncStatusHandler(w http.ResponseWriter, r *http.Request) {
+	sourceID := r.URL.Query().Get("source_id")
+	destinationID := r.URL.Query().Get("destination_id")
+	if sourceID == "" && destinationID == "" {
+		http.Error(w, "either source_id or destination_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var wh []model.Warehouse
+	if destinationID == "" {
+		wh = a.bcManager.WarehousesBySourceID(sourceID)
+	} else {
+		for _, warehouse := range a.bcManager.WarehousesByDestID(destinationID) {
+			if sourceID == "" || warehouse.Source.ID == sourceID {
+				wh = append(wh, warehouse)
+			}
+		}
+	}
+	if len(wh) == 0 {
+		a.logger.Warnw("no warehouse found for sync status",
+			lf.SourceID, sourceID,
+			lf.DestinationID, destinationID,
+		)
+		http.Error(w, ierrors.ErrNoWarehouseFound.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := a.now()
+	res := syncStatusesResponse{Syncs: make([]syncStatusResponse, 0, len(wh))}
+	for _, warehouse := range wh {
+		status, err := a.uploadRepo.SyncStatus(r.Context(), warehouse.Source.ID, warehouse.Destination.ID)
+		if err != nil {
+			if errors.Is(r.Context().Err(), context.Canceled) {
+				http.Error(w, ierrors.ErrRequestCancelled.Error(), http.StatusBadRequest)
+				return
+			}
+			a.logger.Errorw("getting sync status",
+				lf.SourceID, warehouse.Source.ID,
+				lf.DestinationID, warehouse.Destination.ID,
+				lf.Error, err.Error(),
+			)
+			http.Error(w, "can't get sync status", http.StatusInternalServerError)
+			return
+		}
+
+		syncRes := syncStatusResponse{
+			SourceID:        warehouse.Source.ID,
+			DestinationID:   warehouse.Destination.ID,
+			DestinationType: warehouse.Type,
+			Namespace:       warehouse.Namespace,
+			NextScheduledAt: router.NextScheduledTime(a.conf, warehouse, status.LastCreatedAt, now),
+			PendingUploads:  status.PendingUploads,
+		}
+		if _, isTriggered := a.triggerStore.Load(warehouse.Identifier); isTriggered {
+			syncRes.NextScheduledAt = now
+		}
+		if !status.LastSucceededAt.IsZero() {
+			syncRes.LastSucceededAt = &status.LastSucceededAt
+		}
+		if inProgress := status.InProgress; inProgress != nil {
+			syncRes.InProgress = &inProgressResponse{
+				UploadID: inProgress.ID,
+				Status:   inProgress.Status,
+				Stage:    inProgress.Stage,
+			}
+			if !inProgress.StageStartedAt.IsZero() {
+				syncRes.InProgress.StageStartedAt = &inProgress.StageStartedAt
+			}
+		}
+		res.Syncs = append(res.Syncs, syncRes)
+	}
+
+	resBody, err := json.Marshal(res)
+	if err != nil {
+		a.logger.Errorw("marshalling response for sync status", lf.Error, err.Error())
+		http.Error(w, ierrors.ErrMarshallResponse.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(resBody)
+}
+
+// testConnectionHandler tests the connection to the warehouse of the destination of the request, which needn't be saved
+// yet, through the manager of the warehouse. Failing connections are reported along with the stage and the type of
+// their error, so that e.g. invalid credentials can be told apart from missing permissions.
+func (a *Api) testConnectionHandler(w http.ResponseWriter, r *http.Request) {
+	defer func() { _ = r.Body.Close() }()
+
+	var payload testConnectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		a.logger.Warnw("invalid JSON in request body for test connection", lf.Error, err.Error())
+		http.Error(w, ierrors.ErrInvalidJSONRequestBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(payload.Destination.Config) == 0 {
+		http.Error(w, "destination config is empty", http.StatusBadRequest)
+		return
+	}
+
+	res := a.verifyConnection(r.Context(), &payload.Destination)
+	if !res.Success {
+		a.logger.Infow("connection test failed",
+			lf.WorkspaceID, payload.Destination.WorkspaceID,
+			lf.DestinationID, payload.Destination.ID,
+			lf.DestinationType, payload.Destination.DestinationDefinition.Name,
+			"stage", res.Stage,
+			"errorType", res.ErrorType,
+			lf.Error, res.Error,
+		)
+	}
+
+	resBody, err := json.Marshal(res)
+	if err != nil {
+		a.logger.Errorw("marshalling response for test connection", lf.Error, err.Error())
+		http.Error(w, ierrors.ErrMarshallResponse.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(resBody)
+}
+
 func (a *Api) fetchTablesHandler(w http.ResponseWriter, r *http.Request) {
 	defer func() { _ = r.Body.Close() }()
 
@@ -436,6 +768,262 @@ func (a *Api) fetchTablesHandler(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(resBody)
 }
 
+// stagingFileDeliveryHandler returns the status of the staging file of a source and destination at a location, along
+// with the status of the upload loading it, for following the events the batch router uploaded to it
+func (a *Api) stagingFileDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	sourceID := r.URL.Query().Get("source_id")
+	destinationID := r.URL.Query().Get("destination_id")
+	location := r.URL.Query().Get("location")
+	if sourceID == "" || destinationID == "" || location == "" {
+		http.Error(w, "source_id, destination_id and location are required", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := a.stagingRepo.Delivery(r.Context(), sourceID, destinationID, location)
+	if errors.Is(err, model.ErrStagingFileNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		if errors.Is(r.Context().Err(), context.Canceled) {
+			http.Error(w, ierrors.ErrRequestCancelled.Error(), http.StatusBadRequest)
+			return
+		}
+		a.logger.Errorw("fetching staging file delivery", lf.SourceID, sourceID, lf.DestinationID, destinationID, lf.Error, err.Error())
+		http.Error(w, "can't fetch staging file delivery", http.StatusInternalServerError)
+		return
+	}
+
+	resBody, err := json.Marshal(stagingFileDeliveryResponse{
+		StagingFileID: delivery.StagingFileID,
+		Status:        delivery.Status,
+		UploadID:      delivery.UploadID,
+		UploadStatus:  delivery.UploadStatus,
+	})
+	if err != nil {
+		a.logger.Errorw("marshalling response for staging file delivery", lf.Error, err.Error())
+		http.Error(w, ierrors.ErrMarshallResponse.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(resBody)
+}
+
+// streamHandler streams the events of a source and destination directly into the tables of the warehouse, for the
+// tables the batch router streams instead of uploading them to staging files
+func (a *Api) streamHandler(w http.ResponseWriter, r *http.Request) {
+	defer func() { _ = r.Body.Close() }()
+
+	var payload streamRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		a.logger.Warnw("invalid JSON in request body for streaming events", lf.Error, err.Error())
+		http.Error(w, ierrors.ErrInvalidJSONRequestBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if payload.SourceID == "" || payload.DestinationID == "" {
+		http.Error(w, "source_id and destination_id are required", http.StatusBadRequest)
+		return
+	}
+
+	streamed, err := a.streaming.Stream(r.Context(), payload.SourceID, payload.DestinationID, payload.Events)
+	if errors.Is(err, streaming.ErrConnectionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		if errors.Is(r.Context().Err(), context.Canceled) {
+			http.Error(w, ierrors.ErrRequestCancelled.Error(), http.StatusBadRequest)
+			return
+		}
+		a.logger.Errorw("streaming events", lf.SourceID, payload.SourceID, lf.DestinationID, payload.DestinationID, lf.Error, err.Error())
+		http.Error(w, "can't stream events", http.StatusInternalServerError)
+		return
+	}
+
+	resBody, err := json.Marshal(streamResponse{Streamed: streamed})
+	if err != nil {
+		a.logger.Errorw("marshalling response for streaming events", lf.Error, err.Error())
+		http.Error(w, ierrors.ErrMarshallResponse.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(resBody)
+}
+
+// eventSchemasHandler returns the latest version of the schemas observed for the events of a source and destination,
+// optionally filtered by namespace
+func (a *Api) eventSchemasHandler(w http.ResponseWriter, r *http.Request) {
+	sourceID := r.URL.Query().Get("source_id")
+	destinationID := r.URL.Query().Get("destination_id")
+	namespace := r.URL.Query().Get("namespace")
+	if sourceID == "" || destinationID == "" {
+		http.Error(w, "source_id and destination_id are required", http.StatusBadRequest)
+		return
+	}
+
+	eventSchemas, err := a.eventSchemas.GetLatest(r.Context(), sourceID, destinationID, namespace)
+	a.writeEventSchemas(w, r, eventSchemas, err, lf.SourceID, sourceID, lf.DestinationID, destinationID)
+}
+
+// eventSchemaVersionsHandler returns all the versions of the schema observed for the events of a table of a source
+// and destination, optionally filtered by namespace
+func (a *Api) eventSchemaVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	sourceID := r.URL.Query().Get("source_id")
+	destinationID := r.URL.Query().Get("destination_id")
+	namespace := r.URL.Query().Get("namespace")
+	tableName := r.URL.Query().Get("table_name")
+	if sourceID == "" || destinationID == "" || tableName == "" {
+		http.Error(w, "source_id, destination_id and table_name are required", http.StatusBadRequest)
+		return
+	}
+
+	eventSchemas, err := a.eventSchemas.GetVersions(r.Context(), sourceID, destinationID, namespace, tableName)
+	a.writeEventSchemas(w, r, eventSchemas, err, lf.SourceID, sourceID, lf.DestinationID, destinationID, lf.TableName, tableName)
+}
+
+func (a *Api) writeEventSchemas(w http.ResponseWriter, r *http.Request, eventSchemas []model.EventSchema, err error, logFields ...any) {
+	if err != nil {
+		if errors.Is(r.Context().Err(), context.Canceled) {
+			http.Error(w, ierrors.ErrRequestCancelled.Error(), http.StatusBadRequest)
+			return
+		}
+		a.logger.Errorw("fetching event schemas", append(logFields, lf.Error, err.Error())...)
+		http.Error(w, "can't fetch event schemas", http.StatusInternalServerError)
+		return
+	}
+
+	res := eventSchemasResponse{EventSchemas: make([]eventSchemaResponse, 0, len(eventSchemas))}
+	for _, eventSchema := range eventSchemas {
+		res.EventSchemas = append(res.EventSchemas, eventSchemaResponse{
+			SourceID:      eventSchema.SourceID,
+			DestinationID: eventSchema.DestinationID,
+			Namespace:     eventSchema.Namespace,
+			TableName:     eventSchema.TableName,
+			Version:       eventSchema.Version,
+			Properties:    eventSchema.Properties,
+			Observations:  eventSchema.Observations,
+			CreatedAt:     eventSchema.CreatedAt,
+			UpdatedAt:     eventSchema.UpdatedAt,
+		})
+	}
+	resBody, err := json.Marshal(res)
+	if err != nil {
+		a.logger.Errorw("marshalling response for event schemas", lf.Error, err.Error())
+		http.Error(w, ierrors.ErrMarshallResponse.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(resBody)
+}
+
+// renameHandler requests the rename of the namespace of a connection, or of one of its tables, in the warehouse. The
+// rename is executed by the router of the destination type once no upload of the namespace is in progress, holding
+// back the uploads meanwhile, after which the uploads resume under the new name, see renameStatusHandler.
+//
+// Namespaces are shared by all the sources of the destination pointed at them. A namespace set in the config of the
+// destination needs to be changed there as well, otherwise the uploads resume under the configured one. Similarly, the
+// events of a renamed table need to be sent under the new name from then on, otherwise the table gets created again.
+func (a *Api) renameHandler(w http.ResponseWriter, r *http.Request) {
+	defer func() { _ = r.Body.Close() }()
+
+	var payload renameRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		a.logger.Warnw("invalid JSON in request body for rename", lf.Error, err.Error())
+		http.Error(w, ierrors.ErrInvalidJSONRequestBody.Error(), http.StatusBadRequest)
+		return
+	}
+	if payload.SourceID == "" || payload.DestinationID == "" || payload.NewName == "" {
+		http.Error(w, "source_id, destination_id and new_name are required", http.StatusBadRequest)
+		return
+	}
+
+	sources, _ := a.bcManager.ConnectionSourcesMap(payload.DestinationID)
+	warehouse, ok := sources[payload.SourceID]
+	if !ok {
+		http.Error(w, "connection not found", http.StatusNotFound)
+		return
+	}
+	if !slices.Contains(warehouseutils.RenameWarehouses, warehouse.Type) {
+		http.Error(w, fmt.Sprintf("renames are not supported for %s", warehouse.Type), http.StatusBadRequest)
+		return
+	}
+
+	rename := model.Rename{
+		WorkspaceID:     warehouse.WorkspaceID,
+		SourceID:        payload.SourceID,
+		DestinationID:   payload.DestinationID,
+		DestinationType: warehouse.Type,
+		Namespace:       warehouse.Namespace,
+		NewName:         warehouseutils.ToProviderCase(warehouse.Type, warehouseutils.ToSafeNamespace(warehouse.Type, payload.NewName)),
+	}
+	if payload.TableName != "" {
+		rename.TableName = warehouseutils.ToProviderCase(warehouse.Type, payload.TableName)
+	}
+	if (rename.IsTableRename() && rename.NewName == rename.TableName) || (!rename.IsTableRename() && rename.NewName == rename.Namespace) {
+		http.Error(w, "new_name is the current name", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := a.renameRepo.Insert(r.Context(), &rename); err != nil {
+		if errors.Is(r.Context().Err(), context.Canceled) {
+			http.Error(w, ierrors.ErrRequestCancelled.Error(), http.StatusBadRequest)
+			return
+		}
+		a.logger.Errorw("inserting rename", lf.SourceID, payload.SourceID, lf.DestinationID, payload.DestinationID, lf.Error, err.Error())
+		http.Error(w, "can't insert rename", http.StatusInternalServerError)
+		return
+	}
+	a.writeRename(w, rename)
+}
+
+// renameStatusHandler returns the rename with the id, along with its status
+func (a *Api) renameStatusHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	rename, err := a.renameRepo.GetByID(r.Context(), id)
+	if errors.Is(err, model.ErrRenameNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		if errors.Is(r.Context().Err(), context.Canceled) {
+			http.Error(w, ierrors.ErrRequestCancelled.Error(), http.StatusBadRequest)
+			return
+		}
+		a.logger.Errorw("fetching rename", lf.Error, err.Error())
+		http.Error(w, "can't fetch rename", http.StatusInternalServerError)
+		return
+	}
+	a.writeRename(w, rename)
+}
+
+func (a *Api) writeRename(w http.ResponseWriter, rename model.Rename) {
+	resBody, err := json.Marshal(renameResponse{
+		ID:            rename.ID,
+		SourceID:      rename.SourceID,
+		DestinationID: rename.DestinationID,
+		Namespace:     rename.Namespace,
+		TableName:     rename.TableName,
+		NewName:       rename.NewName,
+		Status:        string(rename.Status),
+		Error:         rename.Error,
+		Attempts:      rename.Attempts,
+		CreatedAt:     rename.CreatedAt,
+		UpdatedAt:     rename.UpdatedAt,
+	})
+	if err != nil {
+		a.logger.Errorw("marshalling response for rename", lf.Error, err.Error())
+		http.Error(w, ierrors.ErrMarshallResponse.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(resBody)
+}
+
 func (a *Api) logMiddleware(delegate http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		a.logger.LogRequest(r)
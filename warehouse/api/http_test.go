@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -568,6 +569,66 @@ func TestHTTPApi(t *testing.T) {
 		})
 	})
 
+	t.Run("event schemas handler", func(t *testing.T) {
+		eventSchemasRepo := repo.NewEventSchemas(db, repo.WithNow(func() time.Time {
+			return now
+		}))
+		_, err := eventSchemasRepo.Observe(ctx, sourceID, destinationID, namespace, 1, model.Schema{
+			"order_completed": {"id": "string", "revenue": "int"},
+		})
+		require.NoError(t, err)
+		_, err = eventSchemasRepo.Observe(ctx, sourceID, destinationID, namespace, 2, model.Schema{
+			"order_completed": {"id": "string", "revenue": "float"},
+		})
+		require.NoError(t, err)
+
+		t.Run("missing params", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/internal/v1/warehouse/event-schemas?source_id="+sourceID, nil)
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.eventSchemasHandler(resp, req)
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+
+			req = httptest.NewRequest(http.MethodGet, "/internal/v1/warehouse/event-schemas/versions?source_id="+sourceID+"&destination_id="+destinationID, nil)
+			resp = httptest.NewRecorder()
+
+			a.eventSchemaVersionsHandler(resp, req)
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+
+		t.Run("latest", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/internal/v1/warehouse/event-schemas?source_id="+sourceID+"&destination_id="+destinationID, nil)
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.eventSchemasHandler(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var res eventSchemasResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+			require.Len(t, res.EventSchemas, 1)
+			require.Equal(t, "order_completed", res.EventSchemas[0].TableName)
+			require.Equal(t, 2, res.EventSchemas[0].Version)
+			require.Equal(t, model.EventProperty{Type: "float", Frequency: 1}, res.EventSchemas[0].Properties["revenue"])
+		})
+
+		t.Run("versions", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/internal/v1/warehouse/event-schemas/versions?source_id="+sourceID+"&destination_id="+destinationID+"&table_name=order_completed", nil)
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.eventSchemaVersionsHandler(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var res eventSchemasResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+			require.Len(t, res.EventSchemas, 2)
+			require.Equal(t, 1, res.EventSchemas[0].Version)
+			require.Equal(t, 2, res.EventSchemas[1].Version)
+		})
+	})
+
 	t.Run("fetch tables handler", func(t *testing.T) {
 		t.Run("invalid payload", func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/internal/v1/warehouse/fetch-tables", bytes.NewReader([]byte(`"Invalid payload"`)))
@@ -741,6 +802,291 @@ func TestHTTPApi(t *testing.T) {
 			_, isTriggered := triggerStore.Load("POSTGRES:test_source_id:test_destination_id")
 			require.True(t, isTriggered)
 		})
+
+		t.Run("with tags", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/warehouse/trigger-upload", bytes.NewReader([]byte(`
+				{
+				  "source_id": "test_source_id",
+				  "destination_id": "test_destination_id",
+				  "tags": {"triggered_by": "user-1", "ticket": "T-1"}
+				}
+			`)))
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.triggerUploadHandler(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			defer func() {
+				triggerStore.Delete("POSTGRES:test_source_id:test_destination_id")
+			}()
+
+			tags, isTriggered := triggerStore.Load("POSTGRES:test_source_id:test_destination_id")
+			require.True(t, isTriggered)
+			require.Equal(t, map[string]string{"triggered_by": "user-1", "ticket": "T-1"}, tags)
+		})
+	})
+
+	t.Run("upload estimate handler", func(t *testing.T) {
+		t.Run("missing params", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/warehouse/upload-estimate", nil)
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.uploadEstimateHandler(resp, req)
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+
+		t.Run("no warehouses", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/warehouse/upload-estimate?source_id="+unsupportedSourceID, nil)
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.uploadEstimateHandler(resp, req)
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+
+			b, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, "no warehouse found\n", string(b))
+		})
+
+		t.Run("succeed", func(t *testing.T) {
+			for _, query := range []string{
+				"source_id=" + sourceID,
+				"destination_id=" + destinationID,
+				"source_id=" + sourceID + "&destination_id=" + destinationID,
+			} {
+				req := httptest.NewRequest(http.MethodGet, "/v1/warehouse/upload-estimate?"+query, nil)
+				resp := httptest.NewRecorder()
+
+				a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+				a.uploadEstimateHandler(resp, req)
+				require.Equal(t, http.StatusOK, resp.Code)
+
+				var res uploadEstimatesResponse
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+				require.Len(t, res.Estimates, 1)
+
+				estimate := res.Estimates[0]
+				require.Equal(t, sourceID, estimate.SourceID)
+				require.Equal(t, destinationID, estimate.DestinationID)
+				require.Equal(t, warehouseutils.POSTGRES, estimate.DestinationType)
+				require.Equal(t, 5, estimate.PendingStagingFiles, "staging files created after the last upload")
+				require.EqualValues(t, 500, estimate.TotalEvents)
+				require.Equal(t, stagingFile.FirstEventAt, estimate.FirstEventAt.UTC())
+				require.Nil(t, estimate.LastEventAt)
+			}
+		})
+	})
+
+	t.Run("sync status handler", func(t *testing.T) {
+		t.Run("missing params", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/warehouse/sync-status", nil)
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.syncStatusHandler(resp, req)
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+
+		t.Run("no warehouses", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/warehouse/sync-status?source_id="+unsupportedSourceID, nil)
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.syncStatusHandler(resp, req)
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+
+		t.Run("succeed", func(t *testing.T) {
+			lastCreatedAt, err := uploadsRepo.LastCreatedAt(ctx, sourceID, destinationID)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/warehouse/sync-status?destination_id="+destinationID, nil)
+			resp := httptest.NewRecorder()
+
+			c := config.New()
+			c.Set("Warehouse.uploadFreqInS", 3600)
+			a := NewApi(config.MasterMode, c, logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.now = func() time.Time { return lastCreatedAt }
+			a.syncStatusHandler(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var res syncStatusesResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+			require.Len(t, res.Syncs, 1)
+
+			syncStatus := res.Syncs[0]
+			require.Equal(t, sourceID, syncStatus.SourceID)
+			require.Equal(t, destinationID, syncStatus.DestinationID)
+			require.Equal(t, warehouseutils.POSTGRES, syncStatus.DestinationType)
+			require.Equal(t, lastCreatedAt.Add(time.Hour).UTC(), syncStatus.NextScheduledAt.UTC())
+			require.Nil(t, syncStatus.LastSucceededAt)
+			require.EqualValues(t, 1, syncStatus.PendingUploads)
+			require.Equal(t, &inProgressResponse{UploadID: uploadID, Status: model.Waiting}, syncStatus.InProgress)
+		})
+	})
+
+	t.Run("test connection handler", func(t *testing.T) {
+		t.Run("invalid payload", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/warehouse/test-connection", bytes.NewReader([]byte(`"Invalid payload"`)))
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.testConnectionHandler(resp, req)
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+
+		t.Run("empty config", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/warehouse/test-connection", bytes.NewReader([]byte(`{"destination":{"id":"`+destinationID+`"}}`)))
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.testConnectionHandler(resp, req)
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+
+		t.Run("unsupported destination type", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/warehouse/test-connection", bytes.NewReader([]byte(`{"destination":{"id":"`+destinationID+`","config":{"host":"localhost"},"destinationDefinition":{"name":"UNKNOWN"}}}`)))
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.testConnectionHandler(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var res model.ConnectionTestResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+			require.False(t, res.Success)
+			require.Equal(t, model.ConnectionTestSetup, res.Stage)
+			require.Equal(t, model.InvalidConfigurationError, res.ErrorType)
+			require.NotEmpty(t, res.Error)
+		})
+
+		t.Run("succeed", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/warehouse/test-connection", bytes.NewReader([]byte(`{"destination":{"id":"`+destinationID+`","config":{"host":"localhost"},"destinationDefinition":{"name":"POSTGRES"}}}`)))
+			resp := httptest.NewRecorder()
+
+			var tested *backendconfig.DestinationT
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.verifyConnection = func(_ context.Context, dest *backendconfig.DestinationT) *model.ConnectionTestResponse {
+				tested = dest
+				return &model.ConnectionTestResponse{Success: true}
+			}
+			a.testConnectionHandler(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+			require.JSONEq(t, `{"success":true}`, resp.Body.String())
+			require.Equal(t, destinationID, tested.ID)
+			require.Equal(t, warehouseutils.POSTGRES, tested.DestinationDefinition.Name)
+		})
+	})
+
+	t.Run("stream handler", func(t *testing.T) {
+		t.Run("invalid payload", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/internal/v1/warehouse/stream", bytes.NewReader([]byte(`"Invalid payload"`)))
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.streamHandler(resp, req)
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+
+		t.Run("missing params", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/internal/v1/warehouse/stream", bytes.NewReader([]byte(`{"source_id":"`+sourceID+`"}`)))
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.streamHandler(resp, req)
+			require.Equal(t, http.StatusBadRequest, resp.Code)
+		})
+
+		t.Run("unknown connection", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/internal/v1/warehouse/stream", bytes.NewReader([]byte(`{"source_id":"`+unsupportedSourceID+`","destination_id":"`+destinationID+`","events":[]}`)))
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.streamHandler(resp, req)
+			require.Equal(t, http.StatusNotFound, resp.Code)
+		})
+
+		t.Run("warehouse not supporting streaming", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/internal/v1/warehouse/stream", bytes.NewReader([]byte(`{
+				"source_id":"`+sourceID+`",
+				"destination_id":"`+destinationID+`",
+				"events":[{"metadata":{"table":"tracks","columns":{"id":"string"}},"data":{"id":"1"}}]
+			}`)))
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.streamHandler(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var res streamResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+			require.Equal(t, []bool{false}, res.Streamed, "events are left to the uploads")
+		})
+	})
+
+	t.Run("rename handlers", func(t *testing.T) {
+		rename := func(t *testing.T, payload string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/internal/v1/warehouse/renames", bytes.NewReader([]byte(payload)))
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.renameHandler(resp, req)
+			return resp
+		}
+		renameStatus := func(t *testing.T, id string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/internal/v1/warehouse/renames?id="+id, nil)
+			resp := httptest.NewRecorder()
+
+			a := NewApi(config.MasterMode, config.New(), logger.NOP, stats.NOP, mockBackendConfig, db, n, tenantManager, bcManager, sourcesManager, triggerStore)
+			a.renameStatusHandler(resp, req)
+			return resp
+		}
+
+		t.Run("invalid payload", func(t *testing.T) {
+			require.Equal(t, http.StatusBadRequest, rename(t, `"Invalid payload"`).Code)
+		})
+
+		t.Run("missing params", func(t *testing.T) {
+			require.Equal(t, http.StatusBadRequest, rename(t, `{"source_id":"`+sourceID+`","destination_id":"`+destinationID+`"}`).Code)
+		})
+
+		t.Run("unknown connection", func(t *testing.T) {
+			require.Equal(t, http.StatusNotFound, rename(t, `{"source_id":"`+sourceID+`","destination_id":"`+unusedDestinationID+`","new_name":"new_namespace"}`).Code)
+		})
+
+		t.Run("same name", func(t *testing.T) {
+			require.Equal(t, http.StatusBadRequest, rename(t, `{"source_id":"`+sourceID+`","destination_id":"`+destinationID+`","table_name":"tracks","new_name":"tracks"}`).Code)
+		})
+
+		t.Run("table rename", func(t *testing.T) {
+			resp := rename(t, `{"source_id":"`+sourceID+`","destination_id":"`+destinationID+`","table_name":"tracks","new_name":"Legacy Tracks"}`)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var res renameResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+			require.NotZero(t, res.ID)
+			require.Equal(t, "tracks", res.TableName)
+			require.Equal(t, "legacy_tracks", res.NewName)
+			require.Equal(t, string(model.RenameStatusWaiting), res.Status)
+
+			resp = renameStatus(t, strconv.FormatInt(res.ID, 10))
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var status renameResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+			require.Equal(t, res.ID, status.ID)
+			require.Equal(t, res.Namespace, status.Namespace)
+			require.Equal(t, "legacy_tracks", status.NewName)
+			require.Equal(t, string(model.RenameStatusWaiting), status.Status)
+		})
+
+		t.Run("unknown rename", func(t *testing.T) {
+			require.Equal(t, http.StatusNotFound, renameStatus(t, "-1").Code)
+			require.Equal(t, http.StatusBadRequest, renameStatus(t, "invalid").Code)
+		})
 	})
 
 	t.Run("endpoints", func(t *testing.T) {
@@ -24,9 +24,11 @@ import (
 	"github.com/rudderlabs/rudder-server/app"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/info"
+	"github.com/rudderlabs/rudder-server/internal/sharding"
 	"github.com/rudderlabs/rudder-server/services/controlplane"
 	"github.com/rudderlabs/rudder-server/services/notifier"
 	migrator "github.com/rudderlabs/rudder-server/services/sql-migrator"
+	"github.com/rudderlabs/rudder-server/services/sql-migrator/online"
 	"github.com/rudderlabs/rudder-server/services/validators"
 	"github.com/rudderlabs/rudder-server/utils/crash"
 	"github.com/rudderlabs/rudder-server/utils/misc"
@@ -40,6 +42,7 @@ import (
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/mode"
 	"github.com/rudderlabs/rudder-server/warehouse/multitenant"
+	"github.com/rudderlabs/rudder-server/warehouse/reverseetl"
 	"github.com/rudderlabs/rudder-server/warehouse/router"
 	"github.com/rudderlabs/rudder-server/warehouse/slave"
 	"github.com/rudderlabs/rudder-server/warehouse/source"
@@ -65,6 +68,11 @@ type App struct {
 	fileManagerFactory filemanager.Factory
 	sourcesManager     *source.Manager
 	admin              *whadmin.Admin
+	onlineMigrator     *online.Migrator
+	sharding           *sharding.Manager   // nil unless the master is sharded across replicas
+	workerPools        *router.WorkerPools // shared by the routers of the destination types
+	endpoints          *router.Endpoints   // shared by the routers of the destination types
+	reverseETL         *reverseetl.Syncer  // nil unless reverse ETL is enabled for the master
 	triggerStore       *sync.Map
 	createUploadAlways *atomic.Bool
 
@@ -80,6 +88,7 @@ type App struct {
 
 		mode                       string
 		runningMode                string
+		shardingEnabled            bool
 		shouldForceSetLowerVersion bool
 		dbQueryTimeout             time.Duration
 		maxOpenConnections         int
@@ -114,6 +123,7 @@ func New(
 	a.config.port = conf.GetInt("WAREHOUSE_JOBS_DB_PORT", 5432)
 	a.config.mode = conf.GetString("Warehouse.mode", "embedded")
 	a.config.runningMode = conf.GetString("Warehouse.runningMode", "")
+	a.config.shardingEnabled = conf.GetBool("Warehouse.sharding.enabled", false)
 	a.config.shouldForceSetLowerVersion = conf.GetBoolVar(true, "SQLMigrator.forceSetLowerVersion")
 	a.config.maxOpenConnections = conf.GetInt("Warehouse.maxOpenConnections", 20)
 	a.config.configBackendURL = conf.GetString("CONFIG_BACKEND_URL", "https://api.rudderstack.com")
@@ -165,7 +175,7 @@ func (a *App) Setup(ctx context.Context) error {
 		a.statsFactory,
 		workspaceIdentifier,
 	)
-	err := a.notifier.Setup(ctx, a.connectionString("notifier"))
+	err := a.notifier.Setup(ctx, a.ConnectionString("notifier"))
 	if err != nil {
 		return fmt.Errorf("cannot setup notifier: %w", err)
 	}
@@ -203,9 +213,39 @@ func (a *App) Setup(ctx context.Context) error {
 		a.sourcesManager,
 		a.triggerStore,
 	)
+	a.onlineMigrator = online.New(
+		a.db.DB,
+		onlineMigrationsTable,
+		onlineMigrations,
+		a.conf,
+		a.logger,
+		a.statsFactory,
+	)
+	a.workerPools = router.NewWorkerPools(a.conf)
+	a.endpoints = router.NewEndpoints(a.conf)
+	if a.config.shardingEnabled && mode.IsMaster(a.config.mode) {
+		a.sharding = sharding.New(
+			"Warehouse",
+			a.conf,
+			a.logger,
+			a.statsFactory,
+			a.db.DB,
+		)
+	}
+	if a.conf.GetBoolVar(false, "Warehouse.reverseEtl.enabled") && mode.IsMaster(a.config.mode) {
+		a.reverseETL = reverseetl.New(
+			a.conf,
+			a.logger,
+			a.statsFactory,
+			a.db,
+			a.tenantManager,
+			a.bcManager,
+		)
+	}
 	a.admin = whadmin.New(
 		a.bcManager,
 		a.createUploadAlways,
+		a.onlineMigrator,
 		a.logger,
 	)
 
@@ -213,7 +253,7 @@ func (a *App) Setup(ctx context.Context) error {
 }
 
 func (a *App) setupDatabase(ctx context.Context) error {
-	dsn := a.connectionString("warehouse")
+	dsn := a.ConnectionString("warehouse")
 
 	database, err := sql.Open("postgres", dsn)
 	if err != nil {
@@ -248,7 +288,9 @@ func (a *App) setupDatabase(ctx context.Context) error {
 	return nil
 }
 
-func (a *App) connectionString(componentName string) string {
+// ConnectionString returns the connection string of the warehouse database, which is the database of the server
+// unless the WAREHOUSE_JOBS_DB_* environment variables are set
+func (a *App) ConnectionString(componentName string) string {
 	if !a.checkForWarehouseEnvVars() {
 		return misc.GetConnectionString(a.conf, componentName)
 	}
@@ -347,7 +389,7 @@ func (a *App) Run(ctx context.Context) error {
 	if !mode.IsStandAloneSlave(a.config.mode) {
 		a.reporting = a.app.Features().Reporting.Setup(gCtx, a.bcConfig)
 		defer a.reporting.Stop()
-		syncer := a.reporting.DatabaseSyncer(types.SyncerConfig{ConnInfo: a.connectionString("reporting"), Label: types.WarehouseReportingLabel})
+		syncer := a.reporting.DatabaseSyncer(types.SyncerConfig{ConnInfo: a.ConnectionString("reporting"), Label: types.WarehouseReportingLabel})
 		g.Go(crash.NotifyWarehouse(func() error {
 			syncer()
 			return nil
@@ -392,30 +434,47 @@ func (a *App) Run(ctx context.Context) error {
 
 		a.bcConfig.WaitForConfig(ctx)
 
-		g.Go(crash.NotifyWarehouse(func() error {
-			return a.notifier.ClearJobs(gCtx)
-		}))
+		if a.sharding != nil {
+			// the jobs of the other replicas are not cleared, the replica taking over the shards resets their uploads
+			g.Go(crash.NotifyWarehouse(func() error {
+				return a.sharding.Run(gCtx)
+			}))
+		} else {
+			g.Go(crash.NotifyWarehouse(func() error {
+				return a.notifier.ClearJobs(gCtx)
+			}))
+		}
 		g.Go(crash.NotifyWarehouse(func() error {
 			return a.monitorDestRouters(gCtx)
 		}))
 		g.Go(crash.NotifyWarehouse(func() error {
-			archive.CronArchiver(gCtx, archive.New(
-				a.conf,
-				a.logger,
-				a.statsFactory,
-				a.db,
-				a.fileManagerFactory,
-				a.tenantManager,
-			))
-			return nil
+			return a.sharding.RunAsLeader(gCtx, func(ctx context.Context) error {
+				archive.CronArchiver(ctx, archive.New(
+					a.conf,
+					a.logger,
+					a.statsFactory,
+					a.db,
+					a.fileManagerFactory,
+					a.tenantManager,
+				))
+				return nil
+			})
 		}))
 		g.Go(func() error {
 			a.grpcServer.Start(gCtx)
 			return nil
 		})
 		g.Go(crash.NotifyWarehouse(func() error {
-			return a.sourcesManager.Run(gCtx)
+			return a.sharding.RunAsLeader(gCtx, a.sourcesManager.Run)
 		}))
+		g.Go(crash.NotifyWarehouse(func() error {
+			return a.sharding.RunAsLeader(gCtx, a.onlineMigrator.Run)
+		}))
+		if a.reverseETL != nil {
+			g.Go(crash.NotifyWarehouse(func() error {
+				return a.sharding.RunAsLeader(gCtx, a.reverseETL.Run)
+			}))
+		}
 	}
 
 	g.Go(func() error {
@@ -484,6 +543,9 @@ func (a *App) onConfigDataEvent(
 					a.encodingFactory,
 					a.triggerStore,
 					a.createUploadAlways,
+					a.sharding,
+					a.workerPools,
+					a.endpoints,
 				)
 				dstToWhRouter[destination.DestinationDefinition.Name] = r
 				diffRouters[destination.DestinationDefinition.Name] = r
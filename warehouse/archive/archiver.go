@@ -382,9 +382,35 @@ func (a *Archiver) archiveUploads(ctx context.Context, maxArchiveLimit int) erro
 			}
 		}
 
+		// delete load file checkpoints left by generations which never completed
+		stmt := fmt.Sprintf(`
+			DELETE FROM %s
+			WHERE upload_id = $1;`,
+			pq.QuoteIdentifier(warehouseutils.WarehouseLoadFileCheckpointsTable),
+		)
+		_, err = txn.ExecContext(ctx, stmt, u.uploadID)
+		if err != nil {
+			a.log.Errorf(`[Archiver]: Error running txn in archiveUploadFiles. Query: %s Error: %v`, stmt, err)
+			_ = txn.Rollback()
+			continue
+		}
+
+		// delete the failed tables of the upload from their summary, e.g. those left by aborted uploads
+		stmt = fmt.Sprintf(`
+			DELETE FROM %s
+			WHERE wh_upload_id = $1;`,
+			pq.QuoteIdentifier(warehouseutils.WarehouseTableUploadFailuresTable),
+		)
+		_, err = txn.ExecContext(ctx, stmt, u.uploadID)
+		if err != nil {
+			a.log.Errorf(`[Archiver]: Error running txn in archiveUploadFiles. Query: %s Error: %v`, stmt, err)
+			_ = txn.Rollback()
+			continue
+		}
+
 		// update upload metadata
 		u.uploadMetdata, _ = sjson.SetBytes(u.uploadMetdata, "archivedStagingAndLoadFiles", true)
-		stmt := fmt.Sprintf(`
+		stmt = fmt.Sprintf(`
 			UPDATE %s
 			SET metadata = $1
 			WHERE id = $2;`,
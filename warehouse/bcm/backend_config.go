@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"strings"
 	"sync"
 
@@ -164,6 +165,7 @@ func (bcm *BackendConfigManager) processData(ctx context.Context, data map[strin
 				if bcm.internalControlPlaneClient != nil {
 					destination = bcm.attachSSHTunnellingInfo(ctx, destination)
 				}
+				destination = withWorkspaceDefaults(destination, wConfig.Settings.WarehouseDefaults)
 
 				warehouse := model.Warehouse{
 					Source:      source,
@@ -181,7 +183,7 @@ func (bcm *BackendConfigManager) processData(ctx context.Context, data map[strin
 				}
 				connectionsMap[destination.ID][source.ID] = warehouse
 
-				if destination.Config["sslMode"] == "verify-ca" {
+				if whutils.SSLKeysRequired(destination.Config["sslMode"]) {
 					if err := whutils.WriteSSLKeys(destination); err.IsError() {
 						bcm.logger.Error(err.Error())
 						bcm.persistSSLFileErrorStat(
@@ -213,10 +215,38 @@ func (bcm *BackendConfigManager) processData(ctx context.Context, data map[strin
 	bcm.subscriptionsMu.Unlock()
 }
 
+// withWorkspaceDefaults returns the destination with the settings of the workspace defaults it doesn't set, see
+// [model.WorkspaceDefaultSettings]
+func withWorkspaceDefaults(destination backendconfig.DestinationT, defaults map[string]interface{}) backendconfig.DestinationT {
+	var inherited map[string]interface{}
+	for _, setting := range model.WorkspaceDefaultSettings {
+		value, ok := defaults[setting.String()]
+		if !ok {
+			continue
+		}
+		if _, ok := destination.Config[setting.String()]; ok {
+			continue
+		}
+		if inherited == nil {
+			// the config of the destination is shared with the other consumers of the backend config
+			inherited = maps.Clone(destination.Config)
+			if inherited == nil {
+				inherited = make(map[string]interface{})
+			}
+		}
+		inherited[setting.String()] = value
+	}
+	if inherited != nil {
+		destination.Config = inherited
+	}
+	return destination
+}
+
 // namespace gives the namespace for the warehouse in the following order
-//  1. user set name from destinationConfig
-//  2. from existing record in wh_schemas with same source + dest combo
-//  3. convert source name
+//  1. namespace template from destinationConfig, see [whutils.NamespaceFromTemplate]
+//  2. user set name from destinationConfig, suffixed with the source name for postgres destinations with a schema per source
+//  3. from existing record in wh_schemas with same source + dest combo
+//  4. convert source name
 func (bcm *BackendConfigManager) namespace(ctx context.Context, source backendconfig.SourceT, destination backendconfig.DestinationT) string {
 	destType := destination.DestinationDefinition.Name
 	destConfig := destination.Config
@@ -228,9 +258,16 @@ func (bcm *BackendConfigManager) namespace(ctx context.Context, source backendco
 		return "rudder"
 	}
 
+	if namespace, ok := whutils.NamespaceFromTemplate(destType, destConfig, source); ok {
+		return namespace
+	}
+
 	if destConfig["namespace"] != nil {
 		namespace, _ := destConfig["namespace"].(string)
 		if len(strings.TrimSpace(namespace)) > 0 {
+			if schemaPerSource, _ := destConfig[model.SchemaPerSourceSetting.String()].(bool); schemaPerSource && destType == whutils.POSTGRES {
+				namespace = fmt.Sprintf(`%s_%s`, strings.TrimSpace(namespace), source.Name)
+			}
 			return whutils.ToProviderCase(destType, whutils.ToSafeNamespace(destType, namespace))
 		}
 	}
@@ -305,6 +342,48 @@ func (bcm *BackendConfigManager) WarehousesByDestID(destID string) []model.Wareh
 	})
 }
 
+// RenameNamespace moves the connections of the destination from the namespace to the new one, once renamed in the
+// warehouse, notifying the subscribers. Namespaces are resolved again on the next config update, picking up the new
+// one from the schemas unless set in the config of the destination.
+func (bcm *BackendConfigManager) RenameNamespace(destID, namespace, newNamespace string) {
+	bcm.subscriptionsMu.Lock()
+	defer bcm.subscriptionsMu.Unlock()
+
+	// the warehouses and connections are shared with the subscribers and callers, so they get copied
+	bcm.warehousesMu.Lock()
+	warehouses := make([]model.Warehouse, len(bcm.warehouses))
+	for i, warehouse := range bcm.warehouses {
+		if warehouse.Destination.ID == destID && warehouse.Namespace == namespace {
+			warehouse.Namespace = newNamespace
+		}
+		warehouses[i] = warehouse
+	}
+	bcm.warehouses = warehouses
+	bcm.warehousesMu.Unlock()
+
+	bcm.connectionsMapMu.Lock()
+	if sources, ok := bcm.connectionsMap[destID]; ok {
+		connectionsMap := make(map[string]map[string]model.Warehouse, len(bcm.connectionsMap))
+		for id, m := range bcm.connectionsMap {
+			connectionsMap[id] = m
+		}
+		renamedSources := make(map[string]model.Warehouse, len(sources))
+		for sourceID, warehouse := range sources {
+			if warehouse.Namespace == namespace {
+				warehouse.Namespace = newNamespace
+			}
+			renamedSources[sourceID] = warehouse
+		}
+		connectionsMap[destID] = renamedSources
+		bcm.connectionsMap = connectionsMap
+	}
+	bcm.connectionsMapMu.Unlock()
+
+	for _, sub := range bcm.subscriptions {
+		sub <- warehouses
+	}
+}
+
 func (bcm *BackendConfigManager) attachSSHTunnellingInfo(
 	ctx context.Context,
 	upstream backendconfig.DestinationT,
@@ -332,6 +332,42 @@ func TestBackendConfigManager_Namespace(t *testing.T) {
 			expectedNamespace: "test_namespace",
 			setConfig:         false,
 		},
+		{
+			name: "postgres namespace with schema per source",
+			source: backendconfig.SourceT{
+				Name: "test-source",
+			},
+			destination: backendconfig.DestinationT{
+				Config: map[string]interface{}{
+					"namespace":       "test_namespace",
+					"schemaPerSource": true,
+				},
+				DestinationDefinition: backendconfig.DestinationDefinitionT{
+					Name: warehouseutils.POSTGRES,
+				},
+			},
+			expectedNamespace: "test_namespace_test_source",
+			setConfig:         false,
+		},
+		{
+			name: "namespace template",
+			source: backendconfig.SourceT{
+				ID:               "source-id",
+				Name:             "Test Source",
+				SourceDefinition: backendconfig.SourceDefinitionT{Name: "HTTP"},
+			},
+			destination: backendconfig.DestinationT{
+				Config: map[string]interface{}{
+					"namespace":         "test_namespace",
+					"namespaceTemplate": "{{namespace}}_{{sourceType}}_{{sourceName}}",
+				},
+				DestinationDefinition: backendconfig.DestinationDefinitionT{
+					Name: warehouseutils.SNOWFLAKE,
+				},
+			},
+			expectedNamespace: "TEST_NAMESPACE_HTTP_TEST_SOURCE",
+			setConfig:         false,
+		},
 		{
 			name:   "namespace only contains special characters",
 			source: backendconfig.SourceT{},
@@ -467,3 +503,91 @@ func TestBackendConfigManager_Namespace(t *testing.T) {
 		})
 	}
 }
+
+func TestBackendConfigManager_RenameNamespace(t *testing.T) {
+	warehouse := func(sourceID, destinationID, namespace string) model.Warehouse {
+		return model.Warehouse{
+			Source:      backendconfig.SourceT{ID: sourceID},
+			Destination: backendconfig.DestinationT{ID: destinationID},
+			Namespace:   namespace,
+		}
+	}
+
+	bcm := New(config.New(), nil, nil, logger.NOP, stats.NOP)
+	bcm.warehouses = []model.Warehouse{
+		warehouse("source-1", "destination-1", "namespace"),
+		warehouse("source-2", "destination-1", "namespace"),
+		warehouse("source-3", "destination-1", "other_namespace"),
+		warehouse("source-1", "destination-2", "namespace"),
+	}
+	bcm.connectionsMap = map[string]map[string]model.Warehouse{
+		"destination-1": {
+			"source-1": bcm.warehouses[0],
+			"source-2": bcm.warehouses[1],
+			"source-3": bcm.warehouses[2],
+		},
+		"destination-2": {
+			"source-1": bcm.warehouses[3],
+		},
+	}
+	connections := bcm.Connections()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	subscriptionsCh := bcm.Subscribe(ctx)
+	<-subscriptionsCh
+
+	bcm.RenameNamespace("destination-1", "namespace", "new_namespace")
+
+	expectedWarehouses := []model.Warehouse{
+		warehouse("source-1", "destination-1", "new_namespace"),
+		warehouse("source-2", "destination-1", "new_namespace"),
+		warehouse("source-3", "destination-1", "other_namespace"),
+		warehouse("source-1", "destination-2", "namespace"),
+	}
+	require.Equal(t, expectedWarehouses, <-subscriptionsCh)
+	require.Equal(t, expectedWarehouses[:3], bcm.WarehousesByDestID("destination-1"))
+	require.Equal(t, map[string]map[string]model.Warehouse{
+		"destination-1": {
+			"source-1": expectedWarehouses[0],
+			"source-2": expectedWarehouses[1],
+			"source-3": expectedWarehouses[2],
+		},
+		"destination-2": {
+			"source-1": expectedWarehouses[3],
+		},
+	}, bcm.Connections())
+	require.Equal(t, "namespace", connections["destination-1"]["source-1"].Namespace, "connections returned before are left untouched")
+}
+
+func TestWithWorkspaceDefaults(t *testing.T) {
+	defaults := map[string]interface{}{
+		"syncFrequency": "30",
+		"excludeWindow": map[string]interface{}{"excludeWindowStartTime": "05:00", "excludeWindowEndTime": "06:00"},
+		"preferAppend":  true,
+		"password":      "password",
+	}
+
+	t.Run("inherited", func(t *testing.T) {
+		destination := backendconfig.DestinationT{
+			ID:     "destination-1",
+			Config: map[string]interface{}{"namespace": "namespace", "syncFrequency": "60"},
+		}
+
+		inherited := withWorkspaceDefaults(destination, defaults)
+		require.Equal(t, map[string]interface{}{
+			"namespace":     "namespace",
+			"syncFrequency": "60",
+			"excludeWindow": map[string]interface{}{"excludeWindowStartTime": "05:00", "excludeWindowEndTime": "06:00"},
+			"preferAppend":  true,
+		}, inherited.Config, "only the settings not set for the destination are inherited, among the defaultable ones")
+		require.Equal(t, map[string]interface{}{"namespace": "namespace", "syncFrequency": "60"}, destination.Config, "the config of the destination is left untouched")
+	})
+	t.Run("no defaults", func(t *testing.T) {
+		destination := backendconfig.DestinationT{ID: "destination-1"}
+		require.Equal(t, destination, withWorkspaceDefaults(destination, nil))
+
+		inherited := withWorkspaceDefaults(destination, map[string]interface{}{"syncFrequency": "30"})
+		require.Equal(t, map[string]interface{}{"syncFrequency": "30"}, inherited.Config)
+	})
+}
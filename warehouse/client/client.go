@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"io"
 
 	"cloud.google.com/go/bigquery"
 	"google.golang.org/api/iterator"
@@ -18,9 +19,10 @@ const (
 )
 
 type Client struct {
-	SQL  *sql.DB
-	BQ   *bigquery.Client
-	Type string
+	SQL    *sql.DB
+	BQ     *bigquery.Client
+	Type   string
+	Closer io.Closer // closed along with the client, e.g. the ssh tunnel the connection goes through
 }
 
 func (cl *Client) sqlQuery(statement string) (result warehouseutils.QueryResult, err error) {
@@ -113,4 +115,7 @@ func (cl *Client) Close() {
 	default:
 		_ = cl.SQL.Close()
 	}
+	if cl.Closer != nil {
+		_ = cl.Closer.Close()
+	}
 }
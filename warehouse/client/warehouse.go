@@ -3,9 +3,11 @@ package client
 import (
 	"bytes"
 	"context"
+	stdjson "encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
@@ -30,6 +32,7 @@ type StagingFile struct {
 	LastEventAt           string
 	TotalEvents           int
 	TotalBytes            int
+	EventsPerTable        map[string]int
 	UseRudderStorage      bool
 	DestinationRevisionID string
 	// cloud sources specific info
@@ -50,6 +53,7 @@ type legacyPayload struct {
 	LastEventAt           string
 	TotalEvents           int
 	TotalBytes            int
+	EventsPerTable        map[string]int
 	UseRudderStorage      bool
 	DestinationRevisionID string
 	// cloud sources specific info
@@ -103,6 +107,7 @@ func (warehouse *Warehouse) Process(ctx context.Context, stagingFile StagingFile
 		LastEventAt:           stagingFile.LastEventAt,
 		TotalEvents:           stagingFile.TotalEvents,
 		TotalBytes:            stagingFile.TotalBytes,
+		EventsPerTable:        stagingFile.EventsPerTable,
 		UseRudderStorage:      stagingFile.UseRudderStorage,
 		DestinationRevisionID: stagingFile.DestinationRevisionID,
 		SourceTaskRunID:       stagingFile.SourceTaskRunID,
@@ -136,3 +141,96 @@ func (warehouse *Warehouse) Process(ctx context.Context, stagingFile StagingFile
 
 	return nil
 }
+
+// StagingFileDelivery is the status of a staging file, along with the status of the upload loading it, if any
+type StagingFileDelivery struct {
+	StagingFileID int64  `json:"staging_file_id"`
+	Status        string `json:"status"`
+	UploadID      int64  `json:"upload_id,omitempty"`
+	UploadStatus  string `json:"upload_status,omitempty"`
+}
+
+// StagingFileDelivery returns the delivery of the staging file of a source and destination at the given location
+func (warehouse *Warehouse) StagingFileDelivery(ctx context.Context, sourceID, destinationID, location string) (StagingFileDelivery, error) {
+	query := url.Values{}
+	query.Set("source_id", sourceID)
+	query.Set("destination_id", destinationID)
+	query.Set("location", location)
+
+	uri := fmt.Sprintf(`%s/internal/v1/warehouse/staging-files/delivery?%s`, warehouse.baseURL, query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return StagingFileDelivery{}, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := warehouse.client.Do(req)
+	if err != nil {
+		return StagingFileDelivery{}, fmt.Errorf("http request to %q: %w", warehouse.baseURL, err)
+	}
+	defer func() { httputil.CloseResponse(resp) }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return StagingFileDelivery{}, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return StagingFileDelivery{}, fmt.Errorf("unexpected status code %q on %s: %v", resp.Status, warehouse.baseURL, string(body))
+	}
+
+	var delivery StagingFileDelivery
+	if err := json.Unmarshal(body, &delivery); err != nil {
+		return StagingFileDelivery{}, fmt.Errorf("unmarshalling response: %w", err)
+	}
+	return delivery, nil
+}
+
+// StreamRequest is a request to stream the events of a source and destination directly into the warehouse
+type StreamRequest struct {
+	SourceID      string               `json:"source_id"`
+	DestinationID string               `json:"destination_id"`
+	Events        []stdjson.RawMessage `json:"events"`
+}
+
+// StreamResponse is whether each of the events of a [StreamRequest] got streamed, the ones which didn't being left to
+// the staging files
+type StreamResponse struct {
+	Streamed []bool `json:"streamed"`
+}
+
+// Stream streams the events of a source and destination directly into the tables of the warehouse
+func (warehouse *Warehouse) Stream(ctx context.Context, streamRequest StreamRequest) (StreamResponse, error) {
+	jsonPayload, err := json.Marshal(streamRequest)
+	if err != nil {
+		return StreamResponse{}, fmt.Errorf("marshaling stream request: %w", err)
+	}
+
+	uri := fmt.Sprintf(`%s/internal/v1/warehouse/stream`, warehouse.baseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return StreamResponse{}, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := warehouse.client.Do(req)
+	if err != nil {
+		return StreamResponse{}, fmt.Errorf("http request to %q: %w", warehouse.baseURL, err)
+	}
+	defer func() { httputil.CloseResponse(resp) }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return StreamResponse{}, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return StreamResponse{}, fmt.Errorf("unexpected status code %q on %s: %v", resp.Status, warehouse.baseURL, string(body))
+	}
+
+	var streamResponse StreamResponse
+	if err := json.Unmarshal(body, &streamResponse); err != nil {
+		return StreamResponse{}, fmt.Errorf("unmarshalling response: %w", err)
+	}
+	if len(streamResponse.Streamed) != len(streamRequest.Events) {
+		return StreamResponse{}, fmt.Errorf("unexpected number of events in response: %d, expected %d", len(streamResponse.Streamed), len(streamRequest.Events))
+	}
+	return streamResponse, nil
+}
@@ -2,6 +2,7 @@ package client_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -54,6 +55,7 @@ func TestWarehouse(t *testing.T) {
 			Location:              "rudder-warehouse-staging-logs/279L3gEKqwruBoKGsXZtSVX7vIy/2022-11-08/1667913810.279L3gEKqwruBoKGsXZtSVX7vIy.7a6e7785-7a75-4345-8d3c-d7a1ce49a43f.json.gz",
 			TotalEvents:           2,
 			TotalBytes:            2000,
+			EventsPerTable:        map[string]int{"product_track": 1, "tracks": 1},
 			FirstEventAt:          "2022-11-08T13:23:07Z",
 			LastEventAt:           "2022-11-08T13:23:07Z",
 			UseRudderStorage:      false,
@@ -164,3 +166,57 @@ func TestWarehouse(t *testing.T) {
 		require.EqualError(t, err, fmt.Sprintf("http request to \"%[1]s\": Post \"%[1]s/v1/process\": context deadline exceeded (Client.Timeout exceeded while awaiting headers)", ts.URL))
 	})
 }
+
+func TestWarehouse_StagingFileDelivery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/internal/v1/warehouse/staging-files/delivery", r.URL.Path)
+		require.Equal(t, http.MethodGet, r.Method)
+		if r.URL.Query().Get("location") != "rudder-warehouse-staging-logs/source-1/staging.json.gz" {
+			http.Error(w, "staging file not found", http.StatusNotFound)
+			return
+		}
+		require.Equal(t, "source-1", r.URL.Query().Get("source_id"))
+		require.Equal(t, "destination-1", r.URL.Query().Get("destination_id"))
+		_, _ = w.Write([]byte(`{"staging_file_id":1,"status":"succeeded","upload_id":2,"upload_status":"exported_data"}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	c := client.NewWarehouse(ts.URL)
+	delivery, err := c.StagingFileDelivery(context.Background(), "source-1", "destination-1", "rudder-warehouse-staging-logs/source-1/staging.json.gz")
+	require.NoError(t, err)
+	require.Equal(t, client.StagingFileDelivery{StagingFileID: 1, Status: "succeeded", UploadID: 2, UploadStatus: "exported_data"}, delivery)
+
+	_, err = c.StagingFileDelivery(context.Background(), "source-1", "destination-1", "unknown")
+	require.ErrorContains(t, err, "staging file not found")
+}
+
+func TestWarehouse_Stream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/internal/v1/warehouse/stream", r.URL.Path)
+		require.Equal(t, http.MethodPost, r.Method)
+
+		var req client.StreamRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "source-1", req.SourceID)
+		require.Equal(t, "destination-1", req.DestinationID)
+		require.JSONEq(t, `{"id":1}`, string(req.Events[0]))
+		_, _ = w.Write([]byte(`{"streamed":[true,false]}`))
+	}))
+	t.Cleanup(ts.Close)
+
+	c := client.NewWarehouse(ts.URL)
+	res, err := c.Stream(context.Background(), client.StreamRequest{
+		SourceID:      "source-1",
+		DestinationID: "destination-1",
+		Events:        []json.RawMessage{[]byte(`{"id":1}`), []byte(`{"id":2}`)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, false}, res.Streamed)
+
+	_, err = c.Stream(context.Background(), client.StreamRequest{
+		SourceID:      "source-1",
+		DestinationID: "destination-1",
+		Events:        []json.RawMessage{[]byte(`{"id":1}`), []byte(`{"id":2}`), []byte(`{"id":3}`)},
+	})
+	require.ErrorContains(t, err, "unexpected number of events in response")
+}
@@ -1,8 +1,12 @@
 package constraints
 
 import (
+	"fmt"
+
 	"github.com/rudderlabs/rudder-go-kit/config"
 
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+
 	"github.com/rudderlabs/rudder-server/warehouse/utils/types"
 
 	"github.com/rudderlabs/rudder-server/utils/misc"
@@ -20,6 +24,8 @@ type Violation struct {
 	IsViolated         bool
 	ViolatedIdentifier string
 	Reason             string
+	// MaxValueSize is the size the value is beyond, only set for the violations of the max value size
+	MaxValueSize int
 }
 
 type indexConstraint struct {
@@ -30,6 +36,12 @@ type indexConstraint struct {
 	reason       string
 }
 
+// valueSizeConstraint limits the size of the string values, so that values too large for the warehouse are loaded
+// into the discards table instead of failing the load
+type valueSizeConstraint struct {
+	limit config.ValueLoader[int]
+}
+
 type Manager struct {
 	constraintsMap              map[string][]constraints
 	enableConstraintsViolations config.ValueLoader[bool]
@@ -72,6 +84,11 @@ func New(conf *config.Config) *Manager {
 			},
 		},
 	}
+	for destType, destName := range warehouseutils.WHDestNameMap {
+		cm.constraintsMap[destType] = append(cm.constraintsMap[destType], &valueSizeConstraint{
+			limit: conf.GetReloadableIntVar(0, 1, fmt.Sprintf("Warehouse.%s.maxValueSize", destName)),
+		})
+	}
 	cm.enableConstraintsViolations = conf.GetReloadableBoolVar(true, "Warehouse.enableConstraintsViolations")
 
 	return cm
@@ -126,3 +143,25 @@ func (ic *indexConstraint) violates(brEvent *types.BatchRouterEvent, columnName
 		Reason:             ic.reason,
 	}
 }
+
+func (vc *valueSizeConstraint) violates(brEvent *types.BatchRouterEvent, columnName string) *Violation {
+	limit := vc.limit.Load()
+	if limit <= 0 {
+		return &Violation{}
+	}
+
+	columnInfo, ok := brEvent.GetColumnInfo(columnName)
+	if !ok || (columnInfo.Type != string(model.StringDataType) && columnInfo.Type != string(model.TextDataType)) {
+		return &Violation{}
+	}
+	columnVal, ok := columnInfo.Value.(string)
+	if !ok || len(columnVal) <= limit {
+		return &Violation{}
+	}
+	return &Violation{
+		IsViolated:         true,
+		ViolatedIdentifier: strcase.ToKebab(warehouseutils.DiscardsTable) + "-" + misc.FastUUID().String(),
+		Reason:             fmt.Sprintf("The size of the value should be at most %d bytes", limit),
+		MaxValueSize:       limit,
+	}
+}
@@ -81,4 +81,36 @@ func TestConstraintsManager(t *testing.T) {
 			require.True(t, strings.HasPrefix(cv.ViolatedIdentifier, tc.expected.ViolatedIdentifier))
 		})
 	}
+
+	t.Run("value size", func(t *testing.T) {
+		c := config.New()
+		c.Set("Warehouse.redshift.maxValueSize", 10)
+
+		brEvent := &types.BatchRouterEvent{
+			Metadata: types.Metadata{
+				Table: "tracks",
+				Columns: model.TableSchema{
+					"short":  "string",
+					"long":   "text",
+					"number": "int",
+				},
+			},
+			Data: map[string]interface{}{
+				"short":  "value",
+				"long":   "a value longer than the limit",
+				"number": 12345678901,
+			},
+		}
+
+		cm := New(c)
+		cv := cm.ViolatedConstraints(warehouseutils.RS, brEvent, "long")
+		require.True(t, cv.IsViolated)
+		require.True(t, strings.HasPrefix(cv.ViolatedIdentifier, "rudder-discards-"))
+		require.Equal(t, "The size of the value should be at most 10 bytes", cv.Reason)
+		require.Equal(t, 10, cv.MaxValueSize)
+
+		require.False(t, cm.ViolatedConstraints(warehouseutils.RS, brEvent, "short").IsViolated)
+		require.False(t, cm.ViolatedConstraints(warehouseutils.RS, brEvent, "number").IsViolated)
+		require.False(t, cm.ViolatedConstraints(warehouseutils.POSTGRES, brEvent, "long").IsViolated)
+	})
 }
@@ -1,6 +1,7 @@
 package encoding
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -78,6 +79,9 @@ func parquetValue(val interface{}, colType string) (interface{}, error) {
 	case "string", "text":
 		retVal, err := getString(val)
 		return retVal, err
+	case "json":
+		retVal, err := getJSONString(val)
+		return retVal, err
 	}
 	return nil, fmt.Errorf("unsupported type for parquet: %s", colType)
 }
@@ -127,3 +131,14 @@ func getString(val interface{}) (string, error) {
 		return stringVal, nil
 	}
 }
+
+func getJSONString(val interface{}) (string, error) {
+	if stringVal, ok := val.(string); ok {
+		return stringVal, nil
+	}
+	jsonVal, err := json.Marshal(val)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert %v to json: %w", val, err)
+	}
+	return string(jsonVal), nil
+}
@@ -34,6 +34,7 @@ var rudderDataTypeToParquetDataType = map[string]map[string]string{
 		"string":   parquetString,
 		"text":     parquetString,
 		"datetime": parquetTimestampMicros,
+		"json":     parquetString,
 	},
 	warehouseutils.S3Datalake: {
 		"bigint":   parquetInt64,
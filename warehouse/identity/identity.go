@@ -77,22 +77,24 @@ func (idr *Identity) whMappingsTable() string {
 }
 
 func (idr *Identity) applyRule(txn *sqlmiddleware.Tx, ruleID int64, gzWriter *misc.GZipWriter) (totalRowsModified int, err error) {
-	sqlStatement := fmt.Sprintf(`SELECT merge_property_1_type, merge_property_1_value, merge_property_2_type, merge_property_2_value FROM %s WHERE id=%v`, idr.mergeRulesTable(), ruleID)
+	sqlStatement := fmt.Sprintf(`SELECT merge_property_1_type, merge_property_1_value, merge_property_2_type, merge_property_2_value FROM %s WHERE id = $1`, idr.mergeRulesTable())
 
 	var prop1Val, prop2Val, prop1Type, prop2Type sql.NullString
-	err = txn.QueryRow(sqlStatement).Scan(&prop1Type, &prop1Val, &prop2Type, &prop2Val)
+	err = txn.QueryRow(sqlStatement, ruleID).Scan(&prop1Type, &prop1Val, &prop2Type, &prop2Val)
 	if err != nil {
 		return
 	}
 
 	var rudderIDs []string
 	var additionalClause string
+	args := []any{prop1Type.String, prop1Val.String}
 	if prop2Val.Valid && prop2Type.Valid {
-		additionalClause = fmt.Sprintf(`OR (merge_property_type='%s' AND merge_property_value=%s)`, prop2Type.String, misc.QuoteLiteral(prop2Val.String))
+		additionalClause = `OR (merge_property_type = $3 AND merge_property_value = $4)`
+		args = append(args, prop2Type.String, prop2Val.String)
 	}
-	sqlStatement = fmt.Sprintf(`SELECT ARRAY_AGG(DISTINCT(rudder_id)) FROM %s WHERE (merge_property_type='%s' AND merge_property_value=%s) %s`, idr.mappingsTable(), prop1Type.String, misc.QuoteLiteral(prop1Val.String), additionalClause)
+	sqlStatement = fmt.Sprintf(`SELECT ARRAY_AGG(DISTINCT(rudder_id)) FROM %s WHERE (merge_property_type = $1 AND merge_property_value = $2) %s`, idr.mappingsTable(), additionalClause)
 	pkgLogger.Debugf(`IDR: Fetching all rudder_id's corresponding to the merge_rule: %v`, sqlStatement)
-	err = txn.QueryRow(sqlStatement).Scan(pq.Array(&rudderIDs))
+	err = txn.QueryRow(sqlStatement, args...).Scan(pq.Array(&rudderIDs))
 	if err != nil {
 		pkgLogger.Errorf("IDR: Error fetching all rudder_id's corresponding to the merge_rule: %v\nwith Error: %v", sqlStatement, err)
 		return
@@ -112,20 +114,15 @@ func (idr *Identity) applyRule(txn *sqlmiddleware.Tx, ruleID int64, gzWriter *mi
 		} else {
 			rudderID = rudderIDs[0]
 		}
-		row1 := []string{prop1Type.String, prop1Val.String, rudderID, currentTimeString}
-		rows = append(rows, row1)
-		row1Values := misc.SingleQuoteLiteralJoin(row1)
-
-		var row2Values string
+		rows = append(rows, []string{prop1Type.String, prop1Val.String, rudderID, currentTimeString})
 		if prop2Val.Valid && prop2Type.Valid {
-			row2 := []string{prop2Type.String, prop2Val.String, rudderID, currentTimeString}
-			rows = append(rows, row2)
-			row2Values = fmt.Sprintf(`, (%s)`, misc.SingleQuoteLiteralJoin(row2))
+			rows = append(rows, []string{prop2Type.String, prop2Val.String, rudderID, currentTimeString})
 		}
 
-		sqlStatement = fmt.Sprintf(`INSERT INTO %s (merge_property_type, merge_property_value, rudder_id, updated_at) VALUES (%s) %s ON CONFLICT ON CONSTRAINT %s DO NOTHING`, idr.mappingsTable(), row1Values, row2Values, warehouseutils.IdentityMappingsUniqueMappingConstraintName(idr.warehouse))
+		values, args := mappingsValues(rows)
+		sqlStatement = fmt.Sprintf(`INSERT INTO %s (merge_property_type, merge_property_value, rudder_id, updated_at) VALUES %s ON CONFLICT ON CONSTRAINT %s DO NOTHING`, idr.mappingsTable(), values, warehouseutils.IdentityMappingsUniqueMappingConstraintName(idr.warehouse))
 		pkgLogger.Debugf(`IDR: Inserting properties from merge_rule into mappings table: %v`, sqlStatement)
-		_, err = txn.Exec(sqlStatement)
+		_, err = txn.Exec(sqlStatement, args...)
 		if err != nil {
 			pkgLogger.Errorf(`IDR: Error inserting properties from merge_rule into mappings table: %v`, err)
 			return
@@ -133,22 +130,16 @@ func (idr *Identity) applyRule(txn *sqlmiddleware.Tx, ruleID int64, gzWriter *mi
 	} else {
 		// generate new one and update all
 		newID := rudderIDs[0]
-		row1 := []string{prop1Type.String, prop1Val.String, newID, currentTimeString}
-		rows = append(rows, row1)
-		row1Values := misc.SingleQuoteLiteralJoin(row1)
-
-		var row2Values string
+		rows = append(rows, []string{prop1Type.String, prop1Val.String, newID, currentTimeString})
 		if prop2Val.Valid && prop2Type.Valid {
-			row2 := []string{prop2Type.String, prop2Val.String, newID, currentTimeString}
-			rows = append(rows, row2)
-			row2Values = fmt.Sprintf(`, (%s)`, misc.SingleQuoteLiteralJoin(row2))
+			rows = append(rows, []string{prop2Type.String, prop2Val.String, newID, currentTimeString})
 		}
+		newValues, newArgs := mappingsValues(rows)
 
-		quotedRudderIDs := misc.SingleQuoteLiteralJoin(rudderIDs)
-		sqlStatement := fmt.Sprintf(`SELECT merge_property_type, merge_property_value FROM %s WHERE rudder_id IN (%v)`, idr.mappingsTable(), quotedRudderIDs)
-		pkgLogger.Debugf(`IDR: Get all merge properties from mapping table with rudder_id's %v: %v`, quotedRudderIDs, sqlStatement)
+		sqlStatement := fmt.Sprintf(`SELECT merge_property_type, merge_property_value FROM %s WHERE rudder_id = ANY($1)`, idr.mappingsTable())
+		pkgLogger.Debugf(`IDR: Get all merge properties from mapping table with rudder_id's %v: %v`, rudderIDs, sqlStatement)
 		var tableRows *sqlmiddleware.Rows
-		tableRows, err = txn.Query(sqlStatement)
+		tableRows, err = txn.Query(sqlStatement, pq.Array(rudderIDs))
 		if err != nil {
 			return
 		}
@@ -167,18 +158,18 @@ func (idr *Identity) applyRule(txn *sqlmiddleware.Tx, ruleID int64, gzWriter *mi
 			return
 		}
 
-		sqlStatement = fmt.Sprintf(`UPDATE %s SET rudder_id='%s', updated_at='%s' WHERE rudder_id IN (%v)`, idr.mappingsTable(), newID, currentTimeString, misc.SingleQuoteLiteralJoin(rudderIDs[1:]))
+		sqlStatement = fmt.Sprintf(`UPDATE %s SET rudder_id = $1, updated_at = $2 WHERE rudder_id = ANY($3)`, idr.mappingsTable())
 		var res sql.Result
-		res, err = txn.Exec(sqlStatement)
+		res, err = txn.Exec(sqlStatement, newID, currentTimeString, pq.Array(rudderIDs[1:]))
 		if err != nil {
 			return
 		}
 		affectedRowCount, _ := res.RowsAffected()
 		pkgLogger.Debugf(`IDR: Updated rudder_id for all properties in mapping table. Updated %v rows: %v `, affectedRowCount, sqlStatement)
 
-		sqlStatement = fmt.Sprintf(`INSERT INTO %s (merge_property_type, merge_property_value, rudder_id, updated_at) VALUES (%s) %s ON CONFLICT ON CONSTRAINT %s DO NOTHING`, idr.mappingsTable(), row1Values, row2Values, warehouseutils.IdentityMappingsUniqueMappingConstraintName(idr.warehouse))
+		sqlStatement = fmt.Sprintf(`INSERT INTO %s (merge_property_type, merge_property_value, rudder_id, updated_at) VALUES %s ON CONFLICT ON CONSTRAINT %s DO NOTHING`, idr.mappingsTable(), newValues, warehouseutils.IdentityMappingsUniqueMappingConstraintName(idr.warehouse))
 		pkgLogger.Debugf(`IDR: Insert new mappings into %s: %v`, idr.mappingsTable(), sqlStatement)
-		_, err = txn.Exec(sqlStatement)
+		_, err = txn.Exec(sqlStatement, newArgs...)
 		if err != nil {
 			return
 		}
@@ -195,6 +186,21 @@ func (idr *Identity) applyRule(txn *sqlmiddleware.Tx, ruleID int64, gzWriter *mi
 	return len(rows), err
 }
 
+// mappingsValues returns the VALUES list of the rows of the mappings table along with their arguments
+func mappingsValues(rows [][]string) (string, []any) {
+	values := make([]string, 0, len(rows))
+	args := make([]any, 0, len(rows)*4)
+	for _, row := range rows {
+		placeholders := make([]string, 0, len(row))
+		for _, value := range row {
+			args = append(args, value)
+			placeholders = append(placeholders, fmt.Sprintf(`$%d`, len(args)))
+		}
+		values = append(values, fmt.Sprintf(`(%s)`, strings.Join(placeholders, ", ")))
+	}
+	return strings.Join(values, ", "), args
+}
+
 func (idr *Identity) addRules(txn *sqlmiddleware.Tx, loadFileNames []string, gzWriter *misc.GZipWriter) (ids []int64, err error) {
 	// add rules from load files into temp table
 	// use original table to delete redundant ones from temp table
@@ -344,10 +350,10 @@ func (idr *Identity) writeTableToFile(tableName string, txn *sqlmiddleware.Tx, g
 
 	var offset int64
 	for {
-		sqlStatement = fmt.Sprintf(`SELECT merge_property_1_type, merge_property_1_value, merge_property_2_type, merge_property_2_value FROM %s LIMIT %d OFFSET %d`, tableName, batchSize, offset)
+		sqlStatement = fmt.Sprintf(`SELECT merge_property_1_type, merge_property_1_value, merge_property_2_type, merge_property_2_value FROM %s LIMIT $1 OFFSET $2`, tableName)
 
 		var rows *sqlmiddleware.Rows
-		rows, err = txn.Query(sqlStatement)
+		rows, err = txn.Query(sqlStatement, batchSize, offset)
 		if err != nil {
 			return
 		}
@@ -409,9 +415,9 @@ func (idr *Identity) uploadFile(ctx context.Context, filePath string, txn *sqlmi
 		return
 	}
 
-	sqlStatement := fmt.Sprintf(`UPDATE %s SET location='%s', total_events=%d WHERE wh_upload_id=%d AND table_name='%s'`, warehouseutils.WarehouseTableUploadsTable, output.Location, totalRecords, idr.uploadID, warehouseutils.ToProviderCase(idr.warehouse.Destination.DestinationDefinition.Name, tableName))
+	sqlStatement := fmt.Sprintf(`UPDATE %s SET location = $1, total_events = $2 WHERE wh_upload_id = $3 AND table_name = $4`, warehouseutils.WarehouseTableUploadsTable)
 	pkgLogger.Infof(`IDR: Updating load file location for table: %s: %s `, tableName, sqlStatement)
-	_, err = txn.Exec(sqlStatement)
+	_, err = txn.Exec(sqlStatement, output.Location, totalRecords, idr.uploadID, warehouseutils.ToProviderCase(idr.warehouse.Destination.DestinationDefinition.Name, tableName))
 	if err != nil {
 		pkgLogger.Errorf(`IDR: Error updating load file location for table: %s: %v`, tableName, err)
 	}
@@ -14,6 +14,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf16"
 	"unicode/utf8"
@@ -97,6 +98,11 @@ type AzureSynapse struct {
 	conf   *config.Config
 	logger logger.Logger
 
+	// stagingSchema is the schema the staging tables get created in, the namespace unless configured otherwise
+	stagingSchema        string
+	stagingSchemaMu      sync.Mutex
+	stagingSchemaCreated bool
+
 	config struct {
 		numWorkersDownloadLoadFiles int
 		slowQueryThreshold          time.Duration
@@ -251,15 +257,20 @@ func (as *AzureSynapse) loadTable(
 	// - See the discussion at https://github.com/denisenkom/go-mssqldb/issues/149 regarding prepared statements.
 	// - Refer to Microsoft's documentation on temporary tables at
 	//   https://docs.microsoft.com/en-us/previous-versions/sql/sql-server-2008-r2/ms175528(v=sql.105)?redirectedfrom=MSDN.
+	if err = as.createStagingSchema(ctx); err != nil {
+		return nil, "", fmt.Errorf("creating staging schema: %w", err)
+	}
+
 	log.Debugw("creating staging table")
 	createStagingTableStmt := fmt.Sprintf(`
 		SELECT
-		  TOP 0 * INTO %[1]s.%[2]s
+		  TOP 0 * INTO %[4]s.%[2]s
 		FROM
 		  %[1]s.%[3]s;`,
 		as.Namespace,
 		stagingTableName,
 		tableName,
+		as.stagingSchema,
 	)
 	if _, err = as.DB.ExecContext(ctx, createStagingTableStmt); err != nil {
 		return nil, "", fmt.Errorf("creating temporary table: %w", err)
@@ -294,7 +305,7 @@ func (as *AzureSynapse) loadTable(
 	})
 
 	log.Debugw("creating prepared stmt for loading data")
-	copyInStmt := mssql.CopyIn(as.Namespace+"."+stagingTableName, mssql.BulkOptions{CheckConstraints: false},
+	copyInStmt := mssql.CopyIn(as.stagingSchema+"."+stagingTableName, mssql.BulkOptions{CheckConstraints: false},
 		append(sortedColumnKeys, extraColumns...)...,
 	)
 	stmt, err := txn.PrepareContext(ctx, copyInStmt)
@@ -509,7 +520,7 @@ func (as *AzureSynapse) deleteFromLoadTable(
 		DELETE FROM
 		  %[1]q.%[2]q
 		FROM
-		  %[1]q.%[3]q AS _source
+		  %[6]q.%[3]q AS _source
 		WHERE
 		  (
 			_source.%[4]s = %[1]q.%[2]q.%[4]q %[5]s
@@ -519,6 +530,7 @@ func (as *AzureSynapse) deleteFromLoadTable(
 		stagingTableName,
 		primaryKey,
 		additionalJoinClause,
+		as.stagingSchema,
 	)
 
 	r, err := txn.ExecContext(ctx, deleteStmt)
@@ -558,7 +570,7 @@ func (as *AzureSynapse) insertIntoLoadTable(
 				  received_at DESC
 			  ) AS _rudder_staging_row_number
 			FROM
-			  %[1]q.%[4]q
+			  %[6]q.%[4]q
 		  ) AS _
 		WHERE
 		  _rudder_staging_row_number = 1;`,
@@ -567,6 +579,7 @@ func (as *AzureSynapse) insertIntoLoadTable(
 		quotedColumnNames,
 		stagingTableName,
 		partitionKey,
+		as.stagingSchema,
 	)
 
 	r, err := txn.ExecContext(ctx, insertStmt)
@@ -630,7 +643,7 @@ func (as *AzureSynapse) loadUserTables(ctx context.Context) (errorMap map[string
 							  and %[1]q is not null
 							order by received_at desc
 							)
-						  end as %[1]q`, colName, as.Namespace+"."+unionStagingTableName)
+						  end as %[1]q`, colName, as.stagingSchema+"."+unionStagingTableName)
 		// IGNORE NULLS only supported in Azure SQL edge, in which case the query can be shortened to below
 		// https://docs.microsoft.com/en-us/sql/t-sql/functions/first-value-transact-sql?view=sql-server-ver15
 		// caseSubQuery := fmt.Sprintf(`FIRST_VALUE(%[1]s) IGNORE NULLS OVER (PARTITION BY id ORDER BY received_at DESC ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING) AS "%[1]s"`, colName)
@@ -645,7 +658,7 @@ func (as *AzureSynapse) loadUserTables(ctx context.Context) (errorMap map[string
 												(
 													SELECT user_id, %[4]s FROM %[3]s  WHERE user_id IS NOT NULL
 												)) a
-											`, as.Namespace, as.Namespace+"."+warehouseutils.UsersTable, as.Namespace+"."+identifyStagingTable, strings.Join(userColNames, ","), as.Namespace+"."+unionStagingTableName)
+											`, as.Namespace, as.Namespace+"."+warehouseutils.UsersTable, as.stagingSchema+"."+identifyStagingTable, strings.Join(userColNames, ","), as.stagingSchema+"."+unionStagingTableName)
 
 	as.logger.Debugf("AZ: Creating staging table for union of users table with identify staging table: %s\n", sqlStatement)
 	_, err = as.DB.ExecContext(ctx, sqlStatement)
@@ -661,9 +674,9 @@ func (as *AzureSynapse) loadUserTables(ctx context.Context) (errorMap map[string
 											FROM %[3]s as x
 										) as xyz
 									) a`,
-		as.Namespace+"."+stagingTableName,
+		as.stagingSchema+"."+stagingTableName,
 		strings.Join(firstValProps, ","),
-		as.Namespace+"."+unionStagingTableName,
+		as.stagingSchema+"."+unionStagingTableName,
 	)
 
 	as.logger.Debugf("AZ: Creating staging table for users: %s\n", sqlStatement)
@@ -682,7 +695,7 @@ func (as *AzureSynapse) loadUserTables(ctx context.Context) (errorMap map[string
 	}
 
 	primaryKey := "id"
-	sqlStatement = fmt.Sprintf(`DELETE FROM %[1]s."%[2]s" FROM %[3]s _source where (_source.%[4]s = %[1]s.%[2]s.%[4]s)`, as.Namespace, warehouseutils.UsersTable, as.Namespace+"."+stagingTableName, primaryKey)
+	sqlStatement = fmt.Sprintf(`DELETE FROM %[1]s."%[2]s" FROM %[3]s _source where (_source.%[4]s = %[1]s.%[2]s.%[4]s)`, as.Namespace, warehouseutils.UsersTable, as.stagingSchema+"."+stagingTableName, primaryKey)
 	as.logger.Infof("AZ: Dedup records for table:%s using staging table: %s\n", warehouseutils.UsersTable, sqlStatement)
 	_, err = tx.ExecContext(ctx, sqlStatement)
 	if err != nil {
@@ -692,7 +705,7 @@ func (as *AzureSynapse) loadUserTables(ctx context.Context) (errorMap map[string
 		return
 	}
 
-	sqlStatement = fmt.Sprintf(`INSERT INTO "%[1]s"."%[2]s" (%[4]s) SELECT %[4]s FROM  %[3]s`, as.Namespace, warehouseutils.UsersTable, as.Namespace+"."+stagingTableName, strings.Join(append([]string{"id"}, userColNames...), ","))
+	sqlStatement = fmt.Sprintf(`INSERT INTO "%[1]s"."%[2]s" (%[4]s) SELECT %[4]s FROM  %[3]s`, as.Namespace, warehouseutils.UsersTable, as.stagingSchema+"."+stagingTableName, strings.Join(append([]string{"id"}, userColNames...), ","))
 	as.logger.Infof("AZ: Inserting records for table:%s using staging table: %s\n", warehouseutils.UsersTable, sqlStatement)
 	_, err = tx.ExecContext(ctx, sqlStatement)
 	if err != nil {
@@ -727,11 +740,28 @@ func (as *AzureSynapse) CreateSchema(ctx context.Context) (err error) {
 	return
 }
 
+// createStagingSchema creates the staging schema, if configured, before the first staging table of the upload
+func (as *AzureSynapse) createStagingSchema(ctx context.Context) error {
+	as.stagingSchemaMu.Lock()
+	defer as.stagingSchemaMu.Unlock()
+
+	if as.stagingSchemaCreated || as.stagingSchema == as.Namespace {
+		return nil
+	}
+	sqlStatement := fmt.Sprintf(`IF NOT EXISTS ( SELECT  * FROM  sys.schemas WHERE   name = N'%s' )
+    EXEC('CREATE SCHEMA [%s]');`, as.stagingSchema, as.stagingSchema)
+	if _, err := as.DB.ExecContext(ctx, sqlStatement); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	as.stagingSchemaCreated = true
+	return nil
+}
+
 func (as *AzureSynapse) dropStagingTable(ctx context.Context, stagingTableName string) {
 	as.logger.Infof("AZ: dropping table %+v\n", stagingTableName)
-	_, err := as.DB.ExecContext(ctx, fmt.Sprintf(`IF OBJECT_ID ('%[1]s','U') IS NOT NULL DROP TABLE %[1]s;`, as.Namespace+"."+stagingTableName))
+	_, err := as.DB.ExecContext(ctx, fmt.Sprintf(`IF OBJECT_ID ('%[1]s','U') IS NOT NULL DROP TABLE %[1]s;`, as.stagingSchema+"."+stagingTableName))
 	if err != nil {
-		as.logger.Errorf("AZ:  Error dropping staging table %s in synapse: %v", as.Namespace+"."+stagingTableName, err)
+		as.logger.Errorf("AZ:  Error dropping staging table %s in synapse: %v", as.stagingSchema+"."+stagingTableName, err)
 	}
 }
 
@@ -820,6 +850,10 @@ func (as *AzureSynapse) Setup(_ context.Context, warehouse model.Warehouse, uplo
 	as.Warehouse = warehouse
 	as.Namespace = warehouse.Namespace
 	as.Uploader = uploader
+	as.stagingSchema = as.Namespace
+	if stagingSchema := warehouse.GetStringDestinationConfig(as.conf, model.StagingSchemaSetting); stagingSchema != "" {
+		as.stagingSchema = warehouseutils.ToSafeNamespace(warehouseutils.AzureSynapse, stagingSchema)
+	}
 	as.ObjectStorage = warehouseutils.ObjectStorageType(warehouseutils.AzureSynapse, warehouse.Destination.Config, as.Uploader.UseRudderStorage())
 	as.LoadFileDownLoader = downloader.NewDownloader(&warehouse, uploader, as.config.numWorkersDownloadLoadFiles)
 
@@ -833,20 +867,31 @@ func (as *AzureSynapse) CrashRecover(ctx context.Context) error {
 	return as.dropDanglingStagingTables(ctx)
 }
 
+// dropDanglingStagingTables drops the staging tables left behind in the staging schema, and in the namespace in case
+// the staging schema got configured after they were created there
 func (as *AzureSynapse) dropDanglingStagingTables(ctx context.Context) error {
-	sqlStatement := fmt.Sprintf(`
+	for _, schema := range lo.Uniq([]string{as.Namespace, as.stagingSchema}) {
+		if err := as.dropDanglingStagingTablesInSchema(ctx, schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (as *AzureSynapse) dropDanglingStagingTablesInSchema(ctx context.Context, schema string) error {
+	sqlStatement := `
 		select
 		  table_name
 		from
 		  information_schema.tables
 		where
-		  table_schema = '%s'
-		  AND table_name like '%s';
-	`,
-		as.Namespace,
-		fmt.Sprintf(`%s%%`, warehouseutils.StagingTablePrefix(provider)),
+		  table_schema = @schema
+		  AND table_name like @prefix;
+	`
+	rows, err := as.DB.QueryContext(ctx, sqlStatement,
+		sql.Named("schema", schema),
+		sql.Named("prefix", fmt.Sprintf(`%s%%`, warehouseutils.StagingTablePrefix(provider))),
 	)
-	rows, err := as.DB.QueryContext(ctx, sqlStatement)
 	if err != nil {
 		return fmt.Errorf("querying for dangling staging tables: %w", err)
 	}
@@ -866,9 +911,9 @@ func (as *AzureSynapse) dropDanglingStagingTables(ctx context.Context) error {
 	}
 	as.logger.Infof("WH: SYNAPSE: Dropping dangling staging tables: %+v  %+v\n", len(stagingTableNames), stagingTableNames)
 	for _, stagingTableName := range stagingTableNames {
-		_, err := as.DB.ExecContext(ctx, fmt.Sprintf(`DROP TABLE "%[1]s"."%[2]s"`, as.Namespace, stagingTableName))
+		_, err := as.DB.ExecContext(ctx, fmt.Sprintf(`DROP TABLE "%[1]s"."%[2]s"`, schema, stagingTableName))
 		if err != nil {
-			return fmt.Errorf("dropping dangling staging table %q.%q: %w", as.Namespace, stagingTableName, err)
+			return fmt.Errorf("dropping dangling staging table %q.%q: %w", schema, stagingTableName, err)
 		}
 	}
 	return nil
@@ -94,6 +94,19 @@ var partitionKeyMap = map[string]string{
 	warehouseutils.IdentityMergeRulesTable: "merge_property_1_type, merge_property_1_value, merge_property_2_type, merge_property_2_value",
 }
 
+// partitionColumns are the columns which the tables can be partitioned by instead of the ingestion time
+var partitionColumns = []string{"loaded_at", "received_at", "timestamp", "original_timestamp", "sent_at"}
+
+var partitionTypes = map[string]bigquery.TimePartitioningType{
+	"hour":  bigquery.HourPartitioningType,
+	"day":   bigquery.DayPartitioningType,
+	"month": bigquery.MonthPartitioningType,
+	"year":  bigquery.YearPartitioningType,
+}
+
+// maxClusteringColumns is the maximum number of clustering columns of a table
+const maxClusteringColumns = 4
+
 var errorsMappings = []model.JobError{
 	{
 		Type:   model.PermissionError,
@@ -165,11 +178,7 @@ func (bq *BigQuery) DeleteTable(ctx context.Context, tableName string) (err erro
 
 func (bq *BigQuery) CreateTable(ctx context.Context, tableName string, columnMap model.TableSchema) error {
 	bq.logger.Infof("BQ: Creating table: %s in bigquery dataset: %s in project: %s", tableName, bq.namespace, bq.projectID)
-	sampleSchema := getTableSchema(columnMap)
-	metaData := &bigquery.TableMetadata{
-		Schema:           sampleSchema,
-		TimePartitioning: &bigquery.TimePartitioning{},
-	}
+	metaData := bq.tableMetadata(tableName, columnMap)
 	tableRef := bq.db.Dataset(bq.namespace).Table(tableName)
 	err := tableRef.Create(ctx, metaData)
 	if !checkAndIgnoreAlreadyExistError(err) {
@@ -183,6 +192,52 @@ func (bq *BigQuery) CreateTable(ctx context.Context, tableName string, columnMap
 	return nil
 }
 
+// tableMetadata returns the metadata of a new table, which is ingestion-time partitioned by day unless the partitioning
+// and clustering of the tables are configured for the destination. The users and identifies tables are always
+// ingestion-time partitioned by day, since the users table is loaded from the partition of the identifies table.
+func (bq *BigQuery) tableMetadata(tableName string, columnMap model.TableSchema) *bigquery.TableMetadata {
+	metaData := &bigquery.TableMetadata{
+		Schema:           getTableSchema(columnMap),
+		TimePartitioning: &bigquery.TimePartitioning{},
+	}
+	if !bq.customTablePartitioning(tableName) {
+		return metaData
+	}
+
+	partitionType := strings.ToLower(bq.warehouse.GetStringDestinationConfig(bq.conf, model.PartitionTypeSetting))
+	if t, ok := partitionTypes[partitionType]; ok {
+		metaData.TimePartitioning.Type = t
+	}
+	partitionColumn := bq.warehouse.GetStringDestinationConfig(bq.conf, model.PartitionColumnSetting)
+	if slices.Contains(partitionColumns, partitionColumn) && columnMap[partitionColumn] == "datetime" {
+		metaData.TimePartitioning.Field = partitionColumn
+	}
+	metaData.RequirePartitionFilter = bq.warehouse.GetBoolDestinationConfig(model.RequirePartitionFilterSetting)
+
+	var clusteringColumns []string
+	for _, column := range strings.Split(bq.warehouse.GetStringDestinationConfig(bq.conf, model.ClusteringColumnsSetting), ",") {
+		column = strings.TrimSpace(column)
+		if _, ok := columnMap[column]; ok && !slices.Contains(clusteringColumns, column) && len(clusteringColumns) < maxClusteringColumns {
+			clusteringColumns = append(clusteringColumns, column)
+		}
+	}
+	if len(clusteringColumns) > 0 {
+		metaData.Clustering = &bigquery.Clustering{Fields: clusteringColumns}
+	}
+	return metaData
+}
+
+// customTablePartitioning returns whether the partitioning or clustering of the table is configured for the destination
+func (bq *BigQuery) customTablePartitioning(tableName string) bool {
+	if tableName == warehouseutils.UsersTable || tableName == warehouseutils.IdentifiesTable {
+		return false
+	}
+	return bq.warehouse.GetStringDestinationConfig(bq.conf, model.PartitionColumnSetting) != "" ||
+		bq.warehouse.GetStringDestinationConfig(bq.conf, model.PartitionTypeSetting) != "" ||
+		bq.warehouse.GetStringDestinationConfig(bq.conf, model.ClusteringColumnsSetting) != "" ||
+		bq.warehouse.GetBoolDestinationConfig(model.RequirePartitionFilterSetting)
+}
+
 func (bq *BigQuery) DropTable(ctx context.Context, tableName string) error {
 	if err := bq.DeleteTable(ctx, tableName); err != nil {
 		return err
@@ -331,6 +386,52 @@ func (bq *BigQuery) DeleteBy(ctx context.Context, tableNames []string, params wa
 	return nil
 }
 
+// DeleteLoadedRows deletes the rows of the table loaded by the upload, see [warehouseutils.LoadIDPattern]
+func (bq *BigQuery) DeleteLoadedRows(ctx context.Context, tableName string, uploadID int64) error {
+	sqlStatement := fmt.Sprintf("DELETE FROM `%s`.`%s` WHERE %s LIKE @loadid;",
+		bq.namespace,
+		tableName,
+		warehouseutils.LoadIDColumn,
+	)
+	query := bq.db.Query(sqlStatement)
+	query.Parameters = []bigquery.QueryParameter{
+		{Name: "loadid", Value: warehouseutils.LoadIDPattern(uploadID)},
+	}
+	job, err := bq.getMiddleware().Run(ctx, query)
+	if err != nil {
+		return fmt.Errorf("running delete job: %w", err)
+	}
+	status, err := job.Wait(ctx)
+	if err != nil {
+		return fmt.Errorf("waiting for delete job: %w", err)
+	}
+	return status.Err()
+}
+
+// StreamRows inserts the rows into the table through the streaming inserts of BigQuery, see [manager.Streamer]
+func (bq *BigQuery) StreamRows(ctx context.Context, tableName string, _ model.TableSchema, rows []map[string]any) error {
+	savers := make([]bigquery.ValueSaver, 0, len(rows))
+	for _, row := range rows {
+		savers = append(savers, streamedRow(row))
+	}
+	if err := bq.db.Dataset(bq.namespace).Table(tableName).Inserter().Put(ctx, savers); err != nil {
+		return fmt.Errorf("streaming rows: %w", err)
+	}
+	return nil
+}
+
+// streamedRow is a row streamed into BigQuery, its insert id being generated by the client so that retries of the
+// insert get deduplicated
+type streamedRow map[string]any
+
+func (r streamedRow) Save() (map[string]bigquery.Value, string, error) {
+	values := make(map[string]bigquery.Value, len(r))
+	for column, value := range r {
+		values[column] = value
+	}
+	return values, "", nil
+}
+
 func partitionedTable(tableName, partitionDate string) string {
 	return fmt.Sprintf(`%s$%v`, tableName, strings.ReplaceAll(partitionDate, "-", ""))
 }
@@ -395,7 +496,8 @@ func (bq *BigQuery) loadFileLocations(
 // based on the time when BigQuery ingests the data. To support custom field partitions, it is
 // important to avoid loading data into partitioned tables with names like tableName$20191221.
 // Instead, ensure that data is loaded into the appropriate ingestion-time partition, allowing
-// BigQuery to manage partitioning based on the data's ingestion time.
+// BigQuery to manage partitioning based on the data's ingestion time. The same goes for the
+// tables whose partitioning is configured for the destination, see tableMetadata.
 //
 // TODO: Support custom field partition on users & identifies tables
 func (bq *BigQuery) loadTableByAppend(
@@ -410,7 +512,7 @@ func (bq *BigQuery) loadTableByAppend(
 		tableName,
 		partitionDate,
 	)
-	if bq.config.customPartitionsEnabled || slices.Contains(bq.config.customPartitionsEnabledWorkspaceIDs, bq.warehouse.WorkspaceID) {
+	if bq.config.customPartitionsEnabled || slices.Contains(bq.config.customPartitionsEnabledWorkspaceIDs, bq.warehouse.WorkspaceID) || bq.customTablePartitioning(tableName) {
 		outputTable = tableName
 	}
 
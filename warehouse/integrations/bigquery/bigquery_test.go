@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"slices"
 	"strconv"
@@ -709,6 +710,65 @@ func TestIntegration(t *testing.T) {
 			)
 			require.Equal(t, records, whth.SampleTestRecords())
 		})
+		t.Run("partition column and clustering", func(t *testing.T) {
+			tableName := "partition_column_test_table"
+
+			uploadOutput := whth.UploadLoadFile(t, fm, "../testdata/load.json.gz", tableName)
+
+			loadFiles := []warehouseutils.LoadFile{{Location: uploadOutput.Location}}
+			mockUploader := newMockUploader(
+				t, loadFiles, tableName, schemaInUpload,
+				schemaInWarehouse,
+			)
+
+			partitionedWarehouse := warehouse
+			partitionedWarehouse.Destination.Config = maps.Clone(warehouse.Destination.Config)
+			partitionedWarehouse.Destination.Config["partitionColumn"] = "received_at"
+			partitionedWarehouse.Destination.Config["partitionType"] = "month"
+			partitionedWarehouse.Destination.Config["requirePartitionFilter"] = true
+			partitionedWarehouse.Destination.Config["clusteringColumns"] = "test_string, id, unknown_column"
+
+			bq := whbigquery.New(config.New(), logger.NOP)
+			err := bq.Setup(ctx, partitionedWarehouse, mockUploader)
+			require.NoError(t, err)
+
+			err = bq.CreateSchema(ctx)
+			require.NoError(t, err)
+
+			err = bq.CreateTable(ctx, tableName, schemaInWarehouse)
+			require.NoError(t, err)
+
+			metadata, err := db.Dataset(namespace).Table(tableName).Metadata(ctx)
+			require.NoError(t, err)
+			require.Equal(t, "received_at", metadata.TimePartitioning.Field)
+			require.Equal(t, bigquery.MonthPartitioningType, metadata.TimePartitioning.Type)
+			require.True(t, metadata.RequirePartitionFilter)
+			require.Equal(t, []string{"test_string", "id"}, metadata.Clustering.Fields)
+
+			loadTableStat, err := bq.LoadTable(ctx, tableName)
+			require.NoError(t, err)
+			require.Equal(t, loadTableStat.RowsInserted, int64(14))
+			require.Equal(t, loadTableStat.RowsUpdated, int64(0))
+
+			records := bqHelper.RetrieveRecordsFromWarehouse(t, db,
+				fmt.Sprintf(
+					`SELECT
+						id,
+						received_at,
+						test_bool,
+						test_datetime,
+						test_float,
+						test_int,
+						test_string
+					FROM %s.%s
+					WHERE received_at >= TIMESTAMP('2000-01-01')
+					ORDER BY id;`,
+					namespace,
+					tableName,
+				),
+			)
+			require.Equal(t, records, whth.SampleTestRecords())
+		})
 	})
 
 	t.Run("IsEmpty", func(t *testing.T) {
@@ -2,11 +2,15 @@ package middleware
 
 import (
 	"context"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/bigquery"
 
 	"github.com/rudderlabs/rudder-server/warehouse/logfield"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
 )
 
 type Opt func(*Client)
@@ -62,6 +66,7 @@ func New(client *bigquery.Client, opts ...Opt) *Client {
 
 func (client *Client) Run(ctx context.Context, query *bigquery.Query) (*bigquery.Job, error) {
 	startedAt := time.Now()
+	tagQuery(ctx, query)
 	job, err := query.Run(ctx)
 	client.logQuery(query, client.since(startedAt))
 	return job, err
@@ -69,11 +74,39 @@ func (client *Client) Run(ctx context.Context, query *bigquery.Query) (*bigquery
 
 func (client *Client) Read(ctx context.Context, query *bigquery.Query) (it *bigquery.RowIterator, err error) {
 	startedAt := time.Now()
+	tagQuery(ctx, query)
 	it, err = query.Read(ctx)
 	client.logQuery(query, client.since(startedAt))
 	return it, err
 }
 
+var invalidLabelChars = regexp.MustCompile(`[^a-z0-9_-]`)
+
+// tagQuery labels the query job with the query tag of the context, if any, see [warehouseutils.QueryTag].
+// Label values can only contain lowercase letters, digits, underscores and dashes, up to 63 characters.
+func tagQuery(ctx context.Context, query *bigquery.Query) {
+	tag, ok := warehouseutils.QueryTagFromCtx(ctx)
+	if !ok {
+		return
+	}
+	labels := map[string]string{
+		"app":            "rudderstack",
+		"upload_id":      strconv.FormatInt(tag.UploadID, 10),
+		"destination_id": tag.DestinationID,
+		"version":        tag.Version,
+	}
+	for key, value := range labels {
+		value = invalidLabelChars.ReplaceAllString(strings.ToLower(value), "_")
+		if value == "" {
+			continue
+		}
+		if query.Labels == nil {
+			query.Labels = make(map[string]string)
+		}
+		query.Labels[key] = value[:min(len(value), 63)]
+	}
+}
+
 func (client *Client) logQuery(query *bigquery.Query, elapsed time.Duration) {
 	if elapsed < client.slowQueryThreshold {
 		return
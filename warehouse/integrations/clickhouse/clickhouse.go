@@ -3,8 +3,6 @@ package clickhouse
 import (
 	"compress/gzip"
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"database/sql"
 	"encoding/csv"
 	"encoding/json"
@@ -30,10 +28,13 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 
+	"github.com/rudderlabs/rudder-server/internal/featureflags"
 	"github.com/rudderlabs/rudder-server/rruntime"
 	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/utils/tlsutil"
 	"github.com/rudderlabs/rudder-server/warehouse/client"
 	sqlmw "github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/tunnelling"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/types"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/service/loadfiles/downloader"
@@ -134,6 +135,7 @@ type Clickhouse struct {
 	Uploader           warehouseutils.Uploader
 	connectTimeout     time.Duration
 	LoadFileDownloader downloader.Downloader
+	tunnel             *tunnelling.Tunnel // ssh tunnel the connections go through, if enabled for the destination
 
 	conf   *config.Config
 	logger logger.Logger
@@ -167,6 +169,20 @@ type credentials struct {
 	skipVerify string
 	tlsConfig  string
 	timeout    time.Duration
+	tunnelInfo *tunnelling.TunnelInfo
+}
+
+// tableEngine is the engine of a table, set in the tableEngines setting of the destinations keyed by table name, e.g.
+// {"tracks": {"engine": "ReplacingMergeTree", "versionColumn": "received_at", "orderBy": ["id"], "partitionBy": "toYYYYMM(received_at)"}}
+type tableEngine struct {
+	// Engine is either MergeTree or ReplacingMergeTree, defaults to ReplacingMergeTree
+	Engine string `json:"engine"`
+	// VersionColumn is the datetime column of the ReplacingMergeTree deciding which of the duplicate rows is kept
+	VersionColumn string `json:"versionColumn"`
+	// OrderBy are the columns of the sorting key, which is also the deduplication key of ReplacingMergeTree
+	OrderBy []string `json:"orderBy"`
+	// PartitionBy is the partition expression
+	PartitionBy string `json:"partitionBy"`
 }
 
 type clickHouseStat struct {
@@ -244,6 +260,11 @@ func New(conf *config.Config, log logger.Logger, stat stats.Stats) *Clickhouse {
 }
 
 func (ch *Clickhouse) connectToClickhouse(includeDBInConn bool) (*sqlmw.DB, error) {
+	return ch.connectThrough(&ch.tunnel, includeDBInConn)
+}
+
+// connectThrough connects to clickhouse through the ssh tunnel, opening it if enabled for the destination and not open yet
+func (ch *Clickhouse) connectThrough(tunnel **tunnelling.Tunnel, includeDBInConn bool) (*sqlmw.DB, error) {
 	cred, err := ch.connectionCredentials()
 	if err != nil {
 		return nil, fmt.Errorf("could not get connection credentials: %w", err)
@@ -269,9 +290,19 @@ func (ch *Clickhouse) connectToClickhouse(includeDBInConn bool) (*sqlmw.DB, erro
 		values.Add("timeout", fmt.Sprintf("%d", cred.timeout/time.Second))
 	}
 
+	host, port := cred.host, cred.port
+	if cred.tunnelInfo != nil {
+		if *tunnel == nil {
+			if *tunnel, err = tunnelling.Listen(cred.tunnelInfo.Config, cred.host, cred.port, ch.connectTimeout); err != nil {
+				return nil, fmt.Errorf("opening ssh tunnel: %w", err)
+			}
+		}
+		host, port = (*tunnel).Addr()
+	}
+
 	dsn := url.URL{
 		Scheme:   "tcp",
-		Host:     fmt.Sprintf("%s:%s", cred.host, cred.port),
+		Host:     fmt.Sprintf("%s:%s", host, port),
 		RawQuery: values.Encode(),
 	}
 
@@ -303,11 +334,20 @@ func (ch *Clickhouse) connectionCredentials() (*credentials, error) {
 		secure:     strconv.FormatBool(ch.Warehouse.GetBoolDestinationConfig(model.SecureSetting)),
 		skipVerify: strconv.FormatBool(ch.Warehouse.GetBoolDestinationConfig(model.SkipVerifySetting)),
 		timeout:    ch.connectTimeout,
+		tunnelInfo: tunnelling.ExtractTunnelInfoFromDestinationConfig(ch.Warehouse.Destination.Config),
 	}
 
-	certificate := ch.Warehouse.GetStringDestinationConfig(ch.conf, model.CACertificateSetting)
-	if strings.TrimSpace(certificate) != "" {
-		if err := registerTLSConfig(ch.Warehouse.Destination.ID, certificate); err != nil {
+	tlsConf := tlsutil.Config{
+		CACertificate: strings.TrimSpace(ch.Warehouse.GetStringDestinationConfig(ch.conf, model.CACertificateSetting)),
+		ClientCert:    strings.TrimSpace(ch.Warehouse.GetStringDestinationConfig(ch.conf, model.ClientCertSetting)),
+		ClientKey:     strings.TrimSpace(ch.Warehouse.GetStringDestinationConfig(ch.conf, model.ClientKeySetting)),
+	}
+	// connections through an ssh tunnel need a tls config for verifying the certificate against the host instead of the tunnel's address
+	tunnelledSecureConn := credentials.tunnelInfo != nil && ch.Warehouse.GetBoolDestinationConfig(model.SecureSetting)
+	if !tlsConf.IsEmpty() || tunnelledSecureConn {
+		// skipVerify only applies if no certificate authority is configured, same as when connecting without a tls config
+		skipVerify := tlsConf.IsEmpty() && ch.Warehouse.GetBoolDestinationConfig(model.SkipVerifySetting)
+		if err := registerTLSConfig(ch.Warehouse.Destination.ID, tlsConf, credentials.host, skipVerify); err != nil {
 			return nil, fmt.Errorf("registering tls config: %w", err)
 		}
 
@@ -318,13 +358,14 @@ func (ch *Clickhouse) connectionCredentials() (*credentials, error) {
 
 // registerTLSConfig will create a global map, use different names for the different tls config.
 // clickhouse will access the config by mentioning the key in connection string
-func registerTLSConfig(key, certificate string) error {
-	caCertPool := x509.NewCertPool()
-	caCertPool.AppendCertsFromPEM([]byte(certificate))
-
-	return clickhouse.RegisterTLSConfig(key, &tls.Config{
-		RootCAs: caCertPool,
-	})
+func registerTLSConfig(key string, tlsConf tlsutil.Config, serverName string, skipVerify bool) error {
+	tlsConfig, err := tlsConf.ClientConfig()
+	if err != nil {
+		return fmt.Errorf("invalid tls configuration: %w", err)
+	}
+	tlsConfig.ServerName = serverName
+	tlsConfig.InsecureSkipVerify = skipVerify
+	return clickhouse.RegisterTLSConfig(key, tlsConfig)
 }
 
 func (ch *Clickhouse) defaultLogFields() []any {
@@ -508,7 +549,8 @@ func (ch *Clickhouse) loadTable(ctx context.Context, tableName string, tableSche
 }
 
 func (ch *Clickhouse) UseS3CopyEngineForLoading() bool {
-	if !slices.Contains(ch.config.s3EngineEnabledWorkspaceIDs, ch.Warehouse.WorkspaceID) {
+	if !slices.Contains(ch.config.s3EngineEnabledWorkspaceIDs, ch.Warehouse.WorkspaceID) &&
+		!featureflags.Default.Enabled(featureflags.ClickhouseS3Engine, ch.Warehouse.WorkspaceID, ch.Warehouse.Destination.ID) {
 		return false
 	}
 	return ch.ObjectStorage == warehouseutils.S3 || ch.ObjectStorage == warehouseutils.MINIO
@@ -772,6 +814,75 @@ func (ch *Clickhouse) loadTablesFromFilesNamesWithRetry(ctx context.Context, tab
 	return
 }
 
+// StreamRows inserts the rows into the table in a single transaction, typecasting their values the same way as the
+// values of the load files, see [manager.Streamer]
+func (ch *Clickhouse) StreamRows(ctx context.Context, tableName string, tableSchema model.TableSchema, rows []map[string]any) (err error) {
+	// only the columns of the rows are inserted, the same way as only the columns of the upload schema are loaded
+	var columns []string
+	for _, row := range rows {
+		for column := range row {
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	sort.Strings(columns)
+
+	txn, err := ch.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = txn.Rollback()
+		}
+	}()
+
+	sqlStatement := fmt.Sprintf(`INSERT INTO %q.%q (%v) VALUES (%s)`,
+		ch.Namespace,
+		tableName,
+		warehouseutils.DoubleQuoteAndJoinByComma(columns),
+		generateArgumentString(len(columns)),
+	)
+	stmt, err := txn.PrepareContext(ctx, sqlStatement)
+	if err != nil {
+		return fmt.Errorf("preparing insert statement: %w", err)
+	}
+	for _, row := range rows {
+		values := make([]interface{}, 0, len(columns))
+		for _, column := range columns {
+			values = append(values, ch.typecastDataFromType(streamedValue(row[column]), tableSchema[column]))
+		}
+		if _, err = stmt.ExecContext(ctx, values...); err != nil {
+			return fmt.Errorf("inserting row: %w", err)
+		}
+	}
+	if err = stmt.Close(); err != nil {
+		return fmt.Errorf("closing insert statement: %w", err)
+	}
+	if err = txn.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	return nil
+}
+
+// streamedValue formats a value of a streamed row the way it is formatted in the load files
+func streamedValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []interface{}, map[string]interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
 func (ch *Clickhouse) schemaExists(ctx context.Context, schemaName string) (exists bool, err error) {
 	var count int64
 	sqlStatement := "SELECT count(*) FROM system.databases WHERE name = ?"
@@ -820,8 +931,52 @@ func getSortKeyTuple(sortKeyFields []string) string {
 	return tuple
 }
 
+// tableEngine returns the engine configured for the table, if any
+func (ch *Clickhouse) tableEngine(tableName string, columns model.TableSchema) (*tableEngine, error) {
+	for table, value := range ch.Warehouse.GetMapDestinationConfig(model.TableEnginesSetting) {
+		if !strings.EqualFold(table, tableName) {
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling table engine: %w", err)
+		}
+		var te tableEngine
+		if err := json.Unmarshal(raw, &te); err != nil {
+			return nil, fmt.Errorf("unmarshalling table engine: %w", err)
+		}
+
+		switch te.Engine {
+		case "":
+			te.Engine = "ReplacingMergeTree"
+		case "MergeTree", "ReplacingMergeTree":
+		default:
+			return nil, fmt.Errorf("unsupported engine %q", te.Engine)
+		}
+		if te.VersionColumn != "" {
+			if te.Engine != "ReplacingMergeTree" {
+				return nil, fmt.Errorf("version column is only supported with ReplacingMergeTree, got %q", te.Engine)
+			}
+			if columns[te.VersionColumn] != "datetime" {
+				return nil, fmt.Errorf("version column %q should be a datetime column", te.VersionColumn)
+			}
+		}
+		for _, column := range te.OrderBy {
+			if _, ok := columns[column]; !ok {
+				return nil, fmt.Errorf("order by column %q not found", column)
+			}
+		}
+		if strings.Contains(te.PartitionBy, ";") {
+			return nil, fmt.Errorf("invalid partition expression %q", te.PartitionBy)
+		}
+		return &te, nil
+	}
+	return nil, nil
+}
+
 // CreateTable creates table with engine ReplacingMergeTree(), this is used for dedupe event data and replace it will the latest data if duplicate data found. This logic is handled by clickhouse
 // The engine differs from MergeTree in that it removes duplicate entries with the same sorting key value.
+// The engine, its version column, the sorting key and the partition expression can be configured per table.
 func (ch *Clickhouse) CreateTable(ctx context.Context, tableName string, columns model.TableSchema) (err error) {
 	sortKeyFields := []string{"received_at", "id"}
 	if tableName == warehouseutils.DiscardsTable {
@@ -834,26 +989,49 @@ func (ch *Clickhouse) CreateTable(ctx context.Context, tableName string, columns
 	if tableName == warehouseutils.UsersTable {
 		return ch.createUsersTable(ctx, tableName, columns)
 	}
+
+	var te *tableEngine
+	if !strings.HasPrefix(tableName, warehouseutils.CTStagingTablePrefix) {
+		if te, err = ch.tableEngine(tableName, columns); err != nil {
+			return fmt.Errorf("table engine of %s: %w", tableName, err)
+		}
+	}
+
 	clusterClause := ""
 	engine := "ReplacingMergeTree"
-	engineOptions := ""
+	var engineOptions []string
+	notNullableColumns := sortKeyFields
+	var partitionByClause string
+	if _, ok := columns[partitionField]; ok {
+		partitionByClause = fmt.Sprintf(`PARTITION BY toDate(%s)`, partitionField)
+	}
+	if te != nil {
+		engine = te.Engine
+		if len(te.OrderBy) > 0 {
+			sortKeyFields = te.OrderBy
+			notNullableColumns = te.OrderBy
+		}
+		if te.PartitionBy != "" {
+			partitionByClause = fmt.Sprintf(`PARTITION BY %s`, te.PartitionBy)
+		}
+	}
+
 	cluster := ch.Warehouse.GetStringDestinationConfig(ch.conf, model.ClusterSetting)
 	if len(strings.TrimSpace(cluster)) > 0 {
 		clusterClause = fmt.Sprintf(`ON CLUSTER %q`, cluster)
 		engine = fmt.Sprintf(`%s%s`, "Replicated", engine)
-		engineOptions = fmt.Sprintf(`'/clickhouse/{cluster}/tables/%s/{database}/{table}', '{replica}'`, uuid.New().String())
+		engineOptions = append(engineOptions, fmt.Sprintf(`'/clickhouse/{cluster}/tables/%s/{database}/{table}', '{replica}'`, uuid.New().String()))
+	}
+	if te != nil && te.VersionColumn != "" {
+		engineOptions = append(engineOptions, fmt.Sprintf(`%q`, te.VersionColumn))
+		notNullableColumns = append(slices.Clone(notNullableColumns), te.VersionColumn)
 	}
 	var orderByClause string
 	if len(sortKeyFields) > 0 {
 		orderByClause = fmt.Sprintf(`ORDER BY %s`, getSortKeyTuple(sortKeyFields))
 	}
 
-	var partitionByClause string
-	if _, ok := columns[partitionField]; ok {
-		partitionByClause = fmt.Sprintf(`PARTITION BY toDate(%s)`, partitionField)
-	}
-
-	sqlStatement = fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q.%q %s ( %v ) ENGINE = %s(%s) %s %s`, ch.Namespace, tableName, clusterClause, ch.ColumnsWithDataTypes(tableName, columns, sortKeyFields), engine, engineOptions, orderByClause, partitionByClause)
+	sqlStatement = fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %q.%q %s ( %v ) ENGINE = %s(%s) %s %s`, ch.Namespace, tableName, clusterClause, ch.ColumnsWithDataTypes(tableName, columns, notNullableColumns), engine, strings.Join(engineOptions, ", "), orderByClause, partitionByClause)
 
 	ch.logger.Infof("CH: Creating table in clickhouse for ch:%s : %v", ch.Warehouse.Destination.ID, sqlStatement)
 	_, err = ch.DB.ExecContext(ctx, sqlStatement)
@@ -1068,6 +1246,9 @@ func (ch *Clickhouse) Cleanup(context.Context) {
 	if ch.DB != nil {
 		_ = ch.DB.Close()
 	}
+	if ch.tunnel != nil {
+		_ = ch.tunnel.Close()
+	}
 }
 
 func (*Clickhouse) LoadIdentityMergeRulesTable(context.Context) (err error) {
@@ -1111,12 +1292,21 @@ func (ch *Clickhouse) Connect(_ context.Context, warehouse model.Warehouse) (cli
 		misc.IsConfiguredToUseRudderObjectStorage(ch.Warehouse.Destination.Config),
 	)
 
-	db, err := ch.connectToClickhouse(true)
+	// the client gets a dedicated tunnel, since closing the client closes its tunnel too
+	var tunnel *tunnelling.Tunnel
+	db, err := ch.connectThrough(&tunnel, true)
 	if err != nil {
+		if tunnel != nil {
+			_ = tunnel.Close()
+		}
 		return client.Client{}, fmt.Errorf("connecting to clickhouse: %w", err)
 	}
 
-	return client.Client{Type: client.SQLClient, SQL: db.DB}, err
+	c := client.Client{Type: client.SQLClient, SQL: db.DB}
+	if tunnel != nil {
+		c.Closer = tunnel
+	}
+	return c, err
 }
 
 func (ch *Clickhouse) GetLogIdentifier(args ...string) string {
@@ -19,6 +19,7 @@ import (
 	"go.uber.org/mock/gomock"
 
 	clickhousestd "github.com/ClickHouse/clickhouse-go"
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
@@ -34,6 +35,7 @@ import (
 	"github.com/rudderlabs/rudder-server/utils/misc"
 	"github.com/rudderlabs/rudder-server/warehouse/client"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/clickhouse"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
 	whth "github.com/rudderlabs/rudder-server/warehouse/integrations/testhelper"
 	mockuploader "github.com/rudderlabs/rudder-server/warehouse/internal/mocks/utils"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
@@ -1240,3 +1242,68 @@ func newMockUploader(
 
 	return u
 }
+
+func TestClickhouse_TableEngines(t *testing.T) {
+	columns := model.TableSchema{
+		"id":          "string",
+		"received_at": "datetime",
+		"event":       "string",
+	}
+	tableEngines := map[string]any{
+		"tracks": map[string]any{
+			"versionColumn": "received_at",
+			"orderBy":       []any{"id"},
+			"partitionBy":   "toYYYYMM(received_at)",
+		},
+		"pages": map[string]any{
+			"engine":  "MergeTree",
+			"orderBy": []any{"received_at", "id"},
+		},
+		"screens": map[string]any{
+			"engine":        "MergeTree",
+			"versionColumn": "received_at",
+		},
+		"groups": map[string]any{
+			"versionColumn": "event",
+		},
+	}
+
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	ch := clickhouse.New(config.New(), logger.NOP, stats.NOP)
+	ch.Namespace = "namespace"
+	ch.Warehouse = model.Warehouse{
+		Type: whutils.CLICKHOUSE,
+		Destination: backendconfig.DestinationT{
+			Config: map[string]any{
+				model.TableEnginesSetting.String(): tableEngines,
+			},
+		},
+	}
+	ch.DB = sqlquerywrapper.New(db)
+
+	t.Run("replacing merge tree with version column", func(t *testing.T) {
+		dbMock.ExpectExec(`ENGINE = ReplacingMergeTree\("received_at"\) ORDER BY \("id"\) PARTITION BY toYYYYMM\(received_at\)`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		require.NoError(t, ch.CreateTable(context.Background(), "tracks", columns))
+		require.NoError(t, dbMock.ExpectationsWereMet())
+	})
+	t.Run("merge tree", func(t *testing.T) {
+		dbMock.ExpectExec(`ENGINE = MergeTree\(\) ORDER BY \("received_at","id"\) PARTITION BY toDate\(received_at\)`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		require.NoError(t, ch.CreateTable(context.Background(), "pages", columns))
+		require.NoError(t, dbMock.ExpectationsWereMet())
+	})
+	t.Run("default", func(t *testing.T) {
+		dbMock.ExpectExec(`ENGINE = ReplacingMergeTree\(\) ORDER BY \("received_at","id"\) PARTITION BY toDate\(received_at\)`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		require.NoError(t, ch.CreateTable(context.Background(), "identifies", columns))
+		require.NoError(t, dbMock.ExpectationsWereMet())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		require.ErrorContains(t, ch.CreateTable(context.Background(), "screens", columns), "only supported with ReplacingMergeTree")
+		require.ErrorContains(t, ch.CreateTable(context.Background(), "groups", columns), "should be a datetime column")
+	})
+}
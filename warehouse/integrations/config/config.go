@@ -1,22 +1,28 @@
 package config
 
 import (
+	"slices"
+
 	"github.com/rudderlabs/rudder-go-kit/config"
+
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
 	whutils "github.com/rudderlabs/rudder-server/warehouse/utils"
 )
 
-func MaxParallelLoadsMap(conf *config.Config) map[string]int {
-	return map[string]int{
-		whutils.BQ:            conf.GetInt("Warehouse.bigquery.maxParallelLoads", 20),
-		whutils.RS:            conf.GetInt("Warehouse.redshift.maxParallelLoads", 8),
-		whutils.POSTGRES:      conf.GetInt("Warehouse.postgres.maxParallelLoads", 8),
-		whutils.MSSQL:         conf.GetInt("Warehouse.mssql.maxParallelLoads", 8),
-		whutils.SNOWFLAKE:     conf.GetInt("Warehouse.snowflake.maxParallelLoads", 8),
-		whutils.CLICKHOUSE:    conf.GetInt("Warehouse.clickhouse.maxParallelLoads", 8),
-		whutils.DELTALAKE:     conf.GetInt("Warehouse.deltalake.maxParallelLoads", 8),
-		whutils.S3Datalake:    conf.GetInt("Warehouse.s3_datalake.maxParallelLoads", 8),
-		whutils.GCSDatalake:   conf.GetInt("Warehouse.gcs_datalake.maxParallelLoads", 8),
-		whutils.AzureDatalake: conf.GetInt("Warehouse.azure_datalake.maxParallelLoads", 8),
+// MaxParallelLoadsMap returns the maximum number of tables loaded in parallel by warehouse type.
+// They are reloadable, so that the load of a busy warehouse can be tuned without restarting.
+func MaxParallelLoadsMap(conf *config.Config) map[string]config.ValueLoader[int] {
+	return map[string]config.ValueLoader[int]{
+		whutils.BQ:            conf.GetReloadableIntVar(20, 1, "Warehouse.bigquery.maxParallelLoads"),
+		whutils.RS:            conf.GetReloadableIntVar(8, 1, "Warehouse.redshift.maxParallelLoads"),
+		whutils.POSTGRES:      conf.GetReloadableIntVar(8, 1, "Warehouse.postgres.maxParallelLoads"),
+		whutils.MSSQL:         conf.GetReloadableIntVar(8, 1, "Warehouse.mssql.maxParallelLoads"),
+		whutils.SNOWFLAKE:     conf.GetReloadableIntVar(8, 1, "Warehouse.snowflake.maxParallelLoads"),
+		whutils.CLICKHOUSE:    conf.GetReloadableIntVar(8, 1, "Warehouse.clickhouse.maxParallelLoads"),
+		whutils.DELTALAKE:     conf.GetReloadableIntVar(8, 1, "Warehouse.deltalake.maxParallelLoads"),
+		whutils.S3Datalake:    conf.GetReloadableIntVar(8, 1, "Warehouse.s3_datalake.maxParallelLoads"),
+		whutils.GCSDatalake:   conf.GetReloadableIntVar(8, 1, "Warehouse.gcs_datalake.maxParallelLoads"),
+		whutils.AzureDatalake: conf.GetReloadableIntVar(8, 1, "Warehouse.azure_datalake.maxParallelLoads"),
 	}
 }
 
@@ -31,3 +37,26 @@ func ColumnCountLimitMap(conf *config.Config) map[string]int {
 		whutils.S3Datalake:   conf.GetInt("Warehouse.s3_datalake.columnCountLimit", 10000),
 	}
 }
+
+// Capabilities returns the capabilities of the warehouses of the destination type, the managers of the warehouses
+// declaring their own otherwise, see manager.CapabilitiesOf
+func Capabilities(conf *config.Config, destType string) model.Capabilities {
+	capabilities := model.Capabilities{
+		Merge:                       slices.Contains([]string{whutils.SNOWFLAKE, whutils.DELTALAKE}, destType),
+		AlterColumn:                 slices.Contains([]string{whutils.RS, whutils.S3Datalake, whutils.GCSDatalake, whutils.AzureDatalake}, destType),
+		Transactions:                slices.Contains([]string{whutils.POSTGRES, whutils.RS, whutils.MSSQL, whutils.AzureSynapse}, destType),
+		IdentityResolution:          slices.Contains(whutils.IdentityEnabledWarehouses, destType),
+		RegenerateLoadFilesOnResume: slices.Contains([]string{whutils.SNOWFLAKE, whutils.BQ}, destType),
+		TimeWindowLayout:            slices.Contains(whutils.TimeWindowDestinations, destType),
+	}
+	// the columns of the datalakes are limited by the server only, for the column overflow
+	if !capabilities.TimeWindowLayout {
+		capabilities.MaxColumns = ColumnCountLimitMap(conf)[destType]
+	}
+	return capabilities
+}
+
+// DecimalPrecisionAndScale returns the precision and scale the decimal columns get created with
+func DecimalPrecisionAndScale(conf *config.Config) (precision, scale int) {
+	return conf.GetInt("Warehouse.decimalPrecision", 38), conf.GetInt("Warehouse.decimalScale", 6)
+}
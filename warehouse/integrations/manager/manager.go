@@ -16,9 +16,11 @@ import (
 	azuresynapse "github.com/rudderlabs/rudder-server/warehouse/integrations/azure-synapse"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/bigquery"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/clickhouse"
+	integrationsconfig "github.com/rudderlabs/rudder-server/warehouse/integrations/config"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/datalake"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/deltalake"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/mssql"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/plugin"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/postgres"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/redshift"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/snowflake"
@@ -53,6 +55,61 @@ type WarehouseDelete interface {
 	DeleteBy(ctx context.Context, tableName []string, params warehouseutils.DeleteByParams) error
 }
 
+// IngestionConfirmer is implemented by the warehouses which can ingest the load files of the tables asynchronously
+type IngestionConfirmer interface {
+	// ConfirmIngestion returns whether the load files of the table were ingested, or an error if their ingestion failed
+	ConfirmIngestion(ctx context.Context, tableName string) (bool, error)
+}
+
+// LoadRollbacker is implemented by the warehouses which can delete the rows loaded by an upload, using the load audit
+// columns of the tables
+type LoadRollbacker interface {
+	// DeleteLoadedRows deletes the rows of the table loaded by the upload
+	DeleteLoadedRows(ctx context.Context, tableName string, uploadID int64) error
+}
+
+// Streamer is implemented by the warehouses which can insert rows into their tables directly, bypassing the staging
+// and load files, for the tables streamed into the warehouse
+type Streamer interface {
+	// StreamRows inserts the rows into the table, the columns of the rows being columns of the table schema
+	StreamRows(ctx context.Context, tableName string, tableSchema model.TableSchema, rows []map[string]any) error
+}
+
+// Leaser is implemented by the warehouses which can record leases in the namespace, so that uploads of different
+// installations pointed at the same namespace, e.g. during blue/green deployments, don't run at the same time
+type Leaser interface {
+	// AcquireLease takes the lease of the key for the owner until the ttl elapses, renewing it if already held by the
+	// owner, and returns false if the lease is held by another owner and hasn't expired
+	AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// ReleaseLease releases the lease of the key, if held by the owner
+	ReleaseLease(ctx context.Context, key, owner string) error
+}
+
+// Renamer is implemented by the warehouses which can rename their namespaces and tables in place, keeping the rows
+// loaded so far, see [warehouseutils.RenameWarehouses]
+type Renamer interface {
+	// RenameNamespace renames the namespace the manager is set up for
+	RenameNamespace(ctx context.Context, newNamespace string) error
+	// RenameTable renames the table of the namespace
+	RenameTable(ctx context.Context, tableName, newTableName string) error
+}
+
+// Capable is implemented by the warehouses declaring their own capabilities, e.g. the plugins, rather than those of
+// their destination type
+type Capable interface {
+	// Capabilities returns the capabilities of the warehouse, known once the manager is set up
+	Capabilities() model.Capabilities
+}
+
+// CapabilitiesOf returns the capabilities of the warehouse of the manager, which can be nil, see
+// [integrationsconfig.Capabilities]
+func CapabilitiesOf(conf *config.Config, destType string, m Manager) model.Capabilities {
+	if c, ok := m.(Capable); ok {
+		return c.Capabilities()
+	}
+	return integrationsconfig.Capabilities(conf, destType)
+}
+
 type WarehouseOperations interface {
 	Manager
 	WarehouseDelete
@@ -80,6 +137,9 @@ func New(destType string, conf *config.Config, logger logger.Logger, stats stats
 	case warehouseutils.DELTALAKE:
 		return deltalake.New(conf, logger, stats), nil
 	}
+	if url, ok := warehouseutils.PluginEndpoint(destType); ok {
+		return plugin.New(destType, url, conf, logger), nil
+	}
 	return nil, fmt.Errorf("provider of type %s is not configured for WarehouseManager", destType)
 }
 
@@ -105,5 +165,8 @@ func NewWarehouseOperations(destType string, conf *config.Config, logger logger.
 	case warehouseutils.DELTALAKE:
 		return deltalake.New(conf, logger, stats), nil
 	}
+	if url, ok := warehouseutils.PluginEndpoint(destType); ok {
+		return plugin.New(destType, url, conf, logger), nil
+	}
 	return nil, fmt.Errorf("provider of type %s is not configured for WarehouseManager", destType)
 }
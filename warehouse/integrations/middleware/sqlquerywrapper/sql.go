@@ -36,6 +36,7 @@ type DB struct {
 	rollbackThreshold  time.Duration
 	commitThreshold    time.Duration
 	secretsRegex       map[string]string
+	queryTagContext    func(ctx context.Context, tag string) context.Context
 }
 
 type Rows struct {
@@ -132,6 +133,14 @@ func WithTransactionTimeout(timeout time.Duration) Opt {
 	}
 }
 
+// WithQueryTagContext tags the queries through the context passed to the driver, e.g. using the QUERY_TAG of snowflake,
+// instead of prepending the query tag to the queries as a comment
+func WithQueryTagContext(queryTagContext func(ctx context.Context, tag string) context.Context) Opt {
+	return func(s *DB) {
+		s.queryTagContext = queryTagContext
+	}
+}
+
 func New(db *sql.DB, opts ...Opt) *DB {
 	s := &DB{
 		DB:                 db,
@@ -155,7 +164,8 @@ func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}
 	startedAt := time.Now()
 	ctx, cancel := queryContextWithTimeout(ctx, db.queryTimeout)
 	defer cancel()
-	result, err := db.DB.ExecContext(ctx, query, args...)
+	taggedCtx, taggedQuery := db.tagQuery(ctx, query)
+	result, err := db.DB.ExecContext(taggedCtx, taggedQuery, args...)
 	db.logQuery(query, startedAt)()
 	return result, err
 }
@@ -167,7 +177,8 @@ func (db *DB) Query(query string, args ...interface{}) (*Rows, error) {
 func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
 	startedAt := time.Now()
 	ctx, cancel := queryContextWithTimeout(ctx, db.queryTimeout)
-	rows, err := db.DB.QueryContext(ctx, query, args...)
+	taggedCtx, taggedQuery := db.tagQuery(ctx, query)
+	rows, err := db.DB.QueryContext(taggedCtx, taggedQuery, args...)
 	if err != nil {
 		defer cancel()
 		defer db.logQuery(query, startedAt)()
@@ -193,8 +204,9 @@ func (db *DB) QueryRow(query string, args ...interface{}) *Row {
 func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
 	startedAt := time.Now()
 	ctx, cancel := queryContextWithTimeout(ctx, db.queryTimeout)
+	taggedCtx, taggedQuery := db.tagQuery(ctx, query)
 	return &Row{
-		Row:        db.DB.QueryRowContext(ctx, query, args...),
+		Row:        db.DB.QueryRowContext(taggedCtx, taggedQuery, args...),
 		CancelFunc: cancel,
 		logQ:       db.logQuery(query, startedAt),
 	}
@@ -221,6 +233,18 @@ func (db *DB) WithTx(ctx context.Context, fn func(*Tx) error) error {
 	return tx.Commit()
 }
 
+// tagQuery tags the query with the query tag of the context, if any, see [warehouseutils.QueryTag]
+func (db *DB) tagQuery(ctx context.Context, query string) (context.Context, string) {
+	tag, ok := warehouseutils.QueryTagFromCtx(ctx)
+	if !ok {
+		return ctx, query
+	}
+	if db.queryTagContext != nil {
+		return db.queryTagContext(ctx, tag.String()), query
+	}
+	return ctx, tag.Comment() + query
+}
+
 func (db *DB) logQuery(query string, since time.Time) logQ {
 	return func() {
 		var (
@@ -296,7 +320,8 @@ func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}
 	startedAt := time.Now()
 	ctx, cancel := queryContextWithTimeout(ctx, tx.db.queryTimeout)
 	defer cancel()
-	result, err := tx.Tx.ExecContext(ctx, query, args...)
+	taggedCtx, taggedQuery := tx.db.tagQuery(ctx, query)
+	result, err := tx.Tx.ExecContext(taggedCtx, taggedQuery, args...)
 	tx.db.logQuery(query, startedAt)()
 	return result, err
 }
@@ -308,7 +333,8 @@ func (tx *Tx) Query(query string, args ...interface{}) (*Rows, error) {
 func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
 	startedAt := time.Now()
 	ctx, cancel := queryContextWithTimeout(ctx, tx.db.queryTimeout)
-	rows, err := tx.Tx.QueryContext(ctx, query, args...)
+	taggedCtx, taggedQuery := tx.db.tagQuery(ctx, query)
+	rows, err := tx.Tx.QueryContext(taggedCtx, taggedQuery, args...)
 	if err != nil {
 		defer cancel()
 		defer tx.db.logQuery(query, startedAt)()
@@ -334,8 +360,9 @@ func (tx *Tx) QueryRow(query string, args ...interface{}) *Row {
 func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
 	startedAt := time.Now()
 	ctx, cancel := queryContextWithTimeout(ctx, tx.db.queryTimeout)
+	taggedCtx, taggedQuery := tx.db.tagQuery(ctx, query)
 	return &Row{
-		Row:        tx.Tx.QueryRowContext(ctx, query, args...),
+		Row:        tx.Tx.QueryRowContext(taggedCtx, taggedQuery, args...),
 		CancelFunc: cancel,
 		logQ:       tx.db.logQuery(query, startedAt),
 	}
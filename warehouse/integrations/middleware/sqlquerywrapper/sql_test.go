@@ -18,6 +18,7 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
 	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
 	"github.com/rudderlabs/rudder-server/warehouse/logfield"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
 )
 
 func TestQueryWrapper(t *testing.T) {
@@ -477,3 +478,49 @@ func TestWithStats(t *testing.T) {
 	})
 	require.NotNilf(t, measurement, "measurement should not be nil")
 }
+
+func TestQueryTag(t *testing.T) {
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pgResource, err := postgres.Setup(pool, t)
+	require.NoError(t, err)
+
+	tag := warehouseutils.QueryTag{UploadID: 1, DestinationID: "destination-1", Version: "1.2.3"}
+	ctx := warehouseutils.CtxWithQueryTag(context.Background(), tag)
+
+	t.Run("comment", func(t *testing.T) {
+		qw := New(pgResource.DB)
+
+		var query string
+		require.NoError(t, qw.QueryRowContext(ctx, "SELECT current_query();").Scan(&query))
+		require.Equal(t, tag.Comment()+"SELECT current_query();", query)
+
+		require.NoError(t, qw.WithTx(ctx, func(tx *Tx) error {
+			return tx.QueryRowContext(ctx, "SELECT current_query();").Scan(&query)
+		}))
+		require.Equal(t, tag.Comment()+"SELECT current_query();", query)
+
+		require.NoError(t, qw.QueryRowContext(context.Background(), "SELECT current_query();").Scan(&query))
+		require.Equal(t, "SELECT current_query();", query, "queries without a query tag are left as is")
+	})
+
+	t.Run("context", func(t *testing.T) {
+		type tagKey struct{}
+
+		var tags []string
+		qw := New(pgResource.DB, WithQueryTagContext(func(ctx context.Context, tag string) context.Context {
+			tags = append(tags, tag)
+			return context.WithValue(ctx, tagKey{}, tag)
+		}))
+
+		var query string
+		require.NoError(t, qw.QueryRowContext(ctx, "SELECT current_query();").Scan(&query))
+		require.Equal(t, "SELECT current_query();", query)
+		_, err := qw.ExecContext(ctx, "SELECT 1;")
+		require.NoError(t, err)
+		require.Equal(t, []string{tag.String(), tag.String()}, tags)
+	})
+}
@@ -14,6 +14,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf16"
 	"unicode/utf8"
@@ -94,6 +95,11 @@ type MSSQL struct {
 	stats  stats.Stats
 	logger logger.Logger
 
+	// stagingSchema is the schema the staging tables get created in, the namespace unless configured otherwise
+	stagingSchema        string
+	stagingSchemaMu      sync.Mutex
+	stagingSchemaCreated bool
+
 	config struct {
 		enableDeleteByJobs          bool
 		numWorkersDownloadLoadFiles int
@@ -288,15 +294,20 @@ func (ms *MSSQL) loadTable(
 	// - See the discussion at https://github.com/denisenkom/go-mssqldb/issues/149 regarding prepared statements.
 	// - Refer to Microsoft's documentation on temporary tables at
 	//   https://docs.microsoft.com/en-us/previous-versions/sql/sql-server-2008-r2/ms175528(v=sql.105)?redirectedfrom=MSDN.
+	if err = ms.createStagingSchema(ctx); err != nil {
+		return nil, "", fmt.Errorf("creating staging schema: %w", err)
+	}
+
 	log.Debugw("creating staging table")
 	createStagingTableStmt := fmt.Sprintf(`
 		SELECT
-		  TOP 0 * INTO %[1]s.%[2]s
+		  TOP 0 * INTO %[4]s.%[2]s
 		FROM
 		  %[1]s.%[3]s;`,
 		ms.Namespace,
 		stagingTableName,
 		tableName,
+		ms.stagingSchema,
 	)
 	if _, err = ms.DB.ExecContext(ctx, createStagingTableStmt); err != nil {
 		return nil, "", fmt.Errorf("creating temporary table: %w", err)
@@ -323,7 +334,7 @@ func (ms *MSSQL) loadTable(
 	)
 
 	log.Debugw("creating prepared stmt for loading data")
-	copyInStmt := mssql.CopyIn(ms.Namespace+"."+stagingTableName, mssql.BulkOptions{CheckConstraints: false},
+	copyInStmt := mssql.CopyIn(ms.stagingSchema+"."+stagingTableName, mssql.BulkOptions{CheckConstraints: false},
 		sortedColumnKeys...,
 	)
 	stmt, err := txn.PrepareContext(ctx, copyInStmt)
@@ -522,7 +533,7 @@ func (ms *MSSQL) deleteFromLoadTable(
 		DELETE FROM
 		  %[1]q.%[2]q
 		FROM
-		  %[1]q.%[3]q AS _source
+		  %[6]q.%[3]q AS _source
 		WHERE
 		  (
 			_source.%[4]s = %[1]q.%[2]q.%[4]q %[5]s
@@ -532,6 +543,7 @@ func (ms *MSSQL) deleteFromLoadTable(
 		stagingTableName,
 		primaryKey,
 		additionalDeleteStmtClause,
+		ms.stagingSchema,
 	)
 
 	r, err := txn.ExecContext(ctx, deleteStmt)
@@ -571,7 +583,7 @@ func (ms *MSSQL) insertIntoLoadTable(
 				  received_at DESC
 			  ) AS _rudder_staging_row_number
 			FROM
-			  %[1]q.%[4]q
+			  %[6]q.%[4]q
 		  ) AS _
 		WHERE
 		  _rudder_staging_row_number = 1;`,
@@ -580,6 +592,7 @@ func (ms *MSSQL) insertIntoLoadTable(
 		quotedColumnNames,
 		stagingTableName,
 		partitionKey,
+		ms.stagingSchema,
 	)
 
 	r, err := txn.ExecContext(ctx, insertStmt)
@@ -644,7 +657,7 @@ func (ms *MSSQL) loadUserTables(ctx context.Context) (errorMap map[string]error)
 							  order by received_at desc
 						  	OFFSET 0 ROWS
 							FETCH NEXT 1 ROWS ONLY)
-						  end as "%[1]s"`, colName, ms.Namespace+"."+unionStagingTableName)
+						  end as "%[1]s"`, colName, ms.stagingSchema+"."+unionStagingTableName)
 
 		// IGNORE NULLS only supported in Azure SQL edge, in which case the query can be shortened to below
 		// https://docs.microsoft.com/en-us/sql/t-sql/functions/first-value-transact-sql?view=sql-server-ver15
@@ -660,7 +673,7 @@ func (ms *MSSQL) loadUserTables(ctx context.Context) (errorMap map[string]error)
 												(
 													SELECT user_id, %[4]s FROM %[3]s  WHERE user_id IS NOT NULL
 												)) a
-											`, ms.Namespace, ms.Namespace+"."+warehouseutils.UsersTable, ms.Namespace+"."+identifyStagingTable, strings.Join(userColNames, ","), ms.Namespace+"."+unionStagingTableName)
+											`, ms.Namespace, ms.Namespace+"."+warehouseutils.UsersTable, ms.stagingSchema+"."+identifyStagingTable, strings.Join(userColNames, ","), ms.stagingSchema+"."+unionStagingTableName)
 
 	ms.logger.Debugf("MSSQL: Creating staging table for union of users table with identify staging table: %s\n", sqlStatement)
 	_, err = ms.DB.ExecContext(ctx, sqlStatement)
@@ -676,9 +689,9 @@ func (ms *MSSQL) loadUserTables(ctx context.Context) (errorMap map[string]error)
 											FROM %[3]s as x
 										) as xyz
 									) a`,
-		ms.Namespace+"."+stagingTableName,
+		ms.stagingSchema+"."+stagingTableName,
 		strings.Join(firstValProps, ","),
-		ms.Namespace+"."+unionStagingTableName,
+		ms.stagingSchema+"."+unionStagingTableName,
 	)
 
 	ms.logger.Debugf("MSSQL: Creating staging table for users: %s\n", sqlStatement)
@@ -697,7 +710,7 @@ func (ms *MSSQL) loadUserTables(ctx context.Context) (errorMap map[string]error)
 	}
 
 	primaryKey := "id"
-	sqlStatement = fmt.Sprintf(`DELETE FROM %[1]s."%[2]s" FROM %[3]s _source where (_source.%[4]s = %[1]s.%[2]s.%[4]s)`, ms.Namespace, warehouseutils.UsersTable, ms.Namespace+"."+stagingTableName, primaryKey)
+	sqlStatement = fmt.Sprintf(`DELETE FROM %[1]s."%[2]s" FROM %[3]s _source where (_source.%[4]s = %[1]s.%[2]s.%[4]s)`, ms.Namespace, warehouseutils.UsersTable, ms.stagingSchema+"."+stagingTableName, primaryKey)
 	ms.logger.Infof("MSSQL: Dedup records for table:%s using staging table: %s\n", warehouseutils.UsersTable, sqlStatement)
 	_, err = tx.ExecContext(ctx, sqlStatement)
 	if err != nil {
@@ -707,7 +720,7 @@ func (ms *MSSQL) loadUserTables(ctx context.Context) (errorMap map[string]error)
 		return
 	}
 
-	sqlStatement = fmt.Sprintf(`INSERT INTO "%[1]s"."%[2]s" (%[4]s) SELECT %[4]s FROM  %[3]s`, ms.Namespace, warehouseutils.UsersTable, ms.Namespace+"."+stagingTableName, strings.Join(append([]string{"id"}, userColNames...), ","))
+	sqlStatement = fmt.Sprintf(`INSERT INTO "%[1]s"."%[2]s" (%[4]s) SELECT %[4]s FROM  %[3]s`, ms.Namespace, warehouseutils.UsersTable, ms.stagingSchema+"."+stagingTableName, strings.Join(append([]string{"id"}, userColNames...), ","))
 	ms.logger.Infof("MSSQL: Inserting records for table:%s using staging table: %s\n", warehouseutils.UsersTable, sqlStatement)
 	_, err = tx.ExecContext(ctx, sqlStatement)
 	if err != nil {
@@ -738,11 +751,28 @@ func (ms *MSSQL) CreateSchema(ctx context.Context) (err error) {
 	return
 }
 
+// createStagingSchema creates the staging schema, if configured, before the first staging table of the upload
+func (ms *MSSQL) createStagingSchema(ctx context.Context) error {
+	ms.stagingSchemaMu.Lock()
+	defer ms.stagingSchemaMu.Unlock()
+
+	if ms.stagingSchemaCreated || ms.stagingSchema == ms.Namespace {
+		return nil
+	}
+	sqlStatement := fmt.Sprintf(`IF NOT EXISTS ( SELECT  * FROM  sys.schemas WHERE   name = N'%s' )
+    EXEC('CREATE SCHEMA [%s]');`, ms.stagingSchema, ms.stagingSchema)
+	if _, err := ms.DB.ExecContext(ctx, sqlStatement); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	ms.stagingSchemaCreated = true
+	return nil
+}
+
 func (ms *MSSQL) dropStagingTable(ctx context.Context, stagingTableName string) {
 	ms.logger.Infof("MSSQL: dropping table %+v\n", stagingTableName)
-	_, err := ms.DB.ExecContext(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s`, ms.Namespace+"."+stagingTableName))
+	_, err := ms.DB.ExecContext(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s`, ms.stagingSchema+"."+stagingTableName))
 	if err != nil {
-		ms.logger.Errorf("MSSQL:  Error dropping staging table %s in mssql: %v", ms.Namespace+"."+stagingTableName, err)
+		ms.logger.Errorf("MSSQL:  Error dropping staging table %s in mssql: %v", ms.stagingSchema+"."+stagingTableName, err)
 	}
 }
 
@@ -831,6 +861,10 @@ func (ms *MSSQL) Setup(_ context.Context, warehouse model.Warehouse, uploader wa
 	ms.Warehouse = warehouse
 	ms.Namespace = warehouse.Namespace
 	ms.Uploader = uploader
+	ms.stagingSchema = ms.Namespace
+	if stagingSchema := warehouse.GetStringDestinationConfig(ms.conf, model.StagingSchemaSetting); stagingSchema != "" {
+		ms.stagingSchema = warehouseutils.ToSafeNamespace(warehouseutils.MSSQL, stagingSchema)
+	}
 	ms.ObjectStorage = warehouseutils.ObjectStorageType(warehouseutils.MSSQL, warehouse.Destination.Config, ms.Uploader.UseRudderStorage())
 	ms.LoadFileDownLoader = downloader.NewDownloader(&warehouse, uploader, ms.config.numWorkersDownloadLoadFiles)
 
@@ -844,20 +878,31 @@ func (ms *MSSQL) CrashRecover(ctx context.Context) error {
 	return ms.dropDanglingStagingTables(ctx)
 }
 
+// dropDanglingStagingTables drops the staging tables left behind in the staging schema, and in the namespace in case
+// the staging schema got configured after they were created there
 func (ms *MSSQL) dropDanglingStagingTables(ctx context.Context) error {
-	sqlStatement := fmt.Sprintf(`
+	for _, schema := range lo.Uniq([]string{ms.Namespace, ms.stagingSchema}) {
+		if err := ms.dropDanglingStagingTablesInSchema(ctx, schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ms *MSSQL) dropDanglingStagingTablesInSchema(ctx context.Context, schema string) error {
+	sqlStatement := `
 		select
 		  table_name
 		from
 		  information_schema.tables
 		where
-		  table_schema = '%s'
-		  AND table_name like '%s';
-	`,
-		ms.Namespace,
-		fmt.Sprintf(`%s%%`, warehouseutils.StagingTablePrefix(provider)),
+		  table_schema = @schema
+		  AND table_name like @prefix;
+	`
+	rows, err := ms.DB.QueryContext(ctx, sqlStatement,
+		sql.Named("schema", schema),
+		sql.Named("prefix", fmt.Sprintf(`%s%%`, warehouseutils.StagingTablePrefix(provider))),
 	)
-	rows, err := ms.DB.QueryContext(ctx, sqlStatement)
 	if err != nil {
 		return fmt.Errorf("querying for dangling staging tables: %w", err)
 	}
@@ -877,9 +922,9 @@ func (ms *MSSQL) dropDanglingStagingTables(ctx context.Context) error {
 	}
 	ms.logger.Infof("WH: MSSQL: Dropping dangling staging tables: %+v  %+v\n", len(stagingTableNames), stagingTableNames)
 	for _, stagingTableName := range stagingTableNames {
-		_, err := ms.DB.ExecContext(ctx, fmt.Sprintf(`DROP TABLE "%[1]s"."%[2]s"`, ms.Namespace, stagingTableName))
+		_, err := ms.DB.ExecContext(ctx, fmt.Sprintf(`DROP TABLE "%[1]s"."%[2]s"`, schema, stagingTableName))
 		if err != nil {
-			return fmt.Errorf("dropping dangling staging tables %q.%q : %w", ms.Namespace, stagingTableName, err)
+			return fmt.Errorf("dropping dangling staging tables %q.%q : %w", schema, stagingTableName, err)
 		}
 	}
 	return nil
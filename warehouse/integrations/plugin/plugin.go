@@ -0,0 +1,363 @@
+// Package plugin implements warehouses out of tree, through plugins running as sidecars of the server.
+//
+// Plugins implement the operations of the warehouse manager behind a JSON over HTTP contract, while the server keeps
+// owning the upload state machine, the schema handling and the generation of the load files. Plugins are registered
+// for destination types in Warehouse.plugins.endpoints, see [warehouseutils.PluginEndpoint], and every operation is
+// a POST request to <url>/v1/<operation> holding the warehouse the operation is performed on, i.e.
+//
+//	{"warehouse": {"type", "workspaceId", "sourceId", "destinationId", "namespace", "config", "connectionTimeout"}, ...}
+//
+// Operations respond with 2xx and their result, or with any other status and {"error": "<message>"}. The load files of
+// the tables are generated by the transformer, which needs to support the destination type, and are csv files.
+// Plugins declare the capabilities of the warehouse, see [model.Capabilities], in the response to the handshake.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/warehouse/client"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/types"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+// Version is the version of the contract between the server and the plugins
+const Version = 1
+
+// Plugin is the warehouse manager of a destination type implemented by a plugin
+type Plugin struct {
+	destType string
+	url      string
+	client   *http.Client
+	logger   logger.Logger
+
+	warehouse         model.Warehouse
+	uploader          warehouseutils.Uploader
+	connectionTimeout time.Duration
+	errorMappings     []model.JobError
+	capabilities      model.Capabilities
+}
+
+// warehousePayload is the warehouse an operation is performed on
+type warehousePayload struct {
+	Type              string                 `json:"type"`
+	WorkspaceID       string                 `json:"workspaceId"`
+	SourceID          string                 `json:"sourceId"`
+	DestinationID     string                 `json:"destinationId"`
+	Namespace         string                 `json:"namespace"`
+	Config            map[string]interface{} `json:"config"`
+	ConnectionTimeout string                 `json:"connectionTimeout,omitempty"`
+}
+
+type handshakeResponse struct {
+	Version       int `json:"version"`
+	ErrorMappings []struct {
+		Type    model.JobErrorType `json:"type"`
+		Pattern string             `json:"pattern"`
+	} `json:"errorMappings"`
+	Capabilities *model.Capabilities `json:"capabilities"`
+}
+
+type objectStorage struct {
+	Provider string                 `json:"provider"`
+	Config   map[string]interface{} `json:"config"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New returns the warehouse manager of the destination type, delegating to the plugin listening on the url
+func New(destType, url string, conf *config.Config, log logger.Logger) *Plugin {
+	return &Plugin{
+		destType: destType,
+		url:      url,
+		client:   &http.Client{Timeout: conf.GetDuration("Warehouse.plugins.timeout", 3600, time.Second)},
+		logger:   log.Child("integrations").Child("plugin").With("destType", destType),
+	}
+}
+
+// Setup performs the handshake with the plugin, which returns the error mappings and the capabilities of the warehouse
+func (p *Plugin) Setup(ctx context.Context, warehouse model.Warehouse, uploader warehouseutils.Uploader) error {
+	p.warehouse = warehouse
+	p.uploader = uploader
+
+	var res handshakeResponse
+	if err := p.call(ctx, warehouse, "handshake", map[string]interface{}{"version": Version}, &res); err != nil {
+		return fmt.Errorf("handshake with plugin: %w", err)
+	}
+	if res.Version != Version {
+		return fmt.Errorf("plugin implements version %d of the contract, expected %d", res.Version, Version)
+	}
+	errorMappings := make([]model.JobError, 0, len(res.ErrorMappings))
+	for _, mapping := range res.ErrorMappings {
+		format, err := regexp.Compile(mapping.Pattern)
+		if err != nil {
+			return fmt.Errorf("compiling pattern of error mapping %q: %w", mapping.Type, err)
+		}
+		errorMappings = append(errorMappings, model.JobError{Type: mapping.Type, Format: format})
+	}
+	p.errorMappings = errorMappings
+
+	// the columns of the plugins not declaring their capabilities are altered, the plugins ignoring unsupported changes
+	p.capabilities = model.Capabilities{AlterColumn: true}
+	if res.Capabilities != nil {
+		p.capabilities = *res.Capabilities
+	}
+	return nil
+}
+
+func (p *Plugin) CrashRecover(ctx context.Context) error {
+	return p.call(ctx, p.warehouse, "crash-recover", nil, nil)
+}
+
+func (p *Plugin) FetchSchema(ctx context.Context) (model.Schema, model.Schema, error) {
+	var res struct {
+		Schema             model.Schema `json:"schema"`
+		UnrecognizedSchema model.Schema `json:"unrecognizedSchema"`
+	}
+	if err := p.call(ctx, p.warehouse, "fetch-schema", nil, &res); err != nil {
+		return nil, nil, err
+	}
+	if res.Schema == nil {
+		res.Schema = model.Schema{}
+	}
+	if res.UnrecognizedSchema == nil {
+		res.UnrecognizedSchema = model.Schema{}
+	}
+	return res.Schema, res.UnrecognizedSchema, nil
+}
+
+func (p *Plugin) CreateSchema(ctx context.Context) error {
+	return p.call(ctx, p.warehouse, "create-schema", nil, nil)
+}
+
+func (p *Plugin) CreateTable(ctx context.Context, tableName string, columnMap model.TableSchema) error {
+	return p.call(ctx, p.warehouse, "create-table", map[string]interface{}{
+		"table":   tableName,
+		"columns": columnMap,
+	}, nil)
+}
+
+func (p *Plugin) DropTable(ctx context.Context, tableName string) error {
+	return p.call(ctx, p.warehouse, "drop-table", map[string]interface{}{"table": tableName}, nil)
+}
+
+func (p *Plugin) AddColumns(ctx context.Context, tableName string, columnsInfo []warehouseutils.ColumnInfo) error {
+	columns := make(map[string]string, len(columnsInfo))
+	for _, column := range columnsInfo {
+		columns[column.Name] = column.Type
+	}
+	return p.call(ctx, p.warehouse, "add-columns", map[string]interface{}{
+		"table":   tableName,
+		"columns": columns,
+	}, nil)
+}
+
+func (p *Plugin) AlterColumn(ctx context.Context, tableName, columnName, columnType string) (model.AlterTableResponse, error) {
+	var res struct {
+		IsDependent bool   `json:"isDependent"`
+		Query       string `json:"query"`
+	}
+	err := p.call(ctx, p.warehouse, "alter-column", map[string]interface{}{
+		"table":      tableName,
+		"column":     columnName,
+		"columnType": columnType,
+	}, &res)
+	return model.AlterTableResponse{IsDependent: res.IsDependent, Query: res.Query}, err
+}
+
+// LoadTable sends the load files of the table to the plugin, along with the object storage they are kept in
+func (p *Plugin) LoadTable(ctx context.Context, tableName string) (*types.LoadTableStats, error) {
+	loadFiles, err := p.uploader.GetLoadFilesMetadata(ctx, warehouseutils.GetLoadFilesOptions{Table: tableName})
+	if err != nil {
+		return nil, fmt.Errorf("getting load files metadata: %w", err)
+	}
+	type loadFile struct {
+		Location string          `json:"location"`
+		Metadata json.RawMessage `json:"metadata,omitempty"`
+	}
+	files := make([]loadFile, 0, len(loadFiles))
+	for _, file := range loadFiles {
+		files = append(files, loadFile{Location: file.Location, Metadata: file.Metadata})
+	}
+
+	var res struct {
+		RowsInserted int64 `json:"rowsInserted"`
+		RowsUpdated  int64 `json:"rowsUpdated"`
+	}
+	err = p.call(ctx, p.warehouse, "load-table", map[string]interface{}{
+		"table":             tableName,
+		"schemaInUpload":    p.uploader.GetTableSchemaInUpload(tableName),
+		"schemaInWarehouse": p.uploader.GetTableSchemaInWarehouse(tableName),
+		"loadFiles":         files,
+		"loadFileType":      p.uploader.GetLoadFileType(),
+		"objectStorage":     p.objectStorage(),
+	}, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &types.LoadTableStats{RowsInserted: res.RowsInserted, RowsUpdated: res.RowsUpdated}, nil
+}
+
+func (p *Plugin) objectStorage() objectStorage {
+	useRudderStorage := p.uploader.UseRudderStorage()
+	provider := warehouseutils.ObjectStorageType(p.destType, p.warehouse.Destination.Config, useRudderStorage)
+	return objectStorage{
+		Provider: provider,
+		Config: misc.GetObjectStorageConfig(misc.ObjectStorageOptsT{
+			Provider:         provider,
+			Config:           p.warehouse.Destination.Config,
+			UseRudderStorage: useRudderStorage,
+			WorkspaceID:      p.warehouse.WorkspaceID,
+		}),
+	}
+}
+
+func (p *Plugin) DeleteBy(ctx context.Context, tableNames []string, params warehouseutils.DeleteByParams) error {
+	return p.call(ctx, p.warehouse, "delete-by", map[string]interface{}{
+		"tables":    tableNames,
+		"sourceId":  params.SourceId,
+		"jobRunId":  params.JobRunId,
+		"taskRunId": params.TaskRunId,
+		"startTime": params.StartTime,
+	}, nil)
+}
+
+// LoadUserTables loads the identifies table and, if part of the upload, the users table
+func (p *Plugin) LoadUserTables(ctx context.Context) map[string]error {
+	errorMap := make(map[string]error)
+	tables := []string{warehouseutils.IdentifiesTable}
+	if len(p.uploader.GetTableSchemaInUpload(warehouseutils.UsersTable)) > 0 {
+		tables = append(tables, warehouseutils.UsersTable)
+	}
+	for _, tableName := range tables {
+		_, errorMap[tableName] = p.LoadTable(ctx, tableName)
+	}
+	return errorMap
+}
+
+func (p *Plugin) LoadIdentityMergeRulesTable(context.Context) error {
+	p.logger.Infof("Skipping load for identity merge rules : %s is a plugin destination", p.warehouse.Destination.ID)
+	return nil
+}
+
+func (p *Plugin) LoadIdentityMappingsTable(context.Context) error {
+	p.logger.Infof("Skipping load for identity mappings : %s is a plugin destination", p.warehouse.Destination.ID)
+	return nil
+}
+
+func (p *Plugin) Cleanup(ctx context.Context) {
+	if err := p.call(ctx, p.warehouse, "cleanup", nil, nil); err != nil {
+		p.logger.Warnw("cleaning up plugin", "destinationID", p.warehouse.Destination.ID, "error", err.Error())
+	}
+}
+
+func (p *Plugin) IsEmpty(ctx context.Context, warehouse model.Warehouse) (bool, error) {
+	var res struct {
+		Empty bool `json:"empty"`
+	}
+	if err := p.call(ctx, warehouse, "is-empty", nil, &res); err != nil {
+		return false, err
+	}
+	return res.Empty, nil
+}
+
+func (p *Plugin) TestConnection(ctx context.Context, warehouse model.Warehouse) error {
+	return p.call(ctx, warehouse, "test-connection", nil, nil)
+}
+
+func (*Plugin) DownloadIdentityRules(context.Context, *misc.GZipWriter) error {
+	return fmt.Errorf("plugin err :not implemented")
+}
+
+func (*Plugin) Connect(context.Context, model.Warehouse) (client.Client, error) {
+	return client.Client{}, fmt.Errorf("plugin err :not implemented")
+}
+
+func (p *Plugin) LoadTestTable(ctx context.Context, location, stagingTableName string, payloadMap map[string]interface{}, loadFileFormat string) error {
+	return p.call(ctx, p.warehouse, "load-test-table", map[string]interface{}{
+		"location":      location,
+		"table":         stagingTableName,
+		"payload":       payloadMap,
+		"loadFileType":  loadFileFormat,
+		"objectStorage": p.objectStorage(),
+	}, nil)
+}
+
+func (p *Plugin) SetConnectionTimeout(timeout time.Duration) {
+	p.connectionTimeout = timeout
+}
+
+func (p *Plugin) ErrorMappings() []model.JobError {
+	return p.errorMappings
+}
+
+// Capabilities returns the capabilities declared by the plugin during the handshake
+func (p *Plugin) Capabilities() model.Capabilities {
+	return p.capabilities
+}
+
+// call performs the operation on the warehouse through the plugin, decoding its result into res if not nil
+func (p *Plugin) call(ctx context.Context, warehouse model.Warehouse, operation string, payload map[string]interface{}, res interface{}) error {
+	if payload == nil {
+		payload = make(map[string]interface{})
+	}
+	wh := warehousePayload{
+		Type:          p.destType,
+		WorkspaceID:   warehouse.WorkspaceID,
+		SourceID:      warehouse.Source.ID,
+		DestinationID: warehouse.Destination.ID,
+		Namespace:     warehouse.Namespace,
+		Config:        warehouse.Destination.Config,
+	}
+	if p.connectionTimeout > 0 {
+		wh.ConnectionTimeout = p.connectionTimeout.String()
+	}
+	payload["warehouse"] = wh
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshalling %s request: %w", operation, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+"/v1/"+operation, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating %s request: %w", operation, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling plugin for %s: %w", operation, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading %s response: %w", operation, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// the error is returned as is, so that it can be matched against the error mappings of the plugin
+		var errRes errorResponse
+		if json.Unmarshal(respBody, &errRes) == nil && errRes.Error != "" {
+			return errors.New(errRes.Error)
+		}
+		return fmt.Errorf("plugin responded to %s with status %d: %s", operation, resp.StatusCode, string(respBody))
+	}
+	if res == nil || len(respBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, res); err != nil {
+		return fmt.Errorf("unmarshalling %s response: %w", operation, err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package plugin_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/plugin"
+	mockuploader "github.com/rudderlabs/rudder-server/warehouse/internal/mocks/utils"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+// sidecar is a fake plugin, responding to the operations with the configured responses
+type sidecar struct {
+	mu        sync.Mutex
+	requests  map[string][]byte
+	responses map[string]string
+}
+
+func (s *sidecar) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	operation := strings.TrimPrefix(r.URL.Path, "/v1/")
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.requests[operation] = body
+	response, ok := s.responses[operation]
+	s.mu.Unlock()
+	if strings.HasPrefix(response, `{"error"`) {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	if ok {
+		_, _ = w.Write([]byte(response))
+	}
+}
+
+func (s *sidecar) request(operation string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[operation]
+}
+
+func TestPlugin(t *testing.T) {
+	s := &sidecar{
+		requests: map[string][]byte{},
+		responses: map[string]string{
+			"handshake":    `{"version":1,"errorMappings":[{"type":"permission_error","pattern":"permission denied for .*"}],"capabilities":{"merge":true,"maxColumns":1600}}`,
+			"fetch-schema": `{"schema":{"tracks":{"id":"string","received_at":"datetime"}}}`,
+			"load-table":   `{"rowsInserted":2,"rowsUpdated":1}`,
+			"alter-column": `{"isDependent":true,"query":"ALTER TABLE tracks"}`,
+			"is-empty":     `{"empty":true}`,
+			"create-table": `{"error":"permission denied for schema namespace"}`,
+		},
+	}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+
+	warehouse := model.Warehouse{
+		WorkspaceID: "workspace-1",
+		Source:      backendconfig.SourceT{ID: "source-1"},
+		Destination: backendconfig.DestinationT{
+			ID: "destination-1",
+			Config: map[string]interface{}{
+				"host":           "localhost",
+				"bucketProvider": warehouseutils.MINIO,
+				"bucketName":     "bucket",
+			},
+		},
+		Namespace: "namespace",
+		Type:      "NETEZZA",
+	}
+
+	ctrl := gomock.NewController(t)
+	uploader := mockuploader.NewMockUploader(ctrl)
+	uploader.EXPECT().GetLoadFilesMetadata(gomock.Any(), warehouseutils.GetLoadFilesOptions{Table: "tracks"}).Return([]warehouseutils.LoadFile{
+		{Location: "http://localhost:9000/bucket/load-1.csv.gz"},
+		{Location: "http://localhost:9000/bucket/load-2.csv.gz"},
+	}, nil).AnyTimes()
+	uploader.EXPECT().GetTableSchemaInUpload("tracks").Return(model.TableSchema{"id": "string"}).AnyTimes()
+	uploader.EXPECT().GetTableSchemaInWarehouse("tracks").Return(model.TableSchema{}).AnyTimes()
+	uploader.EXPECT().GetLoadFileType().Return(warehouseutils.LoadFileTypeCsv).AnyTimes()
+	uploader.EXPECT().UseRudderStorage().Return(false).AnyTimes()
+
+	ctx := context.Background()
+	p := plugin.New("NETEZZA", server.URL, config.New(), logger.NOP)
+	p.SetConnectionTimeout(time.Minute)
+	require.NoError(t, p.Setup(ctx, warehouse, uploader))
+	require.JSONEq(t, `{
+		"version":1,
+		"warehouse":{
+			"type":"NETEZZA",
+			"workspaceId":"workspace-1",
+			"sourceId":"source-1",
+			"destinationId":"destination-1",
+			"namespace":"namespace",
+			"config":{"host":"localhost","bucketProvider":"MINIO","bucketName":"bucket"},
+			"connectionTimeout":"1m0s"
+		}
+	}`, string(s.request("handshake")))
+	require.Equal(t, model.Capabilities{Merge: true, MaxColumns: 1600}, manager.CapabilitiesOf(config.New(), "NETEZZA", p))
+
+	t.Run("fetch schema", func(t *testing.T) {
+		schema, unrecognizedSchema, err := p.FetchSchema(ctx)
+		require.NoError(t, err)
+		require.Equal(t, model.Schema{"tracks": {"id": "string", "received_at": "datetime"}}, schema)
+		require.Equal(t, model.Schema{}, unrecognizedSchema)
+	})
+
+	t.Run("load table", func(t *testing.T) {
+		stats, err := p.LoadTable(ctx, "tracks")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), stats.RowsInserted)
+		require.Equal(t, int64(1), stats.RowsUpdated)
+
+		req := s.request("load-table")
+		require.Equal(t, "tracks", gjson.GetBytes(req, "table").String())
+		require.JSONEq(t, `{"id":"string"}`, gjson.GetBytes(req, "schemaInUpload").Raw)
+		require.Equal(t, "csv", gjson.GetBytes(req, "loadFileType").String())
+		require.Len(t, gjson.GetBytes(req, "loadFiles").Array(), 2)
+		require.Equal(t, "http://localhost:9000/bucket/load-1.csv.gz", gjson.GetBytes(req, "loadFiles.0.location").String())
+		require.Equal(t, warehouseutils.MINIO, gjson.GetBytes(req, "objectStorage.provider").String())
+		require.Equal(t, "bucket", gjson.GetBytes(req, "objectStorage.config.bucketName").String())
+	})
+
+	t.Run("alter column", func(t *testing.T) {
+		res, err := p.AlterColumn(ctx, "tracks", "id", "int")
+		require.NoError(t, err)
+		require.Equal(t, model.AlterTableResponse{IsDependent: true, Query: "ALTER TABLE tracks"}, res)
+
+		var req map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(s.request("alter-column"), &req))
+		require.JSONEq(t, `"int"`, string(req["columnType"]))
+	})
+
+	t.Run("is empty", func(t *testing.T) {
+		empty, err := p.IsEmpty(ctx, warehouse)
+		require.NoError(t, err)
+		require.True(t, empty)
+	})
+
+	t.Run("errors are matched against the error mappings of the plugin", func(t *testing.T) {
+		err := p.CreateTable(ctx, "tracks", model.TableSchema{"id": "string"})
+		require.EqualError(t, err, "permission denied for schema namespace")
+
+		mappings := p.ErrorMappings()
+		require.Len(t, mappings, 1)
+		require.Equal(t, model.PermissionError, mappings[0].Type)
+		require.True(t, mappings[0].Format.MatchString(err.Error()))
+	})
+
+	t.Run("unexpected status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		t.Cleanup(server.Close)
+
+		p := plugin.New("NETEZZA", server.URL, config.New(), logger.NOP)
+		err := p.TestConnection(ctx, warehouse)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "status 500")
+	})
+
+	t.Run("version mismatch", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"version":2}`))
+		}))
+		t.Cleanup(server.Close)
+
+		p := plugin.New("NETEZZA", server.URL, config.New(), logger.NOP)
+		require.Error(t, p.Setup(ctx, warehouse, uploader))
+	})
+}
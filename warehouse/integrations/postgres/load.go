@@ -95,6 +95,15 @@ func (pg *Postgres) loadTable(
 		tableNameLimit,
 	)
 
+	optimizeBackfills := pg.Warehouse.GetBoolDestinationConfig(model.OptimizeBackfillsSetting)
+	var backfill bool
+	if optimizeBackfills {
+		if backfill, err = pg.prepareBackfill(ctx, txn, tableName); err != nil {
+			return nil, "", fmt.Errorf("preparing backfill: %w", err)
+		}
+		log.Infow("optimizing load", "backfill", backfill)
+	}
+
 	log.Debugw("creating staging table")
 	createStagingTableStmt := fmt.Sprintf(
 		`CREATE TEMPORARY TABLE %[2]s (LIKE %[1]q.%[3]q)
@@ -113,6 +122,10 @@ func (pg *Postgres) loadTable(
 
 	log.Debugw("creating prepared stmt for loading data")
 	copyInStmt := pq.CopyIn(stagingTableName, sortedColumnKeys...)
+	if optimizeBackfills {
+		// the staging table is created in this transaction, so its rows can be frozen while copying them
+		copyInStmt += " WITH (FREEZE)"
+	}
 	stmt, err := txn.PrepareContext(ctx, copyInStmt)
 	if err != nil {
 		return nil, "", fmt.Errorf("preparing statement for copy in: %w", err)
@@ -133,7 +146,7 @@ func (pg *Postgres) loadTable(
 	}
 
 	var rowsDeleted int64
-	if pg.shouldMerge(tableName) {
+	if pg.shouldMerge(tableName) && !backfill {
 		log.Infow("deleting from load table")
 		rowsDeleted, err = pg.deleteFromLoadTable(
 			ctx, txn, tableName,
@@ -159,6 +172,48 @@ func (pg *Postgres) loadTable(
 	}, stagingTableName, nil
 }
 
+// prepareBackfill returns whether the table is empty, i.e. it is being backfilled, truncating it if so. Staging tables
+// are temporary, hence never WAL-logged, and with wal_level minimal the rows inserted into a table truncated in the same
+// transaction aren't WAL-logged either. There is nothing to merge with while backfilling.
+func (pg *Postgres) prepareBackfill(ctx context.Context, txn *sqlmiddleware.Tx, tableName string) (bool, error) {
+	emptyStmt := fmt.Sprintf(`SELECT NOT EXISTS (SELECT 1 FROM %q.%q);`,
+		pg.Namespace,
+		tableName,
+	)
+
+	var empty bool
+	if err := txn.QueryRowContext(ctx, emptyStmt).Scan(&empty); err != nil {
+		return false, fmt.Errorf("checking if table is empty: %w", err)
+	}
+	if !empty {
+		return false, nil
+	}
+
+	// checking again once locked, as rows might have been inserted in the meantime
+	lockStmt := fmt.Sprintf(`LOCK TABLE %q.%q IN ACCESS EXCLUSIVE MODE;`,
+		pg.Namespace,
+		tableName,
+	)
+	if _, err := txn.ExecContext(ctx, lockStmt); err != nil {
+		return false, fmt.Errorf("locking table: %w", err)
+	}
+	if err := txn.QueryRowContext(ctx, emptyStmt).Scan(&empty); err != nil {
+		return false, fmt.Errorf("checking if table is empty: %w", err)
+	}
+	if !empty {
+		return false, nil
+	}
+
+	truncateStmt := fmt.Sprintf(`TRUNCATE TABLE %q.%q;`,
+		pg.Namespace,
+		tableName,
+	)
+	if _, err := txn.ExecContext(ctx, truncateStmt); err != nil {
+		return false, fmt.Errorf("truncating table: %w", err)
+	}
+	return true, nil
+}
+
 func (pg *Postgres) loadDataIntoStagingTable(
 	ctx context.Context,
 	stmt *sql.Stmt,
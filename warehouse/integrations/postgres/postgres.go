@@ -10,6 +10,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lib/pq"
+
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/tunnelling"
 
 	"github.com/rudderlabs/rudder-go-kit/stats"
@@ -25,13 +27,10 @@ import (
 
 	"github.com/rudderlabs/rudder-server/utils/misc"
 	"github.com/rudderlabs/rudder-server/warehouse/client"
+	integrationsconfig "github.com/rudderlabs/rudder-server/warehouse/integrations/config"
 	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
 )
 
-const (
-	verifyCA = "verify-ca"
-)
-
 const (
 	provider       = warehouseutils.POSTGRES
 	tableNameLimit = 127
@@ -90,20 +89,21 @@ var rudderDataTypesMapToPostgres = map[string]string{
 }
 
 var postgresDataTypesMapToRudder = map[string]string{
-	"integer":                  "int",
-	"smallint":                 "int",
-	"bigint":                   "int",
-	"double precision":         "float",
-	"numeric":                  "float",
-	"real":                     "float",
-	"text":                     "string",
-	"varchar":                  "string",
-	"char":                     "string",
-	"timestamptz":              "datetime",
-	"timestamp with time zone": "datetime",
-	"timestamp":                "datetime",
-	"boolean":                  "boolean",
-	"jsonb":                    "json",
+	"integer":                     "int",
+	"smallint":                    "int",
+	"bigint":                      "int",
+	"double precision":            "float",
+	"numeric":                     "float",
+	"real":                        "float",
+	"text":                        "string",
+	"varchar":                     "string",
+	"char":                        "string",
+	"timestamptz":                 "datetime",
+	"timestamp with time zone":    "datetime",
+	"timestamp":                   "datetime",
+	"timestamp without time zone": "datetime",
+	"boolean":                     "boolean",
+	"jsonb":                       "json",
 }
 
 type Postgres struct {
@@ -127,19 +127,22 @@ type Postgres struct {
 		skipDedupDestinationIDs                   []string
 		skipComputingUserLatestTraits             bool
 		skipComputingUserLatestTraitsWorkspaceIDs []string
+		decimalPrecision                          int
+		decimalScale                              int
 	}
 }
 
 type credentials struct {
-	host       string
-	database   string
-	user       string
-	password   string
-	port       string
-	sslMode    string
-	sslDir     string
-	tunnelInfo *tunnelling.TunnelInfo
-	timeout    time.Duration
+	host          string
+	database      string
+	user          string
+	password      string
+	port          string
+	sslMode       string
+	sslDir        string
+	sslClientCert bool // whether a client certificate is configured, for mutual tls
+	tunnelInfo    *tunnelling.TunnelInfo
+	timeout       time.Duration
 }
 
 var primaryKeyMap = map[string]string{
@@ -169,6 +172,7 @@ func New(conf *config.Config, log logger.Logger, stat stats.Stats) *Postgres {
 	pg.config.skipDedupDestinationIDs = conf.GetStringSlice("Warehouse.postgres.skipDedupDestinationIDs", nil)
 	pg.config.skipComputingUserLatestTraits = conf.GetBool("Warehouse.postgres.skipComputingUserLatestTraits", false)
 	pg.config.skipComputingUserLatestTraitsWorkspaceIDs = conf.GetStringSlice("Warehouse.postgres.skipComputingUserLatestTraitsWorkspaceIDs", nil)
+	pg.config.decimalPrecision, pg.config.decimalScale = integrationsconfig.DecimalPrecisionAndScale(conf)
 
 	return pg
 }
@@ -192,8 +196,49 @@ func (pg *Postgres) getNewMiddleWare(db *sql.DB) *sqlmiddleware.DB {
 	return middleware
 }
 
+// sslModeFallbacks are the ssl modes of libpq which the driver doesn't support, with the mode tried first and the one
+// falling back to if the server refuses it
+var sslModeFallbacks = map[string][2]string{
+	"allow":  {"disable", "require"},
+	"prefer": {"require", "disable"},
+}
+
 func (pg *Postgres) connect() (*sqlmiddleware.DB, error) {
 	cred := pg.getConnectionCredentials()
+
+	modes, ok := sslModeFallbacks[cred.sslMode]
+	if !ok {
+		db, err := pg.open(cred, cred.sslMode)
+		if err != nil {
+			return nil, err
+		}
+		return pg.getNewMiddleWare(db), nil
+	}
+
+	db, err := pg.open(cred, modes[0])
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil && shouldFallbackSSLMode(modes[0], err) {
+		_ = db.Close()
+		if db, err = pg.open(cred, modes[1]); err != nil {
+			return nil, err
+		}
+	}
+	return pg.getNewMiddleWare(db), nil
+}
+
+// shouldFallbackSSLMode returns whether connecting with the ssl mode failed because the server refused it, i.e. it
+// doesn't support ssl or doesn't accept connections without it
+func shouldFallbackSSLMode(sslMode string, err error) bool {
+	if sslMode == "require" {
+		return errors.Is(err, pq.ErrSSLNotSupported)
+	}
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "28000"
+}
+
+func (pg *Postgres) open(cred credentials, sslMode string) (*sql.DB, error) {
 	dsn := url.URL{
 		Scheme: "postgres",
 		Host:   fmt.Sprintf("%s:%s", cred.host, cred.port),
@@ -202,16 +247,18 @@ func (pg *Postgres) connect() (*sqlmiddleware.DB, error) {
 	}
 
 	values := url.Values{}
-	values.Add("sslmode", cred.sslMode)
+	values.Add("sslmode", sslMode)
 
 	if cred.timeout > 0 {
 		values.Add("connect_timeout", fmt.Sprintf("%d", cred.timeout/time.Second))
 	}
 
-	if cred.sslMode == verifyCA {
+	if warehouseutils.SSLKeysRequired(sslMode) {
 		values.Add("sslrootcert", fmt.Sprintf("%s/server-ca.pem", cred.sslDir))
-		values.Add("sslcert", fmt.Sprintf("%s/client-cert.pem", cred.sslDir))
-		values.Add("sslkey", fmt.Sprintf("%s/client-key.pem", cred.sslDir))
+		if cred.sslClientCert {
+			values.Add("sslcert", fmt.Sprintf("%s/client-cert.pem", cred.sslDir))
+			values.Add("sslkey", fmt.Sprintf("%s/client-key.pem", cred.sslDir))
+		}
 	}
 
 	dsn.RawQuery = values.Encode()
@@ -227,27 +274,28 @@ func (pg *Postgres) connect() (*sqlmiddleware.DB, error) {
 		if err != nil {
 			return nil, fmt.Errorf("opening connection to postgres through tunnelling: %w", err)
 		}
-		return pg.getNewMiddleWare(db), nil
+		return db, nil
 	}
 
 	if db, err = sql.Open("postgres", dsn.String()); err != nil {
 		return nil, fmt.Errorf("opening connection to postgres: %w", err)
 	}
 
-	return pg.getNewMiddleWare(db), nil
+	return db, nil
 }
 
 func (pg *Postgres) getConnectionCredentials() credentials {
 	sslMode := pg.Warehouse.GetStringDestinationConfig(pg.conf, model.SSLModeSetting)
 	creds := credentials{
-		host:     pg.Warehouse.GetStringDestinationConfig(pg.conf, model.HostSetting),
-		database: pg.Warehouse.GetStringDestinationConfig(pg.conf, model.DatabaseSetting),
-		user:     pg.Warehouse.GetStringDestinationConfig(pg.conf, model.UserSetting),
-		password: pg.Warehouse.GetStringDestinationConfig(pg.conf, model.PasswordSetting),
-		port:     pg.Warehouse.GetStringDestinationConfig(pg.conf, model.PortSetting),
-		sslMode:  sslMode,
-		sslDir:   warehouseutils.GetSSLKeyDirPath(pg.Warehouse.Destination.ID),
-		timeout:  pg.connectTimeout,
+		host:          pg.Warehouse.GetStringDestinationConfig(pg.conf, model.HostSetting),
+		database:      pg.Warehouse.GetStringDestinationConfig(pg.conf, model.DatabaseSetting),
+		user:          pg.Warehouse.GetStringDestinationConfig(pg.conf, model.UserSetting),
+		password:      pg.Warehouse.GetStringDestinationConfig(pg.conf, model.PasswordSetting),
+		port:          pg.Warehouse.GetStringDestinationConfig(pg.conf, model.PortSetting),
+		sslMode:       sslMode,
+		sslDir:        warehouseutils.GetSSLKeyDirPath(pg.Warehouse.Destination.ID),
+		sslClientCert: pg.Warehouse.GetStringDestinationConfig(pg.conf, model.ClientCertSetting) != "",
+		timeout:       pg.connectTimeout,
 		tunnelInfo: tunnelling.ExtractTunnelInfoFromDestinationConfig(
 			pg.Warehouse.Destination.Config,
 		),
@@ -256,14 +304,26 @@ func (pg *Postgres) getConnectionCredentials() credentials {
 	return creds
 }
 
-func ColumnsWithDataTypes(columns model.TableSchema, prefix string) string {
+func (pg *Postgres) ColumnsWithDataTypes(columns model.TableSchema, prefix string) string {
 	var arr []string
 	for name, dataType := range columns {
-		arr = append(arr, fmt.Sprintf(`"%s%s" %s`, prefix, name, rudderDataTypesMapToPostgres[dataType]))
+		arr = append(arr, fmt.Sprintf(`"%s%s" %s`, prefix, name, pg.dataType(dataType)))
 	}
 	return strings.Join(arr, ",")
 }
 
+// dataType returns the postgres data type of the rudder data type, creating the decimal columns with the configured
+// precision and scale, and the datetime columns without a time zone if configured for the destination
+func (pg *Postgres) dataType(dataType string) string {
+	switch {
+	case dataType == model.DecimalDataType:
+		return fmt.Sprintf("numeric(%d,%d)", pg.config.decimalPrecision, pg.config.decimalScale)
+	case dataType == model.DateTimeDataType && pg.Warehouse.GetTimestampTypeSetting(pg.conf) == model.TimestampTypeWithoutTimeZone:
+		return "timestamp"
+	}
+	return rudderDataTypesMapToPostgres[dataType]
+}
+
 func (*Postgres) IsEmpty(context.Context, model.Warehouse) (empty bool, err error) {
 	return
 }
@@ -298,9 +358,115 @@ func (pg *Postgres) DeleteBy(ctx context.Context, tableNames []string, params wa
 	return nil
 }
 
+// DeleteLoadedRows deletes the rows of the table loaded by the upload, see [warehouseutils.LoadIDPattern]
+func (pg *Postgres) DeleteLoadedRows(ctx context.Context, tableName string, uploadID int64) error {
+	sqlStatement := fmt.Sprintf(`DELETE FROM %q.%q WHERE %q LIKE $1;`,
+		pg.Namespace,
+		tableName,
+		warehouseutils.LoadIDColumn,
+	)
+	_, err := pg.DB.ExecContext(ctx, sqlStatement, warehouseutils.LoadIDPattern(uploadID))
+	return err
+}
+
+// AcquireLease takes the lease of the key for the owner in the upload leases table of the namespace, creating them
+// if needed, see [manager.Leaser]
+func (pg *Postgres) AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	if err := pg.CreateSchema(ctx); err != nil {
+		return false, fmt.Errorf("creating schema: %w", err)
+	}
+	sqlStatement := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %q.%q (
+		  lease_key TEXT PRIMARY KEY,
+		  owner TEXT NOT NULL,
+		  expires_at TIMESTAMP NOT NULL,
+		  updated_at TIMESTAMP NOT NULL
+		);`,
+		pg.Namespace,
+		warehouseutils.UploadLeasesTable,
+	)
+	if _, err := pg.DB.ExecContext(ctx, sqlStatement); err != nil {
+		return false, fmt.Errorf("creating leases table: %w", err)
+	}
+
+	txn, err := pg.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return false, fmt.Errorf("beginning transaction: %w", err)
+	}
+	acquired, err := pg.acquireLease(ctx, txn, key, owner, ttl)
+	if err != nil {
+		_ = txn.Rollback()
+		return false, err
+	}
+	if err := txn.Commit(); err != nil {
+		return false, fmt.Errorf("committing transaction: %w", err)
+	}
+	return acquired, nil
+}
+
+// acquireLease replaces the lease of the key if held by the owner or expired, the table being locked for the leases
+// of concurrent installations to be taken one after the other
+func (pg *Postgres) acquireLease(ctx context.Context, txn *sqlmiddleware.Tx, key, owner string, ttl time.Duration) (bool, error) {
+	if _, err := txn.ExecContext(ctx, fmt.Sprintf(`LOCK TABLE %q.%q IN EXCLUSIVE MODE;`, pg.Namespace, warehouseutils.UploadLeasesTable)); err != nil {
+		return false, fmt.Errorf("locking leases table: %w", err)
+	}
+
+	now := time.Now().UTC()
+	sqlStatement := fmt.Sprintf(`DELETE FROM %q.%q WHERE lease_key = $1 AND (owner = $2 OR expires_at < $3);`,
+		pg.Namespace,
+		warehouseutils.UploadLeasesTable,
+	)
+	if _, err := txn.ExecContext(ctx, sqlStatement, key, owner, now); err != nil {
+		return false, fmt.Errorf("deleting expired lease: %w", err)
+	}
+
+	sqlStatement = fmt.Sprintf(`
+		INSERT INTO %[1]q.%[2]q (lease_key, owner, expires_at, updated_at)
+		SELECT $1, $2, $3, $4
+		WHERE NOT EXISTS (SELECT 1 FROM %[1]q.%[2]q WHERE lease_key = $1);`,
+		pg.Namespace,
+		warehouseutils.UploadLeasesTable,
+	)
+	result, err := txn.ExecContext(ctx, sqlStatement, key, owner, now.Add(ttl), now)
+	if err != nil {
+		return false, fmt.Errorf("inserting lease: %w", err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("getting rows affected: %w", err)
+	}
+	return inserted > 0, nil
+}
+
+// ReleaseLease releases the lease of the key held by the owner, see [manager.Leaser]
+func (pg *Postgres) ReleaseLease(ctx context.Context, key, owner string) error {
+	sqlStatement := fmt.Sprintf(`DELETE FROM %q.%q WHERE lease_key = $1 AND owner = $2;`,
+		pg.Namespace,
+		warehouseutils.UploadLeasesTable,
+	)
+	_, err := pg.DB.ExecContext(ctx, sqlStatement, key, owner)
+	return err
+}
+
+// RenameNamespace renames the namespace, see [manager.Renamer]
+func (pg *Postgres) RenameNamespace(ctx context.Context, newNamespace string) error {
+	sqlStatement := fmt.Sprintf(`ALTER SCHEMA %q RENAME TO %q;`, pg.Namespace, newNamespace)
+	pg.logger.Infof("PG: Renaming schema in postgres for PG:%s : %v", pg.Warehouse.Destination.ID, sqlStatement)
+	_, err := pg.DB.ExecContext(ctx, sqlStatement)
+	return err
+}
+
+// RenameTable renames the table of the namespace, see [manager.Renamer]
+func (pg *Postgres) RenameTable(ctx context.Context, tableName, newTableName string) error {
+	sqlStatement := fmt.Sprintf(`ALTER TABLE %q.%q RENAME TO %q;`, pg.Namespace, tableName, newTableName)
+	pg.logger.Infof("PG: Renaming table in postgres for PG:%s : %v", pg.Warehouse.Destination.ID, sqlStatement)
+	_, err := pg.DB.ExecContext(ctx, sqlStatement)
+	return err
+}
+
 func (pg *Postgres) schemaExists(ctx context.Context, _ string) (exists bool, err error) {
-	sqlStatement := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_namespace WHERE nspname = '%s');`, pg.Namespace)
-	err = pg.DB.QueryRowContext(ctx, sqlStatement).Scan(&exists)
+	sqlStatement := `SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_namespace WHERE nspname = $1);`
+	err = pg.DB.QueryRowContext(ctx, sqlStatement, pg.Namespace).Scan(&exists)
 	return
 }
 
@@ -322,7 +488,7 @@ func (pg *Postgres) CreateSchema(ctx context.Context) (err error) {
 }
 
 func (pg *Postgres) createTable(ctx context.Context, name string, columns model.TableSchema) (err error) {
-	sqlStatement := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS "%[1]s"."%[2]s" ( %v )`, pg.Namespace, name, ColumnsWithDataTypes(columns, ""))
+	sqlStatement := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS "%[1]s"."%[2]s" ( %v )`, pg.Namespace, name, pg.ColumnsWithDataTypes(columns, ""))
 	pg.logger.Infof("PG: Creating table in postgres for PG:%s : %v", pg.Warehouse.Destination.ID, sqlStatement)
 	_, err = pg.DB.ExecContext(ctx, sqlStatement)
 	return
@@ -368,7 +534,7 @@ func (pg *Postgres) AddColumns(ctx context.Context, tableName string, columnsInf
 	))
 
 	for _, columnInfo := range columnsInfo {
-		queryBuilder.WriteString(fmt.Sprintf(` ADD COLUMN IF NOT EXISTS %q %s,`, columnInfo.Name, rudderDataTypesMapToPostgres[columnInfo.Type]))
+		queryBuilder.WriteString(fmt.Sprintf(` ADD COLUMN IF NOT EXISTS %q %s,`, columnInfo.Name, pg.dataType(columnInfo.Type)))
 	}
 
 	query = strings.TrimSuffix(queryBuilder.String(), ",")
@@ -384,7 +550,7 @@ func (*Postgres) AlterColumn(context.Context, string, string, string) (model.Alt
 }
 
 func (pg *Postgres) TestConnection(ctx context.Context, warehouse model.Warehouse) error {
-	if warehouse.Destination.Config["sslMode"] == verifyCA {
+	if warehouseutils.SSLKeysRequired(warehouse.Destination.Config["sslMode"]) {
 		if sslKeyError := warehouseutils.WriteSSLKeys(warehouse.Destination); sslKeyError.IsError() {
 			return fmt.Errorf("writing ssl keys: %s", sslKeyError.Error())
 		}
@@ -493,7 +659,7 @@ func (*Postgres) DownloadIdentityRules(context.Context, *misc.GZipWriter) (err e
 }
 
 func (pg *Postgres) Connect(_ context.Context, warehouse model.Warehouse) (client.Client, error) {
-	if warehouse.Destination.Config["sslMode"] == "verify-ca" {
+	if warehouseutils.SSLKeysRequired(warehouse.Destination.Config["sslMode"]) {
 		if err := warehouseutils.WriteSSLKeys(warehouse.Destination); err.IsError() {
 			pg.logger.Error(err.Error())
 			return client.Client{}, errors.New(err.Error())
@@ -1007,6 +1007,58 @@ func TestIntegration(t *testing.T) {
 			)
 			require.Equal(t, records, whth.AppendTestRecords())
 		})
+		t.Run("optimize backfills", func(t *testing.T) {
+			ctx := context.Background()
+			tableName := "optimize_backfills_test_table"
+			uploadOutput := whth.UploadLoadFile(t, fm, "../testdata/dedup.csv.gz", tableName)
+
+			loadFiles := []whutils.LoadFile{{Location: uploadOutput.Location}}
+			mockUploader := mockUploader(t, loadFiles, tableName, schemaInUpload, schemaInWarehouse)
+
+			backfillWarehouse := th.Clone(t, warehouse)
+			backfillWarehouse.Destination.Config[model.OptimizeBackfillsSetting.String()] = true
+
+			pg := postgres.New(config.New(), logger.NOP, stats.NOP)
+			err := pg.Setup(ctx, backfillWarehouse, mockUploader)
+			require.NoError(t, err)
+
+			err = pg.CreateSchema(ctx)
+			require.NoError(t, err)
+
+			err = pg.CreateTable(ctx, tableName, schemaInWarehouse)
+			require.NoError(t, err)
+
+			loadTableStat, err := pg.LoadTable(ctx, tableName)
+			require.NoError(t, err)
+			require.Equal(t, loadTableStat.RowsInserted, int64(14))
+			require.Equal(t, loadTableStat.RowsUpdated, int64(0))
+
+			loadTableStat, err = pg.LoadTable(ctx, tableName)
+			require.NoError(t, err)
+			require.Equal(t, loadTableStat.RowsInserted, int64(0))
+			require.Equal(t, loadTableStat.RowsUpdated, int64(14))
+
+			records := whth.RetrieveRecordsFromWarehouse(t, pg.DB.DB,
+				fmt.Sprintf(`
+					SELECT
+					  id,
+					  received_at,
+					  test_bool,
+					  test_datetime,
+					  test_float,
+					  test_int,
+					  test_string
+					FROM
+					  %q.%q
+					ORDER BY
+					  id;
+					`,
+					namespace,
+					tableName,
+				),
+			)
+			require.Equal(t, records, whth.DedupTestRecords())
+		})
 		t.Run("load file does not exists", func(t *testing.T) {
 			ctx := context.Background()
 			tableName := "load_file_not_exists_test_table"
@@ -13,6 +13,7 @@ import (
 	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rudderlabs/sqlconnect-go/sqlconnect"
@@ -34,6 +35,7 @@ import (
 
 	"github.com/rudderlabs/rudder-server/utils/misc"
 	"github.com/rudderlabs/rudder-server/warehouse/client"
+	integrationsconfig "github.com/rudderlabs/rudder-server/warehouse/integrations/config"
 	sqlmiddleware "github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
 	"github.com/rudderlabs/rudder-server/warehouse/logfield"
@@ -65,6 +67,10 @@ var errorsMappings = []model.JobError{
 		Type:   model.PermissionError,
 		Format: regexp.MustCompile(`pq: permission denied for database`),
 	},
+	{
+		Type:   model.PermissionError,
+		Format: regexp.MustCompile(`pq: permission denied for (schema|relation|table)`),
+	},
 	{
 		Type:   model.PermissionError,
 		Format: regexp.MustCompile(`pq: must be owner of relation`),
@@ -152,6 +158,11 @@ type Redshift struct {
 	logger         logger.Logger
 	stats          stats.Stats
 
+	// stagingSchema is the schema the staging tables get created in, the namespace unless configured otherwise
+	stagingSchema        string
+	stagingSchemaMu      sync.Mutex
+	stagingSchemaCreated bool
+
 	config struct {
 		allowMerge                    bool
 		slowQueryThreshold            time.Duration
@@ -160,6 +171,8 @@ type Redshift struct {
 		skipDedupDestinationIDs       []string
 		skipComputingUserLatestTraits bool
 		enableDeleteByJobs            bool
+		decimalPrecision              int
+		decimalScale                  int
 	}
 }
 
@@ -191,16 +204,24 @@ func New(conf *config.Config, log logger.Logger, stat stats.Stats) *Redshift {
 	rs.config.skipComputingUserLatestTraits = conf.GetBool("Warehouse.redshift.skipComputingUserLatestTraits", false)
 	rs.config.enableDeleteByJobs = conf.GetBool("Warehouse.redshift.enableDeleteByJobs", false)
 	rs.config.slowQueryThreshold = conf.GetDuration("Warehouse.redshift.slowQueryThreshold", 5, time.Minute)
+	rs.config.decimalPrecision, rs.config.decimalScale = integrationsconfig.DecimalPrecisionAndScale(conf)
 
 	return rs
 }
 
-// getRSDataType gets datatype for rs which is mapped with RudderStack datatype
-func getRSDataType(columnType string) string {
+// dataType gets datatype for rs which is mapped with RudderStack datatype, creating the decimal columns with the
+// configured precision and scale, and the datetime columns with a time zone if configured for the destination
+func (rs *Redshift) dataType(columnType string) string {
+	switch {
+	case columnType == model.DecimalDataType:
+		return fmt.Sprintf("decimal(%d,%d)", rs.config.decimalPrecision, rs.config.decimalScale)
+	case columnType == model.DateTimeDataType && rs.Warehouse.GetTimestampTypeSetting(rs.conf) == model.TimestampTypeWithTimeZone:
+		return "timestamptz"
+	}
 	return dataTypesMap[columnType]
 }
 
-func ColumnsWithDataTypes(columns model.TableSchema, prefix string) string {
+func (rs *Redshift) ColumnsWithDataTypes(columns model.TableSchema, prefix string) string {
 	// TODO: do we need sorted order here?
 	var keys []string
 	for colName := range columns {
@@ -210,12 +231,16 @@ func ColumnsWithDataTypes(columns model.TableSchema, prefix string) string {
 
 	var arr []string
 	for _, name := range keys {
-		arr = append(arr, fmt.Sprintf(`"%s%s" %s`, prefix, name, getRSDataType(columns[name])))
+		arr = append(arr, fmt.Sprintf(`"%s%s" %s`, prefix, name, rs.dataType(columns[name])))
 	}
 	return strings.Join(arr, ",")
 }
 
 func (rs *Redshift) CreateTable(ctx context.Context, tableName string, columns model.TableSchema) (err error) {
+	if rs.createAsSpectrumTable(tableName) {
+		return rs.createSpectrumTable(ctx, tableName, columns)
+	}
+
 	name := fmt.Sprintf(`%q.%q`, rs.Namespace, tableName)
 	sortKeyField := "received_at"
 	if _, ok := columns["received_at"]; !ok {
@@ -228,7 +253,7 @@ func (rs *Redshift) CreateTable(ctx context.Context, tableName string, columns m
 	if _, ok := columns["id"]; ok {
 		distKeySql = `DISTSTYLE KEY DISTKEY("id")`
 	}
-	sqlStatement := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s ( %v ) %s SORTKEY(%q) `, name, ColumnsWithDataTypes(columns, ""), distKeySql, sortKeyField)
+	sqlStatement := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s ( %v ) %s SORTKEY(%q) `, name, rs.ColumnsWithDataTypes(columns, ""), distKeySql, sortKeyField)
 	rs.logger.Infof("Creating table in redshift for RS:%s : %v", rs.Warehouse.Destination.ID, sqlStatement)
 	_, err = rs.DB.ExecContext(ctx, sqlStatement)
 	return
@@ -242,21 +267,30 @@ func (rs *Redshift) DropTable(ctx context.Context, tableName string) (err error)
 }
 
 func (rs *Redshift) schemaExists(ctx context.Context) (exists bool, err error) {
-	sqlStatement := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_namespace WHERE nspname = '%s');`, rs.Namespace)
-	err = rs.DB.QueryRowContext(ctx, sqlStatement).Scan(&exists)
+	sqlStatement := `SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_namespace WHERE nspname = $1);`
+	err = rs.DB.QueryRowContext(ctx, sqlStatement, rs.Namespace).Scan(&exists)
 	return
 }
 
 func (rs *Redshift) AddColumns(ctx context.Context, tableName string, columnsInfo []warehouseutils.ColumnInfo) error {
+	spectrumTable, err := rs.spectrumTableExists(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	namespace, dataType := rs.Namespace, rs.dataType
+	if spectrumTable {
+		namespace, dataType = rs.spectrumSchema(), func(columnType string) string { return spectrumDataTypesMap[columnType] }
+	}
+
 	for _, columnInfo := range columnsInfo {
-		columnType := getRSDataType(columnInfo.Type)
+		columnType := dataType(columnInfo.Type)
 		query := fmt.Sprintf(`
 			ALTER TABLE
 			  %q.%q
 			ADD
 			  COLUMN %q %s;
 	`,
-			rs.Namespace,
+			namespace,
 			tableName,
 			columnInfo.Name,
 			columnType,
@@ -271,7 +305,7 @@ func (rs *Redshift) AddColumns(ctx context.Context, tableName string, columnsInf
 					logfield.DestinationID, rs.Warehouse.Destination.ID,
 					logfield.DestinationType, rs.Warehouse.Destination.DestinationDefinition.Name,
 					logfield.WorkspaceID, rs.Warehouse.WorkspaceID,
-					logfield.Schema, rs.Namespace,
+					logfield.Schema, namespace,
 					logfield.TableName, tableName,
 					logfield.ColumnName, columnInfo.Name,
 					logfield.ColumnType, columnType,
@@ -304,6 +338,16 @@ func (rs *Redshift) DeleteBy(ctx context.Context, tableNames []string, params wa
 	rs.logger.Infof("RS: Cleaning up the following tables in redshift for RS:%s : %+v", tableNames, params)
 	rs.logger.Infof("RS: Flag for enableDeleteByJobs is %t", rs.config.enableDeleteByJobs)
 	for _, tb := range tableNames {
+		var spectrumTable bool
+		spectrumTable, err = rs.spectrumTableExists(ctx, tb)
+		if err != nil {
+			return err
+		}
+		if spectrumTable {
+			rs.logger.Infof("RS: Skipping cleaning up spectrum table %s for RS:%s, since spectrum tables are append only", tb, rs.Warehouse.Destination.ID)
+			continue
+		}
+
 		sqlStatement := fmt.Sprintf(`DELETE FROM "%[1]s"."%[2]s" WHERE
 			context_sources_job_run_id <> $1 AND
 			context_sources_task_run_id <> $2 AND
@@ -333,6 +377,135 @@ func (rs *Redshift) DeleteBy(ctx context.Context, tableNames []string, params wa
 	return nil
 }
 
+// DeleteLoadedRows deletes the rows of the table loaded by the upload, see [warehouseutils.LoadIDPattern]
+func (rs *Redshift) DeleteLoadedRows(ctx context.Context, tableName string, uploadID int64) error {
+	spectrumTable, err := rs.spectrumTableExists(ctx, tableName)
+	if err != nil {
+		return fmt.Errorf("checking spectrum table: %w", err)
+	}
+	if spectrumTable {
+		return fmt.Errorf("spectrum table %s is append only", tableName)
+	}
+
+	sqlStatement := fmt.Sprintf(`DELETE FROM %q.%q WHERE %q LIKE $1;`,
+		rs.Namespace,
+		tableName,
+		warehouseutils.LoadIDColumn,
+	)
+	_, err = rs.DB.ExecContext(ctx, sqlStatement, warehouseutils.LoadIDPattern(uploadID))
+	return err
+}
+
+// AcquireLease takes the lease of the key for the owner in the upload leases table of the namespace, creating them
+// if needed, see [manager.Leaser]
+func (rs *Redshift) AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	if err := rs.CreateSchema(ctx); err != nil {
+		return false, fmt.Errorf("creating schema: %w", err)
+	}
+	sqlStatement := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %q.%q (
+		  lease_key VARCHAR(512) NOT NULL,
+		  owner VARCHAR(512) NOT NULL,
+		  expires_at TIMESTAMP NOT NULL,
+		  updated_at TIMESTAMP NOT NULL
+		);`,
+		rs.Namespace,
+		warehouseutils.UploadLeasesTable,
+	)
+	if _, err := rs.DB.ExecContext(ctx, sqlStatement); err != nil {
+		return false, fmt.Errorf("creating leases table: %w", err)
+	}
+
+	txn, err := rs.DB.BeginTx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return false, fmt.Errorf("beginning transaction: %w", err)
+	}
+	acquired, err := rs.acquireLease(ctx, txn, key, owner, ttl)
+	if err != nil {
+		_ = txn.Rollback()
+		return false, err
+	}
+	if err := txn.Commit(); err != nil {
+		return false, fmt.Errorf("committing transaction: %w", err)
+	}
+	return acquired, nil
+}
+
+// acquireLease replaces the lease of the key if held by the owner or expired, the table being locked for the leases
+// of concurrent installations to be taken one after the other
+func (rs *Redshift) acquireLease(ctx context.Context, txn *sqlmiddleware.Tx, key, owner string, ttl time.Duration) (bool, error) {
+	if _, err := txn.ExecContext(ctx, fmt.Sprintf(`LOCK %q.%q;`, rs.Namespace, warehouseutils.UploadLeasesTable)); err != nil {
+		return false, fmt.Errorf("locking leases table: %w", err)
+	}
+
+	now := time.Now().UTC()
+	sqlStatement := fmt.Sprintf(`DELETE FROM %q.%q WHERE lease_key = $1 AND (owner = $2 OR expires_at < $3);`,
+		rs.Namespace,
+		warehouseutils.UploadLeasesTable,
+	)
+	if _, err := txn.ExecContext(ctx, sqlStatement, key, owner, now); err != nil {
+		return false, fmt.Errorf("deleting expired lease: %w", err)
+	}
+
+	sqlStatement = fmt.Sprintf(`
+		INSERT INTO %[1]q.%[2]q (lease_key, owner, expires_at, updated_at)
+		SELECT $1, $2, $3, $4
+		WHERE NOT EXISTS (SELECT 1 FROM %[1]q.%[2]q WHERE lease_key = $1);`,
+		rs.Namespace,
+		warehouseutils.UploadLeasesTable,
+	)
+	result, err := txn.ExecContext(ctx, sqlStatement, key, owner, now.Add(ttl), now)
+	if err != nil {
+		return false, fmt.Errorf("inserting lease: %w", err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("getting rows affected: %w", err)
+	}
+	return inserted > 0, nil
+}
+
+// ReleaseLease releases the lease of the key held by the owner, see [manager.Leaser]
+func (rs *Redshift) ReleaseLease(ctx context.Context, key, owner string) error {
+	sqlStatement := fmt.Sprintf(`DELETE FROM %q.%q WHERE lease_key = $1 AND owner = $2;`,
+		rs.Namespace,
+		warehouseutils.UploadLeasesTable,
+	)
+	_, err := rs.DB.ExecContext(ctx, sqlStatement, key, owner)
+	return err
+}
+
+// RenameNamespace renames the namespace, see [manager.Renamer]
+func (rs *Redshift) RenameNamespace(ctx context.Context, newNamespace string) error {
+	sqlStatement := fmt.Sprintf(`ALTER SCHEMA %q RENAME TO %q;`, rs.Namespace, newNamespace)
+	rs.logger.Infof("RS: Renaming schema in redshift for RS:%s : %v", rs.Warehouse.Destination.ID, sqlStatement)
+	_, err := rs.DB.ExecContext(ctx, sqlStatement)
+	return err
+}
+
+// RenameTable renames the table of the namespace, see [manager.Renamer]
+func (rs *Redshift) RenameTable(ctx context.Context, tableName, newTableName string) error {
+	sqlStatement := fmt.Sprintf(`ALTER TABLE %q.%q RENAME TO %q;`, rs.Namespace, tableName, newTableName)
+	rs.logger.Infof("RS: Renaming table in redshift for RS:%s : %v", rs.Warehouse.Destination.ID, sqlStatement)
+	_, err := rs.DB.ExecContext(ctx, sqlStatement)
+	return err
+}
+
+// createStagingSchema creates the staging schema, if configured, before the first staging table of the upload
+func (rs *Redshift) createStagingSchema(ctx context.Context) error {
+	rs.stagingSchemaMu.Lock()
+	defer rs.stagingSchemaMu.Unlock()
+
+	if rs.stagingSchemaCreated || rs.stagingSchema == rs.Namespace {
+		return nil
+	}
+	if _, err := rs.DB.ExecContext(ctx, fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS %q`, rs.stagingSchema)); err != nil {
+		return err
+	}
+	rs.stagingSchemaCreated = true
+	return nil
+}
+
 func (rs *Redshift) createSchema(ctx context.Context) (err error) {
 	sqlStatement := fmt.Sprintf(`CREATE SCHEMA IF NOT EXISTS %q`, rs.Namespace)
 	rs.logger.Infof("Creating schema name in redshift for RS:%s : %v", rs.Warehouse.Destination.ID, sqlStatement)
@@ -429,7 +602,7 @@ func (rs *Redshift) generateManifest(ctx context.Context, tableName string) (str
 func (rs *Redshift) dropStagingTables(ctx context.Context, stagingTableNames []string) {
 	for _, stagingTableName := range stagingTableNames {
 		rs.logger.Infof("WH: dropping table %+v\n", stagingTableName)
-		_, err := rs.DB.ExecContext(ctx, fmt.Sprintf(`DROP TABLE "%[1]s"."%[2]s"`, rs.Namespace, stagingTableName))
+		_, err := rs.DB.ExecContext(ctx, fmt.Sprintf(`DROP TABLE "%[1]s"."%[2]s"`, rs.stagingSchema, stagingTableName))
 		if err != nil {
 			rs.logger.Errorf("WH: RS:  Error dropping staging tables in redshift: %v", err)
 		}
@@ -467,11 +640,16 @@ func (rs *Redshift) loadTable(
 		tableNameLimit,
 	)
 
+	if err = rs.createStagingSchema(ctx); err != nil {
+		return nil, "", fmt.Errorf("creating staging schema: %w", err)
+	}
+
 	log.Debugw("creating staging table")
-	createStagingTableStmt := fmt.Sprintf(`CREATE TABLE %[1]q.%[2]q (LIKE %[1]q.%[3]q INCLUDING DEFAULTS);`,
+	createStagingTableStmt := fmt.Sprintf(`CREATE TABLE %[4]q.%[2]q (LIKE %[1]q.%[3]q INCLUDING DEFAULTS);`,
 		rs.Namespace,
 		stagingTableName,
 		tableName,
+		rs.stagingSchema,
 	)
 	if _, err = rs.DB.ExecContext(ctx, createStagingTableStmt); err != nil {
 		return nil, "", fmt.Errorf("creating staging table: %w", err)
@@ -562,9 +740,9 @@ func (rs *Redshift) copyIntoLoadTable(
 	manifestLocation string,
 	strKeys []string,
 ) error {
-	tempAccessKeyId, tempSecretAccessKey, token, err := warehouseutils.GetTemporaryS3Cred(&rs.Warehouse.Destination)
+	credentials, err := rs.copyCredentials()
 	if err != nil {
-		return fmt.Errorf("getting temporary s3 credentials: %w", err)
+		return fmt.Errorf("getting copy credentials: %w", err)
 	}
 
 	manifestS3Location, region := warehouseutils.GetS3Location(manifestLocation)
@@ -581,36 +759,28 @@ func (rs *Redshift) copyIntoLoadTable(
 		copyStmt = fmt.Sprintf(
 			`COPY %s
 			FROM '%s'
-			ACCESS_KEY_ID '%s'
-			SECRET_ACCESS_KEY '%s'
-			SESSION_TOKEN '%s'
+			%s
 			MANIFEST FORMAT PARQUET;`,
-			fmt.Sprintf(`%q.%q`, rs.Namespace, stagingTableName),
+			fmt.Sprintf(`%q.%q`, rs.stagingSchema, stagingTableName),
 			manifestS3Location,
-			tempAccessKeyId,
-			tempSecretAccessKey,
-			token,
+			credentials,
 		)
 	} else {
 		copyStmt = fmt.Sprintf(
 			`COPY %s(%s)
 			FROM '%s'
 			CSV GZIP
-			ACCESS_KEY_ID '%s'
-			SECRET_ACCESS_KEY '%s'
-			SESSION_TOKEN '%s'
+			%s
 			REGION '%s'
 			DATEFORMAT 'auto'
 			TIMEFORMAT 'auto'
 			MANIFEST TRUNCATECOLUMNS EMPTYASNULL BLANKSASNULL FILLRECORD ACCEPTANYDATE TRIMBLANKS ACCEPTINVCHARS
 			COMPUPDATE OFF
 			STATUPDATE OFF;`,
-			fmt.Sprintf(`%q.%q`, rs.Namespace, stagingTableName),
+			fmt.Sprintf(`%q.%q`, rs.stagingSchema, stagingTableName),
 			sortedColumnNames,
 			manifestS3Location,
-			tempAccessKeyId,
-			tempSecretAccessKey,
-			token,
+			credentials,
 			region,
 		)
 	}
@@ -635,12 +805,13 @@ func (rs *Redshift) deleteFromLoadTable(
 
 	deleteStmt := fmt.Sprintf(
 		`DELETE FROM %[1]s.%[2]q
-		USING %[1]s.%[3]q _source
+		USING %[5]q.%[3]q _source
 		WHERE _source.%[4]s = %[1]s.%[2]q.%[4]s`,
 		rs.Namespace,
 		tableName,
 		stagingTableName,
 		primaryKey,
+		rs.stagingSchema,
 	)
 	if rs.config.dedupWindow {
 		if _, ok := tableSchemaAfterUpload["received_at"]; ok {
@@ -697,7 +868,7 @@ func (rs *Redshift) insertIntoLoadTable(
 				ORDER BY
 				  received_at DESC
 			  ) AS _rudder_staging_row_number
-			FROM %[1]q.%[4]q
+			FROM %[6]q.%[4]q
 		  ) AS _
 		WHERE _rudder_staging_row_number = 1;`,
 		rs.Namespace,
@@ -705,6 +876,7 @@ func (rs *Redshift) insertIntoLoadTable(
 		quotedColumnNames,
 		stagingTableName,
 		partitionKey,
+		rs.stagingSchema,
 	)
 
 	result, err := txn.ExecContext(ctx, insertStmt)
@@ -789,7 +961,7 @@ func (rs *Redshift) loadUserTables(ctx context.Context) map[string]error {
 	stagingTableName := warehouseutils.StagingTableName(provider, warehouseutils.UsersTable, tableNameLimit)
 
 	query = fmt.Sprintf(
-		`CREATE TABLE %[1]q.%[2]q AS (
+		`CREATE TABLE %[7]q.%[2]q AS (
 		  SELECT DISTINCT *
 		  FROM
 			(
@@ -807,7 +979,7 @@ func (rs *Redshift) loadUserTables(ctx context.Context) map[string]error {
 						SELECT
 						  DISTINCT(user_id)
 						FROM
-						  %[1]q.%[5]q
+						  %[7]q.%[5]q
 						WHERE
 						  user_id IS NOT NULL
 					  )
@@ -815,7 +987,7 @@ func (rs *Redshift) loadUserTables(ctx context.Context) map[string]error {
 				  UNION
 					(
 					  SELECT user_id, %[6]s
-					  FROM %[1]q.%[5]q
+					  FROM %[7]q.%[5]q
 					  WHERE user_id IS NOT NULL
 					)
 				)
@@ -827,6 +999,7 @@ func (rs *Redshift) loadUserTables(ctx context.Context) map[string]error {
 		warehouseutils.UsersTable,
 		identifyStagingTable,
 		quotedUserColNames,
+		rs.stagingSchema,
 	)
 
 	if txn, err = rs.DB.BeginTx(ctx, &sql.TxOptions{}); err != nil {
@@ -847,12 +1020,13 @@ func (rs *Redshift) loadUserTables(ctx context.Context) map[string]error {
 	defer rs.dropStagingTables(ctx, []string{stagingTableName})
 
 	primaryKey := "id"
-	query = fmt.Sprintf(`DELETE FROM %[1]s.%[2]q USING %[1]s.%[3]q _source
+	query = fmt.Sprintf(`DELETE FROM %[1]s.%[2]q USING %[5]q.%[3]q _source
 			WHERE _source.%[4]s = %[1]s.%[2]s.%[4]s;`,
 		rs.Namespace,
 		warehouseutils.UsersTable,
 		stagingTableName,
 		primaryKey,
+		rs.stagingSchema,
 	)
 
 	if _, err = txn.ExecContext(ctx, query); err != nil {
@@ -870,11 +1044,12 @@ func (rs *Redshift) loadUserTables(ctx context.Context) map[string]error {
 	query = fmt.Sprintf(
 		`INSERT INTO %[1]q.%[2]q (%[4]s)
 		SELECT %[4]s
-		FROM %[1]q.%[3]q;`,
+		FROM %[5]q.%[3]q;`,
 		rs.Namespace,
 		warehouseutils.UsersTable,
 		stagingTableName,
 		warehouseutils.DoubleQuoteAndJoinByComma(append([]string{"id"}, userColNames...)),
+		rs.stagingSchema,
 	)
 
 	rs.logger.Infow("inserting into users table", append(logFields, logfield.Query, query)...)
@@ -951,6 +1126,31 @@ func (rs *Redshift) connect(ctx context.Context) (*sqlmiddleware.DB, error) {
 	return middleware, nil
 }
 
+// iamRoleARNRegex matches the ARNs of IAM roles, e.g. arn:aws:iam::123456789012:role/path/name
+var iamRoleARNRegex = regexp.MustCompile(`^arn:aws(-[a-z]+)*:iam::\d{12}:role/[\w+=.@/-]+$`)
+
+// copyCredentials returns the credentials clause of COPY commands. If an IAM role associated with the cluster is
+// configured, Redshift assumes it for reading the load files, refreshing its credentials for as long as the COPY command
+// runs. Otherwise, temporary credentials of the staging bucket are used, Redshift only loading from S3.
+//
+// Chained roles can be configured as a comma separated list of ARNs, each of them having to be a valid IAM role ARN
+// since the list is interpolated into the COPY command.
+func (rs *Redshift) copyCredentials() (string, error) {
+	if roleARN := rs.Warehouse.GetStringDestinationConfig(rs.conf, model.IAMRoleARNForCopySetting); roleARN != "" {
+		for _, arn := range strings.Split(roleARN, ",") {
+			if !iamRoleARNRegex.MatchString(arn) {
+				return "", fmt.Errorf("invalid IAM role ARN for COPY: %q", arn)
+			}
+		}
+		return fmt.Sprintf(`IAM_ROLE '%s'`, roleARN), nil
+	}
+	tempAccessKeyId, tempSecretAccessKey, token, err := warehouseutils.GetTemporaryS3Cred(&rs.Warehouse.Destination)
+	if err != nil {
+		return "", fmt.Errorf("getting temporary s3 credentials: %w", err)
+	}
+	return fmt.Sprintf(`ACCESS_KEY_ID '%s' SECRET_ACCESS_KEY '%s' SESSION_TOKEN '%s'`, tempAccessKeyId, tempSecretAccessKey, token), nil
+}
+
 func (rs *Redshift) useIAMForAuth() bool {
 	return rs.Warehouse.GetBoolDestinationConfig(model.UseIAMForAuthSetting)
 }
@@ -1034,13 +1234,24 @@ func (rs *Redshift) connectUsingPassword() (*sql.DB, error) {
 	return db, nil
 }
 
+// dropDanglingStagingTables drops the staging tables left behind in the staging schema, and in the namespace in case
+// the staging schema got configured after they were created there
 func (rs *Redshift) dropDanglingStagingTables(ctx context.Context) error {
+	for _, schema := range lo.Uniq([]string{rs.Namespace, rs.stagingSchema}) {
+		if err := rs.dropDanglingStagingTablesInSchema(ctx, schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rs *Redshift) dropDanglingStagingTablesInSchema(ctx context.Context, schema string) error {
 	sqlStatement := `SELECT table_name
 		FROM information_schema.tables
 		WHERE table_schema = $1 AND table_name like $2;`
 	rows, err := rs.DB.QueryContext(ctx,
 		sqlStatement,
-		rs.Namespace,
+		schema,
 		fmt.Sprintf(`%s%%`, warehouseutils.StagingTablePrefix(provider)),
 	)
 	if err != nil {
@@ -1064,9 +1275,9 @@ func (rs *Redshift) dropDanglingStagingTables(ctx context.Context) error {
 		logger.NewStringField("stagingTableNames", strings.Join(stagingTableNames, ",")),
 	)
 	for _, stagingTableName := range stagingTableNames {
-		_, err := rs.DB.ExecContext(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS "%[1]s"."%[2]s"`, rs.Namespace, stagingTableName))
+		_, err := rs.DB.ExecContext(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS "%[1]s"."%[2]s"`, schema, stagingTableName))
 		if err != nil {
-			return fmt.Errorf("dropping dangling staging table %q.%q: %w", rs.Namespace, stagingTableName, err)
+			return fmt.Errorf("dropping dangling staging table %q.%q: %w", schema, stagingTableName, err)
 		}
 	}
 	return nil
@@ -1097,6 +1308,12 @@ func (rs *Redshift) AlterColumn(ctx context.Context, tableName, columnName, colu
 		err                  error
 	)
 
+	// Spectrum tables only have varchar(65535) string columns, so there is nothing to alter
+	spectrumTable, err := rs.spectrumTableExists(ctx, tableName)
+	if err != nil || spectrumTable {
+		return model.AlterTableResponse{}, err
+	}
+
 	// Begin a transaction
 	if tx, err = rs.DB.BeginTx(ctx, &sql.TxOptions{}); err != nil {
 		return model.AlterTableResponse{}, fmt.Errorf("begin transaction: %w", err)
@@ -1110,7 +1327,7 @@ func (rs *Redshift) AlterColumn(ctx context.Context, tableName, columnName, colu
 	}()
 
 	// creating staging column
-	stagingColumnType = getRSDataType(columnType)
+	stagingColumnType = rs.dataType(columnType)
 	stagingColumnName = fmt.Sprintf(`%s-staging-%s`, columnName, misc.FastUUID().String())
 	query = fmt.Sprintf(`ALTER TABLE %q.%q ADD COLUMN %q %s;`,
 		rs.Namespace,
@@ -1252,6 +1469,12 @@ func (rs *Redshift) FetchSchema(ctx context.Context) (model.Schema, model.Schema
 		return nil, nil, fmt.Errorf("fetching schema: %w", err)
 	}
 
+	if rs.useSpectrum() {
+		if err := rs.fetchSpectrumSchema(ctx, schema, unrecognizedSchema); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	return schema, unrecognizedSchema, nil
 }
 
@@ -1270,6 +1493,11 @@ func (rs *Redshift) Setup(ctx context.Context, warehouse model.Warehouse, upload
 	rs.Namespace = warehouse.Namespace
 	rs.Uploader = uploader
 
+	rs.stagingSchema = rs.Namespace
+	if stagingSchema := warehouse.GetStringDestinationConfig(rs.conf, model.StagingSchemaSetting); stagingSchema != "" {
+		rs.stagingSchema = warehouseutils.ToSafeNamespace(warehouseutils.RS, stagingSchema)
+	}
+
 	rs.DB, err = rs.connect(ctx)
 	return err
 }
@@ -1311,6 +1539,14 @@ func (rs *Redshift) LoadUserTables(ctx context.Context) map[string]error {
 }
 
 func (rs *Redshift) LoadTable(ctx context.Context, tableName string) (*types.LoadTableStats, error) {
+	spectrumTable, err := rs.spectrumTableExists(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if spectrumTable {
+		return rs.loadSpectrumTable(ctx, tableName)
+	}
+
 	loadTableStat, _, err := rs.loadTable(
 		ctx,
 		tableName,
@@ -1345,7 +1581,7 @@ func (rs *Redshift) Connect(ctx context.Context, warehouse model.Warehouse) (cli
 }
 
 func (rs *Redshift) LoadTestTable(ctx context.Context, location, tableName string, _ map[string]interface{}, format string) (err error) {
-	tempAccessKeyId, tempSecretAccessKey, token, err := warehouseutils.GetTemporaryS3Cred(&rs.Warehouse.Destination)
+	credentials, err := rs.copyCredentials()
 	if err != nil {
 		rs.logger.Errorf("RS: Failed to create temp credentials before copying, while create load for table %v, err%v", tableName, err)
 		return
@@ -1359,22 +1595,18 @@ func (rs *Redshift) LoadTestTable(ctx context.Context, location, tableName strin
 	var sqlStatement string
 	if format == warehouseutils.LoadFileTypeParquet {
 		// copy statement for parquet load files
-		sqlStatement = fmt.Sprintf(`COPY %v FROM '%s' ACCESS_KEY_ID '%s' SECRET_ACCESS_KEY '%s' SESSION_TOKEN '%s' FORMAT PARQUET`,
+		sqlStatement = fmt.Sprintf(`COPY %v FROM '%s' %s FORMAT PARQUET`,
 			fmt.Sprintf(`%q.%q`, rs.Namespace, tableName),
 			manifestS3Location,
-			tempAccessKeyId,
-			tempSecretAccessKey,
-			token,
+			credentials,
 		)
 	} else {
 		// copy statement for csv load files
-		sqlStatement = fmt.Sprintf(`COPY %v(%v) FROM '%v' CSV GZIP ACCESS_KEY_ID '%s' SECRET_ACCESS_KEY '%s' SESSION_TOKEN '%s' REGION '%s'  DATEFORMAT 'auto' TIMEFORMAT 'auto' TRUNCATECOLUMNS EMPTYASNULL BLANKSASNULL FILLRECORD ACCEPTANYDATE TRIMBLANKS ACCEPTINVCHARS COMPUPDATE OFF STATUPDATE OFF`,
+		sqlStatement = fmt.Sprintf(`COPY %v(%v) FROM '%v' CSV GZIP %s REGION '%s'  DATEFORMAT 'auto' TIMEFORMAT 'auto' TRUNCATECOLUMNS EMPTYASNULL BLANKSASNULL FILLRECORD ACCEPTANYDATE TRIMBLANKS ACCEPTINVCHARS COMPUPDATE OFF STATUPDATE OFF`,
 			fmt.Sprintf(`%q.%q`, rs.Namespace, tableName),
 			fmt.Sprintf(`%q, %q`, "id", "val"),
 			manifestS3Location,
-			tempAccessKeyId,
-			tempSecretAccessKey,
-			token,
+			credentials,
 			region,
 		)
 	}
@@ -14,6 +14,7 @@ import (
 
 	"go.uber.org/mock/gomock"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/lib/pq"
 	"github.com/ory/dockertest/v3"
 	"github.com/stretchr/testify/require"
@@ -1638,6 +1639,113 @@ func TestRedshift_AlterColumn(t *testing.T) {
 	}
 }
 
+func TestRedshift_Spectrum(t *testing.T) {
+	ctx := context.Background()
+
+	warehouse := model.Warehouse{
+		Type:      whutils.RS,
+		Namespace: "namespace",
+		Destination: backendconfig.DestinationT{
+			ID: "destination_id",
+			Config: map[string]any{
+				model.UseSpectrumSetting.String():     true,
+				model.SpectrumIAMRoleSetting.String(): "arn:aws:iam::123456789012:role/spectrum",
+			},
+		},
+	}
+	loadFiles := []whutils.LoadFile{
+		{Location: "https://bucket.s3.amazonaws.com/rudder-warehouse-load-objects/tracks/source_id/load_gen_id-tracks/1.parquet"},
+		{Location: "https://bucket.s3.amazonaws.com/rudder-warehouse-load-objects/tracks/source_id/load_gen_id-tracks/2.parquet"},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockUploader := mockuploader.NewMockUploader(ctrl)
+	mockUploader.EXPECT().UseRudderStorage().Return(false).AnyTimes()
+	mockUploader.EXPECT().GetLoadFileType().Return(whutils.LoadFileTypeParquet).AnyTimes()
+	mockUploader.EXPECT().GetSampleLoadFileLocation(gomock.Any(), "tracks").Return(loadFiles[0].Location, nil).AnyTimes()
+	mockUploader.EXPECT().GetLoadFilesMetadata(gomock.Any(), gomock.Any()).Return(loadFiles, nil).AnyTimes()
+	mockUploader.EXPECT().GetLoadFileGenStartTIme().Return(time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)).AnyTimes()
+
+	db, dbMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	rs := redshift.New(config.New(), logger.NOP, stats.NOP)
+	rs.Warehouse = warehouse
+	rs.Namespace = warehouse.Namespace
+	rs.Uploader = mockUploader
+	rs.DB = sqlmiddleware.New(db)
+
+	t.Run("create table", func(t *testing.T) {
+		dbMock.ExpectExec(`CREATE EXTERNAL SCHEMA IF NOT EXISTS "namespace_spectrum" FROM DATA CATALOG DATABASE 'namespace_spectrum' IAM_ROLE 'arn:aws:iam::123456789012:role/spectrum' CREATE EXTERNAL DATABASE IF NOT EXISTS;`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		dbMock.ExpectExec(`CREATE EXTERNAL TABLE "namespace_spectrum"."tracks" ( "context_traits" varchar(65535),"id" varchar(65535),"received_at" timestamp ) PARTITIONED BY ("rudder_loaded_on" date, "rudder_load_id" varchar(64)) STORED AS PARQUET LOCATION 's3://bucket/rudder-warehouse-load-objects/tracks/source_id/';`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		require.NoError(t, rs.CreateTable(ctx, "tracks", model.TableSchema{
+			"id":             "string",
+			"received_at":    "datetime",
+			"context_traits": "json",
+		}))
+		require.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("users table stays native", func(t *testing.T) {
+		dbMock.ExpectExec(`CREATE TABLE IF NOT EXISTS "namespace"."users" ( "id" varchar(65535) ) DISTSTYLE KEY DISTKEY("id") SORTKEY("id") `).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		require.NoError(t, rs.CreateTable(ctx, "users", model.TableSchema{"id": "string"}))
+		require.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("load table", func(t *testing.T) {
+		dbMock.ExpectQuery(`SELECT EXISTS (SELECT 1 FROM SVV_EXTERNAL_TABLES WHERE schemaname = $1 AND tablename = $2);`).
+			WithArgs("namespace_spectrum", "tracks").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		dbMock.ExpectExec(`ALTER TABLE "namespace_spectrum"."tracks" ADD IF NOT EXISTS PARTITION (rudder_loaded_on = '2024-10-01', rudder_load_id = 'load_gen_id-tracks') LOCATION 's3://bucket/rudder-warehouse-load-objects/tracks/source_id/load_gen_id-tracks/';`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		loadTableStat, err := rs.LoadTable(ctx, "tracks")
+		require.NoError(t, err)
+		require.Zero(t, loadTableStat.RowsInserted)
+		require.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("fetch schema", func(t *testing.T) {
+		dbMock.ExpectQuery(`SELECT
+		  table_name,
+		  column_name,
+		  data_type,
+		  character_maximum_length
+		FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE table_schema = $1 and table_name not like $2;`).
+			WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "data_type", "character_maximum_length"}).
+				AddRow("users", "id", "character varying", 65535),
+			)
+		dbMock.ExpectQuery(`SELECT tablename, columnname, external_type FROM SVV_EXTERNAL_COLUMNS WHERE schemaname = $1 AND part_key = 0;`).
+			WithArgs("namespace_spectrum").
+			WillReturnRows(sqlmock.NewRows([]string{"tablename", "columnname", "external_type"}).
+				AddRow("tracks", "id", "varchar(65535)").
+				AddRow("tracks", "received_at", "timestamp").
+				AddRow("tracks", "context_screen_density", "double").
+				AddRow("tracks", "context_geo", "struct<lat:double>"),
+			)
+
+		schema, unrecognizedSchema, err := rs.FetchSchema(ctx)
+		require.NoError(t, err)
+		require.Equal(t, model.Schema{
+			"users": {"id": "text"},
+			"tracks": {
+				"id":                     "text",
+				"received_at":            "datetime",
+				"context_screen_density": "float",
+			},
+		}, schema)
+		require.Equal(t, model.Schema{"tracks": {"context_geo": whutils.MissingDatatype}}, unrecognizedSchema)
+		require.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
 func newMockUploader(
 	t testing.TB,
 	loadFiles []whutils.LoadFile,
@@ -0,0 +1,260 @@
+package redshift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/types"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	"github.com/rudderlabs/rudder-server/warehouse/logfield"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+// In spectrum mode the event tables are Redshift Spectrum external tables over the parquet load files in S3, so that
+// they don't use the storage of the cluster. Loading registers the load files of the upload as a partition of the
+// table instead of copying them. Existing native tables, and the tables which are merged or read while loading the
+// users, are kept native.
+
+const (
+	spectrumSchemaSuffix   = "_spectrum"
+	spectrumLoadedOnColumn = "rudder_loaded_on"
+	spectrumLoadIDColumn   = "rudder_load_id"
+)
+
+var spectrumDataTypesMap = map[string]string{
+	"boolean":  "boolean",
+	"int":      "bigint",
+	"bigint":   "bigint",
+	"float":    "double precision",
+	"string":   "varchar(65535)",
+	"text":     "varchar(65535)",
+	"datetime": "timestamp",
+	"json":     "varchar(65535)",
+}
+
+var spectrumDataTypesMapToRudder = map[string]string{
+	"smallint":         "int",
+	"int":              "int",
+	"integer":          "int",
+	"bigint":           "int",
+	"real":             "float",
+	"float":            "float",
+	"double":           "float",
+	"double precision": "float",
+	"boolean":          "boolean",
+	"date":             "datetime",
+	"timestamp":        "datetime",
+}
+
+var nativeTables = map[string]struct{}{
+	warehouseutils.UsersTable:              {},
+	warehouseutils.IdentifiesTable:         {},
+	warehouseutils.DiscardsTable:           {},
+	warehouseutils.IdentityMergeRulesTable: {},
+	warehouseutils.IdentityMappingsTable:   {},
+}
+
+func (rs *Redshift) useSpectrum() bool {
+	return rs.Warehouse.GetBoolDestinationConfig(model.UseSpectrumSetting)
+}
+
+// spectrumSchema returns the external schema of the spectrum tables, which is also the name of their database in the
+// data catalog
+func (rs *Redshift) spectrumSchema() string {
+	if schema := rs.Warehouse.GetStringDestinationConfig(rs.conf, model.SpectrumSchemaSetting); schema != "" {
+		return strings.ToLower(schema)
+	}
+	return strings.ToLower(rs.Namespace) + spectrumSchemaSuffix
+}
+
+// createAsSpectrumTable returns whether a new table is created as a spectrum table
+func (rs *Redshift) createAsSpectrumTable(tableName string) bool {
+	if !rs.useSpectrum() || rs.Uploader.GetLoadFileType() != warehouseutils.LoadFileTypeParquet {
+		return false
+	}
+	_, native := nativeTables[tableName]
+	return !native
+}
+
+func (rs *Redshift) spectrumTableExists(ctx context.Context, tableName string) (bool, error) {
+	if !rs.useSpectrum() {
+		return false, nil
+	}
+
+	var exists bool
+	err := rs.DB.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM SVV_EXTERNAL_TABLES WHERE schemaname = $1 AND tablename = $2);`,
+		rs.spectrumSchema(),
+		tableName,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("checking if spectrum table exists: %w", err)
+	}
+	return exists, nil
+}
+
+func spectrumColumnsWithDataTypes(columns model.TableSchema) string {
+	keys := lo.Keys(columns)
+	sort.Strings(keys)
+
+	return warehouseutils.JoinWithFormatting(keys, func(_ int, name string) string {
+		return fmt.Sprintf(`%q %s`, name, spectrumDataTypesMap[columns[name]])
+	}, ",")
+}
+
+func (rs *Redshift) createSpectrumSchema(ctx context.Context) error {
+	iamRole := rs.Warehouse.GetStringDestinationConfig(rs.conf, model.SpectrumIAMRoleSetting)
+	if iamRole == "" {
+		return errors.New("spectrum IAM role is not configured")
+	}
+
+	sqlStatement := fmt.Sprintf(`CREATE EXTERNAL SCHEMA IF NOT EXISTS %[1]q FROM DATA CATALOG DATABASE '%[1]s' IAM_ROLE '%[2]s' CREATE EXTERNAL DATABASE IF NOT EXISTS;`,
+		rs.spectrumSchema(),
+		iamRole,
+	)
+	rs.logger.Infof("Creating spectrum schema in redshift for RS:%s : %v", rs.Warehouse.Destination.ID, sqlStatement)
+	_, err := rs.DB.ExecContext(ctx, sqlStatement)
+	return err
+}
+
+// createSpectrumTable creates the external table at the folder of the load files of the table, partitioned by the
+// load files of the uploads
+func (rs *Redshift) createSpectrumTable(ctx context.Context, tableName string, columns model.TableSchema) error {
+	if rs.Uploader.UseRudderStorage() {
+		return errors.New("spectrum tables are not supported with RudderStack storage")
+	}
+	if err := rs.createSpectrumSchema(ctx); err != nil {
+		return fmt.Errorf("creating spectrum schema: %w", err)
+	}
+
+	sampleLocation, err := rs.Uploader.GetSampleLoadFileLocation(ctx, tableName)
+	if err != nil {
+		return fmt.Errorf("getting sample load file location: %w", err)
+	}
+	// load files are at <loadObjectFolder>/<tableName>/<sourceID>/<loadGenID>-<tableName>/<file>
+	loadFolder := warehouseutils.GetS3LocationFolder(sampleLocation)
+	location := loadFolder[:strings.LastIndex(loadFolder, "/")+1]
+
+	sqlStatement := fmt.Sprintf(`CREATE EXTERNAL TABLE %q.%q ( %s ) PARTITIONED BY (%q date, %q varchar(64)) STORED AS PARQUET LOCATION '%s';`,
+		rs.spectrumSchema(),
+		tableName,
+		spectrumColumnsWithDataTypes(columns),
+		spectrumLoadedOnColumn,
+		spectrumLoadIDColumn,
+		location,
+	)
+	rs.logger.Infof("Creating spectrum table in redshift for RS:%s : %v", rs.Warehouse.Destination.ID, sqlStatement)
+	_, err = rs.DB.ExecContext(ctx, sqlStatement)
+	return err
+}
+
+// loadSpectrumTable registers the folders of the load files of the table as partitions. Spectrum tables are append
+// only, and the number of rows loaded is not known without scanning the files.
+func (rs *Redshift) loadSpectrumTable(ctx context.Context, tableName string) (*types.LoadTableStats, error) {
+	log := rs.logger.With(
+		logfield.SourceID, rs.Warehouse.Source.ID,
+		logfield.DestinationID, rs.Warehouse.Destination.ID,
+		logfield.WorkspaceID, rs.Warehouse.WorkspaceID,
+		logfield.Namespace, rs.spectrumSchema(),
+		logfield.TableName, tableName,
+	)
+	log.Infow("started loading spectrum table")
+
+	if loadFileType := rs.Uploader.GetLoadFileType(); loadFileType != warehouseutils.LoadFileTypeParquet {
+		return nil, fmt.Errorf("spectrum tables need parquet load files, got %s", loadFileType)
+	}
+
+	metadata, err := rs.Uploader.GetLoadFilesMetadata(ctx, warehouseutils.GetLoadFilesOptions{Table: tableName})
+	if err != nil {
+		return nil, fmt.Errorf("getting load files metadata: %w", err)
+	}
+	folders := lo.Uniq(lo.Map(metadata, func(loadFile warehouseutils.LoadFile, _ int) string {
+		return warehouseutils.GetS3LocationFolder(loadFile.Location)
+	}))
+
+	loadedOn := rs.Uploader.GetLoadFileGenStartTIme()
+	if loadedOn.IsZero() {
+		loadedOn = time.Now()
+	}
+
+	for _, folder := range folders {
+		sqlStatement := fmt.Sprintf(`ALTER TABLE %q.%q ADD IF NOT EXISTS PARTITION (%s = '%s', %s = '%s') LOCATION '%s/';`,
+			rs.spectrumSchema(),
+			tableName,
+			spectrumLoadedOnColumn,
+			loadedOn.UTC().Format(time.DateOnly),
+			spectrumLoadIDColumn,
+			path.Base(folder),
+			folder,
+		)
+		log.Debugw("registering partition", logfield.Query, sqlStatement)
+
+		if _, err := rs.DB.ExecContext(ctx, sqlStatement); err != nil {
+			return nil, fmt.Errorf("registering partition: %w", normalizeError(err))
+		}
+	}
+
+	log.Infow("completed loading spectrum table", "partitions", len(folders))
+	return &types.LoadTableStats{}, nil
+}
+
+// fetchSpectrumSchema adds the columns of the spectrum tables, except the partition columns, to the schema
+func (rs *Redshift) fetchSpectrumSchema(ctx context.Context, schema, unrecognizedSchema model.Schema) error {
+	rows, err := rs.DB.QueryContext(ctx,
+		`SELECT tablename, columnname, external_type FROM SVV_EXTERNAL_COLUMNS WHERE schemaname = $1 AND part_key = 0;`,
+		rs.spectrumSchema(),
+	)
+	if err != nil {
+		return fmt.Errorf("fetching spectrum schema: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var tableName, columnName, columnType string
+		if err := rows.Scan(&tableName, &columnName, &columnType); err != nil {
+			return fmt.Errorf("scanning spectrum schema: %w", err)
+		}
+
+		if _, ok := schema[tableName]; !ok {
+			schema[tableName] = make(model.TableSchema)
+		}
+		if datatype, ok := calculateSpectrumDataType(columnType); ok {
+			schema[tableName][columnName] = datatype
+		} else {
+			if _, ok := unrecognizedSchema[tableName]; !ok {
+				unrecognizedSchema[tableName] = make(model.TableSchema)
+			}
+			unrecognizedSchema[tableName][columnName] = warehouseutils.MissingDatatype
+
+			warehouseutils.WHCounterStat(rs.stats, warehouseutils.RudderMissingDatatype, &rs.Warehouse, warehouseutils.Tag{Name: "datatype", Value: columnType}).Count(1)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("fetching spectrum schema: %w", err)
+	}
+	return nil
+}
+
+func calculateSpectrumDataType(columnType string) (string, bool) {
+	columnType = strings.ToLower(columnType)
+	if length, ok := strings.CutPrefix(columnType, "varchar("); ok {
+		charLength, err := strconv.Atoi(strings.TrimSuffix(length, ")"))
+		if err != nil {
+			return "", false
+		}
+		if charLength > rudderStringLength {
+			return "text", true
+		}
+		return "string", true
+	}
+	datatype, ok := spectrumDataTypesMapToRudder[columnType]
+	return datatype, ok
+}
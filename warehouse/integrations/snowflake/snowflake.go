@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"regexp"
 	"slices"
 	"sort"
@@ -27,6 +28,7 @@ import (
 
 	"github.com/rudderlabs/rudder-server/utils/misc"
 	"github.com/rudderlabs/rudder-server/warehouse/client"
+	integrationsconfig "github.com/rudderlabs/rudder-server/warehouse/integrations/config"
 	sqlmw "github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/types"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
@@ -147,6 +149,7 @@ type Snowflake struct {
 	conf           *config.Config
 	logger         logger.Logger
 	stats          stats.Stats
+	snowpipeClient *http.Client
 
 	config struct {
 		allowMerge         bool
@@ -158,6 +161,11 @@ type Snowflake struct {
 		debugDuplicateTables         []string
 		debugDuplicateIntervalInDays int
 		debugDuplicateLimit          int
+
+		snowpipeURL string
+
+		decimalPrecision int
+		decimalScale     int
 	}
 }
 
@@ -168,9 +176,13 @@ func New(conf *config.Config, log logger.Logger, stat stats.Stats) *Snowflake {
 		stats:  stat,
 	}
 
+	sf.snowpipeClient = &http.Client{Timeout: conf.GetDuration("Warehouse.snowflake.snowpipe.timeout", 30, time.Second)}
+	sf.config.snowpipeURL = conf.GetString("Warehouse.snowflake.snowpipe.url", "")
+
 	sf.config.allowMerge = conf.GetBool("Warehouse.snowflake.allowMerge", true)
 	sf.config.enableDeleteByJobs = conf.GetBool("Warehouse.snowflake.enableDeleteByJobs", false)
 	sf.config.slowQueryThreshold = conf.GetDuration("Warehouse.snowflake.slowQueryThreshold", 5, time.Minute)
+	sf.config.decimalPrecision, sf.config.decimalScale = integrationsconfig.DecimalPrecisionAndScale(conf)
 
 	// appendOnlyTables is a workaround introduced for Mattermost for now. It is only supported for snowflake.
 	sf.config.appendOnlyTables = conf.GetStringSlice("Warehouse.snowflake.appendOnlyTables", nil)
@@ -185,14 +197,26 @@ func New(conf *config.Config, log logger.Logger, stat stats.Stats) *Snowflake {
 	return sf
 }
 
-func ColumnsWithDataTypes(columns model.TableSchema, prefix string) string {
+func (sf *Snowflake) ColumnsWithDataTypes(columns model.TableSchema, prefix string) string {
 	var arr []string
 	for name, dataType := range columns {
-		arr = append(arr, fmt.Sprintf(`"%s%s" %s`, prefix, name, dataTypesMap[dataType]))
+		arr = append(arr, fmt.Sprintf(`"%s%s" %s`, prefix, name, sf.dataType(dataType)))
 	}
 	return strings.Join(arr, ",")
 }
 
+// dataType returns the snowflake data type of the rudder data type, creating the decimal columns with the configured
+// precision and scale, and the datetime columns without a time zone if configured for the destination
+func (sf *Snowflake) dataType(dataType string) string {
+	switch {
+	case dataType == model.DecimalDataType:
+		return fmt.Sprintf("number(%d,%d)", sf.config.decimalPrecision, sf.config.decimalScale)
+	case dataType == model.DateTimeDataType && sf.Warehouse.GetTimestampTypeSetting(sf.conf) == model.TimestampTypeWithoutTimeZone:
+		return "timestamp_ntz"
+	}
+	return dataTypesMap[dataType]
+}
+
 // schemaIdentifier returns [DATABASE_NAME].[NAMESPACE] format to access the schema directly.
 func (sf *Snowflake) schemaIdentifier() string {
 	return fmt.Sprintf(`%q`,
@@ -204,7 +228,7 @@ func (sf *Snowflake) createTable(ctx context.Context, tableName string, columns
 	schemaIdentifier := sf.schemaIdentifier()
 	sqlStatement := fmt.Sprintf(
 		`CREATE TABLE IF NOT EXISTS %s.%q ( %v )`,
-		schemaIdentifier, tableName, ColumnsWithDataTypes(columns, ""),
+		schemaIdentifier, tableName, sf.ColumnsWithDataTypes(columns, ""),
 	)
 	sf.logger.Infow("Creating table in snowflake",
 		lf.DestinationID, sf.Warehouse.Destination.ID,
@@ -215,29 +239,29 @@ func (sf *Snowflake) createTable(ctx context.Context, tableName string, columns
 }
 
 func (sf *Snowflake) tableExists(ctx context.Context, tableName string) (exists bool, err error) {
-	sqlStatement := fmt.Sprintf(`SELECT EXISTS ( SELECT 1
+	sqlStatement := `SELECT EXISTS ( SELECT 1
    								 FROM   information_schema.tables
-   								 WHERE  table_schema = '%s'
-   								 AND    table_name = '%s'
-								   )`, sf.Namespace, tableName)
-	err = sf.DB.QueryRowContext(ctx, sqlStatement).Scan(&exists)
+   								 WHERE  table_schema = ?
+   								 AND    table_name = ?
+								   )`
+	err = sf.DB.QueryRowContext(ctx, sqlStatement, sf.Namespace, tableName).Scan(&exists)
 	return
 }
 
 func (sf *Snowflake) columnExists(ctx context.Context, columnName, tableName string) (exists bool, err error) {
-	sqlStatement := fmt.Sprintf(`SELECT EXISTS ( SELECT 1
+	sqlStatement := `SELECT EXISTS ( SELECT 1
    								 FROM   information_schema.columns
-   								 WHERE  table_schema = '%s'
-									AND table_name = '%s'
-									AND column_name = '%s'
-								   )`, sf.Namespace, tableName, columnName)
-	err = sf.DB.QueryRowContext(ctx, sqlStatement).Scan(&exists)
+   								 WHERE  table_schema = ?
+									AND table_name = ?
+									AND column_name = ?
+								   )`
+	err = sf.DB.QueryRowContext(ctx, sqlStatement, sf.Namespace, tableName, columnName).Scan(&exists)
 	return
 }
 
 func (sf *Snowflake) schemaExists(ctx context.Context) (exists bool, err error) {
-	sqlStatement := fmt.Sprintf("SELECT EXISTS ( SELECT 1 FROM INFORMATION_SCHEMA.SCHEMATA WHERE SCHEMA_NAME = '%s' )", sf.Namespace)
-	r := sf.DB.QueryRowContext(ctx, sqlStatement)
+	sqlStatement := "SELECT EXISTS ( SELECT 1 FROM INFORMATION_SCHEMA.SCHEMATA WHERE SCHEMA_NAME = ? )"
+	r := sf.DB.QueryRowContext(ctx, sqlStatement, sf.Namespace)
 	err = r.Scan(&exists)
 	// ignore err if no results for query
 	if errors.Is(err, sql.ErrNoRows) {
@@ -312,6 +336,15 @@ func (sf *Snowflake) DeleteBy(ctx context.Context, tableNames []string, params w
 	return nil
 }
 
+// DeleteLoadedRows deletes the rows of the table loaded by the upload, see [whutils.LoadIDPattern]
+func (sf *Snowflake) DeleteLoadedRows(ctx context.Context, tableName string, uploadID int64) error {
+	_, err := sf.DB.ExecContext(ctx,
+		`DELETE FROM "`+sf.Namespace+`"."`+tableName+`" WHERE "`+whutils.ToProviderCase(whutils.SNOWFLAKE, whutils.LoadIDColumn)+`" LIKE ?;`,
+		whutils.LoadIDPattern(uploadID),
+	)
+	return err
+}
+
 func (sf *Snowflake) loadTable(
 	ctx context.Context,
 	tableName string,
@@ -1101,6 +1134,7 @@ func (sf *Snowflake) connect(ctx context.Context, opts optionalCreds) (*sqlmw.DB
 			"AWS_SECRET_KEY='[^']*'": "AWS_SECRET_KEY='***'",
 			"AWS_TOKEN='[^']*'":      "AWS_TOKEN='***'",
 		}),
+		sqlmw.WithQueryTagContext(snowflake.WithQueryTag),
 	)
 	return middleware, nil
 }
@@ -1138,6 +1172,29 @@ func (sf *Snowflake) DropTable(ctx context.Context, tableName string) (err error
 	return
 }
 
+// RenameNamespace renames the namespace, see [manager.Renamer]
+func (sf *Snowflake) RenameNamespace(ctx context.Context, newNamespace string) error {
+	sqlStatement := fmt.Sprintf(`ALTER SCHEMA %s RENAME TO %q`, sf.schemaIdentifier(), newNamespace)
+	sf.logger.Infow("Renaming schema in snowflake",
+		lf.DestinationID, sf.Warehouse.Destination.ID,
+		lf.Query, sqlStatement,
+	)
+	_, err := sf.DB.ExecContext(ctx, sqlStatement)
+	return err
+}
+
+// RenameTable renames the table of the namespace, see [manager.Renamer]
+func (sf *Snowflake) RenameTable(ctx context.Context, tableName, newTableName string) error {
+	schemaIdentifier := sf.schemaIdentifier()
+	sqlStatement := fmt.Sprintf(`ALTER TABLE %[1]s.%[2]q RENAME TO %[1]s.%[3]q`, schemaIdentifier, tableName, newTableName)
+	sf.logger.Infow("Renaming table in snowflake",
+		lf.DestinationID, sf.Warehouse.Destination.ID,
+		lf.Query, sqlStatement,
+	)
+	_, err := sf.DB.ExecContext(ctx, sqlStatement)
+	return err
+}
+
 func (sf *Snowflake) AddColumns(ctx context.Context, tableName string, columnsInfo []whutils.ColumnInfo) (err error) {
 	var (
 		query            string
@@ -1154,7 +1211,7 @@ func (sf *Snowflake) AddColumns(ctx context.Context, tableName string, columnsIn
 	))
 
 	for _, columnInfo := range columnsInfo {
-		queryBuilder.WriteString(fmt.Sprintf(` %q %s,`, columnInfo.Name, dataTypesMap[columnInfo.Type]))
+		queryBuilder.WriteString(fmt.Sprintf(` %q %s,`, columnInfo.Name, sf.dataType(columnInfo.Type)))
 	}
 
 	query = strings.TrimSuffix(queryBuilder.String(), ",") + ";"
@@ -1417,6 +1474,9 @@ func (sf *Snowflake) Cleanup(context.Context) {
 }
 
 func (sf *Snowflake) LoadTable(ctx context.Context, tableName string) (*types.LoadTableStats, error) {
+	if sf.useSnowpipe(tableName) {
+		return sf.loadTableWithSnowpipe(ctx, tableName, sf.Uploader.GetTableSchemaInUpload(tableName))
+	}
 	loadTableStat, _, err := sf.loadTable(
 		ctx,
 		tableName,
@@ -0,0 +1,340 @@
+package snowflake
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"github.com/youmark/pkcs8"
+
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/types"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	lf "github.com/rudderlabs/rudder-server/warehouse/logfield"
+	whutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+// In snowpipe mode the load files of the tables which are not merged are ingested by Snowpipe instead of being copied
+// using the warehouse: loading a table submits its load files to the pipe of the table through the Snowpipe REST API,
+// and the upload confirms their ingestion later from the copy history of the table.
+
+const (
+	snowpipeStagePrefix = "RUDDER_SNOWPIPE_STAGE_"
+	snowpipePipePrefix  = "RUDDER_PIPE_"
+	snowpipeMaxFiles    = 5000
+	snowpipeJWTLifetime = 59 * time.Minute
+
+	// load files are at <loadObjectFolder>/<tableName>/<sourceID>/<loadGenID>-<tableName>/<file>
+	loadFilePathSegments = 4
+
+	copyStatusLoaded          = "loaded"
+	copyStatusLoadFailed      = "load failed"
+	copyStatusPartiallyLoaded = "partially loaded"
+	copyStatusLoadSkipped     = "load skipped"
+)
+
+func (sf *Snowflake) useSnowpipe(tableName string) bool {
+	return sf.Warehouse.GetBoolDestinationConfig(model.UseSnowpipeSetting) && !sf.ShouldMerge(tableName)
+}
+
+// snowpipeFiles returns the url of the stage of the load files and their paths relative to it
+func (sf *Snowflake) snowpipeFiles(loadFiles []whutils.LoadFile) (string, []string, error) {
+	var (
+		stageURL string
+		paths    = make([]string, 0, len(loadFiles))
+	)
+	for _, loadFile := range loadFiles {
+		objectLocation := whutils.GetObjectLocation(sf.ObjectStorage, loadFile.Location)
+
+		idx := len(objectLocation)
+		for i := 0; i < loadFilePathSegments; i++ {
+			idx = strings.LastIndex(objectLocation[:idx], "/")
+			if idx == -1 {
+				return "", nil, fmt.Errorf("unexpected load file location: %s", loadFile.Location)
+			}
+		}
+		fileStageURL, path := objectLocation[:idx+1], objectLocation[idx+1:]
+		if stageURL != "" && stageURL != fileStageURL {
+			return "", nil, fmt.Errorf("load files are in different stages: %s and %s", stageURL, fileStageURL)
+		}
+		stageURL = fileStageURL
+		paths = append(paths, path)
+	}
+	return stageURL, paths, nil
+}
+
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:4]))
+}
+
+// createPipe creates the stage of the load files and the pipe copying them into the table. The pipe is named after
+// the stage and the columns, so that a new pipe is created when the columns of the table change.
+func (sf *Snowflake) createPipe(ctx context.Context, tableName, stageURL string, columns []string) (string, error) {
+	storageIntegration := sf.Warehouse.GetStringDestinationConfig(sf.conf, model.StorageIntegrationSetting)
+	if storageIntegration == "" || sf.Uploader.UseRudderStorage() {
+		return "", errors.New("snowpipe needs a storage integration to the bucket of the destination")
+	}
+
+	schemaIdentifier := sf.schemaIdentifier()
+	stageName := snowpipeStagePrefix + shortHash(stageURL)
+	pipeName := fmt.Sprintf("%s%s_%s", snowpipePipePrefix, tableName, shortHash(stageName+":"+strings.Join(columns, ",")))
+
+	createStageStmt := fmt.Sprintf(`CREATE STAGE IF NOT EXISTS %s.%q URL = '%s' STORAGE_INTEGRATION = %s;`,
+		schemaIdentifier,
+		stageName,
+		stageURL,
+		storageIntegration,
+	)
+	if _, err := sf.DB.ExecContext(ctx, createStageStmt); err != nil {
+		return "", fmt.Errorf("creating stage: %w", err)
+	}
+
+	createPipeStmt := fmt.Sprintf(
+		`CREATE PIPE IF NOT EXISTS %[1]s.%[2]q AS
+		COPY INTO
+			%[1]s.%[3]q(%[4]s)
+		FROM
+			@%[1]s.%[5]q
+		FILE_FORMAT = ( TYPE = csv FIELD_OPTIONALLY_ENCLOSED_BY = '"' ESCAPE_UNENCLOSED_FIELD = NONE)
+		TRUNCATECOLUMNS = TRUE;`,
+		schemaIdentifier,
+		pipeName,
+		tableName,
+		sf.joinColumnsWithFormatting(columns, "%q"),
+		stageName,
+	)
+	if _, err := sf.DB.ExecContext(ctx, createPipeStmt); err != nil {
+		return "", fmt.Errorf("creating pipe: %w", err)
+	}
+	return pipeName, nil
+}
+
+// loadTableWithSnowpipe submits the load files of the table to its pipe. The table is loaded once ConfirmIngestion
+// reports the files as ingested.
+func (sf *Snowflake) loadTableWithSnowpipe(ctx context.Context, tableName string, tableSchemaInUpload model.TableSchema) (*types.LoadTableStats, error) {
+	log := sf.logger.With(
+		lf.SourceID, sf.Warehouse.Source.ID,
+		lf.DestinationID, sf.Warehouse.Destination.ID,
+		lf.WorkspaceID, sf.Warehouse.WorkspaceID,
+		lf.Namespace, sf.Namespace,
+		lf.TableName, tableName,
+	)
+	log.Infow("started submitting load files to snowpipe")
+
+	loadFiles, err := sf.Uploader.GetLoadFilesMetadata(ctx, whutils.GetLoadFilesOptions{Table: tableName})
+	if err != nil {
+		return nil, fmt.Errorf("getting load files metadata: %w", err)
+	}
+	stageURL, paths, err := sf.snowpipeFiles(loadFiles)
+	if err != nil {
+		return nil, fmt.Errorf("getting snowpipe files: %w", err)
+	}
+	if len(paths) == 0 {
+		return &types.LoadTableStats{}, nil
+	}
+
+	pipeName, err := sf.createPipe(ctx, tableName, stageURL, sf.getSortedColumnsFromTableSchema(tableSchemaInUpload))
+	if err != nil {
+		return nil, err
+	}
+	if err := sf.insertFiles(ctx, pipeName, paths); err != nil {
+		return nil, fmt.Errorf("submitting load files to snowpipe: %w", err)
+	}
+
+	log.Infow("completed submitting load files to snowpipe", "pipe", pipeName, "files", len(paths))
+	return &types.LoadTableStats{Ingesting: true}, nil
+}
+
+// insertFiles submits the files to the pipe using the insertFiles endpoint of the Snowpipe REST API
+func (sf *Snowflake) insertFiles(ctx context.Context, pipeName string, paths []string) error {
+	var (
+		account  = sf.Warehouse.GetStringDestinationConfig(sf.conf, model.AccountSetting)
+		database = sf.Warehouse.GetStringDestinationConfig(sf.conf, model.DatabaseSetting)
+	)
+	if !sf.Warehouse.GetBoolDestinationConfig(model.UseKeyPairAuthSetting) {
+		return errors.New("snowpipe needs key pair authentication")
+	}
+	token, err := snowpipeJWT(
+		account,
+		sf.Warehouse.GetStringDestinationConfig(sf.conf, model.UserSetting),
+		sf.Warehouse.GetStringDestinationConfig(sf.conf, model.PrivateKeySetting),
+		sf.Warehouse.GetStringDestinationConfig(sf.conf, model.PrivateKeyPassphraseSetting),
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("creating jwt: %w", err)
+	}
+
+	baseURL := sf.config.snowpipeURL
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("https://%s.snowflakecomputing.com", account)
+	}
+	pipe := url.PathEscape(fmt.Sprintf("%s.%s.%s", database, sf.Namespace, pipeName))
+
+	for _, chunk := range lo.Chunk(paths, snowpipeMaxFiles) {
+		body, err := json.Marshal(map[string]any{
+			"files": lo.Map(chunk, func(path string, _ int) map[string]string {
+				return map[string]string{"path": path}
+			}),
+		})
+		if err != nil {
+			return fmt.Errorf("marshalling files: %w", err)
+		}
+
+		reqURL := fmt.Sprintf("%s/v1/data/pipes/%s/insertFiles?requestId=%s", baseURL, pipe, uuid.NewString())
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-Snowflake-Authorization-Token-Type", "KEYPAIR_JWT")
+
+		resp, err := sf.snowpipeClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("sending request: %w", err)
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		_ = resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, respBody)
+		}
+	}
+	return nil
+}
+
+// snowpipeJWT returns the key pair JWT authenticating the user against the Snowpipe REST API
+func snowpipeJWT(account, user, privateKey, passphrase string, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return "", errors.New("decoding private key: no PEM data found")
+	}
+	var (
+		key *rsa.PrivateKey
+		err error
+	)
+	if passphrase != "" {
+		key, err = pkcs8.ParsePKCS8PrivateKeyRSA(block.Bytes, []byte(passphrase))
+	} else {
+		var parsed any
+		if parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+			var ok bool
+			if key, ok = parsed.(*rsa.PrivateKey); !ok {
+				err = errors.New("private key is not an RSA key")
+			}
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("parsing private key: %w", err)
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("marshalling public key: %w", err)
+	}
+	fingerprint := sha256.Sum256(publicKey)
+
+	// the account identifier in the JWT excludes the region and the cloud of account locators
+	accountIdentifier, _, _ := strings.Cut(account, ".")
+	qualifiedUser := strings.ToUpper(accountIdentifier) + "." + strings.ToUpper(user)
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("marshalling header: %w", err)
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iss": qualifiedUser + ".SHA256:" + base64.StdEncoding.EncodeToString(fingerprint[:]),
+		"sub": qualifiedUser,
+		"iat": now.Unix(),
+		"exp": now.Add(snowpipeJWTLifetime).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshalling claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("signing jwt: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// ConfirmIngestion returns whether snowpipe has ingested all the load files of the table, from its copy history
+func (sf *Snowflake) ConfirmIngestion(ctx context.Context, tableName string) (bool, error) {
+	loadFiles, err := sf.Uploader.GetLoadFilesMetadata(ctx, whutils.GetLoadFilesOptions{Table: tableName})
+	if err != nil {
+		return false, fmt.Errorf("getting load files metadata: %w", err)
+	}
+	_, paths, err := sf.snowpipeFiles(loadFiles)
+	if err != nil {
+		return false, fmt.Errorf("getting snowpipe files: %w", err)
+	}
+	if len(paths) == 0 {
+		return true, nil
+	}
+
+	startTime := sf.Uploader.GetLoadFileGenStartTIme()
+	if startTime.IsZero() {
+		startTime = time.Now().Add(-24 * time.Hour)
+	}
+	query := fmt.Sprintf(`SELECT FILE_NAME, STATUS, COALESCE(FIRST_ERROR_MESSAGE, '') FROM TABLE(INFORMATION_SCHEMA.COPY_HISTORY(TABLE_NAME => '%s.%s', START_TIME => TO_TIMESTAMP_LTZ(%d)));`,
+		sf.Namespace,
+		tableName,
+		startTime.Unix(),
+	)
+	rows, err := sf.DB.QueryContext(ctx, query)
+	if err != nil {
+		return false, fmt.Errorf("querying copy history: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	type fileStatus struct {
+		status, firstError string
+	}
+	statuses := make(map[string]fileStatus)
+	for rows.Next() {
+		var fileName, status, firstError string
+		if err := rows.Scan(&fileName, &status, &firstError); err != nil {
+			return false, fmt.Errorf("scanning copy history: %w", err)
+		}
+		statuses[fileName] = fileStatus{status: strings.ToLower(status), firstError: firstError}
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("querying copy history: %w", err)
+	}
+
+	ingested := true
+	for _, path := range paths {
+		fs, ok := statuses[path]
+		switch {
+		case !ok:
+			ingested = false
+		case fs.status == copyStatusLoaded:
+		case fs.status == copyStatusLoadFailed, fs.status == copyStatusPartiallyLoaded, fs.status == copyStatusLoadSkipped:
+			return false, fmt.Errorf("ingesting %s: %s: %s", path, fs.status, fs.firstError)
+		default:
+			ingested = false
+		}
+	}
+	return ingested, nil
+}
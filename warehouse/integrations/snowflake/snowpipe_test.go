@@ -0,0 +1,131 @@
+package snowflake
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	whutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+func TestSnowpipe(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	t.Run("jwt", func(t *testing.T) {
+		now := time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)
+		token, err := snowpipeJWT("xy12345.us-east-1", "rudder", privateKey, "", now)
+		require.NoError(t, err)
+
+		parts := strings.Split(token, ".")
+		require.Len(t, parts, 3)
+		claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(gjson.GetBytes(claims, "iss").String(), "XY12345.RUDDER.SHA256:"))
+		require.Equal(t, "XY12345.RUDDER", gjson.GetBytes(claims, "sub").String())
+		require.Equal(t, now.Unix(), gjson.GetBytes(claims, "iat").Int())
+		require.Equal(t, now.Add(snowpipeJWTLifetime).Unix(), gjson.GetBytes(claims, "exp").Int())
+
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+
+		_, err = snowpipeJWT("xy12345", "rudder", "invalid", "", now)
+		require.Error(t, err)
+	})
+
+	t.Run("files", func(t *testing.T) {
+		sf := New(config.New(), logger.NOP, stats.NOP)
+		sf.ObjectStorage = whutils.S3
+
+		stageURL, paths, err := sf.snowpipeFiles([]whutils.LoadFile{
+			{Location: "https://bucket.s3.amazonaws.com/rudder-warehouse-load-objects/tracks/source-1/1-tracks/load.csv.gz"},
+			{Location: "https://bucket.s3.amazonaws.com/rudder-warehouse-load-objects/tracks/source-1/1-tracks/load-2.csv.gz"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "s3://bucket/rudder-warehouse-load-objects/", stageURL)
+		require.Equal(t, []string{"tracks/source-1/1-tracks/load.csv.gz", "tracks/source-1/1-tracks/load-2.csv.gz"}, paths)
+
+		_, _, err = sf.snowpipeFiles([]whutils.LoadFile{
+			{Location: "https://bucket.s3.amazonaws.com/rudder-warehouse-load-objects/tracks/source-1/1-tracks/load.csv.gz"},
+			{Location: "https://other.s3.amazonaws.com/rudder-warehouse-load-objects/tracks/source-1/1-tracks/load.csv.gz"},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("insert files", func(t *testing.T) {
+		var (
+			requests int
+			files    []string
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/data/pipes/DB.NAMESPACE.RUDDER_PIPE_TRACKS/insertFiles" ||
+				r.URL.Query().Get("requestId") == "" ||
+				!strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") ||
+				r.Header.Get("X-Snowflake-Authorization-Token-Type") != "KEYPAIR_JWT" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			for _, file := range gjson.GetBytes(body, "files.#.path").Array() {
+				files = append(files, file.String())
+			}
+			requests++
+			_, _ = w.Write([]byte(`{"responseCode":"SUCCESS"}`))
+		}))
+		defer srv.Close()
+
+		conf := config.New()
+		conf.Set("Warehouse.snowflake.snowpipe.url", srv.URL)
+		sf := New(conf, logger.NOP, stats.NOP)
+		sf.Namespace = "NAMESPACE"
+		sf.Warehouse = model.Warehouse{
+			Destination: backendconfig.DestinationT{
+				Config: map[string]interface{}{
+					model.AccountSetting.String():        "xy12345",
+					model.UserSetting.String():           "rudder",
+					model.DatabaseSetting.String():       "DB",
+					model.UseKeyPairAuthSetting.String(): true,
+					model.PrivateKeySetting.String():     privateKey,
+				},
+			},
+		}
+
+		paths := make([]string, snowpipeMaxFiles+1)
+		for i := range paths {
+			paths[i] = "tracks/source-1/1-tracks/load.csv.gz"
+		}
+		require.NoError(t, sf.insertFiles(context.Background(), "RUDDER_PIPE_TRACKS", paths))
+		require.Equal(t, 2, requests)
+		require.Equal(t, paths, files)
+
+		require.Error(t, sf.insertFiles(context.Background(), "RUDDER_PIPE_IDENTIFIES", paths))
+
+		sf.Warehouse.Destination.Config[model.UseKeyPairAuthSetting.String()] = false
+		require.ErrorContains(t, sf.insertFiles(context.Background(), "RUDDER_PIPE_TRACKS", paths), "key pair")
+	})
+}
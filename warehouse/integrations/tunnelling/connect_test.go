@@ -1,11 +1,14 @@
 package tunnelling
 
 import (
+	"database/sql"
 	"fmt"
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
+	_ "github.com/lib/pq"
 	"github.com/ory/dockertest/v3"
 	dc "github.com/ory/dockertest/v3/docker"
 	"github.com/stretchr/testify/require"
@@ -177,4 +180,49 @@ func TestConnect(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("listen", func(t *testing.T) {
+		config := Config{
+			sshUser:       tunnelledSSHUser,
+			sshHost:       tunnelledSSHHost,
+			sshPort:       tunnelledSSHPort,
+			sshPrivateKey: string(tunnelledPrivateKey),
+		}
+		tunnel, err := Listen(config, tunnelledHost, "5432", 10*time.Second)
+		require.NoError(t, err)
+
+		host, port := tunnel.Addr()
+		db, err := sql.Open("postgres", fmt.Sprintf(
+			"postgres://%s:%s@%s:%s/%s?sslmode=disable",
+			tunnelledUser, tunnelledPassword, host, port, tunnelledDatabase,
+		))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+		require.NoError(t, db.Ping())
+
+		require.NoError(t, tunnel.Close())
+		require.NoError(t, tunnel.Close(), "closing the tunnel should be idempotent")
+		db.SetMaxIdleConns(0)
+		require.Error(t, db.Ping(), "connections should fail once the tunnel is closed")
+
+		_, err = Listen(Config{
+			sshUser:       tunnelledSSHUser,
+			sshHost:       tunnelledSSHHost,
+			sshPort:       tunnelledSSHPort,
+			sshPrivateKey: "privateKey",
+		}, tunnelledHost, "5432", 10*time.Second)
+		require.ErrorContains(t, err, "parsing ssh private key")
+
+		_, err = Listen(Config{
+			sshUser:       tunnelledSSHUser,
+			sshHost:       tunnelledSSHHost,
+			sshPort:       tunnelledSSHPort,
+			sshPrivateKey: string(tunnelledPrivateKey),
+			sshHostKey:    "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl",
+		}, tunnelledHost, "5432", 10*time.Second)
+		require.ErrorContains(t, err, "connecting to ssh host", "the connection should fail if the host key doesn't match")
+
+		_, err = Listen(Config{}, tunnelledHost, "5432", 10*time.Second)
+		require.ErrorIs(t, err, ErrMissingKey)
+	})
 }
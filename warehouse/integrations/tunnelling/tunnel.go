@@ -0,0 +1,124 @@
+package tunnelling
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const sshHostKey = "sshHostKey"
+
+// Tunnel forwards the connections made to its local address to a remote address through an ssh bastion.
+// It is used by warehouses whose drivers can't be wrapped by the sql+ssh driver, by connecting to the tunnel's local address instead.
+type Tunnel struct {
+	client   *ssh.Client
+	listener net.Listener
+	remote   string
+
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// Listen connects to the ssh bastion configured in the destination config and forwards the connections made to the
+// tunnel's local address to the remote host and port. The tunnel needs to be closed once it is no longer used.
+func Listen(config Config, remoteHost, remotePort string, timeout time.Duration) (*Tunnel, error) {
+	tunnelConfig, err := extractTunnelConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("reading ssh tunnel config: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(tunnelConfig.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ssh private key: %w", err)
+	}
+	hostKeyCallback := ssh.InsecureIgnoreHostKey() // the host key is only verified if configured, same as the sql+ssh driver
+	if hostKey, ok := config[sshHostKey].(string); ok && hostKey != "" {
+		publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+		if err != nil {
+			return nil, fmt.Errorf("parsing ssh host key: %w", err)
+		}
+		hostKeyCallback = ssh.FixedHostKey(publicKey)
+	}
+
+	client, err := ssh.Dial("tcp", net.JoinHostPort(tunnelConfig.Host, strconv.Itoa(tunnelConfig.Port)), &ssh.ClientConfig{
+		User:            tunnelConfig.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to ssh host: %w", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("listening for tunnelled connections: %w", err)
+	}
+
+	t := &Tunnel{
+		client:   client,
+		listener: listener,
+		remote:   net.JoinHostPort(remoteHost, remotePort),
+	}
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.accept()
+	}()
+	return t, nil
+}
+
+// Addr returns the local host and port of the tunnel
+func (t *Tunnel) Addr() (host, port string) {
+	host, port, _ = net.SplitHostPort(t.listener.Addr().String())
+	return host, port
+}
+
+// Close stops forwarding connections and closes the connection to the ssh bastion, along with the tunnelled connections
+func (t *Tunnel) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		err = errors.Join(t.listener.Close(), t.client.Close())
+		t.wg.Wait()
+	})
+	return err
+}
+
+func (t *Tunnel) accept() {
+	for {
+		local, err := t.listener.Accept()
+		if err != nil { // listener closed
+			return
+		}
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			t.forward(local)
+		}()
+	}
+}
+
+func (t *Tunnel) forward(local net.Conn) {
+	defer func() { _ = local.Close() }()
+	remote, err := t.client.Dial("tcp", t.remote)
+	if err != nil {
+		return
+	}
+	defer func() { _ = remote.Close() }()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(remote, local)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(local, remote)
+		done <- struct{}{}
+	}()
+	<-done // closing both connections as soon as either side is done, which unblocks the other copy too
+}
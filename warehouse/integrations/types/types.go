@@ -3,4 +3,6 @@ package types
 type LoadTableStats struct {
 	RowsInserted int64
 	RowsUpdated  int64
+	// Ingesting is set when the load files were submitted to be ingested asynchronously, see manager.IngestionConfirmer
+	Ingesting bool
 }
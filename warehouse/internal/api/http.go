@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	ierrors "github.com/rudderlabs/rudder-server/warehouse/internal/errors"
@@ -13,6 +14,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	jsoniter "github.com/json-iterator/go"
 
+	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 
@@ -28,11 +30,19 @@ type stagingFilesRepo interface {
 	Insert(ctx context.Context, stagingFile *model.StagingFileWithSchema) (int64, error)
 }
 
+type warehousesRepo interface {
+	WarehousesBySourceID(sourceID string) []model.Warehouse
+}
+
 type WarehouseAPI struct {
+	Conf        *config.Config
 	Logger      logger.Logger
 	Stats       stats.Stats
 	Repo        stagingFilesRepo
 	Multitenant *multitenant.Manager
+	// Warehouses finds the destinations reusing the load files of the destination of the staging files, so that they
+	// get the staging files as well, see model.LoadFilesFromSetting. Staging files aren't fanned out if nil.
+	Warehouses warehousesRepo
 }
 
 type destinationSchema struct {
@@ -49,6 +59,7 @@ type stagingFileSchema struct {
 	LastEventAt           time.Time
 	TotalEvents           int
 	TotalBytes            int
+	EventsPerTable        map[string]int
 	UseRudderStorage      bool
 	DestinationRevisionID string
 	// cloud sources specific info
@@ -95,6 +106,7 @@ func mapStagingFile(payload *stagingFileSchema) (model.StagingFileWithSchema, er
 		DestinationRevisionID: payload.DestinationRevisionID,
 		TotalEvents:           payload.TotalEvents,
 		TotalBytes:            payload.TotalBytes,
+		EventsPerTable:        payload.EventsPerTable,
 		SourceTaskRunID:       payload.SourceTaskRunID,
 		SourceJobID:           payload.SourceJobID,
 		SourceJobRunID:        payload.SourceJobRunID,
@@ -136,14 +148,25 @@ func (api *WarehouseAPI) processHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if _, err := api.Repo.Insert(r.Context(), &stagingFile); err != nil {
-		if errors.Is(r.Context().Err(), context.Canceled) {
-			http.Error(w, ierrors.ErrRequestCancelled.Error(), http.StatusBadRequest)
+	stagingFiles := api.fanOut(stagingFile)
+	if len(stagingFiles) == 0 {
+		api.Logger.Infow("skipping staging file of destination reusing load files",
+			lf.SourceID, stagingFile.SourceID,
+			lf.DestinationID, stagingFile.DestinationID,
+		)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	for i := range stagingFiles {
+		if _, err := api.Repo.Insert(r.Context(), &stagingFiles[i]); err != nil {
+			if errors.Is(r.Context().Err(), context.Canceled) {
+				http.Error(w, ierrors.ErrRequestCancelled.Error(), http.StatusBadRequest)
+				return
+			}
+			api.Logger.Errorw("inserting staging file", lf.Error, err.Error())
+			http.Error(w, "can't insert staging file", http.StatusInternalServerError)
 			return
 		}
-		api.Logger.Errorw("inserting staging file", lf.Error, err.Error())
-		http.Error(w, "can't insert staging file", http.StatusInternalServerError)
-		return
 	}
 
 	api.Stats.NewTaggedStat("rows_staged", stats.CountType, stats.Tags{
@@ -161,3 +184,34 @@ func (api *WarehouseAPI) processHandler(w http.ResponseWriter, r *http.Request)
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// fanOut returns the staging files to insert for the staging file: none if its destination reuses the load files of
+// another destination, which gets the staging file as well, otherwise the staging file along with a twin for every
+// destination reusing the load files of its destination
+func (api *WarehouseAPI) fanOut(stagingFile model.StagingFileWithSchema) []model.StagingFileWithSchema {
+	stagingFiles := []model.StagingFileWithSchema{stagingFile}
+	if api.Warehouses == nil {
+		return stagingFiles
+	}
+	warehouses := api.Warehouses.WarehousesBySourceID(stagingFile.SourceID)
+	idx := slices.IndexFunc(warehouses, func(warehouse model.Warehouse) bool {
+		return warehouse.Destination.ID == stagingFile.DestinationID
+	})
+	if idx == -1 {
+		return stagingFiles
+	}
+	warehouse := warehouses[idx]
+
+	for _, other := range warehouses {
+		switch {
+		case warehouse.LoadsFilesFrom(api.Conf, other):
+			return nil
+		case other.LoadsFilesFrom(api.Conf, warehouse):
+			twin := stagingFile
+			twin.DestinationID = other.Destination.ID
+			twin.DestinationRevisionID = other.Destination.RevisionID
+			stagingFiles = append(stagingFiles, twin)
+		}
+	}
+	return stagingFiles
+}
@@ -41,6 +41,18 @@ func (m *memRepo) Insert(_ context.Context, stagingFile *model.StagingFileWithSc
 	return int64(len(m.files)), nil
 }
 
+type memWarehouses []model.Warehouse
+
+func (m memWarehouses) WarehousesBySourceID(sourceID string) []model.Warehouse {
+	var warehouses []model.Warehouse
+	for _, warehouse := range m {
+		if warehouse.Source.ID == sourceID {
+			warehouses = append(warehouses, warehouse)
+		}
+	}
+	return warehouses
+}
+
 func loadFile(t *testing.T, path string) string {
 	t.Helper()
 
@@ -77,6 +89,7 @@ func TestAPI_Process(t *testing.T) {
 			DestinationRevisionID: "2H1cLBvL3v0prRBNzpe8D34XTzU",
 			TotalEvents:           2,
 			TotalBytes:            2000,
+			EventsPerTable:        map[string]int{"product_track": 1, "tracks": 1},
 			SourceTaskRunID:       "<source-task-run-id>",
 			SourceJobID:           "<source-job-id>",
 			SourceJobRunID:        "<source-job-run-id>",
@@ -87,11 +100,23 @@ func TestAPI_Process(t *testing.T) {
 		Schema: json.RawMessage("{\"product_track\":{\"context_destination_id\":\"string\",\"context_destination_type\":\"string\",\"context_ip\":\"string\",\"context_library_name\":\"string\",\"context_passed_ip\":\"string\",\"context_request_ip\":\"string\",\"context_source_id\":\"string\",\"context_source_type\":\"string\",\"event\":\"string\",\"event_text\":\"string\",\"id\":\"string\",\"original_timestamp\":\"datetime\",\"product_id\":\"string\",\"rating\":\"int\",\"received_at\":\"datetime\",\"revenue\":\"float\",\"review_body\":\"string\",\"review_id\":\"string\",\"sent_at\":\"datetime\",\"timestamp\":\"datetime\",\"user_id\":\"string\",\"uuid_ts\":\"datetime\"},\"tracks\":{\"context_destination_id\":\"string\",\"context_destination_type\":\"string\",\"context_ip\":\"string\",\"context_library_name\":\"string\",\"context_passed_ip\":\"string\",\"context_request_ip\":\"string\",\"context_source_id\":\"string\",\"context_source_type\":\"string\",\"event\":\"string\",\"event_text\":\"string\",\"id\":\"string\",\"original_timestamp\":\"datetime\",\"received_at\":\"datetime\",\"sent_at\":\"datetime\",\"timestamp\":\"datetime\",\"user_id\":\"string\",\"uuid_ts\":\"datetime\"}}"),
 	}
 
+	warehouse := func(destinationID, revisionID string, destConfig map[string]interface{}) model.Warehouse {
+		return model.Warehouse{
+			Source:      backendconfig.SourceT{ID: "279L3gEKqwruBoKGsXZtSVX7vIy"},
+			Destination: backendconfig.DestinationT{ID: destinationID, RevisionID: revisionID, Config: destConfig},
+			Type:        "POSTGRES",
+		}
+	}
+	mirrorStagingFile := expectedStagingFile
+	mirrorStagingFile.DestinationID = "mirror-destination"
+	mirrorStagingFile.DestinationRevisionID = "mirror-revision"
+
 	testcases := []struct {
 		name                 string
 		reqBody              string
 		degradedWorkspaceIDs []string
 		storeErr             error
+		warehouses           []model.Warehouse
 
 		storage []model.StagingFileWithSchema
 
@@ -106,6 +131,29 @@ func TestAPI_Process(t *testing.T) {
 
 			respCode: http.StatusOK,
 		},
+		{
+			name:    "process request fanned out to destinations reusing load files",
+			reqBody: body,
+			warehouses: []model.Warehouse{
+				warehouse("27CHciD6leAhurSyFAeN4dp14qZ", "2H1cLBvL3v0prRBNzpe8D34XTzU", map[string]interface{}{"bucketName": "bucket"}),
+				warehouse("mirror-destination", "mirror-revision", map[string]interface{}{"bucketName": "bucket", "loadFilesFrom": "27CHciD6leAhurSyFAeN4dp14qZ"}),
+				warehouse("other-bucket-destination", "other-bucket-revision", map[string]interface{}{"bucketName": "other-bucket", "loadFilesFrom": "27CHciD6leAhurSyFAeN4dp14qZ"}),
+			},
+
+			storage: []model.StagingFileWithSchema{expectedStagingFile, mirrorStagingFile},
+
+			respCode: http.StatusOK,
+		},
+		{
+			name:    "process request of destination reusing load files",
+			reqBody: body,
+			warehouses: []model.Warehouse{
+				warehouse("27CHciD6leAhurSyFAeN4dp14qZ", "2H1cLBvL3v0prRBNzpe8D34XTzU", map[string]interface{}{"loadFilesFrom": "source-destination"}),
+				warehouse("source-destination", "source-revision", map[string]interface{}{}),
+			},
+
+			respCode: http.StatusOK,
+		},
 		{
 			name:     "process request storage error",
 			reqBody:  body,
@@ -177,10 +225,12 @@ func TestAPI_Process(t *testing.T) {
 			m := multitenant.New(c, backendconfig.DefaultBackendConfig)
 
 			wAPI := api.WarehouseAPI{
+				Conf:        c,
 				Repo:        r,
 				Logger:      logger.NOP,
 				Stats:       stats.NOP,
 				Multitenant: m,
+				Warehouses:  memWarehouses(tc.warehouses),
 			}
 
 			req, err := http.NewRequest(http.MethodPost, "https://localhost:8080/v1/process", bytes.NewBuffer([]byte(tc.reqBody)))
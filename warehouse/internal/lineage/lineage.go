@@ -0,0 +1,288 @@
+// Package lineage emits the column-level lineage of the warehouse uploads in the OpenLineage format, i.e. which event
+// property each column of the exported tables is loaded from and how, so that data catalogs consuming OpenLineage
+// events (DataHub, Amundsen through Marquez, etc.) display the provenance of the tables automatically.
+package lineage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+)
+
+const (
+	producer               = "https://github.com/rudderlabs/rudder-server"
+	runEventSchemaURL      = "https://openlineage.io/spec/2-0-2/OpenLineage.json#/$defs/RunEvent"
+	schemaFacetURL         = "https://openlineage.io/spec/facets/1-1-1/SchemaDatasetFacet.json#/$defs/SchemaDatasetFacet"
+	columnLineageFacetURL  = "https://openlineage.io/spec/facets/1-2-0/ColumnLineageDatasetFacet.json#/$defs/ColumnLineageDatasetFacet"
+	outputStatisticsURL    = "https://openlineage.io/spec/facets/1-0-2/OutputStatisticsOutputDatasetFacet.json#/$defs/OutputStatisticsOutputDatasetFacet"
+	flattenedDescription   = "flattened from the event payload, converted to snake case and coerced to %s"
+	identityDescription    = "copied from the event"
+	transformationDirect   = "DIRECT"
+	subtypeIdentity        = "IDENTITY"
+	subtypeTransformation  = "TRANSFORMATION"
+	eventTypeComplete      = "COMPLETE"
+	defaultNamespacePrefix = "rudderstack"
+)
+
+// eventFields are the columns loaded from the top level fields of the events
+var eventFields = map[string]string{
+	"id":                 "messageId",
+	"anonymous_id":       "anonymousId",
+	"user_id":            "userId",
+	"sent_at":            "sentAt",
+	"received_at":        "receivedAt",
+	"original_timestamp": "originalTimestamp",
+	"timestamp":          "timestamp",
+	"event_text":         "event",
+	"channel":            "channel",
+}
+
+// generatedColumns are the columns generated while loading, without a source field
+var generatedColumns = map[string]struct{}{
+	"uuid_ts":   {},
+	"loaded_at": {},
+}
+
+// Table is a table exported by an upload
+type Table struct {
+	Name     string
+	Schema   model.TableSchema
+	RowCount int64
+}
+
+// Upload is an upload which has exported its data
+type Upload struct {
+	ID              int64
+	WorkspaceID     string
+	SourceID        string
+	DestinationID   string
+	DestinationType string
+	Namespace       string
+	Tables          []Table
+	CompletedAt     time.Time
+}
+
+// Emitter sends the lineage of the uploads to an OpenLineage endpoint
+type Emitter struct {
+	logger       logger.Logger
+	statsFactory stats.Stats
+	client       *http.Client
+
+	config struct {
+		url    string
+		apiKey string
+	}
+}
+
+// New returns an emitter sending the lineage to the configured endpoint, or nil if lineage is not enabled
+func New(conf *config.Config, log logger.Logger, statsFactory stats.Stats) *Emitter {
+	if !conf.GetBoolVar(false, "Warehouse.lineage.enabled") {
+		return nil
+	}
+	e := &Emitter{
+		logger:       log.Child("lineage"),
+		statsFactory: statsFactory,
+		client:       &http.Client{Timeout: conf.GetDurationVar(30, time.Second, "Warehouse.lineage.timeout")},
+	}
+	e.config.url = conf.GetStringVar("http://localhost:5000/api/v1/lineage", "Warehouse.lineage.url")
+	e.config.apiKey = conf.GetStringVar("", "Warehouse.lineage.apiKey")
+	return e
+}
+
+// Emit sends the lineage of the upload. The upload is complete by then, so failing to send it is only reported.
+func (e *Emitter) Emit(ctx context.Context, upload Upload) {
+	if e == nil {
+		return
+	}
+	status := "succeeded"
+	if err := e.send(ctx, NewRunEvent(upload)); err != nil {
+		status = "failed"
+		e.logger.Warnn("Emitting upload lineage",
+			logger.NewIntField("uploadId", upload.ID),
+			logger.NewStringField("destinationId", upload.DestinationID),
+			logger.NewErrorField(err),
+		)
+	}
+	e.statsFactory.NewTaggedStat("warehouse_lineage_events", stats.CountType, stats.Tags{
+		"workspaceId": upload.WorkspaceID,
+		"destID":      upload.DestinationID,
+		"destType":    upload.DestinationType,
+		"status":      status,
+	}).Increment()
+}
+
+func (e *Emitter) send(ctx context.Context, event RunEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshalling run event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.config.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.config.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
+
+// RunEvent is an OpenLineage run event
+type RunEvent struct {
+	EventType string    `json:"eventType"`
+	EventTime time.Time `json:"eventTime"`
+	Producer  string    `json:"producer"`
+	SchemaURL string    `json:"schemaURL"`
+	Run       Run       `json:"run"`
+	Job       Job       `json:"job"`
+	Inputs    []Dataset `json:"inputs"`
+	Outputs   []Dataset `json:"outputs"`
+}
+
+type Run struct {
+	RunID string `json:"runId"`
+}
+
+type Job struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+type Dataset struct {
+	Namespace    string                 `json:"namespace"`
+	Name         string                 `json:"name"`
+	Facets       map[string]interface{} `json:"facets,omitempty"`
+	OutputFacets map[string]interface{} `json:"outputFacets,omitempty"`
+}
+
+type SchemaField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type InputField struct {
+	Namespace       string           `json:"namespace"`
+	Name            string           `json:"name"`
+	Field           string           `json:"field"`
+	Transformations []Transformation `json:"transformations"`
+}
+
+type Transformation struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype"`
+	Description string `json:"description"`
+}
+
+// NewRunEvent returns the run event of the upload, with a run id derived from the upload id so that sending it again
+// does not create another run
+func NewRunEvent(upload Upload) RunEvent {
+	input := Dataset{
+		Namespace: defaultNamespacePrefix + "://" + upload.WorkspaceID,
+		Name:      upload.SourceID,
+	}
+	event := RunEvent{
+		EventType: eventTypeComplete,
+		EventTime: upload.CompletedAt.UTC(),
+		Producer:  producer,
+		SchemaURL: runEventSchemaURL,
+		Run:       Run{RunID: uuid.NewSHA1(uuid.NameSpaceURL, []byte(fmt.Sprintf("%s/uploads/%d", producer, upload.ID))).String()},
+		Job: Job{
+			Namespace: defaultNamespacePrefix + "://" + upload.WorkspaceID,
+			Name:      upload.SourceID + "." + upload.DestinationID,
+		},
+		Inputs:  []Dataset{input},
+		Outputs: make([]Dataset, 0, len(upload.Tables)),
+	}
+
+	for _, table := range upload.Tables {
+		columns := make([]string, 0, len(table.Schema))
+		for column := range table.Schema {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+
+		fields := make([]SchemaField, 0, len(columns))
+		columnLineage := make(map[string]interface{}, len(columns))
+		for _, column := range columns {
+			columnType := table.Schema[column]
+			fields = append(fields, SchemaField{Name: column, Type: columnType})
+			if _, ok := generatedColumns[column]; ok {
+				continue
+			}
+			columnLineage[column] = map[string]interface{}{
+				"inputFields": []InputField{inputField(input, column, columnType)},
+			}
+		}
+
+		event.Outputs = append(event.Outputs, Dataset{
+			Namespace: strings.ToLower(upload.DestinationType) + "://" + upload.DestinationID,
+			Name:      upload.Namespace + "." + table.Name,
+			Facets: map[string]interface{}{
+				"schema": map[string]interface{}{
+					"_producer":  producer,
+					"_schemaURL": schemaFacetURL,
+					"fields":     fields,
+				},
+				"columnLineage": map[string]interface{}{
+					"_producer":  producer,
+					"_schemaURL": columnLineageFacetURL,
+					"fields":     columnLineage,
+				},
+			},
+			OutputFacets: map[string]interface{}{
+				"outputStatistics": map[string]interface{}{
+					"_producer":  producer,
+					"_schemaURL": outputStatisticsURL,
+					"rowCount":   table.RowCount,
+				},
+			},
+		})
+	}
+	return event
+}
+
+// inputField returns the event field the column is loaded from
+func inputField(input Dataset, column, columnType string) InputField {
+	if field, ok := eventFields[column]; ok {
+		return InputField{
+			Namespace: input.Namespace,
+			Name:      input.Name,
+			Field:     field,
+			Transformations: []Transformation{
+				{Type: transformationDirect, Subtype: subtypeIdentity, Description: identityDescription},
+			},
+		}
+	}
+	return InputField{
+		Namespace: input.Namespace,
+		Name:      input.Name,
+		Field:     column,
+		Transformations: []Transformation{
+			{Type: transformationDirect, Subtype: subtypeTransformation, Description: fmt.Sprintf(flattenedDescription, columnType)},
+		},
+	}
+}
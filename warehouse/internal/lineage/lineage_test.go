@@ -0,0 +1,100 @@
+package lineage_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+
+	"github.com/rudderlabs/rudder-server/warehouse/internal/lineage"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+)
+
+func TestLineage(t *testing.T) {
+	upload := lineage.Upload{
+		ID:              1,
+		WorkspaceID:     "workspace-1",
+		SourceID:        "source-1",
+		DestinationID:   "destination-1",
+		DestinationType: "POSTGRES",
+		Namespace:       "namespace",
+		Tables: []lineage.Table{
+			{
+				Name: "tracks",
+				Schema: model.TableSchema{
+					"id":                 "string",
+					"event_text":         "string",
+					"context_app_name":   "string",
+					"uuid_ts":            "datetime",
+					"original_timestamp": "datetime",
+				},
+				RowCount: 10,
+			},
+		},
+		CompletedAt: time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("run event", func(t *testing.T) {
+		event := lineage.NewRunEvent(upload)
+		require.Equal(t, event.Run, lineage.NewRunEvent(upload).Run, "run ids should be stable")
+		require.Equal(t, "source-1.destination-1", event.Job.Name)
+		require.Len(t, event.Outputs, 1)
+		require.Equal(t, "postgres://destination-1", event.Outputs[0].Namespace)
+		require.Equal(t, "namespace.tracks", event.Outputs[0].Name)
+
+		columnLineage := event.Outputs[0].Facets["columnLineage"].(map[string]interface{})["fields"].(map[string]interface{})
+		require.Len(t, columnLineage, 4, "generated columns should not have lineage")
+		require.Equal(t, "messageId", columnLineage["id"].(map[string]interface{})["inputFields"].([]lineage.InputField)[0].Field)
+		contextAppName := columnLineage["context_app_name"].(map[string]interface{})["inputFields"].([]lineage.InputField)[0]
+		require.Equal(t, "context_app_name", contextAppName.Field)
+		require.Equal(t, "TRANSFORMATION", contextAppName.Transformations[0].Subtype)
+	})
+
+	t.Run("emit", func(t *testing.T) {
+		var body []byte
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer api-key" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer srv.Close()
+
+		conf := config.New()
+		conf.Set("Warehouse.lineage.enabled", true)
+		conf.Set("Warehouse.lineage.url", srv.URL)
+		conf.Set("Warehouse.lineage.apiKey", "api-key")
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+
+		lineage.New(conf, logger.NOP, statsStore).Emit(context.Background(), upload)
+		require.Equal(t, "COMPLETE", gjson.GetBytes(body, "eventType").String())
+		require.Equal(t, "2024-10-01T00:00:00Z", gjson.GetBytes(body, "eventTime").String())
+		require.EqualValues(t, 10, gjson.GetBytes(body, "outputs.0.outputFacets.outputStatistics.rowCount").Int())
+		require.Equal(t, "originalTimestamp", gjson.GetBytes(body, "outputs.0.facets.columnLineage.fields.original_timestamp.inputFields.0.field").String())
+		require.EqualValues(t, 1, statsStore.Get("warehouse_lineage_events", stats.Tags{
+			"workspaceId": "workspace-1",
+			"destID":      "destination-1",
+			"destType":    "POSTGRES",
+			"status":      "succeeded",
+		}).LastValue())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		emitter := lineage.New(config.New(), logger.NOP, stats.NOP)
+		require.Nil(t, emitter)
+		emitter.Emit(context.Background(), upload)
+	})
+}
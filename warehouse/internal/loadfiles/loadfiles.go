@@ -5,8 +5,11 @@ import (
 	stdjson "encoding/json"
 	"errors"
 	"fmt"
+	"path"
+	"reflect"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
@@ -41,6 +44,7 @@ var warehousesToVerifyLoadFilesFolder = []string{warehouseutils.SNOWFLAKE}
 
 type Notifier interface {
 	Publish(ctx context.Context, payload *notifier.PublishRequest) (ch <-chan *notifier.PublishResponse, err error)
+	Track(ctx context.Context, batchID string) <-chan *notifier.PublishResponse
 }
 
 type StageFileRepo interface {
@@ -54,6 +58,23 @@ type LoadFileRepo interface {
 	GetByStagingFiles(ctx context.Context, stagingFileIDs []int64) ([]model.LoadFile, error)
 }
 
+type LoadFileCheckpointRepo interface {
+	Insert(ctx context.Context, checkpoint model.LoadFileCheckpoint) error
+	GetForUpload(ctx context.Context, uploadID int64) ([]model.LoadFileCheckpoint, error)
+	DeleteForUpload(ctx context.Context, uploadID int64) error
+}
+
+// TwinStagingFileRepo finds the staging files of the destination the load files get reused from, see
+// model.LoadFilesFromSetting
+type TwinStagingFileRepo interface {
+	Twins(ctx context.Context, stagingFileIDs []int64, destinationID string) ([]model.StagingFileTwin, error)
+	GetForUploadID(ctx context.Context, sourceID, destinationID string, uploadID int64) ([]*model.StagingFile, error)
+}
+
+type UploadRepo interface {
+	Get(ctx context.Context, id int64) (model.Upload, error)
+}
+
 type ControlPlaneClient interface {
 	DestinationHistory(ctx context.Context, revisionID string) (backendconfig.DestinationT, error)
 }
@@ -65,11 +86,18 @@ type LoadFileGenerator struct {
 
 	StageRepo StageFileRepo
 	LoadRepo  LoadFileRepo
+	// CheckpointRepo records the batches published to the notifier, so that generating load files resumes tracking
+	// them after a restart, instead of publishing them again. Batches are always published again if nil.
+	CheckpointRepo LoadFileCheckpointRepo
+	// TwinRepo and UploadRepo find the load files generated for the destination named in the loadFilesFrom setting of
+	// the destination, so that they get reused instead of generated again. Load files are always generated if nil.
+	TwinRepo   TwinStagingFileRepo
+	UploadRepo UploadRepo
 
 	ControlPlaneClient ControlPlaneClient
 
-	publishBatchSize             int
-	publishBatchSizePerWorkspace map[string]int
+	publishBatchSize             config.ValueLoader[int]
+	publishBatchSizePerWorkspace func() map[string]int
 }
 
 type WorkerJobResponse struct {
@@ -112,17 +140,20 @@ type WorkerJobRequest struct {
 }
 
 func WithConfig(ld *LoadFileGenerator, config *config.Config) {
-	ld.publishBatchSize = config.GetInt("Warehouse.loadFileGenerator.publishBatchSize", defaultPublishBatchSize)
-	mapConfig := config.GetStringMap("Warehouse.pgNotifierPublishBatchSizeWorkspaceIDs", nil)
+	ld.publishBatchSize = config.GetReloadableIntVar(defaultPublishBatchSize, 1, "Warehouse.loadFileGenerator.publishBatchSize")
+	ld.publishBatchSizePerWorkspace = func() map[string]int {
+		mapConfig := config.GetStringMap("Warehouse.pgNotifierPublishBatchSizeWorkspaceIDs", nil)
 
-	ld.publishBatchSizePerWorkspace = make(map[string]int, len(mapConfig))
-	for k, v := range mapConfig {
-		val, ok := v.(float64)
-		if !ok {
-			ld.publishBatchSizePerWorkspace[k] = defaultPublishBatchSize
-			continue
+		publishBatchSizePerWorkspace := make(map[string]int, len(mapConfig))
+		for k, v := range mapConfig {
+			val, ok := v.(float64)
+			if !ok {
+				publishBatchSizePerWorkspace[k] = defaultPublishBatchSize
+				continue
+			}
+			publishBatchSizePerWorkspace[k] = int(val)
 		}
-		ld.publishBatchSizePerWorkspace[k] = int(val)
+		return publishBatchSizePerWorkspace
 	}
 }
 
@@ -131,6 +162,7 @@ func (lf *LoadFileGenerator) CreateLoadFiles(ctx context.Context, job *model.Upl
 	return lf.createFromStaging(
 		ctx,
 		job,
+		true,
 		lo.Filter(
 			job.StagingFiles,
 			func(stagingFile *model.StagingFile, _ int) bool {
@@ -142,27 +174,47 @@ func (lf *LoadFileGenerator) CreateLoadFiles(ctx context.Context, job *model.Upl
 
 // ForceCreateLoadFiles creates load files for the staging files, regardless if they are already successfully processed.
 func (lf *LoadFileGenerator) ForceCreateLoadFiles(ctx context.Context, job *model.UploadJob) (int64, int64, error) {
-	return lf.createFromStaging(ctx, job, job.StagingFiles)
+	return lf.createFromStaging(ctx, job, false, job.StagingFiles)
 }
 
-func (lf *LoadFileGenerator) createFromStaging(ctx context.Context, job *model.UploadJob, toProcessStagingFiles []*model.StagingFile) (int64, int64, error) {
+func (lf *LoadFileGenerator) createFromStaging(ctx context.Context, job *model.UploadJob, resume bool, toProcessStagingFiles []*model.StagingFile) (int64, int64, error) {
 	destID := job.Upload.DestinationID
 	destType := job.Upload.DestinationType
 
-	publishBatchSize := lf.publishBatchSize
-	if publishBatchSize == 0 {
-		publishBatchSize = defaultPublishBatchSize
+	publishBatchSize := defaultPublishBatchSize
+	if lf.publishBatchSize != nil && lf.publishBatchSize.Load() > 0 {
+		publishBatchSize = lf.publishBatchSize.Load()
 	}
-	if size, ok := lf.publishBatchSizePerWorkspace[strings.ToLower(job.Warehouse.WorkspaceID)]; ok {
-		publishBatchSize = size
+	if lf.publishBatchSizePerWorkspace != nil {
+		if size, ok := lf.publishBatchSizePerWorkspace()[strings.ToLower(job.Warehouse.WorkspaceID)]; ok {
+			publishBatchSize = size
+		}
 	}
 
+	checkpoints, err := lf.checkpoints(ctx, job, resume)
+	if err != nil {
+		return 0, 0, err
+	}
 	uniqueLoadGenID := misc.FastUUID().String()
+	if len(checkpoints) > 0 {
+		// load files of a resumed generation need to be in the same folder as the ones of the batches being resumed
+		uniqueLoadGenID = checkpoints[0].UniqueLoadGenID
+	}
 
 	lf.Logger.Infof("[WH]: Starting batch processing %v stage files for %s:%s", publishBatchSize, destType, destID)
 
 	job.LoadFileGenStartTime = timeutil.Now()
 
+	if len(checkpoints) == 0 {
+		reused, err := lf.reuseLoadFiles(ctx, job, toProcessStagingFiles)
+		if err != nil {
+			return 0, 0, fmt.Errorf("reusing load files: %w", err)
+		}
+		if reused {
+			return lf.loadFilesRange(ctx, repo.StagingFileIDs(toProcessStagingFiles))
+		}
+	}
+
 	// Getting distinct destination revision ID from staging files metadata
 	destinationRevisionIDMap, err := lf.destinationRevisionIDMap(ctx, job)
 	if err != nil {
@@ -197,10 +249,136 @@ func (lf *LoadFileGenerator) createFromStaging(ctx context.Context, job *model.U
 		}
 	}()
 
+	var (
+		sampleError   error
+		sampleErrorMu sync.Mutex
+	)
+	// handleResponses saves the load files of the staging files of a batch, ignoring the responses of other staging files
+	handleResponses := func(responses *notifier.PublishResponse, batchStagingFileIDs []int64) error {
+		var loadFiles []model.LoadFile
+		var successfulStagingFileIDs []int64
+		for _, resp := range responses.Jobs {
+			// Error handling during generating_load_files step:
+			// 1. any error returned by notifier is set on corresponding staging_file
+			// 2. any error effecting a batch/all the staging files like saving load file records to wh db
+			//    is returned as error to caller of the func to set error on all staging files and the whole generating_load_files step
+			var jobResponse WorkerJobResponse
+			if err := json.Unmarshal(resp.Payload, &jobResponse); err != nil {
+				return fmt.Errorf("unmarshalling response from notifier: %w", err)
+			}
+			if !slices.Contains(batchStagingFileIDs, jobResponse.StagingFileID) {
+				continue
+			}
+
+			if resp.Status == notifier.Aborted && resp.Error != nil {
+				lf.Logger.Errorf("[WH]: Error in generating load files: %v", resp.Error)
+				stagingFileErr := errors.New(resp.Error.Error())
+				sampleErrorMu.Lock()
+				sampleError = stagingFileErr
+				sampleErrorMu.Unlock()
+				if err := lf.StageRepo.SetErrorStatus(ctx, jobResponse.StagingFileID, stagingFileErr); err != nil {
+					return fmt.Errorf("set staging file error status: %w", err)
+				}
+				continue
+			}
+			if len(jobResponse.Output) == 0 {
+				lf.Logger.Errorf("[WH]: No LoadFiles returned by worker")
+				continue
+			}
+			for _, output := range jobResponse.Output {
+				loadFiles = append(loadFiles, model.LoadFile{
+					TableName:             output.TableName,
+					Location:              output.Location,
+					TotalRows:             output.TotalRows,
+					ContentLength:         output.ContentLength,
+					StagingFileID:         output.StagingFileID,
+					DestinationRevisionID: output.DestinationRevisionID,
+					UseRudderStorage:      output.UseRudderStorage,
+					SourceID:              job.Upload.SourceID,
+					DestinationID:         job.Upload.DestinationID,
+					DestinationType:       job.Upload.DestinationType,
+				})
+			}
+
+			successfulStagingFileIDs = append(successfulStagingFileIDs, jobResponse.StagingFileID)
+		}
+
+		if len(loadFiles) == 0 {
+			return nil
+		}
+
+		if err := lf.LoadRepo.Insert(ctx, loadFiles); err != nil {
+			return fmt.Errorf("inserting load files: %w", err)
+		}
+		if err := lf.StageRepo.SetStatuses(ctx, successfulStagingFileIDs, warehouseutils.StagingFileSucceededState); err != nil {
+			return fmt.Errorf("setting staging file status to succeeded: %w", err)
+		}
+		return nil
+	}
+
+	// staging files published before, e.g. before a restart, are tracked in the last batch they were published in
+	toProcessStagingFileIDs := lo.SliceToMap(stagingFileIDs, func(id int64) (int64, struct{}) { return id, struct{}{} })
+	lastBatchIDs := make(map[int64]string)
+	for _, checkpoint := range checkpoints {
+		for _, stagingFileID := range checkpoint.StagingFileIDs {
+			if _, ok := toProcessStagingFileIDs[stagingFileID]; ok {
+				lastBatchIDs[stagingFileID] = checkpoint.BatchID
+			}
+		}
+	}
+	resumedBatches := make(map[string][]int64)
+	for stagingFileID, batchID := range lastBatchIDs {
+		resumedBatches[batchID] = append(resumedBatches[batchID], stagingFileID)
+	}
+
+	var (
+		republishStagingFileIDs = make(map[int64]struct{})
+		republishMu             sync.Mutex
+	)
+	if len(resumedBatches) > 0 {
+		lf.Logger.Infon("Resuming tracking of batches published before",
+			logger.NewIntField("batches", int64(len(resumedBatches))),
+			obskit.DestinationID(destID),
+			obskit.DestinationType(destType),
+		)
+
+		var g errgroup.Group
+		for batchID, batchStagingFileIDs := range resumedBatches {
+			ch := lf.Notifier.Track(ctx, batchID)
+			g.Go(func() error {
+				responses, ok := <-ch
+				if !ok {
+					return fmt.Errorf("receiving notifier channel closed")
+				}
+				if responses.Err != nil {
+					return fmt.Errorf("receiving responses from notifier: %w", responses.Err)
+				}
+				if len(responses.Jobs) == 0 {
+					// the batch no longer exists, e.g. its jobs got cleared, thus its staging files need to be published again
+					republishMu.Lock()
+					for _, stagingFileID := range batchStagingFileIDs {
+						republishStagingFileIDs[stagingFileID] = struct{}{}
+					}
+					republishMu.Unlock()
+					return nil
+				}
+				return handleResponses(responses, batchStagingFileIDs)
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	toPublishStagingFiles := lo.Filter(toProcessStagingFiles, func(stagingFile *model.StagingFile, _ int) bool {
+		_, tracked := lastBatchIDs[stagingFile.ID]
+		_, republish := republishStagingFileIDs[stagingFile.ID]
+		return !tracked || republish
+	})
+
 	var g errgroup.Group
 
-	var sampleError error
-	for _, chunk := range lo.Chunk(toProcessStagingFiles, publishBatchSize) {
+	for _, chunk := range lo.Chunk(toPublishStagingFiles, publishBatchSize) {
 		// td : add prefix to payload for s3 dest
 		var messages []stdjson.RawMessage
 		for _, stagingFile := range chunk {
@@ -249,7 +427,21 @@ func (lf *LoadFileGenerator) createFromStaging(ctx context.Context, job *model.U
 
 		lf.Logger.Infof("[WH]: Publishing %d staging files for %s:%s to notifier", len(messages), destType, destID)
 
+		batchID := misc.FastUUID().String()
+		batchStagingFileIDs := repo.StagingFileIDs(chunk)
+		if lf.CheckpointRepo != nil {
+			if err := lf.CheckpointRepo.Insert(ctx, model.LoadFileCheckpoint{
+				UploadID:        job.Upload.ID,
+				BatchID:         batchID,
+				UniqueLoadGenID: uniqueLoadGenID,
+				StagingFileIDs:  batchStagingFileIDs,
+			}); err != nil {
+				return 0, 0, fmt.Errorf("inserting load file checkpoint: %w", err)
+			}
+		}
+
 		ch, err := lf.Notifier.Publish(ctx, &notifier.PublishRequest{
+			BatchID:      batchID,
 			Payloads:     messages,
 			JobType:      notifier.JobTypeUpload,
 			UploadSchema: uploadSchemaJSON,
@@ -277,67 +469,18 @@ func (lf *LoadFileGenerator) createFromStaging(ctx context.Context, job *model.U
 			if responses.Err != nil {
 				return fmt.Errorf("receiving responses from notifier: %w", responses.Err)
 			}
-
-			var loadFiles []model.LoadFile
-			var successfulStagingFileIDs []int64
-			for _, resp := range responses.Jobs {
-				// Error handling during generating_load_files step:
-				// 1. any error returned by notifier is set on corresponding staging_file
-				// 2. any error effecting a batch/all the staging files like saving load file records to wh db
-				//    is returned as error to caller of the func to set error on all staging files and the whole generating_load_files step
-				var jobResponse WorkerJobResponse
-				if err := json.Unmarshal(resp.Payload, &jobResponse); err != nil {
-					return fmt.Errorf("unmarshalling response from notifier: %w", err)
-				}
-
-				if resp.Status == notifier.Aborted && resp.Error != nil {
-					lf.Logger.Errorf("[WH]: Error in generating load files: %v", resp.Error)
-					sampleError = errors.New(resp.Error.Error())
-					err = lf.StageRepo.SetErrorStatus(ctx, jobResponse.StagingFileID, sampleError)
-					if err != nil {
-						return fmt.Errorf("set staging file error status: %w", err)
-					}
-					continue
-				}
-				if len(jobResponse.Output) == 0 {
-					lf.Logger.Errorf("[WH]: No LoadFiles returned by worker")
-					continue
-				}
-				for _, output := range jobResponse.Output {
-					loadFiles = append(loadFiles, model.LoadFile{
-						TableName:             output.TableName,
-						Location:              output.Location,
-						TotalRows:             output.TotalRows,
-						ContentLength:         output.ContentLength,
-						StagingFileID:         output.StagingFileID,
-						DestinationRevisionID: output.DestinationRevisionID,
-						UseRudderStorage:      output.UseRudderStorage,
-						SourceID:              job.Upload.SourceID,
-						DestinationID:         job.Upload.DestinationID,
-						DestinationType:       job.Upload.DestinationType,
-					})
-				}
-
-				successfulStagingFileIDs = append(successfulStagingFileIDs, jobResponse.StagingFileID)
-			}
-
-			if len(loadFiles) == 0 {
-				return nil
-			}
-
-			if err = lf.LoadRepo.Insert(ctx, loadFiles); err != nil {
-				return fmt.Errorf("inserting load files: %w", err)
-			}
-			if err = lf.StageRepo.SetStatuses(ctx, successfulStagingFileIDs, warehouseutils.StagingFileSucceededState); err != nil {
-				return fmt.Errorf("setting staging file status to succeeded: %w", err)
-			}
-			return nil
+			return handleResponses(responses, batchStagingFileIDs)
 		})
 	}
 
 	if err := g.Wait(); err != nil {
 		return 0, 0, err
 	}
+	if lf.CheckpointRepo != nil {
+		if err := lf.CheckpointRepo.DeleteForUpload(ctx, job.Upload.ID); err != nil {
+			return 0, 0, fmt.Errorf("deleting load file checkpoints: %w", err)
+		}
+	}
 
 	loadFiles, err := lf.LoadRepo.GetByStagingFiles(ctx, stagingFileIDs)
 	if err != nil {
@@ -366,6 +509,145 @@ func (lf *LoadFileGenerator) createFromStaging(ctx context.Context, job *model.U
 	return loadFiles[0].ID, loadFiles[len(loadFiles)-1].ID, nil
 }
 
+// reuseLoadFiles copies the load files generated for the destination named in the loadFilesFrom setting of the
+// warehouse, as long as the staging files are the twins of the staging files of exactly one of its uploads, with the
+// same upload schema and load file type, returning false otherwise
+func (lf *LoadFileGenerator) reuseLoadFiles(ctx context.Context, job *model.UploadJob, toProcessStagingFiles []*model.StagingFile) (bool, error) {
+	if lf.TwinRepo == nil || lf.UploadRepo == nil || len(toProcessStagingFiles) == 0 {
+		return false, nil
+	}
+	fromDestinationID := job.Warehouse.GetStringDestinationConfig(lf.Conf, model.LoadFilesFromSetting)
+	if fromDestinationID == "" {
+		return false, nil
+	}
+
+	stagingFileIDs := repo.StagingFileIDs(toProcessStagingFiles)
+	twins, err := lf.TwinRepo.Twins(ctx, stagingFileIDs, fromDestinationID)
+	if err != nil {
+		return false, fmt.Errorf("getting staging file twins: %w", err)
+	}
+	if len(twins) != len(stagingFileIDs) {
+		return false, nil
+	}
+	stagingFileIDsByTwin := make(map[int64]int64, len(twins))
+	for _, twin := range twins {
+		if twin.TwinUploadID == 0 || twin.TwinUploadID != twins[0].TwinUploadID {
+			return false, nil
+		}
+		stagingFileIDsByTwin[twin.TwinID] = twin.StagingFileID
+	}
+	if len(stagingFileIDsByTwin) != len(twins) {
+		return false, nil
+	}
+
+	upload, err := lf.UploadRepo.Get(ctx, twins[0].TwinUploadID)
+	if err != nil {
+		return false, fmt.Errorf("getting upload %d: %w", twins[0].TwinUploadID, err)
+	}
+	if upload.DestinationType != job.Upload.DestinationType ||
+		upload.LoadFileType != job.Upload.LoadFileType ||
+		upload.UseRudderStorage != job.Upload.UseRudderStorage ||
+		!reflect.DeepEqual(upload.UploadSchema, job.Upload.UploadSchema) {
+		return false, nil
+	}
+	uploadStagingFiles, err := lf.TwinRepo.GetForUploadID(ctx, upload.SourceID, upload.DestinationID, upload.ID)
+	if err != nil {
+		return false, fmt.Errorf("getting staging files of upload %d: %w", upload.ID, err)
+	}
+	if len(uploadStagingFiles) != len(twins) {
+		return false, nil
+	}
+
+	twinLoadFiles, err := lf.LoadRepo.GetByStagingFiles(ctx, lo.Keys(stagingFileIDsByTwin))
+	if err != nil {
+		return false, fmt.Errorf("getting load files of staging file twins: %w", err)
+	}
+	// every twin needs its load files, and warehouses loading from the folders of the load files need the ones of a
+	// table to be in the same folder
+	twinsWithLoadFiles := make(map[int64]struct{}, len(twins))
+	folders := make(map[string]string)
+	for _, loadFile := range twinLoadFiles {
+		twinsWithLoadFiles[loadFile.StagingFileID] = struct{}{}
+		if !slices.Contains(warehousesToVerifyLoadFilesFolder, job.Warehouse.Type) {
+			continue
+		}
+		folder, ok := folders[loadFile.TableName]
+		if !ok {
+			folders[loadFile.TableName] = path.Dir(loadFile.Location)
+		} else if folder != path.Dir(loadFile.Location) {
+			return false, nil
+		}
+	}
+	if len(twinsWithLoadFiles) != len(twins) {
+		return false, nil
+	}
+
+	loadFiles := make([]model.LoadFile, 0, len(twinLoadFiles))
+	for _, loadFile := range twinLoadFiles {
+		loadFiles = append(loadFiles, model.LoadFile{
+			TableName:             loadFile.TableName,
+			Location:              loadFile.Location,
+			TotalRows:             loadFile.TotalRows,
+			ContentLength:         loadFile.ContentLength,
+			StagingFileID:         stagingFileIDsByTwin[loadFile.StagingFileID],
+			DestinationRevisionID: job.Warehouse.Destination.RevisionID,
+			UseRudderStorage:      loadFile.UseRudderStorage,
+			SourceID:              job.Upload.SourceID,
+			DestinationID:         job.Upload.DestinationID,
+			DestinationType:       job.Upload.DestinationType,
+		})
+	}
+	if err := lf.LoadRepo.DeleteByStagingFiles(ctx, stagingFileIDs); err != nil {
+		return false, fmt.Errorf("deleting previous load files: %w", err)
+	}
+	if err := lf.LoadRepo.Insert(ctx, loadFiles); err != nil {
+		return false, fmt.Errorf("inserting load files: %w", err)
+	}
+	if err := lf.StageRepo.SetStatuses(ctx, stagingFileIDs, warehouseutils.StagingFileSucceededState); err != nil {
+		return false, fmt.Errorf("setting staging file status to succeeded: %w", err)
+	}
+
+	lf.Logger.Infon("Reused load files",
+		logger.NewIntField("uploadId", job.Upload.ID),
+		logger.NewIntField("fromUploadId", upload.ID),
+		logger.NewIntField("loadFiles", int64(len(loadFiles))),
+		obskit.DestinationID(job.Upload.DestinationID),
+		obskit.DestinationType(job.Upload.DestinationType),
+	)
+	return true, nil
+}
+
+// loadFilesRange returns the range of the ids of the load files of the staging files
+func (lf *LoadFileGenerator) loadFilesRange(ctx context.Context, stagingFileIDs []int64) (int64, int64, error) {
+	loadFiles, err := lf.LoadRepo.GetByStagingFiles(ctx, stagingFileIDs)
+	if err != nil {
+		return 0, 0, fmt.Errorf("getting load files: %w", err)
+	}
+	if len(loadFiles) == 0 {
+		return 0, 0, errors.New("no load files reused")
+	}
+	return loadFiles[0].ID, loadFiles[len(loadFiles)-1].ID, nil
+}
+
+// checkpoints returns the checkpoints of the batches published before for the upload when resuming,
+// otherwise it deletes them, so that their batches are not tracked anymore
+func (lf *LoadFileGenerator) checkpoints(ctx context.Context, job *model.UploadJob, resume bool) ([]model.LoadFileCheckpoint, error) {
+	if lf.CheckpointRepo == nil {
+		return nil, nil
+	}
+	if !resume {
+		if err := lf.CheckpointRepo.DeleteForUpload(ctx, job.Upload.ID); err != nil {
+			return nil, fmt.Errorf("deleting load file checkpoints: %w", err)
+		}
+		return nil, nil
+	}
+	checkpoints, err := lf.CheckpointRepo.GetForUpload(ctx, job.Upload.ID)
+	if err != nil {
+		return nil, fmt.Errorf("getting load file checkpoints: %w", err)
+	}
+	return checkpoints, nil
+}
+
 func (lf *LoadFileGenerator) destinationRevisionIDMap(ctx context.Context, job *model.UploadJob) (revisionIDMap map[string]backendconfig.DestinationT, err error) {
 	revisionIDMap = make(map[string]backendconfig.DestinationT)
 
@@ -3,6 +3,7 @@ package loadfiles_test
 import (
 	"context"
 	"fmt"
+	"slices"
 	"testing"
 	"time"
 
@@ -151,6 +152,288 @@ func TestCreateLoadFiles(t *testing.T) {
 	})
 }
 
+func TestCreateLoadFiles_Resume(t *testing.T) {
+	t.Parallel()
+
+	const uniqueLoadGenID = "unique_load_gen_id"
+
+	stagingFiles := getStagingFiles()
+	stagingFiles[0].Status = warehouseutils.StagingFileSucceededState
+
+	trackedRequest := func(stagingFile *model.StagingFile) loadfiles.WorkerJobRequest {
+		return loadfiles.WorkerJobRequest{
+			StagingFileID:       stagingFile.ID,
+			StagingFileLocation: stagingFile.Location,
+			UniqueLoadGenID:     uniqueLoadGenID,
+		}
+	}
+	notifier := &mockNotifier{
+		t:      t,
+		tables: []string{"track", "indentify"},
+		tracked: map[string][]loadfiles.WorkerJobRequest{
+			"batch_1": {trackedRequest(stagingFiles[0]), trackedRequest(stagingFiles[1]), trackedRequest(stagingFiles[2])},
+			// batch_2 no longer exists
+		},
+	}
+	stageRepo := &mockStageFilesRepo{}
+	loadRepo := &mockLoadFilesRepo{}
+	checkpointRepo := &mockLoadFileCheckpointRepo{
+		store: []model.LoadFileCheckpoint{
+			{UploadID: 1, BatchID: "batch_1", UniqueLoadGenID: uniqueLoadGenID, StagingFileIDs: []int64{0, 1, 2}},
+			{UploadID: 1, BatchID: "batch_2", UniqueLoadGenID: uniqueLoadGenID, StagingFileIDs: []int64{3, 4}},
+			{UploadID: 2, BatchID: "batch_3", UniqueLoadGenID: "other_unique_load_gen_id", StagingFileIDs: []int64{5}},
+		},
+	}
+
+	lf := loadfiles.LoadFileGenerator{
+		Logger:         logger.NOP,
+		Notifier:       notifier,
+		StageRepo:      stageRepo,
+		LoadRepo:       loadRepo,
+		CheckpointRepo: checkpointRepo,
+
+		ControlPlaneClient: &mockControlPlaneClient{},
+	}
+
+	job := model.UploadJob{
+		Warehouse: model.Warehouse{
+			Destination: backendconfig.DestinationT{
+				ID:         "destination_id",
+				RevisionID: "revision_id",
+			},
+			Type: warehouseutils.SNOWFLAKE,
+		},
+		Upload: model.Upload{
+			ID:               1,
+			DestinationID:    "destination_id",
+			DestinationType:  warehouseutils.SNOWFLAKE,
+			SourceID:         "source_id",
+			UseRudderStorage: true,
+		},
+		StagingFiles: stagingFiles,
+	}
+
+	_, _, err := lf.CreateLoadFiles(context.Background(), &job)
+	require.NoError(t, err)
+
+	var publishedIDs []int64
+	for _, req := range notifier.requests {
+		require.Equal(t, uniqueLoadGenID, req.UniqueLoadGenID, "load files should be generated in the folder of the resumed batches")
+		publishedIDs = append(publishedIDs, req.StagingFileID)
+	}
+	require.ElementsMatch(t, []int64{3, 4, 5, 6, 7, 8, 9}, publishedIDs, "only staging files of batches which no longer exist should be published again")
+
+	require.Len(t, loadRepo.store, (len(stagingFiles)-1)*len(notifier.tables), "load files of processed staging files should not be generated again")
+	for _, stagingFile := range stagingFiles[1:] {
+		require.Equal(t, warehouseutils.StagingFileSucceededState, stageRepo.store[stagingFile.ID].Status)
+	}
+
+	remaining, err := checkpointRepo.GetForUpload(context.Background(), 1)
+	require.NoError(t, err)
+	require.Empty(t, remaining, "checkpoints should be deleted once load files are generated")
+	remaining, err = checkpointRepo.GetForUpload(context.Background(), 2)
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+}
+
+type mockTwinRepo struct {
+	twins        []model.StagingFileTwin
+	uploadFiles  []*model.StagingFile
+	uploadsByIDs map[int64]model.Upload
+}
+
+func (m *mockTwinRepo) Twins(_ context.Context, stagingFileIDs []int64, _ string) ([]model.StagingFileTwin, error) {
+	var twins []model.StagingFileTwin
+	for _, twin := range m.twins {
+		if slices.Contains(stagingFileIDs, twin.StagingFileID) {
+			twins = append(twins, twin)
+		}
+	}
+	return twins, nil
+}
+
+func (m *mockTwinRepo) GetForUploadID(context.Context, string, string, int64) ([]*model.StagingFile, error) {
+	return m.uploadFiles, nil
+}
+
+func (m *mockTwinRepo) Get(_ context.Context, id int64) (model.Upload, error) {
+	upload, ok := m.uploadsByIDs[id]
+	if !ok {
+		return model.Upload{}, model.ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+func TestCreateLoadFiles_Reuse(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	schema := model.Schema{"tracks": {"id": "string"}}
+	stagingFiles := getStagingFiles()[:3]
+
+	setup := func(t *testing.T) (*loadfiles.LoadFileGenerator, *mockNotifier, *mockLoadFilesRepo, *model.UploadJob) {
+		t.Helper()
+		notifier := &mockNotifier{
+			t:      t,
+			tables: []string{"track", "indentify"},
+		}
+		loadRepo := &mockLoadFilesRepo{}
+		twinRepo := &mockTwinRepo{
+			uploadsByIDs: map[int64]model.Upload{
+				7: {
+					ID:               7,
+					SourceID:         "source_id",
+					DestinationID:    "from_destination_id",
+					DestinationType:  warehouseutils.POSTGRES,
+					LoadFileType:     "csv",
+					UseRudderStorage: true,
+					UploadSchema:     schema,
+				},
+			},
+		}
+		for _, stagingFile := range stagingFiles {
+			twinID := stagingFile.ID + 100
+			twinRepo.twins = append(twinRepo.twins, model.StagingFileTwin{StagingFileID: stagingFile.ID, TwinID: twinID, TwinUploadID: 7})
+			twinRepo.uploadFiles = append(twinRepo.uploadFiles, &model.StagingFile{ID: twinID})
+			require.NoError(t, loadRepo.Insert(ctx, []model.LoadFile{{
+				TableName:        "tracks",
+				Location:         fmt.Sprintf("s3://bucket/load/tracks/%d", twinID),
+				TotalRows:        10,
+				StagingFileID:    twinID,
+				UseRudderStorage: true,
+				SourceID:         "source_id",
+				DestinationID:    "from_destination_id",
+				DestinationType:  warehouseutils.POSTGRES,
+			}}))
+		}
+
+		lf := &loadfiles.LoadFileGenerator{
+			Conf:       config.New(),
+			Logger:     logger.NOP,
+			Notifier:   notifier,
+			StageRepo:  &mockStageFilesRepo{},
+			LoadRepo:   loadRepo,
+			TwinRepo:   twinRepo,
+			UploadRepo: twinRepo,
+
+			ControlPlaneClient: &mockControlPlaneClient{},
+		}
+		job := &model.UploadJob{
+			Warehouse: model.Warehouse{
+				Destination: backendconfig.DestinationT{
+					ID:         "destination_id",
+					RevisionID: "revision_id",
+					Config:     map[string]interface{}{model.LoadFilesFromSetting.String(): "from_destination_id"},
+				},
+				Type: warehouseutils.POSTGRES,
+			},
+			Upload: model.Upload{
+				ID:               8,
+				SourceID:         "source_id",
+				DestinationID:    "destination_id",
+				DestinationType:  warehouseutils.POSTGRES,
+				LoadFileType:     "csv",
+				UseRudderStorage: true,
+				UploadSchema:     schema,
+			},
+			StagingFiles: stagingFiles,
+		}
+		return lf, notifier, loadRepo, job
+	}
+
+	t.Run("reused", func(t *testing.T) {
+		lf, notifier, loadRepo, job := setup(t)
+
+		startID, endID, err := lf.CreateLoadFiles(ctx, job)
+		require.NoError(t, err)
+		require.Equal(t, int64(4), startID)
+		require.Equal(t, int64(6), endID)
+		require.Empty(t, notifier.requests, "no load files should be generated")
+
+		for _, stagingFile := range stagingFiles {
+			loadFiles, err := loadRepo.GetByStagingFiles(ctx, []int64{stagingFile.ID})
+			require.NoError(t, err)
+			require.Len(t, loadFiles, 1)
+			require.Equal(t, fmt.Sprintf("s3://bucket/load/tracks/%d", stagingFile.ID+100), loadFiles[0].Location)
+			require.Equal(t, "destination_id", loadFiles[0].DestinationID)
+			require.Equal(t, "revision_id", loadFiles[0].DestinationRevisionID)
+		}
+	})
+
+	t.Run("different upload schema", func(t *testing.T) {
+		lf, notifier, _, job := setup(t)
+		job.Upload.UploadSchema = model.Schema{"tracks": {"id": "int"}}
+
+		_, _, err := lf.CreateLoadFiles(ctx, job)
+		require.NoError(t, err)
+		require.Len(t, notifier.requests, len(stagingFiles), "load files should be generated")
+	})
+
+	t.Run("not the staging files of one upload", func(t *testing.T) {
+		lf, notifier, _, job := setup(t)
+		job.StagingFiles = stagingFiles[:2]
+
+		_, _, err := lf.CreateLoadFiles(ctx, job)
+		require.NoError(t, err)
+		require.Len(t, notifier.requests, 2, "load files should be generated")
+	})
+}
+
+func TestCreateLoadFiles_PublishBatchSize(t *testing.T) {
+	t.Parallel()
+	notifier := &mockNotifier{
+		t:      t,
+		tables: []string{"track"},
+	}
+
+	lf := loadfiles.LoadFileGenerator{
+		Logger:    logger.NOP,
+		Notifier:  notifier,
+		StageRepo: &mockStageFilesRepo{},
+		LoadRepo:  &mockLoadFilesRepo{},
+
+		ControlPlaneClient: &mockControlPlaneClient{},
+	}
+	conf := config.New()
+	conf.Set("Warehouse.loadFileGenerator.publishBatchSize", 5)
+	loadfiles.WithConfig(&lf, conf)
+
+	job := model.UploadJob{
+		Warehouse: model.Warehouse{
+			WorkspaceID: "workspace_id",
+			Destination: backendconfig.DestinationT{
+				ID:         "destination_id",
+				RevisionID: "revision_id",
+			},
+			Type: warehouseutils.SNOWFLAKE,
+		},
+		Upload: model.Upload{
+			DestinationID:    "destination_id",
+			DestinationType:  warehouseutils.SNOWFLAKE,
+			SourceID:         "source_id",
+			UseRudderStorage: true,
+		},
+		StagingFiles: getStagingFiles(),
+	}
+
+	_, _, err := lf.ForceCreateLoadFiles(context.Background(), &job)
+	require.NoError(t, err)
+	require.Equal(t, 2, notifier.published)
+
+	t.Log("changes are applied without creating the generator again")
+	conf.Set("Warehouse.loadFileGenerator.publishBatchSize", 4)
+	notifier.published = 0
+	_, _, err = lf.ForceCreateLoadFiles(context.Background(), &job)
+	require.NoError(t, err)
+	require.Equal(t, 3, notifier.published)
+
+	conf.Set("Warehouse.pgNotifierPublishBatchSizeWorkspaceIDs", map[string]interface{}{"workspace_id": float64(10)})
+	notifier.published = 0
+	_, _, err = lf.ForceCreateLoadFiles(context.Background(), &job)
+	require.NoError(t, err)
+	require.Equal(t, 1, notifier.published)
+}
+
 func TestCreateLoadFiles_Failure(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,37 @@
+package loadfiles_test
+
+import (
+	"context"
+
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+)
+
+type mockLoadFileCheckpointRepo struct {
+	store []model.LoadFileCheckpoint
+}
+
+func (m *mockLoadFileCheckpointRepo) Insert(_ context.Context, checkpoint model.LoadFileCheckpoint) error {
+	m.store = append(m.store, checkpoint)
+	return nil
+}
+
+func (m *mockLoadFileCheckpointRepo) GetForUpload(_ context.Context, uploadID int64) ([]model.LoadFileCheckpoint, error) {
+	var checkpoints []model.LoadFileCheckpoint
+	for _, checkpoint := range m.store {
+		if checkpoint.UploadID == uploadID {
+			checkpoints = append(checkpoints, checkpoint)
+		}
+	}
+	return checkpoints, nil
+}
+
+func (m *mockLoadFileCheckpointRepo) DeleteForUpload(_ context.Context, uploadID int64) error {
+	store := make([]model.LoadFileCheckpoint, 0)
+	for _, checkpoint := range m.store {
+		if checkpoint.UploadID != uploadID {
+			store = append(store, checkpoint)
+		}
+	}
+	m.store = store
+	return nil
+}
@@ -16,23 +16,42 @@ import (
 type mockNotifier struct {
 	t *testing.T
 
-	requests []loadfiles.WorkerJobRequest
-	tables   []string
+	requests  []loadfiles.WorkerJobRequest
+	published int // number of batches published
+	tables    []string
+	// tracked are the requests of the batches published earlier, by batch id
+	tracked map[string][]loadfiles.WorkerJobRequest
 }
 
 func (n *mockNotifier) Publish(_ context.Context, payload *notifier.PublishRequest) (<-chan *notifier.PublishResponse, error) {
-	var responses notifier.PublishResponse
+	var requests []loadfiles.WorkerJobRequest
 	for _, p := range payload.Payloads {
 		var req loadfiles.WorkerJobRequest
 		err := json.Unmarshal(p, &req)
 		require.NoError(n.t, err)
+		requests = append(requests, req)
+	}
+	n.requests = append(n.requests, requests...)
+	n.published++
 
+	ch := make(chan *notifier.PublishResponse, 1)
+	ch <- n.respond(requests)
+	return ch, nil
+}
+
+func (n *mockNotifier) Track(_ context.Context, batchID string) <-chan *notifier.PublishResponse {
+	ch := make(chan *notifier.PublishResponse, 1)
+	ch <- n.respond(n.tracked[batchID])
+	return ch
+}
+
+func (n *mockNotifier) respond(requests []loadfiles.WorkerJobRequest) *notifier.PublishResponse {
+	var responses notifier.PublishResponse
+	for _, req := range requests {
 		var loadFileUploads []loadfiles.LoadFileUpload
 		for _, tableName := range n.tables {
 			destinationRevisionID := req.DestinationRevisionID
 
-			n.requests = append(n.requests, req)
-
 			loadFileUploads = append(loadFileUploads, loadfiles.LoadFileUpload{
 				TableName:             tableName,
 				Location:              req.StagingFileLocation + "/" + req.UniqueLoadGenID + "/" + tableName,
@@ -67,7 +86,5 @@ func (n *mockNotifier) Publish(_ context.Context, payload *notifier.PublishReque
 		})
 	}
 
-	ch := make(chan *notifier.PublishResponse, 1)
-	ch <- &responses
-	return ch, nil
+	return &responses
 }
@@ -0,0 +1,21 @@
+package model
+
+// Capabilities are the features of a warehouse the uploads adapt to, rather than checking the destination type
+type Capabilities struct {
+	// Merge is whether the warehouse deduplicates the rows loaded into the tables through MERGE statements
+	Merge bool `json:"merge"`
+	// AlterColumn is whether the warehouse changes the type of the columns of the tables, through AlterColumn
+	AlterColumn bool `json:"alterColumn"`
+	// MaxColumns is the limit of the columns of the tables, 0 if the warehouse doesn't limit them
+	MaxColumns int `json:"maxColumns"`
+	// Transactions is whether the tables are loaded within transactions, so that failed loads leave no rows behind
+	Transactions bool `json:"transactions"`
+	// IdentityResolution is whether the identity merge rules and mappings tables are loaded into the warehouse
+	IdentityResolution bool `json:"identityResolution"`
+	// RegenerateLoadFilesOnResume is whether the uploads generate all their load files again when resumed, rather than
+	// the missing ones only
+	RegenerateLoadFilesOnResume bool `json:"regenerateLoadFilesOnResume"`
+	// TimeWindowLayout is whether the load files are laid out by time window, with the partitions of the tables
+	// refreshed once loaded
+	TimeWindowLayout bool `json:"timeWindowLayout"`
+}
@@ -16,3 +16,13 @@ type LoadFile struct {
 	DestinationType       string
 	CreatedAt             time.Time
 }
+
+// LoadFileCheckpoint records a batch of staging files published to the notifier for generating the load files
+// of an upload, so that generation resumes tracking the batch after a restart, instead of publishing it again.
+type LoadFileCheckpoint struct {
+	UploadID        int64
+	BatchID         string
+	UniqueLoadGenID string
+	StagingFileIDs  []int64
+	CreatedAt       time.Time
+}
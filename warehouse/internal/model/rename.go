@@ -0,0 +1,48 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrRenameNotFound = errors.New("rename not found")
+
+type RenameStatus string
+
+const (
+	RenameStatusWaiting   RenameStatus = "waiting"
+	RenameStatusFailed    RenameStatus = "failed"
+	RenameStatusAborted   RenameStatus = "aborted"
+	RenameStatusSucceeded RenameStatus = "succeeded"
+)
+
+// Rename is the rename of the namespace of a connection or of one of its tables in the warehouse, along with the
+// metadata of the connection, so that the uploads resume under the new name
+type Rename struct {
+	ID int64
+
+	WorkspaceID     string
+	SourceID        string
+	DestinationID   string
+	DestinationType string
+	Namespace       string
+	TableName       string // empty when renaming the namespace
+	NewName         string
+
+	Status   RenameStatus
+	Error    string
+	Attempts int
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// IsTableRename returns whether a table of the namespace gets renamed, rather than the namespace
+func (r Rename) IsTableRename() bool {
+	return r.TableName != ""
+}
+
+// Pending returns whether the rename is still to be executed, possibly retried
+func (r Rename) Pending() bool {
+	return r.Status == RenameStatusWaiting || r.Status == RenameStatusFailed
+}
@@ -18,6 +18,10 @@ const (
 	TextDataType           SchemaType = "text"
 	DateTimeDataType       SchemaType = "datetime"
 	ArrayOfBooleanDatatype SchemaType = "array(boolean)"
+
+	// DecimalDataType is the type of the numeric columns configured to be loaded as exact numerics instead of floats,
+	// e.g. revenue, see Warehouse.decimalColumns
+	DecimalDataType SchemaType = "decimal"
 )
 
 type WHSchema struct {
@@ -31,3 +35,33 @@ type WHSchema struct {
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
 }
+
+// EventSchema is a version of the schema observed for the table of an event, in the registry of the event schemas
+type EventSchema struct {
+	ID            int64
+	SourceID      string
+	DestinationID string
+	Namespace     string
+	TableName     string
+	Version       int
+	Properties    map[string]EventProperty
+	// Observations is the number of uploads the table was observed in, up to the version
+	Observations int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// EventProperty is a property of an event schema, along with the number of uploads it was observed in with its type
+type EventProperty struct {
+	Type      string `json:"type"`
+	Frequency int64  `json:"frequency"`
+}
+
+// EventSchemaChange is a new version of an event schema, along with the properties which got added or changed their
+// types since the previous version. The first version of a table has none of them.
+type EventSchemaChange struct {
+	TableName         string
+	Version           int
+	NewProperties     []string
+	ChangedProperties []string
+}
@@ -2,9 +2,12 @@ package model
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
 )
 
+var ErrStagingFileNotFound = errors.New("staging file not found")
+
 // StagingFile a domain model for a staging file.
 //
 //	The staging file contains events that should be loaded into a warehouse.
@@ -24,6 +27,8 @@ type StagingFile struct {
 	DestinationRevisionID string
 	TotalEvents           int
 	TotalBytes            int
+	// EventsPerTable is the number of events in the staging file by table, not recorded by older batch routers
+	EventsPerTable map[string]int
 	// cloud sources specific info
 	SourceTaskRunID string
 	SourceJobID     string
@@ -42,9 +47,38 @@ type StagingFileWithSchema struct {
 	Schema json.RawMessage
 }
 
+// StagingFileDelivery is the status of a staging file, along with the status of the upload loading it into the warehouse
+type StagingFileDelivery struct {
+	StagingFileID int64
+	Status        string
+	// UploadID and UploadStatus are only set once the staging file got picked up by an upload
+	UploadID     int64
+	UploadStatus string
+}
+
+// StagingFileTwin is the staging file of another destination stored at the same location as a staging file, i.e. the
+// staging file of the destination the load files get reused from, see LoadFilesFromSetting
+type StagingFileTwin struct {
+	StagingFileID int64
+	TwinID        int64
+	// TwinUploadID is only set once the twin got picked up by an upload
+	TwinUploadID int64
+}
+
 func (s StagingFile) WithSchema(schema json.RawMessage) StagingFileWithSchema {
 	return StagingFileWithSchema{
 		StagingFile: s,
 		Schema:      schema,
 	}
 }
+
+// UploadEstimate is an estimate of the next upload of a source and destination, computed from its pending staging files
+type UploadEstimate struct {
+	PendingStagingFiles int
+	TotalEvents         int64
+	TotalBytes          int64
+	// EventsPerTable only accounts for the staging files recording the number of their events by table
+	EventsPerTable map[string]int64
+	FirstEventAt   time.Time
+	LastEventAt    time.Time
+}
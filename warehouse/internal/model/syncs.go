@@ -21,6 +21,9 @@ type UploadInfo struct {
 	NextRetryTime    time.Time
 	Duration         time.Duration
 	IsArchivedUpload bool
+	Tags             map[string]string
+	// CleanupStatus is the status of the cleanup of the tables partially loaded by the upload, if aborted, see CleanupInfo
+	CleanupStatus string
 }
 
 type TableUploadInfo struct {
@@ -32,6 +35,12 @@ type TableUploadInfo struct {
 	LastExecAt time.Time
 	Count      int64
 	Duration   int64
+	// LoadAttempt is the number of attempts at loading the table, the last of which is described by LoadDuration,
+	// RowsLoaded and BytesLoaded
+	LoadAttempt  int64
+	LoadDuration int64 // in milliseconds
+	RowsLoaded   int64
+	BytesLoaded  int64
 }
 
 type RetrieveFailedBatchesRequest struct {
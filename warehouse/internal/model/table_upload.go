@@ -15,6 +15,12 @@ type TableUpload struct {
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	Location     string
+	// LoadAttempt is the number of attempts at loading the table, the last of which is described by LoadDuration,
+	// RowsLoaded and BytesLoaded. They are zero until the table is attempted to be loaded.
+	LoadAttempt  int64
+	LoadDuration time.Duration
+	RowsLoaded   int64
+	BytesLoaded  int64
 }
 
 const (
@@ -26,4 +32,9 @@ const (
 	TableUploadExporting            = "exporting_data"
 	TableUploadExportingFailed      = "exporting_data_failed"
 	TableUploadExported             = "exported_data"
+	// TableUploadQualityCheckFailed is the status of the tables which were loaded but failed their data quality assertions
+	TableUploadQualityCheckFailed = "quality_check_failed"
+	// TableUploadIngesting is the status of the tables whose load files were submitted to the warehouse to be ingested
+	// asynchronously, until the ingestion is confirmed
+	TableUploadIngesting = "ingesting"
 )
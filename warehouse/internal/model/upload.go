@@ -22,6 +22,10 @@ const (
 	ExportingDataFailed       = "exporting_data_failed"
 	Aborted                   = "aborted"
 	Failed                    = "failed"
+	// AwaitingIngestion is the status of the uploads waiting for the warehouse to confirm the ingestion of their data
+	AwaitingIngestion = "awaiting_ingestion"
+	// PermissionDenied is the status of the uploads stopped on a permission error until the destination config changes
+	PermissionDenied = "permission_denied"
 )
 
 type JobErrorType = string
@@ -35,6 +39,8 @@ const (
 	ColumnSizeError           JobErrorType = "column_size_error"
 	InsufficientResourceError JobErrorType = "insufficient_resource_error"
 	ConcurrentQueriesError    JobErrorType = "concurrent_queries_error"
+	// StageTimedOutError is the type of the errors of the states of the uploads cancelled for exceeding their timeout
+	StageTimedOutError JobErrorType = "stage_timed_out"
 )
 
 var userFriendlyJobErrorCategoryMap = map[JobErrorType]string{
@@ -46,6 +52,7 @@ var userFriendlyJobErrorCategoryMap = map[JobErrorType]string{
 	ColumnSizeError:           "Column size error",
 	InsufficientResourceError: "Insufficient resource error",
 	ConcurrentQueriesError:    "Concurrent queries error",
+	StageTimedOutError:        "Stage timed out error",
 }
 
 type JobError struct {
@@ -104,6 +111,43 @@ type Upload struct {
 	Attempts       int64
 
 	UploadSchema Schema
+
+	PermissionDenied *PermissionDeniedInfo
+
+	// Cleanup is set for the aborted uploads whose partially loaded tables got cleaned up, see CleanupOnAbortSetting
+	Cleanup *CleanupInfo
+
+	// Tags are arbitrary metadata attached to the upload when triggering it, e.g. who triggered it or the ticket it was triggered for
+	Tags map[string]string
+}
+
+// PermissionDeniedInfo is what an upload stopped on a permission error needs to be resumed, and to tell the user what
+// to grant
+type PermissionDeniedInfo struct {
+	// State is the failed state the upload is resumed from
+	State string `json:"state"`
+	// Privilege is the missing privilege, in the form to grant it
+	Privilege string `json:"privilege,omitempty"`
+	// RevisionID is the revision of the destination config the error happened with
+	RevisionID string `json:"revision_id"`
+}
+
+// Statuses of the cleanup of the tables partially loaded by an aborted upload
+const (
+	CleanupSucceeded = "succeeded"
+	CleanupFailed    = "failed"
+	// CleanupUnsupported is set if the warehouse can't delete the rows loaded by an upload
+	CleanupUnsupported = "unsupported"
+)
+
+// CleanupInfo is the status of the cleanup of the tables partially loaded by an aborted upload, whose rows loaded by the
+// upload get deleted so that downstream consumers don't see a partially loaded sync
+type CleanupInfo struct {
+	Status string `json:"status"`
+	// Tables are the tables whose rows loaded by the upload got deleted
+	Tables      []string  `json:"tables,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CompletedAt time.Time `json:"completed_at"`
 }
 
 type Timings []map[string]time.Time
@@ -143,6 +187,26 @@ func GetLastFailedStatus(timingsMap Timings) (status string) {
 	return // zero values
 }
 
+// GetLastTiming returns the last state the upload entered as per its timings, along with when it entered it
+func GetLastTiming(timingsMap Timings) (status string, t time.Time) {
+	if len(timingsMap) > 0 {
+		for s, t := range timingsMap[len(timingsMap)-1] {
+			return s, t
+		}
+	}
+	return // zero values
+}
+
+// GetTiming returns when the upload last entered the state as per its timings
+func GetTiming(timingsMap Timings, status string) (t time.Time) {
+	for index := len(timingsMap) - 1; index >= 0; index-- {
+		if t, ok := timingsMap[index][status]; ok {
+			return t
+		}
+	}
+	return // zero values
+}
+
 func GetLoadFileGenTime(timingsMap Timings) (t time.Time) {
 	if len(timingsMap) > 0 {
 		for index := len(timingsMap) - 1; index >= 0; index-- {
@@ -178,6 +242,8 @@ type SyncUploadOptions struct {
 	DestinationType string
 	Status          string
 	UploadID        int64
+	// Tags filters the uploads having all of the tags
+	Tags map[string]string
 }
 
 type LatestUploadInfo struct {
@@ -185,3 +251,21 @@ type LatestUploadInfo struct {
 	Status   string
 	Priority int
 }
+
+// SyncStatus is the state of the uploads of a connection, for external schedulers to wait on its syncs
+type SyncStatus struct {
+	LastCreatedAt   time.Time
+	LastSucceededAt time.Time // zero if no upload got exported yet
+	PendingUploads  int64
+	// InProgress is the oldest upload neither exported nor aborted yet, i.e. the one in progress or next to be, nil if none
+	InProgress *InProgressUpload
+}
+
+type InProgressUpload struct {
+	ID     int64
+	Status string
+	// Stage is the last state the upload entered as per its timings, along with when it entered it. Empty until the
+	// upload gets picked up.
+	Stage          string
+	StageStartedAt time.Time
+}
@@ -11,6 +11,29 @@ const (
 	VerifyingLoadTable           = "Verifying Load Table"
 )
 
+// the stages of the connection tests, in order
+const (
+	ConnectionTestSetup       = "setup"
+	ConnectionTestConnect     = "connect"
+	ConnectionTestPermissions = "permissions"
+)
+
+// the types of the errors of the connection tests, besides those of the error mappings of the warehouses
+const (
+	InvalidConfigurationError JobErrorType = "invalid_configuration_error"
+	ConnectionError           JobErrorType = "connection_error"
+	ConnectionTimeoutError    JobErrorType = "connection_timeout_error"
+)
+
+// ConnectionTestResponse is the result of testing the connection to the warehouse of a destination, along with the
+// stage and the type of the error it failed with, if any
+type ConnectionTestResponse struct {
+	Success   bool         `json:"success"`
+	Stage     string       `json:"stage,omitempty"`
+	ErrorType JobErrorType `json:"errorType,omitempty"`
+	Error     string       `json:"error,omitempty"`
+}
+
 type ValidationRequest struct {
 	Path        string
 	Step        string
@@ -36,6 +36,8 @@ var (
 	CredentialsSetting            DestinationConfigSetting = destConfSetting("credentials")
 	LocationSetting               DestinationConfigSetting = destConfSetting("location")
 	CACertificateSetting          DestinationConfigSetting = destConfSetting("caCertificate")
+	ClientCertSetting             DestinationConfigSetting = destConfSetting("clientCert")
+	ClientKeySetting              DestinationConfigSetting = destConfSetting("clientKey")
 	ClusterSetting                DestinationConfigSetting = destConfSetting("cluster")
 	AWSAccessKeySetting           DestinationConfigSetting = destConfSetting("accessKey")
 	AWSAccessSecretSetting        DestinationConfigSetting = destConfSetting("accessKeyID")
@@ -50,6 +52,7 @@ var (
 	ExternalLocationSetting       DestinationConfigSetting = destConfSetting("externalLocation")
 	UseIAMForAuthSetting          DestinationConfigSetting = destConfSetting("useIAMForAuth")
 	IAMRoleARNForAuthSetting      DestinationConfigSetting = destConfSetting("iamRoleARNForAuth")
+	IAMRoleARNForCopySetting      DestinationConfigSetting = destConfSetting("iamRoleARNForCopy")
 	ClusterIDSetting              DestinationConfigSetting = destConfSetting("clusterId")
 	ClusterRegionSetting          DestinationConfigSetting = destConfSetting("clusterRegion")
 	StorageIntegrationSetting     DestinationConfigSetting = destConfSetting("storageIntegration")
@@ -63,6 +66,112 @@ var (
 	SyncFrequencySetting          DestinationConfigSetting = destConfSetting("syncFrequency")
 	SyncStartAtSetting            DestinationConfigSetting = destConfSetting("syncStartAt")
 	ExcludeWindowSetting          DestinationConfigSetting = destConfSetting("excludeWindow")
+
+	SyncTriggerSetting              DestinationConfigSetting = destConfSetting("syncTrigger")
+	DbtCloudURLSetting              DestinationConfigSetting = destConfSetting("dbtCloudUrl")
+	DbtCloudAccountIDSetting        DestinationConfigSetting = destConfSetting("dbtCloudAccountId")
+	DbtCloudJobIDSetting            DestinationConfigSetting = destConfSetting("dbtCloudJobId")
+	DbtCloudTokenSetting            DestinationConfigSetting = destConfSetting("dbtCloudToken")
+	AirflowURLSetting               DestinationConfigSetting = destConfSetting("airflowUrl")
+	AirflowDagIDSetting             DestinationConfigSetting = destConfSetting("airflowDagId")
+	AirflowUsernameSetting          DestinationConfigSetting = destConfSetting("airflowUsername")
+	AirflowPasswordSetting          DestinationConfigSetting = destConfSetting("airflowPassword")
+	SyncTriggerWebhookURLSetting    DestinationConfigSetting = destConfSetting("syncTriggerWebhookUrl")
+	SyncTriggerWebhookSecretSetting DestinationConfigSetting = destConfSetting("syncTriggerWebhookSecret")
+
+	UploadWebhookURLSetting    DestinationConfigSetting = destConfSetting("uploadWebhookUrl")
+	UploadWebhookSecretSetting DestinationConfigSetting = destConfSetting("uploadWebhookSecret")
+	// UploadWebhookEventsSetting is the comma separated transitions of the uploads the webhook is notified of, all of
+	// them if not set
+	UploadWebhookEventsSetting DestinationConfigSetting = destConfSetting("uploadWebhookEvents")
+
+	QualityAssertionsSetting DestinationConfigSetting = destConfSetting("qualityAssertions")
+
+	PartitionColumnSetting        DestinationConfigSetting = destConfSetting("partitionColumn")
+	PartitionTypeSetting          DestinationConfigSetting = destConfSetting("partitionType")
+	RequirePartitionFilterSetting DestinationConfigSetting = destConfSetting("requirePartitionFilter")
+	ClusteringColumnsSetting      DestinationConfigSetting = destConfSetting("clusteringColumns")
+
+	UseSpectrumSetting     DestinationConfigSetting = destConfSetting("useSpectrum")
+	SpectrumSchemaSetting  DestinationConfigSetting = destConfSetting("spectrumSchema")
+	SpectrumIAMRoleSetting DestinationConfigSetting = destConfSetting("spectrumIamRole")
+
+	TableEnginesSetting DestinationConfigSetting = destConfSetting("tableEngines")
+
+	SchemaPerSourceSetting   DestinationConfigSetting = destConfSetting("schemaPerSource")
+	OptimizeBackfillsSetting DestinationConfigSetting = destConfSetting("optimizeBackfills")
+
+	NamespaceTemplateSetting DestinationConfigSetting = destConfSetting("namespaceTemplate")
+
+	UseSnowpipeSetting DestinationConfigSetting = destConfSetting("useSnowpipe")
+
+	TimestampOffsetSetting DestinationConfigSetting = destConfSetting("timestampOffset")
+	TimestampTypeSetting   DestinationConfigSetting = destConfSetting("timestampType")
+
+	TableLayoutSetting     DestinationConfigSetting = destConfSetting("tableLayout")
+	EventCategoriesSetting DestinationConfigSetting = destConfSetting("eventCategories")
+
+	OversizedValuesSetting DestinationConfigSetting = destConfSetting("oversizedValues")
+
+	CleanupOnAbortSetting DestinationConfigSetting = destConfSetting("cleanupOnAbort")
+
+	// StagingSchemaSetting is the schema the staging tables get created in instead of the namespace, so that they don't
+	// show up next to the tables of the events and can be granted separately. The staging schema is expected to be
+	// dedicated to the destination, since dangling staging tables get dropped from it.
+	StagingSchemaSetting DestinationConfigSetting = destConfSetting("stagingSchema")
+
+	// WarehouseEndpointSetting names the physical warehouse the destination loads into, so that the uploads and table
+	// loads of the destinations of the workspace sharing it are limited collectively
+	WarehouseEndpointSetting DestinationConfigSetting = destConfSetting("warehouseEndpoint")
+
+	// LoadFilesFromSetting names the destination the destination reuses the load files of, instead of generating its
+	// own from the same staging files, see Warehouse.LoadsFilesFrom
+	LoadFilesFromSetting DestinationConfigSetting = destConfSetting("loadFilesFrom")
+)
+
+// WorkspaceDefaultSettings are the settings the warehouse destinations inherit from the warehouseDefaults of the
+// settings of their workspace, unless set for the destination
+var WorkspaceDefaultSettings = []DestinationConfigSetting{
+	SyncFrequencySetting,
+	SyncStartAtSetting,
+	ExcludeWindowSetting,
+	PreferAppendSetting,
+	TimestampOffsetSetting,
+	TimestampTypeSetting,
+	OversizedValuesSetting,
+}
+
+// Handling of the string values beyond the max value size of the destination type, see Warehouse.<destType>.maxValueSize
+const (
+	// OversizedValuesDiscard loads the values into the discards table with the reason, the default
+	OversizedValuesDiscard = "discard"
+	// OversizedValuesTruncate truncates the values to the max value size, ending them with a marker
+	OversizedValuesTruncate = "truncate"
+	// OversizedValuesOverflow moves the values into the overflow column of the table, as a JSON object
+	OversizedValuesOverflow = "overflow"
+)
+
+// Table layouts of the track events, i.e. the tables the rows of the events get loaded into
+const (
+	// TableLayoutPerEvent loads the events into a table per event, the default
+	TableLayoutPerEvent = "perEvent"
+	// TableLayoutUnified loads all the events into one events table
+	TableLayoutUnified = "unified"
+	// TableLayoutCategory loads the events into a table per category, as configured in EventCategoriesSetting. Events
+	// without a category are loaded into a table per event.
+	TableLayoutCategory = "category"
+)
+
+const (
+	// TimestampOffsetUTC normalizes the timestamps of the events to UTC in the load files, the default
+	TimestampOffsetUTC = "utc"
+	// TimestampOffsetPreserve keeps the offsets the timestamps of the events came with in the load files
+	TimestampOffsetPreserve = "preserve"
+
+	// TimestampTypeWithTimeZone creates the datetime columns with a time zone, i.e. TIMESTAMPTZ
+	TimestampTypeWithTimeZone = "timestamptz"
+	// TimestampTypeWithoutTimeZone creates the datetime columns without a time zone, i.e. TIMESTAMP
+	TimestampTypeWithoutTimeZone = "timestamp"
 )
 
 type Warehouse struct {
@@ -74,6 +183,20 @@ type Warehouse struct {
 	Identifier  string
 }
 
+// LoadsFilesFrom returns whether the warehouse reuses the load files generated for the other warehouse, i.e. it names
+// the other destination in its loadFilesFrom setting, both are connected to the same source, have the same type and
+// stage into the same bucket. The other destination can't reuse the load files of another destination in turn.
+func (w *Warehouse) LoadsFilesFrom(conf *config.Config, other Warehouse) bool {
+	return other.Destination.ID != "" &&
+		other.Destination.ID != w.Destination.ID &&
+		w.GetStringDestinationConfig(conf, LoadFilesFromSetting) == other.Destination.ID &&
+		other.GetStringDestinationConfig(conf, LoadFilesFromSetting) == "" &&
+		w.Source.ID == other.Source.ID &&
+		w.Type == other.Type &&
+		w.GetBoolDestinationConfig(UseRudderStorageSetting) == other.GetBoolDestinationConfig(UseRudderStorageSetting) &&
+		w.GetStringDestinationConfig(conf, AWSBucketNameSetting) == other.GetStringDestinationConfig(conf, AWSBucketNameSetting)
+}
+
 func (w *Warehouse) GetBoolDestinationConfig(key DestinationConfigSetting) bool {
 	destConfig := w.Destination.Config
 	if destConfig[key.String()] != nil {
@@ -109,6 +232,17 @@ func (w *Warehouse) GetMapDestinationConfig(key DestinationConfigSetting) map[st
 	return map[string]interface{}{}
 }
 
+// GetTimestampTypeSetting returns the type the datetime columns get created with, either TimestampTypeWithTimeZone or
+// TimestampTypeWithoutTimeZone. Empty if not set, in which case the default type of the destination is used.
+func (w *Warehouse) GetTimestampTypeSetting(conf *config.Config) string {
+	switch timestampType := w.GetStringDestinationConfig(conf, TimestampTypeSetting); timestampType {
+	case TimestampTypeWithTimeZone, TimestampTypeWithoutTimeZone:
+		return timestampType
+	default:
+		return ""
+	}
+}
+
 func (w *Warehouse) GetPreferAppendSetting() bool {
 	destConfig := w.Destination.Config
 	value, ok := destConfig[PreferAppendSetting.String()].(bool)
@@ -209,3 +209,65 @@ func TestWarehouse_GetStringDestinationConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestWarehouse_GetTimestampTypeSetting(t *testing.T) {
+	testCases := []struct {
+		name          string
+		timestampType any
+		expected      string
+	}{
+		{name: "with time zone", timestampType: "timestamptz", expected: TimestampTypeWithTimeZone},
+		{name: "without time zone", timestampType: "timestamp", expected: TimestampTypeWithoutTimeZone},
+		{name: "unknown type", timestampType: "datetime", expected: ""},
+		{name: "not set", timestampType: nil, expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			warehouse := Warehouse{
+				Destination: backendconfig.DestinationT{
+					Config: map[string]interface{}{
+						"timestampType": tc.timestampType,
+					},
+				},
+			}
+			require.Equal(t, tc.expected, warehouse.GetTimestampTypeSetting(config.New()))
+		})
+	}
+}
+
+func TestWarehouse_LoadsFilesFrom(t *testing.T) {
+	warehouse := func(destinationID, destType string, destConfig map[string]interface{}) Warehouse {
+		return Warehouse{
+			Source:      backendconfig.SourceT{ID: "source-1"},
+			Destination: backendconfig.DestinationT{ID: destinationID, Config: destConfig},
+			Type:        destType,
+		}
+	}
+	from := warehouse("destination-1", "RS", map[string]interface{}{"bucketName": "bucket"})
+
+	testCases := []struct {
+		name      string
+		warehouse Warehouse
+		expected  bool
+	}{
+		{name: "mirror", warehouse: warehouse("destination-2", "RS", map[string]interface{}{"bucketName": "bucket", "loadFilesFrom": "destination-1"}), expected: true},
+		{name: "not set", warehouse: warehouse("destination-2", "RS", map[string]interface{}{"bucketName": "bucket"})},
+		{name: "other destination", warehouse: warehouse("destination-2", "RS", map[string]interface{}{"bucketName": "bucket", "loadFilesFrom": "destination-3"})},
+		{name: "other type", warehouse: warehouse("destination-2", "SNOWFLAKE", map[string]interface{}{"bucketName": "bucket", "loadFilesFrom": "destination-1"})},
+		{name: "other bucket", warehouse: warehouse("destination-2", "RS", map[string]interface{}{"bucketName": "other-bucket", "loadFilesFrom": "destination-1"})},
+		{name: "itself", warehouse: warehouse("destination-1", "RS", map[string]interface{}{"bucketName": "bucket", "loadFilesFrom": "destination-1"})},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.warehouse.LoadsFilesFrom(config.New(), from))
+		})
+	}
+
+	t.Run("chained", func(t *testing.T) {
+		chained := warehouse("destination-1", "RS", map[string]interface{}{"bucketName": "bucket", "loadFilesFrom": "destination-0"})
+		mirror := warehouse("destination-2", "RS", map[string]interface{}{"bucketName": "bucket", "loadFilesFrom": "destination-1"})
+		require.False(t, mirror.LoadsFilesFrom(config.New(), chained))
+	})
+}
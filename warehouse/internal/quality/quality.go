@@ -0,0 +1,168 @@
+// Package quality evaluates the data quality assertions configured per table for the warehouse destinations, i.e. a
+// minimum row count, null-rate thresholds on key columns and freshness, against the rows of the tables loaded by an
+// upload, after they are loaded.
+package quality
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Assertions are the assertions of a table, set in the qualityAssertions setting of the destinations keyed by table
+// name, e.g. {"tracks": {"minRowCount": 1, "maxNullRates": {"user_id": 0.05}, "freshnessColumn": "received_at", "maxStaleness": "24h"}}
+type Assertions struct {
+	// MinRowCount is the minimum number of rows loaded into the table, if positive
+	MinRowCount int64 `json:"minRowCount"`
+	// MaxNullRates are the maximum ratios of null values of the columns of the table, between 0 and 1
+	MaxNullRates map[string]float64 `json:"maxNullRates"`
+	// FreshnessColumn is the timestamp column of the table which should hold a value newer than MaxStaleness
+	FreshnessColumn string `json:"freshnessColumn"`
+	MaxStaleness    string `json:"maxStaleness"`
+}
+
+// Filter restricts the evaluated rows to the ones with a value of Column within [Since, Until], e.g. to the rows loaded
+// by an upload, or to the partitions of a table holding them. Either bound is left open if zero.
+type Filter struct {
+	Column string
+	Since  time.Time
+	Until  time.Time
+}
+
+// Querier runs queries against a warehouse, e.g. the client of a warehouse manager
+type Querier interface {
+	Query(statement string) (warehouseutils.QueryResult, error)
+}
+
+// TableAssertions returns the assertions configured for the table of the warehouse, if any
+func TableAssertions(warehouse model.Warehouse, tableName string) (*Assertions, error) {
+	for table, value := range warehouse.GetMapDestinationConfig(model.QualityAssertionsSetting) {
+		if !strings.EqualFold(table, tableName) {
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling assertions: %w", err)
+		}
+		var assertions Assertions
+		if err := json.Unmarshal(raw, &assertions); err != nil {
+			return nil, fmt.Errorf("unmarshalling assertions: %w", err)
+		}
+		return &assertions, nil
+	}
+	return nil, nil
+}
+
+// Evaluate evaluates the assertions against the rows of the table of the namespace matching the filters with a single
+// query, returning the failed ones
+func Evaluate(querier Querier, destType, namespace, tableName string, assertions Assertions, filters []Filter, now time.Time) ([]string, error) {
+	columns := make([]string, 0, len(assertions.MaxNullRates))
+	for column := range assertions.MaxNullRates {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	for _, identifier := range append([]string{namespace, tableName}, columns...) {
+		if !identifierRegex.MatchString(identifier) {
+			return nil, fmt.Errorf("invalid identifier: %q", identifier)
+		}
+	}
+	var conditions []string
+	for _, filter := range filters {
+		if !identifierRegex.MatchString(filter.Column) {
+			return nil, fmt.Errorf("invalid identifier: %q", filter.Column)
+		}
+		if !filter.Since.IsZero() {
+			conditions = append(conditions, fmt.Sprintf("%s >= '%s'", quote(destType, filter.Column), formatTime(filter.Since)))
+		}
+		if !filter.Until.IsZero() {
+			// the bound is rounded up to the next second, since it is formatted without its fractional seconds
+			until := filter.Until.Truncate(time.Second).Add(time.Second)
+			conditions = append(conditions, fmt.Sprintf("%s < '%s'", quote(destType, filter.Column), formatTime(until)))
+		}
+	}
+	selects := []string{"COUNT(*)"}
+	for _, column := range columns {
+		selects = append(selects, fmt.Sprintf("COUNT(%s)", quote(destType, column)))
+	}
+	var freshSince time.Time
+	if assertions.FreshnessColumn != "" {
+		if !identifierRegex.MatchString(assertions.FreshnessColumn) {
+			return nil, fmt.Errorf("invalid identifier: %q", assertions.FreshnessColumn)
+		}
+		maxStaleness, err := time.ParseDuration(assertions.MaxStaleness)
+		if err != nil {
+			return nil, fmt.Errorf("parsing max staleness: %w", err)
+		}
+		freshSince = now.Add(-maxStaleness).UTC()
+		selects = append(selects, fmt.Sprintf("COUNT(CASE WHEN %s >= '%s' THEN 1 END)",
+			quote(destType, assertions.FreshnessColumn), formatTime(freshSince),
+		))
+	}
+
+	statement := fmt.Sprintf("SELECT %s FROM %s.%s",
+		strings.Join(selects, ", "), quote(destType, namespace), quote(destType, tableName),
+	)
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	result, err := querier.Query(statement)
+	if err != nil {
+		return nil, fmt.Errorf("querying table: %w", err)
+	}
+	if len(result.Values) != 1 || len(result.Values[0]) != len(selects) {
+		return nil, fmt.Errorf("unexpected query result: %v", result.Values)
+	}
+	counts := make([]int64, len(selects))
+	for i, value := range result.Values[0] {
+		if counts[i], err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, fmt.Errorf("parsing count: %w", err)
+		}
+	}
+
+	var failures []string
+	total := counts[0]
+	if assertions.MinRowCount > 0 && total < assertions.MinRowCount {
+		failures = append(failures, fmt.Sprintf("row count %d is lower than %d", total, assertions.MinRowCount))
+	}
+	for i, column := range columns {
+		if total == 0 {
+			break
+		}
+		nullRate := float64(total-counts[i+1]) / float64(total)
+		if maxNullRate := assertions.MaxNullRates[column]; nullRate > maxNullRate {
+			failures = append(failures, fmt.Sprintf("null rate %.4f of column %s is higher than %.4f", nullRate, column, maxNullRate))
+		}
+	}
+	if assertions.FreshnessColumn != "" && counts[len(counts)-1] == 0 {
+		failures = append(failures, fmt.Sprintf("no rows with %s newer than %s", assertions.FreshnessColumn, freshSince.Format(time.RFC3339)))
+	}
+	return failures, nil
+}
+
+// formatTime formats the time as a timestamp literal, in UTC
+func formatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// quote quotes the identifier for the warehouse, in the case of the warehouse
+func quote(destType, identifier string) string {
+	identifier = warehouseutils.ToProviderCase(destType, identifier)
+	switch destType {
+	case warehouseutils.BQ, warehouseutils.DELTALAKE:
+		return "`" + identifier + "`"
+	case warehouseutils.MSSQL, warehouseutils.AzureSynapse:
+		return "[" + identifier + "]"
+	default:
+		return `"` + identifier + `"`
+	}
+}
@@ -0,0 +1,133 @@
+package quality_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/quality"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+type mockQuerier struct {
+	statement string
+	result    warehouseutils.QueryResult
+	err       error
+}
+
+func (m *mockQuerier) Query(statement string) (warehouseutils.QueryResult, error) {
+	m.statement = statement
+	return m.result, m.err
+}
+
+func TestTableAssertions(t *testing.T) {
+	warehouse := model.Warehouse{
+		Destination: backendconfig.DestinationT{
+			Config: map[string]interface{}{
+				"qualityAssertions": map[string]interface{}{
+					"tracks": map[string]interface{}{
+						"minRowCount":     1,
+						"maxNullRates":    map[string]interface{}{"user_id": 0.05},
+						"freshnessColumn": "received_at",
+						"maxStaleness":    "24h",
+					},
+					"users": "invalid",
+				},
+			},
+		},
+	}
+
+	assertions, err := quality.TableAssertions(warehouse, "TRACKS")
+	require.NoError(t, err)
+	require.Equal(t, &quality.Assertions{
+		MinRowCount:     1,
+		MaxNullRates:    map[string]float64{"user_id": 0.05},
+		FreshnessColumn: "received_at",
+		MaxStaleness:    "24h",
+	}, assertions)
+
+	assertions, err = quality.TableAssertions(warehouse, "pages")
+	require.NoError(t, err)
+	require.Nil(t, assertions)
+
+	_, err = quality.TableAssertions(warehouse, "users")
+	require.Error(t, err)
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2024, 10, 2, 12, 0, 0, 0, time.UTC)
+	assertions := quality.Assertions{
+		MinRowCount:     10,
+		MaxNullRates:    map[string]float64{"user_id": 0.1, "event": 0},
+		FreshnessColumn: "received_at",
+		MaxStaleness:    "24h",
+	}
+
+	t.Run("passing", func(t *testing.T) {
+		querier := &mockQuerier{result: warehouseutils.QueryResult{Values: [][]string{{"100", "100", "95", "3"}}}}
+		failures, err := quality.Evaluate(querier, warehouseutils.POSTGRES, "namespace", "tracks", assertions, nil, now)
+		require.NoError(t, err)
+		require.Empty(t, failures)
+		require.Equal(t, `SELECT COUNT(*), COUNT("event"), COUNT("user_id"), COUNT(CASE WHEN "received_at" >= '2024-10-01 12:00:00' THEN 1 END) FROM "namespace"."tracks"`, querier.statement)
+	})
+
+	t.Run("failing", func(t *testing.T) {
+		querier := &mockQuerier{result: warehouseutils.QueryResult{Values: [][]string{{"5", "4", "5", "0"}}}}
+		failures, err := quality.Evaluate(querier, warehouseutils.POSTGRES, "namespace", "tracks", assertions, nil, now)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"row count 5 is lower than 10",
+			"null rate 0.2000 of column event is higher than 0.0000",
+			"no rows with received_at newer than 2024-10-01T12:00:00Z",
+		}, failures)
+	})
+
+	t.Run("filtered", func(t *testing.T) {
+		querier := &mockQuerier{result: warehouseutils.QueryResult{Values: [][]string{{"10"}}}}
+		filters := []quality.Filter{
+			{Column: "received_at", Since: now.Add(-time.Hour), Until: now.Add(-time.Minute + 500*time.Millisecond)},
+			{Column: "_PARTITIONTIME", Since: now.Truncate(24 * time.Hour)},
+		}
+		failures, err := quality.Evaluate(querier, warehouseutils.BQ, "namespace", "tracks", quality.Assertions{MinRowCount: 1}, filters, now)
+		require.NoError(t, err)
+		require.Empty(t, failures)
+		require.Equal(t, "SELECT COUNT(*) FROM `namespace`.`tracks` WHERE `received_at` >= '2024-10-02 11:00:00' AND `received_at` < '2024-10-02 11:59:01' AND `_PARTITIONTIME` >= '2024-10-02 00:00:00'", querier.statement)
+
+		_, err = quality.Evaluate(querier, warehouseutils.BQ, "namespace", "tracks", quality.Assertions{MinRowCount: 1}, []quality.Filter{{Column: "received_at; --", Since: now}}, now)
+		require.ErrorContains(t, err, "invalid identifier")
+	})
+
+	t.Run("quoting", func(t *testing.T) {
+		testCases := []struct {
+			destType string
+			want     string
+		}{
+			{destType: warehouseutils.BQ, want: "SELECT COUNT(*) FROM `namespace`.`tracks`"},
+			{destType: warehouseutils.MSSQL, want: "SELECT COUNT(*) FROM [namespace].[tracks]"},
+			{destType: warehouseutils.SNOWFLAKE, want: `SELECT COUNT(*) FROM "NAMESPACE"."TRACKS"`},
+		}
+		for _, tc := range testCases {
+			querier := &mockQuerier{result: warehouseutils.QueryResult{Values: [][]string{{"1"}}}}
+			failures, err := quality.Evaluate(querier, tc.destType, "namespace", "tracks", quality.Assertions{MinRowCount: 1}, nil, now)
+			require.NoError(t, err)
+			require.Empty(t, failures)
+			require.Equal(t, tc.want, querier.statement)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		querier := &mockQuerier{err: errors.New("table not found")}
+		_, err := quality.Evaluate(querier, warehouseutils.POSTGRES, "namespace", "tracks", assertions, nil, now)
+		require.ErrorContains(t, err, "table not found")
+
+		_, err = quality.Evaluate(querier, warehouseutils.POSTGRES, "namespace", "tracks", quality.Assertions{MaxNullRates: map[string]float64{`user_id"; DROP TABLE tracks; --`: 0}}, nil, now)
+		require.ErrorContains(t, err, "invalid identifier")
+
+		_, err = quality.Evaluate(querier, warehouseutils.POSTGRES, "namespace", "tracks", quality.Assertions{FreshnessColumn: "received_at", MaxStaleness: "a day"}, nil, now)
+		require.ErrorContains(t, err, "parsing max staleness")
+	})
+}
@@ -0,0 +1,289 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/samber/lo"
+
+	"github.com/rudderlabs/rudder-server/utils/timeutil"
+	sqlmiddleware "github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+const eventSchemasTableName = warehouseutils.WarehouseEventSchemasTable
+
+const eventSchemaColumns = `
+	id,
+	source_id,
+	destination_id,
+	namespace,
+	table_name,
+	version,
+	properties,
+	observations,
+	created_at,
+	updated_at
+`
+
+// EventSchemas is the registry of the schemas observed for the tables of the events, keeping their versions
+type EventSchemas repo
+
+func NewEventSchemas(db *sqlmiddleware.DB, opts ...Opt) *EventSchemas {
+	r := &EventSchemas{
+		db:  db,
+		now: timeutil.Now,
+	}
+
+	for _, opt := range opts {
+		opt((*repo)(r))
+	}
+	return r
+}
+
+// Observe records the schema observed by an upload in its staging files, up to the staging file id. The frequencies of
+// the observed properties are incremented in the latest versions of the tables, while a new version is created for
+// the tables with properties added or changed in their types, which are returned.
+//
+// Staging files already observed are skipped, so that the retries of an upload are not counted again.
+func (es *EventSchemas) Observe(ctx context.Context, sourceID, destinationID, namespace string, stagingFileID int64, schema model.Schema) ([]model.EventSchemaChange, error) {
+	var changes []model.EventSchemaChange
+
+	tableNames := lo.Keys(schema)
+	slices.Sort(tableNames)
+
+	err := (*repo)(es).WithTx(ctx, func(tx *sqlmiddleware.Tx) error {
+		for _, tableName := range tableNames {
+			change, err := es.observeTable(ctx, tx, sourceID, destinationID, namespace, tableName, stagingFileID, schema[tableName])
+			if err != nil {
+				return fmt.Errorf("observing schema of table %s: %w", tableName, err)
+			}
+			if change != nil {
+				changes = append(changes, *change)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func (es *EventSchemas) observeTable(
+	ctx context.Context,
+	tx *sqlmiddleware.Tx,
+	sourceID, destinationID, namespace, tableName string,
+	stagingFileID int64,
+	tableSchema model.TableSchema,
+) (*model.EventSchemaChange, error) {
+	var (
+		id, observations, lastStagingFileID int64
+		version                             int
+		propertiesRaw                       []byte
+	)
+	err := tx.QueryRowContext(ctx, `
+		SELECT
+		  id,
+		  version,
+		  properties,
+		  observations,
+		  last_staging_file_id
+		FROM
+		  `+eventSchemasTableName+`
+		WHERE
+		  source_id = $1
+		  AND destination_id = $2
+		  AND namespace = $3
+		  AND table_name = $4
+		ORDER BY
+		  version DESC
+		LIMIT 1
+		FOR UPDATE;`,
+		sourceID,
+		destinationID,
+		namespace,
+		tableName,
+	).Scan(&id, &version, &propertiesRaw, &observations, &lastStagingFileID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("querying latest version: %w", err)
+	}
+
+	properties := make(map[string]model.EventProperty)
+	if len(propertiesRaw) > 0 {
+		if err := json.Unmarshal(propertiesRaw, &properties); err != nil {
+			return nil, fmt.Errorf("unmarshalling properties: %w", err)
+		}
+	}
+	if version > 0 && lastStagingFileID >= stagingFileID {
+		return nil, nil
+	}
+
+	var newProperties, changedProperties []string
+	for columnName, columnType := range tableSchema {
+		property, ok := properties[columnName]
+		switch {
+		case !ok:
+			newProperties = append(newProperties, columnName)
+		case property.Type != columnType:
+			changedProperties = append(changedProperties, columnName)
+			property.Frequency = 0 // the frequency of the new type
+		}
+		property.Type = columnType
+		property.Frequency++
+		properties[columnName] = property
+	}
+	marshalledProperties, err := json.Marshal(properties)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling properties: %w", err)
+	}
+
+	now := es.now()
+	if version > 0 && len(newProperties) == 0 && len(changedProperties) == 0 {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE
+			  `+eventSchemasTableName+`
+			SET
+			  properties = $1,
+			  observations = observations + 1,
+			  last_staging_file_id = $2,
+			  updated_at = $3
+			WHERE
+			  id = $4;`,
+			marshalledProperties,
+			stagingFileID,
+			now,
+			id,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("updating latest version: %w", err)
+		}
+		return nil, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO `+eventSchemasTableName+` (
+		  source_id, destination_id, namespace,
+		  table_name, version, properties,
+		  observations, last_staging_file_id,
+		  created_at, updated_at
+		)
+		VALUES
+		  ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);`,
+		sourceID,
+		destinationID,
+		namespace,
+		tableName,
+		version+1,
+		marshalledProperties,
+		observations+1,
+		stagingFileID,
+		now,
+		now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("inserting version: %w", err)
+	}
+
+	change := &model.EventSchemaChange{TableName: tableName, Version: version + 1}
+	if version > 0 {
+		slices.Sort(newProperties)
+		slices.Sort(changedProperties)
+		change.NewProperties, change.ChangedProperties = newProperties, changedProperties
+	}
+	return change, nil
+}
+
+// GetLatest returns the latest versions of the schemas of the tables of a source and destination, ordered by namespace
+// and table name. All the namespaces are considered if the namespace is empty.
+func (es *EventSchemas) GetLatest(ctx context.Context, sourceID, destinationID, namespace string) ([]model.EventSchema, error) {
+	rows, err := es.db.QueryContext(ctx, `
+		SELECT
+		  DISTINCT ON (namespace, table_name) `+eventSchemaColumns+`
+		FROM
+		  `+eventSchemasTableName+`
+		WHERE
+		  source_id = $1
+		  AND destination_id = $2
+		  AND ($3 = '' OR namespace = $3)
+		ORDER BY
+		  namespace,
+		  table_name,
+		  version DESC;`,
+		sourceID,
+		destinationID,
+		namespace,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying latest event schemas: %w", err)
+	}
+	return scanEventSchemas(rows)
+}
+
+// GetVersions returns the history of the schema of a table of a source and destination, ordered by namespace and
+// version. All the namespaces are considered if the namespace is empty.
+func (es *EventSchemas) GetVersions(ctx context.Context, sourceID, destinationID, namespace, tableName string) ([]model.EventSchema, error) {
+	rows, err := es.db.QueryContext(ctx, `
+		SELECT
+		  `+eventSchemaColumns+`
+		FROM
+		  `+eventSchemasTableName+`
+		WHERE
+		  source_id = $1
+		  AND destination_id = $2
+		  AND ($3 = '' OR namespace = $3)
+		  AND table_name = $4
+		ORDER BY
+		  namespace,
+		  version;`,
+		sourceID,
+		destinationID,
+		namespace,
+		tableName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying event schema versions: %w", err)
+	}
+	return scanEventSchemas(rows)
+}
+
+func scanEventSchemas(rows *sqlmiddleware.Rows) ([]model.EventSchema, error) {
+	defer func() { _ = rows.Close() }()
+
+	var eventSchemas []model.EventSchema
+	for rows.Next() {
+		var (
+			eventSchema   model.EventSchema
+			propertiesRaw []byte
+		)
+		err := rows.Scan(
+			&eventSchema.ID,
+			&eventSchema.SourceID,
+			&eventSchema.DestinationID,
+			&eventSchema.Namespace,
+			&eventSchema.TableName,
+			&eventSchema.Version,
+			&propertiesRaw,
+			&eventSchema.Observations,
+			&eventSchema.CreatedAt,
+			&eventSchema.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning event schema: %w", err)
+		}
+		if err := json.Unmarshal(propertiesRaw, &eventSchema.Properties); err != nil {
+			return nil, fmt.Errorf("unmarshalling properties: %w", err)
+		}
+		eventSchema.CreatedAt = eventSchema.CreatedAt.UTC()
+		eventSchema.UpdatedAt = eventSchema.UpdatedAt.UTC()
+		eventSchemas = append(eventSchemas, eventSchema)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating event schemas: %w", err)
+	}
+	return eventSchemas, nil
+}
@@ -0,0 +1,98 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/repo"
+)
+
+func TestEventSchemas(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+	db := setupDB(t)
+
+	r := repo.NewEventSchemas(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+
+	changes, err := r.Observe(ctx, "source-1", "destination-1", "namespace", 1, model.Schema{
+		"tracks":          {"id": "string", "event": "string"},
+		"order_completed": {"id": "string", "revenue": "float"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []model.EventSchemaChange{
+		{TableName: "order_completed", Version: 1},
+		{TableName: "tracks", Version: 1},
+	}, changes)
+
+	t.Run("already observed", func(t *testing.T) {
+		changes, err := r.Observe(ctx, "source-1", "destination-1", "namespace", 1, model.Schema{
+			"order_completed": {"id": "string", "coupon": "string"},
+		})
+		require.NoError(t, err)
+		require.Empty(t, changes)
+	})
+
+	changes, err = r.Observe(ctx, "source-1", "destination-1", "namespace", 2, model.Schema{
+		"tracks": {"id": "string"},
+	})
+	require.NoError(t, err)
+	require.Empty(t, changes, "properties missing from the observed schema don't change it")
+
+	changes, err = r.Observe(ctx, "source-1", "destination-1", "namespace", 3, model.Schema{
+		"order_completed": {"id": "string", "revenue": "int", "coupon": "string"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []model.EventSchemaChange{
+		{TableName: "order_completed", Version: 2, NewProperties: []string{"coupon"}, ChangedProperties: []string{"revenue"}},
+	}, changes)
+
+	t.Run("latest", func(t *testing.T) {
+		eventSchemas, err := r.GetLatest(ctx, "source-1", "destination-1", "")
+		require.NoError(t, err)
+		require.Len(t, eventSchemas, 2)
+
+		require.Equal(t, "order_completed", eventSchemas[0].TableName)
+		require.Equal(t, 2, eventSchemas[0].Version)
+		require.EqualValues(t, 2, eventSchemas[0].Observations)
+		require.Equal(t, map[string]model.EventProperty{
+			"id":      {Type: "string", Frequency: 2},
+			"revenue": {Type: "int", Frequency: 1},
+			"coupon":  {Type: "string", Frequency: 1},
+		}, eventSchemas[0].Properties)
+
+		require.Equal(t, "tracks", eventSchemas[1].TableName)
+		require.Equal(t, 1, eventSchemas[1].Version)
+		require.EqualValues(t, 2, eventSchemas[1].Observations)
+		require.Equal(t, map[string]model.EventProperty{
+			"id":    {Type: "string", Frequency: 2},
+			"event": {Type: "string", Frequency: 1},
+		}, eventSchemas[1].Properties)
+		require.Equal(t, now, eventSchemas[1].UpdatedAt)
+
+		eventSchemas, err = r.GetLatest(ctx, "source-1", "destination-1", "other-namespace")
+		require.NoError(t, err)
+		require.Empty(t, eventSchemas)
+	})
+
+	t.Run("versions", func(t *testing.T) {
+		eventSchemas, err := r.GetVersions(ctx, "source-1", "destination-1", "namespace", "order_completed")
+		require.NoError(t, err)
+		require.Len(t, eventSchemas, 2)
+		require.Equal(t, 1, eventSchemas[0].Version)
+		require.Equal(t, map[string]model.EventProperty{
+			"id":      {Type: "string", Frequency: 1},
+			"revenue": {Type: "float", Frequency: 1},
+		}, eventSchemas[0].Properties)
+		require.Equal(t, 2, eventSchemas[1].Version)
+
+		eventSchemas, err = r.GetVersions(ctx, "source-1", "destination-1", "namespace", "pages")
+		require.NoError(t, err)
+		require.Empty(t, eventSchemas)
+	})
+}
@@ -271,6 +271,42 @@ func (lf *LoadFiles) TotalExportedEvents(
 	return count.Int64, nil
 }
 
+// TotalBytes returns the total size of the load files of a table generated from the corresponding staging files.
+func (lf *LoadFiles) TotalBytes(ctx context.Context, stagingFileIDs []int64, tableName string) (int64, error) {
+	var totalBytes int64
+	err := lf.db.QueryRowContext(ctx, `
+		WITH row_numbered_load_files AS (
+		SELECT
+			metadata,
+			row_number() OVER (
+				PARTITION BY
+					staging_file_id,
+					table_name
+				ORDER BY
+					id DESC
+			) AS row_number
+		FROM
+			`+loadTableName+`
+		WHERE
+			staging_file_id = ANY($1)
+		AND
+			table_name = $2
+		)
+		SELECT
+			COALESCE(sum((metadata->>'content_length')::BIGINT), 0)
+		FROM
+			row_numbered_load_files
+		WHERE
+			row_number = 1;`,
+		pq.Array(stagingFileIDs),
+		tableName,
+	).Scan(&totalBytes)
+	if err != nil {
+		return 0, fmt.Errorf(`summing load files size: %w`, err)
+	}
+	return totalBytes, nil
+}
+
 // DistinctTableName returns the distinct table names for the given parameters.
 func (lf *LoadFiles) DistinctTableName(
 	ctx context.Context,
@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/rudderlabs/rudder-server/utils/timeutil"
+	sqlmiddleware "github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+const (
+	loadCheckpointTableName    = warehouseutils.WarehouseLoadFileCheckpointsTable
+	loadCheckpointTableColumns = `
+		upload_id,
+		batch_id,
+		unique_load_gen_id,
+		staging_file_ids,
+		created_at
+`
+)
+
+type LoadFileCheckpoints repo
+
+func NewLoadFileCheckpoints(db *sqlmiddleware.DB, opts ...Opt) *LoadFileCheckpoints {
+	r := &LoadFileCheckpoints{
+		db:  db,
+		now: timeutil.Now,
+	}
+
+	for _, opt := range opts {
+		opt((*repo)(r))
+	}
+	return r
+}
+
+// Insert records a batch published for generating the load files of an upload.
+func (lc *LoadFileCheckpoints) Insert(ctx context.Context, checkpoint model.LoadFileCheckpoint) error {
+	_, err := lc.db.ExecContext(ctx, `
+		INSERT INTO `+loadCheckpointTableName+` (`+loadCheckpointTableColumns+`)
+		VALUES ($1, $2, $3, $4, $5);`,
+		checkpoint.UploadID,
+		checkpoint.BatchID,
+		checkpoint.UniqueLoadGenID,
+		pq.Array(checkpoint.StagingFileIDs),
+		lc.now(),
+	)
+	if err != nil {
+		return fmt.Errorf("inserting load file checkpoint: %w", err)
+	}
+	return nil
+}
+
+// GetForUpload returns the checkpoints of an upload.
+//
+//	Ordered by creation time ascending.
+func (lc *LoadFileCheckpoints) GetForUpload(ctx context.Context, uploadID int64) ([]model.LoadFileCheckpoint, error) {
+	rows, err := lc.db.QueryContext(ctx, `
+		SELECT
+		`+loadCheckpointTableColumns+`
+		FROM
+			`+loadCheckpointTableName+`
+		WHERE
+			upload_id = $1
+		ORDER BY
+			created_at ASC, batch_id ASC;`,
+		uploadID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying load file checkpoints: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var checkpoints []model.LoadFileCheckpoint
+	for rows.Next() {
+		var checkpoint model.LoadFileCheckpoint
+		if err := rows.Scan(
+			&checkpoint.UploadID,
+			&checkpoint.BatchID,
+			&checkpoint.UniqueLoadGenID,
+			pq.Array(&checkpoint.StagingFileIDs),
+			&checkpoint.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scanning load file checkpoint: %w", err)
+		}
+		checkpoint.CreatedAt = checkpoint.CreatedAt.UTC()
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating load file checkpoints: %w", err)
+	}
+	return checkpoints, nil
+}
+
+// DeleteForUpload deletes the checkpoints of an upload.
+func (lc *LoadFileCheckpoints) DeleteForUpload(ctx context.Context, uploadID int64) error {
+	_, err := lc.db.ExecContext(ctx, `
+		DELETE FROM
+		  `+loadCheckpointTableName+`
+		WHERE
+		  upload_id = $1;`,
+		uploadID,
+	)
+	if err != nil {
+		return fmt.Errorf("deleting load file checkpoints: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/repo"
+)
+
+func TestLoadFileCheckpoints(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+	db := setupDB(t)
+
+	r := repo.NewLoadFileCheckpoints(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+
+	checkpoints := []model.LoadFileCheckpoint{
+		{UploadID: 1, BatchID: "batch-1", UniqueLoadGenID: "load-gen-1", StagingFileIDs: []int64{1, 2}, CreatedAt: now},
+		{UploadID: 1, BatchID: "batch-2", UniqueLoadGenID: "load-gen-1", StagingFileIDs: []int64{3}, CreatedAt: now},
+		{UploadID: 2, BatchID: "batch-3", UniqueLoadGenID: "load-gen-2", StagingFileIDs: []int64{4}, CreatedAt: now},
+	}
+	for _, checkpoint := range checkpoints {
+		require.NoError(t, r.Insert(ctx, checkpoint))
+	}
+	require.Error(t, r.Insert(ctx, checkpoints[0]), "batches should be recorded once")
+
+	got, err := r.GetForUpload(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, checkpoints[:2], got)
+
+	require.NoError(t, r.DeleteForUpload(ctx, 1))
+	got, err = r.GetForUpload(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, got)
+
+	got, err = r.GetForUpload(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, checkpoints[2:], got)
+}
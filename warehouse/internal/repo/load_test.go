@@ -256,6 +256,37 @@ func TestLoadFiles_TotalExportedEvents(t *testing.T) {
 	})
 }
 
+func TestLoadFiles_TotalBytes(t *testing.T) {
+	ctx := context.Background()
+	db := setupDB(t)
+	r := repo.NewLoadFiles(db)
+
+	var loadFiles []model.LoadFile
+	for stagingFileID := int64(1); stagingFileID <= 3; stagingFileID++ {
+		for retry := int64(1); retry <= 2; retry++ {
+			for _, tableName := range []string{"tracks", "pages"} {
+				loadFiles = append(loadFiles, model.LoadFile{
+					TableName:     tableName,
+					Location:      "s3://bucket/path/to/file",
+					ContentLength: stagingFileID * retry * 100,
+					StagingFileID: stagingFileID,
+					SourceID:      "source_id",
+					DestinationID: "destination_id",
+				})
+			}
+		}
+	}
+	require.NoError(t, r.Insert(ctx, loadFiles))
+
+	totalBytes, err := r.TotalBytes(ctx, []int64{1, 2}, "tracks")
+	require.NoError(t, err)
+	require.EqualValues(t, 600, totalBytes, "only the load files of the last retry are considered")
+
+	totalBytes, err = r.TotalBytes(ctx, []int64{1, 2}, "identifies")
+	require.NoError(t, err)
+	require.Zero(t, totalBytes)
+}
+
 func TestLoadFiles_DistinctTableName(t *testing.T) {
 	sourceID := "source_id"
 	destinationID := "destination_id"
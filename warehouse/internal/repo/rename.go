@@ -0,0 +1,387 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/rudderlabs/rudder-server/utils/timeutil"
+	sqlmiddleware "github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+const renamesTableName = warehouseutils.WarehouseRenamesTable
+
+// renameSkippedUploadStatuses are the statuses of the uploads whose metadata is left untouched by the renames, being
+// done with
+var renameSkippedUploadStatuses = []string{model.ExportedData, model.Aborted}
+
+const renameColumns = `
+	id,
+	workspace_id,
+	source_id,
+	destination_id,
+	destination_type,
+	namespace,
+	table_name,
+	new_name,
+	status,
+	error,
+	attempts,
+	created_at,
+	updated_at
+`
+
+// Renames keeps the renames of the namespaces and tables of the warehouses, see [model.Rename]
+type Renames repo
+
+func NewRenames(db *sqlmiddleware.DB, opts ...Opt) *Renames {
+	r := &Renames{
+		db:  db,
+		now: timeutil.Now,
+	}
+
+	for _, opt := range opts {
+		opt((*repo)(r))
+	}
+	return r
+}
+
+// Insert records a waiting rename, setting its id, status and timestamps, and returns its id
+func (rn *Renames) Insert(ctx context.Context, rename *model.Rename) (int64, error) {
+	var id int64
+	now := rn.now()
+
+	err := rn.db.QueryRowContext(ctx, `
+		INSERT INTO `+renamesTableName+` (
+		  workspace_id, source_id, destination_id,
+		  destination_type, namespace, table_name,
+		  new_name, status, created_at, updated_at
+		)
+		VALUES
+		  ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id;`,
+		rename.WorkspaceID,
+		rename.SourceID,
+		rename.DestinationID,
+		rename.DestinationType,
+		rename.Namespace,
+		rename.TableName,
+		rename.NewName,
+		model.RenameStatusWaiting,
+		now.UTC(),
+		now.UTC(),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("inserting rename: %w", err)
+	}
+	rename.ID = id
+	rename.Status = model.RenameStatusWaiting
+	rename.CreatedAt = now.UTC()
+	rename.UpdatedAt = now.UTC()
+	return id, nil
+}
+
+// GetByID returns the rename, or [model.ErrRenameNotFound]
+func (rn *Renames) GetByID(ctx context.Context, id int64) (model.Rename, error) {
+	row := rn.db.QueryRowContext(ctx, `
+		SELECT
+		  `+renameColumns+`
+		FROM
+		  `+renamesTableName+`
+		WHERE
+		  id = $1;`,
+		id,
+	)
+
+	var rename model.Rename
+	err := scanRename(row.Scan, &rename)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Rename{}, model.ErrRenameNotFound
+	}
+	if err != nil {
+		return model.Rename{}, fmt.Errorf("scanning rename: %w", err)
+	}
+	return rename, nil
+}
+
+// GetPending returns the renames of the destination type still to be executed, in the order they were requested
+func (rn *Renames) GetPending(ctx context.Context, destType string) ([]model.Rename, error) {
+	rows, err := rn.db.QueryContext(ctx, `
+		SELECT
+		  `+renameColumns+`
+		FROM
+		  `+renamesTableName+`
+		WHERE
+		  destination_type = $1
+		  AND (status = $2 OR status = $3)
+		ORDER BY
+		  id ASC;`,
+		destType,
+		model.RenameStatusWaiting,
+		model.RenameStatusFailed,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying pending renames: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var renames []model.Rename
+	for rows.Next() {
+		var rename model.Rename
+		if err := scanRename(rows.Scan, &rename); err != nil {
+			return nil, fmt.Errorf("scanning rename: %w", err)
+		}
+		renames = append(renames, rename)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating renames: %w", err)
+	}
+	return renames, nil
+}
+
+func scanRename(scan scanFn, rename *model.Rename) error {
+	var errorRaw sql.NullString
+	if err := scan(
+		&rename.ID,
+		&rename.WorkspaceID,
+		&rename.SourceID,
+		&rename.DestinationID,
+		&rename.DestinationType,
+		&rename.Namespace,
+		&rename.TableName,
+		&rename.NewName,
+		&rename.Status,
+		&errorRaw,
+		&rename.Attempts,
+		&rename.CreatedAt,
+		&rename.UpdatedAt,
+	); err != nil {
+		return err
+	}
+	rename.Error = errorRaw.String
+	rename.CreatedAt = rename.CreatedAt.UTC()
+	rename.UpdatedAt = rename.UpdatedAt.UTC()
+	return nil
+}
+
+// OnFailure records the failure of an attempt at the rename, aborting it once maxAttempts is reached
+func (rn *Renames) OnFailure(ctx context.Context, id int64, renameErr error, maxAttempts int) error {
+	r, err := rn.db.ExecContext(ctx, `
+		UPDATE
+		  `+renamesTableName+`
+		SET
+		  status = (
+			CASE WHEN attempts + 1 >= $1 THEN $2
+			ELSE $3 END
+		  ),
+		  attempts = attempts + 1,
+		  error = $4,
+		  updated_at = $5
+		WHERE
+		  id = $6;`,
+		maxAttempts,
+		model.RenameStatusAborted,
+		model.RenameStatusFailed,
+		renameErr.Error(),
+		rn.now(),
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("updating rename: %w", err)
+	}
+	rowsAffected, err := r.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return model.ErrRenameNotFound
+	}
+	return nil
+}
+
+// OnSuccess marks the rename as succeeded, once executed in the warehouse, moving the metadata of the destination to
+// the new name: the schemas of the warehouse, the observed event schemas, the table failures and the uploads not
+// exported yet, including their upload schemas and table uploads.
+//
+// Namespaces are shared by the sources of the destination, so the metadata of all of them is moved.
+func (rn *Renames) OnSuccess(ctx context.Context, rename model.Rename) error {
+	return (*repo)(rn).WithTx(ctx, func(tx *sqlmiddleware.Tx) error {
+		var err error
+		if rename.IsTableRename() {
+			err = rn.renameTable(ctx, tx, rename)
+		} else {
+			err = rn.renameNamespace(ctx, tx, rename)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE
+			  `+renamesTableName+`
+			SET
+			  status = $1,
+			  attempts = attempts + 1,
+			  error = NULL,
+			  updated_at = $2
+			WHERE
+			  id = $3;`,
+			model.RenameStatusSucceeded,
+			rn.now(),
+			rename.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("updating rename: %w", err)
+		}
+		return nil
+	})
+}
+
+func (rn *Renames) renameNamespace(ctx context.Context, tx *sqlmiddleware.Tx, rename model.Rename) error {
+	now := rn.now()
+
+	// schemas left over in the new namespace are stale, since the namespace didn't exist in the warehouse
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM `+whSchemaTableName+`
+		WHERE
+		  destination_id = $1
+		  AND namespace = $2;`,
+		rename.DestinationID,
+		rename.NewName,
+	)
+	if err != nil {
+		return fmt.Errorf("deleting stale schemas: %w", err)
+	}
+
+	for _, table := range []struct {
+		name   string
+		filter string
+	}{
+		{name: whSchemaTableName},
+		{name: eventSchemasTableName},
+		{name: warehouseutils.WarehouseTableUploadFailuresTable},
+		{name: uploadsTableName, filter: `AND status != ALL($5)`},
+	} {
+		args := []any{rename.NewName, now.UTC(), rename.DestinationID, rename.Namespace}
+		if table.filter != "" {
+			args = append(args, pq.Array(renameSkippedUploadStatuses))
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE
+			  `+table.name+`
+			SET
+			  namespace = $1,
+			  updated_at = $2
+			WHERE
+			  destination_id = $3
+			  AND namespace = $4 `+table.filter+`;`,
+			args...,
+		)
+		if err != nil {
+			return fmt.Errorf("renaming namespace in %s: %w", table.name, err)
+		}
+	}
+	return nil
+}
+
+func (rn *Renames) renameTable(ctx context.Context, tx *sqlmiddleware.Tx, rename model.Rename) error {
+	now := rn.now()
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE
+		  `+whSchemaTableName+`
+		SET
+		  schema = (schema - $1::TEXT) || jsonb_build_object($2::TEXT, schema -> $1::TEXT),
+		  updated_at = $3
+		WHERE
+		  destination_id = $4
+		  AND namespace = $5
+		  AND schema ? $1::TEXT;`,
+		rename.TableName,
+		rename.NewName,
+		now.UTC(),
+		rename.DestinationID,
+		rename.Namespace,
+	)
+	if err != nil {
+		return fmt.Errorf("renaming table in schemas: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE
+		  `+uploadsTableName+`
+		SET
+		  schema = (schema - $1::TEXT) || jsonb_build_object($2::TEXT, schema -> $1::TEXT),
+		  updated_at = $3
+		WHERE
+		  destination_id = $4
+		  AND namespace = $5
+		  AND status != ALL($6)
+		  AND schema ? $1::TEXT;`,
+		rename.TableName,
+		rename.NewName,
+		now.UTC(),
+		rename.DestinationID,
+		rename.Namespace,
+		pq.Array(renameSkippedUploadStatuses),
+	)
+	if err != nil {
+		return fmt.Errorf("renaming table in upload schemas: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE
+		  `+tableUploadTableName+`
+		SET
+		  table_name = $1,
+		  updated_at = $2
+		WHERE
+		  table_name = $3
+		  AND wh_upload_id IN (
+			SELECT
+			  id
+			FROM
+			  `+uploadsTableName+`
+			WHERE
+			  destination_id = $4
+			  AND namespace = $5
+			  AND status != ALL($6)
+		  );`,
+		rename.NewName,
+		now.UTC(),
+		rename.TableName,
+		rename.DestinationID,
+		rename.Namespace,
+		pq.Array(renameSkippedUploadStatuses),
+	)
+	if err != nil {
+		return fmt.Errorf("renaming table in table uploads: %w", err)
+	}
+
+	for _, tableName := range []string{eventSchemasTableName, warehouseutils.WarehouseTableUploadFailuresTable} {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE
+			  `+tableName+`
+			SET
+			  table_name = $1,
+			  updated_at = $2
+			WHERE
+			  destination_id = $3
+			  AND namespace = $4
+			  AND table_name = $5;`,
+			rename.NewName,
+			now.UTC(),
+			rename.DestinationID,
+			rename.Namespace,
+			rename.TableName,
+		)
+		if err != nil {
+			return fmt.Errorf("renaming table in %s: %w", tableName, err)
+		}
+	}
+	return nil
+}
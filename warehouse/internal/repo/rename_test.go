@@ -0,0 +1,187 @@
+package repo_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/repo"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+func TestRenames(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+	db := setupDB(t)
+
+	r := repo.NewRenames(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+	schemaRepo := repo.NewWHSchemas(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+	uploadRepo := repo.NewUploads(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+	stagingRepo := repo.NewStagingFiles(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+
+	createUpload := func(t *testing.T, sourceID, namespace, status string, schema model.Schema) int64 {
+		t.Helper()
+		stagingFileID, err := stagingRepo.Insert(ctx, &model.StagingFileWithSchema{
+			StagingFile: model.StagingFile{SourceID: sourceID, DestinationID: "destination-1"},
+		})
+		require.NoError(t, err)
+		id, err := uploadRepo.CreateWithStagingFiles(ctx, model.Upload{
+			SourceID:        sourceID,
+			DestinationID:   "destination-1",
+			DestinationType: warehouseutils.POSTGRES,
+			Namespace:       namespace,
+			Status:          status,
+		}, []*model.StagingFile{{ID: stagingFileID}})
+		require.NoError(t, err)
+		schemaJSON, err := json.Marshal(schema)
+		require.NoError(t, err)
+		require.NoError(t, uploadRepo.Update(ctx, id, []repo.UpdateKeyValue{repo.UploadFieldSchema(schemaJSON)}))
+		return id
+	}
+	insertSchema := func(t *testing.T, sourceID, namespace string, schema model.Schema) {
+		t.Helper()
+		_, err := schemaRepo.Insert(ctx, &model.WHSchema{
+			SourceID:        sourceID,
+			DestinationID:   "destination-1",
+			DestinationType: warehouseutils.POSTGRES,
+			Namespace:       namespace,
+			Schema:          schema,
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("insert and get", func(t *testing.T) {
+		rename := model.Rename{
+			WorkspaceID:     "workspace-1",
+			SourceID:        "source-1",
+			DestinationID:   "destination-2",
+			DestinationType: warehouseutils.RS,
+			Namespace:       "namespace",
+			NewName:         "new_namespace",
+		}
+		id, err := r.Insert(ctx, &rename)
+		require.NoError(t, err)
+		require.Equal(t, id, rename.ID)
+		require.Equal(t, model.RenameStatusWaiting, rename.Status)
+
+		got, err := r.GetByID(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, rename, got)
+
+		_, err = r.GetByID(ctx, -1)
+		require.ErrorIs(t, err, model.ErrRenameNotFound)
+
+		pending, err := r.GetPending(ctx, warehouseutils.RS)
+		require.NoError(t, err)
+		require.Equal(t, []model.Rename{rename}, pending)
+
+		pending, err = r.GetPending(ctx, warehouseutils.SNOWFLAKE)
+		require.NoError(t, err)
+		require.Empty(t, pending)
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		rename := model.Rename{DestinationID: "destination-3", DestinationType: warehouseutils.SNOWFLAKE, NewName: "NEW_NAMESPACE"}
+		_, err := r.Insert(ctx, &rename)
+		require.NoError(t, err)
+
+		require.NoError(t, r.OnFailure(ctx, rename.ID, errors.New("permission denied"), 2))
+		got, err := r.GetByID(ctx, rename.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.RenameStatusFailed, got.Status)
+		require.Equal(t, "permission denied", got.Error)
+		require.Equal(t, 1, got.Attempts)
+		require.True(t, got.Pending())
+
+		require.NoError(t, r.OnFailure(ctx, rename.ID, errors.New("permission denied"), 2))
+		got, err = r.GetByID(ctx, rename.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.RenameStatusAborted, got.Status)
+		require.Equal(t, 2, got.Attempts)
+		require.False(t, got.Pending())
+
+		require.ErrorIs(t, r.OnFailure(ctx, -1, errors.New("permission denied"), 2), model.ErrRenameNotFound)
+	})
+
+	t.Run("namespace rename", func(t *testing.T) {
+		insertSchema(t, "source-1", "namespace", model.Schema{"tracks": {"id": "string"}})
+		insertSchema(t, "source-2", "namespace", model.Schema{"pages": {"id": "string"}})
+		insertSchema(t, "source-1", "new_namespace", model.Schema{"stale": {"id": "string"}})
+		waitingUploadID := createUpload(t, "source-1", "namespace", model.Waiting, model.Schema{})
+		exportedUploadID := createUpload(t, "source-1", "namespace", model.ExportedData, model.Schema{})
+
+		rename := model.Rename{
+			SourceID:        "source-1",
+			DestinationID:   "destination-1",
+			DestinationType: warehouseutils.POSTGRES,
+			Namespace:       "namespace",
+			NewName:         "new_namespace",
+		}
+		_, err := r.Insert(ctx, &rename)
+		require.NoError(t, err)
+		require.NoError(t, r.OnSuccess(ctx, rename))
+
+		got, err := r.GetByID(ctx, rename.ID)
+		require.NoError(t, err)
+		require.Equal(t, model.RenameStatusSucceeded, got.Status)
+
+		for sourceID, schema := range map[string]model.Schema{
+			"source-1": {"tracks": {"id": "string"}},
+			"source-2": {"pages": {"id": "string"}},
+		} {
+			namespace, err := schemaRepo.GetNamespace(ctx, sourceID, "destination-1")
+			require.NoError(t, err)
+			require.Equal(t, "new_namespace", namespace)
+
+			whSchema, err := schemaRepo.GetForNamespace(ctx, sourceID, "destination-1", "new_namespace")
+			require.NoError(t, err)
+			require.Equal(t, schema, whSchema.Schema)
+		}
+
+		upload, err := uploadRepo.Get(ctx, waitingUploadID)
+		require.NoError(t, err)
+		require.Equal(t, "new_namespace", upload.Namespace)
+
+		upload, err = uploadRepo.Get(ctx, exportedUploadID)
+		require.NoError(t, err)
+		require.Equal(t, "namespace", upload.Namespace, "exported uploads are left untouched")
+	})
+
+	t.Run("table rename", func(t *testing.T) {
+		insertSchema(t, "source-3", "table_namespace", model.Schema{"tracks": {"id": "string"}, "pages": {"id": "string"}})
+		uploadID := createUpload(t, "source-3", "table_namespace", model.Waiting, model.Schema{"tracks": {"id": "string", "event": "string"}})
+
+		rename := model.Rename{
+			SourceID:        "source-3",
+			DestinationID:   "destination-1",
+			DestinationType: warehouseutils.POSTGRES,
+			Namespace:       "table_namespace",
+			TableName:       "tracks",
+			NewName:         "legacy_tracks",
+		}
+		_, err := r.Insert(ctx, &rename)
+		require.NoError(t, err)
+		require.NoError(t, r.OnSuccess(ctx, rename))
+
+		whSchema, err := schemaRepo.GetForNamespace(ctx, "source-3", "destination-1", "table_namespace")
+		require.NoError(t, err)
+		require.Equal(t, model.Schema{"legacy_tracks": {"id": "string"}, "pages": {"id": "string"}}, whSchema.Schema)
+
+		upload, err := uploadRepo.Get(ctx, uploadID)
+		require.NoError(t, err)
+		require.Equal(t, model.Schema{"legacy_tracks": {"id": "string", "event": "string"}}, upload.UploadSchema)
+	})
+}
@@ -0,0 +1,90 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/rudderlabs/rudder-server/utils/timeutil"
+	sqlmiddleware "github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+const reverseETLSnapshotTableName = warehouseutils.WarehouseReverseETLSnapshotsTable
+
+type ReverseETLSnapshots repo
+
+func NewReverseETLSnapshots(db *sqlmiddleware.DB, opts ...Opt) *ReverseETLSnapshots {
+	r := &ReverseETLSnapshots{
+		db:  db,
+		now: timeutil.Now,
+	}
+
+	for _, opt := range opts {
+		opt((*repo)(r))
+	}
+	return r
+}
+
+// Get returns the hashes of the rows last synced for a source.
+//
+//	Keyed by the primary key of the rows.
+func (rs *ReverseETLSnapshots) Get(ctx context.Context, sourceID string) (map[string]string, error) {
+	rows, err := rs.db.QueryContext(ctx, `
+		SELECT
+		  primary_key,
+		  row_hash
+		FROM
+		  `+reverseETLSnapshotTableName+`
+		WHERE
+		  source_id = $1;`,
+		sourceID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying reverse etl snapshot: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var primaryKey, rowHash string
+		if err := rows.Scan(&primaryKey, &rowHash); err != nil {
+			return nil, fmt.Errorf("scanning reverse etl snapshot: %w", err)
+		}
+		hashes[primaryKey] = rowHash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating reverse etl snapshot: %w", err)
+	}
+	return hashes, nil
+}
+
+// Upsert records the hashes of the rows synced for a source, keyed by the primary key of the rows.
+func (rs *ReverseETLSnapshots) Upsert(ctx context.Context, sourceID string, hashes map[string]string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	primaryKeys := make([]string, 0, len(hashes))
+	rowHashes := make([]string, 0, len(hashes))
+	for primaryKey, rowHash := range hashes {
+		primaryKeys = append(primaryKeys, primaryKey)
+		rowHashes = append(rowHashes, rowHash)
+	}
+
+	_, err := rs.db.ExecContext(ctx, `
+		INSERT INTO `+reverseETLSnapshotTableName+` (source_id, primary_key, row_hash, updated_at)
+		SELECT $1, UNNEST($2::TEXT[]), UNNEST($3::TEXT[]), $4
+		ON CONFLICT (source_id, primary_key) DO UPDATE SET
+		  row_hash = EXCLUDED.row_hash,
+		  updated_at = EXCLUDED.updated_at;`,
+		sourceID,
+		pq.Array(primaryKeys),
+		pq.Array(rowHashes),
+		rs.now(),
+	)
+	if err != nil {
+		return fmt.Errorf("upserting reverse etl snapshot: %w", err)
+	}
+	return nil
+}
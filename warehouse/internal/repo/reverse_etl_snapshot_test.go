@@ -0,0 +1,38 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/warehouse/internal/repo"
+)
+
+func TestReverseETLSnapshots(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+	db := setupDB(t)
+
+	r := repo.NewReverseETLSnapshots(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+
+	hashes, err := r.Get(ctx, "source-1")
+	require.NoError(t, err)
+	require.Empty(t, hashes)
+
+	require.NoError(t, r.Upsert(ctx, "source-1", map[string]string{"1": "hash-1", "2": "hash-2"}))
+	require.NoError(t, r.Upsert(ctx, "source-1", map[string]string{"2": "hash-2-updated", "3": "hash-3"}))
+	require.NoError(t, r.Upsert(ctx, "source-2", map[string]string{"1": "hash-1"}))
+	require.NoError(t, r.Upsert(ctx, "source-2", nil))
+
+	hashes, err = r.Get(ctx, "source-1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"1": "hash-1", "2": "hash-2-updated", "3": "hash-3"}, hashes)
+
+	hashes, err = r.Get(ctx, "source-2")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"1": "hash-1"}, hashes)
+}
@@ -39,15 +39,16 @@ const stagingTableColumns = `
 type StagingFiles repo
 
 type metadataSchema struct {
-	UseRudderStorage      bool   `json:"use_rudder_storage"`
-	SourceTaskRunID       string `json:"source_task_run_id"`
-	SourceJobID           string `json:"source_job_id"`
-	SourceJobRunID        string `json:"source_job_run_id"`
-	TimeWindowYear        int    `json:"time_window_year"`
-	TimeWindowMonth       int    `json:"time_window_month"`
-	TimeWindowDay         int    `json:"time_window_day"`
-	TimeWindowHour        int    `json:"time_window_hour"`
-	DestinationRevisionID string `json:"destination_revision_id"`
+	UseRudderStorage      bool           `json:"use_rudder_storage"`
+	SourceTaskRunID       string         `json:"source_task_run_id"`
+	SourceJobID           string         `json:"source_job_id"`
+	SourceJobRunID        string         `json:"source_job_run_id"`
+	TimeWindowYear        int            `json:"time_window_year"`
+	TimeWindowMonth       int            `json:"time_window_month"`
+	TimeWindowDay         int            `json:"time_window_day"`
+	TimeWindowHour        int            `json:"time_window_hour"`
+	DestinationRevisionID string         `json:"destination_revision_id"`
+	EventsPerTable        map[string]int `json:"events_per_table,omitempty"`
 }
 
 func StagingFileIDs(stagingFiles []*model.StagingFile) []int64 {
@@ -69,6 +70,7 @@ func metadataFromStagingFile(stagingFile *model.StagingFile) metadataSchema {
 		TimeWindowDay:         stagingFile.TimeWindow.Day(),
 		TimeWindowHour:        stagingFile.TimeWindow.Hour(),
 		DestinationRevisionID: stagingFile.DestinationRevisionID,
+		EventsPerTable:        stagingFile.EventsPerTable,
 	}
 }
 
@@ -90,6 +92,7 @@ func (m *metadataSchema) SetStagingFile(stagingFile *model.StagingFile) {
 	stagingFile.SourceJobRunID = m.SourceJobRunID
 	stagingFile.TimeWindow = time.Date(m.TimeWindowYear, time.Month(m.TimeWindowMonth), m.TimeWindowDay, m.TimeWindowHour, 0, 0, 0, time.UTC)
 	stagingFile.DestinationRevisionID = m.DestinationRevisionID
+	stagingFile.EventsPerTable = m.EventsPerTable
 }
 
 // Insert inserts a staging file into the staging files table. It returns the ID of the inserted staging file.
@@ -253,6 +256,87 @@ func (sf *StagingFiles) GetByID(ctx context.Context, ID int64) (model.StagingFil
 	return *entries[0], err
 }
 
+// Delivery returns the status of the latest staging file of the source and destination stored at the given location,
+// along with the status of the upload it is part of, if any.
+func (sf *StagingFiles) Delivery(ctx context.Context, sourceID, destinationID, location string) (model.StagingFileDelivery, error) {
+	var (
+		delivery     model.StagingFileDelivery
+		uploadID     sql.NullInt64
+		uploadStatus sql.NullString
+	)
+	err := sf.db.QueryRowContext(ctx, `
+		SELECT
+		  s.id,
+		  s.status,
+		  u.id,
+		  u.status
+		FROM
+		  `+stagingTableName+` s
+		  LEFT JOIN `+warehouseutils.WarehouseUploadsTable+` u ON u.id = s.upload_id
+		WHERE
+		  s.source_id = $1
+		  AND s.destination_id = $2
+		  AND s.location = $3
+		ORDER BY
+		  s.id DESC
+		LIMIT
+		  1;`,
+		sourceID, destinationID, location,
+	).Scan(&delivery.StagingFileID, &delivery.Status, &uploadID, &uploadStatus)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.StagingFileDelivery{}, model.ErrStagingFileNotFound
+	}
+	if err != nil {
+		return model.StagingFileDelivery{}, fmt.Errorf("querying staging file delivery: %w", err)
+	}
+	delivery.UploadID = uploadID.Int64
+	delivery.UploadStatus = uploadStatus.String
+	return delivery, nil
+}
+
+// Twins returns the latest twins of the staging files among the staging files of the destination, i.e. the ones of the
+// same source stored at the same location. Staging files without a twin are left out.
+func (sf *StagingFiles) Twins(ctx context.Context, stagingFileIDs []int64, destinationID string) ([]model.StagingFileTwin, error) {
+	rows, err := sf.db.QueryContext(ctx, `
+		SELECT
+		  DISTINCT ON (s.id) s.id,
+		  t.id,
+		  t.upload_id
+		FROM
+		  `+stagingTableName+` s
+		  JOIN `+stagingTableName+` t ON t.source_id = s.source_id
+		  AND t.location = s.location
+		WHERE
+		  s.id = ANY ($1)
+		  AND t.destination_id = $2
+		ORDER BY
+		  s.id,
+		  t.id DESC;`,
+		pq.Array(stagingFileIDs), destinationID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("querying staging file twins: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var twins []model.StagingFileTwin
+	for rows.Next() {
+		var (
+			twin     model.StagingFileTwin
+			uploadID sql.NullInt64
+		)
+		if err := rows.Scan(&twin.StagingFileID, &twin.TwinID, &uploadID); err != nil {
+			return nil, fmt.Errorf("scanning staging file twin: %w", err)
+		}
+		twin.TwinUploadID = uploadID.Int64
+		twins = append(twins, twin)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating staging file twins: %w", err)
+	}
+	return twins, nil
+}
+
 // GetSchemasByIDs returns staging file schemas for the given IDs.
 func (sf *StagingFiles) GetSchemasByIDs(ctx context.Context, ids []int64) ([]model.Schema, error) {
 	query := `SELECT schema FROM ` + stagingTableName + ` WHERE id = ANY ($1);`
@@ -354,6 +438,33 @@ func (sf *StagingFiles) Pending(ctx context.Context, sourceID, destinationID str
 	return parseStagingFiles(rows)
 }
 
+// Estimate returns an estimate of the next upload of a source and destination from its pending staging files
+func (sf *StagingFiles) Estimate(ctx context.Context, sourceID, destinationID string) (model.UploadEstimate, error) {
+	stagingFiles, err := sf.Pending(ctx, sourceID, destinationID)
+	if err != nil {
+		return model.UploadEstimate{}, fmt.Errorf("pending staging files: %w", err)
+	}
+
+	estimate := model.UploadEstimate{
+		PendingStagingFiles: len(stagingFiles),
+		EventsPerTable:      make(map[string]int64),
+	}
+	for _, stagingFile := range stagingFiles {
+		estimate.TotalEvents += int64(stagingFile.TotalEvents)
+		estimate.TotalBytes += int64(stagingFile.TotalBytes)
+		for tableName, events := range stagingFile.EventsPerTable {
+			estimate.EventsPerTable[tableName] += int64(events)
+		}
+		if !stagingFile.FirstEventAt.IsZero() && (estimate.FirstEventAt.IsZero() || stagingFile.FirstEventAt.Before(estimate.FirstEventAt)) {
+			estimate.FirstEventAt = stagingFile.FirstEventAt
+		}
+		if stagingFile.LastEventAt.After(estimate.LastEventAt) {
+			estimate.LastEventAt = stagingFile.LastEventAt
+		}
+	}
+	return estimate, nil
+}
+
 func (sf *StagingFiles) CountPendingForSource(ctx context.Context, sourceID string) (int64, error) {
 	return sf.countPending(ctx, `source_id = $1`, sourceID)
 }
@@ -150,6 +150,7 @@ func manyStagingFiles(size int, now time.Time) []*model.StagingFile {
 			UseRudderStorage:      true,
 			DestinationRevisionID: "destination_revision_id",
 			TotalEvents:           100,
+			EventsPerTable:        map[string]int{"tracks": 60, "identifies": 40},
 			SourceTaskRunID:       "source_task_run_id",
 			SourceJobID:           "source_job_id",
 			SourceJobRunID:        "source_job_run_id",
@@ -392,6 +393,47 @@ func TestStagingFileRepo_Pending(t *testing.T) {
 	}
 }
 
+func TestStagingFileRepo_Estimate(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+	db := setupDB(t)
+	r := repo.NewStagingFiles(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+
+	estimate, err := r.Estimate(ctx, "source_id", "destination_id")
+	require.NoError(t, err)
+	require.Equal(t, model.UploadEstimate{EventsPerTable: map[string]int64{}}, estimate)
+
+	stagingFiles := manyStagingFiles(3, now)
+	stagingFiles[1].TotalBytes = 2048
+	stagingFiles[1].FirstEventAt = now.Add(-time.Hour)
+	stagingFiles[2].EventsPerTable = nil // recorded by an older batch router
+	stagingFiles[2].LastEventAt = now.Add(time.Hour)
+	for i := range stagingFiles {
+		file := stagingFiles[i].WithSchema([]byte(`{"tracks": {"id": "string"}}`))
+		id, err := r.Insert(ctx, &file)
+		require.NoError(t, err)
+		stagingFiles[i].ID = id
+	}
+	_, err = repo.NewUploads(db).CreateWithStagingFiles(ctx, model.Upload{
+		SourceID:      "source_id",
+		DestinationID: "destination_id",
+	}, stagingFiles[:1])
+	require.NoError(t, err)
+
+	estimate, err = r.Estimate(ctx, "source_id", "destination_id")
+	require.NoError(t, err)
+	require.Equal(t, model.UploadEstimate{
+		PendingStagingFiles: 2,
+		TotalEvents:         200,
+		TotalBytes:          2048,
+		EventsPerTable:      map[string]int64{"tracks": 60, "identifies": 40},
+		FirstEventAt:        now.Add(-time.Hour),
+		LastEventAt:         now.Add(time.Hour),
+	}, estimate)
+}
+
 func TestStagingFileRepo_Status(t *testing.T) {
 	ctx := context.Background()
 	now := time.Now().Truncate(time.Second).UTC()
@@ -552,3 +594,88 @@ func BenchmarkFiles(b *testing.B) {
 		}
 	})
 }
+
+func TestStagingFileRepo_Delivery(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+	db := setupDB(t)
+	r := repo.NewStagingFiles(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+
+	stagingFiles := manyStagingFiles(2, now)
+	for i := range stagingFiles {
+		file := stagingFiles[i].WithSchema([]byte(`{"table": {"column": "type"} }`))
+		id, err := r.Insert(ctx, &file)
+		require.NoError(t, err)
+		stagingFiles[i].ID = id
+	}
+	uploadID, err := repo.NewUploads(db).CreateWithStagingFiles(ctx, model.Upload{Status: model.Waiting}, stagingFiles[:1])
+	require.NoError(t, err)
+
+	delivery, err := r.Delivery(ctx, "source_id", "destination_id", stagingFiles[0].Location)
+	require.NoError(t, err)
+	require.Equal(t, model.StagingFileDelivery{
+		StagingFileID: stagingFiles[0].ID,
+		Status:        warehouseutils.StagingFileWaitingState,
+		UploadID:      uploadID,
+		UploadStatus:  model.Waiting,
+	}, delivery)
+
+	delivery, err = r.Delivery(ctx, "source_id", "destination_id", stagingFiles[1].Location)
+	require.NoError(t, err)
+	require.Equal(t, model.StagingFileDelivery{
+		StagingFileID: stagingFiles[1].ID,
+		Status:        warehouseutils.StagingFileWaitingState,
+	}, delivery, "staging files which are not part of an upload yet have no upload status")
+
+	_, err = r.Delivery(ctx, "source_id", "other_destination_id", stagingFiles[0].Location)
+	require.ErrorIs(t, err, model.ErrStagingFileNotFound)
+}
+
+func TestStagingFileRepo_Twins(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).UTC()
+	db := setupDB(t)
+	r := repo.NewStagingFiles(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+
+	insert := func(t *testing.T, stagingFile model.StagingFile) *model.StagingFile {
+		t.Helper()
+		file := stagingFile.WithSchema([]byte(`{"table": {"column": "type"} }`))
+		id, err := r.Insert(ctx, &file)
+		require.NoError(t, err)
+		stagingFile.ID = id
+		return &stagingFile
+	}
+
+	stagingFiles := manyStagingFiles(3, now)
+	for i := range stagingFiles {
+		stagingFiles[i] = insert(t, *stagingFiles[i])
+	}
+
+	var twins []*model.StagingFile
+	for _, stagingFile := range stagingFiles[:2] {
+		twin := *stagingFile
+		twin.DestinationID = "other_destination_id"
+		twins = append(twins, insert(t, twin))
+	}
+	uploadID, err := repo.NewUploads(db).CreateWithStagingFiles(ctx, model.Upload{
+		SourceID:      "source_id",
+		DestinationID: "other_destination_id",
+		Status:        model.Waiting,
+	}, twins[:1])
+	require.NoError(t, err)
+
+	result, err := r.Twins(ctx, []int64{stagingFiles[0].ID, stagingFiles[1].ID, stagingFiles[2].ID}, "other_destination_id")
+	require.NoError(t, err)
+	require.Equal(t, []model.StagingFileTwin{
+		{StagingFileID: stagingFiles[0].ID, TwinID: twins[0].ID, TwinUploadID: uploadID},
+		{StagingFileID: stagingFiles[1].ID, TwinID: twins[1].ID},
+	}, result, "staging files without a twin are left out")
+
+	result, err = r.Twins(ctx, []int64{stagingFiles[0].ID}, "unknown_destination_id")
+	require.NoError(t, err)
+	require.Empty(t, result)
+}
@@ -20,6 +20,7 @@ import (
 
 const (
 	tableUploadTableName            = warehouseutils.WarehouseTableUploadsTable
+	tableUploadFailuresTableName    = warehouseutils.WarehouseTableUploadFailuresTable
 	tableUploadUniqueConstraintName = "unique_table_upload_wh_upload"
 
 	tableUploadColumns = `
@@ -32,7 +33,11 @@ const (
 		total_events,
 		created_at,
 		updated_at,
-		location
+		location,
+		load_attempt,
+		load_duration_ms,
+		rows_loaded,
+		bytes_loaded
 	`
 )
 
@@ -45,6 +50,10 @@ type TableUploadSetOptions struct {
 	LastExecTime *time.Time
 	Location     *string
 	TotalEvents  *int64
+	LoadAttempt  *int64
+	LoadDuration *time.Duration
+	RowsLoaded   *int64
+	BytesLoaded  *int64
 }
 
 func NewTableUploads(db *sqlmiddleware.DB, opts ...Opt) *TableUploads {
@@ -150,6 +159,10 @@ func scanTableUpload(scan scanFn, tableUpload *model.TableUpload) error {
 		locationRaw     sql.NullString
 		lastExecTimeRaw sql.NullTime
 		totalEvents     sql.NullInt64
+		loadAttempt     sql.NullInt64
+		loadDurationMS  sql.NullInt64
+		rowsLoaded      sql.NullInt64
+		bytesLoaded     sql.NullInt64
 	)
 	err := scan(
 		&tableUpload.ID,
@@ -162,6 +175,10 @@ func scanTableUpload(scan scanFn, tableUpload *model.TableUpload) error {
 		&tableUpload.CreatedAt,
 		&tableUpload.UpdatedAt,
 		&locationRaw,
+		&loadAttempt,
+		&loadDurationMS,
+		&rowsLoaded,
+		&bytesLoaded,
 	)
 	if err != nil {
 		return fmt.Errorf("scanning row: %w", err)
@@ -179,6 +196,10 @@ func scanTableUpload(scan scanFn, tableUpload *model.TableUpload) error {
 	if totalEvents.Valid {
 		tableUpload.TotalEvents = totalEvents.Int64
 	}
+	tableUpload.LoadAttempt = loadAttempt.Int64
+	tableUpload.LoadDuration = time.Duration(loadDurationMS.Int64) * time.Millisecond
+	tableUpload.RowsLoaded = rowsLoaded.Int64
+	tableUpload.BytesLoaded = bytesLoaded.Int64
 	return nil
 }
 
@@ -283,7 +304,6 @@ func (tu *TableUploads) Set(ctx context.Context, uploadId int64, tableName strin
 		query     string
 		queryArgs []any
 		setQuery  strings.Builder
-		err       error
 	)
 
 	queryArgs = []any{
@@ -312,6 +332,22 @@ func (tu *TableUploads) Set(ctx context.Context, uploadId int64, tableName strin
 		setQuery.WriteString(fmt.Sprintf(`total_events = $%d,`, len(queryArgs)+1))
 		queryArgs = append(queryArgs, *options.TotalEvents)
 	}
+	if options.LoadAttempt != nil {
+		setQuery.WriteString(fmt.Sprintf(`load_attempt = $%d,`, len(queryArgs)+1))
+		queryArgs = append(queryArgs, *options.LoadAttempt)
+	}
+	if options.LoadDuration != nil {
+		setQuery.WriteString(fmt.Sprintf(`load_duration_ms = $%d,`, len(queryArgs)+1))
+		queryArgs = append(queryArgs, options.LoadDuration.Milliseconds())
+	}
+	if options.RowsLoaded != nil {
+		setQuery.WriteString(fmt.Sprintf(`rows_loaded = $%d,`, len(queryArgs)+1))
+		queryArgs = append(queryArgs, *options.RowsLoaded)
+	}
+	if options.BytesLoaded != nil {
+		setQuery.WriteString(fmt.Sprintf(`bytes_loaded = $%d,`, len(queryArgs)+1))
+		queryArgs = append(queryArgs, *options.BytesLoaded)
+	}
 
 	if setQuery.Len() == 0 {
 		return fmt.Errorf(`no set options provided`)
@@ -330,20 +366,69 @@ func (tu *TableUploads) Set(ctx context.Context, uploadId int64, tableName strin
 			` + setQueryString + `
 		WHERE
 		  wh_upload_id = $1 AND
-		  table_name = $2;
-`
-	result, err := tu.db.ExecContext(
-		ctx,
-		query,
-		queryArgs...,
-	)
-	if err != nil {
-		return fmt.Errorf(`set: %w`, err)
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf(`rows affected: %w`, err)
+		  table_name = $2`
+
+	var rowsAffected int64
+	if options.Status == nil {
+		result, err := tu.db.ExecContext(
+			ctx,
+			query+`;`,
+			queryArgs...,
+		)
+		if err != nil {
+			return fmt.Errorf(`set: %w`, err)
+		}
+		if rowsAffected, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf(`rows affected: %w`, err)
+		}
+	} else {
+		// the summary of the failed tables follows the status transitions of the table upload
+		queryArgs = append(queryArgs, model.TableUploadExportingFailed)
+		failedStatusArg := len(queryArgs)
+		query = fmt.Sprintf(`
+		WITH updated AS (
+			%[1]s
+			RETURNING wh_upload_id, table_name, status, error, updated_at
+		),
+		failed AS (
+			INSERT INTO `+tableUploadFailuresTableName+` (wh_upload_id, table_name, destination_id, namespace, error, updated_at)
+			SELECT
+			  updated.wh_upload_id,
+			  updated.table_name,
+			  UT.destination_id,
+			  UT.namespace,
+			  updated.error,
+			  updated.updated_at
+			FROM
+			  updated
+			INNER JOIN
+			  `+uploadsTableName+` UT
+			ON
+			  UT.id = updated.wh_upload_id
+			WHERE
+			  updated.status = $%[2]d
+			ON CONFLICT (wh_upload_id, table_name) DO UPDATE SET
+			  error = EXCLUDED.error,
+			  updated_at = EXCLUDED.updated_at
+		),
+		recovered AS (
+			DELETE FROM
+			  `+tableUploadFailuresTableName+` F
+			USING
+			  updated
+			WHERE
+			  F.wh_upload_id = updated.wh_upload_id AND
+			  F.table_name = updated.table_name AND
+			  updated.status != $%[2]d
+		)
+		SELECT COUNT(*) FROM updated;
+`,
+			query,
+			failedStatusArg,
+		)
+		if err := tu.db.QueryRowContext(ctx, query, queryArgs...).Scan(&rowsAffected); err != nil {
+			return fmt.Errorf(`set: %w`, err)
+		}
 	}
 	if rowsAffected == 0 {
 		return fmt.Errorf(`no rows affected`)
@@ -389,6 +474,11 @@ func (tu *TableUploads) SyncsInfo(ctx context.Context, uploadID int64) ([]model.
 			Error:      item.Error,
 			LastExecAt: item.LastExecTime,
 			Count:      item.TotalEvents,
+
+			LoadAttempt:  item.LoadAttempt,
+			LoadDuration: item.LoadDuration.Milliseconds(),
+			RowsLoaded:   item.RowsLoaded,
+			BytesLoaded:  item.BytesLoaded,
 		}
 		if !item.LastExecTime.IsZero() {
 			tuf.Duration = int64(item.UpdatedAt.Sub(item.LastExecTime) / time.Second)
@@ -288,6 +288,35 @@ func TestTableUploadRepo(t *testing.T) {
 			require.Equal(t, now, tableUpload.UpdatedAt)
 		})
 
+		t.Run("set load metrics", func(t *testing.T) {
+			loadAttempt, loadDuration, rowsLoaded, bytesLoaded := int64(2), 1500*time.Millisecond, int64(1000), int64(4096)
+			err := r.Set(ctx, uploadID, table, repo.TableUploadSetOptions{
+				LoadAttempt:  &loadAttempt,
+				LoadDuration: &loadDuration,
+				RowsLoaded:   &rowsLoaded,
+				BytesLoaded:  &bytesLoaded,
+			})
+			require.NoError(t, err)
+
+			tableUpload, err := r.GetByUploadIDAndTableName(ctx, uploadID, table)
+			require.NoError(t, err)
+			require.Equal(t, loadAttempt, tableUpload.LoadAttempt)
+			require.Equal(t, loadDuration, tableUpload.LoadDuration)
+			require.Equal(t, rowsLoaded, tableUpload.RowsLoaded)
+			require.Equal(t, bytesLoaded, tableUpload.BytesLoaded)
+
+			syncsInfos, err := r.SyncsInfo(ctx, uploadID)
+			require.NoError(t, err)
+			syncsInfo, ok := lo.Find(syncsInfos, func(item model.TableUploadInfo) bool {
+				return item.Name == table
+			})
+			require.True(t, ok)
+			require.Equal(t, loadAttempt, syncsInfo.LoadAttempt)
+			require.EqualValues(t, 1500, syncsInfo.LoadDuration)
+			require.Equal(t, rowsLoaded, syncsInfo.RowsLoaded)
+			require.Equal(t, bytesLoaded, syncsInfo.BytesLoaded)
+		})
+
 		t.Run("set all", func(t *testing.T) {
 			err := r.Set(ctx, uploadID, table, repo.TableUploadSetOptions{
 				Status:       &status,
@@ -79,6 +79,7 @@ type scanFn func(dest ...any) error
 type ProcessOptions struct {
 	SkipIdentifiers                   []string
 	SkipWorkspaces                    []string
+	SkipDestinations                  []string
 	AllowMultipleSourcesForJobsPickup bool
 }
 
@@ -91,6 +92,10 @@ type UploadMetadata struct {
 	Retried          bool      `json:"retried"`
 	Priority         int       `json:"priority"`
 	NextRetryTime    time.Time `json:"nextRetryTime"`
+
+	PermissionDenied *model.PermissionDeniedInfo `json:"permission_denied,omitempty"`
+	Cleanup          *model.CleanupInfo          `json:"cleanup,omitempty"`
+	Tags             map[string]string           `json:"tags,omitempty"`
 }
 
 func NewUploads(db *sqlmiddleware.DB, opts ...Opt) *Uploads {
@@ -116,6 +121,9 @@ func ExtractUploadMetadata(upload model.Upload) UploadMetadata {
 		Retried:          upload.Retried,
 		Priority:         upload.Priority,
 		NextRetryTime:    upload.NextRetryTime,
+		PermissionDenied: upload.PermissionDenied,
+		Cleanup:          upload.Cleanup,
+		Tags:             upload.Tags,
 	}
 }
 
@@ -136,15 +144,21 @@ func (u *Uploads) CreateWithStagingFiles(ctx context.Context, upload model.Uploa
 		lastEventAt = files[len(files)-1].LastEventAt
 	}
 
+	loadFileType := upload.LoadFileType
+	if loadFileType == "" {
+		loadFileType = warehouseutils.GetLoadFileType(upload.DestinationType)
+	}
+
 	metadataMap := UploadMetadata{
 		UseRudderStorage: files[0].UseRudderStorage,
 		SourceTaskRunID:  files[0].SourceTaskRunID,
 		SourceJobID:      files[0].SourceJobID,
 		SourceJobRunID:   files[0].SourceJobRunID,
-		LoadFileType:     warehouseutils.GetLoadFileType(upload.DestinationType),
+		LoadFileType:     loadFileType,
 		Retried:          upload.Retried,
 		Priority:         upload.Priority,
 		NextRetryTime:    upload.NextRetryTime,
+		Tags:             upload.Tags,
 	}
 
 	metadata, err := json.Marshal(metadataMap)
@@ -294,6 +308,10 @@ func (u *Uploads) GetToProcess(ctx context.Context, destType string, limit int,
 		partitionIdentifierSQL = fmt.Sprintf(`%s, %s`, "source_id", partitionIdentifierSQL)
 	}
 
+	if len(opts.SkipDestinations) > 0 {
+		skipIdentifiersSQL += fmt.Sprintf(` AND destination_id != ALL($%d)`, lo.Ternary(len(opts.SkipIdentifiers) > 0, 6, 5))
+	}
+
 	sqlStatement := fmt.Sprintf(`
 			SELECT
 			`+uploadColumns+`
@@ -307,7 +325,7 @@ func (u *Uploads) GetToProcess(ctx context.Context, destType string, limit int,
 					t.destination_type = $1 AND
 					t.in_progress=false AND
 					t.status != $2 AND
-					t.status != $3 %s AND
+					t.status != ALL($3) %s AND
 					COALESCE(metadata->>'nextRetryTime', NOW()::text)::timestamptz <= NOW() AND
           			workspace_id <> ALL ($4)
 			) grouped_uploads
@@ -335,13 +353,16 @@ func (u *Uploads) GetToProcess(ctx context.Context, destType string, limit int,
 	args := []interface{}{
 		destType,
 		model.ExportedData,
-		model.Aborted,
+		pq.Array([]string{model.Aborted, model.PermissionDenied}),
 		pq.Array(opts.SkipWorkspaces),
 	}
 
 	if len(opts.SkipIdentifiers) > 0 {
 		args = append(args, pq.Array(opts.SkipIdentifiers))
 	}
+	if len(opts.SkipDestinations) > 0 {
+		args = append(args, pq.Array(opts.SkipDestinations))
+	}
 
 	rows, err = u.db.QueryContext(
 		ctx,
@@ -387,7 +408,7 @@ func (u *Uploads) UploadJobsStats(ctx context.Context, destType string, opts Pro
 			destination_type = $2 AND
 			in_progress = false AND
 			status != $3 AND
-			status != $4  AND
+			status != ALL($4) AND
 			COALESCE((metadata->>'nextRetryTime')::TIMESTAMPTZ, $1::TIMESTAMPTZ) <= $1::TIMESTAMPTZ AND
 			workspace_id <> ALL ($5)`
 
@@ -399,7 +420,7 @@ func (u *Uploads) UploadJobsStats(ctx context.Context, destType string, opts Pro
 		u.now(),
 		destType,
 		model.ExportedData,
-		model.Aborted,
+		pq.Array([]string{model.Aborted, model.PermissionDenied}),
 		pq.Array(opts.SkipWorkspaces),
 	}
 
@@ -407,6 +428,10 @@ func (u *Uploads) UploadJobsStats(ctx context.Context, destType string, opts Pro
 		query += `AND ((destination_id || '_' || namespace)) != ALL($6)`
 		args = append(args, pq.Array(opts.SkipIdentifiers))
 	}
+	if len(opts.SkipDestinations) > 0 {
+		query += fmt.Sprintf(` AND destination_id != ALL($%d)`, len(args)+1)
+		args = append(args, pq.Array(opts.SkipDestinations))
+	}
 
 	var stats model.UploadJobsStats
 	var (
@@ -534,6 +559,9 @@ func scanUpload(scan scanFn, upload *model.Upload) error {
 	upload.Priority = metadata.Priority
 	upload.Retried = metadata.Retried
 	upload.UseRudderStorage = metadata.UseRudderStorage
+	upload.PermissionDenied = metadata.PermissionDenied
+	upload.Cleanup = metadata.Cleanup
+	upload.Tags = metadata.Tags
 
 	_, upload.FirstAttemptAt = warehouseutils.TimingFromJSONString(firstTiming)
 	var lastStatus string
@@ -658,6 +686,66 @@ func (u *Uploads) PendingTableUploads(ctx context.Context, namespace string, upl
 	return pendingTableUploads, nil
 }
 
+// PreviouslyFailedTableUploads returns the failed table uploads of the tables, for the pending uploads of the
+// destination and namespace which are older than the upload. Failed tables are looked up in the summary of the failed
+// tables maintained by [TableUploads.Set], rather than in the history of the table uploads.
+func (u *Uploads) PreviouslyFailedTableUploads(ctx context.Context, namespace string, uploadID int64, destID string, tableNames []string) ([]model.PendingTableUpload, error) {
+	rows, err := u.db.QueryContext(ctx, `
+		SELECT
+		  F.wh_upload_id,
+		  F.destination_id,
+		  F.namespace,
+		  F.table_name,
+		  COALESCE(F.error, '')
+		FROM
+			`+tableUploadFailuresTableName+` F
+		INNER JOIN
+			`+uploadsTableName+` UT
+		ON
+			UT.id = F.wh_upload_id
+		WHERE
+		  	F.wh_upload_id < $1 AND
+			F.destination_id = $2 AND
+		   	F.namespace = $3 AND
+		  	F.table_name = ANY($6) AND
+		  	UT.status != $4 AND
+		  	UT.status != $5
+		ORDER BY
+		  F.wh_upload_id ASC;
+`,
+		uploadID,
+		destID,
+		namespace,
+		model.ExportedData,
+		model.Aborted,
+		pq.Array(tableNames),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("previously failed table uploads: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	pendingTableUploads := make([]model.PendingTableUpload, 0)
+	for rows.Next() {
+		pendingTableUpload := model.PendingTableUpload{Status: model.TableUploadExportingFailed}
+		err := rows.Scan(
+			&pendingTableUpload.UploadID,
+			&pendingTableUpload.DestinationID,
+			&pendingTableUpload.Namespace,
+			&pendingTableUpload.TableName,
+			&pendingTableUpload.Error,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+		pendingTableUploads = append(pendingTableUploads, pendingTableUpload)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+	return pendingTableUploads, nil
+}
+
 func (u *Uploads) ResetInProgress(ctx context.Context, destType string) error {
 	_, err := u.db.ExecContext(ctx, `
 		UPDATE
@@ -676,6 +764,59 @@ func (u *Uploads) ResetInProgress(ctx context.Context, destType string) error {
 	return nil
 }
 
+// InProgressDestinations returns the destinations of the destination type with uploads in progress
+func (u *Uploads) InProgressDestinations(ctx context.Context, destType string) ([]string, error) {
+	rows, err := u.db.QueryContext(ctx, `
+		SELECT
+			DISTINCT destination_id
+		FROM
+			`+uploadsTableName+`
+		WHERE
+			destination_type = $1 AND
+			in_progress = TRUE;
+	`,
+		destType,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("in progress destinations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var destinationIDs []string
+	for rows.Next() {
+		var destinationID string
+		if err := rows.Scan(&destinationID); err != nil {
+			return nil, fmt.Errorf("scanning in progress destination: %w", err)
+		}
+		destinationIDs = append(destinationIDs, destinationID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("in progress destinations: %w", err)
+	}
+	return destinationIDs, nil
+}
+
+// ResetInProgressForDestinations is like ResetInProgress, but only for the uploads of the destinations
+func (u *Uploads) ResetInProgressForDestinations(ctx context.Context, destType string, destinationIDs []string) error {
+	_, err := u.db.ExecContext(ctx, `
+		UPDATE
+			`+uploadsTableName+`
+		SET
+			in_progress = FALSE
+		WHERE
+			destination_type = $1 AND
+			destination_id = ANY($2) AND
+			in_progress = TRUE;
+	`,
+		destType,
+		pq.Array(destinationIDs),
+	)
+	if err != nil {
+		return fmt.Errorf("reset in progress for destinations: %w", err)
+	}
+	return nil
+}
+
 func (u *Uploads) LastCreatedAt(ctx context.Context, sourceID, destinationID string) (time.Time, error) {
 	row := u.db.QueryRowContext(ctx, `
 		SELECT
@@ -706,6 +847,90 @@ func (u *Uploads) LastCreatedAt(ctx context.Context, sourceID, destinationID str
 	return createdAt.Time, nil
 }
 
+// SyncStatus returns the state of the uploads of the connection, see [model.SyncStatus]
+func (u *Uploads) SyncStatus(ctx context.Context, sourceID, destinationID string) (model.SyncStatus, error) {
+	var (
+		status     model.SyncStatus
+		timingsRaw []byte
+		updatedAt  time.Time
+		err        error
+	)
+
+	status.LastCreatedAt, err = u.LastCreatedAt(ctx, sourceID, destinationID)
+	if err != nil {
+		return model.SyncStatus{}, err
+	}
+
+	err = u.db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(timings, '[]')::JSONB,
+			updated_at
+		FROM
+			`+uploadsTableName+`
+		WHERE
+			source_id = $1 AND
+			destination_id = $2 AND
+			status = $3
+		ORDER BY
+			id DESC
+		LIMIT 1;
+	`,
+		sourceID,
+		destinationID,
+		model.ExportedData,
+	).Scan(&timingsRaw, &updatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return model.SyncStatus{}, fmt.Errorf("last exported upload: %w", err)
+	}
+	if err == nil {
+		var timings model.Timings
+		if err := json.Unmarshal(timingsRaw, &timings); err != nil {
+			return model.SyncStatus{}, fmt.Errorf("unmarshal timings: %w", err)
+		}
+		status.LastSucceededAt = model.GetTiming(timings, model.ExportedData)
+		if status.LastSucceededAt.IsZero() {
+			status.LastSucceededAt = updatedAt
+		}
+		status.LastSucceededAt = status.LastSucceededAt.UTC()
+	}
+
+	var inProgress model.InProgressUpload
+	err = u.db.QueryRowContext(ctx, `
+		SELECT
+			id,
+			status,
+			COALESCE(timings, '[]')::JSONB,
+			COUNT(*) OVER ()
+		FROM
+			`+uploadsTableName+`
+		WHERE
+			source_id = $1 AND
+			destination_id = $2 AND
+			status != ALL($3)
+		ORDER BY
+			id ASC
+		LIMIT 1;
+	`,
+		sourceID,
+		destinationID,
+		pq.Array([]string{model.ExportedData, model.Aborted}),
+	).Scan(&inProgress.ID, &inProgress.Status, &timingsRaw, &status.PendingUploads)
+	if errors.Is(err, sql.ErrNoRows) {
+		return status, nil
+	}
+	if err != nil {
+		return model.SyncStatus{}, fmt.Errorf("upload in progress: %w", err)
+	}
+	var timings model.Timings
+	if err := json.Unmarshal(timingsRaw, &timings); err != nil {
+		return model.SyncStatus{}, fmt.Errorf("unmarshal timings: %w", err)
+	}
+	inProgress.Stage, inProgress.StageStartedAt = model.GetLastTiming(timings)
+	inProgress.StageStartedAt = inProgress.StageStartedAt.UTC()
+	status.InProgress = &inProgress
+	return status, nil
+}
+
 func (u *Uploads) SyncsInfoForMultiTenant(ctx context.Context, limit, offset int, opts model.SyncUploadOptions) ([]model.UploadInfo, int64, error) {
 	syncUploadInfos, totalUploads, err := u.syncsInfo(ctx, limit, offset, opts, true)
 	if err != nil {
@@ -764,6 +989,8 @@ func (u *Uploads) syncsInfo(ctx context.Context, limit, offset int, opts model.S
 			timings,
 			metadata->>'nextRetryTime',
 			metadata->>'archivedStagingAndLoadFiles',
+			metadata->'tags',
+			metadata->'cleanup'->>'status',
 			%s
 		FROM
 			`+uploadsTableName+`
@@ -799,6 +1026,8 @@ func (u *Uploads) syncsInfo(ctx context.Context, limit, offset int, opts model.S
 		var timingsRaw []byte
 		var nextRetryTime sql.NullString
 		var archivedStagingAndLoadFiles sql.NullBool
+		var tagsRaw []byte
+		var cleanupStatus sql.NullString
 		var firstEventAt, lastEventAt, lastExecAt, updatedAt sql.NullTime
 
 		err := rows.Scan(
@@ -817,6 +1046,8 @@ func (u *Uploads) syncsInfo(ctx context.Context, limit, offset int, opts model.S
 			&timingsRaw,
 			&nextRetryTime,
 			&archivedStagingAndLoadFiles,
+			&tagsRaw,
+			&cleanupStatus,
 			&totalUploads,
 		)
 		if err != nil {
@@ -837,6 +1068,12 @@ func (u *Uploads) syncsInfo(ctx context.Context, limit, offset int, opts model.S
 		if archivedStagingAndLoadFiles.Valid {
 			uploadInfo.IsArchivedUpload = archivedStagingAndLoadFiles.Bool
 		}
+		if len(tagsRaw) > 0 {
+			if err := json.Unmarshal(tagsRaw, &uploadInfo.Tags); err != nil {
+				return nil, 0, fmt.Errorf("syncs upload info: unmarshal tags: %w", err)
+			}
+		}
+		uploadInfo.CleanupStatus = cleanupStatus.String
 		gjson.Parse(uploadInfo.Error).ForEach(func(key, value gjson.Result) bool {
 			uploadInfo.Attempt += gjson.Get(value.String(), "attempt").Int()
 			return true
@@ -906,6 +1143,11 @@ func syncUploadQueryArgs(suo *model.SyncUploadOptions) (string, []interface{}) {
 		queryFilters += fmt.Sprintf(" AND workspace_id = $%d", len(queryArgs)+1)
 		queryArgs = append(queryArgs, suo.WorkspaceID)
 	}
+	if len(suo.Tags) > 0 {
+		tags, _ := json.Marshal(suo.Tags)
+		queryFilters += fmt.Sprintf(" AND metadata->'tags' @> $%d::jsonb", len(queryArgs)+1)
+		queryArgs = append(queryArgs, string(tags))
+	}
 	return queryFilters, queryArgs
 }
 
@@ -960,6 +1202,48 @@ func (u *Uploads) TriggerUpload(ctx context.Context, uploadID int64) error {
 	return nil
 }
 
+// ResumePermissionDenied resumes the uploads stopped on a permission error of the destinations whose config revision
+// has changed since, from the state they failed at. revisions are the current config revisions by destination id.
+func (u *Uploads) ResumePermissionDenied(ctx context.Context, revisions map[string]string) (int64, error) {
+	if len(revisions) == 0 {
+		return 0, nil
+	}
+
+	destinationIDs := lo.Keys(revisions)
+	revisionIDs := lo.Map(destinationIDs, func(destinationID string, _ int) string {
+		return revisions[destinationID]
+	})
+
+	r, err := u.db.ExecContext(ctx, `
+		UPDATE
+			`+uploadsTableName+` t
+		SET
+			status = t.metadata->'permission_denied'->>'state',
+			metadata = t.metadata - 'permission_denied' - 'nextRetryTime',
+			updated_at = $1
+		FROM
+			UNNEST($2::text[], $3::text[]) AS d(destination_id, revision_id)
+		WHERE
+			t.destination_id = d.destination_id AND
+			t.status = $4 AND
+			COALESCE(t.metadata->'permission_denied'->>'revision_id', '') != d.revision_id;
+`,
+		u.now(),
+		pq.Array(destinationIDs),
+		pq.Array(revisionIDs),
+		model.PermissionDenied,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("resume permission denied uploads: update: %w", err)
+	}
+
+	rowsAffected, err := r.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("resume permission denied uploads: rows affected: %w", err)
+	}
+	return rowsAffected, nil
+}
+
 func (u *Uploads) Retry(ctx context.Context, opts model.RetryOptions) (int64, error) {
 	filterQuery, filterArgs := retryQueryArgs(&opts)
 
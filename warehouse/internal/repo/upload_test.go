@@ -1007,6 +1007,112 @@ func TestUploads_PendingTableUploads(t *testing.T) {
 	})
 }
 
+func TestUploads_PreviouslyFailedTableUploads(t *testing.T) {
+	t.Parallel()
+
+	const (
+		namespace   = "namespace"
+		destID      = "destination_id"
+		sourceID    = "source_id"
+		destType    = "RS"
+		workspaceID = "workspace_id"
+	)
+
+	var (
+		ctx             = context.Background()
+		db              = setupDB(t)
+		repoUpload      = repo.NewUploads(db)
+		repoTableUpload = repo.NewTableUploads(db)
+		repoStaging     = repo.NewStagingFiles(db)
+	)
+
+	uploadIDs := make([]int64, 0, 3)
+	for _, status := range []string{"exporting_data", "aborted", "exporting_data"} {
+		file := model.StagingFile{
+			WorkspaceID:   workspaceID,
+			Location:      "s3://bucket/path/to/file",
+			SourceID:      sourceID,
+			DestinationID: destID,
+			Status:        warehouseutils.StagingFileWaitingState,
+			FirstEventAt:  time.Now(),
+			LastEventAt:   time.Now(),
+		}.WithSchema([]byte(`{"type": "object"}`))
+
+		stagingID, err := repoStaging.Insert(ctx, &file)
+		require.NoError(t, err)
+
+		uploadID, err := repoUpload.CreateWithStagingFiles(
+			ctx,
+			model.Upload{
+				SourceID:        sourceID,
+				DestinationID:   destID,
+				Status:          status,
+				Namespace:       namespace,
+				DestinationType: destType,
+			},
+			[]*model.StagingFile{
+				{
+					ID:            stagingID,
+					SourceID:      sourceID,
+					DestinationID: destID,
+				},
+			},
+		)
+		require.NoError(t, err)
+		uploadIDs = append(uploadIDs, uploadID)
+	}
+
+	setTableUpload := func(uploadID int64, tableName, status, errorString string) {
+		require.NoError(t, repoTableUpload.Insert(ctx, uploadID, []string{tableName}))
+		require.NoError(t, repoTableUpload.Set(ctx, uploadID, tableName, repo.TableUploadSetOptions{
+			Status: &status,
+			Error:  &errorString,
+		}))
+	}
+	setTableUpload(uploadIDs[0], "failed_table", model.TableUploadExportingFailed, "error loading data")
+	setTableUpload(uploadIDs[0], "exported_table", model.TableUploadExported, "{}")
+	setTableUpload(uploadIDs[0], "not_pending_table", model.TableUploadExportingFailed, "error loading data")
+	setTableUpload(uploadIDs[1], "aborted_table", model.TableUploadExportingFailed, "error loading data")
+	setTableUpload(uploadIDs[2], "failed_table", model.TableUploadWaiting, "{}")
+	setTableUpload(uploadIDs[0], "recovered_table", model.TableUploadExportingFailed, "error loading data")
+	exported := model.TableUploadExported
+	require.NoError(t, repoTableUpload.Set(ctx, uploadIDs[0], "recovered_table", repo.TableUploadSetOptions{Status: &exported}))
+
+	t.Run("should return the failed table uploads of earlier uploads", func(t *testing.T) {
+		t.Parallel()
+
+		tableUploads, err := repoUpload.PreviouslyFailedTableUploads(ctx, namespace, uploadIDs[2], destID, []string{"failed_table", "exported_table", "aborted_table", "recovered_table"})
+		require.NoError(t, err)
+		require.Equal(t, []model.PendingTableUpload{
+			{
+				UploadID:      uploadIDs[0],
+				DestinationID: destID,
+				Namespace:     namespace,
+				TableName:     "failed_table",
+				Status:        model.TableUploadExportingFailed,
+				Error:         "error loading data",
+			},
+		}, tableUploads)
+	})
+
+	t.Run("should not return the table uploads of the upload", func(t *testing.T) {
+		t.Parallel()
+
+		tableUploads, err := repoUpload.PreviouslyFailedTableUploads(ctx, namespace, uploadIDs[0], destID, []string{"failed_table"})
+		require.NoError(t, err)
+		require.Empty(t, tableUploads)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := repoUpload.PreviouslyFailedTableUploads(ctx, namespace, uploadIDs[2], destID, []string{"failed_table"})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
 func TestUploads_ResetInProgress(t *testing.T) {
 	const (
 		sourceID        = "source_id"
@@ -1067,6 +1173,79 @@ func TestUploads_ResetInProgress(t *testing.T) {
 	})
 }
 
+func TestUploads_ResetInProgressForDestinations(t *testing.T) {
+	const (
+		sourceID        = "source_id"
+		destinationType = "destination_type"
+	)
+
+	db, ctx := setupDB(t), context.Background()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	repoUpload := repo.NewUploads(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+	repoStaging := repo.NewStagingFiles(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+
+	for _, destinationID := range []string{"destination_id_1", "destination_id_2"} {
+		stagingID, err := repoStaging.Insert(ctx, &model.StagingFileWithSchema{})
+		require.NoError(t, err)
+
+		uploadID, err := repoUpload.CreateWithStagingFiles(ctx, model.Upload{
+			SourceID:        sourceID,
+			DestinationID:   destinationID,
+			DestinationType: destinationType,
+			Status:          model.Waiting,
+		}, []*model.StagingFile{
+			{
+				ID:            stagingID,
+				SourceID:      sourceID,
+				DestinationID: destinationID,
+			},
+		})
+		require.NoError(t, err)
+
+		_, err = db.ExecContext(ctx, `UPDATE wh_uploads SET in_progress = TRUE WHERE id = $1;`, uploadID)
+		require.NoError(t, err)
+	}
+
+	destinationIDs, err := repoUpload.InProgressDestinations(ctx, destinationType)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"destination_id_1", "destination_id_2"}, destinationIDs)
+
+	err = repoUpload.ResetInProgressForDestinations(ctx, destinationType, []string{"destination_id_1"})
+	require.NoError(t, err)
+
+	destinationIDs, err = repoUpload.InProgressDestinations(ctx, destinationType)
+	require.NoError(t, err)
+	require.Equal(t, []string{"destination_id_2"}, destinationIDs)
+
+	err = repoUpload.ResetInProgress(ctx, destinationType)
+	require.NoError(t, err)
+
+	uploadsToProcess, err := repoUpload.GetToProcess(ctx, destinationType, 10, repo.ProcessOptions{
+		SkipDestinations: []string{"destination_id_1"},
+	})
+	require.NoError(t, err)
+	require.Len(t, uploadsToProcess, 1)
+	require.Equal(t, "destination_id_2", uploadsToProcess[0].DestinationID)
+
+	uploadsToProcess, err = repoUpload.GetToProcess(ctx, destinationType, 10, repo.ProcessOptions{
+		SkipIdentifiers:  []string{"destination_id_2_"},
+		SkipDestinations: []string{"destination_id_1"},
+	})
+	require.NoError(t, err)
+	require.Empty(t, uploadsToProcess)
+
+	stats, err := repoUpload.UploadJobsStats(ctx, destinationType, repo.ProcessOptions{
+		SkipDestinations: []string{"destination_id_1"},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, stats.PendingJobs)
+}
+
 func TestUploads_LastCreatedAt(t *testing.T) {
 	const (
 		sourceID        = "source_id"
@@ -1129,6 +1308,82 @@ func TestUploads_LastCreatedAt(t *testing.T) {
 	})
 }
 
+func TestUploads_SyncStatus(t *testing.T) {
+	const (
+		sourceID        = "source_id"
+		destinationID   = "destination_id"
+		destinationType = "destination_type"
+	)
+
+	db, ctx := setupDB(t), context.Background()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	repoUpload := repo.NewUploads(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+	repoStaging := repo.NewStagingFiles(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+
+	createUpload := func(t *testing.T, status string, timings model.Timings) int64 {
+		t.Helper()
+		stagingID, err := repoStaging.Insert(ctx, &model.StagingFileWithSchema{})
+		require.NoError(t, err)
+		id, err := repoUpload.CreateWithStagingFiles(ctx, model.Upload{
+			SourceID:        sourceID,
+			DestinationID:   destinationID,
+			DestinationType: destinationType,
+			Status:          status,
+		}, []*model.StagingFile{{ID: stagingID, SourceID: sourceID, DestinationID: destinationID}})
+		require.NoError(t, err)
+		timingsJSON, err := json.Marshal(timings)
+		require.NoError(t, err)
+		require.NoError(t, repoUpload.Update(ctx, id, []repo.UpdateKeyValue{repo.UploadFieldTimings(timingsJSON)}))
+		return id
+	}
+
+	t.Run("no uploads", func(t *testing.T) {
+		status, err := repoUpload.SyncStatus(ctx, sourceID, destinationID)
+		require.NoError(t, err)
+		require.Equal(t, model.SyncStatus{}, status)
+	})
+
+	t.Run("uploads", func(t *testing.T) {
+		createUpload(t, model.ExportedData, model.Timings{
+			{model.GeneratingLoadFiles: now.Add(-3 * time.Hour)},
+			{model.ExportedData: now.Add(-2 * time.Hour)},
+		})
+		createUpload(t, model.Aborted, model.Timings{
+			{model.Aborted: now.Add(-time.Hour)},
+		})
+		inProgressID := createUpload(t, model.ExportingData, model.Timings{
+			{model.GeneratingLoadFiles: now.Add(-time.Minute)},
+			{model.ExportingData: now},
+		})
+		createUpload(t, model.Waiting, model.Timings{})
+
+		status, err := repoUpload.SyncStatus(ctx, sourceID, destinationID)
+		require.NoError(t, err)
+		require.Equal(t, now, status.LastCreatedAt.UTC())
+		require.Equal(t, now.Add(-2*time.Hour), status.LastSucceededAt)
+		require.EqualValues(t, 2, status.PendingUploads)
+		require.Equal(t, &model.InProgressUpload{
+			ID:             inProgressID,
+			Status:         model.ExportingData,
+			Stage:          model.ExportingData,
+			StageStartedAt: now,
+		}, status.InProgress)
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := repoUpload.SyncStatus(ctx, sourceID, destinationID)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
 func TestUploads_TriggerUpload(t *testing.T) {
 	const (
 		sourceID        = "source_id"
@@ -1191,6 +1446,90 @@ func TestUploads_TriggerUpload(t *testing.T) {
 	})
 }
 
+func TestUploads_ResumePermissionDenied(t *testing.T) {
+	const (
+		sourceID        = "source_id"
+		destinationID   = "destination_id"
+		destinationType = "destination_type"
+		workspaceID     = "workspace_id"
+	)
+
+	db, ctx := setupDB(t), context.Background()
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	repoUpload := repo.NewUploads(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+	repoStaging := repo.NewStagingFiles(db, repo.WithNow(func() time.Time {
+		return now
+	}))
+
+	stagingID, err := repoStaging.Insert(ctx, &model.StagingFileWithSchema{})
+	require.NoError(t, err)
+
+	uploadID, err := repoUpload.CreateWithStagingFiles(ctx, model.Upload{
+		SourceID:        sourceID,
+		DestinationID:   destinationID,
+		DestinationType: destinationType,
+		WorkspaceID:     workspaceID,
+		Status:          model.Waiting,
+	}, []*model.StagingFile{
+		{
+			ID:            stagingID,
+			SourceID:      sourceID,
+			DestinationID: destinationID,
+			WorkspaceID:   workspaceID,
+		},
+	})
+	require.NoError(t, err)
+
+	upload, err := repoUpload.Get(ctx, uploadID)
+	require.NoError(t, err)
+	upload.PermissionDenied = &model.PermissionDeniedInfo{
+		State:      "creating_remote_schema_failed",
+		Privilege:  "GRANT CREATE ON DATABASE dev",
+		RevisionID: "revision-1",
+	}
+	metadata, err := json.Marshal(repo.ExtractUploadMetadata(upload))
+	require.NoError(t, err)
+	require.NoError(t, repoUpload.Update(ctx, uploadID, []repo.UpdateKeyValue{
+		repo.UploadFieldStatus(model.PermissionDenied),
+		repo.UploadFieldMetadata(metadata),
+	}))
+
+	t.Run("not picked up", func(t *testing.T) {
+		uploads, err := repoUpload.GetToProcess(ctx, destinationType, 10, repo.ProcessOptions{})
+		require.NoError(t, err)
+		require.Empty(t, uploads)
+	})
+
+	t.Run("same revision", func(t *testing.T) {
+		resumed, err := repoUpload.ResumePermissionDenied(ctx, map[string]string{destinationID: "revision-1"})
+		require.NoError(t, err)
+		require.Zero(t, resumed)
+
+		upload, err := repoUpload.Get(ctx, uploadID)
+		require.NoError(t, err)
+		require.Equal(t, model.PermissionDenied, upload.Status)
+		require.Equal(t, "GRANT CREATE ON DATABASE dev", upload.PermissionDenied.Privilege)
+	})
+
+	t.Run("changed revision", func(t *testing.T) {
+		resumed, err := repoUpload.ResumePermissionDenied(ctx, map[string]string{destinationID: "revision-2", "other_destination_id": "revision-1"})
+		require.NoError(t, err)
+		require.EqualValues(t, 1, resumed)
+
+		upload, err := repoUpload.Get(ctx, uploadID)
+		require.NoError(t, err)
+		require.Equal(t, "creating_remote_schema_failed", upload.Status)
+		require.Nil(t, upload.PermissionDenied)
+
+		uploads, err := repoUpload.GetToProcess(ctx, destinationType, 10, repo.ProcessOptions{})
+		require.NoError(t, err)
+		require.Len(t, uploads, 1)
+	})
+}
+
 func TestUploads_Retry(t *testing.T) {
 	const (
 		sourceID        = "source_id"
@@ -1364,6 +1703,14 @@ func TestUploads_SyncsInfo(t *testing.T) {
 		sid, err := repoStaging.Insert(ctx, &model.StagingFileWithSchema{})
 		require.NoError(t, err)
 
+		var tags map[string]string
+		switch {
+		case i >= totalUploads-5:
+			tags = map[string]string{"batch": "backfill-1", "ticket": "T-1"}
+		case i >= totalUploads-10:
+			tags = map[string]string{"batch": "backfill-1"}
+		}
+
 		uploadID, err := repoUpload.CreateWithStagingFiles(ctx, model.Upload{
 			SourceID:        sourceID,
 			DestinationID:   destinationID,
@@ -1371,6 +1718,7 @@ func TestUploads_SyncsInfo(t *testing.T) {
 			WorkspaceID:     workspaceID,
 			Status:          model.Waiting,
 			NextRetryTime:   now,
+			Tags:            tags,
 		}, []*model.StagingFile{
 			{
 				ID:            fid,
@@ -1558,6 +1906,9 @@ func TestUploads_SyncsInfo(t *testing.T) {
 			require.Equal(t, uploadInfo.FirstEventAt.UTC(), firstEventAt.UTC())
 			require.Equal(t, uploadInfo.LastEventAt.UTC(), lastEventAt.UTC())
 		}
+		for _, uploadInfo := range nonMultiTenantUploadInfos[:5] {
+			require.Equal(t, map[string]string{"batch": "backfill-1", "ticket": "T-1"}, uploadInfo.Tags)
+		}
 		for _, uploadInfo := range nonMultiTenantUploadInfos[:26] {
 			require.Equal(t, uploadInfo.Status, model.Waiting)
 			require.Equal(t, uploadInfo.Error, "{}")
@@ -1616,6 +1967,27 @@ func TestUploads_SyncsInfo(t *testing.T) {
 				},
 				expectedIDs: reverseUploadIDs[51:76],
 			},
+			{
+				name: "tag",
+				options: model.SyncUploadOptions{
+					Tags: map[string]string{"batch": "backfill-1"},
+				},
+				expectedIDs: reverseUploadIDs[:10],
+			},
+			{
+				name: "all of the tags",
+				options: model.SyncUploadOptions{
+					Tags: map[string]string{"batch": "backfill-1", "ticket": "T-1"},
+				},
+				expectedIDs: reverseUploadIDs[:5],
+			},
+			{
+				name: "unknown tag",
+				options: model.SyncUploadOptions{
+					Tags: map[string]string{"batch": "backfill-2"},
+				},
+				expectedIDs: []int64{},
+			},
 		}
 
 		for _, tc := range testCases {
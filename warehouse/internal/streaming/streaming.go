@@ -0,0 +1,269 @@
+// Package streaming streams the events of the warehouse destinations supporting it directly into the tables of the
+// warehouse, bypassing the staging files, for the tables needing a lower latency than the one of the uploads.
+package streaming
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/utils/timeutil"
+	"github.com/rudderlabs/rudder-server/warehouse/encoding"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	"github.com/rudderlabs/rudder-server/warehouse/logfield"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+	"github.com/rudderlabs/rudder-server/warehouse/utils/types"
+)
+
+// ErrConnectionNotFound is returned when streaming events of a source and destination which aren't connected
+var ErrConnectionNotFound = errors.New("connection not found")
+
+type connections interface {
+	ConnectionSourcesMap(destID string) (map[string]model.Warehouse, bool)
+}
+
+type schemas interface {
+	GetForNamespace(ctx context.Context, sourceID, destID, namespace string) (model.WHSchema, error)
+}
+
+// Service streams events into the warehouses implementing [manager.Streamer].
+//
+// Events only get streamed into the tables and columns already in the schema of the warehouse, with the same data
+// types, while the rest is left to the uploads, which take care of the schema changes, the conversions of the values
+// and the discards.
+type Service struct {
+	logger       logger.Logger
+	statsFactory stats.Stats
+	connections  connections
+	schemas      schemas
+	newManager   func(destType string) (manager.Manager, error)
+	now          func() time.Time
+
+	mu        sync.Mutex
+	streamers map[string]*streamer // by source and destination
+
+	config struct {
+		enabled config.ValueLoader[bool]
+	}
+}
+
+// streamer is a manager set up for streaming into the warehouse of a source and destination
+type streamer struct {
+	manager    manager.Manager
+	streamer   manager.Streamer
+	revisionID string
+}
+
+func New(conf *config.Config, log logger.Logger, statsFactory stats.Stats, connections connections, schemas schemas) *Service {
+	s := &Service{
+		logger:       log.Child("streaming"),
+		statsFactory: statsFactory,
+		connections:  connections,
+		schemas:      schemas,
+		newManager: func(destType string) (manager.Manager, error) {
+			return manager.New(destType, conf, log, statsFactory)
+		},
+		now:       timeutil.Now,
+		streamers: make(map[string]*streamer),
+	}
+	s.config.enabled = conf.GetReloadableBoolVar(true, "Warehouse.streaming.enabled")
+	return s
+}
+
+// Stream streams the events, i.e. the lines of a staging file, into the tables of the warehouse of the source and
+// destination. It returns whether each of the events got streamed, the ones which didn't being left to the uploads.
+func (s *Service) Stream(ctx context.Context, sourceID, destinationID string, events []json.RawMessage) ([]bool, error) {
+	streamed := make([]bool, len(events))
+
+	sources, ok := s.connections.ConnectionSourcesMap(destinationID)
+	if !ok {
+		return nil, ErrConnectionNotFound
+	}
+	warehouse, ok := sources[sourceID]
+	if !ok {
+		return nil, ErrConnectionNotFound
+	}
+	if !s.config.enabled.Load() {
+		return streamed, nil
+	}
+
+	st, err := s.streamer(ctx, warehouse)
+	if err != nil {
+		return nil, fmt.Errorf("setting up streamer: %w", err)
+	}
+	if st == nil {
+		return streamed, nil // streaming isn't supported by the warehouse
+	}
+
+	whSchema, err := s.schemas.GetForNamespace(ctx, sourceID, destinationID, warehouse.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("getting schema: %w", err)
+	}
+
+	now := s.now()
+	rowsByTable := make(map[string][]map[string]any)
+	indexesByTable := make(map[string][]int)
+	for i, event := range events {
+		var batchRouterEvent types.BatchRouterEvent
+		if err := json.Unmarshal(event, &batchRouterEvent); err != nil {
+			continue
+		}
+		tableName := batchRouterEvent.Metadata.Table
+		row, ok := streamedRow(warehouse.Type, whSchema.Schema[tableName], &batchRouterEvent, now)
+		if !ok {
+			continue
+		}
+		rowsByTable[tableName] = append(rowsByTable[tableName], row)
+		indexesByTable[tableName] = append(indexesByTable[tableName], i)
+	}
+
+	for tableName, rows := range rowsByTable {
+		tags := stats.Tags{
+			"module":        "warehouse",
+			"workspaceId":   warehouse.WorkspaceID,
+			"destType":      warehouse.Type,
+			"sourceId":      sourceID,
+			"destinationId": destinationID,
+			"tableName":     warehouseutils.TableNameForStats(tableName),
+		}
+		start := s.now()
+		if err := st.streamer.StreamRows(ctx, tableName, whSchema.Schema[tableName], rows); err != nil {
+			s.logger.Warnw("streaming rows, leaving them to the uploads",
+				logfield.SourceID, sourceID,
+				logfield.DestinationID, destinationID,
+				logfield.DestinationType, warehouse.Type,
+				logfield.WorkspaceID, warehouse.WorkspaceID,
+				logfield.Namespace, warehouse.Namespace,
+				logfield.TableName, tableName,
+				logfield.Error, err.Error(),
+			)
+			s.statsFactory.NewTaggedStat("warehouse_streaming_failed_rows", stats.CountType, tags).Count(len(rows))
+			continue
+		}
+		s.statsFactory.NewTaggedStat("warehouse_streaming_time", stats.TimerType, tags).Since(start)
+		s.statsFactory.NewTaggedStat("warehouse_streaming_rows", stats.CountType, tags).Count(len(rows))
+		for _, i := range indexesByTable[tableName] {
+			streamed[i] = true
+		}
+	}
+	return streamed, nil
+}
+
+// streamer returns the streamer of the warehouse, setting it up again if the destination changed since, or nil if the
+// warehouse doesn't support streaming
+func (s *Service) streamer(ctx context.Context, warehouse model.Warehouse) (*streamer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := warehouse.Source.ID + ":" + warehouse.Destination.ID
+	if st, ok := s.streamers[key]; ok {
+		if st.revisionID == warehouse.Destination.RevisionID {
+			return st, nil
+		}
+		st.manager.Cleanup(ctx)
+		delete(s.streamers, key)
+	}
+
+	m, err := s.newManager(warehouse.Type)
+	if err != nil {
+		return nil, fmt.Errorf("creating manager: %w", err)
+	}
+	ms, ok := m.(manager.Streamer)
+	if !ok {
+		return nil, nil
+	}
+	if err := m.Setup(ctx, warehouse, &uploader{warehouse: warehouse}); err != nil {
+		return nil, fmt.Errorf("setting up manager: %w", err)
+	}
+	st := &streamer{manager: m, streamer: ms, revisionID: warehouse.Destination.RevisionID}
+	s.streamers[key] = st
+	return st, nil
+}
+
+// Cleanup closes the connections of the streamers to the warehouses
+func (s *Service) Cleanup(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, st := range s.streamers {
+		st.manager.Cleanup(ctx)
+		delete(s.streamers, key)
+	}
+}
+
+// streamedRow returns the row of the event to be streamed into its table, or false if the event doesn't fit the schema
+// of the table in the warehouse, e.g. because of a new column or a column of a different data type
+func streamedRow(destType string, tableSchema model.TableSchema, event *types.BatchRouterEvent, now time.Time) (map[string]any, bool) {
+	if len(tableSchema) == 0 {
+		return nil, false
+	}
+	row := make(map[string]any, len(event.Data))
+	for columnName, value := range event.Data {
+		columnType, ok := tableSchema[columnName]
+		if !ok || columnType != event.Metadata.Columns[columnName] {
+			return nil, false
+		}
+		if value == nil {
+			continue
+		}
+		if columnType == model.IntDataType || columnType == model.BigIntDataType {
+			floatValue, ok := value.(float64)
+			if !ok {
+				return nil, false
+			}
+			value = int64(floatValue)
+		}
+		row[columnName] = value
+	}
+	// the load time columns are set by the warehouse, as for the load files
+	for _, columnName := range []string{encoding.UUIDTsColumn, encoding.LoadedAtColumn} {
+		columnName = warehouseutils.ToProviderCase(destType, columnName)
+		if _, ok := tableSchema[columnName]; ok {
+			row[columnName] = now
+		}
+	}
+	return row, true
+}
+
+// uploader is the uploader of the managers set up for streaming, which neither load files nor sync schemas
+type uploader struct {
+	warehouse model.Warehouse
+}
+
+func (*uploader) IsWarehouseSchemaEmpty() bool                          { return false }
+func (*uploader) GetLocalSchema(context.Context) (model.Schema, error)  { return model.Schema{}, nil }
+func (*uploader) UpdateLocalSchema(context.Context, model.Schema) error { return nil }
+func (*uploader) GetTableSchemaInWarehouse(string) model.TableSchema    { return nil }
+func (*uploader) GetTableSchemaInUpload(string) model.TableSchema       { return nil }
+func (*uploader) GetSampleLoadFileLocation(context.Context, string) (string, error) {
+	return "", nil
+}
+
+func (*uploader) GetLoadFilesMetadata(context.Context, warehouseutils.GetLoadFilesOptions) ([]warehouseutils.LoadFile, error) {
+	return nil, nil
+}
+
+func (*uploader) GetSingleLoadFile(context.Context, string) (warehouseutils.LoadFile, error) {
+	return warehouseutils.LoadFile{}, nil
+}
+func (*uploader) ShouldOnDedupUseNewRecord() bool           { return false }
+func (*uploader) GetLoadFileGenStartTIme() time.Time        { return time.Time{} }
+func (*uploader) GetFirstLastEvent() (time.Time, time.Time) { return time.Time{}, time.Time{} }
+func (*uploader) CanAppend() bool                           { return true }
+
+func (u *uploader) GetLoadFileType() string {
+	return warehouseutils.GetLoadFileType(u.warehouse.Type)
+}
+
+func (u *uploader) UseRudderStorage() bool {
+	return misc.IsConfiguredToUseRudderObjectStorage(u.warehouse.Destination.Config)
+}
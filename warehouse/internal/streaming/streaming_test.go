@@ -0,0 +1,136 @@
+package streaming
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+type fakeConnections map[string]map[string]model.Warehouse
+
+func (c fakeConnections) ConnectionSourcesMap(destID string) (map[string]model.Warehouse, bool) {
+	m, ok := c[destID]
+	return m, ok
+}
+
+type fakeSchemas model.Schema
+
+func (s fakeSchemas) GetForNamespace(context.Context, string, string, string) (model.WHSchema, error) {
+	return model.WHSchema{Schema: model.Schema(s)}, nil
+}
+
+type fakeStreamer struct {
+	manager.Manager
+
+	setups    int
+	cleanups  int
+	failTable string
+	rows      map[string][]map[string]any
+}
+
+func (f *fakeStreamer) Setup(context.Context, model.Warehouse, warehouseutils.Uploader) error {
+	f.setups++
+	return nil
+}
+
+func (f *fakeStreamer) Cleanup(context.Context) {
+	f.cleanups++
+}
+
+func (f *fakeStreamer) StreamRows(_ context.Context, tableName string, _ model.TableSchema, rows []map[string]any) error {
+	if tableName == f.failTable {
+		return errors.New("streaming failed")
+	}
+	f.rows[tableName] = append(f.rows[tableName], rows...)
+	return nil
+}
+
+func TestService_Stream(t *testing.T) {
+	now := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	warehouse := model.Warehouse{
+		WorkspaceID: "workspace-1",
+		Source:      backendconfig.SourceT{ID: "source-1"},
+		Destination: backendconfig.DestinationT{ID: "destination-1", RevisionID: "revision-1"},
+		Namespace:   "namespace",
+		Type:        warehouseutils.CLICKHOUSE,
+	}
+	schema := fakeSchemas{
+		"tracks":   {"id": "string", "count": "int", "received_at": "datetime", "uuid_ts": "datetime"},
+		"products": {"id": "string"},
+	}
+	events := []json.RawMessage{
+		[]byte(`{"metadata":{"table":"tracks","columns":{"id":"string","count":"int","received_at":"datetime"}},"data":{"id":"1","count":2,"received_at":"2023-01-01T09:00:00.000Z"}}`),
+		[]byte(`{"metadata":{"table":"tracks","columns":{"id":"string","new_column":"string"}},"data":{"id":"2","new_column":"value"}}`),
+		[]byte(`{"metadata":{"table":"tracks","columns":{"id":"string","count":"string"}},"data":{"id":"3","count":"two"}}`),
+		[]byte(`{"metadata":{"table":"new_table","columns":{"id":"string"}},"data":{"id":"4"}}`),
+		[]byte(`{"metadata":{"table":"products","columns":{"id":"string"}},"data":{"id":"5"}}`),
+		[]byte(`not json`),
+	}
+
+	newService := func(t *testing.T, c *config.Config, newManager func(string) (manager.Manager, error)) *Service {
+		t.Helper()
+		s := New(c, logger.NOP, stats.NOP, fakeConnections{"destination-1": {"source-1": warehouse}}, schema)
+		s.newManager = newManager
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	t.Run("streams the events fitting the schema of the warehouse", func(t *testing.T) {
+		st := &fakeStreamer{failTable: "products", rows: map[string][]map[string]any{}}
+		s := newService(t, config.New(), func(string) (manager.Manager, error) { return st, nil })
+
+		streamed, err := s.Stream(context.Background(), "source-1", "destination-1", events)
+		require.NoError(t, err)
+		require.Equal(t, []bool{true, false, false, false, false, false}, streamed)
+		require.Equal(t, map[string][]map[string]any{
+			"tracks": {{"id": "1", "count": int64(2), "received_at": "2023-01-01T09:00:00.000Z", "uuid_ts": now}},
+		}, st.rows)
+
+		_, err = s.Stream(context.Background(), "source-1", "destination-1", events)
+		require.NoError(t, err)
+		require.Equal(t, 1, st.setups, "the streamer is reused")
+
+		s.Cleanup(context.Background())
+		require.Equal(t, 1, st.cleanups)
+	})
+
+	t.Run("warehouse not supporting streaming", func(t *testing.T) {
+		s := newService(t, config.New(), func(string) (manager.Manager, error) { return struct{ manager.Manager }{}, nil })
+
+		streamed, err := s.Stream(context.Background(), "source-1", "destination-1", events)
+		require.NoError(t, err)
+		require.Equal(t, make([]bool, len(events)), streamed)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		c := config.New()
+		c.Set("Warehouse.streaming.enabled", false)
+		s := newService(t, c, func(string) (manager.Manager, error) {
+			return nil, errors.New("unexpected manager")
+		})
+
+		streamed, err := s.Stream(context.Background(), "source-1", "destination-1", events)
+		require.NoError(t, err)
+		require.Equal(t, make([]bool, len(events)), streamed)
+	})
+
+	t.Run("unknown connection", func(t *testing.T) {
+		s := newService(t, config.New(), nil)
+
+		_, err := s.Stream(context.Background(), "source-2", "destination-1", events)
+		require.ErrorIs(t, err, ErrConnectionNotFound)
+	})
+}
@@ -0,0 +1,236 @@
+// Package synctrigger triggers the downstream jobs of a warehouse destination, i.e. a dbt Cloud job, an Airflow DAG or
+// a generic webhook, once an upload of the destination has exported its data, so that the transformations of the
+// synced tables start as soon as they are synced rather than on a timer.
+package synctrigger
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+)
+
+// the kinds of downstream jobs, set in the syncTrigger setting of the destinations
+const (
+	DbtCloud = "dbtCloud"
+	Airflow  = "airflow"
+	Webhook  = "webhook"
+)
+
+const (
+	// SignatureHeader is the header of the webhook requests holding the hex encoded HMAC-SHA256 of their body, keyed
+	// with the secret of the webhook, if any
+	SignatureHeader = "X-Rudder-Signature"
+
+	defaultDbtCloudURL = "https://cloud.getdbt.com"
+)
+
+// Table is a table synced by an upload
+type Table struct {
+	Name     string `json:"name"`
+	RowCount int64  `json:"rowCount"`
+}
+
+// Sync is an upload which has exported its data
+type Sync struct {
+	UploadID        int64     `json:"uploadId"`
+	WorkspaceID     string    `json:"workspaceId"`
+	SourceID        string    `json:"sourceId"`
+	DestinationID   string    `json:"destinationId"`
+	DestinationType string    `json:"destinationType"`
+	Namespace       string    `json:"namespace"`
+	Tables          []Table   `json:"tables"`
+	CompletedAt     time.Time `json:"completedAt"`
+}
+
+// Trigger triggers the downstream job configured for the warehouse destinations
+type Trigger struct {
+	conf         *config.Config
+	logger       logger.Logger
+	statsFactory stats.Stats
+	client       *http.Client
+
+	config struct {
+		maxRetries config.ValueLoader[int]
+	}
+}
+
+func New(conf *config.Config, log logger.Logger, statsFactory stats.Stats) *Trigger {
+	t := &Trigger{
+		conf:         conf,
+		logger:       log.Child("sync-trigger"),
+		statsFactory: statsFactory,
+		client:       &http.Client{Timeout: conf.GetDurationVar(30, time.Second, "Warehouse.syncTrigger.timeout")},
+	}
+	t.config.maxRetries = conf.GetReloadableIntVar(3, 1, "Warehouse.syncTrigger.maxRetries")
+	return t
+}
+
+// Trigger triggers the downstream job configured for the warehouse with the sync, if any, retrying on server errors.
+// The sync is complete by then, so failing to trigger the job is only reported.
+func (t *Trigger) Trigger(ctx context.Context, warehouse model.Warehouse, sync Sync) {
+	kind := warehouse.GetStringDestinationConfig(t.conf, model.SyncTriggerSetting)
+	if kind == "" {
+		return
+	}
+	log := t.logger.Withn(
+		logger.NewStringField("kind", kind),
+		logger.NewIntField("uploadId", sync.UploadID),
+		logger.NewStringField("destinationId", sync.DestinationID),
+	)
+
+	req, err := t.request(warehouse, kind, sync)
+	if err == nil {
+		b := backoff.WithContext(backoff.WithMaxRetries(backoff.NewExponentialBackOff(), uint64(t.config.maxRetries.Load())), ctx)
+		err = backoff.RetryNotify(func() error {
+			return t.do(ctx, req)
+		}, b, func(err error, d time.Duration) {
+			log.Warnn("Retrying sync trigger", logger.NewDurationField("backoff", d), logger.NewErrorField(err))
+		})
+	}
+
+	status := "succeeded"
+	if err != nil {
+		status = "failed"
+		log.Errorn("Triggering downstream job after sync", logger.NewErrorField(err))
+	} else {
+		log.Infon("Triggered downstream job after sync")
+	}
+	t.statsFactory.NewTaggedStat("warehouse_sync_triggers", stats.CountType, stats.Tags{
+		"workspaceId": sync.WorkspaceID,
+		"destID":      sync.DestinationID,
+		"destType":    sync.DestinationType,
+		"kind":        kind,
+		"status":      status,
+	}).Increment()
+}
+
+// triggerRequest is an http request which can be sent multiple times
+type triggerRequest struct {
+	method string
+	url    string
+	header http.Header
+	body   []byte
+}
+
+// request returns the request triggering the downstream job of the kind, as configured for the warehouse
+func (t *Trigger) request(warehouse model.Warehouse, kind string, sync Sync) (*triggerRequest, error) {
+	setting := func(key model.DestinationConfigSetting) string {
+		return warehouse.GetStringDestinationConfig(t.conf, key)
+	}
+	header := http.Header{"Content-Type": []string{"application/json"}}
+
+	switch kind {
+	case DbtCloud:
+		accountID, jobID, token := setting(model.DbtCloudAccountIDSetting), setting(model.DbtCloudJobIDSetting), setting(model.DbtCloudTokenSetting)
+		if accountID == "" || jobID == "" || token == "" {
+			return nil, errors.New("dbt Cloud account id, job id and token are required")
+		}
+		baseURL := setting(model.DbtCloudURLSetting)
+		if baseURL == "" {
+			baseURL = defaultDbtCloudURL
+		}
+		body, err := json.Marshal(map[string]string{
+			"cause": fmt.Sprintf("Triggered by the sync of upload %d to %s", sync.UploadID, sync.Namespace),
+		})
+		if err != nil {
+			return nil, err
+		}
+		header.Set("Authorization", "Token "+token)
+		return &triggerRequest{
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/api/v2/accounts/%s/jobs/%s/run/", strings.TrimSuffix(baseURL, "/"), url.PathEscape(accountID), url.PathEscape(jobID)),
+			header: header,
+			body:   body,
+		}, nil
+
+	case Airflow:
+		baseURL, dagID := setting(model.AirflowURLSetting), setting(model.AirflowDagIDSetting)
+		if baseURL == "" || dagID == "" {
+			return nil, errors.New("airflow url and dag id are required")
+		}
+		body, err := json.Marshal(map[string]interface{}{
+			"dag_run_id": fmt.Sprintf("rudder-upload-%d-%d", sync.UploadID, sync.CompletedAt.Unix()),
+			"conf":       sync,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if username := setting(model.AirflowUsernameSetting); username != "" {
+			credentials := username + ":" + setting(model.AirflowPasswordSetting)
+			header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+		}
+		return &triggerRequest{
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/api/v1/dags/%s/dagRuns", strings.TrimSuffix(baseURL, "/"), url.PathEscape(dagID)),
+			header: header,
+			body:   body,
+		}, nil
+
+	case Webhook:
+		webhookURL := setting(model.SyncTriggerWebhookURLSetting)
+		if webhookURL == "" {
+			return nil, errors.New("webhook url is required")
+		}
+		body, err := json.Marshal(sync)
+		if err != nil {
+			return nil, err
+		}
+		if secret := setting(model.SyncTriggerWebhookSecretSetting); secret != "" {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(body)
+			header.Set(SignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+		}
+		return &triggerRequest{
+			method: http.MethodPost,
+			url:    webhookURL,
+			header: header,
+			body:   body,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown sync trigger: %q", kind)
+	}
+}
+
+// do sends the request, failing permanently on client errors
+func (t *Trigger) do(ctx context.Context, tr *triggerRequest) error {
+	req, err := http.NewRequestWithContext(ctx, tr.method, tr.url, bytes.NewReader(tr.body))
+	if err != nil {
+		return backoff.Permanent(fmt.Errorf("creating request: %w", err))
+	}
+	req.Header = tr.header.Clone()
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, respBody)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return backoff.Permanent(err)
+	}
+	return err
+}
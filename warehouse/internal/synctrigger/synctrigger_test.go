@@ -0,0 +1,151 @@
+package synctrigger_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/synctrigger"
+)
+
+func TestTrigger(t *testing.T) {
+	syncInfo := synctrigger.Sync{
+		UploadID:        1,
+		WorkspaceID:     "workspace-1",
+		SourceID:        "source-1",
+		DestinationID:   "destination-1",
+		DestinationType: "POSTGRES",
+		Namespace:       "namespace",
+		Tables:          []synctrigger.Table{{Name: "tracks", RowCount: 10}, {Name: "users", RowCount: 2}},
+		CompletedAt:     time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+	}
+	type request struct {
+		method, path, authorization, signature string
+		body                                   []byte
+	}
+	setup := func(t *testing.T, statusCodes ...int) (*httptest.Server, func() []request) {
+		var (
+			mu       sync.Mutex
+			requests []request
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			defer mu.Unlock()
+			requests = append(requests, request{r.Method, r.URL.Path, r.Header.Get("Authorization"), r.Header.Get(synctrigger.SignatureHeader), body})
+			statusCode := http.StatusOK
+			if len(requests) <= len(statusCodes) {
+				statusCode = statusCodes[len(requests)-1]
+			}
+			w.WriteHeader(statusCode)
+		}))
+		t.Cleanup(srv.Close)
+		return srv, func() []request {
+			mu.Lock()
+			defer mu.Unlock()
+			return requests
+		}
+	}
+	trigger := func(destConfig map[string]interface{}) (*memstats.Store, func()) {
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+		tr := synctrigger.New(config.New(), logger.NOP, statsStore)
+		return statsStore, func() {
+			tr.Trigger(context.Background(), model.Warehouse{
+				Destination: backendconfig.DestinationT{ID: "destination-1", Config: destConfig},
+			}, syncInfo)
+		}
+	}
+	triggered := func(statsStore *memstats.Store, kind, status string) float64 {
+		return statsStore.Get("warehouse_sync_triggers", stats.Tags{
+			"workspaceId": "workspace-1",
+			"destID":      "destination-1",
+			"destType":    "POSTGRES",
+			"kind":        kind,
+			"status":      status,
+		}).LastValue()
+	}
+
+	t.Run("dbt cloud", func(t *testing.T) {
+		srv, getRequests := setup(t)
+		statsStore, run := trigger(map[string]interface{}{
+			"syncTrigger":       "dbtCloud",
+			"dbtCloudUrl":       srv.URL,
+			"dbtCloudAccountId": "account-1",
+			"dbtCloudJobId":     "job-1",
+			"dbtCloudToken":     "token",
+		})
+		run()
+		requests := getRequests()
+		require.Len(t, requests, 1)
+		require.Equal(t, http.MethodPost, requests[0].method)
+		require.Equal(t, "/api/v2/accounts/account-1/jobs/job-1/run/", requests[0].path)
+		require.Equal(t, "Token token", requests[0].authorization)
+		require.Contains(t, gjson.GetBytes(requests[0].body, "cause").String(), "upload 1")
+		require.EqualValues(t, 1, triggered(statsStore, synctrigger.DbtCloud, "succeeded"))
+	})
+
+	t.Run("airflow", func(t *testing.T) {
+		srv, getRequests := setup(t, http.StatusServiceUnavailable)
+		statsStore, run := trigger(map[string]interface{}{
+			"syncTrigger":     "airflow",
+			"airflowUrl":      srv.URL + "/",
+			"airflowDagId":    "dag-1",
+			"airflowUsername": "user",
+			"airflowPassword": "password",
+		})
+		run()
+		requests := getRequests()
+		require.Len(t, requests, 2, "server errors should be retried")
+		require.Equal(t, "/api/v1/dags/dag-1/dagRuns", requests[1].path)
+		require.Equal(t, "Basic dXNlcjpwYXNzd29yZA==", requests[1].authorization)
+		require.Equal(t, "namespace", gjson.GetBytes(requests[1].body, "conf.namespace").String())
+		require.EqualValues(t, 10, gjson.GetBytes(requests[1].body, "conf.tables.0.rowCount").Int())
+		require.EqualValues(t, 1, triggered(statsStore, synctrigger.Airflow, "succeeded"))
+	})
+
+	t.Run("webhook", func(t *testing.T) {
+		srv, getRequests := setup(t, http.StatusUnauthorized)
+		statsStore, run := trigger(map[string]interface{}{
+			"syncTrigger":              "webhook",
+			"syncTriggerWebhookUrl":    srv.URL + "/hook",
+			"syncTriggerWebhookSecret": "secret",
+		})
+		run()
+		requests := getRequests()
+		require.Len(t, requests, 1, "client errors should not be retried")
+		require.Equal(t, "/hook", requests[0].path)
+		require.Equal(t, "users", gjson.GetBytes(requests[0].body, "tables.1.name").String())
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(requests[0].body)
+		require.Equal(t, hex.EncodeToString(mac.Sum(nil)), requests[0].signature)
+		require.EqualValues(t, 1, triggered(statsStore, synctrigger.Webhook, "failed"))
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		statsStore, run := trigger(map[string]interface{}{})
+		run()
+		require.Empty(t, statsStore.GetAll())
+
+		statsStore, run = trigger(map[string]interface{}{"syncTrigger": "dbtCloud"})
+		run()
+		require.EqualValues(t, 1, triggered(statsStore, synctrigger.DbtCloud, "failed"), "incomplete settings should fail")
+	})
+}
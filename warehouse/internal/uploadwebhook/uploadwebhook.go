@@ -0,0 +1,174 @@
+// Package uploadwebhook notifies the webhook configured for a warehouse destination of the transitions of its uploads,
+// i.e. once an upload starts, has exported the user tables, has exported its data or gets aborted, so that downstream
+// orchestration can be driven by the events of the uploads rather than by polling them.
+package uploadwebhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+)
+
+// the transitions of the uploads the webhook can be notified of, set in the uploadWebhookEvents setting of the
+// destinations
+const (
+	Started            = "started"
+	ExportedUserTables = "exported_user_tables"
+	ExportedData       = "exported_data"
+	Aborted            = "aborted"
+)
+
+// Events are the transitions the webhook is notified of if the destination doesn't select any
+var Events = []string{Started, ExportedUserTables, ExportedData, Aborted}
+
+// SignatureHeader is the header of the webhook requests holding the hex encoded HMAC-SHA256 of their body, keyed with
+// the secret of the webhook, if any
+const SignatureHeader = "X-Rudder-Signature"
+
+// Table is a table of an upload, along with its load status
+type Table struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	RowCount int64  `json:"rowCount"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Event is a transition of an upload
+type Event struct {
+	Event           string    `json:"event"`
+	UploadID        int64     `json:"uploadId"`
+	WorkspaceID     string    `json:"workspaceId"`
+	SourceID        string    `json:"sourceId"`
+	DestinationID   string    `json:"destinationId"`
+	DestinationType string    `json:"destinationType"`
+	Namespace       string    `json:"namespace"`
+	Attempt         int64     `json:"attempt"`
+	Tables          []Table   `json:"tables"`
+	Error           string    `json:"error,omitempty"`
+	OccurredAt      time.Time `json:"occurredAt"`
+}
+
+// Notifier notifies the webhooks configured for the warehouse destinations of the transitions of their uploads
+type Notifier struct {
+	conf         *config.Config
+	logger       logger.Logger
+	statsFactory stats.Stats
+	client       *http.Client
+
+	config struct {
+		maxRetries config.ValueLoader[int]
+	}
+}
+
+func New(conf *config.Config, log logger.Logger, statsFactory stats.Stats) *Notifier {
+	n := &Notifier{
+		conf:         conf,
+		logger:       log.Child("upload-webhook"),
+		statsFactory: statsFactory,
+		client:       &http.Client{Timeout: conf.GetDurationVar(10, time.Second, "Warehouse.uploadWebhook.timeout")},
+	}
+	n.config.maxRetries = conf.GetReloadableIntVar(3, 1, "Warehouse.uploadWebhook.maxRetries")
+	return n
+}
+
+// Enabled returns whether the webhook of the warehouse is to be notified of the event, i.e. a webhook is configured
+// for the destination and the event is among the selected ones, all of them if none is selected
+func (n *Notifier) Enabled(warehouse model.Warehouse, event string) bool {
+	if n == nil || warehouse.GetStringDestinationConfig(n.conf, model.UploadWebhookURLSetting) == "" {
+		return false
+	}
+	events := warehouse.GetStringDestinationConfig(n.conf, model.UploadWebhookEventsSetting)
+	if events == "" {
+		return slices.Contains(Events, event)
+	}
+	return slices.ContainsFunc(strings.Split(events, ","), func(e string) bool {
+		return strings.TrimSpace(e) == event
+	})
+}
+
+// Notify notifies the webhook of the warehouse of the event if enabled, retrying on server errors. The upload goes on
+// regardless, so failing to notify the webhook is only reported.
+func (n *Notifier) Notify(ctx context.Context, warehouse model.Warehouse, event Event) {
+	if !n.Enabled(warehouse, event.Event) {
+		return
+	}
+	log := n.logger.Withn(
+		logger.NewStringField("event", event.Event),
+		logger.NewIntField("uploadId", event.UploadID),
+		logger.NewStringField("destinationId", event.DestinationID),
+	)
+
+	body, err := json.Marshal(event)
+	if err == nil {
+		header := http.Header{"Content-Type": []string{"application/json"}}
+		if secret := warehouse.GetStringDestinationConfig(n.conf, model.UploadWebhookSecretSetting); secret != "" {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(body)
+			header.Set(SignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+		}
+		webhookURL := warehouse.GetStringDestinationConfig(n.conf, model.UploadWebhookURLSetting)
+
+		b := backoff.WithContext(backoff.WithMaxRetries(backoff.NewExponentialBackOff(), uint64(n.config.maxRetries.Load())), ctx)
+		err = backoff.RetryNotify(func() error {
+			return n.do(ctx, webhookURL, header, body)
+		}, b, func(err error, d time.Duration) {
+			log.Warnn("Retrying upload webhook", logger.NewDurationField("backoff", d), logger.NewErrorField(err))
+		})
+	}
+
+	status := "succeeded"
+	if err != nil {
+		status = "failed"
+		log.Errorn("Notifying upload webhook", logger.NewErrorField(err))
+	} else {
+		log.Infon("Notified upload webhook")
+	}
+	n.statsFactory.NewTaggedStat("warehouse_upload_webhooks", stats.CountType, stats.Tags{
+		"workspaceId": event.WorkspaceID,
+		"destID":      event.DestinationID,
+		"destType":    event.DestinationType,
+		"event":       event.Event,
+		"status":      status,
+	}).Increment()
+}
+
+// do sends the request, failing permanently on client errors
+func (n *Notifier) do(ctx context.Context, webhookURL string, header http.Header, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return backoff.Permanent(fmt.Errorf("creating request: %w", err))
+	}
+	req.Header = header.Clone()
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, respBody)
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return backoff.Permanent(err)
+	}
+	return err
+}
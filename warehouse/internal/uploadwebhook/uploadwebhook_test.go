@@ -0,0 +1,138 @@
+package uploadwebhook_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/uploadwebhook"
+)
+
+func TestNotifier(t *testing.T) {
+	event := func(name string) uploadwebhook.Event {
+		return uploadwebhook.Event{
+			Event:           name,
+			UploadID:        1,
+			WorkspaceID:     "workspace-1",
+			SourceID:        "source-1",
+			DestinationID:   "destination-1",
+			DestinationType: "POSTGRES",
+			Namespace:       "namespace",
+			Tables:          []uploadwebhook.Table{{Name: "tracks", Status: model.TableUploadExported, RowCount: 10}},
+			OccurredAt:      time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+		}
+	}
+	type request struct {
+		path, signature string
+		body            []byte
+	}
+	setup := func(t *testing.T, statusCodes ...int) (*httptest.Server, func() []request) {
+		var (
+			mu       sync.Mutex
+			requests []request
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			defer mu.Unlock()
+			requests = append(requests, request{r.URL.Path, r.Header.Get(uploadwebhook.SignatureHeader), body})
+			statusCode := http.StatusOK
+			if len(requests) <= len(statusCodes) {
+				statusCode = statusCodes[len(requests)-1]
+			}
+			w.WriteHeader(statusCode)
+		}))
+		t.Cleanup(srv.Close)
+		return srv, func() []request {
+			mu.Lock()
+			defer mu.Unlock()
+			return requests
+		}
+	}
+	notifier := func(t *testing.T) (*uploadwebhook.Notifier, *memstats.Store) {
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+		return uploadwebhook.New(config.New(), logger.NOP, statsStore), statsStore
+	}
+	warehouse := func(destConfig map[string]interface{}) model.Warehouse {
+		return model.Warehouse{
+			Destination: backendconfig.DestinationT{ID: "destination-1", Config: destConfig},
+		}
+	}
+	notified := func(statsStore *memstats.Store, event, status string) float64 {
+		return statsStore.Get("warehouse_upload_webhooks", stats.Tags{
+			"workspaceId": "workspace-1",
+			"destID":      "destination-1",
+			"destType":    "POSTGRES",
+			"event":       event,
+			"status":      status,
+		}).LastValue()
+	}
+
+	t.Run("all events", func(t *testing.T) {
+		srv, getRequests := setup(t, http.StatusServiceUnavailable)
+		n, statsStore := notifier(t)
+		wh := warehouse(map[string]interface{}{
+			"uploadWebhookUrl":    srv.URL + "/hook",
+			"uploadWebhookSecret": "secret",
+		})
+
+		for _, e := range uploadwebhook.Events {
+			require.True(t, n.Enabled(wh, e))
+		}
+		n.Notify(context.Background(), wh, event(uploadwebhook.ExportedData))
+
+		requests := getRequests()
+		require.Len(t, requests, 2, "server errors should be retried")
+		require.Equal(t, "/hook", requests[1].path)
+		require.Equal(t, uploadwebhook.ExportedData, gjson.GetBytes(requests[1].body, "event").String())
+		require.EqualValues(t, 10, gjson.GetBytes(requests[1].body, "tables.0.rowCount").Int())
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(requests[1].body)
+		require.Equal(t, hex.EncodeToString(mac.Sum(nil)), requests[1].signature)
+		require.EqualValues(t, 1, notified(statsStore, uploadwebhook.ExportedData, "succeeded"))
+	})
+
+	t.Run("selected events", func(t *testing.T) {
+		srv, getRequests := setup(t, http.StatusBadRequest)
+		n, statsStore := notifier(t)
+		wh := warehouse(map[string]interface{}{
+			"uploadWebhookUrl":    srv.URL,
+			"uploadWebhookEvents": "started, aborted",
+		})
+
+		require.False(t, n.Enabled(wh, uploadwebhook.ExportedData))
+		n.Notify(context.Background(), wh, event(uploadwebhook.ExportedData))
+		require.Empty(t, getRequests())
+
+		n.Notify(context.Background(), wh, event(uploadwebhook.Aborted))
+		require.Len(t, getRequests(), 1, "client errors should not be retried")
+		require.EqualValues(t, 1, notified(statsStore, uploadwebhook.Aborted, "failed"))
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		n, statsStore := notifier(t)
+		n.Notify(context.Background(), warehouse(map[string]interface{}{}), event(uploadwebhook.Started))
+		require.Empty(t, statsStore.GetAll())
+
+		var nilNotifier *uploadwebhook.Notifier
+		require.False(t, nilNotifier.Enabled(warehouse(map[string]interface{}{"uploadWebhookUrl": "http://localhost"}), uploadwebhook.Started))
+	})
+}
@@ -0,0 +1,35 @@
+package warehouse
+
+import (
+	"github.com/rudderlabs/rudder-server/services/sql-migrator/online"
+)
+
+// onlineMigrationsTable holds the state of the online migrations of the warehouse metadata tables
+const onlineMigrationsTable = "wh_online_migrations"
+
+// onlineMigrations are the migrations of the warehouse metadata tables which are too large for being altered at startup,
+// thus are applied in the background by the master. They are applied in order, new ones need to be appended.
+var onlineMigrations = []online.Migration{
+	{
+		ID: "wh_uploads_workspace_id_created_at_index",
+		Statements: []string{
+			// an index left invalid by a failed concurrent creation needs to be dropped before creating it again
+			`DROP INDEX CONCURRENTLY IF EXISTS wh_uploads_workspace_id_created_at_index`,
+			`CREATE INDEX CONCURRENTLY IF NOT EXISTS wh_uploads_workspace_id_created_at_index ON wh_uploads (workspace_id, created_at)`,
+		},
+	},
+	{
+		// uploads created before workspace ids were tracked take the workspace id of their staging files, if known
+		ID: "wh_uploads_workspace_id_backfill",
+		Backfill: &online.Backfill{
+			Table: "wh_uploads",
+			Key:   "id",
+			Update: `UPDATE wh_uploads u SET workspace_id = sf.workspace_id
+				FROM wh_staging_files sf
+				WHERE u.id >= $1 AND u.id < $2
+				AND u.workspace_id = ''
+				AND sf.id = u.start_staging_file_id
+				AND sf.workspace_id <> ''`,
+		},
+	},
+}
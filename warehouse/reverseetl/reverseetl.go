@@ -0,0 +1,408 @@
+// Package reverseetl syncs warehouse data back into the pipeline. It runs the queries configured for the reverse ETL
+// sources against their warehouse destinations on a schedule, diffs the results against a snapshot of the rows synced
+// before and sends the new or changed rows to the gateway as identify or track events of the sources, to be routed to
+// their destinations like any other event.
+package reverseetl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/utils/timeutil"
+	"github.com/rudderlabs/rudder-server/warehouse/bcm"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
+	sqlmw "github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/repo"
+	"github.com/rudderlabs/rudder-server/warehouse/multitenant"
+	whutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+// the types of the events emitted for the rows
+const (
+	Identify = "identify"
+	Track    = "track"
+)
+
+// nullValue is how the warehouse clients format null values
+const nullValue = "<nil>"
+
+// Model is the reverse ETL configuration of a source, set in its reverseEtl setting
+type Model struct {
+	// DestinationID is the id of the warehouse destination to run the query against
+	DestinationID string `json:"destinationId"`
+	Query         string `json:"query"`
+	// PrimaryKey is the column identifying the rows of the query across runs
+	PrimaryKey string `json:"primaryKey"`
+	// EventType is the type of the events emitted for the new or changed rows, identify or track
+	EventType string `json:"eventType"`
+	// EventName is the name of the track events
+	EventName string `json:"eventName"`
+	// UserIDColumn is the column holding the user id of the events, the primary key by default
+	UserIDColumn string `json:"userIdColumn"`
+	// SyncFrequency is the number of minutes between the runs of the query
+	SyncFrequency string `json:"syncFrequency"`
+}
+
+// syncSource is a source with a valid reverse ETL configuration
+type syncSource struct {
+	Model
+	WorkspaceID string
+	SourceID    string
+	WriteKey    string
+	frequency   time.Duration
+}
+
+type snapshotRepo interface {
+	Get(ctx context.Context, sourceID string) (map[string]string, error)
+	Upsert(ctx context.Context, sourceID string, hashes map[string]string) error
+}
+
+type connectionsProvider interface {
+	ConnectionSourcesMap(destID string) (map[string]model.Warehouse, bool)
+}
+
+type querier interface {
+	Query(statement string) (whutils.QueryResult, error)
+}
+
+type Syncer struct {
+	conf          *config.Config
+	logger        logger.Logger
+	statsFactory  stats.Stats
+	tenantManager *multitenant.Manager
+	bcManager     connectionsProvider
+	snapshotRepo  snapshotRepo
+	client        *http.Client
+	now           func() time.Time
+	connect       func(ctx context.Context, warehouse model.Warehouse) (querier, func(), error)
+
+	sourcesMu sync.RWMutex
+	sources   map[string]syncSource
+
+	config struct {
+		gatewayURL         string
+		maxConcurrentSyncs int
+		pollInterval       config.ValueLoader[time.Duration]
+		batchSize          config.ValueLoader[int]
+	}
+}
+
+func New(
+	conf *config.Config,
+	log logger.Logger,
+	statsFactory stats.Stats,
+	db *sqlmw.DB,
+	tenantManager *multitenant.Manager,
+	bcManager *bcm.BackendConfigManager,
+) *Syncer {
+	s := &Syncer{
+		conf:          conf,
+		logger:        log.Child("reverse-etl"),
+		statsFactory:  statsFactory,
+		tenantManager: tenantManager,
+		bcManager:     bcManager,
+		snapshotRepo:  repo.NewReverseETLSnapshots(db),
+		client:        &http.Client{Timeout: conf.GetDurationVar(30, time.Second, "Warehouse.reverseEtl.timeout")},
+		now:           timeutil.Now,
+		sources:       make(map[string]syncSource),
+	}
+	s.connect = s.connectWarehouse
+	s.config.gatewayURL = strings.TrimSuffix(conf.GetStringVar("http://localhost:8080", "Warehouse.reverseEtl.gatewayURL"), "/")
+	s.config.maxConcurrentSyncs = conf.GetIntVar(4, 1, "Warehouse.reverseEtl.maxConcurrentSyncs")
+	s.config.pollInterval = conf.GetReloadableDurationVar(60, time.Second, "Warehouse.reverseEtl.pollInterval")
+	s.config.batchSize = conf.GetReloadableIntVar(100, 1, "Warehouse.reverseEtl.batchSize")
+	return s
+}
+
+// Run keeps the reverse ETL sources in sync with the backend config and syncs them as often as configured, until the
+// context is cancelled
+func (s *Syncer) Run(ctx context.Context) error {
+	g, gCtx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		for data := range s.tenantManager.WatchConfig(gCtx) {
+			s.updateSources(data)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		lastSyncs := make(map[string]time.Time)
+		for {
+			select {
+			case <-gCtx.Done():
+				return nil
+			case <-time.After(s.config.pollInterval.Load()):
+			}
+			s.syncDue(gCtx, lastSyncs)
+		}
+	})
+	return g.Wait()
+}
+
+func (s *Syncer) updateSources(data map[string]backendconfig.ConfigT) {
+	sources := make(map[string]syncSource)
+	for workspaceID, wConfig := range data {
+		for _, source := range wConfig.Sources {
+			value, ok := source.Config["reverseEtl"]
+			if !ok || !source.Enabled {
+				continue
+			}
+			src, err := parseSource(workspaceID, source, value)
+			if err != nil {
+				s.logger.Warnn("Invalid reverse ETL source",
+					obskit.WorkspaceID(workspaceID),
+					obskit.SourceID(source.ID),
+					obskit.Error(err),
+				)
+				continue
+			}
+			sources[source.ID] = src
+		}
+	}
+
+	s.sourcesMu.Lock()
+	s.sources = sources
+	s.sourcesMu.Unlock()
+}
+
+func parseSource(workspaceID string, source backendconfig.SourceT, value interface{}) (syncSource, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return syncSource{}, fmt.Errorf("marshalling reverse etl config: %w", err)
+	}
+	src := syncSource{WorkspaceID: workspaceID, SourceID: source.ID, WriteKey: source.WriteKey}
+	if err := json.Unmarshal(raw, &src.Model); err != nil {
+		return syncSource{}, fmt.Errorf("unmarshalling reverse etl config: %w", err)
+	}
+	if src.DestinationID == "" || src.Query == "" || src.PrimaryKey == "" {
+		return syncSource{}, errors.New("destination id, query and primary key are required")
+	}
+	switch src.EventType {
+	case Identify:
+	case Track:
+		if src.EventName == "" {
+			return syncSource{}, errors.New("event name is required for track events")
+		}
+	default:
+		return syncSource{}, fmt.Errorf("unsupported event type: %q", src.EventType)
+	}
+	if src.UserIDColumn == "" {
+		src.UserIDColumn = src.PrimaryKey
+	}
+	frequency, err := strconv.Atoi(src.SyncFrequency)
+	if err != nil || frequency <= 0 {
+		return syncSource{}, fmt.Errorf("invalid sync frequency: %q", src.SyncFrequency)
+	}
+	src.frequency = time.Duration(frequency) * time.Minute
+	return src, nil
+}
+
+// syncDue syncs the sources which were not synced within their sync frequency
+func (s *Syncer) syncDue(ctx context.Context, lastSyncs map[string]time.Time) {
+	s.sourcesMu.RLock()
+	sources := lo.Values(s.sources)
+	s.sourcesMu.RUnlock()
+
+	now := s.now()
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(s.config.maxConcurrentSyncs)
+	for _, src := range sources {
+		if lastSync, ok := lastSyncs[src.SourceID]; ok && now.Sub(lastSync) < src.frequency {
+			continue
+		}
+		lastSyncs[src.SourceID] = now
+
+		g.Go(func() error {
+			tags := stats.Tags{"workspaceId": src.WorkspaceID, "sourceId": src.SourceID, "destID": src.DestinationID}
+			start := s.now()
+			err := s.sync(gCtx, src)
+			if err != nil {
+				if gCtx.Err() != nil {
+					return nil
+				}
+				s.logger.Errorn("Reverse ETL sync",
+					obskit.WorkspaceID(src.WorkspaceID),
+					obskit.SourceID(src.SourceID),
+					obskit.DestinationID(src.DestinationID),
+					obskit.Error(err),
+				)
+				s.statsFactory.NewTaggedStat("reverse_etl_sync_failures", stats.CountType, tags).Increment()
+				return nil
+			}
+			s.statsFactory.NewTaggedStat("reverse_etl_sync_time", stats.TimerType, tags).Since(start)
+			return nil
+		})
+	}
+	_ = g.Wait()
+}
+
+// sync runs the query of the source and sends the new or changed rows, recording them in the snapshot of the source
+// after each batch so that the rows of failed batches are sent again on the next run
+func (s *Syncer) sync(ctx context.Context, src syncSource) error {
+	warehouses, ok := s.bcManager.ConnectionSourcesMap(src.DestinationID)
+	if !ok || len(warehouses) == 0 {
+		return fmt.Errorf("warehouse destination %s not found", src.DestinationID)
+	}
+	// any connection of the destination will do, they share its credentials
+	warehouse := warehouses[lo.Min(lo.Keys(warehouses))]
+
+	q, closeFn, err := s.connect(ctx, warehouse)
+	if err != nil {
+		return fmt.Errorf("connecting to warehouse: %w", err)
+	}
+	defer closeFn()
+
+	result, err := q.Query(src.Query)
+	if err != nil {
+		return fmt.Errorf("running query: %w", err)
+	}
+	primaryKeyIndex, userIDIndex := slices.Index(result.Columns, src.PrimaryKey), slices.Index(result.Columns, src.UserIDColumn)
+	if primaryKeyIndex == -1 || userIDIndex == -1 {
+		return fmt.Errorf("columns %s and %s are required in the query results", src.PrimaryKey, src.UserIDColumn)
+	}
+
+	snapshot, err := s.snapshotRepo.Get(ctx, src.SourceID)
+	if err != nil {
+		return fmt.Errorf("getting snapshot: %w", err)
+	}
+
+	type changedRow struct {
+		primaryKey, hash string
+		event            map[string]interface{}
+	}
+	var changed []changedRow
+	for _, values := range result.Values {
+		row := make(map[string]interface{}, len(values))
+		for i, value := range values {
+			if value == nullValue {
+				row[result.Columns[i]] = nil
+				continue
+			}
+			row[result.Columns[i]] = value
+		}
+		hash, err := rowHash(src.Model, row)
+		if err != nil {
+			return fmt.Errorf("hashing row: %w", err)
+		}
+		primaryKey := values[primaryKeyIndex]
+		if snapshot[primaryKey] == hash {
+			continue
+		}
+		changed = append(changed, changedRow{primaryKey: primaryKey, hash: hash, event: s.event(src, values[userIDIndex], hash, row)})
+	}
+
+	tags := stats.Tags{"workspaceId": src.WorkspaceID, "sourceId": src.SourceID, "destID": src.DestinationID}
+	s.statsFactory.NewTaggedStat("reverse_etl_rows", stats.CountType, lo.Assign(tags, stats.Tags{"status": "unchanged"})).Count(len(result.Values) - len(changed))
+	for _, chunk := range lo.Chunk(changed, s.config.batchSize.Load()) {
+		if err := s.send(ctx, src.WriteKey, lo.Map(chunk, func(r changedRow, _ int) map[string]interface{} {
+			return r.event
+		})); err != nil {
+			return fmt.Errorf("sending events: %w", err)
+		}
+		if err := s.snapshotRepo.Upsert(ctx, src.SourceID, lo.SliceToMap(chunk, func(r changedRow) (string, string) {
+			return r.primaryKey, r.hash
+		})); err != nil {
+			return fmt.Errorf("updating snapshot: %w", err)
+		}
+		s.statsFactory.NewTaggedStat("reverse_etl_rows", stats.CountType, lo.Assign(tags, stats.Tags{"status": "sent"})).Count(len(chunk))
+	}
+
+	s.logger.Infon("Reverse ETL sync",
+		obskit.WorkspaceID(src.WorkspaceID),
+		obskit.SourceID(src.SourceID),
+		logger.NewIntField("rows", int64(len(result.Values))),
+		logger.NewIntField("changed", int64(len(changed))),
+	)
+	return nil
+}
+
+// rowHash returns the hash of the row along with the event mapping of the model, so that changing the mapping sends
+// all rows again
+func rowHash(m Model, row map[string]interface{}) (string, error) {
+	raw, err := json.Marshal([]interface{}{m.EventType, m.EventName, m.UserIDColumn, row})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (s *Syncer) event(src syncSource, userID, hash string, row map[string]interface{}) map[string]interface{} {
+	messageID := sha256.Sum256([]byte(src.SourceID + hash))
+	event := map[string]interface{}{
+		"type":              src.EventType,
+		"messageId":         hex.EncodeToString(messageID[:16]),
+		"userId":            userID,
+		"originalTimestamp": s.now().Format(time.RFC3339Nano),
+		"context": map[string]interface{}{
+			"sources": map[string]interface{}{"name": "reverse-etl", "destination_id": src.DestinationID},
+		},
+	}
+	switch src.EventType {
+	case Identify:
+		event["traits"] = row
+	case Track:
+		event["event"] = src.EventName
+		event["properties"] = row
+	}
+	return event
+}
+
+// send sends the events to the batch endpoint of the gateway with the write key of the source
+func (s *Syncer) send(ctx context.Context, writeKey string, events []map[string]interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"batch": events})
+	if err != nil {
+		return fmt.Errorf("marshalling batch: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.gatewayURL+"/v1/batch", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(writeKey, "")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, respBody)
+	}
+	return nil
+}
+
+func (s *Syncer) connectWarehouse(ctx context.Context, warehouse model.Warehouse) (querier, func(), error) {
+	whManager, err := manager.New(warehouse.Type, s.conf, s.logger, s.statsFactory)
+	if err != nil {
+		return nil, nil, err
+	}
+	whManager.SetConnectionTimeout(whutils.GetConnectionTimeout(warehouse.Type, warehouse.Destination.ID))
+	whClient, err := whManager.Connect(ctx, warehouse)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &whClient, whClient.Close, nil
+}
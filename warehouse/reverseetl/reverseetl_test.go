@@ -0,0 +1,195 @@
+package reverseetl
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	whutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+type mockSnapshotRepo struct {
+	hashes map[string]map[string]string
+}
+
+func (m *mockSnapshotRepo) Get(_ context.Context, sourceID string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	for primaryKey, hash := range m.hashes[sourceID] {
+		hashes[primaryKey] = hash
+	}
+	return hashes, nil
+}
+
+func (m *mockSnapshotRepo) Upsert(_ context.Context, sourceID string, hashes map[string]string) error {
+	if m.hashes[sourceID] == nil {
+		m.hashes[sourceID] = make(map[string]string)
+	}
+	for primaryKey, hash := range hashes {
+		m.hashes[sourceID][primaryKey] = hash
+	}
+	return nil
+}
+
+type mockConnections map[string]map[string]model.Warehouse
+
+func (m mockConnections) ConnectionSourcesMap(destID string) (map[string]model.Warehouse, bool) {
+	warehouses, ok := m[destID]
+	return warehouses, ok
+}
+
+type mockQuerier struct {
+	result whutils.QueryResult
+}
+
+func (m *mockQuerier) Query(string) (whutils.QueryResult, error) {
+	return m.result, nil
+}
+
+func TestSyncer(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		batches    [][]byte
+		statusCode = http.StatusOK
+	)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if writeKey, _, _ := r.BasicAuth(); writeKey != "write-key" || r.URL.Path != "/v1/batch" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if statusCode == http.StatusOK {
+			batches = append(batches, body)
+		}
+		w.WriteHeader(statusCode)
+	}))
+	t.Cleanup(gateway.Close)
+	sent := func() (events []gjson.Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, batch := range batches {
+			events = append(events, gjson.GetBytes(batch, "batch").Array()...)
+		}
+		batches = nil
+		return events
+	}
+
+	conf := config.New()
+	conf.Set("Warehouse.reverseEtl.gatewayURL", gateway.URL+"/")
+	conf.Set("Warehouse.reverseEtl.batchSize", 2)
+	s := New(conf, logger.NOP, stats.NOP, nil, nil, nil)
+	s.bcManager = mockConnections{"destination-1": {"source-2": model.Warehouse{Type: whutils.POSTGRES}}}
+	s.snapshotRepo = &mockSnapshotRepo{hashes: make(map[string]map[string]string)}
+	mockQ := &mockQuerier{}
+	s.connect = func(context.Context, model.Warehouse) (querier, func(), error) {
+		return mockQ, func() {}, nil
+	}
+
+	reverseEtl := func(m Model) map[string]interface{} {
+		raw, err := json.Marshal(m)
+		require.NoError(t, err)
+		var value map[string]interface{}
+		require.NoError(t, json.Unmarshal(raw, &value))
+		return value
+	}
+	s.updateSources(map[string]backendconfig.ConfigT{
+		"workspace-1": {
+			Sources: []backendconfig.SourceT{
+				{
+					ID:       "source-1",
+					WriteKey: "write-key",
+					Enabled:  true,
+					Config: map[string]interface{}{"reverseEtl": reverseEtl(Model{
+						DestinationID: "destination-1",
+						Query:         "SELECT id, email, plan FROM analytics.users",
+						PrimaryKey:    "id",
+						EventType:     Identify,
+						SyncFrequency: "60",
+					})},
+				},
+				{
+					ID:      "source-2",
+					Enabled: true,
+					Config: map[string]interface{}{"reverseEtl": reverseEtl(Model{
+						DestinationID: "destination-1",
+						Query:         "SELECT id FROM analytics.users",
+						PrimaryKey:    "id",
+						EventType:     Track,
+						SyncFrequency: "60",
+					})},
+				},
+				{
+					ID:      "source-3",
+					Enabled: true,
+				},
+			},
+		},
+	})
+	require.Len(t, s.sources, 1, "sources without a valid reverse ETL configuration should be skipped")
+	src := s.sources["source-1"]
+	require.Equal(t, "id", src.UserIDColumn)
+	require.Equal(t, time.Hour, src.frequency)
+
+	mockQ.result = whutils.QueryResult{
+		Columns: []string{"id", "email", "plan"},
+		Values: [][]string{
+			{"1", "one@example.com", "free"},
+			{"2", "two@example.com", "<nil>"},
+			{"3", "three@example.com", "pro"},
+		},
+	}
+	require.NoError(t, s.sync(context.Background(), src))
+	events := sent()
+	require.Len(t, events, 3, "all rows should be sent on the first sync")
+	require.Equal(t, "identify", events[0].Get("type").String())
+	require.Equal(t, "1", events[0].Get("userId").String())
+	require.Equal(t, "one@example.com", events[0].Get("traits.email").String())
+	require.Equal(t, gjson.Null, events[1].Get("traits.plan").Type)
+	require.NotEmpty(t, events[2].Get("messageId").String())
+
+	require.NoError(t, s.sync(context.Background(), src))
+	require.Empty(t, sent(), "unchanged rows should not be sent again")
+
+	mockQ.result.Values[1][2] = "pro"
+	mockQ.result.Values = append(mockQ.result.Values, []string{"4", "four@example.com", "free"})
+	mu.Lock()
+	statusCode = http.StatusBadGateway
+	mu.Unlock()
+	require.Error(t, s.sync(context.Background(), src))
+
+	mu.Lock()
+	statusCode = http.StatusOK
+	mu.Unlock()
+	require.NoError(t, s.sync(context.Background(), src))
+	events = sent()
+	require.Len(t, events, 2, "changed and new rows should be sent, including those of failed syncs")
+	require.Equal(t, "2", events[0].Get("userId").String())
+	require.Equal(t, "pro", events[0].Get("traits.plan").String())
+	require.Equal(t, "4", events[1].Get("userId").String())
+
+	t.Run("missing primary key column", func(t *testing.T) {
+		mockQ.result = whutils.QueryResult{Columns: []string{"email"}, Values: [][]string{{"one@example.com"}}}
+		require.Error(t, s.sync(context.Background(), src))
+	})
+
+	t.Run("missing destination", func(t *testing.T) {
+		src := src
+		src.DestinationID = "destination-2"
+		require.ErrorContains(t, s.sync(context.Background(), src), "not found")
+	})
+}
@@ -0,0 +1,83 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/repo"
+	"github.com/rudderlabs/rudder-server/warehouse/logfield"
+	whutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+// loadedTableUploadStatuses are the statuses of the table uploads which loaded some or all of their rows
+var loadedTableUploadStatuses = []string{
+	model.TableUploadExported,
+	model.TableUploadExportingFailed,
+	model.TableUploadQualityCheckFailed,
+	model.TableUploadIngesting,
+}
+
+// cleanupPartiallyLoadedTables deletes the rows loaded by the aborted upload from the tables it loaded, if enabled for
+// the destination, so that downstream consumers never see a partially loaded sync. Rows are identified by the load
+// audit columns, so only the tables loaded with them are cleaned up. The users table is left as is, since its rows are
+// merged with the ones of the previous uploads. The status of the cleanup is recorded in the metadata of the upload.
+func (job *UploadJob) cleanupPartiallyLoadedTables() {
+	if !job.warehouse.GetBoolDestinationConfig(model.CleanupOnAbortSetting) {
+		return
+	}
+
+	cleanup := &model.CleanupInfo{Status: model.CleanupSucceeded}
+	if err := job.deleteLoadedRows(cleanup); err != nil {
+		cleanup.Status = model.CleanupFailed
+		cleanup.Error = err.Error()
+		job.logger.Warnw("cleaning up partially loaded tables", logfield.Error, err.Error())
+	}
+	cleanup.CompletedAt = job.now()
+	job.upload.Cleanup = cleanup
+	job.counterStat("upload_cleanup", whutils.Tag{Name: "status", Value: cleanup.Status}).Count(1)
+
+	metadataJSON, err := json.Marshal(repo.ExtractUploadMetadata(job.upload))
+	if err != nil {
+		job.logger.Warnw("marshalling upload metadata", logfield.Error, err.Error())
+		return
+	}
+	if err := job.uploadsRepo.Update(job.ctx, job.upload.ID, []repo.UpdateKeyValue{
+		repo.UploadFieldMetadata(metadataJSON),
+	}); err != nil {
+		job.logger.Warnw("recording cleanup of partially loaded tables", logfield.Error, err.Error())
+	}
+}
+
+func (job *UploadJob) deleteLoadedRows(cleanup *model.CleanupInfo) error {
+	rollbacker, ok := job.whManager.(manager.LoadRollbacker)
+	if !ok {
+		cleanup.Status = model.CleanupUnsupported
+		return nil
+	}
+
+	tableUploads, err := job.tableUploadsRepo.GetByUploadID(job.ctx, job.upload.ID)
+	if err != nil {
+		return fmt.Errorf("getting table uploads: %w", err)
+	}
+
+	var (
+		loadIDColumn = whutils.ToProviderCase(job.warehouse.Type, whutils.LoadIDColumn)
+		usersTable   = whutils.ToProviderCase(job.warehouse.Type, whutils.UsersTable)
+	)
+	for _, tableUpload := range tableUploads {
+		if !slices.Contains(loadedTableUploadStatuses, tableUpload.Status) || tableUpload.TableName == usersTable {
+			continue
+		}
+		if _, ok := job.upload.UploadSchema[tableUpload.TableName][loadIDColumn]; !ok {
+			continue
+		}
+		if err := rollbacker.DeleteLoadedRows(job.ctx, tableUpload.TableName, job.upload.ID); err != nil {
+			return fmt.Errorf("deleting rows loaded into table %s: %w", tableUpload.TableName, err)
+		}
+		cleanup.Tables = append(cleanup.Tables, tableUpload.TableName)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+
+	integrationsconfig "github.com/rudderlabs/rudder-server/warehouse/integrations/config"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+// endpoint identifies the physical warehouse shared by the destinations of a workspace declaring the same
+// warehouseEndpoint setting. The zero value is no endpoint, i.e. the destination has the warehouse for itself.
+type endpoint struct {
+	workspaceID string
+	destType    string
+	name        string
+}
+
+func (e endpoint) String() string {
+	return e.workspaceID + ":" + e.destType + ":" + e.name
+}
+
+// Endpoints limits the load on the physical warehouses shared by several destinations, so that e.g. ten destinations
+// configured against one Redshift cluster don't overload it. Destinations declare the warehouse they load into through
+// their warehouseEndpoint setting, and the uploads and table loads of the destinations sharing an endpoint are limited
+// collectively:
+//   - Warehouse.<destType>.endpointMaxConcurrentUploads limits the uploads running at once, unlimited by default.
+//   - Warehouse.<destType>.endpointMaxParallelLoads limits the tables loaded at once, defaulting to
+//     Warehouse.<destType>.maxParallelLoads, which applies per upload otherwise.
+//
+// Endpoints are scoped by workspace and destination type. Destinations without an endpoint, as well as a nil Endpoints,
+// aren't limited.
+type Endpoints struct {
+	conf             *config.Config
+	maxParallelLoads map[string]config.ValueLoader[int]
+
+	mu                   sync.Mutex
+	maxConcurrentUploads map[string]config.ValueLoader[int] // by destination type
+	maxEndpointLoads     map[string]config.ValueLoader[int] // by destination type
+	uploads              map[endpoint]int                   // running uploads by endpoint
+	loads                map[endpoint]int                   // running table loads by endpoint
+	loadReleased         chan struct{}                      // closed whenever a table load is done
+}
+
+func NewEndpoints(conf *config.Config) *Endpoints {
+	return &Endpoints{
+		conf:                 conf,
+		maxParallelLoads:     integrationsconfig.MaxParallelLoadsMap(conf),
+		maxConcurrentUploads: make(map[string]config.ValueLoader[int]),
+		maxEndpointLoads:     make(map[string]config.ValueLoader[int]),
+		uploads:              make(map[endpoint]int),
+		loads:                make(map[endpoint]int),
+		loadReleased:         make(chan struct{}),
+	}
+}
+
+// endpointOf returns the endpoint of the warehouse, the zero value if it has none
+func endpointOf(conf *config.Config, warehouse model.Warehouse) endpoint {
+	name := warehouse.GetStringDestinationConfig(conf, model.WarehouseEndpointSetting)
+	if name == "" {
+		return endpoint{}
+	}
+	return endpoint{
+		workspaceID: warehouse.WorkspaceID,
+		destType:    warehouse.Type,
+		name:        name,
+	}
+}
+
+// TryAcquireUpload takes a slot for an upload loading into the endpoint, returning false if the uploads already running
+// against it reached the limit
+func (e *Endpoints) TryAcquireUpload(ep endpoint) bool {
+	if e == nil || ep == (endpoint{}) {
+		return true
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.uploads[ep] >= e.uploadsLimit(ep.destType) {
+		return false
+	}
+	e.uploads[ep]++
+	return true
+}
+
+// ReleaseUpload returns the slot taken through [Endpoints.TryAcquireUpload]
+func (e *Endpoints) ReleaseUpload(ep endpoint) {
+	if e == nil || ep == (endpoint{}) {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.uploads[ep] > 0 {
+		e.uploads[ep]--
+	}
+	if e.uploads[ep] == 0 {
+		delete(e.uploads, ep)
+	}
+}
+
+// AcquireLoad waits for a slot to load a table into the endpoint, until the context is done
+func (e *Endpoints) AcquireLoad(ctx context.Context, ep endpoint) error {
+	if e == nil || ep == (endpoint{}) {
+		return nil
+	}
+	for {
+		e.mu.Lock()
+		if e.loads[ep] < e.loadsLimit(ep.destType) {
+			e.loads[ep]++
+			e.mu.Unlock()
+			return nil
+		}
+		loadReleased := e.loadReleased
+		e.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for loads into endpoint %s: %w", ep, ctx.Err())
+		case <-loadReleased:
+		}
+	}
+}
+
+// ReleaseLoad returns the slot taken through [Endpoints.AcquireLoad]
+func (e *Endpoints) ReleaseLoad(ep endpoint) {
+	if e == nil || ep == (endpoint{}) {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.loads[ep] > 0 {
+		e.loads[ep]--
+	}
+	if e.loads[ep] == 0 {
+		delete(e.loads, ep)
+	}
+	close(e.loadReleased)
+	e.loadReleased = make(chan struct{})
+}
+
+func (e *Endpoints) uploadsLimit(destType string) int {
+	limit, ok := e.maxConcurrentUploads[destType]
+	if !ok {
+		limit = e.conf.GetReloadableIntVar(0, 1, fmt.Sprintf("Warehouse.%s.endpointMaxConcurrentUploads", warehouseutils.WHDestNameMap[destType]))
+		e.maxConcurrentUploads[destType] = limit
+	}
+	if limit.Load() <= 0 {
+		return math.MaxInt
+	}
+	return limit.Load()
+}
+
+func (e *Endpoints) loadsLimit(destType string) int {
+	limit, ok := e.maxEndpointLoads[destType]
+	if !ok {
+		limit = e.conf.GetReloadableIntVar(0, 1, fmt.Sprintf("Warehouse.%s.endpointMaxParallelLoads", warehouseutils.WHDestNameMap[destType]))
+		e.maxEndpointLoads[destType] = limit
+	}
+	if limit.Load() > 0 {
+		return limit.Load()
+	}
+	if maxParallelLoads, ok := e.maxParallelLoads[destType]; ok {
+		return max(maxParallelLoads.Load(), 1)
+	}
+	return 1
+}
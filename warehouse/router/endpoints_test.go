@@ -0,0 +1,107 @@
+package router
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+func TestEndpoints(t *testing.T) {
+	warehouseWithEndpoint := func(destinationID, name string) model.Warehouse {
+		return model.Warehouse{
+			WorkspaceID: "workspace-1",
+			Type:        warehouseutils.RS,
+			Destination: backendconfig.DestinationT{
+				ID:     destinationID,
+				Config: map[string]any{model.WarehouseEndpointSetting.String(): name},
+			},
+		}
+	}
+
+	t.Run("endpoint of the warehouse", func(t *testing.T) {
+		c := config.New()
+		require.Equal(t, endpoint{}, endpointOf(c, warehouseWithEndpoint("destination-1", "")))
+		require.Equal(t,
+			endpointOf(c, warehouseWithEndpoint("destination-1", "cluster-1")),
+			endpointOf(c, warehouseWithEndpoint("destination-2", "cluster-1")),
+		)
+		require.NotEqual(t,
+			endpointOf(c, warehouseWithEndpoint("destination-1", "cluster-1")),
+			endpointOf(c, warehouseWithEndpoint("destination-2", "cluster-2")),
+		)
+
+		otherWorkspace := warehouseWithEndpoint("destination-2", "cluster-1")
+		otherWorkspace.WorkspaceID = "workspace-2"
+		require.NotEqual(t, endpointOf(c, warehouseWithEndpoint("destination-1", "cluster-1")), endpointOf(c, otherWorkspace))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		ctx := context.Background()
+		ep := endpointOf(config.New(), warehouseWithEndpoint("destination-1", "cluster-1"))
+
+		var nilEndpoints *Endpoints
+		require.True(t, nilEndpoints.TryAcquireUpload(ep))
+		nilEndpoints.ReleaseUpload(ep)
+		require.NoError(t, nilEndpoints.AcquireLoad(ctx, ep))
+		nilEndpoints.ReleaseLoad(ep)
+
+		e := NewEndpoints(config.New())
+		for range 10 {
+			require.True(t, e.TryAcquireUpload(ep), "uploads are unlimited by default")
+			require.True(t, e.TryAcquireUpload(endpoint{}))
+			require.NoError(t, e.AcquireLoad(ctx, endpoint{}), "warehouses without endpoint aren't limited")
+		}
+	})
+
+	t.Run("concurrent uploads", func(t *testing.T) {
+		c := config.New()
+		c.Set("Warehouse.redshift.endpointMaxConcurrentUploads", 2)
+		e := NewEndpoints(c)
+		ep := endpointOf(c, warehouseWithEndpoint("destination-1", "cluster-1"))
+		otherEp := endpointOf(c, warehouseWithEndpoint("destination-2", "cluster-2"))
+
+		require.True(t, e.TryAcquireUpload(ep))
+		require.True(t, e.TryAcquireUpload(ep))
+		require.False(t, e.TryAcquireUpload(ep))
+		require.True(t, e.TryAcquireUpload(otherEp))
+
+		e.ReleaseUpload(ep)
+		require.True(t, e.TryAcquireUpload(ep))
+
+		c.Set("Warehouse.redshift.endpointMaxConcurrentUploads", 3)
+		require.True(t, e.TryAcquireUpload(ep), "limit is reloadable")
+	})
+
+	t.Run("parallel loads", func(t *testing.T) {
+		ctx := context.Background()
+		c := config.New()
+		c.Set("Warehouse.redshift.maxParallelLoads", 2)
+		e := NewEndpoints(c)
+		ep := endpointOf(c, warehouseWithEndpoint("destination-1", "cluster-1"))
+
+		require.NoError(t, e.AcquireLoad(ctx, ep))
+		require.NoError(t, e.AcquireLoad(ctx, ep))
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, e.AcquireLoad(timeoutCtx, ep), context.DeadlineExceeded, "maxParallelLoads applies across the destinations of the endpoint")
+
+		acquired := make(chan error)
+		go func() {
+			acquired <- e.AcquireLoad(ctx, ep)
+		}()
+		e.ReleaseLoad(ep)
+		require.NoError(t, <-acquired)
+
+		c.Set("Warehouse.redshift.endpointMaxParallelLoads", 3)
+		require.NoError(t, e.AcquireLoad(ctx, ep), "endpointMaxParallelLoads overrides maxParallelLoads")
+	})
+}
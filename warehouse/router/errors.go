@@ -1,9 +1,16 @@
 package router
 
 import (
+	"errors"
+	"regexp"
+
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
 )
 
+// errStageTimedOut is the error of the states of the uploads cancelled for exceeding their timeout, see
+// Warehouse.stageTimeout
+var errStageTimedOut = errors.New("stage timed out")
+
 type errorMapper interface {
 	ErrorMappings() []model.JobError
 }
@@ -12,9 +19,35 @@ type ErrorHandler struct {
 	Mapper errorMapper
 }
 
+// missingPrivileges maps the permission errors of the warehouses to the privilege to grant, with the submatches of the
+// format expanded in the privilege
+var missingPrivileges = []struct {
+	format    *regexp.Regexp
+	privilege string
+}{
+	// postgres and redshift
+	{format: regexp.MustCompile(`permission denied for schema "?([^\s"]+)`), privilege: `GRANT USAGE, CREATE ON SCHEMA $1`},
+	{format: regexp.MustCompile(`permission denied for database "?([^\s"]+)`), privilege: `GRANT CREATE ON DATABASE $1`},
+	{format: regexp.MustCompile(`permission denied for (?:relation|table) "?([^\s"]+)`), privilege: `GRANT SELECT, INSERT, UPDATE, DELETE ON TABLE $1`},
+	{format: regexp.MustCompile(`must be owner of (?:relation|table) "?([^\s"]+)`), privilege: `ALTER TABLE $1 OWNER TO <user>`},
+	// snowflake
+	{format: regexp.MustCompile(`Insufficient privileges to operate on schema '([^']+)'`), privilege: `GRANT CREATE TABLE ON SCHEMA $1`},
+	{format: regexp.MustCompile(`Insufficient privileges to operate on table '([^']+)'`), privilege: `GRANT OWNERSHIP ON TABLE $1`},
+	{format: regexp.MustCompile(`Schema '([^']+)' already exists, but current role has no privileges on it`), privilege: `GRANT USAGE, CREATE TABLE ON SCHEMA $1`},
+	// bigquery
+	{format: regexp.MustCompile(`Permission (bigquery\.[\w.]+) denied`), privilege: `$1`},
+	// deltalake
+	{format: regexp.MustCompile(`User does not have (?:permission )?([A-Z_ ]+?) on (CATALOG|SCHEMA|TABLE|External Location)`), privilege: `GRANT $1 ON $2`},
+	// datalake
+	{format: regexp.MustCompile(`is not authorized to perform: (\S+)`), privilege: `$1`},
+}
+
 // MatchUploadJobErrorType matches the error with the error mappings defined in the integrations
 // and returns the corresponding matched error type else returns UncategorizedError
 func (e *ErrorHandler) MatchUploadJobErrorType(err error) model.JobErrorType {
+	if errors.Is(err, errStageTimedOut) {
+		return model.StageTimedOutError
+	}
 	if e.Mapper == nil || err == nil {
 		return model.UncategorizedError
 	}
@@ -29,3 +62,19 @@ func (e *ErrorHandler) MatchUploadJobErrorType(err error) model.JobErrorType {
 
 	return model.UncategorizedError
 }
+
+// missingPrivilege returns the privilege missing for the permission error, or an empty string if it is not known
+func missingPrivilege(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	errString := err.Error()
+
+	for _, mp := range missingPrivileges {
+		if match := mp.format.FindStringSubmatchIndex(errString); match != nil {
+			return string(mp.format.ExpandString(nil, mp.privilege, errString, match))
+		}
+	}
+	return ""
+}
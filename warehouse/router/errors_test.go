@@ -123,6 +123,9 @@ func TestErrorHandler_MatchUploadJobErrorType(t *testing.T) {
 			{
 				"RedShift permission denied for database", warehouseutils.RS, errors.New("{\"creating_remote_schema_failed\":{\"attempt\":5,\"errors\":[\"pq: permission denied for database ***\"]}}"), model.PermissionError,
 			},
+			{
+				"RedShift permission denied for schema", warehouseutils.RS, errors.New("{\"exporting_data_failed\":{\"attempt\":1,\"errors\":[\"pq: permission denied for schema ***\"]}}"), model.PermissionError,
+			},
 			{
 				"RedShift must be owner of relation", warehouseutils.RS, errors.New("{\"exporting_data_failed\":{\"attempt\":5,\"errors\":[\"pq: must be owner of relation ***\"]}}"), model.PermissionError,
 			},
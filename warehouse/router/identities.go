@@ -174,8 +174,8 @@ func (r *Router) hasWarehouseData(ctx context.Context, warehouse model.Warehouse
 
 func (r *Router) setupIdentityTables(ctx context.Context, warehouse model.Warehouse) {
 	var name sql.NullString
-	sqlStatement := fmt.Sprintf(`SELECT to_regclass('%s')`, warehouseutils.IdentityMappingsTableName(warehouse))
-	err := r.db.QueryRow(sqlStatement).Scan(&name)
+	sqlStatement := `SELECT to_regclass($1)`
+	err := r.db.QueryRow(sqlStatement, warehouseutils.IdentityMappingsTableName(warehouse)).Scan(&name)
 	if err != nil {
 		panic(fmt.Errorf("Query: %s\nfailed with Error : %w", sqlStatement, err))
 	}
@@ -424,7 +424,7 @@ func (r *Router) populateHistoricIdentities(ctx context.Context, warehouse model
 			return
 		}
 		if !tableUploadsCreated {
-			err := job.createTableUploads()
+			err := job.createTableUploads(ctx)
 			if err != nil {
 				// TODO: Handle error / Retry
 				r.logger.Error("[WH]: Error creating records in wh_table_uploads", err)
@@ -449,7 +449,7 @@ func (r *Router) populateHistoricIdentities(ctx context.Context, warehouse model
 		}
 
 		_ = job.setUploadStatus(UploadStatusOpts{Status: inProgressState(model.ExportedData)})
-		loadErrors, err := job.loadIdentityTables(true)
+		loadErrors, err := job.loadIdentityTables(ctx, true)
 		if err != nil {
 			r.logger.Errorf(`[WH]: Identity table upload errors: %v`, err)
 		}
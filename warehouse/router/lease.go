@@ -0,0 +1,120 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-server/rruntime"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
+	"github.com/rudderlabs/rudder-server/warehouse/logfield"
+)
+
+// errUploadLeaseHeld is returned when the lease of the upload is held by another installation
+var errUploadLeaseHeld = errors.New("upload lease held by another installation")
+
+// acquireLease takes the lease of the upload in the warehouse, if enabled and supported by the warehouse, so that
+// uploads of other installations pointed at the same namespace, e.g. during blue/green deployments, don't run at the
+// same time. The lease is renewed in the background until released by the returned function.
+//
+// The returned context, derived from ctx, is cancelled once the lease is lost, i.e. once another installation took it
+// over or once it expired without being renewed, so that the upload stops and gets postponed, see leaseLost. Failing to
+// renew the lease, e.g. due to a transient error of the warehouse, is retried until the lease expires.
+//
+// Installations are told apart by Warehouse.uploadLease.owner, the hostname by default. Leases expire after
+// Warehouse.uploadLease.ttl if not renewed, e.g. if the installation holding them crashed.
+func (job *UploadJob) acquireLease(ctx context.Context, whManager manager.Manager) (context.Context, func(), error) {
+	leaser, ok := whManager.(manager.Leaser)
+	if !ok || !job.config.uploadLease.enabled {
+		return ctx, func() {}, nil
+	}
+
+	key, owner, ttl := job.leaseKey(), job.config.uploadLease.owner, job.config.uploadLease.ttl
+	acquired, err := leaser.AcquireLease(ctx, key, owner, ttl)
+	if err != nil {
+		return nil, nil, fmt.Errorf("acquiring upload lease: %w", err)
+	}
+	if !acquired {
+		return nil, nil, errUploadLeaseHeld
+	}
+
+	leaseCtx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	rruntime.GoForWarehouse(func() {
+		defer close(done)
+
+		renewInterval := ttl / 3
+		ticker := time.NewTicker(renewInterval)
+		defer ticker.Stop()
+		renewedAt := job.now()
+		for {
+			select {
+			case <-leaseCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			renewCtx, cancelRenew := context.WithTimeout(leaseCtx, renewInterval)
+			acquired, err := leaser.AcquireLease(renewCtx, key, owner, ttl)
+			cancelRenew()
+			switch {
+			case leaseCtx.Err() != nil:
+				return
+			case err != nil:
+				if job.now().Sub(renewedAt) < ttl {
+					job.logger.Warnw("renewing upload lease", logfield.Error, err.Error())
+					continue
+				}
+				job.logger.Warnw("upload lease lost", logfield.Error, err.Error())
+				cancel(fmt.Errorf("%w: upload lease expired: %v", errUploadLeaseHeld, err))
+				return
+			case !acquired:
+				job.logger.Warnw("upload lease lost", logfield.Error, errUploadLeaseHeld.Error())
+				cancel(errUploadLeaseHeld)
+				return
+			}
+			renewedAt = job.now()
+		}
+	})
+	var once sync.Once
+	return leaseCtx, func() {
+		once.Do(func() {
+			cancel(nil)
+			<-done
+			if err := leaser.ReleaseLease(context.WithoutCancel(ctx), key, owner); err != nil {
+				job.logger.Warnw("releasing upload lease", logfield.Error, err.Error())
+			}
+		})
+	}, nil
+}
+
+// leaseLost returns whether the lease context got cancelled because its lease got lost
+func leaseLost(leaseCtx context.Context) bool {
+	return errors.Is(context.Cause(leaseCtx), errUploadLeaseHeld)
+}
+
+// postponeForLostLease releases the lost lease of the upload and postpones the upload, see postponeForLease
+func (job *UploadJob) postponeForLostLease(leaseCtx context.Context, releaseLease func()) error {
+	cause := context.Cause(leaseCtx)
+	releaseLease()
+	return job.postponeForLease(cause)
+}
+
+// leaseKey identifies the uploads which can't run at the same time, as the uploads picked up by the router
+func (job *UploadJob) leaseKey() string {
+	if job.config.allowMultipleSourcesForJobsPickup {
+		return job.warehouse.Source.ID + "_" + job.warehouse.Destination.ID + "_" + job.warehouse.Namespace
+	}
+	return job.warehouse.Destination.ID + "_" + job.warehouse.Namespace
+}
+
+// postponeForLease postpones the upload by Warehouse.uploadLease.retryInterval, without counting an attempt, since
+// the lease is held by another installation
+func (job *UploadJob) postponeForLease(err error) error {
+	job.counterStat("upload_lease_held").Count(1)
+	if err := job.setNextRetryTime(job.now().Add(job.config.uploadLease.retryInterval)); err != nil {
+		return fmt.Errorf("postponing upload: %w", err)
+	}
+	return fmt.Errorf("upload job postponed: %w", err)
+}
@@ -0,0 +1,105 @@
+package router
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+// WorkerPools isolates the uploads of the destination types from each other. Uploads of all destination types share
+// Warehouse.maxUploadWorkers workers, out of which Warehouse.<destType>.reservedUploadWorkers are reserved for the
+// uploads of a destination type, so that a burst of uploads of one destination type can't hold back the others.
+// Uploads of a destination type first take the workers reserved for it and then the shared ones, if any are left.
+//
+// Pools are disabled if Warehouse.maxUploadWorkers is not set, in which case uploads are only limited by the
+// Warehouse.<destType>.noOfWorkers of their destination type. A nil WorkerPools is disabled as well.
+type WorkerPools struct {
+	conf       *config.Config
+	maxWorkers config.ValueLoader[int]
+
+	mu       sync.Mutex
+	reserved map[string]config.ValueLoader[int] // workers reserved by destination type
+	active   map[string]int                     // running uploads by destination type
+}
+
+func NewWorkerPools(conf *config.Config) *WorkerPools {
+	return &WorkerPools{
+		conf:       conf,
+		maxWorkers: conf.GetReloadableIntVar(0, 1, "Warehouse.maxUploadWorkers"),
+		reserved:   make(map[string]config.ValueLoader[int]),
+		active:     make(map[string]int),
+	}
+}
+
+// Available returns the number of workers available to the uploads of the destination type
+func (p *WorkerPools) Available(destType string) int {
+	if p == nil {
+		return math.MaxInt
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.available(destType)
+}
+
+// TryAcquire takes a worker for an upload of the destination type, returning false if none is available
+func (p *WorkerPools) TryAcquire(destType string) bool {
+	if p == nil {
+		return true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.available(destType) < 1 {
+		return false
+	}
+	p.active[destType]++
+	return true
+}
+
+// Release returns the worker taken by an upload of the destination type through [WorkerPools.TryAcquire]
+func (p *WorkerPools) Release(destType string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.active[destType] > 0 {
+		p.active[destType]--
+	}
+}
+
+func (p *WorkerPools) available(destType string) int {
+	maxWorkers := p.maxWorkers.Load()
+	if maxWorkers <= 0 {
+		return math.MaxInt
+	}
+	shared := maxWorkers
+	for _, t := range sharingDestTypes(destType) {
+		reserved := p.reservedWorkers(t)
+		shared -= reserved
+		shared -= max(p.active[t]-reserved, 0) // shared workers taken by uploads beyond the reserved ones
+	}
+	return max(p.reservedWorkers(destType)-p.active[destType], 0) + max(shared, 0)
+}
+
+// sharingDestTypes returns the destination types sharing the workers, i.e. the warehouses along with the destination type
+func sharingDestTypes(destType string) []string {
+	destTypes := warehouseutils.WarehouseDestinations
+	if slices.Contains(destTypes, destType) {
+		return destTypes
+	}
+	return append(slices.Clip(destTypes), destType)
+}
+
+func (p *WorkerPools) reservedWorkers(destType string) int {
+	reserved, ok := p.reserved[destType]
+	if !ok {
+		reserved = p.conf.GetReloadableIntVar(0, 1, fmt.Sprintf("Warehouse.%s.reservedUploadWorkers", warehouseutils.WHDestNameMap[destType]))
+		p.reserved[destType] = reserved
+	}
+	return reserved.Load()
+}
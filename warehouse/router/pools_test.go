@@ -0,0 +1,65 @@
+package router
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+func TestWorkerPools(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		p := NewWorkerPools(config.New())
+		require.Equal(t, math.MaxInt, p.Available(warehouseutils.POSTGRES))
+		require.True(t, p.TryAcquire(warehouseutils.POSTGRES))
+		p.Release(warehouseutils.POSTGRES)
+
+		var nilPools *WorkerPools
+		require.Equal(t, math.MaxInt, nilPools.Available(warehouseutils.POSTGRES))
+		require.True(t, nilPools.TryAcquire(warehouseutils.POSTGRES))
+		nilPools.Release(warehouseutils.POSTGRES)
+	})
+
+	t.Run("reserved workers", func(t *testing.T) {
+		c := config.New()
+		c.Set("Warehouse.maxUploadWorkers", 4)
+		c.Set("Warehouse.snowflake.reservedUploadWorkers", 1)
+		p := NewWorkerPools(c)
+
+		require.Equal(t, 3, p.Available(warehouseutils.POSTGRES))
+		require.Equal(t, 4, p.Available(warehouseutils.SNOWFLAKE))
+
+		for range 3 {
+			require.True(t, p.TryAcquire(warehouseutils.POSTGRES))
+		}
+		require.False(t, p.TryAcquire(warehouseutils.POSTGRES), "a burst of postgres uploads can't take the workers reserved for snowflake")
+		require.Equal(t, 1, p.Available(warehouseutils.SNOWFLAKE))
+		require.True(t, p.TryAcquire(warehouseutils.SNOWFLAKE))
+		require.False(t, p.TryAcquire(warehouseutils.SNOWFLAKE))
+
+		p.Release(warehouseutils.POSTGRES)
+		require.Equal(t, 1, p.Available(warehouseutils.SNOWFLAKE), "shared workers are available to all destination types")
+		require.True(t, p.TryAcquire(warehouseutils.SNOWFLAKE))
+		require.Zero(t, p.Available(warehouseutils.POSTGRES))
+
+		p.Release(warehouseutils.SNOWFLAKE)
+		p.Release(warehouseutils.SNOWFLAKE)
+		require.Equal(t, 1, p.Available(warehouseutils.POSTGRES))
+		require.Equal(t, 2, p.Available(warehouseutils.SNOWFLAKE))
+	})
+
+	t.Run("reloadable", func(t *testing.T) {
+		c := config.New()
+		c.Set("Warehouse.maxUploadWorkers", 1)
+		p := NewWorkerPools(c)
+		require.True(t, p.TryAcquire(warehouseutils.POSTGRES))
+		require.False(t, p.TryAcquire(warehouseutils.POSTGRES))
+
+		c.Set("Warehouse.maxUploadWorkers", 2)
+		require.True(t, p.TryAcquire(warehouseutils.POSTGRES))
+	})
+}
@@ -0,0 +1,172 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/samber/lo"
+
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	"github.com/rudderlabs/rudder-server/warehouse/logfield"
+	"github.com/rudderlabs/rudder-server/warehouse/source"
+)
+
+var errRenameNotSupported = errors.New("renames are not supported by the warehouse")
+
+// processRenames executes the pending renames of the namespaces and tables of the owned destinations, once no upload
+// of their namespace is in progress. It returns the identifiers of the namespaces whose uploads are held back until
+// their renames are done.
+//
+// Renames are executed by the allocator, before picking up the uploads, so that no upload starts meanwhile.
+func (r *Router) processRenames(ctx context.Context) ([]string, error) {
+	if !r.config.renamesEnabled {
+		return nil, nil
+	}
+	renames, err := r.renameRepo.GetPending(ctx, r.destType)
+	if err != nil {
+		return nil, fmt.Errorf("getting pending renames: %w", err)
+	}
+
+	var skipIdentifiers []string
+	for _, rename := range renames {
+		if !r.sharding.Owns(rename.DestinationID) {
+			continue
+		}
+		identifiers := r.renameIdentifiers(rename)
+		skipIdentifiers = append(skipIdentifiers, identifiers...)
+
+		if slices.ContainsFunc(identifiers, r.namespaceInProgress) {
+			continue
+		}
+		if rename.Status == model.RenameStatusFailed && r.now().Before(rename.UpdatedAt.Add(r.config.renameRetryInterval)) {
+			continue
+		}
+		r.executeRename(ctx, rename)
+	}
+	return lo.Uniq(skipIdentifiers), nil
+}
+
+// renameIdentifiers returns the identifiers of the namespaces affected by the rename, i.e. the namespace of all the
+// sources of the destination, along with the new namespace when renaming the namespace
+func (r *Router) renameIdentifiers(rename model.Rename) []string {
+	namespaces := []string{rename.Namespace}
+	if !rename.IsTableRename() {
+		namespaces = append(namespaces, rename.NewName)
+	}
+	sourceIDs := []string{rename.SourceID}
+
+	r.configSubscriberLock.RLock()
+	for _, warehouse := range r.warehouses {
+		if warehouse.Destination.ID == rename.DestinationID {
+			sourceIDs = append(sourceIDs, warehouse.Source.ID)
+		}
+	}
+	r.configSubscriberLock.RUnlock()
+
+	var identifiers []string
+	for _, sourceID := range lo.Uniq(sourceIDs) {
+		for _, namespace := range namespaces {
+			var warehouse model.Warehouse
+			warehouse.Source.ID = sourceID
+			warehouse.Destination.ID = rename.DestinationID
+			warehouse.Namespace = namespace
+			identifiers = append(identifiers, r.workerIdentifier(warehouse))
+		}
+	}
+	return lo.Uniq(identifiers)
+}
+
+func (r *Router) namespaceInProgress(identifier string) bool {
+	r.inProgressMapLock.RLock()
+	defer r.inProgressMapLock.RUnlock()
+
+	return len(r.inProgressMap[identifier]) > 0
+}
+
+// executeRename renames the namespace or table in the warehouse, then moves the metadata of the destination and its
+// connections to the new name, recording the failures for the rename to be retried
+func (r *Router) executeRename(ctx context.Context, rename model.Rename) {
+	log := r.logger.With(
+		logfield.WorkspaceID, rename.WorkspaceID,
+		logfield.SourceID, rename.SourceID,
+		logfield.DestinationID, rename.DestinationID,
+		logfield.DestinationType, rename.DestinationType,
+		logfield.Namespace, rename.Namespace,
+		logfield.TableName, rename.TableName,
+		"newName", rename.NewName,
+	)
+	status := model.RenameStatusSucceeded
+
+	err := r.rename(ctx, rename)
+	if err == nil {
+		err = r.renameRepo.OnSuccess(ctx, rename)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		maxAttempts := r.config.renameMaxAttempts
+		if errors.Is(err, errRenameNotSupported) {
+			maxAttempts = 0
+		}
+		log.Warnw("renaming in warehouse", logfield.Error, err.Error())
+		if err := r.renameRepo.OnFailure(ctx, rename.ID, err, maxAttempts); err != nil {
+			log.Warnw("recording rename failure", logfield.Error, err.Error())
+		}
+		status = model.RenameStatusFailed
+	} else {
+		log.Infow("renamed in warehouse")
+		if !rename.IsTableRename() {
+			r.bcManager.RenameNamespace(rename.DestinationID, rename.Namespace, rename.NewName)
+		}
+	}
+
+	r.statsFactory.NewTaggedStat("wh_renames", stats.CountType, stats.Tags{
+		"destType":      r.destType,
+		"workspaceId":   rename.WorkspaceID,
+		"destinationId": rename.DestinationID,
+		"status":        string(status),
+	}).Count(1)
+}
+
+// rename renames the namespace or table in the warehouse, using the destination config of any of its connections
+func (r *Router) rename(ctx context.Context, rename model.Rename) error {
+	r.configSubscriberLock.RLock()
+	warehouse, found := lo.Find(r.warehouses, func(w model.Warehouse) bool {
+		return w.Destination.ID == rename.DestinationID
+	})
+	r.configSubscriberLock.RUnlock()
+	if !found {
+		return fmt.Errorf("destination %s not found", rename.DestinationID)
+	}
+	warehouse.Namespace = rename.Namespace
+
+	whManager, err := r.newManager(r.destType)
+	if err != nil {
+		return fmt.Errorf("creating manager: %w", err)
+	}
+	renamer, ok := whManager.(manager.Renamer)
+	if !ok {
+		return errRenameNotSupported
+	}
+	if err := whManager.Setup(ctx, warehouse, &source.Uploader{}); err != nil {
+		return fmt.Errorf("setting up manager: %w", err)
+	}
+	defer whManager.Cleanup(ctx)
+
+	if rename.IsTableRename() {
+		if err := renamer.RenameTable(ctx, rename.TableName, rename.NewName); err != nil {
+			return fmt.Errorf("renaming table: %w", err)
+		}
+		return nil
+	}
+	if err := renamer.RenameNamespace(ctx, rename.NewName); err != nil {
+		return fmt.Errorf("renaming namespace: %w", err)
+	}
+	return nil
+}
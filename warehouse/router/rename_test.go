@@ -0,0 +1,152 @@
+package router
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	migrator "github.com/rudderlabs/rudder-server/services/sql-migrator"
+	"github.com/rudderlabs/rudder-server/warehouse/bcm"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
+	sqlmiddleware "github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/repo"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+type fakeRenamer struct {
+	manager.Manager
+
+	namespace string
+	renamed   []string
+}
+
+func (f *fakeRenamer) Setup(_ context.Context, warehouse model.Warehouse, _ warehouseutils.Uploader) error {
+	f.namespace = warehouse.Namespace
+	return nil
+}
+
+func (*fakeRenamer) Cleanup(context.Context) {}
+
+func (f *fakeRenamer) RenameNamespace(_ context.Context, newNamespace string) error {
+	f.renamed = append(f.renamed, f.namespace+" -> "+newNamespace)
+	return nil
+}
+
+func (f *fakeRenamer) RenameTable(_ context.Context, tableName, newTableName string) error {
+	f.renamed = append(f.renamed, f.namespace+"."+tableName+" -> "+newTableName)
+	return nil
+}
+
+func TestRouter_ProcessRenames(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pgResource, err := postgres.Setup(pool, t)
+	require.NoError(t, err)
+
+	err = (&migrator.Migrator{
+		Handle:          pgResource.DB,
+		MigrationsTable: "wh_schema_migrations",
+	}).Migrate("warehouse")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	db := sqlmiddleware.New(pgResource.DB)
+	renameRepo := repo.NewRenames(db)
+
+	warehouse := model.Warehouse{
+		WorkspaceID: "workspace-1",
+		Source:      backendconfig.SourceT{ID: "source-1"},
+		Destination: backendconfig.DestinationT{ID: "destination-1"},
+		Namespace:   "namespace",
+		Type:        warehouseutils.POSTGRES,
+	}
+
+	renamer := &fakeRenamer{}
+	r := Router{}
+	r.destType = warehouseutils.POSTGRES
+	r.logger = logger.NOP
+	r.statsFactory = stats.NOP
+	r.now = time.Now
+	r.renameRepo = renameRepo
+	r.bcManager = bcm.New(config.New(), db, nil, logger.NOP, stats.NOP)
+	r.warehouses = []model.Warehouse{warehouse}
+	r.inProgressMap = make(map[workerIdentifierMapKey][]jobID)
+	r.config.renamesEnabled = true
+	r.config.renameMaxAttempts = 3
+	r.newManager = func(string) (manager.Manager, error) { return renamer, nil }
+
+	insertRename := func(t *testing.T, tableName, newName string) int64 {
+		t.Helper()
+		id, err := renameRepo.Insert(ctx, &model.Rename{
+			WorkspaceID:     warehouse.WorkspaceID,
+			SourceID:        warehouse.Source.ID,
+			DestinationID:   warehouse.Destination.ID,
+			DestinationType: warehouseutils.POSTGRES,
+			Namespace:       warehouse.Namespace,
+			TableName:       tableName,
+			NewName:         newName,
+		})
+		require.NoError(t, err)
+		return id
+	}
+	requireStatus := func(t *testing.T, id int64, status model.RenameStatus) {
+		t.Helper()
+		rename, err := renameRepo.GetByID(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, status, rename.Status)
+	}
+
+	t.Run("upload in progress", func(t *testing.T) {
+		id := insertRename(t, "tracks", "legacy_tracks")
+		r.setDestInProgress(warehouse, 1)
+
+		skipIdentifiers, err := r.processRenames(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"destination-1_namespace"}, skipIdentifiers)
+		require.Empty(t, renamer.renamed)
+		requireStatus(t, id, model.RenameStatusWaiting)
+
+		r.removeDestInProgress(warehouse, 1)
+
+		skipIdentifiers, err = r.processRenames(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"destination-1_namespace"}, skipIdentifiers)
+		require.Equal(t, []string{"namespace.tracks -> legacy_tracks"}, renamer.renamed)
+		requireStatus(t, id, model.RenameStatusSucceeded)
+
+		skipIdentifiers, err = r.processRenames(ctx)
+		require.NoError(t, err)
+		require.Empty(t, skipIdentifiers)
+	})
+
+	t.Run("namespace rename", func(t *testing.T) {
+		renamer.renamed = nil
+		id := insertRename(t, "", "new_namespace")
+
+		skipIdentifiers, err := r.processRenames(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"destination-1_namespace", "destination-1_new_namespace"}, skipIdentifiers)
+		require.Equal(t, []string{"namespace -> new_namespace"}, renamer.renamed)
+		requireStatus(t, id, model.RenameStatusSucceeded)
+	})
+
+	t.Run("warehouse not supporting renames", func(t *testing.T) {
+		r.newManager = func(string) (manager.Manager, error) { return struct{ manager.Manager }{}, nil }
+		id := insertRename(t, "pages", "legacy_pages")
+
+		_, err := r.processRenames(ctx)
+		require.NoError(t, err)
+		requireStatus(t, id, model.RenameStatusAborted)
+	})
+}
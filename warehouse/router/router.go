@@ -20,6 +20,7 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 
+	"github.com/rudderlabs/rudder-server/internal/sharding"
 	"github.com/rudderlabs/rudder-server/rruntime"
 	"github.com/rudderlabs/rudder-server/services/controlplane"
 	"github.com/rudderlabs/rudder-server/services/notifier"
@@ -30,10 +31,13 @@ import (
 	"github.com/rudderlabs/rudder-server/warehouse/encoding"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/lineage"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/loadfiles"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/repo"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/service"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/synctrigger"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/uploadwebhook"
 	"github.com/rudderlabs/rudder-server/warehouse/logfield"
 	"github.com/rudderlabs/rudder-server/warehouse/multitenant"
 	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
@@ -87,7 +91,14 @@ type Router struct {
 	tenantManager    *multitenant.Manager
 	bcManager        *bcm.BackendConfigManager
 	uploadJobFactory UploadJobFactory
+	renameRepo       *repo.Renames
+	newManager       func(destType string) (manager.Manager, error)
 	notifier         *notifier.Notifier
+	sharding         *sharding.Manager // nil if the master isn't sharded across replicas
+	workerPools      *WorkerPools      // workers shared with the routers of the other destination types
+	endpoints        *Endpoints        // physical warehouses shared by destinations, across destination types
+
+	resetShards map[int]struct{} // owned shards whose uploads in progress have been reset
 
 	config struct {
 		maxConcurrentUploadJobs           int
@@ -104,6 +115,9 @@ type Router struct {
 		stagingFilesBatchSize             config.ValueLoader[int]
 		warehouseSyncFreqIgnore           config.ValueLoader[bool]
 		cronTrackerRetries                config.ValueLoader[int64]
+		renamesEnabled                    bool
+		renameMaxAttempts                 int
+		renameRetryInterval               time.Duration
 	}
 
 	stats struct {
@@ -131,6 +145,9 @@ func New(
 	encodingFactory *encoding.Factory,
 	triggerStore *sync.Map,
 	createUploadAlways createUploadAlwaysLoader,
+	shardingManager *sharding.Manager,
+	workerPools *WorkerPools,
+	endpoints *Endpoints,
 ) *Router {
 	r := &Router{}
 
@@ -144,8 +161,13 @@ func New(
 	r.stagingRepo = repo.NewStagingFiles(db)
 	r.uploadRepo = repo.NewUploads(db)
 	r.whSchemaRepo = repo.NewWHSchemas(db)
+	r.renameRepo = repo.NewRenames(db)
 
 	r.notifier = notifier
+	r.sharding = shardingManager
+	r.workerPools = workerPools
+	r.endpoints = endpoints
+	r.resetShards = make(map[int]struct{})
 	r.tenantManager = tenantManager
 	r.bcManager = bcManager
 	r.destType = destType
@@ -155,6 +177,9 @@ func New(
 	r.createUploadAlways = createUploadAlways
 	r.scheduledTimesCache = make(map[string][]int)
 	r.inProgressMap = make(map[workerIdentifierMapKey][]jobID)
+	r.newManager = func(destType string) (manager.Manager, error) {
+		return manager.New(destType, r.conf, r.logger, r.statsFactory)
+	}
 
 	r.uploadJobFactory = UploadJobFactory{
 		reporting:            reporting,
@@ -169,10 +194,17 @@ func New(
 			Notifier:           r.notifier,
 			StageRepo:          r.stagingRepo,
 			LoadRepo:           repo.NewLoadFiles(db),
+			CheckpointRepo:     repo.NewLoadFileCheckpoints(db),
+			TwinRepo:           r.stagingRepo,
+			UploadRepo:         r.uploadRepo,
 			ControlPlaneClient: controlPlaneClient,
 		},
 		recovery:        service.NewRecovery(destType, r.uploadRepo),
 		encodingFactory: encodingFactory,
+		syncTrigger:     synctrigger.New(r.conf, r.logger, r.statsFactory),
+		lineage:         lineage.New(r.conf, r.logger, r.statsFactory),
+		uploadWebhook:   uploadwebhook.New(r.conf, r.logger, r.statsFactory),
+		endpoints:       endpoints,
 	}
 	loadfiles.WithConfig(r.uploadJobFactory.loadFile, r.conf)
 
@@ -182,8 +214,11 @@ func New(
 }
 
 func (r *Router) Start(ctx context.Context) error {
-	if err := r.uploadRepo.ResetInProgress(ctx, r.destType); err != nil {
-		return err
+	// with sharding, the uploads in progress are reset as their shards are acquired by the allocator
+	if r.sharding == nil {
+		if err := r.uploadRepo.ResetInProgress(ctx, r.destType); err != nil {
+			return err
+		}
 	}
 
 	g, gCtx := errgroup.WithContext(ctx)
@@ -215,7 +250,7 @@ func (r *Router) backendConfigSubscriber(ctx context.Context) {
 		})
 
 		for _, warehouse := range warehouses {
-			if warehouseutils.IDResolutionEnabled() && slices.Contains(warehouseutils.IdentityEnabledWarehouses, r.destType) {
+			if warehouseutils.IDResolutionEnabled() && manager.CapabilitiesOf(r.conf, r.destType, nil).IdentityResolution {
 				r.setupIdentityTables(ctx, warehouse)
 				if r.config.shouldPopulateHistoricIdentities && warehouse.Destination.Enabled {
 					// non-blocking populate historic identities
@@ -236,6 +271,8 @@ func (r *Router) backendConfigSubscriber(ctx context.Context) {
 		}
 		r.configSubscriberLock.Unlock()
 
+		r.resumePermissionDeniedUploads(ctx, warehouses)
+
 		r.workerChannelMapLock.Lock()
 		if r.workerChannelMap == nil {
 			r.workerChannelMap = make(map[string]chan *UploadJob)
@@ -254,6 +291,23 @@ func (r *Router) backendConfigSubscriber(ctx context.Context) {
 	}
 }
 
+// resumePermissionDeniedUploads resumes the uploads stopped on a permission error of the destinations whose config
+// has changed, since the privileges or the credentials might have been fixed
+func (r *Router) resumePermissionDeniedUploads(ctx context.Context, warehouses []model.Warehouse) {
+	revisions := lo.SliceToMap(warehouses, func(warehouse model.Warehouse) (string, string) {
+		return warehouse.Destination.ID, warehouse.Destination.RevisionID
+	})
+
+	resumed, err := r.uploadRepo.ResumePermissionDenied(ctx, revisions)
+	if err != nil {
+		r.logger.Warnw("resuming permission denied uploads", logfield.DestinationType, r.destType, logfield.Error, err.Error())
+		return
+	}
+	if resumed > 0 {
+		r.logger.Infow("resumed permission denied uploads", logfield.DestinationType, r.destType, "count", resumed)
+	}
+}
+
 // workerIdentifier get name of the worker (`destID_namespace`) to be stored in map wh.workerChannelMap
 func (r *Router) workerIdentifier(warehouse model.Warehouse) (identifier string) {
 	if r.config.allowMultipleSourcesForJobsPickup {
@@ -271,11 +325,18 @@ func (r *Router) initWorker() chan *UploadJob {
 				r.incrementActiveWorkers()
 
 				err := uploadJob.run()
-				if err != nil {
+				if err != nil && uploadJob.stopping() {
+					r.logger.Infof("[WH] Upload job stopped for shutting down: %v", err)
+				} else if errors.Is(err, errUploadLeaseHeld) {
+					r.logger.Infof("[WH] Upload job postponed: %v", err)
+				} else if err != nil {
 					r.logger.Errorf("[WH] Failed in handle Upload jobs for worker: %+v", err)
 				}
 
 				r.removeDestInProgress(uploadJob.warehouse, uploadJob.upload.ID)
+				r.sharding.End(uploadJob.warehouse.Destination.ID)
+				r.endpoints.ReleaseUpload(uploadJob.endpoint)
+				r.workerPools.Release(r.destType)
 
 				r.decrementActiveWorkers()
 			}
@@ -363,7 +424,7 @@ loop:
 			r.logger.Debugf("Initial config fetched in runUploadJobAllocator for %s", r.destType)
 		}
 
-		availableWorkers := r.config.noOfWorkers.Load() - r.getActiveWorkerCount()
+		availableWorkers := min(r.config.noOfWorkers.Load()-r.getActiveWorkerCount(), r.workerPools.Available(r.destType))
 		if availableWorkers < 1 {
 			select {
 			case <-ctx.Done():
@@ -376,7 +437,17 @@ loop:
 		inProgressNamespaces := r.getInProgressNamespaces()
 		r.logger.Debugf(`Current inProgress namespace identifiers for %s: %v`, r.destType, inProgressNamespaces)
 
-		uploadJobsToProcess, err := r.uploadsToProcess(ctx, availableWorkers, inProgressNamespaces)
+		err := r.resetInProgressOfAcquiredShards(ctx)
+		if err == nil {
+			// uploads of the namespaces being renamed are held back until the renames are done
+			var renamingNamespaces []string
+			renamingNamespaces, err = r.processRenames(ctx)
+			if err == nil {
+				var uploadJobsToProcess []*UploadJob
+				uploadJobsToProcess, err = r.uploadsToProcess(ctx, availableWorkers, append(inProgressNamespaces, renamingNamespaces...))
+				r.dispatch(uploadJobsToProcess)
+			}
+		}
 		if err != nil {
 			var pqErr *pq.Error
 
@@ -391,16 +462,6 @@ loop:
 			}
 		}
 
-		for _, uploadJob := range uploadJobsToProcess {
-			r.setDestInProgress(uploadJob.warehouse, uploadJob.upload.ID)
-
-			workerName := r.workerIdentifier(uploadJob.warehouse)
-
-			r.workerChannelMapLock.RLock()
-			r.workerChannelMap[workerName] <- uploadJob
-			r.workerChannelMapLock.RUnlock()
-		}
-
 		select {
 		case <-ctx.Done():
 			break loop
@@ -410,10 +471,98 @@ loop:
 	return nil
 }
 
+func (r *Router) dispatch(uploadJobs []*UploadJob) {
+	for _, uploadJob := range uploadJobs {
+		// the workers shared with the other destination types might have been taken since the uploads were picked up,
+		// in which case the upload is picked up again once a worker is available
+		if !r.workerPools.TryAcquire(r.destType) {
+			continue
+		}
+		// so are the uploads of the other destinations loading into the same physical warehouse
+		if !r.endpoints.TryAcquireUpload(uploadJob.endpoint) {
+			r.workerPools.Release(r.destType)
+			continue
+		}
+		// the shard can't be released once the upload has begun, but it might have started being released before
+		r.sharding.Begin(uploadJob.warehouse.Destination.ID)
+		if !r.sharding.Owns(uploadJob.warehouse.Destination.ID) {
+			r.sharding.End(uploadJob.warehouse.Destination.ID)
+			r.endpoints.ReleaseUpload(uploadJob.endpoint)
+			r.workerPools.Release(r.destType)
+			continue
+		}
+
+		r.setDestInProgress(uploadJob.warehouse, uploadJob.upload.ID)
+
+		workerName := r.workerIdentifier(uploadJob.warehouse)
+
+		r.workerChannelMapLock.RLock()
+		r.workerChannelMap[workerName] <- uploadJob
+		r.workerChannelMapLock.RUnlock()
+	}
+}
+
+// resetInProgressOfAcquiredShards resets the uploads in progress of the destinations of the shards acquired since
+// the last call, which were left in progress by the replica owning them before
+func (r *Router) resetInProgressOfAcquiredShards(ctx context.Context) error {
+	if r.sharding == nil {
+		return nil
+	}
+	ownedShards := r.sharding.OwnedShards()
+	for shard := range r.resetShards {
+		if !slices.Contains(ownedShards, shard) {
+			delete(r.resetShards, shard)
+		}
+	}
+	acquiredShards := lo.Filter(ownedShards, func(shard, _ int) bool {
+		_, ok := r.resetShards[shard]
+		return !ok
+	})
+	if len(acquiredShards) == 0 {
+		return nil
+	}
+
+	destinationIDs, err := r.uploadRepo.InProgressDestinations(ctx, r.destType)
+	if err != nil {
+		return fmt.Errorf("in progress destinations: %w", err)
+	}
+	destinationIDs = lo.Filter(destinationIDs, func(destinationID string, _ int) bool {
+		return slices.Contains(acquiredShards, r.sharding.Shard(destinationID))
+	})
+	if len(destinationIDs) > 0 {
+		if err := r.uploadRepo.ResetInProgressForDestinations(ctx, r.destType, destinationIDs); err != nil {
+			return fmt.Errorf("reset in progress: %w", err)
+		}
+	}
+	for _, shard := range acquiredShards {
+		r.resetShards[shard] = struct{}{}
+	}
+	return nil
+}
+
+// notOwnedDestinations returns the destinations whose uploads are scheduled by other replicas
+func (r *Router) notOwnedDestinations() []string {
+	if r.sharding == nil {
+		return nil
+	}
+	r.configSubscriberLock.RLock()
+	defer r.configSubscriberLock.RUnlock()
+
+	var destinationIDs []string
+	for _, warehouse := range r.warehouses {
+		if !r.sharding.Owns(warehouse.Destination.ID) && !slices.Contains(destinationIDs, warehouse.Destination.ID) {
+			destinationIDs = append(destinationIDs, warehouse.Destination.ID)
+		}
+	}
+	return destinationIDs
+}
+
 func (r *Router) uploadsToProcess(ctx context.Context, availableWorkers int, skipIdentifiers []string) ([]*UploadJob, error) {
+	skipDestinations := r.notOwnedDestinations()
 	uploads, err := r.uploadRepo.GetToProcess(ctx, r.destType, availableWorkers, repo.ProcessOptions{
 		SkipIdentifiers:                   skipIdentifiers,
 		SkipWorkspaces:                    r.tenantManager.DegradedWorkspaces(),
+		SkipDestinations:                  skipDestinations,
 		AllowMultipleSourcesForJobsPickup: r.config.allowMultipleSourcesForJobsPickup,
 	})
 	if err != nil {
@@ -422,6 +571,11 @@ func (r *Router) uploadsToProcess(ctx context.Context, availableWorkers int, ski
 
 	var uploadJobs []*UploadJob
 	for _, upload := range uploads {
+		if !r.sharding.Owns(upload.DestinationID) {
+			// destinations missing from the config aren't skipped by the query
+			continue
+		}
+
 		r.configSubscriberLock.RLock()
 
 		if upload.WorkspaceID == "" {
@@ -438,6 +592,10 @@ func (r *Router) uploadsToProcess(ctx context.Context, availableWorkers int, ski
 		r.configSubscriberLock.RUnlock()
 
 		upload.UseRudderStorage = warehouse.GetBoolDestinationConfig(model.UseRudderStorageSetting)
+		if upload.Namespace != "" {
+			// uploads are loaded into the namespace they were created for, even if the namespace of the source changed since
+			warehouse.Namespace = upload.Namespace
+		}
 
 		if !found {
 			uploadJob := r.uploadJobFactory.NewUploadJob(ctx, &model.UploadJob{
@@ -472,8 +630,9 @@ func (r *Router) uploadsToProcess(ctx context.Context, availableWorkers int, ski
 	}
 
 	jobsStats, err := r.uploadRepo.UploadJobsStats(ctx, r.destType, repo.ProcessOptions{
-		SkipIdentifiers: skipIdentifiers,
-		SkipWorkspaces:  r.tenantManager.DegradedWorkspaces(),
+		SkipIdentifiers:  skipIdentifiers,
+		SkipWorkspaces:   r.tenantManager.DegradedWorkspaces(),
+		SkipDestinations: skipDestinations,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("processing stats: %w", err)
@@ -496,15 +655,16 @@ func (r *Router) mainLoop(ctx context.Context) {
 		jobCreationChan := make(chan struct{}, r.config.maxParallelJobCreation.Load())
 
 		r.configSubscriberLock.RLock()
+		warehouses := r.ownedWarehouses()
 
 		wg := sync.WaitGroup{}
-		wg.Add(len(r.warehouses))
+		wg.Add(len(warehouses))
 
-		r.stats.schedulerWarehouseLengthStat.Gauge(len(r.warehouses))
+		r.stats.schedulerWarehouseLengthStat.Gauge(len(warehouses))
 
 		schedulingStartTime := r.now()
 
-		for _, warehouse := range r.warehouses {
+		for _, warehouse := range warehouses {
 			w := warehouse
 
 			rruntime.GoForWarehouse(func() {
@@ -536,6 +696,16 @@ func (r *Router) mainLoop(ctx context.Context) {
 	}
 }
 
+// ownedWarehouses returns the warehouses whose uploads are scheduled by this replica, with configSubscriberLock held
+func (r *Router) ownedWarehouses() []model.Warehouse {
+	if r.sharding == nil {
+		return r.warehouses
+	}
+	return lo.Filter(r.warehouses, func(warehouse model.Warehouse, _ int) bool {
+		return r.sharding.Owns(warehouse.Destination.ID)
+	})
+}
+
 func (r *Router) createJobs(ctx context.Context, warehouse model.Warehouse) (err error) {
 	if ok, err := r.canCreateUpload(ctx, warehouse); !ok {
 		r.statsFactory.NewTaggedStat("wh_scheduler.upload_sync_skipped", stats.CountType, stats.Tags{
@@ -628,10 +798,12 @@ func (r *Router) createUploadJobsFromStagingFiles(ctx context.Context, warehouse
 	// Process staging files in batches of stagingFilesBatchSize
 	// E.g. If there are 1000 pending staging files and stagingFilesBatchSize is 100,
 	// Then we create 10 new entries in wh_uploads table each with 100 staging files
-	_, uploadTriggered := r.triggerStore.Load(warehouse.Identifier)
+	trigger, uploadTriggered := r.triggerStore.Load(warehouse.Identifier)
 	if uploadTriggered {
 		priority = 50
 	}
+	// triggers can carry the tags to attach to the uploads
+	tags, _ := trigger.(map[string]string)
 
 	batches := service.StageFileBatching(stagingFiles, r.config.stagingFilesBatchSize.Load())
 	for _, batch := range batches {
@@ -643,9 +815,10 @@ func (r *Router) createUploadJobsFromStagingFiles(ctx context.Context, warehouse
 			DestinationType: r.destType,
 			Status:          model.Waiting,
 
-			LoadFileType:  warehouseutils.GetLoadFileType(r.destType),
+			LoadFileType:  warehouseutils.GetWarehouseLoadFileType(warehouse),
 			NextRetryTime: uploadStartAfter,
 			Priority:      priority,
+			Tags:          tags,
 
 			// The following will be populated by staging files:
 			// FirstEventAt:     0,
@@ -713,6 +886,9 @@ func (r *Router) loadReloadableConfig(whName string) {
 	r.config.enableJitterForSyncs = r.conf.GetReloadableBoolVar(false, "Warehouse.enableJitterForSyncs")
 	r.config.warehouseSyncFreqIgnore = r.conf.GetReloadableBoolVar(false, "Warehouse.warehouseSyncFreqIgnore")
 	r.config.cronTrackerRetries = r.conf.GetReloadableInt64Var(5, 1, "Warehouse.cronTrackerRetries")
+	r.config.renamesEnabled = r.conf.GetBoolVar(true, "Warehouse.renames.enabled")
+	r.config.renameMaxAttempts = r.conf.GetIntVar(3, 1, "Warehouse.renames.maxAttempts")
+	r.config.renameRetryInterval = r.conf.GetDurationVar(1, time.Minute, "Warehouse.renames.retryInterval")
 }
 
 func (r *Router) loadStats() {
@@ -125,6 +125,9 @@ func TestRouter(t *testing.T) {
 			ef,
 			triggerStore,
 			createUploadAlways,
+			nil,
+			nil,
+			nil,
 		)
 		_ = r.Start(ctx)
 	})
@@ -263,7 +266,7 @@ func TestRouter(t *testing.T) {
 
 			t.Run("upload triggered", func(t *testing.T) {
 				r.updateCreateJobMarker(warehouse, now.Add(-time.Hour))
-				r.triggerStore.Store(warehouse.Identifier, struct{}{})
+				r.triggerStore.Store(warehouse.Identifier, map[string]string{"triggered_by": "user-1"})
 
 				stagingFiles := append(stagingFiles, createStagingFiles(t, ctx, repoStaging, workspaceID, sourceID, destinationID)...)
 
@@ -286,6 +289,7 @@ func TestRouter(t *testing.T) {
 				require.Equal(t, upload.Namespace, "test_namespace")
 				require.Equal(t, upload.Status, model.Waiting)
 				require.Equal(t, upload.Priority, 50)
+				require.Equal(t, map[string]string{"triggered_by": "user-1"}, upload.Tags)
 			})
 		})
 	})
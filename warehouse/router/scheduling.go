@@ -8,6 +8,8 @@ import (
 
 	"github.com/samber/lo"
 
+	"github.com/rudderlabs/rudder-go-kit/config"
+
 	"github.com/rudderlabs/rudder-server/utils/timeutil"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
 	whutils "github.com/rudderlabs/rudder-server/warehouse/utils"
@@ -108,8 +110,10 @@ func checkCurrentTimeExistsInExcludeWindow(currentTime time.Time, windowStartTim
 // e.g. Syncing every 3hrs starting at 13:00 (scheduled times: 13:00, 16:00, 19:00, 22:00, 01:00, 04:00, 07:00, 10:00)
 // prev scheduled time for current time (e.g. 18:00 -> 16:00 same day, 00:30 -> 22:00 prev day)
 func (r *Router) prevScheduledTime(syncFrequency, syncStartAt string, currTime time.Time) time.Time {
-	allStartTimes := r.scheduledTimes(syncFrequency, syncStartAt)
+	return prevScheduledTimeOf(r.scheduledTimes(syncFrequency, syncStartAt), currTime)
+}
 
+func prevScheduledTimeOf(allStartTimes []int, currTime time.Time) time.Time {
 	loc, _ := time.LoadLocation("UTC")
 	now := currTime.In(loc)
 	// current time in minutes since start of day
@@ -148,6 +152,16 @@ func (r *Router) scheduledTimes(syncFrequency, syncStartAt string) []int {
 		return cachedTimes
 	}
 
+	times := scheduledTimesOf(syncFrequency, syncStartAt)
+
+	r.scheduledTimesCacheLock.Lock()
+	r.scheduledTimesCache[fmt.Sprintf(`%s-%s`, syncFrequency, syncStartAt)] = times
+	r.scheduledTimesCacheLock.Unlock()
+
+	return times
+}
+
+func scheduledTimesOf(syncFrequency, syncStartAt string) []int {
 	syncStartAtInMin := timeutil.MinsOfDay(syncStartAt)
 	syncFrequencyInMin, _ := strconv.Atoi(syncFrequency)
 	times := []int{syncStartAtInMin}
@@ -174,11 +188,60 @@ func (r *Router) scheduledTimes(syncFrequency, syncStartAt string) []int {
 		counter++
 	}
 
-	times = append(lo.Reverse(prependTimes), times...)
+	return append(lo.Reverse(prependTimes), times...)
+}
 
-	r.scheduledTimesCacheLock.Lock()
-	r.scheduledTimesCache[fmt.Sprintf(`%s-%s`, syncFrequency, syncStartAt)] = times
-	r.scheduledTimesCacheLock.Unlock()
+// nextScheduledTimeOf returns the closest next scheduled time, i.e. the first of the day's start times after the
+// current time, or the first start time of the next day
+func nextScheduledTimeOf(allStartTimes []int, currTime time.Time) time.Time {
+	now := currTime.UTC()
+	currMins := now.Hour()*60 + now.Minute()
 
-	return times
+	for _, t := range allStartTimes {
+		if t > currMins {
+			return timeutil.StartOfDay(now).Add(time.Minute * time.Duration(t))
+		}
+	}
+	return timeutil.StartOfDay(now).Add(24 * time.Hour).Add(time.Minute * time.Duration(allStartTimes[0]))
+}
+
+// NextScheduledTime returns when the next upload of the warehouse is due as per its schedule, given when its last upload
+// was created, or now if it's due already. Uploads due get created once there are staging files pending for them, unless
+// triggered, and get held back until the end of the exclude window of the destination.
+//
+// Uploads without a sync start time are due once the sync frequency of the destination passed since the last upload,
+// Warehouse.uploadFreqInS if not set either.
+func NextScheduledTime(conf *config.Config, warehouse model.Warehouse, lastCreatedAt, now time.Time) time.Time {
+	syncFrequency := warehouse.GetStringDestinationConfig(conf, model.SyncFrequencySetting)
+	syncStartAt := warehouse.GetStringDestinationConfig(conf, model.SyncStartAtSetting)
+
+	var next time.Time
+	if syncFrequency == "" || syncStartAt == "" {
+		freqInS := conf.GetInt64("Warehouse.uploadFreqInS", 1800)
+		if freqInMin, err := strconv.ParseInt(syncFrequency, 10, 64); err == nil {
+			freqInS = freqInMin * 60
+		}
+		next = lastCreatedAt.Add(time.Duration(freqInS) * time.Second)
+	} else {
+		allStartTimes := scheduledTimesOf(syncFrequency, syncStartAt)
+		if lastCreatedAt.Before(prevScheduledTimeOf(allStartTimes, now)) {
+			next = now
+		} else {
+			next = nextScheduledTimeOf(allStartTimes, now)
+		}
+	}
+	if next.Before(now) {
+		next = now
+	}
+
+	excludeWindow := warehouse.GetMapDestinationConfig(model.ExcludeWindowSetting)
+	excludeWindowStartTime, excludeWindowEndTime := excludeWindowStartEndTimes(excludeWindow)
+	if checkCurrentTimeExistsInExcludeWindow(next.UTC(), excludeWindowStartTime, excludeWindowEndTime) {
+		windowEnd := timeutil.StartOfDay(next.UTC()).Add(time.Minute * time.Duration(timeutil.MinsOfDay(excludeWindowEndTime)))
+		if windowEnd.Before(next) {
+			windowEnd = windowEnd.Add(24 * time.Hour)
+		}
+		next = windowEnd
+	}
+	return next
 }
@@ -470,3 +470,69 @@ func TestRouter_CanCreateUpload(t *testing.T) {
 		})
 	})
 }
+
+func TestNextScheduledTime(t *testing.T) {
+	now := time.Date(2020, 4, 27, 20, 23, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		destConfig    map[string]interface{}
+		lastCreatedAt time.Time
+		want          time.Time
+	}{
+		{
+			name:          "default upload frequency",
+			lastCreatedAt: now.Add(-10 * time.Minute),
+			want:          now.Add(20 * time.Minute),
+		},
+		{
+			name:          "no uploads yet",
+			destConfig:    map[string]interface{}{"syncFrequency": "60"},
+			lastCreatedAt: time.Time{},
+			want:          now,
+		},
+		{
+			name:          "sync frequency",
+			destConfig:    map[string]interface{}{"syncFrequency": "60"},
+			lastCreatedAt: now.Add(-10 * time.Minute),
+			want:          now.Add(50 * time.Minute),
+		},
+		{
+			name:          "next scheduled time",
+			destConfig:    map[string]interface{}{"syncFrequency": "180", "syncStartAt": "14:00"},
+			lastCreatedAt: time.Date(2020, 4, 27, 20, 1, 0, 0, time.UTC),
+			want:          time.Date(2020, 4, 27, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "next day's first scheduled time",
+			destConfig:    map[string]interface{}{"syncFrequency": "1440", "syncStartAt": "05:00"},
+			lastCreatedAt: time.Date(2020, 4, 27, 5, 1, 0, 0, time.UTC),
+			want:          time.Date(2020, 4, 28, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "missed scheduled time",
+			destConfig:    map[string]interface{}{"syncFrequency": "180", "syncStartAt": "14:00"},
+			lastCreatedAt: time.Date(2020, 4, 27, 19, 0, 0, 0, time.UTC),
+			want:          now,
+		},
+		{
+			name: "exclude window",
+			destConfig: map[string]interface{}{
+				"syncFrequency": "180",
+				"syncStartAt":   "14:00",
+				"excludeWindow": map[string]interface{}{"excludeWindowStartTime": "22:00", "excludeWindowEndTime": "06:30"},
+			},
+			lastCreatedAt: time.Date(2020, 4, 27, 20, 1, 0, 0, time.UTC),
+			want:          time.Date(2020, 4, 28, 6, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := model.Warehouse{
+				Destination: backendConfig.DestinationT{Config: tc.destConfig},
+			}
+			require.Equal(t, tc.want, NextScheduledTime(config.New(), w, tc.lastCreatedAt, now))
+		})
+	}
+}
@@ -10,6 +10,8 @@ type state struct {
 	inProgress string
 	failed     string
 	completed  string
+	// waiting is the status of the uploads waiting for the state to complete asynchronously
+	waiting string
 
 	nextState *state
 }
@@ -63,6 +65,7 @@ func init() {
 		inProgress: "exporting_data",
 		failed:     "exporting_data_failed",
 		completed:  model.ExportedData,
+		waiting:    model.AwaitingIngestion,
 	}
 	stateTransitions[model.ExportedData] = exportDataState
 
@@ -95,7 +98,7 @@ func nextState(currentState string) *state {
 	}
 
 	for _, uploadState := range stateTransitions {
-		if currentState == uploadState.inProgress || currentState == uploadState.failed {
+		if currentState == uploadState.inProgress || currentState == uploadState.failed || (uploadState.waiting != "" && currentState == uploadState.waiting) {
 			return uploadState
 		}
 	}
@@ -1,14 +1,15 @@
 package router
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
 )
 
-func (job *UploadJob) createRemoteSchema(whManager manager.Manager) error {
+func (job *UploadJob) createRemoteSchema(ctx context.Context, whManager manager.Manager) error {
 	if job.schemaHandle.IsWarehouseSchemaEmpty() {
-		if err := whManager.CreateSchema(job.ctx); err != nil {
+		if err := whManager.CreateSchema(ctx); err != nil {
 			return fmt.Errorf("creating schema: %w", err)
 		}
 	}
@@ -2,11 +2,13 @@ package router
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/samber/lo"
 
@@ -16,8 +18,11 @@ import (
 	"github.com/rudderlabs/rudder-server/warehouse/identity"
 	integrationsconfig "github.com/rudderlabs/rudder-server/warehouse/integrations/config"
 	schemarepository "github.com/rudderlabs/rudder-server/warehouse/integrations/datalake/schema-repository"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/quality"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/repo"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/service/loadfiles/downloader"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/uploadwebhook"
 	"github.com/rudderlabs/rudder-server/warehouse/logfield"
 
 	whutils "github.com/rudderlabs/rudder-server/warehouse/utils"
@@ -27,7 +32,13 @@ import (
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
 )
 
-func (job *UploadJob) exportData() error {
+// errIngestionPending is returned while the warehouse has not confirmed the ingestion of some tables yet
+var errIngestionPending = errors.New("ingestion pending")
+
+// tableUploadLoadedStatuses are the statuses of the tables which were loaded by the upload, or are being ingested
+var tableUploadLoadedStatuses = []string{model.TableUploadExported, model.TableUploadQualityCheckFailed, model.TableUploadIngesting}
+
+func (job *UploadJob) exportData(ctx context.Context) error {
 	_, currentSucceededTables, err := job.TablesToSkip()
 	if err != nil {
 		return fmt.Errorf("tables to skip: %w", err)
@@ -39,7 +50,7 @@ func (job *UploadJob) exportData() error {
 		loadFilesTableMap map[tableNameT]bool
 	)
 
-	loadFilesTableMap, err = job.getLoadFilesTableMap()
+	loadFilesTableMap, err = job.getLoadFilesTableMap(ctx)
 	if err != nil {
 		return fmt.Errorf("unable to get load files table map: %w", err)
 	}
@@ -62,7 +73,7 @@ func (job *UploadJob) exportData() error {
 		if succeededUserTableCount >= len(userTables) {
 			return
 		}
-		err := job.exportUserTables(loadFilesTableMap)
+		err := job.exportUserTables(ctx, loadFilesTableMap)
 		if err != nil {
 			loadErrorLock.Lock()
 			loadErrors = append(loadErrors, err)
@@ -82,7 +93,7 @@ func (job *UploadJob) exportData() error {
 		if succeededIdentityTableCount >= len(identityTables) {
 			return
 		}
-		err := job.exportIdentities()
+		err := job.exportIdentities(ctx)
 		if err != nil {
 			loadErrorLock.Lock()
 			loadErrors = append(loadErrors, err)
@@ -97,7 +108,7 @@ func (job *UploadJob) exportData() error {
 		specialTables = append(specialTables, userTables...)
 		specialTables = append(specialTables, identityTables...)
 
-		err := job.exportRegularTables(specialTables, loadFilesTableMap)
+		err := job.exportRegularTables(ctx, specialTables, loadFilesTableMap)
 		if err != nil {
 			loadErrorLock.Lock()
 			loadErrors = append(loadErrors, err)
@@ -107,13 +118,16 @@ func (job *UploadJob) exportData() error {
 
 	wg.Wait()
 
-	if err := job.RefreshPartitions(job.upload.LoadFileStartID, job.upload.LoadFileEndID); err != nil {
+	if err := job.RefreshPartitions(ctx, job.upload.LoadFileStartID, job.upload.LoadFileEndID); err != nil {
 		loadErrors = append(loadErrors, fmt.Errorf("refresh partitions: %w", err))
 	}
 
 	if len(loadErrors) > 0 {
 		return misc.ConcatErrors(loadErrors)
 	}
+	if err := job.confirmIngestion(ctx); err != nil {
+		return err
+	}
 	job.generateUploadSuccessMetrics()
 
 	return nil
@@ -137,12 +151,7 @@ func (job *UploadJob) identityMappingsTableName() string {
 
 func (job *UploadJob) TablesToSkip() (map[string]model.PendingTableUpload, map[string]model.PendingTableUpload, error) {
 	job.pendingTableUploadsOnce.Do(func() {
-		job.pendingTableUploads, job.pendingTableUploadsError = job.pendingTableUploadsRepo.PendingTableUploads(
-			job.ctx,
-			job.upload.Namespace,
-			job.upload.ID,
-			job.upload.DestinationID,
-		)
+		job.pendingTableUploads, job.pendingTableUploadsError = job.getPendingTableUploads()
 	})
 
 	if job.pendingTableUploadsError != nil {
@@ -158,16 +167,59 @@ func (job *UploadJob) TablesToSkip() (map[string]model.PendingTableUpload, map[s
 		if pendingTableUpload.UploadID < job.upload.ID && pendingTableUpload.Status == model.TableUploadExportingFailed {
 			previouslyFailedTableMap[pendingTableUpload.TableName] = pendingTableUpload
 		}
-		if pendingTableUpload.UploadID == job.upload.ID && pendingTableUpload.Status == model.TableUploadExported { // Current upload and table upload succeeded
+		if pendingTableUpload.UploadID == job.upload.ID && slices.Contains(tableUploadLoadedStatuses, pendingTableUpload.Status) { // Current upload and table upload succeeded, or is being ingested
 			currentlySucceededTableMap[pendingTableUpload.TableName] = pendingTableUpload
 		}
 	}
 	return previouslyFailedTableMap, currentlySucceededTableMap, nil
 }
 
-func (job *UploadJob) getLoadFilesTableMap() (loadFilesMap map[tableNameT]bool, err error) {
-	tableName, err := job.loadFilesRepo.DistinctTableName(
+// getPendingTableUploads returns the progress of the tables of the upload persisted by its previous attempts, along
+// with the failures of the tables it still has to load in earlier uploads. When resuming an upload, only the tables
+// which were not loaded yet are looked up in the earlier uploads, and none at all once every table was loaded.
+func (job *UploadJob) getPendingTableUploads() ([]model.PendingTableUpload, error) {
+	tableUploads, err := job.pendingTableUploadsRepo.GetByUploadID(job.ctx, job.upload.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		pendingTableUploads = make([]model.PendingTableUpload, 0, len(tableUploads))
+		tablesToLoad        []string
+	)
+	for _, tableUpload := range tableUploads {
+		pendingTableUploads = append(pendingTableUploads, model.PendingTableUpload{
+			UploadID:      job.upload.ID,
+			DestinationID: job.upload.DestinationID,
+			Namespace:     job.upload.Namespace,
+			TableName:     tableUpload.TableName,
+			Status:        tableUpload.Status,
+			Error:         tableUpload.Error,
+		})
+		if !slices.Contains(tableUploadLoadedStatuses, tableUpload.Status) {
+			tablesToLoad = append(tablesToLoad, tableUpload.TableName)
+		}
+	}
+	if len(tablesToLoad) == 0 {
+		return pendingTableUploads, nil
+	}
+
+	previouslyFailedTableUploads, err := job.pendingTableUploadsRepo.PreviouslyFailedTableUploads(
 		job.ctx,
+		job.upload.Namespace,
+		job.upload.ID,
+		job.upload.DestinationID,
+		tablesToLoad,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return append(previouslyFailedTableUploads, pendingTableUploads...), nil
+}
+
+func (job *UploadJob) getLoadFilesTableMap(ctx context.Context) (loadFilesMap map[tableNameT]bool, err error) {
+	tableName, err := job.loadFilesRepo.DistinctTableName(
+		ctx,
 		job.warehouse.Source.ID,
 		job.warehouse.Destination.ID,
 		job.upload.LoadFileStartID,
@@ -183,12 +235,12 @@ func (job *UploadJob) getLoadFilesTableMap() (loadFilesMap map[tableNameT]bool,
 	return tablesMap, nil
 }
 
-func (job *UploadJob) exportUserTables(loadFilesTableMap map[tableNameT]bool) (err error) {
+func (job *UploadJob) exportUserTables(ctx context.Context, loadFilesTableMap map[tableNameT]bool) (err error) {
 	uploadSchema := job.upload.UploadSchema
 	if _, ok := uploadSchema[job.identifiesTableName()]; ok {
 		defer job.stats.userTablesLoadTime.RecordDuration()()
 		var loadErrors []error
-		loadErrors, err = job.loadUserTables(loadFilesTableMap)
+		loadErrors, err = job.loadUserTables(ctx, loadFilesTableMap)
 		if err != nil {
 			return
 		}
@@ -197,11 +249,12 @@ func (job *UploadJob) exportUserTables(loadFilesTableMap map[tableNameT]bool) (e
 			err = misc.ConcatErrors(loadErrors)
 			return
 		}
+		job.notifyUploadWebhook(uploadwebhook.ExportedUserTables, []string{job.identifiesTableName(), job.usersTableName()}, nil)
 	}
 	return
 }
 
-func (job *UploadJob) loadUserTables(loadFilesTableMap map[tableNameT]bool) ([]error, error) {
+func (job *UploadJob) loadUserTables(ctx context.Context, loadFilesTableMap map[tableNameT]bool) ([]error, error) {
 	var hasLoadFiles bool
 	userTables := []string{job.identifiesTableName(), job.usersTableName()}
 
@@ -238,38 +291,38 @@ func (job *UploadJob) loadUserTables(loadFilesTableMap map[tableNameT]bool) ([]e
 	// Load all user tables
 	status := model.TableUploadExecuting
 	lastExecTime := job.now()
-	_ = job.tableUploadsRepo.Set(job.ctx, job.upload.ID, job.identifiesTableName(), repo.TableUploadSetOptions{
+	_ = job.tableUploadsRepo.Set(ctx, job.upload.ID, job.identifiesTableName(), repo.TableUploadSetOptions{
 		Status:       &status,
 		LastExecTime: &lastExecTime,
 	})
 
-	alteredIdentitySchema, err := job.updateSchema(job.identifiesTableName())
+	alteredIdentitySchema, err := job.updateSchema(ctx, job.identifiesTableName())
 	if err != nil {
 		status := model.TableUploadUpdatingSchemaFailed
 		errorsString := misc.QuoteLiteral(err.Error())
-		_ = job.tableUploadsRepo.Set(job.ctx, job.upload.ID, job.identifiesTableName(), repo.TableUploadSetOptions{
+		_ = job.tableUploadsRepo.Set(ctx, job.upload.ID, job.identifiesTableName(), repo.TableUploadSetOptions{
 			Status: &status,
 			Error:  &errorsString,
 		})
-		return job.processLoadTableResponse(map[string]error{job.identifiesTableName(): err})
+		return job.processLoadTableResponse(ctx, map[string]error{job.identifiesTableName(): err})
 	}
 	var alteredUserSchema bool
 	if _, ok := job.upload.UploadSchema[job.usersTableName()]; ok {
 		status := model.TableUploadExecuting
 		lastExecTime := job.now()
-		_ = job.tableUploadsRepo.Set(job.ctx, job.upload.ID, job.usersTableName(), repo.TableUploadSetOptions{
+		_ = job.tableUploadsRepo.Set(ctx, job.upload.ID, job.usersTableName(), repo.TableUploadSetOptions{
 			Status:       &status,
 			LastExecTime: &lastExecTime,
 		})
-		alteredUserSchema, err = job.updateSchema(job.usersTableName())
+		alteredUserSchema, err = job.updateSchema(ctx, job.usersTableName())
 		if err != nil {
 			status = model.TableUploadUpdatingSchemaFailed
 			errorsString := misc.QuoteLiteral(err.Error())
-			_ = job.tableUploadsRepo.Set(job.ctx, job.upload.ID, job.usersTableName(), repo.TableUploadSetOptions{
+			_ = job.tableUploadsRepo.Set(ctx, job.upload.ID, job.usersTableName(), repo.TableUploadSetOptions{
 				Status: &status,
 				Error:  &errorsString,
 			})
-			return job.processLoadTableResponse(map[string]error{job.usersTableName(): err})
+			return job.processLoadTableResponse(ctx, map[string]error{job.usersTableName(): err})
 		}
 	}
 
@@ -278,19 +331,19 @@ func (job *UploadJob) loadUserTables(loadFilesTableMap map[tableNameT]bool) ([]e
 		return []error{}, nil
 	}
 
-	errorMap := job.whManager.LoadUserTables(job.ctx)
+	errorMap := job.whManager.LoadUserTables(ctx)
 
 	if alteredIdentitySchema || alteredUserSchema {
 		job.logger.Infof("loadUserTables: schema changed - updating local schema for %s", job.warehouse.Identifier)
-		_ = job.schemaHandle.UpdateLocalSchemaWithWarehouse(job.ctx, job.upload.ID)
+		_ = job.schemaHandle.UpdateLocalSchemaWithWarehouse(ctx, job.upload.ID)
 	}
-	return job.processLoadTableResponse(errorMap)
+	return job.processLoadTableResponse(ctx, errorMap)
 }
 
-func (job *UploadJob) updateSchema(tName string) (alteredSchema bool, err error) {
+func (job *UploadJob) updateSchema(ctx context.Context, tName string) (alteredSchema bool, err error) {
 	tableSchemaDiff := job.schemaHandle.TableSchemaDiff(tName, job.GetTableSchemaInUpload(tName))
 	if tableSchemaDiff.Exists {
-		err = job.UpdateTableSchema(tName, tableSchemaDiff)
+		err = job.UpdateTableSchema(ctx, tName, tableSchemaDiff)
 		if err != nil {
 			return
 		}
@@ -301,10 +354,10 @@ func (job *UploadJob) updateSchema(tName string) (alteredSchema bool, err error)
 	return
 }
 
-func (job *UploadJob) UpdateTableSchema(tName string, tableSchemaDiff whutils.TableSchemaDiff) (err error) {
+func (job *UploadJob) UpdateTableSchema(ctx context.Context, tName string, tableSchemaDiff whutils.TableSchemaDiff) (err error) {
 	job.logger.Infof(`[WH]: Starting schema update for table %s in namespace %s of destination %s:%s`, tName, job.warehouse.Namespace, job.warehouse.Type, job.warehouse.Destination.ID)
 	if tableSchemaDiff.TableToBeCreated {
-		err = job.whManager.CreateTable(job.ctx, tName, tableSchemaDiff.ColumnMap)
+		err = job.whManager.CreateTable(ctx, tName, tableSchemaDiff.ColumnMap)
 		if err != nil {
 			job.logger.Errorf("Error creating table %s on namespace: %s, error: %v", tName, job.warehouse.Namespace, err)
 			return err
@@ -313,11 +366,11 @@ func (job *UploadJob) UpdateTableSchema(tName string, tableSchemaDiff whutils.Ta
 		return nil
 	}
 
-	if err = job.addColumnsToWarehouse(job.ctx, tName, tableSchemaDiff.ColumnMap); err != nil {
+	if err = job.addColumnsToWarehouse(ctx, tName, tableSchemaDiff.ColumnMap); err != nil {
 		return fmt.Errorf("adding columns to warehouse: %w", err)
 	}
 
-	if err = job.alterColumnsToWarehouse(job.ctx, tName, tableSchemaDiff.AlteredColumnMap); err != nil {
+	if err = job.alterColumnsToWarehouse(ctx, tName, tableSchemaDiff.AlteredColumnMap); err != nil {
 		return fmt.Errorf("altering columns to warehouse: %w", err)
 	}
 
@@ -325,7 +378,7 @@ func (job *UploadJob) UpdateTableSchema(tName string, tableSchemaDiff whutils.Ta
 }
 
 func (job *UploadJob) alterColumnsToWarehouse(ctx context.Context, tName string, columnsMap model.TableSchema) error {
-	if job.config.disableAlter {
+	if job.config.disableAlter || !job.capabilities().AlterColumn {
 		job.logger.Debugw("skipping alter columns to warehouse",
 			logfield.TableName, tName,
 			"columns", columnsMap,
@@ -419,25 +472,25 @@ func (job *UploadJob) addColumnsToWarehouse(ctx context.Context, tName string, c
 	return err
 }
 
-func (job *UploadJob) processLoadTableResponse(errorMap map[string]error) (errors []error, tableUploadErr error) {
+func (job *UploadJob) processLoadTableResponse(ctx context.Context, errorMap map[string]error) (errors []error, tableUploadErr error) {
 	for tName, loadErr := range errorMap {
 		// TODO: set last_exec_time
 		if loadErr != nil {
 			errors = append(errors, loadErr)
 			errorsString := misc.QuoteLiteral(loadErr.Error())
 			status := model.TableUploadExportingFailed
-			tableUploadErr = job.tableUploadsRepo.Set(job.ctx, job.upload.ID, tName, repo.TableUploadSetOptions{
+			tableUploadErr = job.tableUploadsRepo.Set(ctx, job.upload.ID, tName, repo.TableUploadSetOptions{
 				Status: &status,
 				Error:  &errorsString,
 			})
 		} else {
 			status := model.TableUploadExported
-			tableUploadErr = job.tableUploadsRepo.Set(job.ctx, job.upload.ID, tName, repo.TableUploadSetOptions{
+			tableUploadErr = job.tableUploadsRepo.Set(ctx, job.upload.ID, tName, repo.TableUploadSetOptions{
 				Status: &status,
 			})
 			if tableUploadErr == nil {
 				// Since load is successful, we assume all events in load files are uploaded
-				tableUpload, queryErr := job.tableUploadsRepo.GetByUploadIDAndTableName(job.ctx, job.upload.ID, tName)
+				tableUpload, queryErr := job.tableUploadsRepo.GetByUploadIDAndTableName(ctx, job.upload.ID, tName)
 				if queryErr == nil {
 					job.recordTableLoad(tName, tableUpload.TotalEvents)
 				}
@@ -452,15 +505,15 @@ func (job *UploadJob) processLoadTableResponse(errorMap map[string]error) (error
 	return errors, tableUploadErr
 }
 
-func (job *UploadJob) exportIdentities() (err error) {
+func (job *UploadJob) exportIdentities(ctx context.Context) (err error) {
 	// Load Identities if enabled
 	uploadSchema := job.upload.UploadSchema
-	if whutils.IDResolutionEnabled() && slices.Contains(whutils.IdentityEnabledWarehouses, job.warehouse.Type) {
+	if whutils.IDResolutionEnabled() && job.capabilities().IdentityResolution {
 		if _, ok := uploadSchema[job.identityMergeRulesTableName()]; ok {
 			defer job.stats.identityTablesLoadTime.RecordDuration()()
 
 			var loadErrors []error
-			loadErrors, err = job.loadIdentityTables(false)
+			loadErrors, err = job.loadIdentityTables(ctx, false)
 			if err != nil {
 				return
 			}
@@ -474,7 +527,7 @@ func (job *UploadJob) exportIdentities() (err error) {
 	return
 }
 
-func (job *UploadJob) loadIdentityTables(populateHistoricIdentities bool) (loadErrors []error, tableUploadErr error) {
+func (job *UploadJob) loadIdentityTables(ctx context.Context, populateHistoricIdentities bool) (loadErrors []error, tableUploadErr error) {
 	job.logger.Infof(`[WH]: Starting load for identity tables in namespace %s of destination %s:%s`, job.warehouse.Namespace, job.warehouse.Type, job.warehouse.Destination.ID)
 	identityTables := []string{job.identityMergeRulesTableName(), job.identityMappingsTableName()}
 
@@ -496,11 +549,11 @@ func (job *UploadJob) loadIdentityTables(populateHistoricIdentities bool) (loadE
 
 	errorMap := make(map[string]error)
 	// var generated bool
-	if generated, _ := job.areIdentityTablesLoadFilesGenerated(job.ctx); !generated {
-		if err := job.resolveIdentities(populateHistoricIdentities); err != nil {
+	if generated, _ := job.areIdentityTablesLoadFilesGenerated(ctx); !generated {
+		if err := job.resolveIdentities(ctx, populateHistoricIdentities); err != nil {
 			job.logger.Errorf(` ID Resolution operation failed: %v`, err)
 			errorMap[job.identityMergeRulesTableName()] = err
-			return job.processLoadTableResponse(errorMap)
+			return job.processLoadTableResponse(ctx, errorMap)
 		}
 	}
 
@@ -514,21 +567,21 @@ func (job *UploadJob) loadIdentityTables(populateHistoricIdentities bool) (loadE
 
 		tableSchemaDiff := job.schemaHandle.TableSchemaDiff(tableName, job.GetTableSchemaInUpload(tableName))
 		if tableSchemaDiff.Exists {
-			err := job.UpdateTableSchema(tableName, tableSchemaDiff)
+			err := job.UpdateTableSchema(ctx, tableName, tableSchemaDiff)
 			if err != nil {
 				status := model.TableUploadUpdatingSchemaFailed
 				errorsString := misc.QuoteLiteral(err.Error())
-				_ = job.tableUploadsRepo.Set(job.ctx, job.upload.ID, tableName, repo.TableUploadSetOptions{
+				_ = job.tableUploadsRepo.Set(ctx, job.upload.ID, tableName, repo.TableUploadSetOptions{
 					Status: &status,
 					Error:  &errorsString,
 				})
 				errorMap := map[string]error{tableName: err}
-				return job.processLoadTableResponse(errorMap)
+				return job.processLoadTableResponse(ctx, errorMap)
 			}
 			job.schemaHandle.UpdateWarehouseTableSchema(tableName, tableSchemaDiff.UpdatedSchema)
 
 			status := model.TableUploadUpdatedSchema
-			_ = job.tableUploadsRepo.Set(job.ctx, job.upload.ID, tableName, repo.TableUploadSetOptions{
+			_ = job.tableUploadsRepo.Set(ctx, job.upload.ID, tableName, repo.TableUploadSetOptions{
 				Status: &status,
 			})
 			alteredSchema = true
@@ -536,7 +589,7 @@ func (job *UploadJob) loadIdentityTables(populateHistoricIdentities bool) (loadE
 
 		status := model.TableUploadExecuting
 		lastExecTime := job.now()
-		err = job.tableUploadsRepo.Set(job.ctx, job.upload.ID, tableName, repo.TableUploadSetOptions{
+		err = job.tableUploadsRepo.Set(ctx, job.upload.ID, tableName, repo.TableUploadSetOptions{
 			Status:       &status,
 			LastExecTime: &lastExecTime,
 		})
@@ -547,9 +600,9 @@ func (job *UploadJob) loadIdentityTables(populateHistoricIdentities bool) (loadE
 
 		switch tableName {
 		case job.identityMergeRulesTableName():
-			err = job.whManager.LoadIdentityMergeRulesTable(job.ctx)
+			err = job.whManager.LoadIdentityMergeRulesTable(ctx)
 		case job.identityMappingsTableName():
-			err = job.whManager.LoadIdentityMappingsTable(job.ctx)
+			err = job.whManager.LoadIdentityMappingsTable(ctx)
 		}
 
 		if err != nil {
@@ -560,10 +613,10 @@ func (job *UploadJob) loadIdentityTables(populateHistoricIdentities bool) (loadE
 
 	if alteredSchema {
 		job.logger.Infof("loadIdentityTables: schema changed - updating local schema for %s", job.warehouse.Identifier)
-		_ = job.schemaHandle.UpdateLocalSchemaWithWarehouse(job.ctx, job.upload.ID) // TODO check error
+		_ = job.schemaHandle.UpdateLocalSchemaWithWarehouse(ctx, job.upload.ID) // TODO check error
 	}
 
-	return job.processLoadTableResponse(errorMap)
+	return job.processLoadTableResponse(ctx, errorMap)
 }
 
 func (job *UploadJob) areIdentityTablesLoadFilesGenerated(ctx context.Context) (bool, error) {
@@ -589,7 +642,7 @@ func (job *UploadJob) areIdentityTablesLoadFilesGenerated(ctx context.Context) (
 	return true, nil
 }
 
-func (job *UploadJob) resolveIdentities(populateHistoricIdentities bool) (err error) {
+func (job *UploadJob) resolveIdentities(ctx context.Context, populateHistoricIdentities bool) (err error) {
 	idr := identity.New(
 		job.warehouse,
 		job.db,
@@ -600,17 +653,17 @@ func (job *UploadJob) resolveIdentities(populateHistoricIdentities bool) (err er
 		job.encodingFactory,
 	)
 	if populateHistoricIdentities {
-		return idr.ResolveHistoricIdentities(job.ctx)
+		return idr.ResolveHistoricIdentities(ctx)
 	}
-	return idr.Resolve(job.ctx)
+	return idr.Resolve(ctx)
 }
 
-func (job *UploadJob) exportRegularTables(specialTables []string, loadFilesTableMap map[tableNameT]bool) (err error) {
+func (job *UploadJob) exportRegularTables(ctx context.Context, specialTables []string, loadFilesTableMap map[tableNameT]bool) (err error) {
 	//[]string{job.identifiesTableName(), job.usersTableName(), job.identityMergeRulesTableName(), job.identityMappingsTableName()}
 	// Export all other tables
 	defer job.stats.otherTablesLoadTime.RecordDuration()()
 
-	loadErrors := job.loadAllTablesExcept(specialTables, loadFilesTableMap)
+	loadErrors := job.loadAllTablesExcept(ctx, specialTables, loadFilesTableMap)
 
 	if len(loadErrors) > 0 {
 		err = misc.ConcatErrors(loadErrors)
@@ -620,14 +673,13 @@ func (job *UploadJob) exportRegularTables(specialTables []string, loadFilesTable
 	return
 }
 
-func (job *UploadJob) loadAllTablesExcept(skipLoadForTables []string, loadFilesTableMap map[tableNameT]bool) []error {
+func (job *UploadJob) loadAllTablesExcept(ctx context.Context, skipLoadForTables []string, loadFilesTableMap map[tableNameT]bool) []error {
 	maxParallelLoadsMap := integrationsconfig.MaxParallelLoadsMap(job.conf)
 
 	uploadSchema := job.upload.UploadSchema
-	var parallelLoads int
-	var ok bool
-	if parallelLoads, ok = maxParallelLoadsMap[job.warehouse.Type]; !ok {
-		parallelLoads = 1
+	parallelLoads := 1
+	if maxParallelLoads, ok := maxParallelLoadsMap[job.warehouse.Type]; ok {
+		parallelLoads = maxParallelLoads.Load()
 	}
 
 	if k, ok := job.config.maxParallelLoadsWorkspaceIDs[strings.ToLower(job.warehouse.WorkspaceID)]; ok {
@@ -675,7 +727,7 @@ func (job *UploadJob) loadAllTablesExcept(skipLoadForTables []string, loadFilesT
 		if !hasLoadFiles {
 			if slices.Contains(alwaysMarkExported, strings.ToLower(tableName)) {
 				status := model.TableUploadExported
-				_ = job.tableUploadsRepo.Set(job.ctx, job.upload.ID, tableName, repo.TableUploadSetOptions{
+				_ = job.tableUploadsRepo.Set(ctx, job.upload.ID, tableName, repo.TableUploadSetOptions{
 					Status: &status,
 				})
 			}
@@ -685,7 +737,13 @@ func (job *UploadJob) loadAllTablesExcept(skipLoadForTables []string, loadFilesT
 		tableName := tableName
 		concurrencyGuard <- struct{}{}
 		rruntime.GoForWarehouse(func() {
-			alteredSchema, err := job.loadTable(tableName)
+			// tables loaded by the uploads of the other destinations sharing the physical warehouse count as well
+			var alteredSchema bool
+			err := job.endpoints.AcquireLoad(ctx, job.endpoint)
+			if err == nil {
+				alteredSchema, err = job.loadTable(ctx, tableName)
+				job.endpoints.ReleaseLoad(job.endpoint)
+			}
 			if alteredSchema {
 				alteredSchemaInAtLeastOneTable.Store(true)
 			}
@@ -703,18 +761,18 @@ func (job *UploadJob) loadAllTablesExcept(skipLoadForTables []string, loadFilesT
 
 	if alteredSchemaInAtLeastOneTable.Load() {
 		job.logger.Infof("loadAllTablesExcept: schema changed - updating local schema for %s", job.warehouse.Identifier)
-		_ = job.schemaHandle.UpdateLocalSchemaWithWarehouse(job.ctx, job.upload.ID) // TODO check error
+		_ = job.schemaHandle.UpdateLocalSchemaWithWarehouse(ctx, job.upload.ID) // TODO check error
 	}
 
 	return loadErrors
 }
 
-func (job *UploadJob) loadTable(tName string) (bool, error) {
-	alteredSchema, err := job.updateSchema(tName)
+func (job *UploadJob) loadTable(ctx context.Context, tName string) (bool, error) {
+	alteredSchema, err := job.updateSchema(ctx, tName)
 	if err != nil {
 		status := model.TableUploadUpdatingSchemaFailed
 		errorsString := misc.QuoteLiteral(err.Error())
-		_ = job.tableUploadsRepo.Set(job.ctx, job.upload.ID, tName, repo.TableUploadSetOptions{
+		_ = job.tableUploadsRepo.Set(ctx, job.upload.ID, tName, repo.TableUploadSetOptions{
 			Status: &status,
 			Error:  &errorsString,
 		})
@@ -723,23 +781,51 @@ func (job *UploadJob) loadTable(tName string) (bool, error) {
 
 	job.logger.Infow("starting load for table", logfield.TableName, tName)
 
+	loadAttempt := int64(1)
+	if tableUpload, err := job.tableUploadsRepo.GetByUploadIDAndTableName(ctx, job.upload.ID, tName); err == nil {
+		loadAttempt = tableUpload.LoadAttempt + 1
+	}
 	status := model.TableUploadExecuting
 	lastExecTime := job.now()
-	_ = job.tableUploadsRepo.Set(job.ctx, job.upload.ID, tName, repo.TableUploadSetOptions{
+	_ = job.tableUploadsRepo.Set(ctx, job.upload.ID, tName, repo.TableUploadSetOptions{
 		Status:       &status,
 		LastExecTime: &lastExecTime,
+		LoadAttempt:  &loadAttempt,
 	})
 
-	loadTableStat, err := job.whManager.LoadTable(job.ctx, tName)
+	loadTableStat, err := job.whManager.LoadTable(ctx, tName)
+	loadDuration := job.now().Sub(lastExecTime)
 	if err != nil {
 		status := model.TableUploadExportingFailed
 		errorsString := misc.QuoteLiteral(err.Error())
-		_ = job.tableUploadsRepo.Set(job.ctx, job.upload.ID, tName, repo.TableUploadSetOptions{
-			Status: &status,
-			Error:  &errorsString,
+		_ = job.tableUploadsRepo.Set(ctx, job.upload.ID, tName, repo.TableUploadSetOptions{
+			Status:       &status,
+			Error:        &errorsString,
+			LoadDuration: &loadDuration,
 		})
+		job.recordTableLoadAttempt(tName, loadDuration, 0, 0, false)
 		return alteredSchema, fmt.Errorf("load table: %w", err)
 	}
+	if loadTableStat.Ingesting {
+		status := model.TableUploadIngesting
+		_ = job.tableUploadsRepo.Set(ctx, job.upload.ID, tName, repo.TableUploadSetOptions{
+			Status:       &status,
+			LoadDuration: &loadDuration,
+		})
+		return alteredSchema, nil
+	}
+
+	rowsLoaded := loadTableStat.RowsInserted + loadTableStat.RowsUpdated
+	bytesLoaded, err := job.loadFilesRepo.TotalBytes(ctx, job.stagingFileIDs, tName)
+	if err != nil {
+		job.logger.Warnw("summing size of load files", logfield.TableName, tName, logfield.Error, err.Error())
+	}
+	_ = job.tableUploadsRepo.Set(ctx, job.upload.ID, tName, repo.TableUploadSetOptions{
+		LoadDuration: &loadDuration,
+		RowsLoaded:   &rowsLoaded,
+		BytesLoaded:  &bytesLoaded,
+	})
+	job.recordTableLoadAttempt(tName, loadDuration, rowsLoaded, bytesLoaded, true)
 	if loadTableStat.RowsUpdated > 0 {
 		job.statsFactory.NewTaggedStat("dedup_rows", stats.CountType, stats.Tags{
 			"sourceID":       job.warehouse.Source.ID,
@@ -752,7 +838,7 @@ func (job *UploadJob) loadTable(tName string) (bool, error) {
 		}).Count(int(loadTableStat.RowsUpdated))
 	}
 
-	tableUpload, errEventCount := job.tableUploadsRepo.GetByUploadIDAndTableName(job.ctx, job.upload.ID, tName)
+	tableUpload, errEventCount := job.tableUploadsRepo.GetByUploadIDAndTableName(ctx, job.upload.ID, tName)
 	if errEventCount != nil {
 		return alteredSchema, fmt.Errorf("get table upload: %w", errEventCount)
 	}
@@ -764,36 +850,177 @@ func (job *UploadJob) loadTable(tName string) (bool, error) {
 	job.gaugeStat(`post_load_table_rows_estimate`, tags...).Gauge(int(tableUpload.TotalEvents))
 	job.gaugeStat(`post_load_table_rows`, tags...).Gauge(int(loadTableStat.RowsInserted))
 
-	status = model.TableUploadExported
-	_ = job.tableUploadsRepo.Set(job.ctx, job.upload.ID, tName, repo.TableUploadSetOptions{
-		Status: &status,
-	})
-	tableUpload, queryErr := job.tableUploadsRepo.GetByUploadIDAndTableName(job.ctx, job.upload.ID, tName)
+	job.completeTableLoad(ctx, tName)
+
+	return alteredSchema, nil
+}
+
+// completeTableLoad marks the loaded table as exported, or as failing its data quality assertions
+func (job *UploadJob) completeTableLoad(ctx context.Context, tName string) {
+	setOptions := repo.TableUploadSetOptions{}
+	status := model.TableUploadExported
+	if failures := job.checkDataQuality(ctx, tName); len(failures) > 0 {
+		status = model.TableUploadQualityCheckFailed
+		errorsString := misc.QuoteLiteral(strings.Join(failures, "; "))
+		setOptions.Error = &errorsString
+	}
+	setOptions.Status = &status
+	_ = job.tableUploadsRepo.Set(ctx, job.upload.ID, tName, setOptions)
+	tableUpload, queryErr := job.tableUploadsRepo.GetByUploadIDAndTableName(ctx, job.upload.ID, tName)
 	if queryErr == nil {
 		job.recordTableLoad(tName, tableUpload.TotalEvents)
 	}
 
 	job.columnCountStat(tName)
+}
 
-	return alteredSchema, nil
+// confirmIngestion confirms the ingestion of the tables whose load files were submitted to be ingested asynchronously,
+// completing their load once ingested. It returns errIngestionPending while some ingestions are not confirmed yet.
+func (job *UploadJob) confirmIngestion(ctx context.Context) error {
+	tableUploads, err := job.tableUploadsRepo.GetByUploadID(ctx, job.upload.ID)
+	if err != nil {
+		return fmt.Errorf("getting table uploads: %w", err)
+	}
+	ingestingTables := lo.Filter(tableUploads, func(tableUpload model.TableUpload, _ int) bool {
+		return tableUpload.Status == model.TableUploadIngesting
+	})
+	if len(ingestingTables) == 0 {
+		return nil
+	}
+
+	confirmer, ok := job.whManager.(manager.IngestionConfirmer)
+	if !ok {
+		return fmt.Errorf("confirming ingestion is not supported for %s", job.warehouse.Type)
+	}
+
+	var pendingTables []string
+	for _, tableUpload := range ingestingTables {
+		ingested, err := confirmer.ConfirmIngestion(ctx, tableUpload.TableName)
+		if err == nil && !ingested && job.now().Sub(tableUpload.LastExecTime) > job.config.ingestionTimeout {
+			err = fmt.Errorf("ingestion not confirmed within %s", job.config.ingestionTimeout)
+		}
+		if err != nil {
+			status := model.TableUploadExportingFailed
+			errorsString := misc.QuoteLiteral(err.Error())
+			_ = job.tableUploadsRepo.Set(ctx, job.upload.ID, tableUpload.TableName, repo.TableUploadSetOptions{
+				Status: &status,
+				Error:  &errorsString,
+			})
+			return fmt.Errorf("confirming ingestion of table %s: %w", tableUpload.TableName, err)
+		}
+		if !ingested {
+			pendingTables = append(pendingTables, tableUpload.TableName)
+			continue
+		}
+		job.completeTableLoad(ctx, tableUpload.TableName)
+	}
+	if len(pendingTables) > 0 {
+		return fmt.Errorf("tables %s: %w", strings.Join(pendingTables, ", "), errIngestionPending)
+	}
+	return nil
 }
 
-// columnCountStat sent the column count for a table to statsd
-// skip sending for S3_DATALAKE, GCS_DATALAKE, AZURE_DATALAKE
-func (job *UploadJob) columnCountStat(tableName string) {
-	var (
-		columnCountLimit int
-		ok               bool
+// checkDataQuality evaluates the data quality assertions configured for the loaded table, if any, alerting on the failed
+// ones. The data is loaded by then, so failures are only reported and do not fail the upload.
+func (job *UploadJob) checkDataQuality(ctx context.Context, tName string) []string {
+	assertions, err := quality.TableAssertions(job.warehouse, tName)
+	if err != nil {
+		return []string{fmt.Sprintf("parsing assertions: %v", err)}
+	}
+	if assertions == nil {
+		return nil
+	}
+
+	failures, err := job.evaluateDataQuality(ctx, tName, *assertions)
+	if err != nil {
+		failures = []string{fmt.Sprintf("evaluating assertions: %v", err)}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+
+	job.logger.Warnw("data quality assertions failed",
+		logfield.TableName, tName,
+		"failures", failures,
 	)
+	job.counterStat("quality_check_failures", whutils.Tag{Name: "tableName", Value: whutils.TableNameForStats(tName)}).Count(len(failures))
+	if err := job.alertSender.SendAlert(ctx, "warehouse-quality-check-failed",
+		alerta.SendAlertOpts{
+			Severity:    alerta.SeverityWarning,
+			Priority:    alerta.PriorityP2,
+			Environment: alerta.PROXYMODE,
+			Text:        strings.Join(failures, "\n"),
+			Tags: alerta.Tags{
+				"destID":      job.upload.DestinationID,
+				"destType":    job.upload.DestinationType,
+				"workspaceID": job.upload.WorkspaceID,
+				"namespace":   job.warehouse.Namespace,
+				"tableName":   tName,
+			},
+		},
+	); err != nil {
+		job.logger.Warnw("sending data quality alert", logfield.TableName, tName, logfield.Error, err.Error())
+	}
+	return failures
+}
 
-	switch job.warehouse.Type {
-	case whutils.S3Datalake, whutils.GCSDatalake, whutils.AzureDatalake:
-		return
+func (job *UploadJob) evaluateDataQuality(ctx context.Context, tName string, assertions quality.Assertions) ([]string, error) {
+	whClient, err := job.whManager.Connect(ctx, job.warehouse)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to warehouse: %w", err)
 	}
+	defer whClient.Close()
 
-	columnCountLimitMap := integrationsconfig.ColumnCountLimitMap(job.conf)
+	return quality.Evaluate(&whClient, job.warehouse.Type, job.warehouse.Namespace, tName, assertions, job.dataQualityFilters(tName), job.now())
+}
 
-	if columnCountLimit, ok = columnCountLimitMap[job.warehouse.Type]; !ok {
+// dataQualityFilters restricts the evaluation of the data quality assertions to the rows of the table loaded by the
+// upload, i.e. to the events it received, rather than scanning the whole table. For BigQuery, the partitions holding
+// them are filtered as well, by the partitioning the table was created with, which might require a partition filter.
+func (job *UploadJob) dataQualityFilters(tName string) []quality.Filter {
+	filters := []quality.Filter{{Column: "received_at", Since: job.upload.FirstEventAt, Until: job.upload.LastEventAt}}
+	if job.warehouse.Type != whutils.BQ {
+		return filters
+	}
+
+	// the rows of the upload were loaded after its first attempt
+	loadedSince := job.upload.FirstAttemptAt.UTC()
+	if loadedSince.IsZero() {
+		return filters
+	}
+	var partitionColumn, partitionType string
+	if tName != whutils.UsersTable && tName != whutils.IdentifiesTable { // always ingestion-time partitioned by day
+		partitionColumn = job.warehouse.GetStringDestinationConfig(job.conf, model.PartitionColumnSetting)
+		partitionType = strings.ToLower(job.warehouse.GetStringDestinationConfig(job.conf, model.PartitionTypeSetting))
+	}
+	switch partitionColumn {
+	case "received_at":
+		return filters
+	case "loaded_at":
+		return append(filters, quality.Filter{Column: partitionColumn, Since: loadedSince})
+	case "timestamp", "original_timestamp", "sent_at":
+		// event times can precede the time the events were received by far, so only their upper bound is known
+		return append(filters, quality.Filter{Column: partitionColumn, Until: job.upload.LastEventAt})
+	}
+
+	// ingestion-time partitions start at the beginning of their hour, day, month or year
+	partitionSince := time.Date(loadedSince.Year(), loadedSince.Month(), loadedSince.Day(), 0, 0, 0, 0, time.UTC)
+	switch partitionType {
+	case "hour":
+		partitionSince = loadedSince.Truncate(time.Hour)
+	case "month":
+		partitionSince = time.Date(loadedSince.Year(), loadedSince.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		partitionSince = time.Date(loadedSince.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return append(filters, quality.Filter{Column: "_PARTITIONTIME", Since: partitionSince})
+}
+
+// columnCountStat sent the column count for a table to statsd
+// skip sending for the warehouses not limiting the columns of the tables
+func (job *UploadJob) columnCountStat(tableName string) {
+	columnCountLimit := job.capabilities().MaxColumns
+	if columnCountLimit == 0 {
 		return
 	}
 
@@ -806,8 +1033,8 @@ func (job *UploadJob) columnCountStat(tableName string) {
 	job.gaugeStat(`warehouse_load_table_column_limit`, tags...).Gauge(columnCountLimit)
 }
 
-func (job *UploadJob) RefreshPartitions(loadFileStartID, loadFileEndID int64) error {
-	if !slices.Contains(whutils.TimeWindowDestinations, job.upload.DestinationType) {
+func (job *UploadJob) RefreshPartitions(ctx context.Context, loadFileStartID, loadFileEndID int64) error {
+	if !job.capabilities().TimeWindowLayout {
 		return nil
 	}
 
@@ -822,7 +1049,7 @@ func (job *UploadJob) RefreshPartitions(loadFileStartID, loadFileEndID int64) er
 
 	// Refresh partitions if exists
 	for tableName := range job.upload.UploadSchema {
-		loadFiles, err := job.GetLoadFilesMetadata(job.ctx, whutils.GetLoadFilesOptions{
+		loadFiles, err := job.GetLoadFilesMetadata(ctx, whutils.GetLoadFilesOptions{
 			Table:   tableName,
 			StartID: loadFileStartID,
 			EndID:   loadFileEndID,
@@ -832,7 +1059,7 @@ func (job *UploadJob) RefreshPartitions(loadFileStartID, loadFileEndID int64) er
 		}
 		batches := lo.Chunk(loadFiles, job.config.refreshPartitionBatchSize)
 		for _, batch := range batches {
-			if err = repository.RefreshPartitions(job.ctx, tableName, batch); err != nil {
+			if err = repository.RefreshPartitions(ctx, tableName, batch); err != nil {
 				return fmt.Errorf("refresh partitions: %w", err)
 			}
 		}
@@ -3,7 +3,6 @@ package router
 import (
 	"context"
 	"fmt"
-	"slices"
 
 	"github.com/rudderlabs/rudder-server/warehouse/logfield"
 
@@ -11,26 +10,26 @@ import (
 	whutils "github.com/rudderlabs/rudder-server/warehouse/utils"
 )
 
-func (job *UploadJob) generateLoadFiles(hasSchemaChanged bool) error {
+func (job *UploadJob) generateLoadFiles(ctx context.Context, hasSchemaChanged bool) error {
 	generateAll := hasSchemaChanged ||
-		slices.Contains(warehousesToAlwaysRegenerateAllLoadFilesOnResume, job.warehouse.Type) ||
+		job.capabilities().RegenerateLoadFilesOnResume ||
 		job.config.alwaysRegenerateAllLoadFiles
 
 	var startLoadFileID, endLoadFileID int64
 	var err error
 	if generateAll {
-		startLoadFileID, endLoadFileID, err = job.loadfile.ForceCreateLoadFiles(job.ctx, job.DTO())
+		startLoadFileID, endLoadFileID, err = job.loadfile.ForceCreateLoadFiles(ctx, job.DTO())
 	} else {
-		startLoadFileID, endLoadFileID, err = job.loadfile.CreateLoadFiles(job.ctx, job.DTO())
+		startLoadFileID, endLoadFileID, err = job.loadfile.CreateLoadFiles(ctx, job.DTO())
 	}
 	if err != nil {
 		return err
 	}
 
-	if err := job.setLoadFileIDs(startLoadFileID, endLoadFileID); err != nil {
+	if err := job.setLoadFileIDs(ctx, startLoadFileID, endLoadFileID); err != nil {
 		return err
 	}
-	if err := job.matchRowsInStagingAndLoadFiles(job.ctx); err != nil {
+	if err := job.matchRowsInStagingAndLoadFiles(ctx); err != nil {
 		return err
 	}
 
@@ -38,7 +37,7 @@ func (job *UploadJob) generateLoadFiles(hasSchemaChanged bool) error {
 	return nil
 }
 
-func (job *UploadJob) setLoadFileIDs(startLoadFileID, endLoadFileID int64) error {
+func (job *UploadJob) setLoadFileIDs(ctx context.Context, startLoadFileID, endLoadFileID int64) error {
 	if startLoadFileID > endLoadFileID {
 		return fmt.Errorf("end id less than start id: %d > %d", startLoadFileID, endLoadFileID)
 	}
@@ -47,7 +46,7 @@ func (job *UploadJob) setLoadFileIDs(startLoadFileID, endLoadFileID int64) error
 	job.upload.LoadFileEndID = endLoadFileID
 
 	return job.uploadsRepo.Update(
-		job.ctx,
+		ctx,
 		job.upload.ID,
 		[]repo.UpdateKeyValue{
 			repo.UploadFieldStartLoadFileID(startLoadFileID),
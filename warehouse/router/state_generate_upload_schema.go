@@ -1,14 +1,15 @@
 package router
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/rudderlabs/rudder-server/warehouse/internal/repo"
 )
 
-func (job *UploadJob) generateUploadSchema() error {
-	uploadSchema, err := job.schemaHandle.ConsolidateStagingFilesUsingLocalSchema(job.ctx, job.stagingFiles)
+func (job *UploadJob) generateUploadSchema(ctx context.Context) error {
+	uploadSchema, err := job.schemaHandle.ConsolidateStagingFilesUsingLocalSchema(ctx, job.stagingFiles)
 	if err != nil {
 		return fmt.Errorf("consolidate staging files schema using warehouse schema: %w", err)
 	}
@@ -19,7 +20,7 @@ func (job *UploadJob) generateUploadSchema() error {
 	}
 
 	err = job.uploadsRepo.Update(
-		job.ctx,
+		ctx,
 		job.upload.ID,
 		[]repo.UpdateKeyValue{
 			repo.UploadFieldSchema(marshalledSchema),
@@ -65,6 +65,9 @@ func TestState(t *testing.T) {
 			{current: "updating_table_uploads_counts_failed", next: stateTransitions[model.UpdatedTableUploadsCounts]},
 			{current: "creating_remote_schema_failed", next: stateTransitions[model.CreatedRemoteSchema]},
 			{current: "exporting_data_failed", next: stateTransitions[model.ExportedData]},
+
+			// waiting states
+			{current: model.AwaitingIngestion, next: stateTransitions[model.ExportedData]},
 		}
 		for index, tc := range testCases {
 			require.Equal(t, tc.next, nextState(tc.current), "test case %d", index)
@@ -1,16 +1,17 @@
 package router
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
 )
 
-func (job *UploadJob) updateTableUploadsCounts() error {
-	return job.tableUploadsRepo.WithTx(job.ctx, func(tx *sqlquerywrapper.Tx) error {
+func (job *UploadJob) updateTableUploadsCounts(ctx context.Context) error {
+	return job.tableUploadsRepo.WithTx(ctx, func(tx *sqlquerywrapper.Tx) error {
 		for tableName := range job.upload.UploadSchema {
 			if err := job.tableUploadsRepo.PopulateTotalEventsWithTx(
-				job.ctx,
+				ctx,
 				tx,
 				job.upload.ID,
 				tableName,
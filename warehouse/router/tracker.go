@@ -33,7 +33,7 @@ func (r *Router) CronTracker(ctx context.Context) error {
 		cronTrackerExecTimestamp.Gauge(execTime.Unix())
 
 		r.configSubscriberLock.RLock()
-		warehouses := append([]model.Warehouse{}, r.warehouses...)
+		warehouses := append([]model.Warehouse{}, r.ownedWarehouses()...)
 		r.configSubscriberLock.RUnlock()
 
 		for _, warehouse := range warehouses {
@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
@@ -13,11 +15,14 @@ import (
 	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/lib/pq"
+	"github.com/samber/lo"
 
 	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 
+	"github.com/rudderlabs/rudder-server/internal/shutdown"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	"github.com/rudderlabs/rudder-server/rruntime"
 	"github.com/rudderlabs/rudder-server/services/alerta"
@@ -27,10 +32,13 @@ import (
 	"github.com/rudderlabs/rudder-server/warehouse/encoding"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/lineage"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/loadfiles"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/repo"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/service"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/synctrigger"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/uploadwebhook"
 	"github.com/rudderlabs/rudder-server/warehouse/logfield"
 	"github.com/rudderlabs/rudder-server/warehouse/schema"
 	whutils "github.com/rudderlabs/rudder-server/warehouse/utils"
@@ -61,10 +69,16 @@ type UploadJobFactory struct {
 	logger               logger.Logger
 	statsFactory         stats.Stats
 	encodingFactory      *encoding.Factory
+	syncTrigger          *synctrigger.Trigger
+	lineage              *lineage.Emitter
+	uploadWebhook        *uploadwebhook.Notifier
+	endpoints            *Endpoints
 }
 
 type UploadJob struct {
-	ctx                  context.Context
+	ctx                  context.Context // outlives stopCtx by the drain timeout, to finish the stage in progress
+	stopCtx              context.Context // cancelled when the upload job should stop
+	cancelDrain          context.CancelFunc
 	db                   *sqlquerywrapper.DB
 	reporting            types.Reporting
 	destinationValidator validations.DestinationValidator
@@ -86,6 +100,11 @@ type UploadJob struct {
 	stagingFiles   []*model.StagingFile
 	stagingFileIDs []int64
 	alertSender    alerta.AlertSender
+	syncTrigger    *synctrigger.Trigger    // nil in tests
+	lineage        *lineage.Emitter        // nil unless lineage is enabled
+	uploadWebhook  *uploadwebhook.Notifier // nil in tests
+	endpoints      *Endpoints              // shared with the routers of the other destination types
+	endpoint       endpoint                // physical warehouse shared with other destinations, if any
 	now            func() time.Time
 
 	pendingTableUploads      []model.PendingTableUpload
@@ -105,6 +124,17 @@ type UploadJob struct {
 		maxParallelLoadsWorkspaceIDs        map[string]interface{}
 		columnsBatchSize                    int
 		longRunningUploadStatThresholdInMin time.Duration
+		stageTimeouts                       map[string]time.Duration // by in progress state, none if not set
+		ingestionPollInterval               time.Duration
+		ingestionTimeout                    time.Duration
+		stopOnPermissionError               bool
+		allowMultipleSourcesForJobsPickup   bool
+		uploadLease                         struct {
+			enabled       bool
+			owner         string
+			ttl           time.Duration
+			retryInterval time.Duration
+		}
 	}
 
 	errorHandler    ErrorHandler
@@ -126,21 +156,40 @@ type UploadJob struct {
 	}
 }
 
+// pendingTableUploadsRepo provides the progress of the tables of an upload, persisted by its attempts, and the
+// failures of its tables in earlier uploads
 type pendingTableUploadsRepo interface {
-	PendingTableUploads(ctx context.Context, namespace string, uploadID int64, destID string) ([]model.PendingTableUpload, error)
+	GetByUploadID(ctx context.Context, uploadID int64) ([]model.TableUpload, error)
+	PreviouslyFailedTableUploads(ctx context.Context, namespace string, uploadID int64, destID string, tableNames []string) ([]model.PendingTableUpload, error)
+}
+
+type pendingTableUploadsStore struct {
+	*repo.TableUploads
+	uploads *repo.Uploads
+}
+
+func (s pendingTableUploadsStore) PreviouslyFailedTableUploads(ctx context.Context, namespace string, uploadID int64, destID string, tableNames []string) ([]model.PendingTableUpload, error) {
+	return s.uploads.PreviouslyFailedTableUploads(ctx, namespace, uploadID, destID, tableNames)
 }
 
 var (
-	alwaysMarkExported                               = []string{whutils.DiscardsTable}
-	warehousesToAlwaysRegenerateAllLoadFilesOnResume = []string{whutils.SNOWFLAKE, whutils.BQ}
-	mergeSourceCategoryMap                           = map[string]struct{}{
+	alwaysMarkExported     = []string{whutils.DiscardsTable}
+	mergeSourceCategoryMap = map[string]struct{}{
 		"cloud":           {},
 		"singer-protocol": {},
 	}
 )
 
 func (f *UploadJobFactory) NewUploadJob(ctx context.Context, dto *model.UploadJob, whManager manager.Manager) *UploadJob {
-	ujCtx := whutils.CtxWithUploadID(ctx, dto.Upload.ID)
+	stopCtx := whutils.CtxWithUploadID(ctx, dto.Upload.ID)
+	if f.conf.GetBoolVar(true, "Warehouse.enableQueryTagging") {
+		stopCtx = whutils.CtxWithQueryTag(stopCtx, whutils.QueryTag{
+			UploadID:      dto.Upload.ID,
+			DestinationID: dto.Warehouse.Destination.ID,
+			Version:       f.conf.GetStringVar("", "Warehouse.serverVersion"),
+		})
+	}
+	ujCtx, cancelDrain := shutdown.Drain(stopCtx)
 
 	log := f.logger.With(
 		logfield.UploadJobID, dto.Upload.ID,
@@ -155,6 +204,8 @@ func (f *UploadJobFactory) NewUploadJob(ctx context.Context, dto *model.UploadJo
 
 	uj := &UploadJob{
 		ctx:                  ujCtx,
+		stopCtx:              stopCtx,
+		cancelDrain:          cancelDrain,
 		reporting:            f.reporting,
 		db:                   f.db,
 		loadfile:             f.loadFile,
@@ -181,9 +232,14 @@ func (f *UploadJobFactory) NewUploadJob(ctx context.Context, dto *model.UploadJo
 		stagingFiles:   dto.StagingFiles,
 		stagingFileIDs: repo.StagingFileIDs(dto.StagingFiles),
 
-		pendingTableUploadsRepo: repo.NewUploads(f.db),
+		pendingTableUploadsRepo: pendingTableUploadsStore{TableUploads: repo.NewTableUploads(f.db), uploads: repo.NewUploads(f.db)},
 		pendingTableUploads:     []model.PendingTableUpload{},
 
+		syncTrigger:   f.syncTrigger,
+		lineage:       f.lineage,
+		uploadWebhook: f.uploadWebhook,
+		endpoints:     f.endpoints,
+		endpoint:      endpointOf(f.conf, dto.Warehouse),
 		alertSender: alerta.NewClient(
 			f.conf.GetString("ALERTA_URL", "https://alerta.rudderstack.com/api/"),
 		),
@@ -201,9 +257,28 @@ func (f *UploadJobFactory) NewUploadJob(ctx context.Context, dto *model.UploadJo
 	uj.config.columnsBatchSize = f.conf.GetInt(fmt.Sprintf("Warehouse.%s.columnsBatchSize", whutils.WHDestNameMap[uj.upload.DestinationType]), 100)
 	uj.config.maxParallelLoadsWorkspaceIDs = f.conf.GetStringMap(fmt.Sprintf("Warehouse.%s.maxParallelLoadsWorkspaceIDs", whutils.WHDestNameMap[uj.upload.DestinationType]), nil)
 	uj.config.longRunningUploadStatThresholdInMin = f.conf.GetDurationVar(120, time.Minute, "Warehouse.longRunningUploadStatThreshold", "Warehouse.longRunningUploadStatThresholdInMin")
+	uj.config.stageTimeouts = make(map[string]time.Duration, len(stateTransitions))
+	for _, uploadState := range stateTransitions {
+		if uploadState.inProgress == "" {
+			continue
+		}
+		uj.config.stageTimeouts[uploadState.inProgress] = f.conf.GetDurationVar(0, time.Minute,
+			fmt.Sprintf("Warehouse.%s.stageTimeout.%s", whutils.WHDestNameMap[uj.upload.DestinationType], uploadState.inProgress),
+			fmt.Sprintf("Warehouse.stageTimeout.%s", uploadState.inProgress),
+			"Warehouse.stageTimeout",
+		)
+	}
 	uj.config.minUploadBackoff = f.conf.GetDurationVar(60, time.Second, "Warehouse.minUploadBackoff", "Warehouse.minUploadBackoffInS")
 	uj.config.maxUploadBackoff = f.conf.GetDurationVar(1800, time.Second, "Warehouse.maxUploadBackoff", "Warehouse.maxUploadBackoffInS")
 	uj.config.retryTimeWindow = f.conf.GetDurationVar(180, time.Minute, "Warehouse.retryTimeWindow", "Warehouse.retryTimeWindowInMins")
+	uj.config.ingestionPollInterval = f.conf.GetDurationVar(1, time.Minute, "Warehouse.ingestionPollInterval")
+	uj.config.ingestionTimeout = f.conf.GetDurationVar(1, time.Hour, "Warehouse.ingestionTimeout")
+	uj.config.stopOnPermissionError = f.conf.GetBoolVar(true, "Warehouse.stopOnPermissionError")
+	uj.config.allowMultipleSourcesForJobsPickup = f.conf.GetBoolVar(false, fmt.Sprintf("Warehouse.%s.allowMultipleSourcesForJobsPickup", whutils.WHDestNameMap[uj.upload.DestinationType]))
+	uj.config.uploadLease.enabled = f.conf.GetBoolVar(false, fmt.Sprintf("Warehouse.%s.uploadLease.enabled", whutils.WHDestNameMap[uj.upload.DestinationType]), "Warehouse.uploadLease.enabled")
+	uj.config.uploadLease.owner = f.conf.GetStringVar(misc.DefaultString("rudder-server").OnError(os.Hostname()), "Warehouse.uploadLease.owner")
+	uj.config.uploadLease.ttl = f.conf.GetDurationVar(10, time.Minute, "Warehouse.uploadLease.ttl")
+	uj.config.uploadLease.retryInterval = f.conf.GetDurationVar(1, time.Minute, "Warehouse.uploadLease.retryInterval")
 
 	uj.stats.uploadTime = uj.timerStat("upload_time")
 	uj.stats.userTablesLoadTime = uj.timerStat("user_tables_load_time")
@@ -247,6 +322,14 @@ func (job *UploadJob) trackLongRunningUpload() chan struct{} {
 }
 
 func (job *UploadJob) run() (err error) {
+	if job.cancelDrain != nil {
+		defer job.cancelDrain()
+	}
+	if job.stopping() {
+		// the upload job was queued when stopping, it is picked up again after restarting
+		return fmt.Errorf("upload job not started: %w", job.stopCtx.Err())
+	}
+
 	start := job.now()
 	ch := job.trackLongRunningUpload()
 	defer func() {
@@ -288,7 +371,20 @@ func (job *UploadJob) run() (err error) {
 	}
 	defer whManager.Cleanup(job.ctx)
 
-	if err = job.recovery.Recover(job.ctx, whManager, job.warehouse); err != nil {
+	leaseCtx, releaseLease, err := job.acquireLease(job.ctx, whManager)
+	if err != nil {
+		if errors.Is(err, errUploadLeaseHeld) {
+			return job.postponeForLease(err)
+		}
+		_, _ = job.setUploadError(err, InternalProcessingFailed)
+		return err
+	}
+	defer releaseLease()
+
+	if err = job.recovery.Recover(leaseCtx, whManager, job.warehouse); err != nil {
+		if leaseLost(leaseCtx) {
+			return job.postponeForLostLease(leaseCtx, releaseLease)
+		}
 		job.logger.Warnn("Error during recovery (dangling staging table cleanup)",
 			obskit.DestinationID(job.warehouse.Destination.ID),
 			obskit.DestinationType(job.warehouse.Destination.DestinationDefinition.Name),
@@ -302,8 +398,11 @@ func (job *UploadJob) run() (err error) {
 		return err
 	}
 
-	hasSchemaChanged, err := job.schemaHandle.SyncRemoteSchema(job.ctx, whManager, job.upload.ID)
+	hasSchemaChanged, err := job.schemaHandle.SyncRemoteSchema(leaseCtx, whManager, job.upload.ID)
 	if err != nil {
+		if leaseLost(leaseCtx) {
+			return job.postponeForLostLease(leaseCtx, releaseLease)
+		}
 		_, _ = job.setUploadError(err, FetchingRemoteSchemaFailed)
 		return err
 	}
@@ -324,6 +423,8 @@ func (job *UploadJob) run() (err error) {
 		nextUploadState = stateTransitions[model.GeneratedUploadSchema]
 	}
 
+	job.notifyUploadWebhook(uploadwebhook.Started, nil, nil)
+
 	for {
 		stateStartTime := job.now()
 		err = nil
@@ -333,45 +434,50 @@ func (job *UploadJob) run() (err error) {
 
 		targetStatus := nextUploadState.completed
 
+		stageCtx, endStage := job.beginStage(leaseCtx, nextUploadState.inProgress)
 		switch targetStatus {
 		case model.GeneratedUploadSchema:
 			newStatus = nextUploadState.failed
-			if err = job.generateUploadSchema(); err != nil {
+			if err = job.generateUploadSchema(stageCtx); err != nil {
 				break
 			}
 			newStatus = nextUploadState.completed
 
 		case model.CreatedTableUploads:
 			newStatus = nextUploadState.failed
-			if err = job.createTableUploads(); err != nil {
+			if err = job.createTableUploads(stageCtx); err != nil {
 				break
 			}
 			newStatus = nextUploadState.completed
 
 		case model.GeneratedLoadFiles:
 			newStatus = nextUploadState.failed
-			if err = job.generateLoadFiles(hasSchemaChanged); err != nil {
+			if err = job.generateLoadFiles(stageCtx, hasSchemaChanged); err != nil {
 				break
 			}
 			newStatus = nextUploadState.completed
 
 		case model.UpdatedTableUploadsCounts:
 			newStatus = nextUploadState.failed
-			if err = job.updateTableUploadsCounts(); err != nil {
+			if err = job.updateTableUploadsCounts(stageCtx); err != nil {
 				break
 			}
 			newStatus = nextUploadState.completed
 
 		case model.CreatedRemoteSchema:
 			newStatus = nextUploadState.failed
-			if err = job.createRemoteSchema(whManager); err != nil {
+			if err = job.createRemoteSchema(stageCtx, whManager); err != nil {
 				break
 			}
 			newStatus = nextUploadState.completed
 
 		case model.ExportedData:
 			newStatus = nextUploadState.failed
-			if err = job.exportData(); err != nil {
+			if err = job.exportData(stageCtx); err != nil {
+				if errors.Is(err, errIngestionPending) {
+					_ = endStage(nil)
+					return job.awaitIngestion(nextUploadState)
+				}
 				break
 			}
 			newStatus = nextUploadState.completed
@@ -380,11 +486,19 @@ func (job *UploadJob) run() (err error) {
 			// If unknown state, start again
 			newStatus = model.Waiting
 		}
+		err = endStage(err)
+
+		if leaseLost(leaseCtx) {
+			// another installation may be running the upload, which resumes from the last completed state once postponed
+			job.logger.Infon("Stopping upload job after losing its lease", logger.NewStringField("state", nextUploadState.inProgress))
+			return job.postponeForLostLease(leaseCtx, releaseLease)
+		}
 
 		if err != nil {
 			state, err := job.setUploadError(err, newStatus)
 			if err == nil && state == model.Aborted {
 				job.generateUploadAbortedMetrics()
+				job.cleanupPartiallyLoadedTables()
 			}
 			break
 		}
@@ -425,9 +539,19 @@ func (job *UploadJob) run() (err error) {
 
 		if newStatus == model.ExportedData {
 			_ = job.loadFilesRepo.DeleteByStagingFiles(job.ctx, job.stagingFileIDs)
+			exportedTables := job.exportedTables()
+			job.triggerSync(exportedTables)
+			job.emitLineage(exportedTables)
+			job.notifyUploadWebhook(uploadwebhook.ExportedData, nil, nil)
 			break
 		}
 
+		if job.stopping() {
+			// the completed state is a checkpoint, the upload resumes with the next state after restarting
+			job.logger.Infon("Stopping upload job after completing state", logger.NewStringField("state", newStatus))
+			return fmt.Errorf("upload job stopped after %s: %w", newStatus, job.stopCtx.Err())
+		}
+
 		nextUploadState = nextState(newStatus)
 	}
 
@@ -438,6 +562,173 @@ func (job *UploadJob) run() (err error) {
 	return nil
 }
 
+// capabilities returns the capabilities of the warehouse of the upload, those declared by its manager if any
+func (job *UploadJob) capabilities() model.Capabilities {
+	return manager.CapabilitiesOf(job.conf, job.warehouse.Type, job.whManager)
+}
+
+// beginStage bounds the state of the upload by its timeout, if any, by returning a context derived from ctx which is
+// cancelled once the timeout is exceeded, for the state to run with, so that e.g. a hung COPY doesn't hold the worker of
+// the destination until the upload gets aborted. The returned function ends the state, turning its error into
+// errStageTimedOut if it timed out, for the upload to be retried.
+func (job *UploadJob) beginStage(ctx context.Context, stage string) (context.Context, func(error) error) {
+	timeout := job.config.stageTimeouts[stage]
+	if timeout <= 0 {
+		return ctx, func(err error) error { return err }
+	}
+	stageCtx, cancel := context.WithTimeout(ctx, timeout)
+
+	return stageCtx, func(err error) error {
+		timedOut := errors.Is(stageCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+		cancel()
+		if err == nil || !timedOut {
+			return err
+		}
+		job.logger.Warnn("Upload state timed out",
+			logger.NewStringField("state", stage),
+			logger.NewDurationField("timeout", timeout),
+			obskit.Error(err),
+		)
+		job.counterStat("upload_stage_timed_out", whutils.Tag{Name: "stage", Value: stage}).Count(1)
+		return fmt.Errorf("%w: %s exceeded %s: %v", errStageTimedOut, stage, timeout, err)
+	}
+}
+
+// awaitIngestion leaves the upload waiting for the warehouse to confirm the ingestion of its data, which is checked
+// again after the ingestion poll interval without counting as a failed attempt
+func (job *UploadJob) awaitIngestion(uploadState *state) error {
+	if err := job.setUploadStatus(UploadStatusOpts{Status: uploadState.waiting}); err != nil {
+		return fmt.Errorf("setting upload status: %w", err)
+	}
+
+	if err := job.setNextRetryTime(job.now().Add(job.config.ingestionPollInterval)); err != nil {
+		return err
+	}
+
+	job.logger.Infon("Awaiting ingestion confirmation",
+		logger.NewIntField("uploadId", job.upload.ID),
+		logger.NewTimeField("nextRetryTime", job.upload.NextRetryTime),
+	)
+	return nil
+}
+
+// setNextRetryTime postpones the next attempt of the upload until the retry time, without counting an attempt
+func (job *UploadJob) setNextRetryTime(retryTime time.Time) error {
+	job.upload.NextRetryTime = retryTime
+	metadataJSON, err := json.Marshal(repo.ExtractUploadMetadata(job.upload))
+	if err != nil {
+		return fmt.Errorf("marshalling upload metadata: %w", err)
+	}
+	if err := job.uploadsRepo.Update(job.ctx, job.upload.ID, []repo.UpdateKeyValue{
+		repo.UploadFieldMetadata(metadataJSON),
+	}); err != nil {
+		return fmt.Errorf("updating upload metadata: %w", err)
+	}
+	return nil
+}
+
+// exportedTables returns the table uploads of the upload which exported their data, including the ones which failed
+// their data quality assertions after being loaded
+func (job *UploadJob) exportedTables() []model.TableUpload {
+	tableUploads, err := job.tableUploadsRepo.GetByUploadID(job.ctx, job.upload.ID)
+	if err != nil {
+		job.logger.Warnn("Getting exported table uploads", obskit.Error(err))
+	}
+	return lo.Filter(tableUploads, func(tableUpload model.TableUpload, _ int) bool {
+		return slices.Contains(tableUploadLoadedStatuses, tableUpload.Status)
+	})
+}
+
+// triggerSync triggers the downstream job configured for the destination, if any, with the tables exported by the upload
+func (job *UploadJob) triggerSync(exportedTables []model.TableUpload) {
+	if job.syncTrigger == nil || job.warehouse.GetStringDestinationConfig(job.conf, model.SyncTriggerSetting) == "" {
+		return
+	}
+	tables := lo.Map(exportedTables, func(tableUpload model.TableUpload, _ int) synctrigger.Table {
+		return synctrigger.Table{Name: tableUpload.TableName, RowCount: tableUpload.TotalEvents}
+	})
+	job.syncTrigger.Trigger(job.ctx, job.warehouse, synctrigger.Sync{
+		UploadID:        job.upload.ID,
+		WorkspaceID:     job.upload.WorkspaceID,
+		SourceID:        job.upload.SourceID,
+		DestinationID:   job.upload.DestinationID,
+		DestinationType: job.upload.DestinationType,
+		Namespace:       job.upload.Namespace,
+		Tables:          tables,
+		CompletedAt:     job.now(),
+	})
+}
+
+// notifyUploadWebhook notifies the webhook configured for the destination, if any, of the transition of the upload
+// along with the stats of its tables, limited to the given ones if any
+func (job *UploadJob) notifyUploadWebhook(event string, tableNames []string, uploadErr error) {
+	if !job.uploadWebhook.Enabled(job.warehouse, event) {
+		return
+	}
+	tableUploads, err := job.tableUploadsRepo.GetByUploadID(job.ctx, job.upload.ID)
+	if err != nil {
+		job.logger.Warnn("Getting table uploads for upload webhook", obskit.Error(err))
+	}
+	tables := make([]uploadwebhook.Table, 0, len(tableUploads))
+	for _, tableUpload := range tableUploads {
+		if len(tableNames) > 0 && !slices.Contains(tableNames, tableUpload.TableName) {
+			continue
+		}
+		tables = append(tables, uploadwebhook.Table{
+			Name:     tableUpload.TableName,
+			Status:   tableUpload.Status,
+			RowCount: tableUpload.TotalEvents,
+			Error:    tableUpload.Error,
+		})
+	}
+
+	e := uploadwebhook.Event{
+		Event:           event,
+		UploadID:        job.upload.ID,
+		WorkspaceID:     job.upload.WorkspaceID,
+		SourceID:        job.upload.SourceID,
+		DestinationID:   job.upload.DestinationID,
+		DestinationType: job.upload.DestinationType,
+		Namespace:       job.upload.Namespace,
+		Attempt:         job.upload.Attempts,
+		Tables:          tables,
+		OccurredAt:      job.now(),
+	}
+	if uploadErr != nil {
+		e.Error = uploadErr.Error()
+	}
+	job.uploadWebhook.Notify(job.ctx, job.warehouse, e)
+}
+
+// emitLineage emits the column-level lineage of the tables exported by the upload, if lineage is enabled
+func (job *UploadJob) emitLineage(exportedTables []model.TableUpload) {
+	if job.lineage == nil {
+		return
+	}
+	tables := lo.Map(exportedTables, func(tableUpload model.TableUpload, _ int) lineage.Table {
+		return lineage.Table{
+			Name:     tableUpload.TableName,
+			Schema:   job.upload.UploadSchema[tableUpload.TableName],
+			RowCount: tableUpload.TotalEvents,
+		}
+	})
+	job.lineage.Emit(job.ctx, lineage.Upload{
+		ID:              job.upload.ID,
+		WorkspaceID:     job.upload.WorkspaceID,
+		SourceID:        job.upload.SourceID,
+		DestinationID:   job.upload.DestinationID,
+		DestinationType: job.upload.DestinationType,
+		Namespace:       job.upload.Namespace,
+		Tables:          tables,
+		CompletedAt:     job.now(),
+	})
+}
+
+// stopping returns whether the upload job should stop, once the state in progress is completed
+func (job *UploadJob) stopping() bool {
+	return job.stopCtx != nil && job.stopCtx.Err() != nil
+}
+
 // CanAppend returns true if:
 // * the source is not an ETL source
 // * the source is not a replay source
@@ -483,12 +774,11 @@ func (job *UploadJob) getUploadFirstAttemptTime() (timing time.Time) {
 		FROM
 		  %s
 		WHERE
-		  id = %d;
+		  id = $1;
 `,
 		whutils.WarehouseUploadsTable,
-		job.upload.ID,
 	)
-	err := job.db.QueryRowContext(job.ctx, sqlStatement).Scan(&firstTiming)
+	err := job.db.QueryRowContext(job.ctx, sqlStatement, job.upload.ID).Scan(&firstTiming)
 	if err != nil {
 		return
 	}
@@ -609,6 +899,7 @@ func (job *UploadJob) setUploadError(statusError error, state string) (string, e
 			logfield.LoadFileType, job.upload.LoadFileType,
 			logfield.ErrorMapping, jobErrorType,
 			logfield.DestinationCredsValid, destCredentialsValidations,
+			logfield.MissingPrivilege, lo.FromPtr(job.upload.PermissionDenied).Privilege,
 		)
 	}()
 
@@ -632,7 +923,20 @@ func (job *UploadJob) setUploadError(statusError error, state string) (string, e
 	// exceeded.
 	uploadErrorAttempts := uploadErrors[state]["attempt"].(int)
 
-	if job.Aborted(uploadErrorAttempts, job.getUploadFirstAttemptTime()) {
+	// some errors mapped as permission errors are transient, e.g. connection errors, so only the errors with a known
+	// missing privilege stop the upload. Retrying those can't succeed until the privilege is granted, after which the
+	// destination config is expected to be saved again to resume it.
+	privilege := missingPrivilege(statusError)
+
+	switch {
+	case jobErrorType == model.PermissionError && privilege != "" && job.config.stopOnPermissionError:
+		job.upload.PermissionDenied = &model.PermissionDeniedInfo{
+			State:      state,
+			Privilege:  privilege,
+			RevisionID: job.warehouse.Destination.RevisionID,
+		}
+		state = model.PermissionDenied
+	case job.Aborted(uploadErrorAttempts, job.getUploadFirstAttemptTime()):
 		state = model.Aborted
 	}
 
@@ -741,6 +1045,7 @@ func (job *UploadJob) setUploadError(statusError error, state string) (string, e
 
 	job.upload.Status = state
 	job.upload.Error = serializedErr
+	job.upload.NextRetryTime = metadata.NextRetryTime
 
 	job.stats.uploadFailed.Count(1)
 
@@ -758,6 +1063,7 @@ func (job *UploadJob) setUploadError(statusError error, state string) (string, e
 		}
 
 		job.counterStat("upload_aborted", tags...).Count(1)
+		job.notifyUploadWebhook(uploadwebhook.Aborted, nil, statusError)
 	}
 
 	return state, err
@@ -787,14 +1093,18 @@ func (job *UploadJob) validateDestinationCredentials() (bool, error) {
 }
 
 func (job *UploadJob) GetLoadFilesMetadata(ctx context.Context, options whutils.GetLoadFilesOptions) (loadFiles []whutils.LoadFile, err error) {
+	args := []any{pq.Array(job.stagingFileIDs)}
+
 	var tableFilterSQL string
 	if options.Table != "" {
-		tableFilterSQL = fmt.Sprintf(` AND table_name='%s'`, options.Table)
+		args = append(args, options.Table)
+		tableFilterSQL = fmt.Sprintf(` AND table_name = $%d`, len(args))
 	}
 
 	var limitSQL string
 	if options.Limit != 0 {
-		limitSQL = fmt.Sprintf(`LIMIT %d`, options.Limit)
+		args = append(args, options.Limit)
+		limitSQL = fmt.Sprintf(`LIMIT $%d`, len(args))
 	}
 
 	sqlStatement := fmt.Sprintf(`
@@ -811,7 +1121,7 @@ func (job *UploadJob) GetLoadFilesMetadata(ctx context.Context, options whutils.
 		  FROM
 			%[1]s
 		  WHERE
-			staging_file_id IN (%[2]v) %[3]s
+			staging_file_id = ANY($1) %[2]s
 		)
 		SELECT
 		  location,
@@ -820,16 +1130,15 @@ func (job *UploadJob) GetLoadFilesMetadata(ctx context.Context, options whutils.
 		  row_numbered_load_files
 		WHERE
 		  row_number = 1
-		%[4]s;
+		%[3]s;
 `,
 		whutils.WarehouseLoadFilesTable,
-		misc.IntArrayToString(job.stagingFileIDs, ","),
 		tableFilterSQL,
 		limitSQL,
 	)
 
 	job.logger.Debugf(`Fetching loadFileLocations: %v`, sqlStatement)
-	rows, err := job.db.QueryContext(ctx, sqlStatement)
+	rows, err := job.db.QueryContext(ctx, sqlStatement, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query: %s\nfailed with Error : %w", sqlStatement, err)
 	}
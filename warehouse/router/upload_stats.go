@@ -2,6 +2,8 @@ package router
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/rudderlabs/rudder-go-kit/stats"
 
@@ -103,6 +105,21 @@ func (job *UploadJob) generateUploadAbortedMetrics() {
 	job.stats.numStagedEvents.Count(int(numStagedEvents))
 }
 
+// recordTableLoadAttempt records the duration of an attempt at loading a table, along with the rows and bytes loaded
+// if it succeeded, to identify the tables taking most of the time of the uploads
+func (job *UploadJob) recordTableLoadAttempt(tableName string, duration time.Duration, rowsLoaded, bytesLoaded int64, succeeded bool) {
+	tags := []warehouseutils.Tag{
+		{Name: "tableName", Value: warehouseutils.TableNameForStats(tableName)},
+		{Name: "succeeded", Value: strconv.FormatBool(succeeded)},
+	}
+	job.timerStat("table_load_duration", tags...).SendTiming(duration)
+	if !succeeded {
+		return
+	}
+	job.counterStat("table_load_rows", tags...).Count(int(rowsLoaded))
+	job.counterStat("table_load_bytes", tags...).Count(int(bytesLoaded))
+}
+
 func (job *UploadJob) recordTableLoad(tableName string, numEvents int64) {
 	capturedTableName := warehouseutils.TableNameForStats(tableName)
 
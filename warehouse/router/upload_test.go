@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/ory/dockertest/v3"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/require"
 
 	"github.com/rudderlabs/rudder-go-kit/config"
@@ -18,10 +20,14 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
 
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/internal/shutdown"
 	"github.com/rudderlabs/rudder-server/services/alerta"
+	migrator "github.com/rudderlabs/rudder-server/services/sql-migrator"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
 	sqlmiddleware "github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/redshift"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/repo"
 	"github.com/rudderlabs/rudder-server/warehouse/schema"
 	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
 )
@@ -294,7 +300,7 @@ func TestUploadJobT_UpdateTableSchema(t *testing.T) {
 						require.NoError(t, err)
 					}
 
-					err = job.UpdateTableSchema(testTable, warehouseutils.TableSchemaDiff{
+					err = job.UpdateTableSchema(context.Background(), testTable, warehouseutils.TableSchemaDiff{
 						AlteredColumnMap: model.TableSchema{
 							testColumn: testColumnType,
 						},
@@ -387,7 +393,7 @@ func TestUploadJobT_UpdateTableSchema(t *testing.T) {
 				alteredColumnsMap[fmt.Sprintf("%s_%d", testColumn, i)] = testColumnType
 			}
 
-			err = job.UpdateTableSchema(testTable, warehouseutils.TableSchemaDiff{
+			err = job.UpdateTableSchema(context.Background(), testTable, warehouseutils.TableSchemaDiff{
 				AlteredColumnMap: alteredColumnsMap,
 			})
 			require.Error(t, err)
@@ -463,14 +469,75 @@ func TestUploadJobT_Aborted(t *testing.T) {
 	}
 }
 
+func TestMissingPrivilege(t *testing.T) {
+	testCases := []struct {
+		name      string
+		err       error
+		privilege string
+	}{
+		{
+			name:      "postgres schema",
+			err:       errors.New(`creating table: pq: permission denied for schema rudder`),
+			privilege: "GRANT USAGE, CREATE ON SCHEMA rudder",
+		},
+		{
+			name:      "redshift database",
+			err:       errors.New(`creating schema: pq: permission denied for database "dev"`),
+			privilege: "GRANT CREATE ON DATABASE dev",
+		},
+		{
+			name:      "postgres table",
+			err:       errors.New(`loading table: pq: permission denied for table tracks`),
+			privilege: "GRANT SELECT, INSERT, UPDATE, DELETE ON TABLE tracks",
+		},
+		{
+			name:      "snowflake schema",
+			err:       errors.New(`SQL compilation error:\nSchema 'DB.RUDDER' already exists, but current role has no privileges on it.`),
+			privilege: "GRANT USAGE, CREATE TABLE ON SCHEMA DB.RUDDER",
+		},
+		{
+			name:      "bigquery",
+			err:       errors.New(`googleapi: Error 403: Access Denied: Dataset p:d: Permission bigquery.tables.create denied on dataset p:d`),
+			privilege: "bigquery.tables.create",
+		},
+		{
+			name:      "deltalake",
+			err:       errors.New(`SecurityException: User does not have permission CREATE on CATALOG`),
+			privilege: "GRANT CREATE ON CATALOG",
+		},
+		{
+			name:      "unknown",
+			err:       errors.New(`pq: password authentication failed for user "rudder"`),
+			privilege: "",
+		},
+		{
+			name:      "nil",
+			privilege: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.privilege, missingPrivilege(tc.err))
+		})
+	}
+}
+
 type mockPendingTablesRepo struct {
+	tableUploads  []model.TableUpload
 	pendingTables []model.PendingTableUpload
 	err           error
 	called        int
+	tablesToLoad  []string
 }
 
-func (m *mockPendingTablesRepo) PendingTableUploads(context.Context, string, int64, string) ([]model.PendingTableUpload, error) {
+func (m *mockPendingTablesRepo) GetByUploadID(context.Context, int64) ([]model.TableUpload, error) {
 	m.called++
+	return m.tableUploads, m.err
+}
+
+func (m *mockPendingTablesRepo) PreviouslyFailedTableUploads(_ context.Context, _ string, _ int64, _ string, tableNames []string) ([]model.PendingTableUpload, error) {
+	m.tablesToLoad = tableNames
 	return m.pendingTables, m.err
 }
 
@@ -532,48 +599,38 @@ func TestUploadJobT_TablesToSkip(t *testing.T) {
 				TableName:     "previously_failed_table_1",
 				Error:         "some error",
 			},
+		}
+		tableUploads := []model.TableUpload{
 			{
-				UploadID:      1,
-				DestinationID: destID,
-				Namespace:     namespace,
-				Status:        model.TableUploadUpdatingSchemaFailed,
-				TableName:     "previously_failed_table_2",
-				Error:         "",
+				UploadID:  5,
+				TableName: "previously_failed_table_1",
+				Status:    model.TableUploadWaiting,
 			},
 			{
-				UploadID:      1,
-				DestinationID: destID,
-				Namespace:     namespace,
-				Status:        model.TableUploadExported,
-				TableName:     "previously_succeeded_table_1",
-				Error:         "",
+				UploadID:  5,
+				TableName: "current_failed_table_1",
+				Status:    model.TableUploadExportingFailed,
+				Error:     "some error",
 			},
 			{
-				UploadID:      5,
-				DestinationID: destID,
-				Namespace:     namespace,
-				Status:        model.TableUploadExportingFailed,
-				TableName:     "current_failed_table_1",
-				Error:         "some error",
-			},
-			{
-				UploadID:      5,
-				DestinationID: destID,
-				Namespace:     namespace,
-				Status:        model.TableUploadExported,
-				TableName:     "current_succeeded_table_1",
-				Error:         "",
+				UploadID:  5,
+				TableName: "current_succeeded_table_1",
+				Status:    model.TableUploadExported,
 			},
 		}
+		ptRepo := &mockPendingTablesRepo{
+			tableUploads:  tableUploads,
+			pendingTables: pendingTables,
+		}
 
 		job := &UploadJob{
 			upload: model.Upload{
-				ID: 5,
-			},
-			pendingTableUploadsRepo: &mockPendingTablesRepo{
-				pendingTables: pendingTables,
+				ID:            5,
+				DestinationID: destID,
+				Namespace:     namespace,
 			},
-			ctx: context.Background(),
+			pendingTableUploadsRepo: ptRepo,
+			ctx:                     context.Background(),
 		}
 
 		previouslyFailedTables, currentJobSucceededTables, err := job.TablesToSkip()
@@ -582,8 +639,37 @@ func TestUploadJobT_TablesToSkip(t *testing.T) {
 			"previously_failed_table_1": pendingTables[0],
 		})
 		require.Equal(t, currentJobSucceededTables, map[string]model.PendingTableUpload{
-			"current_succeeded_table_1": pendingTables[4],
+			"current_succeeded_table_1": {
+				UploadID:      5,
+				DestinationID: destID,
+				Namespace:     namespace,
+				TableName:     "current_succeeded_table_1",
+				Status:        model.TableUploadExported,
+			},
 		})
+		require.Equal(t, []string{"previously_failed_table_1", "current_failed_table_1"}, ptRepo.tablesToLoad, "only the tables left to load are looked up in earlier uploads")
+	})
+
+	t.Run("all tables loaded", func(t *testing.T) {
+		t.Parallel()
+
+		ptRepo := &mockPendingTablesRepo{
+			tableUploads: []model.TableUpload{
+				{UploadID: 5, TableName: "tracks", Status: model.TableUploadExported},
+				{UploadID: 5, TableName: "pages", Status: model.TableUploadIngesting},
+			},
+		}
+		job := &UploadJob{
+			upload:                  model.Upload{ID: 5},
+			pendingTableUploadsRepo: ptRepo,
+			ctx:                     context.Background(),
+		}
+
+		previouslyFailedTables, currentJobSucceededTables, err := job.TablesToSkip()
+		require.NoError(t, err)
+		require.Empty(t, previouslyFailedTables)
+		require.Len(t, currentJobSucceededTables, 2)
+		require.Nil(t, ptRepo.tablesToLoad, "earlier uploads are not looked up")
 	})
 }
 
@@ -695,3 +781,440 @@ func TestUploadJob_CanAppend(t *testing.T) {
 		})
 	}
 }
+
+func TestUploadJob_Stopping(t *testing.T) {
+	ujf := &UploadJobFactory{
+		conf:         config.New(),
+		logger:       logger.NOP,
+		statsFactory: stats.NOP,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	job := ujf.NewUploadJob(shutdown.WithDrainTimeout(ctx, time.Hour), &model.UploadJob{
+		Upload: model.Upload{ID: 1},
+	}, nil)
+	require.False(t, job.stopping())
+
+	cancel()
+	require.True(t, job.stopping())
+	require.NoError(t, job.ctx.Err(), "the state in progress should be given the drain timeout to complete")
+
+	err := job.run()
+	require.ErrorIs(t, err, context.Canceled)
+	require.ErrorContains(t, err, "upload job not started", "queued upload jobs should not be started when stopping")
+	require.Error(t, job.ctx.Err())
+}
+
+func TestUploadJob_GetLoadFilesMetadata(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	pgResource, err := postgres.Setup(pool, t)
+	require.NoError(t, err)
+
+	err = (&migrator.Migrator{
+		Handle:          pgResource.DB,
+		MigrationsTable: "wh_schema_migrations",
+	}).Migrate("warehouse")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	db := sqlmiddleware.New(pgResource.DB)
+
+	tableNames := []string{"tracks", "pages", "order_completed'; DROP TABLE wh_uploads; --"}
+	var loadFiles []model.LoadFile
+	for stagingFileID := int64(1); stagingFileID <= 3; stagingFileID++ {
+		for _, tableName := range tableNames {
+			loadFiles = append(loadFiles, model.LoadFile{
+				StagingFileID:   stagingFileID,
+				Location:        fmt.Sprintf("s3://bucket/%d/%s.csv.gz", stagingFileID, tableName),
+				SourceID:        "source-id",
+				DestinationID:   "destination-id",
+				DestinationType: warehouseutils.POSTGRES,
+				TableName:       tableName,
+				TotalRows:       10,
+			})
+		}
+	}
+	require.NoError(t, repo.NewLoadFiles(db).Insert(ctx, loadFiles))
+
+	ujf := &UploadJobFactory{
+		conf:         config.New(),
+		logger:       logger.NOP,
+		statsFactory: stats.NOP,
+		db:           db,
+	}
+	job := ujf.NewUploadJob(ctx, &model.UploadJob{
+		Upload: model.Upload{
+			DestinationID:   "destination-id",
+			DestinationType: warehouseutils.POSTGRES,
+		},
+		StagingFiles: []*model.StagingFile{{ID: 1}, {ID: 2}},
+	}, nil)
+
+	t.Run("all tables", func(t *testing.T) {
+		metadata, err := job.GetLoadFilesMetadata(ctx, warehouseutils.GetLoadFilesOptions{})
+		require.NoError(t, err)
+		require.Len(t, metadata, 6)
+	})
+
+	t.Run("table", func(t *testing.T) {
+		metadata, err := job.GetLoadFilesMetadata(ctx, warehouseutils.GetLoadFilesOptions{Table: tableNames[2]})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{
+			fmt.Sprintf("s3://bucket/1/%s.csv.gz", tableNames[2]),
+			fmt.Sprintf("s3://bucket/2/%s.csv.gz", tableNames[2]),
+		}, lo.Map(metadata, func(loadFile warehouseutils.LoadFile, _ int) string {
+			return loadFile.Location
+		}))
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		metadata, err := job.GetLoadFilesMetadata(ctx, warehouseutils.GetLoadFilesOptions{Table: "tracks", Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, metadata, 1)
+	})
+}
+
+type loadRollbacker struct {
+	manager.Manager
+
+	deleted []string
+	err     error
+}
+
+func (l *loadRollbacker) DeleteLoadedRows(_ context.Context, tableName string, uploadID int64) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.deleted = append(l.deleted, fmt.Sprintf("%s:%d", tableName, uploadID))
+	return nil
+}
+
+func TestUploadJob_CleanupPartiallyLoadedTables(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	pgResource, err := postgres.Setup(pool, t)
+	require.NoError(t, err)
+
+	err = (&migrator.Migrator{
+		Handle:          pgResource.DB,
+		MigrationsTable: "wh_schema_migrations",
+	}).Migrate("warehouse")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	db := sqlmiddleware.New(pgResource.DB)
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	auditSchema := model.TableSchema{"id": "string", warehouseutils.LoadIDColumn: "string"}
+	uploadSchema := model.Schema{
+		"tracks":   auditSchema,
+		"pages":    auditSchema,
+		"screens":  auditSchema,
+		"users":    auditSchema,
+		"identify": {"id": "string"},
+	}
+	tableStatuses := map[string]string{
+		"tracks":   model.TableUploadExported,
+		"pages":    model.TableUploadExportingFailed,
+		"screens":  model.TableUploadWaiting,
+		"users":    model.TableUploadExported,
+		"identify": model.TableUploadExported,
+	}
+
+	setup := func(t *testing.T, cleanupOnAbort bool, whManager manager.Manager) *UploadJob {
+		t.Helper()
+
+		stagingID, err := repo.NewStagingFiles(db).Insert(ctx, &model.StagingFileWithSchema{})
+		require.NoError(t, err)
+		uploadID, err := repo.NewUploads(db).CreateWithStagingFiles(ctx, model.Upload{
+			SourceID:        "source-id",
+			DestinationID:   "destination-id",
+			DestinationType: warehouseutils.POSTGRES,
+			Status:          model.Aborted,
+		}, []*model.StagingFile{{ID: stagingID, SourceID: "source-id", DestinationID: "destination-id"}})
+		require.NoError(t, err)
+
+		tableUploads := repo.NewTableUploads(db)
+		require.NoError(t, tableUploads.Insert(ctx, uploadID, lo.Keys(tableStatuses)))
+		for tableName, status := range tableStatuses {
+			require.NoError(t, tableUploads.Set(ctx, uploadID, tableName, repo.TableUploadSetOptions{Status: &status}))
+		}
+
+		ujf := &UploadJobFactory{
+			conf:         config.New(),
+			logger:       logger.NOP,
+			statsFactory: stats.NOP,
+			db:           db,
+		}
+		job := ujf.NewUploadJob(ctx, &model.UploadJob{
+			Upload: model.Upload{
+				ID:              uploadID,
+				DestinationID:   "destination-id",
+				DestinationType: warehouseutils.POSTGRES,
+				UploadSchema:    uploadSchema,
+			},
+			Warehouse: model.Warehouse{
+				Type: warehouseutils.POSTGRES,
+				Destination: backendconfig.DestinationT{
+					Config: map[string]any{model.CleanupOnAbortSetting.String(): cleanupOnAbort},
+				},
+			},
+		}, whManager)
+		job.now = func() time.Time { return now }
+		return job
+	}
+
+	t.Run("succeeded", func(t *testing.T) {
+		rollbacker := &loadRollbacker{}
+		job := setup(t, true, rollbacker)
+		job.cleanupPartiallyLoadedTables()
+
+		require.ElementsMatch(t, []string{
+			fmt.Sprintf("tracks:%d", job.upload.ID),
+			fmt.Sprintf("pages:%d", job.upload.ID),
+		}, rollbacker.deleted, "only the loaded tables with the load audit columns are cleaned up, except users")
+
+		upload, err := repo.NewUploads(db).Get(ctx, job.upload.ID)
+		require.NoError(t, err)
+		require.NotNil(t, upload.Cleanup)
+		require.Equal(t, model.CleanupSucceeded, upload.Cleanup.Status)
+		require.ElementsMatch(t, []string{"tracks", "pages"}, upload.Cleanup.Tables)
+		require.Equal(t, now, upload.Cleanup.CompletedAt.UTC())
+	})
+	t.Run("failed", func(t *testing.T) {
+		job := setup(t, true, &loadRollbacker{err: errors.New("permission denied")})
+		job.cleanupPartiallyLoadedTables()
+
+		upload, err := repo.NewUploads(db).Get(ctx, job.upload.ID)
+		require.NoError(t, err)
+		require.NotNil(t, upload.Cleanup)
+		require.Equal(t, model.CleanupFailed, upload.Cleanup.Status)
+		require.Contains(t, upload.Cleanup.Error, "permission denied")
+		require.Empty(t, upload.Cleanup.Tables)
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		job := setup(t, true, struct{ manager.Manager }{})
+		job.cleanupPartiallyLoadedTables()
+
+		upload, err := repo.NewUploads(db).Get(ctx, job.upload.ID)
+		require.NoError(t, err)
+		require.NotNil(t, upload.Cleanup)
+		require.Equal(t, model.CleanupUnsupported, upload.Cleanup.Status)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		rollbacker := &loadRollbacker{}
+		job := setup(t, false, rollbacker)
+		job.cleanupPartiallyLoadedTables()
+		require.Empty(t, rollbacker.deleted)
+
+		upload, err := repo.NewUploads(db).Get(ctx, job.upload.ID)
+		require.NoError(t, err)
+		require.Nil(t, upload.Cleanup)
+	})
+}
+
+type leaser struct {
+	manager.Manager
+
+	mu       sync.Mutex
+	holder   string
+	acquires int
+	failures int // number of the next acquisitions failing, -1 for all of them
+	released bool
+}
+
+func (l *leaser) AcquireLease(_ context.Context, _, owner string, _ time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.failures != 0 {
+		if l.failures > 0 {
+			l.failures--
+		}
+		return false, errors.New("connection reset by peer")
+	}
+	if l.holder != "" && l.holder != owner {
+		return false, nil
+	}
+	l.holder = owner
+	l.acquires++
+	return true, nil
+}
+
+func (l *leaser) ReleaseLease(_ context.Context, _, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder == owner {
+		l.holder = ""
+		l.released = true
+	}
+	return nil
+}
+
+func TestUploadJob_AcquireLease(t *testing.T) {
+	newJob := func(t *testing.T, c *config.Config, whManager manager.Manager) *UploadJob {
+		t.Helper()
+		ujf := &UploadJobFactory{
+			conf:         c,
+			logger:       logger.NOP,
+			statsFactory: stats.NOP,
+		}
+		return ujf.NewUploadJob(context.Background(), &model.UploadJob{
+			Upload: model.Upload{ID: 1, DestinationType: warehouseutils.POSTGRES},
+			Warehouse: model.Warehouse{
+				Type:        warehouseutils.POSTGRES,
+				Namespace:   "namespace",
+				Source:      backendconfig.SourceT{ID: "source-id"},
+				Destination: backendconfig.DestinationT{ID: "destination-id"},
+			},
+		}, whManager)
+	}
+	enabled := func(owner string) *config.Config {
+		c := config.New()
+		c.Set("Warehouse.uploadLease.enabled", true)
+		c.Set("Warehouse.uploadLease.owner", owner)
+		c.Set("Warehouse.uploadLease.ttl", "30ms")
+		return c
+	}
+
+	t.Run("acquired, renewed and released", func(t *testing.T) {
+		l := &leaser{}
+		ctx, release, err := newJob(t, enabled("blue"), l).acquireLease(context.Background(), l)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return l.acquires > 1
+		}, time.Second, time.Millisecond, "the lease is renewed")
+		require.NoError(t, ctx.Err())
+
+		release()
+		require.True(t, l.released)
+		require.Empty(t, l.holder)
+	})
+	t.Run("taken over", func(t *testing.T) {
+		l := &leaser{}
+		job := newJob(t, enabled("blue"), l)
+		ctx, release, err := job.acquireLease(job.ctx, l)
+		require.NoError(t, err)
+		require.False(t, leaseLost(ctx))
+
+		l.mu.Lock()
+		l.holder = "green"
+		l.mu.Unlock()
+
+		require.Eventually(t, func() bool {
+			return ctx.Err() != nil
+		}, time.Second, time.Millisecond, "the lease context is cancelled once the lease is taken over")
+		require.True(t, leaseLost(ctx))
+		require.NoError(t, job.ctx.Err(), "the context of the job is left untouched")
+
+		release()
+		require.Equal(t, "green", l.holder)
+	})
+	t.Run("renewal failing transiently", func(t *testing.T) {
+		c := enabled("blue")
+		c.Set("Warehouse.uploadLease.ttl", "300ms")
+		l := &leaser{}
+		ctx, release, err := newJob(t, c, l).acquireLease(context.Background(), l)
+		require.NoError(t, err)
+		defer release()
+
+		l.mu.Lock()
+		l.failures = 1
+		l.mu.Unlock()
+
+		require.Eventually(t, func() bool {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return l.failures == 0 && l.acquires > 1
+		}, 2*time.Second, time.Millisecond, "the lease is renewed after the failure")
+		require.NoError(t, ctx.Err(), "the lease isn't lost before expiring")
+	})
+	t.Run("expired", func(t *testing.T) {
+		l := &leaser{}
+		ctx, release, err := newJob(t, enabled("blue"), l).acquireLease(context.Background(), l)
+		require.NoError(t, err)
+		defer release()
+
+		l.mu.Lock()
+		l.failures = -1
+		l.mu.Unlock()
+
+		require.Eventually(t, func() bool {
+			return ctx.Err() != nil
+		}, time.Second, time.Millisecond, "the lease context is cancelled once the lease expired without being renewed")
+		require.True(t, leaseLost(ctx))
+	})
+	t.Run("held by another installation", func(t *testing.T) {
+		l := &leaser{holder: "green"}
+		_, _, err := newJob(t, enabled("blue"), l).acquireLease(context.Background(), l)
+		require.ErrorIs(t, err, errUploadLeaseHeld)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		l := &leaser{holder: "green"}
+		ctx := context.Background()
+		leaseCtx, release, err := newJob(t, config.New(), l).acquireLease(ctx, l)
+		require.NoError(t, err)
+		require.Equal(t, ctx, leaseCtx)
+		release()
+		require.Zero(t, l.acquires)
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		_, release, err := newJob(t, enabled("blue"), struct{ manager.Manager }{}).acquireLease(context.Background(), struct{ manager.Manager }{})
+		require.NoError(t, err)
+		release()
+	})
+	t.Run("lease key", func(t *testing.T) {
+		require.Equal(t, "destination-id_namespace", newJob(t, config.New(), nil).leaseKey())
+
+		c := config.New()
+		c.Set("Warehouse.postgres.allowMultipleSourcesForJobsPickup", true)
+		require.Equal(t, "source-id_destination-id_namespace", newJob(t, c, nil).leaseKey())
+	})
+}
+
+func TestUploadJob_StageTimeout(t *testing.T) {
+	c := config.New()
+	c.Set("Warehouse.stageTimeout.exporting_data", "10ms")
+	ujf := &UploadJobFactory{
+		conf:         c,
+		logger:       logger.NOP,
+		statsFactory: stats.NOP,
+	}
+	job := ujf.NewUploadJob(context.Background(), &model.UploadJob{
+		Upload:    model.Upload{ID: 1, DestinationType: warehouseutils.POSTGRES},
+		Warehouse: model.Warehouse{Type: warehouseutils.POSTGRES},
+	}, nil)
+
+	t.Run("timed out", func(t *testing.T) {
+		ctx, endStage := job.beginStage(job.ctx, model.ExportingData)
+		<-ctx.Done()
+
+		err := endStage(fmt.Errorf("loading table: %w", ctx.Err()))
+		require.ErrorIs(t, err, errStageTimedOut)
+		require.NoError(t, job.ctx.Err(), "the context of the upload should be left untouched")
+		require.Equal(t, model.StageTimedOutError, (&ErrorHandler{}).MatchUploadJobErrorType(err))
+	})
+	t.Run("cancelled", func(t *testing.T) {
+		parentCtx, cancel := context.WithCancel(job.ctx)
+		ctx, endStage := job.beginStage(parentCtx, model.ExportingData)
+		cancel()
+		<-ctx.Done()
+
+		loadErr := fmt.Errorf("loading table: %w", ctx.Err())
+		require.Equal(t, loadErr, endStage(loadErr), "only timeouts of the state itself are turned into errStageTimedOut")
+	})
+	t.Run("completed in time", func(t *testing.T) {
+		_, endStage := job.beginStage(job.ctx, model.ExportingData)
+		loadErr := errors.New("loading table")
+		require.Equal(t, loadErr, endStage(loadErr))
+		require.NoError(t, endStage(nil))
+	})
+	t.Run("no timeout", func(t *testing.T) {
+		ctx, endStage := job.beginStage(job.ctx, model.GeneratingLoadFiles)
+		_, ok := ctx.Deadline()
+		require.False(t, ok)
+		require.NoError(t, endStage(nil))
+	})
+}
@@ -1,11 +1,13 @@
 package schema
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"reflect"
 	"regexp"
 	"slices"
+	"sort"
 	"sync"
 
 	jsoniter "github.com/json-iterator/go"
@@ -16,6 +18,7 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/logger"
 
+	integrationsconfig "github.com/rudderlabs/rudder-server/warehouse/integrations/config"
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/middleware/sqlquerywrapper"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/repo"
@@ -32,6 +35,31 @@ var deprecatedColumnsRegex = regexp.MustCompile(
 	`.*-deprecated-[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`,
 )
 
+// priorityColumns are the columns of the events kept before the others when the tables exceed the column count limit
+var priorityColumns = []string{
+	"id",
+	"anonymous_id",
+	"user_id",
+	"received_at",
+	"sent_at",
+	"timestamp",
+	"original_timestamp",
+	"event",
+	"event_text",
+	"channel",
+	"uuid_ts",
+	"loaded_at",
+	"context_source_id",
+	"context_destination_id",
+	"context_sources_job_run_id",
+	"context_sources_task_run_id",
+	whutils.LoadIDColumn,
+	whutils.LoadAuditTimeColumn,
+}
+
+// decimalDestinations are the destination types the decimal columns are supported for, see model.DecimalDataType
+var decimalDestinations = []string{whutils.POSTGRES, whutils.RS, whutils.SNOWFLAKE}
+
 type schemaRepo interface {
 	GetForNamespace(ctx context.Context, sourceID, destID, namespace string) (model.WHSchema, error)
 	Insert(ctx context.Context, whSchema *model.WHSchema) (int64, error)
@@ -41,6 +69,10 @@ type stagingFileRepo interface {
 	GetSchemasByIDs(ctx context.Context, ids []int64) ([]model.Schema, error)
 }
 
+type eventSchemaRepo interface {
+	Observe(ctx context.Context, sourceID, destinationID, namespace string, stagingFileID int64, schema model.Schema) ([]model.EventSchemaChange, error)
+}
+
 type fetchSchemaRepo interface {
 	FetchSchema(ctx context.Context) (model.Schema, model.Schema, error)
 }
@@ -49,10 +81,17 @@ type Schema struct {
 	warehouse                        model.Warehouse
 	schemaRepo                       schemaRepo
 	stagingFileRepo                  stagingFileRepo
+	eventSchemaRepo                  eventSchemaRepo // nil if the registry of the event schemas is disabled
 	log                              logger.Logger
+	statsFactory                     stats.Stats
 	stagingFilesSchemaPaginationSize int
 	skipDeepEqualSchemas             bool
 	enableIDResolution               bool
+	enableLoadAuditColumns           bool
+	columnCountLimit                 int
+	decimalColumns                   []string
+	tableLayout                      *whutils.TableLayout
+	overflowOversizedValues          bool
 
 	localSchema                     model.Schema
 	localSchemaMu                   sync.RWMutex
@@ -78,9 +117,23 @@ func New(
 		schemaRepo:                       repo.NewWHSchemas(db),
 		stagingFileRepo:                  repo.NewStagingFiles(db),
 		log:                              logger.Child("schema"),
+		statsFactory:                     statsFactory,
 		stagingFilesSchemaPaginationSize: conf.GetInt("Warehouse.stagingFilesSchemaPaginationSize", 100),
 		skipDeepEqualSchemas:             conf.GetBool("Warehouse.skipDeepEqualSchemas", false),
 		enableIDResolution:               conf.GetBool("Warehouse.enableIDResolution", false),
+		enableLoadAuditColumns:           conf.GetBool("Warehouse.enableLoadAuditColumns", false),
+		tableLayout:                      whutils.NewTableLayout(warehouse.Type, warehouse.Destination.Config),
+		overflowOversizedValues:          warehouse.GetStringDestinationConfig(conf, model.OversizedValuesSetting) == model.OversizedValuesOverflow,
+	}
+	// the spectrum tables of redshift are loaded with parquet load files, which have no decimal type
+	if slices.Contains(decimalDestinations, warehouse.Type) && !warehouse.GetBoolDestinationConfig(model.UseSpectrumSetting) {
+		s.decimalColumns = conf.GetStringSlice("Warehouse.decimalColumns", nil)
+	}
+	if conf.GetBool("Warehouse.eventSchemaRegistry.enabled", false) {
+		s.eventSchemaRepo = repo.NewEventSchemas(db)
+	}
+	if conf.GetBool("Warehouse.enableColumnOverflow", true) {
+		s.columnCountLimit = integrationsconfig.ColumnCountLimitMap(conf)[warehouse.Type]
 	}
 	s.stats.schemaSize = statsFactory.NewTaggedStat("warehouse_schema_size", stats.HistogramType, stats.Tags{
 		"module":        "warehouse",
@@ -94,11 +147,16 @@ func New(
 
 // ConsolidateStagingFilesUsingLocalSchema
 // 1. Fetches the schemas for the staging files
-// 2. Consolidates the staging files schemas
-// 3. Consolidates the consolidated schema with the warehouse schema
-// 4. Enhances the consolidated schema with discards schema
-// 5. Enhances the consolidated schema with ID resolution schema
-// 6. Returns the consolidated schema
+// 2. Consolidates the staging files schemas, recording them in the registry of the event schemas
+// 3. Merges the tables of the events as per the table layout of the destination
+// 4. Consolidates the consolidated schema with the warehouse schema
+// 5. Sets the decimal type for the new decimal columns
+// 6. Enhances the tables of the consolidated schema with the load audit columns
+// 7. Enhances the tables of the consolidated schema with the overflow column, if oversized values are moved into it
+// 8. Routes the columns beyond the column count limit into the overflow column
+// 9. Enhances the consolidated schema with discards schema
+// 10. Enhances the consolidated schema with ID resolution schema
+// 11. Returns the consolidated schema
 func (sh *Schema) ConsolidateStagingFilesUsingLocalSchema(ctx context.Context, stagingFiles []*model.StagingFile) (model.Schema, error) {
 	consolidatedSchema := model.Schema{}
 	batches := lo.Chunk(stagingFiles, sh.stagingFilesSchemaPaginationSize)
@@ -110,10 +168,16 @@ func (sh *Schema) ConsolidateStagingFilesUsingLocalSchema(ctx context.Context, s
 
 		consolidatedSchema = consolidateStagingSchemas(consolidatedSchema, schemas)
 	}
+	sh.observeEventSchemas(ctx, stagingFiles, consolidatedSchema)
+	consolidatedSchema = applyTableLayout(consolidatedSchema, sh.tableLayout)
 
 	sh.localSchemaMu.RLock()
 	consolidatedSchema = consolidateWarehouseSchema(consolidatedSchema, sh.localSchema)
+	consolidatedSchema = enhanceSchemaWithDecimals(consolidatedSchema, sh.localSchema, sh.decimalColumns, sh.warehouse.Type)
 	consolidatedSchema = overrideUsersWithIdentifiesSchema(consolidatedSchema, sh.warehouse.Type, sh.localSchema)
+	consolidatedSchema = enhanceSchemaWithLoadAudit(consolidatedSchema, sh.enableLoadAuditColumns, sh.warehouse.Type)
+	consolidatedSchema = enhanceSchemaWithOverflowColumn(consolidatedSchema, sh.overflowOversizedValues, sh.warehouse.Type)
+	consolidatedSchema = routeOverflowColumns(consolidatedSchema, sh.warehouse.Type, sh.localSchema, sh.columnCountLimit)
 	sh.localSchemaMu.RUnlock()
 
 	consolidatedSchema = enhanceDiscardsSchema(consolidatedSchema, sh.warehouse.Type)
@@ -145,6 +209,64 @@ func consolidateStagingSchemas(consolidatedSchema model.Schema, schemas []model.
 	return consolidatedSchema
 }
 
+// observeEventSchemas records the schema observed in the staging files in the registry of the event schemas, alerting
+// on the new properties of the tables observed before. Failing to record it doesn't fail the upload.
+func (sh *Schema) observeEventSchemas(ctx context.Context, stagingFiles []*model.StagingFile, observedSchema model.Schema) {
+	if sh.eventSchemaRepo == nil || len(stagingFiles) == 0 {
+		return
+	}
+	lastStagingFileID := slices.MaxFunc(stagingFiles, func(a, b *model.StagingFile) int {
+		return cmp.Compare(a.ID, b.ID)
+	}).ID
+
+	changes, err := sh.eventSchemaRepo.Observe(ctx, sh.warehouse.Source.ID, sh.warehouse.Destination.ID, sh.warehouse.Namespace, lastStagingFileID, observedSchema)
+	if err != nil {
+		sh.log.Warnw("recording event schemas",
+			logfield.SourceID, sh.warehouse.Source.ID,
+			logfield.DestinationID, sh.warehouse.Destination.ID,
+			logfield.Namespace, sh.warehouse.Namespace,
+			logfield.Error, err.Error(),
+		)
+		return
+	}
+	for _, change := range changes {
+		if len(change.NewProperties) == 0 {
+			continue
+		}
+		sh.log.Warnw("new unexpected properties observed for event",
+			logfield.SourceID, sh.warehouse.Source.ID,
+			logfield.DestinationID, sh.warehouse.Destination.ID,
+			logfield.WorkspaceID, sh.warehouse.WorkspaceID,
+			logfield.Namespace, sh.warehouse.Namespace,
+			logfield.TableName, change.TableName,
+			"version", change.Version,
+			"newProperties", change.NewProperties,
+		)
+		sh.statsFactory.NewTaggedStat("warehouse_event_schema_new_properties", stats.CountType, stats.Tags{
+			"module":        "warehouse",
+			"workspaceId":   sh.warehouse.WorkspaceID,
+			"destType":      sh.warehouse.Type,
+			"sourceId":      sh.warehouse.Source.ID,
+			"destinationId": sh.warehouse.Destination.ID,
+			"tableName":     whutils.TableNameForStats(change.TableName),
+		}).Count(len(change.NewProperties))
+	}
+}
+
+// applyTableLayout merges the tables of the events into the tables they get loaded into as per the table layout, in the
+// order of the tables so that the types of the columns the events disagree on are deterministic
+func applyTableLayout(consolidatedSchema model.Schema, tableLayout *whutils.TableLayout) model.Schema {
+	tableNames := lo.Keys(consolidatedSchema)
+	slices.Sort(tableNames)
+
+	layoutSchema := model.Schema{}
+	for _, tableName := range tableNames {
+		tableSchema := consolidatedSchema[tableName]
+		layoutSchema = consolidateStagingSchemas(layoutSchema, []model.Schema{{tableLayout.Table(tableName, tableSchema): tableSchema}})
+	}
+	return layoutSchema
+}
+
 // consolidateWarehouseSchema overwrites the consolidatedSchema with the schemaInWarehouse
 // Prefer the type of the schemaInWarehouse, If the type is text, prefer text
 func consolidateWarehouseSchema(consolidatedSchema, warehouseSchema model.Schema) model.Schema {
@@ -205,6 +327,118 @@ func overrideUsersWithIdentifiesSchema(consolidatedSchema model.Schema, warehous
 	return consolidatedSchema
 }
 
+// routeOverflowColumns caps the number of columns of the tables to the column count limit, instead of failing to add the
+// columns to the warehouse. The columns already in the warehouse are kept, then the overflow column, the priority
+// columns and the others in alphabetical order. The properties of the columns left out are loaded in the overflow column.
+func routeOverflowColumns(consolidatedSchema model.Schema, warehouseType string, warehouseSchema model.Schema, columnCountLimit int) model.Schema {
+	if columnCountLimit <= 0 {
+		return consolidatedSchema
+	}
+
+	overflowColumn := whutils.ToProviderCase(warehouseType, whutils.OverflowColumn)
+	rank := func(tableName, columnName string) int {
+		if _, ok := warehouseSchema[tableName][columnName]; ok {
+			return 0
+		}
+		if columnName == overflowColumn {
+			return 1
+		}
+		if slices.ContainsFunc(priorityColumns, func(c string) bool {
+			return whutils.ToProviderCase(warehouseType, c) == columnName
+		}) {
+			return 2
+		}
+		return 3
+	}
+
+	for tableName, tableSchema := range consolidatedSchema {
+		if len(tableSchema) <= columnCountLimit {
+			continue
+		}
+		if _, ok := tableSchema[overflowColumn]; !ok {
+			tableSchema[overflowColumn] = overflowColumnType(warehouseType)
+		}
+
+		columns := lo.Keys(tableSchema)
+		sort.Slice(columns, func(i, j int) bool {
+			ri, rj := rank(tableName, columns[i]), rank(tableName, columns[j])
+			if ri != rj {
+				return ri < rj
+			}
+			return columns[i] < columns[j]
+		})
+
+		cappedSchema := make(model.TableSchema, columnCountLimit)
+		for _, columnName := range columns {
+			// columns already in the warehouse are kept even beyond the limit
+			if len(cappedSchema) >= columnCountLimit && rank(tableName, columnName) > 0 {
+				break
+			}
+			cappedSchema[columnName] = tableSchema[columnName]
+		}
+		consolidatedSchema[tableName] = cappedSchema
+	}
+	return consolidatedSchema
+}
+
+// overflowColumnType returns the type of the overflow column, which is text for redshift where strings are limited to
+// 512 bytes
+func overflowColumnType(warehouseType string) string {
+	if warehouseType == whutils.RS {
+		return model.TextDataType
+	}
+	return model.StringDataType
+}
+
+// enhanceSchemaWithOverflowColumn adds the overflow column to the tables if the values beyond the max value size are
+// moved into it, see model.OversizedValuesOverflow
+func enhanceSchemaWithOverflowColumn(consolidatedSchema model.Schema, overflowOversizedValues bool, warehouseType string) model.Schema {
+	if !overflowOversizedValues {
+		return consolidatedSchema
+	}
+	overflowColumn := whutils.ToProviderCase(warehouseType, whutils.OverflowColumn)
+	for _, tableSchema := range consolidatedSchema {
+		if _, ok := tableSchema[overflowColumn]; !ok {
+			tableSchema[overflowColumn] = overflowColumnType(warehouseType)
+		}
+	}
+	return consolidatedSchema
+}
+
+// enhanceSchemaWithDecimals sets the decimal type for the numeric columns configured as decimal columns, e.g. revenue,
+// for their values to land as exact numerics instead of floats. Columns already in the warehouse keep their type.
+func enhanceSchemaWithDecimals(consolidatedSchema, warehouseSchema model.Schema, decimalColumns []string, warehouseType string) model.Schema {
+	for tableName, tableSchema := range consolidatedSchema {
+		for _, columnName := range decimalColumns {
+			columnName = whutils.ToProviderCase(warehouseType, columnName)
+			switch tableSchema[columnName] {
+			case model.IntDataType, model.BigIntDataType, model.FloatDataType:
+			default:
+				continue
+			}
+			if _, ok := warehouseSchema[tableName][columnName]; ok {
+				continue
+			}
+			tableSchema[columnName] = model.DecimalDataType
+		}
+	}
+	return consolidatedSchema
+}
+
+// enhanceSchemaWithLoadAudit adds the load audit columns to the tables of the events if enabled, for incremental models
+// to pick up the rows of the latest loads and to trace duplicate rows back to the loads they came with
+func enhanceSchemaWithLoadAudit(consolidatedSchema model.Schema, isLoadAuditEnabled bool, warehouseType string) model.Schema {
+	if !isLoadAuditEnabled {
+		return consolidatedSchema
+	}
+	for _, tableSchema := range consolidatedSchema {
+		for colName, colType := range whutils.LoadAuditSchema {
+			tableSchema[whutils.ToProviderCase(warehouseType, colName)] = colType
+		}
+	}
+	return consolidatedSchema
+}
+
 // enhanceDiscardsSchema adds the discards table to the schema
 // For bq, adds the loaded_at column to be segment compatible
 func enhanceDiscardsSchema(consolidatedSchema model.Schema, warehouseType string) model.Schema {
@@ -344,7 +578,7 @@ func (sh *Schema) GetLocalSchema(ctx context.Context) (model.Schema, error) {
 
 // FetchSchemaFromWarehouse
 // 1. Fetches schema from warehouse
-// 2. Removes deprecated columns from schema
+// 2. Removes deprecated columns and the upload leases table from schema
 // 3. Updates local warehouse schema and unrecognized schema instance
 func (sh *Schema) FetchSchemaFromWarehouse(ctx context.Context, repo fetchSchemaRepo) error {
 	warehouseSchema, unrecognizedWarehouseSchema, err := repo.FetchSchema(ctx)
@@ -355,6 +589,10 @@ func (sh *Schema) FetchSchemaFromWarehouse(ctx context.Context, repo fetchSchema
 	sh.removeDeprecatedColumns(warehouseSchema)
 	sh.removeDeprecatedColumns(unrecognizedWarehouseSchema)
 
+	leasesTable := whutils.ToProviderCase(sh.warehouse.Type, whutils.UploadLeasesTable)
+	delete(warehouseSchema, leasesTable)
+	delete(unrecognizedWarehouseSchema, leasesTable)
+
 	sh.schemaInWarehouseMu.Lock()
 	defer sh.schemaInWarehouseMu.Unlock()
 	sh.unrecognizedSchemaInWarehouseMu.Lock()
@@ -61,6 +61,21 @@ func (m *mockStagingFileRepo) GetSchemasByIDs(context.Context, []int64) ([]model
 	return m.schemas, nil
 }
 
+type mockEventSchemaRepo struct {
+	stagingFileID int64
+	schema        model.Schema
+	changes       []model.EventSchemaChange
+	err           error
+}
+
+func (m *mockEventSchemaRepo) Observe(_ context.Context, _, _, _ string, stagingFileID int64, schema model.Schema) ([]model.EventSchemaChange, error) {
+	m.stagingFileID, m.schema = stagingFileID, schema
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.changes, nil
+}
+
 type mockFetchSchemaRepo struct {
 	schemaInWarehouse             model.Schema
 	unrecognizedSchemaInWarehouse model.Schema
@@ -1743,6 +1758,239 @@ func TestSchema_ConsolidateStagingFilesUsingLocalSchema(t *testing.T) {
 	}
 }
 
+func TestRouteOverflowColumns(t *testing.T) {
+	warehouseSchema := model.Schema{
+		"tracks": model.TableSchema{
+			"id":      "string",
+			"zeta":    "string",
+			"context": "string",
+		},
+	}
+
+	t.Run("within limit", func(t *testing.T) {
+		consolidatedSchema := model.Schema{
+			"tracks": model.TableSchema{"id": "string", "alpha": "int"},
+		}
+		require.Equal(t, model.Schema{
+			"tracks": model.TableSchema{"id": "string", "alpha": "int"},
+		}, routeOverflowColumns(consolidatedSchema, warehouseutils.POSTGRES, warehouseSchema, 3))
+	})
+	t.Run("beyond limit", func(t *testing.T) {
+		consolidatedSchema := model.Schema{
+			"tracks": model.TableSchema{
+				"id":          "string",
+				"zeta":        "string",
+				"context":     "string",
+				"received_at": "datetime",
+				"alpha":       "int",
+				"beta":        "float",
+				"gamma":       "string",
+			},
+			"pages": model.TableSchema{"id": "string"},
+		}
+		require.Equal(t, model.Schema{
+			"tracks": model.TableSchema{
+				"id":              "string",
+				"zeta":            "string",
+				"context":         "string",
+				"rudder_overflow": "text",
+				"received_at":     "datetime",
+				"alpha":           "int",
+			},
+			"pages": model.TableSchema{"id": "string"},
+		}, routeOverflowColumns(consolidatedSchema, warehouseutils.RS, warehouseSchema, 6))
+	})
+	t.Run("warehouse columns beyond limit", func(t *testing.T) {
+		consolidatedSchema := model.Schema{
+			"TRACKS": model.TableSchema{"ID": "string", "ZETA": "string", "ALPHA": "int"},
+		}
+		require.Equal(t, model.Schema{
+			"TRACKS": model.TableSchema{"ID": "string", "ZETA": "string"},
+		}, routeOverflowColumns(consolidatedSchema, warehouseutils.SNOWFLAKE, model.Schema{
+			"TRACKS": model.TableSchema{"ID": "string", "ZETA": "string"},
+		}, 2))
+	})
+	t.Run("disabled", func(t *testing.T) {
+		consolidatedSchema := model.Schema{
+			"tracks": model.TableSchema{"id": "string", "alpha": "int"},
+		}
+		require.Equal(t, consolidatedSchema, routeOverflowColumns(consolidatedSchema, warehouseutils.POSTGRES, warehouseSchema, 0))
+	})
+}
+
+func TestEnhanceSchemaWithLoadAudit(t *testing.T) {
+	t.Run("enabled", func(t *testing.T) {
+		consolidatedSchema := model.Schema{
+			"TRACKS": model.TableSchema{"ID": "string"},
+			"PAGES":  model.TableSchema{"ID": "string", "NAME": "string"},
+		}
+		require.Equal(t, model.Schema{
+			"TRACKS": model.TableSchema{"ID": "string", "_RUDDER_LOAD_ID": "string", "_LOADED_AT": "datetime"},
+			"PAGES":  model.TableSchema{"ID": "string", "NAME": "string", "_RUDDER_LOAD_ID": "string", "_LOADED_AT": "datetime"},
+		}, enhanceSchemaWithLoadAudit(consolidatedSchema, true, warehouseutils.SNOWFLAKE))
+	})
+	t.Run("kept beyond the column count limit", func(t *testing.T) {
+		consolidatedSchema := enhanceSchemaWithLoadAudit(model.Schema{
+			"tracks": model.TableSchema{"id": "string", "alpha": "int", "beta": "int"},
+		}, true, warehouseutils.POSTGRES)
+		require.Equal(t, model.Schema{
+			"tracks": model.TableSchema{
+				"id":              "string",
+				"rudder_overflow": "string",
+				"_rudder_load_id": "string",
+				"_loaded_at":      "datetime",
+			},
+		}, routeOverflowColumns(consolidatedSchema, warehouseutils.POSTGRES, model.Schema{}, 4))
+	})
+	t.Run("disabled", func(t *testing.T) {
+		consolidatedSchema := model.Schema{
+			"tracks": model.TableSchema{"id": "string"},
+		}
+		require.Equal(t, model.Schema{
+			"tracks": model.TableSchema{"id": "string"},
+		}, enhanceSchemaWithLoadAudit(consolidatedSchema, false, warehouseutils.POSTGRES))
+	})
+}
+
+func TestEnhanceSchemaWithOverflowColumn(t *testing.T) {
+	t.Run("enabled", func(t *testing.T) {
+		consolidatedSchema := model.Schema{
+			"tracks":     model.TableSchema{"id": "string"},
+			"identifies": model.TableSchema{"id": "string", "rudder_overflow": "text"},
+		}
+		require.Equal(t, model.Schema{
+			"tracks":     model.TableSchema{"id": "string", "rudder_overflow": "text"},
+			"identifies": model.TableSchema{"id": "string", "rudder_overflow": "text"},
+		}, enhanceSchemaWithOverflowColumn(consolidatedSchema, true, warehouseutils.RS))
+	})
+	t.Run("disabled", func(t *testing.T) {
+		consolidatedSchema := model.Schema{
+			"tracks": model.TableSchema{"id": "string"},
+		}
+		require.Equal(t, model.Schema{
+			"tracks": model.TableSchema{"id": "string"},
+		}, enhanceSchemaWithOverflowColumn(consolidatedSchema, false, warehouseutils.POSTGRES))
+	})
+}
+
+func TestSchema_ObserveEventSchemas(t *testing.T) {
+	warehouse := model.Warehouse{
+		WorkspaceID: "workspace-1",
+		Source:      backendconfig.SourceT{ID: "source-1"},
+		Destination: backendconfig.DestinationT{ID: "destination-1"},
+		Namespace:   "namespace",
+		Type:        warehouseutils.POSTGRES,
+	}
+	stagingFiles := []*model.StagingFile{{ID: 3}, {ID: 7}, {ID: 5}}
+	observedSchema := model.Schema{"order_completed": model.TableSchema{"id": "string", "revenue": "float", "coupon": "string"}}
+
+	t.Run("new properties", func(t *testing.T) {
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+
+		eventSchemaRepo := &mockEventSchemaRepo{changes: []model.EventSchemaChange{
+			{TableName: "order_completed", Version: 2, NewProperties: []string{"coupon"}},
+			{TableName: "identifies", Version: 3, ChangedProperties: []string{"email"}},
+		}}
+		s := Schema{
+			warehouse:       warehouse,
+			log:             logger.NOP,
+			statsFactory:    statsStore,
+			eventSchemaRepo: eventSchemaRepo,
+		}
+		s.observeEventSchemas(context.Background(), stagingFiles, observedSchema)
+		require.EqualValues(t, 7, eventSchemaRepo.stagingFileID)
+		require.Equal(t, observedSchema, eventSchemaRepo.schema)
+
+		tags := stats.Tags{
+			"module":        "warehouse",
+			"workspaceId":   "workspace-1",
+			"destType":      warehouseutils.POSTGRES,
+			"sourceId":      "source-1",
+			"destinationId": "destination-1",
+		}
+		require.EqualValues(t, 1, statsStore.Get("warehouse_event_schema_new_properties", lo.Assign(tags, stats.Tags{"tableName": "order_completed"})).LastValue())
+		require.Nil(t, statsStore.Get("warehouse_event_schema_new_properties", lo.Assign(tags, stats.Tags{"tableName": "identifies"})))
+	})
+	t.Run("error", func(t *testing.T) {
+		s := Schema{
+			warehouse:       warehouse,
+			log:             logger.NOP,
+			eventSchemaRepo: &mockEventSchemaRepo{err: errors.New("test error")},
+		}
+		s.observeEventSchemas(context.Background(), stagingFiles, observedSchema)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		s := Schema{
+			warehouse: warehouse,
+			log:       logger.NOP,
+		}
+		s.observeEventSchemas(context.Background(), stagingFiles, observedSchema)
+	})
+}
+
+func TestApplyTableLayout(t *testing.T) {
+	consolidatedSchema := func() model.Schema {
+		return model.Schema{
+			"tracks":          model.TableSchema{"id": "string", "event": "string", "event_text": "string"},
+			"order_completed": model.TableSchema{"id": "string", "event": "string", "event_text": "string", "revenue": "float"},
+			"order_refunded":  model.TableSchema{"id": "string", "event": "string", "event_text": "string", "revenue": "int", "reason": "text"},
+			"product_viewed":  model.TableSchema{"id": "string", "event": "string", "event_text": "string", "reason": "string"},
+			"identifies":      model.TableSchema{"id": "string", "user_id": "string"},
+		}
+	}
+
+	t.Run("per event", func(t *testing.T) {
+		tableLayout := warehouseutils.NewTableLayout(warehouseutils.POSTGRES, map[string]interface{}{})
+		require.Equal(t, consolidatedSchema(), applyTableLayout(consolidatedSchema(), tableLayout))
+	})
+	t.Run("unified", func(t *testing.T) {
+		tableLayout := warehouseutils.NewTableLayout(warehouseutils.POSTGRES, map[string]interface{}{"tableLayout": "unified"})
+		require.Equal(t, model.Schema{
+			"tracks":     model.TableSchema{"id": "string", "event": "string", "event_text": "string"},
+			"events":     model.TableSchema{"id": "string", "event": "string", "event_text": "string", "revenue": "float", "reason": "text"},
+			"identifies": model.TableSchema{"id": "string", "user_id": "string"},
+		}, applyTableLayout(consolidatedSchema(), tableLayout))
+	})
+	t.Run("category", func(t *testing.T) {
+		tableLayout := warehouseutils.NewTableLayout(warehouseutils.POSTGRES, map[string]interface{}{
+			"tableLayout":     "category",
+			"eventCategories": map[string]interface{}{"orders": []interface{}{"order_completed", "order_refunded"}},
+		})
+		require.Equal(t, model.Schema{
+			"tracks":         model.TableSchema{"id": "string", "event": "string", "event_text": "string"},
+			"orders":         model.TableSchema{"id": "string", "event": "string", "event_text": "string", "revenue": "float", "reason": "text"},
+			"product_viewed": model.TableSchema{"id": "string", "event": "string", "event_text": "string", "reason": "string"},
+			"identifies":     model.TableSchema{"id": "string", "user_id": "string"},
+		}, applyTableLayout(consolidatedSchema(), tableLayout))
+	})
+}
+
+func TestEnhanceSchemaWithDecimals(t *testing.T) {
+	consolidatedSchema := model.Schema{
+		"order_completed": model.TableSchema{"id": "string", "revenue": "float", "price": "int", "currency": "string"},
+		"product_viewed":  model.TableSchema{"id": "string", "price": "float"},
+		"refunded":        model.TableSchema{"id": "string", "revenue": "string"},
+	}
+	warehouseSchema := model.Schema{
+		"product_viewed": model.TableSchema{"id": "string", "price": "float"},
+	}
+	require.Equal(t, model.Schema{
+		"order_completed": model.TableSchema{"id": "string", "revenue": "decimal", "price": "decimal", "currency": "string"},
+		"product_viewed":  model.TableSchema{"id": "string", "price": "float"},
+		"refunded":        model.TableSchema{"id": "string", "revenue": "string"},
+	}, enhanceSchemaWithDecimals(consolidatedSchema, warehouseSchema, []string{"revenue", "price", "currency"}, warehouseutils.POSTGRES))
+
+	t.Run("provider case", func(t *testing.T) {
+		consolidatedSchema := model.Schema{
+			"ORDER_COMPLETED": model.TableSchema{"ID": "string", "REVENUE": "float"},
+		}
+		require.Equal(t, model.Schema{
+			"ORDER_COMPLETED": model.TableSchema{"ID": "string", "REVENUE": "decimal"},
+		}, enhanceSchemaWithDecimals(consolidatedSchema, model.Schema{}, []string{"revenue"}, warehouseutils.SNOWFLAKE))
+	})
+}
+
 func TestSchema_SyncRemoteSchema(t *testing.T) {
 	sourceID := "test_source_id"
 	destinationID := "test_destination_id"
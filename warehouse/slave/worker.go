@@ -6,9 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"reflect"
 	"strconv"
 	"time"
+	"unicode/utf8"
 
 	jsoniter "github.com/json-iterator/go"
 
@@ -28,6 +30,7 @@ import (
 	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
 	"github.com/rudderlabs/rudder-server/warehouse/source"
+	"github.com/rudderlabs/rudder-server/warehouse/stagingfile"
 	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
 )
 
@@ -238,6 +241,20 @@ func (w *worker) processStagingFile(ctx context.Context, job payload) ([]uploadR
 	processingStart := jr.now()
 	sortedTableColumnMap := job.sortedColumnMapForAllTables()
 
+	// the load audit columns are set for all the rows, if they are in the schema of the upload
+	loadIDColumn := job.columnName(warehouseutils.LoadIDColumn)
+	loadAuditTimeColumn := job.columnName(warehouseutils.LoadAuditTimeColumn)
+	loadID := job.loadID()
+
+	tableLayout := warehouseutils.NewTableLayout(job.DestinationType, job.DestinationConfig)
+
+	// timestamps are normalized to UTC, unless their offsets are to be preserved for the destination
+	preserveTimestampOffset := job.DestinationConfig[model.TimestampOffsetSetting.String()] == model.TimestampOffsetPreserve
+
+	// values beyond the max value size are discarded, unless they are to be truncated or moved into the overflow column
+	oversizedValuesHandling, _ := job.DestinationConfig[model.OversizedValuesSetting.String()].(string)
+	overflowColumn := job.columnName(warehouseutils.OverflowColumn)
+
 	// default scanner buffer maxCapacity is 64K
 	// set it to higher value to avoid read stop on read size error
 	maxCapacity := w.config.maxStagingFileReadBufferCapacityInK.Load() * 1024
@@ -257,6 +274,9 @@ func (w *worker) processStagingFile(ctx context.Context, job payload) ([]uploadR
 		}
 
 		lineBytes := bufScanner.Bytes()
+		if stagingfile.IsFooter(lineBytes) {
+			continue
+		}
 		lineBytesCounter += len(lineBytes)
 
 		var (
@@ -269,7 +289,7 @@ func (w *worker) processStagingFile(ctx context.Context, job payload) ([]uploadR
 			continue
 		}
 
-		tableName := batchRouterEvent.Metadata.Table
+		tableName := tableLayout.Table(batchRouterEvent.Metadata.Table, batchRouterEvent.Metadata.Columns)
 		columnData := batchRouterEvent.Data
 
 		if job.DestinationType == warehouseutils.S3Datalake && len(sortedTableColumnMap[tableName]) > columnCountLimitMap[warehouseutils.S3Datalake] {
@@ -286,7 +306,21 @@ func (w *worker) processStagingFile(ctx context.Context, job payload) ([]uploadR
 
 		eventLoader := w.encodingFactory.NewEventLoader(writer, job.LoadFileType, job.DestinationType)
 
+		var oversizedValues map[string]any
+		if _, ok := job.UploadSchema[tableName][overflowColumn]; ok && oversizedValuesHandling == model.OversizedValuesOverflow {
+			oversizedValues = w.oversizedValues(job.DestinationType, &batchRouterEvent, sortedTableColumnMap[tableName])
+		}
+
 		for _, columnName := range sortedTableColumnMap[tableName] {
+			switch columnName {
+			case loadIDColumn:
+				eventLoader.AddColumn(columnName, job.UploadSchema[tableName][columnName], loadID)
+				continue
+			case loadAuditTimeColumn:
+				timestampFormat := eventLoader.GetLoadTimeFormat(job.columnName(encoding.UUIDTsColumn))
+				eventLoader.AddColumn(columnName, job.UploadSchema[tableName][columnName], jr.uuidTS.Format(timestampFormat))
+				continue
+			}
 			if eventLoader.IsLoadTimeColumn(columnName) {
 				timestampFormat := eventLoader.GetLoadTimeFormat(columnName)
 				eventLoader.AddColumn(job.columnName(columnName), job.UploadSchema[tableName][columnName], jr.uuidTS.Format(timestampFormat))
@@ -294,6 +328,15 @@ func (w *worker) processStagingFile(ctx context.Context, job payload) ([]uploadR
 			}
 
 			columnInfo, ok := batchRouterEvent.GetColumnInfo(columnName)
+			if !ok && columnName == overflowColumn {
+				overflow, err := overflowProperties(&batchRouterEvent, job.UploadSchema[tableName], oversizedValues)
+				if err != nil || overflow == "" {
+					eventLoader.AddEmptyColumn(columnName)
+					continue
+				}
+				eventLoader.AddColumn(columnName, job.UploadSchema[tableName][columnName], overflow)
+				continue
+			}
 			if !ok {
 				eventLoader.AddEmptyColumn(columnName)
 				continue
@@ -344,6 +387,21 @@ func (w *worker) processStagingFile(ctx context.Context, job payload) ([]uploadR
 
 			violatedConstraints := w.constraintsManager.ViolatedConstraints(job.DestinationType, &batchRouterEvent, columnName)
 
+			if violatedConstraints.MaxValueSize > 0 {
+				switch oversizedValuesHandling {
+				case model.OversizedValuesTruncate:
+					if value, ok := columnVal.(string); ok {
+						eventLoader.AddColumn(columnName, job.UploadSchema[tableName][columnName], truncateValue(value, violatedConstraints.MaxValueSize))
+						continue
+					}
+				case model.OversizedValuesOverflow:
+					if _, ok := oversizedValues[columnName]; ok {
+						eventLoader.AddEmptyColumn(columnName)
+						continue
+					}
+				}
+			}
+
 			if ok && ((columnType != dataTypeInSchema) || (violatedConstraints.IsViolated)) {
 				newColumnVal, convError := handleSchemaChange(
 					dataTypeInSchema,
@@ -384,6 +442,10 @@ func (w *worker) processStagingFile(ctx context.Context, job payload) ([]uploadR
 				columnVal = newColumnVal
 			}
 
+			if !preserveTimestampOffset && job.UploadSchema[tableName][columnName] == model.DateTimeDataType {
+				columnVal = utcTimestamp(columnVal)
+			}
+
 			// Special handling for JSON arrays
 			// TODO: Will this work for both BQ and RS?
 			if reflect.TypeOf(columnVal) == reflect.TypeOf(interfaceSliceSample) {
@@ -522,6 +584,75 @@ func (w *worker) destinationFromSlaveConnectionMap(destinationId, sourceId strin
 	return conn, nil
 }
 
+// oversizedValues returns the values of the columns of the event beyond the max value size of the destination type
+func (w *worker) oversizedValues(destType string, event *types.BatchRouterEvent, columns []string) map[string]any {
+	oversized := make(map[string]any)
+	for _, columnName := range columns {
+		if violation := w.constraintsManager.ViolatedConstraints(destType, event, columnName); violation.MaxValueSize > 0 {
+			oversized[columnName] = event.Data[columnName]
+		}
+	}
+	return oversized
+}
+
+// truncatedValueMarker ends the values truncated to the max value size
+const truncatedValueMarker = "...[truncated]"
+
+// truncateValue truncates the value to the limit, ending it with the marker and without splitting its characters
+func truncateValue(value string, limit int) string {
+	marker := truncatedValueMarker
+	if limit <= len(marker) {
+		marker = ""
+	}
+	size := limit - len(marker)
+	for size > 0 && !utf8.RuneStart(value[size]) {
+		size--
+	}
+	return value[:size] + marker
+}
+
+// overflowProperties returns the properties of the event left out of the schema of its table, as they are beyond the
+// column count limit of the warehouse, along with the oversized values moved into the overflow column, as a JSON
+// object. It is empty when there are none.
+func overflowProperties(event *types.BatchRouterEvent, tableSchema model.TableSchema, oversizedValues map[string]any) (string, error) {
+	overflow := maps.Clone(oversizedValues)
+	if overflow == nil {
+		overflow = make(map[string]interface{})
+	}
+	for columnName := range event.Metadata.Columns {
+		if _, ok := tableSchema[columnName]; ok {
+			continue
+		}
+		if value, ok := event.Data[columnName]; ok && value != nil {
+			overflow[columnName] = value
+		}
+	}
+	if len(overflow) == 0 {
+		return "", nil
+	}
+	marshalledOverflow, err := json.Marshal(overflow)
+	if err != nil {
+		return "", fmt.Errorf("marshalling overflow properties: %w", err)
+	}
+	return string(marshalledOverflow), nil
+}
+
+// utcTimestamp converts the timestamp to UTC if it has a non-zero offset, returning the value as is otherwise
+func utcTimestamp(value any) any {
+	timestamp, ok := value.(string)
+	if !ok {
+		return value
+	}
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return value
+	}
+	if _, offset := t.Zone(); offset == 0 {
+		return value
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
 // handleSchemaChange checks if the existing column type is compatible with the new column type
 func handleSchemaChange(existingDataType, currentDataType model.SchemaType, value any) (any, error) {
 	var (
@@ -559,6 +690,16 @@ func handleSchemaChange(existingDataType, currentDataType model.SchemaType, valu
 		} else {
 			newColumnVal = fmt.Sprintf(`"%v"`, value)
 		}
+	} else if existingDataType == model.DecimalDataType && (currentDataType == model.IntDataType || currentDataType == model.BigIntDataType || currentDataType == model.FloatDataType) {
+		// plain decimal notation, for the exact value to land in the decimal column without the exponent of floats
+		switch v := value.(type) {
+		case int:
+			newColumnVal = strconv.Itoa(v)
+		case float64:
+			newColumnVal = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			err = fmt.Errorf("incompatible schema conversion from %v to %v", existingDataType, currentDataType)
+		}
 	} else {
 		err = fmt.Errorf("incompatible schema conversion from %v to %v", existingDataType, currentDataType)
 	}
@@ -27,6 +27,7 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 
+	"github.com/rudderlabs/rudder-server/utils/filemanagerutil"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 	"github.com/rudderlabs/rudder-server/warehouse/encoding"
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
@@ -67,6 +68,11 @@ func (p *payload) columnName(columnName string) string {
 	return warehouseutils.ToProviderCase(p.DestinationType, columnName)
 }
 
+// loadID identifies the load files generated for the upload, set in the load audit column of the rows
+func (p *payload) loadID() string {
+	return fmt.Sprintf("%d-%s", p.UploadID, p.UniqueLoadGenID)
+}
+
 // sortedColumnMapForAllTables Sort columns per table to maintain same order in load file (needed in case of csv load file)
 func (p *payload) sortedColumnMapForAllTables() map[string][]string {
 	return lo.MapValues(p.UploadSchema, func(value model.TableSchema, key string) []string {
@@ -78,7 +84,7 @@ func (p *payload) sortedColumnMapForAllTables() map[string][]string {
 
 func (p *payload) fileManager(config interface{}, useRudderStorage bool) (filemanager.FileManager, error) {
 	storageProvider := warehouseutils.ObjectStorageType(p.DestinationType, config, useRudderStorage)
-	fileManager, err := filemanager.New(&filemanager.Settings{
+	fileManager, err := filemanagerutil.FailoverFactory(filemanager.New)(&filemanager.Settings{
 		Provider: storageProvider,
 		Config: misc.GetObjectStorageConfig(misc.ObjectStorageOptsT{
 			Provider:                    storageProvider,
@@ -5,8 +5,10 @@ import (
 	"context"
 	"encoding/csv"
 	"errors"
+	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/ory/dockertest/v3"
@@ -33,6 +35,7 @@ import (
 	"github.com/rudderlabs/rudder-server/warehouse/multitenant"
 	"github.com/rudderlabs/rudder-server/warehouse/source"
 	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+	"github.com/rudderlabs/rudder-server/warehouse/utils/types"
 )
 
 func TestSlaveWorker(t *testing.T) {
@@ -305,6 +308,129 @@ func TestSlaveWorker(t *testing.T) {
 			<-claimedJobDone
 		})
 
+		t.Run("load audit columns", func(t *testing.T) {
+			subscribeCh := make(chan *notifier.ClaimJobResponse)
+			defer close(subscribeCh)
+
+			slaveNotifier := &mockSlaveNotifier{
+				subscribeCh: subscribeCh,
+			}
+
+			tenantManager := multitenant.New(config.New(), backendconfig.DefaultBackendConfig)
+
+			slaveWorker := newWorker(
+				config.New(),
+				logger.NOP,
+				stats.NOP,
+				slaveNotifier,
+				bcm.New(config.New(), nil, tenantManager, logger.NOP, stats.NOP),
+				constraints.New(config.New()),
+				ef,
+				workerIdx,
+			)
+
+			auditSchemaMap := lo.MapValues(schemaMap, func(tableSchema model.TableSchema, _ string) model.TableSchema {
+				return lo.Assign(tableSchema, warehouseutils.LoadAuditSchema)
+			})
+
+			p := payload{
+				UploadID:                     1,
+				StagingFileID:                1,
+				StagingFileLocation:          jobLocation,
+				UploadSchema:                 auditSchemaMap,
+				WorkspaceID:                  workspaceID,
+				SourceID:                     sourceID,
+				SourceName:                   sourceName,
+				DestinationID:                destinationID,
+				DestinationName:              destinationName,
+				DestinationType:              warehouseutils.POSTGRES,
+				DestinationNamespace:         namespace,
+				DestinationRevisionID:        uuid.New().String(),
+				StagingDestinationRevisionID: uuid.New().String(),
+				DestinationConfig:            destConf,
+				StagingDestinationConfig:     map[string]interface{}{},
+				UniqueLoadGenID:              uuid.New().String(),
+				RudderStoragePrefix:          misc.GetRudderObjectStoragePrefix(),
+				LoadFileType:                 "csv",
+			}
+
+			payloadJson, err := json.Marshal(p)
+			require.NoError(t, err)
+
+			claim := &notifier.ClaimJob{
+				Job: &notifier.Job{
+					ID:                  1,
+					BatchID:             uuid.New().String(),
+					Payload:             payloadJson,
+					Status:              model.Waiting,
+					WorkspaceIdentifier: "test_workspace",
+					Type:                notifier.JobTypeUpload,
+				},
+			}
+
+			claimedJobDone := make(chan struct{})
+			go func() {
+				defer close(claimedJobDone)
+
+				slaveWorker.processClaimedUploadJob(ctx, claim)
+			}()
+
+			response := <-subscribeCh
+			require.NoError(t, response.Err)
+
+			var uploadPayload payload
+			err = json.Unmarshal(response.Payload, &uploadPayload)
+			require.NoError(t, err)
+			require.NotEmpty(t, uploadPayload.Output)
+
+			sortedColMap := p.sortedColumnMapForAllTables()
+
+			for _, output := range uploadPayload.Output {
+				fm, err := filemanager.New(&filemanager.Settings{
+					Provider: "MINIO",
+					Config: misc.GetObjectStorageConfig(misc.ObjectStorageOptsT{
+						Provider: "MINIO",
+						Config:   destConf,
+					}),
+				})
+				require.NoError(t, err)
+
+				objKey, err := fm.GetObjectNameFromLocation(output.Location)
+				require.NoError(t, err)
+
+				tmpFile, err := os.CreateTemp("", "load.csv")
+				require.NoError(t, err)
+
+				err = fm.Download(ctx, tmpFile, objKey)
+				require.NoError(t, err)
+
+				tmpFile, err = os.Open(tmpFile.Name())
+				require.NoError(t, err)
+
+				gzReader, err := gzip.NewReader(tmpFile)
+				require.NoError(t, err)
+
+				reader := csv.NewReader(gzReader)
+				reader.Comma = ','
+
+				sortedCols := sortedColMap[output.TableName]
+				loadIDColIdx := lo.IndexOf(sortedCols, warehouseutils.LoadIDColumn)
+				loadedAtColIdx := lo.IndexOf(sortedCols, warehouseutils.LoadAuditTimeColumn)
+
+				for i := 0; i < output.TotalRows; i++ {
+					row, err := reader.Read()
+					require.NoError(t, err)
+
+					require.Equal(t, fmt.Sprintf("1-%s", p.UniqueLoadGenID), row[loadIDColIdx])
+					_, err = time.Parse(misc.RFC3339Milli, row[loadedAtColIdx])
+					require.NoError(t, err)
+				}
+				require.NoError(t, gzReader.Close())
+			}
+
+			<-claimedJobDone
+		})
+
 		t.Run("schema limit exceeded", func(t *testing.T) {
 			subscribeCh := make(chan *notifier.ClaimJobResponse)
 			defer close(subscribeCh)
@@ -954,6 +1080,27 @@ func TestHandleSchemaChange(t *testing.T) {
 			value:            `{"json":true}`,
 			convError:        errors.New("incompatible schema conversion from datetime to json"),
 		},
+		{
+			name:             "existing datatype is decimal, new datatype is float",
+			existingDatatype: "decimal",
+			currentDataType:  "float",
+			value:            1234567890.12,
+			newColumnVal:     "1234567890.12",
+		},
+		{
+			name:             "existing datatype is decimal, new datatype is int",
+			existingDatatype: "decimal",
+			currentDataType:  "int",
+			value:            100000000000000000,
+			newColumnVal:     "100000000000000000",
+		},
+		{
+			name:             "existing datatype is decimal, new datatype is string",
+			existingDatatype: "decimal",
+			currentDataType:  "string",
+			value:            "12.5",
+			convError:        errors.New("incompatible schema conversion from decimal to string"),
+		},
 	}
 	for _, ip := range inputs {
 		tc := ip
@@ -976,3 +1123,62 @@ func TestHandleSchemaChange(t *testing.T) {
 		})
 	}
 }
+
+func TestOverflowProperties(t *testing.T) {
+	event := &types.BatchRouterEvent{
+		Metadata: types.Metadata{
+			Table: "tracks",
+			Columns: map[string]string{
+				"id":    "string",
+				"alpha": "int",
+				"beta":  "string",
+				"gamma": "string",
+			},
+		},
+		Data: map[string]interface{}{
+			"id":    "1",
+			"alpha": float64(1),
+			"beta":  "value",
+			"gamma": nil,
+		},
+	}
+
+	overflow, err := overflowProperties(event, model.TableSchema{"id": "string", "alpha": "int"}, nil)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"beta":"value"}`, overflow)
+
+	overflow, err = overflowProperties(event, model.TableSchema{"id": "string", "alpha": "int", "beta": "string"}, nil)
+	require.NoError(t, err)
+	require.Empty(t, overflow)
+
+	overflow, err = overflowProperties(event, model.TableSchema{"id": "string", "alpha": "int"}, map[string]any{"id": "1"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"beta":"value","id":"1"}`, overflow, "oversized values are moved into the overflow column")
+}
+
+func TestTruncateValue(t *testing.T) {
+	require.Equal(t, "a value...[truncated]", truncateValue("a value longer than the limit", 21))
+	require.Equal(t, "a valu", truncateValue("a value longer than the limit", 6), "no room for the marker")
+	require.Equal(t, "a ...[truncated]", truncateValue("a €uro value longer than the limit", 18), "characters are not split")
+}
+
+func TestUTCTimestamp(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    any
+		expected any
+	}{
+		{name: "utc", value: "2020-01-05T10:00:00.000Z", expected: "2020-01-05T10:00:00.000Z"},
+		{name: "zero offset", value: "2020-01-05T10:00:00.000+00:00", expected: "2020-01-05T10:00:00.000+00:00"},
+		{name: "positive offset", value: "2020-01-05T10:00:00.123+05:30", expected: "2020-01-05T04:30:00.123Z"},
+		{name: "negative offset", value: "2020-01-05T22:00:00-03:00", expected: "2020-01-06T01:00:00Z"},
+		{name: "not a timestamp", value: "tomorrow", expected: "tomorrow"},
+		{name: "not a string", value: 1, expected: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, utcTimestamp(tc.value))
+		})
+	}
+}
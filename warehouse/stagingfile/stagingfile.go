@@ -0,0 +1,117 @@
+// Package stagingfile defines the formats of the warehouse staging files, i.e. the gzipped JSON lines of the batch
+// router events the load files get generated from:
+//   - V1 holds the events only.
+//   - V2 ends with a footer embedding the schema of the events, their row counts by table and the min/max times of
+//     their receipt, so that the warehouse master can consolidate the schemas and compute the stats of the uploads
+//     without reading the contents of the files from object storage.
+//
+// The footer is a JSON line keyed by FooterKey, so that it can't be mistaken for an event. Readers of the events skip
+// it through IsFooter.
+package stagingfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// the versions of the staging file format
+const (
+	V1 = 1
+	V2 = 2
+)
+
+// FooterKey is the key of the footer line of V2 staging files
+const FooterKey = "__rudderStagingFileFooter"
+
+var footerPrefix = []byte(`{"` + FooterKey + `":`)
+
+// ErrNoFooter is returned when parsing a line which isn't a footer, e.g. the last line of a V1 staging file
+var ErrNoFooter = errors.New("no staging file footer")
+
+// Footer summarizes the events of a V2 staging file. The zero value is the footer of a staging file without events.
+type Footer struct {
+	Version int `json:"version"`
+	// Schema are the columns of the events by table, along with their types
+	Schema       map[string]map[string]string `json:"schema"`
+	RowsPerTable map[string]int               `json:"rowsPerTable"`
+	TotalRows    int                          `json:"totalRows"`
+	// MinEventAt and MaxEventAt are the min and max receivedAt of the events, in UTC
+	MinEventAt time.Time `json:"minEventAt"`
+	MaxEventAt time.Time `json:"maxEventAt"`
+}
+
+// Add accounts for the batch router event in the footer, ignoring events without a table
+func (f *Footer) Add(event []byte) {
+	metadata := gjson.GetBytes(event, "metadata")
+	table := metadata.Get("table").String()
+	if table == "" {
+		return
+	}
+	if f.Schema == nil {
+		f.Schema = make(map[string]map[string]string)
+		f.RowsPerTable = make(map[string]int)
+	}
+	f.TotalRows++
+	f.RowsPerTable[table]++
+
+	columns, ok := f.Schema[table]
+	if !ok {
+		columns = make(map[string]string)
+		f.Schema[table] = columns
+	}
+	metadata.Get("columns").ForEach(func(column, columnType gjson.Result) bool {
+		// text takes precedence over string, so that string columns get altered to text
+		if existing, ok := columns[column.String()]; !ok || (columnType.String() == "text" && existing == "string") {
+			columns[column.String()] = columnType.String()
+		}
+		return true
+	})
+
+	receivedAt, err := time.Parse(misc.RFC3339Milli, metadata.Get("receivedAt").String())
+	if err != nil {
+		return
+	}
+	receivedAt = receivedAt.UTC()
+	if f.MinEventAt.IsZero() || receivedAt.Before(f.MinEventAt) {
+		f.MinEventAt = receivedAt
+	}
+	if receivedAt.After(f.MaxEventAt) {
+		f.MaxEventAt = receivedAt
+	}
+}
+
+// Line returns the footer line ending a V2 staging file
+func (f *Footer) Line() (string, error) {
+	f.Version = V2
+	line, err := json.Marshal(map[string]*Footer{FooterKey: f})
+	if err != nil {
+		return "", fmt.Errorf("marshalling staging file footer: %w", err)
+	}
+	return string(line) + "\n", nil
+}
+
+// IsFooter returns whether the line of a staging file is its footer rather than an event
+func IsFooter(line []byte) bool {
+	return bytes.HasPrefix(line, footerPrefix)
+}
+
+// ParseFooter parses the footer line of a V2 staging file, returning ErrNoFooter if the line isn't a footer
+func ParseFooter(line []byte) (Footer, error) {
+	if !IsFooter(line) {
+		return Footer{}, ErrNoFooter
+	}
+	var footer map[string]Footer
+	if err := json.Unmarshal(line, &footer); err != nil {
+		return Footer{}, fmt.Errorf("unmarshalling staging file footer: %w", err)
+	}
+	return footer[FooterKey], nil
+}
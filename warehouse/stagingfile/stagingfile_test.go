@@ -0,0 +1,51 @@
+package stagingfile_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/warehouse/stagingfile"
+)
+
+func TestFooter(t *testing.T) {
+	events := []string{
+		`{"data":{"id":"1"},"metadata":{"table":"tracks","columns":{"id":"string","event":"string"},"receivedAt":"2024-10-01T10:00:02.000Z"}}`,
+		`{"data":{"id":"2"},"metadata":{"table":"tracks","columns":{"id":"string","event":"text"},"receivedAt":"2024-10-01T10:00:01.000Z"}}`,
+		`{"data":{"id":"3"},"metadata":{"table":"pages","columns":{"id":"string"},"receivedAt":"2024-10-01T12:00:03.000+02:00"}}`,
+		`{"data":{"id":"4"},"metadata":{"columns":{"id":"string"}}}`,
+	}
+
+	var footer stagingfile.Footer
+	for _, event := range events {
+		footer.Add([]byte(event))
+	}
+	require.Equal(t, stagingfile.Footer{
+		Schema: map[string]map[string]string{
+			"tracks": {"id": "string", "event": "text"},
+			"pages":  {"id": "string"},
+		},
+		RowsPerTable: map[string]int{"tracks": 2, "pages": 1},
+		TotalRows:    3,
+		MinEventAt:   time.Date(2024, 10, 1, 10, 0, 1, 0, time.UTC),
+		MaxEventAt:   time.Date(2024, 10, 1, 10, 0, 3, 0, time.UTC),
+	}, footer, "events without a table should be ignored")
+
+	line, err := footer.Line()
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(line, "\n"))
+	require.True(t, stagingfile.IsFooter([]byte(line)))
+
+	parsed, err := stagingfile.ParseFooter([]byte(strings.TrimSuffix(line, "\n")))
+	require.NoError(t, err)
+	footer.Version = stagingfile.V2
+	require.Equal(t, footer, parsed)
+
+	for _, event := range events {
+		require.False(t, stagingfile.IsFooter([]byte(event)))
+	}
+	_, err = stagingfile.ParseFooter([]byte(events[0]))
+	require.ErrorIs(t, err, stagingfile.ErrNoFooter)
+}
@@ -24,4 +24,24 @@ func TestContext(t *testing.T) {
 			require.Equal(t, int64(0), uploadID)
 		})
 	})
+	t.Run("QueryTag", func(t *testing.T) {
+		t.Run("should return query tag from context", func(t *testing.T) {
+			tag := QueryTag{UploadID: 1, DestinationID: "destination-1", Version: "1.2.3"}
+			queryTag, ok := QueryTagFromCtx(CtxWithQueryTag(context.Background(), tag))
+			require.True(t, ok)
+			require.Equal(t, tag, queryTag)
+			require.JSONEq(t, `{"app":"rudderstack","upload_id":1,"destination_id":"destination-1","version":"1.2.3"}`, queryTag.String())
+			require.Equal(t, `/* {"app":"rudderstack","upload_id":1,"destination_id":"destination-1","version":"1.2.3"} */ `, queryTag.Comment())
+		})
+
+		t.Run("should not close the comment early", func(t *testing.T) {
+			tag := QueryTag{DestinationID: "*/ DROP TABLE tracks; /*"}
+			require.Equal(t, `/* {"app":"rudderstack","destination_id":"\u002a/ DROP TABLE tracks; /\u002a"} */ `, tag.Comment())
+		})
+
+		t.Run("should return false if query tag is not present in context", func(t *testing.T) {
+			_, ok := QueryTagFromCtx(context.Background())
+			require.False(t, ok)
+		})
+	})
 }
@@ -0,0 +1,55 @@
+package warehouseutils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+)
+
+// pluginEndpoints are the urls of the plugins implementing warehouses out of tree, by destination type, see the
+// warehouse/integrations/plugin package
+var pluginEndpoints = map[string]string{}
+
+// registerPlugins registers the warehouses implemented by plugins as warehouse destinations. Plugins are configured in
+// Warehouse.plugins.endpoints, as a list of "<destination type>=<url>" entries, e.g. "NETEZZA=http://localhost:9093".
+func registerPlugins(conf *config.Config) error {
+	endpoints := make(map[string]string)
+	for _, entry := range conf.GetStringSlice("Warehouse.plugins.endpoints", nil) {
+		destType, url, ok := strings.Cut(entry, "=")
+		destType, url = strings.ToUpper(strings.TrimSpace(destType)), strings.TrimSpace(url)
+		if !ok || destType == "" || url == "" {
+			return fmt.Errorf("invalid warehouse plugin endpoint %q, expected <destination type>=<url>", entry)
+		}
+		if _, ok := WHDestNameMap[destType]; ok && pluginEndpoints[destType] == "" {
+			return fmt.Errorf("warehouse plugin endpoint for %s, which is already a warehouse", destType)
+		}
+		endpoints[destType] = strings.TrimSuffix(url, "/")
+	}
+	for destType, url := range endpoints {
+		pluginEndpoints[destType] = url
+		if !slices.Contains(WarehouseDestinations, destType) {
+			WarehouseDestinations = append(WarehouseDestinations, destType)
+		}
+		WarehouseDestinationMap[destType] = struct{}{}
+		WHDestNameMap[destType] = strings.ToLower(destType)
+	}
+	return nil
+}
+
+// PluginEndpoint returns the url of the plugin implementing the warehouse of the destination type, if any
+func PluginEndpoint(destType string) (string, bool) {
+	url, ok := pluginEndpoints[destType]
+	return url, ok
+}
+
+// PluginWarehouses returns the destination types of the warehouses implemented by plugins
+func PluginWarehouses() []string {
+	destTypes := make([]string, 0, len(pluginEndpoints))
+	for destType := range pluginEndpoints {
+		destTypes = append(destTypes, destType)
+	}
+	slices.Sort(destTypes)
+	return destTypes
+}
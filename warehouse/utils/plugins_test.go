@@ -0,0 +1,56 @@
+package warehouseutils
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+)
+
+func TestRegisterPlugins(t *testing.T) {
+	restore := func(t *testing.T) {
+		endpoints, destinations := maps.Clone(pluginEndpoints), slices.Clone(WarehouseDestinations)
+		destinationMap, nameMap := maps.Clone(WarehouseDestinationMap), maps.Clone(WHDestNameMap)
+		t.Cleanup(func() {
+			pluginEndpoints, WarehouseDestinations = endpoints, destinations
+			WarehouseDestinationMap, WHDestNameMap = destinationMap, nameMap
+		})
+	}
+
+	t.Run("registers the warehouses of the plugins", func(t *testing.T) {
+		restore(t)
+		c := config.New()
+		c.Set("Warehouse.plugins.endpoints", []string{"NETEZZA=http://localhost:9093/", " teradata = http://localhost:9094"})
+		require.NoError(t, registerPlugins(c))
+
+		url, ok := PluginEndpoint("NETEZZA")
+		require.True(t, ok)
+		require.Equal(t, "http://localhost:9093", url)
+		url, ok = PluginEndpoint("TERADATA")
+		require.True(t, ok)
+		require.Equal(t, "http://localhost:9094", url)
+		_, ok = PluginEndpoint(POSTGRES)
+		require.False(t, ok)
+
+		require.Equal(t, []string{"NETEZZA", "TERADATA"}, PluginWarehouses())
+		require.Contains(t, WarehouseDestinations, "NETEZZA")
+		require.Contains(t, WarehouseDestinationMap, "TERADATA")
+		require.Equal(t, "netezza", WHDestNameMap["NETEZZA"])
+
+		require.NoError(t, registerPlugins(c), "registering again is a no-op")
+		require.Len(t, slices.Compact(slices.Sorted(slices.Values(WarehouseDestinations))), len(WarehouseDestinations))
+	})
+
+	t.Run("invalid endpoints", func(t *testing.T) {
+		restore(t)
+		for _, entry := range []string{"NETEZZA", "NETEZZA=", "=http://localhost:9093", "POSTGRES=http://localhost:9093"} {
+			c := config.New()
+			c.Set("Warehouse.plugins.endpoints", []string{entry})
+			require.Error(t, registerPlugins(c), entry)
+		}
+		require.Empty(t, PluginWarehouses())
+	})
+}
@@ -0,0 +1,42 @@
+package warehouseutils
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+type queryTagContextKey struct{}
+
+// QueryTag attributes the queries issued to the warehouses to the upload and the destination they are issued for, so
+// that warehouse admins can find our sessions and the cost incurred by them
+type QueryTag struct {
+	App           string `json:"app"`
+	UploadID      int64  `json:"upload_id,omitempty"`
+	DestinationID string `json:"destination_id,omitempty"`
+	Version       string `json:"version,omitempty"`
+}
+
+// String returns the JSON representation of the tag
+func (t QueryTag) String() string {
+	if t.App == "" {
+		t.App = "rudderstack"
+	}
+	tag, _ := json.Marshal(t)
+	return string(tag)
+}
+
+// Comment returns the tag as a SQL comment to be prepended to the queries. Asterisks within the tag are escaped, so
+// that it can't open or close comments, keeping the tag valid JSON.
+func (t QueryTag) Comment() string {
+	return "/* " + strings.ReplaceAll(t.String(), "*", `\u002a`) + " */ "
+}
+
+func CtxWithQueryTag(ctx context.Context, tag QueryTag) context.Context {
+	return context.WithValue(ctx, queryTagContextKey{}, tag)
+}
+
+func QueryTagFromCtx(ctx context.Context) (QueryTag, bool) {
+	tag, ok := ctx.Value(queryTagContextKey{}).(QueryTag)
+	return tag, ok
+}
@@ -0,0 +1,64 @@
+package warehouseutils
+
+import (
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+)
+
+// EventsTable is the table all the events get loaded into with the unified table layout
+const EventsTable = "events"
+
+// TableLayout maps the tables of the track events in the staging files to the tables they get loaded into, as per the
+// table layout of the destination, see model.TableLayoutSetting. It is applied to the schemas of the staging files
+// during schema consolidation and to their rows during load file generation, so both of them agree on the tables.
+type TableLayout struct {
+	destType   string
+	layout     string
+	categories map[string]string // category table by event table
+}
+
+// NewTableLayout returns the table layout configured in the config of the destination
+func NewTableLayout(destType string, destConfig map[string]interface{}) *TableLayout {
+	l := &TableLayout{destType: destType, categories: make(map[string]string)}
+	l.layout, _ = destConfig[model.TableLayoutSetting.String()].(string)
+	if l.layout != model.TableLayoutCategory {
+		return l
+	}
+	// categories are configured as the event tables by category, e.g. {"orders": ["order_completed", "order_refunded"]}
+	categories, _ := destConfig[model.EventCategoriesSetting.String()].(map[string]interface{})
+	for category, eventTables := range categories {
+		eventTables, _ := eventTables.([]interface{})
+		for _, eventTable := range eventTables {
+			if eventTable, ok := eventTable.(string); ok {
+				l.categories[ToProviderCase(destType, eventTable)] = ToProviderCase(destType, category)
+			}
+		}
+	}
+	return l
+}
+
+// Table returns the table the rows of the table with the columns get loaded into. A nil TableLayout is the table per
+// event layout.
+func (l *TableLayout) Table(tableName string, columns map[string]string) string {
+	if l == nil || !l.isEventTable(tableName, columns) {
+		return tableName
+	}
+	switch l.layout {
+	case model.TableLayoutUnified:
+		return ToProviderCase(l.destType, EventsTable)
+	case model.TableLayoutCategory:
+		if category, ok := l.categories[tableName]; ok {
+			return category
+		}
+	}
+	return tableName
+}
+
+// isEventTable returns whether the table is the table of a track event, which unlike the tracks table and the tables
+// of the sources are the only ones with the event_text column
+func (l *TableLayout) isEventTable(tableName string, columns map[string]string) bool {
+	if tableName == ToProviderCase(l.destType, "tracks") {
+		return false
+	}
+	_, ok := columns[ToProviderCase(l.destType, "event_text")]
+	return ok
+}
@@ -0,0 +1,38 @@
+package warehouseutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableLayout(t *testing.T) {
+	eventColumns := map[string]string{"id": "string", "event": "string", "event_text": "string"}
+	categories := map[string]interface{}{
+		"orders": []interface{}{"order_completed", "order_refunded"},
+	}
+
+	testCases := []struct {
+		name       string
+		destType   string
+		destConfig map[string]interface{}
+		tableName  string
+		columns    map[string]string
+		expected   string
+	}{
+		{name: "per event", destType: POSTGRES, destConfig: map[string]interface{}{}, tableName: "order_completed", columns: eventColumns, expected: "order_completed"},
+		{name: "unified", destType: POSTGRES, destConfig: map[string]interface{}{"tableLayout": "unified"}, tableName: "order_completed", columns: eventColumns, expected: "events"},
+		{name: "unified tracks", destType: POSTGRES, destConfig: map[string]interface{}{"tableLayout": "unified"}, tableName: "tracks", columns: eventColumns, expected: "tracks"},
+		{name: "unified source table", destType: POSTGRES, destConfig: map[string]interface{}{"tableLayout": "unified"}, tableName: "charges", columns: map[string]string{"id": "string"}, expected: "charges"},
+		{name: "unified provider case", destType: SNOWFLAKE, destConfig: map[string]interface{}{"tableLayout": "unified"}, tableName: "ORDER_COMPLETED", columns: map[string]string{"ID": "string", "EVENT_TEXT": "string"}, expected: "EVENTS"},
+		{name: "category", destType: POSTGRES, destConfig: map[string]interface{}{"tableLayout": "category", "eventCategories": categories}, tableName: "order_refunded", columns: eventColumns, expected: "orders"},
+		{name: "without category", destType: POSTGRES, destConfig: map[string]interface{}{"tableLayout": "category", "eventCategories": categories}, tableName: "product_viewed", columns: eventColumns, expected: "product_viewed"},
+		{name: "category provider case", destType: SNOWFLAKE, destConfig: map[string]interface{}{"tableLayout": "category", "eventCategories": categories}, tableName: "ORDER_COMPLETED", columns: map[string]string{"ID": "string", "EVENT_TEXT": "string"}, expected: "ORDERS"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, NewTableLayout(tc.destType, tc.destConfig).Table(tc.tableName, tc.columns))
+		})
+	}
+}
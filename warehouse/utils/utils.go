@@ -22,6 +22,10 @@ import (
 	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
 
 	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/iancoleman/strcase"
@@ -35,6 +39,7 @@ import (
 
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/utils/awsutils"
+	"github.com/rudderlabs/rudder-server/utils/filemanagerutil"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 )
 
@@ -62,12 +67,17 @@ const (
 
 // warehouse table names
 const (
-	WarehouseStagingFilesTable = "wh_staging_files"
-	WarehouseLoadFilesTable    = "wh_load_files"
-	WarehouseUploadsTable      = "wh_uploads"
-	WarehouseTableUploadsTable = "wh_table_uploads"
-	WarehouseSchemasTable      = "wh_schemas"
-	WarehouseAsyncJobTable     = "wh_async_jobs"
+	WarehouseStagingFilesTable        = "wh_staging_files"
+	WarehouseLoadFilesTable           = "wh_load_files"
+	WarehouseLoadFileCheckpointsTable = "wh_load_file_checkpoints"
+	WarehouseReverseETLSnapshotsTable = "wh_reverse_etl_snapshots"
+	WarehouseUploadsTable             = "wh_uploads"
+	WarehouseTableUploadsTable        = "wh_table_uploads"
+	WarehouseTableUploadFailuresTable = "wh_table_upload_failures"
+	WarehouseEventSchemasTable        = "wh_event_schemas"
+	WarehouseSchemasTable             = "wh_schemas"
+	WarehouseAsyncJobTable            = "wh_async_jobs"
+	WarehouseRenamesTable             = "wh_renames"
 )
 
 const (
@@ -78,6 +88,20 @@ const (
 	ExcludeWindowEndTime    = "excludeWindowEndTime"
 )
 
+// UploadLeasesTable is the table of the namespace recording the leases of the uploads into it, preventing uploads of
+// different installations into the namespace from running at the same time
+const UploadLeasesTable = "rudder_upload_leases"
+
+// OverflowColumn holds the properties of the events beyond the column count limit of the warehouse, as a JSON object
+const OverflowColumn = "rudder_overflow"
+
+// Load audit columns are added to the tables of the events if enabled, identifying the load the rows came with and when
+// the load files got generated for it
+const (
+	LoadIDColumn        = "_rudder_load_id"
+	LoadAuditTimeColumn = "_loaded_at"
+)
+
 const (
 	UsersTable      = "users"
 	UsersView       = "users_view"
@@ -109,6 +133,7 @@ const (
 	GCS                = "GCS"
 	MINIO              = "MINIO"
 	DigitalOceanSpaces = "DIGITAL_OCEAN_SPACES"
+	CloudflareR2       = "CLOUDFLARE_R2"
 )
 
 // Cloud providers
@@ -126,6 +151,8 @@ var (
 	TimeWindowDestinations    = []string{S3Datalake, GCSDatalake, AzureDatalake}
 	WarehouseDestinations     = []string{RS, BQ, SNOWFLAKE, POSTGRES, CLICKHOUSE, MSSQL, AzureSynapse, S3Datalake, GCSDatalake, AzureDatalake, DELTALAKE}
 	IdentityEnabledWarehouses = []string{SNOWFLAKE, BQ}
+	StreamingWarehouses       = []string{BQ, CLICKHOUSE}          // warehouses events can be streamed into, see manager.Streamer
+	RenameWarehouses          = []string{POSTGRES, RS, SNOWFLAKE} // warehouses whose namespaces and tables can be renamed, see manager.Renamer
 	S3PathStyleRegex          = regexp.MustCompile(`https?://s3([.-](?P<region>[^.]+))?.amazonaws\.com/(?P<bucket>[^/]+)/(?P<keyname>.*)`)
 	S3VirtualHostedRegex      = regexp.MustCompile(`https?://(?P<bucket>[^/]+).s3([.-](?P<region>[^.]+))?.amazonaws\.com/(?P<keyname>.*)`)
 
@@ -172,6 +199,17 @@ var DiscardsSchema = map[string]string{
 	"reason":       "string",
 }
 
+var LoadAuditSchema = map[string]string{
+	LoadIDColumn:        "string",
+	LoadAuditTimeColumn: "datetime",
+}
+
+// LoadIDPattern is the LIKE pattern of the load ids of the rows loaded by the upload, whose load ids are made of the id
+// of the upload and of the generation of its load files
+func LoadIDPattern(uploadID int64) string {
+	return fmt.Sprintf("%d-%%", uploadID)
+}
+
 const (
 	LoadFileTypeCsv     = "csv"
 	LoadFileTypeJson    = "json"
@@ -181,6 +219,9 @@ const (
 func Init() {
 	loadConfig()
 	pkgLogger = logger.NewLogger().Child("warehouse").Child("utils")
+	if err := registerPlugins(config.Default); err != nil {
+		pkgLogger.Errorf("Warehouse plugins are not registered: %v", err)
+	}
 }
 
 func loadConfig() {
@@ -364,9 +405,12 @@ func GetObjectName(location string, providerConfig interface{}, objectProvider s
 	if destConfig, ok = providerConfig.(map[string]interface{}); !ok {
 		return "", errors.New("failed to cast destination config interface{} to map[string]interface{}")
 	}
-	fm, err := filemanager.New(&filemanager.Settings{
+	fm, err := filemanagerutil.FailoverFactory(filemanager.New)(&filemanager.Settings{
 		Provider: objectProvider,
-		Config:   destConfig,
+		Config: misc.GetObjectStorageConfig(misc.ObjectStorageOptsT{
+			Provider: objectProvider,
+			Config:   destConfig,
+		}),
 	})
 	if err != nil {
 		return "", err
@@ -573,6 +617,10 @@ func ObjectStorageType(destType string, config interface{}, useRudderStorage boo
 		return SnowflakeStorageMap[provider]
 	}
 	provider, _ := c["bucketProvider"].(string)
+	if provider == CloudflareR2 {
+		// R2 is S3 compatible, so it is accessed the same way as MinIO
+		return MINIO
+	}
 	return provider
 }
 
@@ -605,6 +653,25 @@ func IdentityMappingsUniqueMappingConstraintName(warehouse model.Warehouse) stri
 	return fmt.Sprintf(`unique_merge_property_%s_%s`, warehouse.Namespace, warehouse.Destination.ID)
 }
 
+// NamespaceFromTemplate returns the namespace of the source given by the namespace template of the destination, if set,
+// so that the sources of a destination can be loaded into different namespaces. {{namespace}}, {{sourceName}},
+// {{sourceId}} and {{sourceType}} in the template are replaced by the namespace configured for the destination and the
+// name, id and type of the source, e.g. {{namespace}}_{{sourceName}}.
+func NamespaceFromTemplate(destType string, destConfig map[string]interface{}, source backendconfig.SourceT) (string, bool) {
+	template, _ := destConfig[model.NamespaceTemplateSetting.String()].(string)
+	if strings.TrimSpace(template) == "" {
+		return "", false
+	}
+	namespace, _ := destConfig["namespace"].(string)
+	namespace = strings.NewReplacer(
+		"{{namespace}}", strings.TrimSpace(namespace),
+		"{{sourceName}}", source.Name,
+		"{{sourceId}}", source.ID,
+		"{{sourceType}}", source.SourceDefinition.Name,
+	).Replace(template)
+	return ToProviderCase(destType, ToSafeNamespace(destType, namespace)), true
+}
+
 func GetWarehouseIdentifier(destType, sourceID, destinationID string) string {
 	return fmt.Sprintf("%s:%s:%s", destType, sourceID, destinationID)
 }
@@ -659,31 +726,50 @@ func CreateAWSSessionConfig(destination *backendconfig.DestinationT, serviceName
 	}, nil
 }
 
+// GetTemporaryS3Cred returns temporary credentials for accessing the staging bucket of the destination, valid for
+// Warehouse.awsCredsExpiryInS so that they outlive long running loads using them (e.g. Redshift COPY commands).
+// Only S3 staging buckets are covered: GCS ones already use the GKE workload identity when no credentials are
+// configured, whereas Azure managed identities aren't supported by the file manager, requiring account keys or SAS tokens.
 func GetTemporaryS3Cred(destination *backendconfig.DestinationT) (string, string, string, error) {
 	sessionConfig, err := CreateAWSSessionConfig(destination, s3.ServiceID)
 	if err != nil {
 		return "", "", "", err
 	}
 
-	awsSession, err := awsutil.CreateSession(sessionConfig)
-	if err != nil {
-		return "", "", "", err
-	}
+	expiryInSec := awsCredsExpiryInS.Load()
 
 	// Role already provides temporary credentials
 	// so we shouldn't call sts.GetSessionToken again
 	if sessionConfig.RoleBasedAuth {
-		creds, err := awsSession.Config.Credentials.Get()
+		roleCreds, err := assumeRoleCredentials(sessionConfig, time.Duration(expiryInSec)*time.Second)
 		if err != nil {
 			return "", "", "", err
 		}
+		creds, err := roleCreds.Get()
+		if err != nil {
+			return "", "", "", err
+		}
+		return creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken, nil
+	}
+
+	awsSession, err := awsutil.CreateSession(sessionConfig)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	// Without static keys the credentials come from the environment (e.g. IRSA, ECS task or EC2 instance roles),
+	// which are temporary already and cannot be used for calling sts.GetSessionToken
+	creds, err := awsSession.Config.Credentials.Get()
+	if err != nil {
+		return "", "", "", err
+	}
+	if creds.SessionToken != "" {
 		return creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken, nil
 	}
 
 	// Create an STS client from just a session.
 	svc := sts.New(awsSession)
 
-	expiryInSec := awsCredsExpiryInS.Load()
 	sessionTokenOutput, err := svc.GetSessionToken(&sts.GetSessionTokenInput{DurationSeconds: &expiryInSec})
 	if err != nil {
 		return "", "", "", err
@@ -691,6 +777,25 @@ func GetTemporaryS3Cred(destination *backendconfig.DestinationT) (string, string
 	return *sessionTokenOutput.Credentials.AccessKeyId, *sessionTokenOutput.Credentials.SecretAccessKey, *sessionTokenOutput.Credentials.SessionToken, err
 }
 
+// assumeRoleCredentials returns the credentials of the role of the session config, lasting for the given duration
+// instead of the default 15 minutes of the sts assume role provider
+func assumeRoleCredentials(sessionConfig *awsutil.SessionConfig, duration time.Duration) (*credentials.Credentials, error) {
+	if sessionConfig.ExternalID == "" {
+		return nil, errors.New("externalID is required for IAM role")
+	}
+	hostSession, err := session.NewSession(&aws.Config{
+		Region: aws.String(sessionConfig.Region),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating host session: %w", err)
+	}
+	return stscreds.NewCredentials(hostSession, sessionConfig.IAMRoleARN, func(p *stscreds.AssumeRoleProvider) {
+		p.ExternalID = aws.String(sessionConfig.ExternalID)
+		p.RoleSessionName = "rudderstack-aws-s3-access"
+		p.Duration = duration
+	}), nil
+}
+
 type Tag struct {
 	Name  string
 	Value string
@@ -747,6 +852,12 @@ func (err *WriteSSLKeyError) GetErrTag() string {
 	return err.errorTag
 }
 
+// SSLKeysRequired returns true if the ssl mode verifies the server's certificate against the certificate authority
+// present in the destination config, thus requiring the destination's ssl key(s) to be written to the file system
+func SSLKeysRequired(sslMode interface{}) bool {
+	return sslMode == "verify-ca" || sslMode == "verify-full"
+}
+
 // WriteSSLKeys writes the ssl key(s) present in the destination config
 // to the file system, this function checks whether a given config is
 // already written to the file system, writes to the file system if the
@@ -758,15 +869,19 @@ func WriteSSLKeys(destination backendconfig.DestinationT) WriteSSLKeyError {
 	if directoryName, err = misc.CreateTMPDIR(); err != nil {
 		return WriteSSLKeyError{fmt.Sprintf("Error creating SSL root TMP directory for destination %v", err), "tmp_dir_failure"}
 	}
-	clientKeyConfig := destination.Config["clientKey"]
-	clientCertConfig := destination.Config["clientCert"]
-	serverCAConfig := destination.Config["serverCA"]
-	if clientKeyConfig == nil || clientCertConfig == nil || serverCAConfig == nil {
+	clientKeyConfig, _ := destination.Config["clientKey"].(string)
+	clientCertConfig, _ := destination.Config["clientCert"].(string)
+	serverCAConfig, _ := destination.Config["serverCA"].(string)
+	// the client certificate is optional, for servers which don't require clients to authenticate with one
+	if serverCAConfig == "" || (clientKeyConfig == "") != (clientCertConfig == "") {
 		return WriteSSLKeyError{fmt.Sprintf("Error extracting ssl information; invalid config passed for destination %s", destination.ID), "certs_nil_value"}
 	}
-	clientKey := FormatPemContent(clientKeyConfig.(string))
-	clientCert := FormatPemContent(clientCertConfig.(string))
-	serverCert := FormatPemContent(serverCAConfig.(string))
+	var clientKey, clientCert string
+	if clientCertConfig != "" {
+		clientKey = FormatPemContent(clientKeyConfig)
+		clientCert = FormatPemContent(clientCertConfig)
+	}
+	serverCert := FormatPemContent(serverCAConfig)
 	sslDirPath := fmt.Sprintf("%s/dest-ssls/%s", directoryName, destination.ID)
 	if err = os.MkdirAll(sslDirPath, 0o700); err != nil {
 		return WriteSSLKeyError{fmt.Sprintf("Error creating SSL root directory for destination %s %v", destination.ID, err), "dest_ssl_create_err"}
@@ -786,11 +901,19 @@ func WriteSSLKeys(destination backendconfig.DestinationT) WriteSSLKeyError {
 		// Permission files already written to FS
 		return WriteSSLKeyError{}
 	}
-	if err = os.WriteFile(clientCertPemFile, []byte(clientCert), 0o600); err != nil {
-		return WriteSSLKeyError{fmt.Sprintf("Error saving file %s error::%v", clientCertPemFile, err), "client_cert_create_err"}
-	}
-	if err = os.WriteFile(clientKeyPemFile, []byte(clientKey), 0o600); err != nil {
-		return WriteSSLKeyError{fmt.Sprintf("Error saving file %s error::%v", clientKeyPemFile, err), "client_key_create_err"}
+	if clientCert == "" {
+		for _, file := range []string{clientCertPemFile, clientKeyPemFile} {
+			if err = os.Remove(file); err != nil && !os.IsNotExist(err) {
+				return WriteSSLKeyError{fmt.Sprintf("Error removing file %s error::%v", file, err), "client_cert_remove_err"}
+			}
+		}
+	} else {
+		if err = os.WriteFile(clientCertPemFile, []byte(clientCert), 0o600); err != nil {
+			return WriteSSLKeyError{fmt.Sprintf("Error saving file %s error::%v", clientCertPemFile, err), "client_cert_create_err"}
+		}
+		if err = os.WriteFile(clientKeyPemFile, []byte(clientKey), 0o600); err != nil {
+			return WriteSSLKeyError{fmt.Sprintf("Error saving file %s error::%v", clientKeyPemFile, err), "client_key_create_err"}
+		}
 	}
 	if err = os.WriteFile(serverCertPemFile, []byte(serverCert), 0o600); err != nil {
 		return WriteSSLKeyError{fmt.Sprintf("Error saving file %s error::%v", serverCertPemFile, err), "server_cert_create_err"}
@@ -830,6 +953,15 @@ func GetLoadFileType(destType string) string {
 	}
 }
 
+// GetWarehouseLoadFileType returns the load file type of the warehouse, which is the one of its destination type unless
+// its settings need another one, e.g. the parquet load files of the Redshift Spectrum tables
+func GetWarehouseLoadFileType(warehouse model.Warehouse) string {
+	if warehouse.Type == RS && warehouse.GetBoolDestinationConfig(model.UseSpectrumSetting) {
+		return LoadFileTypeParquet
+	}
+	return GetLoadFileType(warehouse.Type)
+}
+
 func GetLoadFileFormat(loadFileType string) string {
 	switch loadFileType {
 	case LoadFileTypeJson:
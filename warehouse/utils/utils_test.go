@@ -805,6 +805,13 @@ func TestObjectStorageType(t *testing.T) {
 			},
 			storageType: "GCP",
 		},
+		{
+			destType: "POSTGRES",
+			config: map[string]interface{}{
+				"bucketProvider": "CLOUDFLARE_R2",
+			},
+			storageType: "MINIO",
+		},
 		{
 			destType: "POSTGRES",
 			config:   map[string]interface{}{},
@@ -1062,6 +1069,20 @@ func TestGetLoadFileType(t *testing.T) {
 	}
 }
 
+func TestGetWarehouseLoadFileType(t *testing.T) {
+	warehouse := func(destType string, config map[string]interface{}) model.Warehouse {
+		return model.Warehouse{
+			Type:        destType,
+			Destination: backendconfig.DestinationT{Config: config},
+		}
+	}
+
+	require.Equal(t, LoadFileTypeCsv, GetWarehouseLoadFileType(warehouse(RS, nil)))
+	require.Equal(t, LoadFileTypeParquet, GetWarehouseLoadFileType(warehouse(RS, map[string]interface{}{"useSpectrum": true})))
+	require.Equal(t, LoadFileTypeCsv, GetWarehouseLoadFileType(warehouse(POSTGRES, map[string]interface{}{"useSpectrum": true})))
+	require.Equal(t, LoadFileTypeJson, GetWarehouseLoadFileType(warehouse(BQ, nil)))
+}
+
 func TestGetTimeWindow(t *testing.T) {
 	inputs := []struct {
 		ts       time.Time
@@ -1078,6 +1099,45 @@ func TestGetTimeWindow(t *testing.T) {
 	}
 }
 
+func TestNamespaceFromTemplate(t *testing.T) {
+	source := backendconfig.SourceT{
+		ID:               "source-id",
+		Name:             "Mobile App",
+		SourceDefinition: backendconfig.SourceDefinitionT{Name: "Android"},
+	}
+	testCases := []struct {
+		name       string
+		destType   string
+		destConfig map[string]interface{}
+		expected   string
+		ok         bool
+	}{
+		{name: "no template", destType: POSTGRES, destConfig: map[string]interface{}{"namespace": "analytics"}},
+		{name: "blank template", destType: POSTGRES, destConfig: map[string]interface{}{"namespaceTemplate": "  "}},
+		{
+			name:       "namespace and source name",
+			destType:   POSTGRES,
+			destConfig: map[string]interface{}{"namespace": " analytics ", "namespaceTemplate": "{{namespace}}_{{sourceName}}"},
+			expected:   "analytics_mobile_app",
+			ok:         true,
+		},
+		{
+			name:       "source id and type",
+			destType:   SNOWFLAKE,
+			destConfig: map[string]interface{}{"namespaceTemplate": "raw_{{sourceType}}_{{sourceId}}"},
+			expected:   "RAW_ANDROID_SOURCE_ID",
+			ok:         true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			namespace, ok := NamespaceFromTemplate(tc.destType, tc.destConfig, source)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.expected, namespace)
+		})
+	}
+}
+
 func TestGetWarehouseIdentifier(t *testing.T) {
 	inputs := []struct {
 		destType      string
@@ -1312,6 +1372,37 @@ var _ = Describe("Utils", func() {
 
 		Expect(os.RemoveAll(path)).NotTo(HaveOccurred())
 	})
+
+	It("SSL keys without client certificate", func() {
+		destinationID := "destIDWithoutClientCert"
+		clientKey, clientCert, serverCA := misc.FastUUID().String(), misc.FastUUID().String(), misc.FastUUID().String()
+
+		err := WriteSSLKeys(backendconfig.DestinationT{ID: destinationID, Config: map[string]interface{}{"clientKey": clientKey, "clientCert": clientCert, "serverCA": serverCA}})
+		Expect(err).To(Equal(WriteSSLKeyError{}))
+		path := GetSSLKeyDirPath(destinationID)
+		Expect(path + "/client-cert.pem").To(BeAnExistingFile())
+
+		err = WriteSSLKeys(backendconfig.DestinationT{ID: destinationID, Config: map[string]interface{}{"serverCA": serverCA}})
+		Expect(err).To(Equal(WriteSSLKeyError{}))
+		Expect(path + "/server-ca.pem").To(BeAnExistingFile())
+		Expect(path + "/client-cert.pem").NotTo(BeAnExistingFile())
+		Expect(path + "/client-key.pem").NotTo(BeAnExistingFile())
+
+		err = WriteSSLKeys(backendconfig.DestinationT{ID: destinationID, Config: map[string]interface{}{"clientKey": clientKey, "serverCA": serverCA}})
+		Expect(err.GetErrTag()).To(Equal("certs_nil_value"))
+
+		Expect(os.RemoveAll(path)).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("SSL keys required", func(sslMode interface{}, expected bool) {
+		Expect(SSLKeysRequired(sslMode)).To(Equal(expected))
+	},
+		Entry(nil, "verify-ca", true),
+		Entry(nil, "verify-full", true),
+		Entry(nil, "require", false),
+		Entry(nil, "disable", false),
+		Entry(nil, nil, false),
+	)
 })
 
 func TestMain(m *testing.M) {
@@ -0,0 +1,92 @@
+package validations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/datalake"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/manager"
+	"github.com/rudderlabs/rudder-server/warehouse/integrations/plugin"
+	"github.com/rudderlabs/rudder-server/warehouse/internal/model"
+)
+
+// VerifyConnection tests the connection to the warehouse of the destination through its manager, in stages:
+//   - setup: the manager is set up with the config of the destination.
+//   - connect: the manager connects to the warehouse, which runs a query to validate the credentials.
+//   - permissions: the schema of the namespace is fetched, probing the permissions of the user on it.
+//
+// The test stops at the first failing stage, the error of which is categorised by the error mappings of the
+// warehouse, or by the stage otherwise.
+func VerifyConnection(ctx context.Context, dest *backendconfig.DestinationT) *model.ConnectionTestResponse {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	failed := func(m manager.Manager, stage string, err error) *model.ConnectionTestResponse {
+		return &model.ConnectionTestResponse{
+			Stage:     stage,
+			ErrorType: connectionErrorType(m, stage, err),
+			Error:     err.Error(),
+		}
+	}
+
+	operations, err := createManager(ctx, dest)
+	if err != nil {
+		return failed(nil, model.ConnectionTestSetup, err)
+	}
+	defer operations.Cleanup(ctx)
+
+	if err := connect(ctx, operations, createDummyWarehouse(dest)); err != nil {
+		return failed(operations, model.ConnectionTestConnect, err)
+	}
+	if _, _, err := operations.FetchSchema(ctx); err != nil {
+		return failed(operations, model.ConnectionTestPermissions, fmt.Errorf("fetch schema: %w", err))
+	}
+	return &model.ConnectionTestResponse{Success: true}
+}
+
+// connect connects to the warehouse through the client of the manager, testing the connection of the warehouses
+// without a client, i.e. the datalakes and the plugins, through the manager instead
+func connect(ctx context.Context, m manager.Manager, warehouse model.Warehouse) error {
+	switch m.(type) {
+	case *datalake.Datalake, *plugin.Plugin:
+		if err := m.TestConnection(ctx, warehouse); err != nil {
+			return fmt.Errorf("test connection: %w", err)
+		}
+		return nil
+	}
+
+	cl, err := m.Connect(ctx, warehouse)
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer cl.Close()
+
+	if _, err := cl.Query("SELECT 1"); err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+	return nil
+}
+
+// connectionErrorType returns the type of the error of the stage, matching the error mappings of the manager if any
+func connectionErrorType(m manager.Manager, stage string, err error) model.JobErrorType {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return model.ConnectionTimeoutError
+	}
+	if m != nil {
+		for _, mapping := range m.ErrorMappings() {
+			if mapping.Format.MatchString(err.Error()) {
+				return mapping.Type
+			}
+		}
+	}
+	switch stage {
+	case model.ConnectionTestSetup:
+		return model.InvalidConfigurationError
+	case model.ConnectionTestPermissions:
+		return model.PermissionError
+	default:
+		return model.ConnectionError
+	}
+}